@@ -0,0 +1,436 @@
+#!/usr/bin/env python3
+"""
+API server for remote benchmark orchestration.
+
+Exposes a REST API to start, stop and list virtbench runs on this host
+and to stream their progress and results, so a central service can drive
+benchmarks on lab clusters without logging in to a jump host. A run is
+requested as a workload plus option mappings (see utils/launch.py):
+
+    curl -H "Authorization: Bearer $TOKEN" -X POST http://jumphost:8090/api/runs \\
+        -d '{"workload": "vm-clone", "args": {"start": 1, "end": 20, "save-results": true}}'
+
+Endpoints:
+  GET  /healthz                      liveness probe (no token needed)
+  GET  /api/runs                     run list, newest first
+  POST /api/runs                     start a run: {"workload", "args", "options"}
+  GET  /api/runs/<id>                one run
+  POST /api/runs/<id>/stop           stop a queued or running run
+  GET  /api/runs/<id>/log            run log; ?follow=true streams it until the run ends
+  GET  /api/runs/<id>/events         server-sent events: log lines and state changes
+  GET  /api/runs/<id>/results        run summaries (summary_*.json) written by the run
+
+Runs are executed with the virtbench CLI from the repository root, at most
+--max-concurrent at a time; later requests are queued. Run records and logs
+are kept under <results-dir>/server/, so the run list survives a restart
+(runs that were in progress are then reported as interrupted).
+
+Only the standard library is used for the server itself.
+
+Usage:
+  python3 virtbench-server.py [--host HOST] [--port PORT] [--token TOKEN] [--max-concurrent N]
+"""
+
+import argparse
+import hmac
+import json
+import os
+import signal
+import subprocess
+import sys
+import threading
+import time
+import uuid
+from datetime import datetime, timezone
+from http.server import BaseHTTPRequestHandler, ThreadingHTTPServer
+from pathlib import Path
+from typing import Dict, List, Optional
+from urllib.parse import parse_qs, unquote, urlparse
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
+
+from utils.common import setup_logging
+from virtbench.utils.launch import virtbench_command, results_since
+
+TOKEN_ENV = 'VIRTBENCH_SERVE_TOKEN'
+DEFAULT_PORT = 8090
+DEFAULT_MAX_CONCURRENT = 1
+STOP_GRACE_SEC = 60
+FOLLOW_POLL_SEC = 0.5
+MAX_REQUEST_BYTES = 64 * 1024
+
+# Run states
+QUEUED = 'queued'
+RUNNING = 'running'
+SUCCEEDED = 'succeeded'
+FAILED = 'failed'
+STOPPED = 'stopped'
+INTERRUPTED = 'interrupted'
+FINAL_STATES = (SUCCEEDED, FAILED, STOPPED, INTERRUPTED)
+
+
+def now() -> str:
+    return datetime.now(timezone.utc).strftime('%Y-%m-%dT%H:%M:%SZ')
+
+
+class RunManager:
+    """Queue, processes and records of the runs started through the API."""
+
+    def __init__(self, repo_root: Path, results_dir: Path, max_concurrent: int, logger):
+        self.repo_root = repo_root
+        self.results_dir = results_dir
+        self.state_dir = results_dir / 'server'
+        self.max_concurrent = max_concurrent
+        self.logger = logger
+        self.runs: Dict[str, Dict] = {}
+        self.processes: Dict[str, subprocess.Popen] = {}
+        self.lock = threading.RLock()
+        self.shutting_down = False
+        self.changed = threading.Condition(self.lock)
+        self.state_dir.mkdir(parents=True, exist_ok=True)
+        self._load()
+
+    def _load(self):
+        """Reload the records of earlier server instances."""
+        for path in self.state_dir.glob('*.json'):
+            try:
+                run = json.loads(path.read_text())
+            except (OSError, ValueError):
+                continue
+            if run.get('state') not in FINAL_STATES:
+                run.update(state=INTERRUPTED, finished=now())
+                path.write_text(json.dumps(run, indent=2))
+            self.runs[run['id']] = run
+
+    def _save(self, run: Dict):
+        (self.state_dir / f"{run['id']}.json").write_text(json.dumps(run, indent=2))
+        self.changed.notify_all()
+
+    def log_path(self, run_id: str) -> Path:
+        return self.state_dir / f"{run_id}.log"
+
+    def list(self) -> List[Dict]:
+        with self.lock:
+            return sorted((dict(r) for r in self.runs.values()), key=lambda r: r['created'], reverse=True)
+
+    def get(self, run_id: str) -> Optional[Dict]:
+        with self.lock:
+            run = self.runs.get(run_id)
+            return dict(run) if run else None
+
+    def submit(self, request: Dict) -> Dict:
+        """
+        Queue a run and start it when a slot is free.
+
+        Raises:
+            ValueError: For an invalid request
+        """
+        if not isinstance(request, dict):
+            raise ValueError("request body must be a JSON object")
+        run_id = str(uuid.uuid4())
+        cmd = virtbench_command(request.get('workload'), request.get('args'), request.get('options'), run_id)
+        run = {
+            'id': run_id,
+            'workload': request['workload'].strip(),
+            'args': request.get('args') or {},
+            'options': request.get('options') or {},
+            'command': cmd,
+            'state': QUEUED,
+            'created': now(),
+            'started': None,
+            'finished': None,
+            'exit_code': None,
+        }
+        with self.lock:
+            self.runs[run_id] = run
+            self._save(run)
+            self.logger.info(f"[{run_id}] Queued: {' '.join(cmd)}")
+            self._start_queued()
+            return dict(run)
+
+    def stop(self, run_id: str) -> Optional[Dict]:
+        """Stop a queued run, or interrupt a running one (killed after STOP_GRACE_SEC)."""
+        with self.lock:
+            run = self.runs.get(run_id)
+            if run is None:
+                return None
+            if run['state'] == QUEUED:
+                run.update(state=STOPPED, finished=now())
+                self._save(run)
+            elif run['state'] == RUNNING:
+                run['stop_requested'] = True
+                process = self.processes[run_id]
+                self.logger.info(f"[{run_id}] Stopping")
+                process.send_signal(signal.SIGINT)
+                threading.Thread(target=self._kill_after_grace, args=(process,), daemon=True).start()
+            return dict(run)
+
+    @staticmethod
+    def _kill_after_grace(process: subprocess.Popen):
+        try:
+            process.wait(timeout=STOP_GRACE_SEC)
+        except subprocess.TimeoutExpired:
+            process.kill()
+
+    def _start_queued(self):
+        """Start queued runs, oldest first, while slots are free. Called with the lock held."""
+        queued = sorted((r for r in self.runs.values() if r['state'] == QUEUED), key=lambda r: r['created'])
+        for run in queued:
+            if len(self.processes) >= self.max_concurrent:
+                return
+            log_file = open(self.log_path(run['id']), 'a')
+            env = dict(os.environ, VIRTBENCH_REPO=str(self.repo_root))
+            try:
+                process = subprocess.Popen(run['command'], cwd=self.repo_root, env=env,
+                                           stdout=log_file, stderr=subprocess.STDOUT)
+            except OSError as e:
+                log_file.write(f"Cannot start run: {e}\n")
+                log_file.close()
+                run.update(state=FAILED, finished=now())
+                self._save(run)
+                continue
+            run.update(state=RUNNING, started=now(), started_epoch=time.time())
+            self.processes[run['id']] = process
+            self._save(run)
+            self.logger.info(f"[{run['id']}] Started (pid {process.pid})")
+            threading.Thread(target=self._wait, args=(run, process, log_file), daemon=True).start()
+
+    def _wait(self, run: Dict, process: subprocess.Popen, log_file):
+        returncode = process.wait()
+        log_file.close()
+        results = [str(p.relative_to(self.results_dir))
+                   for p in results_since(self.results_dir, run['started_epoch'], exclude='server')]
+        with self.lock:
+            if run.get('stop_requested'):
+                state = STOPPED
+            elif self.shutting_down:
+                state = INTERRUPTED
+            else:
+                state = SUCCEEDED if returncode == 0 else FAILED
+            run.update(state=state, finished=now(), exit_code=returncode, results=results)
+            self.processes.pop(run['id'], None)
+            self._save(run)
+            self.logger.info(f"[{run['id']}] {state} (exit code {returncode})")
+            self._start_queued()
+
+    def wait_for_change(self, timeout: float):
+        with self.changed:
+            self.changed.wait(timeout)
+
+    def shutdown(self):
+        """Interrupt the running runs; they are reported as interrupted."""
+        with self.lock:
+            self.shutting_down = True
+            processes = list(self.processes.items())
+        for run_id, process in processes:
+            self.logger.info(f"[{run_id}] Interrupting (server shutdown)")
+            process.send_signal(signal.SIGINT)
+        for _, process in processes:
+            try:
+                process.wait(timeout=STOP_GRACE_SEC)
+            except subprocess.TimeoutExpired:
+                process.kill()
+
+
+class ApiHandler(BaseHTTPRequestHandler):
+    manager: RunManager = None
+    token: Optional[str] = None
+    logger = None
+
+    def log_message(self, fmt, *args):
+        self.logger.debug(f"{self.client_address[0]} {fmt % args}")
+
+    def _send(self, status: int, body: bytes, content_type: str):
+        self.send_response(status)
+        self.send_header("Content-Type", content_type)
+        self.send_header("Content-Length", str(len(body)))
+        self.end_headers()
+        self.wfile.write(body)
+
+    def _send_json(self, data, status: int = 200):
+        self._send(status, json.dumps(data, indent=2).encode(), "application/json")
+
+    def _authorized(self) -> bool:
+        if not self.token:
+            return True
+        header = self.headers.get('Authorization', '')
+        if header.startswith('Bearer ') and hmac.compare_digest(header[len('Bearer '):], self.token):
+            return True
+        self._send_json({"error": "unauthorized"}, 401)
+        return False
+
+    def _route(self):
+        """Split /api/runs/<id>/<action> into (id, action)."""
+        parts = unquote(urlparse(self.path).path).strip('/').split('/')
+        if parts[:2] != ['api', 'runs']:
+            return None, None, False
+        return (parts[2] if len(parts) > 2 else None), (parts[3] if len(parts) > 3 else None), len(parts) <= 4
+
+    def do_GET(self):
+        url = urlparse(self.path)
+        if url.path == '/healthz':
+            self._send(200, b"ok", "text/plain")
+            return
+        if not self._authorized():
+            return
+        run_id, action, known = self._route()
+        if not known:
+            self._send_json({"error": "not found"}, 404)
+        elif run_id is None:
+            self._send_json(self.manager.list())
+        elif self.manager.get(run_id) is None:
+            self._send_json({"error": "run not found"}, 404)
+        elif action is None:
+            self._send_json(self.manager.get(run_id))
+        elif action == 'log':
+            follow = parse_qs(url.query).get('follow', ['false'])[0].lower() in ('1', 'true', 'yes')
+            self._stream_log(run_id, follow)
+        elif action == 'events':
+            self._stream_events(run_id)
+        elif action == 'results':
+            self._send_json(self._results(run_id))
+        else:
+            self._send_json({"error": "not found"}, 404)
+
+    def do_POST(self):
+        if not self._authorized():
+            return
+        run_id, action, known = self._route()
+        if not known:
+            self._send_json({"error": "not found"}, 404)
+        elif run_id is None:
+            length = int(self.headers.get('Content-Length') or 0)
+            if length > MAX_REQUEST_BYTES:
+                self._send_json({"error": "request too large"}, 413)
+                return
+            try:
+                run = self.manager.submit(json.loads(self.rfile.read(length) or b'{}'))
+            except ValueError as e:
+                self._send_json({"error": str(e)}, 400)
+                return
+            self._send_json(run, 201)
+        elif action == 'stop':
+            run = self.manager.stop(run_id)
+            if run is None:
+                self._send_json({"error": "run not found"}, 404)
+            else:
+                self._send_json(run, 202)
+        else:
+            self._send_json({"error": "not found"}, 404)
+
+    def _results(self, run_id: str) -> Dict:
+        run = self.manager.get(run_id)
+        summaries = {}
+        for name in run.get('results') or []:
+            try:
+                summaries[name] = json.loads((self.manager.results_dir / name).read_text())
+            except (OSError, ValueError):
+                continue
+        return {"id": run_id, "state": run['state'], "summaries": summaries}
+
+    def _follow(self, run_id: str, follow: bool):
+        """Yield (log text, run) as the log grows; ends when the run is final (or at once without follow)."""
+        path = self.manager.log_path(run_id)
+        position = 0
+        while True:
+            run = self.manager.get(run_id)
+            text = ''
+            if path.exists():
+                with open(path, errors='replace') as f:
+                    f.seek(position)
+                    text = f.read()
+                    position = f.tell()
+            yield text, run
+            if not follow or run['state'] in FINAL_STATES:
+                return
+            self.manager.wait_for_change(FOLLOW_POLL_SEC)
+
+    def _stream_log(self, run_id: str, follow: bool):
+        # HTTP/1.0 without Content-Length: the body ends when the connection closes
+        self.send_response(200)
+        self.send_header("Content-Type", "text/plain; charset=utf-8")
+        self.end_headers()
+        try:
+            for text, _ in self._follow(run_id, follow):
+                if text:
+                    self.wfile.write(text.encode())
+                    self.wfile.flush()
+        except (BrokenPipeError, ConnectionResetError):
+            pass
+
+    def _stream_events(self, run_id: str):
+        self.send_response(200)
+        self.send_header("Content-Type", "text/event-stream")
+        self.send_header("Cache-Control", "no-cache")
+        self.end_headers()
+        state = None
+        partial = ''
+        try:
+            for text, run in self._follow(run_id, True):
+                lines = (partial + text).split('\n')
+                partial = lines.pop()
+                for line in lines:
+                    self.wfile.write(f"event: log\ndata: {json.dumps(line)}\n\n".encode())
+                if run['state'] != state:
+                    state = run['state']
+                    self.wfile.write(f"event: state\ndata: {json.dumps(run)}\n\n".encode())
+                self.wfile.flush()
+        except (BrokenPipeError, ConnectionResetError):
+            pass
+
+
+def parse_args():
+    parser = argparse.ArgumentParser(description='Serve a REST API to run virtbench benchmarks remotely')
+    parser.add_argument('--host', default='127.0.0.1', help='Address to listen on (default: 127.0.0.1)')
+    parser.add_argument('--port', type=int, default=DEFAULT_PORT, help=f'Port (default: {DEFAULT_PORT})')
+    parser.add_argument('--token', default=os.environ.get(TOKEN_ENV),
+                        help=f'Bearer token required on API requests (default: ${TOKEN_ENV})')
+    parser.add_argument('--max-concurrent', type=int, default=DEFAULT_MAX_CONCURRENT,
+                        help=f'Runs executed at the same time (default: {DEFAULT_MAX_CONCURRENT})')
+    parser.add_argument('--results-dir', default=None,
+                        help='Results directory of the runs (default: <repo>/results)')
+    parser.add_argument('--log-file', default=None, help='Log file path')
+    parser.add_argument('--log-level', default='INFO',
+                        choices=['DEBUG', 'INFO', 'WARNING', 'ERROR'], help='Logging level (default: INFO)')
+    args = parser.parse_args()
+    if args.max_concurrent < 1:
+        parser.error("--max-concurrent must be >= 1")
+    return args
+
+
+def main():
+    args = parse_args()
+    logger = setup_logging(args.log_file, args.log_level)
+
+    repo_root = Path(__file__).resolve().parent.parent
+    results_dir = Path(args.results_dir).resolve() if args.results_dir else repo_root / 'results'
+    if not args.token and args.host not in ('127.0.0.1', 'localhost', '::1'):
+        logger.warning(f"Listening on {args.host} without --token: anyone who can reach the port "
+                       f"can start benchmarks on this cluster")
+
+    manager = RunManager(repo_root, results_dir, args.max_concurrent, logger)
+    ApiHandler.manager = manager
+    ApiHandler.token = args.token
+    ApiHandler.logger = logger
+    server = ThreadingHTTPServer((args.host, args.port), ApiHandler)
+    server.daemon_threads = True
+
+    def shutdown(signum, frame):
+        threading.Thread(target=server.shutdown, daemon=True).start()
+
+    signal.signal(signal.SIGTERM, shutdown)
+    logger.info(f"Serving the virtbench API at http://{args.host}:{args.port}/ "
+                f"(max {args.max_concurrent} concurrent run(s), records in {manager.state_dir})")
+    try:
+        server.serve_forever()
+    except KeyboardInterrupt:
+        pass
+    finally:
+        server.server_close()
+        manager.shutdown()
+    return 0
+
+
+if __name__ == '__main__':
+    raise SystemExit(main())
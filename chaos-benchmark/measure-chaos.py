@@ -21,19 +21,33 @@ import argparse
 import json
 import os
 import sys
+import threading
 import time
+from collections import Counter
 from datetime import datetime
-from concurrent.futures import ThreadPoolExecutor, as_completed
 from typing import Optional, Tuple, List, Dict
 
 # Add parent directory to path for imports
 sys.path.insert(0, os.path.join(os.path.dirname(__file__), '..'))
 
+from utils import timing
 from utils.common import (
-    setup_logging, run_kubectl_command, create_namespace, namespace_exists,
-    get_vm_status, restart_vm, resize_pvc, wait_for_pvc_resize,
+    setup_logging, run_kubectl_command, create_or_adopt, create_namespace, namespace_exists,
+    get_vm_status, restart_vm,
     create_vm_snapshot, wait_for_snapshot_ready, delete_vm_snapshot,
-    get_pvc_size, get_vm_volume_names, Colors, save_capacity_results
+    get_vm_volume_names, get_pvc_storage_class, Colors, get_worker_nodes,
+    save_capacity_results, expand_pvc, set_run_workload, labeled_namespaces, vm_snapshot_manifest,
+    ZONE_LABEL
+)
+from utils.inventory import cluster_inventory, resolve_storage_driver
+from utils.cluster_platform import resolve_datasource_namespace
+from utils.dryrun import DryRunPlan, is_dry_run
+from utils.stats import describe, steady_state, DEFAULT_STEADY_STATE_CV, STEADY_STATE_WINDOW
+from utils.placement import get_strategy, PlacementStrategy, STRATEGIES as PLACEMENT_STRATEGIES, STRATEGY_NONE
+from utils.quota import namespace_quota_exhausted
+from utils.concurrency import run_parallel, DEFAULT_QPS, DEFAULT_BURST
+from utils.capacity import (
+    CapacityIteration, CapacityReached, PhaseFailed, PHASE_TITLES, phase_summary, skipped_phases
 )
 
 # Default configuration
@@ -76,12 +90,38 @@ Examples:
                         help='Storage class name (comma-separated for multiple)')
     parser.add_argument('--concurrency', type=int, required=True,
                         help='Number of concurrent operations (REQUIRED)')
+    parser.add_argument('--qps', type=float, default=DEFAULT_QPS,
+                        help='Max VM operations started per second, 0 disables rate limiting (default: 0)')
+    parser.add_argument('--burst', type=int, default=DEFAULT_BURST,
+                        help=f'Max VM operations started back-to-back when --qps is set (default: {DEFAULT_BURST})')
 
     # Test configuration
     parser.add_argument('--namespace', '-n', type=str, default=DEFAULT_NAMESPACE,
                         help=f'Namespace for test resources (default: {DEFAULT_NAMESPACE})')
     parser.add_argument('--max-iterations', type=int, default=0,
                         help='Maximum number of iterations (0 for infinite, default: 0)')
+    parser.add_argument('--placement', choices=list(PLACEMENT_STRATEGIES), default=STRATEGY_NONE,
+                        help='How VMs are placed on nodes across iterations: none (scheduler decides), spread, '
+                             'pack, interleave, nodes (--placement-nodes) or zone (default: none)')
+    parser.add_argument('--placement-nodes', nargs='+', default=None,
+                        help='Nodes the placement strategy uses (required by --placement nodes; '
+                             'default: all Ready workers)')
+    parser.add_argument('--max-vms-per-node', type=int, default=None,
+                        help='VMs per node before --placement pack moves to the next node')
+    parser.add_argument('--zone', nargs='+', default=None,
+                        help='Only place VMs on nodes in these zones (values of --topology-key); '
+                             'spreads over their nodes unless --placement says otherwise')
+    parser.add_argument('--topology-key', default=ZONE_LABEL,
+                        help=f'Node label of the zone, e.g. a rack label (default: {ZONE_LABEL})')
+    parser.add_argument('--topology-spread', action='store_true',
+                        help='Spread VMs evenly across zones, then across the nodes of each zone. '
+                             'Same as --placement zone.')
+    parser.add_argument('--warmup-iterations', type=int, default=0,
+                        help='Iterations run before the measured ones, up to N or until steady state; '
+                             'their VMs count towards capacity but their timings are not reported (default: 0)')
+    parser.add_argument('--steady-state-cv', type=float, default=DEFAULT_STEADY_STATE_CV,
+                        help='Coefficient of variation of the last warm-up iterations\' VM creation time at '
+                             f'which warm-up ends early (default: {DEFAULT_STEADY_STATE_CV})')
     parser.add_argument('--vms', type=int, default=DEFAULT_VMS_PER_ITERATION,
                         help=f'Number of VMs per iteration (default: {DEFAULT_VMS_PER_ITERATION})')
     parser.add_argument('--data-volume-count', type=int, default=DEFAULT_DATA_VOLUME_COUNT,
@@ -96,10 +136,13 @@ Examples:
                         help=f'Path to VM YAML template (default: {DEFAULT_VM_YAML})')
     parser.add_argument('--vm-name', type=str, default=DEFAULT_VM_NAME,
                         help=f'Base VM name (default: {DEFAULT_VM_NAME})')
+    parser.add_argument('--data-storage-class', type=str, default=None,
+                        help='Storage class for data volumes (default: same as the OS disk storage class)')
     parser.add_argument('--datasource-name', type=str, default='rhel9',
                         help='DataSource name (default: rhel9)')
-    parser.add_argument('--datasource-namespace', type=str, default='openshift-virtualization-os-images',
-                        help='DataSource namespace (default: openshift-virtualization-os-images)')
+    parser.add_argument('--datasource-namespace', type=str, default=None,
+                        help='DataSource namespace (default: openshift-virtualization-os-images on OpenShift, '
+                             'kubevirt-os-images on Kubernetes, or wherever the DataSource is found)')
     parser.add_argument('--vm-memory', type=str, default='2048M',
                         help='VM memory (default: 2048M)')
     parser.add_argument('--vm-cpu-cores', type=int, default=1,
@@ -114,6 +157,9 @@ Examples:
                         help='Skip VM snapshot phase')
     parser.add_argument('--skip-restart', action='store_true',
                         help='Skip VM restart phase')
+    parser.add_argument('--legacy', action='store_true',
+                        help='Run iterations with the previous inline loop instead of the phase engine '
+                             '(no per-phase results); kept during the transition')
 
     # Execution options
     parser.add_argument('--scheduling-timeout', type=int, default=120,
@@ -135,7 +181,7 @@ Examples:
     parser.add_argument('--results-dir', type=str, default='results',
                         help='Directory to save results (default: results)')
     parser.add_argument('--storage-driver', type=str, default=None,
-                        help='Storage driver for results folder hierarchy (e.g., portworx-3.6)')
+                        help='Storage driver for results folder hierarchy (e.g., portworx-3.6), or auto to detect it')
 
     # Logging
     parser.add_argument('--log-file', type=str,
@@ -149,26 +195,49 @@ Examples:
     # Validate arguments
     if not args.cleanup_only and not args.storage_class:
         parser.error('--storage-class is required (unless using --cleanup-only)')
+    if args.topology_spread:
+        if args.placement not in (STRATEGY_NONE, 'zone'):
+            parser.error(f'--topology-spread cannot be combined with --placement {args.placement}')
+        args.placement = 'zone'
+    if args.placement == 'nodes' and not args.placement_nodes:
+        parser.error('--placement nodes requires --placement-nodes')
+    if args.max_vms_per_node is not None and args.max_vms_per_node < 1:
+        parser.error('--max-vms-per-node must be >= 1')
+    if args.warmup_iterations < 0:
+        parser.error('--warmup-iterations must be >= 0')
+    if args.steady_state_cv <= 0:
+        parser.error('--steady-state-cv must be > 0')
+    if args.qps < 0:
+        parser.error('--qps must be >= 0')
+    if args.burst < 1:
+        parser.error('--burst must be >= 1')
 
     return args
 
 
-def parse_size_to_gi(size_str: str) -> int:
-    """Parse size string to GiB integer."""
-    size_str = size_str.strip().upper()
-    if size_str.endswith('GI'):
-        return int(size_str[:-2])
-    elif size_str.endswith('G'):
-        return int(size_str[:-1])
-    else:
-        raise ValueError(f"Unsupported size format: {size_str}")
+class DiskClassMetrics:
+    """
+    Thread-safe collector of per-disk operation timings keyed by storage class.
 
+    Used so that VMs whose OS and data disks live on different storage
+    classes report resize, clone and snapshot times for each class.
+    """
+
+    def __init__(self):
+        self._lock = threading.Lock()
+        self._timings: Dict[str, Dict[str, List[float]]] = {}
 
-def increment_size(current_size: str, increment: str) -> str:
-    """Increment size by specified amount."""
-    current_gi = parse_size_to_gi(current_size)
-    increment_gi = parse_size_to_gi(increment)
-    return f"{current_gi + increment_gi}Gi"
+    def record(self, storage_class: str, operation: str, seconds: float):
+        with self._lock:
+            self._timings.setdefault(storage_class or 'unknown', {}).setdefault(operation, []).append(seconds)
+
+    def summary(self) -> Dict[str, Dict[str, Dict]]:
+        """Return {storage_class: {operation: {avg, min, max, median, p90, p95, p99, stddev, count}}}."""
+        with self._lock:
+            return {
+                sc: {op: describe(values) for op, values in ops.items()}
+                for sc, ops in self._timings.items()
+            }
 
 
 def get_storage_classes(storage_class_arg: str) -> List[str]:
@@ -180,8 +249,6 @@ def clone_pvc(source_pvc: str, clone_name: str, namespace: str, storage_class: s
               logger) -> bool:
     """Clone a PVC using dataSource. Copies spec from source PVC."""
     try:
-        import subprocess
-
         # Get source PVC spec
         returncode, stdout, stderr = run_kubectl_command(
             ['get', 'pvc', source_pvc, '-n', namespace, '-o', 'json'],
@@ -216,14 +283,11 @@ def clone_pvc(source_pvc: str, clone_name: str, namespace: str, storage_class: s
             }
         }
 
-        process = subprocess.Popen(
-            ['kubectl', 'create', '-f', '-', '-n', namespace],
-            stdin=subprocess.PIPE, stdout=subprocess.PIPE, stderr=subprocess.PIPE, text=True
-        )
-        stdout, stderr = process.communicate(input=json.dumps(clone_manifest))
+        created, adopted, stderr = create_or_adopt(json.dumps(clone_manifest), namespace, logger)
 
-        if process.returncode == 0:
-            logger.info(f"Clone PVC {clone_name} created from {source_pvc}")
+        if created:
+            if not adopted:
+                logger.info(f"Clone PVC {clone_name} created from {source_pvc}")
             return True
         logger.error(f"Failed to create clone PVC {clone_name}: {stderr}")
         return False
@@ -235,15 +299,15 @@ def clone_pvc(source_pvc: str, clone_name: str, namespace: str, storage_class: s
 def wait_for_pvc_bound(pvc_name: str, namespace: str, timeout: int = 600,
                        poll_interval: int = 5, logger=None) -> Tuple[bool, float]:
     """Wait for PVC to be bound. Returns (success, duration_seconds)."""
-    start_time = time.time()
-    while time.time() - start_time < timeout:
+    start_time = timing.now()
+    while (timing.now() - start_time).total_seconds() < timeout:
         try:
             returncode, stdout, _ = run_kubectl_command(
                 ['get', 'pvc', pvc_name, '-n', namespace, '-o', 'jsonpath={.status.phase}'],
                 check=False, logger=logger
             )
             if returncode == 0 and stdout.strip() == 'Bound':
-                duration = time.time() - start_time
+                duration = (timing.now() - start_time).total_seconds()
                 if logger:
                     logger.info(f"PVC {pvc_name} bound after {duration:.2f}s")
                 return True, duration
@@ -254,101 +318,126 @@ def wait_for_pvc_bound(pvc_name: str, namespace: str, timeout: int = 600,
             time.sleep(poll_interval)
     if logger:
         logger.error(f"Timeout waiting for PVC {pvc_name} to be bound")
-    return False, time.time() - start_time
+    return False, (timing.now() - start_time).total_seconds()
+
+
 
 
+def build_vm_with_data_volumes(vm_name: str, namespace: str, vm_yaml: str,
+                               storage_class: str, data_volume_count: int,
+                               volume_size: str, args,
+                               data_storage_class: Optional[str] = None,
+                               node_name: Optional[str] = None) -> dict:
+    """
+    Render the VM manifest of create_vm_with_data_volumes.
+
+    The OS disk uses storage_class; data volumes use data_storage_class
+    when given, otherwise the same class as the OS disk. node_name pins the
+    VM to a node (placement strategies).
+    """
+    import yaml as pyyaml
+
+    data_storage_class = data_storage_class or storage_class
+
+    # Read VM template as text and replace placeholders
+    with open(vm_yaml, 'r') as f:
+        template_text = f.read()
+
+    # Replace all placeholders with actual values
+    template_text = template_text.replace('{{VM_NAME}}', vm_name)
+    template_text = template_text.replace('{{DATA_STORAGE_CLASS_NAME}}', data_storage_class)
+    template_text = template_text.replace('{{STORAGE_CLASS_NAME}}', storage_class)
+    template_text = template_text.replace('{{DATASOURCE_NAME}}', args.datasource_name)
+    template_text = template_text.replace('{{DATASOURCE_NAMESPACE}}', args.datasource_namespace)
+    template_text = template_text.replace('{{STORAGE_SIZE}}', volume_size)
+    template_text = template_text.replace('{{VM_MEMORY}}', args.vm_memory)
+    template_text = template_text.replace('{{VM_CPU_CORES}}', str(args.vm_cpu_cores))
+
+    # Parse the YAML after placeholder replacement
+    vm_template = pyyaml.safe_load(template_text)
+
+    # Update VM metadata
+    vm_template['metadata']['name'] = vm_name
+    vm_template['metadata']['namespace'] = namespace
+
+    # Update spec
+    spec = vm_template.get('spec', {})
+    template_spec = spec.get('template', {}).get('spec', {})
+
+    # Update memory and CPU
+    domain = template_spec.get('domain', {})
+    if 'resources' in domain:
+        domain['resources']['requests'] = {'memory': args.vm_memory}
+    if 'cpu' in domain:
+        domain['cpu']['cores'] = args.vm_cpu_cores
+
+    # Update volumes and disks
+    volumes = template_spec.get('volumes', [])
+    disks = domain.get('devices', {}).get('disks', [])
+
+    # Update root volume storage class (template data disks keep their own class)
+    for vol in volumes:
+        if 'dataVolume' in vol:
+            dv_template = spec.get('dataVolumeTemplates', [])
+            for dvt in dv_template:
+                if dvt['metadata']['name'] == vol['dataVolume']['name']:
+                    if dvt['spec']['storage'].get('storageClassName') != data_storage_class:
+                        dvt['spec']['storage']['storageClassName'] = storage_class
+                    dvt['spec']['storage']['resources']['requests']['storage'] = volume_size
+
+    # Add data volumes
+    dv_templates = spec.get('dataVolumeTemplates', [])
+    for i in range(1, data_volume_count + 1):
+        dv_name = f"{vm_name}-data-{i}"
+        dv_template = {
+            "metadata": {"name": dv_name},
+            "spec": {
+                "storage": {
+                    "storageClassName": data_storage_class,
+                    "accessModes": ["ReadWriteOnce"],
+                    "resources": {"requests": {"storage": volume_size}}
+                },
+                "source": {"blank": {}}
+            }
+        }
+        dv_templates.append(dv_template)
+        volumes.append({"dataVolume": {"name": dv_name}, "name": f"data-vol-{i}"})
+        disks.append({"disk": {"bus": "virtio"}, "name": f"data-vol-{i}"})
+
+    spec['dataVolumeTemplates'] = dv_templates
+    template_spec['volumes'] = volumes
+    domain['devices']['disks'] = disks
+    if node_name:
+        template_spec.setdefault('nodeSelector', {})['kubernetes.io/hostname'] = node_name
+    return vm_template
 
 
 def create_vm_with_data_volumes(vm_name: str, namespace: str, vm_yaml: str,
                                  storage_class: str, data_volume_count: int,
                                  volume_size: str, args, logger,
-                                 max_retries: int = 5) -> bool:
-    """Create a VM with multiple data volumes."""
+                                 max_retries: int = 5,
+                                 data_storage_class: Optional[str] = None,
+                                 node_name: Optional[str] = None) -> bool:
+    """
+    Create a VM with multiple data volumes.
+
+    The OS disk uses storage_class; data volumes use data_storage_class
+    when given, otherwise the same class as the OS disk.
+    """
     for attempt in range(max_retries):
         try:
-            import subprocess
             import yaml as pyyaml
 
-            # Read VM template as text and replace placeholders
-            with open(vm_yaml, 'r') as f:
-                template_text = f.read()
-
-            # Replace all placeholders with actual values
-            template_text = template_text.replace('{{VM_NAME}}', vm_name)
-            template_text = template_text.replace('{{STORAGE_CLASS_NAME}}', storage_class)
-            template_text = template_text.replace('{{DATASOURCE_NAME}}', args.datasource_name)
-            template_text = template_text.replace('{{DATASOURCE_NAMESPACE}}', args.datasource_namespace)
-            template_text = template_text.replace('{{STORAGE_SIZE}}', volume_size)
-            template_text = template_text.replace('{{VM_MEMORY}}', args.vm_memory)
-            template_text = template_text.replace('{{VM_CPU_CORES}}', str(args.vm_cpu_cores))
-
-            # Parse the YAML after placeholder replacement
-            vm_template = pyyaml.safe_load(template_text)
-
-            # Update VM metadata
-            vm_template['metadata']['name'] = vm_name
-            vm_template['metadata']['namespace'] = namespace
-
-            # Update spec
-            spec = vm_template.get('spec', {})
-            template_spec = spec.get('template', {}).get('spec', {})
-
-            # Update memory and CPU
-            domain = template_spec.get('domain', {})
-            if 'resources' in domain:
-                domain['resources']['requests'] = {'memory': args.vm_memory}
-            if 'cpu' in domain:
-                domain['cpu']['cores'] = args.vm_cpu_cores
-
-            # Update volumes and disks
-            volumes = template_spec.get('volumes', [])
-            disks = domain.get('devices', {}).get('disks', [])
-
-            # Update root volume storage class
-            for vol in volumes:
-                if 'dataVolume' in vol:
-                    dv_template = spec.get('dataVolumeTemplates', [])
-                    for dvt in dv_template:
-                        if dvt['metadata']['name'] == vol['dataVolume']['name']:
-                            dvt['spec']['storage']['storageClassName'] = storage_class
-                            dvt['spec']['storage']['resources']['requests']['storage'] = volume_size
-
-            # Add data volumes
-            dv_templates = spec.get('dataVolumeTemplates', [])
-            for i in range(1, data_volume_count + 1):
-                dv_name = f"{vm_name}-data-{i}"
-                dv_template = {
-                    "metadata": {"name": dv_name},
-                    "spec": {
-                        "storage": {
-                            "storageClassName": storage_class,
-                            "accessModes": ["ReadWriteOnce"],
-                            "resources": {"requests": {"storage": volume_size}}
-                        },
-                        "source": {"blank": {}}
-                    }
-                }
-                dv_templates.append(dv_template)
-                volumes.append({"dataVolume": {"name": dv_name}, "name": f"data-vol-{i}"})
-                disks.append({"disk": {"bus": "virtio"}, "name": f"data-vol-{i}"})
-
-            spec['dataVolumeTemplates'] = dv_templates
-            template_spec['volumes'] = volumes
-            domain['devices']['disks'] = disks
+            vm_template = build_vm_with_data_volumes(vm_name, namespace, vm_yaml, storage_class,
+                                                     data_volume_count, volume_size, args,
+                                                     data_storage_class, node_name)
 
             # Create VM
-            process = subprocess.Popen(
-                ['kubectl', 'create', '-f', '-', '-n', namespace],
-                stdin=subprocess.PIPE, stdout=subprocess.PIPE, stderr=subprocess.PIPE, text=True
-            )
-            stdout, stderr = process.communicate(input=pyyaml.dump(vm_template))
+            created, adopted, stderr = create_or_adopt(pyyaml.dump(vm_template), namespace, logger)
 
-            if process.returncode == 0:
-                logger.info(f"VM {vm_name} created successfully")
-                return True
-
-            if 'AlreadyExists' in stderr:
-                logger.warning(f"VM {vm_name} already exists")
+            if created:
+                if not adopted:
+                    logger.info(f"VM {vm_name} created successfully")
                 return True
 
             logger.error(f"Failed to create VM {vm_name}: {stderr}")
@@ -376,25 +465,25 @@ def wait_for_vm_running(vm_name: str, namespace: str, logger, timeout: int = 180
     Returns:
         Tuple of (success, failure_reason)
     """
-    start_time = time.time()
+    start_time = timing.now()
     stuck_state_start = None
     last_status = 'Unknown'
 
-    while time.time() - start_time < timeout:
+    while (timing.now() - start_time).total_seconds() < timeout:
         status = get_vm_status(vm_name, namespace, logger)
         last_status = status
 
         if status == 'Running':
-            elapsed = time.time() - start_time
+            elapsed = (timing.now() - start_time).total_seconds()
             logger.info(f"VM {vm_name} reached Running state after {elapsed:.2f}s")
             return True, ''
 
         # Track time in Scheduling OR Provisioning state (both indicate stuck)
         if status in ('Scheduling', 'Provisioning', 'WaitingForVolumeBinding'):
             if stuck_state_start is None:
-                stuck_state_start = time.time()
+                stuck_state_start = timing.now()
                 logger.debug(f"VM {vm_name} entered {status} state")
-            elif time.time() - stuck_state_start > scheduling_timeout:
+            elif (timing.now() - stuck_state_start).total_seconds() > scheduling_timeout:
                 logger.warning(f"VM {vm_name} stuck in {status} state for {scheduling_timeout}s")
                 return False, 'scheduling'
         else:
@@ -421,36 +510,118 @@ def wait_for_vms_running_concurrent(vm_names: List[str], namespace: str, logger,
     failed = []
     failure_reason = ''
 
-    with ThreadPoolExecutor(max_workers=concurrency) as executor:
-        futures = {
-            executor.submit(wait_for_vm_running, vm, namespace, logger, timeout, scheduling_timeout): vm
-            for vm in vm_names
-        }
-
-        for future in as_completed(futures):
-            vm_name = futures[future]
-            try:
-                success, reason = future.result()
-                if success:
-                    successful.append(vm_name)
-                else:
-                    failed.append(vm_name)
-                    if not failure_reason:
-                        failure_reason = reason
-            except Exception as e:
-                logger.error(f"Error waiting for VM {vm_name}: {e}")
-                failed.append(vm_name)
-                if not failure_reason:
-                    failure_reason = 'error'
+    outcomes = run_parallel(wait_for_vm_running, vm_names, concurrency=concurrency,
+                            args=(namespace, logger, timeout, scheduling_timeout), logger=logger,
+                            description='VM start wait')
+    for vm_name, result, error in outcomes:
+        if error is None and result[0]:
+            successful.append(vm_name)
+            continue
+        failed.append(vm_name)
+        if not failure_reason:
+            failure_reason = result[1] if error is None else 'error'
 
     return successful, failed, failure_reason
 
 
 
+def placement_candidates(args, logger) -> List[str]:
+    """Nodes the placement strategy chooses from (its own --placement-nodes list needs no lookup)."""
+    if (args.placement == STRATEGY_NONE and not args.zone) or args.placement_nodes:
+        return []
+    return get_worker_nodes(logger)
+
+
+def iteration_vm_names(args, iteration: int) -> List[str]:
+    """Names of the VMs an iteration creates."""
+    return [f"{args.vm_name}-{iteration}-{i}" for i in range(1, args.vms + 1)]
+
+
+def place_iteration(placement: PlacementStrategy, args, iteration: int, nodes: List[str],
+                    placed: Counter) -> Dict[str, Optional[str]]:
+    """
+    Node of each VM of an iteration, in submission order.
+
+    placed counts the VMs of earlier iterations per node, so strategies such
+    as pack continue filling nodes where the previous iteration stopped.
+    """
+    names = iteration_vm_names(args, iteration)
+    assignment = placement.assign(names, nodes, placed)
+    return {name: assignment[name] for name in placement.order(names, assignment)}
+
+
+def log_iteration_header(iteration: int, storage_class: str, args, logger):
+    """Log the banner of an iteration with its storage classes and VM count."""
+    data_storage_class = args.data_storage_class or storage_class
+    logger.info("=" * 100)
+    logger.info(f"{Colors.BOLD}ITERATION {iteration}{Colors.ENDC}")
+    logger.info(f"Storage Class: {storage_class}")
+    if data_storage_class != storage_class:
+        logger.info(f"Data Storage Class: {data_storage_class}")
+    logger.info(f"VMs to create: {args.vms}, Concurrency: {args.concurrency}")
+    logger.info("=" * 100)
+
+
+def resize_vm_volumes(vm_name: str, namespace: str, increment: str, disk_metrics: DiskClassMetrics,
+                      logger) -> Tuple[bool, List[str], Optional[str]]:
+    """Expand every volume of a VM by increment. Returns (success, resized PVCs, error)."""
+    resized = []
+    for pvc_name in get_vm_volume_names(vm_name, namespace, logger):
+        _, seconds, error = expand_pvc(pvc_name, namespace, increment, logger=logger)
+        if error:
+            return False, resized, error
+        disk_metrics.record(get_pvc_storage_class(pvc_name, namespace, logger), 'resize', seconds)
+        resized.append(pvc_name)
+    return True, resized, None
+
+
+def clone_vm_volumes(vm_name: str, namespace: str, storage_class: str, disk_metrics: DiskClassMetrics,
+                     logger) -> Tuple[bool, List[str], Optional[str]]:
+    """Clone every volume of a VM and wait for the clones to bind. Returns (success, clones, error)."""
+    cloned = []
+    for pvc_name in get_vm_volume_names(vm_name, namespace, logger):
+        clone_name = f"{pvc_name}-clone"
+        # Clones stay on the source disk's class (OS and data disks may differ)
+        source_class = get_pvc_storage_class(pvc_name, namespace, logger) or storage_class
+        clone_start = timing.now()
+        if not clone_pvc(pvc_name, clone_name, namespace, source_class, logger):
+            return False, cloned, f"Failed to clone PVC {pvc_name}"
+        success, _ = wait_for_pvc_bound(clone_name, namespace, logger=logger)
+        if not success:
+            return False, cloned, f"Clone PVC {clone_name} did not become bound"
+        disk_metrics.record(source_class, 'clone', (timing.now() - clone_start).total_seconds())
+        cloned.append(clone_name)
+    return True, cloned, None
+
+
+def create_snapshot_for_vm(vm_name: str, namespace: str, disk_metrics: DiskClassMetrics,
+                           logger) -> Tuple[bool, Optional[str], Optional[str]]:
+    """Snapshot a VM and wait until it is ready. Returns (success, snapshot name, error)."""
+    snapshot_name = f"{vm_name}-snapshot"
+    snapshot_start = timing.now()
+    if not create_vm_snapshot(vm_name, snapshot_name, namespace, logger):
+        return False, None, f"Failed to create snapshot for VM {vm_name}"
+    if not wait_for_snapshot_ready(snapshot_name, namespace, logger=logger):
+        return False, snapshot_name, f"Snapshot {snapshot_name} did not become ready"
+    # A VM snapshot covers every disk, so count it once per class the VM uses
+    snapshot_seconds = (timing.now() - snapshot_start).total_seconds()
+    vm_classes = {get_pvc_storage_class(pvc, namespace, logger)
+                  for pvc in get_vm_volume_names(vm_name, namespace, logger)}
+    for sc in vm_classes:
+        disk_metrics.record(sc, 'snapshot', snapshot_seconds)
+    return True, snapshot_name, None
+
+
 def run_iteration(iteration: int, namespace: str, storage_class: str, args, logger,
-                  phases_executed: List[str]) -> Tuple[bool, bool, int]:
+                  phases_executed: List[str],
+                  disk_metrics: Optional[DiskClassMetrics] = None,
+                  phase_durations: Optional[Dict[str, float]] = None,
+                  vm_nodes: Optional[Dict[str, Optional[str]]] = None) -> Tuple[bool, bool, int]:
     """
-    Run a single chaos test iteration with concurrent operations.
+    Run a single chaos test iteration with concurrent operations (--legacy).
+
+    run_capacity_iteration() runs the same phases through the utils.capacity
+    engine, with per-phase results; this loop is kept as a fallback.
 
     Args:
         iteration: Iteration number
@@ -459,42 +630,37 @@ def run_iteration(iteration: int, namespace: str, storage_class: str, args, logg
         args: Command line arguments
         logger: Logger instance
         phases_executed: List to track which phases actually executed (modified in place)
+        disk_metrics: Optional collector for per-storage-class operation timings
+        phase_durations: Optional dict filled with phase name -> duration in seconds
+        vm_nodes: Optional VM name -> node of the placement strategy, in submission order
 
     Returns:
         Tuple of (success, capacity_reached, vms_created)
     """
-    logger.info("=" * 100)
-    logger.info(f"{Colors.BOLD}ITERATION {iteration}{Colors.ENDC}")
-    logger.info(f"Storage Class: {storage_class}")
-    logger.info(f"VMs to create: {args.vms}, Concurrency: {args.concurrency}")
-    logger.info("=" * 100)
+    log_iteration_header(iteration, storage_class, args, logger)
+    data_storage_class = args.data_storage_class or storage_class
+    if disk_metrics is None:
+        disk_metrics = DiskClassMetrics()
 
-    vm_names = [f"{args.vm_name}-{iteration}-{i}" for i in range(1, args.vms + 1)]
+    vm_names = list(vm_nodes) if vm_nodes else iteration_vm_names(args, iteration)
+    vm_nodes = vm_nodes or {}
 
     # Phase 1: Create VMs (concurrent)
     logger.info(f"\n{Colors.HEADER}Phase 1: Creating {args.vms} VMs (concurrency: {args.concurrency}){Colors.ENDC}")
-    phase_start = time.time()
+    phase_start = timing.now()
 
     created_vms = []
-    with ThreadPoolExecutor(max_workers=args.concurrency) as executor:
-        futures = {
-            executor.submit(
-                create_vm_with_data_volumes, vm_name, namespace, args.vm_yaml,
-                storage_class, args.data_volume_count, args.min_vol_size, args, logger,
-                args.max_create_retries
-            ): vm_name for vm_name in vm_names
-        }
-        for future in as_completed(futures):
-            vm_name = futures[future]
-            try:
-                if future.result():
-                    created_vms.append(vm_name)
-                else:
-                    logger.error(f"Failed to create VM {vm_name}")
-                    return False, False, 0
-            except Exception as e:
-                logger.error(f"Exception creating VM {vm_name}: {e}")
-                return False, False, 0
+    outcomes = run_parallel(
+        lambda vm_name: create_vm_with_data_volumes(
+            vm_name, namespace, args.vm_yaml, storage_class, args.data_volume_count, args.min_vol_size,
+            args, logger, args.max_create_retries, data_storage_class, vm_nodes.get(vm_name)),
+        vm_names, concurrency=args.concurrency, qps=args.qps, burst=args.burst, logger=logger,
+        description='VM creation')
+    for vm_name, created, error in outcomes:
+        if error is not None or not created:
+            logger.error(f"Failed to create VM {vm_name}")
+            return False, False, 0
+        created_vms.append(vm_name)
 
     # Wait for VMs to be running (concurrent)
     logger.info(f"Waiting for {len(created_vms)} VMs to reach Running state (scheduling timeout: {args.scheduling_timeout}s)...")
@@ -506,45 +672,37 @@ def run_iteration(iteration: int, namespace: str, storage_class: str, args, logg
         if failure_reason in ('scheduling', 'capacity'):
             logger.warning(f"{Colors.WARNING}CAPACITY REACHED: {len(failed_vms)} VMs could not be scheduled{Colors.ENDC}")
             return False, True, len(successful_vms)
+        exhausted = namespace_quota_exhausted(namespace, logger)
+        if exhausted:
+            logger.warning(f"{Colors.WARNING}QUOTA REACHED: {len(failed_vms)} VMs could not start, "
+                           f"namespace quota exhausted ({', '.join(exhausted)}){Colors.ENDC}")
+            return False, True, len(successful_vms)
         logger.error(f"Phase 1 FAILED: {len(failed_vms)} VMs failed to start (reason: {failure_reason})")
         return False, False, len(successful_vms)
 
-    phase_duration = time.time() - phase_start
+    phase_duration = (timing.now() - phase_start).total_seconds()
     phases_executed.append('Create VMs')
+    if phase_durations is not None:
+        phase_durations['Create VMs'] = phase_duration
     logger.info(f"{Colors.OKGREEN}Phase 1 COMPLETE: {len(successful_vms)} VMs running (took {phase_duration:.2f}s){Colors.ENDC}")
 
     # Phase 2: Resize Volumes (concurrent)
     if not args.skip_resize:
         logger.info(f"\n{Colors.HEADER}Phase 2: Resizing Volumes (concurrency: {args.concurrency}){Colors.ENDC}")
-        phase_start = time.time()
-
-        def resize_vm_volumes(vm_name):
-            pvc_names = get_vm_volume_names(vm_name, namespace, logger)
-            for pvc_name in pvc_names:
-                current_size = get_pvc_size(pvc_name, namespace, logger)
-                if not current_size:
-                    return False, f"Failed to get size for PVC {pvc_name}"
-                new_size = increment_size(current_size, args.min_vol_inc_size)
-                if not resize_pvc(pvc_name, namespace, new_size, logger):
-                    return False, f"Failed to resize PVC {pvc_name}"
-                if not wait_for_pvc_resize(pvc_name, namespace, new_size, logger=logger):
-                    return False, f"PVC {pvc_name} resize did not complete"
-            return True, None
-
-        with ThreadPoolExecutor(max_workers=args.concurrency) as executor:
-            futures = {executor.submit(resize_vm_volumes, vm): vm for vm in successful_vms}
-            for future in as_completed(futures):
-                vm_name = futures[future]
-                try:
-                    success, error = future.result()
-                    if not success:
-                        logger.error(f"Phase 2 FAILED for {vm_name}: {error}")
-                        return False, False, len(successful_vms)
-                except Exception as e:
-                    logger.error(f"Phase 2 FAILED for {vm_name}: {e}")
-                    return False, False, len(successful_vms)
-
-        phase_duration = time.time() - phase_start
+        phase_start = timing.now()
+
+        outcomes = run_parallel(resize_vm_volumes, successful_vms, concurrency=args.concurrency,
+                                qps=args.qps, burst=args.burst,
+                                args=(namespace, args.min_vol_inc_size, disk_metrics, logger),
+                                logger=logger, description='volume resize')
+        for vm_name, result, error in outcomes:
+            if error is None and not result[0]:
+                error = result[2]
+            if error is not None:
+                logger.error(f"Phase 2 FAILED for {vm_name}: {error}")
+                return False, False, len(successful_vms)
+
+        phase_duration = (timing.now() - phase_start).total_seconds()
         phases_executed.append('Resize Volumes')
         logger.info(f"{Colors.OKGREEN}Phase 2 COMPLETE: All volumes resized (took {phase_duration:.2f}s){Colors.ENDC}")
     else:
@@ -553,37 +711,23 @@ def run_iteration(iteration: int, namespace: str, storage_class: str, args, logg
     # Phase 3: Clone Volumes (concurrent)
     if not args.skip_clone:
         logger.info(f"\n{Colors.HEADER}Phase 3: Cloning Volumes (concurrency: {args.concurrency}){Colors.ENDC}")
-        phase_start = time.time()
+        phase_start = timing.now()
         clones_created = []
 
-        def clone_vm_volumes(vm_name):
-            pvc_names = get_vm_volume_names(vm_name, namespace, logger)
-            cloned = []
-            for pvc_name in pvc_names:
-                clone_name = f"{pvc_name}-clone"
-                if not clone_pvc(pvc_name, clone_name, namespace, storage_class, logger):
-                    return False, cloned, f"Failed to clone PVC {pvc_name}"
-                success, _ = wait_for_pvc_bound(clone_name, namespace, logger=logger)
-                if not success:
-                    return False, cloned, f"Clone PVC {clone_name} did not become bound"
-                cloned.append(clone_name)
-            return True, cloned, None
-
-        with ThreadPoolExecutor(max_workers=args.concurrency) as executor:
-            futures = {executor.submit(clone_vm_volumes, vm): vm for vm in successful_vms}
-            for future in as_completed(futures):
-                vm_name = futures[future]
-                try:
-                    success, cloned, error = future.result()
-                    clones_created.extend(cloned)
-                    if not success:
-                        logger.error(f"Phase 3 FAILED for {vm_name}: {error}")
-                        return False, False, len(successful_vms)
-                except Exception as e:
-                    logger.error(f"Phase 3 FAILED for {vm_name}: {e}")
-                    return False, False, len(successful_vms)
-
-        phase_duration = time.time() - phase_start
+        outcomes = run_parallel(clone_vm_volumes, successful_vms, concurrency=args.concurrency,
+                                qps=args.qps, burst=args.burst,
+                                args=(namespace, storage_class, disk_metrics, logger),
+                                logger=logger, description='volume clone')
+        for vm_name, result, error in outcomes:
+            if error is None:
+                success, cloned, error = result
+                clones_created.extend(cloned)
+                if success:
+                    continue
+            logger.error(f"Phase 3 FAILED for {vm_name}: {error}")
+            return False, False, len(successful_vms)
+
+        phase_duration = (timing.now() - phase_start).total_seconds()
         phases_executed.append('Clone Volumes')
         logger.info(f"{Colors.OKGREEN}Phase 3 COMPLETE: {len(clones_created)} clones created (took {phase_duration:.2f}s){Colors.ENDC}")
     else:
@@ -592,19 +736,18 @@ def run_iteration(iteration: int, namespace: str, storage_class: str, args, logg
     # Phase 4: Restart VMs (concurrent)
     if not args.skip_restart:
         logger.info(f"\n{Colors.HEADER}Phase 4: Restarting VMs (concurrency: {args.concurrency}){Colors.ENDC}")
-        phase_start = time.time()
-
-        with ThreadPoolExecutor(max_workers=args.concurrency) as executor:
-            futures = {executor.submit(restart_vm, vm, namespace, logger): vm for vm in successful_vms}
-            for future in as_completed(futures):
-                vm_name = futures[future]
-                try:
-                    if not future.result():
-                        logger.error(f"Phase 4 FAILED: Failed to restart VM {vm_name}")
-                        return False, False, len(successful_vms)
-                except Exception as e:
-                    logger.error(f"Phase 4 FAILED for {vm_name}: {e}")
-                    return False, False, len(successful_vms)
+        phase_start = timing.now()
+
+        outcomes = run_parallel(restart_vm, successful_vms, concurrency=args.concurrency,
+                                qps=args.qps, burst=args.burst, args=(namespace, logger),
+                                logger=logger, description='VM restart')
+        for vm_name, restarted, error in outcomes:
+            if error is not None:
+                logger.error(f"Phase 4 FAILED for {vm_name}: {error}")
+                return False, False, len(successful_vms)
+            if not restarted:
+                logger.error(f"Phase 4 FAILED: Failed to restart VM {vm_name}")
+                return False, False, len(successful_vms)
 
         # Wait for VMs to be running again
         logger.info("Waiting for VMs to be running after restart...")
@@ -615,7 +758,7 @@ def run_iteration(iteration: int, namespace: str, storage_class: str, args, logg
             logger.error(f"Phase 4 FAILED: {len(failed_vms)} VMs failed to restart")
             return False, False, len(successful_vms)
 
-        phase_duration = time.time() - phase_start
+        phase_duration = (timing.now() - phase_start).total_seconds()
         phases_executed.append('Restart VMs')
         logger.info(f"{Colors.OKGREEN}Phase 4 COMPLETE: All VMs restarted (took {phase_duration:.2f}s){Colors.ENDC}")
     else:
@@ -624,33 +767,23 @@ def run_iteration(iteration: int, namespace: str, storage_class: str, args, logg
     # Phase 5: Snapshot VMs (concurrent)
     if not args.skip_snapshot:
         logger.info(f"\n{Colors.HEADER}Phase 5: Creating VM Snapshots (concurrency: {args.concurrency}){Colors.ENDC}")
-        phase_start = time.time()
+        phase_start = timing.now()
         snapshots_created = []
 
-        def create_snapshot_for_vm(vm_name):
-            snapshot_name = f"{vm_name}-snapshot"
-            if not create_vm_snapshot(vm_name, snapshot_name, namespace, logger):
-                return False, None, f"Failed to create snapshot for VM {vm_name}"
-            if not wait_for_snapshot_ready(snapshot_name, namespace, logger=logger):
-                return False, snapshot_name, f"Snapshot {snapshot_name} did not become ready"
-            return True, snapshot_name, None
-
-        with ThreadPoolExecutor(max_workers=args.concurrency) as executor:
-            futures = {executor.submit(create_snapshot_for_vm, vm): vm for vm in successful_vms}
-            for future in as_completed(futures):
-                vm_name = futures[future]
-                try:
-                    success, snapshot_name, error = future.result()
-                    if snapshot_name:
-                        snapshots_created.append(snapshot_name)
-                    if not success:
-                        logger.error(f"Phase 5 FAILED for {vm_name}: {error}")
-                        return False, False, len(successful_vms)
-                except Exception as e:
-                    logger.error(f"Phase 5 FAILED for {vm_name}: {e}")
-                    return False, False, len(successful_vms)
-
-        phase_duration = time.time() - phase_start
+        outcomes = run_parallel(create_snapshot_for_vm, successful_vms, concurrency=args.concurrency,
+                                qps=args.qps, burst=args.burst, args=(namespace, disk_metrics, logger),
+                                logger=logger, description='VM snapshot')
+        for vm_name, result, error in outcomes:
+            if error is None:
+                success, snapshot_name, error = result
+                if snapshot_name:
+                    snapshots_created.append(snapshot_name)
+                if success:
+                    continue
+            logger.error(f"Phase 5 FAILED for {vm_name}: {error}")
+            return False, False, len(successful_vms)
+
+        phase_duration = (timing.now() - phase_start).total_seconds()
         phases_executed.append('Create Snapshots')
         logger.info(f"{Colors.OKGREEN}Phase 5 COMPLETE: {len(snapshots_created)} snapshots created (took {phase_duration:.2f}s){Colors.ENDC}")
     else:
@@ -660,6 +793,155 @@ def run_iteration(iteration: int, namespace: str, storage_class: str, args, logg
     return True, False, len(successful_vms)
 
 
+def for_each_vm(vm_names: List[str], args, func, running: List[str], description: str) -> List[str]:
+    """
+    Call func(vm_name) for the VMs concurrently (--concurrency, --qps, --burst); func returns (success, items, error).
+
+    Returns:
+        All items, flattened
+    Raises:
+        PhaseFailed: on the first failing VM, with running as the VMs still running
+    """
+    items = []
+    outcomes = run_parallel(func, vm_names, concurrency=args.concurrency, qps=args.qps, burst=args.burst,
+                            description=description)
+    for vm_name, result, exception in outcomes:
+        if exception is not None:
+            raise PhaseFailed(f"{vm_name}: {exception}", running)
+        success, vm_items, error = result
+        if isinstance(vm_items, list):
+            items.extend(vm_items)
+        elif vm_items:
+            items.append(vm_items)
+        if not success:
+            raise PhaseFailed(f"{vm_name}: {error}", running)
+    return items
+
+
+def iteration_handlers(iteration: int, namespace: str, storage_class: str, args, logger,
+                       disk_metrics: DiskClassMetrics, vm_nodes: Dict[str, Optional[str]]) -> Dict:
+    """Phase handlers of one iteration for the utils.capacity engine."""
+    data_storage_class = args.data_storage_class or storage_class
+    vm_names = list(vm_nodes) if vm_nodes else iteration_vm_names(args, iteration)
+
+    def wait_running(vms: List[str]):
+        logger.info(f"Waiting for {len(vms)} VMs to reach Running state "
+                    f"(scheduling timeout: {args.scheduling_timeout}s)...")
+        return wait_for_vms_running_concurrent(vms, namespace, logger, args.vm_timeout,
+                                               args.scheduling_timeout, args.concurrency)
+
+    def create(_vms):
+        def create_one(vm_name):
+            created = create_vm_with_data_volumes(
+                vm_name, namespace, args.vm_yaml, storage_class, args.data_volume_count, args.min_vol_size,
+                args, logger, args.max_create_retries, data_storage_class, vm_nodes.get(vm_name))
+            return created, vm_name if created else None, f"Failed to create VM {vm_name}"
+
+        created_vms = for_each_vm(vm_names, args, create_one, [], 'VM creation')
+        successful_vms, failed_vms, failure_reason = wait_running(created_vms)
+        if failed_vms:
+            if failure_reason in ('scheduling', 'capacity'):
+                raise CapacityReached(f"{len(failed_vms)} VMs could not be scheduled", successful_vms)
+            exhausted = namespace_quota_exhausted(namespace, logger)
+            if exhausted:
+                raise CapacityReached(f"{len(failed_vms)} VMs could not start, namespace quota exhausted "
+                                      f"({', '.join(exhausted)})", successful_vms)
+            raise PhaseFailed(f"{len(failed_vms)} VMs failed to start (reason: {failure_reason})", successful_vms)
+        return successful_vms, len(successful_vms)
+
+    def resize(vms):
+        resized = for_each_vm(vms, args, lambda vm: resize_vm_volumes(
+            vm, namespace, args.min_vol_inc_size, disk_metrics, logger), vms, 'volume resize')
+        return vms, len(resized)
+
+    def clone(vms):
+        clones = for_each_vm(vms, args, lambda vm: clone_vm_volumes(
+            vm, namespace, storage_class, disk_metrics, logger), vms, 'volume clone')
+        return vms, len(clones)
+
+    def restart(vms):
+        for_each_vm(vms, args, lambda vm: (
+            restart_vm(vm, namespace, logger), None, f"Failed to restart VM {vm}"), vms, 'VM restart')
+        successful_vms, failed_vms, _ = wait_running(vms)
+        if failed_vms:
+            raise PhaseFailed(f"{len(failed_vms)} VMs failed to restart", successful_vms)
+        return successful_vms, len(successful_vms)
+
+    def snapshot(vms):
+        snapshots = for_each_vm(vms, args, lambda vm: create_snapshot_for_vm(
+            vm, namespace, disk_metrics, logger), vms, 'VM snapshot')
+        return vms, len(snapshots)
+
+    return {'create': create, 'resize': resize, 'clone': clone, 'restart': restart, 'snapshot': snapshot}
+
+
+def run_capacity_iteration(iteration: int, namespace: str, storage_class: str, args, logger,
+                           disk_metrics: DiskClassMetrics, vm_nodes: Dict[str, Optional[str]],
+                           warmup: bool = False) -> CapacityIteration:
+    """
+    Run one iteration through the utils.capacity state machine.
+
+    Returns:
+        The finished CapacityIteration: its state (completed, failed or capacity),
+        the VMs left running and a PhaseResult per phase
+    """
+    log_iteration_header(iteration, storage_class, args, logger)
+    handlers = iteration_handlers(iteration, namespace, storage_class, args, logger, disk_metrics, vm_nodes)
+    result = CapacityIteration(iteration, handlers, skip=skipped_phases(args), logger=logger, warmup=warmup).run()
+    if result.succeeded:
+        logger.info(f"\n{Colors.OKGREEN}{Colors.BOLD}ITERATION {iteration} COMPLETE{Colors.ENDC}")
+    elif result.capacity_reached:
+        logger.warning(f"{Colors.WARNING}CAPACITY REACHED in iteration {iteration}: "
+                       f"{len(result.vms)} VMs running{Colors.ENDC}")
+    return result
+
+
+def plan_run(args, storage_classes: List[str], logger):
+    """Print what main() would create, resize, clone, restart and snapshot (virtbench --dry-run)."""
+    import yaml as pyyaml
+
+    plan = DryRunPlan('chaos-benchmark', logger)
+    namespace = args.namespace
+    plan.create_namespaces([namespace])
+
+    iterations = args.max_iterations + args.warmup_iterations if args.max_iterations > 0 else 1
+    if args.max_iterations <= 0:
+        logger.info("[DRY RUN] Iterations repeat until the cluster runs out of capacity; "
+                    "the plan shows the first one")
+    placement = get_strategy(args.placement, args.placement_nodes, args.max_vms_per_node, logger,
+                             zones=args.zone, topology_key=args.topology_key)
+    nodes = placement_candidates(args, logger)
+    placed = Counter()
+    for iteration in range(1, iterations + 1):
+        storage_class = storage_classes[(iteration - 1) % len(storage_classes)]
+        vm_volumes = {}
+        for vm_name, node in place_iteration(placement, args, iteration, nodes, placed).items():
+            vm_template = build_vm_with_data_volumes(vm_name, namespace, args.vm_yaml, storage_class,
+                                                     args.data_volume_count, args.min_vol_size, args,
+                                                     args.data_storage_class, node)
+            plan.apply(pyyaml.dump(vm_template), namespace, detail=f"iteration {iteration}")
+            vm_volumes[vm_name] = [dvt['metadata']['name'] for dvt in vm_template['spec']['dataVolumeTemplates']]
+
+        if not args.skip_resize:
+            for pvcs in vm_volumes.values():
+                for pvc in pvcs:
+                    plan.action('patch', f"pvc/{namespace}/{pvc}", f"expand by {args.min_vol_inc_size}")
+        if not args.skip_clone:
+            for pvcs in vm_volumes.values():
+                for pvc in pvcs:
+                    plan.action('create', f"pvc/{namespace}/{pvc}-clone", f"clone of {pvc}")
+        if not args.skip_restart:
+            for vm_name in vm_volumes:
+                plan.action('restart', f"vm/{namespace}/{vm_name}")
+        if not args.skip_snapshot:
+            for vm_name in vm_volumes:
+                plan.apply(vm_snapshot_manifest(vm_name, f"{vm_name}-snapshot", namespace), namespace)
+
+    if args.cleanup:
+        plan.delete_namespaces([namespace])
+    plan.report()
+
+
 def cleanup_namespace(namespace: str, logger) -> bool:
     """Cleanup test namespace and all resources."""
     try:
@@ -690,6 +972,8 @@ def print_test_summary(results: dict, phases_executed: List[str], logger):
 
     logger.info(f"\n{Colors.HEADER}Test Configuration:{Colors.ENDC}")
     logger.info(f"  Storage Class(es):     {results.get('storage_classes', 'N/A')}")
+    if results.get('data_storage_class'):
+        logger.info(f"  Data Storage Class:    {results['data_storage_class']}")
     logger.info(f"  VMs per iteration:     {results.get('vms_per_iteration', 'N/A')}")
     logger.info(f"  Data volumes per VM:   {results.get('data_volumes_per_vm', 'N/A')}")
     logger.info(f"  Volume size:           {results.get('volume_size', 'N/A')}")
@@ -699,6 +983,10 @@ def print_test_summary(results: dict, phases_executed: List[str], logger):
 
     logger.info(f"\n{Colors.HEADER}Test Results:{Colors.ENDC}")
     logger.info(f"  Iterations completed:  {results.get('iterations_completed', 0)}")
+    warmup = results.get('warmup')
+    if warmup:
+        state = 'steady state' if warmup['steady_state'] else 'no steady state'
+        logger.info(f"  Warm-up iterations:    {warmup['iterations']} ({state}, cv={warmup['cv']}; not measured)")
     logger.info(f"  Total VMs created:     {results.get('total_vms', 0)}")
     logger.info(f"  Total PVCs created:    {results.get('total_pvcs', 0)}")
     logger.info(f"  Test duration:         {results.get('duration_str', 'N/A')}")
@@ -727,6 +1015,24 @@ def print_test_summary(results: dict, phases_executed: List[str], logger):
     else:
         logger.info(f"\n{Colors.WARNING}No phases completed successfully{Colors.ENDC}")
 
+    phases = results.get('phases') or {}
+    if phases:
+        logger.info(f"\n{Colors.HEADER}Per Phase Results (completed / skipped / failed, avg / max time):{Colors.ENDC}")
+        for name, entry in phases.items():
+            timing = entry['duration_sec']
+            times = f"{timing['avg']:.2f}s / {timing['max']:.2f}s" if timing['count'] else "-"
+            logger.info(f"  {PHASE_TITLES[name]:<18} {entry['completed']} / {entry['skipped']} / "
+                        f"{entry['failed']}  {times}")
+
+    per_class = results.get('per_storage_class') or {}
+    if per_class:
+        logger.info(f"\n{Colors.HEADER}Per Storage Class Timings (avg / min / max, count):{Colors.ENDC}")
+        for sc, operations in sorted(per_class.items()):
+            logger.info(f"  {sc}:")
+            for op, stats in sorted(operations.items()):
+                logger.info(f"    {op:<10} {stats['avg']:.2f}s / {stats['min']:.2f}s / "
+                            f"{stats['max']:.2f}s  ({stats['count']})")
+
     logger.info("\n" + "=" * 100)
 
 
@@ -734,18 +1040,38 @@ def print_test_summary(results: dict, phases_executed: List[str], logger):
 def main():
     """Main function."""
     args = parse_args()
+    set_run_workload('chaos-benchmark')
+    if args.storage_class:
+        args.storage_driver = resolve_storage_driver(args.storage_driver,
+                                                     get_storage_classes(args.storage_class)[0])
     logger = setup_logging(args.log_file, args.log_level)
+    dry_run = is_dry_run()
+    args.datasource_namespace = resolve_datasource_namespace(args.datasource_name, args.datasource_namespace,
+                                                             logger)
 
     # Handle cleanup-only mode
     if args.cleanup_only:
         logger.info("Running in cleanup-only mode")
-        cleanup_namespace(args.namespace, logger)
+        if labeled_namespaces([args.namespace], logger=logger):
+            if dry_run:
+                plan = DryRunPlan('chaos-benchmark', logger)
+                plan.action('delete', f"namespace/{args.namespace}")
+                plan.report()
+            else:
+                cleanup_namespace(args.namespace, logger)
+        else:
+            logger.info(f"No namespace {args.namespace} created by virtbench to clean up")
         return
 
     # Parse storage classes
     storage_classes = get_storage_classes(args.storage_class)
     logger.info(f"Starting Chaos Benchmark with storage classes: {storage_classes}")
     logger.info(f"Concurrency: {args.concurrency}")
+    if dry_run:
+        plan_run(args, storage_classes, logger)
+        return
+    if args.save_results:
+        cluster_inventory(logger)
 
     # Create namespace
     if not namespace_exists(args.namespace, logger):
@@ -754,20 +1080,31 @@ def main():
             sys.exit(1)
 
     # Initialize tracking
-    start_time = time.time()
+    start_time = timing.now()
     total_vms = 0
     iterations_completed = 0
     capacity_reached = False
     end_reason = 'unknown'
     phases_executed = []  # Track ACTUALLY executed phases
+    iteration_results = []  # CapacityIteration.to_dict() per iteration (not with --legacy)
+    disk_metrics = DiskClassMetrics()
+    # Warm-up iterations (image pulls, cold caches) are timed separately and not reported
+    warmup_metrics = DiskClassMetrics()
+    warmup_durations = []
+    warmup_done, warming, steady, cv = 0, args.warmup_iterations > 0, False, None
+    placement = get_strategy(args.placement, args.placement_nodes, args.max_vms_per_node, logger,
+                             zones=args.zone, topology_key=args.topology_key)
+    nodes = placement_candidates(args, logger)
+    placed = Counter()
+    vm_nodes_all = {}
 
     try:
         iteration = 0
         while True:
             iteration += 1
 
-            # Check max iterations
-            if args.max_iterations > 0 and iteration > args.max_iterations:
+            # Check max iterations (warm-up iterations are not counted)
+            if not warming and args.max_iterations > 0 and iteration - warmup_done > args.max_iterations:
                 logger.info(f"Reached maximum iterations ({args.max_iterations})")
                 end_reason = 'max_iterations'
                 break
@@ -776,9 +1113,27 @@ def main():
             storage_class = storage_classes[(iteration - 1) % len(storage_classes)]
 
             # Run iteration
-            success, cap_reached, vms_created = run_iteration(
-                iteration, args.namespace, storage_class, args, logger, phases_executed
-            )
+            if warming:
+                logger.info(f"Warm-up iteration {warmup_done + 1}/{args.warmup_iterations} (not measured)")
+            vm_nodes = place_iteration(placement, args, iteration, nodes, placed)
+            vm_nodes_all.update(vm_nodes)
+            metrics = warmup_metrics if warming else disk_metrics
+            if args.legacy:
+                phase_durations = {}
+                success, cap_reached, vms_created = run_iteration(
+                    iteration, args.namespace, storage_class, args, logger, phases_executed,
+                    metrics, phase_durations, vm_nodes
+                )
+                create_sec = phase_durations.get('Create VMs')
+            else:
+                result = run_capacity_iteration(iteration, args.namespace, storage_class, args, logger,
+                                                metrics, vm_nodes, warmup=warming)
+                iteration_results.append(result.to_dict())
+                for phase in result.completed_phases():
+                    if phase.title not in phases_executed:
+                        phases_executed.append(phase.title)
+                success, cap_reached, vms_created = result.succeeded, result.capacity_reached, len(result.vms)
+                create_sec = result.phases['create'].duration_sec
 
             if cap_reached:
                 capacity_reached = True
@@ -791,7 +1146,20 @@ def main():
                 break
 
             total_vms += vms_created
-            iterations_completed += 1
+            if not warming:
+                iterations_completed += 1
+                continue
+
+            warmup_done += 1
+            warmup_durations.append(create_sec)
+            steady, cv = steady_state(warmup_durations, args.steady_state_cv)
+            if steady:
+                logger.info(f"Steady state reached after {warmup_done} warm-up iterations (cv={cv})")
+                warming = False
+            elif warmup_done >= args.warmup_iterations:
+                logger.warning(f"Steady state not reached after {warmup_done} warm-up iterations "
+                               f"(cv={cv}, threshold {args.steady_state_cv})")
+                warming = False
 
     except KeyboardInterrupt:
         logger.info("\nTest interrupted by user")
@@ -801,12 +1169,13 @@ def main():
         end_reason = 'error'
 
     # Calculate duration
-    duration = time.time() - start_time
+    duration = (timing.now() - start_time).total_seconds()
     duration_str = f"{duration:.2f}s ({duration/60:.2f} minutes)"
 
     # Build results
     results = {
         'storage_classes': ', '.join(storage_classes),
+        'data_storage_class': args.data_storage_class,
         'vms_per_iteration': args.vms,
         'data_volumes_per_vm': args.data_volume_count,
         'volume_size': args.min_vol_size,
@@ -819,7 +1188,24 @@ def main():
         'duration_str': duration_str,
         'capacity_reached': capacity_reached,
         'end_reason': end_reason,
+        'phases_skipped': [PHASE_TITLES[name] for name in skipped_phases(args)],
+        'per_storage_class': disk_metrics.summary(),
+        'placement': placement.describe(vm_nodes_all),
+        'namespace': args.namespace,
     }
+    if args.warmup_iterations:
+        results['warmup'] = {
+            'iterations': warmup_done,
+            'max_iterations': args.warmup_iterations,
+            'create_phase_sec': [round(d, 3) for d in warmup_durations],
+            'steady_state': steady,
+            'cv': cv,
+            'cv_threshold': args.steady_state_cv,
+            'window': STEADY_STATE_WINDOW,
+        }
+    if not args.legacy:
+        results['iterations'] = iteration_results
+        results['phases'] = phase_summary(iteration_results)
 
     # Print summary with ONLY actually executed phases
     print_test_summary(results, phases_executed, logger)
@@ -29,6 +29,13 @@ LABEL_MAP = {
     "clone_duration_sec": "Clone Duration",
     "observed_time_sec": "Observed Time",
     "vmim_time_sec": "VMIM Time",
+    "transfer_mib_s": "Transfer Throughput (MiB/s)",
+    "estimated_mib_s": "Estimated Throughput (MiB/s)",
+    "transfer_ratio": "Transferred / Memory",
+    "downtime_ms": "Downtime (ms)",
+    "data_transferred_bytes": "Data Transferred (bytes)",
+    "memory_dirty_rate_mib_s": "Memory Dirty Rate (MiB/s)",
+    "iterations": "Memory Iterations",
 }
 
 # FIO metric labels for display
@@ -165,10 +172,14 @@ def summary_to_df(summary_json):
             "Average (s)": round(m.get("avg", 0), 2) if m.get("avg") is not None else None,
             "Max (s)": round(m.get("max", 0), 2) if m.get("max") is not None else None,
             "Min (s)": round(m.get("min", 0), 2) if m.get("min") is not None else None,
+            "P95 (s)": round(m["p95"], 2) if m.get("p95") is not None else None,
+            "P99 (s)": round(m["p99"], 2) if m.get("p99") is not None else None,
+            "Stddev (s)": round(m["stddev"], 2) if m.get("stddev") is not None else None,
             "Count": m.get("count", ""),
         })
 
-    df = pd.DataFrame(rows)
+    # Summaries saved before percentiles were recorded have no P95/P99/Stddev
+    df = pd.DataFrame(rows).dropna(axis=1, how="all")
     total_info = {
         "total_vms": summary_json.get("total_vms"),
         "successful": summary_json.get("successful"),
@@ -193,6 +204,10 @@ def df_to_html_table(df: pd.DataFrame, table_id: str) -> str:
         "clone_duration_sec": "Clone Duration (s)",
         "observed_time_sec": "Observed Time (s)",
         "vmim_time_sec": "VMIM Time (s)",
+        "transfer_mib_s": "Transfer (MiB/s)",
+        "estimated_mib_s": "Estimated (MiB/s)",
+        "downtime_ms": "Downtime (ms)",
+        "iterations": "Iterations",
         "success": "Success",
         "status": "Status",
         "source_node": "Source Node",
@@ -0,0 +1,364 @@
+#!/usr/bin/env python3
+"""
+Serve the results catalog as a small web app.
+
+Every directory under --results-dir that contains a summary_*.json file is a
+run. The app lists the runs, drills down into one run (summary metrics and
+per-VM charts) and compares the summary metrics of several runs side by side.
+The catalog is rescanned on every request, so runs copied into the results
+directory show up without a restart.
+
+Only the standard library is used so the script can run from a ConfigMap in
+a stock python image (see serve-results.yaml).
+
+Endpoints:
+  /                       web app
+  /api/runs               run list
+  /api/runs/<run id>      summaries and per-VM results of one run
+  /api/compare?run=<id>&run=<id>...
+                          summary metrics of several runs, aligned by metric
+  /files/<run id>/<file>  raw result file
+  /healthz                liveness/readiness probe
+
+Usage:
+  python3 serve_results.py [--results-dir PATH] [--host HOST] [--port PORT]
+"""
+
+import argparse
+import json
+import mimetypes
+import re
+from datetime import datetime
+from http.server import BaseHTTPRequestHandler, ThreadingHTTPServer
+from pathlib import Path
+from urllib.parse import parse_qs, unquote, urlparse
+
+DEFAULT_PORT = 8080
+
+# summary file stem -> workload shown in the run list
+WORKLOAD_MAP = {
+    "summary_vm_creation_results": "datasource-clone",
+    "summary_boot_storm_results": "boot-storm",
+    "summary_migration_results": "migration",
+    "summary_failure_recovery_results": "failure-recovery",
+    "summary_volume_hotplug_results": "volume-hotplug",
+    "summary_volume_resize_results": "volume-resize",
+    "summary_service_exposure_results": "service-exposure",
+    "summary_vm_clone_results": "vm-clone",
+    "summary_vm_lifecycle_results": "vm-lifecycle",
+    "summary_node_drain_results": "node-drain",
+    "summary_descheduler_results": "descheduler",
+    "summary_soak_results": "soak",
+    "summary_random_workload_results": "random-workload",
+}
+
+TIMESTAMP_RE = re.compile(r"^(\d{8}-\d{6})_")
+
+
+def load_json(path: Path):
+    """Safely load JSON file; return None if not found or invalid."""
+    try:
+        with open(path, "r") as f:
+            return json.load(f)
+    except Exception:
+        return None
+
+
+def scan_runs(base_dir: Path) -> list:
+    """Find every run directory (one holding summary_*.json) below base_dir, newest first."""
+    runs = {}
+    for summary in base_dir.rglob("summary_*.json"):
+        run_dir = summary.parent
+        run_id = run_dir.relative_to(base_dir).as_posix()
+        run = runs.setdefault(run_id, {
+            "id": run_id,
+            "name": run_dir.name,
+            # Leading path segments are labels such as the storage driver and disk layout
+            "labels": list(run_dir.relative_to(base_dir).parts[:-1]),
+            "timestamp": None,
+            "workloads": [],
+            "total_vms": None,
+            "successful": None,
+            "failed": None,
+        })
+        match = TIMESTAMP_RE.match(run_dir.name)
+        if match:
+            run["timestamp"] = datetime.strptime(match.group(1), "%Y%m%d-%H%M%S").isoformat()
+
+        data = load_json(summary)
+        run["workloads"].append(WORKLOAD_MAP.get(summary.stem, summary.stem.replace("summary_", "")))
+        if isinstance(data, dict):
+            for key in ("total_vms", "successful", "failed"):
+                if run[key] is None and key in data:
+                    run[key] = data[key]
+            if run["total_vms"] is None and "vms" in data:
+                run["total_vms"] = data["vms"]
+
+    for run in runs.values():
+        run["workloads"].sort()
+    return sorted(runs.values(), key=lambda r: (r["timestamp"] or "", r["id"]), reverse=True)
+
+
+def resolve_run(base_dir: Path, run_id: str):
+    """Map a run id to its directory, refusing paths outside base_dir."""
+    path = (base_dir / run_id).resolve()
+    if base_dir.resolve() not in path.parents and path != base_dir.resolve():
+        return None
+    return path if path.is_dir() else None
+
+
+def load_run(base_dir: Path, run_id: str):
+    """Load the summaries and per-VM result files of one run."""
+    run_dir = resolve_run(base_dir, run_id)
+    if run_dir is None:
+        return None
+    summaries, details = {}, {}
+    for path in sorted(run_dir.glob("*.json")):
+        data = load_json(path)
+        if data is None:
+            continue
+        if path.stem.startswith("summary_"):
+            summaries[WORKLOAD_MAP.get(path.stem, path.stem)] = data
+        elif isinstance(data, list):
+            details[path.stem] = data
+    return {
+        "id": run_id,
+        "summaries": summaries,
+        "details": details,
+        "files": sorted(p.name for p in run_dir.iterdir() if p.is_file()),
+    }
+
+
+def compare_runs(base_dir: Path, run_ids: list) -> dict:
+    """Align the summary metrics of several runs by workload and metric name."""
+    table = {}
+    for run_id in run_ids:
+        run = load_run(base_dir, run_id)
+        if run is None:
+            continue
+        for workload, summary in run["summaries"].items():
+            if not isinstance(summary, dict):
+                continue
+            for metric in summary.get("metrics", []):
+                if "metric" not in metric:
+                    continue
+                key = f"{workload}/{metric['metric']}"
+                table.setdefault(key, {})[run_id] = {
+                    k: metric.get(k) for k in ("avg", "min", "max", "median", "p95", "p99", "stddev", "count")
+                }
+    return {"runs": run_ids, "metrics": table}
+
+
+class ResultsHandler(BaseHTTPRequestHandler):
+    base_dir = Path("results")
+
+    def log_message(self, fmt, *args):
+        pass
+
+    def _send(self, status: int, body: bytes, content_type: str):
+        self.send_response(status)
+        self.send_header("Content-Type", content_type)
+        self.send_header("Content-Length", str(len(body)))
+        self.end_headers()
+        self.wfile.write(body)
+
+    def _send_json(self, data, status: int = 200):
+        self._send(status, json.dumps(data, indent=2).encode(), "application/json")
+
+    def do_GET(self):
+        url = urlparse(self.path)
+        path = unquote(url.path)
+
+        if path == "/healthz":
+            self._send(200, b"ok", "text/plain")
+        elif path in ("/", "/index.html"):
+            self._send(200, INDEX_HTML.encode(), "text/html; charset=utf-8")
+        elif path == "/api/runs":
+            self._send_json(scan_runs(self.base_dir))
+        elif path.startswith("/api/runs/"):
+            run = load_run(self.base_dir, path[len("/api/runs/"):])
+            if run is None:
+                self._send_json({"error": "run not found"}, 404)
+            else:
+                self._send_json(run)
+        elif path == "/api/compare":
+            self._send_json(compare_runs(self.base_dir, parse_qs(url.query).get("run", [])))
+        elif path.startswith("/files/"):
+            run_id, _, name = path[len("/files/"):].rpartition("/")
+            run_dir = resolve_run(self.base_dir, run_id)
+            file_path = run_dir / name if run_dir and name else None
+            if file_path is None or not file_path.is_file():
+                self._send_json({"error": "file not found"}, 404)
+                return
+            content_type = mimetypes.guess_type(name)[0] or "text/plain"
+            self._send(200, file_path.read_bytes(), content_type)
+        else:
+            self._send_json({"error": "not found"}, 404)
+
+
+INDEX_HTML = """<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<title>KubeVirt Benchmark Results</title>
+<link href="https://cdn.jsdelivr.net/npm/bootstrap@5.3.2/dist/css/bootstrap.min.css" rel="stylesheet">
+<script src="https://cdn.plot.ly/plotly-2.27.0.min.js"></script>
+<style>
+body { margin: 20px; }
+tr.run { cursor: pointer; }
+.plotly-chart { height: 360px; width: 100%; }
+</style>
+</head>
+<body>
+<h1 class="mb-3">KubeVirt Benchmark Results</h1>
+<ul class="nav nav-tabs mb-3">
+  <li class="nav-item"><a class="nav-link active" href="#" data-view="runs">Runs</a></li>
+  <li class="nav-item"><a class="nav-link" href="#" data-view="run">Run Details</a></li>
+  <li class="nav-item"><a class="nav-link" href="#" data-view="compare">Compare</a></li>
+</ul>
+
+<div id="view-runs">
+  <div class="d-flex gap-2 mb-2">
+    <input id="filter" class="form-control" placeholder="Filter by name, label or workload">
+    <button id="compare-btn" class="btn btn-primary text-nowrap">Compare selected</button>
+  </div>
+  <table class="table table-sm table-hover">
+    <thead><tr><th></th><th>Timestamp</th><th>Run</th><th>Labels</th><th>Workloads</th>
+      <th>VMs</th><th>Successful</th><th>Failed</th></tr></thead>
+    <tbody id="runs"></tbody>
+  </table>
+</div>
+
+<div id="view-run" class="d-none">
+  <h3 id="run-title">Select a run</h3>
+  <div id="run-files" class="mb-3"></div>
+  <div id="run-body"></div>
+</div>
+
+<div id="view-compare" class="d-none">
+  <p class="text-muted">Select two or more runs on the Runs tab and click "Compare selected".</p>
+  <div id="compare-chart" class="plotly-chart"></div>
+  <div id="compare-table"></div>
+</div>
+
+<script>
+const esc = s => String(s ?? '').replace(/[&<>"]/g, c => ({'&':'&amp;','<':'&lt;','>':'&gt;','"':'&quot;'}[c]));
+const fmt = v => v === null || v === undefined ? '-' : (typeof v === 'number' ? +v.toFixed(2) : esc(v));
+let runs = [];
+
+function show(view) {
+  document.querySelectorAll('[data-view]').forEach(a => a.classList.toggle('active', a.dataset.view === view));
+  ['runs', 'run', 'compare'].forEach(v => document.getElementById('view-' + v).classList.toggle('d-none', v !== view));
+}
+document.querySelectorAll('[data-view]').forEach(a => a.onclick = e => { e.preventDefault(); show(a.dataset.view); });
+
+function renderRuns() {
+  const q = document.getElementById('filter').value.toLowerCase();
+  document.getElementById('runs').innerHTML = runs
+    .filter(r => !q || [r.id, r.workloads.join(' ')].join(' ').toLowerCase().includes(q))
+    .map(r => `<tr class="run" data-id="${esc(r.id)}">
+      <td><input type="checkbox" class="pick" value="${esc(r.id)}"></td>
+      <td>${esc((r.timestamp || '').replace('T', ' '))}</td><td>${esc(r.name)}</td>
+      <td>${r.labels.map(l => `<span class="badge bg-secondary me-1">${esc(l)}</span>`).join('')}</td>
+      <td>${esc(r.workloads.join(', '))}</td>
+      <td>${fmt(r.total_vms)}</td><td>${fmt(r.successful)}</td><td>${fmt(r.failed)}</td></tr>`)
+    .join('');
+  document.querySelectorAll('tr.run').forEach(tr => tr.onclick = e => {
+    if (e.target.classList.contains('pick')) return;
+    openRun(tr.dataset.id);
+  });
+}
+
+function metricsTable(metrics) {
+  return `<table class="table table-sm"><thead><tr><th>Metric</th><th>Avg</th><th>Min</th><th>Max</th><th>Median</th>
+    <th>P95</th><th>P99</th><th>Stddev</th><th>Count</th></tr></thead>
+    <tbody>${metrics.filter(m => m.metric).map(m => `<tr><td>${esc(m.metric)}</td><td>${fmt(m.avg)}</td>
+    <td>${fmt(m.min)}</td><td>${fmt(m.max)}</td><td>${fmt(m.median)}</td><td>${fmt(m.p95)}</td><td>${fmt(m.p99)}</td>
+    <td>${fmt(m.stddev)}</td><td>${fmt(m.count)}</td></tr>`).join('')}</tbody></table>`;
+}
+
+function outliersTable(outliers) {
+  return `<h5>Outliers</h5><table class="table table-sm"><thead><tr><th>Metric</th><th>Item</th><th>Value</th>
+    <th>z-score</th></tr></thead><tbody>${outliers.map(o => `<tr class="table-warning"><td>${esc(o.metric)}</td>
+    <td>${esc(o.item)}</td><td>${fmt(o.value)}</td><td>${fmt(o.z_score)}</td></tr>`).join('')}</tbody></table>`;
+}
+
+async function openRun(id) {
+  show('run');
+  const run = await (await fetch('/api/runs/' + encodeURIComponent(id).replace(/%2F/g, '/'))).json();
+  document.getElementById('run-title').textContent = id;
+  document.getElementById('run-files').innerHTML = run.files
+    .map(f => `<a class="me-3" href="/files/${esc(id)}/${esc(f)}">${esc(f)}</a>`).join('');
+  let html = '', charts = [];
+  Object.entries(run.summaries).forEach(([workload, s], i) => {
+    html += `<h4 class="mt-4">${esc(workload)}</h4>`;
+    if (s && Array.isArray(s.metrics)) html += metricsTable(s.metrics);
+    if (s && Array.isArray(s.outliers) && s.outliers.length) html += outliersTable(s.outliers);
+    if (s && Array.isArray(s.custom_metrics)) {
+      html += '<h5>Custom metrics</h5>' + metricsTable(s.custom_metrics.map(m => ({...m, metric: m.name, count: m.samples})));
+    }
+  });
+  Object.entries(run.details).forEach(([name, rows], i) => {
+    const numeric = rows.length ? Object.keys(rows[0]).filter(k => k.endsWith('_sec') || k.endsWith('_seconds')) : [];
+    if (!numeric.length) return;
+    const id = 'detail-' + i;
+    html += `<h5 class="mt-4">${esc(name)}</h5><div id="${id}" class="plotly-chart"></div>`;
+    const label = r => r.namespace || r.vm || r.volume || '';
+    charts.push([id, numeric.map(k => ({type: 'bar', name: k, x: rows.map(label), y: rows.map(r => r[k])}))]);
+  });
+  document.getElementById('run-body').innerHTML = html || '<p>No results in this run.</p>';
+  charts.forEach(([id, traces]) => Plotly.newPlot(id, traces,
+    {barmode: 'group', margin: {t: 20}, yaxis: {title: 'Seconds'}}, {responsive: true, displayModeBar: false}));
+}
+
+async function compare() {
+  const ids = [...document.querySelectorAll('.pick:checked')].map(c => c.value);
+  if (ids.length < 2) { alert('Select at least two runs'); return; }
+  show('compare');
+  const data = await (await fetch('/api/compare?' + ids.map(i => 'run=' + encodeURIComponent(i)).join('&'))).json();
+  const metrics = Object.keys(data.metrics).sort();
+  Plotly.newPlot('compare-chart', ids.map(id => ({
+    type: 'bar', name: id, x: metrics, y: metrics.map(m => (data.metrics[m][id] || {}).avg ?? null)
+  })), {barmode: 'group', margin: {t: 20, b: 160}, yaxis: {title: 'Average'}}, {responsive: true, displayModeBar: false});
+  document.getElementById('compare-table').innerHTML = `<table class="table table-sm mt-3"><thead><tr><th>Metric</th>
+    ${ids.map(i => `<th>${esc(i)}</th>`).join('')}</tr></thead><tbody>${metrics.map(m => `<tr><td>${esc(m)}</td>
+    ${ids.map(i => `<td>${fmt((data.metrics[m][i] || {}).avg)}
+    <small class="text-muted">p95 ${fmt((data.metrics[m][i] || {}).p95)}</small></td>`).join('')}</tr>`).join('')}</tbody></table>`;
+}
+
+document.getElementById('filter').oninput = renderRuns;
+document.getElementById('compare-btn').onclick = compare;
+fetch('/api/runs').then(r => r.json()).then(data => { runs = data; renderRuns(); });
+</script>
+</body>
+</html>
+"""
+
+
+def main():
+    parser = argparse.ArgumentParser(description="Serve KubeVirt benchmark results as a web app.")
+    parser.add_argument("--results-dir", type=str, default="results")
+    parser.add_argument("--host", type=str, default="127.0.0.1")
+    parser.add_argument("--port", type=int, default=DEFAULT_PORT)
+    args = parser.parse_args()
+
+    base_dir = Path(args.results_dir)
+    if not base_dir.is_dir():
+        print(f"Results directory not found: {base_dir}")
+        return 1
+
+    ResultsHandler.base_dir = base_dir
+    server = ThreadingHTTPServer((args.host, args.port), ResultsHandler)
+    print(f"Serving {len(scan_runs(base_dir))} run(s) from {base_dir.absolute()} "
+          f"at http://{args.host}:{args.port}/")
+    try:
+        server.serve_forever()
+    except KeyboardInterrupt:
+        pass
+    finally:
+        server.server_close()
+    return 0
+
+
+if __name__ == "__main__":
+    raise SystemExit(main())
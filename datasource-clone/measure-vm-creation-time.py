@@ -20,27 +20,74 @@ import signal
 from datetime import datetime, timedelta
 import subprocess, json, time
 from concurrent.futures import ThreadPoolExecutor, as_completed
-from typing import Tuple, List, Optional
+from typing import Dict, Tuple, List, Optional
 
 # Add parent directory to path for imports
 sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
 
+from utils import timing
+from utils.concurrency import run_parallel, DEFAULT_QPS, DEFAULT_BURST
 from utils.common import (
-    setup_logging, run_kubectl_command, create_namespace, create_namespaces_parallel,
-    delete_namespace, get_vm_status, get_vmi_ip, ping_vm, print_summary_table,
+    setup_logging, create_or_adopt, create_namespace, create_namespaces_parallel,
+    delete_namespace, get_vm_status, get_vmi_ip, check_guest_ready, print_summary_table,
     validate_prerequisites, stop_vm, start_vm, wait_for_vm_stopped,
-    get_worker_nodes, select_random_node, add_node_selector_to_vm_yaml,
-    cleanup_test_namespaces, confirm_cleanup, print_cleanup_summary, save_results
+    get_worker_nodes, get_node_zones, select_random_node, add_node_selector_to_vm_yaml,
+    cleanup_test_namespaces, confirm_cleanup, print_cleanup_summary, save_results,
+    get_guest_agent_status, get_vm_placement, analyze_cold_start, print_cold_start_summary,
+    vm_targets, split_vm_target, call_for_target, scoped_resource_name, rename_template_vm,
+    run_kubectl_command, set_run_workload, run_selector, ANY_RUN_SELECTOR, ZONE_LABEL,
+    GUEST_OS_CHOICES, GUEST_OS_LINUX, GUEST_OS_WINDOWS, DEFAULT_NODE_EXEC_IMAGE
+)
+from utils.notify import (
+    notify_phase, notify_run, watch_run, phase_status, duration_metrics, percentile_metrics,
+)
+from utils.events import watch_events
+from utils.utilization import sample_nodes
+from utils.inventory import cluster_inventory, resolve_storage_driver
+from utils.estimate_capacity import estimate_capacity, print_estimate, NO_FIT
+from utils.dryrun import DryRunPlan, is_dry_run
+from utils.progress import track_phase, vm_state
+from utils.reachability import (
+    active_checker, start_checker, stop_checker, CHECKER_POD, REACHABILITY_CHECKER, REACHABILITY_MODES,
+    REACHABILITY_SSH_POD,
+)
+from utils.stats import describe, steady_state, DEFAULT_STEADY_STATE_CV, STEADY_STATE_WINDOW
+from utils.histogram import record_latency, take_latencies
+from utils.tenants import record_call
+from utils.admission import admission_enabled, record_admission, start_admission_metrics, time_admission
+from utils.prewarm import prewarm, plan_prewarm, print_prewarm
+from utils.gpu import add_gpus, check_gpus, print_gpu_report, template_gpus
+from utils.tuning import cpu_manager_nodes, tuning_settings, write_tuned_template, HUGEPAGE_SIZES
+from utils.instancetype import (
+    instancetype_settings, resolve, write_instancetype_template,
+    CLUSTER_INSTANCETYPE, INSTANCETYPE, CLUSTER_PREFERENCE, PREFERENCE
+)
+from utils.network import add_networks, check_networks, parse_network, print_network_report, DEFAULT_NAD_NAMESPACE
+from utils.faultinjector import (
+    FaultInjector, chaos_summary, print_chaos_summary,
+    CHAOS_MODES, DEFAULT_CHAOS_INTERVAL, DEFAULT_CHAOS_DURATION, DEFAULT_STORAGE_PODS, DEFAULT_STORAGE_PROCESS,
+)
+from utils.placement import (
+    get_strategy, placement_quality, print_placement_quality, PlacementStrategy,
+    STRATEGIES as PLACEMENT_STRATEGIES, STRATEGY_NONE,
 )
 
 # Default configuration
 DEFAULT_VM_YAML = '../examples/vm-templates/rhel9-vm-datasource.yaml'
 DEFAULT_VM_NAME = 'rhel-9-vm'
+DEFAULT_WINDOWS_VM_YAML = '../examples/vm-templates/windows-vm-datasource.yaml'
+DEFAULT_WINDOWS_VM_NAME = 'win2k22-vm'
 DEFAULT_SSH_POD = 'ssh-test-pod'
 DEFAULT_SSH_POD_NS = 'default'
+# Seconds past --ping-timeout to wait for the checker pod's answer
+CHECKER_GRACE = 30
 DEFAULT_POLL_INTERVAL = 1
 DEFAULT_CONCURRENCY = 50
 DEFAULT_PING_TIMEOUT = 600  # 10 minutes
+DEFAULT_WINDOWS_PING_TIMEOUT = 1800  # Windows first boot (OOBE/sysprep) is much slower
+DEFAULT_STOP_TIMEOUT = 300
+DEFAULT_GUEST_AGENT_TIMEOUT = 300
+DEFAULT_WINDOWS_STOP_TIMEOUT = 900  # ACPI shutdown of Windows guests can take minutes
 DEFAULT_NAMESPACE_PREFIX = 'kubevirt-perf-test'
 
 
@@ -83,14 +130,20 @@ Examples:
     parser.add_argument(
         '-n', '--vm-name',
         type=str,
-        default=DEFAULT_VM_NAME,
-        help=f'VM resource name (default: {DEFAULT_VM_NAME})'
+        default=None,
+        help=f'VM resource name (default: {DEFAULT_VM_NAME}, or {DEFAULT_WINDOWS_VM_NAME} for Windows)'
     )
     parser.add_argument(
         '--vm-template',
         type=str,
-        default=DEFAULT_VM_YAML,
-        help=f'Path to VM template YAML (default: {DEFAULT_VM_YAML})'
+        default=None,
+        help=f'Path to VM template YAML (default: {DEFAULT_VM_YAML}, or {DEFAULT_WINDOWS_VM_YAML} for Windows)'
+    )
+    parser.add_argument(
+        '--guest-os',
+        choices=GUEST_OS_CHOICES,
+        default=GUEST_OS_LINUX,
+        help='Guest OS of the VMs; Windows guests are checked via RDP/WinRM instead of ping (default: linux)'
     )
     parser.add_argument(
         '--secret-yaml',
@@ -104,13 +157,32 @@ Examples:
         default=DEFAULT_NAMESPACE_PREFIX,
         help=f'Prefix for test namespaces (default: {DEFAULT_NAMESPACE_PREFIX})'
     )
+    parser.add_argument(
+        '--single-namespace',
+        type=str,
+        default=None,
+        help='Create all VMs as <vm-name>-<index> in this existing namespace instead of one '
+             'namespace per VM (for users who cannot create namespaces)'
+    )
     
     # Performance tuning
     parser.add_argument(
         '-c', '--concurrency',
         type=int,
         default=DEFAULT_CONCURRENCY,
-        help=f'Max parallel threads for monitoring (default: {DEFAULT_CONCURRENCY})'
+        help=f'Max VM creations and monitoring threads in flight (default: {DEFAULT_CONCURRENCY})'
+    )
+    parser.add_argument(
+        '--qps',
+        type=float,
+        default=DEFAULT_QPS,
+        help='Max VM create/delete requests started per second, 0 disables rate limiting (default: 0)'
+    )
+    parser.add_argument(
+        '--burst',
+        type=int,
+        default=DEFAULT_BURST,
+        help=f'Max requests started back-to-back when --qps is set (default: {DEFAULT_BURST})'
     )
     parser.add_argument(
         '--poll-interval',
@@ -121,10 +193,25 @@ Examples:
     parser.add_argument(
         '--ping-timeout',
         type=int,
-        default=DEFAULT_PING_TIMEOUT,
-        help=f'Ping timeout in seconds (default: {DEFAULT_PING_TIMEOUT})'
+        default=None,
+        help=f'Guest readiness (ping or RDP/WinRM) timeout in seconds '
+             f'(default: {DEFAULT_PING_TIMEOUT}, or {DEFAULT_WINDOWS_PING_TIMEOUT} for Windows)'
     )
     
+    parser.add_argument(
+        '--guest-agent',
+        action='store_true',
+        help='Also measure time until the qemu-guest-agent connects and record guest OS info '
+             '(requires qemu-guest-agent in the image)'
+    )
+    parser.add_argument(
+        '--guest-agent-timeout',
+        type=int,
+        default=DEFAULT_GUEST_AGENT_TIMEOUT,
+        help=f'Seconds to keep waiting for the guest agent after the guest is reachable '
+             f'(default: {DEFAULT_GUEST_AGENT_TIMEOUT})'
+    )
+
     # SSH pod for ping tests
     parser.add_argument(
         '--ssh-pod',
@@ -138,6 +225,19 @@ Examples:
         default=DEFAULT_SSH_POD_NS,
         help=f'Namespace of SSH pod (default: {DEFAULT_SSH_POD_NS})'
     )
+    parser.add_argument(
+        '--reachability',
+        choices=REACHABILITY_MODES,
+        default=REACHABILITY_CHECKER,
+        help='How guest reachability is checked: "checker" streams concurrent ping/port checks from a '
+             'managed checker pod in the --ssh-pod-ns namespace, with millisecond time to first '
+             'response; "ssh-pod" polls through the existing --ssh-pod (default: checker)'
+    )
+    parser.add_argument(
+        '--checker-host-network',
+        action='store_true',
+        help='Run the reachability checker pod on the host network'
+    )
     
     # Logging
     parser.add_argument(
@@ -191,6 +291,79 @@ Examples:
         action='store_true',
         help='Skip VM creation phase (use with --boot-storm to test existing VMs)'
     )
+    parser.add_argument(
+        '--skip-capacity-check',
+        action='store_true',
+        help='Do not abort when the capacity preflight estimates the VMs cannot fit on the cluster'
+    )
+
+    # GPU passthrough / vGPU
+    parser.add_argument(
+        '--gpu-device',
+        default=None,
+        help='Give each VM GPUs of this device plugin resource, e.g. nvidia.com/GA102GL_A10 (passthrough) '
+             'or nvidia.com/NVIDIA_A10-12Q (vGPU); must be in the KubeVirt CR\'s permittedHostDevices'
+    )
+    parser.add_argument(
+        '--gpus-per-vm',
+        type=int,
+        default=1,
+        help='GPUs of --gpu-device per VM (default: 1)'
+    )
+    parser.add_argument(
+        '--skip-gpu-check',
+        action='store_true',
+        help='Do not abort when the GPU preflight finds unpermitted, unadvertised or too few devices'
+    )
+
+    # CPU pinning / hugepages / NUMA
+    parser.add_argument(
+        '--dedicated-cpus',
+        action='store_true',
+        help='Pin every vCPU to a dedicated host core (dedicatedCpuPlacement); needs nodes with the '
+             'static CPU manager policy'
+    )
+    parser.add_argument(
+        '--hugepages',
+        choices=HUGEPAGE_SIZES,
+        default=None,
+        help='Back guest memory with hugepages of this size'
+    )
+    parser.add_argument(
+        '--numa',
+        action='store_true',
+        help='Pass the host NUMA topology of the pinned CPUs through to the guest '
+             '(requires --dedicated-cpus and --hugepages)'
+    )
+
+    # Instancetype and preference
+    parser.add_argument(
+        '--instancetype',
+        type=str,
+        default=None,
+        help='Create VMs from this VirtualMachineClusterInstancetype (e.g. u1.medium) instead of the '
+             "template's CPU and memory"
+    )
+    parser.add_argument(
+        '--preference',
+        type=str,
+        default=None,
+        help='VirtualMachineClusterPreference of the VMs (e.g. rhel.9); requires --instancetype'
+    )
+
+    # Multus secondary networks
+    parser.add_argument(
+        '--network',
+        nargs='+',
+        default=[],
+        help='Attach each VM to these NetworkAttachmentDefinitions as [namespace/]name (bridge or SR-IOV); '
+             f'the namespace defaults to --single-namespace, or {DEFAULT_NAD_NAMESPACE}'
+    )
+    parser.add_argument(
+        '--skip-network-check',
+        action='store_true',
+        help='Do not abort when a network is missing or its SR-IOV VFs are too few'
+    )
     parser.add_argument(
         '--num-disks',
         type=int,
@@ -201,7 +374,69 @@ Examples:
         '--namespace-batch-size',
         type=int,
         default=20,
-        help='Number of namespaces to create in parallel (default: 20)'
+        help='Namespaces created per server-side apply batch (default: 20)'
+    )
+
+    # Warm-up
+    parser.add_argument(
+        '--prewarm',
+        action='store_true',
+        help='Before timing starts, pre-pull the virt-launcher and container disk images onto the nodes '
+             'and wait until the template\'s DataSources are Ready'
+    )
+    parser.add_argument(
+        '--warmup-iterations',
+        type=int,
+        default=0,
+        help='Create, boot and delete the VMs up to N times before the measured run, so image pulls and '
+             'DataSource population do not skew the results; stops early at steady state (default: 0)'
+    )
+    parser.add_argument(
+        '--steady-state-cv',
+        type=float,
+        default=DEFAULT_STEADY_STATE_CV,
+        help='Coefficient of variation of the last warm-up iterations\' mean time to Running at which the '
+             f'cluster is in steady state (default: {DEFAULT_STEADY_STATE_CV})'
+    )
+
+    # Placement
+    parser.add_argument(
+        '--placement',
+        choices=list(PLACEMENT_STRATEGIES),
+        default=STRATEGY_NONE,
+        help='How VMs are placed on nodes: none (scheduler decides), spread, pack, interleave, '
+             'nodes (--placement-nodes) or zone (default: none)'
+    )
+    parser.add_argument(
+        '--placement-nodes',
+        nargs='+',
+        default=None,
+        help='Nodes the placement strategy uses (required by --placement nodes; default: all Ready workers)'
+    )
+    parser.add_argument(
+        '--max-vms-per-node',
+        type=int,
+        default=None,
+        help='VMs per node before --placement pack moves to the next node (default: all on the first node)'
+    )
+
+    # Topology
+    parser.add_argument(
+        '--zone',
+        nargs='+',
+        default=None,
+        help='Only place VMs on nodes in these zones (values of --topology-key); '
+             'spreads over their nodes unless --placement says otherwise'
+    )
+    parser.add_argument(
+        '--topology-key',
+        default=ZONE_LABEL,
+        help=f'Node label of the zone, e.g. a rack label (default: {ZONE_LABEL})'
+    )
+    parser.add_argument(
+        '--topology-spread',
+        action='store_true',
+        help='Spread VMs evenly across zones, then across the nodes of each zone. Same as --placement zone.'
     )
 
     # Single node testing
@@ -217,6 +452,57 @@ Examples:
         help='Specific node name to use (if not provided, a random worker node will be selected)'
     )
 
+    # Chaos mix mode
+    parser.add_argument(
+        '--chaos-mode',
+        choices=CHAOS_MODES,
+        default=None,
+        help='Inject this failure periodically while VMs are created and boot-stormed, and compare the '
+             'latency of VMs measured under failure with the others: ' + ', '.join(CHAOS_MODES)
+    )
+    parser.add_argument(
+        '--chaos-interval',
+        type=int,
+        default=DEFAULT_CHAOS_INTERVAL,
+        help=f'Seconds between injected failures (default: {DEFAULT_CHAOS_INTERVAL})'
+    )
+    parser.add_argument(
+        '--chaos-duration',
+        type=int,
+        default=DEFAULT_CHAOS_DURATION,
+        help='Seconds each kubelet-stop, network-partition or storage-pod-pause failure lasts '
+             f'(default: {DEFAULT_CHAOS_DURATION})'
+    )
+    parser.add_argument(
+        '--chaos-nodes',
+        nargs='+',
+        default=None,
+        help='Nodes failures are injected on, in rotation (default: all Ready workers)'
+    )
+    parser.add_argument(
+        '--chaos-storage-pods',
+        nargs='+',
+        default=DEFAULT_STORAGE_PODS,
+        help='namespace:label-selector of the storage pods storage-pod-kill deletes '
+             f'(default: {" ".join(DEFAULT_STORAGE_PODS)})'
+    )
+    parser.add_argument(
+        '--chaos-storage-process',
+        default=DEFAULT_STORAGE_PROCESS,
+        help=f'Process storage-pod-pause stops (default: {DEFAULT_STORAGE_PROCESS})'
+    )
+    parser.add_argument(
+        '--chaos-injector-image',
+        default=DEFAULT_NODE_EXEC_IMAGE,
+        help=f'Image of the privileged host pods that inject failures (default: {DEFAULT_NODE_EXEC_IMAGE})'
+    )
+    parser.add_argument(
+        '--chaos-allow-quorum-loss',
+        action='store_true',
+        help='On Portworx, inject failures even when they would lose KVDB quorum '
+             '(default: skip those failures)'
+    )
+
     # Save results
     parser.add_argument(
         '--save-results',
@@ -225,6 +511,12 @@ Examples:
     )
 
     # Base folder for results
+    parser.add_argument(
+        '--precision',
+        type=int,
+        default=timing.DEFAULT_PRECISION,
+        help=f'Decimal places for durations in saved results (default: {timing.DEFAULT_PRECISION})'
+    )
     parser.add_argument(
         '--results-folder',
         type=str,
@@ -237,12 +529,21 @@ Examples:
         dest='storage_driver',
         type=str,
         default=None,
-        help='Storage driver label to include in results path (for example: portworx-3.6, ceph)'
+        help='Storage driver label to include in results path (for example: portworx-3.6, ceph), or auto to detect it'
     )
 
     
     args = parser.parse_args()
 
+    # Guest-OS dependent defaults
+    windows = args.guest_os == GUEST_OS_WINDOWS
+    if args.vm_name is None:
+        args.vm_name = DEFAULT_WINDOWS_VM_NAME if windows else DEFAULT_VM_NAME
+    if args.vm_template is None:
+        args.vm_template = DEFAULT_WINDOWS_VM_YAML if windows else DEFAULT_VM_YAML
+    if args.ping_timeout is None:
+        args.ping_timeout = DEFAULT_WINDOWS_PING_TIMEOUT if windows else DEFAULT_PING_TIMEOUT
+
     # Validation
     if args.start < 1:
         parser.error("--start must be >= 1")
@@ -250,10 +551,60 @@ Examples:
         parser.error("--end must be >= --start")
     if args.concurrency < 1:
         parser.error("--concurrency must be >= 1")
+    if args.qps < 0:
+        parser.error("--qps must be >= 0")
+    if args.burst < 1:
+        parser.error("--burst must be >= 1")
+    if args.precision < 0 or args.precision > 9:
+        parser.error("--precision must be between 0 and 9")
+    if args.topology_spread:
+        if args.placement not in (STRATEGY_NONE, 'zone'):
+            parser.error(f"--topology-spread cannot be combined with --placement {args.placement}")
+        args.placement = 'zone'
+    if args.placement != STRATEGY_NONE and args.single_node:
+        parser.error("--placement cannot be combined with --single-node")
+    if args.zone and args.single_node:
+        parser.error("--zone cannot be combined with --single-node")
+    if args.placement == 'nodes' and not args.placement_nodes:
+        parser.error("--placement nodes requires --placement-nodes")
+    if args.max_vms_per_node is not None and args.max_vms_per_node < 1:
+        parser.error("--max-vms-per-node must be >= 1")
+    if args.gpus_per_vm < 1:
+        parser.error("--gpus-per-vm must be >= 1")
+    args.gpus = {args.gpu_device: args.gpus_per_vm} if args.gpu_device else None
+    if args.numa and not (args.dedicated_cpus and args.hugepages):
+        parser.error("--numa requires --dedicated-cpus and --hugepages")
+    args.tuning = tuning_settings(args.dedicated_cpus, args.hugepages, args.numa)
+    if args.preference and not args.instancetype:
+        parser.error("--preference requires --instancetype")
+    if args.instancetype and args.tuning:
+        parser.error("--instancetype sets the VM's CPU and memory; it cannot be combined with "
+                     "--dedicated-cpus, --hugepages or --numa")
+    # Resolved from --instancetype/--preference in main(): instancetype_settings()
+    args.vm_instancetype = None
+    args.networks = [parse_network(n, args.single_namespace or DEFAULT_NAD_NAMESPACE) for n in args.network]
+    if any(not name for _, name in args.networks):
+        parser.error("--network needs NetworkAttachmentDefinition names as [namespace/]name")
+    # Resolved by the network preflight: [{'namespace', 'name', 'binding', ...}]
+    args.vm_networks = None
+    if args.warmup_iterations < 0:
+        parser.error("--warmup-iterations must be >= 0")
+    if args.chaos_interval < 1:
+        parser.error("--chaos-interval must be >= 1")
+    if args.chaos_duration < 1:
+        parser.error("--chaos-duration must be >= 1")
+    if args.steady_state_cv <= 0:
+        parser.error("--steady-state-cv must be > 0")
     if not os.path.exists(args.vm_template):
         parser.error(f"VM template file not found: {args.vm_template}")
     if args.secret_yaml and not os.path.exists(args.secret_yaml):
         parser.error(f"Secret YAML file not found: {args.secret_yaml}")
+    if args.single_namespace:
+        # Listing nodes is cluster-scoped, so the node has to be given explicitly
+        if args.single_node and not args.node_name:
+            parser.error("--single-node with --single-namespace requires --node-name")
+        if args.ssh_pod_ns == DEFAULT_SSH_POD_NS:
+            args.ssh_pod_ns = args.single_namespace
 
     return args
 
@@ -275,7 +626,7 @@ def detect_disk_count_from_template(vm_template_path: str) -> Optional[int]:
     )
     non_cloudinit_volumes = [
         v for v in volumes
-        if not any(k in v for k in ['cloudInitNoCloud', 'cloudInitConfigDrive'])
+        if not any(k in v for k in ['cloudInitNoCloud', 'cloudInitConfigDrive', 'containerDisk'])
     ]
     return len(non_cloudinit_volumes)
 
@@ -283,7 +634,7 @@ def detect_disk_count_from_template(vm_template_path: str) -> Optional[int]:
 def build_results_dir(args, num_disks_per_vm: int, timestamp: Optional[str] = None) -> str:
     """Build the canonical results directory path for a datasource-clone run."""
     timestamp = timestamp or datetime.now().strftime("%Y%m%d-%H%M%S")
-    suffix = f"{args.namespace_prefix}_{args.start}-{args.end}"
+    suffix = f"{args.single_namespace or args.namespace_prefix}_{args.start}-{args.end}"
     disk_dir = f"{num_disks_per_vm}-disk" if num_disks_per_vm else "unknown-disk"
     if args.storage_driver:
         return os.path.join(args.results_folder, args.storage_driver, disk_dir, f"{timestamp}_{suffix}")
@@ -292,13 +643,13 @@ def build_results_dir(args, num_disks_per_vm: int, timestamp: Optional[str] = No
 
 def ensure_namespaces(start: int, end: int, prefix: str, batch_size: int, logger) -> List[str]:
     """
-    Create test namespaces in parallel batches.
+    Create test namespaces in batches and wait until they are Active.
 
     Args:
         start: Start index
         end: End index
         prefix: Namespace prefix
-        batch_size: Number of namespaces to create in parallel
+        batch_size: Number of namespaces applied per batch
         logger: Logger instance
 
     Returns:
@@ -309,7 +660,7 @@ def ensure_namespaces(start: int, end: int, prefix: str, batch_size: int, logger
     # Generate namespace names
     namespaces = [f"{prefix}-{i}" for i in range(start, end + 1)]
 
-    # Create namespaces in parallel
+    # Create namespaces in batches (one server-side apply each)
     successful = create_namespaces_parallel(namespaces, batch_size, logger)
 
     if len(successful) != len(namespaces):
@@ -351,20 +702,16 @@ def create_secret(ns: str, secret_yaml: str, logger,
 
     for attempt in range(1, max_retries + 1):
         try:
-            returncode, stdout, stderr = run_kubectl_command(
-                ['create', '-f', secret_yaml, '-n', ns],
-                check=False,
-                logger=logger
-            )
+            with open(secret_yaml, 'r') as f:
+                created, adopted, stderr = create_or_adopt(f.read(), ns, logger)
 
-            if returncode == 0:
-                logger.info(f"[{ns}] Secret created successfully")
+            if created:
+                if adopted:
+                    logger.info(f"[{ns}] Secret already exists from an earlier attempt, continuing")
+                else:
+                    logger.info(f"[{ns}] Secret created successfully")
                 return True
             else:
-                if 'AlreadyExists' in stderr:
-                    logger.warning(f"[{ns}] Secret already exists, continuing")
-                    return True
-
                 # Check if it's a retryable error
                 is_retryable = any(err in stderr for err in retryable_errors)
 
@@ -391,33 +738,120 @@ def create_secret(ns: str, secret_yaml: str, logger,
     return False
 
 
+def render_scoped_vm_yaml(vm_yaml: str, vm_name: str, target_vm: str,
+                          node_name: Optional[str] = None, gpus: Optional[Dict[str, int]] = None,
+                          networks: Optional[List[Dict]] = None) -> str:
+    """
+    Render a VM template under a new VM name for namespace-scoped mode.
+
+    The VM and its dataVolumeTemplates are renamed so that several copies of
+    the template can live in one namespace.
+
+    Args:
+        vm_yaml: Path to VM YAML file
+        vm_name: VM name in the template
+        target_vm: Name of the VM to create
+        node_name: Optional node name to pin VM to
+        gpus: Optional GPUs to add, {device name: count}
+        networks: Optional secondary networks to attach, from check_networks()
+
+    Returns:
+        Modified YAML content as string
+    """
+    with open(vm_yaml, 'r') as f:
+        docs = [doc for doc in yaml.safe_load_all(f) if doc]
+
+    for doc in docs:
+        if doc.get('kind') != 'VirtualMachine':
+            continue
+        rename_template_vm(doc, vm_name, target_vm)
+        template_spec = doc['spec']['template']['spec']
+        if node_name:
+            template_spec['nodeSelector'] = {'kubernetes.io/hostname': node_name}
+        for device_name, count in (gpus or {}).items():
+            add_gpus(template_spec, device_name, count)
+        add_networks(template_spec, networks or [])
+
+    return yaml.safe_dump_all(docs, sort_keys=False)
+
+
+def render_vm_manifest(vm_yaml: str, vm_name: Optional[str], target_vm: str,
+                       node_name: Optional[str], logger, gpus: Optional[Dict[str, int]] = None,
+                       networks: Optional[List[Dict]] = None) -> str:
+    """
+    Render the manifest create_vm applies for one VM.
+
+    Args:
+        vm_yaml: Path to VM YAML file
+        vm_name: VM name in the template
+        target_vm: Name of the VM to create
+        node_name: Optional node name to pin VM to
+        logger: Logger instance
+        gpus: Optional GPUs to add, {device name: count}
+        networks: Optional secondary networks to attach, from check_networks()
+
+    Returns:
+        Manifest as string
+    """
+    # Namespace-scoped mode: rename the template VM so copies can share the namespace.
+    # GPUs and networks are added to the parsed template as well (the rename is then a no-op).
+    if target_vm != vm_name or gpus or networks:
+        return render_scoped_vm_yaml(vm_yaml, vm_name, target_vm, node_name, gpus, networks)
+
+    # If node_name is specified, modify YAML to add nodeSelector
+    if node_name:
+        logger.debug(f"[{target_vm}] Adding nodeSelector for node: {node_name}")
+        manifest = add_node_selector_to_vm_yaml(vm_yaml, node_name, logger)
+        if manifest:
+            return manifest
+        logger.warning(f"[{target_vm}] Failed to modify YAML, creating without nodeSelector")
+
+    with open(vm_yaml, 'r') as f:
+        return f.read()
+
+
 def create_vm(ns: str, vm_yaml: str, node_name: Optional[str], logger,
-              secret_yaml: Optional[str] = None,
-              max_retries: int = 5, initial_delay: float = 2.0) -> Tuple[str, datetime]:
+              secret_yaml: Optional[str] = None, vm_name: Optional[str] = None,
+              max_retries: int = 5, initial_delay: float = 2.0,
+              gpus: Optional[Dict[str, int]] = None,
+              networks: Optional[List[Dict]] = None) -> Tuple[str, timing.MonotonicTimestamp, bool]:
     """
     Create a VM in the specified namespace with retry logic.
 
     Args:
-        ns: Namespace name
+        ns: Namespace name, or "{namespace}/{vm}" target in namespace-scoped mode
         vm_yaml: Path to VM YAML file
         node_name: Optional node name to pin VM to
         logger: Logger instance
         secret_yaml: Optional path to secret YAML file to create before VM
+        vm_name: VM name in the template (required for namespace-scoped targets)
         max_retries: Maximum number of retry attempts (default: 5)
         initial_delay: Initial delay between retries in seconds (default: 2.0)
                       Uses exponential backoff: delay * 2^attempt
+        gpus: Optional GPUs to add, {device name: count}
+        networks: Optional secondary networks to attach, from check_networks()
 
     Returns:
-        Tuple of (namespace, creation_timestamp)
+        Tuple of (namespace or target, creation_timestamp, adopted); the timings of
+        an adopted VM only cover the work left after the earlier attempt
     """
+    target = ns
+    ns, target_vm = split_vm_target(target, vm_name)
+
     # Create secret first if provided
     if secret_yaml:
         if not create_secret(ns, secret_yaml, logger):
             logger.error(f"[{ns}] Failed to create secret, aborting VM creation")
             raise RuntimeError(f"Failed to create secret in {ns}")
 
-    logger.info(f"[{ns}] Creating VM from {vm_yaml}")
-    start_ts = datetime.now()
+    admission_sec = None
+    if admission_enabled():
+        # Dry-run create before the VM's timings start (virtbench --admission-latency)
+        manifest = render_vm_manifest(vm_yaml, vm_name, target_vm, node_name, logger, gpus, networks)
+        admission_sec = time_admission(manifest, ns, logger)
+
+    logger.info(f"[{target}] Creating VM from {vm_yaml}")
+    start_ts = timing.now()
 
     # List of retryable error patterns
     retryable_errors = [
@@ -432,45 +866,22 @@ def create_vm(ns: str, vm_yaml: str, node_name: Optional[str], logger,
 
     for attempt in range(1, max_retries + 1):
         try:
-            # If node_name is specified, modify YAML to add nodeSelector
-            if node_name:
-                logger.debug(f"[{ns}] Adding nodeSelector for node: {node_name}")
-                modified_yaml = add_node_selector_to_vm_yaml(vm_yaml, node_name, logger)
-
-                if modified_yaml:
-                    # Create VM using modified YAML via stdin
-                    process = subprocess.Popen(
-                        ['kubectl', 'create', '-f', '-', '-n', ns],
-                        stdin=subprocess.PIPE,
-                        stdout=subprocess.PIPE,
-                        stderr=subprocess.PIPE,
-                        text=True
-                    )
-                    stdout, stderr = process.communicate(input=modified_yaml)
-                    returncode = process.returncode
+            manifest = render_vm_manifest(vm_yaml, vm_name, target_vm, node_name, logger, gpus, networks)
+            call_ts = timing.now()
+            created, adopted, stderr = create_or_adopt(manifest, ns, logger)
+
+            if created:
+                if adopted:
+                    logger.warning(f"[{target}] VM already exists from an earlier attempt with this run UUID, "
+                                   f"adopting it; its timings are left out of the statistics")
                 else:
-                    logger.warning(f"[{ns}] Failed to modify YAML, creating without nodeSelector")
-                    returncode, stdout, stderr = run_kubectl_command(
-                        ['create', '-f', vm_yaml, '-n', ns],
-                        check=False,
-                        logger=logger
-                    )
+                    call_sec = (timing.now() - call_ts).total_seconds()
+                    record_latency('create_call', call_sec)
+                    record_call(ns, 'create_call_sec', call_sec)
+                    record_admission(target, admission_sec, call_sec)
+                    logger.info(f"[{target}] VM creation API call completed")
+                return target, start_ts, adopted
             else:
-                # Create VM normally without nodeSelector
-                returncode, stdout, stderr = run_kubectl_command(
-                    ['create', '-f', vm_yaml, '-n', ns],
-                    check=False,
-                    logger=logger
-                )
-
-            if returncode == 0:
-                logger.info(f"[{ns}] VM creation API call completed")
-                return ns, start_ts
-            else:
-                if 'AlreadyExists' in stderr:
-                    logger.warning(f"[{ns}] VM already exists, continuing with existing VM")
-                    return ns, start_ts
-
                 # Check if it's a retryable error
                 is_retryable = any(err in stderr for err in retryable_errors)
 
@@ -517,11 +928,8 @@ def get_vm_disk_count(ns: str, vm_name: str, logger) -> int:
         Number of disks (excluding cloud-init volumes)
     """
     try:
-        result = subprocess.run(
-            ['kubectl', 'get', 'vm', vm_name, '-n', ns, '-o', 'json'],
-            capture_output=True, text=True, check=True
-        )
-        vm_spec = json.loads(result.stdout)
+        _, stdout, _ = run_kubectl_command(['get', 'vm', vm_name, '-n', ns, '-o', 'json'])
+        vm_spec = json.loads(stdout)
 
         # Get list of volumes under spec.template.spec.volumes
         volumes = (
@@ -534,7 +942,7 @@ def get_vm_disk_count(ns: str, vm_name: str, logger) -> int:
         # Exclude cloudInit volumes
         non_cloudinit_volumes = [
             v for v in volumes
-            if not any(k in v for k in ['cloudInitNoCloud', 'cloudInitConfigDrive'])
+            if not any(k in v for k in ['cloudInitNoCloud', 'cloudInitConfigDrive', 'containerDisk'])
         ]
 
         disk_count = len(non_cloudinit_volumes)
@@ -549,7 +957,7 @@ def get_vm_disk_count(ns: str, vm_name: str, logger) -> int:
         return 1
 
 
-def wait_for_vm_running(ns: str, vm_name: str, start_ts: datetime, poll_interval: int, logger) -> Tuple[str, float]:
+def wait_for_vm_running(ns: str, vm_name: str, start_ts: timing.MonotonicTimestamp, poll_interval: int, logger) -> Tuple[str, float]:
     """
     Wait for VM to reach Running state.
     
@@ -569,7 +977,7 @@ def wait_for_vm_running(ns: str, vm_name: str, start_ts: datetime, poll_interval
         status = get_vm_status(vm_name, ns, logger)
         
         if status == 'Running':
-            elapsed = (datetime.now() - start_ts).total_seconds()
+            elapsed = (timing.now() - start_ts).total_seconds()
             logger.info(f"[{ns}] VM Running after {elapsed:.2f}s")
             return ns, elapsed
         
@@ -601,10 +1009,11 @@ def wait_for_vmi_ip(ns: str, vm_name: str, poll_interval: int, logger) -> str:
         time.sleep(poll_interval)
 
 
-def wait_for_ping(ns: str, ip: str, start_ts: datetime, ssh_pod: str, ssh_pod_ns: str,
-                  poll_interval: int, timeout: int, logger) -> Tuple[str, float, bool]:
+def wait_for_ping(ns: str, ip: str, start_ts: timing.MonotonicTimestamp, ssh_pod: str, ssh_pod_ns: str,
+                  poll_interval: int, timeout: int, logger,
+                  guest_os: str = GUEST_OS_LINUX, target: Optional[str] = None) -> Tuple[str, float, bool]:
     """
-    Wait for VM to respond to ping.
+    Wait for VM to respond to ping (or, for Windows guests, to accept RDP/WinRM).
     
     Args:
         ns: Namespace
@@ -615,37 +1024,134 @@ def wait_for_ping(ns: str, ip: str, start_ts: datetime, ssh_pod: str, ssh_pod_ns
         poll_interval: Polling interval in seconds
         timeout: Timeout in seconds
         logger: Logger instance
+        guest_os: Guest operating system (linux or windows)
+        target: Namespace or "{namespace}/{vm}" the probe metrics are recorded under (default: ns)
     
     Returns:
         Tuple of (namespace, elapsed_seconds, success)
     """
-    logger.info(f"[{ns}] Pinging {ip} (timeout: {timeout}s)...")
-    ping_start = datetime.now()
+    probe = "Probing RDP/WinRM on" if guest_os == GUEST_OS_WINDOWS else "Pinging"
+    logger.info(f"[{ns}] {probe} {ip} (timeout: {timeout}s)...")
+    ping_start = timing.now()
+
+    checker = active_checker()
+    if checker:
+        check = checker.submit(ip, guest_os, timeout, target or ns)
+        if not check.wait(timeout + CHECKER_GRACE):
+            logger.warning(f"[{ns}] Ping timeout after {timeout}s")
+            return ns, None, False
+        elapsed_total = (check.responded_at - start_ts).total_seconds()
+        logger.info(f"[{ns}] Ping successful after {elapsed_total:.3f}s")
+        return ns, elapsed_total, True
     
     while True:
-        elapsed_ping = (datetime.now() - ping_start).total_seconds()
+        elapsed_ping = (timing.now() - ping_start).total_seconds()
         
         if elapsed_ping > timeout:
             logger.warning(f"[{ns}] Ping timeout after {timeout}s")
             return ns, None, False
         
-        if ping_vm(ip, ssh_pod, ssh_pod_ns, logger):
-            elapsed_total = (datetime.now() - start_ts).total_seconds()
+        if check_guest_ready(ip, ssh_pod, ssh_pod_ns, guest_os, logger, target=target or ns):
+            elapsed_total = (timing.now() - start_ts).total_seconds()
             logger.info(f"[{ns}] Ping successful after {elapsed_total:.2f}s")
             return ns, elapsed_total, True
         
         time.sleep(poll_interval)
 
 
-def monitor_vm(ns: str, vm_name: str, start_ts: datetime, ssh_pod: str, ssh_pod_ns: str,
+def wait_for_guest_ready(ns: str, vm_name: str, ip: str, start_ts: timing.MonotonicTimestamp,
+                         ssh_pod: str, ssh_pod_ns: str, poll_interval: int, timeout: int,
+                         agent_timeout: int, logger, guest_os: str = GUEST_OS_LINUX,
+                         target: Optional[str] = None) -> Tuple[str, Optional[float], bool, Dict]:
+    """
+    Wait for the guest to become reachable while timing the qemu-guest-agent connection.
+
+    Both probes run in the same polling loop so that neither delays the other's
+    measurement. Once the guest is reachable the agent gets agent_timeout more
+    seconds to connect before it is reported as not connected.
+
+    Args:
+        ns: Namespace
+        vm_name: VM name (same as VMI name)
+        ip: VM IP address
+        start_ts: Creation timestamp
+        ssh_pod: SSH pod name
+        ssh_pod_ns: SSH pod namespace
+        poll_interval: Polling interval in seconds
+        timeout: Reachability timeout in seconds
+        agent_timeout: Extra seconds to wait for the agent after the guest is reachable
+        logger: Logger instance
+        guest_os: Guest operating system (linux or windows)
+        target: Namespace or "{namespace}/{vm}" the probe metrics are recorded under (default: ns)
+
+    Returns:
+        Tuple of (namespace, ping_seconds, success, agent) where agent is a dict
+        with time (seconds from creation, None if it never connected),
+        connected_at (AgentConnected transition time) and guest_os
+    """
+    logger.info(f"[{ns}] Waiting for guest at {ip} and guest agent (timeout: {timeout}s)...")
+    probe_start = timing.now()
+    ping_time = None
+    reachable_at = None
+    agent = {'time': None, 'connected_at': None, 'guest_os': None}
+    checker = active_checker()
+    check = checker.submit(ip, guest_os, timeout, target or ns) if checker else None
+
+    while True:
+        if ping_time is None:
+            if (timing.now() - probe_start).total_seconds() > timeout or (
+                    check is not None and check.done() and not check.reachable):
+                logger.warning(f"[{ns}] Ping timeout after {timeout}s")
+                return ns, None, False, agent
+            if check is not None:
+                if check.reachable:
+                    reachable_at = check.responded_at
+            elif check_guest_ready(ip, ssh_pod, ssh_pod_ns, guest_os, logger, target=target or ns):
+                reachable_at = timing.now()
+            if reachable_at is not None:
+                ping_time = (reachable_at - start_ts).total_seconds()
+                logger.info(f"[{ns}] Ping successful after {ping_time:.3f}s")
+
+        if agent['time'] is None:
+            status = get_guest_agent_status(vm_name, ns, logger)
+            if status and status['connected']:
+                agent['time'] = (timing.now() - start_ts).total_seconds()
+                agent['connected_at'] = status['connected_at']
+                agent['guest_os'] = status['guest_os']
+                logger.info(f"[{ns}] Guest agent connected after {agent['time']:.2f}s")
+
+        if ping_time is not None:
+            if agent['time'] is not None:
+                break
+            if (timing.now() - reachable_at).total_seconds() > agent_timeout:
+                logger.warning(f"[{ns}] Guest agent did not connect within {agent_timeout}s "
+                               f"of the guest becoming reachable")
+                break
+
+        time.sleep(poll_interval)
+
+    # guestOSInfo is filled in shortly after the agent connects
+    if agent['time'] is not None and agent['guest_os'] is None:
+        status = get_guest_agent_status(vm_name, ns, logger)
+        agent['guest_os'] = status['guest_os'] if status else None
+    if agent['guest_os']:
+        logger.info(f"[{ns}] Guest OS: {agent['guest_os']['name']} "
+                    f"(kernel {agent['guest_os']['kernel'] or 'unknown'})")
+
+    return ns, ping_time, True, agent
+
+
+def monitor_vm(ns: str, vm_name: str, start_ts: timing.MonotonicTimestamp, ssh_pod: str, ssh_pod_ns: str,
                poll_interval: int, ping_timeout: int, logger, skip_dv_clone_tracking=False,
-               vm_template_path: Optional[str] = None) -> Tuple[str, float, float, float, bool]:
+               vm_template_path: Optional[str] = None,
+               guest_os: str = GUEST_OS_LINUX,
+               agent_timeout: Optional[int] = None) -> Tuple:
     """
     Monitor a single VM through its lifecycle and record clone timing.
 
     Args:
-        ns: Namespace
-        vm_name: VM name
+        ns: Namespace, or "{namespace}/{vm}" target in namespace-scoped mode
+        vm_name: VM name (as in the template)
         start_ts: Creation timestamp
         ssh_pod: SSH pod name
         ssh_pod_ns: SSH pod namespace
@@ -654,33 +1160,55 @@ def monitor_vm(ns: str, vm_name: str, start_ts: datetime, ssh_pod: str, ssh_pod_
         logger: Logger instance
         skip_dv_clone_tracking: Flag to control DataVolume Clone
         vm_template_path: Path to VM template YAML (optional, for DV name extraction)
+        guest_os: Guest operating system (linux or windows)
+        agent_timeout: When set, also time the qemu-guest-agent connection (see wait_for_guest_ready)
     Returns:
-        Tuple of (namespace, running_time, ping_time, clone_duration, success)
+        Tuple of (namespace, running_time, ping_time, clone_duration, success), with the
+        guest agent dict appended when agent_timeout is set
     """
+    target = ns
+    template_vm_name = vm_name
+    ns, vm_name = split_vm_target(target, vm_name)
     try:
         # Track clone timing
+        vm_state(target, 'starting' if skip_dv_clone_tracking else 'cloning')
         if not skip_dv_clone_tracking:
             clone_start, clone_end, clone_duration = track_clone_progress(
-                ns, vm_name, start_ts, poll_interval, logger, vm_template_path=vm_template_path
+                ns, vm_name, start_ts, poll_interval, logger, vm_template_path=vm_template_path,
+                template_vm_name=template_vm_name
             )
         else:
             clone_duration = None
         # Wait for VM to become Running
         _, running_time = wait_for_vm_running(ns, vm_name, start_ts, poll_interval, logger)
+        vm_state(target, 'Running')
 
         # Wait for VMI IP
         ip = wait_for_vmi_ip(ns, vm_name, poll_interval, logger)
 
+        if agent_timeout is not None:
+            # Wait until ping works and the guest agent has connected
+            _, ping_time, success, agent = wait_for_guest_ready(
+                ns, vm_name, ip, start_ts, ssh_pod, ssh_pod_ns, poll_interval, ping_timeout,
+                agent_timeout, logger, guest_os, target
+            )
+            vm_state(target, 'reachable' if success else 'unreachable')
+            return target, running_time, ping_time, clone_duration, success, agent
+
         # Wait until ping works
         _, ping_time, success = wait_for_ping(
-            ns, ip, start_ts, ssh_pod, ssh_pod_ns, poll_interval, ping_timeout, logger
+            ns, ip, start_ts, ssh_pod, ssh_pod_ns, poll_interval, ping_timeout, logger, guest_os, target
         )
+        vm_state(target, 'reachable' if success else 'unreachable')
 
-        return ns, running_time, ping_time, clone_duration, success
+        return target, running_time, ping_time, clone_duration, success
 
     except Exception as e:
-        logger.error(f"[{ns}] Error monitoring VM: {e}")
-        return ns, None, None, None, False
+        logger.error(f"[{target}] Error monitoring VM: {e}")
+        vm_state(target, 'error')
+        if agent_timeout is not None:
+            return target, None, None, None, False, None
+        return target, None, None, None, False
 
 
 
@@ -750,8 +1278,9 @@ def extract_datavolume_name_from_yaml(vm_template_path: str, logger) -> Optional
         return None
 
 
-def track_clone_progress(ns: str, vm_name: str, start_ts: datetime, poll_interval: int, logger,
-                        vm_template_path: Optional[str] = None, timeout: int = 1800):
+def track_clone_progress(ns: str, vm_name: str, start_ts: timing.MonotonicTimestamp, poll_interval: int, logger,
+                        vm_template_path: Optional[str] = None, timeout: int = 1800,
+                        template_vm_name: Optional[str] = None):
     """
     Track DataVolume/PVC clone timing for a given VM, including inferred clone start logic.
 
@@ -763,6 +1292,8 @@ def track_clone_progress(ns: str, vm_name: str, start_ts: datetime, poll_interva
         logger: Logger instance
         vm_template_path: Path to VM template YAML (optional, for boot disk name extraction)
         timeout: Timeout in seconds
+        template_vm_name: VM name in the template, when the VM was created under
+                          another name (namespace-scoped mode)
 
     Returns:
         Tuple (clone_start_time, clone_end_time, clone_duration_seconds)
@@ -773,6 +1304,8 @@ def track_clone_progress(ns: str, vm_name: str, start_ts: datetime, poll_interva
     dv_name = None
     if vm_template_path:
         dv_name = extract_datavolume_name_from_yaml(vm_template_path, logger)
+        if dv_name and template_vm_name:
+            dv_name = scoped_resource_name(dv_name, template_vm_name, vm_name)
         if dv_name:
             logger.info(f"[{ns}] Extracted boot disk name from template: {dv_name}")
 
@@ -810,7 +1343,7 @@ def track_clone_progress(ns: str, vm_name: str, start_ts: datetime, poll_interva
             )
             if result.returncode != 0 or not result.stdout:
                 time.sleep(poll_interval)
-                elapsed = (datetime.now() - start_ts).total_seconds()
+                elapsed = (timing.now() - start_ts).total_seconds()
                 continue
 
             data = json.loads(result.stdout)
@@ -828,13 +1361,13 @@ def track_clone_progress(ns: str, vm_name: str, start_ts: datetime, poll_interva
 
             # CloneScheduled observed
             if phase == "clonescheduled" and not clone_start:
-                clone_start = datetime.now()
+                clone_start = timing.now()
                 logger.info(f"[{ns}] {dv_name} entered CloneScheduled ({(clone_start - start_ts).total_seconds():.2f}s after VM creation)")
 
             # Clone in progress but no CloneScheduled observed (inferred)
             elif phase == "csicloneinprogress" and not clone_start:
                 # Infer clone started shortly after VM creation
-                current_time = datetime.now()
+                current_time = timing.now()
                 elapsed_now = (current_time - start_ts).total_seconds()
                 if elapsed_now > poll_interval:
                     # Infer it started one poll interval ago
@@ -848,7 +1381,7 @@ def track_clone_progress(ns: str, vm_name: str, start_ts: datetime, poll_interva
 
             # Clone succeeded (DataVolume) or Bound (PVC)
             elif phase == "succeeded":
-                clone_end = datetime.now()
+                clone_end = timing.now()
                 if not clone_start:
                     # Infer clone started one poll interval ago, but not before VM creation
                     inferred_start = clone_end - timedelta(seconds=poll_interval)
@@ -870,7 +1403,7 @@ def track_clone_progress(ns: str, vm_name: str, start_ts: datetime, poll_interva
             return None, None, None
 
         time.sleep(poll_interval)
-        elapsed = (datetime.now() - start_ts).total_seconds()
+        elapsed = (timing.now() - start_ts).total_seconds()
 
     if clone_start and clone_end:
         duration = round((clone_end - clone_start).total_seconds(), 2)
@@ -882,9 +1415,191 @@ def track_clone_progress(ns: str, vm_name: str, start_ts: datetime, poll_interva
         return None, None, None
 
 
+def delete_warmup_vm(ns: str, vm_name: str, logger, timeout: int = 600) -> bool:
+    """Delete a warm-up VM and wait until it and its DataVolumes are gone, so the name can be reused."""
+    rc, _, stderr = run_kubectl_command(
+        ['delete', 'vm', vm_name, '-n', ns, '--cascade=foreground', '--wait=true',
+         f'--timeout={timeout}s', '--ignore-not-found'],
+        check=False, logger=logger
+    )
+    if rc != 0:
+        logger.warning(f"[{ns}] Failed to delete warm-up VM {vm_name}: {stderr.strip()}")
+    return rc == 0
+
+
+def place_vms(args, namespaces: List[str], target_node: Optional[str],
+              logger) -> Tuple[PlacementStrategy, Dict[str, Optional[str]]]:
+    """Node of each VM: the --single-node node, or the --placement strategy's choice (None: scheduler)."""
+    strategy = get_strategy(args.placement, args.placement_nodes, args.max_vms_per_node, logger,
+                            zones=args.zone, topology_key=args.topology_key)
+    if target_node or strategy.name == STRATEGY_NONE:
+        return strategy, {ns: target_node for ns in namespaces}
+    assignment = strategy.assign(namespaces, [] if args.placement_nodes else get_worker_nodes(logger))
+    logger.info(f"Placement '{strategy.name}': VMs per node {strategy.describe(assignment)['per_node']}")
+    return strategy, assignment
+
+
+def run_warmup(args, namespaces: List[str], vm_nodes: Dict[str, Optional[str]], agent_timeout, logger) -> Dict:
+    """
+    Create, boot and delete the VMs before the measured run.
+
+    The first iterations pay for image pulls, DataSource population and cold
+    caches. Iterations stop after --warmup-iterations or once the mean time to
+    Running of the last STEADY_STATE_WINDOW iterations varies by at most
+    --steady-state-cv (coefficient of variation).
+
+    Returns:
+        Warm-up block for the summary: iterations run, per-iteration mean time
+        to Running, whether steady state was reached and the last coefficient of variation
+    """
+    means, steady, cv, iteration = [], False, None, 0
+    for iteration in range(1, args.warmup_iterations + 1):
+        logger.info(f"\nWarm-up iteration {iteration}/{args.warmup_iterations}: "
+                    f"creating {len(namespaces)} VMs...")
+        outcomes = run_parallel(
+            lambda ns: create_vm(ns, args.vm_template, vm_nodes[ns], logger, args.secret_yaml, args.vm_name,
+                                gpus=args.gpus, networks=args.vm_networks),
+            namespaces, concurrency=args.concurrency, qps=args.qps, burst=args.burst,
+            logger=logger, description="warm-up VM creation"
+        )
+        # Adopted VMs only did part of the work, so they do not count towards the means
+        start_times = {ns: result[1] for ns, result, error in outcomes if error is None and not result[2]}
+
+        results = []
+        with ThreadPoolExecutor(max_workers=args.concurrency) as executor:
+            futures = [
+                executor.submit(
+                    monitor_vm, ns, args.vm_name, ts, args.ssh_pod, args.ssh_pod_ns,
+                    args.poll_interval, args.ping_timeout, logger, False, args.vm_template,
+                    args.guest_os, agent_timeout
+                )
+                for ns, ts in start_times.items()
+            ]
+            for future in as_completed(futures):
+                try:
+                    results.append(future.result())
+                except Exception as e:
+                    logger.warning(f"Warm-up monitoring failed: {e}")
+
+        running_times = [r[1] for r in results if r[4] and r[1] is not None]
+        if running_times:
+            means.append(sum(running_times) / len(running_times))
+            logger.info(f"Warm-up iteration {iteration}: mean time to Running {means[-1]:.2f}s "
+                        f"({len(running_times)}/{len(namespaces)} VMs)")
+        else:
+            logger.warning(f"Warm-up iteration {iteration}: no VM reached Running")
+
+        logger.info(f"Warm-up iteration {iteration}: deleting VMs...")
+        run_parallel(
+            lambda ns: call_for_target(delete_warmup_vm, ns, args.vm_name, logger), namespaces,
+            concurrency=args.concurrency, qps=args.qps, burst=args.burst, logger=logger,
+            description="warm-up VM deletion"
+        )
+
+        steady, cv = steady_state(means, args.steady_state_cv)
+        if steady:
+            logger.info(f"Steady state reached after {iteration} warm-up iterations (cv={cv})")
+            break
+    else:
+        logger.warning(f"Steady state not reached after {args.warmup_iterations} warm-up iterations "
+                       f"(cv={cv}, threshold {args.steady_state_cv})")
+
+    # Warm-up create calls are not part of the measured latency histograms
+    take_latencies()
+    return {
+        'iterations': iteration,
+        'max_iterations': args.warmup_iterations,
+        'iteration_means_sec': [timing.round_duration(m) for m in means],
+        'steady_state': steady,
+        'cv': cv,
+        'cv_threshold': args.steady_state_cv,
+        'window': STEADY_STATE_WINDOW,
+    }
+
+
+def boot_phase_metrics(results: List[Tuple], elapsed: float) -> Tuple[Dict, str]:
+    """Key metrics and notification status of a create or boot storm phase."""
+    failed = sum(1 for r in results if not r[-1])
+    metrics = {'vms': len(results), 'failed': failed, 'duration_sec': round(elapsed, 2)}
+    metrics.update(duration_metrics('running_sec', [r[1] for r in results]))
+    metrics.update(duration_metrics('ping_sec', [r[2] for r in results]))
+    return metrics, phase_status(failed, len(results))
+
+
+def start_chaos(args, logger) -> Optional[FaultInjector]:
+    """Start injecting --chaos-mode failures in the background, or return None without chaos mode."""
+    if not args.chaos_mode:
+        return None
+    injector = FaultInjector(
+        args.chaos_mode, interval=args.chaos_interval, duration=args.chaos_duration, nodes=args.chaos_nodes,
+        storage_pods=args.chaos_storage_pods, storage_process=args.chaos_storage_process,
+        image=args.chaos_injector_image, allow_quorum_loss=args.chaos_allow_quorum_loss, logger=logger
+    )
+    return injector if injector.start() else None
+
+
+def stop_chaos(injector: Optional[FaultInjector], results: List[Tuple],
+               start_times: Dict[str, timing.MonotonicTimestamp], logger, skip_clone: bool = False) -> Optional[Dict]:
+    """Stop the injector and compare the VMs measured under failure with the others."""
+    if injector is None:
+        return None
+    chaos = chaos_summary(results, start_times, injector.stop(), skip_clone=skip_clone)
+    print_chaos_summary(chaos, logger)
+    return chaos
+
+
+def plan_run(args, namespaces: List[str], target_node: Optional[str], vm_nodes: Dict[str, Optional[str]], logger):
+    """Print what main() would create, stop, start and delete (virtbench --dry-run)."""
+    plan = DryRunPlan('datasource-clone', logger)
+    if not args.single_namespace and not args.skip_namespace_creation:
+        plan.create_namespaces(namespaces)
+    if args.reachability == REACHABILITY_CHECKER:
+        plan.action('create', f"pod/{args.ssh_pod_ns}/{CHECKER_POD}", 'reachability checker, if missing')
+
+    if not args.skip_vm_creation:
+        if args.prewarm:
+            plan_prewarm(plan, args.vm_template, logger, node=target_node)
+        if args.warmup_iterations:
+            for target in namespaces:
+                plan.action('create, boot and delete', f"vm/{'/'.join(split_vm_target(target, args.vm_name))}",
+                            f'up to {args.warmup_iterations} warm-up iterations')
+        secret_namespaces = set()
+        for target in namespaces:
+            ns, target_vm = split_vm_target(target, args.vm_name)
+            if args.secret_yaml and ns not in secret_namespaces:
+                secret_namespaces.add(ns)
+                with open(args.secret_yaml, 'r') as f:
+                    plan.apply(f.read(), ns)
+            plan.apply(render_vm_manifest(args.vm_template, args.vm_name, target_vm, vm_nodes[target], logger,
+                                          args.gpus, args.vm_networks), ns)
+
+    if args.boot_storm:
+        for verb in ('stop', 'start'):
+            for target in namespaces:
+                plan.action(verb, f"vm/{'/'.join(split_vm_target(target, args.vm_name))}", 'boot storm')
+
+    if args.chaos_mode:
+        nodes = ', '.join(args.chaos_nodes) if args.chaos_nodes else 'each Ready worker'
+        plan.action('inject', args.chaos_mode, f"every {args.chaos_interval}s on {nodes}, in rotation")
+
+    if args.cleanup or args.cleanup_on_failure:
+        condition = '' if args.cleanup else 'if any VM fails'
+        for target in namespaces:
+            plan.action('delete', f"vm/{'/'.join(split_vm_target(target, args.vm_name))}", condition)
+        plan.delete_namespaces(namespaces, condition)
+
+    plan.report()
+
+
 def main():
     """Main execution function."""
     args = parse_args()
+    set_run_workload('datasource-clone')
+    args.storage_driver = resolve_storage_driver(args.storage_driver)
+    dry_run = is_dry_run()
+    if dry_run:
+        # A dry run has no results to save
+        args.save_results = False
     args._results_dir = None
     args._precomputed_disk_count = None
 
@@ -908,10 +1623,15 @@ def main():
 
     # Setup logging
     logger = setup_logging(args.log_file, args.log_level)
+    watch_run('datasource-clone', logger)
+    watch_events(args.single_namespace or args.namespace_prefix, logger)
+    sample_nodes(logger)
 
     # Global variables for signal handler
     namespaces_created = []
     cleanup_on_interrupt = args.cleanup or args.cleanup_on_failure
+    # VMs reused with --skip-vm-creation were created by an earlier run
+    cleanup_selector = ANY_RUN_SELECTOR if args.skip_vm_creation else run_selector()
 
     def signal_handler(signum, frame):
         """Handle Ctrl+C gracefully with optional cleanup."""
@@ -927,7 +1647,11 @@ def main():
                     delete_namespaces=True,
                     dry_run=False,
                     batch_size=args.namespace_batch_size,
-                    logger=logger
+                    logger=logger,
+                    qps=args.qps,
+                    burst=args.burst,
+                    single_namespace=args.single_namespace,
+                    selector=cleanup_selector
                 )
                 print_cleanup_summary(stats, logger)
             except Exception as e:
@@ -941,15 +1665,64 @@ def main():
     logger.info("KubeVirt VM Creation Performance Test - DataSource Clone Method")
     logger.info("=" * 80)
     logger.info(f"Test range: {args.start} to {args.end} ({args.end - args.start + 1} VMs)")
-    logger.info(f"Namespace prefix: {args.namespace_prefix}")
+    if args.single_namespace:
+        logger.info(f"Namespace: {args.single_namespace} (namespace-scoped mode)")
+    else:
+        logger.info(f"Namespace prefix: {args.namespace_prefix}")
     logger.info(f"VM name: {args.vm_name}")
     logger.info(f"VM template: {args.vm_template}")
     logger.info(f"Concurrency: {args.concurrency}")
     logger.info(f"Poll interval: {args.poll_interval}s")
+    logger.info(f"Guest OS: {args.guest_os}")
     logger.info(f"Ping timeout: {args.ping_timeout}s")
     logger.info("=" * 80)
     num_disks_per_vm = 1
 
+    # Instancetype: create the VMs from a copy of the template that references it
+    if args.instancetype and not args.skip_vm_creation:
+        namespace = args.single_namespace
+        instancetype = resolve(args.instancetype, CLUSTER_INSTANCETYPE, INSTANCETYPE, logger, namespace)
+        if instancetype is None:
+            logger.error(f"Instancetype {args.instancetype} not found as {CLUSTER_INSTANCETYPE}"
+                         + (f" or {INSTANCETYPE} in {namespace}" if namespace else ''))
+            sys.exit(1)
+        preference = None
+        if args.preference:
+            preference = resolve(args.preference, CLUSTER_PREFERENCE, PREFERENCE, logger, namespace)
+            if preference is None:
+                logger.error(f"Preference {args.preference} not found as {CLUSTER_PREFERENCE}"
+                             + (f" or {PREFERENCE} in {namespace}" if namespace else ''))
+                sys.exit(1)
+        try:
+            args.vm_template = write_instancetype_template(args.vm_template, instancetype, preference)
+        except (OSError, yaml.YAMLError) as e:
+            logger.error(f"Cannot apply the instancetype to the VM template: {e}")
+            sys.exit(1)
+        args.vm_instancetype = instancetype_settings(instancetype, preference)
+        logger.info(f"Instancetype: {json.dumps(args.vm_instancetype)}")
+
+    # Performance tuning: create the VMs from a tuned copy of the template
+    if args.tuning and not args.skip_vm_creation:
+        try:
+            args.vm_template = write_tuned_template(args.vm_template, args.tuning)
+        except (OSError, yaml.YAMLError) as e:
+            logger.error(f"Cannot apply CPU/memory tuning to the VM template: {e}")
+            sys.exit(1)
+        logger.info(f"Tuning: {json.dumps(args.tuning)}")
+        if args.dedicated_cpus and cpu_manager_nodes(logger) == []:
+            logger.warning("No node is labelled cpumanager=true: VMs with dedicated CPUs will not schedule "
+                           "until the kubelet runs the static CPU manager policy")
+
+    # Durations use the monotonic clock; absolute times are recorded alongside
+    # the estimated client/cluster clock offset
+    timing.set_precision(args.precision)
+    agent_timeout = args.guest_agent_timeout if args.guest_agent else None
+    if args.guest_agent:
+        logger.info(f"Guest agent tracking enabled (agent timeout: {args.guest_agent_timeout}s)")
+    clock_skew = timing.estimate_clock_skew(logger=logger) if args.save_results else None
+    if args.save_results:
+        cluster_inventory(logger)
+
     if args.storage_driver:
         logger.info(f"Using storage driver label: {args.storage_driver}")
     if args.save_results:
@@ -976,8 +1749,15 @@ def main():
         # skip_vm_creation without num_disks - will detect from existing VM after namespaces are set
         logger.info("Disk count will be detected from existing VM")
 
+    # Reachability is checked from the managed checker pod unless --reachability ssh-pod
+    if args.reachability == REACHABILITY_CHECKER and not dry_run:
+        if start_checker(args.ssh_pod_ns, args.checker_host_network, logger) is None:
+            logger.warning(f"Falling back to pinging through SSH pod {args.ssh_pod_ns}/{args.ssh_pod}")
+            args.reachability = REACHABILITY_SSH_POD
+
     # Validate prerequisites
-    if not validate_prerequisites(args.ssh_pod, args.ssh_pod_ns, logger):
+    if not validate_prerequisites(args.ssh_pod if args.reachability == REACHABILITY_SSH_POD else None,
+                                  args.ssh_pod_ns, logger, namespace=args.single_namespace):
         logger.error("Prerequisites validation failed")
         sys.exit(1)
 
@@ -1006,8 +1786,78 @@ def main():
     else:
         logger.info("Multi-node mode: VMs will be distributed across all available nodes")
 
+    # Capacity preflight: refuse runs that cannot possibly fit before creating anything
+    capacity = None
+    if not args.skip_vm_creation:
+        try:
+            estimate = estimate_capacity(args.vm_template, args.end - args.start + 1, logger,
+                                         node_name=target_node)
+            print_estimate(estimate, logger)
+            capacity = {key: estimate[key] for key in ('status', 'vms_that_fit')}
+            if estimate['status'] == NO_FIT:
+                if not args.skip_capacity_check:
+                    logger.error("Aborting: the planned VMs cannot fit (use --skip-capacity-check to run anyway)")
+                    sys.exit(1)
+                logger.warning("Continuing despite the capacity estimate (--skip-capacity-check)")
+        except (OSError, ValueError, yaml.YAMLError) as e:
+            logger.warning(f"Capacity preflight skipped: {e}")
+
+    # GPU preflight: the devices must be permitted, advertised and sufficient, or the VMs never schedule
+    gpus = None
+    if not args.skip_vm_creation:
+        try:
+            gpus = template_gpus(args.vm_template)
+            for device_name, count in (args.gpus or {}).items():
+                gpus[device_name] = gpus.get(device_name, 0) + count
+        except (OSError, yaml.YAMLError) as e:
+            logger.warning(f"GPU preflight skipped: {e}")
+    if gpus:
+        report = check_gpus(gpus, args.end - args.start + 1, logger, node=target_node)
+        print_gpu_report(report, logger)
+        if report['errors']:
+            if not args.skip_gpu_check:
+                logger.error("Aborting: the GPU VMs cannot be scheduled (use --skip-gpu-check to run anyway)")
+                sys.exit(1)
+            logger.warning("Continuing despite the GPU preflight (--skip-gpu-check)")
+
+    # Secondary network preflight: the NADs must exist and SR-IOV VFs suffice; also picks each interface binding
+    if args.networks and not args.skip_vm_creation:
+        report = check_networks(args.networks, args.end - args.start + 1, logger, node=target_node)
+        print_network_report(report, logger)
+        if report['errors']:
+            if not args.skip_network_check:
+                logger.error("Aborting: the VMs cannot join their networks (use --skip-network-check to run anyway)")
+                sys.exit(1)
+            logger.warning("Continuing despite the network preflight (--skip-network-check)")
+        args.vm_networks = report['networks']
+
+    if dry_run:
+        if args.single_namespace:
+            targets = vm_targets(args.namespace_prefix, args.start, args.end, args.vm_name,
+                                 args.single_namespace)
+        else:
+            targets = [f"{args.namespace_prefix}-{i}" for i in range(args.start, args.end + 1)]
+        plan_run(args, targets, target_node, place_vms(args, targets, target_node, logger)[1], logger)
+        sys.exit(0)
+
+    # Pre-warm images and DataSources so timing measures provisioning, not registry bandwidth
+    if args.prewarm and not args.skip_vm_creation:
+        logger.info("\n" + "=" * 80)
+        logger.info("PRE-WARM - pulling images and waiting for DataSources (not measured)")
+        logger.info("=" * 80)
+        try:
+            print_prewarm(prewarm(args.vm_template, logger, node=target_node), logger)
+        except (OSError, yaml.YAMLError) as e:
+            logger.warning(f"Pre-warm skipped: {e}")
+
     # Create namespaces
-    if not args.skip_namespace_creation:
+    if args.single_namespace:
+        # Namespace-scoped mode: the namespace must already exist, nothing is created outside it
+        namespaces = vm_targets(args.namespace_prefix, args.start, args.end, args.vm_name,
+                                args.single_namespace)
+        namespaces_created.extend(namespaces)  # Track for cleanup on interrupt
+        logger.info(f"Using VMs {namespaces[0]} to {namespaces[-1]}")
+    elif not args.skip_namespace_creation:
         try:
             namespaces = ensure_namespaces(
                 args.start, args.end, args.namespace_prefix,
@@ -1024,6 +1874,8 @@ def main():
     # Initialize variables for results
     results = []
     out_dir = None
+    warmup = None
+    strategy, vm_nodes = place_vms(args, namespaces, target_node, logger)
 
     # Skip VM creation if requested (for boot-storm only tests)
     if args.skip_vm_creation:
@@ -1037,50 +1889,59 @@ def main():
 
         # Detect disk count from existing VM if not provided
         if not args.num_disks:
-            first_ns = namespaces[0]
+            first_ns, first_vm = split_vm_target(namespaces[0], args.vm_name)
             logger.info(f"Detecting disk count from existing VM in {first_ns}...")
-            num_disks_per_vm = get_vm_disk_count(first_ns, args.vm_name, logger)
+            num_disks_per_vm = get_vm_disk_count(first_ns, first_vm, logger)
 
         # Create output directory for results if saving
         if args.save_results:
             out_dir = args._results_dir
             logger.info(f"Using results directory: {out_dir}")
     else:
+        if args.warmup_iterations:
+            logger.info("\n" + "=" * 80)
+            logger.info(f"WARM-UP - up to {args.warmup_iterations} iterations (not measured)")
+            logger.info("=" * 80)
+            warmup = run_warmup(args, namespaces, vm_nodes, agent_timeout, logger)
+
         # Phase 1: Create all VMs in parallel
         logger.info(f"\nPhase 1: Creating {len(namespaces)} VMs in parallel...")
         if target_node:
             logger.info(f"Target node: {target_node}")
         if args.secret_yaml:
             logger.info(f"Using secret YAML: {args.secret_yaml}")
-        create_start = datetime.now()
+        injector = start_chaos(args, logger)
+        start_admission_metrics(logger)
+        create_start = timing.now()
         start_times = {}
+        adopted = set()
 
-        with ThreadPoolExecutor(max_workers=len(namespaces)) as executor:
-            futures = {
-                executor.submit(create_vm, ns, args.vm_template, target_node, logger, args.secret_yaml): ns
-                for ns in namespaces
-            }
-
-            for future in as_completed(futures):
-                try:
-                    ns, ts = future.result()
-                    start_times[ns] = ts
-                except Exception as e:
-                    ns = futures[future]
-                    logger.error(f"[{ns}] Failed to create VM: {e}")
+        outcomes = run_parallel(
+            lambda ns: create_vm(ns, args.vm_template, vm_nodes[ns], logger, args.secret_yaml, args.vm_name,
+                                gpus=args.gpus, networks=args.vm_networks),
+            strategy.order(namespaces, vm_nodes), concurrency=args.concurrency, qps=args.qps, burst=args.burst,
+            logger=logger, description="VM creation"
+        )
+        for ns, result, error in outcomes:
+            if error is None:
+                start_times[ns] = result[1]
+                if result[2]:
+                    adopted.add(ns)
 
-        create_elapsed = (datetime.now() - create_start).total_seconds()
+        create_elapsed = (timing.now() - create_start).total_seconds()
         logger.info(f"Phase 1 completed in {create_elapsed:.2f}s")
 
         # Phase 2: Monitor VMs
         logger.info(f"\nPhase 2: Monitoring {len(start_times)} VMs (concurrency={args.concurrency})...")
-        monitor_start = datetime.now()
+        monitor_start = timing.now()
 
-        with ThreadPoolExecutor(max_workers=args.concurrency) as executor:
+        with track_phase("VM boot", start_times, logger) as phase, \
+                ThreadPoolExecutor(max_workers=args.concurrency) as executor:
             futures = {
                 executor.submit(
                     monitor_vm, ns, args.vm_name, ts, args.ssh_pod, args.ssh_pod_ns,
-                    args.poll_interval, args.ping_timeout, logger, False, args.vm_template
+                    args.poll_interval, args.ping_timeout, logger, False, args.vm_template,
+                    args.guest_os, agent_timeout
                 ): ns
                 for ns, ts in start_times.items()
             }
@@ -1090,18 +1951,50 @@ def main():
                 try:
                     result = future.result()  # now returns (ns, run_time, ping_time, clone_time, success)
                     results.append(result)
+                    phase.finished(ns, ok=result[4])
                 except Exception as e:
                     logger.error(f"[{ns}] Monitoring failed: {e}")
                     results.append((ns, None, None, None, False))
+                    phase.finished(ns, ok=False)
 
-        monitor_elapsed = (datetime.now() - monitor_start).total_seconds()
-        total_elapsed = (datetime.now() - create_start).total_seconds()
+        monitor_elapsed = (timing.now() - monitor_start).total_seconds()
+        total_elapsed = (timing.now() - create_start).total_seconds()
+        chaos = stop_chaos(injector, results, start_times, logger)
 
         logger.info(f"Phase 2 completed in {monitor_elapsed:.2f}s")
         logger.info(f"Total test duration: {total_elapsed:.2f}s")
 
+        # Separate cold-start VMs (first per node / storage class) from steady state
+        placements = {}
+
+        def record_placement(ns, placement):
+            placements[ns] = placement
+
+        run_parallel(
+            lambda ns: call_for_target(get_vm_placement, ns, args.vm_name, logger), list(start_times),
+            concurrency=args.concurrency, qps=args.qps, burst=args.burst, logger=logger,
+            description="placement lookup", on_result=record_placement
+        )
+        node_zones = get_node_zones(topology_key=args.topology_key, logger=logger)
+        for vm_placement in placements.values():
+            vm_placement['zone'] = node_zones.get(vm_placement.get('node'))
+        start_order = sorted(start_times, key=lambda ns: start_times[ns])
+        cold_start = analyze_cold_start(results, placements, start_order)
+
+        metrics, status = boot_phase_metrics(results, total_elapsed)
+        notify_phase('datasource-clone', 'vms-created', metrics, status, logger=logger)
+
         # Print summary
         print_summary_table(results, "VM Creation Performance Test Results", logger=logger)
+        print_cold_start_summary(cold_start, logger=logger)
+        scheduling = describe(p.get('scheduling_time_sec') for p in placements.values())
+        if scheduling['count']:
+            logger.info(f"Scheduling latency (Pending to Scheduled): avg {scheduling['avg']}s, "
+                        f"p95 {scheduling['p95']}s, max {scheduling['max']}s over {scheduling['count']} VMs")
+        candidates = [target_node] if target_node else \
+            strategy.candidates([] if args.placement_nodes else get_worker_nodes(logger))
+        quality = placement_quality(placements, candidates, args.topology_key, logger)
+        print_placement_quality(quality, logger)
 
         # Save structured results if requested
         if args.save_results:
@@ -1115,7 +2008,20 @@ def main():
                 base_dir=out_dir,
                 prefix="vm_creation_results",
                 logger=logger,
-                total_time=total_elapsed
+                total_time=total_elapsed,
+                timing=timing.timing_metadata(create_start, clock_skew=clock_skew),
+                placements=placements,
+                cold_start=cold_start,
+                warmup=warmup,
+                placement=strategy.describe(vm_nodes),
+                placement_quality=quality,
+                gpus=gpus,
+                networks=args.vm_networks,
+                capacity=capacity,
+                tuning=args.tuning,
+                instancetype=args.vm_instancetype,
+                chaos=chaos,
+                adopted=adopted
             )
             logger.info(f"Detailed and summary results saved under: {out_dir}")
         else:
@@ -1130,31 +2036,26 @@ def main():
 
         # Phase 1: Stop all VMs
         logger.info("\nPhase 1: Stopping all VMs...")
-        stop_start = datetime.now()
-
-        with ThreadPoolExecutor(max_workers=args.concurrency) as executor:
-            stop_futures = {
-                executor.submit(stop_vm, args.vm_name, ns, logger): ns
-                for ns in namespaces
-            }
+        stop_start = timing.now()
 
-            for future in as_completed(stop_futures):
-                ns = stop_futures[future]
-                try:
-                    future.result()
-                except Exception as e:
-                    logger.error(f"[{ns}] Failed to stop VM: {e}")
+        run_parallel(
+            lambda ns: call_for_target(stop_vm, ns, args.vm_name, logger), namespaces,
+            concurrency=args.concurrency, qps=args.qps, burst=args.burst,
+            logger=logger, description="VM stop"
+        )
 
-        stop_elapsed = (datetime.now() - stop_start).total_seconds()
+        stop_elapsed = (timing.now() - stop_start).total_seconds()
         logger.info(f"Stop commands issued in {stop_elapsed:.2f}s")
 
         # Phase 2: Wait for all VMs to be fully stopped
         logger.info("\nPhase 2: Waiting for all VMs to be fully stopped...")
-        wait_start = datetime.now()
+        wait_start = timing.now()
+        stop_timeout = DEFAULT_WINDOWS_STOP_TIMEOUT if args.guest_os == GUEST_OS_WINDOWS else DEFAULT_STOP_TIMEOUT
 
         with ThreadPoolExecutor(max_workers=args.concurrency) as executor:
             wait_futures = {
-                executor.submit(wait_for_vm_stopped, args.vm_name, ns, 300, logger): ns
+                executor.submit(call_for_target, wait_for_vm_stopped, ns, args.vm_name,
+                                stop_timeout, logger): ns
                 for ns in namespaces
             }
 
@@ -1168,42 +2069,40 @@ def main():
                 except Exception as e:
                     logger.error(f"[{ns}] Error waiting for VM to stop: {e}")
 
-        wait_elapsed = (datetime.now() - wait_start).total_seconds()
+        wait_elapsed = (timing.now() - wait_start).total_seconds()
         logger.info(f"All VMs stopped in {wait_elapsed:.2f}s")
         logger.info(f"Successfully stopped: {stopped_count}/{len(namespaces)} VMs")
 
         # Phase 3: Start all VMs simultaneously (BOOT STORM)
         logger.info("\nPhase 3: Starting all VMs simultaneously (BOOT STORM)...")
-        boot_start = datetime.now()
+        injector = start_chaos(args, logger)
+        boot_start = timing.now()
         boot_start_times = {}
 
-        with ThreadPoolExecutor(max_workers=args.concurrency) as executor:
-            start_futures = {
-                executor.submit(start_vm, args.vm_name, ns, logger): ns
-                for ns in namespaces
-            }
+        def record_boot_start(ns, _):
+            boot_start_times[ns] = timing.now()
 
-            for future in as_completed(start_futures):
-                ns = start_futures[future]
-                try:
-                    future.result()
-                    boot_start_times[ns] = datetime.now()
-                except Exception as e:
-                    logger.error(f"[{ns}] Failed to start VM: {e}")
+        run_parallel(
+            lambda ns: call_for_target(start_vm, ns, args.vm_name, logger), namespaces,
+            concurrency=args.concurrency, qps=args.qps, burst=args.burst,
+            logger=logger, description="VM start", on_result=record_boot_start
+        )
 
-        boot_issue_elapsed = (datetime.now() - boot_start).total_seconds()
+        boot_issue_elapsed = (timing.now() - boot_start).total_seconds()
         logger.info(f"All start commands issued in {boot_issue_elapsed:.2f}s")
 
         # Phase 4: Monitor boot storm - wait for Running and Ping
         logger.info(f"\nPhase 4: Monitoring boot storm (concurrency: {args.concurrency})...")
-        monitor_start = datetime.now()
+        monitor_start = timing.now()
 
-        with ThreadPoolExecutor(max_workers=args.concurrency) as executor:
+        with track_phase("boot storm", boot_start_times, logger) as phase, \
+                ThreadPoolExecutor(max_workers=args.concurrency) as executor:
             boot_futures = {
                 executor.submit(
                     monitor_vm, ns, args.vm_name, ts, args.ssh_pod, args.ssh_pod_ns,
                     args.poll_interval, args.ping_timeout, logger, skip_dv_clone_tracking=True,
-                    vm_template_path=args.vm_template
+                    vm_template_path=args.vm_template, guest_os=args.guest_os,
+                    agent_timeout=agent_timeout
                 ): ns
                 for ns, ts in boot_start_times.items()
             }
@@ -1212,22 +2111,29 @@ def main():
                 try:
                     result = future.result()
                     boot_storm_results.append(result)
+                    phase.finished(boot_futures[future], ok=result[4])
                 except Exception as e:
                     ns = boot_futures[future]
                     logger.error(f"[{ns}] Boot storm monitoring failed: {e}")
                     boot_storm_results.append((ns, None, None, False))
+                    phase.finished(ns, ok=False)
 
-        boot_monitor_elapsed = (datetime.now() - monitor_start).total_seconds()
-        boot_total_elapsed = (datetime.now() - boot_start).total_seconds()
+        boot_monitor_elapsed = (timing.now() - monitor_start).total_seconds()
+        boot_total_elapsed = (timing.now() - boot_start).total_seconds()
+        chaos = stop_chaos(injector, boot_storm_results, boot_start_times, logger, skip_clone=True)
 
         logger.info(f"Boot storm monitoring completed in {boot_monitor_elapsed:.2f}s")
         logger.info(f"Total boot storm duration: {boot_total_elapsed:.2f}s")
 
+        metrics, status = boot_phase_metrics(boot_storm_results, boot_total_elapsed)
+        notify_phase('datasource-clone', 'boot-storm-complete', metrics, status, logger=logger)
+
         # Print boot storm summary
         print_summary_table(boot_storm_results, "Boot Storm Performance Test Results", skip_clone=True, logger=logger)
         if args.save_results:
             save_results(args, boot_storm_results, base_dir=out_dir, prefix="boot_storm_results", logger=logger,
-                         skip_clone=True, total_time=boot_total_elapsed)
+                         skip_clone=True, total_time=boot_total_elapsed,
+                         timing=timing.timing_metadata(boot_start, clock_skew=clock_skew), chaos=chaos)
 
     failed_count = sum(1 for r in results if len(r) > 4 and not r[4]) if results else 0
     should_cleanup = args.cleanup or (args.cleanup_on_failure and failed_count > 0)
@@ -1253,7 +2159,11 @@ def main():
                     delete_namespaces=True,
                     dry_run=args.dry_run_cleanup,
                     batch_size=args.namespace_batch_size,
-                    logger=logger
+                    logger=logger,
+                    qps=args.qps,
+                    burst=args.burst,
+                    single_namespace=args.single_namespace,
+                    selector=cleanup_selector
                 )
 
                 print_cleanup_summary(stats, logger)
@@ -1265,7 +2175,14 @@ def main():
                 logger.error(f"Error during cleanup: {e}")
                 logger.warning("Some resources may not have been cleaned up")
 
+    stop_checker(delete_pod=should_cleanup and not args.dry_run_cleanup, logger=logger)
+
     logger.info("\nTest completed successfully!")
+    run_metrics = {'vms': len(namespaces), 'failed': failed_count}
+    run_metrics.update(percentile_metrics('running_sec', [r[1] for r in results or boot_storm_results]))
+    run_metrics.update(percentile_metrics('ping_sec', [r[2] for r in results or boot_storm_results]))
+    notify_run('datasource-clone', run_metrics, phase_status(failed_count, len(namespaces)),
+               args._results_dir if args.save_results else None, logger)
 
     # Exit with error code if any VMs failed
     sys.exit(0 if failed_count == 0 else 1)
@@ -0,0 +1,675 @@
+#!/usr/bin/env python3
+"""
+KubeVirt Descheduler Rebalancing Benchmark
+
+Packs running benchmark VMs onto one node, then lets the descheduler spread
+them out again and measures how quickly and how evenly it does so:
+
+  - eviction strategy: sets spec.template.spec.evictionStrategy (and the
+    descheduler.alpha.kubernetes.io/evict annotation the descheduler needs
+    to evict virt-launcher pods) on every benchmark VM
+  - descheduler: optionally points the KubeDescheduler operator CR at a
+    profile (e.g. KubeVirtRelieveAndMigrate) and descheduling interval,
+    and restores its previous settings afterwards
+  - imbalance: cordons every other node and live migrates (or restarts)
+    the benchmark VMs onto one node, then uncordons the nodes
+  - rebalancing: from the uncordon, polls the VMIs until the benchmark VMs
+    per node differ by at most --balance-tolerance, counting the migrations
+    and restarts the evictions triggered
+
+Changes to a VM's evictionStrategy or template annotations only reach a
+running VMI when it restarts, so VMs whose VMI does not match yet are
+restarted during the imbalance step rather than migrated.
+
+Usage:
+    # Point the descheduler at the KubeVirt profile and measure rebalancing
+    python3 measure-descheduler.py --start 1 --end 50 \\
+        --descheduler-profile KubeVirtRelieveAndMigrate --descheduling-interval 60 --save-results
+
+    # Measure an already configured descheduler on an existing imbalance
+    python3 measure-descheduler.py --start 1 --end 50 --skip-imbalance
+
+Author: KubeVirt Benchmark Suite Contributors
+License: Apache 2.0
+"""
+
+import argparse
+import csv
+import json
+import os
+import sys
+import time
+from collections import Counter
+from datetime import datetime
+from typing import Dict, List, Optional
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
+
+from utils import timing
+from utils.common import (
+    setup_logging, run_kubectl_command, uncordon_node, migrate_vm, restart_vm, calculate_vmim_duration,
+    round_duration, run_metadata,
+)
+from utils.custommetrics import collect_custom_metrics
+from utils.events import collect_run_events, watch_events
+from utils.inventory import cluster_inventory, resolve_storage_driver
+from utils.notify import (
+    notify_phase, notify_run, watch_run, phase_status, duration_metrics, percentile_metrics,
+)
+from utils.dryrun import DryRunPlan, is_dry_run
+from utils.stats import STAT_FIELDS, coefficient_of_variation, log_outliers, metric_outliers, metric_stats
+from utils.storageprovider import collect_storage_backend
+from utils.utilization import collect_node_samples, sample_nodes
+
+# Default configuration
+DEFAULT_VM_NAME = 'rhel-9-vm'
+DEFAULT_NAMESPACE_PREFIX = 'kubevirt-perf-test'
+DEFAULT_DESCHEDULER_NAMESPACE = 'openshift-kube-descheduler-operator'
+DEFAULT_TIMEOUT = 1800
+DEFAULT_IMBALANCE_TIMEOUT = 900
+DEFAULT_BALANCE_TOLERANCE = 1
+DEFAULT_POLL_INTERVAL = 2.0
+
+EVICTION_STRATEGIES = ['LiveMigrate', 'LiveMigrateIfPossible', 'External', 'None']
+# Strategies under which an eviction live migrates the VM
+MIGRATING_STRATEGIES = ('LiveMigrate', 'LiveMigrateIfPossible')
+
+# Annotation the descheduler requires on virt-launcher pods before it evicts them
+EVICT_ANNOTATION = 'descheduler.alpha.kubernetes.io/evict'
+KUBEDESCHEDULER = 'kubedeschedulers.operator.openshift.io'
+KUBEDESCHEDULER_NAME = 'cluster'
+
+
+def parse_args():
+    parser = argparse.ArgumentParser(
+        description='Measure how the descheduler rebalances benchmark VMs packed onto one node',
+        formatter_class=argparse.RawDescriptionHelpFormatter,
+    )
+    parser.add_argument('--start', type=int, default=1, help='Starting namespace index (default: 1)')
+    parser.add_argument('--end', type=int, default=10, help='Ending namespace index (default: 10)')
+    parser.add_argument('--namespace-prefix', default=DEFAULT_NAMESPACE_PREFIX,
+                        help=f'Namespace prefix (default: {DEFAULT_NAMESPACE_PREFIX})')
+    parser.add_argument('--vm-name', default=DEFAULT_VM_NAME,
+                        help=f'VM name in each namespace (default: {DEFAULT_VM_NAME})')
+    parser.add_argument('--eviction-strategy', choices=EVICTION_STRATEGIES, default=None,
+                        help='Set this evictionStrategy on the benchmark VMs (default: leave them unchanged)')
+    parser.add_argument('--descheduler-namespace', default=DEFAULT_DESCHEDULER_NAMESPACE,
+                        help=f'Namespace of the descheduler (default: {DEFAULT_DESCHEDULER_NAMESPACE})')
+    parser.add_argument('--descheduler-profile', nargs='+', default=None,
+                        help='Set these KubeDescheduler profiles for the run, e.g. KubeVirtRelieveAndMigrate '
+                             '(default: leave the descheduler unchanged)')
+    parser.add_argument('--descheduling-interval', type=int, default=None,
+                        help='Set the KubeDescheduler deschedulingIntervalSeconds for the run')
+    parser.add_argument('--skip-descheduler-check', action='store_true',
+                        help='Run even when no descheduler is found')
+    parser.add_argument('--imbalance-node', default=None,
+                        help='Node to pack the VMs onto (default: the node hosting the most benchmark VMs)')
+    parser.add_argument('--skip-imbalance', action='store_true',
+                        help='Measure rebalancing of the current placement without packing the VMs first')
+    parser.add_argument('--imbalance-timeout', type=int, default=DEFAULT_IMBALANCE_TIMEOUT,
+                        help=f'Seconds to wait for the VMs to reach the imbalance node '
+                             f'(default: {DEFAULT_IMBALANCE_TIMEOUT})')
+    parser.add_argument('--balance-tolerance', type=int, default=DEFAULT_BALANCE_TOLERANCE,
+                        help=f'Balanced when VMs per node differ by at most this many '
+                             f'(default: {DEFAULT_BALANCE_TOLERANCE})')
+    parser.add_argument('--timeout', type=int, default=DEFAULT_TIMEOUT,
+                        help=f'Seconds to wait for the VMs to be balanced (default: {DEFAULT_TIMEOUT})')
+    parser.add_argument('--poll-interval', type=float, default=DEFAULT_POLL_INTERVAL,
+                        help=f'Seconds between VMI status checks (default: {DEFAULT_POLL_INTERVAL})')
+    parser.add_argument('--precision', type=int, default=timing.DEFAULT_PRECISION,
+                        help=f'Decimal places for durations in saved results (default: {timing.DEFAULT_PRECISION})')
+    parser.add_argument('--save-results', action='store_true', help='Save results as JSON and CSV')
+    parser.add_argument('--results-folder', default='results',
+                        help='Base directory for results (default: results)')
+    parser.add_argument('--storage-driver', default=None,
+                        help='Storage driver label to include in the results path, or auto to detect it')
+    parser.add_argument('--log-file', default=None, help='Log file path')
+    parser.add_argument('--log-level', default='INFO',
+                        choices=['DEBUG', 'INFO', 'WARNING', 'ERROR'], help='Logging level (default: INFO)')
+    args = parser.parse_args()
+
+    if args.poll_interval <= 0:
+        parser.error("--poll-interval must be > 0")
+    if args.timeout < 1 or args.imbalance_timeout < 1:
+        parser.error("--timeout and --imbalance-timeout must be >= 1")
+    if args.balance_tolerance < 0:
+        parser.error("--balance-tolerance must be >= 0")
+    if args.descheduling_interval is not None and args.descheduling_interval < 1:
+        parser.error("--descheduling-interval must be >= 1")
+    if args.skip_imbalance and args.imbalance_node:
+        parser.error("--imbalance-node cannot be combined with --skip-imbalance")
+    return args
+
+
+def build_results_dir(args, timestamp: Optional[str] = None) -> str:
+    """Build the canonical descheduler results directory."""
+    timestamp = timestamp or datetime.now().strftime("%Y%m%d-%H%M%S")
+    suffix = f"{args.namespace_prefix}_{args.start}-{args.end}"
+    if args.storage_driver:
+        return os.path.join(args.results_folder, args.storage_driver, 'descheduler', f"{timestamp}_{suffix}")
+    return os.path.join(args.results_folder, 'descheduler', f"{timestamp}_{suffix}")
+
+
+def get_benchmark_vmis(namespaces: List[str], vm_name: str, logger) -> Optional[Dict[str, Dict]]:
+    """Return {namespace: VMI object} for the benchmark VMIs with one cluster-wide list call, None on error."""
+    rc, stdout, stderr = run_kubectl_command(['get', 'vmi', '-A', '-o', 'json'], check=False, logger=logger)
+    if rc != 0:
+        logger.debug(f"Failed to list VMIs: {stderr.strip()}")
+        return None
+    wanted = set(namespaces)
+    vmis = {}
+    for item in json.loads(stdout).get('items', []):
+        metadata = item.get('metadata', {})
+        if metadata.get('name') == vm_name and metadata.get('namespace') in wanted:
+            vmis[metadata['namespace']] = item
+    return vmis
+
+
+def schedulable_nodes(logger) -> Optional[List[str]]:
+    """Ready, uncordoned nodes without NoSchedule taints (where the descheduler can place VMs), None on error."""
+    rc, stdout, stderr = run_kubectl_command(['get', 'nodes', '-o', 'json'], check=False, logger=logger)
+    if rc != 0:
+        logger.error(f"Failed to list nodes: {stderr.strip()}")
+        return None
+    nodes = []
+    for item in json.loads(stdout).get('items', []):
+        spec = item.get('spec', {})
+        ready = any(c.get('type') == 'Ready' and c.get('status') == 'True'
+                    for c in item.get('status', {}).get('conditions', []))
+        tainted = any(t.get('effect') in ('NoSchedule', 'NoExecute') for t in spec.get('taints') or [])
+        if ready and not spec.get('unschedulable') and not tainted:
+            nodes.append(item['metadata']['name'])
+    return sorted(nodes)
+
+
+def placement(vmis: Dict[str, Dict], nodes: List[str]) -> Dict[str, int]:
+    """Benchmark VMs per node, with every schedulable node listed."""
+    counts = Counter(v.get('status', {}).get('nodeName') for v in vmis.values())
+    counts.pop(None, None)
+    for node in nodes:
+        counts.setdefault(node, 0)
+    return dict(sorted(counts.items()))
+
+
+def spread(counts: Dict[str, int]) -> Dict:
+    """Max - min VMs per node and their coefficient of variation."""
+    values = list(counts.values())
+    cv = coefficient_of_variation(values)
+    return {
+        'max_min_spread': max(values) - min(values) if values else None,
+        'cv': round(cv, 3) if cv is not None else None,
+    }
+
+
+def get_descheduler(namespace: str, logger) -> Optional[Dict]:
+    """The KubeDescheduler operator CR, None when the operator is not installed."""
+    rc, stdout, _ = run_kubectl_command(
+        ['get', KUBEDESCHEDULER, KUBEDESCHEDULER_NAME, '-n', namespace, '-o', 'json'], check=False, logger=logger)
+    if rc != 0:
+        return None
+    try:
+        return json.loads(stdout)
+    except json.JSONDecodeError:
+        return None
+
+
+def descheduler_pods(namespace: str, logger) -> int:
+    """Running pods named descheduler* in the namespace (operator-managed or a plain deployment)."""
+    rc, stdout, _ = run_kubectl_command(
+        ['get', 'pods', '-n', namespace, '--field-selector=status.phase=Running',
+         '-o', 'jsonpath={.items[*].metadata.name}'], check=False, logger=logger)
+    if rc != 0:
+        return 0
+    return sum(1 for name in stdout.split() if name.startswith('descheduler'))
+
+
+def descheduler_settings(args) -> Dict:
+    """KubeDescheduler spec fields the run sets."""
+    settings = {}
+    if args.descheduler_profile:
+        settings['profiles'] = args.descheduler_profile
+        settings['mode'] = 'Automatic'
+    if args.descheduling_interval:
+        settings['deschedulingIntervalSeconds'] = args.descheduling_interval
+    return settings
+
+
+def patch_descheduler(namespace: str, spec: Dict, logger) -> bool:
+    """Merge-patch the KubeDescheduler spec (None values remove a field)."""
+    rc, _, stderr = run_kubectl_command(
+        ['patch', KUBEDESCHEDULER, KUBEDESCHEDULER_NAME, '-n', namespace, '--type', 'merge',
+         '-p', json.dumps({'spec': spec})], check=False, logger=logger)
+    if rc != 0:
+        logger.error(f"Failed to patch the KubeDescheduler: {stderr.strip()}")
+        return False
+    return True
+
+
+def configure_descheduler(args, logger) -> Optional[Dict]:
+    """
+    Apply descheduler_settings() to the KubeDescheduler.
+
+    Returns:
+        The previous values of the patched fields, for restore_descheduler(), or None when unchanged
+    """
+    settings = descheduler_settings(args)
+    if not settings:
+        return None
+    current = (get_descheduler(args.descheduler_namespace, logger) or {}).get('spec', {})
+    previous = {key: current.get(key) for key in settings}
+    if not patch_descheduler(args.descheduler_namespace, settings, logger):
+        return None
+    logger.info(f"KubeDescheduler set to {json.dumps(settings)}")
+    return previous
+
+
+def restore_descheduler(args, previous: Optional[Dict], logger):
+    if previous is not None and patch_descheduler(args.descheduler_namespace, previous, logger):
+        logger.info("KubeDescheduler settings restored")
+
+
+def prepare_vm(namespace: str, args, logger) -> bool:
+    """Set the eviction strategy and the descheduler evict annotation on a benchmark VM's template."""
+    template = {'metadata': {'annotations': {EVICT_ANNOTATION: 'true'}}}
+    if args.eviction_strategy:
+        template['spec'] = {'evictionStrategy': args.eviction_strategy}
+    rc, _, stderr = run_kubectl_command(
+        ['patch', 'vm', args.vm_name, '-n', namespace, '--type', 'merge',
+         '-p', json.dumps({'spec': {'template': template}})], check=False, logger=logger)
+    if rc != 0:
+        logger.error(f"[{namespace}] Failed to patch VM {args.vm_name}: {stderr.strip()}")
+        return False
+    return True
+
+
+def needs_restart(vmi: Dict, args) -> bool:
+    """Whether the running VMI predates prepare_vm() and only picks it up by restarting."""
+    annotations = vmi.get('metadata', {}).get('annotations') or {}
+    if annotations.get(EVICT_ANNOTATION) != 'true':
+        return True
+    return bool(args.eviction_strategy) and vmi.get('spec', {}).get('evictionStrategy') != args.eviction_strategy
+
+
+def cordon(node: str, logger) -> bool:
+    rc, _, stderr = run_kubectl_command(['cordon', node], check=False, logger=logger)
+    if rc != 0:
+        logger.error(f"Failed to cordon node {node}: {stderr.strip()}")
+        return False
+    return True
+
+
+def create_imbalance(node: str, nodes: List[str], namespaces: List[str], vmis: Dict[str, Dict], args,
+                     logger) -> Dict[str, Dict]:
+    """
+    Cordon every node but one, move the benchmark VMs onto it and uncordon the nodes.
+
+    VMs that must restart to pick up prepare_vm() are restarted, the others live migrated.
+
+    Returns:
+        The benchmark VMIs once every VM runs on the node (or the imbalance timed out)
+    """
+    others = [n for n in nodes if n != node]
+    cordoned = []
+    try:
+        for other in others:
+            if cordon(other, logger):
+                cordoned.append(other)
+        logger.info(f"Cordoned {len(cordoned)} nodes; moving the benchmark VMs onto {node}")
+        for ns, vmi in vmis.items():
+            if needs_restart(vmi, args):
+                restart_vm(args.vm_name, ns, logger)
+            elif vmi.get('status', {}).get('nodeName') != node:
+                migrate_vm(args.vm_name, ns, logger=logger)
+
+        deadline = time.monotonic() + args.imbalance_timeout
+        while True:
+            vmis = get_benchmark_vmis(namespaces, args.vm_name, logger) or {}
+            packed = [ns for ns, v in vmis.items()
+                      if v.get('status', {}).get('nodeName') == node and v.get('status', {}).get('phase') == 'Running'
+                      and not needs_restart(v, args)]
+            if len(packed) == len(namespaces):
+                logger.info(f"All {len(packed)} benchmark VMs run on {node}")
+                break
+            if time.monotonic() >= deadline:
+                logger.warning(f"Only {len(packed)}/{len(namespaces)} benchmark VMs reached {node} "
+                               f"within {args.imbalance_timeout}s; measuring from this placement")
+                break
+            time.sleep(args.poll_interval)
+    finally:
+        for other in cordoned:
+            uncordon_node(other, logger)
+    return vmis
+
+
+def track_rebalancing(namespaces: List[str], nodes: List[str], before: Dict[str, Dict], args, logger):
+    """
+    Follow the benchmark VMs from the uncordon until they are balanced across the nodes.
+
+    Returns:
+        Tuple of (per-VM rows, rebalance row, timeline)
+    """
+    rows = {
+        ns: {'namespace': ns, 'node_before': before.get(ns, {}).get('status', {}).get('nodeName'),
+             'node_after': None, 'migrations': 0, 'restarts': 0, 'first_migration_sec': None,
+             'vmim_times_sec': []}
+        for ns in namespaces
+    }
+    old_uids = {ns: (before.get(ns, {}).get('status', {}).get('migrationState') or {}).get('migrationUid')
+                for ns in namespaces}
+    started_uids, completed = set(), set()
+    vmi_uids = {ns: before.get(ns, {}).get('metadata', {}).get('uid') for ns in namespaces}
+    result = {'balanced': False, 'first_migration_sec': None, 'balanced_sec': None}
+    timeline = []
+
+    started = timing.now()
+    deadline = time.monotonic() + args.timeout
+    while True:
+        elapsed = (timing.now() - started).total_seconds()
+        vmis = get_benchmark_vmis(namespaces, args.vm_name, logger)
+        if vmis is not None:
+            in_flight = 0
+            for ns in namespaces:
+                row = rows[ns]
+                vmi = vmis.get(ns, {})
+                uid = vmi.get('metadata', {}).get('uid')
+                if uid and vmi_uids[ns] and uid != vmi_uids[ns]:
+                    # Evicted without live migration (evictionStrategy None) and started again
+                    row['restarts'] += 1
+                    logger.info(f"[{ns}] VM restarted on {vmi.get('status', {}).get('nodeName')} ({elapsed:.2f}s)")
+                if uid:
+                    vmi_uids[ns] = uid
+                state = vmi.get('status', {}).get('migrationState') or {}
+                migration_uid = state.get('migrationUid')
+                if migration_uid and migration_uid != old_uids[ns]:
+                    old_uids[ns] = migration_uid
+                    started_uids.add(migration_uid)
+                    row['migrations'] += 1
+                    if row['first_migration_sec'] is None:
+                        row['first_migration_sec'] = elapsed
+                    if result['first_migration_sec'] is None:
+                        result['first_migration_sec'] = elapsed
+                    logger.info(f"[{ns}] Migration started to {state.get('targetNode')} ({elapsed:.2f}s)")
+                if migration_uid in started_uids and state.get('completed') and migration_uid not in completed:
+                    completed.add(migration_uid)
+                    if not state.get('failed'):
+                        row['vmim_times_sec'].append(calculate_vmim_duration(
+                            state.get('startTimestamp'), state.get('endTimestamp')))
+                elif migration_uid and not state.get('completed'):
+                    in_flight += 1
+
+            counts = placement(vmis, nodes)
+            if not timeline or timeline[-1]['placement'] != counts:
+                timeline.append({'elapsed_sec': round_duration(elapsed), 'placement': counts,
+                                 **spread(counts)})
+                logger.info(f"Placement at {elapsed:.2f}s: {counts}")
+            if not in_flight and spread(counts)['max_min_spread'] <= args.balance_tolerance:
+                result.update(balanced=True, balanced_sec=elapsed)
+                logger.info(f"Benchmark VMs balanced after {elapsed:.2f}s")
+                break
+
+        if time.monotonic() >= deadline:
+            logger.error(f"Benchmark VMs not balanced within {args.timeout}s")
+            break
+        time.sleep(args.poll_interval)
+
+    for ns, vmi in (vmis or {}).items():
+        rows[ns]['node_after'] = vmi.get('status', {}).get('nodeName')
+    return [rows[ns] for ns in namespaces], result, timeline
+
+
+def rebalance_outliers(rows: List[Dict]) -> List[Dict]:
+    """VMs migrated more than --outlier-sigma standard deviations later than the mean."""
+    return metric_outliers([r for r in rows if r['migrations']], ('first_migration_sec',),
+                           lambda r: r['namespace'])
+
+
+def print_summary(rows: List[Dict], result: Dict, before: Dict[str, int], after: Dict[str, int], logger) -> None:
+    def fmt(value):
+        return f"{value:.2f}" if value is not None else '-'
+
+    logger.info("")
+    logger.info("=" * 100)
+    logger.info("DESCHEDULER REBALANCING RESULTS")
+    logger.info("=" * 100)
+    logger.info(f"{'Namespace':<30}{'Node Before':<22}{'Node After':<22}{'First Mig (s)':>14}"
+                f"{'Migrations':>11}{'Restarts':>10}")
+    logger.info("-" * 100)
+    for r in sorted(rows, key=lambda r: r['first_migration_sec'] or float('inf')):
+        logger.info(f"{r['namespace']:<30}{(r['node_before'] or '-'):<22}{(r['node_after'] or '-'):<22}"
+                    f"{fmt(r['first_migration_sec']):>14}{r['migrations']:>11}{r['restarts']:>10}")
+    logger.info("-" * 100)
+    logger.info(f"  Time to first migration:      {fmt(result['first_migration_sec'])}s")
+    logger.info(f"  Time to balanced:             {fmt(result['balanced_sec'])}s")
+    logger.info(f"  Migrations triggered:         {sum(r['migrations'] for r in rows)}")
+    logger.info(f"  Restarts:                     {sum(r['restarts'] for r in rows)}")
+    logger.info(f"  VMs moved:                    "
+                f"{sum(1 for r in rows if r['node_after'] and r['node_after'] != r['node_before'])}/{len(rows)}")
+    logger.info("")
+    logger.info(f"  {'Node':<30}{'VMs before':>12}{'VMs after':>12}")
+    for node in sorted(set(before) | set(after)):
+        logger.info(f"  {node:<30}{before.get(node, 0):>12}{after.get(node, 0):>12}")
+    spread_before, spread_after = spread(before), spread(after)
+    logger.info(f"  {'Max - min spread':<30}{spread_before['max_min_spread']:>12}{spread_after['max_min_spread']:>12}")
+    logger.info(f"  {'Coefficient of variation':<30}{fmt(spread_before['cv']):>12}{fmt(spread_after['cv']):>12}")
+    logger.info("=" * 100)
+    log_outliers(rebalance_outliers(rows), logger)
+
+
+def save_descheduler_results(out_dir: str, args, rows: List[Dict], result: Dict, timeline: List[Dict],
+                             before: Dict[str, int], after: Dict[str, int], descheduler: Dict,
+                             imbalance_node: Optional[str], timing_block: Dict, logger) -> None:
+    """Write per-VM results and the rebalancing summary as JSON and CSV."""
+    os.makedirs(out_dir, exist_ok=True)
+    saved = []
+    for r in rows:
+        row = dict(r)
+        row['first_migration_sec'] = round_duration(row['first_migration_sec'])
+        row['vmim_times_sec'] = [round_duration(t) for t in row['vmim_times_sec']]
+        saved.append(row)
+    with open(os.path.join(out_dir, 'descheduler_results.json'), 'w') as f:
+        json.dump(saved, f, indent=4)
+    with open(os.path.join(out_dir, 'descheduler_results.csv'), 'w', newline='') as f:
+        writer = csv.DictWriter(f, fieldnames=saved[0].keys())
+        writer.writeheader()
+        writer.writerows(dict(r, vmim_times_sec=' '.join(str(t) for t in r['vmim_times_sec'])) for r in saved)
+
+    metrics = [
+        metric_stats('first_migration_sec', [result['first_migration_sec']]),
+        metric_stats('balanced_sec', [result['balanced_sec']]),
+        metric_stats('vm_first_migration_sec', [r['first_migration_sec'] for r in rows]),
+        metric_stats('vmim_time_sec', [t for r in rows for t in r['vmim_times_sec']]),
+    ]
+    summary = {
+        'eviction_strategy': args.eviction_strategy,
+        'descheduler': descheduler,
+        'imbalance_node': imbalance_node,
+        'balance_tolerance': args.balance_tolerance,
+        'balanced': result['balanced'],
+        'total_vms': len(rows),
+        'migrations_triggered': sum(r['migrations'] for r in rows),
+        'restarts': sum(r['restarts'] for r in rows),
+        'vms_moved': sum(1 for r in rows if r['node_after'] and r['node_after'] != r['node_before']),
+        'placement_before': before,
+        'placement_after': after,
+        'spread_before': spread(before),
+        'spread_after': spread(after),
+        'timeline': timeline,
+        'metrics': metrics,
+        'outliers': rebalance_outliers(rows),
+        'timing': timing_block,
+    }
+    custom_metrics = collect_custom_metrics(timing_block, logger)
+    if custom_metrics is not None:
+        summary['custom_metrics'] = custom_metrics
+    run_events = collect_run_events(out_dir, logger, summary.get('outliers'))
+    if run_events is not None:
+        summary['events'] = run_events
+    node_utilization = collect_node_samples(out_dir, logger)
+    if node_utilization is not None:
+        summary['node_utilization'] = node_utilization
+    storage_backend = collect_storage_backend(out_dir, args.namespace_prefix, logger)
+    if storage_backend is not None:
+        summary['storage_backend'] = storage_backend
+    summary['cluster'] = cluster_inventory(logger)
+    summary['run'] = run_metadata()
+    with open(os.path.join(out_dir, 'summary_descheduler_results.json'), 'w') as f:
+        json.dump(summary, f, indent=4)
+    with open(os.path.join(out_dir, 'summary_descheduler_results.csv'), 'w', newline='') as f:
+        writer = csv.DictWriter(f, fieldnames=['metric'] + STAT_FIELDS)
+        writer.writeheader()
+        writer.writerows(metrics)
+    logger.info(f"Results saved under: {out_dir}")
+
+
+def plan_run(node: Optional[str], nodes: List[str], vmis: Dict[str, Dict], args, logger):
+    """Print the changes main() would make and the VMs it would move (virtbench --dry-run)."""
+    plan = DryRunPlan('descheduler', logger)
+    settings = descheduler_settings(args)
+    if settings:
+        plan.action('patch', f"kubedescheduler/{args.descheduler_namespace}/{KUBEDESCHEDULER_NAME}",
+                    json.dumps(settings))
+    for ns in vmis:
+        plan.action('patch', f"vm/{ns}/{args.vm_name}",
+                    f"evictionStrategy={args.eviction_strategy or 'unchanged'}, {EVICT_ANNOTATION}=true")
+    if node:
+        for other in nodes:
+            if other != node:
+                plan.action('cordon', f"node/{other}")
+        for ns, vmi in vmis.items():
+            if needs_restart(vmi, args):
+                plan.action('restart', f"vm/{ns}/{args.vm_name}", f"onto {node}")
+            elif vmi.get('status', {}).get('nodeName') != node:
+                plan.action('migrate', f"vm/{ns}/{args.vm_name}", f"onto {node}")
+        for other in nodes:
+            if other != node:
+                plan.action('uncordon', f"node/{other}")
+    if settings:
+        plan.action('restore', f"kubedescheduler/{args.descheduler_namespace}/{KUBEDESCHEDULER_NAME}")
+    plan.report()
+
+
+def main():
+    args = parse_args()
+    args.storage_driver = resolve_storage_driver(args.storage_driver)
+    dry_run = is_dry_run()
+    if dry_run:
+        # A dry run has no results to save
+        args.save_results = False
+
+    out_dir = None
+    if args.save_results:
+        out_dir = build_results_dir(args)
+        os.makedirs(out_dir, exist_ok=True)
+        if not args.log_file:
+            args.log_file = os.path.join(out_dir, 'descheduler.log')
+
+    logger = setup_logging(args.log_file, args.log_level)
+    watch_run('descheduler', logger)
+    watch_events(args.namespace_prefix, logger)
+    sample_nodes(logger)
+    timing.set_precision(args.precision)
+
+    logger.info("=" * 80)
+    logger.info("KubeVirt Descheduler Rebalancing Benchmark")
+    logger.info("=" * 80)
+    logger.info(f"Namespaces: {args.namespace_prefix}-{args.start} to {args.namespace_prefix}-{args.end}")
+    logger.info(f"VM: {args.vm_name}")
+    logger.info(f"Eviction strategy: {args.eviction_strategy or 'unchanged'}")
+    logger.info("=" * 80)
+
+    # Preflight: a descheduler to measure
+    kubedescheduler = get_descheduler(args.descheduler_namespace, logger)
+    if descheduler_settings(args) and kubedescheduler is None:
+        logger.error(f"No KubeDescheduler '{KUBEDESCHEDULER_NAME}' in {args.descheduler_namespace}; "
+                     f"--descheduler-profile and --descheduling-interval need the descheduler operator")
+        sys.exit(1)
+    if kubedescheduler is None and not descheduler_pods(args.descheduler_namespace, logger):
+        if not args.skip_descheduler_check:
+            logger.error(f"No descheduler found in {args.descheduler_namespace} "
+                         f"(set --descheduler-namespace, or --skip-descheduler-check to run anyway)")
+            sys.exit(1)
+        logger.warning(f"No descheduler found in {args.descheduler_namespace}; continuing")
+    descheduler = {
+        'namespace': args.descheduler_namespace,
+        'operator': kubedescheduler is not None,
+        **{key: (kubedescheduler or {}).get('spec', {}).get(key)
+           for key in ('profiles', 'mode', 'deschedulingIntervalSeconds')},
+        **descheduler_settings(args),
+    }
+
+    if args.eviction_strategy not in (None, *MIGRATING_STRATEGIES):
+        logger.warning(f"evictionStrategy {args.eviction_strategy}: evicted VMs are not live migrated")
+
+    namespaces = [f"{args.namespace_prefix}-{i}" for i in range(args.start, args.end + 1)]
+    nodes = schedulable_nodes(logger)
+    vmis = get_benchmark_vmis(namespaces, args.vm_name, logger) or {}
+    if not vmis or not nodes:
+        logger.error("No running benchmark VMIs found" if not vmis else "No schedulable nodes found")
+        sys.exit(1)
+    missing = sorted(set(namespaces) - set(vmis))
+    if missing:
+        logger.warning(f"{len(missing)} benchmark VMs are not running and are left out: {', '.join(missing[:5])}"
+                       + (' ...' if len(missing) > 5 else ''))
+        namespaces = [ns for ns in namespaces if ns in vmis]
+    if len(nodes) < 2:
+        logger.error(f"Rebalancing needs at least 2 schedulable nodes, found {len(nodes)}")
+        sys.exit(1)
+
+    node = None
+    if not args.skip_imbalance:
+        current = placement(vmis, nodes)
+        node = args.imbalance_node or max(current, key=current.get)
+        if node not in nodes:
+            logger.error(f"Imbalance node {node} is not a schedulable node ({', '.join(nodes)})")
+            sys.exit(1)
+
+    if dry_run:
+        plan_run(node, nodes, vmis, args, logger)
+        sys.exit(0)
+
+    clock_skew = timing.estimate_clock_skew(logger=logger) if args.save_results else None
+    if args.save_results:
+        cluster_inventory(logger)
+
+    for ns in namespaces:
+        prepare_vm(ns, args, logger)
+    stale = [ns for ns in namespaces if needs_restart(vmis[ns], args)]
+    if stale and args.skip_imbalance:
+        logger.warning(f"{len(stale)} running VMIs predate the eviction strategy or evict annotation "
+                       f"and take them only after a restart (without --skip-imbalance they are restarted)")
+
+    previous = configure_descheduler(args, logger)
+    test_start = timing.now()
+    try:
+        if node:
+            vmis = create_imbalance(node, nodes, namespaces, vmis, args, logger)
+        before = placement(vmis, nodes)
+        logger.info(f"Placement before rebalancing: {before}")
+        rows, result, timeline = track_rebalancing(namespaces, nodes, vmis, args, logger)
+    finally:
+        restore_descheduler(args, previous, logger)
+
+    after = placement(get_benchmark_vmis(namespaces, args.vm_name, logger) or {}, nodes)
+    print_summary(rows, result, before, after, logger)
+
+    migrations = sum(r['migrations'] for r in rows)
+    phase_metrics = {'vms': len(rows), 'migrations': migrations, 'restarts': sum(r['restarts'] for r in rows),
+                     'first_migration_sec': round_duration(result['first_migration_sec']),
+                     'balanced_sec': round_duration(result['balanced_sec']),
+                     'spread_after': spread(after)['max_min_spread']}
+    phase_metrics.update(duration_metrics('vm_first_migration_sec', [r['first_migration_sec'] for r in rows]))
+    status = phase_status(0 if result['balanced'] else 1, 1)
+    notify_phase('descheduler', 'rebalance-complete', phase_metrics, status, logger=logger)
+
+    if args.save_results:
+        save_descheduler_results(out_dir, args, rows, result, timeline, before, after, descheduler, node,
+                                 timing.timing_metadata(test_start, clock_skew=clock_skew), logger)
+    phase_metrics.update(percentile_metrics('vmim_time_sec', [t for r in rows for t in r['vmim_times_sec']]))
+    notify_run('descheduler', phase_metrics, status, out_dir, logger)
+
+    sys.exit(0 if result['balanced'] else 2)
+
+
+if __name__ == '__main__':
+    main()
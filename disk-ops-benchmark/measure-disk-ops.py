@@ -21,10 +21,18 @@ from concurrent.futures import ThreadPoolExecutor, as_completed
 from datetime import datetime
 from typing import Dict, List, Optional, Tuple
 
-# Reuse the shared SSH helper that runs `kubectl exec` into a persistent
-# sshpass-equipped pod (same approach as the FIO benchmark).
+# In-VM checks go through the shared guest executor, which runs ssh inside a
+# persistent sshpass-equipped helper pod (same approach as the FIO benchmark).
 sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
-from utils.common import ssh_exec_command
+from utils.apply import apply_manifest
+from utils.common import (
+    create_namespace, labeled_namespaces, run_selector, set_run_workload, stamp_manifest,
+    run_metadata,
+)
+from utils.guestexec import GuestExecutor, ensure_helper_pod
+from utils.inventory import cluster_inventory, resolve_storage_driver
+from utils.storageprovider import collect_storage_backend
+from utils.dryrun import DryRunPlan, is_dry_run
 
 # Constants
 DEFAULT_NAMESPACE_PREFIX = 'disk-ops'
@@ -96,7 +104,9 @@ Examples:
     # Output settings
     parser.add_argument('--results-dir', type=str, default='results')
     parser.add_argument('--save-results', action='store_true')
-    parser.add_argument('--px-version', type=str, default='px-unknown', help='Portworx version for results grouping')
+    parser.add_argument('--storage-driver', '--px-version', dest='storage_driver', type=str, default='px-unknown',
+                        help='Storage driver/version label for results grouping, or auto to detect it '
+                             '(--px-version is the old name)')
     parser.add_argument('--disk-type', type=str, default=None,
                         help='Disk type label for results grouping (default: <disks>-disk)')
     parser.add_argument('--cleanup', action='store_true', help='Remove hotplugged disks after test')
@@ -126,65 +136,6 @@ def setup_logging(level: str, log_file: Optional[str] = None) -> logging.Logger:
 
 
 
-def ensure_ssh_pod(ssh_pod: str, ssh_pod_ns: str, logger, timeout: int = 180) -> Tuple[bool, bool]:
-    """
-    Ensure a persistent SSH helper pod (with sshpass) is running and usable.
-
-    Reuses the pod if it already exists; otherwise creates it and waits until
-    sshpass is installed. Returns (ready, created_by_us).
-    """
-    def sshpass_ready() -> bool:
-        rc, _, _ = run_cmd(f"kubectl exec -n {ssh_pod_ns} {ssh_pod} -- which sshpass", timeout=15)
-        return rc == 0
-
-    if sshpass_ready():
-        logger.info(f"Using existing SSH helper pod {ssh_pod_ns}/{ssh_pod}")
-        return True, False
-
-    created = False
-    rc, _, _ = run_cmd(f"kubectl get pod {ssh_pod} -n {ssh_pod_ns}", timeout=15)
-    if rc != 0:
-        logger.info(f"Creating SSH helper pod {ssh_pod_ns}/{ssh_pod}...")
-        run_cmd(f"kubectl create namespace {ssh_pod_ns} --dry-run=client -o yaml | kubectl apply -f -", timeout=20)
-        manifest = f"""apiVersion: v1
-kind: Pod
-metadata:
-  name: {ssh_pod}
-  namespace: {ssh_pod_ns}
-  labels:
-    app: kubevirt-perf-test
-spec:
-  containers:
-  - name: ssh-client
-    image: alpine:latest
-    command: ["/bin/sh", "-c", "apk add --no-cache bash openssh-client sshpass iputils && tail -f /dev/null"]
-    resources:
-      requests:
-        memory: "128Mi"
-        cpu: "100m"
-      limits:
-        memory: "256Mi"
-        cpu: "200m"
-  restartPolicy: Always
-"""
-        result = subprocess.run(['kubectl', 'apply', '-f', '-'], input=manifest.encode(), capture_output=True)
-        if result.returncode != 0:
-            logger.error(f"Failed to create SSH helper pod: {result.stderr.decode().strip()}")
-            return False, False
-        created = True
-
-    # Pod runs `apk add` on startup; wait until sshpass is actually available.
-    start = time.time()
-    while time.time() - start < timeout:
-        if sshpass_ready():
-            logger.info(f"SSH helper pod {ssh_pod_ns}/{ssh_pod} is ready")
-            return True, created
-        time.sleep(5)
-
-    logger.error(f"SSH helper pod {ssh_pod_ns}/{ssh_pod} not ready after {timeout}s")
-    return False, created
-
-
 def prepare_vm_yaml(template_path: str, vm_name: str, storage_class: str, vm_password: str) -> str:
     """Prepare VM YAML with substituted values."""
     with open(template_path) as f:
@@ -203,18 +154,19 @@ def prepare_vm_yaml(template_path: str, vm_name: str, storage_class: str, vm_pas
     return content
 
 
-def create_namespace(namespace: str) -> bool:
-    """Create a namespace if it doesn't exist."""
-    cmd = f"kubectl create namespace {namespace} --dry-run=client -o yaml | kubectl apply -f -"
-    rc, _, _ = run_cmd(cmd)
-    return rc == 0
+def resolve_template_path(template_path: str) -> str:
+    """Resolve a relative --vm-template against the script directory."""
+    if not os.path.isabs(template_path):
+        script_dir = os.path.dirname(os.path.abspath(__file__))
+        template_path = os.path.join(script_dir, template_path)
+    return template_path
 
 
 def deploy_vm(namespace: str, vm_yaml: str, logger) -> bool:
     """Deploy a VM to a namespace."""
     result = subprocess.run(
         f"kubectl apply -n {namespace} -f -",
-        shell=True, input=vm_yaml.encode(), capture_output=True
+        shell=True, input=stamp_manifest(vm_yaml, namespace, logger).encode(), capture_output=True
     )
     if result.returncode == 0:
         logger.info(f"[{namespace}] VM deployed")
@@ -321,17 +273,11 @@ def run_ssh_command(vm_ip: str, command: str, ssh_config: Dict, timeout: int = 6
     """
     Run a command on the VM via the persistent SSH helper pod (password auth).
 
-    Uses the shared ssh_exec_command helper (kubectl exec into the sshpass pod),
+    Uses the shared GuestExecutor (pooled, retried ssh from the sshpass pod),
     which is far more reliable than spawning a throwaway pod per call.
     Returns stripped stdout, or None if the command produced no usable output.
     """
-    rc, stdout, stderr = ssh_exec_command(
-        vm_ip, command,
-        ssh_config['pod'], ssh_config['pod_ns'],
-        ssh_config['user'], ssh_config['password'],
-        logger=logging.getLogger(__name__),
-        timeout=timeout,
-    )
+    rc, stdout, stderr = ssh_config['executor'].run(vm_ip, command, timeout=timeout)
     output = (stdout or '').strip()
     if rc != 0 and not output:
         logging.getLogger(__name__).debug(
@@ -354,11 +300,8 @@ def create_pvc(namespace: str, pvc_name: str, size: str, storage_class: str) ->
     yaml_content = yaml_content.replace('DISK_SIZE', size)
     yaml_content = yaml_content.replace('STORAGE_CLASS', storage_class)
 
-    result = subprocess.run(
-        ['kubectl', 'apply', '-f', '-'],
-        input=yaml_content.encode(), capture_output=True
-    )
-    return result.returncode == 0
+    applied, _ = apply_manifest(stamp_manifest(yaml_content))
+    return applied
 
 
 def delete_pvc(namespace: str, pvc_name: str) -> bool:
@@ -937,14 +880,20 @@ def aggregate_results(all_results: List[Dict], config: Dict) -> Dict:
     return summary
 
 
-def save_results(results: Dict, results_dir: str, px_version: str, disk_type: str,
-                 vm_start: int, vm_end: int, logger):
+def save_results(results: Dict, results_dir: str, storage_driver: str, disk_type: str,
+                 vm_start: int, vm_end: int, logger, namespace_prefix: Optional[str] = None):
     """Save results to JSON and CSV in the dashboard-compatible folder structure."""
     timestamp = datetime.now().strftime("%Y%m%d-%H%M%S")
     run_name = f"{timestamp}_disk_ops_benchmark_{vm_start}-{vm_end}"
-    folder = os.path.join(results_dir, px_version, disk_type, run_name)
+    folder = os.path.join(results_dir, storage_driver, disk_type, run_name)
     os.makedirs(folder, exist_ok=True)
 
+    results['cluster'] = cluster_inventory(logger)
+    results['run'] = run_metadata()
+    storage_backend = collect_storage_backend(folder, namespace_prefix, logger)
+    if storage_backend is not None:
+        results['storage_backend'] = storage_backend
+
     # Save JSON
     json_path = os.path.join(folder, "disk_ops_results.json")
     with open(json_path, 'w') as f:
@@ -1000,9 +949,60 @@ def print_summary(results: Dict, logger):
     print("=" * 70)
 
 
+def plan_run(namespaces: List[str], args, logger):
+    """Print the VMs, PVCs and volume operations main() would run (virtbench --dry-run)."""
+    plan = DryRunPlan('disk-ops', logger)
+    if args.create_vms:
+        template_path = resolve_template_path(args.vm_template)
+        if not os.path.exists(template_path):
+            logger.error(f"VM template not found: {template_path}")
+            sys.exit(1)
+        plan.create_namespaces(namespaces)
+        vm_yaml = prepare_vm_yaml(template_path, args.vm_name, args.storage_class, args.vm_password)
+        for ns in namespaces:
+            plan.apply(vm_yaml, ns, verb='apply')
+
+    logger.info("[DRY RUN] Disk names get a random suffix, so a real run uses different names")
+    operations = ['hotplug', 'coldplug'] if args.operation == 'all' else [args.operation]
+    attached = []
+    for ns in namespaces:
+        target = f"vm/{ns}/{args.vm_name}"
+        for operation in operations:
+            if operation == 'coldplug':
+                plan.action('stop', target)
+            pvcs = [f"{operation}-disk-{uuid.uuid4().hex[:6]}" for _ in range(args.disks)]
+            for pvc_name in pvcs:
+                plan.action('create', f"pvc/{ns}/{pvc_name}", f"{args.disk_size} ({args.storage_class})")
+                plan.action('addvolume', target, f"{pvc_name} --persist")
+                attached.append((ns, pvc_name))
+            if operation == 'coldplug':
+                plan.action('start', target)
+            if args.test_unplug:
+                for pvc_name in pvcs:
+                    plan.action('removevolume', target, pvc_name)
+
+    if args.cleanup:
+        for ns, pvc_name in attached:
+            plan.action('removevolume', f"vm/{ns}/{args.vm_name}", pvc_name)
+            plan.action('delete', f"pvc/{ns}/{pvc_name}")
+        if args.create_vms:
+            for ns in namespaces:
+                plan.action('delete', f"vm/{ns}/{args.vm_name}")
+            plan.delete_namespaces(namespaces)
+    plan.report()
+
+
 def main():
     args = parse_args()
+    set_run_workload('disk-ops')
+    args.storage_driver = resolve_storage_driver(args.storage_driver, args.storage_class, default='px-unknown')
+    dry_run = is_dry_run()
+    if dry_run:
+        # A dry run has no results to save
+        args.save_results = False
     logger = setup_logging(args.log_level, args.log_file)
+    if args.save_results:
+        cluster_inventory(logger)
 
     created_ssh_pod = False
     validate = not args.skip_validation
@@ -1012,6 +1012,9 @@ def main():
         'pod_ns': args.ssh_pod_ns,
         'user': args.vm_user,
         'password': args.vm_password,
+        'executor': GuestExecutor(args.vm_user, args.vm_password,
+                                  ssh_pod=args.ssh_pod, ssh_pod_ns=args.ssh_pod_ns,
+                                  max_sessions=args.concurrency, logger=logger),
     } if validate else None
     args.ssh_config = ssh_config
 
@@ -1032,11 +1035,14 @@ def main():
 
     # Generate namespace list
     namespaces = [f"{args.namespace_prefix}-{i}" for i in range(args.start, args.end + 1)]
+    if dry_run:
+        plan_run(namespaces, args, logger)
+        return
 
     try:
         # Ensure the persistent SSH helper pod exists when validation is enabled.
         if validate:
-            ready, created_ssh_pod = ensure_ssh_pod(args.ssh_pod, args.ssh_pod_ns, logger)
+            ready, created_ssh_pod = ensure_helper_pod(args.ssh_pod, args.ssh_pod_ns, logger=logger)
             if not ready:
                 logger.error("SSH helper pod unavailable; re-run with --skip-validation "
                              "to proceed without in-VM checks")
@@ -1046,14 +1052,11 @@ def main():
             # Step 1: Create namespaces
             print("[1/3] Creating namespaces...")
             for ns in namespaces:
-                create_namespace(ns)
+                create_namespace(ns, logger)
 
             # Step 2: Deploy VMs
             print("[2/3] Deploying VMs...")
-            template_path = args.vm_template
-            if not os.path.isabs(template_path):
-                script_dir = os.path.dirname(os.path.abspath(__file__))
-                template_path = os.path.join(script_dir, template_path)
+            template_path = resolve_template_path(args.vm_template)
             if not os.path.exists(template_path):
                 logger.error(f"VM template not found: {template_path}")
                 sys.exit(1)
@@ -1145,8 +1148,8 @@ def main():
         # Save results
         if args.save_results:
             disk_type = args.disk_type or f"{args.disks}-disk"
-            save_results(aggregated, args.results_dir, args.px_version, disk_type,
-                        args.start, args.end, logger)
+            save_results(aggregated, args.results_dir, args.storage_driver, disk_type,
+                        args.start, args.end, logger, args.namespace_prefix)
 
         # Cleanup if requested
         if args.cleanup:
@@ -1161,13 +1164,15 @@ def main():
             # Cleanup VMs and namespaces if we created them
             if args.create_vms:
                 logger.info("Cleaning up VMs and namespaces...")
-                for ns in namespaces:
-                    run_cmd(f"kubectl delete vm {args.vm_name} -n {ns} --ignore-not-found")
+                for ns in labeled_namespaces(namespaces, run_selector(), logger):
+                    run_cmd(f"kubectl delete vm -l {run_selector()} -n {ns} --ignore-not-found")
                     run_cmd(f"kubectl delete namespace {ns} --ignore-not-found")
 
             logger.info("Cleanup complete")
 
     finally:
+        if ssh_config:
+            ssh_config['executor'].close()
         # Remove the SSH helper pod only if we created it and cleanup was requested.
         if created_ssh_pod and args.cleanup:
             logger.info(f"Removing SSH helper pod {args.ssh_pod_ns}/{args.ssh_pod}")
@@ -2,7 +2,7 @@
 """
 Node Failure Recovery Test (manual, FAR-operator triggered, or monitor-only)
 
-Three modes:
+Four modes:
   manual       - Wait for the operator to power off the node manually (via BMC/IPMI),
                  then measure VM recovery time.
   far-operator - Apply a Fence Agents Remediation (FAR) configuration to trigger
@@ -12,13 +12,23 @@ Three modes:
                  on the target node and measure recovery from "now". Used by
                  `virtbench failure-recovery` and for measuring recovery after a
                  separate failure event has already occurred.
+  inject       - Inject the failure selected with --failure-mode (drain,
+                 kubelet-stop, reboot, network-partition) and measure detection
+                 time, rescheduling time and VM boot time separately. The
+                 storage-pod-kill / storage-pod-pause modes instead disrupt the
+                 storage provider on the node and measure guest I/O stall,
+                 volume failover time and guest restarts per storage class.
 
 All modes:
   1. Detect VMIs to monitor on the target node
   2. (Optional) Remove nodeSelector from VMs to allow rescheduling
   3. Trigger / wait for node failure (manual + far-operator only)
   4. Wait for the node to become NotReady (manual + far-operator only)
-  5. Monitor VM recovery (Running+Ready, optionally ping)
+  5. Monitor VM recovery (Running+Ready, optionally ping), optionally verifying
+     that the failed node's volume attachments are fenced first (--verify-fencing)
+     With --recovery-detection watch (the default), the node, the VMIs and their
+     virt-launcher pods are watched rather than polled, so node failure,
+     rescheduling and Running+Ready are timed to when the change arrived.
   6. Print summary statistics, optionally save results
   7. (Optional) Cleanup FAR resources, annotations, uncordon nodes, delete VMs
 
@@ -33,23 +43,35 @@ Usage:
     # Monitor-only mode (failure already triggered externally)
     python3 recovery-test.py --mode monitor --node worker-1 --vm-name rhel-9-vm
 
+    # Built-in failure injection
+    python3 recovery-test.py --mode inject --failure-mode kubelet-stop --node worker-1
+
+    # Verify guest data survives the failure
+    python3 recovery-test.py --mode inject --failure-mode reboot --node worker-1 --verify-data \
+        --vm-password <guest-password>
+
 Author: KubeVirt Benchmark Suite Contributors
 License: Apache 2.0
 """
 
 import argparse
+import atexit
 import json
 import logging
 import os
 import signal
+import subprocess
 import sys
+import tempfile
+import threading
 import time
-from concurrent.futures import ThreadPoolExecutor, as_completed
 from datetime import datetime
 from typing import Dict, List, Optional, Tuple
 
 sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
 
+from utils import timing
+from utils.apply import apply_manifest
 from utils.common import (
     setup_logging,
     run_kubectl_command,
@@ -60,6 +82,34 @@ from utils.common import (
     delete_far_resource,
     uncordon_node,
     save_results,
+    get_vm_volume_names,
+    get_pvc_volume_name,
+    get_pvc_storage_class,
+    get_volume_attachments,
+    create_node_exec_pod,
+    delete_node_exec_pod,
+    node_exec_pod_manifest,
+    DEFAULT_NODE_EXEC_IMAGE,
+    ssh_exec_command,
+    set_run_workload,
+    labeled_namespaces,
+    run_metadata,
+)
+from utils.portworx import KvdbMonitor, check_quorum_safe
+from utils.concurrency import run_parallel, DEFAULT_QPS, DEFAULT_BURST
+from utils.inventory import cluster_inventory, resolve_storage_driver
+from utils.storageprovider import collect_storage_backend
+from utils.dryrun import DryRunPlan, is_dry_run
+from utils.stats import describe
+from utils.watch import ResourceWatch, first_match
+from utils.guestexec import GuestExecutor
+from utils.faultinjector import (
+    build_injection_command, get_storage_pods, storage_pause_command,
+    STORAGE_FAILURE_MODES, DEFAULT_STORAGE_PODS, DEFAULT_STORAGE_PROCESS, DEFAULT_PARTITION_PORTS,
+)
+from utils.dataintegrity import (
+    DataVerifier, summarize_data_integrity, print_data_integrity_summary,
+    DEFAULT_VERIFY_SIZE_MB,
 )
 
 # Default values
@@ -72,6 +122,12 @@ DEFAULT_NODE_TIMEOUT = 600       # 10 minutes for node to go down
 DEFAULT_RECOVERY_TIMEOUT = 600   # 10 minutes for VMs to recover
 DEFAULT_SSH_POD = 'ssh-test-pod'
 DEFAULT_SSH_POD_NS = 'default'
+DEFAULT_FAILURE_DURATION = 300  # 5 minutes for kubelet-stop / network-partition
+FAILURE_MODES = ['drain', 'kubelet-stop', 'reboot', 'network-partition'] + STORAGE_FAILURE_MODES
+DEFAULT_VM_USER = 'cloud-user'
+IO_PROBE_FILE = '/var/tmp/virtbench-io-probe.log'
+IO_PROBE_INTERVAL = 0.2
+RECOVERY_DETECTION_MODES = ['watch', 'poll']
 
 
 def run_kubectl(args: List[str], logger: Optional[logging.Logger] = None) -> Tuple[int, str, str]:
@@ -149,28 +205,15 @@ def remove_node_selector(namespace: str, vm_name: str, logger: logging.Logger) -
 
 
 def remove_node_selectors_parallel(namespaces: List[str], vm_name: str,
-                                   concurrency: int, logger: logging.Logger) -> Tuple[int, int]:
+                                   concurrency: int, logger: logging.Logger,
+                                   qps: float = DEFAULT_QPS, burst: int = DEFAULT_BURST) -> Tuple[int, int]:
     """Remove nodeSelector from all VMs in parallel. Returns (success_count, fail_count)."""
     logger.info(f"Removing nodeSelector from {len(namespaces)} VMs...")
 
-    success = 0
-    failed = 0
-
-    with ThreadPoolExecutor(max_workers=concurrency) as executor:
-        futures = {
-            executor.submit(remove_node_selector, ns, vm_name, logger): ns
-            for ns in namespaces
-        }
-        for future in as_completed(futures):
-            ns = futures[future]
-            try:
-                if future.result():
-                    success += 1
-                else:
-                    failed += 1
-            except Exception as e:
-                logger.error(f"[{ns}] Error removing nodeSelector: {e}")
-                failed += 1
+    outcomes = run_parallel(remove_node_selector, namespaces, concurrency=concurrency, qps=qps, burst=burst,
+                            args=(vm_name, logger), logger=logger, description='nodeSelector removal')
+    success = sum(1 for _, removed, error in outcomes if error is None and removed)
+    failed = len(outcomes) - success
 
     logger.info(f"NodeSelector removal complete: {success} success, {failed} failed")
     return success, failed
@@ -183,10 +226,11 @@ def apply_far_config(far_config: str, logger: logging.Logger) -> bool:
         return False
 
     logger.info(f"Applying FAR configuration from {far_config}")
-    returncode, _, stderr = run_kubectl(['apply', '-f', far_config], logger=logger)
+    with open(far_config) as f:
+        applied, error = apply_manifest(f.read(), logger=logger)
 
-    if returncode != 0:
-        logger.error(f"Failed to apply FAR config: {stderr}")
+    if not applied:
+        logger.error(f"Failed to apply FAR config: {error}")
         return False
 
     logger.info("FAR configuration applied successfully")
@@ -210,11 +254,104 @@ def remove_far_config(far_config: str, logger: logging.Logger) -> bool:
     return True
 
 
+def inject_node_failure(args: argparse.Namespace,
+                        logger: logging.Logger) -> Tuple[bool, Dict]:
+    """
+    Inject the failure selected by --failure-mode on args.node.
+
+    Returns (success, state) where state holds what restore_node_failure()
+    needs to undo the injection.
+    """
+    node = args.node
+    state = {'failure_mode': args.failure_mode, 'pod': None, 'drain_proc': None, 'drain_output': None}
+
+    logger.info("=" * 70)
+    logger.info(f"INJECTING FAILURE: {args.failure_mode} on node {node}")
+    logger.info("=" * 70)
+
+    if args.failure_mode == 'drain':
+        returncode, _, stderr = run_kubectl(['cordon', node], logger=logger)
+        if returncode != 0:
+            logger.error(f"Failed to cordon node {node}: {stderr}")
+            return False, state
+
+        # Drain blocks until all pods are evicted, so run it in the background
+        # and let recovery monitoring measure the VMs as they move. Its output
+        # goes to a temporary file (a pipe nobody reads would block a long drain)
+        # and is logged when the drain is collected.
+        state['drain_output'] = tempfile.TemporaryFile(mode='w+')
+        state['drain_proc'] = subprocess.Popen(
+            ['kubectl', 'drain', node, '--ignore-daemonsets', '--delete-emptydir-data',
+             '--force', f'--timeout={args.recovery_timeout}s'],
+            stdout=state['drain_output'],
+            stderr=subprocess.STDOUT,
+            text=True
+        )
+        logger.info(f"Node {node} cordoned, drain started")
+        return True, state
+
+    command = build_injection_command(args.failure_mode, args.failure_duration,
+                                      args.partition_ports)
+    pod_name = f"virtbench-inject-{args.failure_mode}-{int(time.time())}"
+    if not create_node_exec_pod(node, command, pod_name, 'default',
+                                args.injector_image, logger):
+        return False, state
+
+    state['pod'] = pod_name
+    logger.info(f"Failure injector pod default/{pod_name} created on {node}")
+    if args.failure_mode in ('kubelet-stop', 'network-partition'):
+        logger.info(f"The node restores itself after {args.failure_duration}s")
+    return True, state
+
+
+def restore_node_failure(args: argparse.Namespace, state: Dict,
+                         logger: logging.Logger) -> None:
+    """Undo an injected failure where possible and remove the injector pod."""
+    drain_proc = state.get('drain_proc')
+    if drain_proc is not None:
+        if drain_proc.poll() is None:
+            logger.info("Drain still running, terminating it")
+            drain_proc.terminate()
+        drain_proc.wait()
+        output = ''
+        if state.get('drain_output'):
+            state['drain_output'].seek(0)
+            output = state['drain_output'].read().strip()
+            state['drain_output'].close()
+        if drain_proc.returncode != 0:
+            logger.warning(f"Drain of {args.node} exited with code {drain_proc.returncode}"
+                           + (f": {output.splitlines()[-1]}" if output else ''))
+            for line in output.splitlines()[-20:]:
+                logger.debug(f"  drain: {line}")
+        elif output:
+            logger.debug(f"Drain of {args.node} output:\n{output}")
+        uncordon_node(args.node, logger)
+
+    if state.get('pod'):
+        delete_node_exec_pod(state['pod'], 'default', logger)
+
+
+def node_is_down(node: Optional[Dict], failure_mode: Optional[str] = None) -> bool:
+    """Whether a Node object is NotReady (unschedulable for the drain failure mode)."""
+    if not node:
+        return False
+    if failure_mode == 'drain':
+        return bool(node.get('spec', {}).get('unschedulable'))
+    return any(c.get('type') == 'Ready' and c.get('status') in ('False', 'Unknown')
+               for c in node.get('status', {}).get('conditions', []))
+
+
 def wait_for_node_down(node_name: str, timeout: int, mode: str,
-                       logger: logging.Logger) -> Optional[datetime]:
+                       logger: logging.Logger,
+                       failure_mode: Optional[str] = None,
+                       watches: Optional[Dict[str, ResourceWatch]] = None) -> Optional[timing.MonotonicTimestamp]:
     """
     Wait for the target node to become NotReady.
     In manual mode, prompt the operator to power off the node via BMC.
+    For the drain failure mode the node stays Ready, so it counts as down once
+    it is reported unschedulable.
+    With watches (start_recovery_watches()), the node watch gives the time the
+    change arrived instead of the next poll.
     Returns the timestamp the node was first observed NotReady, or None on timeout.
     """
     if mode == 'manual':
@@ -222,10 +359,41 @@ def wait_for_node_down(node_name: str, timeout: int, mode: str,
         logger.info(f"ACTION REQUIRED: Power off node '{node_name}' via BMC/IPMI now")
         logger.info("=" * 70)
 
+    if watches:
+        state = 'unschedulable' if failure_mode == 'drain' else 'NotReady'
+        logger.info(f"Watching node {node_name} until it is {state} (timeout={timeout}s)...")
+        watch = watches['node']
+        found = watch.wait_until(
+            lambda: first_match(watch.history('', node_name), watch.started_at,
+                                lambda node: node_is_down(node, failure_mode)),
+            timeout)
+        if found is None:
+            logger.error(f"Timeout waiting for node {node_name} to become {state}")
+            return None
+        logger.info(f"Node {node_name} is {state} at {found[0].rfc3339nano()}")
+        return found[0]
+
+    if failure_mode == 'drain':
+        logger.info(f"Waiting for node {node_name} to become unschedulable (timeout={timeout}s)...")
+        start = time.monotonic()
+        while time.monotonic() - start < timeout:
+            returncode, output, _ = run_kubectl(
+                ['get', 'node', node_name, '-o', 'jsonpath={.spec.unschedulable}'],
+                logger=logger
+            )
+            if returncode == 0 and output.strip() == 'true':
+                down_ts = timing.now()
+                logger.info(f"Node {node_name} is unschedulable at {down_ts.rfc3339nano()}")
+                return down_ts
+            time.sleep(2)
+
+        logger.error(f"Timeout waiting for node {node_name} to become unschedulable")
+        return None
+
     logger.info(f"Waiting for node {node_name} to become NotReady (timeout={timeout}s)...")
-    start = time.time()
+    start = time.monotonic()
 
-    while time.time() - start < timeout:
+    while time.monotonic() - start < timeout:
         returncode, output, _ = run_kubectl(
             ['get', 'node', node_name, '-o',
              'jsonpath={.status.conditions[?(@.type=="Ready")].status}'],
@@ -233,8 +401,8 @@ def wait_for_node_down(node_name: str, timeout: int, mode: str,
         )
 
         if returncode == 0 and output.strip() in ('False', 'Unknown'):
-            down_ts = datetime.utcnow()
-            logger.info(f"Node {node_name} is NotReady at {down_ts.isoformat()}Z "
+            down_ts = timing.now()
+            logger.info(f"Node {node_name} is NotReady at {down_ts.rfc3339nano()} "
                         f"(status={output.strip()})")
             return down_ts
 
@@ -244,11 +412,11 @@ def wait_for_node_down(node_name: str, timeout: int, mode: str,
     return None
 
 
-def get_vmi_status(namespace: str, vmi_name: str,
-                   logger: logging.Logger) -> Tuple[str, bool, str]:
+def get_vmi_info(namespace: str, vmi_name: str,
+                 logger: logging.Logger) -> Dict:
     """
-    Get VMI phase, ready status, and IP via JSON.
-    Returns (phase, ready, ip).
+    Get VMI phase, ready status, IP, node and UID via JSON.
+    Returns a dict with keys phase, ready, ip, node and uid (empty on error).
     """
     returncode, output, _ = run_kubectl(
         ['get', 'vmi', vmi_name, '-n', namespace, '-o', 'json'],
@@ -256,65 +424,214 @@ def get_vmi_status(namespace: str, vmi_name: str,
     )
 
     if returncode != 0:
-        return '', False, ''
+        return vmi_info(None)
 
     try:
-        vmi_data = json.loads(output)
+        return vmi_info(json.loads(output))
     except json.JSONDecodeError:
-        return '', False, ''
+        return vmi_info(None)
+
 
-    phase = vmi_data.get('status', {}).get('phase', '')
+def vmi_info(vmi_data: Optional[Dict]) -> Dict:
+    """Phase, ready status, IP, node and UID of a VMI object (empty values for None)."""
+    info = {'phase': '', 'ready': False, 'ip': '', 'node': '', 'uid': ''}
+    if not vmi_data:
+        return info
 
-    ready = False
-    for cond in vmi_data.get('status', {}).get('conditions', []):
+    status = vmi_data.get('status', {})
+    info['phase'] = status.get('phase', '')
+    info['node'] = status.get('nodeName', '')
+    info['uid'] = vmi_data.get('metadata', {}).get('uid', '')
+
+    for cond in status.get('conditions', []):
         if cond.get('type') == 'Ready' and cond.get('status') == 'True':
-            ready = True
+            info['ready'] = True
             break
 
-    ip = ''
-    interfaces = vmi_data.get('status', {}).get('interfaces', [])
+    interfaces = status.get('interfaces', [])
     if interfaces:
-        ip = interfaces[0].get('ipAddress', '')
+        info['ip'] = interfaces[0].get('ipAddress', '')
+
+    return info
+
 
-    return phase, ready, ip
+def get_vmi_status(namespace: str, vmi_name: str,
+                   logger: logging.Logger) -> Tuple[str, bool, str]:
+    """
+    Get VMI phase, ready status, and IP via JSON.
+    Returns (phase, ready, ip).
+    """
+    info = get_vmi_info(namespace, vmi_name, logger)
+    return info['phase'], info['ready'], info['ip']
 
 
-def wait_for_vmi_running(namespace: str, vmi_name: str, start_ts: datetime,
+def snapshot_vmi_uids(namespaces: List[str], vmi_name: str,
+                      logger: logging.Logger) -> Dict[str, str]:
+    """
+    Record the current VMI UID per namespace.
+    Must be called BEFORE node failure so a restarted VMI can be told apart from the original.
+    """
+    returncode, output, stderr = run_kubectl(
+        ['get', 'vmi', '--all-namespaces', '-o', 'json'], logger=logger
+    )
+
+    if returncode != 0:
+        logger.warning(f"Failed to snapshot VMI UIDs: {stderr}")
+        return {}
+
+    wanted = set(namespaces)
+    uids = {}
+    try:
+        for vmi in json.loads(output).get('items', []):
+            meta = vmi.get('metadata', {})
+            if meta.get('name') == vmi_name and meta.get('namespace') in wanted:
+                uids[meta['namespace']] = meta.get('uid', '')
+    except json.JSONDecodeError as e:
+        logger.warning(f"Failed to parse VMI JSON: {e}")
+
+    return uids
+
+
+def wait_for_vmi_running(namespace: str, vmi_name: str, start_ts: timing.MonotonicTimestamp,
                          poll_interval: int, timeout: int,
-                         logger: logging.Logger) -> Tuple[str, float]:
-    """Wait for VMI to be Running and Ready. Returns (final_phase, recovery_seconds)."""
-    deadline = time.time() + timeout
+                         logger: logging.Logger,
+                         baseline_uid: Optional[str] = None,
+                         failed_node: Optional[str] = None,
+                         watches: Optional[Dict[str, ResourceWatch]] = None) -> Tuple[str, float, float]:
+    """
+    Wait for VMI to be Running and Ready.
 
-    while time.time() < deadline:
-        phase, ready, _ = get_vmi_status(namespace, vmi_name, logger)
+    When baseline_uid is given, the VMI only counts as recovered once it has been
+    rescheduled, i.e. a new VMI (different UID) exists or the VMI moved off
+    failed_node, and the time of rescheduling is reported separately.
 
-        if phase == 'Running' and ready:
-            elapsed = (datetime.utcnow() - start_ts).total_seconds()
-            return phase, elapsed
+    With watches (start_recovery_watches()), see watch_vmi_running().
+
+    Returns (final_phase, recovery_seconds, rescheduling_seconds).
+    """
+    if watches:
+        return watch_vmi_running(watches, namespace, vmi_name, start_ts, timeout, logger,
+                                 baseline_uid, failed_node)
+
+    deadline = time.monotonic() + timeout
+    rescheduling_secs = -1.0
+
+    while time.monotonic() < deadline:
+        info = get_vmi_info(namespace, vmi_name, logger)
+
+        if baseline_uid is not None and rescheduling_secs < 0:
+            moved = info['node'] and failed_node and info['node'] != failed_node
+            if info['uid'] and (info['uid'] != baseline_uid or moved):
+                rescheduling_secs = (timing.now() - start_ts).total_seconds()
+                logger.debug(f"[{namespace}/{vmi_name}] Rescheduled to {info['node'] or 'pending'} "
+                             f"after {rescheduling_secs:.1f}s")
+
+        rescheduled = baseline_uid is None or rescheduling_secs >= 0
+        if info['phase'] == 'Running' and info['ready'] and rescheduled:
+            elapsed = (timing.now() - start_ts).total_seconds()
+            return info['phase'], elapsed, rescheduling_secs
 
         time.sleep(poll_interval)
 
-    return 'Timeout', -1.0
+    return 'Timeout', -1.0, rescheduling_secs
+
+
+def start_recovery_watches(args: argparse.Namespace,
+                           logger: logging.Logger) -> Optional[Dict[str, ResourceWatch]]:
+    """
+    Watch the target node, the VMIs named --vm-name and their virt-launcher pods.
+
+    Must be called BEFORE node failure, so the watches have the state before it.
+    Returns None with --recovery-detection poll, or when a watch could not be
+    started (recovery is then polled).
+    """
+    if args.recovery_detection != 'watch':
+        return None
+    watches = {
+        'node': ResourceWatch('node', name=args.node, logger=logger),
+        'vmi': ResourceWatch('vmi', field_selector=f'metadata.name={args.vm_name}', logger=logger),
+        'pod': ResourceWatch('pods', selector=f'kubevirt.io=virt-launcher,vm.kubevirt.io/name={args.vm_name}',
+                             logger=logger),
+    }
+    for kind, watch in watches.items():
+        if not watch.start():
+            logger.warning(f"Could not watch {kind}s; polling for recovery every {args.poll_interval}s instead")
+            stop_recovery_watches(watches)
+            return None
+    atexit.register(stop_recovery_watches, watches)
+    logger.info("Watching the node, VMIs and virt-launcher pods for recovery detection")
+    return watches
+
+
+def stop_recovery_watches(watches: Optional[Dict[str, ResourceWatch]]) -> None:
+    for watch in (watches or {}).values():
+        watch.stop()
+
+
+def watch_vmi_running(watches: Dict[str, ResourceWatch], namespace: str, vmi_name: str,
+                      start_ts: timing.MonotonicTimestamp, timeout: int, logger: logging.Logger,
+                      baseline_uid: Optional[str] = None,
+                      failed_node: Optional[str] = None) -> Tuple[str, float, float]:
+    """
+    Wait for the VMI to be Running and Ready from the VMI watch.
+
+    Times are those at which the change arrived: recovery is the first VMI
+    state with a Ready condition of True in phase Running. With baseline_uid,
+    rescheduling is the first of a VMI with a new UID or off failed_node and a
+    new virt-launcher pod scheduled off failed_node.
+
+    Returns (final_phase, recovery_seconds, rescheduling_seconds), like wait_for_vmi_running().
+    """
+    vmi_watch, pod_watch = watches['vmi'], watches['pod']
+
+    def rescheduled(vmi: Optional[Dict]) -> bool:
+        info = vmi_info(vmi)
+        moved = info['node'] and failed_node and info['node'] != failed_node
+        return bool(info['uid'] and (info['uid'] != baseline_uid or moved))
+
+    def recovered(vmi: Optional[Dict]) -> bool:
+        info = vmi_info(vmi)
+        return info['phase'] == 'Running' and info['ready'] and (baseline_uid is None or rescheduled(vmi))
+
+    found = vmi_watch.wait_until(
+        lambda: first_match(vmi_watch.history(namespace, vmi_name), start_ts, recovered), timeout)
+
+    rescheduling_secs = -1.0
+    if baseline_uid is not None:
+        pods = pod_watch.history(namespace)
+        old_pods = {(obj or {}).get('metadata', {}).get('uid') for ts, _, obj in pods if ts <= start_ts}
+        candidates = [first_match(vmi_watch.history(namespace, vmi_name), start_ts, rescheduled),
+                      first_match(pods, start_ts, lambda pod: bool(
+                          pod and pod['metadata'].get('uid') not in old_pods
+                          and pod.get('spec', {}).get('nodeName') not in ('', None, failed_node)))]
+        times = [c[0] for c in candidates if c is not None]
+        if times:
+            rescheduling_secs = (min(times) - start_ts).total_seconds()
+            logger.debug(f"[{namespace}/{vmi_name}] Rescheduled after {rescheduling_secs:.3f}s")
+
+    if found is None:
+        return 'Timeout', -1.0, rescheduling_secs
+    return 'Running', (found[0] - start_ts).total_seconds(), rescheduling_secs
 
 
 def wait_for_ping_recovery(namespace: str, vmi_name: str, ssh_pod: str, ssh_pod_ns: str,
-                           start_ts: datetime, poll_interval: int, timeout: int,
+                           start_ts: timing.MonotonicTimestamp, poll_interval: int, timeout: int,
                            logger: logging.Logger) -> Tuple[bool, float, str]:
     """
     Wait for the VM to respond to ping. The IP is re-fetched on each iteration in case
     it changes during recovery.
     Returns (ping_success, ping_recovery_seconds, ip).
     """
-    deadline = time.time() + timeout
+    deadline = time.monotonic() + timeout
     last_ip = ''
 
-    while time.time() < deadline:
+    while time.monotonic() < deadline:
         _, _, ip = get_vmi_status(namespace, vmi_name, logger)
 
         if ip:
             last_ip = ip
             if ping_vm(ip, ssh_pod, ssh_pod_ns, logger):
-                elapsed = (datetime.utcnow() - start_ts).total_seconds()
+                elapsed = (timing.now() - start_ts).total_seconds()
                 return True, elapsed, ip
 
         time.sleep(poll_interval)
@@ -322,26 +639,116 @@ def wait_for_ping_recovery(namespace: str, vmi_name: str, ssh_pod: str, ssh_pod_
     return False, -1.0, last_ip
 
 
-def monitor_single_vm(namespace: str, vmi_name: str, node_down_ts: datetime,
+def collect_vm_volumes(namespaces: List[str], vm_name: str,
+                       logger: logging.Logger) -> Dict[str, List[str]]:
+    """
+    Map each namespace to the PersistentVolumes backing its VM.
+    Must be called BEFORE node failure so the PVC -> PV lookup is reliable.
+    """
+    pv_map: Dict[str, List[str]] = {}
+    for ns in namespaces:
+        pvs = []
+        for pvc in get_vm_volume_names(vm_name, ns, logger):
+            pv = get_pvc_volume_name(pvc, ns, logger)
+            if pv:
+                pvs.append(pv)
+            else:
+                logger.warning(f"[{ns}] PVC {pvc} is not bound; it will not be checked for fencing")
+        pv_map[ns] = pvs
+        logger.debug(f"[{ns}] Volumes to verify for fencing: {pvs}")
+    return pv_map
+
+
+def wait_for_volume_fencing(namespace: str, pv_names: List[str], failed_node: str,
+                            start_ts: timing.MonotonicTimestamp, poll_interval: int, timeout: int,
+                            logger: logging.Logger) -> Dict:
+    """
+    Wait until none of the VM's volumes are attached to the failed node.
+
+    A volume counts as fenced once its VolumeAttachment on the failed node is
+    gone or reports attached=false. While waiting, any volume that is attached
+    to the failed node and another node at the same time is flagged as a
+    split-brain risk.
+
+    Returns a dict with fenced, fencing_seconds, split_brain and split_brain_pvs.
+    """
+    result = {
+        'fenced': False,
+        'fencing_seconds': -1.0,
+        'split_brain': False,
+        'split_brain_pvs': [],
+    }
+
+    if not pv_names:
+        logger.warning(f"[{namespace}] No bound volumes found; skipping fencing verification")
+        return result
+
+    deadline = time.monotonic() + timeout
+    split_brain_pvs = set()
+
+    while time.monotonic() < deadline:
+        attachments = get_volume_attachments(pv_names, logger)
+        if attachments is None:
+            time.sleep(poll_interval)
+            continue
+
+        on_failed = {a['pv'] for a in attachments if a['node'] == failed_node and a['attached']}
+        elsewhere = {a['pv'] for a in attachments if a['node'] != failed_node and a['attached']}
+
+        overlap = on_failed & elsewhere
+        if overlap - split_brain_pvs:
+            logger.error(f"[{namespace}] SPLIT-BRAIN RISK: volume(s) {sorted(overlap)} attached to "
+                         f"{failed_node} and another node at the same time")
+            split_brain_pvs |= overlap
+
+        if not on_failed:
+            result['fenced'] = True
+            result['fencing_seconds'] = (timing.now() - start_ts).total_seconds()
+            break
+
+        time.sleep(poll_interval)
+
+    result['split_brain'] = bool(split_brain_pvs)
+    result['split_brain_pvs'] = sorted(split_brain_pvs)
+
+    if result['fenced']:
+        logger.info(f"[{namespace}] Volumes fenced from {failed_node} in "
+                    f"{result['fencing_seconds']:.1f}s")
+    else:
+        logger.warning(f"[{namespace}] Volumes still attached to {failed_node} after {timeout}s")
+
+    return result
+
+
+def monitor_single_vm(namespace: str, vmi_name: str, node_down_ts: timing.MonotonicTimestamp,
                       ssh_pod: str, ssh_pod_ns: str, poll_interval: int,
                       recovery_timeout: int, do_ping: bool,
-                      logger: logging.Logger) -> Dict:
+                      logger: logging.Logger,
+                      baseline_uid: Optional[str] = None,
+                      failed_node: Optional[str] = None,
+                      watches: Optional[Dict[str, ResourceWatch]] = None) -> Dict:
     """Monitor recovery of a single VMI. Returns a result dict."""
     result = {
         'namespace': namespace,
         'vmi': vmi_name,
         'phase': '',
         'recovery_seconds': -1.0,
+        'rescheduling_seconds': -1.0,
+        'boot_seconds': -1.0,
         'ping_success': False,
         'ping_recovery_seconds': -1.0,
         'ip': '',
     }
 
-    phase, recovery_secs = wait_for_vmi_running(
-        namespace, vmi_name, node_down_ts, poll_interval, recovery_timeout, logger
+    phase, recovery_secs, rescheduling_secs = wait_for_vmi_running(
+        namespace, vmi_name, node_down_ts, poll_interval, recovery_timeout, logger,
+        baseline_uid=baseline_uid, failed_node=failed_node, watches=watches
     )
     result['phase'] = phase
     result['recovery_seconds'] = recovery_secs
+    result['rescheduling_seconds'] = rescheduling_secs
+    if recovery_secs >= 0 and rescheduling_secs >= 0:
+        result['boot_seconds'] = recovery_secs - rescheduling_secs
 
     if phase != 'Running' or recovery_secs < 0:
         logger.warning(f"[{namespace}/{vmi_name}] Did not reach Running+Ready (phase={phase})")
@@ -366,38 +773,76 @@ def monitor_single_vm(namespace: str, vmi_name: str, node_down_ts: datetime,
     return result
 
 
-def monitor_vm_recovery(namespaces: List[str], vmi_name: str, node_down_ts: datetime,
+def monitor_vm_recovery(namespaces: List[str], vmi_name: str, node_down_ts: timing.MonotonicTimestamp,
                         ssh_pod: str, ssh_pod_ns: str, poll_interval: int,
                         recovery_timeout: int, concurrency: int, do_ping: bool,
-                        logger: logging.Logger) -> List[Dict]:
-    """Monitor recovery of all VMIs in parallel."""
+                        logger: logging.Logger,
+                        pv_map: Optional[Dict[str, List[str]]] = None,
+                        failed_node: Optional[str] = None,
+                        baseline_uids: Optional[Dict[str, str]] = None,
+                        watches: Optional[Dict[str, ResourceWatch]] = None) -> List[Dict]:
+    """
+    Monitor recovery of all VMIs in parallel.
+
+    When pv_map is given, volume fencing on failed_node is verified alongside
+    VM recovery and merged into each result dict. When baseline_uids is given,
+    rescheduling and boot time are measured separately per VM. When watches is
+    given, Running+Ready and rescheduling come from the watches, not polling.
+    """
     logger.info(f"Monitoring recovery of {len(namespaces)} VMIs "
-                f"(timeout={recovery_timeout}s, ping={do_ping})...")
+                f"(timeout={recovery_timeout}s, ping={do_ping}, "
+                f"verify_fencing={pv_map is not None})...")
 
     results: List[Dict] = []
-
-    with ThreadPoolExecutor(max_workers=concurrency) as executor:
-        futures = {
-            executor.submit(monitor_single_vm, ns, vmi_name, node_down_ts,
-                            ssh_pod, ssh_pod_ns, poll_interval, recovery_timeout,
-                            do_ping, logger): ns
-            for ns in namespaces
-        }
-        for future in as_completed(futures):
-            ns = futures[future]
-            try:
-                results.append(future.result())
-            except Exception as e:
-                logger.error(f"[{ns}] Error monitoring VM: {e}")
-                results.append({
-                    'namespace': ns,
-                    'vmi': vmi_name,
-                    'phase': 'Error',
-                    'recovery_seconds': -1.0,
-                    'ping_success': False,
-                    'ping_recovery_seconds': -1.0,
-                    'ip': '',
-                })
+    fencing: Dict[str, Dict] = {}
+    fencing_outcomes = []
+    fencing_thread = None
+
+    # Fencing is verified alongside recovery, in its own pool; both only poll,
+    # so neither is rate limited
+    if pv_map is not None:
+        fencing_thread = threading.Thread(target=lambda: fencing_outcomes.extend(run_parallel(
+            lambda ns: wait_for_volume_fencing(ns, pv_map.get(ns, []), failed_node, node_down_ts,
+                                               poll_interval, recovery_timeout, logger),
+            namespaces, concurrency=concurrency, logger=logger, description='fencing check')), daemon=True)
+        fencing_thread.start()
+
+    outcomes = run_parallel(
+        lambda ns: monitor_single_vm(ns, vmi_name, node_down_ts, ssh_pod, ssh_pod_ns, poll_interval,
+                                     recovery_timeout, do_ping, logger,
+                                     baseline_uids.get(ns, '') if baseline_uids is not None else None,
+                                     failed_node, watches),
+        namespaces, concurrency=concurrency, logger=logger, description='recovery monitor')
+    for ns, result, error in outcomes:
+        if error is None:
+            results.append(result)
+            continue
+        results.append({
+            'namespace': ns,
+            'vmi': vmi_name,
+            'phase': 'Error',
+            'recovery_seconds': -1.0,
+            'rescheduling_seconds': -1.0,
+            'boot_seconds': -1.0,
+            'ping_success': False,
+            'ping_recovery_seconds': -1.0,
+            'ip': '',
+        })
+
+    if fencing_thread:
+        fencing_thread.join()
+        fencing = {ns: result for ns, result, error in fencing_outcomes if error is None}
+
+        for r in results:
+            f = fencing.get(r['namespace'], {})
+            r['fenced'] = f.get('fenced', False)
+            r['fencing_seconds'] = f.get('fencing_seconds', -1.0)
+            r['split_brain'] = f.get('split_brain', False)
+            r['split_brain_pvs'] = f.get('split_brain_pvs', [])
+            r['fenced_before_restart'] = (
+                r['fenced'] and r['recovery_seconds'] >= 0
+                and r['fencing_seconds'] <= r['recovery_seconds']
+            )
 
     return results
 
@@ -448,6 +893,19 @@ def print_summary(results: List[Dict], do_ping: bool, logger: logging.Logger) ->
                     f"{min(rec_times):.1f}s / {sum(rec_times)/len(rec_times):.1f}s / "
                     f"{max(rec_times):.1f}s")
 
+    rescheduled = [r for r in results if r.get('rescheduling_seconds', -1.0) >= 0]
+    if rescheduled:
+        resched_times = [r['rescheduling_seconds'] for r in rescheduled]
+        logger.info(f"Rescheduled: {len(rescheduled)}/{total}")
+        logger.info(f"  Rescheduling time min/avg/max: "
+                    f"{min(resched_times):.1f}s / {sum(resched_times)/len(resched_times):.1f}s / "
+                    f"{max(resched_times):.1f}s")
+        boot_times = [r['boot_seconds'] for r in rescheduled if r['boot_seconds'] >= 0]
+        if boot_times:
+            logger.info(f"  VM boot time min/avg/max: "
+                        f"{min(boot_times):.1f}s / {sum(boot_times)/len(boot_times):.1f}s / "
+                        f"{max(boot_times):.1f}s")
+
     if do_ping:
         logger.info(f"Ping recovered: {len(pinged)}/{total}")
         if pinged:
@@ -462,9 +920,61 @@ def print_summary(results: List[Dict], do_ping: bool, logger: logging.Logger) ->
         for r in failed:
             logger.info(f"  - {r['namespace']}/{r['vmi']} (phase={r['phase']})")
 
+    if any('fenced' in r for r in results):
+        fenced = [r for r in results if r.get('fenced')]
+        logger.info(f"Volumes fenced from failed node: {len(fenced)}/{total}")
+        if fenced:
+            fence_times = [r['fencing_seconds'] for r in fenced]
+            logger.info(f"  Fencing time min/avg/max: "
+                        f"{min(fence_times):.1f}s / {sum(fence_times)/len(fence_times):.1f}s / "
+                        f"{max(fence_times):.1f}s")
+
+        violations = fencing_violations(results)
+        if violations:
+            logger.info(f"Fencing violations: {len(violations)}")
+            for r in violations:
+                reason = 'split-brain' if r.get('split_brain') else 'restarted before fencing'
+                logger.info(f"  - {r['namespace']}/{r['vmi']} ({reason})")
+        else:
+            logger.info("No fencing violations: every recovered VM restarted after its "
+                        "volumes were released by the failed node")
+
+    logger.info("=" * 70)
+
+
+def print_kvdb_summary(summary: Dict, logger: logging.Logger) -> None:
+    """Print Portworx KVDB behaviour observed during the test."""
+    def _fmt(value):
+        return f"{value:.1f}s" if value is not None else 'n/a'
+
+    logger.info("=" * 70)
+    logger.info("PORTWORX KVDB SUMMARY")
+    logger.info("=" * 70)
+    logger.info(f"Baseline members: {summary['baseline_healthy']}/{summary['baseline_total']} "
+                f"healthy, leader={summary['baseline_leader']}")
+    logger.info(f"Quorum lost: {'YES' if summary['quorum_lost'] else 'no'}"
+                + (f" (for {_fmt(summary['quorum_lost_seconds'])})" if summary['quorum_lost'] else ''))
+    logger.info(f"Member degraded after: {_fmt(summary['degraded_seconds'])}")
+    logger.info(f"Leader change after: {_fmt(summary['leader_change_seconds'])}"
+                + (f" (new leader {summary['new_leader']})" if summary['new_leader'] else ''))
+    logger.info(f"KVDB failover complete after: {_fmt(summary['failover_seconds'])}")
+    if summary.get('unknown_samples'):
+        logger.warning(f"KVDB status unknown in {summary['unknown_samples']}/{summary['samples']} samples "
+                       f"(pxctl failed or timed out); these are not counted as quorum loss")
     logger.info("=" * 70)
 
 
+def fencing_violations(results: List[Dict]) -> List[Dict]:
+    """Return recovered VMs that restarted before fencing completed or hit split-brain."""
+    return [
+        r for r in results
+        if 'fenced' in r and (
+            r.get('split_brain')
+            or (r['recovery_seconds'] >= 0 and not r.get('fenced_before_restart'))
+        )
+    ]
+
+
 def results_to_tuples(results: List[Dict]) -> List[Tuple]:
     """Convert result dicts to 5-tuples expected by utils.common.save_results."""
     tuples = []
@@ -583,9 +1093,460 @@ def _log_cleanup_summary(stats: Dict, cleanup_vms: bool, dry_run: bool,
         logger.info("Cleanup completed successfully!")
 
 
+def wait_for_storage_pods_ready(args: argparse.Namespace, killed: List[Dict],
+                                start_ts: timing.MonotonicTimestamp, logger: logging.Logger) -> float:
+    """
+    Wait until the storage pods on the node are Ready again and none of the
+    killed pods remain. Returns seconds since start_ts, or -1.0 on timeout.
+    """
+    node = None if args.include_remote_storage_pods else args.node
+    killed_names = {(p['namespace'], p['name']) for p in killed}
+    expected = max(1, len(killed))
+    deadline = time.monotonic() + args.recovery_timeout
+
+    while time.monotonic() < deadline:
+        pods = get_storage_pods(args.storage_pods, node, logger)
+        current = [p for p in pods if (p['namespace'], p['name']) not in killed_names]
+        if len(current) >= expected and all(p['ready'] for p in current) and \
+                not any((p['namespace'], p['name']) in killed_names for p in pods):
+            return (timing.now() - start_ts).total_seconds()
+        time.sleep(args.poll_interval)
+
+    return -1.0
+
+
+def get_vm_storage_class(namespace: str, vm_name: str,
+                         logger: logging.Logger) -> str:
+    """Return the storage class of the VM's first PVC, or 'unknown'."""
+    for pvc in get_vm_volume_names(vm_name, namespace, logger):
+        storage_class = get_pvc_storage_class(pvc, namespace, logger)
+        if storage_class:
+            return storage_class
+    return 'unknown'
+
+
+def guest_exec(args: argparse.Namespace, ip: str, command: str,
+               logger: logging.Logger, timeout: int = 30) -> Optional[str]:
+    """Run a command in the guest over SSH. Returns stdout or None on failure."""
+    if not ip:
+        return None
+    try:
+        returncode, output, _ = ssh_exec_command(
+            ip, command, args.ssh_pod, args.ssh_pod_namespace,
+            args.vm_user, args.vm_password, logger, timeout=timeout
+        )
+    except Exception as e:
+        logger.debug(f"Guest command failed on {ip}: {e}")
+        return None
+    return output if returncode == 0 else None
+
+
+def start_io_probe(args: argparse.Namespace, namespace: str,
+                   logger: logging.Logger) -> Dict:
+    """
+    Start a background writer in the guest that appends a timestamp and syncs
+    every IO_PROBE_INTERVAL seconds, and record the guest boot ID.
+    """
+    info = get_vmi_info(namespace, args.vm_name, logger)
+    probe = {'ip': info['ip'], 'uid': info['uid'], 'boot_id': None, 'started': False}
+
+    boot_id = guest_exec(args, info['ip'], 'cat /proc/sys/kernel/random/boot_id', logger)
+    if boot_id is None:
+        logger.warning(f"[{namespace}] Guest not reachable over SSH; I/O stall will not be measured")
+        return probe
+
+    probe['boot_id'] = boot_id.strip()
+    command = (
+        f'rm -f {IO_PROBE_FILE}; nohup sh -c "while true; do date +%s.%N >> {IO_PROBE_FILE}; '
+        f'sync; sleep {IO_PROBE_INTERVAL}; done" >/dev/null 2>&1 &'
+    )
+    probe['started'] = guest_exec(args, info['ip'], command, logger) is not None
+    return probe
+
+
+def collect_io_probe(args: argparse.Namespace, namespace: str, probe: Dict,
+                     inject_epoch: float, logger: logging.Logger) -> Dict:
+    """
+    Stop the guest writer and derive the I/O stall and guest restart status.
+    The stall is the longest gap between consecutive probe writes after injection.
+    """
+    info = get_vmi_info(namespace, args.vm_name, logger)
+    result = {'io_stall_seconds': None, 'guest_restarted': None}
+
+    ip = info['ip'] or probe['ip']
+    boot_id = guest_exec(args, ip, 'cat /proc/sys/kernel/random/boot_id', logger)
+    if probe['boot_id'] and boot_id is not None:
+        result['guest_restarted'] = boot_id.strip() != probe['boot_id']
+    elif probe['uid'] and info['uid']:
+        result['guest_restarted'] = info['uid'] != probe['uid']
+
+    if not probe['started'] or result['guest_restarted']:
+        return result
+
+    output = guest_exec(args, ip, f'pkill -f {IO_PROBE_FILE}; cat {IO_PROBE_FILE}',
+                        logger, timeout=60)
+    if output is None:
+        return result
+
+    stamps = []
+    for line in output.splitlines():
+        try:
+            stamps.append(float(line.strip()))
+        except ValueError:
+            continue
+    stamps = [t for t in stamps if t >= inject_epoch - IO_PROBE_INTERVAL]
+    if len(stamps) >= 2:
+        gaps = [b - a for a, b in zip(stamps, stamps[1:])]
+        result['io_stall_seconds'] = round(max(0.0, max(gaps) - IO_PROBE_INTERVAL), 2)
+    return result
+
+
+def wait_for_volume_failover(namespace: str, pv_names: List[str], start_ts: timing.MonotonicTimestamp,
+                             poll_interval: int, timeout: int,
+                             logger: logging.Logger) -> float:
+    """
+    Wait until every volume of the VM is attached again.
+    Returns seconds since start_ts, or -1.0 on timeout.
+    """
+    if not pv_names:
+        return -1.0
+
+    deadline = time.monotonic() + timeout
+    while time.monotonic() < deadline:
+        attachments = get_volume_attachments(pv_names, logger)
+        if attachments is not None:
+            attached = {a['pv'] for a in attachments if a['attached'] and not a['deleting']}
+            if attached >= set(pv_names):
+                return (timing.now() - start_ts).total_seconds()
+        time.sleep(poll_interval)
+    return -1.0
+
+
+def inject_storage_failure(args: argparse.Namespace, logger: logging.Logger) -> Tuple[bool, Dict]:
+    """Kill or pause the storage provider pods for args.node. Returns (success, state)."""
+    state = {'killed': [], 'pod': None}
+    node = None if args.include_remote_storage_pods else args.node
+
+    if args.failure_mode == 'storage-pod-pause':
+        command = storage_pause_command(args.storage_process, args.failure_duration)
+        pod_name = f"virtbench-inject-storage-pause-{int(time.time())}"
+        if not create_node_exec_pod(args.node, command, pod_name, 'default',
+                                    args.injector_image, logger):
+            return False, state
+        state['pod'] = pod_name
+        logger.info(f"Paused '{args.storage_process}' on {args.node} for {args.failure_duration}s")
+        return True, state
+
+    pods = get_storage_pods(args.storage_pods, node, logger)
+    if not pods:
+        logger.error(f"No storage pods matching {args.storage_pods} found"
+                     + (f" on {args.node}" if node else ''))
+        return False, state
+
+    for pod in pods:
+        returncode, _, stderr = run_kubectl(
+            ['delete', 'pod', pod['name'], '-n', pod['namespace'],
+             '--grace-period=0', '--force', '--wait=false'],
+            logger=logger
+        )
+        if returncode == 0:
+            logger.info(f"Deleted storage pod {pod['namespace']}/{pod['name']} on {pod['node']}")
+            state['killed'].append(pod)
+        else:
+            logger.error(f"Failed to delete storage pod {pod['namespace']}/{pod['name']}: {stderr}")
+
+    return bool(state['killed']), state
+
+
+def summarize_by_storage_class(results: List[Dict]) -> Dict[str, Dict]:
+    """Group storage failure results by storage class."""
+    by_class: Dict[str, Dict] = {}
+    for sc in sorted({r['storage_class'] for r in results}):
+        group = [r for r in results if r['storage_class'] == sc]
+        by_class[sc] = {
+            'vms': len(group),
+            'io_stall_seconds': describe([r['io_stall_seconds'] for r in group
+                                          if r['io_stall_seconds'] is not None]),
+            'volume_failover_seconds': describe([r['volume_failover_seconds'] for r in group
+                                                 if r['volume_failover_seconds'] >= 0]),
+            'guest_restarts': sum(1 for r in group if r['guest_restarted']),
+        }
+    return by_class
+
+
+def create_data_verifier(args: argparse.Namespace, namespaces: List[str],
+                         logger: logging.Logger) -> Optional[DataVerifier]:
+    """Seed checksummed data in every guest for --verify-data."""
+    if not args.verify_data:
+        return None
+    if args.mode == 'monitor':
+        logger.warning("--verify-data has no effect in monitor mode (the failure already happened)")
+        return None
+
+    executor = GuestExecutor(args.vm_user, args.vm_password, ssh_pod=args.ssh_pod,
+                             ssh_pod_ns=args.ssh_pod_namespace,
+                             max_sessions=args.concurrency, logger=logger)
+    verifier = DataVerifier(executor, size_mb=args.verify_data_size_mb, logger=logger)
+    logger.info(f"Writing verification data in {len(namespaces)} VM(s)...")
+    verifier.seed_many(namespaces, args.vm_name, args.concurrency)
+    return verifier
+
+
+def run_data_verification(args: argparse.Namespace, verifier: DataVerifier,
+                          namespaces: List[str], logger: logging.Logger) -> Dict[str, Dict]:
+    """Verify the seeded data after recovery and log the summary."""
+    logger.info("Verifying guest data integrity...")
+    results = verifier.verify_many(namespaces, args.vm_name, args.concurrency,
+                                   timeout=args.recovery_timeout)
+    verifier.executor.close()
+    print_data_integrity_summary(results, logger)
+    return results
+
+
+def start_kvdb_tracking(args: argparse.Namespace,
+                        logger: logging.Logger) -> Tuple[bool, Optional[KvdbMonitor]]:
+    """
+    With --track-kvdb, check that failing args.node keeps Portworx KVDB quorum
+    and start sampling KVDB health.
+
+    Returns:
+        (proceed, monitor): proceed is False when the failure would lose
+        quorum without --allow-quorum-loss; monitor is None without
+        --track-kvdb or when KVDB is unreachable
+    """
+    if not args.track_kvdb:
+        return True, None
+    if args.mode != 'monitor':
+        safe = check_quorum_safe(args.node, logger)
+        if safe is None:
+            logger.warning("Could not determine Portworx KVDB quorum status")
+        elif not safe:
+            if args.mode == 'manual':
+                logger.warning(f"Powering off {args.node} will LOSE Portworx KVDB quorum")
+            elif args.allow_quorum_loss:
+                logger.warning(f"Failing {args.node} will lose Portworx KVDB quorum "
+                               f"(--allow-quorum-loss set, continuing)")
+            else:
+                logger.error(f"Aborting: failing {args.node} would lose Portworx KVDB quorum. "
+                             f"Use --allow-quorum-loss to proceed anyway.")
+                return False, None
+
+    monitor = KvdbMonitor(args.node, args.poll_interval, logger)
+    if not monitor.start():
+        logger.warning("Portworx KVDB tracking unavailable")
+        return True, None
+    return True, monitor
+
+
+def run_storage_failure_test(args: argparse.Namespace, namespaces: List[str],
+                             logger: logging.Logger,
+                             verifier: Optional[DataVerifier] = None,
+                             watches: Optional[Dict[str, ResourceWatch]] = None) -> int:
+    """
+    Kill or pause storage provider pods and measure VM I/O stall, volume
+    failover time and whether guests had to restart, per storage class.
+    With --track-kvdb, the KVDB quorum check gates the failure and the KVDB
+    failover time is reported next to the storage recovery.
+    """
+    logger.info("Collecting VM volumes and storage classes...")
+    pv_map = collect_vm_volumes(namespaces, args.vm_name, logger)
+    sc_map = {ns: get_vm_storage_class(ns, args.vm_name, logger) for ns in namespaces}
+
+    logger.info("Starting guest I/O probes...")
+    probes = {ns: probe if error is None else {'ip': '', 'uid': '', 'boot_id': None, 'started': False}
+              for ns, probe, error in run_parallel(lambda ns: start_io_probe(args, ns, logger), namespaces,
+                                                   concurrency=args.concurrency, qps=args.qps,
+                                                   burst=args.burst, logger=logger,
+                                                   description='I/O probe start')}
+
+    # Let the probes write a few samples before the failure
+    time.sleep(2)
+
+    proceed, kvdb_monitor = start_kvdb_tracking(args, logger)
+    if not proceed:
+        return 1
+
+    inject_ts = timing.now()
+    inject_epoch = inject_ts.wall_ns / 1e9
+    ok, state = inject_storage_failure(args, logger)
+    if not ok:
+        if state['pod']:
+            delete_node_exec_pod(state['pod'], 'default', logger)
+        if kvdb_monitor:
+            kvdb_monitor.stop()
+        return 1
+
+    kvdb_summary = None
+    try:
+        if args.failure_mode == 'storage-pod-kill':
+            storage_secs = wait_for_storage_pods_ready(args, state['killed'], inject_ts, logger)
+            if storage_secs >= 0:
+                logger.info(f"Storage pods Ready again after {storage_secs:.1f}s")
+            else:
+                logger.warning("Storage pods did not become Ready within the recovery timeout")
+        else:
+            # Wait for the pause to be lifted before measuring failover
+            time.sleep(args.failure_duration)
+            storage_secs = float(args.failure_duration)
+
+        def _measure(ns: str) -> Dict:
+            failover = wait_for_volume_failover(ns, pv_map.get(ns, []), inject_ts,
+                                                args.poll_interval, args.recovery_timeout, logger)
+            phase, recovery, _ = wait_for_vmi_running(ns, args.vm_name, inject_ts,
+                                                      args.poll_interval, args.recovery_timeout,
+                                                      logger, watches=watches)
+            io = collect_io_probe(args, ns, probes[ns], inject_epoch, logger)
+            return {
+                'namespace': ns,
+                'storage_class': sc_map[ns],
+                'phase': phase,
+                'recovery_seconds': recovery,
+                'volume_failover_seconds': failover,
+                'io_stall_seconds': io['io_stall_seconds'],
+                'guest_restarted': io['guest_restarted'],
+            }
+
+        results = []
+        for ns, result, error in run_parallel(_measure, namespaces, concurrency=args.concurrency,
+                                              logger=logger, description='storage failover monitor'):
+            results.append(result if error is None else {
+                'namespace': ns, 'storage_class': sc_map[ns], 'phase': 'Error', 'recovery_seconds': -1.0,
+                'volume_failover_seconds': -1.0, 'io_stall_seconds': None, 'guest_restarted': None,
+            })
+
+        if kvdb_monitor:
+            kvdb_monitor.stop()
+            kvdb_summary = kvdb_monitor.summary(inject_ts)
+    finally:
+        if state['pod']:
+            delete_node_exec_pod(state['pod'], 'default', logger)
+        if kvdb_monitor:
+            kvdb_monitor.stop()
+
+    data_integrity = None
+    if verifier:
+        data_integrity = run_data_verification(args, verifier, namespaces, logger)
+        for r in results:
+            r['data_integrity'] = data_integrity[r['namespace']]['status']
+
+    logger.info("")
+    logger.info("=" * 100)
+    logger.info(f"{'Namespace':<30}{'Storage Class':<25}{'I/O Stall(s)':<15}"
+                f"{'Failover(s)':<15}{'Guest Restart':<15}")
+    logger.info("-" * 100)
+    for r in sorted(results, key=lambda x: x['namespace']):
+        stall = f"{r['io_stall_seconds']:.2f}" if r['io_stall_seconds'] is not None else 'n/a'
+        failover = f"{r['volume_failover_seconds']:.2f}" if r['volume_failover_seconds'] >= 0 else 'Failed'
+        restarted = {True: 'yes', False: 'no', None: 'unknown'}[r['guest_restarted']]
+        logger.info(f"{r['namespace']:<30}{r['storage_class']:<25}{stall:<15}{failover:<15}{restarted:<15}")
+    logger.info("=" * 100)
+
+    by_class = summarize_by_storage_class(results)
+    logger.info("STORAGE FAILURE SUMMARY (per storage class)")
+    logger.info(f"Failure mode: {args.failure_mode}, storage recovery: "
+                + (f"{storage_secs:.1f}s" if storage_secs >= 0 else 'timeout'))
+    for sc, summary in by_class.items():
+        stall = summary['io_stall_seconds']
+        failover = summary['volume_failover_seconds']
+        logger.info(f"  {sc}: {summary['vms']} VMs, guest restarts: {summary['guest_restarts']}")
+        if stall['count']:
+            logger.info(f"    I/O stall min/avg/max: {stall['min']}s / {stall['avg']}s / {stall['max']}s")
+        if failover['count']:
+            logger.info(f"    Volume failover min/avg/max: "
+                        f"{failover['min']}s / {failover['avg']}s / {failover['max']}s")
+    logger.info("=" * 70)
+    if kvdb_summary:
+        print_kvdb_summary(kvdb_summary, logger)
+
+    if args.save_results:
+        out_dir = args._results_dir or build_results_dir(args)
+        os.makedirs(out_dir, exist_ok=True)
+        out_file = os.path.join(out_dir, 'storage_failure_results.json')
+        with open(out_file, 'w') as f:
+            json.dump({
+                'failure_mode': args.failure_mode,
+                'node': args.node,
+                'storage_recovery_seconds': round(storage_secs, 2) if storage_secs >= 0 else None,
+                'by_storage_class': by_class,
+                'kvdb': kvdb_summary,
+                'data_integrity': summarize_data_integrity(data_integrity) if data_integrity else None,
+                'cluster': cluster_inventory(logger),
+                'storage_backend': collect_storage_backend(out_dir, args.namespace_prefix, logger),
+                'run': run_metadata(),
+                'vms': results,
+            }, f, indent=2)
+        logger.info(f"Storage failure results saved to {out_file}")
+
+    recovered = sum(1 for r in results if r['recovery_seconds'] >= 0 and r['volume_failover_seconds'] >= 0)
+    if recovered != len(results):
+        return 2
+    if data_integrity and summarize_data_integrity(data_integrity)['violations']:
+        return 5
+    return 0
+
+
+def plan_run(args: argparse.Namespace, namespaces: List[str], logger: logging.Logger) -> int:
+    """Print what main() would change on the cluster and in the guests (virtbench --dry-run)."""
+    plan = DryRunPlan('failure-recovery', logger)
+    vms = [f"vm/{ns}/{args.vm_name}" for ns in namespaces]
+
+    if args.verify_data and args.mode != 'monitor':
+        for vm in vms:
+            plan.action('exec', vm, f"write {args.verify_data_size_mb} MiB of verification data")
+    if args.remove_node_selector and args.failure_mode not in STORAGE_FAILURE_MODES:
+        for vm in vms:
+            plan.action('patch', vm, 'remove nodeSelector')
+
+    pod_name = f"virtbench-inject-{args.failure_mode}-<timestamp>"
+    if args.failure_mode in STORAGE_FAILURE_MODES:
+        for vm in vms:
+            plan.action('exec', vm, 'start guest I/O probe')
+        if args.failure_mode == 'storage-pod-pause':
+            command = storage_pause_command(args.storage_process, args.failure_duration)
+            pod_name = "virtbench-inject-storage-pause-<timestamp>"
+            plan.apply(json.dumps(node_exec_pod_manifest(args.node, command, pod_name, 'default',
+                                                         args.injector_image)))
+            plan.action('delete', f"pod/default/{pod_name}")
+        else:
+            node = None if args.include_remote_storage_pods else args.node
+            for pod in get_storage_pods(args.storage_pods, node, logger):
+                plan.action('delete', f"pod/{pod['namespace']}/{pod['name']}", f"storage pod on {pod['node']}")
+    elif args.mode == 'far-operator':
+        with open(args.far_config) as f:
+            far_manifest = f.read()
+        for target in plan.apply(far_manifest, verb='apply', detail=f"from {args.far_config}", stamp=False):
+            plan.action('delete', target, 'when recovery monitoring ends')
+    elif args.mode == 'inject' and args.failure_mode == 'drain':
+        plan.action('cordon', f"node/{args.node}")
+        plan.action('drain', f"node/{args.node}", '--ignore-daemonsets --delete-emptydir-data --force')
+        plan.action('uncordon', f"node/{args.node}", 'when recovery monitoring ends')
+    elif args.mode == 'inject':
+        command = build_injection_command(args.failure_mode, args.failure_duration, args.partition_ports)
+        plan.apply(json.dumps(node_exec_pod_manifest(args.node, command, pod_name, 'default',
+                                                     args.injector_image)))
+        plan.action('delete', f"pod/default/{pod_name}", 'when recovery monitoring ends')
+    elif args.mode == 'manual':
+        logger.info(f"[DRY RUN] The run would wait for {args.node} to be powered off manually")
+
+    if args.cleanup:
+        if args.far_name:
+            plan.action('delete', f"fenceagentsremediation/{args.far_namespace}/{args.far_name}")
+        for vm in vms:
+            plan.action('patch', vm, 'remove FAR annotation')
+        if args.failed_node or args.node:
+            plan.action('uncordon', f"node/{args.failed_node or args.node}")
+        if args.cleanup_vms:
+            for ns in labeled_namespaces(namespaces, logger=logger):
+                plan.action('delete', f"vm/{ns}/{args.vm_name}")
+                plan.action('delete', f"namespace/{ns}")
+
+    plan.report()
+    return 0
+
+
 def parse_args() -> argparse.Namespace:
     parser = argparse.ArgumentParser(
-        description='Node failure recovery test (manual, FAR-operator, or monitor-only)',
+        description='Node failure recovery test (manual, FAR-operator, injected, or monitor-only)',
         formatter_class=argparse.RawDescriptionHelpFormatter,
         epilog="""
 Examples:
@@ -598,13 +1559,48 @@ Examples:
 
   # Monitor only (failure already triggered externally)
   %(prog)s --mode monitor --node worker-1 --vm-name rhel-9-vm
+
+  # Inject a failure: stop kubelet for 5 minutes
+  %(prog)s --mode inject --failure-mode kubelet-stop --node worker-1
+
+  # Poll instead of watching (e.g. without watch permission)
+  %(prog)s --mode monitor --node worker-1 --recovery-detection poll
 """,
     )
 
     parser.add_argument('--mode', required=True,
-                        choices=['manual', 'far-operator', 'monitor'],
-                        help='Failure trigger mode (manual/far-operator trigger the '
+                        choices=['manual', 'far-operator', 'monitor', 'inject'],
+                        help='Failure trigger mode (manual/far-operator/inject trigger the '
                              'failure; monitor only measures recovery)')
+    parser.add_argument('--failure-mode', choices=FAILURE_MODES, default=None,
+                        help='Failure to inject with --mode inject: drain (cordon+drain), '
+                             'kubelet-stop, reboot, network-partition (iptables drop), '
+                             'storage-pod-kill, or storage-pod-pause')
+    parser.add_argument('--failure-duration', type=int, default=DEFAULT_FAILURE_DURATION,
+                        help=f'Seconds before kubelet-stop / network-partition are reverted '
+                             f'on the node (default: {DEFAULT_FAILURE_DURATION})')
+    parser.add_argument('--partition-ports', type=int, nargs='+',
+                        default=DEFAULT_PARTITION_PORTS,
+                        help='API server ports blocked for network-partition '
+                             '(default: 6443)')
+    parser.add_argument('--storage-pods', nargs='+', default=DEFAULT_STORAGE_PODS,
+                        help='namespace:label-selector of storage provider pods killed by '
+                             'storage-pod-kill (default: Portworx node pod and CSI driver)')
+    parser.add_argument('--include-remote-storage-pods', action='store_true',
+                        help='Also kill matching storage pods on other nodes (e.g. a CSI '
+                             'controller that does not run on --node)')
+    parser.add_argument('--storage-process', default=DEFAULT_STORAGE_PROCESS,
+                        help=f'Host process pattern paused by storage-pod-pause '
+                             f'(default: {DEFAULT_STORAGE_PROCESS})')
+    parser.add_argument('--vm-user', default=DEFAULT_VM_USER,
+                        help=f'Guest SSH user for the storage failure I/O probe '
+                             f'(default: {DEFAULT_VM_USER})')
+    parser.add_argument('--vm-password', default=None,
+                        help='Guest SSH password for the storage failure I/O probe and '
+                             '--verify-data (required by both)')
+    parser.add_argument('--injector-image', default=DEFAULT_NODE_EXEC_IMAGE,
+                        help=f'Image for the privileged injector pod; must provide nsenter '
+                             f'(default: {DEFAULT_NODE_EXEC_IMAGE})')
     parser.add_argument('--node', required=True,
                         help='Target node name (the node that will fail / has failed)')
     parser.add_argument('--vm-name', default=DEFAULT_VM_NAME,
@@ -633,14 +1629,39 @@ Examples:
                         help=f'SSH pod namespace (default: {DEFAULT_SSH_POD_NS})')
     parser.add_argument('--poll-interval', type=int, default=DEFAULT_POLL_INTERVAL,
                         help=f'Polling interval in seconds (default: {DEFAULT_POLL_INTERVAL})')
+    parser.add_argument('--recovery-detection', choices=RECOVERY_DETECTION_MODES, default='watch',
+                        help='How node failure, rescheduling and Running+Ready are detected: watch the '
+                             'node, VMIs and virt-launcher pods (sub-second timing), or poll every '
+                             '--poll-interval seconds (default: watch)')
     parser.add_argument('--concurrency', type=int, default=DEFAULT_CONCURRENCY,
                         help=f'Maximum parallel workers (default: {DEFAULT_CONCURRENCY})')
+    parser.add_argument('--qps', type=float, default=DEFAULT_QPS,
+                        help='Max VM operations started per second, 0 disables rate limiting (default: 0)')
+    parser.add_argument('--burst', type=int, default=DEFAULT_BURST,
+                        help=f'Max VM operations started back-to-back when --qps is set (default: {DEFAULT_BURST})')
     parser.add_argument('--node-timeout', type=int, default=DEFAULT_NODE_TIMEOUT,
                         help=f'Timeout for node to become NotReady '
                              f'(default: {DEFAULT_NODE_TIMEOUT}s)')
     parser.add_argument('--recovery-timeout', type=int, default=DEFAULT_RECOVERY_TIMEOUT,
                         help=f'Timeout for VM recovery '
                              f'(default: {DEFAULT_RECOVERY_TIMEOUT}s)')
+    parser.add_argument('--track-kvdb', action='store_true',
+                        help='Track Portworx KVDB health and quorum during the test, '
+                             'refuse to fail a node if quorum would be lost, and '
+                             'record KVDB failover times')
+    parser.add_argument('--allow-quorum-loss', action='store_true',
+                        help='With --track-kvdb, fail the node even if KVDB quorum '
+                             'would be lost')
+    parser.add_argument('--verify-fencing', action='store_true',
+                        help='Verify that volume attachments on the failed node are fenced '
+                             'before VMs restart elsewhere, and measure fencing time')
+    parser.add_argument('--verify-data', action='store_true',
+                        help='Write checksummed files in each guest before the failure and '
+                             'verify them after recovery, reporting corruption or data loss '
+                             'per VM (uses --vm-user/--vm-password)')
+    parser.add_argument('--verify-data-size-mb', type=int, default=DEFAULT_VERIFY_SIZE_MB,
+                        help=f'Data written per VM for --verify-data in MiB '
+                             f'(default: {DEFAULT_VERIFY_SIZE_MB})')
 
     parser.add_argument('--cleanup', action='store_true',
                         help='After the test, clean up FAR resources, annotations, '
@@ -664,7 +1685,7 @@ Examples:
     parser.add_argument('--results-folder', default='../results',
                         help='Base directory for saved results (default: ../results)')
     parser.add_argument('--storage-driver', default=None,
-                        help='Storage driver label included in results path (optional)')
+                        help='Storage driver label included in results path, or auto to detect it (optional)')
 
     parser.add_argument('--log-file', help='Optional log file path')
     parser.add_argument('--log-level', default='INFO',
@@ -676,9 +1697,31 @@ Examples:
     if args.mode == 'far-operator' and not args.far_config:
         parser.error('--far-config is required when --mode far-operator')
 
+    if args.mode == 'inject' and not args.failure_mode:
+        parser.error('--failure-mode is required when --mode inject')
+
+    if args.failure_mode and args.mode != 'inject':
+        parser.error('--failure-mode requires --mode inject')
+
     if args.cleanup_vms and not args.cleanup:
         parser.error('--cleanup-vms requires --cleanup')
 
+    if args.allow_quorum_loss and not args.track_kvdb:
+        parser.error('--allow-quorum-loss requires --track-kvdb')
+
+    # Guests are reached over SSH; there is no password to assume
+    if not args.vm_password:
+        if args.failure_mode in STORAGE_FAILURE_MODES:
+            parser.error(f'--vm-password is required with --failure-mode {args.failure_mode}')
+        if args.verify_data and args.mode != 'monitor':
+            parser.error('--vm-password is required with --verify-data')
+
+    if args.qps < 0:
+        parser.error('--qps must be >= 0')
+
+    if args.burst < 1:
+        parser.error('--burst must be >= 1')
+
     return args
 
 
@@ -695,7 +1738,10 @@ def build_results_dir(args: argparse.Namespace, timestamp: Optional[str] = None)
 
 
 def save_test_results(args: argparse.Namespace, results: List[Dict],
-                      logger: logging.Logger) -> None:
+                      logger: logging.Logger,
+                      detection_seconds: Optional[float] = None,
+                      kvdb_summary: Optional[Dict] = None,
+                      data_integrity: Optional[Dict[str, Dict]] = None) -> None:
     """Save results to disk using utils.common.save_results."""
     out_dir = getattr(args, '_results_dir', None) or build_results_dir(args)
     os.makedirs(out_dir, exist_ok=True)
@@ -709,11 +1755,101 @@ def save_test_results(args: argparse.Namespace, results: List[Dict],
         logger=logger,
         skip_clone=True,
     )
+
+    if any(r.get('rescheduling_seconds', -1.0) >= 0 for r in results):
+        phases_file = os.path.join(out_dir, 'recovery_phases.json')
+
+        payload = {
+            'mode': args.mode,
+            'failure_mode': getattr(args, 'failure_mode', None),
+            'failed_node': args.node,
+            'recovery_detection': args.recovery_detection,
+            'detection_seconds': round(detection_seconds, 2) if detection_seconds is not None else None,
+            'summary': {
+                'rescheduling_seconds': describe([r['rescheduling_seconds'] for r in results
+                                                  if r['rescheduling_seconds'] >= 0]),
+                'boot_seconds': describe([r['boot_seconds'] for r in results
+                                          if r['boot_seconds'] >= 0]),
+                'recovery_seconds': describe([r['recovery_seconds'] for r in results
+                                              if r['recovery_seconds'] >= 0]),
+            },
+            'vms': [
+                {
+                    'namespace': r['namespace'],
+                    'rescheduling_seconds': r['rescheduling_seconds'],
+                    'boot_seconds': r['boot_seconds'],
+                    'recovery_seconds': r['recovery_seconds'],
+                }
+                for r in sorted(results, key=lambda x: x['namespace'])
+            ],
+        }
+        with open(phases_file, 'w') as f:
+            json.dump(payload, f, indent=2)
+        logger.info(f"Recovery phase timings saved to {phases_file}")
+
+    if kvdb_summary:
+        kvdb_file = os.path.join(out_dir, 'kvdb_results.json')
+        recovered = [r['recovery_seconds'] for r in results if r['recovery_seconds'] >= 0]
+        payload = dict(kvdb_summary)
+        payload['failed_node'] = args.node
+        payload['avg_vm_recovery_seconds'] = (
+            round(sum(recovered) / len(recovered), 2) if recovered else None
+        )
+        with open(kvdb_file, 'w') as f:
+            json.dump(payload, f, indent=2)
+        logger.info(f"KVDB results saved to {kvdb_file}")
+
+    if getattr(args, 'verify_fencing', False):
+        fencing_file = os.path.join(out_dir, 'fencing_results.json')
+        fenced = [r['fencing_seconds'] for r in results if r.get('fenced')]
+        payload = {
+            'failed_node': args.node,
+            'summary': {
+                'total_vms': len(results),
+                'fenced': len(fenced),
+                'violations': len(fencing_violations(results)),
+                'avg_fencing_seconds': round(sum(fenced) / len(fenced), 2) if fenced else None,
+                'max_fencing_seconds': round(max(fenced), 2) if fenced else None,
+                'min_fencing_seconds': round(min(fenced), 2) if fenced else None,
+            },
+            'vms': [
+                {
+                    'namespace': r['namespace'],
+                    'fenced': r.get('fenced', False),
+                    'fencing_seconds': r.get('fencing_seconds', -1.0),
+                    'recovery_seconds': r['recovery_seconds'],
+                    'fenced_before_restart': r.get('fenced_before_restart', False),
+                    'split_brain': r.get('split_brain', False),
+                    'split_brain_pvs': r.get('split_brain_pvs', []),
+                }
+                for r in sorted(results, key=lambda x: x['namespace'])
+            ],
+        }
+        with open(fencing_file, 'w') as f:
+            json.dump(payload, f, indent=2)
+        logger.info(f"Fencing results saved to {fencing_file}")
+
+    if data_integrity is not None:
+        integrity_file = os.path.join(out_dir, 'data_integrity_results.json')
+        payload = {
+            'failed_node': args.node,
+            'summary': summarize_data_integrity(data_integrity),
+            'vms': [dict(namespace=ns, **result) for ns, result in sorted(data_integrity.items())],
+        }
+        with open(integrity_file, 'w') as f:
+            json.dump(payload, f, indent=2)
+        logger.info(f"Data integrity results saved to {integrity_file}")
     logger.info(f"Detailed and summary results saved under: {out_dir}")
 
 
 def main() -> int:
     args = parse_args()
+    set_run_workload('failure-recovery')
+    args.storage_driver = resolve_storage_driver(args.storage_driver)
+    dry_run = is_dry_run()
+    if dry_run:
+        # A dry run has no results to save
+        args.save_results = False
 
     args._results_dir = None
     if args.save_results:
@@ -732,8 +1868,15 @@ def main() -> int:
     logger.info(f"Namespace prefix: {args.namespace_prefix}")
     if args.mode == 'far-operator':
         logger.info(f"FAR config: {args.far_config}")
+    if args.mode == 'inject':
+        logger.info(f"Failure mode: {args.failure_mode}")
     logger.info(f"Remove nodeSelector: {args.remove_node_selector}")
     logger.info(f"Ping recovery check: {args.ping}")
+    logger.info(f"Verify volume fencing: {args.verify_fencing}")
+    logger.info(f"Verify guest data: {args.verify_data}")
+    if args.save_results:
+        # Snapshot the cluster while the target node is still healthy
+        cluster_inventory(logger)
 
     # 1. Detect VMIs to monitor on the target node
     logger.info(f"Detecting VMIs on node {args.node}...")
@@ -744,11 +1887,36 @@ def main() -> int:
         return 1
     logger.info(f"Found {len(namespaces)} VMIs on {args.node}")
 
+    if dry_run:
+        return plan_run(args, namespaces, logger)
+
+    verifier = create_data_verifier(args, namespaces, logger)
+    watches = start_recovery_watches(args, logger)
+    args.recovery_detection = 'watch' if watches else 'poll'
+
+    if args.failure_mode in STORAGE_FAILURE_MODES:
+        return run_storage_failure_test(args, namespaces, logger, verifier, watches)
+
+    # Baseline VMI UIDs let us tell rescheduled VMIs apart from the originals
+    baseline_uids = None
+    if args.mode != 'monitor':
+        baseline_uids = snapshot_vmi_uids(namespaces, args.vm_name, logger)
+
+    pv_map = None
+    if args.verify_fencing:
+        logger.info("Collecting volumes for fencing verification...")
+        pv_map = collect_vm_volumes(namespaces, args.vm_name, logger)
+
     # 2. Optionally remove nodeSelector
     if args.remove_node_selector:
-        remove_node_selectors_parallel(namespaces, args.vm_name, args.concurrency, logger)
+        remove_node_selectors_parallel(namespaces, args.vm_name, args.concurrency, logger, args.qps, args.burst)
 
-    # 3. Trigger / wait for node failure (manual + far-operator only)
+    # 3. Check KVDB quorum before anything destructive happens
+    proceed, kvdb_monitor = start_kvdb_tracking(args, logger)
+    if not proceed:
+        return 1
+
+    # Trigger / wait for node failure (manual + far-operator + inject only)
     far_applied = False
     if args.mode == 'far-operator':
         def cleanup_handler(signum, frame):
@@ -764,40 +1932,80 @@ def main() -> int:
             return 1
         far_applied = True
 
+    injection_state = None
+    inject_ts = None
+    if args.mode == 'inject':
+        inject_ts = timing.now()
+        ok, injection_state = inject_node_failure(args, logger)
+        if not ok:
+            restore_node_failure(args, injection_state, logger)
+            return 1
+
     rc = 0
+    detection_secs = None
     try:
         # 4. Determine the start timestamp for measurement
         if args.mode == 'monitor':
-            node_down_ts = datetime.utcnow()
+            node_down_ts = timing.now()
             logger.info(f"Monitor mode: using current time as start "
-                        f"({node_down_ts.isoformat()}Z)")
+                        f"({node_down_ts.rfc3339nano()})")
         else:
             node_down_ts = wait_for_node_down(args.node, args.node_timeout,
-                                              args.mode, logger)
+                                              args.mode, logger,
+                                              failure_mode=args.failure_mode,
+                                              watches=watches)
             if node_down_ts is None:
                 return 1
 
+        if inject_ts is not None:
+            detection_secs = (node_down_ts - inject_ts).total_seconds()
+            logger.info(f"Failure detected {detection_secs:.1f}s after injection")
+
         # 5. Monitor VM recovery
         results = monitor_vm_recovery(
             namespaces, args.vm_name, node_down_ts,
             args.ssh_pod, args.ssh_pod_namespace,
             args.poll_interval, args.recovery_timeout,
             args.concurrency, args.ping, logger,
+            pv_map=pv_map, failed_node=args.node,
+            baseline_uids=baseline_uids, watches=watches,
         )
 
+        kvdb_summary = None
+        if kvdb_monitor:
+            kvdb_monitor.stop()
+            kvdb_summary = kvdb_monitor.summary(node_down_ts)
+
         # 6. Summary
         print_summary(results, args.ping, logger)
+        if detection_secs is not None:
+            logger.info(f"Failure detection time ({args.failure_mode}): {detection_secs:.1f}s")
+        if kvdb_summary:
+            print_kvdb_summary(kvdb_summary, logger)
+
+        data_integrity = None
+        if verifier:
+            data_integrity = run_data_verification(args, verifier, namespaces, logger)
 
         if args.save_results:
-            save_test_results(args, results, logger)
+            save_test_results(args, results, logger, detection_secs, kvdb_summary, data_integrity)
 
         recovered = sum(1 for r in results
                         if r['phase'] == 'Running' and r['recovery_seconds'] >= 0)
         rc = 0 if recovered == len(results) else 2
+        if rc == 0 and args.verify_fencing and fencing_violations(results):
+            rc = 4
+        if rc == 0 and data_integrity and summarize_data_integrity(data_integrity)['violations']:
+            rc = 5
 
     finally:
+        stop_recovery_watches(watches)
+        if kvdb_monitor:
+            kvdb_monitor.stop()
         if far_applied:
             remove_far_config(args.far_config, logger)
+        if injection_state is not None:
+            restore_node_failure(args, injection_state, logger)
 
     # 7. Optional cleanup phase
     if args.cleanup:
@@ -78,7 +78,12 @@ from utils.common import (
     get_vm_disk_count,
     get_vmi_ip,
     ssh_exec_command,
+    set_run_workload,
+    run_metadata,
 )
+from utils.inventory import cluster_inventory, resolve_storage_driver
+from utils.storageprovider import collect_storage_backend
+from utils.dryrun import DryRunPlan, is_dry_run
 
 
 def detect_disk_count_from_template(vm_template_path: str) -> Optional[int]:
@@ -737,6 +742,20 @@ def manage_service_on_vm(namespace: str, vm_name: str, action: str,
     return True
 
 
+def plan_run(args, vm_targets: List[Tuple[str, str]], actions: List[str], logger):
+    """Print the elbencho actions that would run in each VM over SSH (virtbench --dry-run)."""
+    plan = DryRunPlan('elbencho', logger)
+    for action in actions:
+        detail = f"via {args.ssh_pod}"
+        if action == "change-workload":
+            mode = f"iops={args.iops}" if args.iops > 0 else f"rwmixpct={args.rwmixpct}"
+            duration = f"{args.duration}s" if args.duration > 0 else "infinite"
+            detail = f"{mode} bs={args.block_size} iodepth={args.iodepth} duration={duration} {detail}"
+        for ns, vm_name in vm_targets:
+            plan.action(action, f"vm/{ns}/{vm_name}", detail)
+    plan.report()
+
+
 def main():
     parser = argparse.ArgumentParser(
         description="Manage elbencho workloads on VMs"
@@ -788,7 +807,7 @@ def main():
     parser.add_argument("--results-dir", type=str, default="./results",
                         help="Base results directory (default: ./results)")
     parser.add_argument("--storage-driver", type=str, default="Not-Specified",
-                        help="Storage driver for results folder (default: Not-Specified)")
+                        help="Storage driver for results folder, or auto to detect it (default: Not-Specified)")
     parser.add_argument("--disks-per-vm", type=str, default="auto",
                         help="Disks per VM for results folder name (default: auto-detect from first VM, fallback: 1-disk)")
     parser.add_argument("--run-name", type=str, default=None,
@@ -812,6 +831,13 @@ def main():
                         help="Path to log file. If not specified, uses default based on action.")
 
     args = parser.parse_args()
+    set_run_workload('elbencho')
+    args.storage_driver = resolve_storage_driver(args.storage_driver, default="Not-Specified")
+    # status only reads from the VMs; deploy hands the dry run on to datasource-clone
+    dry_run = is_dry_run() and args.action != "status"
+    if dry_run:
+        # A dry run has no results to save
+        args.save_results = False
 
     # Build list of namespaces early so saved runs can log into their result directory.
     namespaces = [f"{args.namespace_prefix}-{i}" for i in range(args.start, args.end + 1)]
@@ -823,6 +849,8 @@ def main():
         args.log_file = os.path.join(output_dir, f"elbencho_{args.action}_{timestamp}.log")
 
     logger = setup_logging(log_file=args.log_file, log_level=args.log_level)
+    if args.save_results and args.action == "run-all":
+        cluster_inventory(logger)
 
     # Validate arguments
     if args.action in ["deploy", "run-all"]:
@@ -865,6 +893,10 @@ def main():
 
     start_time = datetime.now()
 
+    if dry_run and args.action not in ("deploy", "run-all", "cleanup"):
+        plan_run(args, vm_targets, [args.action], logger)
+        return
+
     # Handle gather-results action separately
     if args.action == "gather-results":
         if args.output_dir:
@@ -969,6 +1001,9 @@ def main():
                 "min_latency_us": min(min_latencies) if min_latencies else 0,
                 "max_latency_us": max(max_latencies) if max_latencies else 0
             },
+            "cluster": cluster_inventory(logger),
+            "storage_backend": collect_storage_backend(output_dir, args.namespace_prefix, logger),
+            "run": run_metadata(),
             "per_vm_results": all_results
         }
 
@@ -1062,6 +1097,7 @@ def main():
             end=args.end,
             vm_name=args.vm_name,
             delete_namespaces=True,
+            dry_run=dry_run,
             batch_size=20,
             logger=logger
         )
@@ -1137,6 +1173,10 @@ def main():
         deploy_elapsed = (datetime.now() - start_time).total_seconds()
         logger.info(f"Deploy completed in {deploy_elapsed:.2f}s")
 
+        if dry_run:
+            plan_run(args, vm_targets, ["change-workload", "gather-results"], logger)
+            return
+
         # Step 2: Start workload
         logger.info("")
         logger.info("[2/4] Starting elbencho workload on all VMs...")
@@ -1295,6 +1335,9 @@ def main():
                     "min_latency_us": min(min_latencies) if min_latencies else 0,
                     "max_latency_us": max(max_latencies) if max_latencies else 0
                 },
+                "cluster": cluster_inventory(logger),
+                "storage_backend": collect_storage_backend(output_dir, args.namespace_prefix, logger),
+                "run": run_metadata(),
                 "per_vm_results": all_results
             }
 
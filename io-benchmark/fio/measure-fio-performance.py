@@ -40,12 +40,21 @@ from typing import Tuple, List, Optional, Dict
 
 sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..', '..')))
 
+from utils.apply import apply_manifest
 from utils.common import (
     setup_logging, run_kubectl_command, create_namespace, create_namespaces_parallel,
     delete_namespace, cleanup_test_namespaces, confirm_cleanup,
     print_cleanup_summary, get_vm_disk_count, get_vmi_ip, get_pvc_status,
-    ssh_exec_command,
+    ssh_exec_command, stamp_manifest, set_run_workload, run_selector, labeled_namespaces,
+    WORKLOAD_LABEL,
+    run_metadata,
 )
+from utils.inventory import cluster_inventory, resolve_storage_driver
+from utils.storageprovider import collect_storage_backend
+from utils.dryrun import DryRunPlan, is_dry_run
+
+# Selects the namespaces and VMs created by fio deploy/run-all
+FIO_SELECTOR = f"{WORKLOAD_LABEL}=fio"
 
 # Defaults
 DEFAULT_VM_NAME = 'fio-vm'
@@ -116,7 +125,7 @@ Examples:
     # Output
     parser.add_argument('--results-dir', type=str, default='results', help='Base results directory')
     parser.add_argument('--storage-driver', type=str, default='Not-Specified',
-                        help='Storage driver for results folder (default: Not-Specified)')
+                        help='Storage driver for results folder, or auto to detect it (default: Not-Specified)')
     parser.add_argument('--disks-per-vm', type=str, default='auto',
                         help='Disks per VM for results folder name (default: auto-detect from first VM, fallback: 1-disk)')
     parser.add_argument('--save-results', action='store_true', help='Save results to JSON/CSV')
@@ -173,16 +182,12 @@ def prepare_vm_yaml(template_path: str, vm_name: str, storage_class: str,
 
 def deploy_vm(namespace: str, vm_yaml: str, logger) -> bool:
     """Deploy VM in namespace. Returns success status."""
-    try:
-        proc = subprocess.run(
-            ['kubectl', 'apply', '-f', '-', '-n', namespace],
-            input=vm_yaml, capture_output=True, text=True, check=True
-        )
-        logger.debug(f"[{namespace}] VM deployed")
-        return True
-    except subprocess.CalledProcessError as e:
-        logger.error(f"[{namespace}] Failed to deploy VM: {e.stderr}")
+    applied, error = apply_manifest(stamp_manifest(vm_yaml, namespace, logger), namespace, logger=logger)
+    if not applied:
+        logger.error(f"[{namespace}] Failed to deploy VM: {error}")
         return False
+    logger.debug(f"[{namespace}] VM deployed")
+    return True
 
 
 def wait_for_vm_running(namespace: str, vm_name: str, timeout: int, logger) -> bool:
@@ -406,8 +411,15 @@ def aggregate_results(results: List[Dict], fio_config: Dict,
     }
 
 
-def save_results_to_files(output_dir: str, summary: Dict, all_results: List[Dict], logger):
+def save_results_to_files(output_dir: str, summary: Dict, all_results: List[Dict], logger,
+                          namespace_prefix: Optional[str] = None):
     """Save results to JSON and CSV files."""
+    summary['cluster'] = cluster_inventory(logger)
+    summary['run'] = run_metadata()
+    storage_backend = collect_storage_backend(output_dir, namespace_prefix, logger)
+    if storage_backend is not None:
+        summary['storage_backend'] = storage_backend
+
     # Save summary
     summary_path = os.path.join(output_dir, "summary_fio_benchmark.json")
     with open(summary_path, 'w') as f:
@@ -579,7 +591,7 @@ def action_status(args, namespaces, ssh_config, logger):
 
     summary = {'running': 0, 'completed': 0, 'not-started': 0, 'not-running': 0, 'unknown': 0}
 
-    for ns in namespaces:
+    for ns in labeled_namespaces(namespaces, FIO_SELECTOR, logger):
         vm_status, vmi_phase = get_vm_and_vmi_status(ns, args.vm_name)
         vm_ip = get_vmi_ip(args.vm_name, ns, logger) if vmi_phase == "Running" else None
 
@@ -644,7 +656,7 @@ def action_gather_results(args, namespaces, fio_config, ssh_config, logger):
     summary = aggregate_results(all_results, fio_config, test_duration)
 
     if args.save_results:
-        save_results_to_files(output_dir, summary, all_results, logger)
+        save_results_to_files(output_dir, summary, all_results, logger, args.namespace_prefix)
 
     print_results_table(summary)
 
@@ -666,10 +678,12 @@ def action_cleanup(args, namespaces, logger):
         end=args.end,
         vm_name=args.vm_name,
         delete_namespaces=True,
+        dry_run=is_dry_run(),
         batch_size=20,
-        logger=logger
+        logger=logger,
+        selector=FIO_SELECTOR
     )
-    print(f"Cleaned up {len(namespaces)} namespaces")
+    print(f"{'[DRY RUN] Would clean' if is_dry_run() else 'Cleaned'} up {len(namespaces)} namespaces")
 
 
 def action_run_all(args, namespaces, fio_config, ssh_config, logger):
@@ -757,7 +771,7 @@ def action_run_all(args, namespaces, fio_config, ssh_config, logger):
     summary = aggregate_results(all_results, fio_config, test_duration)
 
     if args.save_results:
-        save_results_to_files(output_dir, summary, all_results, logger)
+        save_results_to_files(output_dir, summary, all_results, logger, args.namespace_prefix)
 
     print_results_table(summary)
 
@@ -774,13 +788,44 @@ def action_run_all(args, namespaces, fio_config, ssh_config, logger):
                 vm_name=args.vm_name,
                 delete_namespaces=True,
                 batch_size=20,
-                logger=logger
+                logger=logger,
+                selector=run_selector()
             )
             print(f"Cleaned up {len(namespaces)} namespaces")
 
 
+def plan_run(args, namespaces, fio_config, logger):
+    """Print what deploy or run-all would create and delete (virtbench --dry-run)."""
+    plan = DryRunPlan('fio', logger)
+    plan.create_namespaces(namespaces)
+    template_path = os.path.join(os.path.dirname(__file__), args.vm_template)
+    vm_yaml = prepare_vm_yaml(
+        template_path, args.vm_name, args.storage_class,
+        fio_config, args.vm_password, logger
+    )
+    for ns in namespaces:
+        plan.apply(vm_yaml, ns, verb='apply', detail='FIO starts on boot')
+
+    if args.action == 'run-all':
+        for ns in namespaces:
+            plan.action('collect', f"vm/{ns}/{args.vm_name}", f"FIO results over SSH via {args.ssh_pod}")
+        if args.cleanup:
+            for ns in namespaces:
+                plan.action('delete', f"vm/{ns}/{args.vm_name}")
+            plan.delete_namespaces(namespaces)
+    plan.report()
+
+
 def main():
     args = parse_args()
+    set_run_workload('fio')
+    args.storage_driver = resolve_storage_driver(args.storage_driver, args.storage_class, default='Not-Specified')
+    # status and gather-results only read from the cluster and cleanup has its own dry run
+    dry_run = is_dry_run() and args.action in ('deploy', 'run-all')
+    if dry_run:
+        # A dry run has no results to save; run-all only cleans up after saving them
+        args.cleanup = args.cleanup and args.save_results
+        args.save_results = False
     namespaces = [f"{args.namespace_prefix}-{i}" for i in range(args.start, args.end + 1)]
 
     if args.save_results and args.action in ['gather-results', 'run-all'] and not args.log_file:
@@ -788,6 +833,8 @@ def main():
         args.log_file = os.path.join(output_dir, "fio-benchmark.log")
 
     logger = setup_logging(args.log_file, args.log_level)
+    if args.save_results and args.action == 'run-all':
+        cluster_inventory(logger)
 
     fio_config = {
         'runtime': args.fio_runtime,
@@ -805,6 +852,10 @@ def main():
         'password': args.vm_password
     }
 
+    if dry_run:
+        plan_run(args, namespaces, fio_config, logger)
+        return
+
     if args.action == 'deploy':
         action_deploy(args, namespaces, fio_config, logger)
     elif args.action == 'status':
@@ -29,11 +29,29 @@ Usage:
     # Multi-source-node migration pinned to a single target node
     python3 measure-vm-migration-time.py --source-nodes worker-1 worker-2 --target-node worker-5 --concurrency 15
 
+    # Verify guest data survives migration (checksummed files written before, checked after)
+    python3 measure-vm-migration-time.py --start 1 --end 10 --parallel --verify-data
+
+    # Migrate under load: 1 GiB of guest memory dirtied at 200 MiB/s plus one CPU worker
+    python3 measure-vm-migration-time.py --start 1 --end 10 --parallel --guest-load-memory-mb 1024 \
+        --guest-load-dirty-rate 200 --guest-load-cpu 1
+
+    # Migrate the same VMs once per MigrationPolicy and compare the policies
+    python3 measure-vm-migration-time.py --start 1 --end 10 --parallel --policy-matrix policies.yaml
+
+    # Bandwidth throttling sweep: every bandwidth cap under 2 and 5 parallel migrations per cluster
+    python3 measure-vm-migration-time.py --start 1 --end 10 --parallel \
+        --bandwidth-sweep 32Mi 64Mi 128Mi --parallel-sweep 2 5
+
+    # Cross-zone migration: every VM moves to a node in another availability zone
+    python3 measure-vm-migration-time.py --start 1 --end 50 --parallel --migration-zone cross
+
 Author: KubeVirt Benchmark Suite Contributors
 License: Apache 2.0
 """
 
 import argparse
+import csv
 import json
 import logging
 import os
@@ -41,30 +59,73 @@ import subprocess
 import sys
 import time
 import random
+import re
 import yaml
+from collections import Counter
 from datetime import datetime
-from concurrent.futures import ThreadPoolExecutor, as_completed
 from typing import Tuple, Dict, List, Optional
 
 # Add parent directory to path for imports
 sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
 
+from utils import timing
+from utils.concurrency import run_parallel, DEFAULT_QPS, DEFAULT_BURST
 from utils.common import (
-    setup_logging, run_kubectl_command, create_namespace, create_namespaces_parallel,
-    delete_namespace, get_vm_status, get_vmi_ip, ping_vm, print_summary_table,
+    setup_logging, run_kubectl_command, create_or_adopt, create_namespace, create_namespaces_parallel,
+    delete_namespace, get_vm_status, get_vmi_ip, check_guest_ready, print_summary_table,
     validate_prerequisites, get_worker_nodes, select_random_node,
     add_node_selector_to_vm_yaml, get_vm_node, migrate_vm, get_migration_status,
     wait_for_migration_complete, get_available_nodes, create_namespace,
     find_busiest_node, get_vms_on_node, remove_node_selectors,
     cleanup_test_namespaces, confirm_cleanup, print_cleanup_summary,
-    list_resources_in_namespace, delete_vmim, save_migration_results,
-    get_command_for_logging,
+    list_resources_in_namespace, delete_vmim, save_migration_results, migration_summary,
+    get_command_for_logging, get_pvc_storage_class, get_vmi_memory_bytes, migration_throughput,
+    get_migration_job_stats, set_run_workload, run_selector, migration_manifest,
+    get_node_zones, migration_zone_summary, ZONE_LABEL,
+    vm_targets, split_vm_target, call_for_target, target_namespaces, rename_template_vm,
+    GUEST_OS_CHOICES, GUEST_OS_LINUX, GUEST_OS_WINDOWS,
+)
+from utils.custommetrics import query_migration_data_bytes
+from utils.stats import describe, log_outliers
+from utils.events import watch_events
+from utils.utilization import sample_nodes
+from utils.inventory import cluster_inventory, resolve_storage_driver
+from utils.dryrun import DryRunPlan, is_dry_run
+from utils.notify import (
+    notify_phase, notify_run, watch_run, phase_status, duration_metrics, percentile_metrics,
+)
+from utils.output import emit
+from utils.placement import (
+    get_strategy, interleave, PlacementStrategy, STRATEGIES as PLACEMENT_STRATEGIES, STRATEGY_NONE,
+)
+from utils.tuning import cpu_manager_nodes, tuning_settings, write_tuned_template, HUGEPAGE_SIZES
+from utils.estimate_capacity import estimate_capacity, print_estimate
+from utils.guestexec import GuestExecutor
+from utils.guestload import GuestLoad
+from utils.dataintegrity import (
+    DataVerifier, print_data_integrity_summary, DEFAULT_VERIFY_SIZE_MB,
 )
 
 # Default configuration
 DEFAULT_VM_NAME = 'rhel-9-vm'
 DEFAULT_NAMESPACE_PREFIX = 'kubevirt-perf-test'
 DEFAULT_VM_YAML = '../examples/vm-templates/rhel9-vm-datasource.yaml'
+DEFAULT_WINDOWS_VM_NAME = 'win2k22-vm'
+DEFAULT_WINDOWS_VM_YAML = '../examples/vm-templates/windows-vm-datasource.yaml'
+DEFAULT_VM_USER = 'cloud-user'
+DEFAULT_VM_PASSWORD = 'changeme'
+
+# MigrationPolicy matrix (--policy-matrix)
+POLICY_SETTINGS = ('allowAutoConverge', 'bandwidthPerMigration', 'completionTimeoutPerGiB', 'allowPostCopy')
+# Matrix settings that only exist cluster-wide, in the KubeVirt CR's spec.configuration.migrations
+CLUSTER_MIGRATION_SETTINGS = ('parallelMigrationsPerCluster', 'parallelOutboundMigrationsPerNode')
+BANDWIDTH_PATTERN = re.compile(r'^[0-9]+(\.[0-9]+)?(Ki|Mi|Gi|K|M|G)?$')
+POLICY_LABEL = 'virtbench.io/migration-policy'
+POLICY_SETTLE_SECONDS = 5
+
+# Zone of a migration's target relative to its source node (--migration-zone)
+ANY_ZONE = 'any'
+MIGRATION_ZONES = [ANY_ZONE, 'same', 'cross']
 
 
 def parse_arguments():
@@ -105,22 +166,38 @@ Examples:
                        help='Start index for test namespaces (default: 1)')
     parser.add_argument('-e', '--end', type=int, default=10,
                        help='End index for test namespaces (default: 10)')
-    parser.add_argument('-n', '--vm-name', type=str, default=DEFAULT_VM_NAME,
-                       help=f'VM name (default: {DEFAULT_VM_NAME})')
+    parser.add_argument('-n', '--vm-name', type=str, default=None,
+                       help=f'VM name (default: {DEFAULT_VM_NAME}, or {DEFAULT_WINDOWS_VM_NAME} for Windows)')
+    parser.add_argument('--guest-os', choices=GUEST_OS_CHOICES, default=GUEST_OS_LINUX,
+                       help='Guest OS of the VMs; Windows guests are validated via RDP/WinRM '
+                            'instead of ping (default: linux)')
     
     # Namespace configuration
     parser.add_argument('--namespace-prefix', type=str, default=DEFAULT_NAMESPACE_PREFIX,
                        help=f'Prefix for test namespaces (default: {DEFAULT_NAMESPACE_PREFIX})')
+    parser.add_argument('--single-namespace', type=str, default=None,
+                       help='Use VMs named <vm-name>-<index> in this existing namespace instead of one '
+                            'namespace per VM (for clusters that restrict namespace churn)')
     
     # VM creation
     parser.add_argument('--create-vms', action='store_true',
                        help='Create VMs before migration (default: use existing VMs)')
-    parser.add_argument('--vm-template', type=str, default=DEFAULT_VM_YAML,
-                       help=f'VM template YAML file (default: {DEFAULT_VM_YAML})')
+    parser.add_argument('--vm-template', type=str, default=None,
+                       help=f'VM template YAML file (default: {DEFAULT_VM_YAML}, '
+                            f'or {DEFAULT_WINDOWS_VM_YAML} for Windows)')
     parser.add_argument('--single-node', action='store_true',
                        help='Create all VMs on a single node (requires --create-vms)')
     parser.add_argument('--node-name', type=str, default=None,
                        help='Specific node to create VMs on (requires --single-node and --create-vms)')
+    parser.add_argument('--dedicated-cpus', action='store_true',
+                       help='Create VMs with every vCPU pinned to a dedicated host core (requires --create-vms)')
+    parser.add_argument('--hugepages', choices=HUGEPAGE_SIZES, default=None,
+                       help='Create VMs with guest memory backed by hugepages of this size (requires --create-vms)')
+    parser.add_argument('--numa', action='store_true',
+                       help='Pass the host NUMA topology through to the guest '
+                            '(requires --dedicated-cpus and --hugepages)')
+    parser.add_argument('--skip-hugepages-check', action='store_true',
+                       help='Do not abort when the nodes lack free hugepages for the VMs (with --hugepages)')
     
     # Migration scenarios
     parser.add_argument('--source-node', type=str, default=None,
@@ -142,10 +219,24 @@ Examples:
                        help='Auto-select the node with most VMs for evacuation (requires --evacuate)')
     parser.add_argument('--round-robin', action='store_true',
                        help='Migrate VMs in round-robin fashion across all nodes')
+    parser.add_argument('--policy-matrix', type=str, default=None,
+                       help='YAML file listing MigrationPolicy settings; every VM is migrated once per '
+                            'policy and the policies are compared (see '
+                            'examples/benchmarks/migration-policy-matrix.yaml)')
+    parser.add_argument('--bandwidth-sweep', type=str, nargs='+', default=None,
+                       help='bandwidthPerMigration values (e.g. 32Mi 64Mi 128Mi); every VM is migrated once per '
+                            'value (and --parallel-sweep value) and the runs are compared')
+    parser.add_argument('--parallel-sweep', type=int, nargs='+', default=None,
+                       help='parallelMigrationsPerCluster values set in the KubeVirt CR for a sweep '
+                            '(requires --parallel)')
     
     # Performance options
     parser.add_argument('-c', '--concurrency', type=int, default=50,
                        help='Number of concurrent migrations (default: 10)')
+    parser.add_argument('--qps', type=float, default=DEFAULT_QPS,
+                       help='Max migrations/deletions started per second, 0 disables rate limiting (default: 0)')
+    parser.add_argument('--burst', type=int, default=DEFAULT_BURST,
+                       help=f'Max operations started back-to-back when --qps is set (default: {DEFAULT_BURST})')
     parser.add_argument('--poll-interval', type=int, default=2,
                        help='Seconds between status checks (default: 5)')
     parser.add_argument('--migration-timeout', type=int, default=600,
@@ -164,6 +255,25 @@ Examples:
                        help='Timeout for ping validation in seconds (default: 3600 = 1 hour)')
     parser.add_argument('--skip-ping', action='store_true',
                        help='Skip ping validation after migration')
+    parser.add_argument('--verify-data', action='store_true',
+                       help='Write checksummed files inside each VM before migration and verify '
+                            'them afterwards, reporting corruption or data loss per VM (Linux guests)')
+    parser.add_argument('--verify-data-size-mb', type=int, default=DEFAULT_VERIFY_SIZE_MB,
+                       help=f'Data written per VM for --verify-data in MiB (default: {DEFAULT_VERIFY_SIZE_MB})')
+    parser.add_argument('--guest-load-memory-mb', type=int, default=0,
+                       help='Keep rewriting this many MiB of guest memory during migration (Linux guests, default: 0 = off)')
+    parser.add_argument('--guest-load-dirty-rate', type=float, default=0,
+                       help='Memory dirtying rate for --guest-load-memory-mb in MiB/s (default: 0 = as fast as possible)')
+    parser.add_argument('--guest-load-cpu', type=int, default=0,
+                       help='Busy-loop CPU workers started in each guest during migration (default: 0)')
+    parser.add_argument('--guest-load-disk-mbs', type=int, default=0,
+                       help='Approximate direct disk write rate in each guest during migration, MiB/s (default: 0)')
+    parser.add_argument('--vm-user', type=str, default=DEFAULT_VM_USER,
+                       help=f'Guest SSH user for --verify-data and --guest-load-* (default: {DEFAULT_VM_USER})')
+    parser.add_argument('--vm-password', type=str, default=DEFAULT_VM_PASSWORD,
+                       help=f'Guest SSH password for --verify-data and --guest-load-* (default: {DEFAULT_VM_PASSWORD})')
+    parser.add_argument('--precision', type=int, default=timing.DEFAULT_PRECISION,
+                       help=f'Decimal places for durations in saved results (default: {timing.DEFAULT_PRECISION})')
     
     # Logging options
     parser.add_argument('--log-file', type=str, default=None,
@@ -193,7 +303,7 @@ Examples:
         '--storage-driver',
         type=str,
         default=None,
-        help='Storage driver to include in results path (optional)'
+        help='Storage driver to include in results path, or auto to detect it (optional)'
     )
 
     parser.add_argument(
@@ -210,14 +320,162 @@ Examples:
              'Instead of sequential (1,2,3,...), distributes VMs evenly across nodes first. '
              'Example: With 400 VMs and 5 nodes, processes VMs in order: 1,81,161,241,321,2,82,162,... '
              'This ensures even load distribution across all nodes from the start, preventing '
-             'hotspots and improving overall migration performance.'
+             'hotspots and improving overall migration performance. Same as --placement interleave.'
+    )
+    parser.add_argument(
+        '--placement',
+        choices=list(PLACEMENT_STRATEGIES),
+        default=STRATEGY_NONE,
+        help='Target node selection of --parallel and --source-nodes migrations: none (KubeVirt picks), '
+             'spread, pack, nodes (--placement-nodes) or zone; interleave only reorders the '
+             'migrations across source nodes (default: none)'
+    )
+    parser.add_argument(
+        '--placement-nodes',
+        nargs='+',
+        default=None,
+        help='Target nodes the placement strategy uses (required by --placement nodes; '
+             'default: Ready workers other than the source nodes)'
+    )
+    parser.add_argument(
+        '--max-vms-per-node',
+        type=int,
+        default=None,
+        help='Migrations per target node before --placement pack moves to the next node'
+    )
+    parser.add_argument(
+        '--migration-zone',
+        choices=MIGRATION_ZONES,
+        default=ANY_ZONE,
+        help='Target node of --parallel and --source-nodes migrations: in the zone of the VM\'s current '
+             'node (same), in another zone (cross) or anywhere (any). Results always record the source '
+             'and target zones and summarize same-zone and cross-zone migrations separately (default: any)'
     )
+    parser.add_argument(
+        '--topology-key',
+        default=ZONE_LABEL,
+        help=f'Node label of the zone, e.g. a rack label (default: {ZONE_LABEL})'
+    )
+
+    args = parser.parse_args()
+
+    # Guest-OS dependent defaults
+    windows = args.guest_os == GUEST_OS_WINDOWS
+    if args.vm_name is None:
+        args.vm_name = DEFAULT_WINDOWS_VM_NAME if windows else DEFAULT_VM_NAME
+    if args.vm_template is None:
+        args.vm_template = DEFAULT_WINDOWS_VM_YAML if windows else DEFAULT_VM_YAML
+    if args.interleaved_scheduling:
+        args.placement = 'interleave'
+
+    return args
+
+
+def run_parallel_migrations(namespaces: List[str], args, logger,
+                            targets: Optional[Dict[str, Optional[str]]] = None,
+                            on_result=None) -> List[Tuple]:
+    """
+    Migrate VMs through the shared concurrency engine.
+
+    Args:
+        namespaces: Namespaces whose VM should be migrated
+        args: Parsed arguments (vm_name, timeouts, concurrency, qps, burst)
+        logger: Logger instance
+        targets: Optional per-namespace target node; defaults to args.target_node
+        on_result: Optional callback invoked as on_result(ns, result) per completed migration
+
+    Returns:
+        List of migration result tuples, one per namespace
+    """
+    def _migrate(ns):
+        target = targets.get(ns) if targets is not None else args.target_node
+        return migrate_vm_sequential(
+            ns, args.vm_name, target, args.migration_timeout, logger,
+            args.poll_interval, 10, args.max_migration_retries
+        )
+
+    outcomes = run_parallel(
+        _migrate, namespaces, concurrency=args.concurrency, qps=args.qps, burst=args.burst,
+        logger=logger, description="migration", on_result=on_result
+    )
+    return [
+        result if error is None else (ns, False, 0.0, None, None, None)
+        for ns, result, error in outcomes
+    ]
 
-    return parser.parse_args()
+
+def zone_targets(namespaces: List[str], nodes: List[str], placement: PlacementStrategy, args,
+                 logger) -> Dict[str, Optional[str]]:
+    """
+    Target node of each migration in the zone of the VM's current node (--migration-zone same) or in another one (cross).
+
+    The placement strategy picks among the eligible nodes; strategies that do
+    not choose targets (none, interleave) spread the migrations over them.
+    VMs without an eligible node are left to KubeVirt (None).
+    """
+    zones = get_node_zones(topology_key=args.topology_key, logger=logger)
+    if placement.name in (STRATEGY_NONE, 'interleave'):
+        placement = get_strategy('spread', logger=logger)
+    nodes = placement.candidates(nodes)
+
+    sources = {}
+
+    def record_source(ns, node):
+        sources[ns] = node
+
+    run_parallel(
+        lambda ns: call_for_target(get_vm_node, ns, args.vm_name, logger), namespaces,
+        concurrency=args.concurrency, qps=args.qps, burst=args.burst, logger=logger,
+        description="source node lookup", on_result=record_source
+    )
+
+    same = args.migration_zone == 'same'
+    placed = Counter()
+    targets = {}
+    for ns in namespaces:
+        source = sources.get(ns)
+        source_zone = zones.get(source)
+        eligible = [n for n in nodes if n != source and zones.get(n) and (zones[n] == source_zone) == same]
+        target = placement.pick(eligible, placed) if source_zone and eligible else None
+        if target is None:
+            logger.warning(f"[{ns}] No {args.migration_zone}-zone target node for {source or 'unknown node'} "
+                           f"({source_zone or 'no zone'}); KubeVirt picks the target")
+        else:
+            placed[target] += 1
+        targets[ns] = target
+    per_zone = Counter(zones.get(t) for t in targets.values() if t)
+    logger.info(f"{args.migration_zone.capitalize()}-zone migrations per target zone: {dict(sorted(per_zone.items()))}")
+    return targets
 
 
 def validate_migration_args(args, logger):
     """Validate migration-specific arguments."""
+    if args.placement == 'nodes' and not args.placement_nodes:
+        logger.error("--placement nodes requires --placement-nodes")
+        return False
+    if args.placement != STRATEGY_NONE and not (args.parallel or args.source_nodes):
+        logger.error("--placement requires --parallel or --source-nodes")
+        return False
+    if args.migration_zone != ANY_ZONE:
+        if not (args.parallel or args.source_nodes):
+            logger.error("--migration-zone requires --parallel or --source-nodes")
+            return False
+        if args.target_node:
+            logger.error("--migration-zone cannot be combined with --target-node")
+            return False
+
+    if args.qps < 0:
+        logger.error("--qps must be >= 0")
+        return False
+
+    if args.burst < 1:
+        logger.error("--burst must be >= 1")
+        return False
+
+    if args.precision < 0 or args.precision > 9:
+        logger.error("--precision must be between 0 and 9")
+        return False
+
     # Validate --single-node usage
     if args.single_node and not args.create_vms:
         logger.error("--single-node requires --create-vms")
@@ -227,6 +485,41 @@ def validate_migration_args(args, logger):
         logger.error("--node-name requires --single-node")
         return False
 
+    if args.single_namespace and args.ssh_pod_ns == 'default':
+        # The SSH pod is looked up next to the VMs unless --ssh-pod-ns says otherwise
+        args.ssh_pod_ns = args.single_namespace
+
+    args.tuning = tuning_settings(args.dedicated_cpus, args.hugepages, args.numa)
+    if args.tuning and not args.create_vms:
+        logger.error("--dedicated-cpus, --hugepages and --numa require --create-vms")
+        return False
+    if args.numa and not (args.dedicated_cpus and args.hugepages):
+        logger.error("--numa requires --dedicated-cpus and --hugepages")
+        return False
+
+    args.policies = None
+    sweep = args.bandwidth_sweep or args.parallel_sweep
+    if args.policy_matrix or sweep:
+        if args.evacuate or args.round_robin or args.source_nodes:
+            logger.error("--policy-matrix and the sweeps cannot be combined with --evacuate, --round-robin, "
+                         "or --source-nodes")
+            return False
+        if args.policy_matrix and sweep:
+            logger.error("--policy-matrix cannot be combined with --bandwidth-sweep or --parallel-sweep")
+            return False
+        if args.parallel_sweep and not args.parallel:
+            logger.error("--parallel-sweep requires --parallel")
+            return False
+        try:
+            if args.policy_matrix:
+                args.policies = load_policy_matrix(args.policy_matrix)
+            else:
+                args.policies = sweep_policies(args.bandwidth_sweep, args.parallel_sweep)
+        except (OSError, yaml.YAMLError, ValueError) as e:
+            logger.error(f"Invalid {'--policy-matrix' if args.policy_matrix else 'sweep'}: {e}")
+            return False
+        logger.info(f"Policy matrix: {', '.join(p['name'] for p in args.policies)}")
+
     # --source-nodes: multi-node parallel evacuation (new scenario)
     if args.source_nodes:
         if args.source_node:
@@ -273,7 +566,8 @@ def create_vms_on_node(namespaces: List[str], vm_yaml: str, node_name: str,
     Create VMs on a specific node with retry logic.
 
     Args:
-        namespaces: List of namespaces to create VMs in
+        namespaces: List of namespaces to create VMs in, or "{namespace}/{vm}" targets
+            (--single-namespace) for which the template VM is renamed
         vm_yaml: Path to VM YAML template
         node_name: Node to create VMs on (can be None for no node selector)
         vm_name: VM resource name
@@ -290,19 +584,19 @@ def create_vms_on_node(namespaces: List[str], vm_yaml: str, node_name: str,
     else:
         logger.info(f"\nCreating {len(namespaces)} VMs (no node selector)...")
 
-    from utils.common import add_node_selector_to_vm_yaml
-    import subprocess
-
     results = {}
 
     for ns in namespaces:
         success = False
         last_error = None
+        namespace, target_vm = split_vm_target(ns, vm_name)
 
         for attempt in range(1, max_retries + 1):
             try:
+                if target_vm != vm_name:
+                    modified_yaml = render_scoped_vm_yaml(vm_yaml, target_vm, node_name)
                 # Modify VM YAML to add nodeSelector if node_name is specified
-                if node_name:
+                elif node_name:
                     modified_yaml = add_node_selector_to_vm_yaml(vm_yaml, node_name, logger)
                     if not modified_yaml:
                         logger.error(f"[{ns}] Failed to modify VM YAML")
@@ -313,18 +607,15 @@ def create_vms_on_node(namespaces: List[str], vm_yaml: str, node_name: str,
                     with open(vm_yaml, 'r') as f:
                         modified_yaml = f.read()
 
-                # Create VM
-                result = subprocess.run(
-                    f"kubectl create -f - -n {ns}",
-                    shell=True, input=modified_yaml.encode(), capture_output=True
-                )
+                # Create VM, adopting one left by an earlier attempt with the same run UUID
+                created, adopted, error_msg = create_or_adopt(modified_yaml, namespace, logger)
 
-                if result.returncode == 0:
-                    logger.info(f"[{ns}] VM created successfully")
+                if created:
+                    if not adopted:
+                        logger.info(f"[{ns}] VM created successfully")
                     success = True
                     break
                 else:
-                    error_msg = result.stderr.decode().strip()
                     last_error = error_msg
 
                     # Check if it's a retryable error (webhook timeout, internal error)
@@ -374,6 +665,19 @@ def create_vms_on_node(namespaces: List[str], vm_yaml: str, node_name: str,
     return results
 
 
+def render_scoped_vm_yaml(vm_yaml: str, target_vm: str, node_name: Optional[str]) -> str:
+    """VM template renamed to target_vm for --single-namespace, pinned to node_name if given."""
+    with open(vm_yaml, 'r') as f:
+        docs = [doc for doc in yaml.safe_load_all(f) if doc]
+    for doc in docs:
+        if doc.get('kind') != 'VirtualMachine':
+            continue
+        rename_template_vm(doc, doc['metadata']['name'], target_vm)
+        if node_name:
+            doc['spec']['template']['spec']['nodeSelector'] = {'kubernetes.io/hostname': node_name}
+    return yaml.safe_dump_all(docs, sort_keys=False)
+
+
 def wait_for_vms_running(namespaces: List[str], vm_name: str, timeout: int, logger,
                          poll_interval: int = 10) -> Dict[str, bool]:
     """
@@ -394,15 +698,15 @@ def wait_for_vms_running(namespaces: List[str], vm_name: str, timeout: int, logg
     # Track which VMs are still pending
     pending = set(namespaces)
     results = {ns: False for ns in namespaces}
-    start_time = time.time()
+    start_time = time.monotonic()
 
-    while pending and (time.time() - start_time) < timeout:
-        elapsed = time.time() - start_time
+    while pending and (time.monotonic() - start_time) < timeout:
+        elapsed = time.monotonic() - start_time
 
         # Check status of all pending VMs
         still_pending = set()
         for ns in pending:
-            status = get_vm_status(vm_name, ns, logger)
+            status = call_for_target(get_vm_status, ns, vm_name, logger)
 
             if status == "Running":
                 logger.info(f"[{ns}] VM is now Running")
@@ -429,7 +733,7 @@ def wait_for_vms_running(namespaces: List[str], vm_name: str, timeout: int, logg
             # Log which VMs are still pending (only first few to avoid spam)
             if len(pending) <= 5:
                 for ns in pending:
-                    status = get_vm_status(vm_name, ns, logger)
+                    status = call_for_target(get_vm_status, ns, vm_name, logger)
                     logger.debug(f"  [{ns}] status: {status}")
 
             time.sleep(poll_interval)
@@ -438,7 +742,7 @@ def wait_for_vms_running(namespaces: List[str], vm_name: str, timeout: int, logg
     if pending:
         logger.warning(f"\nTimeout reached. {len(pending)} VMs did not reach Running state:")
         for ns in pending:
-            status = get_vm_status(vm_name, ns, logger)
+            status = call_for_target(get_vm_status, ns, vm_name, logger)
             logger.warning(f"  [{ns}] final status: {status}")
             results[ns] = False
 
@@ -465,11 +769,13 @@ def migrate_vm_sequential(
 
     Retries VMIM creation up to `max_vmim_retries` times if webhook/internal errors occur.
     Retries the entire migration up to `max_migration_retries` times if migration fails.
+    The namespace may be a "{namespace}/{vm}" target (--single-namespace); results are keyed by it.
     """
+    namespace, vm_name = split_vm_target(ns, vm_name)
 
     try:
         # Get source node
-        source_node = get_vm_node(vm_name, ns, logger)
+        source_node = get_vm_node(vm_name, namespace, logger)
         if not source_node:
             logger.error(f"[{ns}] Could not determine source node for VM {vm_name}")
             return ns, False, 0.0, None, None, None
@@ -484,7 +790,7 @@ def migrate_vm_sequential(
             vmim_created = False
             for attempt in range(1, max_vmim_retries + 1):
                 try:
-                    if migrate_vm(vm_name, ns, target_node, logger):
+                    if migrate_vm(vm_name, namespace, target_node, logger):
                         vmim_created = True
                         break
                     else:
@@ -504,7 +810,7 @@ def migrate_vm_sequential(
 
             # Wait for migration to complete
             success, observed_duration, actual_target, vmim_duration = wait_for_migration_complete(
-                vm_name, ns, migration_timeout, poll_interval, logger
+                vm_name, namespace, migration_timeout, poll_interval, logger
             )
 
             if success:
@@ -516,13 +822,13 @@ def migrate_vm_sequential(
 
                 # Delete the failed VMIM before retrying
                 logger.info(f"[{ns}] Deleting failed VMIM '{vmim_name}' before retry...")
-                delete_vmim(vmim_name, ns, logger)
+                delete_vmim(vmim_name, namespace, logger)
 
                 # Wait a bit for cleanup
                 time.sleep(retry_delay)
 
                 # Update source node in case VM moved partially
-                new_source = get_vm_node(vm_name, ns, logger)
+                new_source = get_vm_node(vm_name, namespace, logger)
                 if new_source and new_source != source_node:
                     logger.info(f"[{ns}] VM is now on {new_source} (was {source_node})")
                     source_node = new_source
@@ -540,12 +846,350 @@ def migrate_vm_sequential(
         return ns, False, 0.0, None, None, None
 
 
+def collect_migration_job_stats(migration_results: List[Tuple], vm_name: str, args, logger) -> Dict[str, Dict]:
+    """
+    Read downtime, data transferred, dirty rate and iterations of each successful migration.
+
+    Returns:
+        {namespace: get_migration_job_stats() dict}
+    """
+    stats = {}
+
+    def record_stats(ns, result):
+        stats[ns] = result
+
+    run_parallel(
+        lambda ns: call_for_target(get_migration_job_stats, ns, vm_name, logger),
+        [r[0] for r in migration_results if r[1]],
+        concurrency=args.concurrency, qps=args.qps, burst=args.burst, logger=logger,
+        description="migration statistics", on_result=record_stats
+    )
+    if stats and all(s['downtime_ms'] is None for s in stats.values()):
+        logger.info("Domain job statistics not available from virt-launcher; downtime and iterations not reported")
+    return stats
+
+
+def collect_migration_throughput(migration_results: List[Tuple], vm_name: str, migration_timing: Dict,
+                                 args, logger, job_stats: Optional[Dict[str, Dict]] = None) -> Dict[str, Dict]:
+    """
+    Compute transfer-based and memory-based throughput for each successful migration.
+
+    Transferred bytes come from Prometheus, or from the domain job statistics
+    when Prometheus has none.
+
+    Returns:
+        {namespace: migration_throughput() dict}
+    """
+    migrated = [r for r in migration_results if r[1]]
+    if not migrated:
+        return {}
+    transferred = query_migration_data_bytes(migration_timing, logger)
+    for ns, stats in (job_stats or {}).items():
+        if split_vm_target(ns, vm_name) not in transferred and stats.get('data_transferred_bytes'):
+            transferred[split_vm_target(ns, vm_name)] = stats['data_transferred_bytes']
+    memory = {}
+
+    def record_memory(ns, size):
+        memory[ns] = size
+
+    run_parallel(
+        lambda ns: call_for_target(get_vmi_memory_bytes, ns, vm_name, logger), [r[0] for r in migrated],
+        concurrency=args.concurrency, qps=args.qps, burst=args.burst, logger=logger,
+        description="memory lookup", on_result=record_memory
+    )
+    if not transferred:
+        logger.info("Transferred bytes not available; reporting memory-based throughput estimates only")
+    return {
+        ns: migration_throughput(transferred.get(split_vm_target(ns, vm_name)), vmim_duration,
+                                 memory.get(ns), observed_duration)
+        for ns, _, observed_duration, _, _, vmim_duration in migrated
+    }
+
+
+def load_policy_matrix(path: str) -> List[Dict]:
+    """
+    Load and validate a MigrationPolicy matrix file.
+
+    The file holds a `policies` list; each entry has a `name` and any of
+    POLICY_SETTINGS and CLUSTER_MIGRATION_SETTINGS. An entry without settings
+    is a baseline run under the cluster-wide migration configuration.
+
+    Raises:
+        ValueError: If the file is malformed
+    """
+    with open(path) as f:
+        policies = (yaml.safe_load(f) or {}).get('policies')
+    if not policies or not isinstance(policies, list):
+        raise ValueError(f"{path}: expected a non-empty 'policies' list")
+    names = set()
+    for policy in policies:
+        name = str(policy.get('name', ''))
+        if not name or name in names or not name.replace('-', '').isalnum() or name != name.lower():
+            raise ValueError(f"{path}: policy names must be unique lowercase alphanumerics or dashes, got '{name}'")
+        unknown = set(policy) - {'name'} - set(POLICY_SETTINGS) - set(CLUSTER_MIGRATION_SETTINGS)
+        if unknown:
+            raise ValueError(f"{path}: policy '{name}' has unknown settings {sorted(unknown)}; "
+                             f"supported: {', '.join(POLICY_SETTINGS + CLUSTER_MIGRATION_SETTINGS)}")
+        names.add(name)
+    return policies
+
+
+def sweep_policies(bandwidths: Optional[List[str]], parallels: Optional[List[int]]) -> List[Dict]:
+    """
+    Matrix entries of a --bandwidth-sweep/--parallel-sweep: one per combination, bandwidth varying fastest.
+
+    Raises:
+        ValueError: If a value is not a bandwidth quantity or parallel count
+    """
+    for bandwidth in bandwidths or []:
+        if not BANDWIDTH_PATTERN.match(bandwidth):
+            raise ValueError(f"--bandwidth-sweep values must be quantities such as 64Mi, got '{bandwidth}'")
+    for parallel in parallels or []:
+        if parallel < 1:
+            raise ValueError(f"--parallel-sweep values must be >= 1, got {parallel}")
+    policies = []
+    for parallel in parallels or [None]:
+        for bandwidth in bandwidths or [None]:
+            policy = {'name': '-'.join(part for part in (
+                f"bw-{bandwidth.lower().replace('.', '-')}" if bandwidth else None,
+                f"par-{parallel}" if parallel else None) if part)}
+            if bandwidth:
+                policy['bandwidthPerMigration'] = bandwidth
+            if parallel:
+                policy['parallelMigrationsPerCluster'] = parallel
+            policies.append(policy)
+    if len({p['name'] for p in policies}) < len(policies):
+        raise ValueError("sweep values must be unique")
+    return policies
+
+
+def migration_policy_manifest(policy: Dict) -> Optional[Dict]:
+    """MigrationPolicy of a matrix entry, or None for an entry without MigrationPolicy settings."""
+    name = policy['name']
+    settings = {k: v for k, v in policy.items() if k in POLICY_SETTINGS}
+    if not settings:
+        return None
+    return {
+        'apiVersion': 'migrations.kubevirt.io/v1alpha1',
+        'kind': 'MigrationPolicy',
+        'metadata': {'name': f"virtbench-{name}"},
+        'spec': {**settings, 'selectors': {'namespaceSelector': {POLICY_LABEL: name}}},
+    }
+
+
+def apply_migration_policy(policy: Dict, namespaces: List[str], logger) -> bool:
+    """Create the MigrationPolicy for a matrix entry and label the namespaces it selects."""
+    name = policy['name']
+    manifest = migration_policy_manifest(policy)
+    settings = manifest is not None
+    if settings:
+        created, _, error = create_or_adopt(json.dumps(manifest), logger=logger)
+        if not created:
+            logger.error(f"Failed to create MigrationPolicy virtbench-{name}: {error}")
+            return False
+    label = [f"{POLICY_LABEL}={name}", '--overwrite'] if settings else [f"{POLICY_LABEL}-"]
+    for ns in target_namespaces(namespaces):
+        returncode, _, stderr = run_kubectl_command(['label', 'namespace', ns] + label, check=False, logger=logger)
+        if returncode != 0:
+            logger.error(f"[{ns}] Failed to label namespace for policy {name}: {stderr.strip()}")
+            return False
+    # Give virt-controller's informers a moment to see the policy before the first VMIM
+    time.sleep(POLICY_SETTLE_SECONDS)
+    return True
+
+
+def remove_migration_policy(policy: Dict, namespaces: List[str], logger) -> None:
+    """Delete a matrix entry's MigrationPolicy and the namespace labels selecting it."""
+    for ns in target_namespaces(namespaces):
+        run_kubectl_command(['label', 'namespace', ns, f"{POLICY_LABEL}-"], check=False, logger=logger)
+    if migration_policy_manifest(policy) is not None:
+        run_kubectl_command(['delete', 'migrationpolicy', f"virtbench-{policy['name']}", '--ignore-not-found'],
+                            check=False, logger=logger)
+
+
+def get_kubevirt_cr(logger) -> Optional[Dict]:
+    """The cluster's KubeVirt CR, None when it cannot be read."""
+    returncode, stdout, _ = run_kubectl_command(['get', 'kubevirt', '-A', '-o', 'json'], check=False, logger=logger)
+    if returncode != 0:
+        return None
+    items = json.loads(stdout).get('items') or []
+    return items[0] if items else None
+
+
+def patch_cluster_migrations(kubevirt: Dict, settings: Dict, logger) -> bool:
+    """Merge-patch the KubeVirt CR's spec.configuration.migrations (None values remove a field)."""
+    metadata = kubevirt['metadata']
+    patch = {'spec': {'configuration': {'migrations': settings}}}
+    returncode, _, stderr = run_kubectl_command(
+        ['patch', 'kubevirt', metadata['name'], '-n', metadata['namespace'], '--type', 'merge',
+         '-p', json.dumps(patch)], check=False, logger=logger)
+    if returncode != 0:
+        logger.error(f"Failed to patch the KubeVirt migration configuration: {stderr.strip()}")
+        return False
+    return True
+
+
+def apply_cluster_migration_settings(policy: Dict, logger) -> Tuple[bool, Optional[Dict]]:
+    """
+    Set a matrix entry's CLUSTER_MIGRATION_SETTINGS in the KubeVirt CR.
+
+    Returns:
+        Tuple of (success, previous values to restore with restore_cluster_migration_settings, or None)
+    """
+    settings = {k: v for k, v in policy.items() if k in CLUSTER_MIGRATION_SETTINGS}
+    if not settings:
+        return True, None
+    kubevirt = get_kubevirt_cr(logger)
+    if kubevirt is None:
+        logger.error("KubeVirt CR not found; cannot set the cluster migration settings")
+        return False, None
+    current = ((kubevirt.get('spec') or {}).get('configuration') or {}).get('migrations') or {}
+    previous = {'kubevirt': kubevirt, 'settings': {k: current.get(k) for k in settings}}
+    if not patch_cluster_migrations(kubevirt, settings, logger):
+        return False, None
+    logger.info(f"KubeVirt migration configuration set to {json.dumps(settings)}")
+    # Give virt-controller a moment to pick up the configuration before the first VMIM
+    time.sleep(POLICY_SETTLE_SECONDS)
+    return True, previous
+
+
+def restore_cluster_migration_settings(previous: Optional[Dict], logger) -> None:
+    if previous and patch_cluster_migrations(previous['kubevirt'], previous['settings'], logger):
+        logger.info("KubeVirt migration configuration restored")
+
+
+def summarize_policy_run(policy: Dict, results: List[Tuple], job_stats: Dict[str, Dict],
+                         throughput: Dict[str, Dict], total_time: Optional[float] = None) -> Dict:
+    """
+    One row of the policy comparison: completion, downtime and transfer figures of a policy run.
+
+    total_time is the wall-clock time of the pass, from the first migration to the last completing.
+    """
+    def avg(values):
+        values = [v for v in values if v is not None]
+        return round(sum(values) / len(values), 2) if values else None
+
+    def peak(values):
+        values = [v for v in values if v is not None]
+        return max(values) if values else None
+
+    migrated = [r for r in results if r[1]]
+    stats = [job_stats.get(r[0], {}) for r in migrated]
+    return {
+        'policy': policy['name'],
+        'settings': json.dumps({k: v for k, v in policy.items() if k != 'name'}, sort_keys=True),
+        'vms': len(results),
+        'successful': len(migrated),
+        'failed': len(results) - len(migrated),
+        'policy_applied': sum(1 for s in stats if s.get('migration_policy') == f"virtbench-{policy['name']}"),
+        'total_time_sec': round(total_time, 2) if total_time is not None else None,
+        'avg_observed_time_sec': avg(r[2] for r in migrated),
+        'max_observed_time_sec': peak(r[2] for r in migrated),
+        'avg_vmim_time_sec': avg(r[5] for r in migrated),
+        'avg_downtime_ms': avg(s.get('downtime_ms') for s in stats),
+        'max_downtime_ms': peak(s.get('downtime_ms') for s in stats),
+        'avg_iterations': avg(s.get('iterations') for s in stats),
+        'avg_transfer_mib_s': avg(t.get('transfer_mib_s') for t in throughput.values()),
+        'postcopy': sum(1 for s in stats if s.get('mode') == 'PostCopy'),
+    }
+
+
+def run_policy_matrix(namespaces: List[str], policies: List[Dict], args, out_dir: Optional[str],
+                      logger) -> int:
+    """
+    Migrate every VM once per MigrationPolicy and compare the policies.
+
+    Returns:
+        Number of failed migrations across all policies
+    """
+    comparison = []
+    failed = 0
+    for index, policy in enumerate(policies, 1):
+        logger.info("\n" + "=" * 80)
+        logger.info(f"POLICY {index}/{len(policies)}: {policy['name']}")
+        logger.info("=" * 80)
+        settings = {k: v for k, v in policy.items() if k != 'name'}
+        logger.info(f"Settings: {settings or 'cluster default (no MigrationPolicy)'}")
+        applied, previous = apply_cluster_migration_settings(policy, logger)
+        if not applied or not apply_migration_policy(policy, namespaces, logger):
+            remove_migration_policy(policy, namespaces, logger)
+            restore_cluster_migration_settings(previous, logger)
+            failed += len(namespaces)
+            continue
+        try:
+            started = timing.now()
+            if args.parallel:
+                results = run_parallel_migrations(namespaces, args, logger)
+            else:
+                results = [
+                    migrate_vm_sequential(ns, args.vm_name, args.target_node, args.migration_timeout, logger,
+                                          poll_interval=args.poll_interval,
+                                          max_migration_retries=args.max_migration_retries)
+                    for ns in namespaces
+                ]
+            finished = timing.now()
+            policy_timing = timing.timing_metadata(started, finished)
+            job_stats = collect_migration_job_stats(results, args.vm_name, args, logger)
+            throughput = collect_migration_throughput(results, args.vm_name, policy_timing, args, logger,
+                                                      job_stats=job_stats)
+        finally:
+            remove_migration_policy(policy, namespaces, logger)
+            restore_cluster_migration_settings(previous, logger)
+
+        failed += sum(1 for r in results if not r[1])
+        row = summarize_policy_run(policy, results, job_stats, throughput,
+                                   total_time=(finished - started).total_seconds())
+        comparison.append(row)
+        notify_phase('migration', 'policy-complete',
+                     {k: row[k] for k in ('policy', 'vms', 'failed', 'avg_observed_time_sec', 'avg_downtime_ms')},
+                     phase_status(row['failed'], row['vms']), logger=logger)
+        logger.info(f"{row['successful']}/{row['vms']} migrated, MigrationPolicy applied to {row['policy_applied']}")
+        if migration_policy_manifest(policy) and row['successful'] and row['policy_applied'] < row['successful']:
+            logger.warning(f"Policy {policy['name']} was not applied to every migration; "
+                           f"a more specific MigrationPolicy in the cluster may take precedence")
+        if out_dir:
+            save_migration_results(
+                args, results, base_dir=os.path.join(out_dir, 'policies', policy['name']), logger=logger,
+                total_time=(finished - started).total_seconds(), timing=policy_timing,
+                throughput=throughput, job_stats=job_stats
+            )
+
+    def fmt(value):
+        return '-' if value is None else str(value)
+
+    logger.info("\n" + "=" * 130)
+    logger.info("MIGRATION POLICY COMPARISON")
+    logger.info("=" * 130)
+    logger.info(f"{'Policy':<24} {'OK':>4} {'Fail':>5} {'Total (s)':>10} {'Avg Obs (s)':>12} {'Max Obs (s)':>12} "
+                f"{'Avg VMIM (s)':>13} {'Avg Down (ms)':>14} {'Max Down (ms)':>14} {'Avg Iter':>9} {'MiB/s':>8}")
+    logger.info("-" * 130)
+    for row in comparison:
+        logger.info(f"{row['policy']:<24} {row['successful']:>4} {row['failed']:>5} "
+                    f"{fmt(row['total_time_sec']):>10} "
+                    f"{fmt(row['avg_observed_time_sec']):>12} {fmt(row['max_observed_time_sec']):>12} "
+                    f"{fmt(row['avg_vmim_time_sec']):>13} {fmt(row['avg_downtime_ms']):>14} "
+                    f"{fmt(row['max_downtime_ms']):>14} {fmt(row['avg_iterations']):>9} "
+                    f"{fmt(row['avg_transfer_mib_s']):>8}")
+    logger.info("=" * 130)
+
+    if out_dir and comparison:
+        with open(os.path.join(out_dir, 'policy_comparison.json'), 'w') as f:
+            json.dump(comparison, f, indent=4)
+        with open(os.path.join(out_dir, 'policy_comparison.csv'), 'w', newline='') as f:
+            writer = csv.DictWriter(f, fieldnames=comparison[0].keys())
+            writer.writeheader()
+            writer.writerows(comparison)
+        logger.info(f"Policy comparison saved under: {out_dir}")
+    emit('migration-policy-comparison', comparison)
+    return failed
+
+
 _ALL_VMIS_CACHE: dict = {}  # node-independent cache so we fetch only once per run
 
 
-def _fetch_all_vmis(logger) -> list:
+def _fetch_all_vmis(logger, namespace: Optional[str] = None) -> list:
     """
-    Fetch all VMIs across all namespaces once and cache the result.
+    Fetch all VMIs across all namespaces (or of one namespace) once and cache the result.
 
     Uses ``kubectl get vmi -A -o json`` and returns the list of item dicts.
     ``spec.nodeName`` field selectors are not supported by the KubeVirt CRD,
@@ -555,7 +1199,7 @@ def _fetch_all_vmis(logger) -> list:
         return _ALL_VMIS_CACHE['items']
 
     returncode, stdout, stderr = run_kubectl_command(
-        ['get', 'vmi', '-A', '-o', 'json'],
+        ['get', 'vmi', '-o', 'json'] + (['-n', namespace] if namespace else ['-A']),
         check=False,
         logger=logger,
     )
@@ -577,7 +1221,7 @@ def _fetch_all_vmis(logger) -> list:
 
 
 def discover_vms_on_node(node_name: str, vm_name: str, namespace_prefix: str,
-                         logger) -> List[str]:
+                         logger, single_namespace: Optional[str] = None) -> List[str]:
     """
     Discover all namespaces that have a VMI named *vm_name* running on *node_name*.
 
@@ -586,12 +1230,18 @@ def discover_vms_on_node(node_name: str, vm_name: str, namespace_prefix: str,
     VMI CRD.
 
     Returns a sorted list of namespace names whose VMI matches *vm_name* on
-    *node_name*.
+    *node_name*. With *single_namespace*, the VMIs named ``<vm_name>-<index>``
+    in that namespace are matched instead and "{namespace}/{vm}" targets returned.
     """
-    logger.info(f"Discovering VMIs named '{vm_name}' on node '{node_name}' "
-                f"(prefix filter: '{namespace_prefix or '<none>'}')...")
+    if single_namespace:
+        logger.info(f"Discovering VMIs named '{vm_name}-<index>' in namespace '{single_namespace}' "
+                    f"on node '{node_name}'...")
+    else:
+        logger.info(f"Discovering VMIs named '{vm_name}' on node '{node_name}' "
+                    f"(prefix filter: '{namespace_prefix or '<none>'}')...")
+    scoped_name = re.compile(rf"{re.escape(vm_name)}-\d+")
     try:
-        all_items = _fetch_all_vmis(logger)
+        all_items = _fetch_all_vmis(logger, single_namespace)
         namespaces: List[str] = []
         for item in all_items:
             meta = item.get('metadata', {})
@@ -600,10 +1250,14 @@ def discover_vms_on_node(node_name: str, vm_name: str, namespace_prefix: str,
             name = meta.get('name', '')
             node = status.get('nodeName', '')
 
-            if name != vm_name:
-                continue
             if node != node_name:
                 continue
+            if single_namespace:
+                if ns == single_namespace and scoped_name.fullmatch(name):
+                    namespaces.append(f"{ns}/{name}")
+                continue
+            if name != vm_name:
+                continue
             if namespace_prefix and not ns.startswith(namespace_prefix):
                 continue
             namespaces.append(ns)
@@ -617,41 +1271,43 @@ def discover_vms_on_node(node_name: str, vm_name: str, namespace_prefix: str,
         return []
 
 
-def interleave_vms_across_nodes(per_node_vms: Dict[str, List[str]],
-                                node_order: List[str]) -> List[str]:
+def detect_disk_storage_classes(vm_spec: dict, volumes: List[dict], namespace: str,
+                                logger) -> Dict[str, str]:
     """
-    Interleave per-node VM lists into a single migration order.
+    Map each VM disk volume to its storage class.
 
-    Order: VM1 from node1, VM1 from node2, VM1 from node3,
-           VM2 from node1, VM2 from node2, VM2 from node3, ...
-
-    Lists of unequal length are zipped using a round-robin walk so that no
-    node is starved at the end. Duplicate namespaces (a VM may appear under
-    more than one node if it migrated mid-discovery) are deduplicated while
-    preserving first-seen order.
+    DataVolume-backed disks are resolved from the VM's dataVolumeTemplates,
+    falling back to the bound PVC, so VMs with OS and data disks on
+    different storage classes are reported per class.
     """
-    seen: set = set()
-    ordered: List[str] = []
-    max_len = max((len(per_node_vms[n]) for n in node_order), default=0)
-    for i in range(max_len):
-        for n in node_order:
-            lst = per_node_vms.get(n, [])
-            if i < len(lst):
-                ns = lst[i]
-                if ns not in seen:
-                    seen.add(ns)
-                    ordered.append(ns)
-    return ordered
+    dv_classes = {
+        dvt.get('metadata', {}).get('name'): (
+            dvt.get('spec', {}).get('storage', {}).get('storageClassName')
+            or dvt.get('spec', {}).get('pvc', {}).get('storageClassName')
+        )
+        for dvt in vm_spec.get('spec', {}).get('dataVolumeTemplates', [])
+    }
+
+    classes = {}
+    for vol in volumes:
+        claim = vol.get('dataVolume', {}).get('name') or vol.get('persistentVolumeClaim', {}).get('claimName')
+        if not claim:
+            continue
+        classes[vol.get('name', claim)] = (
+            dv_classes.get(claim) or get_pvc_storage_class(claim, namespace, logger) or 'unknown'
+        )
+    return classes
 
 
 def build_results_dir(args, num_disks: int, timestamp: Optional[str] = None) -> str:
     """Build the canonical migration results directory."""
     timestamp = timestamp or datetime.now().strftime("%Y%m%d-%H%M%S")
+    label = args.single_namespace or args.namespace_prefix
     if args.source_nodes:
         source_label = "all-workers" if len(args.source_nodes) == 1 and args.source_nodes[0] == "all" else f"{len(args.source_nodes)}-source-nodes"
-        suffix = f"{args.namespace_prefix}_{source_label}"
+        suffix = f"{label}_{source_label}"
     else:
-        suffix = f"{args.namespace_prefix}_{args.start}-{args.end}"
+        suffix = f"{label}_{args.start}-{args.end}"
     disk_dir = f"{num_disks}-disk"
     run_dir = f"{timestamp}_live_migration_{suffix}"
     if args.storage_driver:
@@ -673,12 +1329,200 @@ def attach_file_logging(logger, log_file: str) -> None:
     logger.info(f"Command: {get_command_for_logging()}")
 
 
+def cleanup_migration_test(args, namespaces: List[str], failed_migrations: int, logger) -> None:
+    """Delete VMIMs, and the VMs and namespaces if this test created them, as the cleanup options ask."""
+    # Determine if cleanup should run
+    should_cleanup = args.cleanup or (args.cleanup_on_failure and failed_migrations > 0)
+
+    # Cleanup
+    if should_cleanup or args.dry_run_cleanup:
+        logger.info("\n" + "=" * 80)
+        logger.info("CLEANUP")
+        logger.info("=" * 80)
+
+        # Confirm cleanup if needed
+        if not args.dry_run_cleanup and not confirm_cleanup(len(namespaces), args.yes):
+            logger.info("Cleanup cancelled by user")
+        else:
+            logger.info(f"\n{'[DRY RUN] ' if args.dry_run_cleanup else ''}Cleaning up test resources...")
+
+            try:
+                # Clean up this run's VMIMs first
+                logger.info("Cleaning up VirtualMachineInstanceMigration objects...")
+                vmim_count = 0
+                for ns in target_namespaces(namespaces):
+                    vmims = list_resources_in_namespace(ns, 'virtualmachineinstancemigration', logger,
+                                                        run_selector())
+                    for vmim in vmims:
+                        if args.dry_run_cleanup:
+                            logger.info(f"[DRY RUN] Would delete VMIM: {vmim} in {ns}")
+                        else:
+                            if delete_vmim(vmim, ns, logger):
+                                vmim_count += 1
+
+                logger.info(f"{'[DRY RUN] Would delete' if args.dry_run_cleanup else 'Deleted'} {vmim_count} VMIM objects")
+
+                # Clean up VMs and namespaces if they were created by this test
+                if args.create_vms:
+                    stats = cleanup_test_namespaces(
+                        namespace_prefix=args.namespace_prefix,
+                        start=args.start,
+                        end=args.end,
+                        vm_name=args.vm_name,
+                        delete_namespaces=True,
+                        dry_run=args.dry_run_cleanup,
+                        batch_size=args.concurrency,
+                        logger=logger,
+                        qps=args.qps,
+                        burst=args.burst,
+                        single_namespace=args.single_namespace,
+                        selector=run_selector()
+                    )
+                    print_cleanup_summary(stats, logger)
+                else:
+                    logger.info("VMs were not created by this test, skipping VM/namespace deletion")
+                    logger.info("Only VMIM objects were cleaned up")
+
+                if not args.dry_run_cleanup:
+                    logger.info("Cleanup completed successfully!")
+
+            except Exception as e:
+                logger.error(f"Error during cleanup: {e}")
+                logger.warning("Some resources may not have been cleaned up")
+
+
+def select_creation_node(args, logger) -> Optional[str]:
+    """Node to create the VMs on with --create-vms, or None to let them spread (round-robin)."""
+    if args.single_node:
+        # Single-node mode: create all VMs on one node
+        if args.node_name:
+            logger.info(f"Single-node mode: Creating all VMs on {args.node_name}")
+            return args.node_name
+        # Auto-select a node
+        creation_node = select_random_node(logger)
+        if not creation_node:
+            logger.error("Failed to select a node for single-node mode")
+            sys.exit(1)
+        logger.info(f"Single-node mode: Auto-selected node {creation_node}")
+        return creation_node
+    if args.source_node:
+        logger.info(f"Creating VMs on source node: {args.source_node}")
+        return args.source_node
+    if args.round_robin:
+        # For round-robin, create VMs distributed across nodes
+        logger.info("Round-robin mode: VMs will be created across all nodes")
+        return None
+    # Auto-select a source node
+    creation_node = select_random_node(logger)
+    if not creation_node:
+        logger.error("Failed to select a source node")
+        sys.exit(1)
+    logger.info(f"Auto-selected source node: {creation_node}")
+    return creation_node
+
+
+def plan_run(args, namespaces: List[str], logger):
+    """Print what main() would create, patch, migrate and delete (virtbench --dry-run)."""
+    plan = DryRunPlan('migration', logger)
+
+    def vm_ref(ns):
+        return '/'.join(split_vm_target(ns, args.vm_name))
+
+    creation_node = None
+    if args.create_vms:
+        creation_node = select_creation_node(args, logger)
+        if not args.single_namespace:
+            plan.create_namespaces(namespaces)
+        for ns in namespaces:
+            namespace, target_vm = split_vm_target(ns, args.vm_name)
+            if target_vm != args.vm_name:
+                manifest = render_scoped_vm_yaml(args.vm_template, target_vm, creation_node)
+            elif creation_node:
+                manifest = add_node_selector_to_vm_yaml(args.vm_template, creation_node, logger)
+            else:
+                with open(args.vm_template, 'r') as f:
+                    manifest = f.read()
+            plan.apply(manifest, namespace)
+
+    # The VMs to migrate, as the scenario in main() selects them
+    detail = ''
+    to_migrate = namespaces
+    if args.source_nodes:
+        per_node_vms = {node: discover_vms_on_node(node, args.vm_name, args.namespace_prefix, logger,
+                                                   args.single_namespace)
+                        for node in args.source_nodes}
+        to_migrate = interleave(per_node_vms, args.source_nodes)
+        detail = f"off {', '.join(args.source_nodes)}"
+    elif args.evacuate:
+        source_node = args.source_node or creation_node
+        if not source_node:
+            source_node = find_busiest_node(namespaces, args.vm_name, logger)
+        if not args.create_vms:
+            to_migrate = get_vms_on_node(namespaces, args.vm_name, source_node, logger) if source_node else []
+        detail = f"evacuate {source_node}"
+    if not to_migrate:
+        logger.warning("No VMs to migrate were found; a real run would stop here")
+
+    # nodeSelectors are removed so the VMs can migrate
+    if args.source_nodes:
+        unpin = to_migrate
+    elif creation_node or (not args.create_vms and not args.skip_checks):
+        unpin = namespaces
+    else:
+        unpin = []
+    for ns in unpin:
+        plan.action('patch', f"vm/{vm_ref(ns)}", 'remove nodeSelector')
+
+    for policy in (args.policies or [None]):
+        manifest = migration_policy_manifest(policy) if policy else None
+        cluster = {k: v for k, v in (policy or {}).items() if k in CLUSTER_MIGRATION_SETTINGS}
+        if cluster:
+            plan.action('patch', 'kubevirt/kubevirt', f"spec.configuration.migrations {json.dumps(cluster)}")
+        if manifest:
+            plan.apply(json.dumps(manifest))
+        if policy:
+            for ns in target_namespaces(to_migrate):
+                plan.action('label', f"namespace/{ns}", f"{POLICY_LABEL}={policy['name']}")
+        for ns in to_migrate:
+            plan.apply(call_for_target(migration_manifest, ns, args.vm_name), split_vm_target(ns, '')[0],
+                       detail=detail)
+        if policy:
+            for ns in target_namespaces(to_migrate):
+                plan.action('label', f"namespace/{ns}", f"{POLICY_LABEL}-")
+        if manifest:
+            plan.action('delete', f"migrationpolicy/{manifest['metadata']['name']}")
+        if cluster:
+            plan.action('restore', 'kubevirt/kubevirt', 'spec.configuration.migrations')
+
+    if args.cleanup or args.cleanup_on_failure:
+        condition = '' if args.cleanup else 'if any migration fails'
+        for ns in to_migrate:
+            namespace, name = split_vm_target(ns, args.vm_name)
+            plan.action('delete', f"vmim/{namespace}/migration-{name}", condition)
+        if args.create_vms:
+            for ns in namespaces:
+                plan.action('delete', f"vm/{vm_ref(ns)}", condition)
+            if not args.single_namespace:
+                plan.delete_namespaces(namespaces, condition)
+
+    plan.report()
+
+
 def main():
     """Main function."""
     args = parse_arguments()
+    set_run_workload('migration')
+    args.storage_driver = resolve_storage_driver(args.storage_driver)
+    dry_run = is_dry_run()
+    if dry_run:
+        # A dry run has no results to save
+        args.save_results = False
     
     # Setup logging
     logger = setup_logging(args.log_file, args.log_level)
+    watch_run('migration', logger)
+    watch_events(args.single_namespace or args.namespace_prefix, logger)
+    sample_nodes(logger)
     
     # Print configuration
     logger.info("=" * 80)
@@ -710,7 +1554,10 @@ def main():
     if not args.source_nodes:
         logger.info(f"VM range: {args.start} to {args.end}")
     logger.info(f"VM name: {args.vm_name}")
-    logger.info(f"Namespace prefix: {args.namespace_prefix}")
+    if args.single_namespace:
+        logger.info(f"Namespace: {args.single_namespace} (single-namespace mode)")
+    else:
+        logger.info(f"Namespace prefix: {args.namespace_prefix}")
     logger.info(f"Create VMs: {args.create_vms}")
 
     if args.storage_driver:
@@ -726,6 +1573,10 @@ def main():
             logger.info("Migration mode: Evacuation (auto-select busiest node)")
         else:
             logger.info(f"Migration mode: Evacuation from {args.source_node}")
+    elif args.policy_matrix or args.bandwidth_sweep or args.parallel_sweep:
+        source = args.policy_matrix or 'bandwidth/parallel sweep'
+        logger.info(f"Migration mode: Policy matrix from {source} "
+                    f"({'parallel' if args.parallel else 'sequential'})")
     elif args.parallel:
         logger.info(f"Migration mode: Parallel (concurrency: {args.concurrency})")
     else:
@@ -735,16 +1586,54 @@ def main():
         logger.info(f"Source node: {args.source_node}")
     if args.target_node:
         logger.info(f"Target node: {args.target_node}")
+    if args.guest_load_memory_mb or args.guest_load_cpu or args.guest_load_disk_mbs:
+        logger.info(f"Guest load: memory {args.guest_load_memory_mb} MiB at "
+                    f"{args.guest_load_dirty_rate or 'max'} MiB/s, {args.guest_load_cpu} CPU worker(s), "
+                    f"{args.guest_load_disk_mbs} MiB/s disk")
 
     logger.info("=" * 80)
 
     # Validate arguments
     if not validate_migration_args(args, logger):
         sys.exit(1)
+    if args.tuning:
+        # Create the VMs from a tuned copy of the template
+        try:
+            args.vm_template = write_tuned_template(args.vm_template, args.tuning)
+        except (OSError, yaml.YAMLError) as e:
+            logger.error(f"Cannot apply CPU/memory tuning to the VM template: {e}")
+            sys.exit(1)
+        logger.info(f"Tuning: {json.dumps(args.tuning)}")
+        if args.dedicated_cpus and cpu_manager_nodes(logger) == []:
+            logger.warning("No node is labelled cpumanager=true: VMs with dedicated CPUs will not schedule "
+                           "until the kubelet runs the static CPU manager policy")
+    timing.set_precision(args.precision)
+    if args.save_results:
+        # Snapshot the cluster before any VM is created or moved
+        cluster_inventory(logger)
+
+    if args.verify_data and args.guest_os == GUEST_OS_WINDOWS:
+        logger.error("--verify-data is only supported for Linux guests")
+        sys.exit(1)
+    if args.verify_data and not validate_prerequisites(args.ssh_pod, args.ssh_pod_ns, logger,
+                                                          namespace=args.single_namespace):
+        logger.error("--verify-data requires the SSH pod")
+        sys.exit(1)
+    guest_load_enabled = bool(args.guest_load_memory_mb or args.guest_load_cpu or args.guest_load_disk_mbs)
+    if min(args.guest_load_memory_mb, args.guest_load_dirty_rate, args.guest_load_cpu, args.guest_load_disk_mbs) < 0:
+        logger.error("--guest-load-* values must be >= 0")
+        sys.exit(1)
+    if guest_load_enabled and args.guest_os == GUEST_OS_WINDOWS:
+        logger.error("--guest-load-* is only supported for Linux guests")
+        sys.exit(1)
+    if guest_load_enabled and not validate_prerequisites(args.ssh_pod, args.ssh_pod_ns, logger,
+                                                         namespace=args.single_namespace):
+        logger.error("--guest-load-* requires the SSH pod")
+        sys.exit(1)
 
     # Validate prerequisites (SSH pod for ping tests)
     if not args.skip_ping:
-        if not validate_prerequisites(args.ssh_pod, args.ssh_pod_ns, logger):
+        if not validate_prerequisites(args.ssh_pod, args.ssh_pod_ns, logger, namespace=args.single_namespace):
             logger.warning("SSH pod not available, will skip ping tests")
             args.skip_ping = True
 
@@ -754,8 +1643,13 @@ def main():
         namespaces: List[str] = []
         logger.info("\nNamespace discovery will be performed per source node.")
     else:
-        namespaces = [f"{args.namespace_prefix}-{i}" for i in range(args.start, args.end + 1)]
-        logger.info(f"\nTarget namespaces: {namespaces[0]} to {namespaces[-1]} ({len(namespaces)} total)")
+        namespaces = vm_targets(args.namespace_prefix, args.start, args.end, args.vm_name, args.single_namespace)
+        logger.info(f"\nTarget {'VMs' if args.single_namespace else 'namespaces'}: {namespaces[0]} to "
+                    f"{namespaces[-1]} ({len(namespaces)} total)")
+
+    if dry_run:
+        plan_run(args, namespaces, logger)
+        sys.exit(0)
 
     # Phase 1: Create VMs if requested
     if args.create_vms:
@@ -764,42 +1658,31 @@ def main():
         logger.info("=" * 80)
 
         # Determine node for VM creation
-        creation_node = None
+        creation_node = select_creation_node(args, logger)
 
-        if args.single_node:
-            # Single-node mode: create all VMs on one node
-            if args.node_name:
-                creation_node = args.node_name
-                logger.info(f"Single-node mode: Creating all VMs on {creation_node}")
-            else:
-                # Auto-select a node
-                creation_node = select_random_node(logger)
-                if not creation_node:
-                    logger.error("Failed to select a node for single-node mode")
-                    sys.exit(1)
-                logger.info(f"Single-node mode: Auto-selected node {creation_node}")
-        elif args.source_node:
-            creation_node = args.source_node
-            logger.info(f"Creating VMs on source node: {creation_node}")
-        elif args.round_robin:
-            # For round-robin, create VMs distributed across nodes
-            creation_node = None
-            logger.info("Round-robin mode: VMs will be created across all nodes")
-        else:
-            # Auto-select a source node
-            creation_node = select_random_node(logger)
-            if not creation_node:
-                logger.error("Failed to select a source node")
+        # Hugepages preflight: VMs without free hugepages stay in the Scheduling phase
+        if args.hugepages:
+            try:
+                estimate = estimate_capacity(args.vm_template, len(namespaces), logger, node_name=creation_node)
+                print_estimate(estimate, logger)
+                shortfall = (estimate.get('hugepages') or {}).get('shortfall_vms')
+                if shortfall:
+                    if not args.skip_hugepages_check:
+                        logger.error(f"Aborting: {shortfall} VMs lack free hugepages-{args.hugepages} "
+                                     f"(use --skip-hugepages-check to run anyway)")
+                        sys.exit(1)
+                    logger.warning("Continuing despite the hugepages shortfall (--skip-hugepages-check)")
+            except (OSError, ValueError, yaml.YAMLError) as e:
+                logger.warning(f"Hugepages preflight skipped: {e}")
+
+        # Create namespaces (single-namespace mode uses an existing one)
+        if not args.single_namespace:
+            logger.info(f"\nCreating {len(namespaces)} namespaces...")
+            successful_ns = create_namespaces_parallel(namespaces, 20, logger)
+
+            if len(successful_ns) < len(namespaces):
+                logger.error(f"Failed to create all namespaces. Created: {len(successful_ns)}/{len(namespaces)}")
                 sys.exit(1)
-            logger.info(f"Auto-selected source node: {creation_node}")
-
-        # Create namespaces
-        logger.info(f"\nCreating {len(namespaces)} namespaces...")
-        successful_ns = create_namespaces_parallel(namespaces, 20, logger)
-
-        if len(successful_ns) < len(namespaces):
-            logger.error(f"Failed to create all namespaces. Created: {len(successful_ns)}/{len(namespaces)}")
-            sys.exit(1)
 
         # Create VMs
         if creation_node:
@@ -817,6 +1700,9 @@ def main():
         )
 
         successful_vms = sum(1 for success in running_results.values() if success)
+        notify_phase('migration', 'vms-created',
+                     {'vms': len(namespaces), 'running': successful_vms, 'node': creation_node or 'distributed'},
+                     phase_status(len(namespaces) - successful_vms, len(namespaces)), logger=logger)
 
         if successful_vms == 0:
             logger.error("No VMs are running. Cannot proceed with migration.")
@@ -836,7 +1722,7 @@ def main():
             removal_failed = 0
 
             for ns in namespaces:
-                if remove_node_selectors(args.vm_name, ns, logger):
+                if call_for_target(remove_node_selectors, ns, args.vm_name, logger):
                     removal_success += 1
                     logger.info(f"[{ns}] Removed nodeSelector")
                 else:
@@ -867,7 +1753,7 @@ def main():
             running_count = 0
 
             for ns in namespaces:
-                status = get_vm_status(args.vm_name, ns, logger)
+                status = call_for_target(get_vm_status, ns, args.vm_name, logger)
                 if status == "Running":
                     running_count += 1
                 else:
@@ -890,7 +1776,7 @@ def main():
             removal_failed = 0
 
             for ns in namespaces:
-                if remove_node_selectors(args.vm_name, ns, logger):
+                if call_for_target(remove_node_selectors, ns, args.vm_name, logger):
                     removal_success += 1
                 else:
                     removal_failed += 1
@@ -905,23 +1791,25 @@ def main():
         logger.info("=" * 80)
 
     logger.info("Detecting disk count from existing VM spec...")
+    disk_storage_classes = {}
     try:
         # For --source-nodes mode `namespaces` is empty here; pick any namespace
         # that currently exists by probing the first source node.
         if args.source_nodes:
             _probe_ns_list = discover_vms_on_node(
-                args.source_nodes[0], args.vm_name, args.namespace_prefix, logger
+                args.source_nodes[0], args.vm_name, args.namespace_prefix, logger, args.single_namespace
             )
             sample_ns = _probe_ns_list[0] if _probe_ns_list else None
         else:
-            sample_ns = f"{args.namespace_prefix}-{args.start}"
+            sample_ns = namespaces[0]
 
         if not sample_ns:
             logger.warning("No sample namespace available for disk detection; defaulting to 1 disk")
             num_disks = 1
         else:
+            sample_ns, sample_vm = split_vm_target(sample_ns, args.vm_name)
             vm_yaml_cmd = [
-                "kubectl", "get", "vm", args.vm_name, "-n", sample_ns, "-o", "yaml"
+                "kubectl", "get", "vm", sample_vm, "-n", sample_ns, "-o", "yaml"
             ]
             result = subprocess.run(vm_yaml_cmd, capture_output=True, text=True, check=False)
             if result.returncode == 0 and result.stdout:
@@ -934,10 +1822,13 @@ def main():
                 )
                 non_cloudinit = [
                     v for v in volumes
-                    if not any(k in v for k in ["cloudInitNoCloud", "cloudInitConfigDrive"])
+                    if not any(k in v for k in ["cloudInitNoCloud", "cloudInitConfigDrive", "containerDisk"])
                 ]
                 num_disks = len(non_cloudinit)
                 logger.info(f"Detected {num_disks} disks (excluding cloud-init volumes)")
+                disk_storage_classes = detect_disk_storage_classes(vm_spec, non_cloudinit, sample_ns, logger)
+                if len(set(disk_storage_classes.values())) > 1:
+                    logger.info(f"VM disks span multiple storage classes: {disk_storage_classes}")
             else:
                 logger.warning("Could not retrieve VM spec; defaulting to 1 disk")
                 num_disks = 1
@@ -953,13 +1844,69 @@ def main():
         if not args.log_file:
             attach_file_logging(logger, os.path.join(out_dir, "migration.log"))
 
+    # Seed checksummed data and start load in the guests before anything moves
+    executor = None
+    verifier = None
+    guest_load = None
+    seeded_namespaces: List[str] = []
+    loaded_namespaces: List[str] = []
+    if args.verify_data or guest_load_enabled:
+        executor = GuestExecutor(args.vm_user, args.vm_password, ssh_pod=args.ssh_pod,
+                                 ssh_pod_ns=args.ssh_pod_ns, max_sessions=args.concurrency,
+                                 logger=logger)
+    if args.verify_data:
+        verifier = DataVerifier(executor, size_mb=args.verify_data_size_mb, logger=logger)
+    if guest_load_enabled:
+        guest_load = GuestLoad(executor, memory_mb=args.guest_load_memory_mb,
+                               dirty_rate_mbs=args.guest_load_dirty_rate, cpu_workers=args.guest_load_cpu,
+                               disk_mbs=args.guest_load_disk_mbs, logger=logger)
+
+    def seed_verification_data(target_namespaces: List[str]):
+        if verifier and target_namespaces:
+            logger.info(f"\nWriting verification data in {len(target_namespaces)} VM(s)...")
+            verifier.seed_many(target_namespaces, args.vm_name, args.concurrency)
+            seeded_namespaces.extend(target_namespaces)
+        if guest_load and target_namespaces:
+            logger.info(f"\nStarting guest load in {len(target_namespaces)} VM(s)...")
+            guest_load.start_many(target_namespaces, args.vm_name, args.concurrency)
+            loaded_namespaces.extend(target_namespaces)
+
+    def stop_guest_load() -> Optional[Dict]:
+        if not guest_load:
+            return None
+        logger.info(f"\nStopping guest load in {len(loaded_namespaces)} VM(s)...")
+        return guest_load.stop_many(loaded_namespaces, args.vm_name, args.concurrency)
+
+    seed_verification_data(namespaces)
+
+    # Policy matrix: one migration pass per MigrationPolicy instead of a single scenario
+    if args.policies:
+        failed_migrations = run_policy_matrix(namespaces, args.policies, args, out_dir, logger)
+        stop_guest_load()
+        if verifier:
+            logger.info("\n" + "=" * 80)
+            logger.info("DATA INTEGRITY VERIFICATION")
+            logger.info("=" * 80)
+            print_data_integrity_summary(
+                verifier.verify_many(seeded_namespaces, args.vm_name, args.concurrency), logger)
+        if executor:
+            executor.close()
+        cleanup_migration_test(args, namespaces, failed_migrations, logger)
+        logger.info("\nMigration test complete!")
+        notify_run('migration', {'vms': len(namespaces), 'policies': len(args.policies), 'failed': failed_migrations},
+                   phase_status(failed_migrations, len(namespaces) * len(args.policies)), out_dir, logger)
+        return
+
     # Phase 2: Perform Migration
     logger.info("\n" + "=" * 80)
     logger.info("PHASE 2: Live Migration")
     logger.info("=" * 80)
 
     migration_results = []
-    migration_phase_start = datetime.now()
+    migration_phase_start = timing.now()
+    placement = get_strategy(args.placement, args.placement_nodes, args.max_vms_per_node, logger,
+                             topology_key=args.topology_key)
+    placement_targets: Dict[str, Optional[str]] = {}
 
     # Scenario 1: Sequential Migration
     if not args.parallel and not args.evacuate and not args.round_robin and not args.source_nodes:
@@ -989,47 +1936,30 @@ def main():
 
         # Default: sequential namespace order
         reordered_namespaces = namespaces
+        targets = None
 
-        # --- Interleaved scheduling ---
-        if args.interleaved_scheduling:
-            total_namespaces = len(namespaces)
-            group_size = total_namespaces // num_nodes or 1
-
-            reordered_namespaces = []
-            for offset in range(group_size):
-                for i in range(offset, total_namespaces, group_size):
-                    reordered_namespaces.append(namespaces[i])
-
+        # --- Interleaved scheduling: VMs are assumed to sit in contiguous blocks per node ---
+        if placement.name == 'interleave':
+            reordered_namespaces = placement.order(namespaces, placement.assign(namespaces, available_nodes))
             logger.info(f"Detected {num_nodes} available nodes for interleaved scheduling")
-            logger.info(f"Reordered namespaces for interleaved scheduling (stride={group_size}). "
+            logger.info(f"Reordered namespaces for interleaved scheduling. "
                         f"First 10: {reordered_namespaces[:10]}")
         else:
             logger.info("Using default sequential namespace order for parallel scheduling")
+            if placement.name != STRATEGY_NONE and not args.target_node and args.migration_zone == ANY_ZONE:
+                targets = placement.assign(
+                    namespaces, [n for n in available_nodes or [] if n != args.source_node]
+                )
+                placement_targets.update(targets)
+                logger.info(f"Placement '{placement.name}': migrations per target node "
+                            f"{placement.describe(targets)['per_node']}")
+
+        if args.migration_zone != ANY_ZONE:
+            targets = zone_targets(namespaces, available_nodes or [], placement, args, logger)
+            placement_targets.update(targets)
 
         # --- Parallel migration execution ---
-        with ThreadPoolExecutor(max_workers=args.concurrency) as executor:
-            futures = {
-                executor.submit(
-                    migrate_vm_sequential,
-                    ns,
-                    args.vm_name,
-                    args.target_node,
-                    args.migration_timeout,
-                    logger,
-                    args.poll_interval,
-                    10,  # max_vmim_retries
-                    args.max_migration_retries
-                ): ns for ns in reordered_namespaces
-            }
-
-            for future in as_completed(futures):
-                try:
-                    result = future.result()
-                    migration_results.append(result)
-                except Exception as e:
-                    ns = futures[future]
-                    logger.error(f"[{ns}] Exception during migration: {e}")
-                    migration_results.append((ns, False, 0.0, None, None, None))
+        migration_results.extend(run_parallel_migrations(reordered_namespaces, args, logger, targets=targets))
 
     # Scenario 3: Evacuation
     elif args.evacuate:
@@ -1082,24 +2012,10 @@ def main():
         # Migrate only the VMs that are on the source node
         logger.info(f"\nStarting evacuation of {len(vms_to_evacuate)} VMs...")
 
-        with ThreadPoolExecutor(max_workers=args.concurrency) as executor:
-            futures = {
-                executor.submit(
-                    migrate_vm_sequential, ns, args.vm_name, None,
-                    args.migration_timeout, logger, args.poll_interval,
-                    10, args.max_migration_retries
-                ): ns
-                for ns in vms_to_evacuate  # Only migrate VMs on source node
-            }
-
-            for future in as_completed(futures):
-                try:
-                    result = future.result()
-                    migration_results.append(result)
-                except Exception as e:
-                    ns = futures[future]
-                    logger.error(f"[{ns}] Exception during migration: {e}")
-                    migration_results.append((ns, False, 0.0, None, None, None))
+        # Only migrate VMs on source node; KubeVirt picks the target
+        migration_results.extend(run_parallel_migrations(
+            vms_to_evacuate, args, logger, targets={ns: None for ns in vms_to_evacuate}
+        ))
 
     # Scenario 4: Round-Robin
     elif args.round_robin:
@@ -1116,35 +2032,17 @@ def main():
         logger.info(f"Available nodes: {all_nodes}")
 
         # For each VM, select a target node different from current node
-        with ThreadPoolExecutor(max_workers=args.concurrency) as executor:
-            futures = {}
-
-            for ns in namespaces:
-                # Get current node
-                current_node = get_vm_node(args.vm_name, ns, logger)
-
-                if current_node:
-                    # Select a different node
-                    available = [n for n in all_nodes if n != current_node]
-                    target = random.choice(available) if available else None
-                else:
-                    target = None
+        targets = {}
+        for ns in namespaces:
+            current_node = call_for_target(get_vm_node, ns, args.vm_name, logger)
 
-                future = executor.submit(
-                    migrate_vm_sequential, ns, args.vm_name, target,
-                    args.migration_timeout, logger, args.poll_interval,
-                    10, args.max_migration_retries
-                )
-                futures[future] = ns
+            if current_node:
+                available = [n for n in all_nodes if n != current_node]
+                targets[ns] = random.choice(available) if available else None
+            else:
+                targets[ns] = None
 
-            for future in as_completed(futures):
-                try:
-                    result = future.result()
-                    migration_results.append(result)
-                except Exception as e:
-                    ns = futures[future]
-                    logger.error(f"[{ns}] Exception during migration: {e}")
-                    migration_results.append((ns, False, 0.0, None, None, None))
+        migration_results.extend(run_parallel_migrations(namespaces, args, logger, targets=targets))
 
     # Scenario 5: Multi-source-node parallel migration (interleaved across nodes)
     elif args.source_nodes:
@@ -1158,7 +2056,7 @@ def main():
         per_node_vms: Dict[str, List[str]] = {}
         for source_node in args.source_nodes:
             vms_on_node = discover_vms_on_node(
-                source_node, args.vm_name, args.namespace_prefix, logger
+                source_node, args.vm_name, args.namespace_prefix, logger, args.single_namespace
             )
             per_node_vms[source_node] = vms_on_node
             if vms_on_node:
@@ -1168,7 +2066,7 @@ def main():
 
         # Interleave across nodes so the migration order is:
         # VM1 from node1, VM1 from node2, VM1 from node3, VM2 from node1, ...
-        all_vms_to_migrate = interleave_vms_across_nodes(per_node_vms, args.source_nodes)
+        all_vms_to_migrate = interleave(per_node_vms, args.source_nodes)
 
         if not all_vms_to_migrate:
             logger.error("No VMs found on any of the specified source nodes. "
@@ -1177,6 +2075,7 @@ def main():
 
         logger.info(f"\nTotal unique VMs to migrate: {len(all_vms_to_migrate)}")
         logger.info(f"Interleaved migration order (first 10): {all_vms_to_migrate[:10]}")
+        seed_verification_data(all_vms_to_migrate)
         logger.info(f"Target node: {args.target_node or '(auto-selected per VM)'}")
         logger.info(f"Concurrency: {args.concurrency}")
         logger.info("=" * 80)
@@ -1190,7 +2089,7 @@ def main():
         removal_failed = 0
 
         for ns in all_vms_to_migrate:
-            if remove_node_selectors(args.vm_name, ns, logger):
+            if call_for_target(remove_node_selectors, ns, args.vm_name, logger):
                 removal_success += 1
             else:
                 removal_failed += 1
@@ -1229,49 +2128,54 @@ def main():
 
         logger.info(f"\nStarting parallel migration of {len(all_vms_to_migrate)} VMs...")
 
-        with ThreadPoolExecutor(max_workers=args.concurrency) as executor:
-            futures = {
-                executor.submit(
-                    migrate_vm_sequential,
-                    ns,
-                    args.vm_name,
-                    args.target_node,   # None -> KubeVirt auto-selects from available nodes
-                    args.migration_timeout,
-                    logger,
-                    args.poll_interval,
-                    10,                 # max_vmim_retries
-                    args.max_migration_retries,
-                ): ns
-                for ns in all_vms_to_migrate
-            }
-
-            completed = 0
-            for future in as_completed(futures):
-                ns = futures[future]
-                try:
-                    result = future.result()
-                    migration_results.append(result)
-                    completed += 1
-                    _, success, duration, src, tgt, _ = result
-                    status_str = "✓" if success else "✗"
-                    if success:
-                        logger.info(
-                            f"[{completed}/{len(all_vms_to_migrate)}] {status_str} "
-                            f"{ns}: {src} → {tgt or 'unknown'} ({duration:.1f}s)"
-                        )
-                    else:
-                        logger.info(
-                            f"[{completed}/{len(all_vms_to_migrate)}] {status_str} {ns}: FAILED"
-                        )
-                except Exception as e:
-                    completed += 1
-                    logger.error(f"[{ns}] Exception during migration: {e}")
-                    migration_results.append((ns, False, 0.0, None, None, None))
+        completed = 0
+
+        def report_progress(ns, result):
+            nonlocal completed
+            completed += 1
+            _, success, duration, src, tgt, _ = result
+            if success:
+                logger.info(
+                    f"[{completed}/{len(all_vms_to_migrate)}] ✓ "
+                    f"{ns}: {src} → {tgt or 'unknown'} ({duration:.1f}s)"
+                )
+            else:
+                logger.info(f"[{completed}/{len(all_vms_to_migrate)}] ✗ {ns}: FAILED")
+
+        # args.target_node of None lets KubeVirt auto-select from available nodes
+        targets = None
+        if args.migration_zone != ANY_ZONE:
+            targets = zone_targets(all_vms_to_migrate, available_targets, placement, args, logger)
+            placement_targets.update(targets)
+        elif placement.name not in (STRATEGY_NONE, 'interleave') and not args.target_node:
+            targets = placement.assign(all_vms_to_migrate, available_targets)
+            placement_targets.update(targets)
+            logger.info(f"Placement '{placement.name}': migrations per target node "
+                        f"{placement.describe(targets)['per_node']}")
+        migration_results.extend(run_parallel_migrations(
+            all_vms_to_migrate, args, logger, targets=targets, on_result=report_progress
+        ))
 
         # Expose discovered namespaces to the ping / cleanup phases below.
         namespaces = all_vms_to_migrate
 
-    total_migration_time = (datetime.now() - migration_phase_start).total_seconds()
+    total_migration_time = (timing.now() - migration_phase_start).total_seconds()
+    migration_phase_end = timing.now()
+    clock_skew = timing.estimate_clock_skew(logger=logger) if args.save_results else None
+    migration_timing = timing.timing_metadata(migration_phase_start, migration_phase_end, clock_skew)
+    job_stats = collect_migration_job_stats(migration_results, args.vm_name, args, logger)
+    node_zones = get_node_zones(topology_key=args.topology_key, logger=logger)
+    throughput = collect_migration_throughput(migration_results, args.vm_name, migration_timing, args, logger,
+                                              job_stats=job_stats)
+    guest_load_summary = stop_guest_load()
+    failed_count = sum(1 for r in migration_results if not r[1])
+    phase_metrics = {'vms': len(migration_results), 'failed': failed_count,
+                     'duration_sec': round(total_migration_time, 2)}
+    phase_metrics.update(duration_metrics('observed_sec', [r[2] for r in migration_results if r[1]]))
+    phase_metrics.update(duration_metrics('downtime_ms', [s.get('downtime_ms') for s in job_stats.values()]))
+    notify_phase('migration', 'evacuation-complete' if args.evacuate or args.source_nodes else 'migration-complete',
+                 phase_metrics, phase_status(failed_count, len(migration_results)), logger=logger)
+
     # Phase 4: Validation (Ping Test)
     if not args.skip_ping:
         logger.info("\n" + "=" * 80)
@@ -1285,23 +2189,24 @@ def main():
         pending = set(namespaces)
         ping_results = {ns: False for ns in namespaces}
         vm_ips = {}  # Cache VM IPs
-        start_time = time.time()
+        start_time = time.monotonic()
         poll_interval = 10  # Check every 10 seconds
 
-        while pending and (time.time() - start_time) < args.ping_timeout:
-            elapsed = time.time() - start_time
+        while pending and (time.monotonic() - start_time) < args.ping_timeout:
+            elapsed = time.monotonic() - start_time
             still_pending = set()
 
             for ns in pending:
                 # Get VM IP (may not be available immediately after migration)
                 if ns not in vm_ips or vm_ips[ns] is None:
-                    vm_ips[ns] = get_vmi_ip(args.vm_name, ns, logger)
+                    vm_ips[ns] = call_for_target(get_vmi_ip, ns, args.vm_name, logger)
 
                 vm_ip = vm_ips[ns]
                 if vm_ip:
-                    ping_success = ping_vm(vm_ip, args.ssh_pod, args.ssh_pod_ns, logger)
+                    ping_success = check_guest_ready(vm_ip, args.ssh_pod, args.ssh_pod_ns,
+                                                     args.guest_os, logger)
                     if ping_success:
-                        logger.info(f"[{ns}] Ping successful to {vm_ip}")
+                        logger.info(f"[{ns}] Guest reachable at {vm_ip}")
                         ping_results[ns] = True
                     else:
                         # Keep trying
@@ -1331,6 +2236,16 @@ def main():
         successful_pings = sum(1 for success in ping_results.values() if success)
         logger.info(f"\nNetwork validation complete: {successful_pings}/{len(namespaces)} VMs reachable")
 
+    # Phase 4b: Data integrity
+    data_integrity = None
+    if verifier:
+        logger.info("\n" + "=" * 80)
+        logger.info("DATA INTEGRITY VERIFICATION")
+        logger.info("=" * 80)
+        data_integrity = verifier.verify_many(seeded_namespaces, args.vm_name, args.concurrency)
+    if executor:
+        executor.close()
+
     # Phase 5: Display Results
     logger.info("\n" + "=" * 80)
     logger.info("MIGRATION RESULTS")
@@ -1340,27 +2255,36 @@ def main():
     table_data = []
     for ns, success, observed_duration, source, target, vmim_duration in migration_results:
         status = "Success" if success else "Failed"
+        rates = throughput.get(ns, {})
+        job = job_stats.get(ns, {})
         table_data.append({
             'namespace': ns,
             'source_node': source or 'Unknown',
             'target_node': target or 'Unknown',
             'observed_duration': f"{observed_duration:.2f}s" if success else "N/A",
             'vmim_duration': f"{vmim_duration:.2f}s" if (success and vmim_duration) else "N/A",
+            'transfer_rate': f"{rates['transfer_mib_s']:.1f}" if rates.get('transfer_mib_s') else "N/A",
+            'estimated_rate': f"{rates['estimated_mib_s']:.1f}" if rates.get('estimated_mib_s') else "N/A",
+            'downtime': f"{job['downtime_ms']}ms" if job.get('downtime_ms') is not None else "N/A",
+            'iterations': str(job['iterations']) if job.get('iterations') is not None else "N/A",
             'status': status
         })
 
     # Print table
     if table_data:
         logger.info(f"Total migration time for {len(migration_results)} VMs: {total_migration_time:.2f}s")
-        logger.info("\n" + "=" * 150)
-        logger.info(f"{'Namespace':<25} {'Source Node':<30} {'Target Node':<30} {'Observed Time':<15} {'VMIM Time':<15} {'Status':<10}")
-        logger.info("=" * 150)
+        logger.info("\n" + "=" * 200)
+        logger.info(f"{'Namespace':<25} {'Source Node':<30} {'Target Node':<30} {'Observed Time':<15} {'VMIM Time':<15} "
+                    f"{'MiB/s':<10} {'Est. MiB/s':<12} {'Downtime':<10} {'Iter.':<6} {'Status':<10}")
+        logger.info("=" * 200)
 
         for row in table_data:
             logger.info(f"{row['namespace']:<25} {row['source_node']:<30} {row['target_node']:<30} "
-                  f"{row['observed_duration']:<15} {row['vmim_duration']:<15} {row['status']:<10}")
+                  f"{row['observed_duration']:<15} {row['vmim_duration']:<15} "
+                  f"{row['transfer_rate']:<10} {row['estimated_rate']:<12} "
+                  f"{row['downtime']:<10} {row['iterations']:<6} {row['status']:<10}")
 
-        logger.info("=" * 150)
+        logger.info("=" * 200)
 
     # Statistics
     successful_migrations = sum(1 for _, success, _, _, _, _ in migration_results if success)
@@ -1388,6 +2312,9 @@ def main():
         logger.info(f"    Average:              {avg_observed:.2f}s")
         logger.info(f"    Minimum:              {min_observed:.2f}s")
         logger.info(f"    Maximum:              {max_observed:.2f}s")
+        stats = describe(observed_durations)
+        logger.info(f"    P95 / P99:            {stats['p95']:.2f}s / {stats['p99']:.2f}s "
+                    f"(median {stats['median']:.2f}s, stddev {stats['stddev']:.2f}s)")
 
         if vmim_durations:
             avg_vmim = sum(vmim_durations) / len(vmim_durations)
@@ -1398,6 +2325,9 @@ def main():
             logger.info(f"    Average:              {avg_vmim:.2f}s")
             logger.info(f"    Minimum:              {min_vmim:.2f}s")
             logger.info(f"    Maximum:              {max_vmim:.2f}s")
+            stats = describe(vmim_durations)
+            logger.info(f"    P95 / P99:            {stats['p95']:.2f}s / {stats['p99']:.2f}s "
+                        f"(median {stats['median']:.2f}s, stddev {stats['stddev']:.2f}s)")
 
             # Calculate difference
             avg_diff = avg_observed - avg_vmim
@@ -1407,8 +2337,45 @@ def main():
         else:
             logger.info(f"\n  VMIM Time: Not available (timestamps not found)")
 
+        for key, label in (('transfer_mib_s', 'Transfer Throughput (bytes sent / VMIM time)'),
+                           ('estimated_mib_s', 'Estimated Throughput (memory size / observed time)')):
+            rates = [t[key] for t in throughput.values() if t[key] is not None]
+            if rates:
+                logger.info(f"\n  {label}:")
+                logger.info(f"    Average:              {sum(rates) / len(rates):.1f} MiB/s")
+                logger.info(f"    Minimum:              {min(rates):.1f} MiB/s")
+                logger.info(f"    Maximum:              {max(rates):.1f} MiB/s")
+
+        for key, label, unit in (('downtime_ms', 'Downtime', 'ms'),
+                                 ('memory_dirty_rate_mib_s', 'Memory Dirty Rate', 'MiB/s'),
+                                 ('iterations', 'Memory Iterations', '')):
+            values = [s[key] for s in job_stats.values() if s.get(key) is not None]
+            if values:
+                logger.info(f"\n  {label}:")
+                logger.info(f"    Average:              {sum(values) / len(values):.1f} {unit}".rstrip())
+                logger.info(f"    Minimum:              {min(values)} {unit}".rstrip())
+                logger.info(f"    Maximum:              {max(values)} {unit}".rstrip())
+
+        zone_summary = migration_zone_summary(migration_results, node_zones)
+        for key, label in (('same_zone', 'Same-Zone Migrations'), ('cross_zone', 'Cross-Zone Migrations')):
+            group = zone_summary[key]
+            observed, vmim = group['metrics']
+            if observed['count']:
+                logger.info(f"\n  {label} ({group['successful']}/{group['migrations']} successful):")
+                logger.info(f"    Observed Average:     {observed['avg']:.2f}s (p95 {observed['p95']:.2f}s)")
+                if vmim['count']:
+                    logger.info(f"    VMIM Average:         {vmim['avg']:.2f}s (p95 {vmim['p95']:.2f}s)")
+
         logger.info("=" * 80)
 
+    if data_integrity is not None:
+        print_data_integrity_summary(data_integrity, logger)
+
+    summary = migration_summary(migration_results, total_migration_time, disk_storage_classes or None,
+                                throughput, job_stats, node_zones)
+    log_outliers(summary['outliers'], logger)
+    emit('migration-summary', summary)
+
     # --- Save structured migration results if requested ---
     if args.save_results:
         logger.info(f"Using results directory: {out_dir}")
@@ -1419,69 +2386,29 @@ def main():
             migration_results,
             base_dir=out_dir,
             logger=logger,
-            total_time=total_migration_time
+            total_time=total_migration_time,
+            disk_storage_classes=disk_storage_classes or None,
+            timing=migration_timing,
+            data_integrity=data_integrity,
+            throughput=throughput,
+            job_stats=job_stats,
+            guest_load=guest_load_summary,
+            placement=placement.describe(placement_targets),
+            zones=node_zones,
+            tuning=args.tuning
         )
 
         logger.info(f"Migration results saved under: {out_dir}")
     else:
         logger.info("Migration results not saved (use --save-results to enable).")
 
-
-    # Determine if cleanup should run
-    should_cleanup = args.cleanup or (args.cleanup_on_failure and failed_migrations > 0)
-
-    # Cleanup
-    if should_cleanup or args.dry_run_cleanup:
-        logger.info("\n" + "=" * 80)
-        logger.info("CLEANUP")
-        logger.info("=" * 80)
-
-        # Confirm cleanup if needed
-        if not args.dry_run_cleanup and not confirm_cleanup(len(namespaces), args.yes):
-            logger.info("Cleanup cancelled by user")
-        else:
-            logger.info(f"\n{'[DRY RUN] ' if args.dry_run_cleanup else ''}Cleaning up test resources...")
-
-            try:
-                # Clean up VMIMs first
-                logger.info("Cleaning up VirtualMachineInstanceMigration objects...")
-                vmim_count = 0
-                for ns in namespaces:
-                    vmims = list_resources_in_namespace(ns, 'virtualmachineinstancemigration', logger)
-                    for vmim in vmims:
-                        if args.dry_run_cleanup:
-                            logger.info(f"[DRY RUN] Would delete VMIM: {vmim} in {ns}")
-                        else:
-                            if delete_vmim(vmim, ns, logger):
-                                vmim_count += 1
-
-                logger.info(f"{'[DRY RUN] Would delete' if args.dry_run_cleanup else 'Deleted'} {vmim_count} VMIM objects")
-
-                # Clean up VMs and namespaces if they were created by this test
-                if args.create_vms:
-                    stats = cleanup_test_namespaces(
-                        namespace_prefix=args.namespace_prefix,
-                        start=args.start,
-                        end=args.end,
-                        vm_name=args.vm_name,
-                        delete_namespaces=True,
-                        dry_run=args.dry_run_cleanup,
-                        batch_size=args.concurrency,
-                        logger=logger
-                    )
-                    print_cleanup_summary(stats, logger)
-                else:
-                    logger.info("VMs were not created by this test, skipping VM/namespace deletion")
-                    logger.info("Only VMIM objects were cleaned up")
-
-                if not args.dry_run_cleanup:
-                    logger.info("Cleanup completed successfully!")
-
-            except Exception as e:
-                logger.error(f"Error during cleanup: {e}")
-                logger.warning("Some resources may not have been cleaned up")
+    cleanup_migration_test(args, namespaces, failed_migrations, logger)
 
     logger.info("\nMigration test complete!")
+    run_metrics = {'vms': len(migration_results), 'failed': failed_migrations}
+    run_metrics.update(percentile_metrics('observed_sec', [r[2] for r in migration_results if r[1]]))
+    run_metrics.update(percentile_metrics('downtime_ms', [s.get('downtime_ms') for s in job_stats.values()]))
+    notify_run('migration', run_metrics, phase_status(failed_migrations, len(migration_results)), out_dir, logger)
 
 
 if __name__ == '__main__':
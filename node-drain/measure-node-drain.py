@@ -0,0 +1,644 @@
+#!/usr/bin/env python3
+"""
+KubeVirt Node Drain Benchmark
+
+Drains a node hosting benchmark VMs with `kubectl drain` and measures how
+KubeVirt evacuates it through the eviction API, the path taken by node
+maintenance and cluster upgrades:
+
+  - total drain time: until `kubectl drain` returns (every pod evicted)
+  - evacuation time: until the last benchmark VM has left the node
+  - per VM: time until its migration started and until it ran on another
+    node, plus the migration duration from the VMI migrationState
+  - placement: benchmark VMs per node before and after, and where the
+    evacuated VMs landed
+
+Unlike `migration --evacuate`, which creates one VirtualMachineInstanceMigration
+per VM itself, the migrations here are created by virt-controller in response
+to evictions, so the cluster's parallel migration limits and the drain's own
+pacing apply. The VMs must use evictionStrategy LiveMigrate (or the cluster
+default must be LiveMigrate).
+
+With --pdb-fraction, a PodDisruptionBudget is created for that fraction of
+the VMs on the node before the drain (and deleted afterwards), and the
+evictions the API server refused are counted from the drain's retries.
+--compare-pdb drains twice, first without and then with the PDBs, each
+time the node then hosting the most benchmark VMs.
+
+Usage:
+    # Drain the node hosting the most benchmark VMs
+    python3 measure-node-drain.py --start 1 --end 50 --save-results
+
+    # Drain a given node and leave it cordoned
+    python3 measure-node-drain.py --start 1 --end 50 --node worker-2 --keep-cordoned
+
+    # Drain time without and with PDBs on half of the VMs
+    python3 measure-node-drain.py --start 1 --end 50 --pdb-fraction 0.5 --compare-pdb --save-results
+
+Author: KubeVirt Benchmark Suite Contributors
+License: Apache 2.0
+"""
+
+import argparse
+import csv
+import json
+import math
+import os
+import re
+import subprocess
+import sys
+import tempfile
+import time
+from collections import Counter
+from datetime import datetime
+from typing import Dict, List, Optional
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
+
+from utils import timing
+from utils.apply import apply_manifest
+from utils.common import (
+    setup_logging, run_kubectl_command, uncordon_node, calculate_vmim_duration, round_duration,
+    run_metadata, stamp_manifest,
+)
+from utils.custommetrics import collect_custom_metrics
+from utils.events import collect_run_events, watch_events
+from utils.inventory import cluster_inventory, resolve_storage_driver
+from utils.notify import (
+    notify_phase, notify_run, watch_run, phase_status, duration_metrics, percentile_metrics,
+)
+from utils.dryrun import DryRunPlan, is_dry_run
+from utils.stats import log_outliers, metric_outliers, metric_stats, stat_columns
+from utils.storageprovider import collect_storage_backend
+from utils.utilization import collect_node_samples, sample_nodes
+
+# Default configuration
+DEFAULT_VM_NAME = 'rhel-9-vm'
+DEFAULT_NAMESPACE_PREFIX = 'kubevirt-perf-test'
+DEFAULT_DRAIN_TIMEOUT = 1800
+DEFAULT_GRACE_PERIOD = 30
+DEFAULT_POLL_INTERVAL = 1.0
+DEFAULT_PDB_MIN_AVAILABLE = 1
+
+PDB_NAME = 'virtbench-drain-pdb'
+# virt-launcher pod label naming its VM
+VM_NAME_LABEL = 'vm.kubevirt.io/name'
+# kubectl drain retry line of a refused eviction, e.g.
+# error when evicting pods/"virt-launcher-rhel-9-vm-abcde" -n "ns-1" (will retry after 5s): Cannot evict pod ...
+BLOCKED_EVICTION = re.compile(r'error when evicting pods/"?([^"\s]+)"? -n "?([^"\s]+)"?.*?: (.*)')
+
+
+def parse_args():
+    parser = argparse.ArgumentParser(
+        description='Measure evacuation of a node hosting benchmark VMs with kubectl drain',
+        formatter_class=argparse.RawDescriptionHelpFormatter,
+    )
+    parser.add_argument('--start', type=int, default=1, help='Starting namespace index (default: 1)')
+    parser.add_argument('--end', type=int, default=10, help='Ending namespace index (default: 10)')
+    parser.add_argument('--namespace-prefix', default=DEFAULT_NAMESPACE_PREFIX,
+                        help=f'Namespace prefix (default: {DEFAULT_NAMESPACE_PREFIX})')
+    parser.add_argument('--vm-name', default=DEFAULT_VM_NAME,
+                        help=f'VM name in each namespace (default: {DEFAULT_VM_NAME})')
+    parser.add_argument('--node', default=None,
+                        help='Node to drain (default: the node hosting the most benchmark VMs)')
+    parser.add_argument('--drain-timeout', type=int, default=DEFAULT_DRAIN_TIMEOUT,
+                        help=f'kubectl drain timeout in seconds (default: {DEFAULT_DRAIN_TIMEOUT})')
+    parser.add_argument('--grace-period', type=int, default=DEFAULT_GRACE_PERIOD,
+                        help=f'Pod termination grace period for non-VM pods (default: {DEFAULT_GRACE_PERIOD})')
+    parser.add_argument('--poll-interval', type=float, default=DEFAULT_POLL_INTERVAL,
+                        help=f'Seconds between VMI status checks (default: {DEFAULT_POLL_INTERVAL})')
+    parser.add_argument('--keep-cordoned', action='store_true',
+                        help='Leave the node cordoned afterwards (default: uncordon)')
+    parser.add_argument('--pdb-fraction', type=float, default=0.0,
+                        help='Create a PodDisruptionBudget for this fraction (0-1) of the VMs on the node '
+                             'before the drain (default: 0, none)')
+    parser.add_argument('--pdb-min-available', type=int, default=DEFAULT_PDB_MIN_AVAILABLE,
+                        help=f'minAvailable of the PDBs (default: {DEFAULT_PDB_MIN_AVAILABLE})')
+    parser.add_argument('--compare-pdb', action='store_true',
+                        help='Drain once without and once with the PDBs and compare the two drains')
+    parser.add_argument('--precision', type=int, default=timing.DEFAULT_PRECISION,
+                        help=f'Decimal places for durations in saved results (default: {timing.DEFAULT_PRECISION})')
+    parser.add_argument('--save-results', action='store_true', help='Save results as JSON and CSV')
+    parser.add_argument('--results-folder', default='results',
+                        help='Base directory for results (default: results)')
+    parser.add_argument('--storage-driver', default=None,
+                        help='Storage driver label to include in the results path, or auto to detect it')
+    parser.add_argument('--log-file', default=None, help='Log file path')
+    parser.add_argument('--log-level', default='INFO',
+                        choices=['DEBUG', 'INFO', 'WARNING', 'ERROR'], help='Logging level (default: INFO)')
+    args = parser.parse_args()
+
+    if args.poll_interval <= 0:
+        parser.error("--poll-interval must be > 0")
+    if args.drain_timeout < 1:
+        parser.error("--drain-timeout must be >= 1")
+    if not 0 <= args.pdb_fraction <= 1:
+        parser.error("--pdb-fraction must be between 0 and 1")
+    if args.pdb_min_available < 1:
+        parser.error("--pdb-min-available must be >= 1")
+    if args.compare_pdb:
+        if not args.pdb_fraction:
+            parser.error("--compare-pdb requires --pdb-fraction")
+        if args.node:
+            parser.error("--compare-pdb drains the busiest node each time and cannot be combined with --node")
+        if args.keep_cordoned:
+            parser.error("--compare-pdb cannot be combined with --keep-cordoned")
+    return args
+
+
+def build_results_dir(args, timestamp: Optional[str] = None) -> str:
+    """Build the canonical node-drain results directory."""
+    timestamp = timestamp or datetime.now().strftime("%Y%m%d-%H%M%S")
+    suffix = f"{args.namespace_prefix}_{args.start}-{args.end}"
+    if args.storage_driver:
+        return os.path.join(args.results_folder, args.storage_driver, 'node-drain', f"{timestamp}_{suffix}")
+    return os.path.join(args.results_folder, 'node-drain', f"{timestamp}_{suffix}")
+
+
+def get_benchmark_vmis(namespaces: List[str], vm_name: str, logger) -> Optional[Dict[str, Dict]]:
+    """Return {namespace: VMI object} for the benchmark VMIs with one cluster-wide list call, None on error."""
+    rc, stdout, stderr = run_kubectl_command(['get', 'vmi', '-A', '-o', 'json'], check=False, logger=logger)
+    if rc != 0:
+        logger.debug(f"Failed to list VMIs: {stderr.strip()}")
+        return None
+    wanted = set(namespaces)
+    vmis = {}
+    for item in json.loads(stdout).get('items', []):
+        metadata = item.get('metadata', {})
+        if metadata.get('name') == vm_name and metadata.get('namespace') in wanted:
+            vmis[metadata['namespace']] = item
+    return vmis
+
+
+def placement(vmis: Dict[str, Dict]) -> Dict[str, int]:
+    """Benchmark VMs per node."""
+    counts = Counter(v.get('status', {}).get('nodeName') for v in vmis.values())
+    counts.pop(None, None)
+    return dict(sorted(counts.items()))
+
+
+def pdb_manifest(namespace: str, vm_name: str, min_available: int) -> str:
+    """PodDisruptionBudget covering the virt-launcher pod of a benchmark VM."""
+    return f"""apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  name: {PDB_NAME}
+  namespace: {namespace}
+spec:
+  minAvailable: {min_available}
+  selector:
+    matchLabels:
+      {VM_NAME_LABEL}: {vm_name}
+"""
+
+
+def pdb_targets(on_node: List[str], fraction: float) -> List[str]:
+    """The --pdb-fraction of the VMs on the node that get a PDB, rounded up."""
+    return sorted(on_node)[:math.ceil(len(on_node) * fraction)]
+
+
+def create_pdbs(namespaces: List[str], args, logger) -> List[str]:
+    """Create the PDBs; returns the namespaces that got one."""
+    created = []
+    for ns in namespaces:
+        applied, error = apply_manifest(stamp_manifest(pdb_manifest(ns, args.vm_name, args.pdb_min_available)),
+                                        logger=logger)
+        if not applied:
+            logger.error(f"[{ns}] Failed to create PodDisruptionBudget: {error}")
+            continue
+        created.append(ns)
+    logger.info(f"Created PodDisruptionBudgets (minAvailable {args.pdb_min_available}) for {len(created)} VMs")
+    return created
+
+
+def delete_pdbs(namespaces: List[str], logger) -> None:
+    for ns in namespaces:
+        run_kubectl_command(['delete', 'pdb', PDB_NAME, '-n', ns, '--ignore-not-found'], check=False, logger=logger)
+    if namespaces:
+        logger.info(f"Deleted {len(namespaces)} PodDisruptionBudgets")
+
+
+def blocked_evictions(output: str) -> Dict[str, Dict]:
+    """Refused evictions in kubectl drain output: {namespace: {'count', 'reason'}} (the last reason)."""
+    blocked = {}
+    for line in output.splitlines():
+        match = BLOCKED_EVICTION.search(line)
+        if match:
+            entry = blocked.setdefault(match.group(2), {'count': 0, 'reason': None})
+            entry['count'] += 1
+            entry['reason'] = match.group(3).strip()
+    return blocked
+
+
+def run_drain(node: str, args, logger) -> subprocess.Popen:
+    """Start `kubectl drain` in the background; its output (one line per evicted pod) goes to a temp file."""
+    cmd = ['kubectl', 'drain', node, '--ignore-daemonsets', '--delete-emptydir-data',
+           f'--timeout={args.drain_timeout}s', f'--grace-period={args.grace_period}']
+    logger.info(f"Running: {' '.join(cmd)}")
+    output = tempfile.TemporaryFile(mode='w+')
+    proc = subprocess.Popen(cmd, stdout=output, stderr=subprocess.STDOUT, text=True)
+    proc.output = output
+    return proc
+
+
+def drain_output(proc: subprocess.Popen) -> str:
+    proc.output.seek(0)
+    text = proc.output.read()
+    proc.output.close()
+    return text.strip()
+
+
+def track_evacuation(node: str, on_node: List[str], before: Dict[str, Dict], args, logger,
+                     pdbs: Optional[List[str]] = None):
+    """
+    Drain the node and follow the benchmark VMs until they have all left it and the drain has returned.
+
+    Args:
+        pdbs: Namespaces whose VM has a PodDisruptionBudget
+
+    Returns:
+        Tuple of (per-VM rows, drain row)
+    """
+    pdbs = set(pdbs or [])
+    rows = {
+        ns: {'namespace': ns, 'source_node': node, 'target_node': None, 'evacuated': False,
+             'migration_started_sec': None, 'evacuated_sec': None, 'vmim_time_sec': None,
+             'migration_uid': None, 'attempts': 0, 'pdb': ns in pdbs, 'blocked_evictions': 0, 'error': None}
+        for ns in on_node
+    }
+    old_uids = {ns: (before[ns].get('status', {}).get('migrationState') or {}).get('migrationUid')
+                for ns in on_node}
+    drain = {'node': node, 'success': False, 'drain_sec': None, 'evacuation_sec': None, 'error': None,
+             'pdbs': len(pdbs), 'blocked_evictions': 0, 'blocked_reasons': {}}
+    output = ''
+
+    started = timing.now()
+    proc = run_drain(node, args, logger)
+    deadline = time.monotonic() + args.drain_timeout + 60
+    pending = set(on_node)
+
+    while time.monotonic() < deadline:
+        elapsed = (timing.now() - started).total_seconds()
+        if drain['drain_sec'] is None and proc.poll() is not None:
+            drain['drain_sec'] = elapsed
+            output = drain_output(proc)
+            drain['success'] = proc.returncode == 0
+            if drain['success']:
+                logger.info(f"kubectl drain returned after {elapsed:.2f}s")
+                logger.debug(output)
+            else:
+                drain['error'] = output.splitlines()[-1] if output else f"exit code {proc.returncode}"
+                logger.error(f"kubectl drain failed after {elapsed:.2f}s: {drain['error']}")
+
+        vmis = get_benchmark_vmis(list(pending), args.vm_name, logger) if pending else None
+        if vmis is not None:
+            for ns in list(pending):
+                row = rows[ns]
+                status = vmis.get(ns, {}).get('status', {})
+                state = status.get('migrationState') or {}
+                uid = state.get('migrationUid')
+                if uid and uid != old_uids[ns] and uid != row['migration_uid']:
+                    row['migration_uid'] = uid
+                    row['attempts'] += 1
+                    if row['migration_started_sec'] is None:
+                        row['migration_started_sec'] = elapsed
+                    logger.info(f"[{ns}] Migration started to {state.get('targetNode')} ({elapsed:.2f}s)")
+                new_node = status.get('nodeName')
+                if row['migration_uid'] and state.get('completed') and not state.get('failed') \
+                        and new_node and new_node != node:
+                    row.update(evacuated=True, target_node=new_node, evacuated_sec=elapsed,
+                               vmim_time_sec=calculate_vmim_duration(state.get('startTimestamp'),
+                                                                     state.get('endTimestamp')))
+                    pending.discard(ns)
+                    logger.info(f"[{ns}] Evacuated to {new_node} after {elapsed:.2f}s "
+                                f"({len(on_node) - len(pending)}/{len(on_node)})")
+                elif ns not in vmis and drain['drain_sec'] is not None:
+                    # The VMI is gone: shut down instead of migrated (evictionStrategy None/External)
+                    row['error'] = 'VMI was stopped instead of migrated'
+                    pending.discard(ns)
+                    logger.error(f"[{ns}] {row['error']}")
+            if not pending:
+                drain['evacuation_sec'] = elapsed
+                logger.info(f"All {len(on_node)} benchmark VMs left {node} after {elapsed:.2f}s")
+
+        if not pending and drain['drain_sec'] is not None:
+            break
+        time.sleep(args.poll_interval)
+
+    if drain['drain_sec'] is None:
+        proc.kill()
+        proc.wait()
+        output = drain_output(proc)
+        drain['error'] = f"kubectl drain did not return within {args.drain_timeout + 60}s"
+        logger.error(drain['error'])
+    for ns in pending:
+        rows[ns]['error'] = rows[ns]['error'] or 'not evacuated before the drain timed out'
+        logger.error(f"[{ns}] {rows[ns]['error']}")
+
+    # Evictions refused by the API server (PDBs), retried by kubectl drain
+    reasons = Counter()
+    for ns, entry in blocked_evictions(output).items():
+        drain['blocked_evictions'] += entry['count']
+        reasons[entry['reason']] += entry['count']
+        if ns in rows:
+            rows[ns]['blocked_evictions'] = entry['count']
+    drain['blocked_reasons'] = dict(reasons)
+    if drain['blocked_evictions']:
+        logger.info(f"kubectl drain retried {drain['blocked_evictions']} refused evictions")
+    return [rows[ns] for ns in on_node], drain
+
+
+def drain_outliers(rows: List[Dict]) -> List[Dict]:
+    """VMs evacuated more than --outlier-sigma standard deviations slower than the mean."""
+    return metric_outliers([r for r in rows if r['evacuated']], ('evacuated_sec', 'vmim_time_sec'),
+                           lambda r: r['namespace'])
+
+
+def print_summary(rows: List[Dict], drain: Dict, before: Dict[str, int], after: Dict[str, int], logger) -> None:
+    def fmt(value):
+        return f"{value:.2f}" if value is not None else '-'
+
+    logger.info("")
+    logger.info("=" * 100)
+    logger.info(f"NODE DRAIN RESULTS: {drain['node']}")
+    logger.info("=" * 100)
+    logger.info(f"{'Namespace':<30}{'Target Node':<25}{'Started (s)':>12}{'Evacuated (s)':>15}"
+                f"{'VMIM (s)':>10}{'Tries':>7}  Status")
+    logger.info("-" * 100)
+    for r in sorted(rows, key=lambda r: r['evacuated_sec'] or float('inf')):
+        status = 'OK' if r['evacuated'] else f"FAILED: {r['error']}"
+        logger.info(f"{r['namespace']:<30}{(r['target_node'] or '-'):<25}{fmt(r['migration_started_sec']):>12}"
+                    f"{fmt(r['evacuated_sec']):>15}{fmt(r['vmim_time_sec']):>10}{r['attempts']:>7}  {status}")
+    logger.info("-" * 100)
+    logger.info(f"  Drain time (kubectl drain):   {fmt(drain['drain_sec'])}s")
+    logger.info(f"  Evacuation time (last VM):    {fmt(drain['evacuation_sec'])}s")
+    logger.info(f"  VMs evacuated:                {sum(1 for r in rows if r['evacuated'])}/{len(rows)}")
+    if drain['pdbs'] or drain['blocked_evictions']:
+        logger.info(f"  VMs with a PDB:               {drain['pdbs']}")
+        logger.info(f"  Blocked evictions:            {drain['blocked_evictions']}")
+    logger.info("")
+    logger.info(f"  {'Node':<30}{'VMs before':>12}{'VMs after':>12}")
+    for node in sorted(set(before) | set(after)):
+        logger.info(f"  {node:<30}{before.get(node, 0):>12}{after.get(node, 0):>12}")
+    logger.info("=" * 100)
+    log_outliers(drain_outliers(rows), logger)
+
+
+def pdb_comparison(baseline: Dict, with_pdb: Dict) -> Dict:
+    """Drain without against drain with PDBs (--compare-pdb); slowdown_pct is positive when PDBs slowed it."""
+    def run_summary(run):
+        drain = run['drain']
+        return {
+            'node': drain['node'],
+            'total_vms': len(run['rows']),
+            'evacuated': sum(1 for r in run['rows'] if r['evacuated']),
+            'pdbs': drain['pdbs'],
+            'blocked_evictions': drain['blocked_evictions'],
+            'drain_sec': round_duration(drain['drain_sec']),
+            'evacuation_sec': round_duration(drain['evacuation_sec']),
+        }
+
+    def slowdown(base, value):
+        return round((value - base) / base * 100, 1) if base and value is not None else None
+
+    without, pdb = run_summary(baseline), run_summary(with_pdb)
+    return {
+        'without_pdb': without,
+        'with_pdb': pdb,
+        'drain_slowdown_pct': slowdown(without['drain_sec'], pdb['drain_sec']),
+        'evacuation_slowdown_pct': slowdown(without['evacuation_sec'], pdb['evacuation_sec']),
+    }
+
+
+def print_pdb_comparison(comparison: Dict, logger) -> None:
+    def fmt(value):
+        return '-' if value is None else value
+
+    logger.info("")
+    logger.info("=" * 80)
+    logger.info("PDB COMPARISON")
+    logger.info("=" * 80)
+    logger.info(f"{'':<24}{'Without PDBs':>18}{'With PDBs':>18}")
+    for key, label in (('node', 'Node'), ('evacuated', 'VMs evacuated'), ('pdbs', 'VMs with a PDB'),
+                       ('blocked_evictions', 'Blocked evictions'), ('drain_sec', 'Drain time (s)'),
+                       ('evacuation_sec', 'Evacuation time (s)')):
+        logger.info(f"{label:<24}{fmt(comparison['without_pdb'][key]):>18}{fmt(comparison['with_pdb'][key]):>18}")
+    for key, label in (('drain_slowdown_pct', 'Drain slowdown'), ('evacuation_slowdown_pct', 'Evacuation slowdown')):
+        if comparison[key] is not None:
+            logger.info(f"{label:<24}{comparison[key]:>+35.1f}%")
+    logger.info("=" * 80)
+
+
+def save_drain_results(out_dir: str, args, rows: List[Dict], drain: Dict, before: Dict[str, int],
+                       after: Dict[str, int], timing_block: Dict, logger,
+                       comparison: Optional[Dict] = None) -> None:
+    """Write per-VM results and the drain summary as JSON and CSV."""
+    os.makedirs(out_dir, exist_ok=True)
+    saved = []
+    for r in rows:
+        row = dict(r)
+        for key in ('migration_started_sec', 'evacuated_sec', 'vmim_time_sec'):
+            row[key] = round_duration(row[key])
+        saved.append(row)
+    with open(os.path.join(out_dir, 'node_drain_results.json'), 'w') as f:
+        json.dump(saved, f, indent=4)
+    with open(os.path.join(out_dir, 'node_drain_results.csv'), 'w', newline='') as f:
+        writer = csv.DictWriter(f, fieldnames=saved[0].keys())
+        writer.writeheader()
+        writer.writerows(saved)
+
+    evacuated = [r for r in rows if r['evacuated']]
+    metrics = [
+        metric_stats('drain_sec', [drain['drain_sec']]),
+        metric_stats('evacuation_sec', [drain['evacuation_sec']]),
+        metric_stats('migration_started_sec', [r['migration_started_sec'] for r in rows]),
+        metric_stats('evacuated_sec', [r['evacuated_sec'] for r in evacuated]),
+        metric_stats('vmim_time_sec', [r['vmim_time_sec'] for r in evacuated]),
+    ]
+    summary = {
+        'node': drain['node'],
+        'drain_succeeded': drain['success'],
+        'drain_error': drain['error'],
+        'total_vms': len(rows),
+        'evacuated': len(evacuated),
+        'failed': len(rows) - len(evacuated),
+        'placement_before': before,
+        'placement_after': after,
+        'evacuation_targets': dict(sorted(Counter(r['target_node'] for r in evacuated).items())),
+        'pdbs': drain['pdbs'],
+        'pdb_min_available': args.pdb_min_available if drain['pdbs'] else None,
+        'blocked_evictions': drain['blocked_evictions'],
+        'blocked_reasons': drain['blocked_reasons'],
+        'metrics': metrics,
+        'outliers': drain_outliers(rows),
+        'timing': timing_block,
+    }
+    if comparison:
+        summary['pdb_comparison'] = comparison
+    custom_metrics = collect_custom_metrics(timing_block, logger)
+    if custom_metrics is not None:
+        summary['custom_metrics'] = custom_metrics
+    run_events = collect_run_events(out_dir, logger, summary.get('outliers'))
+    if run_events is not None:
+        summary['events'] = run_events
+    node_utilization = collect_node_samples(out_dir, logger)
+    if node_utilization is not None:
+        summary['node_utilization'] = node_utilization
+    storage_backend = collect_storage_backend(out_dir, args.namespace_prefix, logger)
+    if storage_backend is not None:
+        summary['storage_backend'] = storage_backend
+    summary['cluster'] = cluster_inventory(logger)
+    summary['run'] = run_metadata()
+    with open(os.path.join(out_dir, 'summary_node_drain_results.json'), 'w') as f:
+        json.dump(summary, f, indent=4)
+    with open(os.path.join(out_dir, 'summary_node_drain_results.csv'), 'w', newline='') as f:
+        writer = csv.DictWriter(f, fieldnames=stat_columns('metric', 'avg', 'min', 'max', 'count'))
+        writer.writeheader()
+        writer.writerows(metrics)
+    logger.info(f"Results saved under: {out_dir}")
+
+
+def plan_run(node: str, on_node: List[str], args, logger):
+    """Print the drain main() would run and the VMs it would evacuate (virtbench --dry-run)."""
+    plan = DryRunPlan('node-drain', logger)
+    pdbs = pdb_targets(on_node, args.pdb_fraction)
+    if args.compare_pdb:
+        plan.action('drain', f"node/{node}", "without PDBs, then uncordon")
+        plan.action('drain', "node/(busiest node after the first drain)", f"with PDBs for {len(pdbs)} of its VMs")
+    else:
+        for ns in pdbs:
+            plan.apply(pdb_manifest(ns, args.vm_name, args.pdb_min_available), ns)
+        plan.action('drain', f"node/{node}", f"--timeout={args.drain_timeout}s --grace-period={args.grace_period}")
+        for ns in on_node:
+            plan.action('evict', f"vm/{ns}/{args.vm_name}", f"live migrates off {node}")
+        for ns in pdbs:
+            plan.action('delete', f"pdb/{ns}/{PDB_NAME}")
+        if not args.keep_cordoned:
+            plan.action('uncordon', f"node/{node}")
+    plan.report()
+
+
+def select_node(namespaces: List[str], args, logger):
+    """
+    The node to drain and the benchmark VMs on it.
+
+    Returns:
+        Tuple of (node, namespaces of the VMs on it, VMIs, placement); exits when there is nothing to drain
+    """
+    vmis = get_benchmark_vmis(namespaces, args.vm_name, logger) or {}
+    before = placement(vmis)
+    if not before:
+        logger.error("No running benchmark VMIs found")
+        sys.exit(1)
+
+    node = args.node or max(before, key=before.get)
+    on_node = [ns for ns, v in vmis.items() if v.get('status', {}).get('nodeName') == node]
+    if not on_node:
+        logger.error(f"No benchmark VMs are running on {node}")
+        sys.exit(1)
+    logger.info(f"Draining {node}, hosting {len(on_node)} of {len(vmis)} running benchmark VMs")
+    return node, on_node, vmis, before
+
+
+def drain_node(node: str, on_node: List[str], vmis: Dict[str, Dict], namespaces: List[str], args, logger,
+               pdb_fraction: float) -> Dict:
+    """
+    Drain the node with PDBs for pdb_fraction of its VMs, then uncordon it (unless --keep-cordoned).
+
+    Returns:
+        {'rows', 'drain', 'after'}
+    """
+    pdbs = create_pdbs(pdb_targets(on_node, pdb_fraction), args, logger) if pdb_fraction else []
+    try:
+        rows, drain = track_evacuation(node, on_node, vmis, args, logger, pdbs=pdbs)
+    finally:
+        delete_pdbs(pdbs, logger)
+        if not args.keep_cordoned:
+            uncordon_node(node, logger)
+    after = placement(get_benchmark_vmis(namespaces, args.vm_name, logger) or {})
+    return {'rows': rows, 'drain': drain, 'after': after}
+
+
+def main():
+    args = parse_args()
+    args.storage_driver = resolve_storage_driver(args.storage_driver)
+    dry_run = is_dry_run()
+    if dry_run:
+        # A dry run has no results to save
+        args.save_results = False
+
+    out_dir = None
+    if args.save_results:
+        out_dir = build_results_dir(args)
+        os.makedirs(out_dir, exist_ok=True)
+        if not args.log_file:
+            args.log_file = os.path.join(out_dir, 'node-drain.log')
+
+    logger = setup_logging(args.log_file, args.log_level)
+    watch_run('node-drain', logger)
+    watch_events(args.namespace_prefix, logger)
+    sample_nodes(logger)
+    timing.set_precision(args.precision)
+
+    logger.info("=" * 80)
+    logger.info("KubeVirt Node Drain Benchmark")
+    logger.info("=" * 80)
+    logger.info(f"Namespaces: {args.namespace_prefix}-{args.start} to {args.namespace_prefix}-{args.end}")
+    logger.info(f"VM: {args.vm_name}")
+    logger.info("=" * 80)
+
+    namespaces = [f"{args.namespace_prefix}-{i}" for i in range(args.start, args.end + 1)]
+    node, on_node, vmis_before, before = select_node(namespaces, args, logger)
+    for vmi in vmis_before.values():
+        strategy = vmi.get('spec', {}).get('evictionStrategy')
+        if strategy and strategy != 'LiveMigrate':
+            logger.warning(f"[{vmi['metadata']['namespace']}] evictionStrategy is {strategy}; "
+                           f"the VM will not be live migrated")
+            break
+
+    if dry_run:
+        plan_run(node, on_node, args, logger)
+        sys.exit(0)
+
+    clock_skew = timing.estimate_clock_skew(logger=logger) if args.save_results else None
+    if args.save_results:
+        cluster_inventory(logger)
+    test_start = timing.now()
+    comparison = None
+    if args.compare_pdb:
+        logger.info("PDB comparison: drain without PDBs")
+        baseline = drain_node(node, on_node, vmis_before, namespaces, args, logger, 0.0)
+        print_summary(baseline['rows'], baseline['drain'], before, baseline['after'], logger)
+        logger.info("PDB comparison: drain with PDBs")
+        node, on_node, vmis_before, before = select_node(namespaces, args, logger)
+    run = drain_node(node, on_node, vmis_before, namespaces, args, logger, args.pdb_fraction)
+    rows, drain, after = run['rows'], run['drain'], run['after']
+    print_summary(rows, drain, before, after, logger)
+    if args.compare_pdb:
+        comparison = pdb_comparison(baseline, run)
+        print_pdb_comparison(comparison, logger)
+
+    failed = sum(1 for r in rows if not r['evacuated'])
+    phase_metrics = {'node': node, 'vms': len(rows), 'failed': failed,
+                     'drain_sec': round_duration(drain['drain_sec']),
+                     'evacuation_sec': round_duration(drain['evacuation_sec'])}
+    if drain['pdbs']:
+        phase_metrics.update(pdbs=drain['pdbs'], blocked_evictions=drain['blocked_evictions'])
+    if comparison:
+        phase_metrics['drain_slowdown_pct'] = comparison['drain_slowdown_pct']
+    phase_metrics.update(duration_metrics('evacuated_sec', [r['evacuated_sec'] for r in rows]))
+    status = phase_status(failed + (0 if drain['success'] else 1), len(rows))
+    notify_phase('node-drain', 'evacuation-complete', phase_metrics, status, logger=logger)
+
+    if args.save_results:
+        save_drain_results(out_dir, args, rows, drain, before, after,
+                           timing.timing_metadata(test_start, clock_skew=clock_skew), logger, comparison=comparison)
+    phase_metrics.update(percentile_metrics('evacuated_sec', [r['evacuated_sec'] for r in rows]))
+    notify_run('node-drain', phase_metrics, status, out_dir, logger)
+
+    sys.exit(0 if drain['success'] and not failed else 2)
+
+
+if __name__ == '__main__':
+    main()
@@ -0,0 +1,581 @@
+#!/usr/bin/env python3
+"""
+KubeVirt Randomized Workload (seeded tenant emulation)
+
+Runs a randomized but reproducible sequence of VM operations and VM sizes
+that emulates tenants using the cluster: VMs of weighted random sizes
+(CORESxMEMORY) are created, started, stopped, restarted, live-migrated,
+snapshotted, resized and deleted, arriving at random (Poisson) intervals
+around --rate operations per minute. The sequence is a plan generated from
+--seed (see utils/workloadgen.py), so the same seed and options always run
+the same steps.
+
+The seed and the complete plan are saved with the results (workload_plan.json
+and the summary). A run that hit a problem is replayed exactly with
+--replay <results dir or plan file>, which runs the saved steps rather than
+regenerating them. When no --seed is given, a random one is drawn and
+logged.
+
+Each step starts at its planned time (scaled by --time-scale) once the steps
+before it on the same VM are done, and at most --concurrency steps run at
+once. Every step is timed until its operation completes; the delay between
+the planned and the actual start is recorded as well. After a failed step
+the remaining steps of that VM are skipped until the plan deletes it.
+
+Usage:
+    python3 measure-random-workload.py --seed 42 --operations 200 --max-vms 20 \\
+        --vm-template ../examples/vm-templates/rhel9-vm-datasource.yaml --save-results
+
+    python3 measure-random-workload.py --replay results/random-workload/20250101-120000_virtbench-random_s42
+
+    python3 measure-random-workload.py --seed 42 --operations 200 --write-plan plan.json
+
+Author: KubeVirt Benchmark Suite Contributors
+License: Apache 2.0
+"""
+
+import argparse
+import csv
+import json
+import os
+import subprocess
+import sys
+import time
+from concurrent.futures import wait, FIRST_COMPLETED
+from datetime import datetime
+from typing import Dict, List, Optional
+
+import yaml
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
+
+from utils import timing
+from utils import workloadgen
+from utils.concurrency import WorkerPool, DEFAULT_QPS, DEFAULT_BURST
+from utils.common import (
+    _kubectl_with_input, setup_logging, run_kubectl_command, create_namespaces_parallel, get_vm_status, start_vm,
+    stop_vm, delete_vm, migrate_vm, wait_for_migration_complete, delete_vmim, create_vm_snapshot,
+    wait_for_snapshot_ready, delete_vm_snapshot, expand_pvc, get_vm_volume_names,
+    cleanup_test_namespaces, print_cleanup_summary, round_duration, stamp_manifest,
+    set_run_workload, run_selector, run_metadata,
+)
+from utils.custommetrics import collect_custom_metrics
+from utils.events import collect_run_events, watch_events
+from utils.inventory import cluster_inventory, resolve_storage_driver
+from utils.dryrun import DryRunPlan, is_dry_run
+from utils.notify import notify_run, watch_run, phase_status
+from utils.stats import log_outliers, metric_outliers, metric_stats
+from utils.storageprovider import collect_storage_backend
+from utils.utilization import collect_node_samples, sample_nodes
+
+# Default configuration
+DEFAULT_VM_NAME = 'rhel-9-vm'
+DEFAULT_VM_YAML = '../examples/vm-templates/rhel9-vm-datasource.yaml'
+DEFAULT_NAMESPACE_PREFIX = 'virtbench-random'
+DEFAULT_CONCURRENCY = 5
+DEFAULT_TIMEOUT = 900
+DEFAULT_POLL_INTERVAL = 2
+DEFAULT_TIME_SCALE = 1.0
+
+
+def parse_args():
+    parser = argparse.ArgumentParser(
+        description='Seeded randomized workload: reproducible VM operations and sizes emulating tenants',
+        formatter_class=argparse.RawDescriptionHelpFormatter,
+    )
+    parser.add_argument('--seed', type=int, default=None,
+                        help='Random seed of the plan (default: random, logged and saved with the results)')
+    parser.add_argument('--replay', default=None, metavar='PATH',
+                        help='Run the saved plan of an earlier run (its results directory or workload_plan.json)')
+    parser.add_argument('--write-plan', default=None, metavar='PATH',
+                        help='Only generate the plan and write it to PATH, without running it')
+    parser.add_argument('--operations', type=int, default=workloadgen.DEFAULT_OPERATIONS,
+                        help=f'Number of operations in the plan (default: {workloadgen.DEFAULT_OPERATIONS})')
+    parser.add_argument('--rate', type=float, default=workloadgen.DEFAULT_RATE,
+                        help=f'Average operations per minute (default: {workloadgen.DEFAULT_RATE:g})')
+    parser.add_argument('--max-vms', type=int, default=workloadgen.DEFAULT_MAX_VMS,
+                        help=f'VMs that may exist at once, one namespace each (default: {workloadgen.DEFAULT_MAX_VMS})')
+    parser.add_argument('--mix', nargs='+', default=workloadgen.DEFAULT_MIX, metavar='OPERATION[=WEIGHT]',
+                        help=f'Operations drawn by weight, from {", ".join(workloadgen.OPERATIONS)} '
+                             f'(default: {" ".join(workloadgen.DEFAULT_MIX)})')
+    parser.add_argument('--sizes', nargs='+', default=workloadgen.DEFAULT_SIZES, metavar='CORESxMEMORY[=WEIGHT]',
+                        help=f'VM sizes drawn by weight (default: {" ".join(workloadgen.DEFAULT_SIZES)})')
+    parser.add_argument('--max-resizes-per-vm', type=int, default=workloadgen.DEFAULT_MAX_RESIZES,
+                        help=f'Resizes per VM before resize is no longer drawn for it '
+                             f'(default: {workloadgen.DEFAULT_MAX_RESIZES})')
+    parser.add_argument('--resize-increment', default=workloadgen.DEFAULT_RESIZE_INCREMENT,
+                        help=f'Size each resize adds to the VM\'s first PVC (default: {workloadgen.DEFAULT_RESIZE_INCREMENT})')
+    parser.add_argument('--time-scale', type=float, default=DEFAULT_TIME_SCALE,
+                        help='Multiplier of the planned start times; 0 runs the steps back-to-back '
+                             f'(default: {DEFAULT_TIME_SCALE:g})')
+    parser.add_argument('--namespace-prefix', default=DEFAULT_NAMESPACE_PREFIX,
+                        help=f'Namespace prefix (default: {DEFAULT_NAMESPACE_PREFIX})')
+    parser.add_argument('--vm-name', default=DEFAULT_VM_NAME,
+                        help=f'VM name in the template (default: {DEFAULT_VM_NAME})')
+    parser.add_argument('--vm-template', default=DEFAULT_VM_YAML,
+                        help=f'VM template YAML (default: {DEFAULT_VM_YAML})')
+    parser.add_argument('--concurrency', '-c', type=int, default=DEFAULT_CONCURRENCY,
+                        help=f'Steps running at once (default: {DEFAULT_CONCURRENCY})')
+    parser.add_argument('--qps', type=float, default=DEFAULT_QPS,
+                        help='Max steps started per second on top of the plan timing, 0 disables '
+                             'rate limiting (default: 0)')
+    parser.add_argument('--burst', type=int, default=DEFAULT_BURST,
+                        help=f'Max steps started back-to-back when --qps is set (default: {DEFAULT_BURST})')
+    parser.add_argument('--poll-interval', type=float, default=DEFAULT_POLL_INTERVAL,
+                        help=f'Seconds between status checks (default: {DEFAULT_POLL_INTERVAL})')
+    parser.add_argument('--timeout', type=int, default=DEFAULT_TIMEOUT,
+                        help=f'Timeout per operation in seconds (default: {DEFAULT_TIMEOUT})')
+    parser.add_argument('--skip-namespace-creation', action='store_true',
+                        help='Use existing namespaces')
+    parser.add_argument('--cleanup', action='store_true',
+                        help='Delete the test namespaces afterwards')
+    parser.add_argument('--precision', type=int, default=timing.DEFAULT_PRECISION,
+                        help=f'Decimal places for durations in saved results (default: {timing.DEFAULT_PRECISION})')
+    parser.add_argument('--save-results', action='store_true', help='Save results as JSON and CSV')
+    parser.add_argument('--results-folder', default='results',
+                        help='Base directory for results (default: results)')
+    parser.add_argument('--storage-driver', default=None,
+                        help='Storage driver label to include in the results path, or auto to detect it')
+    parser.add_argument('--log-file', default=None, help='Log file path')
+    parser.add_argument('--log-level', default='INFO',
+                        choices=['DEBUG', 'INFO', 'WARNING', 'ERROR'], help='Logging level (default: INFO)')
+    args = parser.parse_args()
+
+    if args.replay and args.write_plan:
+        parser.error("--replay runs a saved plan; it cannot be combined with --write-plan")
+    if args.replay and args.seed is not None:
+        parser.error("--replay uses the seed of the saved plan; it cannot be combined with --seed")
+    try:
+        args.mix_weights = workloadgen.parse_weights(args.mix, workloadgen.OPERATIONS)
+        args.size_weights = workloadgen.parse_weights(args.sizes)
+    except ValueError as e:
+        parser.error(str(e))
+    if 'create' not in args.mix_weights:
+        parser.error("--mix must include create")
+    if args.operations < 1:
+        parser.error("--operations must be >= 1")
+    if args.rate <= 0:
+        parser.error("--rate must be > 0")
+    if args.max_vms < 1:
+        parser.error("--max-vms must be >= 1")
+    if args.time_scale < 0:
+        parser.error("--time-scale must be >= 0")
+    if args.concurrency < 1:
+        parser.error("--concurrency must be >= 1")
+    if args.qps < 0:
+        parser.error("--qps must be >= 0")
+    if args.burst < 1:
+        parser.error("--burst must be >= 1")
+    if args.poll_interval <= 0:
+        parser.error("--poll-interval must be > 0")
+    if not args.write_plan and not os.path.exists(args.vm_template):
+        parser.error(f"VM template file not found: {args.vm_template}")
+    return args
+
+
+def build_results_dir(args, seed: int, timestamp: Optional[str] = None) -> str:
+    """Build the canonical random-workload results directory."""
+    timestamp = timestamp or datetime.now().strftime("%Y%m%d-%H%M%S")
+    suffix = f"{args.namespace_prefix}_s{seed}"
+    if args.storage_driver:
+        return os.path.join(args.results_folder, args.storage_driver, 'random-workload', f"{timestamp}_{suffix}")
+    return os.path.join(args.results_folder, 'random-workload', f"{timestamp}_{suffix}")
+
+
+def render_sized_vm(vm_yaml: str, size: str) -> str:
+    """Return the template with the VM running and sized CORESxMEMORY (cores, guest memory and requests)."""
+    cores, memory = workloadgen.parse_size(size)
+    with open(vm_yaml) as f:
+        docs = [doc for doc in yaml.safe_load_all(f) if doc]
+    for doc in docs:
+        if doc.get('kind') != 'VirtualMachine':
+            continue
+        spec = doc.setdefault('spec', {})
+        spec.pop('running', None)
+        spec['runStrategy'] = 'Always'
+        domain = spec.setdefault('template', {}).setdefault('spec', {}).setdefault('domain', {})
+        domain.setdefault('cpu', {})['cores'] = cores
+        if 'guest' in (domain.get('memory') or {}):
+            domain['memory']['guest'] = memory
+        resources = domain.setdefault('resources', {})
+        resources.setdefault('requests', {}).update({'cpu': str(cores), 'memory': memory})
+        if resources.get('limits'):
+            resources['limits'].update({'cpu': str(cores), 'memory': memory})
+    return yaml.safe_dump_all(docs, sort_keys=False)
+
+
+def vmi_uid(vm_name: str, namespace: str) -> Optional[str]:
+    """UID of the VM's current VMI, None when it has none; a restart replaces the VMI."""
+    rc, stdout, _ = run_kubectl_command(['get', 'vmi', vm_name, '-n', namespace,
+                                         '-o', 'jsonpath={.metadata.uid}'], check=False)
+    if rc != 0:
+        return None
+    return stdout.strip() or None
+
+
+def wait_for_status(namespace: str, target: Optional[str], args, old_vmi: Optional[str] = None) -> bool:
+    """Wait until the VM reaches target (None: until it is gone), on a VMI other than old_vmi when given."""
+    deadline = time.monotonic() + args.timeout
+    while time.monotonic() < deadline:
+        status = get_vm_status(args.vm_name, namespace)
+        if target is None and status is None:
+            return True
+        if target is not None and status == target:
+            if old_vmi is None or vmi_uid(args.vm_name, namespace) not in (None, old_vmi):
+                return True
+        time.sleep(args.poll_interval)
+    return False
+
+
+def run_step(step: Dict, namespace: str, args, logger) -> Optional[str]:
+    """Run the operation of one plan step; returns an error message or None."""
+    op = step['operation']
+    vm = args.vm_name
+    if op == 'create':
+        manifest = stamp_manifest(render_sized_vm(args.vm_template, step['size']), namespace, logger)
+        returncode, _, stderr = _kubectl_with_input(['create', '-f', '-', '-n', namespace], manifest, logger)
+        if returncode != 0:
+            return f"create failed: {stderr.strip()}"
+        return None if wait_for_status(namespace, 'Running', args) else "VM did not reach Running"
+    if op == 'start':
+        if not start_vm(vm, namespace, logger):
+            return "failed to start the VM"
+        return None if wait_for_status(namespace, 'Running', args) else "VM did not reach Running"
+    if op == 'stop':
+        if not stop_vm(vm, namespace, logger):
+            return "failed to stop the VM"
+        return None if wait_for_status(namespace, 'Stopped', args) else "VM did not reach Stopped"
+    if op == 'restart':
+        old_vmi = vmi_uid(vm, namespace)
+        try:
+            result = subprocess.run(['virtctl', 'restart', 'vm', vm, '-n', namespace],
+                                    capture_output=True, text=True, timeout=60)
+        except (subprocess.TimeoutExpired, FileNotFoundError) as e:
+            return f"virtctl restart failed: {e}"
+        if result.returncode != 0:
+            return f"virtctl restart failed: {result.stderr.strip()}"
+        return None if wait_for_status(namespace, 'Running', args, old_vmi) else "no new VMI reached Running"
+    if op == 'migrate':
+        if not migrate_vm(vm, namespace, logger=logger):
+            return "failed to create the migration"
+        success, _, _, _ = wait_for_migration_complete(vm, namespace, timeout=args.timeout,
+                                                       poll_interval=args.poll_interval, logger=logger)
+        delete_vmim(f"migration-{vm}", namespace, logger)
+        return None if success else "migration did not complete"
+    if op == 'snapshot':
+        name = f"{vm}-step-{step['step']}"
+        if not create_vm_snapshot(vm, name, namespace, logger):
+            return "failed to create the snapshot"
+        ready = wait_for_snapshot_ready(name, namespace, timeout=args.timeout,
+                                        poll_interval=args.poll_interval, logger=logger)
+        delete_vm_snapshot(name, namespace, logger)
+        return None if ready else "snapshot did not become ready"
+    if op == 'resize':
+        pvcs = get_vm_volume_names(vm, namespace, logger)
+        if not pvcs:
+            return "VM has no PVC"
+        _, _, error = expand_pvc(pvcs[0], namespace, step['increment'], timeout=args.timeout,
+                                 poll_interval=args.poll_interval, logger=logger)
+        return error
+    if op == 'delete':
+        if not delete_vm(vm, namespace, logger):
+            return "failed to delete the VM"
+        if not wait_for_status(namespace, None, args):
+            return "VM was not deleted"
+        # The slot's next create reuses the names of the DataVolumes, so wait until they are gone too
+        deadline = time.monotonic() + args.timeout
+        while time.monotonic() < deadline:
+            rc, stdout, _ = run_kubectl_command(['get', 'dv,pvc', '-n', namespace, '-o', 'name'], check=False)
+            if rc == 0 and not stdout.strip():
+                return None
+            time.sleep(args.poll_interval)
+        return "volumes of the VM were not deleted"
+    return f"unknown operation {op}"
+
+
+def timed_step(step: Dict, namespace: str, args, test_start: timing.MonotonicTimestamp, logger) -> Dict:
+    """Run one plan step and time it."""
+    started = timing.now()
+    planned_sec = step['at_sec'] * args.time_scale
+    row = {'step': step['step'], 'operation': step['operation'], 'namespace': namespace,
+           'size': step.get('size'), 'planned_sec': round_duration(planned_sec),
+           'started_sec': round_duration((started - test_start).total_seconds()),
+           'delay_sec': round_duration(max(0.0, (started - test_start).total_seconds() - planned_sec)),
+           'total_sec': None, 'success': False, 'skipped': False, 'error': None}
+    try:
+        error = run_step(step, namespace, args, logger)
+    except Exception as e:
+        error = str(e)
+    if error is None:
+        row['total_sec'] = (timing.now() - started).total_seconds()
+        row['success'] = True
+        logger.info(f"[{namespace}] step {step['step']} {step['operation']} took {row['total_sec']:.2f}s")
+    else:
+        row['error'] = error
+        logger.error(f"[{namespace}] step {step['step']} {step['operation']}: {error}")
+    return row
+
+
+def execute_plan(plan: Dict, args, test_start: timing.MonotonicTimestamp, logger) -> List[Dict]:
+    """
+    Run the steps of a plan in order.
+
+    A step starts at its planned time (times --time-scale) once no earlier step
+    of its VM is pending or running. After a failed step, the VM's steps are
+    skipped until a delete, which is still attempted to reset the slot.
+    """
+    pending = list(plan['steps'])
+    inflight = {}
+    broken = set()
+    rows = []
+    start = time.monotonic()
+
+    def namespace_of(step):
+        return f"{args.namespace_prefix}-{step['slot']}"
+
+    with WorkerPool(args.concurrency, args.qps, args.burst) as executor:
+        while pending or inflight:
+            elapsed = time.monotonic() - start
+            blocked = {step['slot'] for step in inflight.values()}
+            for step in list(pending):
+                if step['slot'] in blocked:
+                    continue
+                blocked.add(step['slot'])   # later steps of the slot wait for this one
+                if step['at_sec'] * args.time_scale > elapsed or len(inflight) >= args.concurrency:
+                    continue
+                pending.remove(step)
+                if step['slot'] in broken and step['operation'] != 'delete':
+                    rows.append({'step': step['step'], 'operation': step['operation'],
+                                 'namespace': namespace_of(step), 'size': step.get('size'),
+                                 'planned_sec': round_duration(step['at_sec'] * args.time_scale),
+                                 'started_sec': None, 'delay_sec': None, 'total_sec': None,
+                                 'success': False, 'skipped': True, 'error': 'skipped: an earlier step failed'})
+                    blocked.discard(step['slot'])
+                    continue
+                future = executor.submit(timed_step, step, namespace_of(step), args, test_start, logger)
+                inflight[future] = step
+            if inflight:
+                done, _ = wait(list(inflight), timeout=0.5, return_when=FIRST_COMPLETED)
+                for future in done:
+                    step = inflight.pop(future)
+                    row = future.result()
+                    if row['success'] and step['operation'] == 'delete':
+                        broken.discard(step['slot'])
+                    elif not row['success']:
+                        broken.add(step['slot'])
+                    rows.append(row)
+            elif pending:
+                next_at = min(s['at_sec'] for s in pending) * args.time_scale
+                time.sleep(min(1.0, max(0.0, next_at - (time.monotonic() - start))))
+    return sorted(rows, key=lambda r: r['step'])
+
+
+def operation_stats(rows: List[Dict]) -> List[Dict]:
+    stats = []
+    for op in workloadgen.OPERATIONS:
+        op_rows = [r for r in rows if r['operation'] == op]
+        if not op_rows:
+            continue
+        stats.append({**metric_stats(f"{op}_sec", [r['total_sec'] for r in op_rows if r['success']]),
+                      'failed': sum(1 for r in op_rows if not r['success'] and not r['skipped']),
+                      'skipped': sum(1 for r in op_rows if r['skipped'])})
+    return stats
+
+
+def step_outliers(rows: List[Dict]) -> List[Dict]:
+    """Steps more than --outlier-sigma standard deviations slower than the mean of their operation."""
+    outliers = []
+    for op in workloadgen.OPERATIONS:
+        op_rows = [r for r in rows if r['operation'] == op and r['success']]
+        found = metric_outliers(op_rows, ['total_sec'], lambda r: f"{r['namespace']} #{r['step']}")
+        outliers += [{**o, 'metric': f"{op}_sec"} for o in found]
+    return outliers
+
+
+def print_summary(plan: Dict, rows: List[Dict], stats: List[Dict], total_time: float, logger) -> None:
+    def fmt(value):
+        return f"{value:.2f}" if value is not None else '-'
+
+    logger.info("")
+    logger.info("=" * 90)
+    logger.info(f"RANDOM WORKLOAD RESULTS (seed {plan['seed']}, seconds)")
+    logger.info("=" * 90)
+    logger.info(f"{'Operation':<12}{'Avg':>10}{'Median':>10}{'P95':>10}{'Max':>10}{'Count':>8}{'Failed':>8}{'Skipped':>9}")
+    logger.info("-" * 90)
+    for s in stats:
+        logger.info(f"{s['metric'][:-4]:<12}{fmt(s['avg']):>10}{fmt(s['median']):>10}{fmt(s['p95']):>10}"
+                    f"{fmt(s['max']):>10}{s['count']:>8}{s['failed']:>8}{s['skipped']:>9}")
+    logger.info("=" * 90)
+    delays = [r['delay_sec'] for r in rows if r['delay_sec'] is not None]
+    if delays:
+        logger.info(f"  Max start delay:        {max(delays):.2f}s")
+    logger.info(f"  Total test duration:    {total_time:.2f}s")
+    logger.info(f"  Replay with:            --replay <results dir> (or --seed {plan['seed']} and the same options)")
+
+
+def save_random_results(out_dir: str, args, plan: Dict, rows: List[Dict], stats: List[Dict],
+                        total_time: float, timing_block: Dict, logger) -> None:
+    """Write the plan, every step and the summary as JSON and CSV."""
+    os.makedirs(out_dir, exist_ok=True)
+    workloadgen.write_plan(plan, out_dir)
+    rows = [dict(r, total_sec=round_duration(r['total_sec'])) for r in rows]
+    with open(os.path.join(out_dir, 'random_workload_results.json'), 'w') as f:
+        json.dump(rows, f, indent=4)
+    with open(os.path.join(out_dir, 'random_workload_results.csv'), 'w', newline='') as f:
+        writer = csv.DictWriter(f, fieldnames=rows[0].keys())
+        writer.writeheader()
+        writer.writerows(rows)
+
+    delays = [r['delay_sec'] for r in rows if r['delay_sec'] is not None]
+    summary = {
+        'seed': plan['seed'],
+        'generator_version': plan['generator_version'],
+        'replayed_from': args.replay,
+        'parameters': plan['parameters'],
+        'plan': workloadgen.plan_overview(plan),
+        'time_scale': args.time_scale,
+        'total_steps': len(rows),
+        'successful': sum(1 for r in rows if r['success']),
+        'failed': sum(1 for r in rows if not r['success'] and not r['skipped']),
+        'skipped': sum(1 for r in rows if r['skipped']),
+        'max_delay_sec': max(delays) if delays else None,
+        'total_test_duration_sec': round_duration(total_time),
+        'metrics': stats,
+        'outliers': step_outliers(rows),
+        'timing': timing_block,
+    }
+    custom_metrics = collect_custom_metrics(timing_block, logger)
+    if custom_metrics is not None:
+        summary['custom_metrics'] = custom_metrics
+    run_events = collect_run_events(out_dir, logger, summary.get('outliers'))
+    if run_events is not None:
+        summary['events'] = run_events
+    node_utilization = collect_node_samples(out_dir, logger)
+    if node_utilization is not None:
+        summary['node_utilization'] = node_utilization
+    storage_backend = collect_storage_backend(out_dir, args.namespace_prefix, logger)
+    if storage_backend is not None:
+        summary['storage_backend'] = storage_backend
+    summary['cluster'] = cluster_inventory(logger)
+    summary['run'] = run_metadata()
+    with open(os.path.join(out_dir, 'summary_random_workload_results.json'), 'w') as f:
+        json.dump(summary, f, indent=4)
+    with open(os.path.join(out_dir, 'summary_random_workload_results.csv'), 'w', newline='') as f:
+        writer = csv.DictWriter(f, fieldnames=stats[0].keys())
+        writer.writeheader()
+        writer.writerows(stats)
+    logger.info(f"Results saved under: {out_dir}")
+
+
+def plan_run(args, plan: Dict, namespaces: List[str], logger):
+    """Print what main() would create and run (virtbench --dry-run)."""
+    dry = DryRunPlan('random-workload', logger)
+    if not args.skip_namespace_creation:
+        dry.create_namespaces(namespaces)
+    overview = workloadgen.plan_overview(plan)
+    ops = ', '.join(f"{op}={n}" for op, n in overview['operations'].items())
+    dry.action('run plan', f"seed {plan['seed']}",
+               f"{overview['steps']} steps ({ops}) over {overview['span_sec'] * args.time_scale:.0f}s")
+    if args.cleanup:
+        dry.delete_namespaces(namespaces)
+    dry.report()
+
+
+def main():
+    args = parse_args()
+    set_run_workload('random-workload')
+    args.storage_driver = resolve_storage_driver(args.storage_driver)
+    dry_run = is_dry_run()
+    if dry_run or args.write_plan:
+        # A dry run or plan-only run has no results to save
+        args.save_results = False
+
+    if args.replay:
+        try:
+            plan = workloadgen.load_plan(args.replay)
+        except ValueError as e:
+            print(f"Error: {e}", file=sys.stderr)
+            sys.exit(1)
+    else:
+        seed = args.seed if args.seed is not None else workloadgen.new_seed()
+        plan = workloadgen.generate_plan(seed, operations=args.operations, rate=args.rate,
+                                         max_vms=args.max_vms, mix=args.mix_weights, sizes=args.size_weights,
+                                         max_resizes=args.max_resizes_per_vm,
+                                         resize_increment=args.resize_increment)
+
+    out_dir = None
+    if args.save_results:
+        out_dir = build_results_dir(args, plan['seed'])
+        os.makedirs(out_dir, exist_ok=True)
+        if not args.log_file:
+            args.log_file = os.path.join(out_dir, 'random-workload.log')
+
+    logger = setup_logging(args.log_file, args.log_level)
+    overview = workloadgen.plan_overview(plan)
+
+    logger.info("=" * 80)
+    logger.info("KubeVirt Randomized Workload")
+    logger.info("=" * 80)
+    logger.info(f"Seed: {plan['seed']}" + (f" (replaying {args.replay})" if args.replay else ""))
+    logger.info(f"Plan: {overview['steps']} steps over {overview['span_sec']:.0f}s, up to {overview['slots']} VMs")
+    logger.info(f"Operations: {', '.join(f'{op}={n}' for op, n in overview['operations'].items())}")
+    logger.info(f"Sizes: {', '.join(f'{size}={n}' for size, n in overview['sizes'].items())}")
+    logger.info("=" * 80)
+
+    if args.write_plan:
+        workloadgen.write_plan(plan, args.write_plan)
+        logger.info(f"Plan written to {args.write_plan}")
+        sys.exit(0)
+
+    namespaces = [f"{args.namespace_prefix}-{slot}" for slot in range(1, overview['slots'] + 1)]
+    if dry_run:
+        plan_run(args, plan, namespaces, logger)
+        sys.exit(0)
+
+    watch_run('random-workload', logger)
+    watch_events(args.namespace_prefix, logger)
+    sample_nodes(logger)
+    timing.set_precision(args.precision)
+
+    if not args.skip_namespace_creation:
+        created = create_namespaces_parallel(namespaces, args.concurrency, logger)
+        if len(created) != len(namespaces):
+            logger.error(f"Failed to create {len(namespaces) - len(created)} namespaces")
+            sys.exit(1)
+
+    clock_skew = timing.estimate_clock_skew(logger=logger) if args.save_results else None
+    if args.save_results:
+        cluster_inventory(logger)
+    test_start = timing.now()
+    failed = 0
+    try:
+        rows = execute_plan(plan, args, test_start, logger)
+        total_time = (timing.now() - test_start).total_seconds()
+        stats = operation_stats(rows)
+        print_summary(plan, rows, stats, total_time, logger)
+        log_outliers(step_outliers(rows), logger)
+        if args.save_results:
+            save_random_results(out_dir, args, plan, rows, stats, total_time,
+                                timing.timing_metadata(test_start, clock_skew=clock_skew), logger)
+
+        failed = sum(1 for r in rows if not r['success'])
+        run_metrics = {'seed': plan['seed'], 'steps': len(rows), 'failed': failed}
+        for s in stats:
+            if s['count']:
+                run_metrics.update({f"p50_{s['metric']}": s['median'], f"p95_{s['metric']}": s['p95']})
+        notify_run('random-workload', run_metrics, phase_status(failed, len(rows)), out_dir, logger)
+    finally:
+        if args.cleanup:
+            cleanup_stats = cleanup_test_namespaces(
+                namespace_prefix=args.namespace_prefix, start=1, end=len(namespaces),
+                vm_name=args.vm_name, delete_namespaces=True, batch_size=args.concurrency,
+                logger=logger, selector=run_selector()
+            )
+            print_cleanup_summary(cleanup_stats, logger)
+
+    sys.exit(0 if not failed else 2)
+
+
+if __name__ == '__main__':
+    main()
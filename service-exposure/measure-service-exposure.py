@@ -0,0 +1,560 @@
+#!/usr/bin/env python3
+"""
+KubeVirt Service Exposure Benchmark
+
+Applications reach VMs through Services and cluster DNS, not through the VMI
+address. This benchmark exposes running VMs with a ClusterIP and/or a
+headless Service and measures, per VM and Service, from Service creation:
+
+  - endpoints time:   until an EndpointSlice lists the virt-launcher pod as ready
+  - DNS time:         until the Service name resolves from inside the cluster
+  - connect time:     until a TCP connection through the Service name succeeds
+
+With --migrate every VM is then live migrated, and the same Services are
+measured again:
+
+  - endpoints time:   migration start until the EndpointSlice points at the
+                      new virt-launcher pod only
+  - DNS time:         migration start until the headless Service name no
+                      longer resolves to the old pod address
+  - connect time:     migration completion until a connection through the
+                      Service succeeds again
+
+DNS and TCP checks run continuously from the managed reachability checker
+pod (utils.reachability), so their times have millisecond resolution. The
+VMs must already be running (for example created with datasource-clone).
+
+Usage:
+    python3 measure-service-exposure.py --start 1 --end 20 --vm-name rhel-9-vm \\
+        --service-type both --migrate --save-results
+
+Author: KubeVirt Benchmark Suite Contributors
+License: Apache 2.0
+"""
+
+import argparse
+import csv
+import json
+import os
+import sys
+import threading
+import time
+from datetime import datetime
+from typing import Callable, Dict, List, Optional, Set
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
+
+from utils import timing
+from utils.apply import apply_manifest
+from utils.concurrency import run_parallel, DEFAULT_QPS, DEFAULT_BURST
+from utils.common import (
+    setup_logging, run_kubectl_command, get_vm_status, migrate_vm, wait_for_migration_complete,
+    delete_vmim, stamp_manifest, round_duration, set_run_workload,
+    run_metadata,
+)
+from utils.custommetrics import collect_custom_metrics
+from utils.events import collect_run_events, watch_events
+from utils.inventory import cluster_inventory
+from utils.dryrun import DryRunPlan, is_dry_run
+from utils.notify import notify_run, watch_run, phase_status, percentile_metrics
+from utils.reachability import (
+    start_checker, stop_checker, CHECKER_POD, PROBE_DNS, PROBE_DNS_NOT, Check,
+)
+from utils.stats import STAT_FIELDS, log_outliers, metric_outliers, metric_stats
+from utils.utilization import collect_node_samples, sample_nodes
+
+# Default configuration
+DEFAULT_VM_NAME = 'rhel-9-vm'
+DEFAULT_NAMESPACE_PREFIX = 'kubevirt-perf-test'
+DEFAULT_PORT = 22
+DEFAULT_CONCURRENCY = 10
+DEFAULT_POLL_INTERVAL = 1
+DEFAULT_TIMEOUT = 300
+DEFAULT_MIGRATION_TIMEOUT = 600
+DEFAULT_CHECKER_NS = 'default'
+DEFAULT_CLUSTER_DOMAIN = 'cluster.local'
+
+SERVICE_CLUSTER_IP = 'clusterip'
+SERVICE_HEADLESS = 'headless'
+SERVICE_TYPE_CHOICES = [SERVICE_CLUSTER_IP, SERVICE_HEADLESS, 'both']
+PHASE_EXPOSE = 'expose'
+PHASE_MIGRATION = 'migration'
+# KubeVirt copies this VM label to the VMI and its virt-launcher pod
+VM_NAME_LABEL = 'vm.kubevirt.io/name'
+
+EXPOSE_METRICS = ['endpoints_sec', 'dns_sec', 'connect_sec']
+METRICS = EXPOSE_METRICS + ['migration_sec']
+
+
+def parse_args():
+    parser = argparse.ArgumentParser(
+        description='Measure Service, DNS and connectivity readiness of KubeVirt VMs, '
+                    'after exposure and after live migration',
+        formatter_class=argparse.RawDescriptionHelpFormatter,
+    )
+    parser.add_argument('--start', type=int, default=1, help='Starting namespace index (default: 1)')
+    parser.add_argument('--end', type=int, default=10, help='Ending namespace index (default: 10)')
+    parser.add_argument('--namespace-prefix', default=DEFAULT_NAMESPACE_PREFIX,
+                        help=f'Namespace prefix (default: {DEFAULT_NAMESPACE_PREFIX})')
+    parser.add_argument('--vm-name', default=DEFAULT_VM_NAME,
+                        help=f'Name of the running VM in each namespace (default: {DEFAULT_VM_NAME})')
+    parser.add_argument('--service-type', choices=SERVICE_TYPE_CHOICES, default='both',
+                        help='Services to create per VM (default: both)')
+    parser.add_argument('--port', type=int, default=DEFAULT_PORT,
+                        help=f'Guest TCP port exposed and connected to (default: {DEFAULT_PORT}, SSH)')
+    parser.add_argument('--migrate', action='store_true',
+                        help='Live migrate every VM after exposing it and measure the Services again')
+    parser.add_argument('--concurrency', '-c', type=int, default=DEFAULT_CONCURRENCY,
+                        help=f'VMs processed concurrently (default: {DEFAULT_CONCURRENCY})')
+    parser.add_argument('--qps', type=float, default=DEFAULT_QPS,
+                        help='Max VMs started per second (default: 0 = unlimited)')
+    parser.add_argument('--burst', type=int, default=DEFAULT_BURST,
+                        help=f'Max VMs started back-to-back when --qps is set (default: {DEFAULT_BURST})')
+    parser.add_argument('--poll-interval', type=int, default=DEFAULT_POLL_INTERVAL,
+                        help=f'Seconds between EndpointSlice checks (default: {DEFAULT_POLL_INTERVAL})')
+    parser.add_argument('--timeout', type=int, default=DEFAULT_TIMEOUT,
+                        help=f'Timeout for endpoints, DNS and connectivity in seconds (default: {DEFAULT_TIMEOUT})')
+    parser.add_argument('--migration-timeout', type=int, default=DEFAULT_MIGRATION_TIMEOUT,
+                        help=f'Timeout for each migration in seconds (default: {DEFAULT_MIGRATION_TIMEOUT})')
+    parser.add_argument('--checker-ns', default=DEFAULT_CHECKER_NS,
+                        help=f'Namespace of the reachability checker pod (default: {DEFAULT_CHECKER_NS})')
+    parser.add_argument('--cluster-domain', default=DEFAULT_CLUSTER_DOMAIN,
+                        help=f'Cluster DNS domain (default: {DEFAULT_CLUSTER_DOMAIN})')
+    parser.add_argument('--keep-services', action='store_true',
+                        help='Do not delete the Services at the end of the run')
+    parser.add_argument('--precision', type=int, default=timing.DEFAULT_PRECISION,
+                        help=f'Decimal places for durations in saved results (default: {timing.DEFAULT_PRECISION})')
+    parser.add_argument('--save-results', action='store_true', help='Save results as JSON and CSV')
+    parser.add_argument('--results-folder', default='results',
+                        help='Base directory for results (default: results)')
+    parser.add_argument('--log-file', default=None, help='Log file path')
+    parser.add_argument('--log-level', default='INFO',
+                        choices=['DEBUG', 'INFO', 'WARNING', 'ERROR'], help='Logging level (default: INFO)')
+    return parser.parse_args()
+
+
+def build_results_dir(args, timestamp: Optional[str] = None) -> str:
+    """Build the canonical service-exposure results directory."""
+    timestamp = timestamp or datetime.now().strftime("%Y%m%d-%H%M%S")
+    suffix = f"{args.namespace_prefix}_{args.start}-{args.end}"
+    return os.path.join(args.results_folder, 'service-exposure', f"{timestamp}_{suffix}")
+
+
+def service_types(args) -> List[str]:
+    if args.service_type == 'both':
+        return [SERVICE_CLUSTER_IP, SERVICE_HEADLESS]
+    return [args.service_type]
+
+
+def service_name(vm_name: str, service_type: str) -> str:
+    return f"{vm_name}-{'headless' if service_type == SERVICE_HEADLESS else 'svc'}"
+
+
+def service_manifest(name: str, namespace: str, vm_name: str, port: int, service_type: str) -> str:
+    """Service selecting the virt-launcher pod of a VM."""
+    cluster_ip = "  clusterIP: None\n" if service_type == SERVICE_HEADLESS else ""
+    return f"""apiVersion: v1
+kind: Service
+metadata:
+  name: {name}
+  namespace: {namespace}
+spec:
+{cluster_ip}  selector:
+    {VM_NAME_LABEL}: {vm_name}
+  ports:
+  - name: probe
+    protocol: TCP
+    port: {port}
+    targetPort: {port}
+"""
+
+
+def ready_endpoints(service: str, namespace: str, logger) -> Optional[Set[str]]:
+    """Ready addresses of a Service's EndpointSlices, or None if they cannot be read."""
+    rc, out, _ = run_kubectl_command(
+        ['get', 'endpointslices', '-n', namespace, '-l', f'kubernetes.io/service-name={service}', '-o', 'json'],
+        check=False, logger=logger
+    )
+    if rc != 0:
+        return None
+    addresses = set()
+    for item in json.loads(out).get('items', []):
+        for endpoint in item.get('endpoints') or []:
+            if (endpoint.get('conditions') or {}).get('ready', True):
+                addresses.update(endpoint.get('addresses') or [])
+    return addresses
+
+
+def wait_for_endpoints(service: str, namespace: str, accept: Callable[[Set[str]], bool],
+                       since: timing.MonotonicTimestamp, timeout: int, poll_interval: int,
+                       logger) -> Optional[float]:
+    """Seconds from since until the ready endpoints are accepted, or None on timeout."""
+    while (timing.now() - since).total_seconds() < timeout:
+        addresses = ready_endpoints(service, namespace, logger)
+        if addresses is not None and accept(addresses):
+            return (timing.now() - since).total_seconds()
+        time.sleep(poll_interval)
+    return None
+
+
+def launcher_pod_ip(vm_name: str, namespace: str, logger) -> Optional[str]:
+    """Address of the VM's newest running virt-launcher pod."""
+    rc, out, _ = run_kubectl_command(
+        ['get', 'pods', '-n', namespace, '-l', f'{VM_NAME_LABEL}={vm_name}',
+         '--field-selector', 'status.phase=Running', '-o', 'json'],
+        check=False, logger=logger
+    )
+    if rc != 0:
+        return None
+    pods = sorted(json.loads(out).get('items', []),
+                  key=lambda p: p['metadata'].get('creationTimestamp', ''))
+    return pods[-1].get('status', {}).get('podIP') if pods else None
+
+
+def check_seconds(check: Check, since: timing.MonotonicTimestamp, timeout: int) -> Optional[float]:
+    """Seconds from since until a checker pod check first succeeded, or None."""
+    if not check.wait(timeout + 30) or check.responded_at is None:
+        return None
+    return max(0.0, (check.responded_at - since).total_seconds())
+
+
+def new_row(namespace: str, vm_name: str, service: Optional[str], service_type: Optional[str],
+            phase: str) -> Dict:
+    return {'namespace': namespace, 'vm_name': vm_name, 'service': service, 'service_type': service_type,
+            'phase': phase, 'endpoints_sec': None, 'dns_sec': None, 'connect_sec': None,
+            'migration_sec': None, 'success': False, 'error': None}
+
+
+def finish_row(row: Dict, logger) -> Dict:
+    missing = [m.replace('_sec', '') for m in ('endpoints_sec', 'dns_sec', 'connect_sec')
+               if row[m] is None and not (m == 'dns_sec' and row['phase'] == PHASE_MIGRATION
+                                          and row['service_type'] == SERVICE_CLUSTER_IP)]
+    if missing and not row['error']:
+        row['error'] = f"timed out: {', '.join(missing)}"
+    row['success'] = row['error'] is None
+
+    def fmt(value):
+        return f"{value:.3f}s" if value is not None else '-'
+
+    logger.info(f"[{row['namespace']}] {row['phase']} {row['service']}: endpoints {fmt(row['endpoints_sec'])}, "
+                f"DNS {fmt(row['dns_sec'])}, connect {fmt(row['connect_sec'])}"
+                f"{'' if row['success'] else ' (' + row['error'] + ')'}")
+    return row
+
+
+def expose_vm(namespace: str, args, checker, logger) -> List[Dict]:
+    """
+    Expose one VM with its Services and measure them, then again after a migration with --migrate.
+
+    Returns:
+        One result dict per Service and phase
+    """
+    vm_name = args.vm_name
+    if get_vm_status(vm_name, namespace, logger) != 'Running':
+        logger.error(f"[{namespace}] VM {vm_name} is not running, skipping")
+        row = new_row(namespace, vm_name, None, None, PHASE_EXPOSE)
+        row['error'] = 'VM not running'
+        return [row]
+
+    rows = []
+    for service_type in service_types(args):
+        service = service_name(vm_name, service_type)
+        row = new_row(namespace, vm_name, service, service_type, PHASE_EXPOSE)
+        rows.append(row)
+        manifest = service_manifest(service, namespace, vm_name, args.port, service_type)
+        applied, error = apply_manifest(stamp_manifest(manifest), logger=logger)
+        if not applied:
+            row['error'] = f"Service not created: {error}"
+            finish_row(row, logger)
+            continue
+        created = timing.now()
+        fqdn = f"{service}.{namespace}.svc.{args.cluster_domain}"
+        dns = checker.submit_probes(fqdn, [PROBE_DNS], args.timeout)
+        connect = checker.submit_probes(fqdn, [str(args.port)], args.timeout)
+        row['endpoints_sec'] = wait_for_endpoints(service, namespace, bool, created, args.timeout,
+                                                  args.poll_interval, logger)
+        row['dns_sec'] = check_seconds(dns, created, args.timeout)
+        row['connect_sec'] = check_seconds(connect, created, args.timeout)
+        finish_row(row, logger)
+
+    if args.migrate and all(r['success'] for r in rows):
+        rows.extend(migrate_and_measure(namespace, args, checker, logger))
+    return rows
+
+
+def migrate_and_measure(namespace: str, args, checker, logger) -> List[Dict]:
+    """Live migrate a VM while watching its Services follow the new virt-launcher pod."""
+    vm_name = args.vm_name
+    rows = [new_row(namespace, vm_name, service_name(vm_name, t), t, PHASE_MIGRATION)
+            for t in service_types(args)]
+    old_ip = launcher_pod_ip(vm_name, namespace, logger)
+    if old_ip is None:
+        for row in rows:
+            row['error'] = 'virt-launcher pod not found'
+            finish_row(row, logger)
+        return rows
+
+    started = timing.now()
+    watchers = []
+    for row in rows:
+        fqdn = f"{row['service']}.{namespace}.svc.{args.cluster_domain}"
+        if row['service_type'] == SERVICE_HEADLESS:
+            row['_dns'] = checker.submit_probes(fqdn, [PROBE_DNS_NOT + old_ip], args.migration_timeout + args.timeout)
+
+        def watch(row=row):
+            row['endpoints_sec'] = wait_for_endpoints(
+                row['service'], namespace, lambda addresses: bool(addresses) and old_ip not in addresses,
+                started, args.migration_timeout + args.timeout, args.poll_interval, logger
+            )
+        watcher = threading.Thread(target=watch, daemon=True)
+        watcher.start()
+        watchers.append(watcher)
+
+    migrated = migrate_vm(vm_name, namespace, logger=logger)
+    success, observed, _, vmim_duration = (
+        wait_for_migration_complete(vm_name, namespace, timeout=args.migration_timeout,
+                                    poll_interval=args.poll_interval, logger=logger)
+        if migrated else (False, None, None, None)
+    )
+    completed = timing.now()
+    delete_vmim(f"migration-{vm_name}", namespace, logger)
+
+    if success:
+        for row in rows:
+            fqdn = f"{row['service']}.{namespace}.svc.{args.cluster_domain}"
+            row['_connect'] = checker.submit_probes(fqdn, [str(args.port)], args.timeout)
+    for watcher in watchers:
+        watcher.join()
+    for row in rows:
+        dns = row.pop('_dns', None)
+        connect = row.pop('_connect', None)
+        if not success:
+            row['error'] = 'migration not triggered' if not migrated else 'migration failed'
+        else:
+            row['migration_sec'] = vmim_duration if vmim_duration is not None else observed
+            row['dns_sec'] = check_seconds(dns, started, args.timeout) if dns else None
+            row['connect_sec'] = check_seconds(connect, completed, args.timeout)
+        finish_row(row, logger)
+    return rows
+
+
+def delete_services(namespaces: List[str], args, logger) -> None:
+    names = [service_name(args.vm_name, t) for t in service_types(args)]
+    for namespace in namespaces:
+        run_kubectl_command(['delete', 'service', *names, '-n', namespace, '--ignore-not-found', '--wait=false'],
+                            check=False, logger=logger)
+
+
+def phase_metrics(rows: List[Dict]) -> List[Dict]:
+    """Statistics of the exposure metrics, then (with --migrate) of the migration_-prefixed ones."""
+    def values(phase, metric):
+        return [r[metric] for r in rows if r['phase'] == phase and r[metric] is not None]
+
+    metrics = [metric_stats(m, values(PHASE_EXPOSE, m)) for m in EXPOSE_METRICS]
+    if any(r['phase'] == PHASE_MIGRATION for r in rows):
+        metrics.append(metric_stats('migration_sec', values(PHASE_MIGRATION, 'migration_sec')))
+        metrics += [metric_stats(f"migration_{m}", values(PHASE_MIGRATION, m)) for m in EXPOSE_METRICS]
+    return metrics
+
+
+def summarize(results: List[Dict]) -> Dict:
+    """Per-metric statistics overall and per Service type, and the outlier Services."""
+    by_type = {}
+    for r in results:
+        if r['service']:
+            by_type.setdefault(r['service_type'], []).append(r)
+    return {
+        'metrics': phase_metrics(results),
+        'per_service_type': {t: phase_metrics(rows) for t, rows in sorted(by_type.items())},
+        'outliers': metric_outliers([r for r in results if r['success']], METRICS,
+                                    lambda r: f"{r['namespace']}/{r['service']} ({r['phase']})"),
+    }
+
+
+def print_summary(results: List[Dict], stats: Dict, total_time: float, logger) -> None:
+    def fmt(value):
+        return f"{value:.3f}" if value is not None else '-'
+
+    logger.info("")
+    logger.info("=" * 118)
+    logger.info(f"{'Namespace':<26}{'Service':<24}{'Phase':<11}{'Endpoints(s)':<14}{'DNS(s)':<10}"
+                f"{'Connect(s)':<12}{'Migration(s)':<14}{'Status':<16}")
+    logger.info("-" * 118)
+    for r in sorted(results, key=lambda x: (x['namespace'], x['phase'] != PHASE_EXPOSE, x['service'] or '')):
+        status = 'Success' if r['success'] else (r['error'] or 'Failed')
+        logger.info(f"{r['namespace']:<26}{(r['service'] or '-'):<24}{r['phase']:<11}"
+                    f"{fmt(r['endpoints_sec']):<14}{fmt(r['dns_sec']):<10}{fmt(r['connect_sec']):<12}"
+                    f"{fmt(r['migration_sec']):<14}{status:<16}")
+    logger.info("=" * 118)
+
+    successful = sum(1 for r in results if r['success'])
+    logger.info(f"  Services measured:      {len(results)}")
+    logger.info(f"  Successful:             {successful}")
+    logger.info(f"  Failed:                 {len(results) - successful}")
+    for service_type, metrics in stats['per_service_type'].items():
+        logger.info(f"  {service_type} Services:")
+        for m in metrics:
+            if m['count']:
+                logger.info(f"    {m['metric'] + ':':<26}avg {m['avg']}s, min {m['min']}s, "
+                            f"max {m['max']}s, p95 {m['p95']}s ({m['count']})")
+    logger.info(f"  Total test duration:    {total_time:.2f}s")
+    logger.info("=" * 118)
+    log_outliers(stats['outliers'], logger)
+
+
+def save_service_results(out_dir: str, args, results: List[Dict], stats: Dict,
+                         total_time: float, timing_block: Dict, logger) -> None:
+    """Write per-Service results and the summary as JSON and CSV."""
+    os.makedirs(out_dir, exist_ok=True)
+    rows = []
+    for r in sorted(results, key=lambda x: (x['namespace'], x['phase'] != PHASE_EXPOSE, x['service'] or '')):
+        row = dict(r)
+        for m in METRICS:
+            row[m] = round_duration(row[m])
+        rows.append(row)
+
+    with open(os.path.join(out_dir, 'service_exposure_results.json'), 'w') as f:
+        json.dump(rows, f, indent=4)
+    with open(os.path.join(out_dir, 'service_exposure_results.csv'), 'w', newline='') as f:
+        writer = csv.DictWriter(f, fieldnames=rows[0].keys())
+        writer.writeheader()
+        writer.writerows(rows)
+
+    summary = {
+        'total_services': len(results),
+        'successful': sum(1 for r in results if r['success']),
+        'failed': sum(1 for r in results if not r['success']),
+        'vms': len({r['namespace'] for r in results}),
+        'service_types': service_types(args),
+        'port': args.port,
+        'migrate': args.migrate,
+        'concurrency': args.concurrency,
+        'total_test_duration_sec': round_duration(total_time),
+        'metrics': stats['metrics'],
+        'per_service_type': stats['per_service_type'],
+        'outliers': stats['outliers'],
+        'timing': timing_block,
+    }
+    custom_metrics = collect_custom_metrics(timing_block, logger)
+    if custom_metrics is not None:
+        summary['custom_metrics'] = custom_metrics
+    run_events = collect_run_events(out_dir, logger, summary.get('outliers'))
+    if run_events is not None:
+        summary['events'] = run_events
+    node_utilization = collect_node_samples(out_dir, logger)
+    if node_utilization is not None:
+        summary['node_utilization'] = node_utilization
+    summary['cluster'] = cluster_inventory(logger)
+    summary['run'] = run_metadata()
+    with open(os.path.join(out_dir, 'summary_service_exposure_results.json'), 'w') as f:
+        json.dump(summary, f, indent=4)
+    with open(os.path.join(out_dir, 'summary_service_exposure_results.csv'), 'w', newline='') as f:
+        writer = csv.DictWriter(f, fieldnames=['service_type', 'metric'] + STAT_FIELDS)
+        writer.writeheader()
+        for m in stats['metrics']:
+            writer.writerow({'service_type': 'all', **m})
+        for service_type, metrics in stats['per_service_type'].items():
+            for m in metrics:
+                writer.writerow({'service_type': service_type, **m})
+    logger.info(f"Results saved under: {out_dir}")
+
+
+def plan_run(namespaces: List[str], args, logger):
+    """Print the Services and migrations main() would create (virtbench --dry-run)."""
+    plan = DryRunPlan('service-exposure', logger)
+    plan.action('create', f"pod/{args.checker_ns}/{CHECKER_POD}", 'reachability checker, if missing')
+    for namespace in namespaces:
+        for service_type in service_types(args):
+            plan.apply(service_manifest(service_name(args.vm_name, service_type), namespace, args.vm_name,
+                                        args.port, service_type), namespace)
+        if args.migrate:
+            plan.action('migrate', f"vm/{namespace}/{args.vm_name}", 'live migration')
+        if not args.keep_services:
+            for service_type in service_types(args):
+                plan.action('delete', f"service/{namespace}/{service_name(args.vm_name, service_type)}",
+                            'at the end of the run')
+    plan.report()
+
+
+def main():
+    args = parse_args()
+    set_run_workload('service-exposure')
+    dry_run = is_dry_run()
+    if dry_run:
+        # A dry run has no results to save
+        args.save_results = False
+
+    out_dir = None
+    if args.save_results:
+        out_dir = build_results_dir(args)
+        os.makedirs(out_dir, exist_ok=True)
+        if not args.log_file:
+            args.log_file = os.path.join(out_dir, 'service-exposure.log')
+
+    logger = setup_logging(args.log_file, args.log_level)
+    watch_run('service-exposure', logger)
+    watch_events(args.namespace_prefix, logger)
+    sample_nodes(logger)
+    timing.set_precision(args.precision)
+
+    logger.info("=" * 80)
+    logger.info("KubeVirt Service Exposure Benchmark")
+    logger.info("=" * 80)
+    logger.info(f"Namespaces: {args.namespace_prefix}-{args.start} to {args.namespace_prefix}-{args.end}")
+    logger.info(f"VM name: {args.vm_name}")
+    logger.info(f"Services: {', '.join(service_types(args))} on port {args.port}")
+    logger.info(f"Migration: {'enabled' if args.migrate else 'disabled'}")
+    logger.info(f"Concurrency: {args.concurrency}")
+    logger.info("=" * 80)
+
+    namespaces = [f"{args.namespace_prefix}-{i}" for i in range(args.start, args.end + 1)]
+    if dry_run:
+        plan_run(namespaces, args, logger)
+        sys.exit(0)
+
+    checker = start_checker(args.checker_ns, logger=logger)
+    if checker is None:
+        logger.error("Reachability checker pod unavailable; DNS and connectivity cannot be measured")
+        sys.exit(1)
+
+    clock_skew = timing.estimate_clock_skew(logger=logger) if args.save_results else None
+    if args.save_results:
+        cluster_inventory(logger)
+    test_start = timing.now()
+
+    results: List[Dict] = []
+    try:
+        outcomes = run_parallel(expose_vm, namespaces, concurrency=args.concurrency,
+                                qps=args.qps, burst=args.burst, args=(args, checker, logger),
+                                logger=logger, description="service exposure")
+        for ns, vm_results, error in outcomes:
+            if error is None:
+                results.extend(vm_results)
+    finally:
+        stop_checker(logger=logger)
+        if not args.keep_services:
+            delete_services(namespaces, args, logger)
+
+    total_time = (timing.now() - test_start).total_seconds()
+    if not results:
+        logger.error("No Services were measured")
+        sys.exit(1)
+
+    stats = summarize(results)
+    print_summary(results, stats, total_time, logger)
+    if args.save_results:
+        save_service_results(out_dir, args, results, stats, total_time,
+                             timing.timing_metadata(test_start, clock_skew=clock_skew), logger)
+
+    failed = sum(1 for r in results if not r['success'])
+    run_metrics = {'services': len(results), 'failed': failed}
+    for m in ('dns_sec', 'connect_sec'):
+        run_metrics.update(percentile_metrics(m, [r[m] for r in results]))
+    notify_run('service-exposure', run_metrics, phase_status(failed, len(results)), out_dir, logger)
+
+    sys.exit(0 if failed == 0 else 2)
+
+
+if __name__ == '__main__':
+    main()
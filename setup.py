@@ -16,6 +16,9 @@ setup(
         'pyyaml>=6.0.3',
         'pandas>=2.3.3',
     ],
+    extras_require={
+        'test': ['pytest>=7.0'],
+    },
     entry_points={
         'console_scripts': [
             'virtbench=virtbench.cli:main',
@@ -0,0 +1,796 @@
+#!/usr/bin/env python3
+"""
+KubeVirt Long-Haul Soak Test
+
+Keeps a stable population of running VMs for hours or days while a low,
+steady rate of operations runs against them, and tracks how the cluster
+ages:
+
+  - restart:  `virtctl restart` until a new VMI is Running
+  - migrate:  VirtualMachineInstanceMigration until the VMI moved node
+  - snapshot: VirtualMachineSnapshot until readyToUse, then deleted
+  - resize:   grow the VM's first PVC by --resize-increment until expanded
+
+Every --rate-th of a minute one operation, drawn by weight from --mix, runs
+against a random idle VM. The run is cut into windows of --window; for each
+window the test records the latency of every operation, the failures so far,
+the objects left in the test namespaces (virt-launcher pods, VMIs,
+migrations, snapshots, PVCs) and the memory of virt-handler and
+virt-controller. VMs that are no longer Running at a window boundary are
+started again, so the population stays stable.
+
+At the end the summary reports:
+
+  - drift:  per operation, average latency of the first and last window and
+            the least-squares slope over all windows (seconds per hour)
+  - errors: failures per operation and whether they accumulate (more in the
+            second half of the run than in the first)
+  - leaks:  per tracked object count and virt component, the first, last and
+            peak value, and whether it grew
+
+Windows are written to soak_windows.json/.csv as they complete, so a run cut
+short (SIGINT/SIGTERM, or a lost session) keeps its history. An interrupted
+run stops starting operations, waits for those in flight and saves results.
+
+Usage:
+    python3 measure-soak.py --start 1 --end 20 --duration 72h \\
+        --vm-template ../examples/vm-templates/rhel9-vm-datasource.yaml \\
+        --rate 2 --mix restart=2 migrate=2 snapshot=1 resize=1 --save-results
+
+    python3 measure-soak.py --existing-vms --namespace-prefix datasource-clone \\
+        --start 1 --end 100 --duration 24h --mix restart migrate
+
+Author: KubeVirt Benchmark Suite Contributors
+License: Apache 2.0
+"""
+
+import argparse
+import csv
+import json
+import os
+import random
+import re
+import signal
+import subprocess
+import sys
+import threading
+import time
+from concurrent.futures import wait, FIRST_COMPLETED
+from datetime import datetime
+from typing import Dict, List, Optional
+
+import yaml
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
+
+from utils import timing
+from utils.concurrency import WorkerPool, run_parallel, DEFAULT_QPS, DEFAULT_BURST
+from utils.common import (
+    _kubectl_with_input, setup_logging, run_kubectl_command, create_namespaces_parallel, get_vm_status,
+    start_vm, migrate_vm, wait_for_migration_complete, delete_vmim, create_vm_snapshot,
+    wait_for_snapshot_ready, delete_vm_snapshot, expand_pvc, get_vm_volume_names,
+    cleanup_test_namespaces, print_cleanup_summary, round_duration, stamp_manifest,
+    set_run_workload, run_selector, run_metadata,
+)
+from utils.custommetrics import collect_custom_metrics
+from utils.events import collect_run_events, watch_events
+from utils.inventory import cluster_inventory, resolve_storage_driver
+from utils.dryrun import DryRunPlan, is_dry_run
+from utils.notify import notify_phase, notify_run, watch_run, phase_status
+from utils.stats import metric_stats, percentile
+from utils.storageprovider import collect_storage_backend
+from utils.utilization import collect_node_samples, metrics_server_components, sample_nodes
+
+# Default configuration
+DEFAULT_VM_NAME = 'rhel-9-vm'
+DEFAULT_VM_YAML = '../examples/vm-templates/rhel9-vm-datasource.yaml'
+DEFAULT_NAMESPACE_PREFIX = 'virtbench-soak'
+DEFAULT_DURATION = '24h'
+DEFAULT_WINDOW = '1h'
+DEFAULT_RATE = 1.0                # operations per minute
+DEFAULT_CONCURRENCY = 2           # operations in flight at once
+DEFAULT_TIMEOUT = 900
+DEFAULT_POLL_INTERVAL = 5
+DEFAULT_RESIZE_INCREMENT = '1Gi'
+DEFAULT_MAX_RESIZES = 5           # per VM, so the disks do not grow without bound over days
+OPERATIONS = ['restart', 'migrate', 'snapshot', 'resize']
+DEFAULT_MIX = ['restart=2', 'migrate=2', 'snapshot=1', 'resize=1']
+DURATION_UNITS = {'s': 1, 'm': 60, 'h': 3600, 'd': 86400}
+# Objects counted in the test namespaces at every window: name -> (kubectl get args, jsonpath)
+TRACKED_OBJECTS = {
+    'virt_launcher_pods': (['pods', '-l', 'kubevirt.io=virt-launcher'],
+                           '{range .items[*]}{.metadata.namespace}{"\\n"}{end}'),
+    'vmis': (['vmi'], '{range .items[*]}{.metadata.namespace}{"\\n"}{end}'),
+    'migrations': (['vmim'], '{range .items[*]}{.metadata.namespace}{"\\n"}{end}'),
+    'vm_snapshots': (['virtualmachinesnapshots'], '{range .items[*]}{.metadata.namespace}{"\\n"}{end}'),
+    'volume_snapshots': (['volumesnapshots'], '{range .items[*]}{.metadata.namespace}{"\\n"}{end}'),
+    'pvcs': (['pvc'], '{range .items[*]}{.metadata.namespace}{"\\n"}{end}'),
+}
+# virt components whose memory is tracked for leaks (virt-launcher scales with the population)
+TRACKED_COMPONENTS = ('virt-handler', 'virt-controller')
+
+
+def parse_duration(value: str) -> int:
+    """'72h', '30m', '2d', '90s' or plain seconds as seconds; raises ValueError."""
+    match = re.fullmatch(r'\s*(\d+(?:\.\d+)?)\s*([smhd]?)\s*', str(value))
+    if not match:
+        raise ValueError(f"invalid duration {value!r} (expected e.g. 72h, 30m, 2d)")
+    seconds = float(match.group(1)) * DURATION_UNITS[match.group(2) or 's']
+    if seconds <= 0:
+        raise ValueError(f"duration must be > 0: {value!r}")
+    return int(seconds)
+
+
+def parse_mix(mix: List[str]) -> Dict[str, float]:
+    """'operation[=weight]' entries as {operation: weight}; raises ValueError on unknown ones or bad weights."""
+    weights = {}
+    for entry in mix:
+        operation, _, weight = entry.partition('=')
+        if operation not in OPERATIONS:
+            raise ValueError(f"unknown operation {operation!r} (choose from {', '.join(OPERATIONS)})")
+        try:
+            weights[operation] = float(weight) if weight else 1.0
+        except ValueError:
+            raise ValueError(f"weight of {operation} is not a number: {weight!r}")
+        if weights[operation] <= 0:
+            raise ValueError(f"weight of {operation} must be > 0")
+    return weights
+
+
+def parse_args():
+    parser = argparse.ArgumentParser(
+        description='Long-haul soak test: a stable VM population under a steady mix of operations',
+        formatter_class=argparse.RawDescriptionHelpFormatter,
+    )
+    parser.add_argument('--start', type=int, default=1, help='Starting namespace index (default: 1)')
+    parser.add_argument('--end', type=int, default=10, help='Ending namespace index (default: 10)')
+    parser.add_argument('--namespace-prefix', default=DEFAULT_NAMESPACE_PREFIX,
+                        help=f'Namespace prefix (default: {DEFAULT_NAMESPACE_PREFIX})')
+    parser.add_argument('--vm-name', default=DEFAULT_VM_NAME,
+                        help=f'VM name in the template (default: {DEFAULT_VM_NAME})')
+    parser.add_argument('--vm-template', default=DEFAULT_VM_YAML,
+                        help=f'VM template YAML (default: {DEFAULT_VM_YAML})')
+    parser.add_argument('--existing-vms', action='store_true',
+                        help='Soak existing VMs (e.g. from datasource-clone) instead of creating them')
+    parser.add_argument('--duration', default=DEFAULT_DURATION,
+                        help=f'How long to run, e.g. 72h, 90m, 3d (default: {DEFAULT_DURATION})')
+    parser.add_argument('--window', default=DEFAULT_WINDOW,
+                        help=f'Length of the windows latency, errors and leaks are tracked over '
+                             f'(default: {DEFAULT_WINDOW})')
+    parser.add_argument('--rate', type=float, default=DEFAULT_RATE,
+                        help=f'Operations started per minute (default: {DEFAULT_RATE:g})')
+    parser.add_argument('--mix', nargs='+', default=DEFAULT_MIX, metavar='OPERATION[=WEIGHT]',
+                        help=f'Operations drawn by weight, from {", ".join(OPERATIONS)} '
+                             f'(default: {" ".join(DEFAULT_MIX)})')
+    parser.add_argument('--seed', type=int, default=None, help='Random seed of the mix (default: random)')
+    parser.add_argument('--concurrency', '-c', type=int, default=DEFAULT_CONCURRENCY,
+                        help=f'Operations in flight at once (default: {DEFAULT_CONCURRENCY})')
+    parser.add_argument('--qps', type=float, default=DEFAULT_QPS,
+                        help='Max operations started per second on top of --rate, 0 disables rate limiting '
+                             '(default: 0)')
+    parser.add_argument('--burst', type=int, default=DEFAULT_BURST,
+                        help=f'Max operations started back-to-back when --qps is set (default: {DEFAULT_BURST})')
+    parser.add_argument('--resize-increment', default=DEFAULT_RESIZE_INCREMENT,
+                        help=f'Size each resize adds to the VM\'s first PVC (default: {DEFAULT_RESIZE_INCREMENT})')
+    parser.add_argument('--max-resizes-per-vm', type=int, default=DEFAULT_MAX_RESIZES,
+                        help=f'Resizes per VM before resize is no longer drawn for it (default: {DEFAULT_MAX_RESIZES})')
+    parser.add_argument('--keep-snapshots', action='store_true',
+                        help='Keep the snapshots instead of deleting each one once it is ready')
+    parser.add_argument('--poll-interval', type=float, default=DEFAULT_POLL_INTERVAL,
+                        help=f'Seconds between status checks (default: {DEFAULT_POLL_INTERVAL})')
+    parser.add_argument('--timeout', type=int, default=DEFAULT_TIMEOUT,
+                        help=f'Timeout per operation in seconds (default: {DEFAULT_TIMEOUT})')
+    parser.add_argument('--skip-namespace-creation', action='store_true',
+                        help='Use existing namespaces')
+    parser.add_argument('--cleanup', action='store_true',
+                        help='Delete the test namespaces afterwards')
+    parser.add_argument('--precision', type=int, default=timing.DEFAULT_PRECISION,
+                        help=f'Decimal places for durations in saved results (default: {timing.DEFAULT_PRECISION})')
+    parser.add_argument('--save-results', action='store_true', help='Save results as JSON and CSV')
+    parser.add_argument('--results-folder', default='results',
+                        help='Base directory for results (default: results)')
+    parser.add_argument('--storage-driver', default=None,
+                        help='Storage driver label to include in the results path, or auto to detect it')
+    parser.add_argument('--log-file', default=None, help='Log file path')
+    parser.add_argument('--log-level', default='INFO',
+                        choices=['DEBUG', 'INFO', 'WARNING', 'ERROR'], help='Logging level (default: INFO)')
+    args = parser.parse_args()
+
+    try:
+        args.duration_sec = parse_duration(args.duration)
+        args.window_sec = parse_duration(args.window)
+    except ValueError as e:
+        parser.error(str(e))
+    try:
+        args.mix_weights = parse_mix(args.mix)
+    except ValueError as e:
+        parser.error(f"--mix: {e}")
+    if args.rate <= 0:
+        parser.error("--rate must be > 0")
+    if args.concurrency < 1:
+        parser.error("--concurrency must be >= 1")
+    if args.qps < 0:
+        parser.error("--qps must be >= 0")
+    if args.burst < 1:
+        parser.error("--burst must be >= 1")
+    if args.poll_interval <= 0:
+        parser.error("--poll-interval must be > 0")
+    if args.window_sec > args.duration_sec:
+        args.window_sec = args.duration_sec
+    if args.existing_vms:
+        if args.cleanup:
+            parser.error("--cleanup would delete the existing VMs; it cannot be combined with --existing-vms")
+    elif not os.path.exists(args.vm_template):
+        parser.error(f"VM template file not found: {args.vm_template}")
+    return args
+
+
+def build_results_dir(args, timestamp: Optional[str] = None) -> str:
+    """Build the canonical soak results directory."""
+    timestamp = timestamp or datetime.now().strftime("%Y%m%d-%H%M%S")
+    suffix = f"{args.namespace_prefix}_{args.start}-{args.end}"
+    if args.storage_driver:
+        return os.path.join(args.results_folder, args.storage_driver, 'soak', f"{timestamp}_{suffix}")
+    return os.path.join(args.results_folder, 'soak', f"{timestamp}_{suffix}")
+
+
+def render_running_vm(vm_yaml: str) -> str:
+    """Return the template with the VM set to runStrategy Always."""
+    with open(vm_yaml) as f:
+        docs = [doc for doc in yaml.safe_load_all(f) if doc]
+    for doc in docs:
+        if doc.get('kind') == 'VirtualMachine':
+            spec = doc.setdefault('spec', {})
+            spec.pop('running', None)
+            spec['runStrategy'] = 'Always'
+    return yaml.safe_dump_all(docs, sort_keys=False)
+
+
+def vmi_uid(vm_name: str, namespace: str) -> Optional[str]:
+    """UID of the VM's current VMI, None when it has none; a restart replaces the VMI."""
+    rc, stdout, _ = run_kubectl_command(['get', 'vmi', vm_name, '-n', namespace,
+                                         '-o', 'jsonpath={.metadata.uid}'], check=False)
+    if rc != 0:
+        return None
+    return stdout.strip() or None
+
+
+def wait_for_running(namespace: str, args, old_vmi: Optional[str] = None) -> bool:
+    """Wait until the VM is Running (on a VMI other than old_vmi, when given)."""
+    deadline = time.monotonic() + args.timeout
+    while time.monotonic() < deadline:
+        if get_vm_status(args.vm_name, namespace) == 'Running':
+            if old_vmi is None or vmi_uid(args.vm_name, namespace) not in (None, old_vmi):
+                return True
+        time.sleep(args.poll_interval)
+    return False
+
+
+def create_population(namespaces: List[str], args, logger) -> List[str]:
+    """Create the VMs and wait until they are Running; returns the namespaces whose VM is."""
+    manifest = render_running_vm(args.vm_template)
+
+    def create(namespace: str) -> bool:
+        returncode, _, stderr = _kubectl_with_input(['create', '-f', '-', '-n', namespace],
+                                                    stamp_manifest(manifest, namespace, logger), logger)
+        if returncode != 0:
+            raise RuntimeError(f"create failed: {stderr.strip()}")
+        return wait_for_running(namespace, args)
+
+    outcomes = run_parallel(create, namespaces, concurrency=min(len(namespaces), 20),
+                            logger=logger, description="VM creation")
+    running = []
+    for ns, ok, error in outcomes:
+        if error is not None:
+            logger.error(f"[{ns}] {error}")
+        elif not ok:
+            logger.error(f"[{ns}] VM did not reach Running within {args.timeout}s")
+        else:
+            running.append(ns)
+    return sorted(running, key=namespaces.index)
+
+
+def restart(namespace: str, args, logger) -> Optional[str]:
+    """Restart the VM; returns an error message or None."""
+    old_vmi = vmi_uid(args.vm_name, namespace)
+    try:
+        result = subprocess.run(['virtctl', 'restart', 'vm', args.vm_name, '-n', namespace],
+                                capture_output=True, text=True, timeout=60)
+    except (subprocess.TimeoutExpired, FileNotFoundError) as e:
+        return f"virtctl restart failed: {e}"
+    if result.returncode != 0:
+        return f"virtctl restart failed: {result.stderr.strip()}"
+    if not wait_for_running(namespace, args, old_vmi):
+        return "timed out waiting for a new VMI to be Running"
+    return None
+
+
+def migrate(namespace: str, args, logger) -> Optional[str]:
+    """Live-migrate the VM and remove its migration object; returns an error message or None."""
+    if not migrate_vm(args.vm_name, namespace, logger=logger):
+        return "failed to create the migration"
+    success, _, _, _ = wait_for_migration_complete(args.vm_name, namespace, timeout=args.timeout,
+                                                   poll_interval=args.poll_interval, logger=logger)
+    delete_vmim(f"migration-{args.vm_name}", namespace, logger)
+    return None if success else "migration did not complete"
+
+
+def snapshot(namespace: str, args, logger) -> Optional[str]:
+    """Snapshot the VM and, unless --keep-snapshots, delete it once ready; returns an error message or None."""
+    name = f"{args.vm_name}-soak-{int(time.time() * 1000)}"
+    if not create_vm_snapshot(args.vm_name, name, namespace, logger):
+        return "failed to create the snapshot"
+    ready = wait_for_snapshot_ready(name, namespace, timeout=args.timeout,
+                                    poll_interval=args.poll_interval, logger=logger)
+    if not args.keep_snapshots:
+        delete_vm_snapshot(name, namespace, logger)
+    return None if ready else "snapshot did not become ready"
+
+
+def resize(namespace: str, args, logger) -> Optional[str]:
+    """Grow the VM's first PVC by --resize-increment; returns an error message or None."""
+    pvcs = get_vm_volume_names(args.vm_name, namespace, logger)
+    if not pvcs:
+        return "VM has no PVC"
+    _, _, error = expand_pvc(pvcs[0], namespace, args.resize_increment, timeout=args.timeout,
+                             poll_interval=args.poll_interval, logger=logger)
+    return error
+
+
+OPERATION_FUNCS = {'restart': restart, 'migrate': migrate, 'snapshot': snapshot, 'resize': resize}
+
+
+def run_operation(namespace: str, operation: str, args, test_start: timing.MonotonicTimestamp, logger) -> Dict:
+    """Run one operation against the VM of a namespace and time it."""
+    started = timing.now()
+    row = {'namespace': namespace, 'operation': operation, 'started': started.rfc3339nano(),
+           'elapsed_hours': round((started - test_start).total_seconds() / 3600, 4),
+           'total_sec': None, 'success': False, 'vm_running': None, 'error': None}
+    try:
+        error = OPERATION_FUNCS[operation](namespace, args, logger)
+    except Exception as e:
+        error = str(e)
+    if error is None:
+        row['total_sec'] = (timing.now() - started).total_seconds()
+        row['success'] = True
+        logger.debug(f"[{namespace}] {operation} took {row['total_sec']:.2f}s")
+    else:
+        row['error'] = error
+        logger.error(f"[{namespace}] {operation}: {error}")
+    row['vm_running'] = get_vm_status(args.vm_name, namespace) == 'Running'
+    return row
+
+
+def pick_operation(namespace: str, weights: Dict[str, float], resizes: Dict[str, int], args,
+                   rng: random.Random) -> Optional[str]:
+    """An operation of the mix drawn by weight; resize stops being drawn for a VM after --max-resizes-per-vm."""
+    valid = [op for op in weights if op != 'resize' or resizes.get(namespace, 0) < args.max_resizes_per_vm]
+    if not valid:
+        return None
+    return rng.choices(valid, weights=[weights[op] for op in valid])[0]
+
+
+def count_objects(namespaces: List[str]) -> Dict[str, Optional[int]]:
+    """Count of every TRACKED_OBJECTS kind in the test namespaces (None when it cannot be listed)."""
+    wanted = set(namespaces)
+    counts = {}
+    for name, (get_args, jsonpath) in TRACKED_OBJECTS.items():
+        rc, stdout, _ = run_kubectl_command(['get'] + get_args + ['--all-namespaces', '-o', f'jsonpath={jsonpath}'],
+                                            check=False)
+        counts[name] = sum(1 for ns in stdout.split() if ns in wanted) if rc == 0 else None
+    return counts
+
+
+def component_memory() -> Dict[str, Optional[int]]:
+    """Memory (bytes) of the TRACKED_COMPONENTS from metrics-server (None without it)."""
+    components = metrics_server_components()
+    return {f"{c.replace('-', '_')}_memory_bytes": (components.get(c) or {}).get('memory_bytes')
+            for c in TRACKED_COMPONENTS}
+
+
+def repair_population(namespaces: List[str], args, logger) -> int:
+    """Start the VMs that are no longer Running; returns how many were started."""
+    stopped = [ns for ns in namespaces if get_vm_status(args.vm_name, ns) in ('Stopped', 'Paused')]
+    for ns in stopped:
+        logger.warning(f"[{ns}] VM is not Running; starting it to keep the population stable")
+        start_vm(args.vm_name, ns, logger)
+    return len(stopped)
+
+
+def window_row(index: int, start: timing.MonotonicTimestamp, end: timing.MonotonicTimestamp,
+               test_start: timing.MonotonicTimestamp, rows: List[Dict], all_rows: List[Dict],
+               operations: List[str], objects: Dict, memory: Dict, repaired: int) -> Dict:
+    """Flat record of one window: latency per operation, failures, tracked objects and memory."""
+    row = {
+        'window': index,
+        'start': start.rfc3339nano(),
+        'end': end.rfc3339nano(),
+        'elapsed_hours': round((end - test_start).total_seconds() / 3600, 4),
+        'operations': len(rows),
+        'failed': sum(1 for r in rows if not r['success']),
+        'cumulative_failed': sum(1 for r in all_rows if not r['success']),
+        'vms_repaired': repaired,
+    }
+    for op in operations:
+        values = [r['total_sec'] for r in rows if r['operation'] == op and r['success']]
+        row[f"{op}_count"] = len(values)
+        row[f"{op}_avg_sec"] = round_duration(sum(values) / len(values)) if values else None
+        row[f"{op}_p95_sec"] = round_duration(percentile(values, 95)) if values else None
+    row.update(objects)
+    row.update(memory)
+    return row
+
+
+def slope_per_hour(points: List[tuple]) -> Optional[float]:
+    """Least-squares slope of (hours, value) points, in value per hour."""
+    points = [(x, y) for x, y in points if y is not None]
+    if len(points) < 2:
+        return None
+    mean_x = sum(x for x, _ in points) / len(points)
+    mean_y = sum(y for _, y in points) / len(points)
+    spread = sum((x - mean_x) ** 2 for x, _ in points)
+    if not spread:
+        return None
+    return sum((x - mean_x) * (y - mean_y) for x, y in points) / spread
+
+
+def latency_drift(windows: List[Dict], operations: List[str]) -> List[Dict]:
+    """Per operation: average latency of the first and last window with samples, change and slope."""
+    drift = []
+    for op in operations:
+        averaged = [w for w in windows if w[f"{op}_avg_sec"] is not None]
+        first = averaged[0][f"{op}_avg_sec"] if averaged else None
+        last = averaged[-1][f"{op}_avg_sec"] if averaged else None
+        slope = slope_per_hour([(w['elapsed_hours'], w[f"{op}_avg_sec"]) for w in averaged])
+        drift.append({
+            'operation': op,
+            'first_window_avg_sec': first,
+            'last_window_avg_sec': last,
+            'drift_pct': round((last - first) / first * 100, 1) if len(averaged) > 1 and first else None,
+            'slope_sec_per_hour': round_duration(slope),
+        })
+    return drift
+
+
+def error_accumulation(results: List[Dict], operations: List[str], duration_hours: float) -> Dict:
+    """Failures per operation and in each half of the run."""
+    half = duration_hours / 2
+    first = [r for r in results if r['elapsed_hours'] < half]
+    second = [r for r in results if r['elapsed_hours'] >= half]
+
+    def rate(rows):
+        return round(sum(1 for r in rows if not r['success']) / len(rows) * 100, 2) if rows else None
+
+    first_rate, second_rate = rate(first), rate(second)
+    return {
+        'total': sum(1 for r in results if not r['success']),
+        'by_operation': {op: sum(1 for r in results if r['operation'] == op and not r['success'])
+                         for op in operations},
+        'vm_not_running_after': sum(1 for r in results if r['vm_running'] is False),
+        'failure_rate_first_half_pct': first_rate,
+        'failure_rate_second_half_pct': second_rate,
+        'accumulating': first_rate is not None and second_rate is not None and second_rate > first_rate,
+    }
+
+
+def resource_leaks(windows: List[Dict]) -> Dict:
+    """First, last and peak of every tracked object count and component memory, and whether it grew."""
+    leaks = {}
+    keys = list(TRACKED_OBJECTS) + [f"{c.replace('-', '_')}_memory_bytes" for c in TRACKED_COMPONENTS]
+    for key in keys:
+        values = [w.get(key) for w in windows if w.get(key) is not None]
+        if not values:
+            continue
+        growth = values[-1] - values[0]
+        leaks[key] = {
+            'first': values[0],
+            'last': values[-1],
+            'peak': max(values),
+            'growth': growth,
+            'growth_pct': round(growth / values[0] * 100, 1) if values[0] else None,
+            'slope_per_hour': round_duration(slope_per_hour(
+                [(w['elapsed_hours'], w.get(key)) for w in windows])),
+            'grew': growth > 0,
+        }
+    return leaks
+
+
+def write_windows(out_dir: Optional[str], windows: List[Dict]):
+    """Checkpoint the windows so far to soak_windows.json/.csv."""
+    if not out_dir or not windows:
+        return
+    with open(os.path.join(out_dir, 'soak_windows.json'), 'w') as f:
+        json.dump(windows, f, indent=4)
+    with open(os.path.join(out_dir, 'soak_windows.csv'), 'w', newline='') as f:
+        writer = csv.DictWriter(f, fieldnames=windows[0].keys())
+        writer.writeheader()
+        writer.writerows(windows)
+
+
+def print_summary(stats: List[Dict], drift: List[Dict], errors: Dict, leaks: Dict,
+                  total_time: float, logger) -> None:
+    def fmt(value):
+        return f"{value:.2f}" if value is not None else '-'
+
+    logger.info("")
+    logger.info("=" * 100)
+    logger.info("SOAK TEST RESULTS (seconds)")
+    logger.info("=" * 100)
+    logger.info(f"{'Operation':<12}{'Avg':>10}{'Median':>10}{'P95':>10}{'Max':>10}{'Count':>8}{'Failed':>8}"
+                f"{'First win':>12}{'Last win':>12}{'Drift':>10}")
+    logger.info("-" * 100)
+    for s, d in zip(stats, drift):
+        drift_pct = f"{d['drift_pct']:+.1f}%" if d['drift_pct'] is not None else '-'
+        logger.info(f"{s['metric'][:-4]:<12}{fmt(s['avg']):>10}{fmt(s['median']):>10}{fmt(s['p95']):>10}"
+                    f"{fmt(s['max']):>10}{s['count']:>8}{s['failed']:>8}"
+                    f"{fmt(d['first_window_avg_sec']):>12}{fmt(d['last_window_avg_sec']):>12}{drift_pct:>10}")
+    logger.info("=" * 100)
+    logger.info(f"  Failures:               {errors['total']} "
+                f"(first half {fmt(errors['failure_rate_first_half_pct'])}%, "
+                f"second half {fmt(errors['failure_rate_second_half_pct'])}%)")
+    if errors['accumulating']:
+        logger.warning("  Failures are accumulating: the second half of the run failed more often")
+    for key, leak in leaks.items():
+        if leak['grew']:
+            logger.warning(f"  Possible leak: {key} grew from {leak['first']} to {leak['last']} "
+                           f"(peak {leak['peak']})")
+    logger.info(f"  Total test duration:    {total_time / 3600:.2f}h")
+
+
+def save_soak_results(out_dir: str, args, results: List[Dict], windows: List[Dict], stats: List[Dict],
+                      drift: List[Dict], errors: Dict, leaks: Dict, vms: int, total_time: float,
+                      timing_block: Dict, logger) -> None:
+    """Write every operation, the windows and the soak summary as JSON and CSV."""
+    os.makedirs(out_dir, exist_ok=True)
+    rows = [dict(r, total_sec=round_duration(r['total_sec'])) for r in results]
+    if rows:
+        with open(os.path.join(out_dir, 'soak_results.json'), 'w') as f:
+            json.dump(rows, f, indent=4)
+        with open(os.path.join(out_dir, 'soak_results.csv'), 'w', newline='') as f:
+            writer = csv.DictWriter(f, fieldnames=rows[0].keys())
+            writer.writeheader()
+            writer.writerows(rows)
+    write_windows(out_dir, windows)
+
+    summary = {
+        'total_vms': vms,
+        'total_operations': len(results),
+        'successful': sum(1 for r in results if r['success']),
+        'failed': sum(1 for r in results if not r['success']),
+        'existing_vms': args.existing_vms,
+        'mix': args.mix_weights,
+        'rate_per_min': args.rate,
+        'achieved_rate_per_min': round(len(results) / (total_time / 60), 3) if total_time else None,
+        'concurrency': args.concurrency,
+        'requested_duration_sec': args.duration_sec,
+        'window_sec': args.window_sec,
+        'windows': len(windows),
+        'total_test_duration_sec': round_duration(total_time),
+        'metrics': stats,
+        'drift': drift,
+        'errors': errors,
+        'leaks': leaks,
+        'timing': timing_block,
+    }
+    custom_metrics = collect_custom_metrics(timing_block, logger)
+    if custom_metrics is not None:
+        summary['custom_metrics'] = custom_metrics
+    run_events = collect_run_events(out_dir, logger)
+    if run_events is not None:
+        summary['events'] = run_events
+    node_utilization = collect_node_samples(out_dir, logger)
+    if node_utilization is not None:
+        summary['node_utilization'] = node_utilization
+    storage_backend = collect_storage_backend(out_dir, args.namespace_prefix, logger)
+    if storage_backend is not None:
+        summary['storage_backend'] = storage_backend
+    summary['cluster'] = cluster_inventory(logger)
+    summary['run'] = run_metadata()
+    with open(os.path.join(out_dir, 'summary_soak_results.json'), 'w') as f:
+        json.dump(summary, f, indent=4)
+    with open(os.path.join(out_dir, 'summary_soak_results.csv'), 'w', newline='') as f:
+        writer = csv.DictWriter(f, fieldnames=stats[0].keys())
+        writer.writeheader()
+        writer.writerows(stats)
+    logger.info(f"Results saved under: {out_dir}")
+
+
+def plan_run(args, namespaces: List[str], logger):
+    """Print what main() would create and run (virtbench --dry-run)."""
+    plan = DryRunPlan('soak', logger)
+    if not args.existing_vms:
+        if not args.skip_namespace_creation:
+            plan.create_namespaces(namespaces)
+        manifest = render_running_vm(args.vm_template)
+        for ns in namespaces:
+            plan.apply(manifest, ns)
+    mix = ', '.join(f"{op}={w:g}" for op, w in args.mix_weights.items())
+    plan.action('soak', f"{len(namespaces)} VMs",
+                f"{args.rate:g} operation(s)/min of {mix} for {args.duration}")
+    if args.cleanup:
+        plan.delete_namespaces(namespaces)
+    plan.report()
+
+
+def main():
+    args = parse_args()
+    set_run_workload('soak')
+    args.storage_driver = resolve_storage_driver(args.storage_driver)
+    dry_run = is_dry_run()
+    if dry_run:
+        # A dry run has no results to save
+        args.save_results = False
+
+    out_dir = None
+    if args.save_results:
+        out_dir = build_results_dir(args)
+        os.makedirs(out_dir, exist_ok=True)
+        if not args.log_file:
+            args.log_file = os.path.join(out_dir, 'soak.log')
+
+    logger = setup_logging(args.log_file, args.log_level)
+    watch_run('soak', logger)
+    watch_events(args.namespace_prefix, logger)
+    sample_nodes(logger)
+    timing.set_precision(args.precision)
+
+    logger.info("=" * 80)
+    logger.info("KubeVirt Long-Haul Soak Test")
+    logger.info("=" * 80)
+    logger.info(f"Namespaces: {args.namespace_prefix}-{args.start} to {args.namespace_prefix}-{args.end}")
+    logger.info(f"VM: {'existing ' + args.vm_name if args.existing_vms else args.vm_name + ' from ' + args.vm_template}")
+    logger.info(f"Duration: {args.duration} in windows of {args.window}")
+    logger.info(f"Mix: {', '.join(f'{op}={w:g}' for op, w in args.mix_weights.items())} "
+                f"at {args.rate:g}/min (concurrency {args.concurrency})")
+    logger.info("=" * 80)
+
+    namespaces = [f"{args.namespace_prefix}-{i}" for i in range(args.start, args.end + 1)]
+    if dry_run:
+        plan_run(args, namespaces, logger)
+        sys.exit(0)
+
+    if args.existing_vms:
+        outcomes = run_parallel(lambda ns: get_vm_status(args.vm_name, ns), namespaces, concurrency=20,
+                                logger=logger, description="VM status")
+        statuses = {ns: status for ns, status, _ in outcomes}
+        population = [ns for ns in namespaces if statuses.get(ns) == 'Running']
+        if len(population) < len(namespaces):
+            logger.warning(f"{len(namespaces) - len(population)} namespaces have no Running VM {args.vm_name}; "
+                           f"they are left out")
+    else:
+        if not args.skip_namespace_creation:
+            created = create_namespaces_parallel(namespaces, args.concurrency, logger)
+            if len(created) != len(namespaces):
+                logger.error(f"Failed to create {len(namespaces) - len(created)} namespaces")
+                sys.exit(1)
+        logger.info(f"Creating {len(namespaces)} VMs...")
+        population = create_population(namespaces, args, logger)
+    if not population:
+        logger.error("No Running VMs to soak")
+        sys.exit(1)
+    logger.info(f"Population: {len(population)} Running VMs")
+
+    stop = threading.Event()
+
+    def request_stop(signum, frame):
+        logger.warning("Stopping the soak test: waiting for operations in flight, then saving results...")
+        stop.set()
+
+    signal.signal(signal.SIGINT, request_stop)
+    signal.signal(signal.SIGTERM, request_stop)
+
+    clock_skew = timing.estimate_clock_skew(logger=logger) if args.save_results else None
+    if args.save_results:
+        cluster_inventory(logger)
+    operations = list(args.mix_weights)
+    rng = random.Random(args.seed)
+    results: List[Dict] = []
+    windows: List[Dict] = []
+    resizes: Dict[str, int] = {}
+    inflight = {}
+    interval = 60.0 / args.rate
+
+    test_start = timing.now()
+    window_start = test_start
+    window_rows: List[Dict] = []
+    deadline = time.monotonic() + args.duration_sec
+    next_op = time.monotonic()
+    next_window = time.monotonic() + args.window_sec
+
+    def collect(done):
+        for future in done:
+            namespace, operation = inflight.pop(future)
+            try:
+                row = future.result()
+            except Exception as e:
+                row = {'namespace': namespace, 'operation': operation, 'started': None, 'elapsed_hours': None,
+                       'total_sec': None, 'success': False, 'vm_running': None, 'error': str(e)}
+            if row['operation'] == 'resize' and row['success']:
+                resizes[namespace] = resizes.get(namespace, 0) + 1
+            results.append(row)
+            window_rows.append(row)
+
+    def close_window():
+        nonlocal window_start, window_rows
+        end = timing.now()
+        repaired = repair_population(population, args, logger)
+        row = window_row(len(windows) + 1, window_start, end, test_start, window_rows, results, operations,
+                         count_objects(population), component_memory(), repaired)
+        windows.append(row)
+        write_windows(out_dir, windows)
+        logger.info(f"Window {row['window']} ({row['elapsed_hours']:.2f}h): {row['operations']} operation(s), "
+                    f"{row['failed']} failed, {row['cumulative_failed']} failed so far")
+        notify_phase('soak', 'window-complete', {'window': row['window'], 'operations': row['operations'],
+                                                  'failed': row['failed']},
+                     phase_status(row['failed'], row['operations']), logger=logger)
+        window_start, window_rows = end, []
+
+    with WorkerPool(args.concurrency, args.qps, args.burst) as executor:
+        while not stop.is_set() and time.monotonic() < deadline:
+            now = time.monotonic()
+            if now >= next_op and len(inflight) < args.concurrency:
+                busy = {ns for ns, _ in inflight.values()}
+                idle = [ns for ns in population if ns not in busy]
+                if idle:
+                    namespace = rng.choice(idle)
+                    operation = pick_operation(namespace, args.mix_weights, resizes, args, rng)
+                    if operation:
+                        future = executor.submit(run_operation, namespace, operation, args, test_start, logger)
+                        inflight[future] = (namespace, operation)
+                next_op += interval
+            if now >= next_window:
+                close_window()
+                next_window += args.window_sec
+            if inflight:
+                done, _ = wait(list(inflight), timeout=1, return_when=FIRST_COMPLETED)
+                collect(done)
+            else:
+                stop.wait(max(0.0, min(next_op, next_window, deadline) - time.monotonic()))
+        # Let the operations in flight finish so the VMs are not left mid-operation
+        if inflight:
+            logger.info(f"Waiting for {len(inflight)} operation(s) in flight...")
+            collect(wait(list(inflight))[0])
+    if window_rows or not windows:
+        close_window()
+
+    total_time = (timing.now() - test_start).total_seconds()
+
+    try:
+        if not results:
+            logger.error("No operations were run")
+            sys.exit(1)
+        stats = []
+        for op in operations:
+            values = [r['total_sec'] for r in results if r['operation'] == op and r['success']]
+            stats.append({**metric_stats(f"{op}_sec", values),
+                          'failed': sum(1 for r in results if r['operation'] == op and not r['success'])})
+        drift = latency_drift(windows, operations)
+        errors = error_accumulation(results, operations, total_time / 3600)
+        leaks = resource_leaks(windows)
+        print_summary(stats, drift, errors, leaks, total_time, logger)
+        if args.save_results:
+            save_soak_results(out_dir, args, results, windows, stats, drift, errors, leaks, len(population),
+                              total_time, timing.timing_metadata(test_start, clock_skew=clock_skew), logger)
+
+        failed = errors['total']
+        run_metrics = {'vms': len(population), 'operations': len(results), 'failed': failed,
+                       'hours': round(total_time / 3600, 2)}
+        for s in stats:
+            if s['count']:
+                run_metrics.update({f"p50_{s['metric']}": s['median'], f"p95_{s['metric']}": s['p95']})
+        notify_run('soak', run_metrics, phase_status(failed, len(results)), out_dir, logger)
+    finally:
+        if args.cleanup:
+            cleanup_stats = cleanup_test_namespaces(
+                namespace_prefix=args.namespace_prefix, start=args.start, end=args.end,
+                vm_name=args.vm_name, delete_namespaces=True, batch_size=args.concurrency,
+                logger=logger, selector=run_selector()
+            )
+            print_cleanup_summary(cleanup_stats, logger)
+
+    sys.exit(0 if not failed else 2)
+
+
+if __name__ == '__main__':
+    main()
@@ -0,0 +1,5 @@
+"""Make the repository root importable (utils.*, virtbench.*) when pytest runs from anywhere."""
+import os
+import sys
+
+sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
@@ -0,0 +1,85 @@
+"""Pass/fail thresholds of virtbench/utils/assertions.py."""
+import json
+from pathlib import Path
+
+import pytest
+
+from virtbench.utils.assertions import (
+    ASSERTIONS_ENV, ASSERTIONS_FILE, check_results, configured_assertions, evaluate, parse_assertion, summary_kind,
+)
+from virtbench.utils.exitcodes import ASSERTION_FAILED_EXIT
+
+SUMMARY = {
+    'total_vms': 50,
+    'successful': 49,
+    'failed': 1,
+    'metrics': [{'metric': 'running_time_sec', 'avg': 40.0, 'p95': 95.0, 'max': 120.0}],
+}
+
+
+@pytest.mark.parametrize('text, name, op, threshold', [
+    ('p95_running_time < 180s', 'p95_running_time', '<', 180.0),
+    ('migration_success_rate >= 99%', 'migration_success_rate', '>=', 99.0),
+    ('failed == 0', 'failed', '==', 0.0),
+    ('avg_running_time<=500ms', 'avg_running_time', '<=', 0.5),
+    ('max_running_time < 2m', 'max_running_time', '<', 120.0),
+])
+def test_parse_assertion(text, name, op, threshold):
+    assertion = parse_assertion(text)
+    assert (assertion['name'], assertion['op'], assertion['threshold']) == (name, op, threshold)
+
+
+@pytest.mark.parametrize('text', ['', 'p95 < fast', 'p95 =< 3', '< 3', 'p95 < 3 days'])
+def test_parse_assertion_invalid(text):
+    with pytest.raises(ValueError):
+        parse_assertion(text)
+
+
+@pytest.mark.parametrize('text, status', [
+    ('p95_running_time < 180s', 'pass'),
+    ('running_time_p95 < 90', 'fail'),
+    ('success_rate >= 98%', 'pass'),
+    ('failure_rate < 1%', 'fail'),
+    ('vm_clone_failed == 1', 'pass'),
+    ('p99_running_time < 180s', 'missing'),
+])
+def test_evaluate(text, status):
+    verdict = evaluate([parse_assertion(text)], 'vm_clone', SUMMARY)
+    assert [r['status'] for r in verdict['results']] == [status]
+    assert verdict['verdict'] == ('pass' if status == 'pass' else 'fail')
+
+
+def test_evaluate_skips_assertions_of_other_kinds():
+    verdict = evaluate([parse_assertion('migration_success_rate >= 99%')], 'vm_clone', SUMMARY)
+    assert verdict['results'] == []
+    assert verdict['verdict'] == 'pass'
+
+
+def test_summary_kind():
+    assert summary_kind(Path('summary_migration_results.json')) == 'migration'
+    assert summary_kind(Path('summary_fio_benchmark.json')) == 'fio'
+
+
+def test_configured_assertions_from_a_plan(monkeypatch):
+    monkeypatch.setenv(ASSERTIONS_ENV, json.dumps(['failed == 0']))
+    assert [a['expression'] for a in configured_assertions()] == ['failed == 0']
+
+
+def test_check_results_resolves_across_summaries(tmp_path):
+    storm = tmp_path / 'summary_boot_storm_results.json'
+    creation = tmp_path / 'summary_vm_creation_results.json'
+    storm.write_text(json.dumps({'metrics': [{'metric': 'boot_time_sec', 'p95': 30.0}]}))
+    creation.write_text(json.dumps(SUMMARY))
+    verdicts = check_results([parse_assertion('p95_boot_time < 60s'), parse_assertion('failed == 0')],
+                             [storm, creation])
+    # An assertion one summary resolves is not missing in the other
+    assert [(r['expression'], r['status']) for r in verdicts[storm]['results']] == [('p95_boot_time < 60s', 'pass')]
+    assert [(r['expression'], r['status']) for r in verdicts[creation]['results']] == [('failed == 0', 'fail')]
+    assert json.loads(creation.read_text())['assertions']['verdict'] == 'fail'
+    assert json.loads((tmp_path / ASSERTIONS_FILE).read_text())['summary'] in (storm.name, creation.name)
+
+
+def test_assertion_exit_code_is_clear_of_script_codes():
+    # 1 is a script error and 2-5 are failure-recovery verdicts
+    assert ASSERTION_FAILED_EXIT not in range(0, 6)
+    assert ASSERTION_FAILED_EXIT != 130
@@ -0,0 +1,106 @@
+"""Phase state machine of the capacity benchmark (utils/capacity.py)."""
+from types import SimpleNamespace
+
+import pytest
+
+from utils.capacity import (
+    STATE_CAPACITY, STATE_COMPLETED, STATE_FAILED, STATUS_COMPLETED, STATUS_FAILED, STATUS_PENDING,
+    STATUS_SKIPPED, CapacityIteration, CapacityReached, PhaseFailed, phase_summary, skipped_phases,
+)
+
+
+def _handlers(calls, **overrides):
+    """Handlers that record their calls and pass the VMs on; overrides replace single phases."""
+    def passing(name):
+        def handler(vms):
+            calls.append((name, list(vms)))
+            return (vms or ['vm-1', 'vm-2']), 1
+        return handler
+    handlers = {name: passing(name) for name in ('create', 'resize', 'clone', 'restart', 'snapshot')}
+    handlers.update(overrides)
+    return handlers
+
+
+def test_all_phases_complete():
+    calls = []
+    iteration = CapacityIteration(1, _handlers(calls)).run()
+    assert iteration.state == STATE_COMPLETED
+    assert iteration.succeeded and not iteration.capacity_reached
+    assert [name for name, _ in calls] == ['create', 'resize', 'clone', 'restart', 'snapshot']
+    # Every later phase gets the VMs the previous one left running
+    assert all(vms == ['vm-1', 'vm-2'] for _, vms in calls[1:])
+    assert all(result.status == STATUS_COMPLETED for result in iteration.phases.values())
+    assert iteration.to_dict()['vms'] == 2
+
+
+def test_skipped_phases_are_not_entered():
+    calls = []
+    iteration = CapacityIteration(1, _handlers(calls), skip=['clone', 'snapshot']).run()
+    assert iteration.state == STATE_COMPLETED
+    assert [name for name, _ in calls] == ['create', 'resize', 'restart']
+    assert iteration.phases['clone'].status == STATUS_SKIPPED
+    assert iteration.phases['snapshot'].status == STATUS_SKIPPED
+
+
+def test_capacity_reached_in_create():
+    def create(vms):
+        raise CapacityReached('0/3 nodes are available', vms=['vm-1'])
+    calls = []
+    iteration = CapacityIteration(1, _handlers(calls, create=create)).run()
+    assert iteration.state == STATE_CAPACITY
+    assert iteration.capacity_reached and not iteration.succeeded
+    assert iteration.vms == ['vm-1']
+    assert iteration.phases['create'].status == STATUS_FAILED
+    assert iteration.phases['create'].error == '0/3 nodes are available'
+    assert iteration.phases['resize'].status == STATUS_PENDING
+    assert calls == []
+
+
+def test_phase_failure_stops_the_iteration():
+    def clone(vms):
+        raise PhaseFailed('clone timed out', vms=vms[:1])
+    calls = []
+    iteration = CapacityIteration(1, _handlers(calls, clone=clone)).run()
+    assert iteration.state == STATE_FAILED
+    assert iteration.vms == ['vm-1']
+    assert [name for name, _ in calls] == ['create', 'resize']
+    assert iteration.phases['clone'].to_dict()['error'] == 'clone timed out'
+    assert iteration.phases['restart'].status == STATUS_PENDING
+
+
+def test_unexpected_error_fails_the_phase_and_keeps_the_vms():
+    def restart(vms):
+        raise KeyError('status')
+    iteration = CapacityIteration(1, _handlers([], restart=restart)).run()
+    assert iteration.state == STATE_FAILED
+    assert iteration.vms == ['vm-1', 'vm-2']
+    assert iteration.phases['restart'].status == STATUS_FAILED
+
+
+def test_capacity_outside_create_is_rejected():
+    def resize(vms):
+        raise CapacityReached('no space left')
+    # capacity is only an end state of the create phase
+    with pytest.raises(RuntimeError):
+        CapacityIteration(1, _handlers([], resize=resize)).run()
+
+
+@pytest.mark.parametrize('skip', [['create'], ['bogus'], ['resize', 'bogus']])
+def test_invalid_skip(skip):
+    with pytest.raises(ValueError):
+        CapacityIteration(1, _handlers([]), skip=skip)
+
+
+def test_skipped_phases_from_args():
+    args = SimpleNamespace(skip_resize=True, skip_clone=False, skip_restart=True)
+    assert skipped_phases(args) == ['resize', 'restart']
+
+
+def test_phase_summary_excludes_warmup():
+    measured = CapacityIteration(2, _handlers([]), skip=['snapshot']).run().to_dict()
+    warmup = CapacityIteration(1, _handlers([]), warmup=True).run().to_dict()
+    summary = phase_summary([warmup, measured])
+    assert summary['create'][STATUS_COMPLETED] == 1
+    assert summary['snapshot'][STATUS_SKIPPED] == 1
+    assert summary['snapshot'][STATUS_COMPLETED] == 0
+    assert summary['create']['duration_sec']['count'] == 1
@@ -0,0 +1,54 @@
+"""Token bucket and worker pool of utils/concurrency.py."""
+import time
+
+from utils.concurrency import RateLimiter, WorkerPool, run_parallel
+
+
+def test_disabled_limiter_never_blocks():
+    limiter = RateLimiter(qps=0)
+    assert not limiter.enabled
+    start = time.monotonic()
+    for _ in range(1000):
+        limiter.wait()
+    assert time.monotonic() - start < 0.5
+
+
+def test_burst_is_served_immediately():
+    limiter = RateLimiter(qps=1, burst=5)
+    start = time.monotonic()
+    for _ in range(5):
+        limiter.wait()
+    assert time.monotonic() - start < 0.5
+
+
+def test_requests_beyond_the_burst_wait_for_tokens():
+    limiter = RateLimiter(qps=20, burst=2)
+    start = time.monotonic()
+    for _ in range(6):
+        limiter.wait()
+    # 4 requests beyond the burst at 20 per second take at least 0.2s
+    assert time.monotonic() - start >= 0.18
+
+
+def test_burst_is_at_least_one():
+    assert RateLimiter(qps=5, burst=0).burst == 1
+
+
+def test_worker_pool_limits_the_start_rate():
+    start = time.monotonic()
+    with WorkerPool(concurrency=4, qps=20, burst=1) as pool:
+        futures = [pool.submit(lambda x: x * 2, i) for i in range(5)]
+    assert [f.result() for f in futures] == [0, 2, 4, 6, 8]
+    # 4 calls beyond the burst at 20 per second
+    assert time.monotonic() - start >= 0.18
+
+
+def test_run_parallel_returns_every_result():
+    def add(item, offset):
+        if item == 3:
+            raise ValueError(item)
+        return item + offset
+
+    results = sorted(run_parallel(add, [1, 2, 3], concurrency=2, args=(10,)), key=lambda r: r[0])
+    assert [(item, result) for item, result, _ in results] == [(1, 11), (2, 12), (3, None)]
+    assert isinstance(results[2][2], ValueError)
@@ -0,0 +1,16 @@
+"""Namespace matching of utils/diagnostics.py."""
+import pytest
+
+from utils.diagnostics import in_namespaces
+
+
+@pytest.mark.parametrize('namespace, expected', [
+    ('bench-1', True),
+    ('vm-1', True),
+    # A namespace given by name is not a prefix
+    ('vm-10', False),
+    ('other', False),
+    (None, False),
+])
+def test_in_namespaces(namespace, expected):
+    assert in_namespaces(namespace, ['bench-'], ['vm-1']) is expected
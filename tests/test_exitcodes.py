@@ -0,0 +1,11 @@
+"""The wrapper's exit codes of virtbench/utils/exitcodes.py."""
+from virtbench.utils import exitcodes
+
+# Codes workload scripts return: success, error, failure-recovery verdicts, Ctrl-C
+SCRIPT_CODES = {0, 1, 2, 3, 4, 5, 130}
+
+
+def test_exit_codes_are_distinct_and_clear_of_script_codes():
+    codes = [value for name, value in vars(exitcodes).items() if name.endswith('_EXIT')]
+    assert len(codes) == len(set(codes))
+    assert not set(codes) & SCRIPT_CODES
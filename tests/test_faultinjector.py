@@ -0,0 +1,151 @@
+"""Fault commands, storage pod listing, the KVDB quorum gate and the chaos comparison of utils/faultinjector.py."""
+import json
+
+import pytest
+
+from utils import faultinjector
+from utils.faultinjector import (
+    FaultInjector, build_injection_command, chaos_summary, get_storage_pods, storage_pause_command,
+)
+from utils.timing import MonotonicTimestamp
+
+SEC = 1_000_000_000
+
+
+def test_build_injection_command_restores_itself():
+    assert build_injection_command('kubelet-stop', 30, [6443]) == \
+        'systemctl stop kubelet; sleep 30; systemctl start kubelet'
+    command = build_injection_command('network-partition', 45, [6443, 2379])
+    add, sleep, remove = command.partition('; sleep 45; ')
+    assert 'iptables -I OUTPUT -p tcp --dport 2379 -j DROP' in add
+    assert 'iptables -I INPUT -p tcp --dport 10250 -j DROP' in add
+    assert remove == add.replace('iptables -I', 'iptables -D')
+
+
+def test_build_injection_command_unknown_mode():
+    with pytest.raises(ValueError):
+        build_injection_command('storage-pod-kill', 30, [6443])
+
+
+def test_storage_pause_command_does_not_match_itself():
+    command = storage_pause_command('px-storage', 20)
+    assert command == "pkill -STOP -f '[p]x-storage'; sleep 20; pkill -CONT -f '[p]x-storage'"
+
+
+def test_fault_injector_rejects_unknown_modes():
+    with pytest.raises(ValueError):
+        FaultInjector('drain')
+
+
+def injector(monkeypatch, safe, allow_quorum_loss=False):
+    """A storage-pod-pause injector on Portworx whose faults succeed right away and whose quorum check returns safe."""
+    fault = FaultInjector('storage-pod-pause', duration=0, allow_quorum_loss=allow_quorum_loss)
+    fault._track_kvdb = True
+    monkeypatch.setattr(faultinjector, 'check_quorum_safe', lambda node, logger=None: safe)
+    monkeypatch.setattr(faultinjector, 'create_node_exec_pod', lambda *args, **kwargs: True)
+    monkeypatch.setattr(FaultInjector, '_start_kvdb_monitor', lambda self, node: None)
+    return fault
+
+
+@pytest.mark.parametrize('safe, allow_quorum_loss, injected', [
+    (True, False, True),
+    (False, False, False),
+    (False, True, True),
+    # Without a readable KVDB status the fault is injected
+    (None, False, True),
+])
+def test_inject_checks_kvdb_quorum(monkeypatch, safe, allow_quorum_loss, injected):
+    fault = injector(monkeypatch, safe, allow_quorum_loss)
+    window = fault._inject('w1', 1)
+    assert (window is not None) is injected
+    assert fault.skipped == (0 if injected else 1)
+
+
+class FakeMonitor:
+    def __init__(self):
+        self.baseline = {'healthy': 3}
+        self.samples = [{'reachable': True, 'healthy': 3}]
+        self.stopped = False
+
+    def stop(self):
+        self.stopped = True
+
+    def summary(self, start):
+        return {'quorum_lost': False, 'failover_seconds': 12.5}
+
+
+def test_inject_times_the_kvdb_failover(monkeypatch):
+    fault = injector(monkeypatch, True)
+    monitor = FakeMonitor()
+    monkeypatch.setattr(FaultInjector, '_start_kvdb_monitor', lambda self, node: monitor)
+    window = fault._inject('w1', 1)
+    assert monitor.stopped
+    assert window['kvdb']['failover_seconds'] == 12.5
+
+
+def pod(name, node, ready=True, deleting=False):
+    metadata = {'name': name}
+    if deleting:
+        metadata['deletionTimestamp'] = '2024-01-01T00:00:00Z'
+    return {'metadata': metadata, 'spec': {'nodeName': node},
+            'status': {'conditions': [{'type': 'Ready', 'status': 'True' if ready else 'False'}]}}
+
+
+def test_get_storage_pods(monkeypatch):
+    listings = {
+        'name=portworx': [pod('px-1', 'w1'), pod('px-2', 'w2', ready=False), pod('px-3', 'w1', deleting=True)],
+        'app=px-csi-driver': [pod('csi-1', 'w1')],
+    }
+    monkeypatch.setattr(faultinjector, 'run_kubectl_command',
+                        lambda args, **kwargs: (0, json.dumps({'items': listings[args[5]]}), ''))
+    pods = get_storage_pods(['portworx:name=portworx', 'portworx:app=px-csi-driver'], 'w1')
+    assert [(p['name'], p['ready']) for p in pods] == [('px-1', True), ('px-3', False), ('csi-1', True)]
+    assert all(p['namespace'] == 'portworx' for p in pods)
+
+
+def test_get_storage_pods_skips_failed_listings(monkeypatch):
+    monkeypatch.setattr(faultinjector, 'run_kubectl_command', lambda args, **kwargs: (1, '', 'forbidden'))
+    assert get_storage_pods(['portworx:name=portworx'], None) == []
+
+
+def at(seconds):
+    return MonotonicTimestamp(int(seconds * SEC), 1_700_000_000 * SEC + int(seconds * SEC))
+
+
+def test_chaos_summary():
+    # (namespace, running, ping, clone, success); vm-hit and vm-failed run while w1 is down
+    results = [
+        ('vm-1', 10.0, 12.0, 5.0, True),
+        ('vm-2', 10.0, 12.0, 5.0, True),
+        ('vm-hit', 20.0, 24.0, 9.0, True),
+        ('vm-failed', 30.0, None, None, False),
+    ]
+    start_times = {'vm-1': at(0), 'vm-2': at(0), 'vm-hit': at(100), 'vm-failed': at(100)}
+    windows = [{'mode': 'kubelet-stop', 'node': 'w1', 'start': at(110), 'end': at(170),
+                'recovered': True, 'detail': None}]
+    summary = chaos_summary(results, start_times, windows)
+    assert summary['under_failure'] == {'vm-hit', 'vm-failed'}
+    assert (summary['vms_under_failure'], summary['vms_no_failure'], summary['failed_under_failure']) == (2, 2, 1)
+    assert summary['faults'][0]['duration_sec'] == 60.0
+    running = next(m for m in summary['metrics'] if m['metric'] == 'running_time_sec')
+    assert running['under_failure']['count'] == 1
+    assert running['degradation_sec'] == 10.0
+    assert running['degradation_pct'] == 100.0
+
+
+def test_chaos_summary_kvdb():
+    windows = [{'mode': 'reboot', 'node': node, 'start': at(0), 'end': at(60), 'recovered': True, 'detail': None,
+                'kvdb': {'quorum_lost': lost, 'failover_seconds': failover}}
+               for node, lost, failover in [('w1', False, 20.0), ('w2', True, 40.0), ('w3', False, None)]]
+    summary = chaos_summary([], {}, windows)
+    assert summary['faults'][0]['kvdb']['failover_seconds'] == 20.0
+    kvdb = summary['kvdb']
+    assert (kvdb['faults_tracked'], kvdb['quorum_lost']) == (3, 1)
+    assert (kvdb['failover_sec']['count'], kvdb['failover_sec']['avg']) == (2, 30.0)
+
+
+def test_chaos_summary_skip_clone():
+    summary = chaos_summary([('vm-1', 10.0, 12.0, None, True)], {'vm-1': at(0)}, [], skip_clone=True)
+    assert [m['metric'] for m in summary['metrics']] == ['running_time_sec', 'ping_time_sec']
+    assert summary['metrics'][0]['degradation_sec'] is None
+    assert summary['kvdb'] is None
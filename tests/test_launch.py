@@ -0,0 +1,45 @@
+"""Command lines of remotely requested runs (virtbench/utils/launch.py)."""
+import os
+
+import pytest
+
+from virtbench.utils.launch import option_args, results_since, virtbench_command
+
+
+def test_option_args():
+    args = option_args({'start': 1, 'save-results': True, 'cleanup': False, 'node': ['w1', 'w2'], 'skip': None})
+    assert args == ['--start', '1', '--save-results', '--node', 'w1', '--node', 'w2']
+
+
+@pytest.mark.parametrize('options', [
+    {'--start': 1},
+    {'start; rm -rf /': 1},
+    {'Start': 1},
+    {'': 1},
+    ['start'],
+])
+def test_option_args_rejects_invalid_names(options):
+    with pytest.raises(ValueError):
+        option_args(options)
+
+
+def test_virtbench_command():
+    cmd = virtbench_command('vm-ops drain', args={'node': 'w1'}, options={'log-level': 'debug'}, run_uuid='u-1')
+    assert cmd == ['virtbench', '--uuid', 'u-1', '--log-level', 'debug', 'vm-ops', 'drain', '--node', 'w1']
+
+
+@pytest.mark.parametrize('workload', ['', 'vm-clone; ls', '../vm-clone', 'vm-ops drain now', 'VM-CLONE', None])
+def test_virtbench_command_rejects_invalid_workloads(workload):
+    with pytest.raises(ValueError):
+        virtbench_command(workload)
+
+
+def test_results_since(tmp_path):
+    for name, mtime in [('vm-clone/new/summary_a.json', 200), ('vm-clone/old/summary_b.json', 50),
+                        ('multi-cluster/summary_c.json', 200), ('vm-clone/new/results.csv', 200)]:
+        path = tmp_path / name
+        path.parent.mkdir(parents=True, exist_ok=True)
+        path.write_text('{}')
+        os.utime(path, (mtime, mtime))
+    assert results_since(tmp_path, 100, exclude='multi-cluster') == [tmp_path / 'vm-clone/new/summary_a.json']
+    assert results_since(tmp_path / 'missing', 0) == []
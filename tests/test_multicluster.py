@@ -0,0 +1,48 @@
+"""Stopping the workload scripts of virtbench/utils/multicluster.py."""
+import signal
+import subprocess
+
+from virtbench.utils import multicluster
+from virtbench.utils.multicluster import _stop
+
+
+class FakeProcess:
+    def __init__(self, exits=True):
+        self.exits = exits
+        self.signals = []
+        self.killed = False
+
+    def poll(self):
+        return None
+
+    def send_signal(self, signum):
+        self.signals.append(signum)
+
+    def wait(self, timeout=None):
+        if not self.exits and not self.killed:
+            raise subprocess.TimeoutExpired('script', timeout)
+        return 0
+
+    def kill(self):
+        self.killed = True
+
+
+def test_stop_sends_sigint_when_terminated():
+    process = FakeProcess()
+    _stop([{'process': process}], interrupted=False)
+    assert process.signals == [signal.SIGINT]
+    assert not process.killed
+
+
+def test_stop_after_ctrl_c_only_waits():
+    # Ctrl-C already reached the scripts through the terminal
+    process = FakeProcess()
+    _stop([{'process': process}], interrupted=True)
+    assert process.signals == []
+
+
+def test_stop_kills_scripts_past_the_grace_period(monkeypatch):
+    monkeypatch.setattr(multicluster, 'STOP_GRACE_SEC', 0)
+    stuck = FakeProcess(exits=False)
+    _stop([{'process': stuck}, {'name': 'not started'}], interrupted=False)
+    assert stuck.killed
@@ -0,0 +1,30 @@
+"""Diagnostics bundles of virtbench/utils/orchestration.py."""
+from types import SimpleNamespace
+
+from virtbench.utils import orchestration
+from virtbench.utils.orchestration import DIAGNOSTICS_ENV, _collect_diagnostics
+
+
+def collected(monkeypatch, cmd, returncode=1):
+    calls = []
+    monkeypatch.delenv(DIAGNOSTICS_ENV, raising=False)
+    monkeypatch.setattr(orchestration.subprocess, 'run', lambda cmd, **kwargs: calls.append(cmd))
+    ctx = SimpleNamespace(info_name='migration', obj=SimpleNamespace(dry_run=False))
+    _collect_diagnostics(ctx, cmd, '/repo', returncode, 120.0)
+    return calls
+
+
+def test_single_namespaces_are_matched_exactly(monkeypatch):
+    calls = collected(monkeypatch, ['script.py', '--single-namespace', 'vm-1', '--namespace-prefix', 'bench'])
+    cmd = calls[0]
+    assert cmd[cmd.index('--namespace') + 1] == 'vm-1'
+    assert cmd[cmd.index('--namespace-prefix') + 1] == 'bench'
+    assert cmd.count('--namespace') == 1
+
+
+def test_no_bundle_without_namespaces(monkeypatch):
+    assert collected(monkeypatch, ['script.py', '--vm-name', 'vm-1']) == []
+
+
+def test_no_bundle_after_a_successful_run(monkeypatch):
+    assert collected(monkeypatch, ['script.py', '--namespace-prefix', 'bench'], returncode=0) == []
@@ -0,0 +1,163 @@
+"""KVDB status parsing, the quorum check and failover timing of utils/portworx.py."""
+import json
+from datetime import timedelta
+
+import pytest
+
+from utils import portworx
+from utils.portworx import KvdbMonitor, check_quorum_safe, get_kvdb_status
+from utils.timing import MonotonicTimestamp
+
+POD = {'name': 'px-1', 'namespace': 'portworx', 'node': 'w1'}
+
+
+def member(name, healthy=True, leader=False, urls=()):
+    return {'Name': name, 'IsHealthy': healthy, 'Leader': leader, 'PeerUrls': list(urls), 'ClientUrls': []}
+
+
+def kvdb_status(members):
+    healthy = sum(1 for m in members if m['IsHealthy'])
+    total = len(members)
+    return {
+        'members': [{'name': m['Name'], 'healthy': m['IsHealthy'], 'leader': m['Leader'], 'urls': m['PeerUrls']}
+                    for m in members],
+        'total': total,
+        'healthy': healthy,
+        'quorum': total // 2 + 1,
+        'has_quorum': healthy >= total // 2 + 1,
+        'leader': next((m['Name'] for m in members if m['Leader']), None),
+    }
+
+
+@pytest.mark.parametrize('payload', [
+    # pxctl returns either a map keyed by member ID or a list of members
+    {'id-1': member('w1', leader=True), 'id-2': member('w2'), 'id-3': member('w3', healthy=False)},
+    [member('w1', leader=True), member('w2'), member('w3', healthy=False)],
+])
+def test_get_kvdb_status(monkeypatch, payload):
+    monkeypatch.setattr(portworx, 'run_pxctl', lambda pod, args, logger=None: json.dumps(payload))
+    status = get_kvdb_status(POD)
+    assert (status['total'], status['healthy'], status['quorum']) == (3, 2, 2)
+    assert status['has_quorum']
+    assert status['leader'] == 'w1'
+
+
+@pytest.mark.parametrize('output', [None, 'not json'])
+def test_get_kvdb_status_unreadable(monkeypatch, output):
+    monkeypatch.setattr(portworx, 'run_pxctl', lambda pod, args, logger=None: output)
+    assert get_kvdb_status(POD) is None
+
+
+@pytest.mark.parametrize('members, node, expected', [
+    # Losing one of three healthy members keeps quorum
+    ([member('w1'), member('w2'), member('w3')], 'w1', True),
+    # Losing a healthy member when one is already down loses it
+    ([member('w1'), member('w2'), member('w3', healthy=False)], 'w1', False),
+    # A node hosting no member, matched by peer URL here, is always safe
+    ([member('a', urls=['http://10.0.0.1:9019']), member('b', urls=['http://10.0.0.2:9019']),
+      member('c', healthy=False, urls=['http://10.0.0.3:9019'])], 'w9', True),
+    ([member('a', urls=['http://10.0.0.1:9019']), member('b', urls=['http://10.0.0.2:9019']),
+      member('c', healthy=False, urls=['http://10.0.0.3:9019'])], 'w2', False),
+])
+def test_check_quorum_safe(monkeypatch, members, node, expected):
+    addresses = {'w1': ['w1', '10.0.0.1'], 'w2': ['w2', '10.0.0.2'], 'w9': ['w9', '10.0.0.9']}
+    monkeypatch.setattr(portworx, 'find_px_pod', lambda exclude_nodes=None, logger=None: POD)
+    monkeypatch.setattr(portworx, 'get_kvdb_status', lambda pod, logger=None: kvdb_status(members))
+    monkeypatch.setattr(portworx, 'get_node_addresses', lambda node_name, logger=None: addresses[node_name])
+    assert check_quorum_safe(node) is expected
+
+
+def test_check_quorum_safe_without_portworx(monkeypatch):
+    monkeypatch.setattr(portworx, 'find_px_pod', lambda exclude_nodes=None, logger=None: None)
+    assert check_quorum_safe('w1') is None
+
+
+START = MonotonicTimestamp(1_000 * 10**9, 1_700_000_000 * 10**9)
+
+
+def sample(offset, healthy, leader='w1', total=3, reachable=True):
+    return {'timestamp': START + timedelta(seconds=offset), 'reachable': reachable, 'healthy': healthy,
+            'total': total, 'has_quorum': healthy >= total // 2 + 1, 'leader': leader}
+
+
+def monitor(samples, baseline_healthy=3, baseline_leader='w1'):
+    m = KvdbMonitor('w1')
+    m.baseline = {'healthy': baseline_healthy, 'total': 3, 'leader': baseline_leader}
+    m.samples = samples
+    return m
+
+
+def test_summary_failover():
+    result = monitor([
+        sample(-5, 3),
+        sample(5, 3),
+        sample(10, 2, leader='w2'),
+        sample(15, 2, leader='w2'),
+        sample(40, 3, leader='w2'),
+    ]).summary(START)
+    assert not result['quorum_lost']
+    assert result['degraded_seconds'] == 10
+    assert result['leader_change_seconds'] == 10
+    assert result['new_leader'] == 'w2'
+    assert result['failover_seconds'] == 40
+    assert result['samples'] == 5
+
+
+def test_summary_quorum_loss():
+    result = monitor([
+        sample(5, 3),
+        sample(10, 1),
+        sample(25, 1),
+        sample(30, 2),
+        sample(60, 3),
+    ]).summary(START)
+    assert result['quorum_lost']
+    assert result['quorum_lost_seconds'] == 20
+    assert result['degraded_seconds'] == 10
+    assert result['failover_seconds'] == 60
+    assert result['leader_change_seconds'] is None
+
+
+def test_summary_unknown_samples_are_not_a_quorum_loss():
+    # Unreadable samples keep the last known state (here: still degraded)
+    result = monitor([
+        sample(5, 3),
+        sample(10, 2),
+        sample(15, 2, reachable=False),
+        sample(20, 2, reachable=False),
+        sample(30, 3),
+    ]).summary(START)
+    assert not result['quorum_lost']
+    assert result['unknown_samples'] == 2
+    assert result['degraded_seconds'] == 10
+    assert result['failover_seconds'] == 30
+
+
+def test_monitor_keeps_the_last_known_state(monkeypatch):
+    statuses = [{'healthy': 2, 'total': 3, 'has_quorum': True, 'leader': 'w2'}, None]
+    m = KvdbMonitor('w1', poll_interval=0)
+    m.baseline = {'healthy': 3, 'total': 3, 'has_quorum': True, 'leader': 'w1'}
+    m._pod = {'name': 'px-2', 'namespace': 'portworx', 'node': 'w2'}
+
+    def status(pod, logger=None):
+        if len(statuses) == 1:
+            m._stop.set()
+        return statuses.pop(0)
+    monkeypatch.setattr(portworx, 'get_kvdb_status', status)
+    monkeypatch.setattr(portworx, 'find_px_pod', lambda exclude_nodes=None, logger=None: None)
+    m._run()
+    assert [(s['reachable'], s['healthy'], s['has_quorum'], s['leader']) for s in m.samples] == \
+        [(True, 2, True, 'w2'), (False, 2, True, 'w2')]
+
+
+def test_summary_ignores_samples_before_start():
+    result = monitor([sample(-10, 2), sample(5, 3)]).summary(START)
+    assert result['degraded_seconds'] is None
+    assert result['failover_seconds'] is None
+
+
+def test_summary_without_baseline():
+    m = KvdbMonitor('w1')
+    result = m.summary(START)
+    assert result['baseline_healthy'] is None
+    assert result['failover_seconds'] is None
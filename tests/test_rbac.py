@@ -0,0 +1,113 @@
+"""Permission audit of virtbench/utils/rbac.py."""
+import json
+import subprocess
+from types import SimpleNamespace
+
+import pytest
+
+from virtbench.utils import rbac
+from virtbench.utils.rbac import (
+    CLEANUP, PERMISSION_CHECK_ENV, access_review, audit_permissions, cluster_kubectl, permission_name,
+    required_permissions,
+)
+
+
+@pytest.mark.parametrize('permission, name', [
+    (('create', 'kubevirt.io', 'virtualmachines', None), 'create virtualmachines.kubevirt.io'),
+    (('create', '', 'pods', 'exec'), 'create pods/exec'),
+    (('delete', '', 'namespaces', None), 'delete namespaces'),
+])
+def test_permission_name(permission, name):
+    assert permission_name(permission) == name
+
+
+def test_required_permissions_adds_flag_permissions_once():
+    base = required_permissions('vm-clone', ['--start', '1'])
+    permissions = required_permissions('vm-clone', ['--cleanup', '--cleanup-on-failure'])
+    assert permissions == base + [p for p in CLEANUP if p not in base]
+    assert len(permissions) == len(set(permissions))
+
+
+def test_required_permissions_of_unknown_workloads():
+    # Workloads the audit does not know are not audited, whatever their flags
+    assert required_permissions('results', ['--cleanup']) == []
+
+
+def test_cluster_kubectl():
+    assert cluster_kubectl(None) == ['kubectl']
+    assert cluster_kubectl({'name': 'east', 'kubeconfig': '/kc', 'context': 'east'}) == \
+        ['kubectl', '--kubeconfig', '/kc', '--context', 'east']
+
+
+def review(allowed):
+    return {'kind': 'SelfSubjectAccessReview', 'status': {'allowed': allowed}}
+
+
+def fake_kubectl(monkeypatch, stdout, returncode=0, calls=None):
+    def run(cmd, input=None, **kwargs):
+        if calls is not None:
+            calls.append((cmd, input))
+        return subprocess.CompletedProcess(cmd, returncode, stdout=json.dumps(stdout), stderr='forbidden')
+    monkeypatch.setattr(rbac.subprocess, 'run', run)
+
+
+PERMISSIONS = [('create', '', 'namespaces', None), ('create', '', 'pods', 'exec')]
+
+
+def test_access_review(monkeypatch):
+    calls = []
+    fake_kubectl(monkeypatch, {'kind': 'List', 'items': [review(True), review(False)]}, calls=calls)
+    assert access_review(['kubectl'], PERMISSIONS) == [True, False]
+    cmd, documents = calls[0]
+    assert cmd == ['kubectl', 'create', '-f', '-', '-o', 'json']
+    assert 'subresource: exec' in documents
+
+
+def test_access_review_single_permission(monkeypatch):
+    fake_kubectl(monkeypatch, review(True))
+    assert access_review(['kubectl'], PERMISSIONS[:1]) == [True]
+
+
+@pytest.mark.parametrize('stdout, returncode', [
+    ({}, 1),
+    ({'kind': 'List', 'items': [review(True)]}, 0),
+])
+def test_access_review_without_an_answer(monkeypatch, stdout, returncode):
+    fake_kubectl(monkeypatch, stdout, returncode)
+    with pytest.raises(RuntimeError):
+        access_review(['kubectl'], PERMISSIONS)
+
+
+def context(workload='vm-clone', clusters=None, dry_run=False):
+    return SimpleNamespace(info_name=workload, obj=SimpleNamespace(dry_run=dry_run, clusters=clusters))
+
+
+def test_audit_permissions_denied(monkeypatch):
+    monkeypatch.delenv(PERMISSION_CHECK_ENV, raising=False)
+    monkeypatch.setattr(rbac, 'access_review', lambda kubectl, permissions: [False] + [True] * (len(permissions) - 1))
+    assert audit_permissions(context(), ['--start', '1']) is False
+
+
+def test_audit_permissions_allowed(monkeypatch):
+    monkeypatch.delenv(PERMISSION_CHECK_ENV, raising=False)
+    monkeypatch.setattr(rbac, 'access_review', lambda kubectl, permissions: [True] * len(permissions))
+    assert audit_permissions(context(clusters=[{'name': 'east'}, {'name': 'west'}]), []) is True
+
+
+def test_audit_permissions_unreachable_only_warns(monkeypatch):
+    monkeypatch.delenv(PERMISSION_CHECK_ENV, raising=False)
+
+    def unreachable(kubectl, permissions):
+        raise RuntimeError('connection refused')
+    monkeypatch.setattr(rbac, 'access_review', unreachable)
+    assert audit_permissions(context(), []) is True
+
+
+@pytest.mark.parametrize('dry_run, check', [(True, '1'), (False, '0')])
+def test_audit_permissions_skipped(monkeypatch, dry_run, check):
+    monkeypatch.setenv(PERMISSION_CHECK_ENV, check)
+
+    def fail(kubectl, permissions):
+        raise AssertionError('audited')
+    monkeypatch.setattr(rbac, 'access_review', fail)
+    assert audit_permissions(context(dry_run=dry_run), []) is True
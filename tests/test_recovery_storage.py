@@ -0,0 +1,154 @@
+"""Storage provider failure modes and KVDB tracking of failure-recovery/recovery-test.py."""
+import importlib.util
+import logging
+import os
+from argparse import Namespace
+
+import pytest
+
+SCRIPT = os.path.join(os.path.dirname(__file__), '..', 'failure-recovery', 'recovery-test.py')
+spec = importlib.util.spec_from_file_location('recovery_test', SCRIPT)
+recovery = importlib.util.module_from_spec(spec)
+spec.loader.exec_module(recovery)
+
+LOGGER = logging.getLogger('test')
+
+
+PROBE = {'ip': '10.0.0.5', 'boot_id': 'boot-1', 'uid': 'uid-1', 'started': True}
+
+
+@pytest.mark.parametrize('stamps, expected', [
+    # Writes every 0.2s, with a 3s gap after injection at t=100
+    ([99.0, 99.2, 100.0, 100.2, 103.2, 103.4], 2.8),
+    # Gaps before the injection are ignored
+    ([90.0, 95.0, 100.0, 100.2, 100.4], 0.0),
+])
+def test_collect_io_probe_stall(monkeypatch, stamps, expected):
+    def guest_exec(args, ip, command, logger, timeout=30):
+        if 'boot_id' in command:
+            return 'boot-1\n'
+        return '\n'.join(str(t) for t in stamps) + '\ngarbage\n'
+
+    monkeypatch.setattr(recovery, 'get_vmi_info', lambda namespace, name, logger: {'ip': '10.0.0.5', 'uid': 'uid-1'})
+    monkeypatch.setattr(recovery, 'guest_exec', guest_exec)
+    result = recovery.collect_io_probe(Namespace(vm_name='vm-1'), 'ns-1', PROBE, 100.0, LOGGER)
+    assert result == {'io_stall_seconds': expected, 'guest_restarted': False}
+
+
+def test_collect_io_probe_guest_restart(monkeypatch):
+    monkeypatch.setattr(recovery, 'get_vmi_info', lambda namespace, name, logger: {'ip': '10.0.0.5', 'uid': 'uid-1'})
+    monkeypatch.setattr(recovery, 'guest_exec', lambda args, ip, command, logger, timeout=30: 'boot-2\n')
+    result = recovery.collect_io_probe(Namespace(vm_name='vm-1'), 'ns-1', PROBE, 100.0, LOGGER)
+    # The probe log did not survive the restart, so no stall is measured
+    assert result == {'io_stall_seconds': None, 'guest_restarted': True}
+
+
+def test_collect_io_probe_restart_by_vmi_uid(monkeypatch):
+    monkeypatch.setattr(recovery, 'get_vmi_info', lambda namespace, name, logger: {'ip': '10.0.0.6', 'uid': 'uid-2'})
+    monkeypatch.setattr(recovery, 'guest_exec', lambda args, ip, command, logger, timeout=30: None)
+    probe = dict(PROBE, boot_id=None)
+    result = recovery.collect_io_probe(Namespace(vm_name='vm-1'), 'ns-1', probe, 100.0, LOGGER)
+    assert result['guest_restarted'] is True
+
+
+def test_summarize_by_storage_class():
+    results = [
+        {'storage_class': 'px-repl3', 'io_stall_seconds': 2.0, 'volume_failover_seconds': 10.0,
+         'guest_restarted': False},
+        {'storage_class': 'px-repl3', 'io_stall_seconds': 4.0, 'volume_failover_seconds': -1,
+         'guest_restarted': True},
+        {'storage_class': 'px-repl1', 'io_stall_seconds': None, 'volume_failover_seconds': 30.0,
+         'guest_restarted': False},
+    ]
+    summary = recovery.summarize_by_storage_class(results)
+    assert list(summary) == ['px-repl1', 'px-repl3']
+    assert summary['px-repl3']['vms'] == 2
+    stall = summary['px-repl3']['io_stall_seconds']
+    assert (stall['avg'], stall['min'], stall['max'], stall['count']) == (3.0, 2.0, 4.0, 2)
+    # A volume that did not fail over (-1) is left out of the failover times
+    assert summary['px-repl3']['volume_failover_seconds']['count'] == 1
+    assert summary['px-repl3']['guest_restarts'] == 1
+    assert summary['px-repl1']['io_stall_seconds']['count'] == 0
+
+
+def kvdb_args(**kwargs):
+    values = dict(track_kvdb=True, mode='inject', allow_quorum_loss=False, node='w1', poll_interval=5)
+    values.update(kwargs)
+    return Namespace(**values)
+
+
+class FakeMonitor:
+    started = True
+
+    def __init__(self, node, poll_interval, logger):
+        self.stopped = False
+
+    def start(self):
+        return self.started
+
+    def stop(self):
+        self.stopped = True
+
+    def summary(self, start_ts):
+        return {'failover_seconds': 12.0}
+
+
+@pytest.mark.parametrize('args, safe, proceed', [
+    (kvdb_args(), True, True),
+    (kvdb_args(), False, False),
+    (kvdb_args(allow_quorum_loss=True), False, True),
+    # Unknown quorum and manual failures only warn
+    (kvdb_args(), None, True),
+    (kvdb_args(mode='manual'), False, True),
+])
+def test_start_kvdb_tracking_checks_quorum(monkeypatch, args, safe, proceed):
+    monkeypatch.setattr(recovery, 'check_quorum_safe', lambda node, logger=None: safe)
+    monkeypatch.setattr(recovery, 'KvdbMonitor', FakeMonitor)
+    started, monitor = recovery.start_kvdb_tracking(args, LOGGER)
+    assert started is proceed
+    assert (monitor is not None) is proceed
+
+
+def test_start_kvdb_tracking_not_asked(monkeypatch):
+    def fail(node, logger=None):
+        raise AssertionError('quorum checked')
+    monkeypatch.setattr(recovery, 'check_quorum_safe', fail)
+    assert recovery.start_kvdb_tracking(kvdb_args(track_kvdb=False), LOGGER) == (True, None)
+
+
+def test_start_kvdb_tracking_unreachable(monkeypatch):
+    monkeypatch.setattr(recovery, 'check_quorum_safe', lambda node, logger=None: True)
+    monkeypatch.setattr(recovery, 'KvdbMonitor', type('Unreachable', (FakeMonitor,), {'started': False}))
+    assert recovery.start_kvdb_tracking(kvdb_args(), LOGGER) == (True, None)
+
+
+def storage_args(**kwargs):
+    return kvdb_args(failure_mode='storage-pod-kill', vm_name='vm-1', concurrency=2, qps=0, burst=10, **kwargs)
+
+
+def test_storage_failure_respects_the_quorum_check(monkeypatch):
+    injected = []
+    monkeypatch.setattr(recovery, 'collect_vm_volumes', lambda namespaces, vm_name, logger: {})
+    monkeypatch.setattr(recovery, 'get_vm_storage_class', lambda ns, vm_name, logger: 'px-repl3')
+    monkeypatch.setattr(recovery, 'start_io_probe', lambda args, ns, logger: PROBE)
+    monkeypatch.setattr(recovery.time, 'sleep', lambda seconds: None)
+    monkeypatch.setattr(recovery, 'check_quorum_safe', lambda node, logger=None: False)
+    monkeypatch.setattr(recovery, 'inject_storage_failure', lambda args, logger: injected.append(args) or (False, {}))
+    assert recovery.run_storage_failure_test(storage_args(), ['ns-1'], LOGGER) == 1
+    assert injected == []
+
+
+@pytest.mark.parametrize('argv, required', [
+    (['--failure-mode', 'storage-pod-kill'], True),
+    (['--failure-mode', 'storage-pod-pause'], True),
+    (['--failure-mode', 'reboot', '--verify-data'], True),
+    (['--failure-mode', 'reboot'], False),
+    (['--failure-mode', 'storage-pod-kill', '--vm-password', 'secret'], False),
+])
+def test_vm_password_required_for_guest_ssh(monkeypatch, argv, required):
+    monkeypatch.setattr(recovery.sys, 'argv', ['recovery-test.py', '--mode', 'inject', '--node', 'w1'] + argv)
+    if required:
+        with pytest.raises(SystemExit):
+            recovery.parse_args()
+    else:
+        assert recovery.parse_args().node == 'w1'
@@ -0,0 +1,130 @@
+"""Scanning, pruning and age parsing of virtbench/utils/results_catalog.py."""
+from datetime import datetime, timedelta
+
+import pytest
+
+from virtbench.utils.results_catalog import delete_run, parse_age, scan_runs, select_prunable
+
+
+def _run(run_id, workloads, days_ago):
+    timestamp = (datetime.now() - timedelta(days=days_ago)).isoformat(timespec='seconds')
+    return {'id': run_id, 'workloads': workloads, 'timestamp': timestamp}
+
+
+# Newest first, as scan_runs() returns them
+RUNS = [
+    _run('clone-3', ['vm-clone'], 1),
+    _run('migration-2', ['migration'], 2),
+    _run('clone-2', ['vm-clone'], 10),
+    _run('both', ['vm-clone', 'migration'], 20),
+    _run('clone-1', ['vm-clone'], 40),
+    _run('migration-1', ['migration'], 50),
+]
+
+
+def _ids(runs):
+    return [run['id'] for run in runs]
+
+
+def test_keep_last_per_workload():
+    assert _ids(select_prunable(RUNS, keep_last=1)) == ['clone-2', 'both', 'clone-1', 'migration-1']
+
+
+def test_keep_last_keeps_a_run_recent_for_any_of_its_workloads():
+    # 'both' is the third vm-clone run but the second migration run
+    assert _ids(select_prunable(RUNS, keep_last=2)) == ['clone-1', 'migration-1']
+
+
+def test_older_than():
+    assert _ids(select_prunable(RUNS, older_than=timedelta(days=30))) == ['clone-1', 'migration-1']
+
+
+def test_keep_last_and_older_than_both_protect():
+    # clone-2 is outside the newest run of vm-clone but younger than 15 days
+    assert _ids(select_prunable(RUNS, keep_last=1, older_than=timedelta(days=15))) == ['both', 'clone-1',
+                                                                                      'migration-1']
+
+
+def test_workload_filter():
+    assert _ids(select_prunable(RUNS, keep_last=1, workloads=['migration'])) == ['migration-1']
+
+
+def test_keep_last_zero_without_age_prunes_everything():
+    assert _ids(select_prunable(RUNS, keep_last=0)) == _ids(RUNS)
+
+
+@pytest.mark.parametrize('text, expected', [
+    ('90m', timedelta(minutes=90)),
+    ('12h', timedelta(hours=12)),
+    ('30d', timedelta(days=30)),
+    (' 2W ', timedelta(weeks=2)),
+    ('1.5d', timedelta(hours=36)),
+])
+def test_parse_age(text, expected):
+    assert parse_age(text) == expected
+
+
+@pytest.mark.parametrize('text', ['', '30', 'd', '30s', '-1d', '1 d'])
+def test_parse_age_invalid(text):
+    with pytest.raises(ValueError):
+        parse_age(text)
+
+
+def _write(base_dir, path):
+    path = base_dir / path
+    path.parent.mkdir(parents=True, exist_ok=True)
+    path.write_text('{}')
+    return path
+
+
+def test_scan_runs(tmp_path):
+    _write(tmp_path, 'px/1-disk/20250101-120000_vm_clone/summary_vm_clone_results.json')
+    _write(tmp_path, 'px/1-disk/20250101-120000_vm_clone/results.csv')
+    _write(tmp_path, 'px/1-disk/20250102-120000_mixed/summary_migration_results.json')
+    _write(tmp_path, 'px/1-disk/20250102-120000_mixed/summary_custom.json')
+    _write(tmp_path, 'px/1-disk/notes/readme.txt')
+    runs = scan_runs(tmp_path)
+    assert _ids(runs) == ['px/1-disk/20250102-120000_mixed', 'px/1-disk/20250101-120000_vm_clone']
+    assert runs[0]['workloads'] == ['custom', 'migration']
+    assert runs[0]['timestamp'] == '2025-01-02T12:00:00'
+    assert runs[1]['labels'] == ['px', '1-disk']
+    assert runs[1]['files'] == 2
+
+
+def test_scan_runs_nested(tmp_path):
+    _write(tmp_path, 'summary_vm_clone_results.json')
+    _write(tmp_path, 'run-a/summary_vm_clone_results.json')
+    _write(tmp_path, 'run-a/results.csv')
+    _write(tmp_path, 'run-a/retry/summary_vm_clone_results.json')
+    runs = {run['id']: run for run in scan_runs(tmp_path)}
+    # Summaries in the results root are not a run, and a nested run's files are its own
+    assert sorted(runs) == ['run-a', 'run-a/retry']
+    assert (runs['run-a']['files'], runs['run-a/retry']['files']) == (2, 1)
+
+
+def test_delete_run_removes_empty_parents(tmp_path):
+    _write(tmp_path, 'px/1-disk/run-a/summary_vm_clone_results.json')
+    _write(tmp_path, 'px/2-disk/run-b/summary_vm_clone_results.json')
+    assert delete_run(tmp_path, {'id': 'px/1-disk/run-a'})
+    assert not (tmp_path / 'px' / '1-disk').exists()
+    assert (tmp_path / 'px' / '2-disk' / 'run-b').is_dir()
+
+
+def test_delete_run_keeps_nested_runs(tmp_path):
+    _write(tmp_path, 'run-a/summary_vm_clone_results.json')
+    _write(tmp_path, 'run-a/logs/clone.log')
+    nested = _write(tmp_path, 'run-a/retry/summary_vm_clone_results.json')
+    assert delete_run(tmp_path, {'id': 'run-a'})
+    assert [p.relative_to(tmp_path).as_posix() for p in tmp_path.rglob('*') if p.is_file()] == \
+        [nested.relative_to(tmp_path).as_posix()]
+    assert not (tmp_path / 'run-a' / 'logs').exists()
+
+
+def test_delete_run_already_deleted(tmp_path):
+    assert delete_run(tmp_path, {'id': 'run-gone'}) is False
+
+
+@pytest.mark.parametrize('run_id', ['..', '../elsewhere', '.'])
+def test_delete_run_outside_results_folder(tmp_path, run_id):
+    with pytest.raises(ValueError):
+        delete_run(tmp_path / 'results', {'id': run_id})
@@ -0,0 +1,136 @@
+"""Error classification and retry decisions of utils/retry.py."""
+import pytest
+
+from utils.retry import RetryPolicy, call_with_retries, classify_error, kubectl_verb, request_verb, retryable
+
+
+@pytest.mark.parametrize('output, expected', [
+    ('Error from server (TooManyRequests): the server has received too many requests', 'throttled'),
+    ('Unable to connect to the server: dial tcp 10.0.0.1:6443: i/o timeout', 'timeout'),
+    ('Operation cannot be fulfilled on virtualmachines.kubevirt.io "vm-1": the object has been modified',
+     'conflict'),
+    ('Error from server (ServiceUnavailable): the server is currently unable to handle the request',
+     'unavailable'),
+    ('The connection to the server 10.0.0.1:6443 was refused - did you specify the right host or port? '
+     'connection refused', 'connection'),
+    ('Error from server (NotFound): virtualmachines.kubevirt.io "vm-1" not found', None),
+    ('', None),
+    (None, None),
+])
+def test_classify_error(output, expected):
+    assert classify_error(output) == expected
+
+
+def test_classify_error_webhook_is_permanent():
+    # A refused connection to a webhook is the webhook's failure, not the API server's
+    output = ('Internal error occurred: failed calling webhook "mutate.kubevirt.io": '
+              'dial tcp 10.96.0.10:443: connect: connection refused')
+    assert classify_error(output) is None
+
+
+@pytest.mark.parametrize('args, expected', [
+    (['get', 'vm', '-n', 'ns'], 'get'),
+    (['-n', 'ns', 'delete', 'vm', 'vm-1'], 'delete'),
+    (['--context', 'apply', 'apply', '-f', '-'], 'apply'),
+    (['--kubeconfig', '/tmp/kc', '--request-timeout=30s', 'create', '-f', 'vm.yaml'], 'create'),
+    (['-n', 'ns'], None),
+    ([], None),
+])
+def test_kubectl_verb(args, expected):
+    assert kubectl_verb(args) == expected
+
+
+
+@pytest.mark.parametrize('args, expected', [
+    (['create', '-f', '-', '--dry-run=server'], 'dry-run'),
+    (['create', '-f', '-', '--dry-run=client'], 'dry-run'),
+    (['apply', '-f', '-', '--dry-run=none'], 'apply'),
+    (['create', '-f', '-', '--dry-run=false'], 'create'),
+    (['-n', 'ns', 'get', 'vmi'], 'get'),
+])
+def test_request_verb(args, expected):
+    assert request_verb(args) == expected
+
+
+@pytest.mark.parametrize('error_class, verb, expected', [
+    (None, 'get', False),
+    ('throttled', 'exec', True),
+    ('throttled', 'create', True),
+    ('timeout', 'get', True),
+    ('timeout', 'create', True),
+    ('timeout', 'patch', False),
+    ('connection', 'dry-run', True),
+    ('unavailable', 'exec', False),
+    ('conflict', 'apply', True),
+    ('conflict', 'create', False),
+    ('conflict', 'patch', False),
+    ('conflict', 'get', False),
+])
+def test_retryable(error_class, verb, expected):
+    assert retryable(error_class, verb) is expected
+
+
+THROTTLED = (1, '', 'Error from server (TooManyRequests): the server has received too many requests')
+NOT_FOUND = (1, '', 'Error from server (NotFound): virtualmachines.kubevirt.io "vm-1" not found')
+
+
+def calls(*results):
+    results = list(results)
+    made = []
+
+    def call():
+        made.append(len(made))
+        return results.pop(0)
+    return call, made
+
+
+def test_call_with_retries_retries_transient_errors():
+    call, made = calls(THROTTLED, THROTTLED, (0, 'ok', ''))
+    assert call_with_retries(call, 'kubectl get vm', RetryPolicy(retries=3, backoff=0)) == (0, 'ok', '')
+    assert len(made) == 3
+
+
+def test_call_with_retries_gives_up():
+    call, made = calls(THROTTLED, THROTTLED, THROTTLED)
+    assert call_with_retries(call, 'kubectl get vm', RetryPolicy(retries=2, backoff=0)) == THROTTLED
+    assert len(made) == 3
+
+
+@pytest.mark.parametrize('result, policy', [
+    (NOT_FOUND, RetryPolicy(retries=3, backoff=0)),
+    (THROTTLED, RetryPolicy(retries=3, backoff=0, retry_on=['timeout'])),
+    (THROTTLED, RetryPolicy(retries=0, backoff=0)),
+])
+def test_call_with_retries_does_not_retry(result, policy):
+    call, made = calls(result)
+    assert call_with_retries(call, 'kubectl get vm', policy) == result
+    assert len(made) == 1
+
+
+def test_policy_delay_is_capped():
+    policy = RetryPolicy(backoff=1.0, max_backoff=4.0)
+    assert 0.5 <= policy.delay(1) <= 1.0
+    assert 2.0 <= policy.delay(10) <= 4.0
+
+
+TIMEOUT = (1, '', 'Unable to connect to the server: dial tcp 10.0.0.1:6443: i/o timeout')
+ALREADY_EXISTS = (1, '', 'Error from server (AlreadyExists): virtualmachines.kubevirt.io "vm-1" already exists')
+
+
+def test_create_that_timed_out_and_then_exists_succeeded():
+    call, made = calls(TIMEOUT, ALREADY_EXISTS)
+    result = call_with_retries(call, 'kubectl create -f -', RetryPolicy(retries=3, backoff=0), verb='create')
+    assert result[0] == 0
+    assert len(made) == 2
+
+
+def test_create_that_already_existed_fails():
+    call, made = calls(ALREADY_EXISTS)
+    assert call_with_retries(call, 'kubectl create -f -', RetryPolicy(retries=3, backoff=0),
+                             verb='create') == ALREADY_EXISTS
+
+
+def test_timed_out_patch_is_not_retried():
+    call, made = calls(TIMEOUT, (0, 'ok', ''))
+    assert call_with_retries(call, 'kubectl patch vm', RetryPolicy(retries=3, backoff=0), verb='patch') == TIMEOUT
+    assert len(made) == 1
@@ -0,0 +1,177 @@
+"""Node selectors, affinity and taints of virtbench/utils/scheduling.py."""
+import json
+import subprocess
+from types import SimpleNamespace
+
+import pytest
+
+from utils import scheduling as vm_scheduling
+from virtbench.utils import scheduling
+from virtbench.utils.scheduling import (
+    TAINT_NODES_ENV, TAINTS_ENV, load_affinity_file, node_matches, parse_node_selector, parse_taint, parse_taints,
+    requirement_matches, taint_arg, taint_nodes, untaint_nodes,
+)
+
+NODE = {'metadata': {'name': 'w1', 'labels': {'disktype': 'ssd', 'zone': 'a', 'cores': '32'}}}
+
+
+def affinity(*terms):
+    return {'nodeAffinity': {'requiredDuringSchedulingIgnoredDuringExecution': {'nodeSelectorTerms': list(terms)}}}
+
+
+def test_parse_node_selector():
+    assert parse_node_selector('node-role.kubernetes.io/worker=, disktype = ssd') == \
+        {'node-role.kubernetes.io/worker': '', 'disktype': 'ssd'}
+
+
+@pytest.mark.parametrize('value', ['disktype', '=ssd', 'a=b,zone'])
+def test_parse_node_selector_invalid(value):
+    with pytest.raises(ValueError):
+        parse_node_selector(value)
+
+
+@pytest.mark.parametrize('operator, values, expected', [
+    ('In', ['ssd', 'nvme'], True),
+    ('NotIn', ['ssd'], False),
+    ('Exists', None, True),
+    ('DoesNotExist', None, False),
+])
+def test_requirement_matches(operator, values, expected):
+    assert requirement_matches({'key': 'disktype', 'operator': operator, 'values': values},
+                               NODE['metadata']['labels']) is expected
+
+
+@pytest.mark.parametrize('operator, value, expected', [
+    ('Gt', '16', True),
+    ('Lt', '16', False),
+    ('Gt', 'many', False),
+])
+def test_requirement_matches_numbers(operator, value, expected):
+    assert requirement_matches({'key': 'cores', 'operator': operator, 'values': [value]},
+                               NODE['metadata']['labels']) is expected
+
+
+def test_node_matches_selector():
+    assert node_matches(NODE, {'disktype': 'ssd'}, None)
+    assert not node_matches(NODE, {'disktype': 'hdd'}, None)
+
+
+def test_node_matches_any_affinity_term():
+    hdd = {'matchExpressions': [{'key': 'disktype', 'operator': 'In', 'values': ['hdd']}]}
+    by_name = {'matchFields': [{'key': 'metadata.name', 'operator': 'In', 'values': ['w1']}]}
+    assert node_matches(NODE, {}, affinity(hdd, by_name))
+    assert not node_matches(NODE, {}, affinity(hdd))
+    # Terms of pod (anti-)affinity do not restrict nodes
+    assert node_matches(NODE, {}, {'podAntiAffinity': {}})
+
+
+def test_load_affinity_file(tmp_path):
+    path = tmp_path / 'affinity.yaml'
+    path.write_text('affinity:\n  podAntiAffinity: {}\n')
+    assert load_affinity_file(str(path)) == {'podAntiAffinity': {}}
+
+
+@pytest.mark.parametrize('text', ['[]\n', 'nodeAffinity: {}\ntolerations: []\n', 'a: [\n'])
+def test_load_affinity_file_invalid(tmp_path, text):
+    path = tmp_path / 'affinity.yaml'
+    path.write_text(text)
+    with pytest.raises(ValueError):
+        load_affinity_file(str(path))
+
+
+@pytest.mark.parametrize('value, taint', [
+    ('dedicated=virt:NoSchedule', {'key': 'dedicated', 'value': 'virt', 'effect': 'NoSchedule'}),
+    ('virtbench.io/dedicated:NoExecute', {'key': 'virtbench.io/dedicated', 'effect': 'NoExecute'}),
+    (' gpu= :PreferNoSchedule ', {'key': 'gpu', 'effect': 'PreferNoSchedule'}),
+])
+def test_parse_taint(value, taint):
+    assert parse_taint(value) == taint
+
+
+@pytest.mark.parametrize('value', ['dedicated', 'dedicated=virt:NoRun', '=virt:NoSchedule', ':NoSchedule'])
+def test_parse_taint_invalid(value):
+    with pytest.raises(ValueError):
+        parse_taint(value)
+
+
+def test_taint_arg():
+    taints = parse_taints('a=1:NoSchedule, b:NoExecute')
+    assert [taint_arg(t) for t in taints] == ['a=1:NoSchedule', 'b:NoExecute']
+    assert [taint_arg(t, remove=True) for t in taints] == ['a:NoSchedule-', 'b:NoExecute-']
+
+
+def test_tolerations_match_the_taints(monkeypatch):
+    monkeypatch.setenv(TAINTS_ENV, 'a=1:NoSchedule,b:NoExecute')
+    assert vm_scheduling.tolerations() == [
+        {'key': 'a', 'operator': 'Equal', 'value': '1', 'effect': 'NoSchedule'},
+        {'key': 'b', 'operator': 'Exists', 'effect': 'NoExecute'},
+    ]
+
+
+def context(dry_run=False):
+    return SimpleNamespace(info_name='vm-clone', obj=SimpleNamespace(dry_run=dry_run, clusters=None))
+
+
+def fake_kubectl(monkeypatch, nodes, fail_taint=None):
+    """Answer kubectl get nodes with the nodes, and record taint calls (failing those of node fail_taint)."""
+    calls = []
+
+    def run(cmd, **kwargs):
+        if 'get' in cmd:
+            return subprocess.CompletedProcess(cmd, 0, stdout=json.dumps({'items': nodes}), stderr='')
+        calls.append(cmd[cmd.index('taint'):])
+        failed = cmd[cmd.index('taint') + 2] == fail_taint and not cmd[-1].endswith('-')
+        return subprocess.CompletedProcess(cmd, 1 if failed else 0, stdout='', stderr='denied' if failed else '')
+    monkeypatch.setattr(scheduling.subprocess, 'run', run)
+    return calls
+
+
+def node(name, taints=()):
+    return {'metadata': {'name': name}, 'spec': {'taints': list(taints)}}
+
+
+def test_taint_nodes_keeps_existing_taints(monkeypatch):
+    monkeypatch.setenv(TAINT_NODES_ENV, 'pool=bench')
+    monkeypatch.setenv(TAINTS_ENV, 'a=1:NoSchedule,b:NoExecute')
+    calls = fake_kubectl(monkeypatch, [node('w1'), node('w2', [{'key': 'b', 'effect': 'NoExecute'}])])
+    added = []
+    assert taint_nodes(context(), added)
+    assert calls == [['taint', 'nodes', 'w1', 'a=1:NoSchedule', 'b:NoExecute'],
+                     ['taint', 'nodes', 'w2', 'a=1:NoSchedule']]
+    untaint_nodes(added)
+    assert calls[2:] == [['taint', 'nodes', 'w1', 'a:NoSchedule-', 'b:NoExecute-'],
+                         ['taint', 'nodes', 'w2', 'a:NoSchedule-']]
+
+
+def test_taint_nodes_failure_removes_added_taints(monkeypatch):
+    monkeypatch.setenv(TAINT_NODES_ENV, 'pool=bench')
+    monkeypatch.setenv(TAINTS_ENV, 'a:NoSchedule')
+    calls = fake_kubectl(monkeypatch, [node('w1'), node('w2')], fail_taint='w2')
+    added = []
+    assert not taint_nodes(context(), added)
+    # The caller removes the taints added before the failure
+    assert [(name, taint['key']) for cluster, name, taint in added] == [('w1', 'a')]
+    untaint_nodes(added)
+    assert calls[-1] == ['taint', 'nodes', 'w1', 'a:NoSchedule-']
+
+
+def test_taint_nodes_without_matching_nodes(monkeypatch):
+    monkeypatch.setenv(TAINT_NODES_ENV, 'pool=none')
+    calls = fake_kubectl(monkeypatch, [])
+    assert not taint_nodes(context(), [])
+    assert calls == []
+
+
+def test_taint_nodes_dry_run(monkeypatch):
+    monkeypatch.setenv(TAINT_NODES_ENV, 'pool=bench')
+    calls = fake_kubectl(monkeypatch, [node('w1')])
+    added = []
+    assert taint_nodes(context(dry_run=True), added)
+    assert added == [] and calls == []
+
+
+def test_taint_nodes_not_asked(monkeypatch):
+    monkeypatch.delenv(TAINT_NODES_ENV, raising=False)
+    added = []
+    assert taint_nodes(context(), added)
+    assert added == []
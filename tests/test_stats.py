@@ -0,0 +1,81 @@
+"""Statistics and outliers of utils/stats.py."""
+import pytest
+
+from utils.stats import (
+    OUTLIER_SIGMA_ENV, STAT_FIELDS, describe, find_outliers, log_outliers, metric_outliers, outlier_sigma,
+    percentile, stat_columns, steady_state,
+)
+
+
+def test_percentile_nearest_rank():
+    values = [float(v) for v in range(1, 101)]
+    assert percentile(values, 50) == 50.0
+    assert percentile(values, 95) == 95.0
+    assert percentile(values, 100) == 100.0
+    assert percentile([7.0], 99) == 7.0
+    assert percentile([], 50) is None
+
+
+def test_describe_skips_none():
+    stats = describe([1.0, None, 3.0])
+    assert stats['count'] == 2
+    assert stats['avg'] == 2.0
+    assert stats['stddev'] == 1.0
+
+
+def test_describe_without_values():
+    stats = describe([])
+    assert stats['count'] == 0
+    assert stats['avg'] is None
+
+
+def test_describe_fields():
+    assert list(describe([1.0, 2.0])) == STAT_FIELDS
+
+
+def test_stat_columns_keep_the_existing_order():
+    assert stat_columns('metric', 'avg', 'max', 'min', 'count') == \
+        ['metric', 'avg', 'max', 'min', 'count', 'median', 'p90', 'p95', 'p99', 'stddev']
+
+
+def test_outlier_sigma_from_env(monkeypatch):
+    monkeypatch.setenv(OUTLIER_SIGMA_ENV, '2.5')
+    assert outlier_sigma() == 2.5
+    monkeypatch.setenv(OUTLIER_SIGMA_ENV, 'lots')
+    assert outlier_sigma() == 3.0
+
+
+def test_find_outliers():
+    samples = [(f"vm-{i}", 10.0) for i in range(20)] + [('vm-slow', 60.0)]
+    outliers = find_outliers(samples, sigma=3)
+    assert [o['item'] for o in outliers] == ['vm-slow']
+    assert outliers[0]['z_score'] > 3
+    assert outliers[0]['sigma'] == 3
+
+
+def test_find_outliers_needs_a_spread():
+    assert find_outliers([('a', 1.0), ('b', 1.0), ('c', 1.0)], sigma=0) == []
+    assert find_outliers([('a', 1.0), ('b', 100.0)], sigma=0) == []
+
+
+def test_metric_outliers(capsys):
+    rows = [{'ns': f"vm-{i}", 'running_time_sec': 10.0, 'ready_sec': 5.0} for i in range(20)]
+    rows.append({'ns': 'vm-slow', 'running_time_sec': 30.0, 'ready_sec': None})
+    outliers = metric_outliers(rows, ['running_time_sec', 'ready_sec'], lambda r: r['ns'], sigma=2)
+    assert [(o['metric'], o['item'], o['sigma']) for o in outliers] == [('running_time_sec', 'vm-slow', 2)]
+    log_outliers(outliers)
+    out = capsys.readouterr().out
+    # Logged under the threshold they were found with, not the global one
+    assert 'more than 2 standard deviations' in out
+    assert 'vm-slow' in out
+
+
+@pytest.mark.parametrize('means, expected', [
+    ([10.0, 10.1], (False, None)),
+    ([10.0, 10.1, 9.9], (True, 0.0082)),
+    ([5.0, 10.0, 15.0], (False, 0.4082)),
+    # Only the last window of means counts
+    ([50.0, 5.0, 10.0, 10.1, 9.9], (True, 0.0082)),
+])
+def test_steady_state(means, expected):
+    assert steady_state(means, threshold=0.1) == expected
@@ -0,0 +1,243 @@
+#!/usr/bin/env python3
+"""
+Admission latency for KubeVirt performance testing.
+
+A VM create call returns only after the API server has run it through
+admission: the built-in admission plugins, every mutating and validating
+webhook that matches (KubeVirt's own, and OPA Gatekeeper, Kyverno or other
+policy engines) and then persistence in etcd. On clusters with a heavy
+webhook chain admission can dominate the create call. With ``virtbench
+--admission-latency`` (VIRTBENCH_ADMISSION_LATENCY=1) the VM creation
+workload measures it in two ways:
+
+    per VM         right before the real create, the same manifest is created
+                   with --dry-run=server, which goes through authorization,
+                   admission and validation but is not persisted; its duration
+                   is the VM's admission_sec, and the rest of the real create
+                   call its persistence_sec
+    per webhook    the API server's admission histograms
+                   (apiserver_admission_webhook_admission_duration_seconds,
+                   apiserver_admission_step_admission_duration_seconds) are
+                   read from /metrics before and after VM creation, and the
+                   CREATE requests in between are reported per webhook and per
+                   admission step (admit = mutating, validate = validating)
+
+The dry run doubles the admission load of VM creation, and webhooks that
+declare side effects (sideEffects: Some or Unknown) reject dry-run requests,
+in which case the VM has no admission_sec. /metrics is answered by one API
+server replica, so on HA control planes the webhook counts cover the
+requests that replica served.
+"""
+
+import logging
+import os
+import re
+import subprocess
+import threading
+from typing import Dict, Optional, Tuple
+
+from utils import timing
+from utils.base import _kubectl_with_input, run_kubectl_command
+from utils.timing import round_duration
+
+ADMISSION_ENV = 'VIRTBENCH_ADMISSION_LATENCY'
+
+WEBHOOK_METRIC = 'apiserver_admission_webhook_admission_duration_seconds'
+STEP_METRIC = 'apiserver_admission_step_admission_duration_seconds'
+METRICS_TIMEOUT = 60
+
+_SAMPLE_RE = re.compile(r'^(\w+?)(_bucket|_sum|_count)\{([^}]*)\}\s+(\S+)')
+_LABEL_RE = re.compile(r'(\w+)="((?:[^"\\]|\\.)*)"')
+
+# Per-target (dry-run admission seconds, create call seconds) of this process
+_vm_timings: Dict[str, Tuple[Optional[float], float]] = {}
+# apiserver admission histograms when VM creation started
+_baseline: Optional[Dict] = None
+_lock = threading.Lock()
+
+
+def admission_enabled() -> bool:
+    """Return True when admission latency was requested (`virtbench --admission-latency`)."""
+    return os.environ.get(ADMISSION_ENV, '').lower() in ('1', 'true', 'yes')
+
+
+def parse_admission_metrics(text: str) -> Dict:
+    """
+    CREATE admission histograms of a Prometheus text exposition.
+
+    Returns:
+        {(metric, name, type): {'buckets': {le: count}, 'sum': seconds, 'count': n,
+        'rejected': n}}, with name '' for admission steps; rejected and
+        accepted requests are added up
+    """
+    histograms: Dict = {}
+    for line in text.splitlines():
+        match = _SAMPLE_RE.match(line)
+        if not match or match.group(1) not in (WEBHOOK_METRIC, STEP_METRIC):
+            continue
+        metric, suffix, labels, value = match.groups()
+        labels = dict(_LABEL_RE.findall(labels))
+        if labels.get('operation') != 'CREATE':
+            continue
+        try:
+            value = float(value)
+        except ValueError:
+            continue
+        key = (metric, labels.get('name', ''), labels.get('type', ''))
+        histogram = histograms.setdefault(key, {'buckets': {}, 'sum': 0.0, 'count': 0, 'rejected': 0})
+        if suffix == '_bucket':
+            le = float('inf') if labels.get('le') == '+Inf' else float(labels.get('le', 'inf'))
+            histogram['buckets'][le] = histogram['buckets'].get(le, 0) + value
+        elif suffix == '_sum':
+            histogram['sum'] += value
+        else:
+            histogram['count'] += int(value)
+            if labels.get('rejected') == 'true':
+                histogram['rejected'] += int(value)
+    return histograms
+
+
+def scrape_admission_metrics(logger: Optional[logging.Logger] = None) -> Optional[Dict]:
+    """The API server's CREATE admission histograms, or None if /metrics cannot be read."""
+    try:
+        returncode, stdout, stderr = run_kubectl_command(['get', '--raw', '/metrics'], check=False,
+                                                         timeout=METRICS_TIMEOUT)
+    except (OSError, subprocess.SubprocessError) as e:
+        if logger:
+            logger.warning(f"Could not read API server metrics: {e}")
+        return None
+    if returncode != 0:
+        if logger:
+            logger.warning(f"Could not read API server metrics: {stderr.strip()}")
+        return None
+    return parse_admission_metrics(stdout)
+
+
+def histogram_quantile(quantile: float, buckets: Dict[float, float]) -> Optional[float]:
+    """Quantile of cumulative histogram buckets, interpolated linearly as PromQL's histogram_quantile()."""
+    bounds = sorted(buckets)
+    if not bounds or not buckets[bounds[-1]]:
+        return None
+    rank = quantile * buckets[bounds[-1]]
+    lower, below = 0.0, 0.0
+    for bound in bounds:
+        count = buckets[bound]
+        if count >= rank:
+            if bound == float('inf'):
+                return lower
+            return lower + (bound - lower) * ((rank - below) / (count - below) if count > below else 1)
+        lower, below = bound, count
+    return lower
+
+
+def admission_delta(before: Dict, after: Dict) -> Dict:
+    """
+    Admission of the CREATE requests between two scrape_admission_metrics() results.
+
+    Returns:
+        {'webhooks': [{name, type, calls, rejected, total_sec, mean_ms, p95_ms}],
+        'steps': {type: {calls, rejected, total_sec, mean_ms, p95_ms}}}, webhooks
+        sorted by total time spent in them
+    """
+    webhooks, steps = [], {}
+    for key, end in after.items():
+        start = before.get(key, {'buckets': {}, 'sum': 0.0, 'count': 0, 'rejected': 0})
+        calls = end['count'] - start['count']
+        if calls <= 0:
+            continue
+        total = end['sum'] - start['sum']
+        buckets = {le: count - start['buckets'].get(le, 0) for le, count in end['buckets'].items()}
+        p95 = histogram_quantile(0.95, buckets)
+        entry = {
+            'calls': calls,
+            'rejected': end['rejected'] - start['rejected'],
+            'total_sec': round_duration(total),
+            'mean_ms': round(total / calls * 1000, 3),
+            'p95_ms': round(p95 * 1000, 3) if p95 is not None else None,
+        }
+        metric, name, kind = key
+        if metric == WEBHOOK_METRIC:
+            webhooks.append({'name': name, 'type': kind, **entry})
+        else:
+            steps[kind] = entry
+    webhooks.sort(key=lambda w: w['total_sec'], reverse=True)
+    return {'webhooks': webhooks, 'steps': steps}
+
+
+def start_admission_metrics(logger: Optional[logging.Logger] = None):
+    """Read the API server's admission histograms at the start of VM creation."""
+    global _baseline
+    if not admission_enabled():
+        return
+    baseline = scrape_admission_metrics(logger)
+    with _lock:
+        _baseline = baseline
+        _vm_timings.clear()
+
+
+def time_admission(manifest: str, namespace: str, logger: Optional[logging.Logger] = None) -> Optional[float]:
+    """
+    Seconds a server-side dry-run create of a manifest takes (authorization, admission, validation).
+
+    Returns:
+        The duration, or None if the dry run was rejected
+    """
+    start = timing.now()
+    returncode, _, stderr = _kubectl_with_input(
+        ['create', '--dry-run=server', '-o', 'name', '-f', '-', '-n', namespace], manifest, logger=logger)
+    elapsed = (timing.now() - start).total_seconds()
+    if returncode != 0:
+        if logger:
+            logger.debug(f"[{namespace}] Dry-run create for admission timing was rejected: {stderr.strip()}")
+        return None
+    return elapsed
+
+
+def record_admission(target: str, admission_sec: Optional[float], create_call_sec: float):
+    """Record a VM's dry-run admission time next to its real create call time."""
+    with _lock:
+        _vm_timings[target] = (admission_sec, create_call_sec)
+
+
+def take_admission_metrics(logger: Optional[logging.Logger] = None) -> Tuple[Dict[str, Dict], Optional[Dict]]:
+    """
+    Per-VM admission times and the webhook breakdown since start_admission_metrics(), and reset them.
+
+    Returns:
+        ({target: {'admission_sec', 'persistence_sec'}}, admission_delta() result
+        or None when the API server metrics could not be read)
+    """
+    global _baseline
+    with _lock:
+        vm_timings, baseline = dict(_vm_timings), _baseline
+        _vm_timings.clear()
+        _baseline = None
+    per_vm = {}
+    for target, (admission, create_call) in vm_timings.items():
+        per_vm[target] = {
+            'admission_sec': round_duration(admission) if admission is not None else None,
+            'persistence_sec': round_duration(max(0.0, create_call - admission)) if admission is not None else None,
+        }
+    after = scrape_admission_metrics(logger) if baseline is not None else None
+    return per_vm, admission_delta(baseline, after) if after is not None else None
+
+
+def print_admission_summary(admission: Dict, logger: logging.Logger, limit: int = 10):
+    """Log the admission steps and the webhooks that took the most time."""
+    logger.info("Admission of CREATE requests during VM creation (API server metrics):")
+    for kind, step in sorted(admission['steps'].items()):
+        logger.info(f"  {kind:<10} {step['calls']:>6} requests  mean {step['mean_ms']:>9.3f}ms  "
+                    f"p95 {step['p95_ms'] if step['p95_ms'] is not None else 'n/a'}ms")
+    for webhook in admission['webhooks'][:limit]:
+        logger.info(f"  {webhook['type']:<10} {webhook['name']}: {webhook['calls']} calls, "
+                    f"mean {webhook['mean_ms']:.3f}ms, p95 {webhook['p95_ms']}ms, rejected {webhook['rejected']}")
+    if not admission['webhooks']:
+        logger.info("  No admission webhooks were called")
+
+
+def admission_report(per_vm: Dict[str, Dict], admission: Optional[Dict]) -> Dict:
+    """Summary block of take_admission_metrics(): the webhook breakdown and how many VMs were dry-run."""
+    report = dict(admission or {'webhooks': [], 'steps': {}})
+    report['source'] = 'apiserver /metrics' if admission is not None else None
+    report['vms_measured'] = sum(1 for vm in per_vm.values() if vm['admission_sec'] is not None)
+    return report
@@ -0,0 +1,119 @@
+#!/usr/bin/env python3
+"""
+Server-side apply of rendered manifests.
+
+Every manifest virtbench applies (VMs, DataVolumes, snapshots, Services,
+helper pods, namespaces and their quotas) goes through apply_manifest(),
+which runs ``kubectl apply --server-side --field-manager=virtbench``. The API
+server merges the manifest and records virtbench as the manager of the
+fields it set, so re-applying after a retry or an adoption only changes what
+virtbench owns, and no last-applied-configuration annotation is stored.
+
+Conflicts (another manager owns a field with a different value) are handled
+by owner:
+
+- Fields of virtbench itself or of the client-side create/apply virtbench
+  used before (kubectl-create, kubectl-client-side-apply,
+  before-first-apply) are taken over with --force-conflicts.
+- Fields of any other manager (a controller, a user's kubectl edit) fail the
+  apply with the conflicting fields listed, unless force_conflicts is set.
+
+validate_manifest() runs the same apply with --dry-run=server, so schema
+errors, admission webhooks and quota are checked without persisting
+anything; `virtbench --dry-run` plans use it (see utils.dryrun).
+"""
+
+import logging
+import re
+from typing import Dict, List, Optional, Tuple
+
+from utils.base import _kubectl_with_input
+
+FIELD_MANAGER = 'virtbench'
+
+# Managers of virtbench's earlier client-side create/apply path, whose fields are taken over on conflict
+TAKEOVER_MANAGERS = (FIELD_MANAGER, 'kubectl-create', 'kubectl-client-side-apply', 'before-first-apply')
+
+_CONFLICT_HEADER = re.compile(r'conflicts? with "([^"]+)"[^:]*:\s*(.*)')
+
+
+def apply_args(namespace: Optional[str] = None, dry_run: bool = False, force_conflicts: bool = False) -> List[str]:
+    """kubectl arguments of a server-side apply of a manifest on stdin."""
+    args = ['apply', '--server-side', f'--field-manager={FIELD_MANAGER}', '-f', '-']
+    if dry_run:
+        args.append('--dry-run=server')
+    if force_conflicts:
+        args.append('--force-conflicts')
+    return args + (['-n', namespace] if namespace else [])
+
+
+def parse_conflicts(stderr: str) -> List[Dict[str, str]]:
+    """
+    Field conflicts reported by a failed server-side apply.
+
+    Understands both forms kubectl prints:
+        conflict with "kubectl-edit" using v1: .spec.runStrategy
+        conflicts with "virt-controller" using kubevirt.io/v1:
+        - .spec.template.spec.domain
+        - .spec.running
+
+    Returns:
+        [{'manager', 'field'}], empty if stderr reports no conflicts
+    """
+    conflicts = []
+    manager = None
+    for line in stderr.splitlines():
+        line = line.strip()
+        header = _CONFLICT_HEADER.search(line)
+        if header:
+            manager = header.group(1)
+            if header.group(2):
+                conflicts.append({'manager': manager, 'field': header.group(2)})
+        elif manager and line.startswith('- '):
+            conflicts.append({'manager': manager, 'field': line[2:].strip()})
+        elif line:
+            manager = None
+    return conflicts
+
+
+def apply_manifest(manifest: str, namespace: Optional[str] = None, dry_run: bool = False,
+                   force_conflicts: bool = False,
+                   logger: Optional[logging.Logger] = None) -> Tuple[bool, str]:
+    """
+    Server-side apply a YAML or JSON manifest (one or more documents) as field manager virtbench.
+
+    The manifest is applied as is; stamp it first (utils.common.stamp_manifest)
+    if it should carry the run labels.
+
+    Args:
+        manifest: Rendered manifest
+        namespace: Namespace for objects without metadata.namespace
+        dry_run: Only validate it on the server (--dry-run=server)
+        force_conflicts: Take over fields of any other manager, not only TAKEOVER_MANAGERS
+        logger: Logger instance
+
+    Returns:
+        Tuple of (success, error message)
+    """
+    returncode, _, stderr = _kubectl_with_input(apply_args(namespace, dry_run, force_conflicts), manifest, logger)
+    if returncode == 0:
+        return True, ''
+    conflicts = parse_conflicts(stderr)
+    if not conflicts or force_conflicts:
+        return False, stderr.strip()
+
+    foreign = [c for c in conflicts if c['manager'] not in TAKEOVER_MANAGERS]
+    if foreign:
+        fields = ', '.join(f"{c['field']} ({c['manager']})" for c in foreign)
+        return False, f"fields managed by another field manager: {fields}"
+    if logger:
+        managers = sorted({c['manager'] for c in conflicts})
+        logger.debug(f"Taking over {len(conflicts)} fields from {', '.join(managers)}")
+    returncode, _, stderr = _kubectl_with_input(apply_args(namespace, dry_run, True), manifest, logger)
+    return returncode == 0, '' if returncode == 0 else stderr.strip()
+
+
+def validate_manifest(manifest: str, namespace: Optional[str] = None,
+                      logger: Optional[logging.Logger] = None) -> Tuple[bool, str]:
+    """Check a manifest with a server-side dry run (schema, admission, quota). Returns (valid, error)."""
+    return apply_manifest(manifest, namespace, dry_run=True, logger=logger)
@@ -19,10 +19,11 @@ OUTPUT_FILE=""
 VM_NAME="rhel-9-vm"
 STORAGE_CLASS_NAME=""
 DATASOURCE_NAME="rhel9"
-DATASOURCE_NAMESPACE="openshift-virtualization-os-images"
+DATASOURCE_NAMESPACE=""
 STORAGE_SIZE="30Gi"
 VM_MEMORY="2048M"
 VM_CPU_CORES="1"
+HUGEPAGES=""
 
 # Colors for output
 RED='\033[0;31m'
@@ -41,12 +42,15 @@ OPTIONS:
     -t, --template FILE          Template file path (default: ../examples/vm-templates/vm-template.yaml)
     -o, --output FILE            Output file path (required)
     -n, --vm-name NAME           VM name (default: rhel-9-vm)
-    -s, --storage-class NAME     Storage class name (required)
+    -s, --storage-class NAME     Storage class name (required; default on Harvester: harvester-longhorn)
     -d, --datasource NAME        DataSource name (default: rhel9)
-    --datasource-namespace NS    DataSource namespace (default: openshift-virtualization-os-images)
+    --datasource-namespace NS    DataSource namespace (default: openshift-virtualization-os-images on
+                                 OpenShift, default on Harvester, kubevirt-os-images on Kubernetes)
     --storage-size SIZE          Storage size (default: 30Gi)
     --memory SIZE                VM memory (default: 2048M)
     --cpu-cores NUM              Number of CPU cores (default: 1)
+    --hugepages SIZE             Back the VM memory with hugepages of this page size (2Mi or 1Gi);
+                                 --memory must be a multiple of it, e.g. 4Gi
     -h, --help                   Show this help message
 
 EXAMPLES:
@@ -62,6 +66,9 @@ EXAMPLES:
         --memory 4Gi \\
         --cpu-cores 2
 
+    # Hugepages-backed VM (the nodes need free hugepages-1Gi)
+    $0 -o hp-vm.yaml -n hp-vm -s YOUR-STORAGE-CLASS --memory 4Gi --hugepages 1Gi
+
     # Apply and create VM directly
     $0 -o /tmp/vm.yaml -n test-vm -s YOUR-STORAGE-CLASS && kubectl apply -f /tmp/vm.yaml -n test-namespace
 
@@ -108,6 +115,10 @@ while [[ $# -gt 0 ]]; do
             VM_CPU_CORES="$2"
             shift 2
             ;;
+        --hugepages)
+            HUGEPAGES="$2"
+            shift 2
+            ;;
         -h|--help)
             usage
             ;;
@@ -124,6 +135,27 @@ if [ -z "$OUTPUT_FILE" ]; then
     usage
 fi
 
+# Platform of the cluster (see utils/cluster_platform.py)
+PLATFORM="${VIRTBENCH_PLATFORM:-auto}"
+if [ "$PLATFORM" = "auto" ]; then
+    if API_VERSIONS=$(kubectl api-versions 2>/dev/null); then
+        if grep -q '^config\.openshift\.io/' <<< "$API_VERSIONS"; then
+            PLATFORM="openshift"
+        elif grep -q '^harvesterhci\.io/' <<< "$API_VERSIONS"; then
+            PLATFORM="harvester"
+        else
+            PLATFORM="kubernetes"
+        fi
+    else
+        PLATFORM="openshift"
+    fi
+fi
+
+# Harvester VM disks default to its Longhorn storage class
+if [ -z "$STORAGE_CLASS_NAME" ] && [ "$PLATFORM" = "harvester" ]; then
+    STORAGE_CLASS_NAME="harvester-longhorn"
+fi
+
 if [ -z "$STORAGE_CLASS_NAME" ]; then
     echo -e "${RED}Error: Storage class is required (-s/--storage-class)${NC}"
     usage
@@ -134,6 +166,30 @@ if [ ! -f "$TEMPLATE_FILE" ]; then
     exit 1
 fi
 
+if [ -n "$HUGEPAGES" ]; then
+    case "$HUGEPAGES" in
+        2Mi|1Gi) ;;
+        *)
+            echo -e "${RED}Error: Hugepages page size must be 2Mi or 1Gi: $HUGEPAGES${NC}"
+            exit 1
+            ;;
+    esac
+    # The guest memory must be a whole number of pages
+    if ! [[ "$VM_MEMORY" =~ ^[0-9]+Gi$ || ( "$HUGEPAGES" == "2Mi" && "$VM_MEMORY" =~ ^[0-9]*[02468]Mi$ ) ]]; then
+        echo -e "${RED}Error: --memory $VM_MEMORY is not a multiple of the $HUGEPAGES hugepages (use e.g. 4Gi)${NC}"
+        exit 1
+    fi
+fi
+
+# Golden images live in a platform-specific namespace
+if [ -z "$DATASOURCE_NAMESPACE" ]; then
+    case "$PLATFORM" in
+        kubernetes) DATASOURCE_NAMESPACE="kubevirt-os-images" ;;
+        harvester) DATASOURCE_NAMESPACE="default" ;;
+        *) DATASOURCE_NAMESPACE="openshift-virtualization-os-images" ;;
+    esac
+fi
+
 # Print configuration
 echo -e "${GREEN}Applying template variables...${NC}"
 echo "Template file:        $TEMPLATE_FILE"
@@ -145,6 +201,7 @@ echo "DataSource Namespace: $DATASOURCE_NAMESPACE"
 echo "Storage Size:         $STORAGE_SIZE"
 echo "VM Memory:            $VM_MEMORY"
 echo "VM CPU Cores:         $VM_CPU_CORES"
+echo "Hugepages:            ${HUGEPAGES:-none}"
 echo ""
 
 # Apply template variables using sed
@@ -155,7 +212,16 @@ cat "$TEMPLATE_FILE" | \
     sed "s/{{DATASOURCE_NAMESPACE}}/$DATASOURCE_NAMESPACE/g" | \
     sed "s/{{STORAGE_SIZE}}/$STORAGE_SIZE/g" | \
     sed "s/{{VM_MEMORY}}/$VM_MEMORY/g" | \
-    sed "s/{{VM_CPU_CORES}}/$VM_CPU_CORES/g" \
+    sed "s/{{VM_CPU_CORES}}/$VM_CPU_CORES/g" | \
+    awk -v pagesize="$HUGEPAGES" '
+        # Add memory.hugepages.pageSize under the VM domain
+        { print }
+        pagesize != "" && /^ *domain:$/ {
+            indent = substr($0, 1, index($0, "d") - 1) "  "
+            print indent "memory:"
+            print indent "  hugepages:"
+            print indent "    pageSize: " pagesize
+        }' \
     > "$OUTPUT_FILE"
 
 # Check if output file was created successfully
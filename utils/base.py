@@ -0,0 +1,245 @@
+#!/usr/bin/env python3
+"""
+Base helpers shared by utils.common and the utils modules it is built on.
+
+The run's labels (UUID, workload, run timestamp), kubectl invocation with
+retries, rate limiting and tenant impersonation, and a few parsers and
+constants live here. This module imports nothing that depends on
+utils.common, so any utils module can import it at top level, and
+utils.common can in turn import those modules at top level. utils.common
+re-exports everything here for the workloads.
+"""
+
+import logging
+import os
+import re
+import subprocess
+from datetime import datetime
+from typing import Dict, List, Optional, Tuple
+
+from utils.concurrency import api_rate_limiter
+from utils.retry import call_with_retries, kubectl_verb, request_verb, NON_RETRIED_VERBS
+
+# Label stamped on created resources so a re-run with the same UUID can adopt them
+RUN_UUID_LABEL = 'virtbench.io/run-uuid'
+RUN_UUID_ENV = 'VIRTBENCH_UUID'
+
+# Labels stamped on every created resource next to the run UUID; cleanup selects by them
+WORKLOAD_LABEL = 'virtbench.io/workload'
+RUN_TIMESTAMP_LABEL = 'virtbench.io/run-timestamp'
+WORKLOAD_ENV = 'VIRTBENCH_WORKLOAD'
+RUN_TIMESTAMP_ENV = 'VIRTBENCH_RUN_TIMESTAMP'
+DEFAULT_WORKLOAD = 'virtbench'
+# Selects resources created by any virtbench run, of any workload
+ANY_RUN_SELECTOR = WORKLOAD_LABEL
+
+# Set by `virtbench --dry-run`: workloads only print their plan (see utils.dryrun)
+DRY_RUN_ENV = 'VIRTBENCH_DRY_RUN'
+
+# Guest operating systems understood by the creation, boot storm and migration workloads
+GUEST_OS_LINUX = 'linux'
+GUEST_OS_WINDOWS = 'windows'
+GUEST_OS_CHOICES = [GUEST_OS_LINUX, GUEST_OS_WINDOWS]
+
+# Windows blocks ICMP echo by default, so readiness is probed on RDP and WinRM instead
+WINDOWS_READINESS_PORTS = [3389, 5985]
+
+
+# kubectl flags acting as the tenant of each tenant namespace (see utils.tenants)
+_impersonation: Dict[str, List[str]] = {}
+
+
+def set_impersonation(namespace: str, flags: List[str]):
+    """Run every later kubectl call aimed at the namespace with these --as flags."""
+    _impersonation[namespace] = flags
+
+
+def _namespace_arg(args: List[str]) -> Optional[str]:
+    for i, arg in enumerate(args):
+        if arg in ('-n', '--namespace') and i + 1 < len(args):
+            return args[i + 1]
+        if arg.startswith('--namespace='):
+            return arg.split('=', 1)[1]
+    return None
+
+
+def impersonate(args: List[str]) -> List[str]:
+    """kubectl arguments with the tenant's --as flags added when they target a tenant namespace."""
+    if not _impersonation or any(arg.startswith('--as') for arg in args):
+        return args
+    return args + _impersonation.get(_namespace_arg(args) or '', [])
+
+
+def is_dry_run() -> bool:
+    """Return True if this run should only print its plan (`virtbench --dry-run`)."""
+    return os.environ.get(DRY_RUN_ENV, '').lower() in ('1', 'true', 'yes')
+
+
+def get_run_uuid() -> Optional[str]:
+    """Return the benchmark UUID of this run (`virtbench --uuid`, auto-generated by the CLI), if any."""
+    return os.environ.get(RUN_UUID_ENV) or None
+
+
+def set_run_workload(workload: str):
+    """
+    Name the workload this process runs, for the labels on the resources it creates.
+
+    A workload started by another one (elbencho run-all creating its VMs
+    through datasource-clone) keeps the outer workload name and run timestamp.
+    """
+    os.environ.setdefault(WORKLOAD_ENV, workload)
+    os.environ.setdefault(RUN_TIMESTAMP_ENV, datetime.now().strftime('%Y%m%d-%H%M%S'))
+
+
+def run_labels() -> dict:
+    """Labels for every resource this run creates: run UUID (if any), workload and run timestamp."""
+    os.environ.setdefault(RUN_TIMESTAMP_ENV, datetime.now().strftime('%Y%m%d-%H%M%S'))
+    labels = {
+        WORKLOAD_LABEL: os.environ.get(WORKLOAD_ENV) or DEFAULT_WORKLOAD,
+        RUN_TIMESTAMP_LABEL: os.environ[RUN_TIMESTAMP_ENV],
+    }
+    if get_run_uuid():
+        labels[RUN_UUID_LABEL] = get_run_uuid()
+    return labels
+
+
+def run_selector() -> str:
+    """Label selector for the resources created by this run: its UUID, or workload and timestamp without one."""
+    if get_run_uuid():
+        return f"{RUN_UUID_LABEL}={get_run_uuid()}"
+    return ','.join(f"{key}={value}" for key, value in run_labels().items())
+
+
+def stamp_run_labels(doc: dict) -> dict:
+    """
+    Label a manifest object with the run labels.
+
+    On a VirtualMachine the labels also go on its template and DataVolume
+    templates, so the VMI, virt-launcher pod, DataVolumes and (through CDI)
+    PVCs carry them too.
+    """
+    labels = run_labels()
+    metadata = doc.setdefault('metadata', {})
+    metadata['labels'] = {**(metadata.get('labels') or {}), **labels}
+    if doc.get('kind') == 'VirtualMachine':
+        spec = doc.setdefault('spec', {})
+        template_metadata = spec.setdefault('template', {}).setdefault('metadata', {})
+        template_metadata['labels'] = {**(template_metadata.get('labels') or {}), **labels}
+        for dvt in spec.get('dataVolumeTemplates') or []:
+            dvt_metadata = dvt.setdefault('metadata', {})
+            dvt_metadata['labels'] = {**(dvt_metadata.get('labels') or {}), **labels}
+    return doc
+
+
+def run_kubectl_command(
+    args: List[str],
+    check: bool = True,
+    capture_output: bool = True,
+    timeout: Optional[int] = None,
+    logger: Optional[logging.Logger] = None
+) -> Tuple[int, str, str]:
+    """
+    Execute a kubectl command with error handling.
+
+    API requests that fail with a transient error (throttling, timeouts,
+    conflicts, apiserver unavailability) are retried with exponential backoff
+    per the run's retry policy, as far as their verb can safely be repeated
+    (see utils.retry). Every request, retries
+    included, is subject to the --kube-api-qps/--kube-api-burst limit.
+
+    Args:
+        args: List of command arguments (e.g., ['get', 'pods'])
+        check: Raise exception on non-zero exit code
+        capture_output: Capture stdout and stderr
+        timeout: Command timeout in seconds
+        logger: Logger instance for debug output
+
+    Returns:
+        Tuple of (return_code, stdout, stderr)
+
+    Raises:
+        subprocess.CalledProcessError: If check=True and command fails
+        subprocess.TimeoutExpired: If command exceeds timeout
+    """
+    cmd = ['kubectl'] + impersonate(args)
+
+    if logger:
+        logger.debug(f"Executing: {' '.join(cmd)}")
+
+    def run_once():
+        api_rate_limiter().wait()
+        result = subprocess.run(
+            cmd,
+            capture_output=capture_output,
+            text=True,
+            timeout=timeout
+        )
+        return result.returncode, result.stdout, result.stderr
+
+    try:
+        if capture_output and kubectl_verb(args) not in NON_RETRIED_VERBS:
+            returncode, stdout, stderr = call_with_retries(run_once, ' '.join(cmd), logger=logger,
+                                                           verb=request_verb(args))
+        else:
+            returncode, stdout, stderr = run_once()
+        if returncode != 0 and check:
+            raise subprocess.CalledProcessError(returncode, cmd, stdout, stderr)
+        return returncode, stdout, stderr
+    except subprocess.CalledProcessError as e:
+        if logger:
+            logger.error(f"Command failed: {' '.join(cmd)}")
+            logger.error(f"Exit code: {e.returncode}")
+            logger.error(f"Stderr: {e.stderr}")
+        raise
+    except subprocess.TimeoutExpired as e:
+        if logger:
+            logger.error(f"Command timed out after {timeout}s: {' '.join(cmd)}")
+        raise
+
+
+def _kubectl_with_input(args: List[str], manifest: str,
+                        logger: Optional[logging.Logger] = None) -> Tuple[int, str, str]:
+    """Run kubectl with a manifest on stdin, retrying transient API errors."""
+    args = impersonate(args)
+
+    def run_once():
+        api_rate_limiter().wait()
+        result = subprocess.run(['kubectl'] + args, input=manifest, capture_output=True, text=True)
+        return result.returncode, result.stdout, result.stderr
+
+    return call_with_retries(run_once, ' '.join(['kubectl'] + args), logger=logger, verb=request_verb(args))
+
+
+def split_vm_target(target: str, vm_name: str) -> Tuple[str, str]:
+    """
+    Resolve a target from vm_targets() to (namespace, VM name).
+
+    Args:
+        target: Namespace, or "{namespace}/{vm}" in namespace-scoped mode
+        vm_name: VM name used when the target is a plain namespace
+
+    Returns:
+        Tuple of (namespace, vm_name)
+    """
+    if '/' in target:
+        namespace, name = target.split('/', 1)
+        return namespace, name
+    return target, vm_name
+
+
+def parse_quantity_bytes(quantity: str) -> Optional[int]:
+    """
+    Convert a Kubernetes memory quantity (e.g. "2Gi", "512M", "1073741824") to bytes.
+
+    Returns:
+        Bytes, or None if the quantity cannot be parsed
+    """
+    units = {'Ki': 1024, 'Mi': 1024 ** 2, 'Gi': 1024 ** 3, 'Ti': 1024 ** 4,
+             'k': 1000, 'K': 1000, 'M': 1000 ** 2, 'G': 1000 ** 3, 'T': 1000 ** 4}
+    match = re.match(r'^\s*([0-9.]+)\s*([A-Za-z]*)\s*$', str(quantity or ''))
+    if not match or (match.group(2) and match.group(2) not in units):
+        return None
+    try:
+        return int(float(match.group(1)) * units.get(match.group(2), 1))
+    except ValueError:
+        return None
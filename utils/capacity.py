@@ -0,0 +1,242 @@
+#!/usr/bin/env python3
+"""
+Iteration engine of the capacity (chaos) benchmark.
+
+Every iteration of chaos-benchmark/measure-chaos.py runs the same phases on
+the VMs it creates: create, resize, clone, restart and snapshot. The engine
+runs them as a state machine:
+
+    pending -> create -> resize -> clone -> restart -> snapshot -> completed
+
+Any phase may end the iteration as failed, and the create phase as capacity.
+Phases named in the skip set (the --skip-* flags) are recorded as skipped and
+never entered. A phase handler gets the VMs of the previous phase and returns
+the VMs it leaves running; it raises PhaseFailed to fail the iteration, and
+the create phase raises CapacityReached when VMs can no longer be scheduled.
+Each phase gets a PhaseResult (status, duration, VMs, items, error), so the
+summary shows where an iteration spent its time and where it stopped.
+"""
+
+import time
+from dataclasses import dataclass, field
+from typing import Callable, Dict, Iterable, List, Optional, Tuple
+
+from utils.stats import describe, metric_stats
+
+# (name, title, skip flag)
+PHASES: Tuple[Tuple[str, str, Optional[str]], ...] = (
+    ('create', 'Create VMs', None),
+    ('resize', 'Resize Volumes', 'skip_resize'),
+    ('clone', 'Clone Volumes', 'skip_clone'),
+    ('restart', 'Restart VMs', 'skip_restart'),
+    ('snapshot', 'Create Snapshots', 'skip_snapshot'),
+)
+PHASE_NAMES = [name for name, _, _ in PHASES]
+PHASE_TITLES = {name: title for name, title, _ in PHASES}
+
+STATE_PENDING = 'pending'
+STATE_COMPLETED = 'completed'
+STATE_FAILED = 'failed'
+STATE_CAPACITY = 'capacity'
+
+STATUS_PENDING = 'pending'
+STATUS_COMPLETED = 'completed'
+STATUS_SKIPPED = 'skipped'
+STATUS_FAILED = 'failed'
+
+
+def _transitions() -> Dict[str, Tuple[str, ...]]:
+    """Allowed state transitions: a phase may be followed by any later phase (skips) or an end state."""
+    transitions = {STATE_PENDING: ('create',)}
+    for i, name in enumerate(PHASE_NAMES):
+        ends = (STATE_COMPLETED, STATE_FAILED, STATE_CAPACITY) if name == 'create' else (STATE_COMPLETED, STATE_FAILED)
+        transitions[name] = tuple(PHASE_NAMES[i + 1:]) + ends
+    return transitions
+
+
+TRANSITIONS = _transitions()
+
+
+class PhaseFailed(Exception):
+    """A phase failed; vms are the VMs still running."""
+
+    def __init__(self, message: str, vms: Iterable[str] = ()):
+        super().__init__(message)
+        self.vms = list(vms)
+
+
+class CapacityReached(Exception):
+    """VMs of the create phase could not be scheduled or started; vms are the ones running."""
+
+    def __init__(self, message: str, vms: Iterable[str] = ()):
+        super().__init__(message)
+        self.vms = list(vms)
+
+
+def skipped_phases(args) -> List[str]:
+    """Names of the phases the --skip-* flags of args skip."""
+    return [name for name, _, flag in PHASES if flag and getattr(args, flag, False)]
+
+
+@dataclass
+class PhaseResult:
+    """Outcome of one phase of an iteration."""
+    phase: str
+    status: str = STATUS_PENDING
+    duration_sec: Optional[float] = None
+    vms: int = 0
+    items: int = 0
+    error: Optional[str] = None
+
+    @property
+    def title(self) -> str:
+        return PHASE_TITLES[self.phase]
+
+    def to_dict(self) -> Dict:
+        result = {
+            'phase': self.phase,
+            'status': self.status,
+            'duration_sec': round(self.duration_sec, 3) if self.duration_sec is not None else None,
+            'vms': self.vms,
+            'items': self.items,
+        }
+        if self.error:
+            result['error'] = self.error
+        return result
+
+
+# A phase handler takes the VMs of the previous phase and returns (VMs still running, items created)
+PhaseHandler = Callable[[List[str]], Tuple[List[str], int]]
+
+
+@dataclass
+class CapacityIteration:
+    """
+    One iteration of the capacity benchmark as a state machine over PHASES.
+
+    Example:
+        iteration = CapacityIteration(3, handlers, skip=skipped_phases(args), logger=logger)
+        iteration.run()
+        if iteration.state == STATE_CAPACITY: ...
+    """
+    iteration: int
+    handlers: Dict[str, PhaseHandler]
+    skip: Iterable[str] = ()
+    logger: object = None
+    warmup: bool = False
+    state: str = STATE_PENDING
+    vms: List[str] = field(default_factory=list)
+    phases: Dict[str, PhaseResult] = field(default_factory=dict)
+
+    def __post_init__(self):
+        self.skip = set(self.skip)
+        unknown = self.skip - set(PHASE_NAMES)
+        if 'create' in self.skip or unknown:
+            raise ValueError(f"Cannot skip phases: {sorted(unknown | (self.skip & {'create'}))}")
+        self.phases = {name: PhaseResult(name) for name in PHASE_NAMES}
+
+    def _transition(self, state: str):
+        if state not in TRANSITIONS.get(self.state, ()):
+            raise RuntimeError(f"Iteration {self.iteration}: invalid transition {self.state} -> {state}")
+        self.state = state
+
+    def _log(self, level: str, message: str):
+        if self.logger:
+            getattr(self.logger, level)(message)
+
+    def run(self) -> 'CapacityIteration':
+        """Run the phases in order and return self, in a final state."""
+        for number, name in enumerate(PHASE_NAMES, 1):
+            result = self.phases[name]
+            if name in self.skip:
+                result.status = STATUS_SKIPPED
+                self._log('info', f"Phase {number}: {result.title} SKIPPED (--skip-{name})")
+                continue
+            self._transition(name)
+            self._log('info', f"Phase {number}: {result.title}")
+            start = time.time()
+            try:
+                self.vms, result.items = self.handlers[name](list(self.vms))
+            except CapacityReached as e:
+                self._finish(result, start, e, STATE_CAPACITY)
+                self._log('warning', f"Phase {number} CAPACITY REACHED: {e}")
+                return self
+            except Exception as e:
+                if not isinstance(e, PhaseFailed):
+                    e = PhaseFailed(str(e), self.vms)
+                self._finish(result, start, e, STATE_FAILED)
+                self._log('error', f"Phase {number} FAILED: {e}")
+                return self
+            result.status = STATUS_COMPLETED
+            result.duration_sec = time.time() - start
+            result.vms = len(self.vms)
+            self._log('info', f"Phase {number} COMPLETE: {result.vms} VMs, {result.items} items "
+                              f"(took {result.duration_sec:.2f}s)")
+        self._transition(STATE_COMPLETED)
+        return self
+
+    def _finish(self, result: PhaseResult, start: float, error, state: str):
+        result.status = STATUS_FAILED
+        result.duration_sec = time.time() - start
+        result.error = str(error)
+        self.vms = error.vms
+        result.vms = len(self.vms)
+        self._transition(state)
+
+    @property
+    def succeeded(self) -> bool:
+        return self.state == STATE_COMPLETED
+
+    @property
+    def capacity_reached(self) -> bool:
+        return self.state == STATE_CAPACITY
+
+    def completed_phases(self) -> List[PhaseResult]:
+        return [r for r in self.phases.values() if r.status == STATUS_COMPLETED]
+
+    def to_dict(self) -> Dict:
+        result = {
+            'iteration': self.iteration,
+            'state': self.state,
+            'vms': len(self.vms),
+            'phases': [self.phases[name].to_dict() for name in PHASE_NAMES],
+        }
+        if self.warmup:
+            result['warmup'] = True
+        return result
+
+
+def _phase_durations(iterations: List[Dict]) -> Dict[str, List[float]]:
+    durations = {name: [] for name in PHASE_NAMES}
+    for iteration in iterations:
+        if iteration.get('warmup'):
+            continue
+        for phase in iteration['phases']:
+            if phase['status'] == STATUS_COMPLETED:
+                durations[phase['phase']].append(phase['duration_sec'])
+    return durations
+
+
+def phase_summary(iterations: List[Dict]) -> Dict[str, Dict]:
+    """
+    Per-phase totals over iteration dicts (CapacityIteration.to_dict()), warm-up excluded.
+
+    Returns:
+        {phase: {completed, skipped, failed, duration_sec: {avg, min, max, ...}}}
+    """
+    summary = {name: {STATUS_COMPLETED: 0, STATUS_SKIPPED: 0, STATUS_FAILED: 0} for name in PHASE_NAMES}
+    for iteration in iterations:
+        if iteration.get('warmup'):
+            continue
+        for phase in iteration['phases']:
+            if phase['status'] in summary[phase['phase']]:
+                summary[phase['phase']][phase['status']] += 1
+    for name, durations in _phase_durations(iterations).items():
+        summary[name]['duration_sec'] = describe(durations)
+    return summary
+
+
+def phase_metrics(iterations: List[Dict]) -> List[Dict]:
+    """{phase}_phase_sec metric statistics of the measured iterations, for summary metrics."""
+    return [metric_stats(f"{name}_phase_sec", durations)
+            for name, durations in _phase_durations(iterations).items() if durations]
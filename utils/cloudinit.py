@@ -0,0 +1,188 @@
+#!/usr/bin/env python3
+"""
+Cloud-init customization of benchmark VMs.
+
+Guest-exec based benchmarks (fio, iperf, stress) need more in the guest than
+the example templates set up: an SSH key, a tool package, a script that
+prepares a disk. Instead of a forked template per benchmark, the global
+options below customize the #cloud-config user data of every VM a workload
+creates, through the same manifest stamping as the run labels (see
+utils.common.stamp_manifest):
+
+- --cloud-init FILE (VIRTBENCH_CLOUD_INIT): #cloud-config files merged into
+  the user data, or scripts (starting with #!) run once on first boot
+- --ssh-key FILE (VIRTBENCH_SSH_KEYS): public keys added to
+  ssh_authorized_keys of the default user
+- --guest-user NAME (VIRTBENCH_GUEST_USER): name of the default user
+- --guest-package NAME (VIRTBENCH_GUEST_PACKAGES): packages to install
+- --guest-hostname TEMPLATE (VIRTBENCH_GUEST_HOSTNAME): per-VM hostname from
+  {namespace} and {vm}, e.g. {namespace}-{vm}, so guest-side results and
+  logs name the VM they came from
+
+Merging follows cloud-init's own list-append rule: mappings are merged key by
+key, lists are extended (without repeats) and scalars are replaced, with the
+template first, then the files in order, then the single-value options. A VM
+without cloud-init gets a cloudInitNoCloud disk; user data that is not
+#cloud-config (e.g. a shell script) is left alone with a warning.
+"""
+
+import copy
+import logging
+import os
+import re
+import threading
+from typing import Dict, List, Optional
+
+import yaml
+
+CLOUD_INIT_ENV = 'VIRTBENCH_CLOUD_INIT'
+SSH_KEYS_ENV = 'VIRTBENCH_SSH_KEYS'
+GUEST_USER_ENV = 'VIRTBENCH_GUEST_USER'
+GUEST_PACKAGES_ENV = 'VIRTBENCH_GUEST_PACKAGES'
+GUEST_HOSTNAME_ENV = 'VIRTBENCH_GUEST_HOSTNAME'
+
+CLOUD_CONFIG_HEADER = '#cloud-config'
+# Volume and disk added to VMs whose template has no cloud-init
+CLOUD_INIT_VOLUME = 'virtbench-cloudinit'
+# Where the --cloud-init scripts are written in the guest before they are run
+SCRIPT_DIR = '/var/lib/virtbench/cloud-init'
+# Hostnames are DNS labels
+HOSTNAME_MAX_LENGTH = 63
+
+_lock = threading.Lock()
+_customization: Optional[Dict] = None
+
+
+def _paths(env: str) -> List[str]:
+    return [p for p in os.environ.get(env, '').split(os.pathsep) if p]
+
+
+def load_cloud_init_file(path: str) -> Dict:
+    """
+    A --cloud-init file as cloud-config.
+
+    Scripts (#!...) become a write_files entry and a runcmd that runs them.
+
+    Raises:
+        ValueError: If the file is neither #cloud-config mapping nor a script
+    """
+    with open(path) as f:
+        content = f.read()
+    stripped = content.lstrip()
+    if stripped.startswith('#!'):
+        guest_path = f"{SCRIPT_DIR}/{os.path.basename(path)}"
+        return {'write_files': [{'path': guest_path, 'permissions': '0755', 'content': content}],
+                'runcmd': [[guest_path]]}
+    if not stripped.startswith(CLOUD_CONFIG_HEADER):
+        raise ValueError(f"{path}: expected #cloud-config user data or a #! script")
+    try:
+        config = yaml.safe_load(content)
+    except yaml.YAMLError as e:
+        raise ValueError(f"{path}: invalid cloud-config: {e}")
+    if not isinstance(config, dict):
+        raise ValueError(f"{path}: cloud-config must be a mapping")
+    return config
+
+
+def merge_cloud_config(base: Dict, extra: Dict) -> Dict:
+    """Merge extra into base (in place): mappings merged, lists extended without repeats, scalars replaced."""
+    for key, value in extra.items():
+        if isinstance(value, dict) and isinstance(base.get(key), dict):
+            merge_cloud_config(base[key], value)
+        elif isinstance(value, list) and isinstance(base.get(key), list):
+            base[key] += [item for item in value if item not in base[key]]
+        else:
+            base[key] = value
+    return base
+
+
+def customization() -> Optional[Dict]:
+    """
+    The cloud-config added to every VM, from the environment (read once), or None without customization.
+
+    Raises:
+        ValueError: If a --cloud-init or --ssh-key file cannot be used
+    """
+    global _customization
+    with _lock:
+        if _customization is not None:
+            return _customization or None
+        config: Dict = {}
+        for path in _paths(CLOUD_INIT_ENV):
+            try:
+                merge_cloud_config(config, load_cloud_init_file(path))
+            except OSError as e:
+                raise ValueError(f"cannot read cloud-init file {path}: {e}")
+        keys = []
+        for path in _paths(SSH_KEYS_ENV):
+            try:
+                with open(path) as f:
+                    keys += [line.strip() for line in f if line.strip() and not line.startswith('#')]
+            except OSError as e:
+                raise ValueError(f"cannot read SSH key file {path}: {e}")
+        if keys:
+            merge_cloud_config(config, {'ssh_authorized_keys': keys})
+        if os.environ.get(GUEST_USER_ENV):
+            config['user'] = os.environ[GUEST_USER_ENV]
+        packages = [p for p in os.environ.get(GUEST_PACKAGES_ENV, '').split(',') if p]
+        if packages:
+            merge_cloud_config(config, {'packages': packages})
+        _customization = config
+        return config or None
+
+
+def guest_hostname(namespace: str, vm_name: str) -> Optional[str]:
+    """The --guest-hostname of a VM, cut to a valid DNS label, or None without one."""
+    template = os.environ.get(GUEST_HOSTNAME_ENV)
+    if not template:
+        return None
+    hostname = template.format(namespace=namespace, vm=vm_name).lower()
+    hostname = re.sub(r'[^a-z0-9-]', '-', hostname)[:HOSTNAME_MAX_LENGTH]
+    return hostname.strip('-') or None
+
+
+def _cloud_init_source(template_spec: Dict) -> Optional[Dict]:
+    for volume in template_spec.get('volumes') or []:
+        source = volume.get('cloudInitNoCloud') or volume.get('cloudInitConfigDrive')
+        if source is not None:
+            return source
+    return None
+
+
+def customize_cloud_init(doc: dict, namespace: Optional[str] = None,
+                         logger: Optional[logging.Logger] = None) -> dict:
+    """
+    Merge the cloud-init customization into a VirtualMachine manifest.
+
+    Other objects, and VMs when nothing is customized, are returned unchanged.
+    """
+    if doc.get('kind') != 'VirtualMachine':
+        return doc
+    config = customization()
+    metadata = doc.setdefault('metadata', {})
+    vm_ns = metadata.get('namespace') or namespace or 'default'
+    hostname = guest_hostname(vm_ns, metadata.get('name', ''))
+    if not config and not hostname:
+        return doc
+
+    template_spec = doc.setdefault('spec', {}).setdefault('template', {}).setdefault('spec', {})
+    source = _cloud_init_source(template_spec)
+    if source is None:
+        source = {'userData': CLOUD_CONFIG_HEADER + '\n'}
+        template_spec.setdefault('volumes', []).append({'name': CLOUD_INIT_VOLUME, 'cloudInitNoCloud': source})
+        devices = template_spec.setdefault('domain', {}).setdefault('devices', {})
+        devices.setdefault('disks', []).append({'name': CLOUD_INIT_VOLUME, 'disk': {'bus': 'virtio'}})
+    user_data = source.get('userData', '')
+    if not user_data.lstrip().startswith(CLOUD_CONFIG_HEADER):
+        if logger:
+            logger.warning(f"[{vm_ns}] VM {metadata.get('name')} has no #cloud-config user data; "
+                           f"cloud-init customization not applied")
+        return doc
+
+    merged = yaml.safe_load(user_data) or {}
+    merge_cloud_config(merged, copy.deepcopy(config or {}))
+    if hostname:
+        merged.update({'hostname': hostname, 'preserve_hostname': False})
+        template_spec['hostname'] = hostname
+    source['userData'] = CLOUD_CONFIG_HEADER + '\n' + yaml.safe_dump(merged, sort_keys=False)
+    return doc
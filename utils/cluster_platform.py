@@ -0,0 +1,342 @@
+#!/usr/bin/env python3
+"""
+Platform detection for KubeVirt performance testing.
+
+virtbench grew up on OpenShift Virtualization, and a few of its defaults
+are OpenShift's: the golden images (DataSources) live in the
+openshift-virtualization-os-images namespace, the cluster version comes
+from the ClusterVersion resource, and privileged pods are admitted through
+SecurityContextConstraints (SCCs). Upstream KubeVirt on vanilla Kubernetes
+has none of these, and Harvester (KubeVirt on RKE2) keeps its images as
+VirtualMachineImages backed by Longhorn. This module detects the platform
+once per process and hides the difference:
+
+- detect_platform(): openshift, harvester or kubernetes, from the API
+  groups the cluster serves (VIRTBENCH_PLATFORM, set by
+  `virtbench --platform`, overrides the detection)
+- openshift_version() / harvester_version(): the platform version, None
+  elsewhere
+- default_storage_class(): the platform's storage class for VM disks
+  (harvester-longhorn on Harvester, else the cluster default)
+- default_datasource_namespace() / resolve_datasource_namespace(): where
+  the golden image DataSources are (kubevirt-os-images upstream, default
+  on Harvester, or wherever the DataSource is found)
+- adjust_datasource_refs(): VM templates pointing at the OpenShift
+  namespace are pointed at the DataSource's namespace elsewhere, so the
+  example templates run unchanged; on Harvester, a DataSource that does not
+  exist is replaced by the VirtualMachineImage of the same name
+- allow_privileged_pods(): a namespace admits the privileged node exec
+  pods, through the privileged SCC on OpenShift and the Pod Security
+  Admission labels on Kubernetes, changed only where the pods would be
+  rejected
+
+Every probe is best effort: when the cluster cannot be asked, the platform
+is assumed to be OpenShift, as before.
+"""
+
+import json
+import logging
+import os
+import subprocess
+import threading
+from typing import Dict, Optional
+
+from utils.apply import apply_args
+from utils.base import _kubectl_with_input, run_kubectl_command
+
+PLATFORM_ENV = 'VIRTBENCH_PLATFORM'
+PLATFORM_AUTO = 'auto'
+OPENSHIFT = 'openshift'
+HARVESTER = 'harvester'
+KUBERNETES = 'kubernetes'
+PLATFORMS = [OPENSHIFT, HARVESTER, KUBERNETES]
+
+# API groups only OpenShift serves
+OPENSHIFT_API_GROUPS = ('config.openshift.io', 'security.openshift.io')
+HARVESTER_API_GROUP = 'harvesterhci.io'
+
+# Namespace of the golden image DataSources (the HCO/CDI common boot images;
+# on Harvester, of the VirtualMachineImages)
+DATASOURCE_NAMESPACES = {
+    OPENSHIFT: 'openshift-virtualization-os-images',
+    HARVESTER: 'default',
+    KUBERNETES: 'kubevirt-os-images',
+}
+
+# Storage class of VM disks where the platform has one (None: the cluster default)
+DEFAULT_STORAGE_CLASSES = {
+    HARVESTER: 'harvester-longhorn',
+}
+
+# Annotation tying a Harvester volume to the VirtualMachineImage it was created from
+HARVESTER_IMAGE_ANNOTATION = 'harvesterhci.io/imageId'
+
+# ClusterRole granting use of the privileged SCC (OpenShift 4)
+PRIVILEGED_SCC_CLUSTER_ROLE = 'system:openshift:scc:privileged'
+PRIVILEGED_SCC_ROLE_BINDING = 'virtbench-privileged-scc'
+POD_SECURITY_LABELS = ('pod-security.kubernetes.io/enforce', 'pod-security.kubernetes.io/warn',
+                       'pod-security.kubernetes.io/audit')
+
+PLATFORM_TIMEOUT = 30
+
+_lock = threading.Lock()
+_platform: Optional[str] = None
+_datasource_namespaces: Dict[str, str] = {}
+_privileged_namespaces: Dict[str, bool] = {}
+
+
+def _get_json(args, logger: Optional[logging.Logger] = None) -> Optional[Dict]:
+    """Run `kubectl <args> -o json`, returning the parsed output or None on any error."""
+    try:
+        rc, stdout, _ = run_kubectl_command(args + ['-o', 'json'], check=False,
+                                            timeout=PLATFORM_TIMEOUT, logger=logger)
+        return json.loads(stdout) if rc == 0 else None
+    except (OSError, subprocess.TimeoutExpired, json.JSONDecodeError):
+        return None
+
+
+def detect_platform(logger: Optional[logging.Logger] = None) -> str:
+    """
+    The platform of the cluster: openshift, harvester or kubernetes.
+
+    VIRTBENCH_PLATFORM (openshift, harvester, kubernetes or auto) overrides the
+    detection. The result is cached for the process.
+    """
+    global _platform
+    with _lock:
+        if _platform:
+            return _platform
+        override = os.environ.get(PLATFORM_ENV, PLATFORM_AUTO).lower()
+        if override in PLATFORMS:
+            _platform = override
+            return _platform
+        try:
+            rc, stdout, _ = run_kubectl_command(['api-versions'], check=False,
+                                                timeout=PLATFORM_TIMEOUT, logger=logger)
+        except (OSError, subprocess.TimeoutExpired):
+            rc, stdout = 1, ''
+        if rc != 0:
+            if logger:
+                logger.debug("Cannot read the API groups of the cluster; assuming OpenShift")
+            return OPENSHIFT
+        groups = {line.split('/')[0] for line in stdout.split()}
+        if any(g in groups for g in OPENSHIFT_API_GROUPS):
+            _platform = OPENSHIFT
+        elif HARVESTER_API_GROUP in groups:
+            _platform = HARVESTER
+        else:
+            _platform = KUBERNETES
+        if logger:
+            logger.debug(f"Detected platform: {_platform}")
+        return _platform
+
+
+def is_openshift(logger: Optional[logging.Logger] = None) -> bool:
+    return detect_platform(logger) == OPENSHIFT
+
+
+def openshift_version(logger: Optional[logging.Logger] = None) -> Optional[str]:
+    """Version of OpenShift from the ClusterVersion resource, or None (Kubernetes, or unreadable)."""
+    if not is_openshift(logger):
+        return None
+    cluster_version = _get_json(['get', 'clusterversion', 'version'], logger) or {}
+    return (cluster_version.get('status', {}).get('desired') or {}).get('version')
+
+
+def harvester_version(logger: Optional[logging.Logger] = None) -> Optional[str]:
+    """Version of Harvester from its server-version setting, or None (other platforms, or unreadable)."""
+    if detect_platform(logger) != HARVESTER:
+        return None
+    setting = _get_json(['get', 'settings.harvesterhci.io', 'server-version'], logger) or {}
+    return setting.get('value') or setting.get('default')
+
+
+def virtualization_name(logger: Optional[logging.Logger] = None) -> str:
+    """What KubeVirt is called on the platform, for messages."""
+    return {OPENSHIFT: 'OpenShift Virtualization', HARVESTER: 'Harvester'}.get(detect_platform(logger), 'KubeVirt')
+
+
+def default_storage_class(logger: Optional[logging.Logger] = None) -> Optional[str]:
+    """Storage class for VM disks on the platform, or None for the cluster's default storage class."""
+    return DEFAULT_STORAGE_CLASSES.get(detect_platform(logger))
+
+
+def default_datasource_namespace(logger: Optional[logging.Logger] = None) -> str:
+    """Namespace of the golden image DataSources on the platform."""
+    return DATASOURCE_NAMESPACES[detect_platform(logger)]
+
+
+def resolve_datasource_namespace(name: str, namespace: Optional[str] = None,
+                                 logger: Optional[logging.Logger] = None) -> str:
+    """
+    Namespace of the DataSource name.
+
+    An explicit namespace is used as given. Otherwise the platform's default
+    namespace is used when the DataSource is there (or found nowhere), else
+    the namespace the DataSource is found in.
+    """
+    if namespace:
+        return namespace
+    default = default_datasource_namespace(logger)
+    with _lock:
+        if name in _datasource_namespaces:
+            return _datasource_namespaces[name]
+    found = [item.get('metadata', {}).get('namespace')
+             for item in (_get_json(['get', 'datasources.cdi.kubevirt.io', '-A'], logger) or {}).get('items', [])
+             if item.get('metadata', {}).get('name') == name]
+    resolved = default if default in found or not found else found[0]
+    if resolved != default and logger:
+        logger.info(f"DataSource '{name}' found in namespace '{resolved}' (not in '{default}')")
+    with _lock:
+        _datasource_namespaces[name] = resolved
+    return resolved
+
+
+def adjust_datasource_refs(doc: dict, logger: Optional[logging.Logger] = None) -> dict:
+    """
+    Point DataSource references in the OpenShift golden image namespace at the
+    DataSource's namespace on Kubernetes and Harvester; on Harvester, a
+    DataSource that does not exist is replaced by the VirtualMachineImage of
+    the same name.
+
+    Covers the dataVolumeTemplates of a VirtualMachine and a DataVolume's own
+    sourceRef. On OpenShift, or for other namespaces, the object is returned
+    unchanged.
+    """
+    openshift_namespace = DATASOURCE_NAMESPACES[OPENSHIFT]
+    if doc.get('kind') == 'VirtualMachine':
+        specs = [dvt.get('spec') or {} for dvt in (doc.get('spec') or {}).get('dataVolumeTemplates') or []]
+    elif doc.get('kind') == 'DataVolume':
+        specs = [doc.get('spec') or {}]
+    else:
+        return doc
+    for spec in specs:
+        ref = spec.get('sourceRef') or {}
+        if ref.get('kind') != 'DataSource' or ref.get('namespace') != openshift_namespace:
+            continue
+        if is_openshift(logger):
+            return doc
+        ref['namespace'] = resolve_datasource_namespace(ref.get('name', ''), logger=logger)
+        if detect_platform(logger) == HARVESTER:
+            _use_harvester_image(doc, spec, logger)
+    return doc
+
+
+def find_harvester_image(name: str, namespace: str,
+                         logger: Optional[logging.Logger] = None) -> Optional[Dict]:
+    """Active VirtualMachineImage of a namespace named name (or with that display name), or None."""
+    images = (_get_json(['get', 'virtualmachineimages.harvesterhci.io', '-n', namespace], logger) or {}).get('items')
+    for image in images or []:
+        if name in (image['metadata'].get('name'), (image.get('spec') or {}).get('displayName')) \
+                and (image.get('status') or {}).get('storageClassName'):
+            return image
+    return None
+
+
+def _use_harvester_image(doc: dict, spec: dict, logger: Optional[logging.Logger] = None):
+    """
+    Replace a DataSource reference that has no DataSource behind it by the
+    Harvester VirtualMachineImage of the same name: the disk is provisioned
+    by the image's Longhorn storage class (a backing image), so the
+    DataVolume only asks for a blank Block volume of that class.
+    """
+    ref = spec['sourceRef']
+    if _get_json(['get', 'datasources.cdi.kubevirt.io', ref.get('name', ''), '-n', ref['namespace']], logger):
+        return
+    image = find_harvester_image(ref.get('name', ''), ref['namespace'], logger)
+    if image is None:
+        if logger:
+            logger.warning(f"Neither a DataSource nor a VirtualMachineImage '{ref.get('name')}' "
+                           f"found in namespace '{ref['namespace']}'")
+        return
+    image_class = image['status']['storageClassName']
+    storage = spec.get('storage') or spec.get('pvc') or spec.setdefault('storage', {})
+    if logger and storage.get('storageClassName') not in (None, image_class):
+        logger.info(f"Disk of image {ref['namespace']}/{image['metadata']['name']} uses the image's "
+                    f"storage class {image_class} instead of {storage['storageClassName']}")
+    storage['storageClassName'] = image_class
+    storage['volumeMode'] = 'Block'
+    del spec['sourceRef']
+    spec['source'] = {'blank': {}}
+    # The image annotation goes on the DataVolume (the template of a VM's disk)
+    if doc.get('kind') == 'DataVolume':
+        owner = doc
+    else:
+        owner = next(dvt for dvt in doc['spec']['dataVolumeTemplates'] if dvt.get('spec') is spec)
+    image_id = f"{image['metadata'].get('namespace', ref['namespace'])}/{image['metadata']['name']}"
+    owner.setdefault('metadata', {}).setdefault('annotations', {})[HARVESTER_IMAGE_ANNOTATION] = image_id
+    if logger:
+        logger.info(f"DataSource {ref['namespace']}/{ref.get('name')} not found; "
+                    f"using Harvester image {image_id} ({image_class})")
+
+
+def _can_use_privileged_scc(namespace: str, logger: Optional[logging.Logger] = None) -> bool:
+    """Whether the current user may use the privileged SCC in the namespace (e.g. cluster-admin)."""
+    try:
+        rc, stdout, _ = run_kubectl_command(
+            ['auth', 'can-i', 'use', 'securitycontextconstraints.security.openshift.io/privileged',
+             '-n', namespace], check=False, timeout=PLATFORM_TIMEOUT, logger=logger)
+    except (OSError, subprocess.TimeoutExpired):
+        return False
+    return rc == 0 and stdout.strip() == 'yes'
+
+
+def allow_privileged_pods(namespace: str, service_account: str = 'default',
+                          logger: Optional[logging.Logger] = None) -> bool:
+    """
+    Let privileged pods (node exec pods) of the service account run in a namespace.
+
+    Only changes the cluster when the pods would be rejected: on OpenShift,
+    when the current user may not use the privileged SCC, the service account
+    is bound to it; on Kubernetes, when the namespace enforces a Pod Security
+    level below privileged, it is relabeled privileged. Done once per
+    namespace and process; a failure only warns, with the command that fixes it.
+
+    Returns:
+        True if the namespace admits privileged pods (as far as is known)
+    """
+    with _lock:
+        if namespace in _privileged_namespaces:
+            return _privileged_namespaces[namespace]
+
+    ok, error = True, ''
+    if is_openshift(logger):
+        fix = f"oc adm policy add-scc-to-user privileged -z {service_account} -n {namespace}"
+        if not _can_use_privileged_scc(namespace, logger):
+            binding = {
+                'apiVersion': 'rbac.authorization.k8s.io/v1',
+                'kind': 'RoleBinding',
+                'metadata': {'name': PRIVILEGED_SCC_ROLE_BINDING, 'namespace': namespace},
+                'roleRef': {'apiGroup': 'rbac.authorization.k8s.io', 'kind': 'ClusterRole',
+                            'name': PRIVILEGED_SCC_CLUSTER_ROLE},
+                'subjects': [{'kind': 'ServiceAccount', 'name': service_account, 'namespace': namespace}],
+            }
+            try:
+                returncode, _, stderr = _kubectl_with_input(apply_args(), json.dumps(binding), logger)
+                ok, error = returncode == 0, stderr.strip()
+            except OSError as e:
+                ok, error = False, str(e)
+            if ok and logger:
+                logger.info(f"Bound {namespace}/{service_account} to the privileged SCC "
+                            f"(RoleBinding {PRIVILEGED_SCC_ROLE_BINDING})")
+    else:
+        fix = f"kubectl label namespace {namespace} --overwrite {POD_SECURITY_LABELS[0]}=privileged"
+        labels = ((_get_json(['get', 'namespace', namespace], logger) or {}).get('metadata') or {}).get('labels') or {}
+        level = labels.get(POD_SECURITY_LABELS[0])
+        if level and level != 'privileged':
+            args = ['label', 'namespace', namespace, '--overwrite'] + [f"{label}=privileged"
+                                                                       for label in POD_SECURITY_LABELS]
+            try:
+                rc, _, error = run_kubectl_command(args, check=False, timeout=PLATFORM_TIMEOUT, logger=logger)
+                ok, error = rc == 0, error.strip()
+            except (OSError, subprocess.TimeoutExpired) as e:
+                ok, error = False, str(e)
+            if ok and logger:
+                logger.info(f"Relabeled namespace {namespace} from Pod Security level '{level}' to privileged")
+
+    if not ok and logger:
+        logger.warning(f"Privileged pods may be rejected in namespace {namespace} ({error}); "
+                       f"to allow them: {fix}")
+    with _lock:
+        _privileged_namespaces[namespace] = ok
+    return ok
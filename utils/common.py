@@ -9,6 +9,7 @@ kubectl command execution, and common helper functions.
 import json
 import logging
 import shlex
+import socket
 import subprocess
 import sys
 import time
@@ -17,6 +18,35 @@ import os
 from typing import Optional, Tuple, List
 import csv
 
+from utils.timing import round_duration
+from utils.stats import STAT_FIELDS, describe, log_outliers, metric_outliers, metric_stats, stat_columns
+from utils.histogram import save_histograms
+from utils.capacity import phase_metrics
+from utils.progress import vm_state
+from utils.output import route_human_output
+# Shared with the utils modules below, which cannot import this module; re-exported for the workloads
+from utils.base import (
+    ANY_RUN_SELECTOR, DEFAULT_WORKLOAD, GUEST_OS_CHOICES, GUEST_OS_LINUX, GUEST_OS_WINDOWS,
+    RUN_TIMESTAMP_ENV, RUN_TIMESTAMP_LABEL, RUN_UUID_ENV, RUN_UUID_LABEL, WINDOWS_READINESS_PORTS,
+    WORKLOAD_ENV, WORKLOAD_LABEL, _kubectl_with_input, get_run_uuid, parse_quantity_bytes, run_kubectl_command,
+    run_labels, run_selector, set_run_workload, split_vm_target, stamp_run_labels,
+)
+from utils.admission import take_admission_metrics, admission_report, print_admission_summary
+from utils.apply import FIELD_MANAGER, apply_manifest
+from utils.cloudinit import customize_cloud_init
+from utils.cluster_platform import adjust_datasource_refs, allow_privileged_pods
+from utils.custommetrics import collect_custom_metrics
+from utils.events import collect_run_events
+from utils.inventory import cluster_inventory
+from utils.quota import apply_namespace_constraints, constraints_summary, print_constraints_summary
+from utils.reachability import prober_for, take_probe_metrics
+from utils.scheduling import constrain_scheduling
+from utils.storageprovider import collect_storage_backend
+from utils.tenants import (
+    namespace_tenant, print_tenant_summary, setup_tenant_namespaces, tenant_count, tenant_summary,
+)
+from utils.utilization import collect_node_samples, storage_usage
+
 # Minimum required Python version
 MIN_PYTHON_VERSION = (3, 8)
 
@@ -29,6 +59,39 @@ SENSITIVE_ARG_KEYWORDS = (
     'pwd',
 )
 
+# Annotations on VMs (and, through the template, their VMIs and virt-launcher pods)
+# joining guest, storage and Kubernetes logs to a run: {run uuid}/{namespace}/{vm name}
+RUN_UUID_ANNOTATION = 'virtbench.io/run-uuid'
+CORRELATION_ID_ANNOTATION = 'virtbench.io/correlation-id'
+# Optional guest path the correlation IDs are written to by cloud-init (virtbench --correlation-file)
+CORRELATION_FILE_ENV = 'VIRTBENCH_CORRELATION_FILE'
+
+# Existing resources in these states are not adopted by create_or_adopt
+UNHEALTHY_VM_STATUSES = (
+    'ErrorUnschedulable',
+    'ErrImagePull',
+    'ImagePullBackOff',
+    'CrashLoopBackOff',
+    'ErrorPvcNotFound',
+    'ErrorDataVolumeNotFound',
+    'DataVolumeError',
+    'Unknown',
+)
+UNHEALTHY_PHASES = ('Failed', 'Lost', 'Unknown')
+
+# Per-migration statistics read from `virsh domjobinfo` (see parse_domjobinfo)
+MIGRATION_JOB_FIELDS = ('downtime_ms', 'data_transferred_bytes', 'memory_dirty_rate_mib_s', 'iterations')
+
+# Node label of the topology domain (availability zone) a node is in; --topology-key picks another, e.g. a rack label
+ZONE_LABEL = 'topology.kubernetes.io/zone'
+
+# libvirt URIs tried inside the virt-launcher compute container, newest layout first
+LAUNCHER_LIBVIRT_URIS = (
+    'qemu+unix:///session?socket=/var/run/libvirt/virtqemud-sock',
+    'qemu+unix:///system?socket=/var/run/libvirt/virtqemud-sock',
+    'qemu:///system',
+)
+
 
 class Colors:
     """ANSI color codes for terminal output."""
@@ -156,7 +219,8 @@ def setup_logging(log_file: Optional[str] = None, log_level: str = 'INFO') -> lo
         datefmt='%Y-%m-%d %H:%M:%S'
     )
 
-    # Console handler
+    # Console handler (stderr with --output json/yaml, so stdout carries only the summary)
+    route_human_output()
     console_handler = logging.StreamHandler(sys.stdout)
     console_handler.setLevel(getattr(logging, log_level.upper()))
     console_handler.setFormatter(formatter)
@@ -173,64 +237,14 @@ def setup_logging(log_file: Optional[str] = None, log_level: str = 'INFO') -> lo
             logger.addHandler(file_handler)
             logger.info(f"Logging to file: {log_file}")
             logger.info(f"Command: {get_command_for_logging()}")
+            if get_run_uuid():
+                logger.info(f"Run UUID: {get_run_uuid()}")
         except Exception as e:
             logger.error(f"Failed to create log file {log_file}: {e}")
 
     return logger
 
 
-def run_kubectl_command(
-    args: List[str],
-    check: bool = True,
-    capture_output: bool = True,
-    timeout: Optional[int] = None,
-    logger: Optional[logging.Logger] = None
-) -> Tuple[int, str, str]:
-    """
-    Execute a kubectl command with error handling.
-
-    Args:
-        args: List of command arguments (e.g., ['get', 'pods'])
-        check: Raise exception on non-zero exit code
-        capture_output: Capture stdout and stderr
-        timeout: Command timeout in seconds
-        logger: Logger instance for debug output
-
-    Returns:
-        Tuple of (return_code, stdout, stderr)
-
-    Raises:
-        subprocess.CalledProcessError: If check=True and command fails
-        subprocess.TimeoutExpired: If command exceeds timeout
-    """
-    cmd = ['kubectl'] + args
-
-    if logger:
-        logger.debug(f"Executing: {' '.join(cmd)}")
-
-    try:
-        result = subprocess.run(
-            cmd,
-            capture_output=capture_output,
-            text=True,
-            timeout=timeout,
-            check=check
-        )
-        return result.returncode, result.stdout, result.stderr
-    except subprocess.CalledProcessError as e:
-        if logger:
-            logger.error(f"Command failed: {' '.join(cmd)}")
-            logger.error(f"Exit code: {e.returncode}")
-            logger.error(f"Stderr: {e.stderr}")
-        if check:
-            raise
-        return e.returncode, e.stdout, e.stderr
-    except subprocess.TimeoutExpired as e:
-        if logger:
-            logger.error(f"Command timed out after {timeout}s: {' '.join(cmd)}")
-        raise
-
-
 def namespace_exists(namespace: str, logger: Optional[logging.Logger] = None) -> bool:
     """
     Check if a namespace exists.
@@ -257,7 +271,7 @@ def namespace_exists(namespace: str, logger: Optional[logging.Logger] = None) ->
 
 def create_namespace(namespace: str, logger: Optional[logging.Logger] = None) -> bool:
     """
-    Create a namespace if it doesn't exist.
+    Create a namespace if it doesn't exist, labeled with the run labels.
 
     Args:
         namespace: Namespace name
@@ -266,13 +280,20 @@ def create_namespace(namespace: str, logger: Optional[logging.Logger] = None) ->
     Returns:
         True if created or already exists, False on error
     """
+
     if namespace_exists(namespace, logger):
         if logger:
             logger.debug(f"Namespace {namespace} already exists")
-        return True
+        return setup_tenant_namespaces([namespace], logger)
 
     try:
         run_kubectl_command(['create', 'namespace', namespace], logger=logger)
+        run_kubectl_command(['label', 'namespace', namespace, '--overwrite'] +
+                            [f"{key}={value}" for key, value in run_labels().items()], logger=logger)
+        if not apply_namespace_constraints([namespace], logger):
+            return False
+        if not setup_tenant_namespaces([namespace], logger):
+            return False
         if logger:
             logger.info(f"Created namespace: {namespace}")
         return True
@@ -285,42 +306,21 @@ def create_namespace(namespace: str, logger: Optional[logging.Logger] = None) ->
 def create_namespaces_parallel(namespaces: List[str], batch_size: int = 20,
                                logger: Optional[logging.Logger] = None) -> List[str]:
     """
-    Create multiple namespaces in parallel batches.
+    Create multiple namespaces in batches and wait until they are Active.
+
+    Each batch is one server-side apply (see utils.namespaces).
 
     Args:
         namespaces: List of namespace names to create
-        batch_size: Number of namespaces to create in parallel
+        batch_size: Number of namespaces applied per batch
         logger: Logger instance
 
     Returns:
         List of successfully created namespace names
     """
-    from concurrent.futures import ThreadPoolExecutor, as_completed
-
-    if logger:
-        logger.info(f"Creating {len(namespaces)} namespaces in batches of {batch_size}...")
-
-    successful = []
-    failed = []
-
-    with ThreadPoolExecutor(max_workers=batch_size) as executor:
-        futures = {executor.submit(create_namespace, ns, logger): ns for ns in namespaces}
-
-        for future in as_completed(futures):
-            ns = futures[future]
-            try:
-                if future.result():
-                    successful.append(ns)
-                else:
-                    failed.append(ns)
-            except Exception as e:
-                if logger:
-                    logger.error(f"Exception creating namespace {ns}: {e}")
-                failed.append(ns)
-
-    if logger:
-        logger.info(f"Namespace creation complete: {len(successful)} successful, {len(failed)} failed")
+    from utils.namespaces import create_namespaces
 
+    successful, _ = create_namespaces(namespaces, batch_size=batch_size, logger=logger)
     return successful
 
 
@@ -360,45 +360,316 @@ def delete_namespace(namespace: str, wait: bool = False, logger: Optional[loggin
 
 
 def delete_namespaces_parallel(namespaces: List[str], batch_size: int = 20,
-                               logger: Optional[logging.Logger] = None) -> Tuple[List[str], List[str]]:
+                               logger: Optional[logging.Logger] = None,
+                               qps: float = 0, burst: int = 10) -> Tuple[List[str], List[str]]:
     """
-    Delete multiple namespaces in parallel batches.
+    Delete multiple namespaces in batches, confirming each is Terminating.
+
+    Each batch is one kubectl delete (see utils.namespaces).
 
     Args:
         namespaces: List of namespace names to delete
-        batch_size: Number of namespaces to delete in parallel
+        batch_size: Number of namespaces deleted per batch
         logger: Logger instance
+        qps: Maximum namespace deletions started per second (0 = unlimited)
+        burst: Maximum deletions started back-to-back when rate limited
 
     Returns:
         Tuple of (successful_deletions, failed_deletions)
     """
-    from concurrent.futures import ThreadPoolExecutor, as_completed
+    from utils.namespaces import delete_namespaces
+
+    return delete_namespaces(namespaces, batch_size=batch_size, qps=qps, burst=burst, logger=logger)
+
+
+def run_metadata() -> dict:
+    """
+    The "run" block of result summaries: which run wrote them, where and how.
+
+    `virtbench results list/show` read it to find runs by UUID and cluster.
+    """
+    os.environ.setdefault(RUN_TIMESTAMP_ENV, datetime.now().strftime('%Y%m%d-%H%M%S'))
+    try:
+        server = subprocess.run(['kubectl', 'config', 'view', '--minify', '-o',
+                                 'jsonpath={.clusters[0].cluster.server}'], capture_output=True, text=True)
+        api_server = server.stdout.strip() if server.returncode == 0 else None
+    except OSError:
+        api_server = None
+    return {
+        'uuid': get_run_uuid(),
+        'workload': os.environ.get(WORKLOAD_ENV) or DEFAULT_WORKLOAD,
+        'started_at': datetime.strptime(os.environ[RUN_TIMESTAMP_ENV], '%Y%m%d-%H%M%S').isoformat(),
+        'saved_at': datetime.now().isoformat(timespec='seconds'),
+        'command': get_command_for_logging(),
+        'cluster': os.environ.get('VIRTBENCH_CLUSTER'),
+        'api_server': api_server or None,
+        'host': socket.gethostname(),
+    }
+
+
+def correlation_id(namespace: str, vm_name: str) -> Optional[str]:
+    """Correlation ID of a VM in this run ({run uuid}/{namespace}/{vm name}), or None without a run UUID."""
+    run_uuid = get_run_uuid()
+    return f"{run_uuid}/{namespace}/{vm_name}" if run_uuid else None
+
+
+def stamp_correlation(doc: dict, namespace: Optional[str] = None,
+                      logger: Optional[logging.Logger] = None) -> dict:
+    """
+    Annotate a VirtualMachine manifest with the run UUID and its correlation ID.
 
+    The annotations go on the VM and its template, so the VMI and virt-launcher
+    pod carry them too. When CORRELATION_FILE_ENV names a guest path, a
+    cloud-init write_files entry also writes both IDs there as JSON on first
+    boot; only #cloud-config user data can be extended. Other objects are
+    returned unchanged.
+    """
+    import yaml
+
+    if doc.get('kind') != 'VirtualMachine':
+        return doc
+    metadata = doc.setdefault('metadata', {})
+    vm_ns = metadata.get('namespace') or namespace or 'default'
+    cid = correlation_id(vm_ns, metadata.get('name', ''))
+    if not cid:
+        return doc
+    annotations = {RUN_UUID_ANNOTATION: get_run_uuid(), CORRELATION_ID_ANNOTATION: cid}
+    metadata.setdefault('annotations', {}).update(annotations)
+    template = doc.setdefault('spec', {}).setdefault('template', {})
+    template.setdefault('metadata', {}).setdefault('annotations', {}).update(annotations)
+
+    guest_path = os.environ.get(CORRELATION_FILE_ENV)
+    if not guest_path:
+        return doc
+    for volume in template.get('spec', {}).get('volumes', []):
+        cloud_init = volume.get('cloudInitNoCloud') or volume.get('cloudInitConfigDrive')
+        user_data = (cloud_init or {}).get('userData', '')
+        if not user_data.lstrip().startswith('#cloud-config'):
+            continue
+        config = yaml.safe_load(user_data) or {}
+        config.setdefault('write_files', []).append({
+            'path': guest_path,
+            'permissions': '0644',
+            'content': json.dumps({'run_uuid': get_run_uuid(), 'correlation_id': cid,
+                                   'namespace': vm_ns, 'vm': metadata.get('name')}) + '\n',
+        })
+        cloud_init['userData'] = '#cloud-config\n' + yaml.safe_dump(config, sort_keys=False)
+        return doc
     if logger:
-        logger.info(f"Deleting {len(namespaces)} namespaces in batches of {batch_size}...")
+        logger.debug(f"[{vm_ns}] No #cloud-config user data; correlation file not written in the guest")
+    return doc
+
 
-    successful = []
-    failed = []
+def stamp_manifest(manifest: str, namespace: Optional[str] = None,
+                   logger: Optional[logging.Logger] = None) -> str:
+    """
+    Apply stamp_run_labels to every object in a YAML/JSON manifest, and
+    stamp_correlation to every VirtualMachine, and return it as YAML.
+    DataSource references are adjusted to the platform (see
+    utils.cluster_platform.adjust_datasource_refs), the cloud-init of
+    VMs is customized (see utils.cloudinit.customize_cloud_init) and their
+    scheduling constrained (see utils.scheduling.constrain_scheduling).
+    """
+    import yaml
 
-    with ThreadPoolExecutor(max_workers=batch_size) as executor:
-        futures = {executor.submit(delete_namespace, ns, False, logger): ns for ns in namespaces}
+    docs = [_stamp_doc(doc, namespace, logger) for doc in yaml.safe_load_all(manifest) if doc]
+    return yaml.safe_dump_all(docs, sort_keys=False)
 
-        for future in as_completed(futures):
-            ns = futures[future]
-            try:
-                if future.result():
-                    successful.append(ns)
-                else:
-                    failed.append(ns)
-            except Exception as e:
-                if logger:
-                    logger.error(f"Exception deleting namespace {ns}: {e}")
-                failed.append(ns)
 
+def _stamp_doc(doc: dict, namespace: Optional[str] = None, logger: Optional[logging.Logger] = None) -> dict:
+
+    doc = customize_cloud_init(stamp_run_labels(adjust_datasource_refs(doc, logger)), namespace, logger)
+    return stamp_correlation(constrain_scheduling(doc, logger), namespace, logger)
+
+
+def _resource_ref(doc: dict) -> str:
+    """kubectl resource argument for a manifest object, qualified by API group (e.g. virtualmachine.kubevirt.io)."""
+    kind = doc.get('kind', '').lower()
+    api_version = doc.get('apiVersion', '')
+    if '/' in api_version:
+        return f"{kind}.{api_version.split('/')[0]}"
+    return kind
+
+
+def _unhealthy_reason(obj: dict) -> Optional[str]:
+    """Return why an existing resource cannot be adopted, or None if it looks healthy."""
+    if obj.get('metadata', {}).get('deletionTimestamp'):
+        return 'being deleted'
+    status = obj.get('status') or {}
+    if obj.get('kind') == 'VirtualMachine':
+        if status.get('printableStatus') in UNHEALTHY_VM_STATUSES:
+            return f"in status {status['printableStatus']}"
+    elif status.get('phase') in UNHEALTHY_PHASES:
+        return f"in phase {status['phase']}"
+    return None
+
+
+def create_or_adopt(manifest: str, namespace: Optional[str] = None,
+                    logger: Optional[logging.Logger] = None) -> Tuple[bool, bool, str]:
+    """
+    Create the objects of a manifest, adopting ones left behind by an earlier attempt.
+
+    Objects are labeled with the run labels (see stamp_run_labels), VMs are
+    annotated with their correlation ID (see stamp_correlation), DataSource
+    references follow the platform (see stamp_manifest), and objects are
+    created with field manager virtbench. When
+    some already exist, each existing object must carry the same run UUID
+    label and must not be failed or terminating; the manifest is then
+    server-side applied (see utils.apply), which creates whatever the earlier
+    attempt did not get to. Objects owned by another run are never adopted,
+    and a run without a UUID adopts nothing.
+
+    Args:
+        manifest: YAML or JSON manifest, optionally with several documents
+        namespace: Namespace for objects without metadata.namespace
+        logger: Logger instance
+
+    Returns:
+        Tuple of (success, adopted, error message)
+    """
+    import yaml
+
+    run_uuid = get_run_uuid()
+    docs = [_stamp_doc(doc, namespace, logger) for doc in yaml.safe_load_all(manifest) if doc]
+    rendered = yaml.safe_dump_all(docs, sort_keys=False)
+    ns_args = ['-n', namespace] if namespace else []
+
+    # A create retried after a timeout may find its own object, which is then adopted below
+    returncode, _, stderr = _kubectl_with_input(
+        ['create', f'--field-manager={FIELD_MANAGER}', '-f', '-'] + ns_args, rendered, logger
+    )
+    if returncode == 0:
+        return True, False, ''
+    if 'AlreadyExists' not in stderr:
+        return False, False, stderr.strip()
+
+    adopted = []
+    for doc in docs:
+        metadata = doc.get('metadata', {})
+        doc_ns = metadata.get('namespace') or namespace
+        returncode, stdout, _ = run_kubectl_command(
+            ['get', _resource_ref(doc), metadata.get('name', ''), '-o', 'json'] + (['-n', doc_ns] if doc_ns else []),
+            check=False, logger=logger
+        )
+        if returncode != 0:
+            continue
+        existing = json.loads(stdout)
+        ref = f"{doc.get('kind')} {metadata.get('name')}"
+        if not run_uuid:
+            return False, False, (f"{ref} already exists; objects are only adopted by runs with a UUID "
+                                  f"(virtbench sets one, or set VIRTBENCH_UUID)")
+        owner = existing.get('metadata', {}).get('labels', {}).get(RUN_UUID_LABEL)
+        if owner != run_uuid:
+            if owner:
+                return False, False, f"{ref} already exists and belongs to run {owner}"
+            return False, False, f"{ref} already exists and was not created by a virtbench run"
+        reason = _unhealthy_reason(existing)
+        if reason:
+            return False, False, f"{ref} already exists but is {reason}"
+        adopted.append(ref)
+
+    applied, error = apply_manifest(rendered, namespace, logger=logger)
+    if not applied:
+        return False, False, error
     if logger:
-        logger.info(f"Namespace deletion complete: {len(successful)} successful, {len(failed)} failed")
+        logger.info(f"Adopted existing {', '.join(adopted)}")
+    return True, True, ''
+
+
+def vm_targets(namespace_prefix: str, start: int, end: int, vm_name: str,
+               single_namespace: Optional[str] = None) -> List[str]:
+    """
+    Build the list of test VM targets for an index range.
+
+    By default every VM lives in its own namespace ({prefix}-{i}) under the
+    same name, and the target is just the namespace. In namespace-scoped
+    mode all VMs share one pre-existing namespace and are told apart by
+    name, so the target is "{namespace}/{vm_name}-{i}". Either way the
+    target is unique per VM and is what results are keyed by.
+
+    Args:
+        namespace_prefix: Namespace prefix
+        start: Starting index
+        end: Ending index
+        vm_name: VM name (per-namespace mode) or VM name prefix (scoped mode)
+        single_namespace: Namespace holding every VM (namespace-scoped mode)
+
+    Returns:
+        List of targets
+    """
+    if single_namespace:
+        return [f"{single_namespace}/{vm_name}-{i}" for i in range(start, end + 1)]
+    return [f"{namespace_prefix}-{i}" for i in range(start, end + 1)]
+
+
+def call_for_target(func, target: str, vm_name: str, *args, **kwargs):
+    """Call a func(vm_name, namespace, ...) helper for a target from vm_targets()."""
+    namespace, name = split_vm_target(target, vm_name)
+    return func(name, namespace, *args, **kwargs)
 
-    return successful, failed
+
+def target_namespaces(targets: List[str]) -> List[str]:
+    """The distinct namespaces of targets from vm_targets(), in order."""
+    return list(dict.fromkeys(split_vm_target(target, '')[0] for target in targets))
+
+
+def namespace_layout(args) -> dict:
+    """
+    Namespace mode of a run, as recorded in the result summary.
+
+    Returns:
+        {'mode': 'single', 'namespace'} when every VM shares one namespace
+        (--single-namespace), else {'mode': 'per-vm', 'prefix'}
+    """
+    single_namespace = getattr(args, 'single_namespace', None)
+    if single_namespace:
+        return {'mode': 'single', 'namespace': single_namespace}
+    return {'mode': 'per-vm', 'prefix': getattr(args, 'namespace_prefix', None)}
+
+
+def scoped_resource_name(name: str, vm_name: str, target_vm: str) -> str:
+    """
+    Derive the per-VM name of a resource defined in a VM template.
+
+    In namespace-scoped mode several VMs created from one template share a
+    namespace, so names derived from the template VM name (e.g. its
+    DataVolume "rhel-9-vm-volume") are rewritten for each VM.
+
+    Args:
+        name: Resource name in the template
+        vm_name: VM name in the template
+        target_vm: Name of the VM being created
+
+    Returns:
+        Resource name unique to target_vm
+    """
+    if vm_name == target_vm:
+        return name
+    if name.startswith(vm_name):
+        return target_vm + name[len(vm_name):]
+    return f"{target_vm}-{name}"
+
+
+def rename_template_vm(doc: dict, vm_name: str, target_vm: str) -> dict:
+    """
+    Rename a parsed VirtualMachine and its DataVolumes for namespace-scoped mode.
+
+    Args:
+        doc: VirtualMachine document of a VM template (modified in place)
+        vm_name: VM name in the template
+        target_vm: Name of the VM being created
+
+    Returns:
+        doc
+    """
+    doc['metadata']['name'] = target_vm
+    spec = doc.setdefault('spec', {})
+    for dv in spec.get('dataVolumeTemplates', []):
+        dv['metadata']['name'] = scoped_resource_name(dv['metadata']['name'], vm_name, target_vm)
+    for volume in spec.setdefault('template', {}).setdefault('spec', {}).get('volumes', []):
+        if 'dataVolume' in volume:
+            volume['dataVolume']['name'] = scoped_resource_name(volume['dataVolume']['name'], vm_name, target_vm)
+    return doc
 
 
 def delete_vm(vm_name: str, namespace: str, logger: Optional[logging.Logger] = None) -> bool:
@@ -495,7 +766,8 @@ def delete_vmim(vmim_name: str, namespace: str, logger: Optional[logging.Logger]
 
 
 def list_resources_in_namespace(namespace: str, resource_type: str,
-                                logger: Optional[logging.Logger] = None) -> List[str]:
+                                logger: Optional[logging.Logger] = None,
+                                selector: Optional[str] = None) -> List[str]:
     """
     List all resources of a specific type in a namespace.
 
@@ -503,13 +775,15 @@ def list_resources_in_namespace(namespace: str, resource_type: str,
         namespace: Namespace name
         resource_type: Resource type (e.g., 'vm', 'dv', 'pvc', 'vmim')
         logger: Logger instance
+        selector: Only list resources matching this label selector
 
     Returns:
         List of resource names
     """
     try:
         returncode, stdout, _ = run_kubectl_command(
-            ['get', resource_type, '-n', namespace, '-o', 'jsonpath={.items[*].metadata.name}'],
+            ['get', resource_type, '-n', namespace, '-o', 'jsonpath={.items[*].metadata.name}'] +
+            (['-l', selector] if selector else []),
             check=False,
             logger=logger
         )
@@ -522,8 +796,44 @@ def list_resources_in_namespace(namespace: str, resource_type: str,
         return []
 
 
+def labeled_namespaces(namespaces: List[str], selector: str = ANY_RUN_SELECTOR,
+                       logger: Optional[logging.Logger] = None) -> List[str]:
+    """
+    Keep the namespaces that carry labels matching selector.
+
+    Namespaces without them (created by hand or with --skip-namespace-creation,
+    or by a version before resources were labeled) are left out with a warning.
+
+    Args:
+        namespaces: Candidate namespace names
+        selector: Label selector (default: created by any virtbench run)
+        logger: Logger instance
+
+    Returns:
+        Matching namespaces, in the order given
+    """
+    returncode, stdout, stderr = run_kubectl_command(
+        ['get', 'namespaces', '-l', selector, '-o', 'jsonpath={.items[*].metadata.name}'],
+        check=False, logger=logger
+    )
+    if returncode != 0:
+        if logger:
+            logger.error(f"Cannot list namespaces labeled {selector}: {stderr.strip()}")
+        return []
+    labeled = set(stdout.split())
+    _, stdout, _ = run_kubectl_command(['get', 'namespaces', '-o', 'jsonpath={.items[*].metadata.name}'],
+                                       check=False, logger=logger)
+    existing = set(stdout.split())
+    skipped = [ns for ns in namespaces if ns in existing and ns not in labeled]
+    if skipped and logger:
+        logger.warning(f"Ignoring {len(skipped)} namespaces without labels matching {selector}: "
+                       f"{', '.join(skipped[:5])}{' ...' if len(skipped) > 5 else ''}")
+    return [ns for ns in namespaces if ns in labeled]
+
+
 def cleanup_namespace_resources(namespace: str, vm_name: Optional[str] = None,
-                                dry_run: bool = False, logger: Optional[logging.Logger] = None) -> dict:
+                                dry_run: bool = False, logger: Optional[logging.Logger] = None,
+                                selector: Optional[str] = None) -> dict:
     """
     Clean up all test resources in a namespace.
 
@@ -532,6 +842,7 @@ def cleanup_namespace_resources(namespace: str, vm_name: Optional[str] = None,
         vm_name: Optional VM name to delete (if None, deletes all VMs)
         dry_run: If True, only show what would be deleted
         logger: Logger instance
+        selector: Only delete resources matching this label selector
 
     Returns:
         Dictionary with cleanup statistics
@@ -550,7 +861,7 @@ def cleanup_namespace_resources(namespace: str, vm_name: Optional[str] = None,
         return stats
 
     # Delete VirtualMachineInstanceMigrations
-    vmims = list_resources_in_namespace(namespace, 'virtualmachineinstancemigration', logger)
+    vmims = list_resources_in_namespace(namespace, 'virtualmachineinstancemigration', logger, selector)
     for vmim in vmims:
         if dry_run:
             if logger:
@@ -562,10 +873,9 @@ def cleanup_namespace_resources(namespace: str, vm_name: Optional[str] = None,
                 stats['errors'] += 1
 
     # Delete VMs
+    vms = list_resources_in_namespace(namespace, 'vm', logger, selector)
     if vm_name:
-        vms = [vm_name]
-    else:
-        vms = list_resources_in_namespace(namespace, 'vm', logger)
+        vms = [vm for vm in vms if vm == vm_name] if selector else [vm_name]
 
     for vm in vms:
         if dry_run:
@@ -578,7 +888,7 @@ def cleanup_namespace_resources(namespace: str, vm_name: Optional[str] = None,
                 stats['errors'] += 1
 
     # Delete DataVolumes
-    dvs = list_resources_in_namespace(namespace, 'dv', logger)
+    dvs = list_resources_in_namespace(namespace, 'dv', logger, selector)
     for dv in dvs:
         if dry_run:
             if logger:
@@ -590,7 +900,7 @@ def cleanup_namespace_resources(namespace: str, vm_name: Optional[str] = None,
                 stats['errors'] += 1
 
     # Delete PVCs (if any remain after DV deletion)
-    pvcs = list_resources_in_namespace(namespace, 'pvc', logger)
+    pvcs = list_resources_in_namespace(namespace, 'pvc', logger, selector)
     for pvc in pvcs:
         if dry_run:
             if logger:
@@ -607,10 +917,18 @@ def cleanup_namespace_resources(namespace: str, vm_name: Optional[str] = None,
 def cleanup_test_namespaces(namespace_prefix: str, start: int, end: int,
                            vm_name: Optional[str] = None, delete_namespaces: bool = True,
                            dry_run: bool = False, batch_size: int = 20,
-                           logger: Optional[logging.Logger] = None) -> dict:
+                           logger: Optional[logging.Logger] = None,
+                           qps: float = 0, burst: int = 10,
+                           single_namespace: Optional[str] = None,
+                           selector: str = ANY_RUN_SELECTOR) -> dict:
     """
     Clean up all test resources across multiple namespaces.
 
+    Only resources and namespaces labeled to match selector are deleted: by
+    default anything a virtbench run created, so that a namespace or VM that
+    merely matches the prefix is never deleted. Pass run_selector() to clean
+    up only what the current run created.
+
     Args:
         namespace_prefix: Namespace prefix (e.g., 'kubevirt-perf-test')
         start: Starting namespace index
@@ -620,11 +938,21 @@ def cleanup_test_namespaces(namespace_prefix: str, start: int, end: int,
         dry_run: If True, only show what would be deleted
         batch_size: Number of namespaces to process in parallel
         logger: Logger instance
+        qps: Maximum namespace cleanups started per second (0 = unlimited)
+        burst: Maximum cleanups started back-to-back when rate limited
+        single_namespace: Namespace-scoped mode; only the test VMs
+            ({vm_name}-{i}) in this namespace are deleted, never the namespace
+        selector: Label selector of the namespaces and resources to clean up
 
     Returns:
         Dictionary with overall cleanup statistics
     """
-    from concurrent.futures import ThreadPoolExecutor, as_completed
+    from utils.concurrency import run_parallel
+
+    if single_namespace:
+        return cleanup_scoped_vms(single_namespace, vm_targets(namespace_prefix, start, end, vm_name,
+                                                               single_namespace),
+                                  dry_run, batch_size, logger, qps, burst, selector)
 
     namespaces = [f"{namespace_prefix}-{i}" for i in range(start, end + 1)]
 
@@ -642,32 +970,28 @@ def cleanup_test_namespaces(namespace_prefix: str, start: int, end: int,
     }
 
     # Clean up resources in each namespace
-    with ThreadPoolExecutor(max_workers=batch_size) as executor:
-        futures = {
-            executor.submit(cleanup_namespace_resources, ns, vm_name, dry_run, logger): ns
-            for ns in namespaces
-        }
-
-        for future in as_completed(futures):
-            ns = futures[future]
-            try:
-                stats = future.result()
-                overall_stats['namespaces_processed'] += 1
-                overall_stats['total_vms_deleted'] += stats['vms_deleted']
-                overall_stats['total_dvs_deleted'] += stats['dvs_deleted']
-                overall_stats['total_pvcs_deleted'] += stats['pvcs_deleted']
-                overall_stats['total_vmims_deleted'] += stats['vmims_deleted']
-                overall_stats['total_errors'] += stats['errors']
-            except Exception as e:
-                if logger:
-                    logger.error(f"Exception cleaning namespace {ns}: {e}")
-                overall_stats['total_errors'] += 1
-
-    # Delete namespaces if requested
+    outcomes = run_parallel(
+        cleanup_namespace_resources, namespaces, concurrency=batch_size, qps=qps, burst=burst,
+        args=(vm_name, dry_run, logger, selector), logger=logger, description="namespace cleanup"
+    )
+    for ns, stats, error in outcomes:
+        if error is not None:
+            overall_stats['total_errors'] += 1
+            continue
+        overall_stats['namespaces_processed'] += 1
+        overall_stats['total_vms_deleted'] += stats['vms_deleted']
+        overall_stats['total_dvs_deleted'] += stats['dvs_deleted']
+        overall_stats['total_pvcs_deleted'] += stats['pvcs_deleted']
+        overall_stats['total_vmims_deleted'] += stats['vmims_deleted']
+        overall_stats['total_errors'] += stats['errors']
+
+    # Delete namespaces if requested; only the ones virtbench created
+    if delete_namespaces:
+        namespaces = labeled_namespaces(namespaces, selector, logger)
     if delete_namespaces and not dry_run:
         if logger:
             logger.info(f"Deleting {len(namespaces)} namespaces...")
-        successful, failed = delete_namespaces_parallel(namespaces, batch_size, logger)
+        successful, failed = delete_namespaces_parallel(namespaces, batch_size, logger, qps, burst)
         overall_stats['namespaces_deleted'] = len(successful)
         overall_stats['total_errors'] += len(failed)
     elif delete_namespaces and dry_run:
@@ -678,6 +1002,71 @@ def cleanup_test_namespaces(namespace_prefix: str, start: int, end: int,
     return overall_stats
 
 
+def cleanup_scoped_vms(namespace: str, targets: List[str], dry_run: bool = False,
+                       batch_size: int = 20, logger: Optional[logging.Logger] = None,
+                       qps: float = 0, burst: int = 10,
+                       selector: str = ANY_RUN_SELECTOR) -> dict:
+    """
+    Delete the test VMs of a namespace-scoped run.
+
+    Only the listed VMs labeled to match selector are deleted; their
+    DataVolumes are owned by the VM and garbage-collected with it, and the
+    shared namespace is kept.
+
+    Args:
+        namespace: Shared namespace
+        targets: Targets from vm_targets()
+        dry_run: If True, only show what would be deleted
+        batch_size: Number of VMs to delete in parallel
+        logger: Logger instance
+        qps: Maximum deletions started per second (0 = unlimited)
+        burst: Maximum deletions started back-to-back when rate limited
+        selector: Label selector of the VMs to delete
+
+    Returns:
+        Dictionary with overall cleanup statistics (same keys as cleanup_test_namespaces)
+    """
+    from utils.concurrency import run_parallel
+
+    overall_stats = {
+        'namespaces_processed': 0,
+        'namespaces_deleted': 0,
+        'total_vms_deleted': 0,
+        'total_dvs_deleted': 0,
+        'total_pvcs_deleted': 0,
+        'total_vmims_deleted': 0,
+        'total_errors': 0
+    }
+    labeled = set(list_resources_in_namespace(namespace, 'vm', logger, selector))
+    existing = set(list_resources_in_namespace(namespace, 'vm', logger))
+    names = [split_vm_target(t, '')[1] for t in targets]
+    skipped = [name for name in names if name in existing and name not in labeled]
+    if skipped and logger:
+        logger.warning(f"Skipping {len(skipped)} VMs without labels matching {selector}: "
+                       f"{', '.join(skipped[:5])}{' ...' if len(skipped) > 5 else ''}")
+    names = [name for name in names if name in labeled]
+    if logger:
+        logger.info(f"{'[DRY RUN] ' if dry_run else ''}Deleting {len(names)} VMs in namespace {namespace}...")
+
+    if dry_run:
+        if logger:
+            for name in names:
+                logger.info(f"[DRY RUN] Would delete VM: {name} in {namespace}")
+        return overall_stats
+
+    outcomes = run_parallel(
+        lambda name: delete_vm(name, namespace, logger), names, concurrency=batch_size,
+        qps=qps, burst=burst, logger=logger, description="VM cleanup"
+    )
+    overall_stats['namespaces_processed'] = 1
+    for _, deleted, error in outcomes:
+        if error is None and deleted:
+            overall_stats['total_vms_deleted'] += 1
+        else:
+            overall_stats['total_errors'] += 1
+    return overall_stats
+
+
 def remove_far_annotation(vm_name: str, namespace: str, logger: Optional[logging.Logger] = None) -> bool:
     """
     Remove FAR (Fence Agents Remediation) annotation from a VM.
@@ -843,26 +1232,51 @@ def get_vm_status(vm_name: str, namespace: str, logger: Optional[logging.Logger]
         return None
 
 
-def get_vmi_ip(vmi_name: str, namespace: str, logger: Optional[logging.Logger] = None) -> Optional[str]:
+def _interface_ip(interface: dict) -> Optional[str]:
+    """Usable address of a VMI status interface: IPv4 preferred, IPv6 link-local skipped."""
+    addresses = [interface.get('ipAddress')] + list(interface.get('ipAddresses') or [])
+    addresses = [a.split('/')[0] for a in addresses if a and not a.lower().startswith('fe80:')]
+    return next((a for a in addresses if ':' not in a), addresses[0] if addresses else None)
+
+
+def get_vmi_ip(vmi_name: str, namespace: str, logger: Optional[logging.Logger] = None,
+               network: Optional[str] = None) -> Optional[str]:
     """
-    Get the IP address of a VMI.
+    Get the IP address of a VMI from its status.
+
+    The first status interface is not necessarily the pod network: with
+    secondary networks, or interfaces only the guest agent reports, it can be
+    any of them. The address of the named network is used, by default the
+    interface of the pod network, falling back to the first interface with
+    an address.
 
     Args:
         vmi_name: VMI name
         namespace: Namespace
         logger: Logger instance
+        network: Name of the VMI network whose address to return (default: the pod network)
 
     Returns:
         IP address or None if not available
     """
     try:
         returncode, stdout, _ = run_kubectl_command(
-            ['get', 'vmi', vmi_name, '-n', namespace, '-o', 'jsonpath={.status.interfaces[0].ipAddress}'],
+            ['get', 'vmi', vmi_name, '-n', namespace, '-o', 'json'],
             check=False,
             logger=logger
         )
-        if returncode == 0 and stdout and stdout != '<none>':
-            return stdout.strip()
+        if returncode != 0 or not stdout.strip():
+            return None
+        vmi = json.loads(stdout)
+        interfaces = (vmi.get('status') or {}).get('interfaces') or []
+        if network is None:
+            networks = (vmi.get('spec') or {}).get('networks') or []
+            network = next((n['name'] for n in networks if 'pod' in n), None)
+        named = [i for i in interfaces if network and i.get('name') == network]
+        for interface in named + interfaces:
+            ip = _interface_ip(interface)
+            if ip:
+                return ip
         return None
     except Exception as e:
         if logger:
@@ -870,6 +1284,59 @@ def get_vmi_ip(vmi_name: str, namespace: str, logger: Optional[logging.Logger] =
         return None
 
 
+def get_guest_agent_status(vmi_name: str, namespace: str,
+                           logger: Optional[logging.Logger] = None) -> Optional[dict]:
+    """
+    Get qemu-guest-agent connection state and guest OS info of a VMI.
+
+    KubeVirt sets the AgentConnected condition once the agent inside the
+    guest talks to virt-launcher, and then fills status.guestOSInfo.
+
+    Args:
+        vmi_name: VMI name
+        namespace: Namespace
+        logger: Logger instance
+
+    Returns:
+        Dict with keys connected (bool), connected_at (condition
+        lastTransitionTime or None) and guest_os (dict with name, version,
+        kernel and id, or None), or None if the VMI could not be read
+    """
+    try:
+        returncode, stdout, _ = run_kubectl_command(
+            ['get', 'vmi', vmi_name, '-n', namespace, '-o', 'json'],
+            check=False,
+            logger=logger
+        )
+        if returncode != 0 or not stdout:
+            return None
+        status = json.loads(stdout).get('status', {})
+    except Exception as e:
+        if logger:
+            logger.debug(f"Error getting guest agent status for {vmi_name} in {namespace}: {e}")
+        return None
+
+    condition = next((c for c in status.get('conditions', [])
+                      if c.get('type') == 'AgentConnected'), None)
+    connected = bool(condition and condition.get('status') == 'True')
+
+    os_info = status.get('guestOSInfo') or {}
+    guest_os = None
+    if os_info:
+        guest_os = {
+            'name': os_info.get('prettyName') or os_info.get('name'),
+            'version': os_info.get('version') or os_info.get('versionId'),
+            'kernel': os_info.get('kernelRelease'),
+            'id': os_info.get('id'),
+        }
+
+    return {
+        'connected': connected,
+        'connected_at': condition.get('lastTransitionTime') if connected else None,
+        'guest_os': guest_os,
+    }
+
+
 def get_vm_disk_count(vm_name: str, namespace: str,
                       logger: Optional[logging.Logger] = None) -> int:
     """
@@ -1000,6 +1467,59 @@ def ping_vm(ip: str, ssh_pod: str, ssh_pod_ns: str, logger: Optional[logging.Log
         return False
 
 
+def check_vm_port(ip: str, port: int, ssh_pod: str, ssh_pod_ns: str,
+                  logger: Optional[logging.Logger] = None) -> bool:
+    """
+    Check whether a TCP port on a VM accepts connections, from an SSH pod.
+
+    Args:
+        ip: VM IP address
+        port: TCP port
+        ssh_pod: SSH pod name
+        ssh_pod_ns: SSH pod namespace
+        logger: Logger instance
+
+    Returns:
+        True if the port is open, False otherwise
+    """
+    try:
+        returncode, _, _ = run_kubectl_command(
+            ['exec', '-n', ssh_pod_ns, ssh_pod, '--', 'nc', '-z', '-w', '2', ip, str(port)],
+            check=False,
+            capture_output=True,
+            timeout=5,
+            logger=logger
+        )
+        return returncode == 0
+    except Exception as e:
+        if logger:
+            logger.debug(f"Port check failed for {ip}:{port}: {e}")
+        return False
+
+
+def check_guest_ready(ip: str, ssh_pod: str, ssh_pod_ns: str, guest_os: str = GUEST_OS_LINUX,
+                      logger: Optional[logging.Logger] = None, target: Optional[str] = None) -> bool:
+    """
+    Check whether a VM guest is reachable.
+
+    Linux guests are pinged. Windows guests are considered ready once RDP or
+    WinRM accepts connections. Concurrent checks against the same SSH pod are
+    batched into one exec per polling round (see utils.reachability).
+
+    Args:
+        ip: VM IP address
+        ssh_pod: SSH pod name
+        ssh_pod_ns: SSH pod namespace
+        guest_os: Guest operating system (linux or windows)
+        logger: Logger instance
+        target: Namespace or "{namespace}/{vm}" to record probe metrics under
+
+    Returns:
+        True if the guest is reachable, False otherwise
+    """
+    return prober_for(ssh_pod, ssh_pod_ns, logger).probe(ip, guest_os, target)
+
+
 def stop_vm(vm_name: str, namespace: str, logger: Optional[logging.Logger] = None) -> bool:
     """
     Stop a VM by setting runStrategy to Halted.
@@ -1182,6 +1702,40 @@ def get_worker_nodes(logger: Optional[logging.Logger] = None) -> List[str]:
         return []
 
 
+def get_node_zones(nodes: Optional[List[str]] = None, topology_key: str = ZONE_LABEL,
+                   logger: Optional[logging.Logger] = None) -> dict:
+    """
+    Get the topology domain (zone, rack, ...) of nodes from a node label.
+
+    Args:
+        nodes: Node names (default: every node in the cluster)
+        topology_key: Node label holding the domain (default: topology.kubernetes.io/zone)
+        logger: Logger instance
+
+    Returns:
+        Dict of node name -> label value (None for nodes without the label)
+    """
+    zones = {}
+    returncode, stdout, _ = run_kubectl_command(['get', 'nodes', '-o', 'json'], check=False, logger=logger)
+    if returncode == 0:
+        try:
+            for node in json.loads(stdout).get('items', []):
+                metadata = node.get('metadata', {})
+                zones[metadata.get('name')] = (metadata.get('labels') or {}).get(topology_key)
+        except json.JSONDecodeError:
+            pass
+    if nodes is None:
+        return zones
+    return {node: zones.get(node) for node in nodes}
+
+
+def is_cross_zone(source_zone: Optional[str], target_zone: Optional[str]) -> Optional[bool]:
+    """Whether a migration left its zone (None when either zone is unknown)."""
+    if not source_zone or not target_zone:
+        return None
+    return source_zone != target_zone
+
+
 def is_node_ready(node_name: str, logger: Optional[logging.Logger] = None) -> bool:
     """
     Check if a specific node is in Ready state.
@@ -1403,10 +1957,22 @@ def remove_node_selectors(vm_name: str, namespace: str,
     return vm_success and vmi_success
 
 
-def migrate_vm(vm_name: str, namespace: str, target_node: Optional[str] = None,
-               logger: Optional[logging.Logger] = None) -> bool:
-    """
-    Trigger live migration of a VM.
+def migration_manifest(vm_name: str, namespace: str) -> str:
+    """VirtualMachineInstanceMigration manifest migrate_vm creates for a VM."""
+    return f"""apiVersion: kubevirt.io/v1
+kind: VirtualMachineInstanceMigration
+metadata:
+  name: migration-{vm_name}
+  namespace: {namespace}
+spec:
+  vmiName: {vm_name}
+"""
+
+
+def migrate_vm(vm_name: str, namespace: str, target_node: Optional[str] = None,
+               logger: Optional[logging.Logger] = None) -> bool:
+    """
+    Trigger live migration of a VM.
 
     Args:
         vm_name: Name of the VM to migrate
@@ -1444,14 +2010,7 @@ spec:
         # Actually, the best way is to create a VirtualMachineInstanceMigration object
         import subprocess
         migration_name = f"migration-{vm_name}"
-        migration_yaml = f"""apiVersion: kubevirt.io/v1
-kind: VirtualMachineInstanceMigration
-metadata:
-  name: {migration_name}
-  namespace: {namespace}
-spec:
-  vmiName: {vm_name}
-"""
+        migration_yaml = migration_manifest(vm_name, namespace)
 
         # Delete any existing migration object first
         subprocess.run(
@@ -1462,7 +2021,7 @@ spec:
         # Create migration object
         result = subprocess.run(
             f"kubectl create -f -",
-            shell=True, input=migration_yaml.encode(), capture_output=True, text=False
+            shell=True, input=stamp_manifest(migration_yaml).encode(), capture_output=True, text=False
         )
 
         if result.returncode == 0:
@@ -1576,6 +2135,205 @@ def calculate_vmim_duration(start_timestamp: str, end_timestamp: str) -> Optiona
         return None
 
 
+def get_vmi_memory_bytes(vm_name: str, namespace: str,
+                         logger: Optional[logging.Logger] = None) -> Optional[int]:
+    """
+    Get the guest memory size of a running VMI.
+
+    Uses the current guest memory reported in the VMI status, falling back
+    to the guest memory or memory request in the spec.
+
+    Args:
+        vm_name: VMI name
+        namespace: Namespace
+        logger: Logger instance
+
+    Returns:
+        Memory in bytes, or None if unavailable
+    """
+    returncode, stdout, _ = run_kubectl_command(
+        ['get', 'vmi', vm_name, '-n', namespace, '-o', 'json'], check=False, logger=logger
+    )
+    if returncode != 0:
+        return None
+    try:
+        vmi = json.loads(stdout)
+    except ValueError:
+        return None
+    domain = vmi.get('spec', {}).get('domain', {})
+    for quantity in (vmi.get('status', {}).get('memory', {}).get('guestCurrent'),
+                     domain.get('memory', {}).get('guest'),
+                     domain.get('resources', {}).get('requests', {}).get('memory')):
+        if quantity:
+            return parse_quantity_bytes(quantity)
+    return None
+
+
+def migration_throughput(transferred_bytes: Optional[float], transfer_sec: Optional[float],
+                         memory_bytes: Optional[int], observed_sec: Optional[float]) -> dict:
+    """
+    Compute per-migration throughput two ways.
+
+    The transfer throughput divides the bytes QEMU actually sent by the
+    transfer duration from the VMI migrationState timestamps. The estimate
+    divides the guest memory size by the observed wall time, which is all
+    that is known without transfer statistics. Their ratio, together with
+    transfer_ratio (bytes sent / memory size, above 1 when dirty pages are
+    resent), shows how efficiently the migration network was used.
+
+    Args:
+        transferred_bytes: Bytes transferred (None if unknown)
+        transfer_sec: Transfer duration from migrationState (vmim_time)
+        memory_bytes: Guest memory size
+        observed_sec: Observed migration wall time
+
+    Returns:
+        Dict with transferred_bytes, memory_bytes, transfer_mib_s,
+        estimated_mib_s and transfer_ratio (None where not computable)
+    """
+    mib = 1024 ** 2
+
+    def rate(size, seconds):
+        return round(size / mib / seconds, 2) if size and seconds else None
+
+    return {
+        'transferred_bytes': int(transferred_bytes) if transferred_bytes else None,
+        'memory_bytes': memory_bytes,
+        'transfer_mib_s': rate(transferred_bytes, transfer_sec),
+        'estimated_mib_s': rate(memory_bytes, observed_sec),
+        'transfer_ratio': round(transferred_bytes / memory_bytes, 2)
+        if transferred_bytes and memory_bytes else None,
+    }
+
+
+def parse_domjobinfo(output: str) -> dict:
+    """
+    Parse `virsh domjobinfo` output into migration statistics.
+
+    Args:
+        output: domjobinfo output ("Key:   value [unit]" lines)
+
+    Returns:
+        Dict with downtime_ms, data_transferred_bytes, memory_dirty_rate_mib_s
+        and iterations (None where not reported)
+    """
+    fields = {}
+    for line in output.splitlines():
+        key, sep, value = line.partition(':')
+        if sep and value.split():
+            fields[key.strip()] = value.split()
+
+    def number(key):
+        try:
+            return float(fields[key][0]) if key in fields else None
+        except ValueError:
+            return None
+
+    def size(key):
+        value = number(key)
+        if value is None:
+            return None
+        unit = fields[key][1] if len(fields[key]) > 1 else ''
+        if unit in ('', 'B', 'bytes'):
+            return int(value)
+        # virsh prints binary units (KiB, MiB, GiB); drop the trailing B for parse_quantity_bytes
+        return parse_quantity_bytes(f"{value}{unit[:-1]}")
+
+    downtime = number('Total downtime')
+    iterations = number('Iteration')
+    dirty_pages = number('Dirty rate')
+    page_size = size('Page size') or 4096
+    transferred = size('Data processed')
+    if transferred is None:
+        transferred = size('Memory processed')
+    return {
+        'downtime_ms': int(downtime) if downtime is not None else None,
+        'data_transferred_bytes': transferred,
+        'memory_dirty_rate_mib_s': round(dirty_pages * page_size / 1024 ** 2, 2)
+        if dirty_pages is not None else None,
+        'iterations': int(iterations) if iterations is not None else None,
+    }
+
+
+def get_migration_state(vm_name: str, namespace: str,
+                        logger: Optional[logging.Logger] = None) -> dict:
+    """
+    Get status.migrationState of the VM's VMIM, falling back to the VMI's.
+
+    Returns:
+        migrationState dict (sourcePod, targetPod, mode, timestamps, ...), empty if unavailable
+    """
+    for resource, name in (('virtualmachineinstancemigration', f"migration-{vm_name}"),
+                           ('vmi', vm_name)):
+        returncode, stdout, _ = run_kubectl_command(
+            ['get', resource, name, '-n', namespace, '-o', 'json'], check=False, logger=logger
+        )
+        if returncode != 0:
+            continue
+        try:
+            state = json.loads(stdout).get('status', {}).get('migrationState') or {}
+        except json.JSONDecodeError:
+            continue
+        if state:
+            return state
+    return {}
+
+
+def get_domain_jobinfo(pod: str, namespace: str, domain: str, completed: bool = True,
+                       logger: Optional[logging.Logger] = None) -> Optional[dict]:
+    """
+    Run `virsh domjobinfo` in the compute container of a virt-launcher pod.
+
+    Args:
+        pod: virt-launcher pod name
+        namespace: Namespace
+        domain: libvirt domain name ({namespace}_{vm})
+        completed: Report the last completed job instead of the running one
+        logger: Logger instance
+
+    Returns:
+        parse_domjobinfo() dict, or None if virsh could not be reached
+    """
+    for uri in LAUNCHER_LIBVIRT_URIS:
+        cmd = ['exec', pod, '-n', namespace, '-c', 'compute', '--',
+               'virsh', '-c', uri, 'domjobinfo', domain] + (['--completed'] if completed else [])
+        try:
+            returncode, stdout, _ = run_kubectl_command(cmd, check=False, timeout=30, logger=logger)
+        except subprocess.TimeoutExpired:
+            continue
+        if returncode == 0 and 'Job type' in stdout:
+            return parse_domjobinfo(stdout)
+    return None
+
+
+def get_migration_job_stats(vm_name: str, namespace: str,
+                            logger: Optional[logging.Logger] = None) -> dict:
+    """
+    Collect the statistics of a VM's last completed migration.
+
+    Reads the source and target pods and migration mode from migrationState,
+    then the completed job statistics libvirt keeps for the domain: the
+    target pod reports them once the migration finishes, the source pod only
+    until it terminates.
+
+    Returns:
+        Dict with mode, migration_policy (the MigrationPolicy KubeVirt applied)
+        and the MIGRATION_JOB_FIELDS (None where unavailable)
+    """
+    state = get_migration_state(vm_name, namespace, logger)
+    stats = None
+    for pod in (state.get('targetPod'), state.get('sourcePod')):
+        found = get_domain_jobinfo(pod, namespace, f"{namespace}_{vm_name}", logger=logger) if pod else None
+        if found:
+            stats = found
+            if found['downtime_ms'] is not None:
+                break
+    if not stats and logger:
+        logger.debug(f"[{namespace}] Domain job statistics not available for {vm_name}")
+    return {'mode': state.get('mode'), 'migration_policy': state.get('migrationPolicyName'),
+            **(stats or parse_domjobinfo(''))}
+
+
 def wait_for_migration_complete(vm_name: str, namespace: str, timeout: int = 600,
                                 poll_interval: int = 2,
                                 logger: Optional[logging.Logger] = None) -> Tuple[bool, float, Optional[str], Optional[float]]:
@@ -1594,19 +2352,19 @@ def wait_for_migration_complete(vm_name: str, namespace: str, timeout: int = 600
         - observed_duration: Time measured by polling node changes
         - vmim_duration: Time from VMIM timestamps (more accurate)
     """
-    start_time = time.time()
+    start_time = time.monotonic()
     original_node = get_vm_node(vm_name, namespace, logger)
 
     if logger:
         logger.info(f"[{namespace}] Waiting for migration of {vm_name} from node {original_node}")
 
-    while time.time() - start_time < timeout:
+    while time.monotonic() - start_time < timeout:
         # Check if VM has moved to a different node
         current_node = get_vm_node(vm_name, namespace, logger)
 
         if current_node and current_node != original_node:
             # VM has migrated to a new node
-            observed_duration = time.time() - start_time
+            observed_duration = time.monotonic() - start_time
 
             # Get VMIM timestamps for accurate measurement
             start_ts, end_ts, phase = get_vmim_timestamps(vm_name, namespace, logger)
@@ -1621,21 +2379,24 @@ def wait_for_migration_complete(vm_name: str, namespace: str, timeout: int = 600
                 if logger:
                     logger.info(f"[{namespace}] Migration complete: {vm_name} moved from {original_node} to {current_node} in {observed_duration:.2f}s")
 
+            vm_state(namespace, f"migrated to {current_node}")
             return True, observed_duration, current_node, vmim_duration
 
         # Check VMIM phase directly (more reliable than VMI migration state)
         start_ts, end_ts, vmim_phase = get_vmim_timestamps(vm_name, namespace, logger)
+        if vmim_phase:
+            vm_state(namespace, f"migration {vmim_phase}")
         if vmim_phase and vmim_phase.lower() == "failed":
             if logger:
                 logger.error(f"[{namespace}] VMIM phase is Failed for VM {vm_name}")
-            return False, time.time() - start_time, None, None
+            return False, time.monotonic() - start_time, None, None
 
         # Also check VMI migration state as fallback
         status = get_migration_status(vm_name, namespace, logger)
         if status == "Failed":
             if logger:
                 logger.error(f"[{namespace}] Migration failed for VM {vm_name}")
-            return False, time.time() - start_time, None, None
+            return False, time.monotonic() - start_time, None, None
 
         time.sleep(poll_interval)
 
@@ -1675,7 +2436,7 @@ def find_busiest_node(namespaces: List[str], vm_name: str,
     Find the node with the most VMs from the given namespaces.
 
     Args:
-        namespaces: List of namespace names to check
+        namespaces: List of namespace names (or vm_targets() targets) to check
         vm_name: VM name to look for
         logger: Logger instance
 
@@ -1688,7 +2449,7 @@ def find_busiest_node(namespaces: List[str], vm_name: str,
         logger.info(f"Scanning {len(namespaces)} namespaces to find busiest node...")
 
     for ns in namespaces:
-        node = get_vm_node(vm_name, ns, logger)
+        node = call_for_target(get_vm_node, ns, vm_name, logger)
         if node:
             node_counts[node] = node_counts.get(node, 0) + 1
 
@@ -1714,7 +2475,7 @@ def get_vms_on_node(namespaces: List[str], vm_name: str, target_node: str,
     Get list of namespaces where VMs are running on a specific node.
 
     Args:
-        namespaces: List of namespace names to check
+        namespaces: List of namespace names (or vm_targets() targets) to check
         vm_name: VM name to look for
         target_node: Node name to filter by
         logger: Logger instance
@@ -1728,7 +2489,7 @@ def get_vms_on_node(namespaces: List[str], vm_name: str, target_node: str,
         logger.info(f"Scanning {len(namespaces)} namespaces for VMs on {target_node}...")
 
     for ns in namespaces:
-        current_node = get_vm_node(vm_name, ns, logger)
+        current_node = call_for_target(get_vm_node, ns, vm_name, logger)
         if current_node == target_node:
             vms_on_node.append(ns)
             if logger:
@@ -1860,9 +2621,13 @@ def print_summary_table(
     running_times = []
     ping_times = []
     clone_times = []
+    agent_times = []
 
     for result in sorted(results, key=lambda x: x[0]):
         ns, run_t, ping_t, clone_t, ok = result[:5]
+        agent = result[5] if len(result) > 5 else None
+        if agent and agent.get('time') is not None:
+            agent_times.append(agent['time'])
 
         run_str = f"{run_t:.2f}" if run_t is not None else '-'
         ping_str = f"{ping_t:.2f}" if ping_t is not None and ok else 'Timeout'
@@ -1893,37 +2658,234 @@ def print_summary_table(
     output(f"  Successful:             {Colors.OKGREEN}{successful}{Colors.ENDC}")
     output(f"  Failed:                 {Colors.FAIL}{failed}{Colors.ENDC}")
 
-    if running_times:
-        output(f"  Avg Time to Running:    {sum(running_times) / len(running_times):.2f}s")
-        output(f"  Max Time to Running:    {max(running_times):.2f}s")
-        output(f"  Min Time to Running:    {min(running_times):.2f}s")
+    for label, values in (("Time to Running", running_times), ("Time to Ping", ping_times),
+                          ("Clone Duration", clone_times), ("Time to Agent", agent_times)):
+        if not values:
+            continue
+        stats = describe(values)
+        output(f"  {'Avg ' + label + ':':<24}{stats['avg']:.2f}s")
+        output(f"  {'Max ' + label + ':':<24}{stats['max']:.2f}s")
+        output(f"  {'Min ' + label + ':':<24}{stats['min']:.2f}s")
+        output(f"  {'P95 ' + label + ':':<24}{stats['p95']:.2f}s "
+               f"(median {stats['median']:.2f}s, P99 {stats['p99']:.2f}s, stddev {stats['stddev']:.2f}s)")
+
+    output("=" * 95)
+    rows = [{"namespace": r[0], "running_time_sec": r[1], "ping_time_sec": r[2],
+             "clone_duration_sec": None if skip_clone else r[3]} for r in results if r[4]]
+    log_outliers(metric_outliers(rows, ("running_time_sec", "ping_time_sec", "clone_duration_sec"),
+                                 lambda r: r["namespace"]), logger)
+
+
+def analyze_cold_start(results: List[Tuple], placements: dict, start_order: List[str],
+                       skip_clone: bool = False) -> dict:
+    """
+    Separate cold-start VMs from steady-state VMs in a creation run.
+
+    A VM is cold when it is the first VM (in creation order) to land on its
+    node, or the first to use its storage class: it pays for image pulls,
+    virt-launcher/CSI warm-up and golden-image caching that later VMs reuse.
+    Mixing those VMs into averages skews them, so metrics are reported for
+    both groups.
 
-    if ping_times:
-        output(f"  Avg Time to Ping:       {sum(ping_times) / len(ping_times):.2f}s")
-        output(f"  Max Time to Ping:       {max(ping_times):.2f}s")
-        output(f"  Min Time to Ping:       {min(ping_times):.2f}s")
+    Args:
+        results: Result tuples as passed to save_results
+        placements: Dict of namespace -> get_vm_placement() result
+        start_order: Namespaces in the order their VMs were created
+        skip_clone: If True, omit clone duration metrics
 
-    if not skip_clone and clone_times:
-        output(f"  Avg Clone Duration:     {sum(clone_times) / len(clone_times):.2f}s")
-        output(f"  Max Clone Duration:     {max(clone_times):.2f}s")
-        output(f"  Min Clone Duration:     {min(clone_times):.2f}s")
+    Returns:
+        Dict with reasons (namespace -> list of 'node'/'storage_class'),
+        cold_vms, steady_vms and metrics (per metric: cold and steady
+        avg/min/max/count plus cold_penalty_sec, the difference in averages)
+    """
+    seen_nodes, seen_classes = set(), set()
+    reasons = {}
+    for ns in start_order:
+        placement = placements.get(ns) or {}
+        why = []
+        node, sc = placement.get('node'), placement.get('storage_class')
+        if node and node not in seen_nodes:
+            seen_nodes.add(node)
+            why.append('node')
+        if sc and sc not in seen_classes:
+            seen_classes.add(sc)
+            why.append('storage_class')
+        if why:
+            reasons[ns] = why
+
+    fields = [("running_time_sec", 1), ("ping_time_sec", 2)]
+    if not skip_clone:
+        fields.append(("clone_duration_sec", 3))
 
+    def _stats(values):
+        return {
+            "avg": round_duration(sum(values) / len(values)) if values else None,
+            "max": round_duration(max(values)) if values else None,
+            "min": round_duration(min(values)) if values else None,
+            "count": len(values),
+        }
+
+    metrics = []
+    ok = [r for r in results if r[4]]
+    for name, idx in fields:
+        cold = [r[idx] for r in ok if r[0] in reasons and r[idx] is not None]
+        steady = [r[idx] for r in ok if r[0] not in reasons and r[idx] is not None]
+        cold_stats, steady_stats = _stats(cold), _stats(steady)
+        penalty = None
+        if cold and steady:
+            penalty = round_duration(sum(cold) / len(cold) - sum(steady) / len(steady))
+        metrics.append({"metric": name, "cold": cold_stats, "steady": steady_stats,
+                        "cold_penalty_sec": penalty})
+
+    return {
+        "reasons": reasons,
+        "cold_vms": sorted(reasons),
+        "steady_vms": sorted(r[0] for r in results if r[0] not in reasons),
+        "nodes": len(seen_nodes),
+        "storage_classes": len(seen_classes),
+        "metrics": metrics,
+    }
+
+
+def print_cold_start_summary(analysis: dict, logger=None):
+    """Print or log the cold-start vs steady-state comparison from analyze_cold_start."""
+    def output(msg=""):
+        if logger:
+            logger.info(msg)
+        else:
+            print(msg)
+
+    output(f"\n{Colors.BOLD}Cold Start vs Steady State:{Colors.ENDC}")
+    output(f"  Cold-start VMs:         {len(analysis['cold_vms'])} "
+           f"(first per node across {analysis['nodes']} node(s), "
+           f"first per storage class across {analysis['storage_classes']} class(es))")
+    output(f"  Steady-state VMs:       {len(analysis['steady_vms'])}")
+    for m in analysis["metrics"]:
+        cold, steady = m["cold"], m["steady"]
+        if not cold["count"] and not steady["count"]:
+            continue
+        label = m["metric"].replace("_sec", "").replace("_", " ").capitalize()
+        cold_avg = f"{cold['avg']:.2f}s" if cold["avg"] is not None else "-"
+        steady_avg = f"{steady['avg']:.2f}s" if steady["avg"] is not None else "-"
+        penalty = f" ({m['cold_penalty_sec']:+.2f}s cold)" if m["cold_penalty_sec"] is not None else ""
+        output(f"  {label + ':':<24}cold avg {cold_avg}, steady avg {steady_avg}{penalty}")
     output("=" * 95)
 
 
+def enrich_summary(summary: dict, output_dir: str, logger=None, timing=None, rows=None, row_metrics=None,
+                   namespaces=None, storage_namespace=None, capacity_reached=False,
+                   warmup=None, placement=None, tuning=None) -> dict:
+    """
+    Add the blocks every result summary carries to a workload's summary.
+
+    These are the timing block and custom PromQL metrics, captured events
+    (matched against summary["outliers"]), node utilization, namespace quota
+    and limit range usage, per-tenant statistics, the cluster inventory, run
+    metadata, the namespace layout and storage backend telemetry; each is left
+    out when the run did not collect it.
+
+    Args:
+        summary: Summary with the workload's metrics and outliers; updated in place
+        output_dir: Results folder; events and node utilization samples are saved there
+        logger: Logger instance
+        timing: Optional timing block (utils.timing timing_metadata()); also the
+            window over which custom metrics are evaluated
+        rows: Optional per-VM result rows, compared per tenant on row_metrics
+        row_metrics: Row keys the tenants are compared on
+        namespaces: Optional namespace_layout() block; its namespace or prefix
+            selects the volumes of the storage backend telemetry
+        storage_namespace: Namespace (prefix) of the run's volumes without a layout
+        capacity_reached: Whether the run stopped at capacity (see constraints_summary)
+        warmup: Optional warm-up block
+        placement: Optional placement block (utils.placement PlacementStrategy.describe())
+        tuning: Optional CPU pinning/hugepages/NUMA block (utils.tuning tuning_settings())
+
+    Returns:
+        The summary
+    """
+    if timing:
+        summary["timing"] = timing
+        custom_metrics = collect_custom_metrics(timing, logger)
+        if custom_metrics is not None:
+            summary["custom_metrics"] = custom_metrics
+    run_events = collect_run_events(output_dir, logger, summary.get("outliers"))
+    if run_events is not None:
+        summary["events"] = run_events
+    node_utilization = collect_node_samples(output_dir, logger)
+    if node_utilization is not None:
+        summary["node_utilization"] = node_utilization
+    namespace_constraints = constraints_summary(logger, capacity_reached)
+    if namespace_constraints is not None:
+        summary["namespace_constraints"] = namespace_constraints
+        if logger:
+            print_constraints_summary(namespace_constraints, logger)
+    if rows is not None:
+        tenants = tenant_summary(rows, row_metrics or [])
+        if tenants is not None:
+            summary["tenants"] = tenants
+            if logger:
+                print_tenant_summary(tenants, logger)
+    summary["cluster"] = cluster_inventory(logger)
+    summary["run"] = run_metadata()
+    if namespaces is not None:
+        summary["namespaces"] = namespaces
+        storage_namespace = namespaces.get("namespace") or namespaces.get("prefix")
+    storage_backend = collect_storage_backend(output_dir, storage_namespace, logger)
+    if storage_backend is not None:
+        summary["storage_backend"] = storage_backend
+    for key, block in (("warmup", warmup), ("placement", placement), ("tuning", tuning)):
+        if block is not None:
+            summary[key] = block
+    return summary
+
+
 def save_results(args, results, base_dir="results", prefix="vm_creation_results",
-                 logger=None, skip_clone=False, total_time=None):
+                 logger=None, skip_clone=False, total_time=None, timing=None,
+                 placements=None, cold_start=None, warmup=None, placement=None, gpus=None,
+                 networks=None, capacity=None, tuning=None, instancetype=None, chaos=None,
+                 placement_quality=None, adopted=None):
     """
     Save test results into the specified results folder (or create a new one), including summary statistics.
 
     Args:
         args: Parsed CLI arguments (used for naming output folders)
-        results: List of tuples (namespace, running_time, ping_time, clone_duration, success)
+        results: List of tuples (namespace, running_time, ping_time, clone_duration, success),
+            optionally followed by a guest agent dict (time, guest_os) when agent tracking is on
         base_dir: Base directory to store results. If None, a new timestamped one is created.
         prefix: File prefix for generated files
         logger: Logger instance (optional)
         skip_clone: If True, omit clone duration metrics from saved results and summaries
         total_time: Total time taken for the test (VM creation or boot storm)
+        timing: Optional timing block (clock source, RFC3339Nano start/end, clock skew)
+            from utils.timing.timing_metadata; also the window over which custom
+            PromQL metrics (utils.custommetrics) are evaluated
+        placements: Optional dict of namespace -> get_vm_placement() result, with
+            the node's zone under 'zone'
+        cold_start: Optional analyze_cold_start() result; adds cold_start per VM
+            and cold vs steady-state statistics to the summary
+        warmup: Optional warm-up block (iterations, per-iteration means, steady state)
+            of the iterations run before the measured one
+        placement: Optional placement block (utils.placement PlacementStrategy.describe())
+        gpus: Optional GPUs per VM by device name (utils.gpu)
+        networks: Optional secondary networks of each VM (utils.network check_networks())
+        capacity: Optional capacity preflight outcome ('status', 'vms_that_fit')
+        tuning: Optional CPU pinning/hugepages/NUMA block (utils.tuning tuning_settings())
+        instancetype: Optional instancetype block (utils.instancetype instancetype_settings())
+        chaos: Optional utils.faultinjector chaos_summary() result; adds under_failure
+            per VM and the injected faults and under-failure statistics to the summary
+        placement_quality: Optional utils.placement placement_quality() result (VMs per
+            node spread, zone spread, anti-affinity, hot nodes)
+        adopted: Optional namespaces whose VM was adopted from an earlier attempt
+            (see create_or_adopt); marked adopted per VM and left out of the timing
+            statistics, as their timings only cover part of the work
+
+    With `virtbench --latency-histograms`, the latencies (and the create call
+    latencies recorded by the workload) are also saved as HDR histograms
+    (utils.histogram) and listed in the summary under latency_histograms.
+
+    With `virtbench --admission-latency`, every VM gets the admission and
+    persistence parts of its create call, and the summary the API server's
+    per-webhook admission times (utils.admission) under admission.
 
     Returns:
         Tuple of (json_path, csv_path, summary_json_path, summary_csv_path, output_dir)
@@ -1946,17 +2908,54 @@ def save_results(args, results, base_dir="results", prefix="vm_creation_results"
     summary_json_path = os.path.join(output_dir, f"summary_{prefix}.json")
     summary_csv_path = os.path.join(output_dir, f"summary_{prefix}.csv")
 
+    track_agent = any(len(r) > 5 and r[5] is not None for r in results)
+    scheduling_times = {ns: p.get('scheduling_time_sec') for ns, p in (placements or {}).items()
+                        if p.get('scheduling_time_sec') is not None}
+
+    probes = take_probe_metrics()
+    admission_vms, admission = take_admission_metrics(logger)
+
     # Convert tuples to dicts
     data = []
-    for ns, run_t, ping_t, clone_t, success in results:
+    for result in results:
+        ns, run_t, ping_t, clone_t, success = result[:5]
         entry = {
             "namespace": ns,
-            "running_time_sec": round(run_t, 2) if run_t is not None else None,
-            "ping_time_sec": round(ping_t, 2) if ping_t is not None else None,
+            "running_time_sec": round_duration(run_t) if run_t is not None else None,
+            "ping_time_sec": round_duration(ping_t) if ping_t is not None else None,
             "success": bool(success),
         }
         if not skip_clone:
-            entry["clone_duration_sec"] = round(clone_t, 2) if clone_t is not None else None
+            entry["clone_duration_sec"] = round_duration(clone_t) if clone_t is not None else None
+        if track_agent:
+            agent = (result[5] if len(result) > 5 else None) or {}
+            guest_os = agent.get('guest_os') or {}
+            entry["guest_agent_time_sec"] = round_duration(agent.get('time'))
+            entry["guest_os"] = guest_os.get('name')
+            entry["guest_kernel"] = guest_os.get('kernel')
+        if probes:
+            vm_probes = probes.get(ns) or {}
+            entry["ip"] = vm_probes.get('ip')
+            entry["ping_rtt_ms"] = vm_probes.get('rtt_ms')
+            entry["ping_probes"] = vm_probes.get('probes')
+        if placements is not None:
+            vm_placement = placements.get(ns) or {}
+            entry["node"] = vm_placement.get('node')
+            entry["zone"] = vm_placement.get('zone')
+            entry["storage_class"] = vm_placement.get('storage_class')
+            if scheduling_times:
+                entry["scheduling_time_sec"] = round_duration(scheduling_times.get(ns))
+        if cold_start is not None:
+            entry["cold_start"] = ns in cold_start["reasons"]
+            entry["cold_start_reason"] = ",".join(cold_start["reasons"].get(ns, []))
+        if chaos is not None:
+            entry["under_failure"] = ns in chaos["under_failure"]
+        if adopted is not None:
+            entry["adopted"] = ns in adopted
+        if tenant_count():
+            entry["tenant"] = namespace_tenant(ns)
+        if admission_vms:
+            entry.update(admission_vms.get(ns) or {"admission_sec": None, "persistence_sec": None})
         data.append(entry)
 
     # Save detailed JSON
@@ -1978,34 +2977,82 @@ def save_results(args, results, base_dir="results", prefix="vm_creation_results"
     successful = sum(1 for r in results if r[4])
     failed = total - successful
 
-    running_times = [r[1] for r in results if r[1] is not None]
-    ping_times = [r[2] for r in results if r[2] is not None]
-    clone_times = [r[3] for r in results if r[3] is not None] if not skip_clone else []
-
-    def calc_stats(name, values):
-        return {
-            "metric": name,
-            "avg": round(sum(values) / len(values), 2) if values else None,
-            "max": round(max(values), 2) if values else None,
-            "min": round(min(values), 2) if values else None,
-            "count": len(values),
-        }
+    timed = [r for r in results if r[0] not in (adopted or ())]
+    running_times = [r[1] for r in timed if r[1] is not None]
+    ping_times = [r[2] for r in timed if r[2] is not None]
+    clone_times = [r[3] for r in timed if r[3] is not None] if not skip_clone else []
+    agent_times = [r[5]['time'] for r in timed
+                   if len(r) > 5 and r[5] and r[5].get('time') is not None]
 
     metrics = [
-        calc_stats("running_time_sec", running_times),
-        calc_stats("ping_time_sec", ping_times),
+        metric_stats("running_time_sec", running_times),
+        metric_stats("ping_time_sec", ping_times),
     ]
     if not skip_clone:
-        metrics.append(calc_stats("clone_duration_sec", clone_times))
+        metrics.append(metric_stats("clone_duration_sec", clone_times))
+    if track_agent:
+        metrics.append(metric_stats("guest_agent_time_sec", agent_times))
+    if scheduling_times:
+        metrics.append(metric_stats("scheduling_time_sec", scheduling_times.values()))
+    rtts = [p['rtt_ms'] for p in probes.values() if p['rtt_ms'] is not None]
+    if rtts:
+        metrics.append(metric_stats("ping_rtt_ms", rtts))
+    if admission_vms:
+        metrics.append(metric_stats("admission_time_sec", [a["admission_sec"] for a in admission_vms.values()]))
+        metrics.append(metric_stats("persistence_time_sec", [a["persistence_sec"] for a in admission_vms.values()]))
 
     # --- Add total test duration ---
     summary = {
         "total_vms": total,
         "successful": successful,
         "failed": failed,
-        "total_test_duration_sec": round(total_time, 2) if total_time else None,
+        "total_test_duration_sec": round_duration(total_time) if total_time else None,
         "metrics": metrics,
+        "outliers": metric_outliers([d for d in data if d["success"] and not d.get("adopted")],
+                                    [m["metric"] for m in metrics],
+                                    lambda d: d["namespace"]),
     }
+    if adopted:
+        summary["adopted_vms"] = len(adopted)
+    enrich_summary(summary, output_dir, logger, timing=timing, rows=data,
+                   row_metrics=["running_time_sec", "ping_time_sec"], namespaces=namespace_layout(args),
+                   warmup=warmup, placement=placement, tuning=tuning)
+    storage = storage_usage(sorted({d["namespace"] for d in data}), logger)
+    if storage is not None:
+        summary["storage_usage"] = storage
+    if cold_start is not None:
+        summary["cold_start"] = {
+            "cold_vms": cold_start["cold_vms"],
+            "nodes": cold_start["nodes"],
+            "storage_classes": cold_start["storage_classes"],
+            "metrics": cold_start["metrics"],
+        }
+    if placement_quality is not None:
+        summary["placement_quality"] = placement_quality
+    if gpus:
+        summary["gpus_per_vm"] = gpus
+    if networks:
+        summary["networks"] = [{key: network[key] for key in ('namespace', 'name', 'type', 'binding', 'resource')}
+                               for network in networks]
+    if capacity is not None:
+        summary["capacity"] = capacity
+    if instancetype is not None:
+        summary["instancetype"] = instancetype
+    if chaos is not None:
+        summary["chaos_mix"] = {key: value for key, value in chaos.items() if key != "under_failure"}
+    if admission_vms or admission is not None:
+        summary["admission"] = admission_report(admission_vms, admission)
+        if logger and admission is not None:
+            print_admission_summary(admission, logger)
+    latency_histograms = save_histograms(output_dir, prefix, {
+        "running_time": running_times,
+        "ping_time": ping_times,
+        "clone_duration": clone_times,
+        "guest_agent_time": agent_times,
+        "scheduling_time": scheduling_times.values(),
+    }, timing, logger)
+    if latency_histograms is not None:
+        summary["latency_histograms"] = latency_histograms
 
     # --- Save summary JSON ---
     with open(summary_json_path, "w") as sf:
@@ -2015,17 +3062,124 @@ def save_results(args, results, base_dir="results", prefix="vm_creation_results"
 
     # --- Save summary CSV ---
     with open(summary_csv_path, "w", newline="") as cf:
-        writer = csv.DictWriter(cf, fieldnames=["metric", "avg", "max", "min", "count"])
+        writer = csv.DictWriter(cf, fieldnames=stat_columns("metric", "avg", "max", "min", "count"))
         writer.writeheader()
         for m in summary["metrics"]:
             writer.writerow(m)
+        if cold_start is not None:
+            for m in cold_start["metrics"]:
+                for group in ("cold", "steady"):
+                    writer.writerow({"metric": f"{m['metric']}_{group}", **m[group]})
+        if chaos is not None:
+            for m in chaos["metrics"]:
+                for group in ("under_failure", "no_failure"):
+                    writer.writerow({"metric": f"{m['metric']}_{group}", **m[group]})
+            if chaos.get("kvdb"):
+                writer.writerow({"metric": "kvdb_failover_sec", **chaos["kvdb"]["failover_sec"]})
     if logger:
         logger.info(f"Saved summary CSV to {summary_csv_path}")
 
     return json_path, csv_path, summary_json_path, summary_csv_path, output_dir
 
 
-def save_migration_results(args, results, base_dir="results", logger=None, total_time=None):
+def migration_zone_summary(results, zones: dict) -> dict:
+    """
+    Same-zone and cross-zone migrations summarized separately.
+
+    Args:
+        results: List of tuples (namespace, success, observed_duration, source, target, vmim_duration)
+        zones: {node: zone} from get_node_zones()
+
+    Returns:
+        {"same_zone": {...}, "cross_zone": {...}, "unknown": {...}}, each with the
+        number of migrations and the observed and VMIM time statistics
+    """
+    groups = {"same_zone": [], "cross_zone": [], "unknown": []}
+    for r in results:
+        crossing = is_cross_zone(zones.get(r[3]), zones.get(r[4]))
+        groups["unknown" if crossing is None else "cross_zone" if crossing else "same_zone"].append(r)
+    return {
+        key: {
+            "migrations": len(group),
+            "successful": sum(1 for r in group if r[1]),
+            "metrics": [
+                metric_stats("observed_time_sec", [r[2] for r in group if r[1] and r[2]]),
+                metric_stats("vmim_time_sec", [r[5] for r in group if r[1] and r[5]]),
+            ],
+        }
+        for key, group in groups.items()
+    }
+
+
+def migration_summary(results, total_time=None, disk_storage_classes=None, throughput=None, job_stats=None,
+                      zones=None) -> dict:
+    """
+    Summary statistics of a migration run (counts and per-metric avg/min/max).
+
+    Args:
+        results: List of tuples (namespace, success, observed_duration, source, target, vmim_duration)
+        total_time: Total wall-clock migration duration (sec)
+        disk_storage_classes: Optional {disk volume name: storage class} of the migrated VMs
+        throughput: Optional {namespace: migration_throughput() result}
+        job_stats: Optional {namespace: get_migration_job_stats() result}
+        zones: Optional {node: zone}; adds same-zone vs cross-zone statistics
+    """
+    total = len(results)
+    successful = sum(1 for r in results if r[1])
+    failed = total - successful
+
+    observed_times = [r[2] for r in results if r[1] and r[2]]
+    vmim_times = [r[5] for r in results if r[1] and r[5]]
+
+    summary = {
+        "total_vms": total,
+        "successful": successful,
+        "failed": failed,
+        "total_migration_duration_sec": round_duration(total_time) if total_time else None,
+        "disk_storage_classes": disk_storage_classes,
+        "metrics": [
+            metric_stats("observed_time_sec", observed_times),
+            metric_stats("vmim_time_sec", vmim_times),
+            {
+                "metric": "difference_observed_vmim_sec",
+                "avg": round_duration((sum(observed_times) / len(observed_times)) - (sum(vmim_times) / len(vmim_times)))
+                if observed_times and vmim_times else None,
+                "note": "Difference includes polling overhead (~2s) and status update delays",
+            },
+        ],
+        "outliers": metric_outliers([{"namespace": r[0], "observed_time_sec": r[2], "vmim_time_sec": r[5]}
+                                     for r in results if r[1]],
+                                    ("observed_time_sec", "vmim_time_sec"), lambda r: r["namespace"]),
+    }
+    if throughput is not None:
+        for key in ("transfer_mib_s", "estimated_mib_s", "transfer_ratio"):
+            values = [t[key] for t in throughput.values() if t[key] is not None]
+            summary["metrics"].append({
+                "metric": key,
+                "avg": round(sum(values) / len(values), 2) if values else None,
+                "min": min(values) if values else None,
+                "max": max(values) if values else None,
+                "count": len(values),
+            })
+    if job_stats is not None:
+        for key in MIGRATION_JOB_FIELDS:
+            values = [s[key] for s in job_stats.values() if s.get(key) is not None]
+            summary["metrics"].append({
+                "metric": key,
+                "avg": round(sum(values) / len(values), 2) if values else None,
+                "min": min(values) if values else None,
+                "max": max(values) if values else None,
+                "count": len(values),
+            })
+    if zones is not None:
+        summary["zones"] = migration_zone_summary(results, zones)
+    return summary
+
+
+def save_migration_results(args, results, base_dir="results", logger=None, total_time=None,
+                           disk_storage_classes=None, timing=None, data_integrity=None,
+                           throughput=None, job_stats=None, guest_load=None, placement=None,
+                           zones=None, tuning=None):
     """
     Save VM migration results (per-VM data and summary) into JSON and CSV files.
 
@@ -2035,10 +3189,19 @@ def save_migration_results(args, results, base_dir="results", logger=None, total
         base_dir: Parent folder
         logger: Logger instance
         total_time: Total wall-clock migration duration (sec)
+        disk_storage_classes: Optional {disk volume name: storage class} of the migrated VMs
+        timing: Optional timing block from utils.timing.timing_metadata; also the
+            window over which custom PromQL metrics (utils.custommetrics) are evaluated
+        data_integrity: Optional {namespace: DataVerifier.verify() result} from --verify-data
+        throughput: Optional {namespace: migration_throughput() result}
+        job_stats: Optional {namespace: get_migration_job_stats() result}
+        guest_load: Optional utils.guestload.GuestLoad.stop_many() summary
+        placement: Optional placement block of the target nodes (utils.placement PlacementStrategy.describe())
+        zones: Optional {node: zone}; adds source and target zones to every row and
+            same-zone vs cross-zone statistics to the summary
+        tuning: Optional CPU pinning/hugepages/NUMA block (utils.tuning tuning_settings())
     """
 
-
-
     # --- Prepare base output directory ---
     if base_dir is None:
         timestamp = datetime.now().strftime("%Y%m%d-%H%M%S")
@@ -2063,10 +3226,26 @@ def save_migration_results(args, results, base_dir="results", logger=None, total
             "namespace": ns,
             "source_node": source or "Unknown",
             "target_node": target or "Unknown",
-            "observed_time_sec": round(observed, 2) if observed else None,
-            "vmim_time_sec": round(vmim, 2) if vmim else None,
+            "observed_time_sec": round_duration(observed) if observed else None,
+            "vmim_time_sec": round_duration(vmim) if vmim else None,
             "status": "Success" if success else "Failed",
         }
+        if zones is not None:
+            entry["source_zone"] = zones.get(source)
+            entry["target_zone"] = zones.get(target)
+            entry["cross_zone"] = is_cross_zone(entry["source_zone"], entry["target_zone"])
+        if throughput is not None:
+            entry.update(throughput.get(ns) or migration_throughput(None, None, None, None))
+        if job_stats is not None:
+            stats = job_stats.get(ns) or {}
+            entry["migration_mode"] = stats.get("mode")
+            entry["migration_policy"] = stats.get("migration_policy")
+            entry.update({key: stats.get(key) for key in MIGRATION_JOB_FIELDS})
+        if data_integrity is not None:
+            check = data_integrity.get(ns)
+            entry["data_integrity"] = check["status"] if check else None
+        if tenant_count():
+            entry["tenant"] = namespace_tenant(ns)
         data.append(entry)
 
     with open(json_path, "w") as jf:
@@ -2080,56 +3259,26 @@ def save_migration_results(args, results, base_dir="results", logger=None, total
         logger.info(f"Saved detailed migration results to {json_path}")
 
     # --- Summary statistics ---
-    total = len(results)
-    successful = sum(1 for r in results if r[1])
-    failed = total - successful
-
-    observed_times = [r[2] for r in results if r[1] and r[2]]
-    vmim_times = [r[5] for r in results if r[1] and r[5]]
-
-    summary = {
-        "total_vms": total,
-        "successful": successful,
-        "failed": failed,
-        "total_migration_duration_sec": round(total_time, 2) if total_time else None,
-        "metrics": [
-            {
-                "metric": "observed_time_sec",
-                "avg": round(sum(observed_times) / len(observed_times), 2) if observed_times else None,
-                "min": round(min(observed_times), 2) if observed_times else None,
-                "max": round(max(observed_times), 2) if observed_times else None,
-                "count": len(observed_times),
-            },
-            {
-                "metric": "vmim_time_sec",
-                "avg": round(sum(vmim_times) / len(vmim_times), 2) if vmim_times else None,
-                "min": round(min(vmim_times), 2) if vmim_times else None,
-                "max": round(max(vmim_times), 2) if vmim_times else None,
-                "count": len(vmim_times),
-            },
-            {
-                "metric": "difference_observed_vmim_sec",
-                "avg": round((sum(observed_times) / len(observed_times)) - (sum(vmim_times) / len(vmim_times)), 2)
-                if observed_times and vmim_times else None,
-                "note": "Difference includes polling overhead (~2s) and status update delays",
-            },
-        ],
-    }
+    summary = migration_summary(results, total_time, disk_storage_classes, throughput, job_stats, zones)
+    enrich_summary(summary, output_dir, logger, timing=timing, rows=data,
+                   row_metrics=["observed_time_sec", "vmim_time_sec"], namespaces=namespace_layout(args),
+                   placement=placement, tuning=tuning)
+    if data_integrity is not None:
+        # Imported here because utils.dataintegrity runs guest commands through utils.guestexec,
+        # which depends on this module
+        from utils.dataintegrity import summarize_data_integrity
+        summary["data_integrity"] = summarize_data_integrity(data_integrity)
+    if guest_load is not None:
+        summary["guest_load"] = guest_load
 
     with open(summary_json_path, "w") as sf:
         json.dump(summary, sf, indent=4)
     with open(summary_csv_path, "w", newline="") as cf:
-        writer = csv.DictWriter(cf, fieldnames=["metric", "avg", "min", "max", "count"])
+        writer = csv.DictWriter(cf, fieldnames=stat_columns("metric", "avg", "min", "max", "count"))
         writer.writeheader()
         for m in summary["metrics"]:
             if "avg" in m:
-                writer.writerow({
-                    "metric": m["metric"],
-                    "avg": m.get("avg"),
-                    "min": m.get("min"),
-                    "max": m.get("max"),
-                    "count": m.get("count"),
-                })
+                writer.writerow({"metric": m["metric"], **{field: m.get(field) for field in STAT_FIELDS}})
 
     if logger:
         logger.info(f"Saved summary migration results to {summary_json_path}")
@@ -2159,6 +3308,12 @@ def save_capacity_results(results: dict, base_dir: str = "results", storage_driv
             - capacity_reached: Whether capacity limit was reached
             - end_reason: Reason for test ending
             - phases_skipped: List of skipped phases
+            - iterations: Optional per-iteration phase results (utils.capacity CapacityIteration.to_dict())
+            - phases: Optional per-phase totals (utils.capacity phase_summary())
+            - per_storage_class: Optional {storage_class: {operation: {avg, min, max, count}}}
+            - warmup: Optional warm-up block (iterations, Create VMs phase times, steady state)
+            - placement: Optional placement block (utils.placement PlacementStrategy.describe())
+            - namespace: Optional namespace of the VMs, whose volumes storage backend telemetry reports
         base_dir: Base directory for results (default: "results")
         storage_driver: Storage driver for folder hierarchy (e.g., "portworx-3.6"). If None, uses "default"
         logger: Logger instance (optional)
@@ -2199,6 +3354,7 @@ def save_capacity_results(results: dict, base_dir: str = "results", storage_driv
         "timestamp": timestamp,
         "config": {
             "storage_classes": results.get('storage_classes', 'N/A'),
+            "data_storage_class": results.get('data_storage_class'),
             "vms_per_iteration": results.get('vms_per_iteration', 0),
             "data_volumes_per_vm": results.get('data_volumes_per_vm', 0),
             "volume_size": results.get('volume_size', 'N/A'),
@@ -2215,6 +3371,16 @@ def save_capacity_results(results: dict, base_dir: str = "results", storage_driv
         "phases_skipped": results.get('phases_skipped', []),
         "duration": results.get('duration_str', 'N/A'),
     }
+    if results.get('per_storage_class'):
+        detailed_results["per_storage_class"] = results['per_storage_class']
+    if results.get('warmup'):
+        detailed_results["warmup"] = results['warmup']
+    if results.get('placement'):
+        detailed_results["placement"] = results['placement']
+    if results.get('iterations'):
+        detailed_results["iterations"] = results['iterations']
+    if results.get('phases'):
+        detailed_results["phases"] = results['phases']
 
     # Save detailed JSON
     with open(json_path, "w") as f:
@@ -2254,6 +3420,20 @@ def save_capacity_results(results: dict, base_dir: str = "results", storage_driv
             },
         ],
     }
+    for storage_class, operations in (results.get('per_storage_class') or {}).items():
+        for operation, stats in operations.items():
+            summary["metrics"].append({
+                "metric": f"{operation}_time_sec",
+                "storage_class": storage_class,
+                **stats,
+            })
+    if results.get('iterations'):
+        summary["metrics"].extend(phase_metrics(results['iterations']))
+    if results.get('phases'):
+        summary["phases"] = results['phases']
+    enrich_summary(summary, output_dir, logger, storage_namespace=results.get('namespace'),
+                   capacity_reached=results.get('capacity_reached', False),
+                   warmup=results.get('warmup') or None, placement=results.get('placement') or None)
 
     # Save summary JSON
     with open(summary_json_path, "w") as f:
@@ -2316,6 +3496,19 @@ def save_capacity_results(results: dict, base_dir: str = "results", storage_driv
             "value": ", ".join(results.get('phases_skipped', [])) or "None",
         },
     ]
+    for storage_class, operations in (results.get('per_storage_class') or {}).items():
+        for operation, stats in operations.items():
+            csv_data.append({
+                "metric": f"{operation.capitalize()} Time avg/min/max ({storage_class})",
+                "value": f"{stats['avg']}s / {stats['min']}s / {stats['max']}s ({stats['count']})",
+            })
+    for phase, entry in (results.get('phases') or {}).items():
+        timing = entry['duration_sec']
+        csv_data.append({
+            "metric": f"{phase.capitalize()} Phase completed/skipped/failed, avg/max",
+            "value": f"{entry['completed']}/{entry['skipped']}/{entry['failed']}, "
+                     f"{timing['avg']}s / {timing['max']}s",
+        })
 
     with open(csv_path, "w", newline="") as f:
         writer = csv.DictWriter(f, fieldnames=["metric", "value"])
@@ -2327,14 +3520,18 @@ def save_capacity_results(results: dict, base_dir: str = "results", storage_driv
     return output_dir
 
 
-def validate_prerequisites(ssh_pod: str, ssh_pod_ns: str, logger: logging.Logger) -> bool:
+def validate_prerequisites(ssh_pod: Optional[str], ssh_pod_ns: str, logger: logging.Logger,
+                           namespace: Optional[str] = None) -> bool:
     """
     Validate that prerequisites are met before running tests.
 
     Args:
-        ssh_pod: SSH pod name
+        ssh_pod: SSH pod name, or None when no SSH pod is needed (e.g. reachability
+            is checked by the utils.reachability checker pod)
         ssh_pod_ns: SSH pod namespace
         logger: Logger instance
+        namespace: Namespace-scoped mode; check access to this namespace
+            instead of cluster-wide connectivity
 
     Returns:
         True if all prerequisites are met, False otherwise
@@ -2345,14 +3542,21 @@ def validate_prerequisites(ssh_pod: str, ssh_pod_ns: str, logger: logging.Logger
     if not check_python_version(logger):
         return False
 
-    # Check kubectl connectivity
+    # Check kubectl connectivity (cluster-info needs cluster-wide read access)
     try:
-        run_kubectl_command(['cluster-info'], logger=logger)
-        logger.info("[OK] kubectl connectivity verified")
+        if namespace:
+            run_kubectl_command(['get', 'vm', '-n', namespace], logger=logger)
+            logger.info(f"[OK] Access to namespace '{namespace}' verified")
+        else:
+            run_kubectl_command(['cluster-info'], logger=logger)
+            logger.info("[OK] kubectl connectivity verified")
     except Exception as e:
         logger.error(f"[FAIL] kubectl connectivity failed: {e}")
         return False
 
+    if ssh_pod is None:
+        return True
+
     # Check SSH pod exists and is Running
     try:
         returncode, stdout, _ = run_kubectl_command(
@@ -2421,6 +3625,24 @@ def restart_vm(vm_name: str, namespace: str, logger: Optional[logging.Logger] =
         return False
 
 
+def parse_size_to_gi(size_str: str) -> int:
+    """Parse size string to GiB integer."""
+    size_str = size_str.strip().upper()
+    if size_str.endswith('GI'):
+        return int(size_str[:-2])
+    elif size_str.endswith('G'):
+        return int(size_str[:-1])
+    else:
+        raise ValueError(f"Unsupported size format: {size_str}")
+
+
+def increment_size(current_size: str, increment: str) -> str:
+    """Increment size by specified amount."""
+    current_gi = parse_size_to_gi(current_size)
+    increment_gi = parse_size_to_gi(increment)
+    return f"{current_gi + increment_gi}Gi"
+
+
 def resize_pvc(pvc_name: str, namespace: str, new_size: str,
                logger: Optional[logging.Logger] = None) -> bool:
     """
@@ -2531,26 +3753,43 @@ def wait_for_pvc_resize(pvc_name: str, namespace: str, expected_size: str,
     return False
 
 
-def create_vm_snapshot(vm_name: str, snapshot_name: str, namespace: str,
-                       logger: Optional[logging.Logger] = None) -> bool:
+def expand_pvc(pvc_name: str, namespace: str, increment: str, timeout: int = 600,
+               poll_interval: int = 5,
+               logger: Optional[logging.Logger] = None) -> Tuple[Optional[str], Optional[float], Optional[str]]:
     """
-    Create a VirtualMachineSnapshot.
+    Grow a PVC by an increment and wait for the CSI expansion to complete.
 
     Args:
-        vm_name: VM name to snapshot
-        snapshot_name: Snapshot name
+        pvc_name: PVC name
         namespace: Namespace name
+        increment: Size to add (e.g., "1Gi")
+        timeout: Timeout in seconds
+        poll_interval: Polling interval in seconds
         logger: Logger instance
 
     Returns:
-        True if snapshot created successfully, False otherwise
+        Tuple of (new_size, expansion_seconds, error); error is None on success
     """
+    current_size = get_pvc_size(pvc_name, namespace, logger)
+    if not current_size:
+        return None, None, f"Failed to get size for PVC {pvc_name}"
     try:
-        if logger:
-            logger.info(f"[{namespace}] Creating snapshot {snapshot_name} for VM {vm_name}")
-
-        # Create snapshot YAML
-        snapshot_yaml = f"""apiVersion: snapshot.kubevirt.io/v1alpha1
+        new_size = increment_size(current_size, increment)
+    except ValueError as e:
+        return None, None, f"PVC {pvc_name}: {e}"
+
+    resize_start = time.time()
+    if not resize_pvc(pvc_name, namespace, new_size, logger):
+        return new_size, None, f"Failed to resize PVC {pvc_name}"
+    if not wait_for_pvc_resize(pvc_name, namespace, new_size, timeout=timeout,
+                               poll_interval=poll_interval, logger=logger):
+        return new_size, None, f"PVC {pvc_name} resize did not complete"
+    return new_size, time.time() - resize_start, None
+
+
+def vm_snapshot_manifest(vm_name: str, snapshot_name: str, namespace: str) -> str:
+    """VirtualMachineSnapshot manifest create_vm_snapshot applies."""
+    return f"""apiVersion: snapshot.kubevirt.io/v1alpha1
 kind: VirtualMachineSnapshot
 metadata:
   name: {snapshot_name}
@@ -2562,19 +3801,34 @@ spec:
     name: {vm_name}
 """
 
+
+def create_vm_snapshot(vm_name: str, snapshot_name: str, namespace: str,
+                       logger: Optional[logging.Logger] = None) -> bool:
+    """
+    Create a VirtualMachineSnapshot.
+
+    Args:
+        vm_name: VM name to snapshot
+        snapshot_name: Snapshot name
+        namespace: Namespace name
+        logger: Logger instance
+
+    Returns:
+        True if snapshot created successfully, False otherwise
+    """
+    try:
+        if logger:
+            logger.info(f"[{namespace}] Creating snapshot {snapshot_name} for VM {vm_name}")
+
+        # Create snapshot YAML
+        snapshot_yaml = vm_snapshot_manifest(vm_name, snapshot_name, namespace)
+
         # Apply snapshot
-        process = subprocess.Popen(
-            ['kubectl', 'apply', '-f', '-'],
-            stdin=subprocess.PIPE,
-            stdout=subprocess.PIPE,
-            stderr=subprocess.PIPE,
-            text=True
-        )
-        stdout, stderr = process.communicate(input=snapshot_yaml)
+        applied, error = apply_manifest(stamp_manifest(snapshot_yaml), logger=logger)
 
-        if process.returncode != 0:
+        if not applied:
             if logger:
-                logger.error(f"[{namespace}] Failed to create snapshot: {stderr}")
+                logger.error(f"[{namespace}] Failed to create snapshot: {error}")
             return False
 
         if logger:
@@ -2751,3 +4005,251 @@ def get_vm_volume_names(vm_name: str, namespace: str,
         if logger:
             logger.error(f"[{namespace}] Failed to get VM volumes: {e}")
         return []
+
+
+def get_pvc_storage_class(pvc_name: str, namespace: str,
+                          logger: Optional[logging.Logger] = None) -> Optional[str]:
+    """
+    Get the storage class of a PVC.
+
+    Args:
+        pvc_name: PVC name
+        namespace: Namespace name
+        logger: Logger instance
+
+    Returns:
+        Storage class name or None on error
+    """
+    returncode, stdout, _ = run_kubectl_command(
+        ['get', 'pvc', pvc_name, '-n', namespace, '-o', 'jsonpath={.spec.storageClassName}'],
+        check=False,
+        logger=logger
+    )
+
+    if returncode == 0 and stdout.strip():
+        return stdout.strip()
+    return None
+
+
+def get_pvc_volume_name(pvc_name: str, namespace: str,
+                        logger: Optional[logging.Logger] = None) -> Optional[str]:
+    """
+    Get the name of the PersistentVolume bound to a PVC.
+
+    Args:
+        pvc_name: PVC name
+        namespace: Namespace name
+        logger: Logger instance
+
+    Returns:
+        PV name or None if the PVC is not bound
+    """
+    returncode, stdout, _ = run_kubectl_command(
+        ['get', 'pvc', pvc_name, '-n', namespace, '-o', 'jsonpath={.spec.volumeName}'],
+        check=False,
+        logger=logger
+    )
+
+    if returncode == 0 and stdout.strip():
+        return stdout.strip()
+    return None
+
+
+def get_vm_placement(vm_name: str, namespace: str,
+                     logger: Optional[logging.Logger] = None) -> dict:
+    """
+    Get the node a VMI runs on, the storage class of its first disk and how long it took to schedule.
+
+    Args:
+        vm_name: VM/VMI name
+        namespace: Namespace name
+        logger: Logger instance
+
+    Returns:
+        Dict with node, storage_class and scheduling_time_sec (any may be None),
+        and the VMI's namespace, labels and affinity for placement checks.
+        scheduling_time_sec runs from the VMI's Pending to its Scheduled phase
+        transition; the timestamps have a resolution of one second.
+    """
+    placement = {'node': None, 'storage_class': None, 'scheduling_time_sec': None,
+                 'namespace': namespace, 'labels': {}, 'affinity': None}
+    returncode, stdout, _ = run_kubectl_command(
+        ['get', 'vmi', vm_name, '-n', namespace, '-o', 'json'],
+        check=False,
+        logger=logger
+    )
+    if returncode != 0:
+        return placement
+
+    try:
+        vmi = json.loads(stdout)
+    except json.JSONDecodeError:
+        return placement
+
+    placement['node'] = vmi.get('status', {}).get('nodeName')
+    placement['labels'] = vmi.get('metadata', {}).get('labels') or {}
+    placement['affinity'] = vmi.get('spec', {}).get('affinity')
+    transitions = {t.get('phase'): t.get('phaseTransitionTimestamp')
+                   for t in vmi.get('status', {}).get('phaseTransitionTimestamps') or []}
+    pending = transitions.get('Pending') or vmi.get('metadata', {}).get('creationTimestamp')
+    if pending and transitions.get('Scheduled'):
+        placement['scheduling_time_sec'] = calculate_vmim_duration(pending, transitions['Scheduled'])
+    for volume in vmi.get('spec', {}).get('volumes', []):
+        claim = (volume.get('dataVolume', {}).get('name')
+                 or volume.get('persistentVolumeClaim', {}).get('claimName'))
+        if claim:
+            placement['storage_class'] = get_pvc_storage_class(claim, namespace, logger)
+            break
+    return placement
+
+
+def get_volume_attachments(pv_names: Optional[List[str]] = None,
+                           logger: Optional[logging.Logger] = None) -> Optional[List[dict]]:
+    """
+    List CSI VolumeAttachment objects, optionally filtered to a set of PVs.
+
+    Args:
+        pv_names: Only return attachments for these PersistentVolumes
+        logger: Logger instance
+
+    Returns:
+        List of dicts with keys name, pv, node, attached and deleting,
+        or None if the attachments could not be listed
+    """
+    try:
+        returncode, stdout, stderr = run_kubectl_command(
+            ['get', 'volumeattachments.storage.k8s.io', '-o', 'json'],
+            check=False,
+            logger=logger
+        )
+
+        if returncode != 0:
+            if logger:
+                logger.debug(f"Failed to list VolumeAttachments: {stderr}")
+            return None
+
+        wanted = set(pv_names) if pv_names is not None else None
+        attachments = []
+        for item in json.loads(stdout).get('items', []):
+            pv = item.get('spec', {}).get('source', {}).get('persistentVolumeName')
+            if wanted is not None and pv not in wanted:
+                continue
+            attachments.append({
+                'name': item.get('metadata', {}).get('name', ''),
+                'pv': pv,
+                'node': item.get('spec', {}).get('nodeName', ''),
+                'attached': bool(item.get('status', {}).get('attached', False)),
+                'deleting': bool(item.get('metadata', {}).get('deletionTimestamp')),
+            })
+
+        return attachments
+
+    except Exception as e:
+        if logger:
+            logger.error(f"Failed to get VolumeAttachments: {e}")
+        return None
+
+
+DEFAULT_NODE_EXEC_IMAGE = 'busybox:1.36'
+
+
+def node_exec_pod_manifest(node_name: str, command: str, pod_name: str,
+                           namespace: str = 'default', image: str = DEFAULT_NODE_EXEC_IMAGE) -> dict:
+    """Privileged pod create_node_exec_pod creates to run a command on a node."""
+    return {
+        'apiVersion': 'v1',
+        'kind': 'Pod',
+        'metadata': {
+            'name': pod_name,
+            'namespace': namespace,
+            'labels': {'app': 'virtbench-node-exec'},
+        },
+        'spec': {
+            'nodeName': node_name,
+            'hostPID': True,
+            'hostNetwork': True,
+            'restartPolicy': 'Never',
+            'tolerations': [{'operator': 'Exists'}],
+            'containers': [{
+                'name': 'exec',
+                'image': image,
+                'command': ['nsenter', '-t', '1', '-m', '-u', '-i', '-n', '-p', '--',
+                            'sh', '-c', command],
+                'securityContext': {'privileged': True},
+            }],
+        },
+    }
+
+
+def create_node_exec_pod(node_name: str, command: str, pod_name: str,
+                         namespace: str = 'default', image: str = DEFAULT_NODE_EXEC_IMAGE,
+                         logger: Optional[logging.Logger] = None) -> bool:
+    """
+    Run a shell command in the host namespaces of a node via a privileged pod.
+
+    The pod is pinned to the node, tolerates every taint and enters the host
+    mount/UTS/IPC/network/PID namespaces with nsenter, so the command behaves
+    as if it were run over SSH on the node. The namespace is first made to
+    admit privileged pods (privileged SCC on OpenShift, Pod Security labels on
+    Kubernetes; see utils.cluster_platform). The pod is not waited on; callers
+    that need the exit status should poll the pod phase.
+
+    Args:
+        node_name: Node to run the command on
+        command: Shell command executed with `sh -c` on the host
+        pod_name: Name of the pod to create
+        namespace: Namespace to create the pod in
+        image: Container image providing nsenter
+        logger: Logger instance
+
+    Returns:
+        True if the pod was created, False otherwise
+    """
+
+    pod = node_exec_pod_manifest(node_name, command, pod_name, namespace, image)
+    allow_privileged_pods(namespace, logger=logger)
+
+    try:
+        if logger:
+            logger.debug(f"[{node_name}] Creating node exec pod {namespace}/{pod_name}: {command}")
+
+        returncode, _, stderr = _kubectl_with_input(['create', '-f', '-'], json.dumps(stamp_run_labels(pod)), logger)
+
+        if returncode != 0:
+            if logger:
+                logger.error(f"[{node_name}] Failed to create node exec pod {pod_name}: {stderr}")
+            return False
+
+        return True
+
+    except Exception as e:
+        if logger:
+            logger.error(f"[{node_name}] Failed to create node exec pod {pod_name}: {e}")
+        return False
+
+
+def delete_node_exec_pod(pod_name: str, namespace: str = 'default',
+                         logger: Optional[logging.Logger] = None) -> bool:
+    """
+    Delete a pod created by create_node_exec_pod without waiting for termination.
+
+    Args:
+        pod_name: Pod name
+        namespace: Pod namespace
+        logger: Logger instance
+
+    Returns:
+        True if the delete request succeeded, False otherwise
+    """
+    returncode, _, stderr = run_kubectl_command(
+        ['delete', 'pod', pod_name, '-n', namespace, '--ignore-not-found',
+         '--wait=false', '--grace-period=0', '--force'],
+        check=False,
+        logger=logger
+    )
+
+    if returncode != 0:
+        if logger:
+            logger.warning(f"Failed to delete node exec pod {namespace}/{pod_name}: {stderr}")
+        return False
+    return True
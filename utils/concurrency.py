@@ -0,0 +1,198 @@
+#!/usr/bin/env python3
+"""
+Shared concurrency engine for KubeVirt performance testing.
+
+This module provides a bounded worker pool with an optional token-bucket
+rate limiter so that VM creation, migration and deletion behave the same
+way across all workloads. Concurrency caps the number of in-flight
+operations, while --qps/--burst cap how quickly new operations are started
+against the API server. run_parallel() runs a fixed list of operations;
+workloads that schedule operations as they go submit them to a WorkerPool
+instead.
+
+Operations that only wait (for a VM to boot or recover, a volume to be
+fenced) are not rate limited, so the start of a measurement is not delayed.
+
+Independently of the operation rate, the global virtbench --kube-api-qps and
+--kube-api-burst options cap the rate of individual API requests (kubectl
+calls) a workload process makes, like the QPS/burst of a client-go client.
+"""
+
+import logging
+import os
+import threading
+import time
+from concurrent.futures import Future, ThreadPoolExecutor, as_completed
+from typing import Any, Callable, Iterable, List, Optional, Tuple
+
+from utils.progress import track_phase
+
+DEFAULT_QPS = 0.0   # 0 disables rate limiting
+DEFAULT_BURST = 10
+
+KUBE_API_QPS_ENV = 'VIRTBENCH_KUBE_API_QPS'
+KUBE_API_BURST_ENV = 'VIRTBENCH_KUBE_API_BURST'
+
+_api_limiter = None
+_api_limiter_lock = threading.Lock()
+
+
+class RateLimiter:
+    """
+    Thread-safe token-bucket rate limiter.
+
+    Tokens are refilled continuously at `qps` per second up to a maximum of
+    `burst`. Each call to wait() consumes one token, blocking until one is
+    available. A qps of 0 (or less) disables limiting entirely.
+    """
+
+    def __init__(self, qps: float = DEFAULT_QPS, burst: int = DEFAULT_BURST):
+        self.qps = float(qps or 0)
+        self.burst = max(1, int(burst or 1))
+        self._tokens = float(self.burst)
+        self._last = time.monotonic()
+        self._lock = threading.Lock()
+
+    @property
+    def enabled(self) -> bool:
+        return self.qps > 0
+
+    def wait(self):
+        """Block until a token is available, then consume it."""
+        if not self.enabled:
+            return
+
+        while True:
+            with self._lock:
+                now = time.monotonic()
+                self._tokens = min(self.burst, self._tokens + (now - self._last) * self.qps)
+                self._last = now
+
+                if self._tokens >= 1:
+                    self._tokens -= 1
+                    return
+
+                delay = (1 - self._tokens) / self.qps
+
+            time.sleep(delay)
+
+
+def api_rate_limiter() -> RateLimiter:
+    """
+    Process-wide limiter for API requests, set by virtbench --kube-api-qps/--kube-api-burst.
+
+    Shared by every thread of the workload; disabled (no limit) unless a QPS
+    is configured.
+    """
+    global _api_limiter
+    with _api_limiter_lock:
+        if _api_limiter is None:
+            try:
+                qps = float(os.environ.get(KUBE_API_QPS_ENV) or DEFAULT_QPS)
+                burst = int(os.environ.get(KUBE_API_BURST_ENV) or DEFAULT_BURST)
+            except ValueError:
+                qps, burst = DEFAULT_QPS, DEFAULT_BURST
+            _api_limiter = RateLimiter(qps, burst)
+        return _api_limiter
+
+
+class WorkerPool:
+    """
+    Bounded thread pool whose submitted calls take a rate-limiter token before they run.
+
+    Used as a context manager like ThreadPoolExecutor; submit() returns a Future.
+    """
+
+    def __init__(self, concurrency: int = 10, qps: float = DEFAULT_QPS, burst: int = DEFAULT_BURST):
+        self.limiter = RateLimiter(qps, burst)
+        self.concurrency = max(1, int(concurrency))
+        self._executor = ThreadPoolExecutor(max_workers=self.concurrency)
+
+    def __enter__(self) -> 'WorkerPool':
+        return self
+
+    def __exit__(self, *exc):
+        self.shutdown()
+
+    def submit(self, func: Callable[..., Any], *args, **kwargs) -> Future:
+        def _invoke():
+            self.limiter.wait()
+            return func(*args, **kwargs)
+        return self._executor.submit(_invoke)
+
+    def shutdown(self, wait: bool = True):
+        self._executor.shutdown(wait=wait)
+
+
+def run_parallel(func: Callable[..., Any], items: Iterable[Any],
+                 concurrency: int = 10, qps: float = DEFAULT_QPS,
+                 burst: int = DEFAULT_BURST, args: Tuple = (),
+                 logger: Optional[logging.Logger] = None,
+                 description: str = "operation",
+                 on_result: Optional[Callable[[Any, Any], None]] = None
+                 ) -> List[Tuple[Any, Any, Optional[Exception]]]:
+    """
+    Run func(item, *args) for every item with bounded concurrency and rate limiting.
+
+    Each worker acquires a rate-limiter token immediately before invoking func,
+    so at most `concurrency` calls are in flight and new calls start no faster
+    than `qps` per second (with up to `burst` started back-to-back).
+
+    Args:
+        func: Callable invoked as func(item, *args)
+        items: Items to process
+        concurrency: Maximum number of calls in flight
+        qps: Maximum sustained call start rate per second (0 = unlimited)
+        burst: Maximum number of calls started back-to-back
+        args: Extra positional arguments passed to func after the item
+        logger: Logger instance
+        description: Short label used in log messages
+        on_result: Optional callback invoked as on_result(item, result) as each call completes
+
+    Returns:
+        List of (item, result, exception) tuples in completion order. result is
+        None and exception is set when func raised.
+    """
+    items = list(items)
+    if not items:
+        return []
+
+    pool = WorkerPool(min(int(concurrency), len(items)), qps, burst)
+
+    if logger:
+        limiter = pool.limiter
+        rate = f"qps={limiter.qps:g}, burst={limiter.burst}" if limiter.enabled else "no rate limit"
+        logger.debug(f"Running {len(items)} {description}(s) with concurrency={pool.concurrency} ({rate})")
+
+    results = []
+    with track_phase(description, items, logger) as phase, pool:
+        def _invoke(item):
+            phase.started(item)
+            return func(item, *args)
+
+        futures = {pool.submit(_invoke, item): item for item in items}
+
+        for future in as_completed(futures):
+            item = futures[future]
+            try:
+                result = future.result()
+                results.append((item, result, None))
+                phase.finished(item, ok=_succeeded(result))
+                if on_result:
+                    on_result(item, result)
+            except Exception as e:
+                if logger:
+                    logger.error(f"[{item}] Exception during {description}: {e}")
+                results.append((item, None, e))
+                phase.finished(item, ok=False, error=str(e))
+
+    return results
+
+
+def _succeeded(result: Any) -> bool:
+    """Whether a worker result counts as done on the progress dashboard: False or {'success': False} is failed."""
+    if result is False:
+        return False
+    if isinstance(result, dict) and result.get('success') is False:
+        return False
+    return True
@@ -0,0 +1,329 @@
+#!/usr/bin/env python3
+"""
+Custom PromQL metrics for KubeVirt performance testing.
+
+Teams can attach their own KPIs (for example Portworx write latency or CNI
+packet drops) to any workload's results without changing the workload. A
+metrics definition file lists PromQL queries; after a run each query is
+evaluated over the run window with a range query and its min/max/avg is
+embedded in the result summary under ``custom_metrics``.
+
+The definition file is passed with ``virtbench --metrics-config FILE`` or
+the VIRTBENCH_METRICS_CONFIG environment variable:
+
+    prometheus:
+      # Either query a URL directly ...
+      url: https://thanos-querier-openshift-monitoring.apps.example.com
+      token_env: PROM_TOKEN          # or token: <bearer token>
+      insecure: true
+      # ... or (default) exec into a Prometheus pod and query localhost
+      namespace: openshift-monitoring
+      pod: prometheus-k8s-0
+      container: prometheus
+    step: 30s
+    metrics:
+      - name: px_write_latency_ms
+        query: avg(rate(px_volume_write_latency_seconds_sum[1m])) * 1000
+        unit: ms
+"""
+
+import json
+import logging
+import math
+import os
+import ssl
+import urllib.parse
+import urllib.request
+from datetime import datetime, timezone
+from typing import Dict, List, Optional, Tuple
+
+import yaml
+
+from utils.base import run_kubectl_command
+
+METRICS_CONFIG_ENV = 'VIRTBENCH_METRICS_CONFIG'
+
+DEFAULT_PROMETHEUS_NAMESPACE = 'openshift-monitoring'
+DEFAULT_PROMETHEUS_POD = 'prometheus-k8s-0'
+DEFAULT_PROMETHEUS_CONTAINER = 'prometheus'
+DEFAULT_PROMETHEUS_PORT = 9090
+DEFAULT_STEP = '30s'
+DEFAULT_QUERY_TIMEOUT = 60
+
+# Bytes sent by each VMI during live migration (the name changed in KubeVirt 1.0)
+MIGRATION_DATA_METRICS = ('kubevirt_vmi_migration_data_processed_bytes',
+                          'kubevirt_migrate_vmi_data_processed_bytes')
+
+
+def load_metrics_config(path: Optional[str] = None,
+                        logger: Optional[logging.Logger] = None) -> Optional[Dict]:
+    """
+    Load a metrics definition file.
+
+    Args:
+        path: YAML file path (default: $VIRTBENCH_METRICS_CONFIG)
+        logger: Logger instance
+
+    Returns:
+        Parsed config with a non-empty metrics list, or None if no file is
+        configured or it is invalid
+    """
+    path = path or os.getenv(METRICS_CONFIG_ENV)
+    if not path:
+        return None
+    try:
+        with open(os.path.expanduser(path)) as f:
+            config = yaml.safe_load(f) or {}
+    except (OSError, yaml.YAMLError) as e:
+        if logger:
+            logger.warning(f"Could not read metrics config {path}: {e}")
+        return None
+
+    metrics = [m for m in config.get('metrics') or [] if m.get('name') and m.get('query')]
+    if not metrics:
+        if logger:
+            logger.warning(f"Metrics config {path} defines no metrics with a name and query")
+        return None
+    config['metrics'] = metrics
+    return config
+
+
+def _parse_rfc3339(value: str) -> float:
+    """Convert an RFC3339 UTC timestamp (as written by utils.timing) to epoch seconds."""
+    base, _, frac = value.rstrip('Z').partition('.')
+    seconds = datetime.strptime(base, '%Y-%m-%dT%H:%M:%S').replace(tzinfo=timezone.utc).timestamp()
+    return seconds + (float(f"0.{frac}") if frac else 0.0)
+
+
+def _query_url(prometheus: Dict, params: Dict, endpoint: str = 'query_range') -> Dict:
+    """Run a query against prometheus.url with an optional bearer token."""
+    url = prometheus['url'].rstrip('/') + f'/api/v1/{endpoint}?' + urllib.parse.urlencode(params)
+    request = urllib.request.Request(url)
+    token = prometheus.get('token') or os.getenv(prometheus.get('token_env', ''), '')
+    if token:
+        request.add_header('Authorization', f'Bearer {token}')
+    context = None
+    if prometheus.get('insecure'):
+        context = ssl.create_default_context()
+        context.check_hostname = False
+        context.verify_mode = ssl.CERT_NONE
+    with urllib.request.urlopen(request, timeout=DEFAULT_QUERY_TIMEOUT, context=context) as response:
+        return json.loads(response.read().decode())
+
+
+def _query_pod(prometheus: Dict, params: Dict, endpoint: str = 'query_range') -> Dict:
+    """Run a query from inside a Prometheus pod against its local API."""
+    port = prometheus.get('port', DEFAULT_PROMETHEUS_PORT)
+    url = f"http://localhost:{port}/api/v1/{endpoint}?" + urllib.parse.urlencode(params)
+    rc, out, err = run_kubectl_command(
+        ['exec', '-n', prometheus.get('namespace', DEFAULT_PROMETHEUS_NAMESPACE),
+         prometheus.get('pod', DEFAULT_PROMETHEUS_POD),
+         '-c', prometheus.get('container', DEFAULT_PROMETHEUS_CONTAINER),
+         '--', 'curl', '-sS', '--max-time', str(DEFAULT_QUERY_TIMEOUT), url],
+        check=False, timeout=DEFAULT_QUERY_TIMEOUT + 15
+    )
+    if rc != 0:
+        raise RuntimeError(err.strip() or f"kubectl exec exited with {rc}")
+    return json.loads(out)
+
+
+def query_range_stats(prometheus: Dict, query: str, start: float, end: float,
+                      step: str = DEFAULT_STEP) -> Dict:
+    """
+    Evaluate a PromQL range query and reduce every returned sample to min/max/avg.
+
+    Samples of all returned series are pooled; NaN and infinite values are
+    ignored.
+
+    Returns:
+        Dict with min, max, avg, samples and series
+
+    Raises:
+        RuntimeError: If the query fails
+    """
+    params = {'query': query, 'start': f"{start:.3f}", 'end': f"{end:.3f}", 'step': step}
+    try:
+        response = _query_url(prometheus, params) if prometheus.get('url') else _query_pod(prometheus, params)
+    except (OSError, ValueError) as e:
+        raise RuntimeError(str(e))
+    if response.get('status') != 'success':
+        raise RuntimeError(response.get('error') or 'query failed')
+
+    series = response.get('data', {}).get('result', [])
+    values = []
+    for s in series:
+        for _, value in s.get('values', []):
+            try:
+                v = float(value)
+            except (TypeError, ValueError):
+                continue
+            if math.isfinite(v):
+                values.append(v)
+    return {
+        'min': min(values) if values else None,
+        'max': max(values) if values else None,
+        'avg': sum(values) / len(values) if values else None,
+        'samples': len(values),
+        'series': len(series),
+    }
+
+
+def evaluate_custom_metrics(config: Dict, start: float, end: float,
+                            logger: Optional[logging.Logger] = None) -> List[Dict]:
+    """
+    Evaluate every configured query over [start, end].
+
+    A failing query is reported with an error rather than failing the run.
+
+    Args:
+        config: Result of load_metrics_config
+        start: Window start (epoch seconds)
+        end: Window end (epoch seconds)
+        logger: Logger instance
+
+    Returns:
+        List of dicts with name, query, unit, min, max, avg, samples, series
+        and error
+    """
+    prometheus = config.get('prometheus') or {}
+    step = str(config.get('step', DEFAULT_STEP))
+    results = []
+    for metric in config['metrics']:
+        entry = {
+            'name': metric['name'], 'query': metric['query'], 'unit': metric.get('unit'),
+            'min': None, 'max': None, 'avg': None, 'samples': 0, 'series': 0, 'error': None,
+        }
+        try:
+            entry.update(query_range_stats(prometheus, metric['query'], start, end,
+                                           str(metric.get('step', step))))
+        except RuntimeError as e:
+            entry['error'] = str(e)
+            if logger:
+                logger.warning(f"Custom metric {metric['name']} failed: {e}")
+        results.append(entry)
+    return results
+
+
+def load_prometheus_settings(path: Optional[str] = None) -> Dict:
+    """
+    Return the prometheus block of the metrics definition file.
+
+    Unlike load_metrics_config, no metrics need to be defined; without a
+    file the defaults (exec into the OpenShift Prometheus pod) are used.
+    """
+    path = path or os.getenv(METRICS_CONFIG_ENV)
+    if not path:
+        return {}
+    try:
+        with open(os.path.expanduser(path)) as f:
+            return (yaml.safe_load(f) or {}).get('prometheus') or {}
+    except (OSError, yaml.YAMLError):
+        return {}
+
+
+def _timing_window(timing: Dict) -> Tuple[float, float]:
+    """Run window of a timing block in cluster-clock epoch seconds."""
+    start = _parse_rfc3339(timing['started_at'])
+    end = _parse_rfc3339(timing['finished_at'])
+    # Translate client timestamps to the cluster clock Prometheus records in
+    skew = timing.get('clock_skew') or {}
+    offset = skew.get('offset_seconds') or 0.0
+    return start + offset, end + offset
+
+
+def query_migration_data_bytes(timing: Optional[Dict],
+                               logger: Optional[logging.Logger] = None) -> Dict[Tuple[str, str], float]:
+    """
+    Look up how many bytes each VMI sent while live migrating.
+
+    KubeVirt reports transfer progress as a Prometheus metric while a
+    migration runs (migrationState only carries timestamps), so the peak of
+    that metric over the run window is the amount sent by the migration.
+    Migrations shorter than the scrape interval may not be captured.
+
+    Args:
+        timing: Timing block from utils.timing.timing_metadata (provides the window)
+        logger: Logger instance
+
+    Returns:
+        {(namespace, vmi name): bytes}; empty if Prometheus cannot be queried
+    """
+    if not timing or not timing.get('started_at') or not timing.get('finished_at'):
+        return {}
+    start, end = _timing_window(timing)
+    # Cover the last scrape after the window closes
+    window = int(end - start) + 60
+    query = ' or '.join(f"max by (namespace, name) (max_over_time({metric}[{window}s]))"
+                        for metric in MIGRATION_DATA_METRICS)
+    params = {'query': query, 'time': f"{end + 60:.3f}"}
+    prometheus = load_prometheus_settings()
+    try:
+        if prometheus.get('url'):
+            response = _query_url(prometheus, params, endpoint='query')
+        else:
+            response = _query_pod(prometheus, params, endpoint='query')
+    except (OSError, ValueError, RuntimeError) as e:
+        if logger:
+            logger.warning(f"Could not query migration transfer bytes from Prometheus: {e}")
+        return {}
+    if response.get('status') != 'success':
+        if logger:
+            logger.warning(f"Migration transfer bytes query failed: {response.get('error')}")
+        return {}
+
+    transferred = {}
+    for series in response.get('data', {}).get('result', []):
+        labels = series.get('metric', {})
+        try:
+            value = float(series.get('value', [None, None])[1])
+        except (TypeError, ValueError):
+            continue
+        if math.isfinite(value) and labels.get('namespace') and labels.get('name'):
+            transferred[(labels['namespace'], labels['name'])] = value
+    return transferred
+
+
+def collect_custom_metrics(timing: Optional[Dict],
+                           logger: Optional[logging.Logger] = None) -> Optional[List[Dict]]:
+    """
+    Evaluate the configured custom metrics over a workload's run window.
+
+    Args:
+        timing: Timing block from utils.timing.timing_metadata (provides the window)
+        logger: Logger instance
+
+    Returns:
+        evaluate_custom_metrics() result, or None if no metrics are configured
+        or there is no run window
+    """
+    if not timing or not timing.get('started_at') or not timing.get('finished_at'):
+        return None
+    config = load_metrics_config(logger=logger)
+    if not config:
+        return None
+
+    start, end = _timing_window(timing)
+    results = evaluate_custom_metrics(config, start, end, logger)
+    if logger:
+        print_custom_metrics(results, logger)
+    return results
+
+
+def print_custom_metrics(results: List[Dict], logger: logging.Logger) -> None:
+    """Log a table of evaluated custom metrics."""
+    def fmt(value):
+        return f"{value:.4g}" if value is not None else '-'
+
+    logger.info("")
+    logger.info("=" * 80)
+    logger.info("CUSTOM METRICS")
+    logger.info("=" * 80)
+    logger.info(f"{'Metric':<36}{'Min':>12}{'Avg':>12}{'Max':>12}  Unit")
+    logger.info("-" * 80)
+    for r in results:
+        if r['error']:
+            logger.info(f"{r['name']:<36}  error: {r['error']}")
+        else:
+            logger.info(f"{r['name']:<36}{fmt(r['min']):>12}{fmt(r['avg']):>12}"
+                        f"{fmt(r['max']):>12}  {r['unit'] or ''}")
+    logger.info("=" * 80)
@@ -0,0 +1,232 @@
+#!/usr/bin/env python3
+"""
+Guest data integrity verification for KubeVirt performance testing.
+
+Before a disruptive operation (live migration, node or storage failure) a
+set of random files is written inside each guest with fsync and their
+SHA-256 checksums are recorded. Afterwards the files are read back with
+O_DIRECT, bypassing the guest page cache so the data comes from the disk
+rather than from migrated memory, and compared with the recorded
+checksums. Each VM ends up with one of the statuses below, which workloads
+report next to their timing results.
+"""
+
+import logging
+import threading
+import time
+from typing import Dict, Iterable, List, Optional
+
+from utils.common import split_vm_target
+from utils.concurrency import run_parallel
+from utils.guestexec import GuestExecutor, SSH_CONNECTION_ERROR
+
+DEFAULT_VERIFY_DIR = '/var/tmp/virtbench-verify'
+DEFAULT_VERIFY_FILES = 4
+DEFAULT_VERIFY_SIZE_MB = 64
+DEFAULT_VERIFY_TIMEOUT = 600
+
+DATA_OK = 'ok'
+DATA_CORRUPTED = 'corrupted'      # at least one file's checksum changed
+DATA_LOST = 'lost'                # at least one file is missing or unreadable
+DATA_UNREACHABLE = 'unreachable'  # guest could not be reached to verify
+DATA_NOT_SEEDED = 'not_seeded'    # seeding failed before the operation
+DATA_STATUSES = [DATA_OK, DATA_CORRUPTED, DATA_LOST, DATA_UNREACHABLE, DATA_NOT_SEEDED]
+
+_MISSING = 'MISSING'
+
+
+class DataVerifier:
+    """
+    Seeds checksummed files in guests and verifies them later.
+
+    One instance tracks the expected checksums of every seeded VM, keyed by
+    namespace, so it can be shared across the seeding and verification
+    phases of a workload.
+    """
+
+    def __init__(self, executor: GuestExecutor, files: int = DEFAULT_VERIFY_FILES,
+                 size_mb: int = DEFAULT_VERIFY_SIZE_MB, directory: str = DEFAULT_VERIFY_DIR,
+                 logger: Optional[logging.Logger] = None):
+        self.executor = executor
+        self.files = max(1, int(files))
+        self.size_mb = max(self.files, int(size_mb))
+        self.directory = directory
+        self.logger = logger
+        self._manifests: Dict[str, Dict[str, str]] = {}
+        self._lock = threading.Lock()
+
+    def _file_names(self) -> List[str]:
+        return [f"blk-{i}" for i in range(1, self.files + 1)]
+
+    def seed(self, namespace: str, vm_name: str, timeout: int = 300) -> bool:
+        """
+        Write the verification files in one guest and record their checksums.
+
+        The namespace may be a "{namespace}/{vm}" target of a namespace-scoped
+        run (utils.common.vm_targets()); results are keyed by it either way.
+
+        Returns:
+            True if the files were written and checksummed
+        """
+        per_file = self.size_mb // self.files
+        writes = '; '.join(
+            f"dd if=/dev/urandom of={self.directory}/{name} bs=1M count={per_file} "
+            f"conv=fsync status=none"
+            for name in self._file_names()
+        )
+        command = (f"set -e; rm -rf {self.directory}; mkdir -p {self.directory}; {writes}; "
+                   f"sync; cd {self.directory} && sha256sum {' '.join(self._file_names())}")
+
+        vmi_namespace, vmi_name = split_vm_target(namespace, vm_name)
+        rc, stdout, stderr = self.executor.run_on_vmi(vmi_name, vmi_namespace, command, timeout=timeout)
+        if rc != 0:
+            if self.logger:
+                self.logger.warning(f"[{namespace}] Failed to seed verification data: "
+                                    f"{(stderr or '').strip()}")
+            return False
+
+        manifest = {}
+        for line in stdout.splitlines():
+            parts = line.split()
+            if len(parts) == 2:
+                manifest[parts[1]] = parts[0]
+        if set(manifest) != set(self._file_names()):
+            if self.logger:
+                self.logger.warning(f"[{namespace}] Unexpected checksum output while seeding")
+            return False
+
+        with self._lock:
+            self._manifests[namespace] = manifest
+        if self.logger:
+            self.logger.debug(f"[{namespace}] Seeded {self.files} file(s), {self.size_mb} MiB")
+        return True
+
+    def seed_many(self, namespaces: Iterable[str], vm_name: str,
+                  concurrency: int = 10) -> int:
+        """Seed every namespace's VM in parallel. Returns the number seeded."""
+        outcomes = run_parallel(self.seed, namespaces, concurrency=concurrency,
+                                args=(vm_name,), logger=self.logger,
+                                description="data seeding")
+        seeded = sum(1 for _, ok, _ in outcomes if ok)
+        if self.logger:
+            self.logger.info(f"Seeded verification data in {seeded}/{len(outcomes)} VM(s) "
+                             f"({self.files} file(s), {self.size_mb} MiB each VM)")
+        return seeded
+
+    def verify(self, namespace: str, vm_name: str,
+               timeout: int = DEFAULT_VERIFY_TIMEOUT) -> Dict:
+        """
+        Verify one guest's files against the recorded checksums.
+
+        The guest may still be booting after a failure, so connection errors
+        are retried until timeout.
+
+        Returns:
+            Dict with status (one of DATA_STATUSES), corrupted and missing
+            file lists, and verify_seconds
+        """
+        result = {'status': DATA_NOT_SEEDED, 'corrupted': [], 'missing': [], 'verify_seconds': None}
+        with self._lock:
+            manifest = self._manifests.get(namespace)
+        if not manifest:
+            return result
+
+        reads = '; '.join(
+            f"s=$(dd if={self.directory}/{name} iflag=direct bs=1M status=none | sha256sum) "
+            f"&& [ -f {self.directory}/{name} ] && echo \"${{s%% *}} {name}\" "
+            f"|| echo \"{_MISSING} {name}\""
+            for name in sorted(manifest)
+        )
+
+        vmi_namespace, vmi_name = split_vm_target(namespace, vm_name)
+        started = time.monotonic()
+        rc, stdout = SSH_CONNECTION_ERROR, ''
+        while time.monotonic() - started < timeout:
+            rc, stdout, _ = self.executor.run_on_vmi(vmi_name, vmi_namespace, reads, timeout=300)
+            if rc != SSH_CONNECTION_ERROR:
+                break
+            time.sleep(5)
+
+        if rc == SSH_CONNECTION_ERROR:
+            result['status'] = DATA_UNREACHABLE
+            return result
+
+        seen = {}
+        for line in stdout.splitlines():
+            parts = line.split()
+            if len(parts) == 2:
+                seen[parts[1]] = parts[0]
+
+        for name, expected in sorted(manifest.items()):
+            actual = seen.get(name, _MISSING)
+            if actual == _MISSING:
+                result['missing'].append(name)
+            elif actual != expected:
+                result['corrupted'].append(name)
+
+        if result['corrupted']:
+            result['status'] = DATA_CORRUPTED
+        elif result['missing']:
+            result['status'] = DATA_LOST
+        else:
+            result['status'] = DATA_OK
+        result['verify_seconds'] = round(time.monotonic() - started, 2)
+
+        if self.logger:
+            if result['status'] == DATA_OK:
+                self.logger.info(f"[{namespace}] Data integrity verified ({len(manifest)} file(s))")
+            else:
+                self.logger.error(f"[{namespace}] Data integrity {result['status'].upper()}: "
+                                  f"corrupted={result['corrupted']} missing={result['missing']}")
+        return result
+
+    def verify_many(self, namespaces: Iterable[str], vm_name: str, concurrency: int = 10,
+                    timeout: int = DEFAULT_VERIFY_TIMEOUT) -> Dict[str, Dict]:
+        """Verify every namespace's VM in parallel. Returns namespace -> verify() result."""
+        outcomes = run_parallel(self.verify, namespaces, concurrency=concurrency,
+                                args=(vm_name, timeout), logger=self.logger,
+                                description="data verification")
+        results = {}
+        for ns, result, error in outcomes:
+            results[ns] = result if error is None else {
+                'status': DATA_UNREACHABLE, 'corrupted': [], 'missing': [], 'verify_seconds': None
+            }
+        return results
+
+
+def summarize_data_integrity(results: Dict[str, Dict]) -> Dict:
+    """
+    Count VMs per data integrity status.
+
+    Returns:
+        Dict with one count per status, total, and violations (VMs with
+        corrupted or lost data)
+    """
+    summary = {status: 0 for status in DATA_STATUSES}
+    for result in results.values():
+        summary[result['status']] = summary.get(result['status'], 0) + 1
+    summary['total'] = len(results)
+    summary['violations'] = summary[DATA_CORRUPTED] + summary[DATA_LOST]
+    return summary
+
+
+def print_data_integrity_summary(results: Dict[str, Dict], logger: logging.Logger) -> None:
+    """Log a data integrity summary and every VM that did not verify cleanly."""
+    summary = summarize_data_integrity(results)
+    logger.info("")
+    logger.info("=" * 70)
+    logger.info("DATA INTEGRITY")
+    logger.info("=" * 70)
+    logger.info(f"  VMs verified:           {summary['total']}")
+    logger.info(f"  Intact:                 {summary[DATA_OK]}")
+    logger.info(f"  Corrupted:              {summary[DATA_CORRUPTED]}")
+    logger.info(f"  Data lost:              {summary[DATA_LOST]}")
+    logger.info(f"  Unreachable:            {summary[DATA_UNREACHABLE]}")
+    logger.info(f"  Not seeded:             {summary[DATA_NOT_SEEDED]}")
+    for ns, result in sorted(results.items()):
+        if result['status'] != DATA_OK:
+            detail = ''
+            if result['corrupted'] or result['missing']:
+                detail = f" (corrupted: {result['corrupted']}, missing: {result['missing']})"
+            logger.info(f"    {ns}: {result['status']}{detail}")
+    logger.info("=" * 70)
@@ -0,0 +1,280 @@
+#!/usr/bin/env python3
+"""
+Diagnostics bundle of a benchmark run, in the spirit of must-gather.
+
+When a workload fails, the state that explains it is gone by the time
+someone looks: VMIs are cleaned up, events expire and component logs roll
+over. `virtbench --collect-diagnostics on-failure` (the default) runs this
+script after every failed workload, and `always` after every workload. It
+writes:
+
+    <results>/diagnostics/<timestamp>_<workload>/
+        summary.json                         what was collected, failing resources, errors
+        nodes.json                           conditions, taints and allocatable of every node
+        logs/<namespace>/<pod>.log           virt-controller, virt-handler, virt-api, virt-operator
+                                             and CDI logs of the run window, and the logs of
+                                             failing pods (virt-launcher, importer, ...)
+        resources/<namespace>/<kind>-<name>.yaml
+                                             failing VMs, VMIs, DataVolumes, PVCs and pods
+        events/<namespace>.txt               events of the namespaces with failing resources
+
+Every item is best effort: one that cannot be read is listed under errors
+in summary.json and the rest is still collected.
+
+Exit codes:
+    0: bundle written (possibly with errors)
+    1: the output directory could not be created
+
+Usage:
+    python3 diagnostics.py --output results/diagnostics/20250101-120000_migration \
+        --namespace-prefix kubevirt-perf-test --since 3600
+"""
+
+import argparse
+import json
+import logging
+import os
+import sys
+from datetime import datetime, timezone
+from typing import Dict, List, Optional
+
+import yaml
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
+
+from utils.common import setup_logging, run_kubectl_command, UNHEALTHY_PHASES
+from utils.concurrency import run_parallel
+
+# Component pods whose logs are collected, by label selector
+COMPONENT_SELECTORS = (
+    'kubevirt.io in (virt-controller,virt-handler,virt-api,virt-operator)',
+    'cdi.kubevirt.io in (cdi-deployment,cdi-apiserver,cdi-uploadproxy,cdi-operator)',
+)
+
+# Kinds checked for failures in the benchmark namespaces
+RESOURCE_KINDS = 'virtualmachines,virtualmachineinstances,datavolumes,persistentvolumeclaims,pods'
+
+HEALTHY_VM_STATUSES = ('Running', 'Stopped', 'Paused')
+
+# Failing resources whose YAML is saved, and failing pods whose logs are saved
+MAX_RESOURCES = 200
+MAX_POD_LOGS = 50
+
+
+def failure_reason(obj: Dict) -> Optional[str]:
+    """Why a VM, VMI, DataVolume, PVC or pod counts as failing, None when it is healthy."""
+    kind = obj.get('kind')
+    status = obj.get('status') or {}
+    if kind == 'VirtualMachine':
+        printable = status.get('printableStatus')
+        return None if printable in HEALTHY_VM_STATUSES else f"status {printable or 'unknown'}"
+    if kind == 'VirtualMachineInstance':
+        phase = status.get('phase')
+        return None if phase in ('Running', 'Succeeded') else f"phase {phase or 'unknown'}"
+    if kind == 'DataVolume':
+        phase = status.get('phase')
+        return None if phase == 'Succeeded' else f"phase {phase or 'unknown'}"
+    if kind == 'PersistentVolumeClaim':
+        phase = status.get('phase')
+        return None if phase == 'Bound' else f"phase {phase or 'unknown'}"
+    if kind == 'Pod':
+        for container in status.get('containerStatuses') or []:
+            waiting = (container.get('state') or {}).get('waiting') or {}
+            if waiting.get('reason') and waiting['reason'] != 'ContainerCreating':
+                return waiting['reason']
+        phase = status.get('phase')
+        if phase in UNHEALTHY_PHASES or phase == 'Pending':
+            return f"phase {phase}"
+    return None
+
+
+def in_namespaces(namespace: Optional[str], prefixes: List[str], names: List[str] = ()) -> bool:
+    """Whether the namespace starts with one of the prefixes or is one of the names."""
+    return bool(namespace) and (namespace in names or any(namespace.startswith(prefix) for prefix in prefixes))
+
+
+def get_json(args: List[str], logger: logging.Logger, errors: List[str]) -> Optional[Dict]:
+    returncode, stdout, stderr = run_kubectl_command(args + ['-o', 'json'], check=False, logger=logger)
+    if returncode != 0:
+        errors.append(f"kubectl {' '.join(args)}: {stderr.strip() or 'failed'}")
+        return None
+    try:
+        return json.loads(stdout)
+    except json.JSONDecodeError as e:
+        errors.append(f"kubectl {' '.join(args)}: {e}")
+        return None
+
+
+def write_file(path: str, content: str):
+    os.makedirs(os.path.dirname(path), exist_ok=True)
+    with open(path, 'w') as f:
+        f.write(content)
+
+
+def node_conditions(logger: logging.Logger, errors: List[str]) -> List[Dict]:
+    """Conditions, taints and allocatable resources of every node."""
+    nodes = get_json(['get', 'nodes'], logger, errors) or {}
+    return [{
+        'name': node['metadata']['name'],
+        'unschedulable': bool((node.get('spec') or {}).get('unschedulable')),
+        'taints': (node.get('spec') or {}).get('taints') or [],
+        'conditions': [{key: c.get(key) for key in ('type', 'status', 'reason', 'message', 'lastTransitionTime')}
+                       for c in (node.get('status') or {}).get('conditions') or []],
+        'allocatable': (node.get('status') or {}).get('allocatable'),
+    } for node in nodes.get('items') or []]
+
+
+def save_pod_log(pod: Dict, out_dir: str, since: Optional[int], logger: logging.Logger) -> Optional[str]:
+    """Write the logs of all containers of a pod; returns an error message or None."""
+    namespace, name = pod['metadata']['namespace'], pod['metadata']['name']
+    args = ['logs', name, '-n', namespace, '--all-containers', '--prefix', '--timestamps']
+    if since:
+        args.append(f'--since={since}s')
+    returncode, stdout, stderr = run_kubectl_command(args, check=False, logger=logger)
+    if returncode != 0 and not stdout:
+        return f"logs of {namespace}/{name}: {stderr.strip() or 'failed'}"
+    write_file(os.path.join(out_dir, 'logs', namespace, f"{name}.log"), stdout)
+    return None
+
+
+def collect_logs(pods: List[Dict], out_dir: str, since: Optional[int], logger: logging.Logger,
+                 errors: List[str]) -> List[str]:
+    """Save the logs of the pods in parallel; returns their paths relative to out_dir."""
+    saved = []
+    for pod, error, exc in run_parallel(save_pod_log, pods, concurrency=10, qps=0, args=(out_dir, since, logger),
+                                        logger=logger, description='log collection'):
+        if exc is not None or error:
+            errors.append(str(exc or error))
+        else:
+            saved.append(os.path.join('logs', pod['metadata']['namespace'], f"{pod['metadata']['name']}.log"))
+    return sorted(saved)
+
+
+def failing_resources(prefixes: List[str], names: List[str], logger: logging.Logger,
+                      errors: List[str]) -> List[Dict]:
+    """The failing VMs, VMIs, DataVolumes, PVCs and pods of the benchmark namespaces (by prefix or name)."""
+    listing = get_json(['get', RESOURCE_KINDS, '--all-namespaces'], logger, errors) or {}
+    failing = []
+    for obj in listing.get('items') or []:
+        metadata = obj.get('metadata') or {}
+        if not in_namespaces(metadata.get('namespace'), prefixes, names):
+            continue
+        reason = failure_reason(obj)
+        if reason:
+            failing.append({'kind': obj.get('kind'), 'namespace': metadata['namespace'],
+                            'name': metadata.get('name'), 'reason': reason, 'object': obj})
+    return failing
+
+
+def collect(args, logger: logging.Logger) -> Dict:
+    """Write the bundle to args.output; returns the contents of summary.json."""
+    errors: List[str] = []
+    out_dir = args.output
+    summary = {
+        'collected_at': datetime.now(timezone.utc).isoformat(timespec='seconds'),
+        'workload': args.workload,
+        'reason': args.reason,
+        'exit_code': args.exit_code,
+        'namespace_prefixes': args.namespace_prefix or [],
+        'namespaces': args.namespace or [],
+        'log_window_sec': args.since,
+    }
+
+    logger.info("Collecting node conditions...")
+    nodes = node_conditions(logger, errors)
+    write_file(os.path.join(out_dir, 'nodes.json'), json.dumps(nodes, indent=2))
+    summary['not_ready_nodes'] = [n['name'] for n in nodes
+                                  if any(c['type'] == 'Ready' and c['status'] != 'True' for c in n['conditions'])]
+
+    logger.info("Collecting KubeVirt and CDI component logs...")
+    components = {}
+    for selector in COMPONENT_SELECTORS:
+        for pod in (get_json(['get', 'pods', '--all-namespaces', '-l', selector], logger, errors) or {}).get('items') or []:
+            components[(pod['metadata']['namespace'], pod['metadata']['name'])] = pod
+    summary['component_logs'] = collect_logs(list(components.values()), out_dir, args.since, logger, errors)
+
+    failing = []
+    if args.namespace_prefix or args.namespace:
+        failing = failing_resources(args.namespace_prefix or [], args.namespace or [], logger, errors)
+    logger.info(f"Collecting {min(len(failing), MAX_RESOURCES)} of {len(failing)} failing resources...")
+    for item in failing[:MAX_RESOURCES]:
+        obj = item['object']
+        (obj.get('metadata') or {}).pop('managedFields', None)
+        write_file(os.path.join(out_dir, 'resources', item['namespace'], f"{item['kind'].lower()}-{item['name']}.yaml"),
+                   yaml.safe_dump(obj, sort_keys=False))
+    summary['failing_resources'] = [{key: item[key] for key in ('kind', 'namespace', 'name', 'reason')}
+                                    for item in failing]
+
+    namespaces = sorted({item['namespace'] for item in failing[:MAX_RESOURCES]})
+    for namespace in namespaces:
+        returncode, stdout, stderr = run_kubectl_command(
+            ['get', 'events', '-n', namespace, '--sort-by=.lastTimestamp'], check=False, logger=logger)
+        if returncode == 0:
+            write_file(os.path.join(out_dir, 'events', f"{namespace}.txt"), stdout)
+        else:
+            errors.append(f"events of {namespace}: {stderr.strip() or 'failed'}")
+    pods = [item['object'] for item in failing if item['kind'] == 'Pod'][:MAX_POD_LOGS]
+    # Failing pods may never have started; their logs cover their whole life
+    summary['pod_logs'] = collect_logs(pods, out_dir, None, logger, errors)
+
+    summary['errors'] = errors
+    write_file(os.path.join(out_dir, 'summary.json'), json.dumps(summary, indent=2))
+    return summary
+
+
+def parse_args():
+    """Parse command line arguments"""
+    parser = argparse.ArgumentParser(
+        description='Collect a diagnostics bundle of a benchmark run',
+        formatter_class=argparse.RawDescriptionHelpFormatter,
+        epilog="""
+Examples:
+  # Bundle of a failed migration run of the last hour
+  %(prog)s --output results/diagnostics/manual --namespace-prefix kubevirt-perf-test --since 3600
+        """
+    )
+    parser.add_argument('--output', required=True, help='Directory to write the bundle to')
+    parser.add_argument('--namespace-prefix', nargs='+',
+                        help='Prefixes of the benchmark namespaces to look for failing resources in')
+    parser.add_argument('--namespace', nargs='+',
+                        help='Names of benchmark namespaces to look for failing resources in (matched exactly)')
+    parser.add_argument('--since', type=int, help='Collect component logs of the last N seconds (default: all)')
+    parser.add_argument('--workload', help='Workload of the run, recorded in summary.json')
+    parser.add_argument('--reason', default='manual', choices=['failure', 'always', 'manual'],
+                        help='Why the bundle is collected, recorded in summary.json (default: manual)')
+    parser.add_argument('--exit-code', type=int, help='Exit code of the run, recorded in summary.json')
+    parser.add_argument(
+        '--log-level',
+        type=str,
+        default='INFO',
+        choices=['DEBUG', 'INFO', 'WARNING', 'ERROR'],
+        help='Logging level (default: INFO)'
+    )
+    return parser.parse_args()
+
+
+def main():
+    """Main execution function"""
+    args = parse_args()
+    logger = setup_logging(log_file=None, log_level=args.log_level)
+
+    try:
+        os.makedirs(args.output, exist_ok=True)
+    except OSError as e:
+        logger.error(f"Cannot create {args.output}: {e}")
+        sys.exit(1)
+
+    summary = collect(args, logger)
+    logger.info("=" * 80)
+    logger.info(f"Diagnostics bundle: {args.output}")
+    logger.info(f"  Component logs:    {len(summary['component_logs'])}")
+    logger.info(f"  Failing resources: {len(summary['failing_resources'])}")
+    logger.info(f"  Not ready nodes:   {', '.join(summary['not_ready_nodes']) or 'none'}")
+    if summary['errors']:
+        logger.warning(f"  Not collected:     {len(summary['errors'])} item(s), see summary.json")
+    logger.info("=" * 80)
+
+
+if __name__ == '__main__':
+    main()
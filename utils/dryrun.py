@@ -0,0 +1,189 @@
+#!/usr/bin/env python3
+"""
+Dry-run planning for KubeVirt performance testing.
+
+With the global ``virtbench --dry-run`` option (or VIRTBENCH_DRY_RUN=1) a
+workload does everything up to the point where it would change the cluster,
+then renders every manifest it would apply and lists every API action it
+would take (migrations, deletions, restarts, drains) instead of running them.
+The manifests are validated with a server-side dry-run apply (see
+utils.apply), so schema errors, admission webhooks and quota show up in the
+plan; manifests in namespaces the run would create cannot be checked yet.
+Read-only lookups such as node selection or finding the VMs on a node still
+query the cluster, so the plan matches what a real run would do right now.
+
+    plan = DryRunPlan('migration', logger)
+    plan.create_namespaces(namespaces)
+    plan.apply(vm_manifest, namespace)
+    plan.action('migrate', f"vm/{namespace}/{vm_name}", f"to {target_node}")
+    plan.report()
+"""
+
+import logging
+import subprocess
+import sys
+from collections import Counter
+from typing import List, Optional
+
+import yaml
+
+from utils.apply import validate_manifest
+from utils.base import DRY_RUN_ENV, is_dry_run, run_kubectl_command
+from utils.common import stamp_manifest
+
+# Kinds that are not namespaced, so a manifest of these never gets a namespace
+CLUSTER_SCOPED_KINDS = {'Namespace', 'Node', 'StorageClass', 'PersistentVolume'}
+
+# kubectl short names used in the action list, so "create vm/..." and "delete vm/..." line up
+SHORT_NAMES = {
+    'VirtualMachine': 'vm',
+    'VirtualMachineInstanceMigration': 'vmim',
+    'DataVolume': 'dv',
+    'PersistentVolumeClaim': 'pvc',
+    'VirtualMachineSnapshot': 'vmsnapshot',
+    'VirtualMachineClone': 'vmclone',
+}
+
+
+def _existing_namespaces(logger: Optional[logging.Logger] = None) -> Optional[set]:
+    """Names of all namespaces, or None if they cannot be listed."""
+    try:
+        returncode, stdout, stderr = run_kubectl_command(['get', 'namespaces', '-o', 'name'], check=False, timeout=30)
+    except (OSError, subprocess.SubprocessError) as e:
+        if logger:
+            logger.debug(f"Could not list namespaces: {e}")
+        return None
+    if returncode != 0:
+        if logger:
+            logger.debug(f"Could not list namespaces: {stderr.strip()}")
+        return None
+    return {line.split('/', 1)[-1] for line in stdout.split()}
+
+
+class DryRunPlan:
+    """
+    The manifests and API actions a workload would apply, printed by report().
+
+    Manifests are stamped with the run labels and correlation annotations
+    exactly as a real run stamps them.
+    """
+
+    def __init__(self, workload: str, logger: logging.Logger):
+        self.workload = workload
+        self.logger = logger
+        self.manifests: List[dict] = []
+        self.actions: List[tuple] = []
+        self.created_namespaces: List[str] = []
+
+    def action(self, verb: str, target: str, detail: str = ''):
+        """
+        Record an API action.
+
+        Args:
+            verb: What would be done, e.g. "delete", "migrate", "stop"
+            target: "{kind}/{namespace}/{name}" or "{kind}/{name}", e.g. "vm/perf-test-1/rhel-9-vm"
+            detail: Optional detail, e.g. "to worker-2"
+        """
+        self.actions.append((verb, target, detail))
+
+    def apply(self, manifest: str, namespace: Optional[str] = None, verb: str = 'create', detail: str = '',
+              stamp: bool = True) -> List[str]:
+        """
+        Record every object of a YAML (or JSON) manifest that would be applied in a namespace.
+
+        stamp=False keeps a user-provided manifest as is, for workloads that
+        apply it unmodified (e.g. a FAR configuration).
+
+        Returns:
+            The action targets of the objects, e.g. ["vm/perf-test-1/rhel-9-vm"]
+        """
+        targets = []
+        for doc in yaml.safe_load_all(stamp_manifest(manifest, namespace) if stamp else manifest):
+            if not doc:
+                continue
+            kind = doc.get('kind', '')
+            metadata = doc.setdefault('metadata', {})
+            if namespace and kind not in CLUSTER_SCOPED_KINDS:
+                metadata.setdefault('namespace', namespace)
+            self.manifests.append(doc)
+            target = '/'.join(part for part in (metadata.get('namespace'), metadata.get('name')) if part)
+            targets.append(f"{SHORT_NAMES.get(kind, kind.lower())}/{target}")
+            self.action(verb, targets[-1], detail)
+        return targets
+
+    def create_namespaces(self, namespaces: List[str]):
+        """Record the namespaces that would be created; namespaces that already exist are reused."""
+        existing = _existing_namespaces(self.logger) or set()
+        reused = 0
+        for namespace in namespaces:
+            if namespace in existing:
+                reused += 1
+                continue
+            self.apply(yaml.safe_dump({'apiVersion': 'v1', 'kind': 'Namespace', 'metadata': {'name': namespace}}))
+            self.created_namespaces.append(namespace)
+        if reused:
+            self.logger.info(f"[DRY RUN] {reused} of {len(namespaces)} namespaces already exist and would be reused")
+
+    def delete_namespaces(self, namespaces: List[str], detail: str = ''):
+        """
+        Record the namespace deletions of the run's own cleanup.
+
+        Cleanup only deletes namespaces labeled by this run, so namespaces
+        that already existed are left out.
+        """
+        for namespace in namespaces:
+            if namespace in self.created_namespaces:
+                self.action('delete', f"namespace/{namespace}", detail)
+
+    def validate(self) -> Optional[str]:
+        """
+        Check the manifests with one server-side dry-run apply.
+
+        Namespaces and manifests in namespaces the run would create are
+        skipped, since the API server would reject them as not found.
+
+        Returns:
+            The errors the API server reported, or None if the manifests are valid
+        """
+        checked = [doc for doc in self.manifests if doc.get('kind') != 'Namespace'
+                   and doc.get('metadata', {}).get('namespace') not in self.created_namespaces]
+        skipped = len(self.manifests) - len(checked)
+        if skipped:
+            self.logger.info(f"[DRY RUN] {skipped} manifests not validated (namespaces do not exist yet)")
+        if not checked:
+            return None
+        valid, error = validate_manifest(yaml.safe_dump({'apiVersion': 'v1', 'kind': 'List', 'items': checked},
+                                                        sort_keys=False), logger=self.logger)
+        return None if valid else error
+
+    def report(self):
+        """Print the rendered manifests, the action list and a summary of the plan."""
+        logger = self.logger
+        logger.info("\n" + "=" * 80)
+        logger.info(f"DRY RUN PLAN: {self.workload}")
+        logger.info("=" * 80)
+
+        if self.manifests:
+            logger.info(f"Manifests that would be applied ({len(self.manifests)}):")
+            sys.stdout.flush()
+            sys.stdout.write(yaml.safe_dump_all(self.manifests, sort_keys=False, explicit_start=True))
+            sys.stdout.flush()
+            error = self.validate()
+            if error:
+                logger.warning(f"Server-side validation failed:\n{error}")
+            else:
+                logger.info("Server-side validation (--dry-run=server) passed")
+
+        logger.info(f"\nActions in order ({len(self.actions)}):")
+        for verb, target, detail in self.actions:
+            logger.info(f"  {verb:<16} {target}{'  ' + detail if detail else ''}")
+
+        # One line per verb and kind, e.g. "create  virtualmachine  200"
+        counts = Counter((verb, target.split('/', 1)[0]) for verb, target, _ in self.actions)
+        logger.info("\nPlan summary:")
+        if not counts:
+            logger.info("  Nothing to do")
+        for (verb, kind), count in counts.items():
+            logger.info(f"  {verb:<16} {kind:<28} {count}")
+        logger.info("=" * 80)
+        logger.info("Dry run: nothing was created, changed or deleted. Rerun without --dry-run to execute.")
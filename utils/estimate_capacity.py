@@ -0,0 +1,571 @@
+#!/usr/bin/env python3
+"""
+Capacity estimation for KubeVirt benchmark runs.
+
+Before a large run, estimates whether the planned VMs can fit on the cluster:
+
+- per VM: the CPU and memory the virt-launcher pod requests (taken from the VM
+  template, plus an approximate virt-launcher memory overhead) and the storage
+  its DataVolume templates request
+- free: each schedulable node's allocatable CPU and memory minus the requests
+  of the pods already running on it, and the free space of the storage pool
+  behind the storage class (Portworx global storage pool, or the
+  CSIStorageCapacity objects the CSI driver publishes)
+
+Hugepages-backed VMs (domain.memory.hugepages) take their guest memory from
+the node's preallocated hugepages of that size (allocatable hugepages-2Mi or
+hugepages-1Gi) instead of its memory; only the overhead counts as memory.
+VMs referencing an instancetype (spec.instancetype) take their vCPUs, guest
+memory and hugepages from it.
+
+CPU, memory and hugepages are checked per node, so a run whose VMs do not fit
+on any node fails even if the cluster total looks large enough. Storage is only warned
+about: DataVolume clones and thin pools usually consume far less than they
+request.
+
+Exit codes:
+    0: the run fits (possibly with a warning), or nodes cannot be listed
+    1: the run cannot fit
+
+Usage:
+    python3 estimate_capacity.py --vm-template examples/vm-templates/rhel9-vm-datasource.yaml --vms 200
+    python3 estimate_capacity.py --vm-template vm.yaml --vms 50 --node worker-1 --storage-class YOUR-STORAGE-CLASS
+"""
+
+import argparse
+import json
+import logging
+import math
+import os
+import re
+import sys
+from datetime import datetime, timezone
+from typing import Dict, List, Optional
+
+import yaml
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
+
+from utils.common import setup_logging, run_kubectl_command, parse_quantity_bytes
+from utils.inventory import PORTWORX_PROVISIONERS
+from utils.portworx import find_px_pod, run_pxctl
+from utils.instancetype import get_object, CLUSTER_INSTANCETYPE
+from utils.output import emit
+
+# Approximate memory virt-launcher adds on top of the guest (QEMU, libvirt, page tables)
+DEFAULT_OVERHEAD_MI = 256
+# KubeVirt requests 1/cpuAllocationRatio of a core per vCPU when the VM sets no CPU request
+DEFAULT_CPU_ALLOCATION_RATIO = 10
+# Warn when the run leaves less than this share of the free capacity unused
+DEFAULT_HEADROOM_PCT = 10
+
+FIT = 'fit'
+TIGHT = 'tight'
+NO_FIT = 'no-fit'
+UNKNOWN = 'unknown'
+
+SCHEDULABLE_LABEL = 'kubevirt.io/schedulable'
+GIB = 1024 ** 3
+HUGEPAGES_PREFIX = 'hugepages-'
+
+
+def parse_cpu_millicores(quantity) -> Optional[int]:
+    """Convert a Kubernetes CPU quantity (e.g. "2", "500m", "0.5") to millicores."""
+    quantity = str(quantity or '').strip()
+    try:
+        if quantity.endswith('m'):
+            return int(float(quantity[:-1]))
+        return int(float(quantity) * 1000)
+    except ValueError:
+        return None
+
+
+def _get_json(args: List[str], logger: logging.Logger) -> Optional[Dict]:
+    returncode, stdout, _ = run_kubectl_command(args + ['-o', 'json'], check=False, logger=logger)
+    if returncode != 0:
+        return None
+    try:
+        return json.loads(stdout)
+    except json.JSONDecodeError:
+        return None
+
+
+def get_cpu_allocation_ratio(logger: logging.Logger) -> int:
+    """cpuAllocationRatio from the KubeVirt CR, or KubeVirt's default."""
+    for kv in (_get_json(['get', 'kubevirt', '-A'], logger) or {}).get('items', []):
+        ratio = kv.get('spec', {}).get('configuration', {}) \
+            .get('developerConfiguration', {}).get('cpuAllocationRatio')
+        if ratio:
+            return int(ratio)
+    return DEFAULT_CPU_ALLOCATION_RATIO
+
+
+def vm_requests(vm_template: str, cpu_allocation_ratio: int = DEFAULT_CPU_ALLOCATION_RATIO,
+                overhead_mi: int = DEFAULT_OVERHEAD_MI, instancetype: Optional[Dict] = None) -> Dict:
+    """
+    Resources one VM created from the template requests.
+
+    Args:
+        vm_template: VM template path
+        cpu_allocation_ratio: KubeVirt's cpuAllocationRatio
+        overhead_mi: Approximate virt-launcher memory overhead
+        instancetype: Spec of the instancetype the VM references, which sets
+            its vCPUs and guest memory
+
+    Returns:
+        {'cpu_m', 'memory_bytes', 'storage_bytes', 'storage_class', 'tolerations',
+         'hugepages_size', 'hugepages_bytes', 'instancetype'}; hugepages_size is None
+        without hugepages, instancetype the VM's spec.instancetype reference, if any
+    """
+    with open(vm_template, 'r') as f:
+        # Templates may still contain {{PLACEHOLDERS}}, which are not valid YAML values
+        content = re.sub(r'\{\{\s*\w+\s*\}\}', 'placeholder', f.read())
+    vm = next((doc for doc in yaml.safe_load_all(content)
+               if doc and doc.get('kind') == 'VirtualMachine'), None)
+    if not vm:
+        raise ValueError(f"No VirtualMachine found in {vm_template}")
+
+    vmi_spec = vm.get('spec', {}).get('template', {}).get('spec', {})
+    domain = vmi_spec.get('domain', {})
+    requests = domain.get('resources', {}).get('requests', {})
+    cpu = domain.get('cpu', {})
+    memory_spec = domain.get('memory', {})
+    if instancetype:
+        cpu = {'cores': (instancetype.get('cpu') or {}).get('guest', 1),
+               'dedicatedCpuPlacement': (instancetype.get('cpu') or {}).get('dedicatedCPUPlacement')}
+        memory_spec = instancetype.get('memory') or {}
+
+    if requests.get('cpu'):
+        cpu_m = parse_cpu_millicores(requests['cpu'])
+    else:
+        vcpus = cpu.get('cores', 1) * cpu.get('sockets', 1) * cpu.get('threads', 1)
+        if cpu.get('dedicatedCpuPlacement'):
+            cpu_m = vcpus * 1000
+        else:
+            cpu_m = math.ceil(vcpus * 1000 / cpu_allocation_ratio)
+
+    memory = requests.get('memory') or memory_spec.get('guest')
+    memory_bytes = (parse_quantity_bytes(memory) or 0) + overhead_mi * 1024 ** 2
+    # Hugepages-backed guest memory is requested as hugepages-<size>; only the overhead is memory
+    hugepages_size = (memory_spec.get('hugepages') or {}).get('pageSize')
+    hugepages_bytes = 0
+    if hugepages_size:
+        hugepages_bytes = parse_quantity_bytes(memory) or 0
+        memory_bytes = overhead_mi * 1024 ** 2
+
+    storage_bytes = 0
+    storage_class = None
+    for dvt in vm.get('spec', {}).get('dataVolumeTemplates', []):
+        spec = dvt.get('spec', {})
+        storage = spec.get('storage') or spec.get('pvc') or {}
+        storage_bytes += parse_quantity_bytes(
+            storage.get('resources', {}).get('requests', {}).get('storage')) or 0
+        if storage.get('storageClassName') not in (None, 'placeholder'):
+            storage_class = storage_class or storage['storageClassName']
+
+    return {
+        'cpu_m': cpu_m or 0,
+        'memory_bytes': memory_bytes,
+        'storage_bytes': storage_bytes,
+        'storage_class': storage_class,
+        'tolerations': [t.get('key') for t in vmi_spec.get('tolerations', []) if t.get('key')],
+        'hugepages_size': hugepages_size,
+        'hugepages_bytes': hugepages_bytes,
+        'instancetype': vm.get('spec', {}).get('instancetype'),
+    }
+
+
+def _pod_requests(pod: Dict) -> Dict:
+    cpu_m = memory_bytes = 0
+    hugepages: Dict[str, int] = {}
+    for container in pod.get('spec', {}).get('containers', []):
+        requests = container.get('resources', {}).get('requests', {})
+        cpu_m += parse_cpu_millicores(requests.get('cpu', 0)) or 0
+        memory_bytes += parse_quantity_bytes(requests.get('memory', 0)) or 0
+        for key, value in requests.items():
+            if key.startswith(HUGEPAGES_PREFIX):
+                size = key[len(HUGEPAGES_PREFIX):]
+                hugepages[size] = hugepages.get(size, 0) + (parse_quantity_bytes(value) or 0)
+    overhead = pod.get('spec', {}).get('overhead') or {}
+    cpu_m += parse_cpu_millicores(overhead.get('cpu', 0)) or 0
+    memory_bytes += parse_quantity_bytes(overhead.get('memory', 0)) or 0
+    return {'cpu_m': cpu_m, 'memory_bytes': memory_bytes, 'hugepages': hugepages}
+
+
+def node_free_resources(logger: logging.Logger, node_name: Optional[str] = None,
+                        tolerations: Optional[List[str]] = None) -> List[Dict]:
+    """
+    Free CPU, memory and hugepages on each node VMs can be scheduled to.
+
+    A node counts if it is Ready, not cordoned, not marked unschedulable for
+    VMs by virt-handler and has no NoSchedule/NoExecute taint other than the
+    tolerated keys. Free is allocatable minus the requests of the pods running
+    on the node.
+
+    Returns:
+        [{'name', 'cpu_m', 'memory_bytes', 'hugepages': {size: {'allocatable', 'free'}}}],
+        or None if nodes cannot be listed
+    """
+    nodes = _get_json(['get', 'nodes'], logger)
+    if nodes is None:
+        return None
+    pods = (_get_json(['get', 'pods', '-A', '--field-selector',
+                       'status.phase!=Succeeded,status.phase!=Failed'], logger) or {}).get('items', [])
+
+    used: Dict[str, Dict[str, int]] = {}
+    for pod in pods:
+        node = pod.get('spec', {}).get('nodeName')
+        if not node:
+            continue
+        requests = _pod_requests(pod)
+        totals = used.setdefault(node, {'cpu_m': 0, 'memory_bytes': 0, 'hugepages': {}})
+        totals['cpu_m'] += requests['cpu_m']
+        totals['memory_bytes'] += requests['memory_bytes']
+        for size, size_bytes in requests['hugepages'].items():
+            totals['hugepages'][size] = totals['hugepages'].get(size, 0) + size_bytes
+
+    free = []
+    for node in nodes.get('items', []):
+        name = node.get('metadata', {}).get('name')
+        if node_name and name != node_name:
+            continue
+        ready = any(c.get('type') == 'Ready' and c.get('status') == 'True'
+                    for c in node.get('status', {}).get('conditions', []))
+        if not ready or node.get('spec', {}).get('unschedulable'):
+            continue
+        if node.get('metadata', {}).get('labels', {}).get(SCHEDULABLE_LABEL) == 'false':
+            continue
+        if any(t.get('effect') in ('NoSchedule', 'NoExecute') and t.get('key') not in (tolerations or [])
+               for t in node.get('spec', {}).get('taints', [])):
+            continue
+        allocatable = node.get('status', {}).get('allocatable', {})
+        node_used = used.get(name, {'cpu_m': 0, 'memory_bytes': 0, 'hugepages': {}})
+        hugepages = {}
+        for key, value in allocatable.items():
+            if key.startswith(HUGEPAGES_PREFIX):
+                size = key[len(HUGEPAGES_PREFIX):]
+                size_bytes = parse_quantity_bytes(value) or 0
+                hugepages[size] = {'allocatable': size_bytes,
+                                   'free': max(0, size_bytes - node_used['hugepages'].get(size, 0))}
+        free.append({
+            'name': name,
+            'cpu_m': max(0, (parse_cpu_millicores(allocatable.get('cpu')) or 0) - node_used['cpu_m']),
+            'memory_bytes': max(0, (parse_quantity_bytes(allocatable.get('memory')) or 0)
+                                - node_used['memory_bytes']),
+            'hugepages': hugepages,
+        })
+    return free
+
+
+def _portworx_free_bytes(logger: logging.Logger) -> Optional[int]:
+    """Free space of the Portworx global storage pool from `pxctl status`."""
+    pod = find_px_pod(logger=logger)
+    if not pod:
+        return None
+    output = run_pxctl(pod, ['status'], logger=logger)
+    if not output:
+        return None
+    sizes = {}
+    for key in ('Total Used', 'Total Capacity'):
+        match = re.search(rf'{key}\s*:\s*([0-9.]+)\s*([KMGT]i)?B', output)
+        if match:
+            sizes[key] = parse_quantity_bytes(match.group(1) + (match.group(2) or ''))
+    if sizes.get('Total Capacity') is None or sizes.get('Total Used') is None:
+        return None
+    return sizes['Total Capacity'] - sizes['Total Used']
+
+
+def storage_free_bytes(storage_class: str, logger: logging.Logger) -> Dict:
+    """
+    Free space behind a storage class.
+
+    Returns:
+        {'free_bytes': int or None, 'source': where the figure came from}
+    """
+    sc = _get_json(['get', 'storageclass', storage_class], logger)
+    if not sc:
+        return {'free_bytes': None, 'source': f"storage class {storage_class} not found"}
+
+    if sc.get('provisioner') in PORTWORX_PROVISIONERS:
+        free = _portworx_free_bytes(logger)
+        if free is not None:
+            return {'free_bytes': free, 'source': 'Portworx global storage pool'}
+
+    capacities = [
+        parse_quantity_bytes(c.get('capacity')) or 0
+        for c in (_get_json(['get', 'csistoragecapacities', '-A'], logger) or {}).get('items', [])
+        if c.get('storageClassName') == storage_class
+    ]
+    if capacities:
+        return {'free_bytes': sum(capacities), 'source': 'CSIStorageCapacity'}
+    return {'free_bytes': None, 'source': 'not reported by the storage driver'}
+
+
+def hugepages_report(per_vm: Dict, nodes: Optional[List[Dict]], vm_count: int) -> Dict:
+    """
+    Hugepages of the VMs' page size per node: free, VMs that fit, and the
+    shortfall against an even share of the VMs.
+
+    Returns:
+        {'page_size', 'per_vm', 'vms_that_fit', 'shortfall_vms',
+         'nodes': [{'name', 'allocatable', 'free', 'vms_that_fit', 'shortfall_bytes'}]}
+    """
+    size, per_vm_bytes = per_vm['hugepages_size'], per_vm['hugepages_bytes']
+    share = math.ceil(vm_count / len(nodes)) if nodes else vm_count
+    rows = []
+    for node in nodes or []:
+        pages = node['hugepages'].get(size, {'allocatable': 0, 'free': 0})
+        rows.append({
+            'name': node['name'],
+            'allocatable': pages['allocatable'],
+            'free': pages['free'],
+            'vms_that_fit': pages['free'] // per_vm_bytes if per_vm_bytes else vm_count,
+            'shortfall_bytes': max(0, share * per_vm_bytes - pages['free']),
+        })
+    vms_that_fit = sum(row['vms_that_fit'] for row in rows) if nodes is not None else None
+    return {
+        'page_size': size,
+        'per_vm': per_vm_bytes,
+        'vms_that_fit': vms_that_fit,
+        'shortfall_vms': max(0, vm_count - vms_that_fit) if vms_that_fit is not None else None,
+        'nodes': rows,
+    }
+
+
+def estimate_capacity(vm_template: str, vm_count: int, logger: logging.Logger,
+                      storage_class: Optional[str] = None, node_name: Optional[str] = None,
+                      headroom_pct: float = DEFAULT_HEADROOM_PCT,
+                      overhead_mi: int = DEFAULT_OVERHEAD_MI) -> Dict:
+    """
+    Estimate whether vm_count VMs from the template fit on the cluster.
+
+    With node_name, all VMs must fit on that node (single-node runs).
+
+    Returns:
+        Estimate dict with 'status' (fit, tight, no-fit, or unknown when
+        nodes cannot be listed, e.g. as a namespace-scoped user), 'per_vm',
+        'resources' (per-resource planned/free/status), 'vms_that_fit',
+        'storage_source', 'messages' and, for hugepages-backed VMs,
+        'hugepages' (hugepages_report())
+    """
+    cpu_allocation_ratio = get_cpu_allocation_ratio(logger)
+    per_vm = vm_requests(vm_template, cpu_allocation_ratio, overhead_mi)
+    messages = []
+    if per_vm['instancetype']:
+        ref = per_vm['instancetype']
+        instancetype = get_object(ref.get('kind') or CLUSTER_INSTANCETYPE, ref.get('name'), logger)
+        if instancetype is None:
+            messages.append(f"Cannot read instancetype {ref.get('name')}; CPU and memory are the template's")
+        else:
+            per_vm = vm_requests(vm_template, cpu_allocation_ratio, overhead_mi, instancetype.get('spec') or {})
+    storage_class = storage_class or per_vm['storage_class']
+    nodes = node_free_resources(logger, node_name, per_vm['tolerations'])
+    if nodes is None:
+        messages.append("Cannot list nodes; CPU and memory were not checked")
+    hugepage_size = per_vm['hugepages_size']
+    keys = ('cpu_m', 'memory_bytes', 'hugepages_bytes') if hugepage_size else ('cpu_m', 'memory_bytes')
+    for node in (nodes or []) if hugepage_size else []:
+        node['hugepages_bytes'] = node['hugepages'].get(hugepage_size, {}).get('free', 0)
+
+    # Place VMs node by node: fragmentation can leave large totals but no room for a VM
+    vms_that_fit = 0
+    for node in nodes or []:
+        fits = [node[key] // per_vm[key] for key in keys if per_vm[key]]
+        vms_that_fit += min(fits) if fits else vm_count
+    if nodes == []:
+        messages.append(f"No schedulable node{' ' + node_name if node_name else 's'} found")
+
+    hugepages = hugepages_report(per_vm, nodes, vm_count) if hugepage_size else None
+    page_bytes = parse_quantity_bytes(hugepage_size) if hugepage_size else None
+    misaligned = bool(page_bytes and per_vm['hugepages_bytes'] % page_bytes)
+    if misaligned:
+        messages.append(f"Guest memory of {per_vm['hugepages_bytes']} bytes is not a multiple of the "
+                        f"{hugepage_size} hugepage size; KubeVirt rejects such VMs")
+
+    resources = {}
+    for key in keys:
+        planned = per_vm[key] * vm_count
+        free = sum(node[key] for node in nodes) if nodes is not None else None
+        resources[key] = {'per_vm': per_vm[key], 'planned': planned, 'free': free}
+
+    storage = storage_free_bytes(storage_class, logger) if storage_class \
+        else {'free_bytes': None, 'source': 'no storage class given'}
+    if per_vm['storage_bytes']:
+        resources['storage_bytes'] = {
+            'per_vm': per_vm['storage_bytes'],
+            'planned': per_vm['storage_bytes'] * vm_count,
+            'free': storage['free_bytes'],
+        }
+
+    for key, res in resources.items():
+        if res['free'] is None:
+            res['status'] = 'unknown'
+        elif res['planned'] > res['free']:
+            res['status'] = 'over'
+        elif res['planned'] > res['free'] * (1 - headroom_pct / 100):
+            res['status'] = 'tight'
+        else:
+            res['status'] = 'ok'
+
+    status = FIT
+    if nodes is None:
+        status = UNKNOWN
+    elif vms_that_fit < vm_count or misaligned:
+        status = NO_FIT
+        if vms_that_fit < vm_count:
+            messages.append(f"Only {vms_that_fit} of {vm_count} VMs fit on the "
+                            f"{'node' if node_name else 'schedulable nodes'} "
+                            f"(CPU/memory{'/hugepages' if hugepage_size else ''})")
+        if hugepages and hugepages['shortfall_vms']:
+            messages.append(f"The free hugepages-{hugepage_size} hold only {hugepages['vms_that_fit']} VMs; "
+                            f"see the per-node shortfall")
+    elif any(res['status'] == 'tight' for res in resources.values()):
+        status = TIGHT
+        messages.append(f"The run leaves less than {headroom_pct:g}% of free capacity unused")
+    if resources.get('storage_bytes', {}).get('status') == 'over':
+        if status == FIT:
+            status = TIGHT
+        messages.append("Requested storage exceeds free space; thin provisioning and clones "
+                        "may still fit")
+
+    return {
+        'generated_at': datetime.now(timezone.utc).strftime('%Y-%m-%dT%H:%M:%SZ'),
+        'vm_template': vm_template,
+        'vms': vm_count,
+        'node': node_name,
+        'schedulable_nodes': len(nodes) if nodes is not None else None,
+        'storage_class': storage_class,
+        'storage_source': storage['source'],
+        'per_vm': per_vm,
+        'resources': resources,
+        'vms_that_fit': vms_that_fit if nodes is not None else None,
+        'hugepages': hugepages,
+        'status': status,
+        'messages': messages,
+    }
+
+
+def _format(key: str, value: Optional[int]) -> str:
+    if value is None:
+        return '-'
+    if key == 'cpu_m':
+        return f"{value / 1000:.1f} cores"
+    return f"{value / GIB:.1f} GiB"
+
+
+def print_estimate(estimate: Dict, logger: logging.Logger):
+    """Log the estimate as a table"""
+    labels = {'cpu_m': 'CPU', 'memory_bytes': 'Memory', 'hugepages_bytes': 'Hugepages', 'storage_bytes': 'Storage'}
+    logger.info("\n" + "=" * 80)
+    logger.info(f"CAPACITY ESTIMATE: {estimate['vms']} VMs"
+                + (f" on node {estimate['node']}" if estimate['node'] else
+                   f" on {estimate['schedulable_nodes']} schedulable nodes"
+                   if estimate['schedulable_nodes'] is not None else ''))
+    logger.info("=" * 80)
+    logger.info(f"{'Resource':<10} {'Per VM':>14} {'Planned':>14} {'Free':>14}  Status")
+    logger.info("-" * 80)
+    for key, res in estimate['resources'].items():
+        logger.info(f"{labels[key]:<10} {_format(key, res['per_vm']):>14} "
+                    f"{_format(key, res['planned']):>14} {_format(key, res['free']):>14}  {res['status']}")
+    logger.info("-" * 80)
+    if estimate['vms_that_fit'] is not None:
+        logger.info(f"VMs that fit (CPU/memory, per node): {estimate['vms_that_fit']}")
+    hugepages = estimate.get('hugepages')
+    if hugepages and hugepages['nodes']:
+        logger.info(f"Hugepages {hugepages['page_size']} per node ({_format('', hugepages['per_vm'])} per VM):")
+        logger.info(f"  {'Node':<32} {'Allocatable':>12} {'Free':>12} {'VMs':>6} {'Shortfall':>12}")
+        for node in hugepages['nodes']:
+            logger.info(f"  {node['name']:<32} {_format('', node['allocatable']):>12} "
+                        f"{_format('', node['free']):>12} {node['vms_that_fit']:>6} "
+                        f"{_format('', node['shortfall_bytes']) if node['shortfall_bytes'] else '-':>12}")
+    if 'storage_bytes' in estimate['resources']:
+        logger.info(f"Storage free space: {estimate['storage_source']}"
+                    + (f" ({estimate['storage_class']})" if estimate['storage_class'] else ''))
+    for message in estimate['messages']:
+        (logger.error if estimate['status'] == NO_FIT else logger.warning)(f"  {message}")
+    logger.info("=" * 80)
+    if estimate['status'] == NO_FIT:
+        logger.error("✗ The planned run cannot fit on the cluster")
+    elif estimate['status'] == TIGHT:
+        logger.warning("⚠ The planned run fits, but with little headroom")
+    elif estimate['status'] == UNKNOWN:
+        logger.warning("⚠ Cannot tell whether the planned run fits on the cluster")
+    else:
+        logger.info("✓ The planned run fits on the cluster")
+
+
+def parse_args():
+    """Parse command line arguments"""
+    parser = argparse.ArgumentParser(
+        description='Estimate whether a planned VM count fits on the cluster',
+        formatter_class=argparse.RawDescriptionHelpFormatter,
+        epilog="""
+Examples:
+  # Can 200 VMs from the default template run on this cluster?
+  %(prog)s --vm-template examples/vm-templates/rhel9-vm-datasource.yaml --vms 200
+
+  # Single-node run with an explicit storage class
+  %(prog)s --vm-template vm.yaml --vms 50 --node worker-1 --storage-class YOUR-STORAGE-CLASS
+        """
+    )
+    parser.add_argument('--vm-template', required=True, help='VM template YAML the run creates VMs from')
+    parser.add_argument('--vms', type=int, required=True, help='Number of VMs the run creates')
+    parser.add_argument('--storage-class', help='Storage class of the VM disks (default: from the template)')
+    parser.add_argument('--node', help='Node all VMs are scheduled on (single-node runs)')
+    parser.add_argument(
+        '--headroom',
+        type=float,
+        default=DEFAULT_HEADROOM_PCT,
+        help=f'Warn when the run leaves less than this percentage of free capacity (default: {DEFAULT_HEADROOM_PCT})'
+    )
+    parser.add_argument(
+        '--overhead-mi',
+        type=int,
+        default=DEFAULT_OVERHEAD_MI,
+        help=f'Approximate virt-launcher memory overhead per VM in MiB (default: {DEFAULT_OVERHEAD_MI})'
+    )
+    parser.add_argument('--report', help='Write the estimate as JSON to this file')
+    parser.add_argument(
+        '--log-level',
+        type=str,
+        default='INFO',
+        choices=['DEBUG', 'INFO', 'WARNING', 'ERROR'],
+        help='Logging level (default: INFO)'
+    )
+    parser.add_argument('--kubeconfig', type=str, help='Path to kubeconfig file')
+    return parser.parse_args()
+
+
+def main():
+    """Main execution function"""
+    args = parse_args()
+
+    if args.kubeconfig:
+        os.environ['KUBECONFIG'] = args.kubeconfig
+
+    logger = setup_logging(log_file=None, log_level=args.log_level)
+
+    try:
+        estimate = estimate_capacity(args.vm_template, args.vms, logger, args.storage_class,
+                                     args.node, args.headroom, args.overhead_mi)
+    except (OSError, ValueError, yaml.YAMLError) as e:
+        logger.error(f"Cannot read VM template: {e}")
+        sys.exit(1)
+
+    print_estimate(estimate, logger)
+
+    if args.report:
+        report_dir = os.path.dirname(args.report)
+        if report_dir:
+            os.makedirs(report_dir, exist_ok=True)
+        with open(args.report, 'w') as f:
+            json.dump(estimate, f, indent=2)
+        logger.info(f"Capacity estimate written to {args.report}")
+    emit('capacity-estimate', estimate)
+
+    sys.exit(1 if estimate['status'] == NO_FIT else 0)
+
+
+if __name__ == '__main__':
+    main()
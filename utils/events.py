@@ -0,0 +1,270 @@
+#!/usr/bin/env python3
+"""
+Kubernetes event capture for KubeVirt performance testing.
+
+Outlier timings usually have a cause the cluster already reported as an
+event: a VM launcher pod that could not be scheduled, an image pull in
+back-off, a PVC that failed to attach, an eviction or an OOM kill. Events
+expire after an hour, so by the time a slow run is looked at they are gone.
+
+After argument parsing a workload calls watch_events(), which watches
+Events in the benchmark namespaces (by namespace prefix) and of nodes in
+the background for the rest of the run. When results are saved,
+collect_run_events() writes them to ``events.json`` in the results folder
+and returns the anomaly summary embedded in the result summary under
+``events``: counts by type and reason, the anomalous reasons (see
+ANOMALY_REASONS) with the objects they hit, and per outlier the anomalies of
+its namespace.
+
+Capture is on by default; ``virtbench --no-events`` (VIRTBENCH_EVENTS=0)
+turns it off. Dry runs capture nothing. Like the cluster inventory, capture
+is best effort and never fails the run.
+"""
+
+import atexit
+import json
+import logging
+import os
+import subprocess
+import threading
+from collections import Counter
+from typing import Dict, List, Optional
+
+from utils.base import is_dry_run
+
+EVENTS_ENV = 'VIRTBENCH_EVENTS'
+EVENTS_FILE = 'events.json'
+
+# Reasons worth explaining an outlier with, by category
+ANOMALY_REASONS = {
+    'FailedScheduling': 'scheduling',
+    'BackOff': 'backoff',
+    'CrashLoopBackOff': 'backoff',
+    'Evicted': 'eviction',
+    'Evicting': 'eviction',
+    'EvictionThresholdMet': 'eviction',
+    'Preempted': 'eviction',
+    'OOMKilling': 'oom',
+    'OOMKilled': 'oom',
+    'SystemOOM': 'oom',
+    'FailedMount': 'storage',
+    'FailedAttachVolume': 'storage',
+    'ProvisioningFailed': 'storage',
+    'FailedBinding': 'storage',
+    'FailedCreate': 'create',
+    'FailedCreatePodSandBox': 'create',
+    'ErrImagePull': 'image',
+    'Failed': 'failed',
+    'FailedMigration': 'migration',
+    'Unhealthy': 'probe',
+    'NodeNotReady': 'node',
+    'NodeHasDiskPressure': 'node',
+    'NodeHasMemoryPressure': 'node',
+}
+
+# Objects listed per anomalous reason in the summary (events.json has them all)
+MAX_OBJECTS = 10
+
+_watch = {'process': None, 'thread': None, 'prefix': None, 'events': {}, 'lock': threading.Lock()}
+
+
+def events_enabled() -> bool:
+    """Return True unless event capture was turned off (`virtbench --no-events`)."""
+    return os.environ.get(EVENTS_ENV, '1').lower() not in ('0', 'false', 'no')
+
+
+def event_record(event: Dict) -> Dict:
+    """Compact form of an Event (core/v1) as saved in events.json."""
+    involved = event.get('involvedObject') or {}
+    source = event.get('source') or {}
+    return {
+        'time': event.get('lastTimestamp') or event.get('eventTime')
+        or (event.get('metadata') or {}).get('creationTimestamp'),
+        'first_time': event.get('firstTimestamp'),
+        'type': event.get('type'),
+        'reason': event.get('reason'),
+        'namespace': involved.get('namespace'),
+        'kind': involved.get('kind'),
+        'name': involved.get('name'),
+        'message': (event.get('message') or '').strip(),
+        'count': event.get('count') or (event.get('series') or {}).get('count') or 1,
+        'source': source.get('host') or source.get('component') or event.get('reportingComponent'),
+    }
+
+
+def is_run_event(record: Dict, namespace_prefix: Optional[str]) -> bool:
+    """Events of the benchmark namespaces and of nodes."""
+    if record['kind'] == 'Node':
+        return True
+    return bool(namespace_prefix and (record['namespace'] or '').startswith(namespace_prefix))
+
+
+def _add_event(event: Dict):
+    record = event_record(event)
+    if not is_run_event(record, _watch['prefix']):
+        return
+    uid = (event.get('metadata') or {}).get('uid') or json.dumps(record, sort_keys=True)
+    with _watch['lock']:
+        # A repeated event is the same object with a higher count; keep the latest
+        _watch['events'][uid] = record
+
+
+def _read_events(process: subprocess.Popen, logger: Optional[logging.Logger]):
+    """Parse the stream of JSON objects of `kubectl get events --watch -o json`."""
+    decoder = json.JSONDecoder()
+    buffer = ''
+    for line in process.stdout:
+        buffer += line
+        while True:
+            buffer = buffer.lstrip()
+            if not buffer:
+                break
+            try:
+                event, end = decoder.raw_decode(buffer)
+            except json.JSONDecodeError:
+                break
+            buffer = buffer[end:]
+            if isinstance(event, dict):
+                _add_event(event)
+    if logger and process.poll() not in (None, 0, -15):
+        logger.debug(f"Event watch ended with exit code {process.returncode}")
+
+
+def watch_events(namespace_prefix: Optional[str], logger: Optional[logging.Logger] = None):
+    """
+    Start capturing the run's events in the background; stopped at exit.
+
+    Call once, right after watch_run(). Does nothing in dry runs or when
+    capture is turned off.
+
+    Args:
+        namespace_prefix: Prefix of the benchmark namespaces (node events are always captured)
+        logger: Logger instance
+    """
+    if is_dry_run() or not events_enabled() or _watch['process'] is not None:
+        return
+    try:
+        process = subprocess.Popen(['kubectl', 'get', 'events', '--all-namespaces', '--watch-only', '-o', 'json'],
+                                   stdout=subprocess.PIPE, stderr=subprocess.DEVNULL, text=True)
+    except OSError as e:
+        if logger:
+            logger.warning(f"Could not watch events: {e}")
+        return
+    _watch.update(process=process, prefix=namespace_prefix)
+    _watch['thread'] = threading.Thread(target=_read_events, args=(process, logger), daemon=True)
+    _watch['thread'].start()
+    atexit.register(stop_events)
+    if logger:
+        logger.debug(f"Watching events of namespaces {namespace_prefix}* and of nodes")
+
+
+def stop_events():
+    """Stop the event watch."""
+    process = _watch['process']
+    if process is not None and process.poll() is None:
+        process.terminate()
+        try:
+            process.wait(timeout=5)
+        except subprocess.TimeoutExpired:
+            process.kill()
+
+
+def captured_events() -> List[Dict]:
+    """The events captured so far, oldest first."""
+    with _watch['lock']:
+        records = list(_watch['events'].values())
+    return sorted(records, key=lambda r: r['time'] or '')
+
+
+def summarize_events(records: List[Dict], outliers: Optional[List[Dict]] = None) -> Dict:
+    """
+    Anomaly summary of captured events.
+
+    Args:
+        records: event_record() results
+        outliers: metric_outliers() results of the run; each one whose
+            namespace had anomalies gets an "events" entry of {reason: count}
+
+    Returns:
+        {'total', 'by_type', 'by_reason', 'anomalies': [{'reason', 'category', 'count',
+         'first', 'last', 'objects', 'affected_objects', 'message'}], 'file'}; the
+        type and reason counts include repeats of an event
+    """
+    by_type = Counter()
+    by_reason = Counter()
+    anomalies = {}
+    by_namespace = {}
+    for record in records:
+        reason = record['reason'] or 'Unknown'
+        by_type[record['type'] or 'Unknown'] += record['count']
+        by_reason[reason] += record['count']
+        if reason not in ANOMALY_REASONS:
+            continue
+        anomaly = anomalies.setdefault(reason, {
+            'reason': reason, 'category': ANOMALY_REASONS[reason], 'count': 0,
+            'first': record['first_time'] or record['time'], 'last': record['time'],
+            'objects': [], 'message': record['message'],
+        })
+        anomaly['count'] += record['count']
+        anomaly['last'] = max(anomaly['last'] or '', record['time'] or '') or None
+        target = '/'.join(part for part in (record['namespace'], record['name']) if part)
+        if target not in anomaly['objects']:
+            anomaly['objects'].append(target)
+        if record['namespace']:
+            counts = by_namespace.setdefault(record['namespace'], Counter())
+            counts[reason] += record['count']
+
+    for outlier in outliers or []:
+        # Items are named by namespace, e.g. "ns", "ns/vm" or "ns #2"
+        item = str(outlier.get('item') or '').replace('/', ' ').split()
+        counts = by_namespace.get(item[0]) if item else None
+        if counts:
+            outlier['events'] = dict(counts)
+
+    for anomaly in anomalies.values():
+        anomaly['affected_objects'] = len(anomaly['objects'])
+        anomaly['objects'] = anomaly['objects'][:MAX_OBJECTS]
+    return {
+        'total': len(records),
+        'by_type': dict(by_type),
+        'by_reason': dict(by_reason.most_common()),
+        'anomalies': sorted(anomalies.values(), key=lambda a: -a['count']),
+        'file': EVENTS_FILE,
+    }
+
+
+def collect_run_events(out_dir: Optional[str], logger: Optional[logging.Logger] = None,
+                       outliers: Optional[List[Dict]] = None) -> Optional[Dict]:
+    """
+    Save the captured events to events.json in out_dir and summarize them.
+
+    Returns:
+        summarize_events() result, or None when no events were watched
+    """
+    if _watch['process'] is None:
+        return None
+    records = captured_events()
+    if out_dir:
+        try:
+            with open(os.path.join(out_dir, EVENTS_FILE), 'w') as f:
+                json.dump(records, f, indent=2)
+        except OSError as e:
+            if logger:
+                logger.warning(f"Could not save events: {e}")
+    summary = summarize_events(records, outliers)
+    if logger:
+        print_event_anomalies(summary, logger)
+    return summary
+
+
+def print_event_anomalies(summary: Dict, logger: logging.Logger):
+    """Log the anomalies of a summarize_events() result."""
+    if not summary['anomalies']:
+        logger.info(f"Events: {summary['total']} captured, no anomalies")
+        return
+    logger.warning(f"Events: {summary['total']} captured; anomalies:")
+    for anomaly in summary['anomalies']:
+        example = anomaly['objects'][0] if anomaly['objects'] else '-'
+        message = f": {anomaly['message'][:100]}" if anomaly['message'] else ''
+        logger.warning(f"  {anomaly['reason']:<24}{anomaly['count']:>6}x  {anomaly['affected_objects']} object(s), "
+                       f"e.g. {example}{message}")
@@ -0,0 +1,473 @@
+#!/usr/bin/env python3
+"""
+Fault injection during creation workloads (chaos mix mode).
+
+failure-recovery measures how VMs recover from one injected failure, and
+datasource-clone measures provisioning latency on a healthy cluster. With
+``--chaos-mode`` datasource-clone composes the two: a FaultInjector runs in
+the background while VMs are created (or boot-stormed) and injects the
+failure every ``--chaos-interval`` seconds, rotating over the worker nodes:
+
+- kubelet-stop, network-partition: stop kubelet / drop API server and
+  kubelet traffic on the node for ``--chaos-duration`` seconds
+- reboot: reboot the node
+- storage-pod-kill: force-delete the storage provider pods on the node
+- storage-pod-pause: SIGSTOP the storage process on the node for
+  ``--chaos-duration`` seconds
+
+Node failures run through the same privileged host pods as failure-recovery
+(utils.common create_node_exec_pod). Each fault is recorded as a window from
+injection until the node is Ready (node faults) or the storage pods on it
+are Ready again (storage faults). On Portworx, each fault is first checked
+against KVDB quorum like failure-recovery's --track-kvdb: a fault on a node
+whose KVDB member is needed for quorum is skipped unless quorum loss is
+allowed, and KVDB health is sampled (utils.portworx KvdbMonitor) while the
+fault lasts to time the KVDB failover. chaos_summary() tags each VM whose
+measured interval overlaps a window as ``under_failure`` and compares its
+latencies with those of the VMs that ran without a failure.
+"""
+
+import json
+import logging
+import threading
+import time
+from typing import Dict, List, Optional, Tuple
+
+from utils import timing
+from utils.common import (
+    create_node_exec_pod, delete_node_exec_pod, get_worker_nodes, is_node_ready,
+    run_kubectl_command, DEFAULT_NODE_EXEC_IMAGE,
+)
+from utils.portworx import KvdbMonitor, check_quorum_safe, find_px_pod
+from utils.stats import describe
+from utils.storageprovider import PortworxProvider, forced_provider
+
+NODE_FAILURE_MODES = ['kubelet-stop', 'reboot', 'network-partition']
+STORAGE_FAILURE_MODES = ['storage-pod-kill', 'storage-pod-pause']
+CHAOS_MODES = NODE_FAILURE_MODES + STORAGE_FAILURE_MODES
+
+DEFAULT_CHAOS_INTERVAL = 120
+DEFAULT_CHAOS_DURATION = 60
+DEFAULT_RECOVERY_TIMEOUT = 600
+# namespace:label-selector pairs for the storage provider pods (Portworx node pod, CSI driver)
+DEFAULT_STORAGE_PODS = ['portworx:name=portworx', 'portworx:app=px-csi-driver']
+DEFAULT_STORAGE_PROCESS = 'px-storage'
+DEFAULT_PARTITION_PORTS = [6443]
+POLL_INTERVAL = 5
+
+
+def build_injection_command(failure_mode: str, duration: int,
+                            partition_ports: List[int]) -> str:
+    """
+    Build the host shell command that injects a node failure.
+
+    kubelet-stop and network-partition restore the node by themselves after
+    `duration` seconds because the node cannot be reached to undo them.
+    """
+    if failure_mode == 'kubelet-stop':
+        return f"systemctl stop kubelet; sleep {duration}; systemctl start kubelet"
+
+    if failure_mode == 'reboot':
+        return "sleep 2; systemctl reboot"
+
+    if failure_mode == 'network-partition':
+        rules = [f"OUTPUT -p tcp --dport {port} -j DROP" for port in partition_ports]
+        rules.append("INPUT -p tcp --dport 10250 -j DROP")
+        add = '; '.join(f"iptables -I {rule}" for rule in rules)
+        remove = '; '.join(f"iptables -D {rule}" for rule in rules)
+        return f"{add}; sleep {duration}; {remove}"
+
+    raise ValueError(f"No host command for failure mode: {failure_mode}")
+
+
+def storage_pause_command(process: str, duration: int) -> str:
+    """Host shell command that pauses the storage process for `duration` seconds."""
+    # Bracket the first character so the pattern does not match this shell's own command line
+    pattern = f"[{process[0]}]{process[1:]}"
+    return f"pkill -STOP -f '{pattern}'; sleep {duration}; pkill -CONT -f '{pattern}'"
+
+
+def get_storage_pods(selectors: List[str], node_name: Optional[str],
+                     logger: Optional[logging.Logger] = None) -> List[Dict]:
+    """
+    List storage provider pods matching namespace:selector pairs.
+    When node_name is given, only pods scheduled on that node are returned.
+    Each pod is a dict with keys name, namespace, node and ready.
+    """
+    pods = []
+    for entry in selectors:
+        namespace, _, selector = entry.partition(':')
+        try:
+            returncode, output, stderr = run_kubectl_command(
+                ['get', 'pods', '-n', namespace, '-l', selector, '-o', 'json'],
+                check=False, timeout=60, logger=logger
+            )
+        except Exception as e:
+            returncode, output, stderr = 1, '', str(e)
+        if returncode != 0:
+            if logger:
+                logger.debug(f"Failed to list pods {entry}: {stderr}")
+            continue
+
+        try:
+            items = json.loads(output).get('items', [])
+        except json.JSONDecodeError:
+            continue
+
+        for pod in items:
+            node = pod.get('spec', {}).get('nodeName', '')
+            if node_name and node != node_name:
+                continue
+            ready = any(
+                c.get('type') == 'Ready' and c.get('status') == 'True'
+                for c in pod.get('status', {}).get('conditions', [])
+            )
+            pods.append({
+                'name': pod['metadata']['name'],
+                'namespace': namespace,
+                'node': node,
+                'ready': ready and not pod['metadata'].get('deletionTimestamp'),
+            })
+    return pods
+
+
+class FaultInjector:
+    """
+    Inject a failure every `interval` seconds in a background thread.
+
+    start() injects the first fault right away; stop() lets the fault in
+    progress recover, removes the host pods and returns the fault windows:
+    [{'mode', 'node', 'start', 'end', 'recovered', 'detail'}] with start/end
+    as utils.timing MonotonicTimestamps. On Portworx, each window also has
+    kvdb, the KvdbMonitor summary of the fault. Faults skipped to keep
+    Portworx KVDB quorum are counted in skipped.
+    """
+
+    def __init__(self, mode: str, interval: float = DEFAULT_CHAOS_INTERVAL,
+                 duration: int = DEFAULT_CHAOS_DURATION, nodes: Optional[List[str]] = None,
+                 storage_pods: Optional[List[str]] = None,
+                 storage_process: str = DEFAULT_STORAGE_PROCESS,
+                 image: str = DEFAULT_NODE_EXEC_IMAGE,
+                 partition_ports: Optional[List[int]] = None,
+                 recovery_timeout: int = DEFAULT_RECOVERY_TIMEOUT,
+                 allow_quorum_loss: bool = False,
+                 logger: Optional[logging.Logger] = None):
+        if mode not in CHAOS_MODES:
+            raise ValueError(f"Unknown chaos mode: {mode}")
+        self.mode = mode
+        self.interval = interval
+        self.duration = duration
+        self.nodes = list(nodes or [])
+        self.storage_pods = storage_pods or DEFAULT_STORAGE_PODS
+        self.storage_process = storage_process
+        self.image = image
+        self.partition_ports = partition_ports or DEFAULT_PARTITION_PORTS
+        self.recovery_timeout = recovery_timeout
+        self.allow_quorum_loss = allow_quorum_loss
+        self.logger = logger
+        self.windows: List[Dict] = []
+        self.skipped = 0
+        self._track_kvdb = False
+        self._pods: List[str] = []
+        self._stop = threading.Event()
+        self._thread: Optional[threading.Thread] = None
+
+    def start(self) -> bool:
+        """Start injecting; returns False if there is no node to inject on."""
+        if not self.nodes:
+            self.nodes = get_worker_nodes(self.logger)
+        if not self.nodes:
+            if self.logger:
+                self.logger.error("Chaos mode: no Ready worker nodes to inject failures on")
+            return False
+        self._track_kvdb = uses_portworx(self.logger)
+        if self.logger:
+            self.logger.info(f"Chaos mode: injecting {self.mode} every {self.interval}s "
+                             f"on {', '.join(self.nodes)}")
+        self._thread = threading.Thread(target=self._loop, name='fault-injector', daemon=True)
+        self._thread.start()
+        return True
+
+    def stop(self) -> List[Dict]:
+        """Stop injecting, wait for the fault in progress to recover and return the windows."""
+        self._stop.set()
+        if self._thread is not None:
+            self._thread.join()
+        for pod_name in self._pods:
+            delete_node_exec_pod(pod_name, 'default', self.logger)
+        self._pods = []
+        if self.logger:
+            self.logger.info(f"Chaos mode: {len(self.windows)} {self.mode} fault(s) injected")
+            if self.skipped:
+                self.logger.warning(f"Chaos mode: {self.skipped} fault(s) skipped to keep Portworx KVDB quorum")
+        return self.windows
+
+    def _loop(self):
+        index = 0
+        while not self._stop.is_set():
+            node = self.nodes[index % len(self.nodes)]
+            index += 1
+            started = time.monotonic()
+            try:
+                window = self._inject(node, index)
+            except Exception as e:
+                if self.logger:
+                    self.logger.error(f"Chaos mode: {self.mode} on {node} failed: {e}")
+                window = None
+            if window is not None:
+                self.windows.append(window)
+            self._stop.wait(max(0.0, self.interval - (time.monotonic() - started)))
+
+    def _inject(self, node: str, index: int) -> Optional[Dict]:
+        """Inject one fault on the node and wait for it to recover; returns its window."""
+        if self._track_kvdb and not self._quorum_safe(node):
+            self.skipped += 1
+            return None
+        monitor = self._start_kvdb_monitor(node)
+        try:
+            start = timing.now()
+            window = self._inject_fault(node, index, start)
+            if window is not None and monitor:
+                self._wait_kvdb_recovered(monitor)
+        finally:
+            if monitor:
+                monitor.stop()
+        if window is not None and monitor:
+            window['kvdb'] = monitor.summary(start)
+            if self.logger and window['kvdb']['failover_seconds'] is not None:
+                self.logger.info(f"Chaos mode: KVDB failover complete after "
+                                 f"{window['kvdb']['failover_seconds']:.1f}s")
+        return window
+
+    def _inject_fault(self, node: str, index: int, start: timing.MonotonicTimestamp) -> Optional[Dict]:
+        detail = None
+        if self.mode == 'storage-pod-kill':
+            killed = self._kill_storage_pods(node)
+            if not killed:
+                return None
+            detail = f"{len(killed)} pod(s) deleted"
+            recovered = self._wait_storage_pods(node, killed)
+        else:
+            if self.mode == 'storage-pod-pause':
+                command = storage_pause_command(self.storage_process, self.duration)
+            else:
+                command = build_injection_command(self.mode, self.duration, self.partition_ports)
+            pod_name = f"virtbench-chaos-{self.mode}-{index}-{int(time.time())}"
+            if not create_node_exec_pod(node, command, pod_name, 'default', self.image, self.logger):
+                return None
+            self._pods.append(pod_name)
+            if self.logger:
+                self.logger.info(f"Chaos mode: injected {self.mode} on {node}")
+            recovered = self._wait_recovered(node)
+        end = timing.now()
+        if self.logger:
+            state = 'recovered' if recovered else 'did not recover'
+            self.logger.info(f"Chaos mode: {node} {state} from {self.mode} after "
+                             f"{(end - start).total_seconds():.1f}s")
+        return {'mode': self.mode, 'node': node, 'start': start, 'end': end,
+                'recovered': recovered, 'detail': detail}
+
+    def _start_kvdb_monitor(self, node: str) -> Optional[KvdbMonitor]:
+        """On Portworx, start sampling KVDB health for a fault on the node."""
+        if not self._track_kvdb:
+            return None
+        monitor = KvdbMonitor(node, POLL_INTERVAL, self.logger)
+        if not monitor.start():
+            if self.logger:
+                self.logger.warning(f"Chaos mode: KVDB unreachable, not timing the KVDB failover on {node}")
+            return None
+        return monitor
+
+    def _wait_kvdb_recovered(self, monitor: KvdbMonitor):
+        """Keep sampling until the KVDB is back to its baseline healthy members, within the recovery timeout."""
+        deadline = time.monotonic() + self.recovery_timeout
+        while time.monotonic() < deadline:
+            known = [s for s in monitor.samples if s['reachable']]
+            if known and known[-1]['healthy'] >= monitor.baseline['healthy']:
+                return
+            time.sleep(POLL_INTERVAL)
+        if self.logger:
+            self.logger.warning(f"Chaos mode: KVDB did not return to {monitor.baseline['healthy']} healthy "
+                                f"member(s) within {self.recovery_timeout}s")
+
+    def _quorum_safe(self, node: str) -> bool:
+        """Whether a fault on the node may be injected without losing KVDB quorum."""
+        safe = check_quorum_safe(node, self.logger)
+        if safe is None:
+            if self.logger:
+                self.logger.warning("Chaos mode: could not determine Portworx KVDB quorum status")
+            return True
+        if not safe:
+            if self.allow_quorum_loss:
+                if self.logger:
+                    self.logger.warning(f"Chaos mode: {self.mode} on {node} will lose Portworx KVDB quorum "
+                                        f"(quorum loss allowed, continuing)")
+                return True
+            if self.logger:
+                self.logger.warning(f"Chaos mode: skipping {self.mode} on {node}, it would lose "
+                                    f"Portworx KVDB quorum")
+            return False
+        return True
+
+    def _kill_storage_pods(self, node: str) -> List[Dict]:
+        killed = []
+        for pod in get_storage_pods(self.storage_pods, node, self.logger):
+            returncode, _, stderr = run_kubectl_command(
+                ['delete', 'pod', pod['name'], '-n', pod['namespace'],
+                 '--grace-period=0', '--force', '--wait=false'],
+                check=False, logger=self.logger
+            )
+            if returncode == 0:
+                killed.append(pod)
+            elif self.logger:
+                self.logger.error(f"Chaos mode: failed to delete {pod['namespace']}/{pod['name']}: {stderr}")
+        if self.logger:
+            if killed:
+                self.logger.info(f"Chaos mode: deleted {len(killed)} storage pod(s) on {node}")
+            else:
+                self.logger.warning(f"Chaos mode: no storage pods matching {self.storage_pods} on {node}")
+        return killed
+
+    def _wait_storage_pods(self, node: str, killed: List[Dict]) -> bool:
+        """Wait until the node has as many Ready storage pods as were killed, none of them old."""
+        killed_names = {(p['namespace'], p['name']) for p in killed}
+        deadline = time.monotonic() + self.recovery_timeout
+        while time.monotonic() < deadline:
+            pods = get_storage_pods(self.storage_pods, node, self.logger)
+            current = [p for p in pods if (p['namespace'], p['name']) not in killed_names]
+            if len(current) >= len(killed) and all(p['ready'] for p in current) and len(current) == len(pods):
+                return True
+            time.sleep(POLL_INTERVAL)
+        return False
+
+    def _wait_recovered(self, node: str) -> bool:
+        """Wait out a host-pod fault: its duration, then (for node faults) the node turning Ready."""
+        if self.mode == 'reboot':
+            # Give the node time to go down before waiting for it to come back
+            deadline = time.monotonic() + self.recovery_timeout
+            while time.monotonic() < deadline and is_node_ready(node, self.logger):
+                time.sleep(POLL_INTERVAL)
+        else:
+            time.sleep(self.duration)
+        if self.mode == 'storage-pod-pause':
+            return True
+        deadline = time.monotonic() + self.recovery_timeout
+        while time.monotonic() < deadline:
+            if is_node_ready(node, self.logger):
+                return True
+            time.sleep(POLL_INTERVAL)
+        return False
+
+
+def uses_portworx(logger: Optional[logging.Logger] = None) -> bool:
+    """Whether the cluster's storage is Portworx: --storage-provider portworx, or a Portworx pod runs."""
+    forced = forced_provider()
+    if forced is not None:
+        return forced is PortworxProvider
+    return find_px_pod(logger=logger) is not None
+
+
+def vm_interval(result: Tuple, start: timing.MonotonicTimestamp) -> Tuple[int, int]:
+    """Monotonic [start, end] (ns) over which a VM was measured: from start to its last milestone."""
+    elapsed = max([t for t in result[1:4] if t is not None], default=0)
+    return start.mono_ns, start.mono_ns + int(elapsed * 1e9)
+
+
+def chaos_summary(results: List[Tuple], start_times: Dict[str, timing.MonotonicTimestamp],
+                  windows: List[Dict], skip_clone: bool = False) -> Dict:
+    """
+    Compare the VMs measured during a fault window with the others.
+
+    Args:
+        results: Result tuples as passed to save_results
+        start_times: Namespace -> start timestamp of each VM
+        windows: FaultInjector.stop() result
+        skip_clone: If True, omit clone duration metrics
+
+    Returns:
+        Dict with under_failure (namespaces overlapping a window), faults
+        (the windows with RFC3339Nano times and duration_sec, plus kvdb on
+        Portworx) and metrics (per metric: under_failure and no_failure
+        statistics plus degradation_sec and degradation_pct, the difference
+        in averages). On Portworx, kvdb gives the statistics of the KVDB
+        failover times and the number of faults that lost quorum.
+    """
+    under_failure = set()
+    for result in results:
+        start = start_times.get(result[0])
+        if start is None:
+            continue
+        vm_start, vm_end = vm_interval(result, start)
+        if any(w['start'].mono_ns <= vm_end and vm_start <= w['end'].mono_ns for w in windows):
+            under_failure.add(result[0])
+
+    fields = [("running_time_sec", 1), ("ping_time_sec", 2)]
+    if not skip_clone:
+        fields.append(("clone_duration_sec", 3))
+
+    metrics = []
+    ok = [r for r in results if len(r) > 4 and r[4]]
+    for name, idx in fields:
+        hit = describe(r[idx] for r in ok if r[0] in under_failure)
+        clean = describe(r[idx] for r in ok if r[0] not in under_failure)
+        degradation = degradation_pct = None
+        if hit['count'] and clean['count']:
+            degradation = timing.round_duration(hit['avg'] - clean['avg'])
+            if clean['avg']:
+                degradation_pct = round(degradation / clean['avg'] * 100, 1)
+        metrics.append({"metric": name, "under_failure": hit, "no_failure": clean,
+                        "degradation_sec": degradation, "degradation_pct": degradation_pct})
+
+    failed = sum(1 for r in results if r[0] in under_failure and not (len(r) > 4 and r[4]))
+    tracked = [w['kvdb'] for w in windows if w.get('kvdb')]
+    kvdb = None
+    if tracked:
+        kvdb = {
+            "faults_tracked": len(tracked),
+            "quorum_lost": sum(1 for k in tracked if k['quorum_lost']),
+            "failover_sec": describe(k['failover_seconds'] for k in tracked),
+        }
+    return {
+        "under_failure": under_failure,
+        "vms_under_failure": len(under_failure),
+        "vms_no_failure": len(results) - len(under_failure),
+        "failed_under_failure": failed,
+        "faults": [{
+            "mode": w['mode'],
+            "node": w['node'],
+            "start": w['start'].rfc3339nano(),
+            "end": w['end'].rfc3339nano(),
+            "duration_sec": timing.round_duration((w['end'] - w['start']).total_seconds()),
+            "recovered": w['recovered'],
+            "detail": w['detail'],
+            "kvdb": w.get('kvdb'),
+        } for w in windows],
+        "metrics": metrics,
+        "kvdb": kvdb,
+    }
+
+
+def print_chaos_summary(analysis: Dict, logger: logging.Logger):
+    """Log the under-failure vs no-failure comparison from chaos_summary()."""
+    logger.info(f"Chaos mode: {len(analysis['faults'])} fault(s), {analysis['vms_under_failure']} VM(s) "
+                f"measured under failure ({analysis['failed_under_failure']} failed), "
+                f"{analysis['vms_no_failure']} without")
+    for m in analysis['metrics']:
+        hit, clean = m['under_failure'], m['no_failure']
+        if not hit['count'] and not clean['count']:
+            continue
+        label = m['metric'].replace('_sec', '').replace('_', ' ').capitalize()
+        hit_avg = f"{hit['avg']:.2f}s" if hit['avg'] is not None else "-"
+        clean_avg = f"{clean['avg']:.2f}s" if clean['avg'] is not None else "-"
+        degradation = ''
+        if m['degradation_sec'] is not None:
+            degradation = f" ({m['degradation_sec']:+.2f}s"
+            degradation += f", {m['degradation_pct']:+.1f}%)" if m['degradation_pct'] is not None else ")"
+        logger.info(f"  {label + ':':<24}under failure avg {hit_avg}, no failure avg {clean_avg}{degradation}")
+    kvdb = analysis.get('kvdb')
+    if kvdb:
+        failover = kvdb['failover_sec']
+        avg = f"{failover['avg']:.2f}s" if failover['avg'] is not None else "-"
+        top = f"{failover['max']:.2f}s" if failover['max'] is not None else "-"
+        logger.info(f"  {'KVDB failover:':<24}avg {avg}, max {top} over {failover['count']} of "
+                    f"{kvdb['faults_tracked']} fault(s); quorum lost in {kvdb['quorum_lost']}")
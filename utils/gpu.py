@@ -0,0 +1,190 @@
+#!/usr/bin/env python3
+"""
+GPU passthrough and vGPU VMs for KubeVirt performance testing.
+
+KubeVirt gives a VM GPUs through spec.template.spec.domain.devices.gpus. Each
+entry names the extended resource a device plugin advertises (deviceName):
+e.g. nvidia.com/GA102GL_A10 for a passed-through PCI GPU, or
+nvidia.com/NVIDIA_A10-12Q for a vGPU, which is a mediated device. A GPU VM
+only schedules when:
+
+- KubeVirt permits the device: the KubeVirt CR lists the resource under
+  spec.configuration.permittedHostDevices (pciHostDevices or mediatedDevices)
+- a device plugin advertises it: schedulable nodes report the resource in
+  status.allocatable (KubeVirt's device plugin, or the vendor's for devices
+  marked externalResourceProvider)
+- for vGPUs, the mediated devices exist: KubeVirt creates them from
+  spec.configuration.mediatedDevicesConfiguration, otherwise they must be
+  created on the nodes out of band
+
+check_gpus() checks all three, and whether the run's GPUs fit on the nodes'
+allocatable devices, so a GPU run fails before creating VMs that would stay
+in the Scheduling phase. GPU VM density is bounded by the devices per node,
+not by CPU or memory.
+
+    gpus = template_gpus(vm_template)            # {'nvidia.com/GA102GL_A10': 1}
+    report = check_gpus(gpus, vms=40, logger=logger)
+    print_gpu_report(report, logger)
+"""
+
+import json
+import logging
+import re
+from typing import Dict, List, Optional
+
+import yaml
+
+from utils.common import run_kubectl_command
+
+PCI = 'pci'
+MEDIATED = 'mediated'
+
+SCHEDULABLE_LABEL = 'kubevirt.io/schedulable'
+
+# Template placeholders such as {{STORAGE_CLASS_NAME}} (see examples/vm-templates/vm-template.yaml)
+PLACEHOLDER_RE = re.compile(r'\{\{\s*\w+\s*\}\}')
+
+
+def _get_json(args: List[str], logger: logging.Logger) -> Optional[Dict]:
+    returncode, stdout, _ = run_kubectl_command(args + ['-o', 'json'], check=False, logger=logger)
+    if returncode != 0:
+        return None
+    try:
+        return json.loads(stdout)
+    except json.JSONDecodeError:
+        return None
+
+
+def template_gpus(vm_template: str) -> Dict[str, int]:
+    """
+    GPUs one VM created from the template gets, by device name.
+
+    Raises:
+        OSError, yaml.YAMLError: If the template cannot be read
+    """
+    with open(vm_template, 'r') as f:
+        docs = list(yaml.safe_load_all(PLACEHOLDER_RE.sub('placeholder', f.read())))
+    gpus: Dict[str, int] = {}
+    for doc in docs:
+        if not doc or doc.get('kind') != 'VirtualMachine':
+            continue
+        devices = doc.get('spec', {}).get('template', {}).get('spec', {}).get('domain', {}).get('devices', {})
+        for gpu in devices.get('gpus') or []:
+            gpus[gpu.get('deviceName')] = gpus.get(gpu.get('deviceName'), 0) + 1
+    return gpus
+
+
+def add_gpus(template_spec: Dict, device_name: str, count: int = 1):
+    """Add count GPUs of device_name to a VM's spec.template.spec, after those it already has."""
+    gpus = template_spec.setdefault('domain', {}).setdefault('devices', {}).setdefault('gpus', [])
+    first = len(gpus) + 1
+    gpus.extend({'name': f'gpu{first + i}', 'deviceName': device_name} for i in range(count))
+
+
+def permitted_host_devices(logger: logging.Logger) -> Dict:
+    """
+    Host devices the KubeVirt CR permits.
+
+    Returns:
+        {'pci': {resource: externalResourceProvider}, 'mediated': {...},
+         'mediated_device_types': [types KubeVirt creates mediated devices of]}
+    """
+    kubevirt = ((_get_json(['get', 'kubevirt', '-A'], logger) or {}).get('items') or [{}])[0]
+    config = kubevirt.get('spec', {}).get('configuration', {})
+    permitted = config.get('permittedHostDevices') or {}
+    mdev_config = config.get('mediatedDevicesConfiguration') or {}
+    types = list(mdev_config.get('mediatedDeviceTypes') or mdev_config.get('mediatedDevicesTypes') or [])
+    for node_types in mdev_config.get('nodeMediatedDeviceTypes') or []:
+        types.extend(node_types.get('mediatedDeviceTypes') or node_types.get('mediatedDevicesTypes') or [])
+    return {
+        PCI: {d.get('resourceName'): bool(d.get('externalResourceProvider'))
+              for d in permitted.get('pciHostDevices') or []},
+        MEDIATED: {d.get('resourceName'): bool(d.get('externalResourceProvider'))
+                   for d in permitted.get('mediatedDevices') or []},
+        'mediated_device_types': sorted(set(types)),
+    }
+
+
+def device_allocatable(device_name: str, logger: logging.Logger) -> Dict[str, int]:
+    """Allocatable devices of a resource per schedulable node; nodes without any are left out."""
+    nodes = (_get_json(['get', 'nodes', '-l', f'{SCHEDULABLE_LABEL}=true'], logger) or {}).get('items', [])
+    allocatable = {}
+    for node in nodes:
+        try:
+            count = int(node.get('status', {}).get('allocatable', {}).get(device_name) or 0)
+        except ValueError:
+            count = 0
+        if count > 0:
+            allocatable[node['metadata']['name']] = count
+    return allocatable
+
+
+def check_gpus(gpus: Dict[str, int], vms: int, logger: logging.Logger, node: Optional[str] = None) -> Dict:
+    """
+    Whether vms VMs with the given GPUs each can be scheduled.
+
+    Devices already in use by running VMs are not subtracted, so a run that
+    fits here may still leave some VMs in the Scheduling phase.
+
+    Args:
+        gpus: GPUs per VM by device name, e.g. from template_gpus()
+        vms: Number of VMs of the run
+        logger: Logger instance
+        node: Only count the devices of this node (single-node runs)
+
+    Returns:
+        {'devices': [{'device_name', 'per_vm', 'type', 'external', 'nodes', 'allocatable'}],
+         'vms', 'vms_fit', 'errors', 'warnings'}; the run can proceed without errors
+    """
+    permitted = permitted_host_devices(logger)
+    devices, errors, warnings = [], [], []
+    fit: Optional[Dict[str, int]] = None
+    for device_name, per_vm in sorted(gpus.items()):
+        kind = PCI if device_name in permitted[PCI] else MEDIATED if device_name in permitted[MEDIATED] else None
+        external = permitted[kind].get(device_name, False) if kind else False
+        nodes = {name: count for name, count in device_allocatable(device_name, logger).items()
+                 if node is None or name == node}
+        devices.append({
+            'device_name': device_name,
+            'per_vm': per_vm,
+            'type': kind,
+            'external': external,
+            'nodes': nodes,
+            'allocatable': sum(nodes.values()),
+        })
+        if kind is None:
+            errors.append(f"{device_name} is not permitted: add it to permittedHostDevices "
+                          f"(pciHostDevices or mediatedDevices) in the KubeVirt CR")
+        if not nodes:
+            errors.append(f"No schedulable node{' ' + node if node else ''} advertises {device_name}: "
+                          f"its device plugin is not running or found no devices")
+        if kind == MEDIATED and not external and not permitted['mediated_device_types']:
+            warnings.append(f"No mediatedDeviceTypes are configured in the KubeVirt CR: the {device_name} "
+                            f"vGPUs must have been created on the nodes out of band")
+        # VMs per node are bounded by the scarcest of their devices
+        node_fit = {name: count // per_vm for name, count in nodes.items()}
+        fit = node_fit if fit is None else {name: min(count, node_fit.get(name, 0)) for name, count in fit.items()}
+
+    vms_fit = sum((fit or {}).values())
+    if gpus and vms_fit < vms:
+        errors.append(f"Only {vms_fit} of {vms} GPU VMs fit on the allocatable devices")
+    return {'devices': devices, 'vms': vms, 'vms_fit': vms_fit, 'errors': errors, 'warnings': warnings}
+
+
+def print_gpu_report(report: Dict, logger: logging.Logger):
+    """Log a check_gpus() report."""
+    logger.info("\n" + "=" * 80)
+    logger.info("GPU PREFLIGHT")
+    logger.info("=" * 80)
+    for device in report['devices']:
+        kind = {PCI: 'PCI passthrough', MEDIATED: 'mediated device (vGPU)'}.get(device['type'], 'not permitted')
+        logger.info(f"{device['device_name']}: {device['per_vm']} per VM, {kind}"
+                    f"{', external device plugin' if device['external'] else ''}")
+        for node, count in sorted(device['nodes'].items()):
+            logger.info(f"  {node:<40} {count} allocatable")
+    logger.info(f"GPU VMs that fit: {report['vms_fit']} (planned: {report['vms']})")
+    for warning in report['warnings']:
+        logger.warning(warning)
+    for error in report['errors']:
+        logger.error(error)
+    logger.info("=" * 80)
@@ -0,0 +1,343 @@
+#!/usr/bin/env python3
+"""
+In-guest command execution for KubeVirt performance testing.
+
+Workloads that need to run something inside a VM (fio, iperf, data
+integrity checks, disk discovery) go through a GuestExecutor instead of
+shelling out to `virtctl ssh`. Two transports are supported:
+
+  pod    ssh runs inside a helper pod (alpine + openssh + sshpass) and
+         reaches the guest over the pod network. Works with password auth
+         and needs nothing on the client besides kubectl.
+  local  ssh runs on the client and is tunnelled to the guest through the
+         helper pod (`kubectl exec -i <pod> -- nc <ip> 22` as ProxyCommand),
+         the same path `virtctl ssh` provides but without needing virtctl.
+         Supports key-based auth (--identity-file) and password auth when
+         sshpass is installed locally.
+
+Connections are pooled with OpenSSH connection multiplexing (one
+authenticated master connection per guest, reused by later commands), the
+number of concurrent sessions is capped per executor, and connection-level
+failures (ssh exit code 255, timeouts) are retried with backoff. Failures of
+the remote command itself are returned to the caller, not retried.
+"""
+
+import logging
+import shlex
+import subprocess
+import threading
+import time
+from typing import Any, Dict, Iterable, List, Optional, Tuple
+
+from utils.apply import apply_manifest
+from utils.base import impersonate
+from utils.common import create_namespace, get_vmi_ip, run_kubectl_command, stamp_manifest
+from utils.concurrency import run_parallel
+
+TRANSPORT_POD = 'pod'
+TRANSPORT_LOCAL = 'local'
+TRANSPORTS = [TRANSPORT_POD, TRANSPORT_LOCAL]
+
+DEFAULT_HELPER_POD = 'ssh-test-pod'
+DEFAULT_HELPER_NAMESPACE = 'default'
+HELPER_POD_IMAGE = 'alpine:latest'
+HELPER_POD_PACKAGES = 'bash openssh-client sshpass iputils'
+
+# ssh exits with 255 when the connection (not the remote command) failed
+SSH_CONNECTION_ERROR = 255
+DEFAULT_MAX_SESSIONS = 20
+DEFAULT_RETRIES = 3
+DEFAULT_RETRY_DELAY = 2.0
+DEFAULT_CONNECT_TIMEOUT = 10
+# Seconds an idle multiplexed master connection is kept open
+DEFAULT_CONTROL_PERSIST = 300
+
+
+def ensure_helper_pod(pod: str = DEFAULT_HELPER_POD, namespace: str = DEFAULT_HELPER_NAMESPACE,
+                      timeout: int = 180,
+                      logger: Optional[logging.Logger] = None) -> Tuple[bool, bool]:
+    """
+    Ensure the SSH helper pod (with sshpass and nc) is running and usable.
+
+    Reuses the pod if it already exists; otherwise creates it and waits until
+    its packages are installed.
+
+    Args:
+        pod: Helper pod name
+        namespace: Helper pod namespace
+        timeout: Seconds to wait for the pod to become usable
+        logger: Logger instance
+
+    Returns:
+        Tuple of (ready, created_by_us)
+    """
+    def _ready() -> bool:
+        rc, _, _ = run_kubectl_command(
+            ['exec', '-n', namespace, pod, '--', 'which', 'sshpass'],
+            check=False, timeout=15
+        )
+        return rc == 0
+
+    if _ready():
+        if logger:
+            logger.info(f"Using existing SSH helper pod {namespace}/{pod}")
+        return True, False
+
+    created = False
+    rc, _, _ = run_kubectl_command(['get', 'pod', pod, '-n', namespace], check=False, timeout=15)
+    if rc != 0:
+        if logger:
+            logger.info(f"Creating SSH helper pod {namespace}/{pod}...")
+        create_namespace(namespace, logger)
+        manifest = f"""apiVersion: v1
+kind: Pod
+metadata:
+  name: {pod}
+  namespace: {namespace}
+  labels:
+    app: kubevirt-perf-test
+spec:
+  containers:
+  - name: ssh-client
+    image: {HELPER_POD_IMAGE}
+    command: ["/bin/sh", "-c", "apk add --no-cache {HELPER_POD_PACKAGES} && tail -f /dev/null"]
+    resources:
+      requests:
+        memory: "128Mi"
+        cpu: "100m"
+      limits:
+        memory: "256Mi"
+        cpu: "200m"
+  restartPolicy: Always
+"""
+        applied, error = apply_manifest(stamp_manifest(manifest), logger=logger)
+        if not applied:
+            if logger:
+                logger.error(f"Failed to create SSH helper pod: {error}")
+            return False, False
+        created = True
+
+    # The pod runs `apk add` on startup; wait until sshpass is actually available
+    start = time.monotonic()
+    while time.monotonic() - start < timeout:
+        if _ready():
+            if logger:
+                logger.info(f"SSH helper pod {namespace}/{pod} is ready")
+            return True, created
+        time.sleep(5)
+
+    if logger:
+        logger.error(f"SSH helper pod {namespace}/{pod} not ready after {timeout}s")
+    return False, created
+
+
+class GuestExecutor:
+    """
+    Runs shell commands inside VM guests over SSH.
+
+    A single executor is meant to be shared by all worker threads of a
+    workload; it is thread-safe and caps concurrent sessions at
+    max_sessions. Call close() (or use it as a context manager) to tear
+    down pooled master connections.
+    """
+
+    def __init__(self, user: str, password: Optional[str] = None,
+                 identity_file: Optional[str] = None,
+                 ssh_pod: str = DEFAULT_HELPER_POD, ssh_pod_ns: str = DEFAULT_HELPER_NAMESPACE,
+                 transport: str = TRANSPORT_POD,
+                 max_sessions: int = DEFAULT_MAX_SESSIONS,
+                 retries: int = DEFAULT_RETRIES, retry_delay: float = DEFAULT_RETRY_DELAY,
+                 connect_timeout: int = DEFAULT_CONNECT_TIMEOUT,
+                 control_persist: int = DEFAULT_CONTROL_PERSIST,
+                 logger: Optional[logging.Logger] = None):
+        if transport not in TRANSPORTS:
+            raise ValueError(f"Unknown transport '{transport}' (expected one of {TRANSPORTS})")
+        if transport == TRANSPORT_POD and not password:
+            raise ValueError("The pod transport requires a password")
+        if not password and not identity_file:
+            raise ValueError("Either password or identity_file is required")
+
+        self.user = user
+        self.password = password
+        self.identity_file = identity_file
+        self.ssh_pod = ssh_pod
+        self.ssh_pod_ns = ssh_pod_ns
+        self.transport = transport
+        self.retries = max(0, int(retries))
+        self.retry_delay = retry_delay
+        self.connect_timeout = connect_timeout
+        self.control_persist = control_persist
+        self.logger = logger
+
+        self._sessions = threading.BoundedSemaphore(max(1, int(max_sessions)))
+        self._lock = threading.Lock()
+        self._hosts = set()
+        self._ip_cache: Dict[Tuple[str, str], str] = {}
+
+    def __enter__(self):
+        return self
+
+    def __exit__(self, *exc):
+        self.close()
+
+    def _ssh_options(self) -> List[str]:
+        # %C is a hash of (local host, remote host, port, user); short enough for a socket path
+        control_path = '/tmp/virtbench-ssh-%C'
+        opts = [
+            '-o', 'StrictHostKeyChecking=no',
+            '-o', 'UserKnownHostsFile=/dev/null',
+            '-o', 'LogLevel=ERROR',
+            '-o', f'ConnectTimeout={self.connect_timeout}',
+            '-o', 'ControlMaster=auto',
+            '-o', f'ControlPath={control_path}',
+            '-o', f'ControlPersist={self.control_persist}',
+        ]
+        if self.identity_file:
+            opts += ['-i', self.identity_file, '-o', 'IdentitiesOnly=yes']
+        else:
+            opts += ['-o', 'PreferredAuthentications=password', '-o', 'PubkeyAuthentication=no']
+        if self.transport == TRANSPORT_LOCAL:
+            proxy = (f"kubectl exec -i -n {self.ssh_pod_ns} {self.ssh_pod} -- "
+                     f"nc %h %p")
+            opts += ['-o', f'ProxyCommand={proxy}']
+        return opts
+
+    def _build_command(self, ip: str, ssh_args: List[str]) -> List[str]:
+        ssh = ['ssh'] + self._ssh_options() + ssh_args
+        if self.password and not self.identity_file:
+            ssh = ['sshpass', '-p', self.password] + ssh
+        if self.transport == TRANSPORT_POD:
+            return ['kubectl'] + impersonate(['exec', '-i', '-n', self.ssh_pod_ns, self.ssh_pod, '--']) + ssh
+        return ssh
+
+    def _redact(self, cmd: List[str]) -> str:
+        return ' '.join('****' if self.password and part == self.password else part for part in cmd)
+
+    def run(self, ip: str, command: str, timeout: int = 60,
+            input_data: Optional[str] = None) -> Tuple[int, str, str]:
+        """
+        Run a shell command inside a guest.
+
+        Args:
+            ip: Guest IP address (pod network)
+            command: Shell command, executed by the guest user's login shell
+            timeout: Per-attempt timeout in seconds
+            input_data: Optional data written to the command's stdin
+
+        Returns:
+            Tuple of (return_code, stdout, stderr). return_code is the remote
+            command's exit code, or 255 if the guest could not be reached
+            after all retries.
+        """
+        cmd = self._build_command(ip, [f'{self.user}@{ip}', command])
+        with self._lock:
+            self._hosts.add(ip)
+
+        rc, stdout, stderr = SSH_CONNECTION_ERROR, '', ''
+        for attempt in range(self.retries + 1):
+            if attempt:
+                delay = self.retry_delay * (2 ** (attempt - 1))
+                if self.logger:
+                    self.logger.debug(f"[{ip}] SSH connection failed (attempt {attempt}), "
+                                      f"retrying in {delay:.1f}s: {stderr.strip()}")
+                time.sleep(delay)
+
+            if self.logger:
+                self.logger.debug(f"Executing: {self._redact(cmd)}")
+            try:
+                with self._sessions:
+                    result = subprocess.run(cmd, input=input_data, capture_output=True,
+                                            text=True, timeout=timeout)
+                rc, stdout, stderr = result.returncode, result.stdout, result.stderr
+            except subprocess.TimeoutExpired:
+                rc, stdout, stderr = SSH_CONNECTION_ERROR, '', f"timed out after {timeout}s"
+
+            if rc != SSH_CONNECTION_ERROR:
+                break
+
+        return rc, stdout, stderr
+
+    def run_on_vmi(self, vmi_name: str, namespace: str, command: str, timeout: int = 60,
+                   input_data: Optional[str] = None) -> Tuple[int, str, str]:
+        """
+        Run a shell command inside a VMI, resolving (and caching) its IP.
+
+        Returns:
+            Tuple of (return_code, stdout, stderr); 255 with an explanatory
+            stderr if the VMI has no IP
+        """
+        ip = self.resolve_ip(vmi_name, namespace)
+        if not ip:
+            return SSH_CONNECTION_ERROR, '', f"VMI {namespace}/{vmi_name} has no IP address"
+        rc, stdout, stderr = self.run(ip, command, timeout=timeout, input_data=input_data)
+        if rc == SSH_CONNECTION_ERROR:
+            # The VMI may have been restarted or migrated with a new IP
+            self.forget_ip(vmi_name, namespace)
+        return rc, stdout, stderr
+
+    def resolve_ip(self, vmi_name: str, namespace: str) -> Optional[str]:
+        key = (namespace, vmi_name)
+        with self._lock:
+            if key in self._ip_cache:
+                return self._ip_cache[key]
+        ip = get_vmi_ip(vmi_name, namespace, self.logger)
+        if ip:
+            with self._lock:
+                self._ip_cache[key] = ip
+        return ip
+
+    def forget_ip(self, vmi_name: str, namespace: str):
+        with self._lock:
+            self._ip_cache.pop((namespace, vmi_name), None)
+
+    def write_file(self, ip: str, path: str, content: str, timeout: int = 60) -> bool:
+        """Write content to a file in the guest (via stdin, no scp needed)."""
+        rc, _, stderr = self.run(ip, f"cat > {shlex.quote(path)}", timeout=timeout,
+                                 input_data=content)
+        if rc != 0 and self.logger:
+            self.logger.debug(f"[{ip}] Failed to write {path}: {stderr.strip()}")
+        return rc == 0
+
+    def wait_for_ssh(self, ip: str, timeout: int = 300, interval: int = 5) -> bool:
+        """Wait until the guest accepts SSH logins."""
+        deadline = time.monotonic() + timeout
+        while time.monotonic() < deadline:
+            rc, _, _ = self.run(ip, 'true', timeout=self.connect_timeout + 10)
+            if rc == 0:
+                return True
+            time.sleep(interval)
+        return False
+
+    def run_many(self, targets: Iterable[Any], command: str, concurrency: int = 10,
+                 timeout: int = 60) -> List[Tuple[Any, Optional[Tuple[int, str, str]], Optional[Exception]]]:
+        """
+        Run the same command in many guests in parallel.
+
+        Args:
+            targets: Guest IPs, or (vmi_name, namespace) tuples
+            command: Shell command
+            concurrency: Maximum number of guests handled at once
+            timeout: Per-attempt timeout in seconds
+
+        Returns:
+            List of (target, (rc, stdout, stderr), exception) tuples, as run_parallel
+        """
+        def _one(target):
+            if isinstance(target, tuple):
+                return self.run_on_vmi(target[0], target[1], command, timeout=timeout)
+            return self.run(target, command, timeout=timeout)
+
+        return run_parallel(_one, targets, concurrency=concurrency, logger=self.logger,
+                            description="guest command")
+
+    def close(self):
+        """Close pooled master connections."""
+        with self._lock:
+            hosts = list(self._hosts)
+            self._hosts.clear()
+        for ip in hosts:
+            cmd = self._build_command(ip, ['-O', 'exit', f'{self.user}@{ip}'])
+            try:
+                subprocess.run(cmd, capture_output=True, text=True, timeout=15)
+            except subprocess.TimeoutExpired:
+                pass
@@ -0,0 +1,205 @@
+#!/usr/bin/env python3
+"""
+In-guest load generation for KubeVirt performance testing.
+
+An idle guest migrates in a single pre-copy pass. To benchmark live migration
+under realistic conditions, background workloads are started inside each
+guest before the operation and stopped afterwards:
+
+- memory: a process that keeps rewriting a buffer of the given size, at a
+  given rate, so pages are dirtied while memory is being copied
+- cpu: busy-loop workers
+- disk: a loop writing to a file with O_DIRECT at roughly the given rate
+
+Only standard tools (sh, dd, python3) are used, so no packages need to be
+installed in the guest. The load survives a live migration; stop() reports
+whether it was still running.
+"""
+
+import logging
+import threading
+from typing import Dict, Iterable, Optional
+
+from utils.common import split_vm_target
+from utils.concurrency import run_parallel
+from utils.guestexec import GuestExecutor
+
+DEFAULT_LOAD_DIR = '/var/tmp/virtbench-load'
+DEFAULT_DISK_FILE_MB = 1024
+
+# Dirties `size_mb` MiB of memory, in 1 MiB chunks, at up to `rate` MiB/s (0: unthrottled)
+_MEMORY_DIRTIER = """\
+import sys, time
+size_mb, rate = int(sys.argv[1]), float(sys.argv[2])
+chunk = 1 << 20
+patterns = [bytes([n]) * chunk for n in (0x5a, 0xa5)]
+buf = bytearray(size_mb * chunk)
+started, written, n = time.monotonic(), 0, 0
+while True:
+    pattern = patterns[n % 2]
+    for off in range(0, len(buf), chunk):
+        buf[off:off + chunk] = pattern
+        written += 1
+        if rate:
+            ahead = written / rate - (time.monotonic() - started)
+            if ahead > 0:
+                time.sleep(ahead)
+    n += 1
+"""
+
+
+class GuestLoad:
+    """
+    Starts and stops background load in guests.
+
+    One instance tracks which namespaces' guests are loaded, so it can be
+    shared across the start and stop phases of a workload.
+    """
+
+    def __init__(self, executor: GuestExecutor, memory_mb: int = 0, dirty_rate_mbs: float = 0,
+                 cpu_workers: int = 0, disk_mbs: int = 0, directory: str = DEFAULT_LOAD_DIR,
+                 logger: Optional[logging.Logger] = None):
+        self.executor = executor
+        self.memory_mb = max(0, int(memory_mb))
+        self.dirty_rate_mbs = max(0.0, float(dirty_rate_mbs))
+        self.cpu_workers = max(0, int(cpu_workers))
+        self.disk_mbs = max(0, int(disk_mbs))
+        self.directory = directory
+        self.logger = logger
+        self._loaded = set()
+        self._lock = threading.Lock()
+
+    @property
+    def enabled(self) -> bool:
+        return bool(self.memory_mb or self.cpu_workers or self.disk_mbs)
+
+    def settings(self) -> Dict:
+        """The load settings, as recorded in results."""
+        return {
+            'memory_mb': self.memory_mb,
+            'dirty_rate_mbs': self.dirty_rate_mbs or None,
+            'cpu_workers': self.cpu_workers,
+            'disk_mbs': self.disk_mbs,
+        }
+
+    def _start_command(self) -> str:
+        d = self.directory
+        launch = []
+        if self.memory_mb:
+            launch.append(f"nohup python3 {d}/dirty.py {self.memory_mb} {self.dirty_rate_mbs:g} "
+                          f">/dev/null 2>&1 & echo $! >> {d}/pids")
+        for _ in range(self.cpu_workers):
+            launch.append(f"nohup sh -c 'while :; do :; done' >/dev/null 2>&1 & echo $! >> {d}/pids")
+        if self.disk_mbs:
+            # Cycle through a bounded file so the load never fills the disk
+            blocks = DEFAULT_DISK_FILE_MB // self.disk_mbs or 1
+            launch.append(
+                f"nohup sh -c 'i=0; while :; do dd if=/dev/urandom of={d}/disk.dat bs=1M "
+                f"count={self.disk_mbs} seek=$((i * {self.disk_mbs})) oflag=direct conv=notrunc "
+                f"status=none; i=$(((i + 1) % {blocks})); sleep 1; done' "
+                f">/dev/null 2>&1 & echo $! >> {d}/pids"
+            )
+        return (f"set -e; mkdir -p {d}; cat > {d}/dirty.py; : > {d}/pids; "
+                + '; '.join(launch) + f"; sleep 1; cat {d}/pids")
+
+    def start(self, namespace: str, vm_name: str, timeout: int = 120) -> bool:
+        """
+        Start the configured load in one guest.
+
+        The namespace may be a "{namespace}/{vm}" target of a namespace-scoped
+        run (utils.common.vm_targets()).
+
+        Returns:
+            True if every load process was started and is running
+        """
+        self.stop(namespace, vm_name, quiet=True)
+        vmi_namespace, vmi_name = split_vm_target(namespace, vm_name)
+        rc, stdout, stderr = self.executor.run_on_vmi(vmi_name, vmi_namespace, self._start_command(),
+                                                      timeout=timeout, input_data=_MEMORY_DIRTIER)
+        expected = (1 if self.memory_mb else 0) + self.cpu_workers + (1 if self.disk_mbs else 0)
+        started = len(stdout.split()) if rc == 0 else 0
+        if started < expected:
+            if self.logger:
+                self.logger.warning(f"[{namespace}] Failed to start guest load "
+                                    f"({started}/{expected} processes): {(stderr or '').strip()}")
+            return False
+
+        with self._lock:
+            self._loaded.add(namespace)
+        if self.logger:
+            self.logger.debug(f"[{namespace}] Guest load started ({expected} process(es))")
+        return True
+
+    def start_many(self, namespaces: Iterable[str], vm_name: str, concurrency: int = 10) -> int:
+        """Start the load in every namespace's VM in parallel. Returns the number started."""
+        outcomes = run_parallel(self.start, namespaces, concurrency=concurrency,
+                                args=(vm_name,), logger=self.logger,
+                                description="guest load start")
+        started = sum(1 for _, ok, _ in outcomes if ok)
+        if self.logger:
+            self.logger.info(f"Guest load running in {started}/{len(outcomes)} VM(s): "
+                             f"{self.describe()}")
+        return started
+
+    def stop(self, namespace: str, vm_name: str, timeout: int = 120, quiet: bool = False) -> Optional[bool]:
+        """
+        Stop the load in one guest and remove its files.
+
+        Returns:
+            True if every load process was still running when stopped, False
+            if some had died, None if the guest was not loaded or unreachable
+        """
+        d = self.directory
+        command = (f"[ -f {d}/pids ] || exit 0; total=0; alive=0; "
+                   f"for p in $(cat {d}/pids); do total=$((total + 1)); "
+                   f"kill -0 $p 2>/dev/null && alive=$((alive + 1)); kill $p 2>/dev/null; done; "
+                   f"rm -rf {d}; echo $alive $total")
+        vmi_namespace, vmi_name = split_vm_target(namespace, vm_name)
+        rc, stdout, stderr = self.executor.run_on_vmi(vmi_name, vmi_namespace, command, timeout=timeout)
+        with self._lock:
+            loaded = namespace in self._loaded
+            self._loaded.discard(namespace)
+        if quiet or not loaded:
+            return None
+        parts = stdout.split()
+        if rc != 0 or len(parts) != 2:
+            if self.logger:
+                self.logger.warning(f"[{namespace}] Could not stop guest load: {(stderr or '').strip()}")
+            return None
+        alive, total = int(parts[0]), int(parts[1])
+        if alive < total and self.logger:
+            self.logger.warning(f"[{namespace}] Only {alive}/{total} guest load process(es) were still running")
+        return alive == total
+
+    def stop_many(self, namespaces: Iterable[str], vm_name: str, concurrency: int = 10) -> Dict:
+        """
+        Stop the load in every namespace's VM in parallel.
+
+        Returns:
+            Settings plus counts of VMs whose load was started, still running
+            when stopped, and unreachable
+        """
+        with self._lock:
+            started = len(self._loaded)
+        outcomes = run_parallel(self.stop, namespaces, concurrency=concurrency,
+                                args=(vm_name,), logger=self.logger,
+                                description="guest load stop")
+        summary = self.settings()
+        summary['vms_loaded'] = started
+        summary['vms_still_running'] = sum(1 for _, ok, _ in outcomes if ok)
+        summary['vms_unreachable'] = started - sum(1 for _, ok, _ in outcomes if ok is not None)
+        if self.logger:
+            self.logger.info(f"Guest load stopped: still running in {summary['vms_still_running']}/"
+                             f"{started} loaded VM(s)")
+        return summary
+
+    def describe(self) -> str:
+        parts = []
+        if self.memory_mb:
+            rate = f" at {self.dirty_rate_mbs:g} MiB/s" if self.dirty_rate_mbs else ''
+            parts.append(f"dirtying {self.memory_mb} MiB{rate}")
+        if self.cpu_workers:
+            parts.append(f"{self.cpu_workers} CPU worker(s)")
+        if self.disk_mbs:
+            parts.append(f"~{self.disk_mbs} MiB/s disk writes")
+        return ', '.join(parts)
@@ -0,0 +1,338 @@
+#!/usr/bin/env python3
+"""
+HDR latency histograms for KubeVirt performance testing.
+
+Summary statistics (utils/stats.py) describe one run. To merge runs, compare
+tails across clusters or plot latency by percentile, ``virtbench
+--latency-histograms`` (VIRTBENCH_LATENCY_HISTOGRAMS=1) also records every
+latency of the VM creation workload in an HdrHistogram:
+
+    create_call           the successful VM create API call
+    scheduling_time       VM creation until the launcher pod is scheduled
+    running_time          VM creation until the VM is Running (boot)
+    ping_time             VM creation until the guest answers ping
+    clone_duration        DataVolume clone, when tracked
+    guest_agent_time      VM creation until the guest agent connects, when tracked
+
+Values are recorded in nanoseconds with 3 significant digits, from 1ns up to
+HIGHEST_TRACKABLE_NS. Next to the results, save_histograms() writes
+
+    {prefix}.hlog                  HdrHistogram interval log (format 1.3): one
+                                   interval per operation, tagged with its name,
+                                   as compressed V2 histograms in base64
+    {prefix}_{operation}.hgrm      percentile distribution in milliseconds, as
+                                   printed by outputPercentileDistribution()
+
+so that HistogramLogProcessor merges .hlog files of several runs and the
+HdrHistogram plotter reads the .hgrm files. The encoding is implemented here,
+without the hdrhistogram package.
+"""
+
+import base64
+import logging
+import math
+import os
+import struct
+import threading
+import time
+import zlib
+from datetime import datetime, timezone
+from typing import Dict, Iterable, Iterator, Optional, Tuple
+
+HISTOGRAMS_ENV = 'VIRTBENCH_LATENCY_HISTOGRAMS'
+
+SIGNIFICANT_DIGITS = 3
+LOWEST_DISCERNIBLE_NS = 1
+HIGHEST_TRACKABLE_NS = 24 * 3600 * 10 ** 9
+
+# .hgrm values and .hlog interval maxima in milliseconds
+OUTPUT_UNIT_RATIO = 1e6
+PERCENTILE_TICKS_PER_HALF_DISTANCE = 5
+
+# V2 encoding with LEB128 zig-zag counts (word size bits 0x10), as written by HdrHistogram 2.x
+_ENCODING_COOKIE = 0x1c849303 | 0x10
+_COMPRESSED_COOKIE = 0x1c849304 | 0x10
+
+LOG_FORMAT_VERSION = '1.3'
+
+_recorded: Dict[str, 'LatencyHistogram'] = {}
+_lock = threading.Lock()
+
+
+def histograms_enabled() -> bool:
+    """Return True when latency histograms were requested (`virtbench --latency-histograms`)."""
+    return os.environ.get(HISTOGRAMS_ENV, '').lower() in ('1', 'true', 'yes')
+
+
+class LatencyHistogram:
+    """A fixed-precision HDR histogram of integer values (the layout of HdrHistogram's Histogram)."""
+
+    def __init__(self, lowest: int = LOWEST_DISCERNIBLE_NS, highest: int = HIGHEST_TRACKABLE_NS,
+                 digits: int = SIGNIFICANT_DIGITS):
+        self.lowest = lowest
+        self.highest = highest
+        self.digits = digits
+        largest_single_unit = 2 * 10 ** digits
+        sub_bucket_count_magnitude = math.ceil(math.log2(largest_single_unit))
+        self.sub_bucket_half_count_magnitude = max(sub_bucket_count_magnitude, 1) - 1
+        self.unit_magnitude = int(math.floor(math.log2(lowest)))
+        self.sub_bucket_count = 2 ** (self.sub_bucket_half_count_magnitude + 1)
+        self.sub_bucket_half_count = self.sub_bucket_count // 2
+        self.sub_bucket_mask = (self.sub_bucket_count - 1) << self.unit_magnitude
+        self.bucket_count = self._buckets_needed(highest)
+        self.counts = [0] * ((self.bucket_count + 1) * self.sub_bucket_half_count)
+        self.total_count = 0
+        self.max_value = 0
+        self.min_value = None
+
+    def _buckets_needed(self, value: int) -> int:
+        smallest_untrackable = self.sub_bucket_count << self.unit_magnitude
+        buckets = 1
+        while smallest_untrackable <= value:
+            smallest_untrackable <<= 1
+            buckets += 1
+        return buckets
+
+    def _bucket_index(self, value: int) -> int:
+        return (value | self.sub_bucket_mask).bit_length() - (
+            self.unit_magnitude + self.sub_bucket_half_count_magnitude + 1)
+
+    def _counts_index(self, value: int) -> int:
+        bucket = self._bucket_index(value)
+        sub_bucket = value >> (bucket + self.unit_magnitude)
+        return ((bucket + 1) << self.sub_bucket_half_count_magnitude) + sub_bucket - self.sub_bucket_half_count
+
+    def _value_from_index(self, index: int) -> int:
+        bucket = (index >> self.sub_bucket_half_count_magnitude) - 1
+        sub_bucket = (index & (self.sub_bucket_half_count - 1)) + self.sub_bucket_half_count
+        if bucket < 0:
+            sub_bucket -= self.sub_bucket_half_count
+            bucket = 0
+        return sub_bucket << (bucket + self.unit_magnitude)
+
+    def _equivalent_range(self, value: int) -> Tuple[int, int]:
+        """(lowest equivalent value, size of the equivalent value range) of value."""
+        bucket = self._bucket_index(value)
+        sub_bucket = value >> (bucket + self.unit_magnitude)
+        adjusted_bucket = bucket + 1 if sub_bucket >= self.sub_bucket_count else bucket
+        return sub_bucket << (bucket + self.unit_magnitude), 1 << (self.unit_magnitude + adjusted_bucket)
+
+    def highest_equivalent_value(self, value: int) -> int:
+        lowest, size = self._equivalent_range(value)
+        return lowest + size - 1
+
+    def median_equivalent_value(self, value: int) -> int:
+        lowest, size = self._equivalent_range(value)
+        return lowest + (size >> 1)
+
+    def record(self, value: int, count: int = 1):
+        """Record an integer value, clamped to [0, highest]."""
+        value = min(max(int(value), 0), self.highest)
+        self.counts[self._counts_index(value)] += count
+        self.total_count += count
+        self.max_value = max(self.max_value, value)
+        self.min_value = value if self.min_value is None else min(self.min_value, value)
+
+    def record_seconds(self, seconds: Optional[float]):
+        """Record a duration in seconds as nanoseconds (None is skipped)."""
+        if seconds is not None:
+            self.record(round(seconds * 1e9))
+
+    def _recorded(self) -> Iterator[Tuple[int, int]]:
+        """(index, count) of every non-empty bucket, lowest first."""
+        for index in range(self._counts_index(self.max_value) + 1 if self.total_count else 0):
+            if self.counts[index]:
+                yield index, self.counts[index]
+
+    def value_at_percentile(self, pct: float) -> int:
+        """Highest equivalent value below which pct percent of the recorded values fall."""
+        if not self.total_count:
+            return 0
+        wanted = max(int(min(pct, 100.0) / 100.0 * self.total_count + 0.5), 1)
+        running = 0
+        for index, count in self._recorded():
+            running += count
+            if running >= wanted:
+                value = self._value_from_index(index)
+                return self._equivalent_range(value)[0] if pct == 0 else self.highest_equivalent_value(value)
+        return 0
+
+    def max(self) -> int:
+        return self.highest_equivalent_value(self.max_value) if self.max_value else 0
+
+    def mean(self) -> float:
+        if not self.total_count:
+            return 0.0
+        return sum(self.median_equivalent_value(self._value_from_index(i)) * c
+                   for i, c in self._recorded()) / self.total_count
+
+    def stddev(self) -> float:
+        if not self.total_count:
+            return 0.0
+        mean = self.mean()
+        return math.sqrt(sum((self.median_equivalent_value(self._value_from_index(i)) - mean) ** 2 * c
+                             for i, c in self._recorded()) / self.total_count)
+
+    def percentiles(self, ticks_per_half_distance: int = PERCENTILE_TICKS_PER_HALF_DISTANCE) -> Iterator[Tuple]:
+        """
+        (value, percentile, total count) steps of HdrHistogram's PercentileIterator.
+
+        Steps get finer towards 100: ticks_per_half_distance per halving of the
+        distance to 100%, ending with the maximum at 100%.
+        """
+        level, running, value = 0.0, 0, 0
+        for index, count in self._recorded():
+            running += count
+            value = self.highest_equivalent_value(self._value_from_index(index))
+            while 100.0 * running / self.total_count >= level:
+                yield value, level, running
+                level += 100.0 / (ticks_per_half_distance * 2 ** (int(math.log2(100.0 / (100.0 - level))) + 1))
+                if running == self.total_count:
+                    break
+        if self.total_count:
+            yield value, 100.0, running
+
+    def percentile_distribution(self, unit_ratio: float = OUTPUT_UNIT_RATIO) -> str:
+        """The .hgrm text of outputPercentileDistribution(), values divided by unit_ratio."""
+        d = self.digits
+        lines = [f"{'Value':>12} {'Percentile':>14} {'TotalCount':>10} {'1/(1-Percentile)':>14}", ""]
+        for value, pct, running in self.percentiles():
+            line = f"{value / unit_ratio:12.{d}f} {pct / 100:2.12f} {running:10d}"
+            lines.append(line if pct == 100.0 else f"{line} {1 / (1 - pct / 100):14.2f}")
+        lines.append(f"#[Mean    = {self.mean() / unit_ratio:12.{d}f}, "
+                     f"StdDeviation   = {self.stddev() / unit_ratio:12.{d}f}]")
+        lines.append(f"#[Max     = {self.max() / unit_ratio:12.{d}f}, Total count    = {self.total_count:12d}]")
+        lines.append(f"#[Buckets = {self.bucket_count:12d}, SubBuckets     = {self.sub_bucket_count:12d}]")
+        return "\n".join(lines) + "\n"
+
+    def encode(self) -> bytes:
+        """Compressed V2 encoding (encodeIntoCompressedByteBuffer())."""
+        payload = bytearray()
+        limit = self._counts_index(self.max_value) + 1 if self.total_count else 0
+        index = 0
+        while index < limit:
+            count = self.counts[index]
+            index += 1
+            if count == 0:
+                zeros = 1
+                while index < limit and self.counts[index] == 0:
+                    zeros += 1
+                    index += 1
+                if zeros > 1:
+                    count = -zeros
+            _put_zigzag(payload, count)
+        header = struct.pack('>iiiiqqd', _ENCODING_COOKIE, len(payload), 0, self.digits,
+                             self.lowest, self.highest, 1.0)
+        compressed = zlib.compress(header + bytes(payload))
+        return struct.pack('>ii', _COMPRESSED_COOKIE, len(compressed)) + compressed
+
+
+def _put_zigzag(buffer: bytearray, value: int):
+    """Append a 64-bit zig-zag LEB128 value (at most 9 bytes, the last one with 8 bits)."""
+    value = ((value << 1) ^ (value >> 63)) & 0xFFFFFFFFFFFFFFFF
+    for _ in range(8):
+        if value < 0x80:
+            buffer.append(value)
+            return
+        buffer.append((value & 0x7F) | 0x80)
+        value >>= 7
+    buffer.append(value & 0xFF)
+
+
+def record_latency(operation: str, seconds: Optional[float]):
+    """Record one latency of an operation for the next save_histograms() (no-op unless enabled)."""
+    if seconds is None or not histograms_enabled():
+        return
+    with _lock:
+        _recorded.setdefault(operation, LatencyHistogram()).record_seconds(seconds)
+
+
+def take_latencies() -> Dict[str, LatencyHistogram]:
+    """Histograms recorded with record_latency() since the last call; resets them."""
+    with _lock:
+        histograms = dict(_recorded)
+        _recorded.clear()
+    return histograms
+
+
+def _epoch_seconds(rfc3339: Optional[str]) -> Optional[float]:
+    """Seconds since the epoch of an RFC3339 UTC timestamp with up to nanoseconds."""
+    if not rfc3339:
+        return None
+    base, _, fraction = rfc3339.rstrip('Z').partition('.')
+    try:
+        seconds = datetime.strptime(base, '%Y-%m-%dT%H:%M:%S').replace(tzinfo=timezone.utc).timestamp()
+        return seconds + (float(f"0.{fraction}") if fraction else 0.0)
+    except ValueError:
+        return None
+
+
+def histogram_log(histograms: Dict[str, LatencyHistogram], start: float, length: float) -> str:
+    """The .hlog text: one interval per histogram, tagged with its operation."""
+    started = datetime.fromtimestamp(start, tz=timezone.utc).strftime('%a %b %d %H:%M:%S UTC %Y')
+    lines = [
+        f"#[Histogram log format version {LOG_FORMAT_VERSION}]",
+        f"#[StartTime: {start:.3f} (seconds since epoch), {started}]",
+        '"StartTimestamp","Interval_Length","Interval_Max","Interval_Compressed_Histogram"',
+    ]
+    for operation, histogram in histograms.items():
+        encoded = base64.b64encode(histogram.encode()).decode()
+        lines.append(f"Tag={operation},0.000,{length:.3f},{histogram.max() / OUTPUT_UNIT_RATIO:.3f},{encoded}")
+    return "\n".join(lines) + "\n"
+
+
+def save_histograms(output_dir: str, prefix: str, series: Dict[str, Iterable[float]],
+                    timing: Optional[Dict] = None,
+                    logger: Optional[logging.Logger] = None) -> Optional[Dict]:
+    """
+    Write the latency histograms of a results folder.
+
+    Args:
+        output_dir: Results folder
+        prefix: File prefix, e.g. vm_creation_results
+        series: Latencies in seconds by operation, recorded together with
+            the ones taken from record_latency()
+        timing: Timing block (utils.timing timing_metadata()) for the log's start time
+        logger: Logger instance (optional)
+
+    Returns:
+        Summary block {"unit", "significant_digits", "log", "operations": {operation:
+        {"count", "p50_ms", "p99_ms", "p999_ms", "max_ms", "percentiles"}}},
+        or None when histograms are off or nothing was recorded
+    """
+    if not histograms_enabled():
+        return None
+    histograms = take_latencies()
+    for operation, values in series.items():
+        for value in values:
+            histograms.setdefault(operation, LatencyHistogram()).record_seconds(value)
+    histograms = {op: h for op, h in sorted(histograms.items()) if h.total_count}
+    if not histograms:
+        return None
+
+    start = _epoch_seconds((timing or {}).get('started_at')) or time.time()
+    finished = _epoch_seconds((timing or {}).get('finished_at'))
+    log_name = f"{prefix}.hlog"
+    block = {'unit': 'ns', 'significant_digits': SIGNIFICANT_DIGITS, 'log': log_name, 'operations': {}}
+    try:
+        with open(os.path.join(output_dir, log_name), 'w') as f:
+            f.write(histogram_log(histograms, start, max((finished or start) - start, 0.0)))
+        for operation, histogram in histograms.items():
+            hgrm_name = f"{prefix}_{operation}.hgrm"
+            with open(os.path.join(output_dir, hgrm_name), 'w') as f:
+                f.write(histogram.percentile_distribution())
+            block['operations'][operation] = {
+                'count': histogram.total_count,
+                **{f"{label}_ms": round(histogram.value_at_percentile(pct) / OUTPUT_UNIT_RATIO, 3)
+                   for label, pct in (('p50', 50), ('p99', 99), ('p999', 99.9))},
+                'max_ms': round(histogram.max() / OUTPUT_UNIT_RATIO, 3),
+                'percentiles': hgrm_name,
+            }
+    except OSError as e:
+        if logger:
+            logger.warning(f"Could not write latency histograms: {e}")
+        return None
+    if logger:
+        logger.info(f"Saved latency histograms of {len(histograms)} operations to "
+                    f"{os.path.join(output_dir, log_name)}")
+    return block
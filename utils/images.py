@@ -0,0 +1,386 @@
+#!/usr/bin/env python3
+"""
+Guest OS image management for KubeVirt benchmark runs.
+
+Benchmarks clone their VMs from a golden image DataSource (rhel9 in the
+platform's golden image namespace by default, see utils/cluster_platform.py).
+This script prepares those images without hand-written YAML:
+
+- list: the DataSources (bootable volumes) of the cluster with their
+  readiness, source PVC or snapshot, default preference and the
+  DataImportCron that keeps them up to date; on Harvester also the
+  VirtualMachineImages
+- upload: upload a local disk image (qcow2, raw, iso) through the CDI upload
+  proxy (`virtctl image-upload`) into a PVC, and create a DataSource for it
+- import: import a disk image from an HTTP(S) URL with a CDI DataVolume,
+  and create a DataSource for it
+
+The DataSource is named like the image PVC and points at it; the benchmarks
+then use it with --datasource-name/--datasource. Images are not labeled as
+run resources, so run cleanup never deletes them.
+
+Exit codes:
+    0: success
+    1: the upload or import failed, or the DataSource is not Ready in time
+
+Usage:
+    python3 images.py --list
+    python3 images.py --upload fedora.qcow2 --name fedora --size 30Gi
+    python3 images.py --import-url https://example.com/fedora.qcow2 --name fedora --storage-class px-csi-db
+"""
+
+import argparse
+import json
+import logging
+import os
+import subprocess
+import sys
+import time
+from typing import Dict, List, Optional
+
+import yaml
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
+
+from utils.cluster_platform import HARVESTER, default_datasource_namespace, detect_platform
+from utils.apply import apply_manifest
+from utils.common import _kubectl_with_input, setup_logging, run_kubectl_command, namespace_exists
+from utils.dryrun import DryRunPlan, is_dry_run
+from utils.output import emit
+from utils.prewarm import wait_for_datasources
+
+DEFAULT_SIZE = '30Gi'
+DEFAULT_TIMEOUT = 1800
+DEFAULT_POLL_INTERVAL = 10
+
+DEFAULT_PREFERENCE_LABEL = 'instancetype.kubevirt.io/default-preference'
+DATA_IMPORT_CRON_LABEL = 'cdi.kubevirt.io/dataImportCron'
+# Binds the import PVC right away on WaitForFirstConsumer storage classes
+BIND_IMMEDIATE_ANNOTATION = 'cdi.kubevirt.io/storage.bind.immediate.requested'
+
+
+def _get_json(args: List[str], logger: logging.Logger) -> Optional[Dict]:
+    returncode, stdout, _ = run_kubectl_command(args + ['-o', 'json'], check=False, logger=logger)
+    if returncode != 0:
+        return None
+    try:
+        return json.loads(stdout)
+    except ValueError:
+        return None
+
+
+def _ready(obj: Dict) -> bool:
+    return any(c.get('type') == 'Ready' and c.get('status') == 'True'
+               for c in (obj.get('status') or {}).get('conditions') or [])
+
+
+def list_images(namespace: Optional[str], logger: logging.Logger) -> Dict:
+    """
+    The DataSources (and on Harvester the VirtualMachineImages) of a namespace, or of all namespaces.
+
+    Returns:
+        {"datasources": [...], "virtual_machine_images": [...] (Harvester only)}
+    """
+    ns_args = ['-n', namespace] if namespace else ['-A']
+    datasources = []
+    for item in (_get_json(['get', 'datasources.cdi.kubevirt.io'] + ns_args, logger) or {}).get('items', []):
+        metadata = item['metadata']
+        source = (item.get('spec') or {}).get('source') or {}
+        kind, ref = next(iter(source.items()), (None, {}))
+        labels = metadata.get('labels') or {}
+        datasources.append({
+            'namespace': metadata.get('namespace'),
+            'name': metadata['name'],
+            'ready': _ready(item),
+            'source': f"{kind}/{ref.get('namespace', metadata.get('namespace'))}/{ref.get('name')}" if kind else None,
+            'default_preference': labels.get(DEFAULT_PREFERENCE_LABEL),
+            'data_import_cron': labels.get(DATA_IMPORT_CRON_LABEL),
+        })
+    report = {'datasources': sorted(datasources, key=lambda d: (d['namespace'], d['name']))}
+
+    if detect_platform(logger) == HARVESTER:
+        images = []
+        for item in (_get_json(['get', 'virtualmachineimages.harvesterhci.io'] + ns_args, logger) or {}).get('items', []):
+            status = item.get('status') or {}
+            images.append({
+                'namespace': item['metadata'].get('namespace'),
+                'name': item['metadata']['name'],
+                'display_name': (item.get('spec') or {}).get('displayName'),
+                'progress': status.get('progress'),
+                'size_bytes': status.get('size'),
+                'storage_class': status.get('storageClassName'),
+            })
+        report['virtual_machine_images'] = sorted(images, key=lambda i: (i['namespace'], i['name']))
+    return report
+
+
+def print_images(report: Dict, logger: logging.Logger):
+    """Log a list_images() report."""
+    logger.info("\n" + "=" * 110)
+    logger.info("DATASOURCES")
+    logger.info("=" * 110)
+    logger.info(f"{'Namespace':<36} {'Name':<24} {'Ready':<6} {'Preference':<16} Source")
+    logger.info("-" * 110)
+    for ds in report['datasources']:
+        logger.info(f"{ds['namespace']:<36} {ds['name']:<24} {'yes' if ds['ready'] else 'no':<6} "
+                    f"{ds['default_preference'] or '-':<16} {ds['source'] or '-'}"
+                    + (f" (DataImportCron {ds['data_import_cron']})" if ds['data_import_cron'] else ''))
+    if not report['datasources']:
+        logger.info("No DataSources found; create one with `virtbench images upload` or `virtbench images import`")
+    if 'virtual_machine_images' in report:
+        logger.info("\n" + "=" * 110)
+        logger.info("HARVESTER VIRTUAL MACHINE IMAGES")
+        logger.info("=" * 110)
+        logger.info(f"{'Namespace':<20} {'Name':<24} {'Display name':<30} {'Progress':>8}  Storage class")
+        logger.info("-" * 110)
+        for image in report['virtual_machine_images']:
+            progress = f"{image['progress']}%" if image['progress'] is not None else '-'
+            logger.info(f"{image['namespace']:<20} {image['name']:<24} {image['display_name'] or '-':<30} "
+                        f"{progress:>8}  {image['storage_class'] or '-'}")
+    logger.info("=" * 110)
+
+
+def import_manifest(name: str, namespace: str, url: str, size: str, storage_class: Optional[str],
+                    access_mode: Optional[str], volume_mode: Optional[str], cert_configmap: Optional[str]) -> Dict:
+    """DataVolume importing a disk image from an HTTP(S) URL."""
+    http = {'url': url}
+    if cert_configmap:
+        http['certConfigMap'] = cert_configmap
+    storage = {'resources': {'requests': {'storage': size}}}
+    if storage_class:
+        storage['storageClassName'] = storage_class
+    if access_mode:
+        storage['accessModes'] = [access_mode]
+    if volume_mode:
+        storage['volumeMode'] = volume_mode
+    return {
+        'apiVersion': 'cdi.kubevirt.io/v1beta1',
+        'kind': 'DataVolume',
+        'metadata': {'name': name, 'namespace': namespace,
+                     'annotations': {BIND_IMMEDIATE_ANNOTATION: 'true'}},
+        'spec': {'source': {'http': http}, 'storage': storage},
+    }
+
+
+def datasource_manifest(name: str, namespace: str, preference: Optional[str]) -> Dict:
+    """DataSource pointing at the image PVC of the same name."""
+    metadata = {'name': name, 'namespace': namespace}
+    if preference:
+        metadata['labels'] = {DEFAULT_PREFERENCE_LABEL: preference}
+    return {
+        'apiVersion': 'cdi.kubevirt.io/v1beta1',
+        'kind': 'DataSource',
+        'metadata': metadata,
+        'spec': {'source': {'pvc': {'name': name, 'namespace': namespace}}},
+    }
+
+
+def upload_command(args) -> List[str]:
+    """virtctl image-upload command line of --upload."""
+    cmd = ['virtctl', 'image-upload', 'dv', args.name, '-n', args.namespace, f"--image-path={args.upload}",
+           f"--size={args.size}", '--force-bind', f"--wait-secs={args.timeout}"]
+    if args.storage_class:
+        cmd.append(f"--storage-class={args.storage_class}")
+    if args.access_mode:
+        cmd.append(f"--access-mode={args.access_mode}")
+    if args.volume_mode:
+        cmd.append(f"--volume-mode={args.volume_mode.lower()}")
+    if args.uploadproxy_url:
+        cmd.append(f"--uploadproxy-url={args.uploadproxy_url}")
+    if args.insecure:
+        cmd.append('--insecure')
+    return cmd
+
+
+def wait_for_import(name: str, namespace: str, logger: logging.Logger, timeout: int = DEFAULT_TIMEOUT,
+                    poll_interval: int = DEFAULT_POLL_INTERVAL) -> Optional[str]:
+    """
+    Wait until the import DataVolume has Succeeded.
+
+    Returns:
+        None on success, else why it did not succeed
+    """
+    start = time.time()
+    while True:
+        dv = _get_json(['get', 'datavolume', name, '-n', namespace], logger)
+        status = (dv or {}).get('status') or {}
+        phase = status.get('phase')
+        if phase == 'Succeeded':
+            logger.info(f"DataVolume {namespace}/{name} imported in {time.time() - start:.0f}s")
+            return None
+        if phase == 'Failed':
+            return 'import failed'
+        if time.time() - start > timeout:
+            return f"still {phase or 'pending'} after {timeout}s"
+        logger.info(f"Importing {namespace}/{name}: {phase or 'Pending'} {status.get('progress', '')}".rstrip())
+        time.sleep(poll_interval)
+
+
+def ensure_namespace(namespace: str, logger: logging.Logger) -> bool:
+    """Create the image namespace if needed, without run labels (images outlive runs)."""
+    if namespace_exists(namespace, logger):
+        return True
+    returncode, _, stderr = run_kubectl_command(['create', 'namespace', namespace], check=False, logger=logger)
+    if returncode != 0:
+        logger.error(f"Cannot create namespace {namespace}: {stderr.strip()}")
+        return False
+    logger.info(f"Created namespace: {namespace}")
+    return True
+
+
+def create_datasource(args, logger: logging.Logger) -> Optional[str]:
+    """Create (or update) the DataSource of the image and wait until it is Ready. Returns an error or None."""
+    manifest = yaml.safe_dump(datasource_manifest(args.name, args.namespace, args.preference), sort_keys=False)
+    applied, error = apply_manifest(manifest, logger=logger)
+    if not applied:
+        return f"cannot create DataSource: {error}"
+    result = wait_for_datasources([(args.namespace, args.name)], logger, args.timeout, args.poll_interval)[0]
+    return None if result['ready'] else f"DataSource not Ready ({result['reason']})"
+
+
+def add_image(args, logger: logging.Logger) -> Dict:
+    """Upload or import the image into a PVC, then create its DataSource (--upload / --import-url)."""
+    start = time.time()
+    source = args.upload or args.import_url
+    error = None
+    if not ensure_namespace(args.namespace, logger):
+        error = f"cannot create namespace {args.namespace}"
+    elif args.upload:
+        logger.info(f"Uploading {args.upload} to {args.namespace}/{args.name} through the CDI upload proxy")
+        try:
+            result = subprocess.run(upload_command(args), timeout=args.timeout + 60)
+            if result.returncode != 0:
+                error = f"virtctl image-upload exited with {result.returncode}"
+        except FileNotFoundError:
+            error = "virtctl not found; install it from the KubeVirt (or OpenShift Virtualization) release"
+        except subprocess.TimeoutExpired:
+            error = f"upload did not finish in {args.timeout}s"
+    else:
+        logger.info(f"Importing {args.import_url} to {args.namespace}/{args.name}")
+        manifest = import_manifest(args.name, args.namespace, args.import_url, args.size, args.storage_class,
+                                   args.access_mode, args.volume_mode, args.cert_configmap)
+        returncode, _, stderr = _kubectl_with_input(['create', '-f', '-'], yaml.safe_dump(manifest, sort_keys=False),
+                                                    logger)
+        if returncode != 0:
+            error = f"cannot create DataVolume: {stderr.strip()}"
+        else:
+            error = wait_for_import(args.name, args.namespace, logger, args.timeout, args.poll_interval)
+    if error is None:
+        error = create_datasource(args, logger)
+    return {
+        'name': args.name,
+        'namespace': args.namespace,
+        'source': source,
+        'method': 'upload' if args.upload else 'import',
+        'datasource_ready': error is None,
+        'error': error,
+        'duration_sec': round(time.time() - start, 1),
+    }
+
+
+def plan_add_image(plan: DryRunPlan, args):
+    """Record what add_image() would create (virtbench --dry-run)."""
+    if args.upload:
+        plan.action('upload', f"dv/{args.namespace}/{args.name}", f"{args.upload} via virtctl image-upload")
+    else:
+        manifest = import_manifest(args.name, args.namespace, args.import_url, args.size, args.storage_class,
+                                   args.access_mode, args.volume_mode, args.cert_configmap)
+        plan.apply(yaml.safe_dump(manifest, sort_keys=False), args.namespace, detail='image import', stamp=False)
+        plan.action('wait', f"dv/{args.namespace}/{args.name}", 'until Succeeded')
+    plan.apply(yaml.safe_dump(datasource_manifest(args.name, args.namespace, args.preference), sort_keys=False),
+               args.namespace, verb='apply', stamp=False)
+    plan.action('wait', f"datasource/{args.namespace}/{args.name}", 'until Ready')
+
+
+def parse_args():
+    """Parse command line arguments"""
+    parser = argparse.ArgumentParser(
+        description='List, upload and import guest OS images (DataSources) for KubeVirt benchmarks',
+        formatter_class=argparse.RawDescriptionHelpFormatter,
+        epilog="""
+Examples:
+  # DataSources of every namespace
+  %(prog)s --list
+
+  # Upload a local image as DataSource fedora in the golden image namespace
+  %(prog)s --upload Fedora-Cloud-Base-40.qcow2 --name fedora --size 20Gi
+
+  # Import an image from a URL onto a given storage class
+  %(prog)s --import-url https://example.com/rhel-9.4.qcow2 --name rhel9 --storage-class px-csi-db
+        """
+    )
+    action = parser.add_mutually_exclusive_group()
+    action.add_argument('--list', action='store_true', help='List DataSources (default)')
+    action.add_argument('--upload', metavar='FILE', help='Upload a local disk image through the CDI upload proxy')
+    action.add_argument('--import-url', metavar='URL', help='Import a disk image from an HTTP(S) URL')
+    parser.add_argument('--name', help='Name of the image PVC and DataSource (required for --upload/--import-url)')
+    parser.add_argument('--namespace', default=None,
+                        help='Namespace of the image (default: the platform\'s golden image namespace; '
+                             '--list: all namespaces)')
+    parser.add_argument('--size', default=DEFAULT_SIZE, help=f'Size of the image PVC (default: {DEFAULT_SIZE})')
+    parser.add_argument('--storage-class', default=None, help='Storage class of the image PVC (default: cluster default)')
+    parser.add_argument('--access-mode', default=None, choices=['ReadWriteOnce', 'ReadWriteMany'],
+                        help='Access mode of the image PVC (default: from the StorageProfile)')
+    parser.add_argument('--volume-mode', default=None, choices=['Block', 'Filesystem'],
+                        help='Volume mode of the image PVC (default: from the StorageProfile)')
+    parser.add_argument('--preference', default=None,
+                        help='Default VirtualMachinePreference of the DataSource, e.g. rhel.9')
+    parser.add_argument('--cert-configmap', default=None,
+                        help='ConfigMap with the CA bundle of the --import-url server')
+    parser.add_argument('--uploadproxy-url', default=None,
+                        help='URL of the CDI upload proxy (default: found by virtctl)')
+    parser.add_argument('--insecure', action='store_true',
+                        help='Do not verify the upload proxy certificate')
+    parser.add_argument('--timeout', type=int, default=DEFAULT_TIMEOUT,
+                        help=f'Seconds to wait for the upload or import and the DataSource (default: {DEFAULT_TIMEOUT})')
+    parser.add_argument('--poll-interval', type=int, default=DEFAULT_POLL_INTERVAL,
+                        help=f'Seconds between status checks (default: {DEFAULT_POLL_INTERVAL})')
+    parser.add_argument('--kubeconfig', type=str, default=None,
+                        help='Path to kubeconfig file')
+    parser.add_argument('--log-level', type=str, default='INFO',
+                        choices=['DEBUG', 'INFO', 'WARNING', 'ERROR'],
+                        help='Logging level (default: INFO)')
+
+    args = parser.parse_args()
+    if (args.upload or args.import_url) and not args.name:
+        parser.error("--name is required with --upload and --import-url")
+    if args.upload and not os.path.isfile(args.upload):
+        parser.error(f"Image file not found: {args.upload}")
+    return args
+
+
+def main():
+    """Main execution function"""
+    args = parse_args()
+
+    if args.kubeconfig:
+        os.environ['KUBECONFIG'] = args.kubeconfig
+
+    logger = setup_logging(log_file=None, log_level=args.log_level)
+
+    if not args.upload and not args.import_url:
+        report = list_images(args.namespace, logger)
+        print_images(report, logger)
+        emit('images', report)
+        return
+
+    args.namespace = args.namespace or default_datasource_namespace(logger)
+    if is_dry_run():
+        plan = DryRunPlan('images', logger)
+        plan_add_image(plan, args)
+        plan.report()
+        return
+
+    report = add_image(args, logger)
+    if report['error']:
+        logger.error(f"Image {args.namespace}/{args.name}: {report['error']}")
+    else:
+        logger.info(f"DataSource {args.namespace}/{args.name} is Ready; reference it in VM templates "
+                    f"(sourceRef name {args.name}, namespace {args.namespace}) or with --datasource-name")
+    emit('image', report)
+    sys.exit(0 if report['error'] is None else 1)
+
+
+if __name__ == '__main__':
+    main()
@@ -0,0 +1,268 @@
+#!/usr/bin/env python3
+"""
+Instancetypes and preferences of benchmark VMs.
+
+Instead of inline CPU and memory, a VM can reference a
+VirtualMachineClusterInstancetype (spec.instancetype), which sets its vCPUs
+and guest memory, and a VirtualMachineClusterPreference (spec.preference),
+which sets defaults such as disk and interface models. KubeVirt rejects VMs
+that set both, so apply_instancetype() removes the template's
+domain.cpu cores/sockets/threads, guest memory and CPU/memory resources.
+Namespaced VirtualMachineInstancetype/VirtualMachinePreference objects are
+used when no cluster-wide object of the name exists and the VMs share one
+namespace (namespace-scoped mode).
+
+The workloads write an instancetype copy of the VM template
+(write_instancetype_template()) and record the instancetype in their
+summary. `virtbench datasource-clone --instancetype u1.small,u1.medium,u1.large`
+runs the workload once per instancetype; this script then compares the runs:
+
+    <results>/instancetype-sweep/<timestamp>/<instancetype>/...   one run each
+    <results>/instancetype-sweep/<timestamp>/instancetype_sweep.json
+
+Exit codes:
+    0: comparison written
+    1: no run summary was found
+
+Usage:
+    python3 instancetype.py --sweep results/instancetype-sweep/20250101-120000
+"""
+
+import argparse
+import atexit
+import json
+import logging
+import os
+import sys
+import tempfile
+from typing import Dict, List, Optional
+
+import yaml
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
+
+from utils.common import setup_logging, run_kubectl_command
+from utils.output import emit
+from utils.tuning import load_summary
+
+CLUSTER_INSTANCETYPE = 'VirtualMachineClusterInstancetype'
+INSTANCETYPE = 'VirtualMachineInstancetype'
+CLUSTER_PREFERENCE = 'VirtualMachineClusterPreference'
+PREFERENCE = 'VirtualMachinePreference'
+
+# Metrics of the sweep table, when the runs recorded them
+SWEEP_METRICS = ['clone_duration_sec', 'running_time_sec', 'ping_time_sec']
+
+
+def get_object(kind: str, name: str, logger: logging.Logger, namespace: Optional[str] = None) -> Optional[Dict]:
+    """An instancetype or preference object, None when it does not exist."""
+    args = ['get', kind.lower(), name, '-o', 'json']
+    if namespace:
+        args += ['-n', namespace]
+    returncode, stdout, _ = run_kubectl_command(args, check=False, logger=logger)
+    if returncode != 0:
+        return None
+    try:
+        return json.loads(stdout)
+    except json.JSONDecodeError:
+        return None
+
+
+def resolve(name: str, cluster_kind: str, namespaced_kind: str, logger: logging.Logger,
+            namespace: Optional[str] = None) -> Optional[Dict]:
+    """
+    The cluster-wide object of the name, else the namespaced one in namespace.
+
+    Returns:
+        {'kind', 'name', 'spec'}, or None when neither exists
+    """
+    obj = get_object(cluster_kind, name, logger)
+    kind = cluster_kind
+    if obj is None and namespace:
+        obj = get_object(namespaced_kind, name, logger, namespace)
+        kind = namespaced_kind
+    if obj is None:
+        return None
+    return {'kind': kind, 'name': name, 'spec': obj.get('spec') or {}}
+
+
+def instancetype_settings(instancetype: Dict, preference: Optional[Dict] = None) -> Dict:
+    """Instancetype block of resolve() results, recorded in result summaries."""
+    spec = instancetype['spec']
+    return {
+        'instancetype': instancetype['name'],
+        'kind': instancetype['kind'],
+        'vcpus': (spec.get('cpu') or {}).get('guest'),
+        'memory': (spec.get('memory') or {}).get('guest'),
+        'preference': preference['name'] if preference else None,
+    }
+
+
+def apply_instancetype(vm: Dict, instancetype: Dict, preference: Optional[Dict] = None):
+    """Point a VirtualMachine at resolve() results, dropping the settings they own."""
+    spec = vm.setdefault('spec', {})
+    spec['instancetype'] = {'kind': instancetype['kind'], 'name': instancetype['name']}
+    if preference:
+        spec['preference'] = {'kind': preference['kind'], 'name': preference['name']}
+
+    domain = spec.setdefault('template', {}).setdefault('spec', {}).setdefault('domain', {})
+    cpu = domain.get('cpu') or {}
+    for key in ('cores', 'sockets', 'threads'):
+        cpu.pop(key, None)
+    if 'cpu' in domain and not cpu:
+        del domain['cpu']
+    memory = domain.get('memory') or {}
+    memory.pop('guest', None)
+    if 'memory' in domain and not memory:
+        del domain['memory']
+    resources = domain.get('resources') or {}
+    for group in ('requests', 'limits'):
+        for key in ('cpu', 'memory'):
+            (resources.get(group) or {}).pop(key, None)
+        if group in resources and not resources[group]:
+            del resources[group]
+    if 'resources' in domain and not resources:
+        del domain['resources']
+
+
+def write_instancetype_template(vm_template: str, instancetype: Dict, preference: Optional[Dict] = None) -> str:
+    """
+    Write a copy of the VM template using the instancetype; removed on exit.
+
+    Returns:
+        Path of the instancetype template
+
+    Raises:
+        OSError, yaml.YAMLError: If the template cannot be read
+    """
+    with open(vm_template, 'r') as f:
+        docs = [doc for doc in yaml.safe_load_all(f) if doc]
+    for doc in docs:
+        if doc.get('kind') == 'VirtualMachine':
+            apply_instancetype(doc, instancetype, preference)
+
+    fd, path = tempfile.mkstemp(prefix='virtbench-instancetype-', suffix='.yaml')
+    with os.fdopen(fd, 'w') as f:
+        yaml.safe_dump_all(docs, f, sort_keys=False)
+    atexit.register(lambda: os.path.exists(path) and os.remove(path))
+    return path
+
+
+def compare_sweep(summaries: Dict[str, Dict]) -> Dict:
+    """
+    Creation timings per instancetype.
+
+    Args:
+        summaries: Run summary by instancetype, in sweep order
+
+    Returns:
+        {'runs': [{'instancetype', 'vcpus', 'memory', 'total_vms', 'successful', 'failed',
+                   'metrics': {metric: {'avg', 'p95'}}}], 'fastest': {metric: instancetype}}
+    """
+    runs = []
+    for name, summary in summaries.items():
+        settings = summary.get('instancetype') or {}
+        runs.append({
+            'instancetype': name,
+            'vcpus': settings.get('vcpus'),
+            'memory': settings.get('memory'),
+            'total_vms': summary.get('total_vms'),
+            'successful': summary.get('successful'),
+            'failed': summary.get('failed'),
+            'metrics': {m['metric']: {'avg': m.get('avg'), 'p95': m.get('p95')}
+                        for m in summary.get('metrics', []) if 'metric' in m},
+        })
+
+    fastest = {}
+    for metric in SWEEP_METRICS:
+        timed = [run for run in runs if (run['metrics'].get(metric) or {}).get('avg') is not None]
+        if timed:
+            fastest[metric] = min(timed, key=lambda run: run['metrics'][metric]['avg'])['instancetype']
+    return {'runs': runs, 'fastest': fastest}
+
+
+def print_sweep(comparison: Dict, logger: logging.Logger):
+    """Log a compare_sweep() result."""
+    def fmt(value):
+        return '-' if value is None else value
+
+    metrics = [m for m in SWEEP_METRICS if m in comparison['fastest']]
+    logger.info("\n" + "=" * 80)
+    logger.info(f"INSTANCETYPE SWEEP: {len(comparison['runs'])} runs (average seconds)")
+    logger.info("=" * 80)
+    logger.info(f"{'Instancetype':<18} {'vCPUs':>5} {'Memory':>8} {'OK':>7}"
+                + ''.join(f" {m.replace('_sec', ''):>15}" for m in metrics))
+    logger.info("-" * 80)
+    for run in comparison['runs']:
+        ok = f"{fmt(run['successful'])}/{fmt(run['total_vms'])}"
+        logger.info(f"{run['instancetype']:<18} {fmt(run['vcpus']):>5} {fmt(run['memory']):>8} {ok:>7}"
+                    + ''.join(f" {fmt((run['metrics'].get(m) or {}).get('avg')):>15}" for m in metrics))
+    logger.info("-" * 80)
+    for metric, name in comparison['fastest'].items():
+        logger.info(f"Fastest {metric}: {name}")
+    logger.info("=" * 80)
+
+
+def parse_args():
+    """Parse command line arguments"""
+    parser = argparse.ArgumentParser(
+        description='Compare the runs of an instancetype sweep',
+        formatter_class=argparse.RawDescriptionHelpFormatter,
+        epilog="""
+Examples:
+  # Compare the runs of virtbench datasource-clone --instancetype u1.small,u1.medium,u1.large
+  %(prog)s --sweep results/instancetype-sweep/20250101-120000 --instancetypes u1.small u1.medium u1.large
+        """
+    )
+    parser.add_argument('--sweep', required=True, help='Sweep folder with one results folder per instancetype')
+    parser.add_argument('--instancetypes', nargs='+',
+                        help='Instancetypes in sweep order (default: every run folder, sorted)')
+    parser.add_argument('--output', help='Write the comparison as JSON to this file')
+    parser.add_argument(
+        '--log-level',
+        type=str,
+        default='INFO',
+        choices=['DEBUG', 'INFO', 'WARNING', 'ERROR'],
+        help='Logging level (default: INFO)'
+    )
+    return parser.parse_args()
+
+
+def main():
+    """Main execution function"""
+    args = parse_args()
+    logger = setup_logging(log_file=None, log_level=args.log_level)
+
+    if not os.path.isdir(args.sweep):
+        logger.error(f"Sweep folder not found: {args.sweep}")
+        sys.exit(1)
+    names: List[str] = args.instancetypes or sorted(
+        d for d in os.listdir(args.sweep) if os.path.isdir(os.path.join(args.sweep, d)))
+    summaries = {}
+    for name in names:
+        summary = load_summary(os.path.join(args.sweep, name))
+        if summary is None:
+            logger.warning(f"No results summary found for {name}; leaving it out")
+            continue
+        summaries[name] = summary
+    if not summaries:
+        logger.error(f"No results summary found in {args.sweep}")
+        sys.exit(1)
+
+    comparison = compare_sweep(summaries)
+    comparison['results'] = args.sweep
+    print_sweep(comparison, logger)
+
+    if args.output:
+        output_dir = os.path.dirname(args.output)
+        if output_dir:
+            os.makedirs(output_dir, exist_ok=True)
+        with open(args.output, 'w') as f:
+            json.dump(comparison, f, indent=2)
+        logger.info(f"Instancetype sweep written to {args.output}")
+    emit('instancetype-sweep', comparison)
+
+
+if __name__ == '__main__':
+    main()
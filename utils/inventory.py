@@ -0,0 +1,310 @@
+#!/usr/bin/env python3
+"""
+Cluster inventory snapshot for KubeVirt performance testing.
+
+Results are compared across months of runs on clusters that get upgraded,
+resized and re-plumbed in between. Before a workload starts, a snapshot of
+the cluster is taken and embedded in the result summary under ``cluster``,
+so an old result still says what it was measured on:
+
+- platform: openshift, harvester or kubernetes (utils.cluster_platform),
+  and the Kubernetes, OpenShift and Harvester versions
+- virtualization: KubeVirt and OpenShift Virtualization (CNV) versions
+- nodes: count, roles and CPU/memory capacity of each node, and whether it
+  runs SMT (from the Node Feature Discovery label, null without NFD)
+- storage: storage classes with their provisioners, CSI drivers and the
+  images of the CSI node plugins, and the Portworx version if installed
+- network: the cluster network plugin
+
+Every probe is best effort: an item that cannot be read (missing CRD, no
+permission) is recorded as null and never fails the run.
+
+The same probes label results folders: ``--storage-driver auto`` resolves to
+the backend of the storage class and its version, as detected by its storage
+provider (utils.storageprovider), for example ``portworx-3.1.2``,
+``odf-4.16.1``, ``ceph-18.2.1``, ``lvms-4.16.0`` or, for any other CSI
+driver, ``<provisioner>-<driver image tag>``.
+"""
+
+import json
+import logging
+import subprocess
+import threading
+from datetime import datetime, timezone
+from typing import Dict, List, Optional
+
+from utils.cluster_platform import HARVESTER, OPENSHIFT, detect_platform, harvester_version, openshift_version
+from utils.base import parse_quantity_bytes, run_kubectl_command
+
+INVENTORY_TIMEOUT = 30
+# Node Feature Discovery label of nodes with simultaneous multithreading (two logical CPUs per core)
+SMT_LABEL = 'feature.node.kubernetes.io/cpu-hardware_multithreading'
+STORAGE_DRIVER_AUTO = 'auto'
+
+PORTWORX_PROVISIONERS = ('pxd.portworx.com', 'kubernetes.io/portworx-volume')
+
+# CSI sidecar images, skipped when looking for a driver's own image
+CSI_SIDECAR_IMAGES = ('csi-', 'livenessprobe')
+
+# Daemonsets that identify the network plugin when the cluster does not report it
+NETWORK_PLUGIN_DAEMONSETS = {
+    'ovnkube-node': 'OVNKubernetes',
+    'sdn': 'OpenShiftSDN',
+    'calico-node': 'Calico',
+    'cilium': 'Cilium',
+    'kube-flannel-ds': 'Flannel',
+    'kube-router': 'kube-router',
+    'antrea-agent': 'Antrea',
+    'weave-net': 'Weave',
+}
+
+_inventory_lock = threading.Lock()
+_inventory: Optional[Dict] = None
+
+
+def _get_json(args: List[str], logger: Optional[logging.Logger] = None) -> Optional[Dict]:
+    """Run `kubectl <args> -o json`, returning the parsed output or None on any error."""
+    try:
+        rc, stdout, _ = run_kubectl_command(args + ['-o', 'json'], check=False,
+                                            timeout=INVENTORY_TIMEOUT, logger=logger)
+        return json.loads(stdout) if rc == 0 else None
+    except (OSError, subprocess.TimeoutExpired, json.JSONDecodeError):
+        return None
+
+
+def _image_tag(image: str) -> Optional[str]:
+    """Version part of an image reference (tag, or digest when pinned by digest)."""
+    if '@' in image:
+        return image.split('@', 1)[1]
+    name = image.rsplit('/', 1)[-1]
+    return name.split(':', 1)[1] if ':' in name else None
+
+
+def _platform(logger) -> Dict:
+    version = _get_json(['version'], logger) or {}
+    return {
+        'name': detect_platform(logger),
+        'kubernetes_version': (version.get('serverVersion') or {}).get('gitVersion'),
+        'openshift_version': openshift_version(logger),
+        'harvester_version': harvester_version(logger),
+    }
+
+
+def _virtualization(logger) -> Dict:
+    kubevirt = (_get_json(['get', 'kubevirt', '-A'], logger) or {}).get('items') or [{}]
+    namespace = kubevirt[0].get('metadata', {}).get('namespace')
+    cnv_version = None
+    if namespace:
+        csvs = (_get_json(['get', 'csv', '-n', namespace], logger) or {}).get('items', [])
+        for csv in csvs:
+            if csv.get('metadata', {}).get('name', '').startswith('kubevirt-hyperconverged-operator'):
+                cnv_version = csv.get('spec', {}).get('version')
+    return {
+        'namespace': namespace,
+        'kubevirt_version': kubevirt[0].get('status', {}).get('observedKubeVirtVersion'),
+        'cnv_version': cnv_version,
+    }
+
+
+def _nodes(logger) -> Optional[Dict]:
+    data = _get_json(['get', 'nodes'], logger)
+    if data is None:
+        return None
+    nodes = []
+    for node in data.get('items', []):
+        labels = node.get('metadata', {}).get('labels', {})
+        capacity = node.get('status', {}).get('capacity', {})
+        memory = parse_quantity_bytes(capacity.get('memory', ''))
+        nodes.append({
+            'name': node['metadata']['name'],
+            'roles': sorted(key.split('/', 1)[1] for key in labels
+                            if key.startswith('node-role.kubernetes.io/')),
+            'instance_type': labels.get('node.kubernetes.io/instance-type'),
+            'cpu': capacity.get('cpu'),
+            'memory_gib': round(memory / 2 ** 30, 1) if memory else None,
+            'smt': {'true': True, 'false': False}.get(labels.get(SMT_LABEL)),
+            'kubelet_version': node.get('status', {}).get('nodeInfo', {}).get('kubeletVersion'),
+            'os_image': node.get('status', {}).get('nodeInfo', {}).get('osImage'),
+        })
+    return {
+        'count': len(nodes),
+        'workers': sum(1 for n in nodes if 'worker' in n['roles']),
+        'items': nodes,
+    }
+
+
+def _containers(workload: Dict) -> List[Dict]:
+    return workload.get('spec', {}).get('template', {}).get('spec', {}).get('containers', [])
+
+
+def _portworx_version(daemonsets: List[Dict], logger) -> Optional[str]:
+    """Portworx version from the StorageCluster status, or the portworx daemonset image without the operator."""
+    clusters = (_get_json(['get', 'storagecluster', '-A'], logger) or {}).get('items')
+    if clusters:
+        return clusters[0].get('status', {}).get('version') or \
+            _image_tag(clusters[0].get('spec', {}).get('image', ''))
+    for ds in daemonsets:
+        if ds.get('metadata', {}).get('name') == 'portworx' and _containers(ds):
+            return _image_tag(_containers(ds)[0].get('image', ''))
+    return None
+
+
+def _operator_version(csv_prefix: str, logger) -> Optional[str]:
+    """Version of the OLM operator whose ClusterServiceVersion name starts with csv_prefix."""
+    for csv in (_get_json(['get', 'csv', '-A'], logger) or {}).get('items', []):
+        if csv.get('metadata', {}).get('name', '').startswith(csv_prefix + '.'):
+            return csv.get('spec', {}).get('version')
+    return None
+
+
+def _csi_driver_version(provisioner: str, daemonsets: List[Dict]) -> Optional[str]:
+    """Image tag of the CSI node plugin registered as `provisioner` (its registrar references the driver name)."""
+    for ds in daemonsets:
+        if provisioner not in json.dumps(ds.get('spec', {})):
+            continue
+        for container in _containers(ds):
+            image = container.get('image', '')
+            if not image.rsplit('/', 1)[-1].startswith(CSI_SIDECAR_IMAGES):
+                return _image_tag(image)
+    return None
+
+
+def _storage(daemonsets: List[Dict], logger) -> Dict:
+    classes = (_get_json(['get', 'storageclass'], logger) or {}).get('items')
+    drivers = (_get_json(['get', 'csidriver'], logger) or {}).get('items')
+
+    csi_images = set()
+    for ds in daemonsets:
+        for container in _containers(ds):
+            if 'csi' in container.get('name', '') or 'csi' in container.get('image', '').rsplit('/', 1)[-1]:
+                csi_images.add(container['image'])
+
+    return {
+        'storage_classes': None if classes is None else [
+            {
+                'name': sc['metadata']['name'],
+                'provisioner': sc.get('provisioner'),
+                'default': sc['metadata'].get('annotations', {}).get(
+                    'storageclass.kubernetes.io/is-default-class') == 'true',
+                'volume_binding_mode': sc.get('volumeBindingMode'),
+            }
+            for sc in classes
+        ],
+        'csi_drivers': None if drivers is None else sorted(d['metadata']['name'] for d in drivers),
+        'csi_images': sorted(csi_images),
+        'portworx_version': _portworx_version(daemonsets, logger),
+    }
+
+
+def _network_plugin(daemonsets: List[Dict], logger) -> Optional[str]:
+    network = _get_json(['get', 'network.config.openshift.io', 'cluster'], logger)
+    if network:
+        plugin = network.get('status', {}).get('networkType') or network.get('spec', {}).get('networkType')
+        if plugin:
+            return plugin
+    names = {ds.get('metadata', {}).get('name') for ds in daemonsets}
+    for name, plugin in NETWORK_PLUGIN_DAEMONSETS.items():
+        if name in names:
+            return plugin
+    return None
+
+
+def collect_cluster_inventory(logger: Optional[logging.Logger] = None) -> Dict:
+    """
+    Take a snapshot of the cluster.
+
+    Args:
+        logger: Logger instance
+
+    Returns:
+        Dict with captured_at, platform, virtualization, nodes, storage and
+        network_plugin; items that could not be read are None
+    """
+    daemonsets = (_get_json(['get', 'daemonset', '-A'], logger) or {}).get('items', [])
+    return {
+        'captured_at': datetime.now(timezone.utc).strftime('%Y-%m-%dT%H:%M:%SZ'),
+        'platform': _platform(logger),
+        'virtualization': _virtualization(logger),
+        'nodes': _nodes(logger),
+        'storage': _storage(daemonsets, logger),
+        'network_plugin': _network_plugin(daemonsets, logger),
+    }
+
+
+def cluster_inventory(logger: Optional[logging.Logger] = None) -> Dict:
+    """
+    The run's cluster snapshot, taken on the first call and reused afterwards.
+
+    Workloads call this before they start changing the cluster, so the
+    snapshot saved with the results describes the cluster as the run found it.
+    """
+    global _inventory
+    with _inventory_lock:
+        if _inventory is None:
+            _inventory = collect_cluster_inventory(logger)
+            if logger:
+                platform = _inventory['platform']
+                virt = _inventory['virtualization']
+                nodes = _inventory['nodes'] or {}
+                distribution = {OPENSHIFT: f"OpenShift {platform['openshift_version'] or 'n/a'}, ",
+                                HARVESTER: f"Harvester {platform['harvester_version'] or 'n/a'}, "}
+                logger.info(f"Cluster: {distribution.get(platform['name'], '')}"
+                            f"Kubernetes {platform['kubernetes_version'] or 'n/a'}, "
+                            f"KubeVirt {virt['kubevirt_version'] or 'n/a'}, "
+                            f"{nodes.get('count', 'n/a')} nodes, "
+                            f"network {_inventory['network_plugin'] or 'n/a'}")
+        return _inventory
+
+
+def detect_storage_driver(storage_class: Optional[str] = None,
+                          logger: Optional[logging.Logger] = None) -> Optional[str]:
+    """
+    Detect the storage backend behind a storage class, as a results folder label.
+
+    Args:
+        storage_class: Storage class name (default: the cluster's default storage class)
+        logger: Logger instance
+
+    Returns:
+        '<backend>-<version>' (or just '<backend>' when the version cannot be
+        read), or None if the storage class cannot be found
+    """
+    if storage_class:
+        classes = [_get_json(['get', 'storageclass', storage_class], logger)]
+    else:
+        classes = [sc for sc in (_get_json(['get', 'storageclass'], logger) or {}).get('items', [])
+                   if sc['metadata'].get('annotations', {}).get(
+                       'storageclass.kubernetes.io/is-default-class') == 'true']
+    provisioner = classes[0].get('provisioner') if classes and classes[0] else None
+    if not provisioner:
+        if logger:
+            logger.warning(f"Cannot detect the storage driver: storage class "
+                           f"{storage_class or '(default)'} not found")
+        return None
+
+    # Imported here because utils.storageprovider itself depends on this module
+    from utils.storageprovider import provider_for
+    try:
+        provider = provider_for(provisioner)
+    except ValueError as e:
+        if logger:
+            logger.warning(f"Cannot detect the storage driver: {e}")
+        return None
+    backend, version = provider.label, provider.version(logger)
+
+    driver = f"{backend}-{version.lstrip('v')}" if version and ':' not in version else backend
+    if logger:
+        logger.info(f"Detected storage driver {driver} (provisioner {provisioner})")
+    return driver
+
+
+def resolve_storage_driver(label: Optional[str], storage_class: Optional[str] = None,
+                           default: Optional[str] = None,
+                           logger: Optional[logging.Logger] = None) -> Optional[str]:
+    """
+    Resolve a --storage-driver value: 'auto' is replaced by detect_storage_driver(),
+    falling back to `default` when detection fails; any other value is kept.
+    """
+    if label != STORAGE_DRIVER_AUTO:
+        return label
+    return detect_storage_driver(storage_class, logger) or default
@@ -0,0 +1,207 @@
+#!/usr/bin/env python3
+"""
+Bulk namespace lifecycle for KubeVirt performance testing.
+
+Workloads that spread VMs over hundreds of namespaces used to create and
+delete them one kubectl call at a time. This module handles them in
+batches instead:
+
+- Creation server-side applies one manifest per batch of Namespace objects
+  (labeled with the run labels) and then watches the batch until every
+  namespace reports phase Active (`kubectl wait`).
+- Deletion deletes a batch in one call and then watches it until the
+  namespaces are gone, or only confirms they are Terminating when not
+  waiting.
+
+New namespaces also get the run's ResourceQuota and LimitRange, if any
+(see utils.quota). The batch size is the workload's --namespace-batch-size. Namespaces that
+already exist are left as they are: they are not relabeled, so run cleanup
+never selects a namespace this run did not create.
+"""
+
+import json
+import logging
+import subprocess
+from typing import Dict, List, Optional, Tuple
+
+from utils.apply import apply_manifest
+from utils.common import run_kubectl_command, run_labels
+from utils.concurrency import RateLimiter
+from utils.quota import apply_namespace_constraints
+from utils.tenants import setup_tenant_namespaces
+
+DEFAULT_BATCH_SIZE = 20
+ACTIVE_TIMEOUT = 120     # seconds a batch may take to become Active
+DELETE_TIMEOUT = 300     # seconds a batch may take to be fully deleted
+
+
+def batches(names: List[str], batch_size: int) -> List[List[str]]:
+    """Split names into consecutive batches of at most batch_size."""
+    size = max(1, int(batch_size or DEFAULT_BATCH_SIZE))
+    return [names[i:i + size] for i in range(0, len(names), size)]
+
+
+def namespace_manifest(names: List[str]) -> str:
+    """A v1 List of labeled Namespace objects for server-side apply."""
+    labels = run_labels()
+    return json.dumps({
+        'apiVersion': 'v1',
+        'kind': 'List',
+        'items': [
+            {'apiVersion': 'v1', 'kind': 'Namespace', 'metadata': {'name': name, 'labels': labels}}
+            for name in names
+        ],
+    })
+
+
+def namespace_phases(names: List[str], logger: Optional[logging.Logger] = None) -> Dict[str, str]:
+    """Phase (Active/Terminating) of each of the given namespaces that exists."""
+    if not names:
+        return {}
+    returncode, stdout, _ = run_kubectl_command(
+        ['get', 'namespace'] + names + ['--ignore-not-found', '-o', 'json'],
+        check=False, logger=logger
+    )
+    if returncode != 0 or not stdout.strip():
+        return {}
+    try:
+        data = json.loads(stdout)
+    except json.JSONDecodeError:
+        return {}
+    items = data.get('items', [data]) if data.get('kind') == 'List' else [data]
+    return {
+        item['metadata']['name']: (item.get('status') or {}).get('phase', '')
+        for item in items if item.get('metadata', {}).get('name')
+    }
+
+
+def _wait_for(names: List[str], condition: str, timeout: int,
+              logger: Optional[logging.Logger] = None) -> None:
+    """Watch the namespaces until the condition holds for all of them or the timeout passes."""
+    if not names:
+        return
+    try:
+        run_kubectl_command(
+            ['wait', f'--for={condition}', f'--timeout={timeout}s'] + [f'namespace/{name}' for name in names],
+            check=False, timeout=timeout + 30, logger=logger
+        )
+    except subprocess.TimeoutExpired:
+        if logger:
+            logger.warning(f"kubectl wait for {len(names)} namespaces did not return within {timeout + 30}s")
+
+
+def _apply_batch(batch: List[str], timeout: int,
+                 logger: Optional[logging.Logger] = None) -> Tuple[List[str], List[str]]:
+    """Create the missing namespaces of one batch and wait for them to become Active."""
+    existing = namespace_phases(batch, logger)
+    missing = [name for name in batch if name not in existing]
+    if existing and logger:
+        logger.debug(f"Namespaces already exist: {', '.join(sorted(existing))}")
+
+    if missing:
+        applied, error = apply_manifest(namespace_manifest(missing), logger=logger)
+        if not applied and logger:
+            logger.warning(f"Server-side apply of {len(missing)} namespaces reported errors: {error}")
+        _wait_for(missing, 'jsonpath={.status.phase}=Active', timeout, logger)
+
+    phases = namespace_phases(batch, logger)
+    ready = [name for name in batch if phases.get(name) == 'Active']
+    failed = [name for name in batch if phases.get(name) != 'Active']
+    for name in failed:
+        if logger:
+            logger.error(f"Namespace {name} is not Active (phase: {phases.get(name) or 'missing'})")
+
+    # Quota and limit range (virtbench --namespace-quota/--namespace-limit-range) of the new namespaces
+    created = [name for name in missing if name in ready]
+    if not apply_namespace_constraints(created, logger):
+        ready = [name for name in ready if name not in created]
+        failed.extend(created)
+    # Tenant RoleBindings (virtbench --tenants), also of reused namespaces
+    if not setup_tenant_namespaces(ready, logger):
+        failed.extend(ready)
+        ready = []
+    return ready, failed
+
+
+def create_namespaces(names: List[str], batch_size: int = DEFAULT_BATCH_SIZE,
+                      timeout: int = ACTIVE_TIMEOUT,
+                      logger: Optional[logging.Logger] = None) -> Tuple[List[str], List[str]]:
+    """
+    Create namespaces in batches and wait until they are Active.
+
+    Args:
+        names: Namespace names
+        batch_size: Namespaces applied per server-side apply call
+        timeout: Seconds each batch may take to become Active
+        logger: Logger instance
+
+    Returns:
+        Tuple of (active namespaces, failed namespaces)
+    """
+    if logger:
+        logger.info(f"Creating {len(names)} namespaces in batches of {batch_size}...")
+
+    active, failed = [], []
+    for batch in batches(names, batch_size):
+        ready, not_ready = _apply_batch(batch, timeout, logger)
+        active.extend(ready)
+        failed.extend(not_ready)
+
+    if logger:
+        logger.info(f"Namespace creation complete: {len(active)} successful, {len(failed)} failed")
+    return active, failed
+
+
+def delete_namespaces(names: List[str], batch_size: int = DEFAULT_BATCH_SIZE,
+                      wait: bool = False, timeout: int = DELETE_TIMEOUT,
+                      qps: float = 0, burst: int = 10,
+                      logger: Optional[logging.Logger] = None) -> Tuple[List[str], List[str]]:
+    """
+    Delete namespaces in batches.
+
+    Without wait a namespace counts as deleted once it is Terminating or
+    gone; with wait, once it is gone.
+
+    Args:
+        names: Namespace names
+        batch_size: Namespaces deleted per kubectl call
+        wait: Wait for the namespaces to be fully deleted
+        timeout: Seconds each batch may take to be deleted when waiting
+        qps: Maximum namespace deletions started per second (0 = unlimited)
+        burst: Maximum deletions started back-to-back when rate limited
+        logger: Logger instance
+
+    Returns:
+        Tuple of (deleted namespaces, failed namespaces)
+    """
+    if logger:
+        logger.info(f"Deleting {len(names)} namespaces in batches of {batch_size}...")
+
+    limiter = RateLimiter(qps, burst) if qps and qps > 0 else None
+    deleted, failed = [], []
+    for batch in batches(names, batch_size):
+        if limiter:
+            for _ in batch:
+                limiter.wait()
+        returncode, _, stderr = run_kubectl_command(
+            ['delete', 'namespace'] + batch + ['--ignore-not-found', '--wait=false'],
+            check=False, logger=logger
+        )
+        if returncode != 0 and logger:
+            logger.warning(f"Deleting {len(batch)} namespaces reported errors: {stderr.strip()}")
+        if wait:
+            _wait_for(batch, 'delete', timeout, logger)
+
+        phases = namespace_phases(batch, logger)
+        for name in batch:
+            phase = phases.get(name)
+            if phase is None or (not wait and phase == 'Terminating'):
+                deleted.append(name)
+            else:
+                failed.append(name)
+                if logger:
+                    logger.error(f"Namespace {name} was not deleted (phase: {phase})")
+
+    if logger:
+        logger.info(f"Namespace deletion complete: {len(deleted)} successful, {len(failed)} failed")
+    return deleted, failed
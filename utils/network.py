@@ -0,0 +1,173 @@
+#!/usr/bin/env python3
+"""
+Multus secondary networks (bridge, SR-IOV) for KubeVirt performance testing.
+
+A VM joins a secondary network through a NetworkAttachmentDefinition (NAD):
+spec.template.spec.networks gets a multus entry naming the NAD, and
+domain.devices.interfaces a matching interface whose binding fits the NAD's
+CNI plugin: 'bridge' for Linux bridge (and most other) plugins, 'sriov' for
+the SR-IOV CNI. The pod network stays the first interface, so ping tests
+still reach the VM on its default address.
+
+An SR-IOV VM only schedules when a node has a free virtual function (VF):
+the NAD's k8s.v1.cni.cncf.io/resourceName annotation names the VF pool, and
+the SR-IOV device plugin advertises the pool in the nodes' status.allocatable.
+check_networks() checks that every NAD exists, and for SR-IOV NADs that the
+run's VFs fit, so a run fails before creating VMs that would stay in the
+Scheduling phase.
+
+    networks = [parse_network('default/br1'), parse_network('sriov-ns/sriov-net')]
+    report = check_networks(networks, vms=40, logger=logger)
+    print_network_report(report, logger)
+"""
+
+import json
+import logging
+from typing import Dict, List, Optional, Tuple
+
+from utils.common import run_kubectl_command
+from utils.gpu import device_allocatable
+
+BRIDGE = 'bridge'
+SRIOV = 'sriov'
+
+DEFAULT_NAD_NAMESPACE = 'default'
+RESOURCE_NAME_ANNOTATION = 'k8s.v1.cni.cncf.io/resourceName'
+
+
+def parse_network(network: str, namespace: str = DEFAULT_NAD_NAMESPACE) -> Tuple[str, str]:
+    """'[namespace/]name' of a NAD as (namespace, name); the namespace defaults to namespace."""
+    ns, _, name = network.rpartition('/')
+    return ns or namespace, name
+
+
+def get_nad(namespace: str, name: str, logger: logging.Logger) -> Optional[Dict]:
+    """The NetworkAttachmentDefinition, or None when it does not exist."""
+    returncode, stdout, _ = run_kubectl_command(
+        ['get', 'network-attachment-definitions', name, '-n', namespace, '-o', 'json'],
+        check=False, logger=logger
+    )
+    if returncode != 0:
+        return None
+    try:
+        return json.loads(stdout)
+    except json.JSONDecodeError:
+        return None
+
+
+def nad_type(nad: Dict) -> Optional[str]:
+    """CNI plugin type of a NAD (the first plugin of a configuration list), None if unparsable."""
+    try:
+        config = json.loads(nad.get('spec', {}).get('config') or '{}')
+    except json.JSONDecodeError:
+        return None
+    if config.get('plugins'):
+        config = config['plugins'][0]
+    return config.get('type')
+
+
+def binding(cni_type: Optional[str]) -> str:
+    """KubeVirt interface binding for a NAD's CNI plugin."""
+    return SRIOV if cni_type == SRIOV else BRIDGE
+
+
+def add_networks(template_spec: Dict, networks: List[Dict]):
+    """
+    Attach secondary networks to a VM's spec.template.spec, after those it already has.
+
+    Args:
+        template_spec: spec.template.spec of the VM
+        networks: [{'namespace', 'name', 'binding'}], e.g. from check_networks()
+    """
+    vm_networks = template_spec.setdefault('networks', [])
+    interfaces = template_spec.setdefault('domain', {}).setdefault('devices', {}).setdefault('interfaces', [])
+    for network in networks:
+        net_name = f"net{len(vm_networks)}"
+        vm_networks.append({'name': net_name,
+                            'multus': {'networkName': f"{network['namespace']}/{network['name']}"}})
+        interfaces.append({'name': net_name, network['binding']: {}})
+
+
+def check_networks(networks: List[Tuple[str, str]], vms: int, logger: logging.Logger,
+                   node: Optional[str] = None) -> Dict:
+    """
+    Whether vms VMs attached to the given NADs can be scheduled.
+
+    VFs already in use by running pods and VMs are not subtracted, so a run
+    that fits here may still leave some VMs in the Scheduling phase.
+
+    Args:
+        networks: NADs as (namespace, name), e.g. from parse_network()
+        vms: Number of VMs of the run
+        logger: Logger instance
+        node: Only count the VFs of this node (single-node runs)
+
+    Returns:
+        {'networks': [{'namespace', 'name', 'exists', 'type', 'binding', 'resource', 'nodes', 'allocatable'}],
+         'vms', 'vms_fit', 'errors', 'warnings'}; the run can proceed without errors.
+        vms_fit is None without SR-IOV networks.
+    """
+    found, errors, warnings = [], [], []
+    per_vm: Dict[str, int] = {}
+    for namespace, name in networks:
+        nad = get_nad(namespace, name, logger)
+        if nad is None:
+            errors.append(f"NetworkAttachmentDefinition {namespace}/{name} not found")
+        cni_type = nad_type(nad) if nad else None
+        if nad and cni_type is None:
+            warnings.append(f"Cannot read the CNI type of {namespace}/{name}; attaching it with a bridge binding")
+        resource = (nad or {}).get('metadata', {}).get('annotations', {}).get(RESOURCE_NAME_ANNOTATION)
+        if cni_type == SRIOV and not resource:
+            errors.append(f"SR-IOV network {namespace}/{name} has no {RESOURCE_NAME_ANNOTATION} annotation")
+        nodes = {}
+        if cni_type == SRIOV and resource:
+            per_vm[resource] = per_vm.get(resource, 0) + 1
+            nodes = {n: count for n, count in device_allocatable(resource, logger).items()
+                     if node is None or n == node}
+            if not nodes:
+                errors.append(f"No schedulable node{' ' + node if node else ''} has VFs of {resource}: "
+                              f"the SR-IOV device plugin is not running or the node policy matched no NICs")
+        found.append({
+            'namespace': namespace,
+            'name': name,
+            'exists': nad is not None,
+            'type': cni_type,
+            'binding': binding(cni_type),
+            'resource': resource,
+            'nodes': nodes,
+            'allocatable': sum(nodes.values()),
+        })
+
+    # VMs per node are bounded by the scarcest of their VF pools
+    fit: Optional[Dict[str, int]] = None
+    for resource, count in per_vm.items():
+        pool = next(n['nodes'] for n in found if n['resource'] == resource)
+        node_fit = {n: vfs // count for n, vfs in pool.items()}
+        fit = node_fit if fit is None else {n: min(c, node_fit.get(n, 0)) for n, c in fit.items()}
+    vms_fit = sum(fit.values()) if fit is not None else None
+    if vms_fit is not None and vms_fit < vms:
+        errors.append(f"Only {vms_fit} of {vms} SR-IOV VMs fit on the allocatable VFs")
+    return {'networks': found, 'vms': vms, 'vms_fit': vms_fit, 'errors': errors, 'warnings': warnings}
+
+
+def print_network_report(report: Dict, logger: logging.Logger):
+    """Log a check_networks() report."""
+    logger.info("\n" + "=" * 80)
+    logger.info("SECONDARY NETWORK PREFLIGHT")
+    logger.info("=" * 80)
+    for network in report['networks']:
+        if not network['exists']:
+            logger.info(f"{network['namespace']}/{network['name']}: not found")
+            continue
+        pool = f", VF pool {network['resource']}" if network['type'] == SRIOV and network['resource'] else ''
+        logger.info(f"{network['namespace']}/{network['name']}: {network['type'] or 'unknown'} CNI, "
+                    f"{network['binding']} binding{pool}")
+        for node, count in sorted(network['nodes'].items()):
+            logger.info(f"  {node:<40} {count} VFs allocatable")
+    if report['vms_fit'] is not None:
+        logger.info(f"SR-IOV VMs that fit: {report['vms_fit']} (planned: {report['vms']})")
+    for warning in report['warnings']:
+        logger.warning(warning)
+    for error in report['errors']:
+        logger.error(error)
+    logger.info("=" * 80)
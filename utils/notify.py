@@ -0,0 +1,337 @@
+#!/usr/bin/env python3
+"""
+Phase notifications for KubeVirt performance testing.
+
+Long suites can run for hours on a jumphost few people have access to. A
+notification file lists where to send an event whenever a workload reaches a
+phase boundary (for example "all VMs created" or "evacuation complete") or
+finishes, together with the key metrics of that phase.
+
+When a run finishes it sends a run summary ("run-complete": workload,
+duration, pass/fail, key percentiles and a link to the results); when it
+fails before that (an error or an early exit) it sends "run-failed".
+
+The file is passed with ``virtbench --notify-config FILE`` or the
+VIRTBENCH_NOTIFY_CONFIG environment variable:
+
+    notifiers:
+      # POST to a webhook; format: slack sends {"text": ...}, teams a message
+      # card, json (default) the event itself
+      - type: webhook
+        url_env: SLACK_WEBHOOK_URL     # or url: https://hooks.slack.com/services/...
+        format: slack
+      - type: webhook
+        url_env: TEAMS_WEBHOOK_URL
+        format: teams
+      # Run a command with the event as JSON on stdin
+      - type: command
+        command: ./scripts/notify.sh
+    # Optional: only these phases are sent (default: all)
+    phases: [vms-created, evacuation-complete, run-complete, run-failed]
+    # Optional: base URL the results directories are published under; the
+    # run summary links to <results_url>/<results directory name>
+    results_url: https://benchmarks.example.com/results
+
+Notifications are best effort: a failing notifier is logged and never fails
+the run.
+"""
+
+import atexit
+import json
+import logging
+import os
+import shlex
+import socket
+import subprocess
+import sys
+import threading
+import time
+import urllib.request
+from datetime import datetime, timezone
+from typing import Dict, Optional
+
+import yaml
+
+from utils.dryrun import is_dry_run
+from utils.stats import percentile
+
+NOTIFY_CONFIG_ENV = 'VIRTBENCH_NOTIFY_CONFIG'
+DEFAULT_NOTIFY_TIMEOUT = 10
+RUN_PERCENTILES = (50, 95, 99)
+
+# Webhook formats and the theme color of a Teams card per event status
+WEBHOOK_FORMATS = ('json', 'slack', 'teams')
+TEAMS_COLORS = {'ok': '2EB886', 'partial': 'DAA038', 'failed': 'D63333'}
+
+_config_lock = threading.Lock()
+_config_cache: Dict[str, Optional[Dict]] = {}
+_run = {'workload': None, 'started': None, 'finished': False, 'error': None}
+
+
+def load_notify_config(path: Optional[str] = None,
+                       logger: Optional[logging.Logger] = None) -> Optional[Dict]:
+    """
+    Load a notification file.
+
+    Args:
+        path: YAML file path (default: $VIRTBENCH_NOTIFY_CONFIG)
+        logger: Logger instance
+
+    Returns:
+        Parsed config with a non-empty notifiers list, or None if no file is
+        configured or it is invalid
+    """
+    path = path or os.getenv(NOTIFY_CONFIG_ENV)
+    if not path:
+        return None
+    with _config_lock:
+        if path in _config_cache:
+            return _config_cache[path]
+        config = None
+        try:
+            with open(os.path.expanduser(path)) as f:
+                config = yaml.safe_load(f) or {}
+        except (OSError, yaml.YAMLError) as e:
+            if logger:
+                logger.warning(f"Could not read notify config {path}: {e}")
+
+        if config is not None:
+            notifiers = [n for n in config.get('notifiers') or []
+                         if n.get('type') in ('webhook', 'command')]
+            if notifiers:
+                config['notifiers'] = notifiers
+            else:
+                if logger:
+                    logger.warning(f"Notify config {path} defines no webhook or command notifiers")
+                config = None
+        _config_cache[path] = config
+        return config
+
+
+def format_message(event: Dict) -> str:
+    """Render an event as a short human-readable message."""
+    status = '' if event['status'] == 'ok' else f" [{event['status'].upper()}]"
+    cluster = f", cluster {event['cluster']}" if event.get('cluster') else ''
+    lines = [f"virtbench {event['workload']}: {event['phase']}{status} "
+             f"(run {event['uuid'] or 'n/a'} on {event['host']}{cluster})"]
+    if event.get('duration_sec') is not None:
+        lines.append(f"  duration: {format_duration(event['duration_sec'])}")
+    for key, value in (event.get('metrics') or {}).items():
+        lines.append(f"  {key}: {value}")
+    if event.get('results'):
+        lines.append(f"  results: {event['results']}")
+    return '\n'.join(lines)
+
+
+def format_duration(seconds: float) -> str:
+    """Render a duration as e.g. "1h02m05s" or "3m20s"."""
+    minutes, seconds = divmod(int(seconds), 60)
+    hours, minutes = divmod(minutes, 60)
+    return f"{hours}h{minutes:02d}m{seconds:02d}s" if hours else f"{minutes}m{seconds:02d}s"
+
+
+def teams_card(event: Dict) -> Dict:
+    """Render an event as a Microsoft Teams (Office 365 connector) message card."""
+    status = '' if event['status'] == 'ok' else f" [{event['status'].upper()}]"
+    facts = [{'name': 'Run', 'value': event['uuid'] or 'n/a'},
+             {'name': 'Host', 'value': event['host']}]
+    if event.get('duration_sec') is not None:
+        facts.append({'name': 'Duration', 'value': format_duration(event['duration_sec'])})
+    facts += [{'name': key, 'value': str(value)} for key, value in (event.get('metrics') or {}).items()]
+    card = {
+        '@type': 'MessageCard',
+        '@context': 'https://schema.org/extensions',
+        'themeColor': TEAMS_COLORS.get(event['status'], TEAMS_COLORS['ok']),
+        'summary': f"virtbench {event['workload']}: {event['phase']}{status}",
+        'sections': [{'activityTitle': f"virtbench {event['workload']}: {event['phase']}{status}",
+                      'facts': facts}],
+    }
+    if event.get('results'):
+        if event['results'].startswith(('http://', 'https://')):
+            card['potentialAction'] = [{'@type': 'OpenUri', 'name': 'Results',
+                                        'targets': [{'os': 'default', 'uri': event['results']}]}]
+        else:
+            facts.append({'name': 'Results', 'value': event['results']})
+    return card
+
+
+def _send_webhook(notifier: Dict, event: Dict) -> None:
+    """POST the event to notifier.url (or the URL in notifier.url_env)."""
+    url = notifier.get('url') or os.getenv(notifier.get('url_env', ''), '')
+    if not url:
+        raise ValueError("webhook notifier has no url (url or url_env)")
+    fmt = notifier.get('format', 'json')
+    if fmt not in WEBHOOK_FORMATS:
+        raise ValueError(f"unknown webhook format '{fmt}' (expected one of {', '.join(WEBHOOK_FORMATS)})")
+    if fmt == 'slack':
+        body = {'text': format_message(event)}
+    elif fmt == 'teams':
+        body = teams_card(event)
+    else:
+        body = event
+    request = urllib.request.Request(url, data=json.dumps(body).encode(),
+                                     headers={'Content-Type': 'application/json'})
+    timeout = notifier.get('timeout', DEFAULT_NOTIFY_TIMEOUT)
+    with urllib.request.urlopen(request, timeout=timeout) as response:
+        response.read()
+
+
+def _send_command(notifier: Dict, event: Dict) -> None:
+    """Run notifier.command with the event as JSON on stdin."""
+    command = notifier.get('command')
+    if not command:
+        raise ValueError("command notifier has no command")
+    if isinstance(command, str):
+        command = shlex.split(command)
+    result = subprocess.run(command, input=json.dumps(event), capture_output=True, text=True,
+                            timeout=notifier.get('timeout', DEFAULT_NOTIFY_TIMEOUT))
+    if result.returncode != 0:
+        raise RuntimeError(f"exit code {result.returncode}: {result.stderr.strip()}")
+
+
+def phase_status(failed: int, total: int) -> str:
+    """Event status for a phase in which `failed` of `total` operations failed."""
+    if not failed:
+        return 'ok'
+    return 'failed' if failed >= total else 'partial'
+
+
+def duration_metrics(name: str, values) -> Dict:
+    """Average and maximum of a list of durations as {avg_<name>: ..., max_<name>: ...}, skipping None."""
+    values = [v for v in values if v is not None]
+    if not values:
+        return {}
+    return {f"avg_{name}": round(sum(values) / len(values), 2), f"max_{name}": round(max(values), 2)}
+
+
+def percentile_metrics(name: str, values, percentiles=RUN_PERCENTILES) -> Dict:
+    """Nearest-rank percentiles of a list of durations as {p50_<name>: ..., p95_<name>: ...}, skipping None."""
+    values = [v for v in values if v is not None]
+    if not values:
+        return {}
+    return {f"p{pct}_{name}": round(percentile(values, pct), 2) for pct in percentiles}
+
+
+def results_link(results_dir: Optional[str], config: Optional[Dict] = None) -> Optional[str]:
+    """
+    Link to a results directory for the run summary.
+
+    <results_url>/<directory name> if the notify config sets results_url,
+    else "<host>:<absolute path>".
+    """
+    if not results_dir:
+        return None
+    base = (config or {}).get('results_url')
+    if base:
+        return f"{base.rstrip('/')}/{os.path.basename(os.path.normpath(results_dir))}"
+    return f"{socket.gethostname()}:{os.path.abspath(results_dir)}"
+
+
+def notify_phase(workload: str, phase: str, metrics: Optional[Dict] = None, status: str = 'ok',
+                 logger: Optional[logging.Logger] = None, config: Optional[Dict] = None,
+                 duration_sec: Optional[float] = None, results: Optional[str] = None) -> int:
+    """
+    Send a phase-boundary event to every configured notifier.
+
+    Args:
+        workload: Workload name (for example 'migration')
+        phase: Phase that just finished (for example 'vms-created', 'run-complete')
+        metrics: Key metrics of the phase, sent as-is (keep it small)
+        status: 'ok', 'partial' (some operations failed) or 'failed'
+        logger: Logger instance
+        config: Parsed notify config (default: load_notify_config())
+        duration_sec: Optional duration, sent with run summaries
+        results: Optional link to the results, sent with run summaries
+
+    Returns:
+        Number of notifiers the event was delivered to
+    """
+    config = config or load_notify_config(logger=logger)
+    if not config:
+        return 0
+    phases = config.get('phases')
+    if phases and phase not in phases:
+        return 0
+
+    event = {
+        'workload': workload,
+        'phase': phase,
+        'status': status,
+        'uuid': os.getenv('VIRTBENCH_UUID'),
+        'host': socket.gethostname(),
+        'timestamp': datetime.now(timezone.utc).strftime('%Y-%m-%dT%H:%M:%SZ'),
+        'metrics': metrics or {},
+    }
+    if os.getenv('VIRTBENCH_CLUSTER'):
+        # Set for each cluster of a multi-cluster run (virtbench --kubeconfig A --kubeconfig B)
+        event['cluster'] = os.getenv('VIRTBENCH_CLUSTER')
+    if duration_sec is not None:
+        event['duration_sec'] = round(duration_sec, 2)
+    if results:
+        event['results'] = results
+    senders = {'webhook': _send_webhook, 'command': _send_command}
+    delivered = 0
+    for notifier in config['notifiers']:
+        try:
+            senders[notifier['type']](notifier, event)
+            delivered += 1
+        except Exception as e:
+            if logger:
+                logger.warning(f"Notification '{phase}' via {notifier['type']} failed: {e}")
+    if logger and delivered:
+        logger.debug(f"Notification '{phase}' sent to {delivered} notifier(s)")
+    return delivered
+
+
+def watch_run(workload: str, logger: Optional[logging.Logger] = None):
+    """
+    Start timing the run and send "run-failed" if it ends without notify_run().
+
+    Call once, right after argument parsing. An uncaught error is included
+    in the event. Does nothing without a notify config, and in dry runs.
+    """
+    if is_dry_run() or not load_notify_config(logger=logger) or _run['started'] is not None:
+        return
+    _run.update(workload=workload, started=time.monotonic())
+    previous_hook = sys.excepthook
+
+    def record_error(exc_type, exc, tb):
+        _run['error'] = 'interrupted' if issubclass(exc_type, KeyboardInterrupt) else f"{exc_type.__name__}: {exc}"
+        previous_hook(exc_type, exc, tb)
+
+    sys.excepthook = record_error
+    atexit.register(_notify_unfinished, logger)
+
+
+def _run_duration() -> Optional[float]:
+    return time.monotonic() - _run['started'] if _run['started'] is not None else None
+
+
+def _notify_unfinished(logger: Optional[logging.Logger]):
+    if _run['finished']:
+        return
+    notify_phase(_run['workload'], 'run-failed', {'error': _run['error'] or 'exited before the run completed'},
+                 'failed', logger=logger, duration_sec=_run_duration())
+
+
+def notify_run(workload: str, metrics: Dict, status: str, results_dir: Optional[str] = None,
+               logger: Optional[logging.Logger] = None) -> int:
+    """
+    Send the run summary ("run-complete") when a workload finishes.
+
+    Args:
+        workload: Workload name (for example 'migration')
+        metrics: Key results, e.g. VM counts and percentile_metrics() of the main duration
+        status: 'ok', 'partial' or 'failed' (see phase_status)
+        results_dir: Results directory of the run, linked in the summary (None if not saved)
+        logger: Logger instance
+
+    Returns:
+        Number of notifiers the summary was delivered to
+    """
+    _run['finished'] = True
+    config = load_notify_config(logger=logger)
+    if not config:
+        return 0
+    return notify_phase(workload, 'run-complete', metrics, status, logger=logger, config=config,
+                        duration_sec=_run_duration(), results=results_link(results_dir, config))
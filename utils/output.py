@@ -0,0 +1,70 @@
+#!/usr/bin/env python3
+"""
+Machine-readable output for KubeVirt performance testing.
+
+With the global ``virtbench --output json`` (or ``yaml``) option, or
+VIRTBENCH_OUTPUT=json, a command writes its summary (validation results,
+capacity estimate, migration summary, clone comparison, ...) to stdout as a
+single JSON or YAML document, and everything meant for humans - log lines,
+banners and tables - goes to stderr instead. Scripts and CI pipelines can
+then consume stdout directly:
+
+    virtbench --output json validate-cluster | jq '.data.status'
+
+Each document has the form {"kind": "<summary kind>", "data": {...}}.
+With the default table output, emit() does nothing.
+"""
+
+import json
+import os
+import sys
+from typing import Any
+
+import yaml
+
+OUTPUT_ENV = 'VIRTBENCH_OUTPUT'
+FORMATS = ('table', 'json', 'yaml')
+
+
+def output_format() -> str:
+    """Return the requested output format: 'table' (default), 'json' or 'yaml'."""
+    value = os.environ.get(OUTPUT_ENV, '').lower()
+    return value if value in FORMATS else 'table'
+
+
+def machine_output() -> bool:
+    """Return True if summaries should be written to stdout as JSON or YAML."""
+    return output_format() != 'table'
+
+
+def route_human_output():
+    """
+    Send human-readable output to stderr in json/yaml mode.
+
+    Called before console log handlers are created, so that stdout only
+    carries the documents written by emit().
+    """
+    if machine_output():
+        sys.stdout = sys.stderr
+
+
+def emit(kind: str, data: Any):
+    """
+    Write a summary document to stdout in the requested format.
+
+    Args:
+        kind: Kind of summary, e.g. "validation-report" or "migration-summary"
+        data: JSON-serializable summary
+    """
+    fmt = output_format()
+    if fmt == 'table':
+        return
+    document = {'kind': kind, 'data': data}
+    sys.stderr.flush()
+    if fmt == 'json':
+        sys.__stdout__.write(json.dumps(document, indent=2, default=str) + '\n')
+    else:
+        # Round trip through JSON so YAML sees plain types (tuples, datetimes, ...)
+        sys.__stdout__.write(yaml.safe_dump(json.loads(json.dumps(document, default=str)), sort_keys=False,
+                                        explicit_start=True))
+    sys.__stdout__.flush()
@@ -0,0 +1,435 @@
+#!/usr/bin/env python3
+"""
+VM placement strategies for KubeVirt performance testing.
+
+A placement strategy decides which node each VM (or migration) targets, and
+in which order the items are submitted:
+
+    none        the scheduler picks the node; items keep their order (default)
+    spread      every item goes to the node with the fewest items so far (round-robin)
+    pack        fill the nodes one after another, up to --max-vms-per-node each
+    interleave  contiguous blocks of items per node, submitted in interleaved order:
+                item 1 of node 1, item 1 of node 2, ..., item 2 of node 1, ...
+    nodes       spread over an explicit node list (--placement-nodes)
+    zone        spread over topology zones (topology.kubernetes.io/zone, or the
+                --topology-key label, e.g. a rack) first, then over the nodes of each zone
+
+Every strategy can be restricted to the nodes of some zones (--zone); with no
+other strategy, a zone restriction places like 'spread'.
+
+    strategy = get_strategy('zone', logger=logger)
+    assignment = strategy.assign(namespaces, get_worker_nodes(logger))   # {ns: node}
+    for ns in strategy.order(namespaces, assignment): ...
+    summary["placement"] = strategy.describe(assignment)
+
+Strategies that fill nodes across batches (density workloads creating VMs
+iteration by iteration) pass the items already placed per node, so a later
+batch continues where the previous one stopped.
+
+Whatever decided the nodes, placement_quality() reports where the VMs ended
+up once they run: VMs per node and their spread, the spread over zones,
+whether the VMs' pod anti-affinity was honored, and hot nodes, which hold
+far more VMs than the average and skew migration tests run on these VMs:
+
+    quality = placement_quality(placements, strategy.candidates(workers), logger=logger)
+    print_placement_quality(quality, logger)
+"""
+
+import json
+import logging
+from collections import Counter
+from typing import Dict, List, Optional, Tuple
+
+from utils.common import get_node_zones, run_kubectl_command, ZONE_LABEL
+from utils.stats import coefficient_of_variation, stddev
+
+STRATEGY_NONE = 'none'
+
+HOSTNAME_LABEL = 'kubernetes.io/hostname'
+# A node is hot when it holds more than HOT_NODE_FACTOR times the mean VMs per
+# node, and at least HOT_NODE_MIN_EXCESS VMs more than the mean
+HOT_NODE_FACTOR = 1.5
+HOT_NODE_MIN_EXCESS = 2
+# Label virt-controller sets on every virt-launcher pod, next to the VMI's labels
+LAUNCHER_LABELS = {'kubevirt.io': 'virt-launcher'}
+
+
+class PlacementStrategy:
+    """No placement: the scheduler picks the node and items keep their order."""
+
+    name = STRATEGY_NONE
+
+    def __init__(self, nodes: Optional[List[str]] = None, max_per_node: Optional[int] = None,
+                 logger: Optional[logging.Logger] = None, zones: Optional[List[str]] = None,
+                 topology_key: str = ZONE_LABEL):
+        """
+        Args:
+            nodes: Explicit node list; replaces the candidate nodes (required by 'nodes')
+            max_per_node: Items per node before moving to the next one ('pack')
+            logger: Logger instance
+            zones: Only place on nodes in these zones
+            topology_key: Node label of the zone ('zone' strategy and zones)
+        """
+        self.nodes = list(nodes or [])
+        self.max_per_node = max_per_node
+        self.logger = logger
+        self.zones = list(zones or [])
+        self.topology_key = topology_key
+        self.node_zones: Dict[str, Optional[str]] = {}
+
+    def candidates(self, nodes: List[str]) -> List[str]:
+        """Nodes this strategy may place on; looks up their zones when restricted to zones."""
+        nodes = self.nodes or list(nodes)
+        if not self.zones:
+            return nodes
+        self.node_zones = get_node_zones(nodes, self.topology_key, self.logger)
+        in_zones = [n for n in nodes if self.node_zones[n] in self.zones]
+        if self.logger and not in_zones:
+            self.logger.warning(f"No candidate node has {self.topology_key} in {', '.join(self.zones)}")
+        return in_zones
+
+    def pick(self, nodes: List[str], placed: Counter) -> Optional[str]:
+        """Node of the next item, given the items placed per node so far (None: leave to the scheduler)."""
+        return None
+
+    def assign(self, items: List[str], nodes: List[str],
+               placed: Optional[Counter] = None) -> Dict[str, Optional[str]]:
+        """
+        Node of every item.
+
+        Args:
+            items: Items to place, e.g. namespaces
+            nodes: Candidate nodes, e.g. the Ready workers
+            placed: Items already on each node from an earlier batch (updated in place)
+
+        Returns:
+            {item: node or None}
+        """
+        nodes = self.candidates(nodes)
+        placed = Counter() if placed is None else placed
+        assignment = {}
+        for item in items:
+            node = self.pick(nodes, placed) if nodes else None
+            assignment[item] = node
+            if node:
+                placed[node] += 1
+        return assignment
+
+    def order(self, items: List[str], assignment: Optional[Dict[str, Optional[str]]] = None) -> List[str]:
+        """Submission order of the items."""
+        return list(items)
+
+    def describe(self, assignment: Optional[Dict[str, Optional[str]]] = None) -> Dict:
+        """Placement block recorded in result summaries."""
+        per_node = Counter(node for node in (assignment or {}).values() if node)
+        block = {
+            'strategy': self.name,
+            'nodes': self.nodes or None,
+            'max_per_node': self.max_per_node,
+            'per_node': dict(sorted(per_node.items())),
+        }
+        if self.zones:
+            block['zones'] = self.zones
+            block['topology_key'] = self.topology_key
+        return block
+
+
+class SpreadPlacement(PlacementStrategy):
+    """Each item goes to the node with the fewest items so far (round-robin from empty nodes)."""
+
+    name = 'spread'
+
+    def pick(self, nodes, placed):
+        return min(nodes, key=lambda n: placed[n])
+
+
+class PackPlacement(PlacementStrategy):
+    """Fill nodes in order, max_per_node items each; the rest is left to the scheduler."""
+
+    name = 'pack'
+
+    def pick(self, nodes, placed):
+        for node in nodes:
+            if self.max_per_node is None or placed[node] < self.max_per_node:
+                return node
+        return None
+
+
+class InterleavePlacement(PlacementStrategy):
+    """Contiguous blocks of items per node, submitted one item per node in turn."""
+
+    name = 'interleave'
+
+    def assign(self, items, nodes, placed=None):
+        nodes = self.candidates(nodes)
+        placed = Counter() if placed is None else placed
+        assignment = {}
+        for i, item in enumerate(items):
+            node = nodes[i * len(nodes) // len(items)] if nodes else None
+            assignment[item] = node
+            if node:
+                placed[node] += 1
+        return assignment
+
+    def order(self, items, assignment=None):
+        if assignment is None:
+            return list(items)
+        per_node: Dict[Optional[str], List[str]] = {}
+        for item in items:
+            per_node.setdefault(assignment.get(item), []).append(item)
+        return interleave(per_node, list(per_node))
+
+
+class NodeListPlacement(SpreadPlacement):
+    """Spread over an explicit node list."""
+
+    name = 'nodes'
+
+
+class ZonePlacement(PlacementStrategy):
+    """Spread over zones first (topology.kubernetes.io/zone), then over the nodes of each zone."""
+
+    name = 'zone'
+
+    def candidates(self, nodes):
+        nodes = super().candidates(nodes)
+        if not self.zones:
+            self.node_zones = get_node_zones(nodes, self.topology_key, self.logger)
+        if self.logger and not any(self.node_zones.get(n) for n in nodes):
+            self.logger.warning(f"No node has a {self.topology_key} label; zone placement spreads over nodes only")
+        return nodes
+
+    def pick(self, nodes, placed):
+        per_zone = Counter()
+        for node in nodes:
+            per_zone[self.node_zones.get(node)] += placed[node]
+        return min(nodes, key=lambda n: (per_zone[self.node_zones.get(n)], placed[n]))
+
+    def describe(self, assignment=None):
+        block = super().describe(assignment)
+        per_zone = Counter(self.node_zones.get(node) or 'none' for node in (assignment or {}).values() if node)
+        block['topology_key'] = self.topology_key
+        block['per_zone'] = dict(sorted(per_zone.items()))
+        return block
+
+
+STRATEGIES = {
+    strategy.name: strategy
+    for strategy in (PlacementStrategy, SpreadPlacement, PackPlacement, InterleavePlacement,
+                     NodeListPlacement, ZonePlacement)
+}
+
+
+def get_strategy(name: str, nodes: Optional[List[str]] = None, max_per_node: Optional[int] = None,
+                 logger: Optional[logging.Logger] = None, zones: Optional[List[str]] = None,
+                 topology_key: str = ZONE_LABEL) -> PlacementStrategy:
+    """
+    Placement strategy by name; 'none' restricted to zones places like 'spread'.
+
+    Raises:
+        ValueError: For an unknown strategy, or 'nodes' without a node list
+    """
+    if name not in STRATEGIES:
+        raise ValueError(f"unknown placement strategy '{name}' (choose from {', '.join(STRATEGIES)})")
+    if name == NodeListPlacement.name and not nodes:
+        raise ValueError("placement strategy 'nodes' needs a node list")
+    if name == STRATEGY_NONE and zones:
+        name = SpreadPlacement.name
+    return STRATEGIES[name](nodes, max_per_node, logger, zones, topology_key)
+
+
+def interleave(per_node: Dict, node_order: List) -> List[str]:
+    """
+    Interleave per-node item lists: item 1 of node 1, item 1 of node 2, ..., item 2 of node 1, ...
+
+    Lists of unequal length are walked round-robin so no node is starved at
+    the end. Items listed under more than one node keep their first position.
+    """
+    seen: set = set()
+    ordered: List[str] = []
+    max_len = max((len(per_node.get(n, [])) for n in node_order), default=0)
+    for i in range(max_len):
+        for n in node_order:
+            lst = per_node.get(n, [])
+            if i < len(lst) and lst[i] not in seen:
+                seen.add(lst[i])
+                ordered.append(lst[i])
+    return ordered
+
+
+
+def selector_matches(selector: Optional[Dict], labels: Dict) -> bool:
+    """Whether a label selector (matchLabels, matchExpressions) selects labels; no selector selects nothing."""
+    if selector is None:
+        return False
+    if any(labels.get(key) != value for key, value in (selector.get('matchLabels') or {}).items()):
+        return False
+    for expression in selector.get('matchExpressions') or []:
+        key, operator, values = expression.get('key'), expression.get('operator'), expression.get('values') or []
+        if operator == 'In' and labels.get(key) not in values:
+            return False
+        if operator == 'NotIn' and key in labels and labels[key] in values:
+            return False
+        if operator == 'Exists' and key not in labels:
+            return False
+        if operator == 'DoesNotExist' and key in labels:
+            return False
+    return True
+
+
+def anti_affinity_terms(affinity: Optional[Dict]) -> List[Tuple[str, Dict]]:
+    """(mode, pod affinity term) of every pod anti-affinity term: 'hard' (required) or 'soft' (preferred)."""
+    anti_affinity = (affinity or {}).get('podAntiAffinity') or {}
+    terms = [('hard', term) for term in anti_affinity.get('requiredDuringSchedulingIgnoredDuringExecution') or []]
+    terms += [('soft', term.get('podAffinityTerm') or {})
+              for term in anti_affinity.get('preferredDuringSchedulingIgnoredDuringExecution') or []]
+    return terms
+
+
+def _node_labels(logger: Optional[logging.Logger] = None) -> Dict[str, Dict]:
+    returncode, stdout, _ = run_kubectl_command(['get', 'nodes', '-o', 'json'], check=False, logger=logger)
+    if returncode != 0:
+        return {}
+    try:
+        return {node['metadata']['name']: node['metadata'].get('labels') or {}
+                for node in json.loads(stdout).get('items', [])}
+    except (json.JSONDecodeError, KeyError):
+        return {}
+
+
+def check_anti_affinity(placements: Dict[str, Dict], node_labels: Dict[str, Dict]) -> List[Dict]:
+    """
+    Whether the pod anti-affinity terms of the placed VMs were honored.
+
+    A VM violates a term when another VM of the run that the term selects
+    (label selector, within the term's namespaces) runs in the same topology
+    domain (node, zone, ...). A namespaceSelector counts as selecting every
+    namespace of the run. Soft terms are preferences the scheduler may
+    override, so their violations are not scheduler errors.
+
+    Args:
+        placements: {target: get_vm_placement() result}
+        node_labels: {node: labels}
+
+    Returns:
+        One entry per distinct term: {mode, topology_key, label_selector, vms,
+        violations, honored, shared_domains}
+    """
+    placed = [(target, p) for target, p in placements.items() if p.get('node')]
+
+    def domain(node: str, key: str) -> Optional[str]:
+        if key == HOSTNAME_LABEL:
+            return node_labels.get(node, {}).get(key, node)
+        return node_labels.get(node, {}).get(key)
+
+    checks: Dict[Tuple[str, str], Dict] = {}
+    for target, vm in placed:
+        for mode, term in anti_affinity_terms(vm.get('affinity')):
+            key = term.get('topologyKey') or HOSTNAME_LABEL
+            check = checks.setdefault((mode, json.dumps(term, sort_keys=True)), {
+                'mode': mode, 'topology_key': key, 'label_selector': term.get('labelSelector'),
+                'vms': 0, 'violations': 0, 'shared_domains': set(),
+            })
+            check['vms'] += 1
+            own = domain(vm['node'], key)
+            if own is None:
+                continue
+            namespaces = None if term.get('namespaceSelector') is not None else \
+                set(term.get('namespaces') or [vm.get('namespace')])
+            if any(other != target and (namespaces is None or peer.get('namespace') in namespaces)
+                   and selector_matches(term.get('labelSelector'), dict(peer.get('labels') or {}, **LAUNCHER_LABELS))
+                   and domain(peer['node'], key) == own
+                   for other, peer in placed):
+                check['violations'] += 1
+                check['shared_domains'].add(own)
+    results = []
+    for check in checks.values():
+        check['honored'] = check['violations'] == 0
+        check['shared_domains'] = sorted(check['shared_domains'])
+        results.append(check)
+    return results
+
+
+def placement_quality(placements: Dict[str, Dict], nodes: List[str], topology_key: str = ZONE_LABEL,
+                      logger: Optional[logging.Logger] = None) -> Dict:
+    """
+    How evenly the VMs of a run were placed, and whether their anti-affinity was honored.
+
+    Args:
+        placements: {target: get_vm_placement() result}
+        nodes: Nodes the VMs could have been placed on, e.g. the placement
+            strategy's candidates; nodes that got VMs are added
+        topology_key: Node label of the zone
+        logger: Logger instance
+
+    Returns:
+        {vms, unscheduled, nodes, per_node, idle_nodes, mean_per_node,
+        stddev_per_node, cv, min_per_node, max_per_node, imbalance, hot_nodes,
+        zones, anti_affinity}; imbalance is the most VMs on a node over the
+        mean, zones is None when no node has the topology label
+    """
+    node_labels = _node_labels(logger)
+    per_node = Counter(p['node'] for p in placements.values() if p.get('node'))
+    nodes = list(dict.fromkeys(list(nodes) + sorted(per_node)))
+    counts = [per_node[node] for node in nodes]
+    mean = sum(counts) / len(counts) if counts else 0.0
+    hot = [node for node in nodes
+           if per_node[node] > mean * HOT_NODE_FACTOR and per_node[node] - mean >= HOT_NODE_MIN_EXCESS]
+
+    zones = None
+    node_zones = {node: node_labels.get(node, {}).get(topology_key) for node in nodes}
+    if any(node_zones.values()):
+        per_zone = Counter({zone or 'none': 0 for zone in node_zones.values()})
+        for node, count in per_node.items():
+            per_zone[node_zones.get(node) or 'none'] += count
+        zones = {
+            'topology_key': topology_key,
+            'per_zone': dict(sorted(per_zone.items())),
+            # As in topologySpreadConstraints: most minus fewest VMs in a zone
+            'skew': max(per_zone.values()) - min(per_zone.values()),
+        }
+
+    cv = coefficient_of_variation(counts)
+    return {
+        'vms': sum(counts),
+        'unscheduled': sum(1 for p in placements.values() if not p.get('node')),
+        'nodes': len(nodes),
+        'per_node': {node: per_node[node] for node in sorted(nodes)},
+        'idle_nodes': [node for node in nodes if not per_node[node]],
+        'mean_per_node': round(mean, 3),
+        'stddev_per_node': round(stddev(counts), 3) if counts else None,
+        'cv': round(cv, 3) if cv is not None else None,
+        'min_per_node': min(counts) if counts else None,
+        'max_per_node': max(counts) if counts else None,
+        'imbalance': round(max(counts) / mean, 3) if mean else None,
+        'hot_nodes': hot,
+        'zones': zones,
+        'anti_affinity': check_anti_affinity(placements, node_labels),
+    }
+
+
+def print_placement_quality(quality: Dict, logger: logging.Logger):
+    """Log a placement_quality() result, warning about hot nodes and anti-affinity violations."""
+    logger.info(f"Placement quality: {quality['vms']} VMs on {quality['nodes']} nodes, "
+                f"mean {quality['mean_per_node']}/node, stddev {quality['stddev_per_node']}, "
+                f"min {quality['min_per_node']}, max {quality['max_per_node']} (imbalance {quality['imbalance']}x)")
+    if quality['unscheduled']:
+        logger.warning(f"  {quality['unscheduled']} VMs have no node")
+    if quality['idle_nodes']:
+        logger.info(f"  Nodes without VMs: {', '.join(quality['idle_nodes'])}")
+    if quality['zones']:
+        zones = quality['zones']
+        logger.info(f"  VMs per {zones['topology_key']}: "
+                    f"{', '.join(f'{zone}={count}' for zone, count in zones['per_zone'].items())} (skew {zones['skew']})")
+    if quality['hot_nodes']:
+        hot = ', '.join(f"{node} ({quality['per_node'][node]} VMs)" for node in quality['hot_nodes'])
+        logger.warning(f"  Hot nodes (more than {HOT_NODE_FACTOR}x the mean): {hot}; "
+                       f"migration tests on these VMs will be skewed by them")
+    for check in quality['anti_affinity']:
+        if check['honored']:
+            logger.info(f"  {check['mode'].capitalize()} anti-affinity on {check['topology_key']} honored "
+                        f"for all {check['vms']} VMs")
+        else:
+            log = logger.warning if check['mode'] == 'hard' else logger.info
+            log(f"  {check['mode'].capitalize()} anti-affinity on {check['topology_key']} not honored: "
+                f"{check['violations']} of {check['vms']} VMs share a domain ({', '.join(check['shared_domains'][:5])})")
@@ -0,0 +1,355 @@
+#!/usr/bin/env python3
+"""
+Portworx helpers for KubeVirt performance testing.
+
+This module wraps pxctl (run inside a Portworx pod via kubectl exec) to
+track KVDB membership and quorum while destructive workloads run, so that
+tests can refuse to break quorum and report KVDB failover times next to VM
+recovery times.
+"""
+
+import json
+import logging
+import threading
+import time
+from typing import Dict, List, Optional
+
+from utils import timing
+from utils.base import run_kubectl_command
+
+PX_NAMESPACES = ['portworx', 'kube-system']
+PX_POD_SELECTOR = 'name=portworx'
+PX_CONTAINER = 'portworx'
+PXCTL = '/opt/pwx/bin/pxctl'
+
+
+def find_px_pod(exclude_nodes: Optional[List[str]] = None,
+                logger: Optional[logging.Logger] = None,
+                namespaces: Optional[List[str]] = None) -> Optional[Dict[str, str]]:
+    """
+    Find a running Portworx pod to run pxctl in.
+
+    Args:
+        exclude_nodes: Nodes whose Portworx pod must not be used (e.g. the node being failed)
+        logger: Logger instance
+        namespaces: Namespaces to look in (default: the --storage-namespace, else PX_NAMESPACES)
+
+    Returns:
+        Dict with keys name, namespace and node, or None if no pod is running
+    """
+    exclude = set(exclude_nodes or [])
+    if namespaces is None:
+        # Imported here because utils.storageprovider itself depends on this module
+        from utils.storageprovider import storage_namespace
+        namespaces = [storage_namespace()] if storage_namespace() else PX_NAMESPACES
+
+    for namespace in namespaces:
+        returncode, stdout, _ = run_kubectl_command(
+            ['get', 'pods', '-n', namespace, '-l', PX_POD_SELECTOR, '-o', 'json'],
+            check=False,
+            logger=logger
+        )
+        if returncode != 0:
+            continue
+
+        try:
+            pods = json.loads(stdout).get('items', [])
+        except json.JSONDecodeError:
+            continue
+
+        for pod in pods:
+            node = pod.get('spec', {}).get('nodeName', '')
+            if node in exclude or pod.get('status', {}).get('phase') != 'Running':
+                continue
+            return {
+                'name': pod['metadata']['name'],
+                'namespace': namespace,
+                'node': node,
+            }
+
+    if logger:
+        logger.warning("No running Portworx pod found")
+    return None
+
+
+def run_pxctl(pod: Dict[str, str], args: List[str], timeout: int = 30,
+              logger: Optional[logging.Logger] = None) -> Optional[str]:
+    """
+    Run a pxctl command inside a Portworx pod.
+
+    Args:
+        pod: Pod dict as returned by find_px_pod
+        args: pxctl arguments
+        timeout: Command timeout in seconds
+        logger: Logger instance
+
+    Returns:
+        Command stdout, or None on failure
+    """
+    try:
+        returncode, stdout, stderr = run_kubectl_command(
+            ['exec', '-n', pod['namespace'], pod['name'], '-c', PX_CONTAINER, '--',
+             PXCTL] + args,
+            check=False,
+            timeout=timeout,
+            logger=logger
+        )
+    except Exception as e:
+        if logger:
+            logger.debug(f"pxctl {' '.join(args)} failed: {e}")
+        return None
+
+    if returncode != 0:
+        if logger:
+            logger.debug(f"pxctl {' '.join(args)} failed: {stderr}")
+        return None
+    return stdout
+
+
+def get_kvdb_status(pod: Dict[str, str],
+                    logger: Optional[logging.Logger] = None) -> Optional[Dict]:
+    """
+    Get KVDB membership and quorum status.
+
+    Args:
+        pod: Pod dict as returned by find_px_pod
+        logger: Logger instance
+
+    Returns:
+        Dict with keys members (list of dicts with name, healthy, leader, urls),
+        total, healthy, quorum (members needed), has_quorum and leader,
+        or None if the status could not be read
+    """
+    output = run_pxctl(pod, ['service', 'kvdb', 'members', '--json'], logger=logger)
+    if output is None:
+        return None
+
+    try:
+        data = json.loads(output)
+    except json.JSONDecodeError as e:
+        if logger:
+            logger.debug(f"Failed to parse KVDB members JSON: {e}")
+        return None
+
+    # pxctl returns either a map keyed by member ID or a list of members
+    raw_members = data.values() if isinstance(data, dict) else data
+    members = []
+    for m in raw_members:
+        if not isinstance(m, dict):
+            continue
+        members.append({
+            'name': m.get('Name') or m.get('name', ''),
+            'healthy': bool(m.get('IsHealthy', m.get('isHealthy', False))),
+            'leader': bool(m.get('Leader', m.get('leader', False))),
+            'urls': list(m.get('PeerUrls', []) or []) + list(m.get('ClientUrls', []) or []),
+        })
+
+    total = len(members)
+    healthy = sum(1 for m in members if m['healthy'])
+    quorum = total // 2 + 1 if total else 0
+    leader = next((m['name'] for m in members if m['leader']), None)
+
+    return {
+        'members': members,
+        'total': total,
+        'healthy': healthy,
+        'quorum': quorum,
+        'has_quorum': total > 0 and healthy >= quorum,
+        'leader': leader,
+    }
+
+
+def get_node_addresses(node_name: str, logger: Optional[logging.Logger] = None) -> List[str]:
+    """Return the hostname and IP addresses of a node."""
+    returncode, stdout, _ = run_kubectl_command(
+        ['get', 'node', node_name, '-o', 'jsonpath={.status.addresses[*].address}'],
+        check=False,
+        logger=logger
+    )
+    addresses = [node_name]
+    if returncode == 0:
+        addresses.extend(stdout.split())
+    return addresses
+
+
+def kvdb_members_on_node(status: Dict, node_name: str,
+                         logger: Optional[logging.Logger] = None) -> List[Dict]:
+    """Return the KVDB members hosted on a node, matched by name or peer/client URL."""
+    addresses = get_node_addresses(node_name, logger)
+    hosted = []
+    for m in status['members']:
+        if m['name'] in addresses or any(addr in url for url in m['urls'] for addr in addresses):
+            hosted.append(m)
+    return hosted
+
+
+def check_quorum_safe(node_name: str, logger: Optional[logging.Logger] = None) -> Optional[bool]:
+    """
+    Check whether taking a node down keeps KVDB quorum.
+
+    Args:
+        node_name: Node that is about to fail
+        logger: Logger instance
+
+    Returns:
+        True if quorum survives losing the node, False if it would be lost,
+        None if KVDB status could not be determined
+    """
+    pod = find_px_pod(exclude_nodes=[node_name], logger=logger)
+    if not pod:
+        return None
+
+    status = get_kvdb_status(pod, logger)
+    if not status:
+        return None
+
+    hosted = [m for m in kvdb_members_on_node(status, node_name, logger) if m['healthy']]
+    remaining = status['healthy'] - len(hosted)
+
+    if logger:
+        logger.info(f"KVDB: {status['healthy']}/{status['total']} healthy members, "
+                    f"quorum needs {status['quorum']}, leader={status['leader']}")
+        if hosted:
+            logger.info(f"KVDB: node {node_name} hosts member(s) "
+                        f"{', '.join(m['name'] for m in hosted)}; {remaining} would remain")
+
+    return remaining >= status['quorum']
+
+
+class KvdbMonitor:
+    """
+    Background sampler of KVDB health during a destructive test.
+
+    Records a timeline of KVDB samples and derives when quorum was lost and
+    regained, when the leader changed and when the KVDB cluster returned to
+    full strength (failover complete), all relative to a start timestamp.
+
+    A sample whose status could not be read (pxctl failed or timed out) is
+    unknown, not a quorum loss: it keeps the last known state, is marked
+    unreachable, and is counted separately in the summary.
+    """
+
+    def __init__(self, failed_node: str, poll_interval: int = 5,
+                 logger: Optional[logging.Logger] = None):
+        self.failed_node = failed_node
+        self.poll_interval = poll_interval
+        self.logger = logger
+        self.samples: List[Dict] = []
+        self.baseline: Optional[Dict] = None
+        self._stop = threading.Event()
+        self._thread: Optional[threading.Thread] = None
+        self._pod: Optional[Dict[str, str]] = None
+
+    def start(self) -> bool:
+        """Capture the baseline status and start sampling. Returns False if KVDB is unreachable."""
+        self._pod = find_px_pod(exclude_nodes=[self.failed_node], logger=self.logger)
+        if not self._pod:
+            return False
+
+        self.baseline = get_kvdb_status(self._pod, self.logger)
+        if not self.baseline:
+            if self.logger:
+                self.logger.warning("Could not read KVDB status; KVDB tracking disabled")
+            return False
+
+        self._thread = threading.Thread(target=self._run, daemon=True)
+        self._thread.start()
+        return True
+
+    def stop(self):
+        """Stop sampling."""
+        self._stop.set()
+        if self._thread:
+            self._thread.join(timeout=self.poll_interval * 2 + 30)
+
+    def _run(self):
+        while not self._stop.is_set():
+            status = get_kvdb_status(self._pod, self.logger)
+            if status is None:
+                # The pod we exec into may itself be disrupted; pick another
+                self._pod = find_px_pod(exclude_nodes=[self.failed_node], logger=self.logger) or self._pod
+            last = self.samples[-1] if self.samples else self.baseline
+            known = status or last
+            sample = {
+                'timestamp': timing.now(),
+                'reachable': status is not None,
+                'healthy': known['healthy'],
+                'total': known['total'],
+                'has_quorum': known['has_quorum'],
+                'leader': known['leader'],
+            }
+            self.samples.append(sample)
+
+            if self.logger and len(self.samples) > 1:
+                prev = self.samples[-2]
+                if prev['reachable'] and not sample['reachable']:
+                    self.logger.warning("KVDB status unknown (pxctl failed); keeping the last known state")
+                elif not prev['reachable'] and sample['reachable']:
+                    self.logger.info("KVDB status readable again")
+                if prev['has_quorum'] and not sample['has_quorum']:
+                    self.logger.warning("KVDB quorum LOST")
+                elif not prev['has_quorum'] and sample['has_quorum']:
+                    self.logger.info("KVDB quorum regained")
+                if sample['leader'] and prev['leader'] and sample['leader'] != prev['leader']:
+                    self.logger.info(f"KVDB leader changed: {prev['leader']} -> {sample['leader']}")
+
+            self._stop.wait(self.poll_interval)
+
+    def summary(self, start_ts: timing.MonotonicTimestamp) -> Dict:
+        """
+        Summarize KVDB behaviour relative to start_ts.
+
+        Returns:
+            Dict with baseline counts, quorum_lost, quorum_lost_seconds,
+            leader_change_seconds and failover_seconds (time until the
+            healthy member count was back to the baseline after first
+            dropping below it), plus the raw sample count and the number of
+            samples whose status was unknown (unknown_samples)
+        """
+        def _offset(ts):
+            return round((ts - start_ts).total_seconds(), 2)
+
+        result = {
+            'baseline_healthy': self.baseline['healthy'] if self.baseline else None,
+            'baseline_total': self.baseline['total'] if self.baseline else None,
+            'baseline_leader': self.baseline['leader'] if self.baseline else None,
+            'quorum_lost': False,
+            'quorum_lost_seconds': None,
+            'leader_change_seconds': None,
+            'new_leader': None,
+            'degraded_seconds': None,
+            'failover_seconds': None,
+            'samples': len(self.samples),
+            'unknown_samples': 0,
+        }
+        if not self.baseline:
+            return result
+
+        lost_at = None
+        degraded_at = None
+        for sample in self.samples:
+            if sample['timestamp'] < start_ts:
+                continue
+            if not sample['reachable']:
+                result['unknown_samples'] += 1
+                continue
+            if not sample['has_quorum']:
+                result['quorum_lost'] = True
+                lost_at = lost_at or sample['timestamp']
+            elif lost_at and sample['has_quorum'] and result['quorum_lost_seconds'] is None:
+                result['quorum_lost_seconds'] = round((sample['timestamp'] - lost_at).total_seconds(), 2)
+
+            if (result['leader_change_seconds'] is None and sample['leader']
+                    and sample['leader'] != self.baseline['leader']):
+                result['leader_change_seconds'] = _offset(sample['timestamp'])
+                result['new_leader'] = sample['leader']
+
+            if sample['healthy'] < self.baseline['healthy']:
+                if degraded_at is None:
+                    degraded_at = sample['timestamp']
+                    result['degraded_seconds'] = _offset(degraded_at)
+            elif degraded_at and sample['healthy'] >= self.baseline['healthy'] \
+                    and result['failover_seconds'] is None:
+                result['failover_seconds'] = _offset(sample['timestamp'])
+
+        return result
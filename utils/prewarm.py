@@ -0,0 +1,412 @@
+#!/usr/bin/env python3
+"""
+Image pre-pull and DataSource pre-warm for KubeVirt benchmark runs.
+
+The first VMs of a run on a fresh cluster wait for the virt-launcher image
+(and container disk images) to be pulled onto each node, and for the golden
+image behind the DataSource to be imported. Both depend on registry
+bandwidth rather than on provisioning performance, so this stage does them
+before timing starts:
+
+- images: a DaemonSet on every schedulable node (kubevirt.io/schedulable=true)
+  with one container per image. A container that cannot run (container disks
+  have no command) still needs its image, so an image counts as pulled as
+  soon as its container is no longer waiting for the pull. The DaemonSet is
+  deleted afterwards.
+- DataSources: every DataSource the VM template's DataVolume templates
+  reference must report Ready, i.e. its source PVC or snapshot is populated.
+
+Exit codes:
+    0: every image is pulled on every node and every DataSource is Ready
+    1: something was still pending at the timeout
+
+Usage:
+    python3 prewarm.py --vm-template examples/vm-templates/rhel9-vm-datasource.yaml
+    python3 prewarm.py --vm-template vm.yaml --image quay.io/containerdisks/fedora:latest --node worker-1
+"""
+
+import argparse
+import json
+import logging
+import os
+import re
+import sys
+import time
+from typing import Dict, Iterable, List, Optional, Tuple
+
+import yaml
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
+
+from utils.common import (
+    setup_logging, run_kubectl_command, namespace_exists, create_namespace, delete_namespace,
+    create_or_adopt,
+)
+from utils.dryrun import DryRunPlan, is_dry_run
+from utils.output import emit
+
+PREWARM_NAMESPACE = 'virtbench-prewarm'
+PREPULL_NAME = 'virtbench-image-prepull'
+DEFAULT_TIMEOUT = 900
+DEFAULT_POLL_INTERVAL = 5
+
+SCHEDULABLE_LABEL = 'kubevirt.io/schedulable'
+# Container waiting reasons while the image is still being pulled (or failing to)
+PULL_PENDING_REASONS = {'ContainerCreating', 'PodInitializing', 'ErrImagePull', 'ImagePullBackOff'}
+PULL_ERROR_REASONS = {'ErrImagePull', 'ImagePullBackOff'}
+
+# Template placeholders such as {{STORAGE_CLASS_NAME}} (see examples/vm-templates/vm-template.yaml)
+PLACEHOLDER_RE = re.compile(r'\{\{\s*\w+\s*\}\}')
+
+
+def _get_json(args: List[str], logger: logging.Logger) -> Optional[Dict]:
+    returncode, stdout, _ = run_kubectl_command(args + ['-o', 'json'], check=False, logger=logger)
+    if returncode != 0:
+        return None
+    try:
+        return json.loads(stdout)
+    except ValueError:
+        return None
+
+
+def launcher_image(logger: logging.Logger) -> Optional[str]:
+    """The virt-launcher image of the installed KubeVirt (virt-controller's --launcher-image)."""
+    kubevirt = ((_get_json(['get', 'kubevirt', '-A'], logger) or {}).get('items') or [{}])[0]
+    namespace = kubevirt.get('metadata', {}).get('namespace')
+    if not namespace:
+        return None
+    controller = _get_json(['get', 'deployment', 'virt-controller', '-n', namespace], logger) or {}
+    for container in controller.get('spec', {}).get('template', {}).get('spec', {}).get('containers', []):
+        args = container.get('args') or []
+        for i, arg in enumerate(args):
+            if arg == '--launcher-image' and i + 1 < len(args):
+                return args[i + 1]
+            if arg.startswith('--launcher-image='):
+                return arg.split('=', 1)[1]
+    # Upstream KubeVirt names its images <registry>/virt-launcher:<version>
+    status = kubevirt.get('status', {})
+    if status.get('observedKubeVirtRegistry') and status.get('observedKubeVirtVersion'):
+        return f"{status['observedKubeVirtRegistry']}/virt-launcher:{status['observedKubeVirtVersion']}"
+    return None
+
+
+def template_sources(vm_template: str) -> Tuple[List[str], List[Tuple[str, str]]]:
+    """
+    Container disk images and DataSources a VM template boots from.
+
+    Returns:
+        (images, [(namespace, name) of DataSources]); values that are still
+        template placeholders are skipped
+
+    Raises:
+        OSError, yaml.YAMLError: If the template cannot be read
+    """
+    with open(vm_template) as f:
+        vm = yaml.safe_load(PLACEHOLDER_RE.sub('', f.read())) or {}
+    spec = vm.get('spec', {})
+
+    images = []
+    for volume in spec.get('template', {}).get('spec', {}).get('volumes') or []:
+        image = (volume.get('containerDisk') or {}).get('image')
+        if image and image not in images:
+            images.append(image)
+
+    datasources = []
+    for dvt in spec.get('dataVolumeTemplates') or []:
+        source_ref = dvt.get('spec', {}).get('sourceRef') or {}
+        if source_ref.get('kind') == 'DataSource' and source_ref.get('name'):
+            key = (source_ref.get('namespace') or vm.get('metadata', {}).get('namespace') or 'default',
+                   source_ref['name'])
+            if key not in datasources:
+                datasources.append(key)
+    return images, datasources
+
+
+def prepull_manifest(images: List[str], node: Optional[str] = None) -> Dict:
+    """DaemonSet with one container per image on every schedulable node (or only on node)."""
+    node_selector = {'kubernetes.io/hostname': node} if node else {SCHEDULABLE_LABEL: 'true'}
+    return {
+        'apiVersion': 'apps/v1',
+        'kind': 'DaemonSet',
+        'metadata': {'name': PREPULL_NAME},
+        'spec': {
+            'selector': {'matchLabels': {'app': PREPULL_NAME}},
+            'template': {
+                'metadata': {'labels': {'app': PREPULL_NAME}},
+                'spec': {
+                    'nodeSelector': node_selector,
+                    'tolerations': [{'operator': 'Exists'}],
+                    'terminationGracePeriodSeconds': 0,
+                    'containers': [
+                        {
+                            'name': f"image-{i}",
+                            'image': image,
+                            'imagePullPolicy': 'IfNotPresent',
+                            'command': ['sleep', 'infinity'],
+                            'resources': {'requests': {'cpu': '1m', 'memory': '8Mi'}},
+                        }
+                        for i, image in enumerate(images)
+                    ],
+                },
+            },
+        },
+    }
+
+
+def pull_status(pods: List[Dict], images: List[str]) -> Dict[str, Dict]:
+    """
+    Per node: the images still being pulled and pull errors.
+
+    Returns:
+        {node: {"pending": [images], "errors": {image: message}}}
+    """
+    status = {}
+    for pod in pods:
+        node = pod.get('spec', {}).get('nodeName')
+        if not node:
+            continue
+        container_statuses = {c.get('name'): c for c in pod.get('status', {}).get('containerStatuses') or []}
+        pending, errors = [], {}
+        for i, image in enumerate(images):
+            waiting = container_statuses.get(f"image-{i}", {}).get('state', {}).get('waiting')
+            if f"image-{i}" not in container_statuses or (waiting and waiting.get('reason') in PULL_PENDING_REASONS):
+                pending.append(image)
+                if waiting and waiting.get('reason') in PULL_ERROR_REASONS:
+                    errors[image] = waiting.get('message') or waiting['reason']
+        status[node] = {'pending': pending, 'errors': errors}
+    return status
+
+
+def prepull_images(images: List[str], namespace: str, logger: logging.Logger, timeout: int = DEFAULT_TIMEOUT,
+                   poll_interval: int = DEFAULT_POLL_INTERVAL, node: Optional[str] = None) -> Dict:
+    """
+    Pull images onto the nodes with a DaemonSet, then delete it.
+
+    Returns:
+        {"images", "nodes": {node: {"pending", "errors"}}, "complete": bool, "duration_sec"}
+    """
+    start = time.time()
+    run_kubectl_command(['delete', 'daemonset', PREPULL_NAME, '-n', namespace, '--ignore-not-found', '--wait=true'],
+                        check=False, logger=logger)
+    ok, _, error = create_or_adopt(yaml.safe_dump(prepull_manifest(images, node), sort_keys=False),
+                                   namespace, logger)
+    if not ok:
+        logger.error(f"Failed to create image pre-pull DaemonSet: {error}")
+        return {'images': images, 'nodes': {}, 'complete': False, 'duration_sec': 0}
+
+    nodes, complete = {}, False
+    try:
+        while True:
+            daemonset = _get_json(['get', 'daemonset', PREPULL_NAME, '-n', namespace], logger) or {}
+            desired = daemonset.get('status', {}).get('desiredNumberScheduled')
+            pods = (_get_json(['get', 'pods', '-n', namespace, '-l', f"app={PREPULL_NAME}"], logger)
+                    or {}).get('items', [])
+            nodes = pull_status(pods, images)
+            pending = sum(len(n['pending']) for n in nodes.values())
+            complete = desired is not None and len(nodes) >= desired and not pending
+            logger.info(f"Image pre-pull: {len(nodes)}/{desired if desired is not None else '?'} nodes, "
+                        f"{pending} image pulls pending")
+            if complete or time.time() - start > timeout:
+                break
+            time.sleep(poll_interval)
+    finally:
+        run_kubectl_command(['delete', 'daemonset', PREPULL_NAME, '-n', namespace, '--ignore-not-found'],
+                            check=False, logger=logger)
+
+    return {'images': images, 'nodes': nodes, 'complete': complete, 'duration_sec': round(time.time() - start, 2)}
+
+
+def datasource_ready(namespace: str, name: str, logger: logging.Logger) -> Tuple[bool, Optional[str]]:
+    """Whether a DataSource is Ready, and the reason it is not."""
+    datasource = _get_json(['get', 'datasource', name, '-n', namespace], logger)
+    if datasource is None:
+        return False, 'not found'
+    for condition in datasource.get('status', {}).get('conditions') or []:
+        if condition.get('type') == 'Ready':
+            if condition.get('status') == 'True':
+                return True, None
+            return False, condition.get('reason') or condition.get('message') or 'not ready'
+    return False, 'no Ready condition'
+
+
+def wait_for_datasources(datasources: List[Tuple[str, str]], logger: logging.Logger,
+                         timeout: int = DEFAULT_TIMEOUT, poll_interval: int = DEFAULT_POLL_INTERVAL) -> List[Dict]:
+    """
+    Wait until every DataSource is Ready (its golden image is populated).
+
+    Returns:
+        List of {"namespace", "name", "ready", "reason"}
+    """
+    start = time.time()
+    pending = list(datasources)
+    reasons = {}
+    while pending:
+        for namespace, name in list(pending):
+            ready, reasons[(namespace, name)] = datasource_ready(namespace, name, logger)
+            if ready:
+                logger.info(f"DataSource {namespace}/{name} is Ready")
+                pending.remove((namespace, name))
+        if not pending or time.time() - start > timeout:
+            break
+        logger.info(f"Waiting for {len(pending)} DataSources: "
+                    + ', '.join(f"{ns}/{name} ({reasons[(ns, name)]})" for ns, name in pending))
+        time.sleep(poll_interval)
+    return [{'namespace': ns, 'name': name, 'ready': (ns, name) not in pending, 'reason': reasons.get((ns, name))}
+            for ns, name in datasources]
+
+
+def prewarm_images(vm_template: Optional[str], extra_images: Iterable[str], logger: logging.Logger,
+                   include_launcher: bool = True) -> Tuple[List[str], List[Tuple[str, str]]]:
+    """Images to pre-pull and DataSources to wait for, from the template and the installed KubeVirt."""
+    images, datasources = template_sources(vm_template) if vm_template else ([], [])
+    if include_launcher:
+        image = launcher_image(logger)
+        if image:
+            images.insert(0, image)
+        else:
+            logger.warning("Could not find the virt-launcher image; it is not pre-pulled")
+    for image in extra_images:
+        if image not in images:
+            images.append(image)
+    return images, datasources
+
+
+def prewarm(vm_template: Optional[str], logger: logging.Logger, extra_images: Iterable[str] = (),
+            namespace: str = PREWARM_NAMESPACE, node: Optional[str] = None, timeout: int = DEFAULT_TIMEOUT,
+            poll_interval: int = DEFAULT_POLL_INTERVAL, include_launcher: bool = True) -> Dict:
+    """
+    Pre-pull the images and wait for the DataSources of a VM template.
+
+    Args:
+        vm_template: VM template whose container disks and DataSources are warmed (optional)
+        extra_images: Further images to pre-pull
+        namespace: Namespace of the pre-pull DaemonSet; deleted afterwards if this created it
+        node: Only pre-pull onto this node (single-node runs)
+        include_launcher: Also pre-pull the virt-launcher image
+
+    Returns:
+        Report: {"images": {...prepull_images()}, "datasources": [...], "ready": bool, "duration_sec"}
+    """
+    start = time.time()
+    images, datasources = prewarm_images(vm_template, extra_images, logger, include_launcher)
+    report = {'images': None, 'datasources': [], 'ready': True}
+
+    if images:
+        logger.info(f"Pre-pulling {len(images)} images onto {'node ' + node if node else 'all schedulable nodes'}: "
+                    f"{', '.join(images)}")
+        created = not namespace_exists(namespace, logger)
+        if not create_namespace(namespace, logger):
+            report['ready'] = False
+        else:
+            try:
+                report['images'] = prepull_images(images, namespace, logger, timeout, poll_interval, node)
+            finally:
+                if created:
+                    delete_namespace(namespace, logger=logger)
+            report['ready'] = report['images']['complete']
+
+    if datasources:
+        remaining = max(poll_interval, timeout - (time.time() - start))
+        report['datasources'] = wait_for_datasources(datasources, logger, int(remaining), poll_interval)
+        report['ready'] = report['ready'] and all(d['ready'] for d in report['datasources'])
+
+    report['duration_sec'] = round(time.time() - start, 2)
+    return report
+
+
+def plan_prewarm(plan: DryRunPlan, vm_template: Optional[str], logger: logging.Logger,
+                 extra_images: Iterable[str] = (), namespace: str = PREWARM_NAMESPACE,
+                 node: Optional[str] = None, include_launcher: bool = True):
+    """Record what prewarm() would create and wait for (virtbench --dry-run)."""
+    images, datasources = prewarm_images(vm_template, extra_images, logger, include_launcher)
+    if images:
+        plan.create_namespaces([namespace])
+        plan.apply(yaml.safe_dump(prepull_manifest(images, node), sort_keys=False), namespace,
+                   detail='image pre-pull')
+        plan.action('delete', f"daemonset/{namespace}/{PREPULL_NAME}", 'after the images are pulled')
+        plan.delete_namespaces([namespace])
+    for ns, name in datasources:
+        plan.action('wait', f"datasource/{ns}/{name}", 'until Ready')
+
+
+def print_prewarm(report: Dict, logger: logging.Logger):
+    """Log a prewarm() report."""
+    images = report.get('images')
+    if images:
+        pulled = sum(1 for n in images['nodes'].values() if not n['pending'])
+        logger.info(f"Images: pulled on {pulled}/{len(images['nodes'])} nodes in {images['duration_sec']}s")
+        for node, status in sorted(images['nodes'].items()):
+            for image in status['pending']:
+                error = status['errors'].get(image)
+                logger.warning(f"  {node}: {image} not pulled" + (f" ({error})" if error else ''))
+    for datasource in report.get('datasources') or []:
+        state = 'Ready' if datasource['ready'] else f"not Ready ({datasource['reason']})"
+        logger.info(f"DataSource {datasource['namespace']}/{datasource['name']}: {state}")
+    if report.get('ready'):
+        logger.info(f"Pre-warm complete in {report['duration_sec']}s")
+    else:
+        logger.warning(f"Pre-warm incomplete after {report['duration_sec']}s; "
+                       "the first VMs may still pay for image pulls or imports")
+
+
+def parse_args():
+    parser = argparse.ArgumentParser(
+        description='Pre-pull VM images onto the nodes and wait for DataSources before a benchmark run',
+        formatter_class=argparse.RawDescriptionHelpFormatter,
+    )
+    parser.add_argument('--vm-template', type=str, default=None,
+                        help='VM template whose container disk images and DataSources are pre-warmed')
+    parser.add_argument('--image', nargs='+', default=[],
+                        help='Additional images to pre-pull')
+    parser.add_argument('--no-launcher', action='store_true',
+                        help='Do not pre-pull the virt-launcher image')
+    parser.add_argument('--node', type=str, default=None,
+                        help='Only pre-pull onto this node (default: all schedulable nodes)')
+    parser.add_argument('--namespace', type=str, default=PREWARM_NAMESPACE,
+                        help=f'Namespace of the pre-pull DaemonSet (default: {PREWARM_NAMESPACE})')
+    parser.add_argument('--timeout', type=int, default=DEFAULT_TIMEOUT,
+                        help=f'Seconds to wait for pulls and DataSources (default: {DEFAULT_TIMEOUT})')
+    parser.add_argument('--poll-interval', type=int, default=DEFAULT_POLL_INTERVAL,
+                        help=f'Seconds between status checks (default: {DEFAULT_POLL_INTERVAL})')
+    parser.add_argument('--kubeconfig', type=str, default=None,
+                        help='Path to kubeconfig file')
+    parser.add_argument('--log-level', type=str, default='INFO',
+                        choices=['DEBUG', 'INFO', 'WARNING', 'ERROR'],
+                        help='Logging level (default: INFO)')
+
+    args = parser.parse_args()
+    if args.vm_template and not os.path.exists(args.vm_template):
+        parser.error(f"VM template file not found: {args.vm_template}")
+    return args
+
+
+def main():
+    """Main execution function"""
+    args = parse_args()
+
+    if args.kubeconfig:
+        os.environ['KUBECONFIG'] = args.kubeconfig
+
+    logger = setup_logging(log_file=None, log_level=args.log_level)
+
+    try:
+        if is_dry_run():
+            plan = DryRunPlan('prewarm', logger)
+            plan_prewarm(plan, args.vm_template, logger, args.image, args.namespace, args.node,
+                         not args.no_launcher)
+            plan.report()
+            return
+        report = prewarm(args.vm_template, logger, args.image, args.namespace, args.node, args.timeout,
+                         args.poll_interval, not args.no_launcher)
+    except (OSError, yaml.YAMLError) as e:
+        logger.error(f"Cannot read VM template: {e}")
+        sys.exit(1)
+
+    print_prewarm(report, logger)
+    emit('prewarm-report', report)
+    sys.exit(0 if report['ready'] else 1)
+
+
+if __name__ == '__main__':
+    main()
@@ -0,0 +1,311 @@
+#!/usr/bin/env python3
+"""
+Live progress dashboard for KubeVirt performance testing.
+
+With the global ``virtbench --tui`` option (or VIRTBENCH_TUI=1) every phase
+that runs through the shared worker pool (VM creation, boot, migration,
+cleanup, ...) is shown as a live terminal dashboard: a progress bar, counts
+of pending, in-progress, done and failed items, the current throughput, the
+latest per-VM state transitions and the most recent errors.
+
+The dashboard needs an interactive terminal and the rich package. Otherwise
+(for example when the output is piped to a file or run from CI) a progress
+line is logged every PLAIN_LOG_INTERVAL seconds instead.
+
+    with track_phase("VM creation", namespaces, logger) as phase:
+        for ns in namespaces:
+            phase.started(ns)
+            ...
+            phase.finished(ns, ok=True)
+
+    vm_state(ns, 'Running')   # optional finer-grained per-VM states
+"""
+
+import logging
+import os
+import sys
+import threading
+import time
+from collections import Counter, deque
+from datetime import datetime
+from typing import Any, Iterable, Optional
+
+TUI_ENV = 'VIRTBENCH_TUI'
+LOGGER_NAME = 'kubevirt-perf'
+REFRESH_PER_SECOND = 4
+RECENT_TRANSITIONS = 8
+RECENT_ERRORS = 5
+PLAIN_LOG_INTERVAL = 30
+
+# Item states within a phase
+PENDING = 'pending'
+IN_PROGRESS = 'in progress'
+DONE = 'done'
+FAILED = 'failed'
+
+_lock = threading.RLock()
+_active = None
+_vm_states = {}
+_transitions = deque(maxlen=RECENT_TRANSITIONS)
+_fallback_noted = False
+
+
+def tui_requested() -> bool:
+    """Return True if the live dashboard was requested (`virtbench --tui`)."""
+    return os.environ.get(TUI_ENV, '').lower() in ('1', 'true', 'yes')
+
+
+def _terminal_available() -> bool:
+    """True if stdout is an interactive terminal and rich can draw on it."""
+    try:
+        import rich  # noqa: F401
+    except ImportError:
+        return False
+    return sys.stdout.isatty()
+
+
+def _label(item: Any) -> str:
+    """Display name of a work item, e.g. "perf-test-3" or "perf-test-3/2" for (namespace, index) items."""
+    if isinstance(item, tuple):
+        return '/'.join(str(part) for part in item)
+    return str(item)
+
+
+def _format_duration(seconds: float) -> str:
+    minutes, seconds = divmod(int(seconds), 60)
+    hours, minutes = divmod(minutes, 60)
+    return f"{hours}h{minutes:02d}m{seconds:02d}s" if hours else f"{minutes}m{seconds:02d}s"
+
+
+def vm_state(item: Any, state: str):
+    """
+    Record a per-VM state transition, e.g. vm_state(ns, 'Running').
+
+    A pending item of the tracked phase that reports a state counts as in
+    progress. Does nothing unless the dashboard was requested.
+    """
+    if not tui_requested():
+        return
+    name = _label(item)
+    with _lock:
+        if _active is not None and _active.status.get(name) == PENDING:
+            _active.status[name] = IN_PROGRESS
+        previous = _vm_states.get(name)
+        if previous == state:
+            return
+        _vm_states[name] = state
+        _transitions.append((datetime.now().strftime('%H:%M:%S'), name, previous, state))
+
+
+class _NullPhase:
+    """Phase tracker used when no dashboard was requested, or inside another tracked phase."""
+
+    def __enter__(self):
+        return self
+
+    def __exit__(self, *exc):
+        return False
+
+    def started(self, item: Any):
+        pass
+
+    def finished(self, item: Any, ok: bool = True, error: Optional[str] = None):
+        pass
+
+
+class _Phase(_NullPhase):
+    """Counts and throughput of one phase; subclasses decide how they are shown."""
+
+    def __init__(self, description: str, items: Iterable[Any], logger: Optional[logging.Logger]):
+        self.description = description
+        self.logger = logger
+        self.status = {_label(item): PENDING for item in items}
+        self.started_at = time.monotonic()
+
+    def __exit__(self, *exc):
+        global _active
+        with _lock:
+            _active = None
+        return False
+
+    def started(self, item: Any):
+        with _lock:
+            self.status[_label(item)] = IN_PROGRESS
+        vm_state(item, f"{self.description}: {IN_PROGRESS}")
+
+    def finished(self, item: Any, ok: bool = True, error: Optional[str] = None):
+        with _lock:
+            self.status[_label(item)] = DONE if ok else FAILED
+        vm_state(item, f"{self.description}: {DONE if ok else FAILED}")
+
+    def counts(self) -> Counter:
+        with _lock:
+            return Counter(self.status.values())
+
+    def throughput(self) -> float:
+        """Completed items per minute since the phase started."""
+        counts = self.counts()
+        elapsed = time.monotonic() - self.started_at
+        return (counts[DONE] + counts[FAILED]) * 60 / elapsed if elapsed > 0 else 0.0
+
+    def summary(self) -> str:
+        counts = self.counts()
+        completed = counts[DONE] + counts[FAILED]
+        return (f"[{self.description}] {completed}/{len(self.status)} completed, {counts[FAILED]} failed, "
+                f"{counts[IN_PROGRESS]} in progress, {counts[PENDING]} pending "
+                f"({self.throughput():.1f}/min, {_format_duration(time.monotonic() - self.started_at)})")
+
+
+class _PlainPhase(_Phase):
+    """Logs a progress line every PLAIN_LOG_INTERVAL seconds, for non-interactive output."""
+
+    def __init__(self, description, items, logger):
+        super().__init__(description, items, logger)
+        self.last_logged = self.started_at
+
+    def finished(self, item: Any, ok: bool = True, error: Optional[str] = None):
+        super().finished(item, ok, error)
+        now = time.monotonic()
+        if self.logger and now - self.last_logged >= PLAIN_LOG_INTERVAL:
+            self.last_logged = now
+            self.logger.info(self.summary())
+
+    def __exit__(self, *exc):
+        if self.logger:
+            self.logger.info(self.summary())
+        return super().__exit__(*exc)
+
+
+class _ErrorCollector(logging.Handler):
+    """Keeps the most recent warnings and errors for the dashboard."""
+
+    def __init__(self):
+        super().__init__(logging.WARNING)
+        self.records = deque(maxlen=RECENT_ERRORS)
+
+    def emit(self, record: logging.LogRecord):
+        self.records.append((datetime.fromtimestamp(record.created).strftime('%H:%M:%S'),
+                             record.levelname, record.getMessage()))
+
+
+def _mute(record: logging.LogRecord) -> bool:
+    return False
+
+
+class _LivePhase(_Phase):
+    """Draws the phase as a live dashboard while it runs; console log output is held back."""
+
+    def __init__(self, description, items, logger):
+        super().__init__(description, items, logger)
+        self.errors = _ErrorCollector()
+        self.live = None
+        self.muted = []
+
+    def __enter__(self):
+        from rich.live import Live
+
+        run_logger = logging.getLogger(LOGGER_NAME)
+        # Console handlers would scroll over the dashboard; file handlers keep logging everything
+        self.muted = [h for h in run_logger.handlers
+                      if isinstance(h, logging.StreamHandler) and not isinstance(h, logging.FileHandler)]
+        for handler in self.muted:
+            handler.addFilter(_mute)
+        run_logger.addHandler(self.errors)
+        self.live = Live(get_renderable=self.render, refresh_per_second=REFRESH_PER_SECOND)
+        self.live.start()
+        return self
+
+    def __exit__(self, *exc):
+        try:
+            self.live.stop()
+        finally:
+            run_logger = logging.getLogger(LOGGER_NAME)
+            run_logger.removeHandler(self.errors)
+            for handler in self.muted:
+                handler.removeFilter(_mute)
+            if self.logger:
+                self.logger.info(self.summary())
+                for _, level, message in self.errors.records:
+                    self.logger.debug(f"Recent {level.lower()}: {message}")
+        return super().__exit__(*exc)
+
+    def render(self):
+        from rich.console import Group
+        from rich.panel import Panel
+        from rich.progress_bar import ProgressBar
+        from rich.table import Table
+        from rich.text import Text
+
+        counts = self.counts()
+        total = len(self.status)
+        completed = counts[DONE] + counts[FAILED]
+
+        header = Table.grid(expand=True)
+        header.add_column(ratio=1)
+        header.add_column(justify='right')
+        header.add_row(ProgressBar(total=total or 1, completed=completed),
+                       Text(f" {completed}/{total}"))
+
+        stats = Text()
+        stats.append(f"pending {counts[PENDING]}  ")
+        stats.append(f"in progress {counts[IN_PROGRESS]}  ", style='cyan')
+        stats.append(f"done {counts[DONE]}  ", style='green')
+        stats.append(f"failed {counts[FAILED]}", style='red' if counts[FAILED] else '')
+        stats.append(f"    {self.throughput():.1f}/min    "
+                     f"elapsed {_format_duration(time.monotonic() - self.started_at)}")
+
+        with _lock:
+            transitions = list(_transitions)
+            states = Counter(_vm_states.values())
+
+        vm_table = Table(title='VM states', title_justify='left', expand=True, show_edge=False)
+        vm_table.add_column('State')
+        vm_table.add_column('VMs', justify='right')
+        for state, count in sorted(states.items()):
+            vm_table.add_row(state, str(count))
+
+        recent = Table(title='Recent transitions', title_justify='left', expand=True, show_edge=False)
+        recent.add_column('Time', no_wrap=True)
+        recent.add_column('VM')
+        recent.add_column('Transition')
+        for stamp, name, previous, state in reversed(transitions):
+            recent.add_row(stamp, name, f"{previous} -> {state}" if previous else state)
+
+        errors = Table(title='Recent errors', title_justify='left', expand=True, show_edge=False)
+        errors.add_column('Time', no_wrap=True)
+        errors.add_column('Message')
+        for stamp, level, message in reversed(self.errors.records):
+            errors.add_row(stamp, Text(message, style='red' if level == 'ERROR' else 'yellow'))
+        if not self.errors.records:
+            errors.add_row('', Text('none', style='dim'))
+
+        return Panel(Group(header, stats, Text(), vm_table, Text(), recent, Text(), errors),
+                     title=f"virtbench: {self.description}", border_style='blue')
+
+
+def track_phase(description: str, items: Iterable[Any], logger: Optional[logging.Logger] = None):
+    """
+    Track a phase of work on the dashboard; use as a context manager.
+
+    Returns a no-op tracker unless the dashboard was requested, and inside
+    an already tracked phase (nested worker pools), so callers never need
+    to check.
+
+    Args:
+        description: Short label of the phase, e.g. "VM creation"
+        items: Work items of the phase (namespaces, "namespace/vm" targets, ...)
+        logger: Logger for the summary line and the non-interactive fallback
+    """
+    global _active, _fallback_noted
+    with _lock:
+        if not tui_requested() or _active is not None:
+            return _NullPhase()
+        if _terminal_available():
+            _active = _LivePhase(description, list(items), logger)
+        else:
+            if logger and not _fallback_noted:
+                logger.info("Output is not an interactive terminal (or rich is missing); "
+                            f"logging progress every {PLAIN_LOG_INTERVAL}s instead of the dashboard")
+            _fallback_noted = True
+            _active = _PlainPhase(description, list(items), logger)
+        return _active
@@ -0,0 +1,265 @@
+#!/usr/bin/env python3
+"""
+ResourceQuota and LimitRange injection for KubeVirt performance testing.
+
+Density and capacity tests normally run into node capacity. With
+``virtbench --namespace-quota`` and ``--namespace-limit-range`` every
+namespace a workload creates also gets a ResourceQuota (``virtbench-quota``)
+and a LimitRange (``virtbench-limits``), so a run emulates tenants that are
+capped per namespace:
+
+    --namespace-quota requests.cpu=8,requests.memory=32Gi,count/virtualmachines.kubevirt.io=10
+    --namespace-limit-range max.cpu=4,max.memory=16Gi,default.cpu=1,default.memory=2Gi
+
+Limit range keys are ``{max,min,default,defaultRequest,maxLimitRequestRatio}.{resource}``
+and apply per container (virt-launcher pods included). Both objects carry the
+run labels and go away with their namespace. Namespaces that already exist are
+left untouched.
+
+When results are saved, constraints_summary() reports the quotas that ran out
+(used >= hard) and the requests denied by quota or limit range (from the
+captured events), and whether the quota or node capacity was the limiting
+factor of the run. It is embedded in the result summary under
+``namespace_constraints``.
+"""
+
+import json
+import logging
+import os
+from collections import Counter
+from typing import Dict, List, Optional
+
+from utils.apply import apply_manifest
+from utils.base import parse_quantity_bytes, run_kubectl_command, run_labels, run_selector
+
+QUOTA_ENV = 'VIRTBENCH_NAMESPACE_QUOTA'
+LIMIT_RANGE_ENV = 'VIRTBENCH_NAMESPACE_LIMIT_RANGE'
+QUOTA_NAME = 'virtbench-quota'
+LIMIT_RANGE_NAME = 'virtbench-limits'
+LIMIT_RANGE_KEYS = ('max', 'min', 'default', 'defaultRequest', 'maxLimitRequestRatio')
+
+# Substrings of the messages of requests the API server denied because of a quota or limit range
+QUOTA_DENIAL = 'exceeded quota'
+LIMIT_RANGE_DENIALS = ('usage per Container is', 'usage per Pod is', 'limit to request ratio per')
+# FailedScheduling messages of pods that did not fit on any node
+CAPACITY_DENIAL = 'Insufficient '
+
+
+def parse_resource_list(spec: Optional[str]) -> Dict[str, str]:
+    """
+    Parse "name=quantity,name=quantity" into a dict.
+
+    Raises:
+        ValueError: If an entry is not of the form name=quantity
+    """
+    values = {}
+    for entry in (spec or '').split(','):
+        entry = entry.strip()
+        if not entry:
+            continue
+        name, sep, quantity = entry.partition('=')
+        if not sep or not name.strip() or not quantity.strip():
+            raise ValueError(f"expected name=quantity, got '{entry}'")
+        values[name.strip()] = quantity.strip()
+    return values
+
+
+def parse_limit_range(spec: Optional[str]) -> Dict[str, Dict[str, str]]:
+    """
+    Parse "max.cpu=4,default.memory=2Gi" into {'max': {'cpu': '4'}, 'default': {'memory': '2Gi'}}.
+
+    Raises:
+        ValueError: If a key is not {max,min,default,defaultRequest,maxLimitRequestRatio}.{resource}
+    """
+    limits = {}
+    for key, quantity in parse_resource_list(spec).items():
+        kind, _, resource = key.partition('.')
+        if kind not in LIMIT_RANGE_KEYS or not resource:
+            raise ValueError(f"expected {{{','.join(LIMIT_RANGE_KEYS)}}}.<resource>, got '{key}'")
+        limits.setdefault(kind, {})[resource] = quantity
+    return limits
+
+
+def configured_quota() -> Dict[str, str]:
+    """Hard limits of the per-namespace ResourceQuota (`virtbench --namespace-quota`)."""
+    return parse_resource_list(os.environ.get(QUOTA_ENV))
+
+
+def configured_limit_range() -> Dict[str, Dict[str, str]]:
+    """Container limits of the per-namespace LimitRange (`virtbench --namespace-limit-range`)."""
+    return parse_limit_range(os.environ.get(LIMIT_RANGE_ENV))
+
+
+def constraints_enabled() -> bool:
+    """Return True if a quota or limit range is injected into created namespaces."""
+    return bool(os.environ.get(QUOTA_ENV) or os.environ.get(LIMIT_RANGE_ENV))
+
+
+def constraint_manifests(namespace: str) -> List[Dict]:
+    """The ResourceQuota and/or LimitRange objects for one namespace."""
+    metadata = {'namespace': namespace, 'labels': run_labels()}
+    items = []
+    quota = configured_quota()
+    if quota:
+        items.append({
+            'apiVersion': 'v1', 'kind': 'ResourceQuota',
+            'metadata': dict(metadata, name=QUOTA_NAME),
+            'spec': {'hard': quota},
+        })
+    limits = configured_limit_range()
+    if limits:
+        items.append({
+            'apiVersion': 'v1', 'kind': 'LimitRange',
+            'metadata': dict(metadata, name=LIMIT_RANGE_NAME),
+            'spec': {'limits': [dict(limits, type='Container')]},
+        })
+    return items
+
+
+def apply_namespace_constraints(namespaces: List[str], logger: Optional[logging.Logger] = None) -> bool:
+    """
+    Server-side apply the configured quota and limit range to the namespaces in one call.
+
+    Returns:
+        True if there was nothing to apply or the apply succeeded
+    """
+    if not namespaces or not constraints_enabled():
+        return True
+    items = [item for namespace in namespaces for item in constraint_manifests(namespace)]
+    manifest = json.dumps({'apiVersion': 'v1', 'kind': 'List', 'items': items})
+    applied, error = apply_manifest(manifest, logger=logger)
+    if not applied:
+        if logger:
+            logger.error(f"Failed to apply namespace quota/limit range: {error}")
+        return False
+    if logger:
+        logger.debug(f"Applied namespace quota/limit range to {len(namespaces)} namespaces")
+    return True
+
+
+def quantity_value(quantity) -> Optional[float]:
+    """Numeric value of a Kubernetes quantity ("500m", "2", "16Gi"), for comparing used with hard."""
+    quantity = str(quantity or '').strip()
+    if quantity.endswith('m'):
+        try:
+            return float(quantity[:-1]) / 1000
+        except ValueError:
+            return None
+    value = parse_quantity_bytes(quantity)
+    return float(value) if value is not None else None
+
+
+def quota_usage(logger: Optional[logging.Logger] = None) -> Dict[str, Dict[str, Dict[str, str]]]:
+    """Used and hard amounts of this run's quotas: {namespace: {resource: {'used', 'hard'}}}."""
+    returncode, stdout, _ = run_kubectl_command(
+        ['get', 'resourcequota', '--all-namespaces', '-l', run_selector(),
+         '--field-selector', f'metadata.name={QUOTA_NAME}', '-o', 'json'],
+        check=False, logger=logger
+    )
+    if returncode != 0:
+        return {}
+    try:
+        items = json.loads(stdout).get('items', [])
+    except json.JSONDecodeError:
+        return {}
+    usage = {}
+    for item in items:
+        status = item.get('status') or {}
+        hard = status.get('hard') or {}
+        used = status.get('used') or {}
+        usage[item['metadata']['namespace']] = {
+            resource: {'used': used.get(resource, '0'), 'hard': limit} for resource, limit in hard.items()
+        }
+    return usage
+
+
+def exhausted_resources(usage: Dict[str, Dict[str, str]]) -> List[str]:
+    """Resources of one namespace's quota whose usage reached the hard limit."""
+    exhausted = []
+    for resource, amounts in usage.items():
+        used, hard = quantity_value(amounts['used']), quantity_value(amounts['hard'])
+        if used is not None and hard is not None and used >= hard:
+            exhausted.append(resource)
+    return sorted(exhausted)
+
+
+def namespace_quota_exhausted(namespace: str, logger: Optional[logging.Logger] = None) -> List[str]:
+    """Resources of the run's quota in the namespace that are used up (empty without a quota)."""
+    if not configured_quota():
+        return []
+    return exhausted_resources(quota_usage(logger).get(namespace, {}))
+
+
+def denial_counts(records: List[Dict]) -> Dict[str, int]:
+    """Requests denied by quota, by limit range and pods that did not fit a node, from event_record()s."""
+    counts = Counter()
+    for record in records:
+        message = record.get('message') or ''
+        if QUOTA_DENIAL in message:
+            counts['quota'] += record['count']
+        elif any(denial in message for denial in LIMIT_RANGE_DENIALS):
+            counts['limit_range'] += record['count']
+        elif record.get('reason') == 'FailedScheduling' and CAPACITY_DENIAL in message:
+            counts['node_capacity'] += record['count']
+    return counts
+
+
+def constraints_summary(logger: Optional[logging.Logger] = None,
+                        capacity_reached: bool = False) -> Optional[Dict]:
+    """
+    The "namespace_constraints" block of result summaries.
+
+    Args:
+        logger: Logger instance
+        capacity_reached: The workload stopped because VMs no longer fit; decides
+            between quota and node capacity as the limiting factor without events
+
+    Returns:
+        {'quota', 'limit_range', 'namespaces', 'exhausted_namespaces', 'exhausted_resources',
+         'quota_denials', 'limit_range_denials', 'capacity_denials', 'limiting_factor'},
+        or None when no quota or limit range was injected
+    """
+    if not constraints_enabled():
+        return None
+    # Imported here because utils.events is only needed when constraints are on
+    from utils.events import captured_events
+
+    usage = quota_usage(logger)
+    exhausted = {namespace: exhausted_resources(resources) for namespace, resources in usage.items()}
+    exhausted = {namespace: resources for namespace, resources in exhausted.items() if resources}
+    by_resource = Counter(resource for resources in exhausted.values() for resource in resources)
+    denials = denial_counts(captured_events())
+
+    if denials['quota'] or denials['limit_range']:
+        limiting_factor = 'quota'
+    elif denials['node_capacity']:
+        limiting_factor = 'node capacity'
+    elif capacity_reached:
+        limiting_factor = 'quota' if exhausted else 'node capacity'
+    else:
+        limiting_factor = None
+
+    return {
+        'quota': configured_quota(),
+        'limit_range': configured_limit_range(),
+        'namespaces': len(usage),
+        'exhausted_namespaces': len(exhausted),
+        'exhausted_resources': dict(by_resource.most_common()),
+        'quota_denials': denials['quota'],
+        'limit_range_denials': denials['limit_range'],
+        'capacity_denials': denials['node_capacity'],
+        'limiting_factor': limiting_factor,
+    }
+
+
+def print_constraints_summary(summary: Dict, logger: logging.Logger):
+    """Log a constraints_summary() result."""
+    logger.info(f"Namespace constraints: {summary['namespaces']} quota(s), "
+                f"{summary['exhausted_namespaces']} exhausted, {summary['quota_denials']} quota denial(s), "
+                f"{summary['limit_range_denials']} limit range denial(s)")
+    for resource, count in summary['exhausted_resources'].items():
+        logger.info(f"  {resource:<40} exhausted in {count} namespace(s)")
+    if summary['limiting_factor'] == 'quota':
+        logger.warning("Namespace quota, not node capacity, was the limiting factor of this run")
+    elif summary['limiting_factor'] == 'node capacity':
+        logger.info("Node capacity, not namespace quota, was the limiting factor of this run")
@@ -0,0 +1,554 @@
+#!/usr/bin/env python3
+"""
+Guest reachability checks for KubeVirt performance testing.
+
+Creation and boot storm workloads wait for every VM to answer a ping (or,
+for Windows guests, to accept RDP/WinRM) from the SSH helper pod. Probing
+each VM with its own `kubectl exec` costs one API request and one exec
+session per VM per poll, which at a few hundred VMs throttles the API
+server and makes the measured ping time depend on exec latency rather than
+on the guest.
+
+A ReachabilityProber coalesces the concurrent probes of all monitor
+threads: the first caller of a round waits a short batching window, then
+probes every pending address in parallel with a single exec into the helper
+pod, and each caller gets the result for its own address. Per-VM metrics
+(number of probes until the guest was reachable, round-trip time of the
+first successful ping) are kept for the results, see take_probe_metrics().
+
+The creation workload goes further with a ReachabilityChecker: a managed
+checker pod (plain alpine, busybox ping/nc) keeps one exec session open for
+the whole run, probes every submitted VM continuously and streams each first
+response back as it happens, so time to ping no longer depends on the
+polling interval. It is started with start_checker() and picked up by the
+workload through active_checker().
+
+VMI addresses come straight from the VMI status (utils.common.get_vmi_ip),
+so no guest-side discovery is needed.
+"""
+
+import logging
+import re
+import shlex
+import subprocess
+import threading
+import time
+from typing import Dict, List, Optional, Tuple
+
+from utils import timing
+from utils.apply import apply_manifest
+from utils.base import GUEST_OS_WINDOWS, WINDOWS_READINESS_PORTS, impersonate, run_kubectl_command, stamp_run_labels
+from utils.concurrency import api_rate_limiter
+
+# --reachability modes of the creation workloads
+REACHABILITY_CHECKER = 'checker'
+REACHABILITY_SSH_POD = 'ssh-pod'
+REACHABILITY_MODES = [REACHABILITY_CHECKER, REACHABILITY_SSH_POD]
+
+# Seconds the first caller of a round waits for other callers to join it
+DEFAULT_BATCH_WINDOW = 0.2
+# Most addresses probed by one exec
+DEFAULT_MAX_BATCH = 256
+# Per-address ping / connect timeout inside the helper pod
+PROBE_TIMEOUT = 2
+# Slack on top of PROBE_TIMEOUT for the exec itself
+EXEC_OVERHEAD = 10
+
+_RTT_RE = re.compile(r'time[=<]([0-9.]+)\s*ms')
+
+# One probe per line: "<ip> [port]", ping when port is 0
+_PROBE_SCRIPT = r'''
+probe() {
+  if [ "$2" = 0 ]; then
+    out=$(ping -c 1 -W %(timeout)d "$1" 2>/dev/null) && echo "$1 0 ok $(echo "$out" | grep -o 'time[=<][0-9.]* *ms' | head -1)" || echo "$1 0 fail"
+  else
+    nc -z -w %(timeout)d "$1" "$2" >/dev/null 2>&1 && echo "$1 $2 ok" || echo "$1 $2 fail"
+  fi
+}
+%(probes)s
+wait
+'''
+
+_probers: Dict[Tuple[str, str], 'ReachabilityProber'] = {}
+_probers_lock = threading.Lock()
+
+
+class _Request:
+    __slots__ = ('probes', 'target', 'done', 'reachable', 'rtt_ms')
+
+    def __init__(self, probes: List[Tuple[str, int]], target: Optional[str]):
+        self.probes = probes
+        self.target = target
+        self.done = False
+        self.reachable = False
+        self.rtt_ms = None
+
+
+class ReachabilityProber:
+    """
+    Probe guest reachability of many VMs from one helper pod, one exec per round.
+
+    probe() is safe to call from any number of threads and blocks until the
+    round that includes the caller's address has finished.
+    """
+
+    def __init__(self, ssh_pod: str, ssh_pod_ns: str, logger: Optional[logging.Logger] = None,
+                 batch_window: float = DEFAULT_BATCH_WINDOW, max_batch: int = DEFAULT_MAX_BATCH):
+        self.ssh_pod = ssh_pod
+        self.ssh_pod_ns = ssh_pod_ns
+        self.logger = logger
+        self.batch_window = batch_window
+        self.max_batch = max_batch
+        self._cond = threading.Condition()
+        self._pending: List[_Request] = []
+        self._running = False
+        self._metrics: Dict[str, Dict] = {}
+
+    def probe(self, ip: str, guest_os: str = 'linux', target: Optional[str] = None) -> bool:
+        """
+        Whether the guest at ip is reachable.
+
+        Linux guests are pinged; Windows guests are reachable once any of the
+        RDP/WinRM ports accepts connections.
+
+        Args:
+            ip: VM IP address
+            guest_os: Guest operating system (linux or windows)
+            target: Namespace or "{namespace}/{vm}" the metrics are recorded under
+        """
+        ports = WINDOWS_READINESS_PORTS if guest_os == GUEST_OS_WINDOWS else [0]
+        request = _Request([(ip, port) for port in ports], target)
+        with self._cond:
+            self._pending.append(request)
+        while True:
+            with self._cond:
+                while not request.done and self._running:
+                    self._cond.wait()
+                if request.done:
+                    break
+                self._running = True
+            self._lead_round()
+        self._record(ip, request)
+        return request.reachable
+
+    def _lead_round(self):
+        time.sleep(self.batch_window)
+        with self._cond:
+            batch, self._pending = self._pending[:self.max_batch], self._pending[self.max_batch:]
+        try:
+            results = self._run([probe for request in batch for probe in request.probes])
+            for request in batch:
+                hits = [probe for probe in request.probes if probe in results]
+                request.reachable = bool(hits)
+                request.rtt_ms = next((results[hit] for hit in hits if results[hit] is not None), None)
+        finally:
+            with self._cond:
+                for request in batch:
+                    request.done = True
+                self._running = False
+                self._cond.notify_all()
+
+    def _run(self, probes: List[Tuple[str, int]]) -> Dict[Tuple[str, int], Optional[float]]:
+        """Probe all addresses in one exec; reachable probes map to their RTT in ms (None for ports)."""
+        lines = '\n'.join(f"probe {shlex.quote(ip)} {port} &" for ip, port in sorted(set(probes)))
+        script = _PROBE_SCRIPT % {'timeout': PROBE_TIMEOUT, 'probes': lines}
+        try:
+            _, stdout, _ = run_kubectl_command(
+                ['exec', '-n', self.ssh_pod_ns, self.ssh_pod, '--', 'sh', '-c', script],
+                check=False,
+                timeout=PROBE_TIMEOUT + EXEC_OVERHEAD,
+                logger=self.logger
+            )
+        except Exception as e:
+            if self.logger:
+                self.logger.debug(f"Reachability probe of {len(probes)} address(es) failed: {e}")
+            return {}
+        results = {}
+        for line in stdout.splitlines():
+            fields = line.split()
+            if len(fields) < 3 or fields[2] != 'ok' or not fields[1].isdigit():
+                continue
+            match = _RTT_RE.search(line)
+            results[(fields[0], int(fields[1]))] = float(match.group(1)) if match else None
+        return results
+
+    def _record(self, ip: str, request: _Request):
+        if request.target is None:
+            return
+        with self._cond:
+            metrics = self._metrics.setdefault(request.target, {'ip': ip, 'probes': 0, 'rtt_ms': None})
+            if metrics.get('reachable'):
+                return
+            metrics['ip'] = ip
+            metrics['probes'] += 1
+            if request.reachable:
+                metrics['reachable'] = True
+                metrics['rtt_ms'] = request.rtt_ms
+
+    def take_metrics(self) -> Dict[str, Dict]:
+        """
+        Per-target probe metrics recorded so far: ip, probes (until reachable)
+        and rtt_ms of the first successful ping.
+
+        The metrics are reset, so a later phase probing the same VMs again
+        (e.g. a boot storm after creation) is measured on its own.
+        """
+        with self._cond:
+            metrics, self._metrics = self._metrics, {}
+        return {target: {'ip': m['ip'], 'probes': m['probes'], 'rtt_ms': m['rtt_ms']}
+                for target, m in metrics.items()}
+
+
+def prober_for(ssh_pod: str, ssh_pod_ns: str,
+               logger: Optional[logging.Logger] = None) -> ReachabilityProber:
+    """The shared prober of a helper pod, so all callers in the process batch together."""
+    with _probers_lock:
+        key = (ssh_pod_ns, ssh_pod)
+        if key not in _probers:
+            _probers[key] = ReachabilityProber(ssh_pod, ssh_pod_ns, logger)
+        return _probers[key]
+
+
+def take_probe_metrics() -> Dict[str, Dict]:
+    """Per-target probe metrics of all probers of this process (see ReachabilityProber.take_metrics)."""
+    with _probers_lock:
+        probers = list(_probers.values())
+    merged: Dict[str, Dict] = {}
+    for prober in probers:
+        merged.update(prober.take_metrics())
+    if _checker is not None:
+        merged.update(_checker.take_metrics())
+    return merged
+
+
+# Managed checker pod: plain alpine (busybox ping/nc/sh), nothing to install
+CHECKER_POD = 'virtbench-reachability'
+CHECKER_IMAGE = 'alpine:latest'
+# Seconds between probes of one address inside the checker pod
+CHECKER_INTERVAL = 0.2
+# Times a dropped exec session is re-established before pending checks fail
+CHECKER_RESTARTS = 3
+
+# Probes of the checker pod, see ReachabilityChecker.submit_probes()
+PROBE_PING = '0'
+PROBE_DNS = 'dns'
+PROBE_DNS_NOT = 'dns-not='
+
+# Reads "<id> <host> <probe> <timeout>" lines from stdin and answers each with
+# "<id> ok <attempts> [time=<rtt> ms]" or "<id> timeout <attempts>" as soon as it is known
+_CHECKER_SCRIPT = r'''
+check() {
+  end=$(( $(date +%%s) + $4 )); n=0
+  while [ "$(date +%%s)" -lt "$end" ]; do
+    n=$((n + 1))
+    case "$3" in
+      0) out=$(ping -c 1 -W 1 "$2" 2>/dev/null) && { echo "$1 ok $n $(echo "$out" | grep -o 'time[=<][0-9.]* *ms' | head -1)"; return; } ;;
+      dns) nslookup "$2" 2>/dev/null | grep -q '^Name:' && { echo "$1 ok $n"; return; } ;;
+      dns-not=*) out=$(nslookup "$2" 2>/dev/null | sed -n '/^Name:/,$p')
+        echo "$out" | grep -q '^Name:' && ! echo "$out" | grep -qwF "${3#dns-not=}" && { echo "$1 ok $n"; return; } ;;
+      *) nc -z -w 1 "$2" "$3" >/dev/null 2>&1 && { echo "$1 ok $n"; return; } ;;
+    esac
+    sleep %(interval)s
+  done
+  echo "$1 timeout $n"
+}
+while read id host probe timeout; do check "$id" "$host" "$probe" "$timeout" & done
+wait
+'''
+
+_checker: Optional['ReachabilityChecker'] = None
+
+
+def ensure_checker_pod(namespace: str, host_network: bool = False, timeout: int = 180,
+                       logger: Optional[logging.Logger] = None) -> Tuple[bool, bool]:
+    """
+    Ensure the reachability checker pod is running and can send ICMP echoes.
+
+    The pod gets the NET_RAW capability for ping. With host_network it runs in
+    the node's network namespace, which takes the pod network's own policies
+    out of the measured path.
+
+    Args:
+        namespace: Checker pod namespace
+        host_network: Run the checker pod with hostNetwork
+        timeout: Seconds to wait for the pod to become usable
+        logger: Logger instance
+
+    Returns:
+        Tuple of (ready, created_by_us)
+    """
+    def _ready() -> bool:
+        rc, _, _ = run_kubectl_command(
+            ['exec', '-n', namespace, CHECKER_POD, '--', 'ping', '-c', '1', '-W', '1', '127.0.0.1'],
+            check=False, timeout=15
+        )
+        return rc == 0
+
+    if _ready():
+        if logger:
+            logger.info(f"Using existing reachability checker pod {namespace}/{CHECKER_POD}")
+        return True, False
+
+    created = False
+    rc, _, _ = run_kubectl_command(['get', 'pod', CHECKER_POD, '-n', namespace], check=False, timeout=15)
+    if rc != 0:
+        if logger:
+            logger.info(f"Creating reachability checker pod {namespace}/{CHECKER_POD}"
+                        f"{' (host network)' if host_network else ''}...")
+        network = "  hostNetwork: true\n  dnsPolicy: ClusterFirstWithHostNet\n" if host_network else ""
+        manifest = f"""apiVersion: v1
+kind: Pod
+metadata:
+  name: {CHECKER_POD}
+  namespace: {namespace}
+  labels:
+    app: kubevirt-perf-test
+spec:
+{network}  containers:
+  - name: checker
+    image: {CHECKER_IMAGE}
+    command: ["/bin/sh", "-c", "tail -f /dev/null"]
+    securityContext:
+      capabilities:
+        add: ["NET_RAW"]
+    resources:
+      requests:
+        memory: "64Mi"
+        cpu: "100m"
+      limits:
+        memory: "256Mi"
+        cpu: "500m"
+  restartPolicy: Always
+"""
+        import yaml
+        applied, error = apply_manifest(yaml.safe_dump(stamp_run_labels(yaml.safe_load(manifest))), logger=logger)
+        if not applied:
+            if logger:
+                logger.error(f"Failed to create reachability checker pod: {error}")
+            return False, False
+        created = True
+
+    start = time.monotonic()
+    while time.monotonic() - start < timeout:
+        if _ready():
+            if logger:
+                logger.info(f"Reachability checker pod {namespace}/{CHECKER_POD} is ready")
+            return True, created
+        time.sleep(2)
+
+    if logger:
+        logger.error(f"Reachability checker pod {namespace}/{CHECKER_POD} not ready after {timeout}s "
+                     f"(ping needs the NET_RAW capability)")
+    return False, created
+
+
+class Check:
+    """A pending reachability check of one VM; see ReachabilityChecker.submit()."""
+
+    def __init__(self, ip: str, target: Optional[str], ids: List[int]):
+        # Address or host name checked
+        self.ip = ip
+        self.target = target
+        self.ids = set(ids)
+        self.reachable = False
+        # timing.now() when the first response arrived
+        self.responded_at: Optional[timing.MonotonicTimestamp] = None
+        self.rtt_ms: Optional[float] = None
+        self.attempts = 0
+        self._event = threading.Event()
+
+    def done(self) -> bool:
+        return self._event.is_set()
+
+    def wait(self, timeout: Optional[float] = None) -> bool:
+        """Block until the check has finished; returns whether the guest responded."""
+        self._event.wait(timeout)
+        return self.reachable
+
+
+class ReachabilityChecker:
+    """
+    Continuous reachability checks from the checker pod over one exec session.
+
+    Each submitted VM is probed inside the pod every CHECKER_INTERVAL seconds
+    until it responds or its timeout expires, all VMs concurrently, and the
+    answer is streamed back the moment it is known. The time of the first
+    response is taken when the answer arrives, so it has millisecond
+    resolution instead of the workload's polling interval, and checking
+    hundreds of VMs costs one API request instead of one per VM and poll.
+    """
+
+    def __init__(self, pod: str, namespace: str, logger: Optional[logging.Logger] = None):
+        self.pod = pod
+        self.namespace = namespace
+        self.logger = logger
+        self._lock = threading.Lock()
+        self._proc: Optional[subprocess.Popen] = None
+        self._checks: Dict[int, Tuple[Check, str, str, float]] = {}
+        self._next_id = 0
+        self._restarts = 0
+        self._closed = False
+        self._metrics: Dict[str, Dict] = {}
+
+    def start(self):
+        script = _CHECKER_SCRIPT % {'interval': CHECKER_INTERVAL}
+        cmd = ['kubectl'] + impersonate(['exec', '-i', '-n', self.namespace, self.pod]) + ['--', 'sh', '-c', script]
+        api_rate_limiter().wait()
+        self._proc = subprocess.Popen(cmd, stdin=subprocess.PIPE, stdout=subprocess.PIPE,
+                                      stderr=subprocess.DEVNULL, text=True, bufsize=1)
+        threading.Thread(target=self._read, args=(self._proc,), daemon=True).start()
+
+    def submit(self, ip: str, guest_os: str = 'linux', timeout: int = 600,
+               target: Optional[str] = None) -> Check:
+        """
+        Start checking a VM. Linux guests are pinged; Windows guests respond once
+        RDP or WinRM accepts connections.
+
+        Args:
+            ip: VM IP address
+            guest_os: Guest operating system (linux or windows)
+            timeout: Seconds after which the VM counts as unreachable
+            target: Namespace or "{namespace}/{vm}" the metrics are recorded under
+        """
+        ports = WINDOWS_READINESS_PORTS if guest_os == GUEST_OS_WINDOWS else [PROBE_PING]
+        return self.submit_probes(ip, [str(port) for port in ports], timeout, target)
+
+    def submit_probes(self, host: str, probes: List[str], timeout: int = 600,
+                      target: Optional[str] = None) -> Check:
+        """
+        Start checking a host; the check succeeds on the first probe that does.
+
+        Args:
+            host: Address or DNS name
+            probes: PROBE_PING, a TCP port number, PROBE_DNS (the name resolves)
+                or "dns-not=<address>" (the name resolves, but no longer to that address)
+            timeout: Seconds after which the check fails
+            target: Name the metrics are recorded under (None to record none)
+        """
+        deadline = time.monotonic() + timeout
+        with self._lock:
+            ids = list(range(self._next_id, self._next_id + len(probes)))
+            self._next_id += len(probes)
+            check = Check(host, target, ids)
+            for check_id, probe in zip(ids, probes):
+                self._checks[check_id] = (check, host, probe, deadline)
+                self._send(check_id, host, probe, deadline)
+        return check
+
+    def _send(self, check_id: int, host: str, probe: str, deadline: float):
+        remaining = max(1, int(deadline - time.monotonic()))
+        try:
+            self._proc.stdin.write(f"{check_id} {host} {probe} {remaining}\n")
+            self._proc.stdin.flush()
+        except (OSError, ValueError):
+            # The reader notices the dropped session and re-sends pending checks
+            pass
+
+    def _read(self, proc: subprocess.Popen):
+        for line in proc.stdout:
+            arrived = timing.now()
+            fields = line.split()
+            if len(fields) < 3 or not fields[0].isdigit():
+                continue
+            with self._lock:
+                entry = self._checks.pop(int(fields[0]), None)
+                if entry is None:
+                    continue
+                check = entry[0]
+                check.ids.discard(int(fields[0]))
+                check.attempts = max(check.attempts, int(fields[2]) if fields[2].isdigit() else 0)
+                if fields[1] == 'ok' and not check.done():
+                    match = _RTT_RE.search(line)
+                    check.reachable = True
+                    check.responded_at = arrived
+                    check.rtt_ms = float(match.group(1)) if match else None
+                    self._finish(check)
+                elif not check.ids and not check.done():
+                    self._finish(check)
+        proc.wait()
+        with self._lock:
+            if self._closed or proc is not self._proc:
+                return
+            pending = list(self._checks.items())
+            if not pending:
+                self._proc = None
+                return
+            self._restarts += 1
+            if self._restarts > CHECKER_RESTARTS:
+                if self.logger:
+                    self.logger.error(f"Reachability checker session lost {self._restarts} times; "
+                                      f"{len(pending)} check(s) failed")
+                for _, (check, _, _, _) in pending:
+                    self._finish(check)
+                self._checks.clear()
+                return
+            if self.logger:
+                self.logger.warning(f"Reachability checker session ended (exit {proc.returncode}); "
+                                    f"restarting with {len(pending)} pending check(s)")
+            self.start()
+            for check_id, (_, host, probe, deadline) in pending:
+                self._send(check_id, host, probe, deadline)
+
+    def _finish(self, check: Check):
+        # Remaining ports of a check that already responded are dropped
+        for check_id in check.ids:
+            self._checks.pop(check_id, None)
+        check.ids.clear()
+        if check.target is not None:
+            self._metrics[check.target] = {'ip': check.ip, 'probes': check.attempts, 'rtt_ms': check.rtt_ms}
+        check._event.set()
+
+    def take_metrics(self) -> Dict[str, Dict]:
+        """Per-target metrics of finished checks (see ReachabilityProber.take_metrics); resets them."""
+        with self._lock:
+            metrics, self._metrics = self._metrics, {}
+        return metrics
+
+    def close(self):
+        with self._lock:
+            self._closed = True
+            proc, self._proc = self._proc, None
+            for check, _, _, _ in self._checks.values():
+                check._event.set()
+            self._checks.clear()
+        if proc:
+            try:
+                proc.stdin.close()
+            except OSError:
+                pass
+            proc.terminate()
+
+
+def start_checker(namespace: str, host_network: bool = False,
+                  logger: Optional[logging.Logger] = None) -> Optional[ReachabilityChecker]:
+    """
+    Ensure the checker pod and start the process-wide checker session.
+
+    Returns:
+        The checker, or None when the checker pod could not be started
+    """
+    global _checker
+    ready, _ = ensure_checker_pod(namespace, host_network, logger=logger)
+    if not ready:
+        return None
+    checker = ReachabilityChecker(CHECKER_POD, namespace, logger)
+    checker.start()
+    _checker = checker
+    return checker
+
+
+def active_checker() -> Optional[ReachabilityChecker]:
+    """The checker started by start_checker(), or None when workloads probe through the SSH pod."""
+    return _checker
+
+
+def stop_checker(delete_pod: bool = False, logger: Optional[logging.Logger] = None):
+    """Close the checker session, and delete the checker pod if asked to."""
+    global _checker
+    checker, _checker = _checker, None
+    if checker is None:
+        return
+    checker.close()
+    if delete_pod:
+        run_kubectl_command(['delete', 'pod', CHECKER_POD, '-n', checker.namespace,
+                             '--ignore-not-found', '--wait=false'], check=False, logger=logger)
@@ -0,0 +1,198 @@
+#!/usr/bin/env python3
+"""
+Retry policy for transient API errors in KubeVirt performance testing.
+
+Large runs issue thousands of kubectl calls, and a busy API server answers
+some of them with throttling (429), timeouts, update conflicts or brief
+unavailability. Every call made through utils.common.run_kubectl_command is
+retried on these transient errors with exponential backoff, so a run does
+not fail outright on a momentary hiccup.
+
+The policy is set with the global virtbench options (or their environment
+variables):
+
+    --retries N            VIRTBENCH_RETRIES         Retries per call (default: 3, 0 disables)
+    --retry-backoff SEC    VIRTBENCH_RETRY_BACKOFF   First delay, doubled per retry (default: 1)
+    --retry-on CLASSES     VIRTBENCH_RETRY_ON        Comma-separated error classes (default: all)
+
+Error classes are throttled, timeout, conflict, unavailable and connection.
+
+Whether a failed request may be sent again depends on its verb. A throttled
+request was rejected before the API server acted on it, so any verb is
+retried. After a timeout or a dropped connection the request may have been
+carried out, so only idempotent verbs (get, apply, delete, ...) are retried,
+and create, whose retry then fails with AlreadyExists: that answer counts as
+success. A conflict means the payload's resourceVersion is stale; only verbs
+that re-read the object before writing (apply, label, annotate, ...) are
+retried on it, never the same replace or patch. Admission webhook failures
+are not transient: a broken webhook fails every retry the same way.
+"""
+
+import logging
+import os
+import random
+import time
+from typing import Callable, Dict, List, Optional, Tuple
+
+RETRIES_ENV = 'VIRTBENCH_RETRIES'
+RETRY_BACKOFF_ENV = 'VIRTBENCH_RETRY_BACKOFF'
+RETRY_ON_ENV = 'VIRTBENCH_RETRY_ON'
+
+DEFAULT_RETRIES = 3
+DEFAULT_BACKOFF = 1.0
+MAX_BACKOFF = 30.0
+
+# Error class -> fragments of kubectl/apiserver error output that identify it
+TRANSIENT_ERRORS: Dict[str, Tuple[str, ...]] = {
+    'throttled': ('Too Many Requests', 'TooManyRequests', '(429)',
+                  'the server has received too many requests'),
+    'timeout': ('context deadline exceeded', 'i/o timeout', 'TLS handshake timeout',
+                'Client.Timeout exceeded', 'etcdserver: request timed out',
+                'the server was unable to return a response in the time allotted',
+                'Timeout: request did not complete'),
+    'conflict': ('the object has been modified', 'Operation cannot be fulfilled'),
+    'unavailable': ('ServiceUnavailable', 'the server is currently unable to handle the request',
+                    'etcdserver: leader changed', 'temporarily unavailable'),
+    'connection': ('connection refused', 'connection reset by peer', 'Unable to connect to the server',
+                   'unexpected EOF', 'no route to host', 'http2: client connection lost'),
+}
+ERROR_CLASSES = tuple(TRANSIENT_ERRORS)
+
+# Fragments of errors that are never retried, even when they also match a
+# transient class (a webhook that cannot be reached is reported as a
+# refused connection wrapped in "failed calling webhook")
+PERMANENT_ERRORS = ('failed calling webhook', 'admission webhook', 'denied the request')
+
+# Verbs whose request has the same outcome when it is sent twice
+IDEMPOTENT_VERBS = {'get', 'describe', 'apply', 'delete', 'label', 'annotate', 'taint', 'cordon',
+                    'uncordon', 'auth', 'api-resources', 'api-versions', 'version', 'explain', 'top',
+                    'dry-run'}
+# Verbs that read the live object before they write it, so a conflict can resolve on retry
+CONFLICT_RETRIED_VERBS = {'apply', 'delete', 'label', 'annotate', 'taint', 'cordon', 'uncordon'}
+ALREADY_EXISTS = ('AlreadyExists', 'already exists')
+
+# kubectl verbs that run something (a guest command, a wait, a stream) rather
+# than a single API request; their failures are not retried
+NON_RETRIED_VERBS = {'exec', 'cp', 'attach', 'port-forward', 'proxy', 'wait', 'logs', 'debug', 'run'}
+
+# kubectl global flags that take a value, skipped when looking for the verb
+_VALUE_FLAGS = {'-n', '--namespace', '--context', '--kubeconfig', '--cluster', '--user', '-s', '--server'}
+
+
+class RetryPolicy:
+    """How often and on which errors an API call is retried."""
+
+    def __init__(self, retries: int = DEFAULT_RETRIES, backoff: float = DEFAULT_BACKOFF,
+                 retry_on: Optional[List[str]] = None, max_backoff: float = MAX_BACKOFF):
+        self.retries = max(0, int(retries))
+        self.backoff = max(0.0, float(backoff))
+        self.retry_on = set(retry_on if retry_on is not None else ERROR_CLASSES)
+        self.max_backoff = max_backoff
+
+    def delay(self, attempt: int) -> float:
+        """Backoff before retry `attempt` (1-based): backoff * 2^(attempt-1), capped, with jitter."""
+        delay = min(self.max_backoff, self.backoff * (2 ** (attempt - 1)))
+        # Jitter spreads the retries of many workers that were throttled at the same moment
+        return delay * random.uniform(0.5, 1.0)
+
+
+def policy_from_env() -> RetryPolicy:
+    """The retry policy set with the global virtbench options (defaults if unset or invalid)."""
+    try:
+        retries = int(os.environ.get(RETRIES_ENV, DEFAULT_RETRIES))
+    except ValueError:
+        retries = DEFAULT_RETRIES
+    try:
+        backoff = float(os.environ.get(RETRY_BACKOFF_ENV, DEFAULT_BACKOFF))
+    except ValueError:
+        backoff = DEFAULT_BACKOFF
+    retry_on = None
+    if os.environ.get(RETRY_ON_ENV):
+        retry_on = [c.strip() for c in os.environ[RETRY_ON_ENV].split(',') if c.strip() in TRANSIENT_ERRORS]
+    return RetryPolicy(retries, backoff, retry_on)
+
+
+def classify_error(output: str) -> Optional[str]:
+    """Return the transient error class of kubectl error output, or None for other errors."""
+    if not output or any(fragment in output for fragment in PERMANENT_ERRORS):
+        return None
+    for error_class, fragments in TRANSIENT_ERRORS.items():
+        if any(fragment in output for fragment in fragments):
+            return error_class
+    return None
+
+
+def kubectl_verb(args: List[str]) -> Optional[str]:
+    """The kubectl verb of a command line, e.g. "get" for ['-n', 'ns', 'get', 'vm']."""
+    skip = False
+    for arg in args:
+        if skip:
+            skip = False
+        elif arg in _VALUE_FLAGS:
+            skip = True
+        elif not arg.startswith('-'):
+            return arg
+    return None
+
+
+def retryable(error_class: Optional[str], verb: Optional[str]) -> bool:
+    """Whether a request of a kubectl verb that failed with an error class may be sent again."""
+    if error_class is None:
+        return False
+    if error_class == 'throttled':
+        return True
+    if error_class == 'conflict':
+        return verb in CONFLICT_RETRIED_VERBS
+    return verb in IDEMPOTENT_VERBS or verb == 'create'
+
+
+def request_verb(args: List[str]) -> Optional[str]:
+    """The verb retryable() judges a kubectl command line by: its kubectl verb, or "dry-run" for a --dry-run request."""
+    if any(arg.startswith('--dry-run') and arg not in ('--dry-run=none', '--dry-run=false') for arg in args):
+        return 'dry-run'
+    return kubectl_verb(args)
+
+
+def call_with_retries(call: Callable[[], Tuple[int, str, str]], description: str,
+                      policy: Optional[RetryPolicy] = None,
+                      logger: Optional[logging.Logger] = None,
+                      verb: Optional[str] = None) -> Tuple[int, str, str]:
+    """
+    Run call() and retry it while it fails with a transient error its verb can be retried on.
+
+    Args:
+        call: Callable returning (return_code, stdout, stderr)
+        description: Command line or operation, for the log
+        policy: Retry policy (default: policy_from_env())
+        logger: Logger instance (default: the kubevirt-perf logger)
+        verb: kubectl verb of the call (see retryable()); None retries throttling only
+
+    Returns:
+        (return_code, stdout, stderr) of the last attempt; a create that fails
+        with AlreadyExists after a timed-out attempt returns 0, as the object was
+        created by that attempt
+    """
+    policy = policy or policy_from_env()
+    logger = logger or logging.getLogger('kubevirt-perf')
+    attempt = 0
+    # Whether an earlier attempt may have been carried out although it failed
+    maybe_applied = False
+    while True:
+        returncode, stdout, stderr = call()
+        if returncode == 0:
+            return returncode, stdout, stderr
+        if maybe_applied and verb == 'create' and any(fragment in (stderr or '') for fragment in ALREADY_EXISTS):
+            logger.info(f"Create succeeded on an earlier attempt that timed out: {description}")
+            return 0, stdout, stderr
+        error_class = classify_error(stderr)
+        maybe_applied = maybe_applied or error_class in ('timeout', 'unavailable', 'connection')
+        if error_class not in policy.retry_on or not retryable(error_class, verb) or attempt >= policy.retries:
+            if error_class and attempt:
+                logger.warning(f"Giving up after {attempt} retries ({error_class}): {description}")
+            return returncode, stdout, stderr
+        attempt += 1
+        delay = policy.delay(attempt)
+        logger.warning(f"Transient API error ({error_class}), retry {attempt}/{policy.retries} "
+                       f"in {delay:.1f}s: {description}")
+        logger.debug(f"Error output: {(stderr or '').strip()}")
+        time.sleep(delay)
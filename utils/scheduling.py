@@ -0,0 +1,139 @@
+#!/usr/bin/env python3
+"""
+Scheduling constraints of benchmark VMs.
+
+Constrained placement scenarios (VMs limited to a node pool, kept off or
+onto nodes by affinity, spread one per node) would otherwise need a forked
+VM template each. The global options below add the constraints to the
+template of every VirtualMachine a workload creates, through the same
+manifest stamping as the run labels (see utils.common.stamp_manifest):
+
+- --node-selector KEY=VALUE,... (VIRTBENCH_NODE_SELECTOR): merged into the
+  VM's nodeSelector, over the template's own labels of the same keys
+- --affinity-file FILE (VIRTBENCH_AFFINITY_FILE): a pod affinity object
+  (nodeAffinity, podAffinity, podAntiAffinity); each part given replaces
+  the template's part of that kind
+- --anti-affinity soft|hard (VIRTBENCH_ANTI_AFFINITY): a pod anti-affinity
+  term keeping the run's VMs on different nodes, preferred (soft) or
+  required (hard); it selects the virt-launcher pods by the run UUID label
+  in every namespace, since the workloads put each VM in its own namespace
+- --taint KEY[=VALUE]:EFFECT,... (VIRTBENCH_TAINTS): a toleration of each
+  taint the CLI puts on the --taint-nodes nodes for the run; tolerations
+  let VMs onto the tainted nodes but do not keep them there, for that pair
+  --taint-nodes with a --node-selector of the same nodes
+
+The virtbench CLI checks before the run that some node satisfies the node
+selector and the required node affinity (virtbench/utils/scheduling.py).
+Placement strategies pin VMs with a kubernetes.io/hostname node selector;
+they do not know about these constraints, so a pinned node must satisfy them.
+"""
+
+import copy
+import logging
+import os
+import threading
+from typing import Dict, List, Optional
+
+import yaml
+
+from utils.base import RUN_UUID_LABEL, get_run_uuid, run_labels
+
+NODE_SELECTOR_ENV = 'VIRTBENCH_NODE_SELECTOR'
+AFFINITY_FILE_ENV = 'VIRTBENCH_AFFINITY_FILE'
+ANTI_AFFINITY_ENV = 'VIRTBENCH_ANTI_AFFINITY'
+TAINTS_ENV = 'VIRTBENCH_TAINTS'
+
+ANTI_AFFINITY_SOFT = 'soft'
+ANTI_AFFINITY_HARD = 'hard'
+HOSTNAME_LABEL = 'kubernetes.io/hostname'
+# Weight of the soft anti-affinity term (1-100)
+ANTI_AFFINITY_WEIGHT = 100
+
+_lock = threading.Lock()
+_affinity: Optional[Dict] = None
+
+
+def node_selector() -> Dict[str, str]:
+    """Labels of --node-selector (key=value,...)."""
+    selector = {}
+    for entry in (e.strip() for e in os.environ.get(NODE_SELECTOR_ENV, '').split(',') if e.strip()):
+        key, _, value = entry.partition('=')
+        selector[key.strip()] = value.strip()
+    return selector
+
+
+def affinity_file() -> Dict:
+    """Affinity of --affinity-file (bare, or under an "affinity" key), loaded once."""
+    global _affinity
+    path = os.environ.get(AFFINITY_FILE_ENV)
+    if not path:
+        return {}
+    with _lock:
+        if _affinity is None:
+            with open(path) as f:
+                affinity = yaml.safe_load(f) or {}
+            _affinity = affinity['affinity'] if set(affinity) == {'affinity'} else affinity
+        return copy.deepcopy(_affinity)
+
+
+def tolerations() -> List[Dict[str, str]]:
+    """Tolerations of the --taint taints (KEY[=VALUE]:EFFECT,...)."""
+    result = []
+    for entry in (e.strip() for e in os.environ.get(TAINTS_ENV, '').split(',') if e.strip()):
+        key_value, _, effect = entry.rpartition(':')
+        key, sep, value = key_value.partition('=')
+        toleration = {'key': key, 'operator': 'Equal' if sep and value else 'Exists', 'effect': effect}
+        if sep and value:
+            toleration['value'] = value
+        result.append(toleration)
+    return result
+
+
+def anti_affinity_term() -> Dict:
+    """Pod affinity term selecting the virt-launcher pods of this run in every namespace, per node."""
+    labels = {RUN_UUID_LABEL: get_run_uuid()} if get_run_uuid() else run_labels()
+    return {
+        'labelSelector': {'matchLabels': labels},
+        'namespaceSelector': {},
+        'topologyKey': HOSTNAME_LABEL,
+    }
+
+
+def constrain_scheduling(doc: Dict, logger: Optional[logging.Logger] = None) -> Dict:
+    """
+    Add the --node-selector, --affinity-file, --anti-affinity and --taint constraints to a VirtualMachine.
+
+    Other objects are returned unchanged.
+    """
+    if doc.get('kind') != 'VirtualMachine':
+        return doc
+    selector, affinity = node_selector(), affinity_file()
+    anti_affinity = os.environ.get(ANTI_AFFINITY_ENV)
+    taint_tolerations = tolerations()
+    if not selector and not affinity and not anti_affinity and not taint_tolerations:
+        return doc
+
+    spec = doc.setdefault('spec', {}).setdefault('template', {}).setdefault('spec', {})
+    if selector:
+        spec['nodeSelector'] = {**(spec.get('nodeSelector') or {}), **selector}
+    if affinity or anti_affinity:
+        spec['affinity'] = {**(spec.get('affinity') or {}), **affinity}
+    if anti_affinity:
+        pod_anti_affinity = spec['affinity'].setdefault('podAntiAffinity', {})
+        if anti_affinity == ANTI_AFFINITY_HARD:
+            terms = pod_anti_affinity.setdefault('requiredDuringSchedulingIgnoredDuringExecution', [])
+            term = anti_affinity_term()
+        else:
+            terms = pod_anti_affinity.setdefault('preferredDuringSchedulingIgnoredDuringExecution', [])
+            term = {'weight': ANTI_AFFINITY_WEIGHT, 'podAffinityTerm': anti_affinity_term()}
+        # A manifest stamped twice (e.g. adopted after a failed create) keeps one term
+        if term not in terms:
+            terms.append(term)
+    if taint_tolerations:
+        spec_tolerations = spec.setdefault('tolerations', [])
+        spec_tolerations += [t for t in taint_tolerations if t not in spec_tolerations]
+    if logger:
+        logger.debug(f"Scheduling constraints of VM {doc.get('metadata', {}).get('name')}: "
+                     f"nodeSelector {spec.get('nodeSelector')}, affinity {spec.get('affinity')}, "
+                     f"tolerations {spec.get('tolerations')}")
+    return doc
@@ -0,0 +1,180 @@
+#!/usr/bin/env python3
+"""
+Statistics of timing metrics for KubeVirt performance testing.
+
+Every workload summarizes its timing metrics (time to Running, clone time,
+migration time, ...) with metric_stats():
+
+    {"metric": "running_time_sec", "avg": 9.2, "min": 6.1, "max": 31.4,
+     "median": 8.7, "p90": 12.0, "p95": 14.2, "p99": 30.8, "stddev": 3.9, "count": 200}
+
+Percentiles use the nearest-rank method, so they are always observed values;
+stddev is the population standard deviation of the run's samples.
+
+Items (VMs, volumes, migrations) whose value is more than N standard
+deviations above the metric's mean are flagged as outliers, a sign of a
+slow node, a throttled volume or a retried operation. N defaults to 3 and
+is set with the global virtbench option (or its environment variable):
+
+    --outlier-sigma N      VIRTBENCH_OUTLIER_SIGMA
+
+Warm-up iterations (--warmup-iterations) end early once the workload is in
+steady state: the coefficient of variation (stddev / mean) of the last
+STEADY_STATE_WINDOW iteration means is at most a threshold (--steady-state-cv).
+"""
+
+import logging
+import math
+import os
+from typing import Callable, Dict, Iterable, List, Optional, Tuple
+
+from utils.timing import round_duration
+
+OUTLIER_SIGMA_ENV = 'VIRTBENCH_OUTLIER_SIGMA'
+DEFAULT_OUTLIER_SIGMA = 3.0
+
+# Outliers need a spread: with n samples no value can be more than (n-1)/sqrt(n) sigma from the mean
+MIN_OUTLIER_SAMPLES = 3
+
+# Warm-up iterations compared for steady state, and the default coefficient of variation threshold
+STEADY_STATE_WINDOW = 3
+DEFAULT_STEADY_STATE_CV = 0.1
+
+# Statistic columns of metric_stats(), in CSV order
+STAT_FIELDS = ['avg', 'min', 'max', 'median', 'p90', 'p95', 'p99', 'stddev', 'count']
+
+
+def stat_columns(*columns: str) -> List[str]:
+    """CSV columns of a summary that already had columns: those first, in their order, then the other STAT_FIELDS."""
+    return list(columns) + [field for field in STAT_FIELDS if field not in columns]
+
+
+def percentile(values: Iterable[float], pct: float) -> Optional[float]:
+    """Nearest-rank percentile (None without values)."""
+    ordered = sorted(values)
+    if not ordered:
+        return None
+    return ordered[max(0, math.ceil(pct / 100 * len(ordered)) - 1)]
+
+
+def stddev(values: List[float]) -> Optional[float]:
+    """Population standard deviation (None without values)."""
+    if not values:
+        return None
+    mean = sum(values) / len(values)
+    return math.sqrt(sum((v - mean) ** 2 for v in values) / len(values))
+
+
+def describe(values: Iterable[float]) -> Dict:
+    """avg, min, max, median, p90, p95, p99, stddev and count of values; None values are skipped."""
+    values = [v for v in values if v is not None]
+    if not values:
+        return {field: (0 if field == 'count' else None) for field in STAT_FIELDS}
+    return {
+        'avg': round_duration(sum(values) / len(values)),
+        'min': round_duration(min(values)),
+        'max': round_duration(max(values)),
+        'median': round_duration(percentile(values, 50)),
+        'p90': round_duration(percentile(values, 90)),
+        'p95': round_duration(percentile(values, 95)),
+        'p99': round_duration(percentile(values, 99)),
+        'stddev': round_duration(stddev(values)),
+        'count': len(values),
+    }
+
+
+def metric_stats(name: str, values: Iterable[float]) -> Dict:
+    """Summary entry of one metric: {"metric": name, **describe(values)}."""
+    return {'metric': name, **describe(values)}
+
+
+def coefficient_of_variation(values: List[float]) -> Optional[float]:
+    """stddev / mean (None with fewer than 2 values or a zero mean)."""
+    if len(values) < 2:
+        return None
+    mean = sum(values) / len(values)
+    return stddev(values) / mean if mean else None
+
+
+def steady_state(iteration_means: List[float], threshold: float = DEFAULT_STEADY_STATE_CV,
+                 window: int = STEADY_STATE_WINDOW) -> Tuple[bool, Optional[float]]:
+    """
+    Whether a series of per-iteration means has settled.
+
+    Returns:
+        (steady, cv): steady once there are window means and the coefficient
+        of variation of the last window of them is at most threshold
+    """
+    if len(iteration_means) < window:
+        return False, None
+    cv = coefficient_of_variation(iteration_means[-window:])
+    return cv is not None and cv <= threshold, None if cv is None else round(cv, 4)
+
+
+def outlier_sigma() -> float:
+    """Outlier threshold in standard deviations (VIRTBENCH_OUTLIER_SIGMA, default 3)."""
+    try:
+        return float(os.environ.get(OUTLIER_SIGMA_ENV) or DEFAULT_OUTLIER_SIGMA)
+    except ValueError:
+        return DEFAULT_OUTLIER_SIGMA
+
+
+def find_outliers(samples: List[Tuple[str, float]], sigma: Optional[float] = None) -> List[Dict]:
+    """
+    Samples more than sigma standard deviations above their mean, slowest first.
+
+    Args:
+        samples: (item, value) pairs, e.g. (namespace, running time)
+        sigma: Threshold (default: outlier_sigma())
+
+    Returns:
+        List of {"item", "value", "z_score", "sigma"}, sigma being the threshold used
+    """
+    sigma = outlier_sigma() if sigma is None else sigma
+    values = [value for _, value in samples if value is not None]
+    if len(values) < MIN_OUTLIER_SAMPLES:
+        return []
+    mean, spread = sum(values) / len(values), stddev(values)
+    if not spread:
+        return []
+    outliers = [{'item': item, 'value': round_duration(value), 'z_score': round((value - mean) / spread, 2),
+                 'sigma': sigma}
+                for item, value in samples if value is not None and (value - mean) / spread > sigma]
+    return sorted(outliers, key=lambda o: o['z_score'], reverse=True)
+
+
+def metric_outliers(rows: List[Dict], metrics: Iterable[str], item: Callable[[Dict], str],
+                    sigma: Optional[float] = None) -> List[Dict]:
+    """
+    Outliers of several metrics of per-item result rows.
+
+    Args:
+        rows: Result rows (dicts), e.g. one per VM
+        metrics: Row keys of the timing metrics
+        item: Name of a row in the outlier list, e.g. lambda r: r['namespace']
+        sigma: Threshold (default: outlier_sigma())
+
+    Returns:
+        List of {"metric", "item", "value", "z_score", "sigma"}
+    """
+    outliers = []
+    for name in metrics:
+        samples = [(item(row), row.get(name)) for row in rows]
+        outliers.extend({'metric': name, **o} for o in find_outliers(samples, sigma))
+    return outliers
+
+
+def log_outliers(outliers: List[Dict], logger: Optional[logging.Logger] = None):
+    """Log (or print) outliers as returned by metric_outliers, under the threshold each was found with."""
+    if not outliers:
+        return
+    lines = []
+    for sigma in dict.fromkeys(o.get('sigma', outlier_sigma()) for o in outliers):
+        lines.append(f"Outliers (more than {sigma:g} standard deviations above the mean):")
+        lines += [f"  {o['metric']:<24}{o['item']:<40}{o['value']}s (z={o['z_score']})"
+                  for o in outliers if o.get('sigma', outlier_sigma()) == sigma]
+    for line in lines:
+        if logger:
+            logger.warning(line)
+        else:
+            print(line)
@@ -0,0 +1,223 @@
+#!/usr/bin/env python3
+"""
+Side-by-side comparison of one workload on several storage classes.
+
+`virtbench datasource-clone`, `virtbench migration --create-vms` and
+`virtbench fio` take several --storage-class values and run the identical
+workload on every storage class, either once per class
+(--storage-class-mode per-class) or striped, the namespace range split
+across the classes and run at the same time (striped). With --access-modes
+and --volume-modes, every (storage class, access mode, volume mode) variant
+is a run of its own, named like px-db-rwx-block and listed in variants.json.
+This script then compares the runs:
+
+    <results>/storage-class-comparison/<timestamp>/<variant>/...   one run each
+    <results>/storage-class-comparison/<timestamp>/variants.json
+    <results>/storage-class-comparison/<timestamp>/storage_class_comparison.{json,csv}
+
+Every metric the runs share is compared by its average and p95. The best
+variant of a metric has the lowest average, or the highest for I/O
+rates (IOPS and bandwidth).
+
+Exit codes:
+    0: comparison written
+    1: no run summary was found
+
+Usage:
+    python3 storageclass.py --comparison results/storage-class-comparison/20250101-120000
+"""
+
+import argparse
+import csv
+import json
+import logging
+import os
+import sys
+from typing import Dict, List, Optional
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
+
+from utils.common import setup_logging
+from utils.output import emit
+from utils.tuning import load_summary
+
+MODES = ['per-class', 'striped']
+
+# Metric name parts of rates, where higher is better
+RATE_METRICS = ('iops', 'bw', 'throughput')
+
+# Statistics set side by side in the comparison table and CSV
+COMPARED_STATS = ('avg', 'p95')
+
+
+def higher_is_better(metric: str) -> bool:
+    """Whether a higher value of the metric is better (I/O rates), rather than lower (durations, latencies)."""
+    return any(part in metric.split('_') for part in RATE_METRICS)
+
+
+def load_variants(path: str) -> Dict[str, Dict]:
+    """Storage class and modes of every run folder from variants.json, by run name; empty without one."""
+    try:
+        with open(os.path.join(path, 'variants.json')) as f:
+            variants = json.load(f)
+    except (OSError, ValueError):
+        return {}
+    return {v['name']: v for v in variants if isinstance(v, dict) and v.get('name')}
+
+
+def compare_storage_classes(summaries: Dict[str, Dict], variants: Optional[Dict[str, Dict]] = None) -> Dict:
+    """
+    Metrics per storage variant.
+
+    Args:
+        summaries: Run summary by run name, in comparison order
+        variants: Storage class, access mode and volume mode by run name (a run
+            without one is a storage class of the template's modes)
+
+    Returns:
+        {'runs': [{'name', 'storage_class', 'access_mode', 'volume_mode', 'storage_backend',
+                   'total_vms', 'successful', 'failed'}],
+         'metrics': {metric: {run name: {'avg', 'p95'}}}, 'best': {metric: run name}}
+    """
+    runs = []
+    metrics: Dict[str, Dict[str, Dict]] = {}
+    for name, summary in summaries.items():
+        variant = (variants or {}).get(name) or {}
+        runs.append({
+            'name': name,
+            'storage_class': variant.get('storage_class', name),
+            'access_mode': variant.get('access_mode'),
+            'volume_mode': variant.get('volume_mode'),
+            'storage_backend': sorted(summary.get('storage_backend') or {}) or None,
+            'total_vms': summary.get('total_vms'),
+            'successful': summary.get('successful'),
+            'failed': summary.get('failed'),
+        })
+        for metric in summary.get('metrics', []):
+            if not isinstance(metric, dict) or not metric.get('metric'):
+                continue
+            metrics.setdefault(metric['metric'], {})[name] = {stat: metric.get(stat) for stat in COMPARED_STATS}
+
+    best = {}
+    for metric, values in metrics.items():
+        timed = {name: stats['avg'] for name, stats in values.items() if stats.get('avg') is not None}
+        if len(timed) < 2:
+            continue
+        pick = max if higher_is_better(metric) else min
+        best[metric] = pick(timed, key=timed.get)
+    return {'runs': runs, 'metrics': metrics, 'best': best}
+
+
+def print_comparison(comparison: Dict, logger: logging.Logger):
+    """Log a compare_storage_classes() result, one column per storage variant."""
+    def fmt(value):
+        return '-' if value is None else value
+
+    names = [run['name'] for run in comparison['runs']]
+    col = max([15] + [len(name) for name in names])
+    width = 32 + (col + 1) * len(names)
+    logger.info("\n" + "=" * width)
+    logger.info(f"STORAGE CLASS COMPARISON ({comparison.get('mode', 'per-class')}): average (p95)")
+    logger.info("=" * width)
+    logger.info(f"{'Metric':<32}" + ''.join(f" {name:>{col}}" for name in names))
+    logger.info("-" * width)
+    logger.info(f"{'successful VMs':<32}" + ''.join(
+        f" {str(fmt(run['successful'])) + '/' + str(fmt(run['total_vms'])):>{col}}" for run in comparison['runs']))
+    for metric, values in comparison['metrics'].items():
+        cells = []
+        for name in names:
+            stats = values.get(name) or {}
+            cell = f"{fmt(stats.get('avg'))} ({fmt(stats.get('p95'))})" if stats.get('p95') is not None \
+                else str(fmt(stats.get('avg')))
+            if comparison['best'].get(metric) == name:
+                cell = '*' + cell
+            cells.append(f" {cell:>{col}}")
+        logger.info(f"{metric[:32]:<32}" + ''.join(cells))
+    logger.info("-" * width)
+    logger.info("* best storage variant of the metric")
+    logger.info("=" * width)
+
+
+def write_csv(comparison: Dict, path: str):
+    """Write the comparison as CSV: one row per metric statistic, one column per storage variant."""
+    names = [run['name'] for run in comparison['runs']]
+    with open(path, 'w', newline='') as f:
+        writer = csv.writer(f)
+        writer.writerow(['metric'] + names + ['best'])
+        for metric, values in comparison['metrics'].items():
+            for stat in COMPARED_STATS:
+                row = [(values.get(name) or {}).get(stat) for name in names]
+                if all(value is None for value in row):
+                    continue
+                writer.writerow([f"{metric}_{stat}"] + row + [comparison['best'].get(metric)])
+
+
+def parse_args():
+    """Parse command line arguments"""
+    parser = argparse.ArgumentParser(
+        description='Compare the runs of a workload on several storage classes',
+        formatter_class=argparse.RawDescriptionHelpFormatter,
+        epilog="""
+Examples:
+  # Compare the runs of virtbench datasource-clone --storage-class px-db --storage-class ocs-rbd
+  %(prog)s --comparison results/storage-class-comparison/20250101-120000 --storage-classes px-db ocs-rbd
+        """
+    )
+    parser.add_argument('--comparison', required=True, help='Comparison folder with one results folder per storage variant')
+    parser.add_argument('--storage-classes', nargs='+',
+                        help='Run folders in comparison order (default: those of variants.json, '
+                             'else every run folder, sorted)')
+    parser.add_argument('--mode', choices=MODES, default='per-class',
+                        help='How the runs were made, recorded in the comparison (default: per-class)')
+    parser.add_argument('--output', help='Write the comparison as JSON to this file, and as CSV next to it')
+    parser.add_argument(
+        '--log-level',
+        type=str,
+        default='INFO',
+        choices=['DEBUG', 'INFO', 'WARNING', 'ERROR'],
+        help='Logging level (default: INFO)'
+    )
+    return parser.parse_args()
+
+
+def main():
+    """Main execution function"""
+    args = parse_args()
+    logger = setup_logging(log_file=None, log_level=args.log_level)
+
+    if not os.path.isdir(args.comparison):
+        logger.error(f"Comparison folder not found: {args.comparison}")
+        sys.exit(1)
+    variants = load_variants(args.comparison)
+    names: List[str] = args.storage_classes or list(variants) or sorted(
+        d for d in os.listdir(args.comparison) if os.path.isdir(os.path.join(args.comparison, d)))
+    summaries = {}
+    for name in names:
+        summary = load_summary(os.path.join(args.comparison, name))
+        if summary is None:
+            logger.warning(f"No results summary found for {name}; leaving it out")
+            continue
+        summaries[name] = summary
+    if not summaries:
+        logger.error(f"No results summary found in {args.comparison}")
+        sys.exit(1)
+
+    comparison = compare_storage_classes(summaries, variants)
+    comparison['mode'] = args.mode
+    comparison['results'] = args.comparison
+    print_comparison(comparison, logger)
+
+    if args.output:
+        output_dir = os.path.dirname(args.output)
+        if output_dir:
+            os.makedirs(output_dir, exist_ok=True)
+        with open(args.output, 'w') as f:
+            json.dump(comparison, f, indent=2)
+        write_csv(comparison, os.path.splitext(args.output)[0] + '.csv')
+        logger.info(f"Storage class comparison written to {args.output}")
+    emit('storage-class-comparison', comparison)
+
+
+if __name__ == '__main__':
+    main()
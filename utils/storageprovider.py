@@ -0,0 +1,755 @@
+#!/usr/bin/env python3
+"""
+Storage provider plugins for KubeVirt performance testing.
+
+VM latency often has its cause below the CSI driver: a volume whose replicas
+all sit on remote nodes, a nearly full pool, a degraded Ceph cluster. Every
+storage backend is wrapped by a StorageProvider that contributes:
+
+- version(): the backend version, used to label results folders
+  (``--storage-driver auto``, see utils.inventory.detect_storage_driver)
+- health(): a health check, run by validate-cluster and recorded with results
+- telemetry(): backend metrics of a run, including its volumes
+
+Providers are picked by the provisioner of a storage class: Portworx, ODF,
+Rook Ceph, LVMS and Longhorn have their own provider; any other provisioner (NFS,
+cloud block storage, ...) gets the generic CSI provider. The global
+``--storage-provider`` option (VIRTBENCH_STORAGE_PROVIDER) forces one
+provider for every provisioner, and ``--storage-namespace``
+(VIRTBENCH_STORAGE_NAMESPACE) replaces the namespaces a provider looks for
+its backend in. register_provider() adds providers for other backends.
+
+When results are saved, collect_storage_backend() finds the provisioners
+behind the run's PVCs (the default storage class when the run has none left)
+and records the version, health and telemetry of their providers in the
+summary under ``storage_backend``, keyed by provider label. It is best
+effort: a backend that cannot be read records what it can and never fails
+the run.
+
+Every provider reports the run's volumes (from their PVs and
+VolumeAttachments) one row per volume in <label>_volumes.csv, summarized as
+volumes per node, storage class and volume mode. On top of that:
+
+- Portworx (pxctl in a Portworx pod): cluster status, KVDB members, quorum
+  and leader, the size and utilization of every storage pool, and per volume
+  the HA level, I/O profile, replica nodes and whether the node running the
+  VM holds a replica
+- ODF / Ceph (CephCluster): Ceph health and its checks, raw capacity and
+  usage, and the RBD pool or CephFS filesystem of every volume
+- LVMS (LVMCluster, TopoLVM): device class state per node, the free
+  capacity of every node and device class, and the device class of every volume
+- Longhorn (Harvester's storage): node readiness and schedulability, and per
+  volume its robustness (healthy, degraded, faulted) and replica count
+"""
+
+import csv
+import json
+import logging
+import os
+from typing import Dict, List, Optional, Tuple
+
+from utils.base import parse_quantity_bytes
+from utils.inventory import (
+    PORTWORX_PROVISIONERS, _containers, _csi_driver_version, _get_json, _image_tag, _operator_version,
+    _portworx_version, cluster_inventory,
+)
+from utils.portworx import find_px_pod, get_kvdb_status, run_pxctl
+
+STORAGE_PROVIDER_ENV = 'VIRTBENCH_STORAGE_PROVIDER'
+STORAGE_NAMESPACE_ENV = 'VIRTBENCH_STORAGE_NAMESPACE'
+STORAGE_PROVIDER_AUTO = 'auto'
+
+# Health check results, from best to worst
+HEALTH_OK = 'ok'
+HEALTH_UNKNOWN = 'unknown'
+HEALTH_DEGRADED = 'degraded'
+HEALTH_FAILED = 'failed'
+
+PXCTL_TIMEOUT = 120
+
+# openstorage IoProfile enum, for pxctl versions that print it as a number
+PX_IO_PROFILES = ['sequential', 'random', 'db', 'db_remote', 'cms', 'sync_shared',
+                  'auto', 'none', 'journal', 'auto_journal']
+
+PX_VOLUME_FIELDS = ['volume', 'namespace', 'pvc', 'size_bytes', 'used_bytes', 'ha_level',
+                    'io_profile', 'replica_nodes', 'attached_on', 'local_replica']
+CSI_VOLUME_FIELDS = ['volume', 'namespace', 'pvc', 'storage_class', 'size_bytes', 'volume_mode', 'node']
+
+CEPH_HEALTH = {'HEALTH_OK': HEALTH_OK, 'HEALTH_WARN': HEALTH_DEGRADED, 'HEALTH_ERR': HEALTH_FAILED}
+LVMS_STATES = {'Ready': HEALTH_OK, 'Progressing': HEALTH_DEGRADED, 'Degraded': HEALTH_DEGRADED,
+               'Failed': HEALTH_FAILED}
+# Node annotation prefix of TopoLVM free capacity, one per device class
+TOPOLVM_CAPACITY_PREFIX = 'capacity.topolvm.io/'
+LONGHORN_ROBUSTNESS = {'healthy': HEALTH_OK, 'degraded': HEALTH_DEGRADED, 'faulted': HEALTH_FAILED}
+
+
+def storage_namespace() -> Optional[str]:
+    """The --storage-namespace override of the backend namespace, if set."""
+    return os.environ.get(STORAGE_NAMESPACE_ENV) or None
+
+
+def _field(item: Dict, *keys):
+    """First of keys present in item (pxctl JSON mixes CamelCase and snake_case)."""
+    for key in keys:
+        if item.get(key) is not None:
+            return item[key]
+    return None
+
+
+def _in_namespaces(namespace: Optional[str], namespace_prefix: Optional[str]) -> bool:
+    return bool(namespace and namespace_prefix) and \
+        (namespace == namespace_prefix or namespace.startswith(namespace_prefix + '-'))
+
+
+def _counts(rows: List[Dict], field: str) -> Dict[str, int]:
+    values = [str(row[field]) for row in rows if row.get(field) is not None]
+    return {value: values.count(value) for value in sorted(set(values))}
+
+
+def _pv_node(pv: Dict) -> Optional[str]:
+    """Node of a node-local PV, from its node affinity (a single node only)."""
+    terms = ((pv.get('spec', {}).get('nodeAffinity') or {}).get('required') or {}).get('nodeSelectorTerms') or []
+    values = {value for term in terms for expr in term.get('matchExpressions') or []
+              for value in expr.get('values') or []}
+    return values.pop() if len(values) == 1 else None
+
+
+class StorageProvider:
+    """
+    A storage backend: version detection, health check and telemetry.
+
+    Subclasses set name, title and default_namespaces, implement matches()
+    and override the methods their backend can do better than plain CSI.
+    One instance covers the provisioners of the backend in use.
+    """
+
+    name = 'csi'
+    title = 'CSI driver'
+    default_namespaces: List[str] = []
+    # Columns of the per-volume CSV written from telemetry()['volume_rows']
+    volume_fields: List[str] = CSI_VOLUME_FIELDS
+
+    def __init__(self, provisioners: List[str]):
+        self.provisioners = list(provisioners)
+        override = storage_namespace()
+        self.namespaces = [override] if override else list(self.default_namespaces)
+
+    @classmethod
+    def matches(cls, provisioner: str) -> bool:
+        """Whether storage classes with this provisioner are served by the provider."""
+        return False
+
+    @property
+    def label(self) -> str:
+        """Backend part of results folder labels and key of the summary entry."""
+        return self.name
+
+    def version(self, logger: Optional[logging.Logger] = None) -> Optional[str]:
+        """Backend version, or None if it cannot be read."""
+        daemonsets = (_get_json(['get', 'daemonset', '-A'], logger) or {}).get('items', [])
+        for provisioner in self.provisioners:
+            version = _csi_driver_version(provisioner, daemonsets)
+            if version:
+                return version
+        return None
+
+    def health(self, logger: Optional[logging.Logger] = None) -> Tuple[str, str]:
+        """
+        Check the backend.
+
+        Returns:
+            (status, message); status is HEALTH_OK, HEALTH_DEGRADED, HEALTH_FAILED
+            or HEALTH_UNKNOWN when the provider cannot tell
+        """
+        drivers = [p for p in self.provisioners if '.' in p.split('/', 1)[0] and not p.startswith('kubernetes.io/')]
+        if not drivers:
+            return HEALTH_UNKNOWN, f"in-tree provisioner {', '.join(self.provisioners)}, no CSI driver to check"
+        nodes = (_get_json(['get', 'nodes', '-l', 'node-role.kubernetes.io/worker='], logger) or {}).get('items')
+        csinodes = (_get_json(['get', 'csinode'], logger) or {}).get('items')
+        if nodes is None or csinodes is None:
+            return HEALTH_UNKNOWN, "cannot read nodes or CSINodes"
+        workers = {n['metadata']['name'] for n in nodes}
+        problems = []
+        for driver in drivers:
+            if _get_json(['get', 'csidriver', driver], logger) is None:
+                problems.append((HEALTH_FAILED, f"CSIDriver {driver} not found"))
+                continue
+            registered = {c['metadata']['name'] for c in csinodes
+                          if any(d.get('name') == driver for d in (c.get('spec') or {}).get('drivers') or [])}
+            missing = sorted(workers - registered)
+            if workers and len(missing) == len(workers):
+                problems.append((HEALTH_FAILED, f"{driver} is not registered on any worker node"))
+            elif missing:
+                problems.append((HEALTH_DEGRADED, f"{driver} is not registered on {', '.join(missing)}"))
+        if problems:
+            status = HEALTH_FAILED if any(s == HEALTH_FAILED for s, _ in problems) else HEALTH_DEGRADED
+            return status, '; '.join(message for _, message in problems)
+        return HEALTH_OK, f"{', '.join(drivers)} registered on all {len(workers)} worker nodes"
+
+    def volume_row(self, pv: Dict, node: Optional[str]) -> Dict:
+        """Per-volume row of a PV of the run."""
+        spec = pv.get('spec', {})
+        claim = spec.get('claimRef') or {}
+        return {
+            'volume': pv['metadata']['name'],
+            'namespace': claim.get('namespace'),
+            'pvc': claim.get('name'),
+            'storage_class': spec.get('storageClassName'),
+            'size_bytes': parse_quantity_bytes((spec.get('capacity') or {}).get('storage')),
+            'volume_mode': spec.get('volumeMode', 'Filesystem'),
+            'node': node,
+        }
+
+    def volume_summary(self, rows: List[Dict]) -> Dict:
+        """Summary of the per-volume rows."""
+        return {
+            'count': len(rows),
+            'size_bytes': sum(row['size_bytes'] or 0 for row in rows),
+            'storage_classes': _counts(rows, 'storage_class'),
+            'volume_modes': _counts(rows, 'volume_mode'),
+            'volumes_per_node': _counts(rows, 'node'),
+        }
+
+    def telemetry(self, namespace_prefix: Optional[str], pvs: List[Dict],
+                  logger: Optional[logging.Logger] = None) -> Optional[Dict]:
+        """
+        Backend metrics at the end of a run.
+
+        Args:
+            namespace_prefix: Prefix of the benchmark namespaces, or the single namespace
+            pvs: PVs of the run's PVCs served by this provider
+            logger: Logger instance
+
+        Returns:
+            JSON-serializable dict, optionally with 'volume_rows' (one dict per
+            volume with volume_fields keys), or None if there is nothing to report
+        """
+        if not pvs:
+            return None
+        attachments = (_get_json(['get', 'volumeattachment'], logger) or {}).get('items') or []
+        attached = {a['spec'].get('source', {}).get('persistentVolumeName'): a['spec'].get('nodeName')
+                    for a in attachments if (a.get('status') or {}).get('attached')}
+        rows = [self.volume_row(pv, attached.get(pv['metadata']['name']) or _pv_node(pv)) for pv in pvs]
+        return {'volumes': self.volume_summary(rows), 'volume_rows': rows}
+
+    def log_telemetry(self, entry: Dict, logger: logging.Logger):
+        """Log the highlights of a collect_storage_backend() entry."""
+        volumes = entry.get('volumes')
+        logger.info(f"{self.title}: version {entry['version'] or 'n/a'}, health {entry['health']['status']}"
+                    + (f", {volumes['count']} volumes of the run on {len(volumes['volumes_per_node'])} nodes"
+                       if volumes and 'volumes_per_node' in volumes else ''))
+
+
+class CsiProvider(StorageProvider):
+    """Any CSI driver (NFS, cloud block storage, ...): CSI registration and volume placement."""
+
+    @classmethod
+    def matches(cls, provisioner):
+        return True
+
+    @property
+    def label(self):
+        return self.provisioners[0].replace('/', '-') if len(self.provisioners) == 1 else self.name
+
+
+def _io_profile(value) -> Optional[str]:
+    if isinstance(value, int):
+        return PX_IO_PROFILES[value] if 0 <= value < len(PX_IO_PROFILES) else str(value)
+    if isinstance(value, str) and value:
+        return value.lower().replace('io_profile_', '')
+    return None
+
+
+class PortworxProvider(StorageProvider):
+    """Portworx: cluster status, KVDB health, pool utilization and volume placement from pxctl."""
+
+    name = 'portworx'
+    title = 'Portworx'
+    default_namespaces = ['portworx', 'kube-system']
+    volume_fields = PX_VOLUME_FIELDS
+
+    @classmethod
+    def matches(cls, provisioner):
+        return provisioner in PORTWORX_PROVISIONERS
+
+    def version(self, logger=None):
+        return _portworx_version((_get_json(['get', 'daemonset', '-A'], logger) or {}).get('items', []), logger)
+
+    def _pod(self, logger):
+        return find_px_pod(namespaces=self.namespaces, logger=logger)
+
+    def _pxctl_json(self, pod: Dict[str, str], args: List[str], logger: Optional[logging.Logger]):
+        output = run_pxctl(pod, args + ['--json'], timeout=PXCTL_TIMEOUT, logger=logger)
+        if output is None:
+            return None
+        try:
+            return json.loads(output)
+        except json.JSONDecodeError as e:
+            if logger:
+                logger.debug(f"Failed to parse pxctl {' '.join(args)} JSON: {e}")
+            return None
+
+    def health(self, logger=None):
+        pod = self._pod(logger)
+        if not pod:
+            return HEALTH_FAILED, f"no running Portworx pod in {', '.join(self.namespaces)}"
+        status = (self._pxctl_json(pod, ['status'], logger) or {}).get('status')
+        kvdb = get_kvdb_status(pod, logger)
+        kvdb_text = f"KVDB {kvdb['healthy']}/{kvdb['total']} healthy" if kvdb else "KVDB n/a"
+        message = f"status {status or 'n/a'}, {kvdb_text}"
+        if kvdb and not kvdb['has_quorum']:
+            return HEALTH_FAILED, f"{message}, no KVDB quorum"
+        if status is None or kvdb is None:
+            return HEALTH_UNKNOWN, message
+        if status != 'STATUS_OK' or kvdb['healthy'] < kvdb['total']:
+            return HEALTH_DEGRADED, message
+        return HEALTH_OK, message
+
+    def telemetry(self, namespace_prefix, pvs, logger=None):
+        pod = self._pod(logger)
+        if not pod:
+            return None
+
+        status = self._pxctl_json(pod, ['status'], logger) or {}
+        node_names = {}     # node ID, hostname or IP -> Kubernetes node name
+        pools = []
+        for node in (status.get('cluster') or {}).get('Nodes') or []:
+            name = _field(node, 'SchedulerNodeName', 'Hostname', 'Id')
+            for key in ('Id', 'Hostname', 'MgmtIp', 'DataIp', 'SchedulerNodeName'):
+                if node.get(key):
+                    node_names[node[key]] = name
+            for pool in node.get('Pools') or []:
+                total, used = _field(pool, 'TotalSize', 'total_size'), _field(pool, 'Used', 'used')
+                pools.append({
+                    'node': name,
+                    'pool': _field(pool, 'ID', 'id'),
+                    'medium': _field(pool, 'Medium', 'medium'),
+                    'total_bytes': total,
+                    'used_bytes': used,
+                    'used_pct': round(used / total * 100, 1) if total and used is not None else None,
+                })
+
+        kvdb = get_kvdb_status(pod, logger)
+        if kvdb:
+            kvdb['members'] = [{k: v for k, v in m.items() if k != 'urls'} for m in kvdb['members']]
+
+        telemetry = {
+            'status': status.get('status'),
+            'kvdb': kvdb,
+            'pools': {
+                'items': pools,
+                'max_used_pct': max((p['used_pct'] for p in pools if p['used_pct'] is not None), default=None),
+            } if pools else None,
+            'volumes': None,
+        }
+        if namespace_prefix:
+            rows = self._volume_rows(pod, node_names, namespace_prefix, logger)
+            if rows is not None:
+                telemetry['volumes'] = self._volume_summary(rows)
+                telemetry['volume_rows'] = rows
+        if not status and not kvdb and telemetry['volumes'] is None:
+            return None
+        return telemetry
+
+    def _volume_rows(self, pod, node_names: Dict[str, str], namespace_prefix: str,
+                     logger) -> Optional[List[Dict]]:
+        volumes = self._pxctl_json(pod, ['volume', 'list'], logger)
+        if not isinstance(volumes, list):
+            return None
+        rows = []
+        for volume in volumes:
+            locator = volume.get('locator') or {}
+            labels = locator.get('volume_labels') or {}
+            if not _in_namespaces(labels.get('namespace'), namespace_prefix):
+                continue
+            spec = volume.get('spec') or {}
+            replica_nodes = sorted({node_names.get(n, n)
+                                    for rs in volume.get('replica_sets') or [] for n in rs.get('nodes') or []})
+            attached = volume.get('attached_on') or None
+            attached = node_names.get(attached, attached)
+            rows.append({
+                'volume': locator.get('name') or volume.get('id'),
+                'namespace': labels.get('namespace'),
+                'pvc': labels.get('pvc'),
+                'size_bytes': spec.get('size'),
+                'used_bytes': volume.get('usage'),
+                'ha_level': spec.get('ha_level'),
+                'io_profile': _io_profile(_field(volume, 'derived_io_profile') or spec.get('io_profile')),
+                'replica_nodes': ';'.join(replica_nodes),
+                'attached_on': attached,
+                'local_replica': attached in replica_nodes if attached else None,
+            })
+        return rows
+
+    @staticmethod
+    def _volume_summary(rows: List[Dict]) -> Dict:
+        replicas_per_node: Dict[str, int] = {}
+        for row in rows:
+            for node in filter(None, row['replica_nodes'].split(';')):
+                replicas_per_node[node] = replicas_per_node.get(node, 0) + 1
+        return {
+            'count': len(rows),
+            'size_bytes': sum(row['size_bytes'] or 0 for row in rows),
+            'used_bytes': sum(row['used_bytes'] or 0 for row in rows),
+            'ha_levels': _counts(rows, 'ha_level'),
+            'io_profiles': _counts(rows, 'io_profile'),
+            'replicas_per_node': dict(sorted(replicas_per_node.items())),
+            'attached': sum(1 for row in rows if row['attached_on']),
+            'local_replica': sum(1 for row in rows if row['local_replica']),
+        }
+
+    def log_telemetry(self, entry, logger):
+        kvdb = entry.get('kvdb')
+        pools = entry.get('pools')
+        volumes = entry.get('volumes')
+        logger.info(f"Portworx {entry['version'] or 'n/a'}: status {entry.get('status') or 'n/a'}, "
+                    + (f"KVDB {kvdb['healthy']}/{kvdb['total']} healthy (leader {kvdb['leader'] or 'none'}), "
+                       if kvdb else "KVDB n/a, ")
+                    + (f"{len(pools['items'])} pools (max {pools['max_used_pct']}% used)" if pools else "pools n/a"))
+        if volumes:
+            logger.info(f"Portworx: {volumes['count']} volumes of the run, {volumes['local_replica']}/"
+                        f"{volumes['attached']} attached volumes with a local replica, I/O profiles "
+                        f"{', '.join(f'{p}={n}' for p, n in volumes['io_profiles'].items()) or 'n/a'}")
+        if kvdb and not kvdb['has_quorum']:
+            logger.warning(f"Portworx KVDB has no quorum: {kvdb['healthy']}/{kvdb['total']} healthy members")
+
+
+class CephProvider(StorageProvider):
+    """Rook Ceph (RBD and CephFS): Ceph health, capacity and the pool of every volume."""
+
+    name = 'ceph'
+    title = 'Ceph'
+    default_namespaces = ['rook-ceph']
+    volume_fields = CSI_VOLUME_FIELDS + ['pool']
+
+    @classmethod
+    def matches(cls, provisioner):
+        return provisioner.endswith('.csi.ceph.com')
+
+    def _ceph_cluster(self, logger) -> Optional[Dict]:
+        for namespace in self.namespaces:
+            items = (_get_json(['get', 'cephcluster', '-n', namespace], logger) or {}).get('items')
+            if items:
+                return items[0]
+        return None
+
+    def version(self, logger=None):
+        cluster = self._ceph_cluster(logger) or {}
+        return ((cluster.get('status') or {}).get('version') or {}).get('version') or super().version(logger)
+
+    def health(self, logger=None):
+        cluster = self._ceph_cluster(logger)
+        if not cluster:
+            return HEALTH_UNKNOWN, f"no CephCluster found in {', '.join(self.namespaces)}"
+        ceph = (cluster.get('status') or {}).get('ceph') or {}
+        health = ceph.get('health')
+        checks = ', '.join(sorted(ceph.get('details') or {}))
+        return CEPH_HEALTH.get(health, HEALTH_UNKNOWN), f"{health or 'health n/a'}" + (f" ({checks})" if checks else '')
+
+    def volume_row(self, pv, node):
+        attributes = (pv.get('spec', {}).get('csi') or {}).get('volumeAttributes') or {}
+        return {**super().volume_row(pv, node), 'pool': attributes.get('pool') or attributes.get('fsName')}
+
+    def volume_summary(self, rows):
+        return {**super().volume_summary(rows), 'volumes_per_pool': _counts(rows, 'pool')}
+
+    def telemetry(self, namespace_prefix, pvs, logger=None):
+        telemetry = super().telemetry(namespace_prefix, pvs, logger) or {}
+        cluster = self._ceph_cluster(logger)
+        if cluster:
+            ceph = (cluster.get('status') or {}).get('ceph') or {}
+            capacity = ceph.get('capacity') or {}
+            total, used = capacity.get('bytesTotal'), capacity.get('bytesUsed')
+            telemetry['cluster'] = {
+                'name': cluster['metadata']['name'],
+                'namespace': cluster['metadata'].get('namespace'),
+                'health': ceph.get('health'),
+                'health_checks': {name: (detail or {}).get('severity')
+                                  for name, detail in sorted((ceph.get('details') or {}).items())},
+                'total_bytes': total,
+                'used_bytes': used,
+                'available_bytes': capacity.get('bytesAvailable'),
+                'used_pct': round(used / total * 100, 1) if total and used is not None else None,
+            }
+        return telemetry or None
+
+    def log_telemetry(self, entry, logger):
+        super().log_telemetry(entry, logger)
+        cluster = entry.get('cluster')
+        if cluster:
+            logger.info(f"{self.title}: {cluster['health'] or 'health n/a'}, "
+                        f"{cluster['used_pct'] if cluster['used_pct'] is not None else 'n/a'}% of raw capacity used")
+
+
+class OdfProvider(CephProvider):
+    """OpenShift Data Foundation: Ceph telemetry, versioned by the ODF operator."""
+
+    name = 'odf'
+    title = 'OpenShift Data Foundation'
+    default_namespaces = ['openshift-storage']
+
+    @classmethod
+    def matches(cls, provisioner):
+        return provisioner.startswith('openshift-storage.')
+
+    def version(self, logger=None):
+        return _operator_version('odf-operator', logger)
+
+
+class LvmsProvider(StorageProvider):
+    """LVM Storage (TopoLVM): device class state and free capacity per node."""
+
+    name = 'lvms'
+    title = 'LVM Storage'
+    default_namespaces = ['openshift-storage', 'topolvm-system']
+    volume_fields = CSI_VOLUME_FIELDS + ['device_class']
+
+    @classmethod
+    def matches(cls, provisioner):
+        return provisioner.startswith('topolvm.')
+
+    def version(self, logger=None):
+        return _operator_version('lvms-operator', logger) or super().version(logger)
+
+    def _lvm_cluster(self, logger) -> Optional[Dict]:
+        for namespace in self.namespaces:
+            items = (_get_json(['get', 'lvmcluster', '-n', namespace], logger) or {}).get('items')
+            if items:
+                return items[0]
+        return None
+
+    def health(self, logger=None):
+        cluster = self._lvm_cluster(logger)
+        if not cluster:
+            # Plain TopoLVM has no LVMCluster; check its CSI driver instead
+            return super().health(logger)
+        status = cluster.get('status') or {}
+        state = status.get('state')
+        failing = sorted({f"{dc.get('name')}/{ns.get('node')}"
+                          for dc in status.get('deviceClassStatuses') or []
+                          for ns in dc.get('nodeStatus') or [] if ns.get('status') != 'Ready'})
+        message = f"LVMCluster {cluster['metadata']['name']} {state or 'state n/a'}"
+        if failing:
+            message += f", device classes not ready: {', '.join(failing)}"
+        return LVMS_STATES.get(state, HEALTH_UNKNOWN), message
+
+    def volume_row(self, pv, node):
+        attributes = (pv.get('spec', {}).get('csi') or {}).get('volumeAttributes') or {}
+        device_class = next((v for k, v in attributes.items() if k.endswith('device-class')), None)
+        return {**super().volume_row(pv, node), 'device_class': device_class}
+
+    def telemetry(self, namespace_prefix, pvs, logger=None):
+        telemetry = super().telemetry(namespace_prefix, pvs, logger) or {}
+        nodes = (_get_json(['get', 'nodes'], logger) or {}).get('items') or []
+        free = {}
+        for node in nodes:
+            capacity = {key[len(TOPOLVM_CAPACITY_PREFIX):]: int(value)
+                        for key, value in (node['metadata'].get('annotations') or {}).items()
+                        if key.startswith(TOPOLVM_CAPACITY_PREFIX) and str(value).isdigit()}
+            if capacity:
+                free[node['metadata']['name']] = capacity
+        if free:
+            telemetry['free_bytes'] = free
+        cluster = self._lvm_cluster(logger)
+        if cluster:
+            telemetry['device_classes'] = {
+                dc.get('name'): {ns.get('node'): ns.get('status') for ns in dc.get('nodeStatus') or []}
+                for dc in (cluster.get('status') or {}).get('deviceClassStatuses') or []
+            }
+        return telemetry or None
+
+
+class LonghornProvider(StorageProvider):
+    """Longhorn (Harvester's storage): node readiness and the robustness and replicas of every volume."""
+
+    name = 'longhorn'
+    title = 'Longhorn'
+    default_namespaces = ['longhorn-system']
+    volume_fields = CSI_VOLUME_FIELDS + ['robustness', 'replicas']
+
+    def __init__(self, provisioners):
+        super().__init__(provisioners)
+        # Longhorn volumes by name (the PVs' volume handle), read by telemetry()
+        self._volumes: Dict[str, Dict] = {}
+
+    @classmethod
+    def matches(cls, provisioner):
+        return provisioner == 'driver.longhorn.io'
+
+    def version(self, logger=None):
+        for namespace in self.namespaces:
+            manager = _get_json(['get', 'daemonset', 'longhorn-manager', '-n', namespace], logger)
+            if manager and _containers(manager):
+                return _image_tag(_containers(manager)[0].get('image', ''))
+        return super().version(logger)
+
+    def _items(self, resource: str, logger) -> Optional[List[Dict]]:
+        for namespace in self.namespaces:
+            items = (_get_json(['get', f"{resource}.longhorn.io", '-n', namespace], logger) or {}).get('items')
+            if items:
+                return items
+        return None
+
+    def health(self, logger=None):
+        nodes = self._items('nodes', logger)
+        if not nodes:
+            return HEALTH_UNKNOWN, f"no Longhorn nodes found in {', '.join(self.namespaces)}"
+        problems = {}
+        for node in nodes:
+            conditions = {c.get('type'): c.get('status') for c in (node.get('status') or {}).get('conditions') or []}
+            for condition in ('Ready', 'Schedulable'):
+                if conditions.get(condition) != 'True':
+                    problems.setdefault(condition, []).append(node['metadata']['name'])
+        if not problems:
+            return HEALTH_OK, f"{len(nodes)} Longhorn nodes ready and schedulable"
+        status = HEALTH_FAILED if len(problems.get('Ready', [])) == len(nodes) else HEALTH_DEGRADED
+        return status, '; '.join(f"not {condition.lower()}: {', '.join(names)}"
+                                 for condition, names in sorted(problems.items()))
+
+    def telemetry(self, namespace_prefix, pvs, logger=None):
+        self._volumes = {v['metadata']['name']: v for v in self._items('volumes', logger) or []}
+        telemetry = super().telemetry(namespace_prefix, pvs, logger) or {}
+        if telemetry:
+            telemetry['volumes']['robustness'] = _counts(telemetry['volume_rows'], 'robustness')
+        return telemetry or None
+
+    def volume_row(self, pv, node):
+        handle = (pv.get('spec', {}).get('csi') or {}).get('volumeHandle')
+        volume = self._volumes.get(handle) or {}
+        return {**super().volume_row(pv, node),
+                'robustness': (volume.get('status') or {}).get('robustness'),
+                'replicas': (volume.get('spec') or {}).get('numberOfReplicas')}
+
+    def log_telemetry(self, entry, logger):
+        super().log_telemetry(entry, logger)
+        robustness = (entry.get('volumes') or {}).get('robustness') or {}
+        if set(robustness) - {'healthy'}:
+            logger.warning(f"{self.title}: volumes of the run by robustness: "
+                           f"{', '.join(f'{k} {v}' for k, v in sorted(robustness.items()))}")
+
+
+# Providers tried in order; CsiProvider takes every provisioner none of them matches
+PROVIDERS: List[type] = [PortworxProvider, OdfProvider, CephProvider, LvmsProvider, LonghornProvider]
+
+
+def register_provider(provider: type):
+    """Add a StorageProvider subclass, tried before the built-in providers."""
+    PROVIDERS.insert(0, provider)
+
+
+def forced_provider() -> Optional[type]:
+    """The provider class chosen with --storage-provider, or None to detect it."""
+    name = os.environ.get(STORAGE_PROVIDER_ENV, STORAGE_PROVIDER_AUTO)
+    if name == STORAGE_PROVIDER_AUTO:
+        return None
+    for provider in PROVIDERS + [CsiProvider]:
+        if provider.name == name:
+            return provider
+    raise ValueError(f"unknown storage provider {name!r} "
+                     f"(choose from {', '.join(p.name for p in PROVIDERS + [CsiProvider])})")
+
+
+def storage_providers(provisioners: List[str]) -> List[StorageProvider]:
+    """
+    The providers of a set of provisioners: one per backend, and one per
+    provisioner for generic CSI drivers.
+    """
+    forced = forced_provider()
+    groups: Dict[str, Tuple[type, List[str]]] = {}
+    for provisioner in sorted(set(provisioners)):
+        provider = forced or next((p for p in PROVIDERS if p.matches(provisioner)), CsiProvider)
+        key = provisioner if provider is CsiProvider else provider.name
+        groups.setdefault(key, (provider, []))[1].append(provisioner)
+    return [provider(provisioners) for provider, provisioners in groups.values()]
+
+
+def provider_for(provisioner: str) -> StorageProvider:
+    """The provider of one provisioner."""
+    return storage_providers([provisioner])[0]
+
+
+def _storage_class_provisioners(logger) -> Dict[str, str]:
+    classes = ((cluster_inventory(logger).get('storage') or {}).get('storage_classes')) or []
+    return {sc['name']: sc.get('provisioner') for sc in classes}
+
+
+def run_volumes(namespace_prefix: Optional[str],
+                logger: Optional[logging.Logger] = None) -> Dict[str, List[Dict]]:
+    """
+    The PVs of the run's PVCs by provisioner; the default storage class's
+    provisioner (without PVs) when the run has none.
+    """
+    classes = _storage_class_provisioners(logger)
+    by_provisioner: Dict[str, List[Dict]] = {}
+    if namespace_prefix:
+        for pv in (_get_json(['get', 'pv'], logger) or {}).get('items') or []:
+            spec = pv.get('spec', {})
+            if not _in_namespaces((spec.get('claimRef') or {}).get('namespace'), namespace_prefix):
+                continue
+            provisioner = (spec.get('csi') or {}).get('driver') or classes.get(spec.get('storageClassName'))
+            if provisioner:
+                by_provisioner.setdefault(provisioner, []).append(pv)
+    if not by_provisioner:
+        default = [sc.get('provisioner')
+                   for sc in ((cluster_inventory(logger).get('storage') or {}).get('storage_classes') or [])
+                   if sc.get('default') and sc.get('provisioner')]
+        by_provisioner = {provisioner: [] for provisioner in default}
+    return by_provisioner
+
+
+def collect_storage_backend(out_dir: Optional[str], namespace_prefix: Optional[str],
+                            logger: Optional[logging.Logger] = None) -> Optional[Dict]:
+    """
+    Record the version, health and telemetry of the providers behind the run's
+    volumes, and save their per-volume rows to <label>_volumes.csv in out_dir.
+
+    Args:
+        out_dir: Results directory (None: nothing is written)
+        namespace_prefix: Prefix of the benchmark namespaces, or the single namespace
+        logger: Logger instance
+
+    Returns:
+        {label: {'provider', 'provisioners', 'version', 'health', ...telemetry}},
+        or None when no provisioner was found
+    """
+    try:
+        volumes = run_volumes(namespace_prefix, logger)
+        providers = storage_providers(list(volumes))
+    except ValueError as e:
+        if logger:
+            logger.warning(f"Storage backend telemetry skipped: {e}")
+        return None
+    backend = {}
+    for provider in providers:
+        entry = {'provider': provider.name, 'provisioners': provider.provisioners}
+        try:
+            entry['version'] = provider.version(logger)
+            status, message = provider.health(logger)
+            entry['health'] = {'status': status, 'message': message}
+            pvs = [pv for provisioner in provider.provisioners for pv in volumes.get(provisioner, [])]
+            telemetry = provider.telemetry(namespace_prefix, pvs, logger) or {}
+        except Exception as e:
+            if logger:
+                logger.warning(f"Could not collect {provider.title} telemetry: {e}")
+            entry.setdefault('version', None)
+            entry.setdefault('health', {'status': HEALTH_UNKNOWN, 'message': str(e)})
+            telemetry = {}
+        rows = telemetry.pop('volume_rows', None)
+        entry.update(telemetry)
+        if rows and out_dir and entry.get('volumes') is not None:
+            filename = f"{provider.label}_volumes.csv"
+            try:
+                with open(os.path.join(out_dir, filename), 'w', newline='') as f:
+                    writer = csv.DictWriter(f, fieldnames=provider.volume_fields)
+                    writer.writeheader()
+                    writer.writerows(rows)
+                entry['volumes']['file'] = filename
+            except OSError as e:
+                if logger:
+                    logger.warning(f"Could not save {provider.title} volumes: {e}")
+        if logger:
+            provider.log_telemetry(entry, logger)
+            if entry['health']['status'] in (HEALTH_DEGRADED, HEALTH_FAILED):
+                logger.warning(f"{provider.title} is {entry['health']['status']}: {entry['health']['message']}")
+        backend[provider.label] = entry
+    return backend or None
@@ -0,0 +1,531 @@
+#!/usr/bin/env python3
+"""
+Lint user-supplied VM templates before a benchmark run.
+
+A template that does not match the cluster usually fails minutes into a run,
+once hundreds of VMs are stuck Pending or a migration phase reports every VM
+as failed. This script checks a template up front:
+
+- schema: every VirtualMachine, VirtualMachineInstance, DataVolume, ... of
+  the file is validated against the OpenAPI v3 schema of its KubeVirt/CDI
+  CRD (types, required fields, enums and unknown fields), read from the
+  cluster or from CRD manifests given with --schema
+- references: the DataSources the disks clone from and the storage classes
+  they use exist in the cluster, and the DataSources are Ready
+- workloads: settings that break specific workloads are warned about, e.g.
+  ReadWriteOnce disks or a bridge on the pod network for live migration, or
+  a storage class without volume expansion for volume-resize
+
+Template placeholders such as {{STORAGE_CLASS_NAME}} are filled from --set
+NAME=VALUE; fields still holding a placeholder are not checked.
+
+Exit codes:
+    0: no errors (warnings allowed unless --strict)
+    1: at least one error, or the template cannot be read
+    2: no errors, but warnings and --strict is set
+
+Usage:
+    python3 template_lint.py examples/vm-templates/vm-template.yaml --set STORAGE_CLASS_NAME=px-csi-db
+    python3 template_lint.py my-vm.yaml --workload migration volume-resize
+    python3 template_lint.py my-vm.yaml --offline --schema kubevirt-crds.yaml
+"""
+
+import argparse
+import json
+import logging
+import os
+import re
+import sys
+from datetime import datetime, timezone
+from typing import Dict, List, Optional, Tuple
+
+import yaml
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
+
+from utils.common import setup_logging, run_kubectl_command
+from utils.output import emit
+
+ERROR = 'ERROR'
+WARN = 'WARN'
+EXIT_FAILED = 1
+EXIT_WARNINGS = 2
+
+# Template placeholders such as {{STORAGE_CLASS_NAME}} (see examples/vm-templates/vm-template.yaml)
+PLACEHOLDER_RE = re.compile(r'\{\{\s*(\w+)\s*\}\}')
+# What an unfilled placeholder is replaced with before parsing, so the YAML stays valid
+PLACEHOLDER_MARK = 'VIRTBENCH_PLACEHOLDER_'
+
+# CRDs of the kinds a template may hold, by (API group, kind)
+KUBEVIRT_CRDS = {
+    ('kubevirt.io', 'VirtualMachine'): 'virtualmachines.kubevirt.io',
+    ('kubevirt.io', 'VirtualMachineInstance'): 'virtualmachineinstances.kubevirt.io',
+    ('cdi.kubevirt.io', 'DataVolume'): 'datavolumes.cdi.kubevirt.io',
+    ('cdi.kubevirt.io', 'DataSource'): 'datasources.cdi.kubevirt.io',
+    ('instancetype.kubevirt.io', 'VirtualMachineInstancetype'): 'virtualmachineinstancetypes.instancetype.kubevirt.io',
+    ('instancetype.kubevirt.io', 'VirtualMachinePreference'): 'virtualmachinepreferences.instancetype.kubevirt.io',
+    ('pool.kubevirt.io', 'VirtualMachinePool'): 'virtualmachinepools.pool.kubevirt.io',
+    ('snapshot.kubevirt.io', 'VirtualMachineSnapshot'): 'virtualmachinesnapshots.snapshot.kubevirt.io',
+}
+
+# Workloads that live-migrate the template's VMs
+MIGRATION_WORKLOADS = ('migration', 'node-drain', 'descheduler', 'vm-lifecycle', 'soak', 'random-workload')
+# Workloads that create VMs from the template and wait for them to run
+RUNNING_WORKLOADS = ('datasource-clone', 'chaos-benchmark', 'fio', 'disk-ops', 'volume-hotplug', 'volume-resize',
+                     'vm-clone', 'soak', 'random-workload')
+# Workload checks: (check, workloads it matters to)
+WORKLOAD_CHECKS = {
+    'rwo-disk': MIGRATION_WORKLOADS,
+    'pod-bridge': MIGRATION_WORKLOADS,
+    'host-devices': MIGRATION_WORKLOADS,
+    'eviction-strategy': ('node-drain', 'descheduler'),
+    'volume-expansion': ('volume-resize', 'chaos-benchmark'),
+    'snapshot-class': ('chaos-benchmark', 'vm-snapshot'),
+    'not-running': RUNNING_WORKLOADS,
+}
+WORKLOADS = sorted({w for workloads in WORKLOAD_CHECKS.values() for w in workloads})
+
+
+def fill_placeholders(text: str, values: Dict[str, str]) -> Tuple[str, List[str]]:
+    """
+    Replace the placeholders of a template with values; the others become PLACEHOLDER_MARK names.
+
+    Returns:
+        (text, names of the placeholders left unfilled)
+    """
+    unfilled = []
+
+    def replace(match):
+        name = match.group(1)
+        if name in values:
+            return values[name]
+        if name not in unfilled:
+            unfilled.append(name)
+        return PLACEHOLDER_MARK + name
+
+    return PLACEHOLDER_RE.sub(replace, text), unfilled
+
+
+def is_placeholder(value) -> bool:
+    return isinstance(value, str) and PLACEHOLDER_MARK in value
+
+
+def object_ref(doc: Dict) -> str:
+    # Placeholders are shown as written in the template
+    name = re.sub(PLACEHOLDER_MARK + r'(\w+)', r'{{\1}}', str((doc.get('metadata') or {}).get('name', '?')))
+    return f"{doc.get('kind', '?')}/{name}"
+
+
+class TemplateLinter:
+    """
+    Checks of the objects of one template, collected as findings.
+
+    Args:
+        docs: Parsed objects of the template
+        namespace: Namespace of objects without metadata.namespace
+        schemas: CRD OpenAPI v3 schemas by CRD name (from --schema), looked up in the cluster otherwise
+        online: Whether the cluster may be queried
+        logger: Logger instance
+    """
+
+    def __init__(self, docs: List[Dict], namespace: str, schemas: Dict[str, Dict], online: bool,
+                 logger: logging.Logger):
+        self.docs = docs
+        self.namespace = namespace
+        self.schemas = dict(schemas)
+        self.online = online
+        self.logger = logger
+        self.findings: List[Dict] = []
+        self.checked: List[str] = []
+        self._cache: Dict[Tuple[str, ...], Optional[Dict]] = {}
+
+    def add(self, level: str, check: str, doc: Dict, path: str, message: str, workloads=()):
+        finding = {'level': level, 'check': check, 'object': object_ref(doc), 'path': path, 'message': message}
+        if workloads:
+            finding['workloads'] = list(workloads)
+        self.findings.append(finding)
+
+    def _get(self, *args: str) -> Optional[Dict]:
+        """kubectl get ARGS -o json, cached; None if not found or offline."""
+        if not self.online:
+            return None
+        if args not in self._cache:
+            returncode, stdout, _ = run_kubectl_command(list(args) + ['-o', 'json'], check=False, logger=self.logger)
+            self._cache[args] = json.loads(stdout) if returncode == 0 and stdout.strip() else None
+        return self._cache[args]
+
+    # --- Schema ---
+
+    def crd_schema(self, crd: str, version: str) -> Optional[Dict]:
+        if crd not in self.schemas:
+            self.schemas.update(crd_schemas([self._get('get', 'crd', crd) or {}]))
+        return (self.schemas.get(crd) or {}).get(version)
+
+    def check_schema(self, doc: Dict):
+        group, _, version = (doc.get('apiVersion') or '').rpartition('/')
+        crd = KUBEVIRT_CRDS.get((group, doc.get('kind')))
+        if not crd:
+            return
+        schema = self.crd_schema(crd, version)
+        if schema is None:
+            if self.online or crd in self.schemas:
+                self.add(ERROR, 'schema', doc, '', f"{doc.get('apiVersion')} {doc.get('kind')} is not served "
+                                                    f"by the cluster (CRD {crd} or its version not found)")
+            return
+        self.checked.append(object_ref(doc))
+        for path, message in validate_schema(doc, schema):
+            self.add(ERROR, 'schema', doc, path, message)
+
+    # --- References ---
+
+    def storage_class(self, name: Optional[str]) -> Optional[Dict]:
+        """The storage class of a volume: by name, or the cluster default for None."""
+        if name:
+            return self._get('get', 'storageclass', name)
+        default_class = 'storageclass.kubernetes.io/is-default-class'
+        for item in (self._get('get', 'storageclass') or {}).get('items', []):
+            if (item.get('metadata', {}).get('annotations') or {}).get(default_class) == 'true':
+                return item
+        return None
+
+    def check_storage_class(self, doc: Dict, path: str, name: Optional[str]):
+        if is_placeholder(name) or not self.online:
+            return
+        if name and self.storage_class(name) is None:
+            self.add(ERROR, 'storage-class', doc, path, f"Storage class '{name}' does not exist")
+        elif not name and self.storage_class(None) is None:
+            self.add(WARN, 'storage-class', doc, path,
+                     "No storage class set and the cluster has no default storage class")
+
+    def check_datasource(self, doc: Dict, path: str, source_ref: Dict):
+        name = source_ref.get('name')
+        namespace = source_ref.get('namespace') or doc.get('metadata', {}).get('namespace') or self.namespace
+        if source_ref.get('kind') != 'DataSource' or not name or is_placeholder(name) or is_placeholder(namespace) \
+                or not self.online:
+            return
+        datasource = self._get('get', 'datasource', name, '-n', namespace)
+        if datasource is None:
+            self.add(ERROR, 'datasource', doc, path, f"DataSource {namespace}/{name} does not exist "
+                                                     f"(create it with `virtbench images`)")
+            return
+        ready = [c for c in datasource.get('status', {}).get('conditions') or [] if c.get('type') == 'Ready']
+        if not ready or ready[0].get('status') != 'True':
+            reason = ready[0].get('reason') if ready else 'no Ready condition'
+            self.add(WARN, 'datasource', doc, path, f"DataSource {namespace}/{name} is not Ready ({reason})")
+
+    # --- Volumes ---
+
+    def access_modes(self, spec: Dict) -> Optional[List[str]]:
+        """Access modes of a DataVolume spec: explicit, or the StorageProfile's for spec.storage."""
+        claim = spec.get('storage') or spec.get('pvc') or {}
+        if claim.get('accessModes'):
+            return claim['accessModes']
+        if 'storage' not in spec or is_placeholder(claim.get('storageClassName')):
+            return None
+        storage_class = self.storage_class(claim.get('storageClassName'))
+        if storage_class is None:
+            return None
+        profile = self._get('get', 'storageprofile', storage_class['metadata']['name']) or {}
+        property_sets = profile.get('status', {}).get('claimPropertySets') or []
+        # CDI uses the first property set of the profile
+        return property_sets[0].get('accessModes') if property_sets else None
+
+    def volumes(self, doc: Dict) -> List[Tuple[str, Dict]]:
+        """(path, DataVolume spec) of the DataVolumes a document creates."""
+        if doc.get('kind') == 'DataVolume':
+            return [('.spec', doc.get('spec') or {})]
+        if doc.get('kind') == 'VirtualMachine':
+            return [(f".spec.dataVolumeTemplates[{i}].spec", dvt.get('spec') or {})
+                    for i, dvt in enumerate(doc.get('spec', {}).get('dataVolumeTemplates') or [])]
+        return []
+
+    def check_volumes(self, doc: Dict, workloads: List[str]):
+        for path, spec in self.volumes(doc):
+            key = 'storage' if 'storage' in spec else 'pvc'
+            name = (spec.get(key) or {}).get('storageClassName')
+            self.check_storage_class(doc, f"{path}.{key}.storageClassName", name)
+            if spec.get('sourceRef'):
+                self.check_datasource(doc, f"{path}.sourceRef", spec['sourceRef'])
+
+            modes = self.access_modes(spec)
+            affected = _affected('rwo-disk', workloads)
+            if affected and modes and 'ReadWriteMany' not in modes:
+                self.add(WARN, 'rwo-disk', doc, path, f"Disk is {'/'.join(modes)}; live migration needs "
+                                                      f"ReadWriteMany", affected)
+
+            storage_class = None if is_placeholder(name) else self.storage_class(name)
+            if storage_class is None:
+                continue
+            affected = _affected('volume-expansion', workloads)
+            if affected and not storage_class.get('allowVolumeExpansion'):
+                self.add(WARN, 'volume-expansion', doc, path, f"Storage class "
+                         f"'{storage_class['metadata']['name']}' does not allow volume expansion", affected)
+            affected = _affected('snapshot-class', workloads)
+            if affected:
+                drivers = {c.get('driver') for c in (self._get('get', 'volumesnapshotclass') or {}).get('items', [])}
+                if storage_class.get('provisioner') not in drivers:
+                    self.add(WARN, 'snapshot-class', doc, path, f"No VolumeSnapshotClass for "
+                             f"{storage_class.get('provisioner')}; VM snapshots will fail", affected)
+
+    # --- VM settings ---
+
+    def check_vm(self, doc: Dict, workloads: List[str]):
+        if doc.get('kind') == 'VirtualMachine':
+            spec = doc.get('spec') or {}
+            vmi_spec, prefix = (spec.get('template') or {}).get('spec') or {}, '.spec.template.spec'
+            affected = _affected('not-running', workloads)
+            if affected and (spec.get('runStrategy') in ('Halted', 'Manual') or spec.get('running') is False):
+                state = f"runStrategy {spec['runStrategy']}" if spec.get('runStrategy') else 'running: false'
+                self.add(WARN, 'not-running', doc, '.spec', f"VMs do not start on creation ({state}); "
+                         f"the run waits for them to be Running", affected)
+        elif doc.get('kind') == 'VirtualMachineInstance':
+            vmi_spec, prefix = doc.get('spec') or {}, '.spec'
+        else:
+            return
+
+        pod_networks = {n.get('name') for n in vmi_spec.get('networks') or [] if 'pod' in n}
+        affected = _affected('pod-bridge', workloads)
+        for i, interface in enumerate((vmi_spec.get('domain') or {}).get('devices', {}).get('interfaces') or []):
+            if affected and 'bridge' in interface and interface.get('name') in pod_networks:
+                self.add(WARN, 'pod-bridge', doc, f"{prefix}.domain.devices.interfaces[{i}]",
+                         "Bridge binding on the pod network blocks live migration; use masquerade", affected)
+
+        devices = (vmi_spec.get('domain') or {}).get('devices', {})
+        affected = _affected('host-devices', workloads)
+        for field in ('gpus', 'hostDevices'):
+            if affected and devices.get(field):
+                self.add(WARN, 'host-devices', doc, f"{prefix}.domain.devices.{field}",
+                         "VMs with passthrough devices cannot live-migrate", affected)
+
+        affected = _affected('eviction-strategy', workloads)
+        if affected and vmi_spec.get('evictionStrategy') == 'None':
+            self.add(WARN, 'eviction-strategy', doc, f"{prefix}.evictionStrategy",
+                     "evictionStrategy None: drained VMs are shut down instead of migrated", affected)
+
+    def lint(self, workloads: List[str]):
+        for doc in self.docs:
+            self.check_schema(doc)
+            self.check_volumes(doc, workloads)
+            self.check_vm(doc, workloads)
+
+    @property
+    def errors(self) -> int:
+        return sum(1 for f in self.findings if f['level'] == ERROR)
+
+    @property
+    def warnings(self) -> int:
+        return sum(1 for f in self.findings if f['level'] == WARN)
+
+    @property
+    def status(self) -> str:
+        """Overall result: 'fail' with errors, 'warn' with warnings, else 'pass'"""
+        if self.errors:
+            return 'fail'
+        return 'warn' if self.warnings else 'pass'
+
+
+def _affected(check: str, workloads: List[str]) -> List[str]:
+    """The selected workloads a workload check matters to."""
+    return [w for w in WORKLOAD_CHECKS[check] if w in workloads]
+
+
+def crd_schemas(crds: List[Dict]) -> Dict[str, Dict[str, Dict]]:
+    """{CRD name: {version: openAPIV3Schema}} of CRD objects."""
+    schemas = {}
+    for crd in crds:
+        if crd.get('kind') != 'CustomResourceDefinition':
+            continue
+        for version in crd.get('spec', {}).get('versions') or []:
+            schema = (version.get('schema') or {}).get('openAPIV3Schema')
+            if schema:
+                schemas.setdefault(crd['metadata']['name'], {})[version['name']] = schema
+    return schemas
+
+
+def load_schema_files(paths: List[str]) -> Dict[str, Dict[str, Dict]]:
+    """CRD schemas from YAML/JSON files of CRDs or Lists of CRDs (e.g. kubectl get crd -o yaml)."""
+    crds = []
+    for path in paths:
+        with open(path) as f:
+            for doc in yaml.safe_load_all(f):
+                if doc:
+                    crds.extend(doc.get('items') or [] if doc.get('kind', '').endswith('List') else [doc])
+    return crd_schemas(crds)
+
+
+def validate_schema(value, schema: Dict, path: str = '') -> List[Tuple[str, str]]:
+    """
+    Check a value against a structural OpenAPI v3 schema, as the API server does for a custom resource.
+
+    Returns:
+        [(field path, message)] of the violations
+    """
+    if value is None or is_placeholder(value):
+        return []
+    if schema.get('x-kubernetes-int-or-string'):
+        ok = isinstance(value, (int, str)) and not isinstance(value, bool)
+        return [] if ok else [(path, f"must be an integer or a string, not {type(value).__name__}")]
+
+    errors = []
+    expected = schema.get('type')
+    if expected == 'object':
+        if not isinstance(value, dict):
+            return [(path, f"must be an object, not {type(value).__name__}")]
+        properties = schema.get('properties')
+        additional = schema.get('additionalProperties')
+        for name in schema.get('required') or []:
+            if name not in value:
+                errors.append((path, f"missing required field '{name}'"))
+        for name, item in value.items():
+            if properties and name in properties:
+                errors += validate_schema(item, properties[name], f"{path}.{name}")
+            elif isinstance(additional, dict):
+                errors += validate_schema(item, additional, f"{path}.{name}")
+            elif properties is not None and not schema.get('x-kubernetes-preserve-unknown-fields') \
+                    and additional is not True:
+                errors.append((f"{path}.{name}", "unknown field"))
+    elif expected == 'array':
+        if not isinstance(value, list):
+            return [(path, f"must be an array, not {type(value).__name__}")]
+        for i, item in enumerate(value):
+            errors += validate_schema(item, schema.get('items') or {}, f"{path}[{i}]")
+    elif expected in ('string', 'integer', 'number', 'boolean'):
+        types = {'string': str, 'integer': int, 'number': (int, float), 'boolean': bool}[expected]
+        if not isinstance(value, types) or (isinstance(value, bool) and expected != 'boolean'):
+            return [(path, f"must be {'an' if expected == 'integer' else 'a'} {expected}, "
+                           f"not {type(value).__name__}")]
+    if schema.get('enum') and value not in schema['enum']:
+        errors.append((path, f"'{value}' is not one of {', '.join(map(str, schema['enum']))}"))
+    return errors
+
+
+def cluster_reachable(logger: logging.Logger) -> bool:
+    try:
+        returncode, _, stderr = run_kubectl_command(['get', '--raw', '/version'], check=False, timeout=30,
+                                                    logger=logger)
+    except Exception as e:
+        stderr, returncode = str(e), 1
+    if returncode != 0:
+        logger.warning(f"Cluster not reachable, checking offline: {stderr.strip()}")
+    return returncode == 0
+
+
+def print_findings(linter: TemplateLinter, template: str, logger: logging.Logger):
+    logger.info("\n" + "=" * 80)
+    logger.info(f"TEMPLATE LINT: {template}")
+    logger.info("=" * 80)
+    for finding in linter.findings:
+        location = f"{finding['object']}{finding['path']}"
+        workloads = f" [{', '.join(finding['workloads'])}]" if finding.get('workloads') else ''
+        log = logger.error if finding['level'] == ERROR else logger.warning
+        log(f"  {finding['level']:<5} {finding['check']:<18} {location}: {finding['message']}{workloads}")
+    if not linter.findings:
+        logger.info("  No problems found")
+    logger.info("-" * 80)
+    logger.info(f"Errors:   {linter.errors}")
+    logger.info(f"Warnings: {linter.warnings}")
+    logger.info("=" * 80)
+
+
+def parse_args():
+    """Parse command line arguments"""
+    parser = argparse.ArgumentParser(
+        description='Lint a VM template: KubeVirt schema, DataSources and storage classes, workload settings',
+        formatter_class=argparse.RawDescriptionHelpFormatter,
+        epilog="""
+Examples:
+  # Lint a template against the current cluster
+  %(prog)s examples/vm-templates/vm-template.yaml --set STORAGE_CLASS_NAME=px-csi-db \\
+      --set DATASOURCE_NAME=rhel9 --set DATASOURCE_NAMESPACE=openshift-virtualization-os-images
+
+  # Only warn about settings that break migration and volume-resize
+  %(prog)s my-vm.yaml --workload migration volume-resize
+
+  # Without a cluster, against saved CRDs
+  %(prog)s my-vm.yaml --offline --schema kubevirt-crds.yaml
+        """
+    )
+    parser.add_argument('template', help='VM template file (YAML or JSON, several documents allowed)')
+    parser.add_argument('--set', dest='values', action='extend', nargs='+', default=[], metavar='NAME=VALUE',
+                        help='Values of template placeholders {{NAME}}')
+    parser.add_argument('--namespace', default='default',
+                        help='Namespace of objects without metadata.namespace (default: default)')
+    parser.add_argument('--workload', dest='workloads', action='extend', nargs='+', choices=WORKLOADS, default=[],
+                        help='Only warn about settings that break these workloads (default: all)')
+    parser.add_argument('--schema', action='extend', nargs='+', default=[], metavar='FILE',
+                        help='CRD manifests to validate against instead of the cluster\'s CRDs')
+    parser.add_argument('--offline', action='store_true',
+                        help='Do not query the cluster (only --schema validation and template settings)')
+    parser.add_argument('--report', default=None, help='Write the findings as a JSON report to this file')
+    parser.add_argument('--strict', action='store_true', help='Exit with code 2 when there are only warnings')
+    parser.add_argument('--kubeconfig', type=str, default=None,
+                        help='Path to kubeconfig file')
+    parser.add_argument('--log-level', type=str, default='INFO',
+                        choices=['DEBUG', 'INFO', 'WARNING', 'ERROR'],
+                        help='Logging level (default: INFO)')
+
+    args = parser.parse_args()
+    for value in args.values:
+        if '=' not in value:
+            parser.error(f"--set expects NAME=VALUE, got '{value}'")
+    return args
+
+
+def main():
+    """Main execution function"""
+    args = parse_args()
+
+    if args.kubeconfig:
+        os.environ['KUBECONFIG'] = args.kubeconfig
+
+    logger = setup_logging(log_file=None, log_level=args.log_level)
+
+    values = dict(PLACEHOLDER_RE.sub(r'\1', value).split('=', 1) for value in args.values)
+    try:
+        with open(args.template) as f:
+            text, unfilled = fill_placeholders(f.read(), values)
+        docs = [doc for doc in yaml.safe_load_all(text) if doc]
+        schemas = load_schema_files(args.schema)
+    except (OSError, yaml.YAMLError) as e:
+        logger.error(f"Cannot read {e}")
+        sys.exit(EXIT_FAILED)
+    if not all(isinstance(doc, dict) for doc in docs):
+        logger.error(f"{args.template} is not a manifest of Kubernetes objects")
+        sys.exit(EXIT_FAILED)
+    if unfilled:
+        logger.info(f"Not checking fields with unfilled placeholders: {', '.join(unfilled)} (fill them with --set)")
+
+    online = not args.offline and cluster_reachable(logger)
+    linter = TemplateLinter(docs, args.namespace, schemas, online, logger)
+    linter.lint(args.workloads or WORKLOADS)
+    if not online and not linter.checked:
+        logger.warning("No object was validated against a schema; give the CRDs with --schema")
+    print_findings(linter, args.template, logger)
+
+    report = {
+        'generated_at': datetime.now(timezone.utc).strftime('%Y-%m-%dT%H:%M:%SZ'),
+        'template': args.template,
+        'status': linter.status,
+        'online': online,
+        'schema_checked': linter.checked,
+        'unfilled_placeholders': unfilled,
+        'summary': {'errors': linter.errors, 'warnings': linter.warnings},
+        'findings': linter.findings,
+    }
+    if args.report:
+        report_dir = os.path.dirname(args.report)
+        if report_dir:
+            os.makedirs(report_dir, exist_ok=True)
+        with open(args.report, 'w') as f:
+            json.dump(report, f, indent=2)
+        logger.info(f"Lint report written to {args.report}")
+    emit('template-lint', report)
+
+    if linter.errors:
+        sys.exit(EXIT_FAILED)
+    sys.exit(EXIT_WARNINGS if args.strict and linter.warnings else 0)
+
+
+if __name__ == '__main__':
+    main()
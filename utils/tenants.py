@@ -0,0 +1,255 @@
+#!/usr/bin/env python3
+"""
+Multi-tenant simulation for KubeVirt performance testing.
+
+A benchmark normally runs as one (cluster-admin) user, so its latencies
+leave out what a tenant's request costs on a shared cluster: RBAC
+authorization of a narrowly bound user, admission of its requests and
+quota accounting. With ``virtbench --tenants N`` the namespaces a workload
+creates are split into N tenants, and every kubectl call aimed at a tenant
+namespace runs as that tenant through impersonation (``kubectl --as``):
+
+    --tenants 4                                  users virtbench-tenant-1 .. -4
+    --tenants 4 --tenant-identity serviceaccount system:serviceaccount:virtbench-tenants:tenant-1 .. -4
+
+Namespace {prefix}-{i} belongs to tenant ((i - 1) mod N) + 1; other names
+are assigned by hash. Each tenant namespace gets a RoleBinding
+(``virtbench-tenant``) of the ``admin`` ClusterRole, which KubeVirt and CDI
+aggregate their roles into, to its tenant only; a ClusterRole and
+ClusterRoleBinding (``virtbench-tenant-clone-source``) let all tenants clone
+from DataSources in other namespaces, as CDI checks for the requesting user.
+Impersonated ServiceAccounts need not exist; RBAC matches them by name.
+
+Namespace creation, cluster-scoped calls (nodes, storage classes) and calls
+in other namespaces (golden images) keep running as the benchmark user, as
+do virtctl and the background watches. Combined with --namespace-quota, every
+tenant also hits its own quota.
+
+When results are saved, tenant_summary() groups the per-VM rows by tenant and
+reports each tenant's metrics and how far its median and p95 are from all
+tenants'; it is embedded in the result summary under ``tenants``.
+"""
+
+import json
+import logging
+import os
+import re
+import threading
+import zlib
+from typing import Dict, Iterable, List, Optional
+
+from utils.apply import apply_manifest
+from utils.base import run_labels, set_impersonation
+from utils.stats import metric_stats
+
+TENANTS_ENV = 'VIRTBENCH_TENANTS'
+TENANT_IDENTITY_ENV = 'VIRTBENCH_TENANT_IDENTITY'
+IDENTITY_USER = 'user'
+IDENTITY_SERVICEACCOUNT = 'serviceaccount'
+IDENTITIES = (IDENTITY_USER, IDENTITY_SERVICEACCOUNT)
+
+TENANT_USER_PREFIX = 'virtbench-tenant'
+TENANT_GROUP = 'virtbench-tenants'
+# Namespace of the impersonated ServiceAccounts (--tenant-identity serviceaccount)
+SERVICEACCOUNT_NAMESPACE = 'virtbench-tenants'
+TENANT_ROLE_BINDING = 'virtbench-tenant'
+TENANT_ROLE = 'admin'
+CLONE_SOURCE_ROLE = 'virtbench-tenant-clone-source'
+TENANT_LABEL = 'virtbench.io/tenant'
+
+# Tenant namespaces set up by this process, with their tenant
+_tenant_namespaces: Dict[str, str] = {}
+# Per-call latencies recorded by workloads: {namespace: {metric: [seconds]}}
+_calls: Dict[str, Dict[str, List[float]]] = {}
+_lock = threading.Lock()
+
+
+def tenant_count() -> int:
+    """Number of tenants (`virtbench --tenants`), 0 when the run is not multi-tenant."""
+    try:
+        return max(0, int(os.environ.get(TENANTS_ENV) or 0))
+    except ValueError:
+        return 0
+
+
+def tenant_identity() -> str:
+    """Kind of identity tenants are impersonated as (`virtbench --tenant-identity`)."""
+    identity = (os.environ.get(TENANT_IDENTITY_ENV) or IDENTITY_USER).lower()
+    return identity if identity in IDENTITIES else IDENTITY_USER
+
+
+def tenant_for(namespace: str) -> str:
+    """Tenant of a namespace: {prefix}-{i} goes to tenant ((i - 1) mod N) + 1, other names by hash."""
+    count = tenant_count() or 1
+    match = re.search(r'-(\d+)$', namespace)
+    index = (int(match.group(1)) - 1) if match else zlib.crc32(namespace.encode())
+    return f"tenant-{index % count + 1}"
+
+
+def tenant_subject(tenant: str) -> Dict:
+    """RBAC subject of a tenant."""
+    if tenant_identity() == IDENTITY_SERVICEACCOUNT:
+        return {'kind': 'ServiceAccount', 'name': tenant, 'namespace': SERVICEACCOUNT_NAMESPACE}
+    return {'apiGroup': 'rbac.authorization.k8s.io', 'kind': 'User', 'name': f"{TENANT_USER_PREFIX}-{tenant[7:]}"}
+
+
+def impersonation_args(tenant: str) -> List[str]:
+    """kubectl flags acting as a tenant."""
+    if tenant_identity() == IDENTITY_SERVICEACCOUNT:
+        groups = ['system:serviceaccounts', f"system:serviceaccounts:{SERVICEACCOUNT_NAMESPACE}"]
+        user = f"system:serviceaccount:{SERVICEACCOUNT_NAMESPACE}:{tenant}"
+    else:
+        groups = [TENANT_GROUP]
+        user = tenant_subject(tenant)['name']
+    return [f"--as={user}"] + [f"--as-group={group}" for group in groups + ['system:authenticated']]
+
+
+def _all_tenants_subject() -> Dict:
+    if tenant_identity() == IDENTITY_SERVICEACCOUNT:
+        return {'apiGroup': 'rbac.authorization.k8s.io', 'kind': 'Group',
+                'name': f"system:serviceaccounts:{SERVICEACCOUNT_NAMESPACE}"}
+    return {'apiGroup': 'rbac.authorization.k8s.io', 'kind': 'Group', 'name': TENANT_GROUP}
+
+
+def tenant_rbac_manifests(namespaces: List[str]) -> List[Dict]:
+    """RoleBindings of the tenant namespaces, and the cluster-wide clone source role of all tenants."""
+    labels = run_labels()
+    items = [
+        {
+            'apiVersion': 'rbac.authorization.k8s.io/v1', 'kind': 'ClusterRole',
+            'metadata': {'name': CLONE_SOURCE_ROLE},
+            'rules': [
+                {'apiGroups': ['cdi.kubevirt.io'], 'resources': ['datavolumes/source'], 'verbs': ['create']},
+                {'apiGroups': ['cdi.kubevirt.io'], 'resources': ['datasources'], 'verbs': ['get', 'list']},
+            ],
+        },
+        {
+            'apiVersion': 'rbac.authorization.k8s.io/v1', 'kind': 'ClusterRoleBinding',
+            'metadata': {'name': CLONE_SOURCE_ROLE},
+            'roleRef': {'apiGroup': 'rbac.authorization.k8s.io', 'kind': 'ClusterRole', 'name': CLONE_SOURCE_ROLE},
+            'subjects': [_all_tenants_subject()],
+        },
+    ]
+    for namespace in namespaces:
+        tenant = tenant_for(namespace)
+        items.append({
+            'apiVersion': 'rbac.authorization.k8s.io/v1', 'kind': 'RoleBinding',
+            'metadata': {'name': TENANT_ROLE_BINDING, 'namespace': namespace,
+                         'labels': dict(labels, **{TENANT_LABEL: tenant})},
+            'roleRef': {'apiGroup': 'rbac.authorization.k8s.io', 'kind': 'ClusterRole', 'name': TENANT_ROLE},
+            'subjects': [tenant_subject(tenant)],
+        })
+    return items
+
+
+def setup_tenant_namespaces(namespaces: List[str], logger: Optional[logging.Logger] = None) -> bool:
+    """
+    Bind the namespaces to their tenants and impersonate the tenants in them from now on.
+
+    Returns:
+        True if the run is not multi-tenant or the RoleBindings were applied
+    """
+    if not namespaces or not tenant_count():
+        return True
+    manifest = json.dumps({'apiVersion': 'v1', 'kind': 'List', 'items': tenant_rbac_manifests(namespaces)})
+    applied, error = apply_manifest(manifest, logger=logger)
+    if not applied:
+        if logger:
+            logger.error(f"Failed to bind namespaces to their tenants: {error}")
+        return False
+    with _lock:
+        for namespace in namespaces:
+            _tenant_namespaces[namespace] = tenant_for(namespace)
+            set_impersonation(namespace, impersonation_args(_tenant_namespaces[namespace]))
+    if logger:
+        logger.debug(f"Bound {len(namespaces)} namespaces to tenants ({tenant_identity()} identities)")
+    return True
+
+
+def namespace_tenant(target: str) -> Optional[str]:
+    """Tenant of a result target ("namespace" or "namespace/vm"), None if it is not a tenant namespace."""
+    return _tenant_namespaces.get(str(target).split('/', 1)[0])
+
+
+def record_call(namespace: str, metric: str, seconds: float):
+    """Record the latency of one API call a workload made in a namespace (e.g. create_call_sec)."""
+    with _lock:
+        _calls.setdefault(namespace, {}).setdefault(metric, []).append(seconds)
+
+
+def tenant_summary(rows: List[Dict], metrics: Iterable[str]) -> Optional[Dict]:
+    """
+    Per-tenant statistics of per-VM result rows and of the recorded call latencies.
+
+    Args:
+        rows: Result rows with a "namespace" (or "namespace/vm" target)
+        metrics: Row keys to compare, e.g. ["running_time_sec"]
+
+    Returns:
+        {identity, count, tenants: {tenant: {subject, namespaces, vms, metrics}},
+        differences: {metric: {spread, tenants: {tenant: {median_vs_all_pct,
+        p95_vs_all_pct}}}}}, where spread is the slowest tenant's median over
+        the fastest's; None when the run is not multi-tenant
+    """
+    if not tenant_count() or not _tenant_namespaces:
+        return None
+    metrics = list(metrics)
+    with _lock:
+        calls = {ns: dict(values) for ns, values in _calls.items()}
+    call_metrics = sorted({metric for values in calls.values() for metric in values})
+
+    groups: Dict[str, List[Dict]] = {}
+    for row in rows:
+        tenant = namespace_tenant(row.get('namespace', ''))
+        if tenant:
+            namespace = str(row['namespace']).split('/', 1)[0]
+            groups.setdefault(tenant, []).append(dict(row, _ns=namespace))
+
+    def values(group: List[Dict], metric: str) -> List[float]:
+        if metric in call_metrics:
+            return [v for ns in {r['_ns'] for r in group} for v in calls.get(ns, {}).get(metric, [])]
+        return [r[metric] for r in group if r.get(metric) is not None]
+
+    everyone = [row for group in groups.values() for row in group]
+    tenants, differences = {}, {}
+    for tenant in sorted(groups, key=lambda t: int(t.split('-')[1])):
+        group = groups[tenant]
+        tenants[tenant] = {
+            'subject': tenant_subject(tenant)['name'],
+            'namespaces': len({r['_ns'] for r in group}),
+            'vms': len(group),
+            'metrics': [metric_stats(metric, values(group, metric)) for metric in metrics + call_metrics],
+        }
+    for metric in metrics + call_metrics:
+        overall = metric_stats(metric, values(everyone, metric))
+        if not overall.get('count'):
+            continue
+        per_tenant = {}
+        for tenant, summary in tenants.items():
+            stats = next(m for m in summary['metrics'] if m['metric'] == metric)
+            if stats.get('count'):
+                per_tenant[tenant] = {
+                    f"{key}_vs_all_pct": round((stats[key] / overall[key] - 1) * 100, 1) if overall[key] else None
+                    for key in ('median', 'p95')
+                }
+        medians = [next(m for m in s['metrics'] if m['metric'] == metric).get('median') for s in tenants.values()]
+        medians = [m for m in medians if m is not None]
+        differences[metric] = {
+            'spread': round(max(medians) / min(medians), 3) if medians and min(medians) else None,
+            'tenants': per_tenant,
+        }
+    return {'identity': tenant_identity(), 'count': tenant_count(), 'tenants': tenants, 'differences': differences}
+
+
+def print_tenant_summary(summary: Dict, logger: logging.Logger):
+    """Log a tenant_summary() result: median and p95 per tenant and metric, with the spread."""
+    logger.info(f"Per-tenant results ({len(summary['tenants'])} tenants, impersonated {summary['identity']}s):")
+    for metric, difference in summary['differences'].items():
+        spread = difference['spread']
+        logger.info(f"  {metric}" + (f" (slowest/fastest tenant median: {spread:.2f}x)" if spread else ''))
+        for tenant, diff in difference['tenants'].items():
+            stats = next(m for m in summary['tenants'][tenant]['metrics'] if m['metric'] == metric)
+            median_pct = f"{diff['median_vs_all_pct']:+.1f}%" if diff['median_vs_all_pct'] is not None else 'n/a'
+            p95_pct = f"{diff['p95_vs_all_pct']:+.1f}%" if diff['p95_vs_all_pct'] is not None else 'n/a'
+            logger.info(f"    {tenant:<12} median {stats['median']:>9.3f}s ({median_pct})  "
+                        f"p95 {stats['p95']:>9.3f}s ({p95_pct})  n={stats['count']}")
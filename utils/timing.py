@@ -0,0 +1,217 @@
+#!/usr/bin/env python3
+"""
+Clock and precision helpers for KubeVirt performance testing.
+
+Durations are measured with the monotonic clock so that NTP steps or manual
+clock changes on the client cannot distort them, while absolute timestamps
+are recorded in RFC3339 with nanoseconds (UTC). Because per-VM timings are
+often compared with Kubernetes object timestamps, the offset between the
+client clock and the API server clock is estimated and stored next to the
+results. Result rounding is configurable via set_precision().
+"""
+
+import logging
+import subprocess
+import time
+from datetime import datetime, timedelta, timezone
+from typing import Dict, Optional
+
+DEFAULT_PRECISION = 2
+CLOCK_SOURCE = 'monotonic'
+# Never stored: the clock skew probe only creates it with a server-side dry run
+CLOCK_PROBE_NAME = 'virtbench-clock-probe'
+CLOCK_PROBE_NAMESPACE = 'default'
+
+_precision = DEFAULT_PRECISION
+
+
+def set_precision(digits: int):
+    """Set the number of decimal places used when rounding durations in results."""
+    global _precision
+    _precision = max(0, int(digits))
+
+
+def get_precision() -> int:
+    return _precision
+
+
+def round_duration(value: Optional[float]) -> Optional[float]:
+    """Round a duration (seconds) to the configured precision, passing None through."""
+    if value is None:
+        return None
+    return round(value, _precision)
+
+
+class MonotonicTimestamp:
+    """
+    A wall-clock timestamp paired with a monotonic clock reading.
+
+    Subtracting two instances yields a timedelta computed from the monotonic
+    readings, so it can replace datetime.now() in duration arithmetic
+    (``(now() - start).total_seconds()``) while still carrying the absolute
+    time for reporting.
+    """
+
+    __slots__ = ('mono_ns', 'wall_ns')
+
+    def __init__(self, mono_ns: int, wall_ns: int):
+        self.mono_ns = mono_ns
+        self.wall_ns = wall_ns
+
+    @property
+    def wall(self) -> datetime:
+        """Absolute time as an aware UTC datetime (microsecond resolution)."""
+        return datetime.fromtimestamp(self.wall_ns / 1e9, tz=timezone.utc)
+
+    def rfc3339nano(self) -> str:
+        return rfc3339nano(self.wall_ns)
+
+    def __sub__(self, other):
+        if isinstance(other, MonotonicTimestamp):
+            return timedelta(microseconds=(self.mono_ns - other.mono_ns) / 1000)
+        if isinstance(other, timedelta):
+            delta_ns = int(other.total_seconds() * 1e9)
+            return MonotonicTimestamp(self.mono_ns - delta_ns, self.wall_ns - delta_ns)
+        return NotImplemented
+
+    def __add__(self, other):
+        if isinstance(other, timedelta):
+            delta_ns = int(other.total_seconds() * 1e9)
+            return MonotonicTimestamp(self.mono_ns + delta_ns, self.wall_ns + delta_ns)
+        return NotImplemented
+
+    def __lt__(self, other):
+        return self.mono_ns < other.mono_ns
+
+    def __le__(self, other):
+        return self.mono_ns <= other.mono_ns
+
+    def __gt__(self, other):
+        return self.mono_ns > other.mono_ns
+
+    def __ge__(self, other):
+        return self.mono_ns >= other.mono_ns
+
+    def __eq__(self, other):
+        return isinstance(other, MonotonicTimestamp) and self.mono_ns == other.mono_ns
+
+    def __hash__(self):
+        return hash(self.mono_ns)
+
+    def __repr__(self):
+        return f"MonotonicTimestamp({self.rfc3339nano()})"
+
+
+def now() -> MonotonicTimestamp:
+    """Capture the current monotonic and wall-clock time."""
+    return MonotonicTimestamp(time.monotonic_ns(), time.time_ns())
+
+
+def rfc3339nano(wall_ns: Optional[int] = None) -> str:
+    """
+    Format a Unix timestamp in nanoseconds as RFC3339 with nanoseconds, in UTC.
+
+    Args:
+        wall_ns: Nanoseconds since the epoch (default: now)
+
+    Returns:
+        Timestamp such as 2024-05-01T12:00:00.123456789Z
+    """
+    if wall_ns is None:
+        wall_ns = time.time_ns()
+    seconds, nanos = divmod(wall_ns, 1_000_000_000)
+    base = datetime.fromtimestamp(seconds, tz=timezone.utc).strftime('%Y-%m-%dT%H:%M:%S')
+    return f"{base}.{nanos:09d}Z"
+
+
+def estimate_clock_skew(samples: int = 3,
+                        logger: Optional[logging.Logger] = None) -> Optional[Dict]:
+    """
+    Estimate the offset between the API server clock and the client clock.
+
+    A ConfigMap is created with a server-side dry run, and the
+    creationTimestamp the API server sets on it (one-second resolution) is
+    compared with the midpoint of the request's round trip. Nothing is
+    stored. The sample with the smallest round trip is used; the uncertainty
+    is half a second (timestamp resolution) plus half that round trip.
+
+    Args:
+        samples: Number of requests to make
+        logger: Logger instance
+
+    Returns:
+        Dict with offset_seconds (server minus client; positive means the
+        cluster clock is ahead), uncertainty_seconds, rtt_seconds and method,
+        or None if no creationTimestamp could be read
+    """
+    best = None
+    for _ in range(max(1, samples)):
+        sent_ns = time.time_ns()
+        sent_mono = time.monotonic_ns()
+        try:
+            result = subprocess.run(
+                ['kubectl', 'create', 'configmap', CLOCK_PROBE_NAME, '-n', CLOCK_PROBE_NAMESPACE,
+                 '--dry-run=server', '-o', 'jsonpath={.metadata.creationTimestamp}'],
+                capture_output=True, text=True, timeout=15, check=False
+            )
+        except Exception as e:
+            if logger:
+                logger.debug(f"Clock skew probe failed: {e}")
+            continue
+        rtt_ns = time.monotonic_ns() - sent_mono
+
+        if result.returncode != 0:
+            if logger:
+                logger.debug(f"Clock skew probe failed: {result.stderr.strip()}")
+            continue
+        try:
+            server = datetime.strptime(result.stdout.strip(), '%Y-%m-%dT%H:%M:%SZ').replace(tzinfo=timezone.utc)
+        except ValueError:
+            continue
+
+        # creationTimestamp is truncated to the second; its midpoint is the best estimate
+        server_s = server.timestamp() + 0.5
+        client_s = (sent_ns + rtt_ns / 2) / 1e9
+        if best is None or rtt_ns < best['rtt_ns']:
+            best = {'offset': server_s - client_s, 'rtt_ns': rtt_ns}
+
+    if best is None:
+        if logger:
+            logger.warning("Could not estimate cluster clock skew (no creationTimestamp from API server)")
+        return None
+
+    rtt = best['rtt_ns'] / 1e9
+    skew = {
+        'offset_seconds': round(best['offset'], 3),
+        'uncertainty_seconds': round(0.5 + rtt / 2, 3),
+        'rtt_seconds': round(rtt, 3),
+        'method': 'apiserver-creation-timestamp',
+    }
+    if logger:
+        logger.info(f"Cluster clock offset: {skew['offset_seconds']:+.3f}s "
+                    f"(±{skew['uncertainty_seconds']:.3f}s)")
+    return skew
+
+
+def timing_metadata(started: MonotonicTimestamp, finished: Optional[MonotonicTimestamp] = None,
+                    clock_skew: Optional[Dict] = None) -> Dict:
+    """
+    Build the timing block stored in result summaries.
+
+    Args:
+        started: Test start
+        finished: Test end (default: now)
+        clock_skew: Result of estimate_clock_skew
+
+    Returns:
+        Dict with clock_source, precision, started_at, finished_at (RFC3339Nano, UTC)
+        and clock_skew
+    """
+    finished = finished or now()
+    return {
+        'clock_source': CLOCK_SOURCE,
+        'precision': _precision,
+        'started_at': started.rfc3339nano(),
+        'finished_at': finished.rfc3339nano(),
+        'clock_skew': clock_skew,
+    }
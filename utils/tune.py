@@ -0,0 +1,426 @@
+#!/usr/bin/env python3
+"""
+Apply and revert KubeVirt tuning profiles.
+
+A tuning profile sets documented KubeVirt / OpenShift Virtualization
+settings for a benchmark run:
+
+    name: migration-throughput
+    description: More and faster parallel live migrations
+    settings:
+      parallelMigrationsPerCluster: 20
+      parallelOutboundMigrationsPerNode: 4
+
+On OpenShift Virtualization the settings go to the HyperConverged CR (the
+operator would revert direct KubeVirt CR edits), otherwise to the KubeVirt
+CR. TUNABLES lists the settings and where each lives; settings with no
+HyperConverged field cannot be tuned on OpenShift Virtualization.
+
+Before patching, apply records the previous values in a state file; revert
+restores them and removes the file. apply refuses to run while a state file
+exists, so profiles never stack and a crashed run can always be reverted
+with `virtbench tune revert`.
+
+Exit codes:
+    0: success
+    1: invalid profile or settings, no KubeVirt found, or nothing to revert
+    2: the CR was patched but did not settle within --timeout
+
+Usage:
+    python3 tune.py --show
+    python3 tune.py --apply examples/tuning/migration-throughput.yaml
+    python3 tune.py --set parallelMigrationsPerCluster=10 workloadUpdateMethods=LiveMigrate
+    python3 tune.py --revert
+"""
+
+import argparse
+import json
+import logging
+import os
+import sys
+import time
+from datetime import datetime
+from typing import Dict, List, Optional, Tuple
+
+import yaml
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
+
+from utils.common import setup_logging, run_kubectl_command
+from utils.dryrun import DryRunPlan, is_dry_run
+from utils.output import emit
+
+DEFAULT_STATE_FILE = os.path.join('results', 'tune', 'state.json')
+DEFAULT_TIMEOUT = 300
+POLL_INTERVAL = 5
+
+# Setting name -> where it lives in the KubeVirt and HyperConverged CRs (None: not exposed there)
+TUNABLES = {
+    'parallelMigrationsPerCluster': {
+        'kubevirt': 'spec.configuration.migrations.parallelMigrationsPerCluster',
+        'hyperconverged': 'spec.liveMigrationConfig.parallelMigrationsPerCluster',
+        'type': int,
+        'description': 'Live migrations running at once across the cluster',
+    },
+    'parallelOutboundMigrationsPerNode': {
+        'kubevirt': 'spec.configuration.migrations.parallelOutboundMigrationsPerNode',
+        'hyperconverged': 'spec.liveMigrationConfig.parallelOutboundMigrationsPerNode',
+        'type': int,
+        'description': 'Live migrations running at once from one node',
+    },
+    'bandwidthPerMigration': {
+        'kubevirt': 'spec.configuration.migrations.bandwidthPerMigration',
+        'hyperconverged': 'spec.liveMigrationConfig.bandwidthPerMigration',
+        'type': str,
+        'description': 'Bandwidth cap of each migration, e.g. 64Mi (0: unlimited)',
+    },
+    'completionTimeoutPerGiB': {
+        'kubevirt': 'spec.configuration.migrations.completionTimeoutPerGiB',
+        'hyperconverged': 'spec.liveMigrationConfig.completionTimeoutPerGiB',
+        'type': int,
+        'description': 'Seconds per GiB of guest memory before a migration is cancelled',
+    },
+    'progressTimeout': {
+        'kubevirt': 'spec.configuration.migrations.progressTimeout',
+        'hyperconverged': 'spec.liveMigrationConfig.progressTimeout',
+        'type': int,
+        'description': 'Seconds a migration may make no progress before it is cancelled',
+    },
+    'workloadUpdateMethods': {
+        'kubevirt': 'spec.workloadUpdateStrategy.workloadUpdateMethods',
+        'hyperconverged': 'spec.workloadUpdateStrategy.workloadUpdateMethods',
+        'type': list,
+        'description': 'How VMs move to a new virt-launcher after an update (LiveMigrate, Evict)',
+    },
+    'batchEvictionSize': {
+        'kubevirt': 'spec.workloadUpdateStrategy.batchEvictionSize',
+        'hyperconverged': 'spec.workloadUpdateStrategy.batchEvictionSize',
+        'type': int,
+        'description': 'VMs evicted per batch by the workload updater',
+    },
+    'batchEvictionInterval': {
+        'kubevirt': 'spec.workloadUpdateStrategy.batchEvictionInterval',
+        'hyperconverged': 'spec.workloadUpdateStrategy.batchEvictionInterval',
+        'type': str,
+        'description': 'Interval between eviction batches, e.g. 1m0s',
+    },
+    'infraReplicas': {
+        'kubevirt': 'spec.infra.replicas',
+        'hyperconverged': None,
+        'type': int,
+        'description': 'virt-api and virt-controller replicas',
+    },
+}
+
+
+def parse_setting(name: str, value) -> object:
+    """
+    A setting value converted to its TUNABLES type; comma-separated strings become lists.
+
+    Raises:
+        ValueError: For an unknown setting or a value of the wrong type
+    """
+    if name not in TUNABLES:
+        raise ValueError(f"unknown setting '{name}'; supported: {', '.join(TUNABLES)}")
+    kind = TUNABLES[name]['type']
+    if kind is list:
+        if isinstance(value, str):
+            value = [v.strip() for v in value.split(',') if v.strip()]
+        if not isinstance(value, list) or not value:
+            raise ValueError(f"{name} must be a list")
+        return [str(v) for v in value]
+    if kind is int:
+        try:
+            return int(value)
+        except (TypeError, ValueError):
+            raise ValueError(f"{name} must be an integer, got '{value}'")
+    return str(value)
+
+
+def load_profile(path: str) -> Dict:
+    """
+    Load a tuning profile: {'name', 'description', 'settings'}.
+
+    Raises:
+        OSError, yaml.YAMLError, ValueError: If the file cannot be read or is invalid
+    """
+    with open(path) as f:
+        profile = yaml.safe_load(f) or {}
+    if not isinstance(profile, dict) or not isinstance(profile.get('settings'), dict) or not profile['settings']:
+        raise ValueError(f"{path}: expected a 'settings' mapping")
+    return {
+        'name': profile.get('name') or os.path.splitext(os.path.basename(path))[0],
+        'description': profile.get('description'),
+        'settings': {name: parse_setting(name, value) for name, value in profile['settings'].items()},
+    }
+
+
+def find_target(logger: logging.Logger) -> Optional[Dict]:
+    """The CR to tune: the HyperConverged CR if there is one, else the KubeVirt CR."""
+    for kind in ('hyperconverged', 'kubevirt'):
+        returncode, stdout, _ = run_kubectl_command(['get', kind, '-A', '-o', 'json'], check=False, logger=logger)
+        if returncode != 0:
+            continue
+        items = json.loads(stdout).get('items') or []
+        if items:
+            metadata = items[0]['metadata']
+            return {'kind': kind, 'name': metadata['name'], 'namespace': metadata['namespace'], 'object': items[0]}
+    return None
+
+
+def get_path(obj: Dict, path: str):
+    for key in path.split('.'):
+        if not isinstance(obj, dict):
+            return None
+        obj = obj.get(key)
+    return obj
+
+
+def merge_patch(values: Dict[str, object], kind: str) -> Dict:
+    """Merge patch setting each TUNABLES value at its path in the CR of the kind (None removes it)."""
+    patch = {}
+    for name, value in values.items():
+        keys = TUNABLES[name][kind].split('.')
+        node = patch
+        for key in keys[:-1]:
+            node = node.setdefault(key, {})
+        node[keys[-1]] = value
+    return patch
+
+
+def check_settings(settings: Dict[str, object], kind: str) -> List[str]:
+    """Settings that cannot be tuned in the CR of the kind."""
+    return [name for name in settings if not TUNABLES[name][kind]]
+
+
+def patch_target(target: Dict, values: Dict[str, object], logger: logging.Logger) -> bool:
+    returncode, _, stderr = run_kubectl_command(
+        ['patch', target['kind'], target['name'], '-n', target['namespace'], '--type', 'merge',
+         '-p', json.dumps(merge_patch(values, target['kind']))], check=False, logger=logger)
+    if returncode != 0:
+        logger.error(f"Failed to patch {target['kind']}/{target['name']}: {stderr.strip()}")
+        return False
+    return True
+
+
+def wait_settled(target: Dict, timeout: int, logger: logging.Logger) -> bool:
+    """Wait until the CR reports Available and not Progressing, i.e. the operators rolled the change out."""
+    # Let the operator notice the change before trusting its conditions
+    time.sleep(POLL_INTERVAL)
+    deadline = time.monotonic() + timeout
+    while True:
+        returncode, stdout, _ = run_kubectl_command(
+            ['get', target['kind'], target['name'], '-n', target['namespace'], '-o', 'json'],
+            check=False, logger=logger)
+        if returncode == 0:
+            conditions = {c.get('type'): c.get('status')
+                          for c in json.loads(stdout).get('status', {}).get('conditions') or []}
+            if conditions.get('Available') == 'True' and conditions.get('Progressing') != 'True':
+                return True
+        if time.monotonic() >= deadline:
+            logger.error(f"{target['kind']}/{target['name']} did not settle within {timeout}s")
+            return False
+        time.sleep(POLL_INTERVAL)
+
+
+def read_state(path: str) -> Optional[Dict]:
+    if not os.path.exists(path):
+        return None
+    with open(path) as f:
+        return json.load(f)
+
+
+def write_state(path: str, state: Dict):
+    directory = os.path.dirname(path)
+    if directory:
+        os.makedirs(directory, exist_ok=True)
+    with open(path, 'w') as f:
+        json.dump(state, f, indent=2)
+
+
+def apply_settings(profile: Dict, args, logger: logging.Logger) -> int:
+    """Snapshot the current values of the profile's settings, then apply it. Returns the exit code."""
+    state = read_state(args.state_file)
+    if state:
+        logger.error(f"Tuning profile '{state['profile']}' applied at {state['applied_at']} was not reverted; "
+                     f"run `virtbench tune revert` first (state file {args.state_file})")
+        return 1
+    target = find_target(logger)
+    if target is None:
+        logger.error("No HyperConverged or KubeVirt CR found")
+        return 1
+    unsupported = check_settings(profile['settings'], target['kind'])
+    if unsupported:
+        logger.error(f"{', '.join(unsupported)} cannot be tuned through the {target['kind']} CR")
+        return 1
+
+    previous = {name: get_path(target['object'], TUNABLES[name][target['kind']]) for name in profile['settings']}
+    print_settings(profile['settings'], previous, target, logger, title=f"APPLYING TUNING PROFILE: {profile['name']}")
+    if is_dry_run():
+        plan = DryRunPlan('tune', logger)
+        plan.action('patch', f"{target['kind']}/{target['namespace']}/{target['name']}",
+                    json.dumps(merge_patch(profile['settings'], target['kind'])))
+        plan.report()
+        return 0
+
+    write_state(args.state_file, {
+        'profile': profile['name'],
+        'applied_at': datetime.now().isoformat(timespec='seconds'),
+        'target': {key: target[key] for key in ('kind', 'name', 'namespace')},
+        'applied': profile['settings'],
+        'previous': previous,
+    })
+    if not patch_target(target, profile['settings'], logger):
+        os.remove(args.state_file)
+        return 1
+    logger.info(f"Previous values saved to {args.state_file}")
+    settled = wait_settled(target, args.timeout, logger)
+    emit('tune', {'action': 'apply', 'profile': profile['name'], 'target': target['kind'],
+                  'applied': profile['settings'], 'previous': previous, 'settled': settled})
+    return 0 if settled else 2
+
+
+def revert_settings(args, logger: logging.Logger) -> int:
+    """Restore the values recorded by apply_settings(). Returns the exit code."""
+    state = read_state(args.state_file)
+    if not state:
+        logger.error(f"Nothing to revert: no state file {args.state_file}")
+        return 1
+    target = state['target']
+    print_settings(state['previous'], state['applied'], target, logger,
+                   title=f"REVERTING TUNING PROFILE: {state['profile']}")
+    if is_dry_run():
+        plan = DryRunPlan('tune', logger)
+        plan.action('patch', f"{target['kind']}/{target['namespace']}/{target['name']}",
+                    json.dumps(merge_patch(state['previous'], target['kind'])))
+        plan.report()
+        return 0
+
+    if not patch_target(target, state['previous'], logger):
+        return 1
+    os.remove(args.state_file)
+    logger.info(f"Tuning profile '{state['profile']}' reverted")
+    settled = wait_settled(target, args.timeout, logger)
+    emit('tune', {'action': 'revert', 'profile': state['profile'], 'target': target['kind'],
+                  'restored': state['previous'], 'settled': settled})
+    return 0 if settled else 2
+
+
+def show_settings(args, logger: logging.Logger) -> int:
+    """Log every tunable's current value, and the applied profile if any."""
+    target = find_target(logger)
+    if target is None:
+        logger.error("No HyperConverged or KubeVirt CR found")
+        return 1
+    current = {name: get_path(target['object'], tunable[target['kind']]) if tunable[target['kind']] else None
+               for name, tunable in TUNABLES.items()}
+    state = read_state(args.state_file)
+    logger.info("\n" + "=" * 100)
+    logger.info(f"KUBEVIRT TUNING ({target['kind']}/{target['name']} in {target['namespace']})")
+    logger.info("=" * 100)
+    logger.info(f"{'Setting':<36} {'Current':<20} Description")
+    logger.info("-" * 100)
+    for name, tunable in TUNABLES.items():
+        value = '(not tunable)' if not tunable[target['kind']] else fmt(current[name])
+        logger.info(f"{name:<36} {value:<20} {tunable['description']}")
+    logger.info("-" * 100)
+    if state:
+        logger.info(f"Applied profile: {state['profile']} (at {state['applied_at']}; revert with `virtbench tune revert`)")
+    else:
+        logger.info("Applied profile: none")
+    logger.info("=" * 100)
+    emit('tune', {'action': 'show', 'target': target['kind'], 'current': current,
+                  'applied_profile': state['profile'] if state else None})
+    return 0
+
+
+def fmt(value) -> str:
+    if value is None:
+        return '(default)'
+    return ','.join(value) if isinstance(value, list) else str(value)
+
+
+def print_settings(new: Dict, old: Dict, target: Dict, logger: logging.Logger, title: str):
+    logger.info("\n" + "=" * 80)
+    logger.info(title)
+    logger.info("=" * 80)
+    logger.info(f"Target: {target['kind']}/{target['name']} in {target['namespace']}")
+    logger.info(f"{'Setting':<36} {'From':>20} {'To':>20}")
+    logger.info("-" * 80)
+    for name in new:
+        logger.info(f"{name:<36} {fmt(old.get(name)):>20} {fmt(new[name]):>20}")
+    logger.info("=" * 80)
+
+
+def settings_from_args(args) -> Tuple[Optional[Dict], Optional[str]]:
+    """The profile to apply from --apply and --set (--set overrides the profile). Returns (profile, error)."""
+    profile = {'name': 'custom', 'description': None, 'settings': {}}
+    try:
+        if args.apply:
+            profile = load_profile(args.apply)
+        for item in args.set or []:
+            name, sep, value = item.partition('=')
+            if not sep:
+                return None, f"--set takes NAME=VALUE, got '{item}'"
+            profile['settings'][name.strip()] = parse_setting(name.strip(), value.strip())
+    except (OSError, yaml.YAMLError, ValueError) as e:
+        return None, str(e)
+    return profile, None
+
+
+def parse_args():
+    """Parse command line arguments"""
+    parser = argparse.ArgumentParser(
+        description='Apply and revert KubeVirt tuning profiles',
+        formatter_class=argparse.RawDescriptionHelpFormatter,
+        epilog="""
+Examples:
+  # Current values of the tunable settings
+  %(prog)s --show
+
+  # Apply a profile, overriding one of its settings
+  %(prog)s --apply examples/tuning/migration-throughput.yaml --set parallelMigrationsPerCluster=10
+
+  # Restore the values from before the apply
+  %(prog)s --revert
+        """
+    )
+    action = parser.add_mutually_exclusive_group()
+    action.add_argument('--show', action='store_true', help='Show the current values (default)')
+    action.add_argument('--apply', metavar='PROFILE', help='Apply a tuning profile (YAML)')
+    action.add_argument('--revert', action='store_true', help='Restore the values from before the last apply')
+    parser.add_argument('--set', nargs='+', metavar='NAME=VALUE',
+                        help='Apply settings (with --apply, they override the profile)')
+    parser.add_argument('--state-file', default=DEFAULT_STATE_FILE,
+                        help=f'Where apply records the previous values (default: {DEFAULT_STATE_FILE})')
+    parser.add_argument('--timeout', type=int, default=DEFAULT_TIMEOUT,
+                        help=f'Seconds to wait for the change to roll out (default: {DEFAULT_TIMEOUT})')
+    parser.add_argument(
+        '--log-level',
+        type=str,
+        default='INFO',
+        choices=['DEBUG', 'INFO', 'WARNING', 'ERROR'],
+        help='Logging level (default: INFO)'
+    )
+    return parser.parse_args()
+
+
+def main():
+    """Main execution function"""
+    args = parse_args()
+    logger = setup_logging(log_file=None, log_level=args.log_level)
+
+    if args.revert:
+        sys.exit(revert_settings(args, logger))
+    if args.apply or args.set:
+        profile, error = settings_from_args(args)
+        if error:
+            logger.error(error)
+            sys.exit(1)
+        sys.exit(apply_settings(profile, args, logger))
+    sys.exit(show_settings(args, logger))
+
+
+if __name__ == '__main__':
+    main()
@@ -0,0 +1,254 @@
+#!/usr/bin/env python3
+"""
+CPU pinning, hugepages and NUMA tuning of benchmark VMs.
+
+Performance-tuned VMs trade density for predictable latency:
+
+- dedicated CPUs (domain.cpu.dedicatedCpuPlacement) pin every vCPU to a
+  host core of its own; the virt-launcher pod gets Guaranteed QoS and only
+  schedules on nodes running the CPU manager's static policy (KubeVirt
+  labels them cpumanager=true)
+- hugepages (domain.memory.hugepages.pageSize, 2Mi or 1Gi) back the guest
+  memory with preallocated hugepages of the node
+- NUMA passthrough (domain.cpu.numa.guestMappingPassthrough) mirrors the
+  host NUMA topology of the pinned CPUs in the guest; it needs both of the
+  above
+
+The workloads write a tuned copy of the VM template (write_tuned_template())
+and record the tuning in their summary. `virtbench datasource-clone` and
+`virtbench migration` with --compare-tuning run the workload once without
+and once with the tuning; this script then compares the two runs.
+
+Exit codes:
+    0: comparison written
+    1: a run's summary was not found
+
+Usage:
+    python3 tuning.py --baseline results/tuning-comparison/20250101-120000/baseline \\
+        --tuned results/tuning-comparison/20250101-120000/tuned
+"""
+
+import argparse
+import atexit
+import glob
+import json
+import logging
+import os
+import sys
+import tempfile
+from typing import Dict, List, Optional
+
+import yaml
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
+
+from utils.common import setup_logging, run_kubectl_command
+from utils.output import emit
+
+HUGEPAGE_SIZES = ['2Mi', '1Gi']
+
+# Label KubeVirt sets on nodes whose kubelet runs the static CPU manager policy
+CPU_MANAGER_LABEL = 'cpumanager'
+
+
+def tuning_settings(dedicated_cpus: bool = False, hugepages: Optional[str] = None,
+                    numa: bool = False) -> Optional[Dict]:
+    """Tuning block of the workload flags, recorded in result summaries; None when untuned."""
+    if not (dedicated_cpus or hugepages or numa):
+        return None
+    return {
+        'dedicated_cpu_placement': bool(dedicated_cpus),
+        'hugepages': hugepages,
+        'numa_passthrough': bool(numa),
+    }
+
+
+def apply_tuning(template_spec: Dict, tuning: Dict):
+    """Apply a tuning_settings() block to a VM's spec.template.spec."""
+    domain = template_spec.setdefault('domain', {})
+    if tuning['dedicated_cpu_placement']:
+        domain.setdefault('cpu', {})['dedicatedCpuPlacement'] = True
+        # Guaranteed QoS: KubeVirt rejects dedicated CPUs whose CPU request differs from the limit
+        resources = domain.get('resources') or {}
+        requests = resources.get('requests') or {}
+        if requests:
+            limits = resources.setdefault('limits', {})
+            for key in ('cpu', 'memory'):
+                if key in requests:
+                    limits[key] = requests[key]
+    if tuning['hugepages']:
+        domain.setdefault('memory', {})['hugepages'] = {'pageSize': tuning['hugepages']}
+    if tuning['numa_passthrough']:
+        domain.setdefault('cpu', {})['numa'] = {'guestMappingPassthrough': {}}
+
+
+def write_tuned_template(vm_template: str, tuning: Dict) -> str:
+    """
+    Write a copy of the VM template with the tuning applied; removed on exit.
+
+    Returns:
+        Path of the tuned template
+
+    Raises:
+        OSError, yaml.YAMLError: If the template cannot be read
+    """
+    with open(vm_template, 'r') as f:
+        docs = [doc for doc in yaml.safe_load_all(f) if doc]
+    for doc in docs:
+        if doc.get('kind') == 'VirtualMachine':
+            apply_tuning(doc.setdefault('spec', {}).setdefault('template', {}).setdefault('spec', {}), tuning)
+
+    fd, path = tempfile.mkstemp(prefix='virtbench-tuned-', suffix='.yaml')
+    with os.fdopen(fd, 'w') as f:
+        yaml.safe_dump_all(docs, f, sort_keys=False)
+    atexit.register(lambda: os.path.exists(path) and os.remove(path))
+    return path
+
+
+def cpu_manager_nodes(logger: logging.Logger) -> Optional[List[str]]:
+    """Nodes that can run VMs with dedicated CPUs, None if nodes cannot be listed."""
+    returncode, stdout, _ = run_kubectl_command(
+        ['get', 'nodes', '-l', f'{CPU_MANAGER_LABEL}=true', '-o', 'jsonpath={.items[*].metadata.name}'],
+        check=False, logger=logger
+    )
+    if returncode != 0:
+        return None
+    return stdout.split()
+
+
+def load_summary(path: str) -> Optional[Dict]:
+    """Newest workload summary (summary_*.json) under a results folder, or the summary file itself."""
+    if os.path.isfile(path):
+        candidates = [path]
+    else:
+        candidates = sorted(glob.glob(os.path.join(path, '**', 'summary_*.json'), recursive=True),
+                            key=os.path.getmtime)
+    for candidate in reversed(candidates):
+        try:
+            with open(candidate) as f:
+                summary = json.load(f)
+        except (OSError, ValueError):
+            continue
+        if isinstance(summary, dict) and 'metrics' in summary:
+            return summary
+    return None
+
+
+def compare_runs(baseline: Dict, tuned: Dict) -> Dict:
+    """
+    Tuning overhead: tuned against baseline averages of every metric both runs have.
+
+    overhead_pct is positive when the tuned run was slower. Density is the
+    VMs that fit per the capacity preflight, when both runs recorded it.
+    """
+    def overhead(base, value):
+        return round((value - base) / base * 100, 1) if base and value is not None else None
+
+    theirs = {m['metric']: m.get('avg') for m in baseline.get('metrics', []) if 'metric' in m}
+    metrics = []
+    for metric in tuned.get('metrics', []):
+        name = metric.get('metric')
+        if name not in theirs or theirs[name] is None or metric.get('avg') is None:
+            continue
+        metrics.append({
+            'metric': name,
+            'baseline_avg': theirs[name],
+            'tuned_avg': metric['avg'],
+            'overhead_pct': overhead(theirs[name], metric['avg']),
+        })
+
+    base_fit = (baseline.get('capacity') or {}).get('vms_that_fit')
+    tuned_fit = (tuned.get('capacity') or {}).get('vms_that_fit')
+    return {
+        'tuning': tuned.get('tuning'),
+        'baseline': {key: baseline.get(key) for key in ('total_vms', 'successful', 'failed')},
+        'tuned': {key: tuned.get(key) for key in ('total_vms', 'successful', 'failed')},
+        'metrics': metrics,
+        'density': {
+            'baseline_vms_that_fit': base_fit,
+            'tuned_vms_that_fit': tuned_fit,
+            'density_loss_pct': -overhead(base_fit, tuned_fit) if base_fit and tuned_fit is not None else None,
+        },
+    }
+
+
+def print_comparison(comparison: Dict, logger: logging.Logger):
+    """Log a compare_runs() result."""
+    def fmt(value):
+        return '-' if value is None else value
+
+    logger.info("\n" + "=" * 80)
+    logger.info(f"TUNING COMPARISON: {json.dumps(comparison['tuning'])}")
+    logger.info("=" * 80)
+    logger.info(f"Successful VMs: baseline {fmt(comparison['baseline']['successful'])}/"
+                f"{fmt(comparison['baseline']['total_vms'])}, tuned {fmt(comparison['tuned']['successful'])}/"
+                f"{fmt(comparison['tuned']['total_vms'])}")
+    logger.info(f"{'Metric':<32} {'Baseline avg':>14} {'Tuned avg':>14} {'Overhead':>10}")
+    logger.info("-" * 80)
+    for metric in comparison['metrics']:
+        pct = metric['overhead_pct']
+        logger.info(f"{metric['metric']:<32} {metric['baseline_avg']:>14} {metric['tuned_avg']:>14} "
+                    f"{(f'{pct:+.1f}%' if pct is not None else '-'):>10}")
+    density = comparison['density']
+    if density['density_loss_pct'] is not None:
+        logger.info("-" * 80)
+        logger.info(f"VMs that fit: baseline {density['baseline_vms_that_fit']}, "
+                    f"tuned {density['tuned_vms_that_fit']} ({density['density_loss_pct']:.1f}% fewer)")
+    logger.info("=" * 80)
+
+
+def parse_args():
+    """Parse command line arguments"""
+    parser = argparse.ArgumentParser(
+        description='Compare a workload run with and without CPU pinning, hugepages and NUMA tuning',
+        formatter_class=argparse.RawDescriptionHelpFormatter,
+        epilog="""
+Examples:
+  # Compare the two runs of virtbench datasource-clone --compare-tuning
+  %(prog)s --baseline results/tuning-comparison/20250101-120000/baseline \\
+      --tuned results/tuning-comparison/20250101-120000/tuned
+        """
+    )
+    parser.add_argument('--baseline', required=True, help='Results folder (or summary JSON) of the untuned run')
+    parser.add_argument('--tuned', required=True, help='Results folder (or summary JSON) of the tuned run')
+    parser.add_argument('--output', help='Write the comparison as JSON to this file')
+    parser.add_argument(
+        '--log-level',
+        type=str,
+        default='INFO',
+        choices=['DEBUG', 'INFO', 'WARNING', 'ERROR'],
+        help='Logging level (default: INFO)'
+    )
+    return parser.parse_args()
+
+
+def main():
+    """Main execution function"""
+    args = parse_args()
+    logger = setup_logging(log_file=None, log_level=args.log_level)
+
+    summaries = {}
+    for name in ('baseline', 'tuned'):
+        summaries[name] = load_summary(getattr(args, name))
+        if summaries[name] is None:
+            logger.error(f"No results summary found in {getattr(args, name)}")
+            sys.exit(1)
+
+    comparison = compare_runs(summaries['baseline'], summaries['tuned'])
+    comparison['baseline_results'] = args.baseline
+    comparison['tuned_results'] = args.tuned
+    print_comparison(comparison, logger)
+
+    if args.output:
+        output_dir = os.path.dirname(args.output)
+        if output_dir:
+            os.makedirs(output_dir, exist_ok=True)
+        with open(args.output, 'w') as f:
+            json.dump(comparison, f, indent=2)
+        logger.info(f"Tuning comparison written to {args.output}")
+    emit('tuning-comparison', comparison)
+
+
+if __name__ == '__main__':
+    main()
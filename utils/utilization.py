@@ -0,0 +1,459 @@
+#!/usr/bin/env python3
+"""
+Node resource utilization sampling for KubeVirt performance testing.
+
+Slow VM creation or migration is often a saturated node rather than a slow
+storage or network path. After argument parsing a workload calls
+sample_nodes(), which samples the CPU and memory usage of every node in the
+background every ``--node-sampling-interval`` seconds (virtbench global
+option, VIRTBENCH_NODE_SAMPLING_INTERVAL; default 15, 0 turns it off):
+
+- from the kubelet summary API (``/api/v1/nodes/<node>/proxy/stats/summary``),
+  which also reports pressure stall information (PSI) on kubelets that
+  expose it, or
+- from metrics-server (``metrics.k8s.io``) when the summary API cannot be
+  read.
+
+The same samples add up the CPU and memory of the virt-launcher,
+virt-handler and virt-controller pods (VIRT_COMPONENTS), which is the
+infrastructure overhead of running the VMs.
+
+When results are saved, collect_node_samples() writes every sample to
+``node_utilization.csv`` and ``virt_overhead.csv`` in the results folder and
+returns the summary embedded in the result summary under
+``node_utilization``: average and peak CPU and memory use per node (as a
+percentage of allocatable) and PSI, the nodes that saturated, and under
+``virt_overhead`` the start, peak and end usage of each virt component and
+the overhead per VM. storage_usage() records the provisioned and consumed
+storage of a run's PVCs (``storage_usage``). Sampling uses its own kubectl calls, outside the
+workload's --kube-api-qps budget, and never fails the run. Dry runs sample
+nothing.
+"""
+
+import atexit
+import csv
+import json
+import logging
+import os
+import subprocess
+import threading
+import time
+from concurrent.futures import ThreadPoolExecutor
+from datetime import datetime, timezone
+from typing import Dict, List, Optional, Tuple
+
+from utils.base import is_dry_run, parse_quantity_bytes, run_kubectl_command
+
+SAMPLING_INTERVAL_ENV = 'VIRTBENCH_NODE_SAMPLING_INTERVAL'
+DEFAULT_SAMPLING_INTERVAL = 15.0
+UTILIZATION_FILE = 'node_utilization.csv'
+OVERHEAD_FILE = 'virt_overhead.csv'
+
+# Pods of the virt components, by name prefix (virt-launcher pods run one VM each)
+VIRT_COMPONENTS = ('virt-launcher', 'virt-handler', 'virt-controller')
+
+# A node whose peak CPU or memory use reaches this share of allocatable counts as saturated
+SATURATION_PCT = 90.0
+
+KUBECTL_TIMEOUT = 20
+
+# PSI "some"/"full" avg10 values of the summary API, as sample columns
+PSI_FIELDS = {
+    'cpu_psi_some': ('cpu', 'some'),
+    'memory_psi_some': ('memory', 'some'),
+    'memory_psi_full': ('memory', 'full'),
+    'io_psi_some': ('io', 'some'),
+    'io_psi_full': ('io', 'full'),
+}
+
+SAMPLE_FIELDS = ['time', 'elapsed_sec', 'node', 'cpu_cores', 'cpu_pct', 'memory_bytes', 'memory_pct'] + list(PSI_FIELDS)
+COMPONENT_FIELDS = ['time', 'elapsed_sec', 'component', 'pods', 'cpu_cores', 'memory_bytes']
+
+_sampler = {'thread': None, 'stop': threading.Event(), 'started': None, 'interval': None, 'source': None,
+            'samples': [], 'components': [], 'lock': threading.Lock()}
+
+
+def sampling_interval() -> float:
+    """Seconds between node samples (`virtbench --node-sampling-interval`); 0 turns sampling off."""
+    try:
+        return max(0.0, float(os.environ.get(SAMPLING_INTERVAL_ENV, DEFAULT_SAMPLING_INTERVAL)))
+    except ValueError:
+        return DEFAULT_SAMPLING_INTERVAL
+
+
+def cpu_cores(quantity) -> Optional[float]:
+    """Convert a Kubernetes CPU quantity (e.g. "2", "500m", "123456789n") to cores."""
+    quantity = str(quantity or '').strip()
+    scale = {'n': 1e-9, 'u': 1e-6, 'm': 1e-3}.get(quantity[-1:], 1)
+    try:
+        return float(quantity[:-1] if scale != 1 else quantity) * scale
+    except ValueError:
+        return None
+
+
+def _kubectl_json(args: List[str]) -> Optional[Dict]:
+    try:
+        returncode, stdout, _ = run_kubectl_command(args, check=False, timeout=KUBECTL_TIMEOUT)
+    except (OSError, subprocess.SubprocessError):
+        return None
+    if returncode != 0:
+        return None
+    try:
+        return json.loads(stdout)
+    except json.JSONDecodeError:
+        return None
+
+
+def node_allocatable() -> Dict[str, Dict]:
+    """Allocatable CPU cores and memory bytes of every node."""
+    nodes = _kubectl_json(['get', 'nodes', '-o', 'json']) or {}
+    return {node['metadata']['name']: {
+        'cpu': cpu_cores((node.get('status') or {}).get('allocatable', {}).get('cpu')),
+        'memory': parse_quantity_bytes((node.get('status') or {}).get('allocatable', {}).get('memory')),
+    } for node in nodes.get('items') or []}
+
+
+def virt_component(pod_name: str) -> Optional[str]:
+    return next((c for c in VIRT_COMPONENTS if pod_name.startswith(c + '-')), None)
+
+
+def _add_component(components: Dict[str, Dict], pod_name: str, cpu: Optional[float], memory: Optional[int]):
+    component = virt_component(pod_name)
+    if component is None:
+        return
+    totals = components.setdefault(component, {'pods': 0, 'cpu_cores': 0.0, 'memory_bytes': 0})
+    totals['pods'] += 1
+    totals['cpu_cores'] += cpu or 0
+    totals['memory_bytes'] += memory or 0
+
+
+def _psi(stats: Dict, resource: str, kind: str) -> Optional[float]:
+    return (((stats.get(resource) or {}).get('psi') or {}).get(kind) or {}).get('avg10')
+
+
+def summary_usage(node: str) -> Optional[Dict]:
+    """CPU, memory and PSI of a node, and the totals of its virt component pods, from the kubelet summary API."""
+    summary = _kubectl_json(['get', '--raw', f'/api/v1/nodes/{node}/proxy/stats/summary'])
+    stats = (summary or {}).get('node')
+    if not stats:
+        return None
+    usage = {
+        'cpu_cores': ((stats.get('cpu') or {}).get('usageNanoCores') or 0) / 1e9,
+        'memory_bytes': (stats.get('memory') or {}).get('workingSetBytes'),
+        'components': {},
+    }
+    for field, (resource, kind) in PSI_FIELDS.items():
+        usage[field] = _psi(stats, resource, kind)
+    for pod in summary.get('pods') or []:
+        _add_component(usage['components'], (pod.get('podRef') or {}).get('name', ''),
+                       ((pod.get('cpu') or {}).get('usageNanoCores') or 0) / 1e9,
+                       (pod.get('memory') or {}).get('workingSetBytes'))
+    return usage
+
+
+def metrics_server_usage() -> Optional[Dict[str, Dict]]:
+    """CPU and memory of every node from metrics-server."""
+    metrics = _kubectl_json(['get', '--raw', '/apis/metrics.k8s.io/v1beta1/nodes'])
+    if metrics is None:
+        return None
+    return {item['metadata']['name']: {
+        'cpu_cores': cpu_cores((item.get('usage') or {}).get('cpu')),
+        'memory_bytes': parse_quantity_bytes((item.get('usage') or {}).get('memory')),
+    } for item in metrics.get('items') or []}
+
+
+def metrics_server_components() -> Dict[str, Dict]:
+    """Totals of the virt component pods from metrics-server."""
+    metrics = _kubectl_json(['get', '--raw', '/apis/metrics.k8s.io/v1beta1/pods?labelSelector=kubevirt.io']) or {}
+    components: Dict[str, Dict] = {}
+    for item in metrics.get('items') or []:
+        containers = item.get('containers') or []
+        _add_component(components, item['metadata']['name'],
+                       sum(cpu_cores((c.get('usage') or {}).get('cpu')) or 0 for c in containers),
+                       sum(parse_quantity_bytes((c.get('usage') or {}).get('memory')) or 0 for c in containers))
+    return components
+
+
+def storage_usage(namespaces: List[str], logger: Optional[logging.Logger] = None) -> Optional[Dict]:
+    """
+    Provisioned and consumed storage of the PVCs in the given namespaces.
+
+    Provisioned is the bound capacity of every PVC. Consumed comes from the
+    kubelet summary API of the nodes running the namespaces' pods, which only
+    reports filesystem volumes: block-mode PVCs are counted as provisioned but
+    not measured, and overhead_ratio (provisioned / consumed) covers the
+    measured PVCs only.
+
+    Returns:
+        {'pvcs', 'provisioned_bytes', 'measured_pvcs', 'measured_provisioned_bytes',
+         'consumed_bytes', 'overhead_ratio'}, or None when no PVC can be listed
+    """
+    wanted = set(namespaces)
+    pvcs = _kubectl_json(['get', 'pvc', '--all-namespaces', '-o', 'json'])
+    if pvcs is None:
+        if logger:
+            logger.warning("Storage usage is not recorded: PVCs cannot be listed")
+        return None
+    provisioned = {}
+    for pvc in pvcs.get('items') or []:
+        meta = pvc.get('metadata') or {}
+        if meta.get('namespace') not in wanted:
+            continue
+        size = ((pvc.get('status') or {}).get('capacity') or {}).get('storage') \
+            or ((pvc.get('spec') or {}).get('resources') or {}).get('requests', {}).get('storage')
+        provisioned[(meta['namespace'], meta.get('name'))] = parse_quantity_bytes(size) or 0
+    if not provisioned:
+        return None
+
+    pods = _kubectl_json(['get', 'pods', '--all-namespaces', '-o', 'json']) or {}
+    nodes = sorted({(pod.get('spec') or {}).get('nodeName') for pod in pods.get('items') or []
+                    if (pod.get('metadata') or {}).get('namespace') in wanted} - {None})
+    consumed = {}
+    for node in nodes:
+        summary = _kubectl_json(['get', '--raw', f'/api/v1/nodes/{node}/proxy/stats/summary']) or {}
+        for pod in summary.get('pods') or []:
+            for volume in pod.get('volume') or []:
+                ref = volume.get('pvcRef') or {}
+                key = (ref.get('namespace'), ref.get('name'))
+                if key in provisioned and volume.get('usedBytes') is not None:
+                    consumed[key] = max(consumed.get(key, 0), volume['usedBytes'])
+    measured_provisioned = sum(provisioned[key] for key in consumed)
+    consumed_bytes = sum(consumed.values())
+    return {
+        'pvcs': len(provisioned),
+        'provisioned_bytes': sum(provisioned.values()),
+        'measured_pvcs': len(consumed),
+        'measured_provisioned_bytes': measured_provisioned,
+        'consumed_bytes': consumed_bytes if consumed else None,
+        'overhead_ratio': round(measured_provisioned / consumed_bytes, 2) if consumed_bytes else None,
+    }
+
+
+def take_sample(allocatable: Dict[str, Dict], source: str, executor: ThreadPoolExecutor) -> Tuple[List[Dict], List[Dict]]:
+    """One sample row per node, and one per virt component."""
+    components: Dict[str, Dict] = {}
+    if source == 'kubelet':
+        nodes = list(allocatable)
+        usage = {node: result for node, result in zip(nodes, executor.map(summary_usage, nodes)) if result}
+        for values in usage.values():
+            for component, totals in values.pop('components').items():
+                for key, value in totals.items():
+                    components.setdefault(component, {'pods': 0, 'cpu_cores': 0.0, 'memory_bytes': 0})[key] += value
+    else:
+        usage = metrics_server_usage() or {}
+        components = metrics_server_components()
+    now = datetime.now(timezone.utc).isoformat(timespec='seconds')
+    elapsed = round(time.monotonic() - _sampler['started'], 1)
+    component_rows = [{'time': now, 'elapsed_sec': elapsed, 'component': component,
+                       'pods': (components.get(component) or {}).get('pods', 0),
+                       'cpu_cores': round((components.get(component) or {}).get('cpu_cores', 0), 3),
+                       'memory_bytes': (components.get(component) or {}).get('memory_bytes', 0)}
+                      for component in VIRT_COMPONENTS]
+    rows = []
+    for node, values in sorted(usage.items()):
+        capacity = allocatable.get(node) or {}
+        row = {field: None for field in SAMPLE_FIELDS}
+        row.update(values, time=now, elapsed_sec=elapsed, node=node)
+        if row['cpu_cores'] is not None:
+            row['cpu_cores'] = round(row['cpu_cores'], 3)
+            if capacity.get('cpu'):
+                row['cpu_pct'] = round(100 * row['cpu_cores'] / capacity['cpu'], 1)
+        if row['memory_bytes'] is not None and capacity.get('memory'):
+            row['memory_pct'] = round(100 * row['memory_bytes'] / capacity['memory'], 1)
+        rows.append(row)
+    return rows, component_rows
+
+
+def _sample_loop(interval: float, logger: Optional[logging.Logger]):
+    allocatable = node_allocatable()
+    if not allocatable:
+        if logger:
+            logger.warning("Node utilization sampling is off: nodes cannot be listed")
+        return
+    # The summary API adds PSI; metrics-server is the fallback
+    source = 'kubelet' if summary_usage(next(iter(allocatable))) else 'metrics-server'
+    if source == 'metrics-server' and metrics_server_usage() is None:
+        if logger:
+            logger.warning("Node utilization sampling is off: neither the kubelet summary API nor "
+                           "metrics-server can be read")
+        return
+    _sampler['source'] = source
+    if logger:
+        logger.debug(f"Sampling {len(allocatable)} nodes every {interval:g}s from {source}")
+    with ThreadPoolExecutor(max_workers=min(10, len(allocatable))) as executor:
+        while not _sampler['stop'].is_set():
+            rows, component_rows = take_sample(allocatable, source, executor)
+            with _sampler['lock']:
+                _sampler['samples'].extend(rows)
+                _sampler['components'].extend(component_rows)
+            _sampler['stop'].wait(interval)
+
+
+def sample_nodes(logger: Optional[logging.Logger] = None):
+    """
+    Start sampling node utilization in the background; stopped at exit.
+
+    Call once, right after watch_run(). Does nothing in dry runs or when
+    sampling is turned off.
+    """
+    interval = sampling_interval()
+    if is_dry_run() or not interval or _sampler['thread'] is not None:
+        return
+    _sampler.update(started=time.monotonic(), interval=interval)
+    _sampler['thread'] = threading.Thread(target=_sample_loop, args=(interval, logger), daemon=True)
+    _sampler['thread'].start()
+    atexit.register(_sampler['stop'].set)
+
+
+def _stats(values: List[Optional[float]]) -> Optional[Dict]:
+    values = [v for v in values if v is not None]
+    if not values:
+        return None
+    return {'avg': round(sum(values) / len(values), 2), 'max': round(max(values), 2)}
+
+
+def summarize_samples(rows: List[Dict]) -> Dict:
+    """
+    Per-node summary of sample rows.
+
+    Returns:
+        {'nodes': {node: {'samples', 'cpu_pct', 'memory_pct', <PSI fields>: {'avg', 'max'}, 'peak_cpu_at_sec'}},
+         'saturated': [nodes whose peak CPU or memory use reached SATURATION_PCT]}
+    """
+    by_node: Dict[str, List[Dict]] = {}
+    for row in rows:
+        by_node.setdefault(row['node'], []).append(row)
+    nodes = {}
+    for node, node_rows in sorted(by_node.items()):
+        entry = {'samples': len(node_rows)}
+        for field in ['cpu_pct', 'memory_pct'] + list(PSI_FIELDS):
+            stats = _stats([row[field] for row in node_rows])
+            if stats is not None:
+                entry[field] = stats
+        busiest = max(node_rows, key=lambda row: row['cpu_pct'] or 0)
+        entry['peak_cpu_at_sec'] = busiest['elapsed_sec'] if busiest['cpu_pct'] is not None else None
+        nodes[node] = entry
+    saturated = [node for node, entry in nodes.items()
+                 if any((entry.get(field) or {}).get('max', 0) >= SATURATION_PCT for field in ('cpu_pct', 'memory_pct'))]
+    return {'nodes': nodes, 'saturated': saturated}
+
+
+def summarize_components(rows: List[Dict]) -> Optional[Dict]:
+    """
+    Virt component overhead of component sample rows.
+
+    Returns:
+        {'components': {component: {'pods', 'cpu_cores', 'memory_bytes': {'start', 'peak', 'end'}}},
+         'per_vm': {...}, 'file'}, or None when no virt component pod was seen. per_vm is
+        taken at the sample with the most virt-launcher pods: the launcher usage per VM
+        (QEMU and the guest memory it touched) and the virt-handler plus virt-controller
+        usage divided by the VMs
+    """
+    by_time: Dict[float, Dict[str, Dict]] = {}
+    for row in rows:
+        by_time.setdefault(row['elapsed_sec'], {})[row['component']] = row
+    if not any(row['pods'] for row in rows):
+        return None
+    samples = [by_time[elapsed] for elapsed in sorted(by_time)]
+    components = {}
+    for component in VIRT_COMPONENTS:
+        series = [sample[component] for sample in samples if component in sample]
+        components[component] = {field: {'start': series[0][field], 'peak': max(row[field] for row in series),
+                                         'end': series[-1][field]}
+                                 for field in ('pods', 'cpu_cores', 'memory_bytes')}
+
+    def usage(sample, component, field):
+        return (sample.get(component) or {}).get(field) or 0
+
+    busiest = max(samples, key=lambda sample: usage(sample, 'virt-launcher', 'pods'))
+    vms = usage(busiest, 'virt-launcher', 'pods')
+    per_vm = None
+    if vms:
+        per_vm = {
+            'vms': vms,
+            'at_sec': next(iter(busiest.values()))['elapsed_sec'],
+            'virt_launcher_cpu_cores': round(usage(busiest, 'virt-launcher', 'cpu_cores') / vms, 4),
+            'virt_launcher_memory_bytes': int(usage(busiest, 'virt-launcher', 'memory_bytes') / vms),
+            'control_plane_cpu_cores': round(sum(usage(busiest, c, 'cpu_cores')
+                                                 for c in ('virt-handler', 'virt-controller')) / vms, 4),
+            'control_plane_memory_bytes': int(sum(usage(busiest, c, 'memory_bytes')
+                                                  for c in ('virt-handler', 'virt-controller')) / vms),
+        }
+    return {'components': components, 'per_vm': per_vm, 'file': OVERHEAD_FILE}
+
+
+def _write_csv(path: str, fields: List[str], rows: List[Dict], logger: Optional[logging.Logger], what: str):
+    try:
+        with open(path, 'w', newline='') as f:
+            writer = csv.DictWriter(f, fieldnames=fields)
+            writer.writeheader()
+            writer.writerows(rows)
+    except OSError as e:
+        if logger:
+            logger.warning(f"Could not save {what}: {e}")
+
+
+def collect_node_samples(out_dir: Optional[str], logger: Optional[logging.Logger] = None) -> Optional[Dict]:
+    """
+    Save the samples taken so far to node_utilization.csv and virt_overhead.csv in
+    out_dir and summarize them.
+
+    Returns:
+        {'interval_sec', 'source', 'samples', 'nodes', 'saturated', 'virt_overhead', 'file'},
+        or None when nothing was sampled; virt_overhead is a summarize_components() result
+    """
+    with _sampler['lock']:
+        rows = list(_sampler['samples'])
+        component_rows = list(_sampler['components'])
+    if not rows:
+        return None
+    overhead = summarize_components(component_rows)
+    if out_dir:
+        _write_csv(os.path.join(out_dir, UTILIZATION_FILE), SAMPLE_FIELDS, rows, logger, 'node utilization samples')
+        if overhead:
+            _write_csv(os.path.join(out_dir, OVERHEAD_FILE), COMPONENT_FIELDS, component_rows, logger,
+                       'virt component samples')
+    summary = {'interval_sec': _sampler['interval'], 'source': _sampler['source'],
+               'samples': len(rows), **summarize_samples(rows), 'virt_overhead': overhead, 'file': UTILIZATION_FILE}
+    if logger:
+        print_node_utilization(summary, logger)
+        if overhead:
+            print_virt_overhead(overhead, logger)
+    return summary
+
+
+def print_node_utilization(summary: Dict, logger: logging.Logger):
+    """Log the peak utilization of the busiest nodes of a collect_node_samples() result."""
+    def peak(entry, field):
+        value = (entry.get(field) or {}).get('max')
+        return '-' if value is None else f"{value:g}"
+
+    busiest = sorted(summary['nodes'].items(), key=lambda item: -((item[1].get('cpu_pct') or {}).get('max') or 0))
+    logger.info(f"Node utilization ({summary['samples']} samples every {summary['interval_sec']:g}s "
+                f"from {summary['source']}), peak values:")
+    logger.info(f"  {'Node':<32} {'CPU %':>7} {'Mem %':>7} {'CPU PSI':>8} {'Mem PSI':>8} {'IO PSI':>8}")
+    for node, entry in busiest[:10]:
+        logger.info(f"  {node:<32} {peak(entry, 'cpu_pct'):>7} {peak(entry, 'memory_pct'):>7} "
+                    f"{peak(entry, 'cpu_psi_some'):>8} {peak(entry, 'memory_psi_some'):>8} {peak(entry, 'io_psi_some'):>8}")
+    if summary['saturated']:
+        logger.warning(f"Saturated nodes (peak CPU or memory >= {SATURATION_PCT:g}%): {', '.join(summary['saturated'])}")
+
+
+def _mib(value) -> str:
+    return '-' if value is None else f"{value / 2**20:.0f}Mi"
+
+
+def print_virt_overhead(overhead: Dict, logger: logging.Logger):
+    """Log the virt component overhead of a summarize_components() result."""
+    logger.info("Virt component overhead (start / peak / end):")
+    logger.info(f"  {'Component':<16} {'Pods':>14} {'CPU cores':>22} {'Memory':>24}")
+    for component, entry in overhead['components'].items():
+        pods, cpu, memory = entry['pods'], entry['cpu_cores'], entry['memory_bytes']
+        logger.info(f"  {component:<16} {pods['start']:>4} /{pods['peak']:>4} /{pods['end']:>4} "
+                    f"{cpu['start']:>6.2f} /{cpu['peak']:>6.2f} /{cpu['end']:>6.2f} "
+                    f"{_mib(memory['start']):>7} /{_mib(memory['peak']):>7} /{_mib(memory['end']):>7}")
+    per_vm = overhead['per_vm']
+    if per_vm:
+        logger.info(f"  Per VM at {per_vm['vms']} VMs: virt-launcher {per_vm['virt_launcher_cpu_cores']:g} cores, "
+                    f"{_mib(per_vm['virt_launcher_memory_bytes'])}; virt-handler + virt-controller "
+                    f"{per_vm['control_plane_cpu_cores']:g} cores, {_mib(per_vm['control_plane_memory_bytes'])}")
@@ -5,15 +5,31 @@ Cluster Validation Script for KubeVirt Benchmark Suite
 This script validates that the cluster is ready for running KubeVirt benchmarks.
 It checks for required components, resources, and configurations.
 
+Every check ends as PASS, WARN (benchmarks can run, but some workloads or
+results may be affected) or FAIL. The summary is printed as a table and can
+be written as a JSON report with --report.
+
+Exit codes:
+    0: no check failed (warnings allowed unless --strict)
+    1: at least one check failed
+    2: no check failed, but some warned and --strict is set
+
 Usage:
     python3 validate_cluster.py --storage-class YOUR-STORAGE-CLASS
     python3 validate_cluster.py --all
+    python3 validate_cluster.py --scratch-disk
+    python3 validate_cluster.py --storage-class YOUR-STORAGE-CLASS --report validation.json
+    python3 validate_cluster.py --gpu-device nvidia.com/GA102GL_A10 --gpu-vms 8
+    python3 validate_cluster.py --network sriov-ns/sriov-net --network-vms 8
 """
 
 import argparse
 import sys
 import subprocess
 import json
+import statistics
+import time
+from datetime import datetime, timezone
 from typing import Tuple, Optional, Dict, List
 import logging
 
@@ -21,7 +37,108 @@ import logging
 import os
 sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
 
-from utils.common import setup_logging, run_kubectl_command
+from utils.common import (
+    setup_logging, run_kubectl_command, get_worker_nodes,
+    create_node_exec_pod, delete_node_exec_pod, DEFAULT_NODE_EXEC_IMAGE,
+)
+from utils.cluster_platform import (
+    default_storage_class, detect_platform, harvester_version, openshift_version, resolve_datasource_namespace,
+    virtualization_name,
+)
+from utils.concurrency import run_parallel
+from utils.gpu import check_gpus
+from utils.network import check_networks, parse_network, SRIOV
+from utils.output import emit
+from utils.storageprovider import HEALTH_FAILED, HEALTH_OK, provider_for
+
+# Node-local storage behind CDI scratch space on local volumes and emptyDirs (kubelet)
+# and behind containerDisk image pulls and extraction (CRI-O / containerd)
+DEFAULT_SCRATCH_PATHS = ['/var/lib/kubelet', '/var/lib/containers', '/var/lib/containerd']
+DEFAULT_SCRATCH_SIZE_MB = 256
+DEFAULT_SCRATCH_SLOW_RATIO = 0.5
+SCRATCH_PROBE_TIMEOUT = 300
+
+# Check results: PASS and FAIL are returned as True/False, warnings as WARN
+WARN = 'WARN'
+EXIT_FAILED = 1
+EXIT_WARNINGS = 2
+
+# Extended resources published by virt-handler on nodes that can run VMs
+KVM_DEVICE = 'devices.kubevirt.io/kvm'
+SCHEDULABLE_LABEL = 'kubevirt.io/schedulable'
+
+
+def build_scratch_probe_command(paths: List[str], size_mb: int) -> str:
+    """
+    Shell command measuring each host directory with dd, bypassing the page cache.
+
+    Prints one line per directory: "SCRATCH <path> <write ns> <read ns> <sync ns>",
+    "SCRATCH <path> missing" or "SCRATCH <path> error". The sync figure is the
+    time for 100 4 KiB writes with O_DSYNC.
+    """
+    return (
+        f"for d in {' '.join(paths)}; do "
+        "[ -d \"$d\" ] || { echo \"SCRATCH $d missing\"; continue; }; "
+        "f=\"$d/.virtbench-scratch-$$\"; "
+        "s=$(date +%s%N); "
+        f"dd if=/dev/zero of=\"$f\" bs=1M count={size_mb} oflag=direct conv=fsync 2>/dev/null "
+        "&& m=$(date +%s%N) && dd if=\"$f\" of=/dev/null bs=1M iflag=direct 2>/dev/null "
+        "&& r=$(date +%s%N) && dd if=/dev/zero of=\"$f\" bs=4k count=100 oflag=dsync 2>/dev/null "
+        "&& e=$(date +%s%N) && echo \"SCRATCH $d $((m-s)) $((r-m)) $((e-r))\" "
+        "|| echo \"SCRATCH $d error\"; "
+        "rm -f \"$f\"; "
+        "done"
+    )
+
+
+def measure_node_scratch(node: str, paths: List[str], size_mb: int, namespace: str,
+                         image: str, logger: logging.Logger) -> Dict[str, Dict]:
+    """
+    Run the scratch probe on a node through a privileged node exec pod.
+
+    Returns:
+        {path: {'write_mb_s', 'read_mb_s', 'sync_write_ms'}} for measured paths,
+        {path: {'error': reason}} for paths that could not be measured
+    """
+    pod_name = f"virtbench-scratch-{node}"[:63].rstrip('-.')
+    if not create_node_exec_pod(node, build_scratch_probe_command(paths, size_mb), pod_name,
+                                namespace, image, logger):
+        raise RuntimeError(f"cannot create probe pod on {node}")
+    try:
+        deadline = time.monotonic() + SCRATCH_PROBE_TIMEOUT
+        phase = ''
+        while time.monotonic() < deadline:
+            _, phase, _ = run_kubectl_command(
+                ['get', 'pod', pod_name, '-n', namespace, '-o', 'jsonpath={.status.phase}'],
+                check=False, logger=logger
+            )
+            if phase in ('Succeeded', 'Failed'):
+                break
+            time.sleep(2)
+        else:
+            raise RuntimeError(f"probe on {node} did not finish within {SCRATCH_PROBE_TIMEOUT}s")
+
+        _, logs, _ = run_kubectl_command(['logs', pod_name, '-n', namespace], check=False, logger=logger)
+    finally:
+        delete_node_exec_pod(pod_name, namespace, logger)
+
+    results = {}
+    for line in logs.splitlines():
+        parts = line.split()
+        if len(parts) < 3 or parts[0] != 'SCRATCH':
+            continue
+        path = parts[1]
+        if len(parts) == 3:
+            if parts[2] != 'missing':
+                results[path] = {'error': 'dd failed (O_DIRECT unsupported or no space?)'}
+            continue
+        write_ns, read_ns, sync_ns = (int(p) for p in parts[2:5])
+        results[path] = {
+            'write_mb_s': round(size_mb / (write_ns / 1e9), 1) if write_ns else None,
+            'read_mb_s': round(size_mb / (read_ns / 1e9), 1) if read_ns else None,
+            'sync_write_ms': round(sync_ns / 1e6 / 100, 2),
+        }
+    return results
 
 
 class ClusterValidator:
@@ -35,10 +152,15 @@ class ClusterValidator:
         self.results = []
     
     def run_check(self, check_name: str, check_func, *args, **kwargs) -> bool:
-        """Run a validation check and track results"""
+        """Run a validation check and track results. Returns False only if it failed."""
         self.logger.info(f"Checking: {check_name}...")
         try:
             result, message = check_func(*args, **kwargs)
+            if result == WARN:
+                self.logger.warning(f"  ⚠ WARN: {message}")
+                self.warnings += 1
+                self.results.append({"check": check_name, "status": "WARN", "message": message})
+                return True
             if result:
                 self.logger.info(f"  ✓ PASS: {message}")
                 self.checks_passed += 1
@@ -62,6 +184,16 @@ class ClusterValidator:
             return True, "kubectl is installed and cluster is accessible"
         return False, f"Cannot access cluster: {stderr}"
     
+    def check_platform(self) -> Tuple[bool, str]:
+        """Report whether the cluster is OpenShift, Harvester or vanilla Kubernetes (see utils/cluster_platform.py)"""
+        platform = detect_platform(self.logger)
+        if platform == 'openshift':
+            return True, f"OpenShift {openshift_version(self.logger) or '(version unknown)'}"
+        if platform == 'harvester':
+            return True, (f"Harvester {harvester_version(self.logger) or '(version unknown)'} "
+                          f"(images in the default namespace, storage class {default_storage_class(self.logger)})")
+        return True, "Kubernetes (upstream KubeVirt; DataSources default to the kubevirt-os-images namespace)"
+
     def check_kubevirt_installed(self) -> Tuple[bool, str]:
         """Verify KubeVirt/OpenShift Virtualization is installed"""
         # First, check for KubeVirt resource (OpenShift Virtualization)
@@ -81,14 +213,14 @@ class ClusterValidator:
                 phase = kubevirt.get('status', {}).get('phase', 'Unknown')
 
                 if phase == 'Deployed':
-                    # Now check critical deployments in openshift-cnv namespace
+                    # Now check critical deployments in its namespace (openshift-cnv, kubevirt)
                     return self._check_kubevirt_components(namespace)
                 else:
                     return False, f"KubeVirt '{name}' found in namespace '{namespace}' but phase is '{phase}' (expected: Deployed)"
             else:
-                return False, "No KubeVirt resource found. Is OpenShift Virtualization installed?"
+                return False, f"No KubeVirt resource found. Is {virtualization_name(self.logger)} installed?"
         else:
-            return False, "Cannot check KubeVirt resource. Is OpenShift Virtualization installed?"
+            return False, f"Cannot check KubeVirt resource. Is {virtualization_name(self.logger)} installed?"
 
     def _check_kubevirt_components(self, namespace: str) -> Tuple[bool, str]:
         """Check critical KubeVirt components are running"""
@@ -149,9 +281,173 @@ class ClusterValidator:
 
             return True, f"OpenShift Virtualization is deployed in '{namespace}' (virt-api, virt-controller, virt-operator, virt-handler ready)"
         else:
-            self.warnings += 1
-            return True, f"OpenShift Virtualization is deployed in '{namespace}' (virt-handler check skipped) - WARNING"
+            return WARN, f"OpenShift Virtualization is deployed in '{namespace}' (virt-handler check skipped)"
     
+    def check_hco_health(self) -> Tuple[bool, str]:
+        """Verify the HyperConverged resource (OpenShift Virtualization operator) is healthy"""
+        returncode, stdout, stderr = run_kubectl_command(
+            ['get', 'hyperconverged', '-A', '-o', 'json'],
+            check=False,
+            logger=self.logger
+        )
+        items = json.loads(stdout).get('items', []) if returncode == 0 else []
+        if not items:
+            return WARN, "No HyperConverged resource found (upstream KubeVirt without the HCO operator?)"
+
+        hco = items[0]
+        name = hco.get('metadata', {}).get('name', 'unknown')
+        status = hco.get('status', {})
+        conditions = {c.get('type'): c for c in status.get('conditions', [])}
+        version = next((v.get('version') for v in status.get('versions', [])
+                        if v.get('name') == 'operator'), 'unknown')
+
+        def is_true(condition_type: str) -> bool:
+            return conditions.get(condition_type, {}).get('status') == 'True'
+
+        if not is_true('Available'):
+            reason = conditions.get('Available', {}).get('message') or 'Available condition not True'
+            return False, f"HyperConverged '{name}' is not available: {reason}"
+        if is_true('Degraded'):
+            return False, f"HyperConverged '{name}' is degraded: {conditions['Degraded'].get('message', '')}"
+        if is_true('Progressing'):
+            return WARN, f"HyperConverged '{name}' (version {version}) is progressing (upgrade or reconcile in flight)"
+        if conditions.get('Upgradeable', {}).get('status') == 'False':
+            return WARN, f"HyperConverged '{name}' (version {version}) is not upgradeable: " \
+                         f"{conditions['Upgradeable'].get('message', '')}"
+        return True, f"HyperConverged '{name}' is available (version {version})"
+
+    def check_cdi_ready(self) -> Tuple[bool, str]:
+        """Verify the Containerized Data Importer is deployed (needed for DataVolumes)"""
+        returncode, stdout, stderr = run_kubectl_command(
+            ['get', 'cdi', '-o', 'json'],
+            check=False,
+            logger=self.logger
+        )
+        items = json.loads(stdout).get('items', []) if returncode == 0 else []
+        if not items:
+            return False, "No CDI resource found. DataVolumes cannot be provisioned"
+
+        cdi = items[0]
+        name = cdi.get('metadata', {}).get('name', 'unknown')
+        status = cdi.get('status', {})
+        phase = status.get('phase', 'Unknown')
+        conditions = {c.get('type'): c.get('status') for c in status.get('conditions', [])}
+        if phase != 'Deployed' or conditions.get('Available') != 'True':
+            return False, f"CDI '{name}' is not ready (phase: {phase})"
+        if conditions.get('Degraded') == 'True':
+            return WARN, f"CDI '{name}' is deployed but degraded"
+        return True, f"CDI '{name}' is deployed (version {status.get('observedVersion', 'unknown')})"
+
+    def check_node_virtualization(self) -> Tuple[bool, str]:
+        """Verify worker nodes can run VMs (schedulable for KubeVirt, with /dev/kvm)"""
+        returncode, stdout, stderr = run_kubectl_command(
+            ['get', 'nodes', '-l', 'node-role.kubernetes.io/worker', '-o', 'json'],
+            check=False,
+            logger=self.logger
+        )
+        if returncode != 0:
+            return False, "Cannot get worker nodes"
+
+        capable, incapable = [], []
+        for node in json.loads(stdout).get('items', []):
+            name = node.get('metadata', {}).get('name')
+            labels = node.get('metadata', {}).get('labels', {})
+            kvm = node.get('status', {}).get('allocatable', {}).get(KVM_DEVICE, '0')
+            if labels.get(SCHEDULABLE_LABEL) != 'true':
+                incapable.append(f"{name} (not schedulable for VMs)")
+            elif kvm in ('0', ''):
+                incapable.append(f"{name} (no {KVM_DEVICE})")
+            else:
+                capable.append(name)
+
+        if not capable:
+            return False, f"No worker node can run VMs: {', '.join(incapable) or 'no worker nodes'}"
+        if incapable:
+            return WARN, f"{len(capable)} worker nodes can run VMs; cannot: {', '.join(incapable)}"
+        return True, f"All {len(capable)} worker nodes can run VMs"
+
+    def check_gpu_devices(self, device_name: str, vms: int) -> Tuple[bool, str]:
+        """Verify GPU VMs can schedule: device permitted, advertised by a device plugin, enough of them"""
+        report = check_gpus({device_name: 1}, vms, self.logger)
+        if report['errors']:
+            return False, '; '.join(report['errors'])
+        device = report['devices'][0]
+        message = (f"{device_name} ({device['type']}): {device['allocatable']} allocatable on "
+                   f"{len(device['nodes'])} nodes, room for {report['vms_fit']} VMs")
+        if report['warnings']:
+            return WARN, f"{message}; {'; '.join(report['warnings'])}"
+        return True, message
+
+    def check_network(self, network: str, vms: int) -> Tuple[bool, str]:
+        """Verify a NetworkAttachmentDefinition exists and, for SR-IOV, has enough VFs"""
+        report = check_networks([parse_network(network)], vms, self.logger)
+        if report['errors']:
+            return False, '; '.join(report['errors'])
+        nad = report['networks'][0]
+        message = f"{nad['namespace']}/{nad['name']} ({nad['type'] or 'unknown'} CNI, {nad['binding']} binding)"
+        if nad['type'] == SRIOV:
+            message += (f": {nad['allocatable']} VFs of {nad['resource']} on {len(nad['nodes'])} nodes, "
+                        f"room for {report['vms_fit']} VMs")
+        if report['warnings']:
+            return WARN, f"{message}; {'; '.join(report['warnings'])}"
+        return True, message
+
+    def check_storage_capabilities(self, storage_class_name: str) -> Tuple[bool, str]:
+        """Check what the storage class supports: expansion, snapshots and RWX access.
+
+        Non-fatal: each capability is only needed by some workloads (volume-resize
+        and chaos expand volumes, snapshot-vms and smart clones need a
+        VolumeSnapshotClass, live migration needs ReadWriteMany).
+        """
+        returncode, stdout, stderr = run_kubectl_command(
+            ['get', 'storageclass', storage_class_name, '-o', 'json'],
+            check=False,
+            logger=self.logger
+        )
+        if returncode != 0:
+            return False, f"Storage class '{storage_class_name}' not found"
+        storage_class = json.loads(stdout)
+        provisioner = storage_class.get('provisioner')
+
+        supported, missing = [], []
+        if storage_class.get('allowVolumeExpansion'):
+            supported.append("volume expansion")
+        else:
+            missing.append("volume expansion (volume-resize, chaos)")
+
+        returncode, stdout, stderr = run_kubectl_command(
+            ['get', 'volumesnapshotclass', '-o', 'json'],
+            check=False,
+            logger=self.logger
+        )
+        snapshot_classes = [c['metadata']['name'] for c in
+                            (json.loads(stdout).get('items', []) if returncode == 0 else [])
+                            if c.get('driver') == provisioner]
+        if snapshot_classes:
+            supported.append(f"snapshots ({snapshot_classes[0]})")
+        else:
+            missing.append(f"VolumeSnapshotClass for {provisioner} (snapshot-vms, smart clones)")
+
+        # CDI's StorageProfile holds the access modes DataVolumes get for this class
+        returncode, stdout, stderr = run_kubectl_command(
+            ['get', 'storageprofile', storage_class_name, '-o', 'json'],
+            check=False,
+            logger=self.logger
+        )
+        profile = json.loads(stdout) if returncode == 0 else {}
+        property_sets = profile.get('status', {}).get('claimPropertySets') or []
+        access_modes = {mode for ps in property_sets for mode in ps.get('accessModes', [])}
+        if 'ReadWriteMany' in access_modes:
+            supported.append("ReadWriteMany")
+        elif not property_sets:
+            missing.append("access modes unknown (StorageProfile has no claimPropertySets)")
+        else:
+            missing.append("ReadWriteMany access (live migration)")
+
+        if missing:
+            return WARN, f"Storage class '{storage_class_name}' lacks: {'; '.join(missing)}"
+        return True, f"Storage class '{storage_class_name}' supports {', '.join(supported)}"
+
     def check_storage_class(self, storage_class_name: str) -> Tuple[bool, str]:
         """Verify storage class exists and is available"""
         returncode, stdout, stderr = run_kubectl_command(
@@ -165,6 +461,29 @@ class ClusterValidator:
         data = json.loads(stdout)
         provisioner = data.get('provisioner', 'unknown')
         return True, f"Storage class '{storage_class_name}' exists (provisioner: {provisioner})"
+
+    def check_storage_backend(self, storage_class_name: str) -> Tuple[bool, str]:
+        """Check the health of the storage backend behind a storage class, through its storage provider."""
+        returncode, stdout, _ = run_kubectl_command(
+            ['get', 'storageclass', storage_class_name, '-o', 'json'],
+            check=False,
+            logger=self.logger
+        )
+        if returncode != 0:
+            return False, f"Storage class '{storage_class_name}' not found"
+        provisioner = json.loads(stdout).get('provisioner', '')
+        try:
+            provider = provider_for(provisioner)
+        except ValueError as e:
+            return False, str(e)
+        status, message = provider.health(self.logger)
+        version = provider.version(self.logger)
+        message = f"{provider.title} {version or '(version unknown)'}: {message}"
+        if status == HEALTH_OK:
+            return True, message
+        if status == HEALTH_FAILED:
+            return False, message
+        return WARN, message
     
     def check_worker_nodes(self, min_nodes: int = 1) -> Tuple[bool, str]:
         """Verify sufficient worker nodes are available"""
@@ -199,12 +518,11 @@ class ClusterValidator:
             logger=self.logger
         )
         if returncode != 0:
-            self.warnings += 1
-            return True, "Cannot check node resources (metrics-server may not be installed) - WARNING"
+            return WARN, "Cannot check node resources (metrics-server may not be installed)"
         
         lines = stdout.strip().split('\n')[1:]  # Skip header
         if len(lines) == 0:
-            return True, "No resource metrics available - WARNING"
+            return WARN, "No resource metrics available"
         
         overloaded_nodes = []
         for line in lines:
@@ -218,8 +536,7 @@ class ClusterValidator:
                         overloaded_nodes.append(f"{node_name} ({cpu_pct}% CPU)")
         
         if overloaded_nodes:
-            self.warnings += 1
-            return True, f"Some nodes are heavily loaded: {', '.join(overloaded_nodes)} - WARNING"
+            return WARN, f"Some nodes are heavily loaded: {', '.join(overloaded_nodes)}"
         
         return True, f"Node resources look healthy ({len(lines)} nodes checked)"
     
@@ -231,15 +548,14 @@ class ClusterValidator:
         from container-disk images, so a missing or not-ready DataSource is a
         warning rather than a cluster-validation failure.
         """
-        suffix = "WARNING (only needed for datasource-clone/chaos tests)"
+        suffix = "only needed for datasource-clone/chaos tests"
         returncode, stdout, stderr = run_kubectl_command(
             ['get', 'datasource', datasource_name, '-n', namespace, '-o', 'json'],
             check=False,
             logger=self.logger
         )
         if returncode != 0:
-            self.warnings += 1
-            return True, f"DataSource '{datasource_name}' not found in namespace '{namespace}' - {suffix}"
+            return WARN, f"DataSource '{datasource_name}' not found in namespace '{namespace}' ({suffix})"
 
         data = json.loads(stdout)
         conditions = data.get('status', {}).get('conditions', [])
@@ -247,8 +563,7 @@ class ClusterValidator:
 
         if ready:
             return True, f"DataSource '{datasource_name}' is ready in namespace '{namespace}'"
-        self.warnings += 1
-        return True, f"DataSource '{datasource_name}' exists but is not ready - {suffix}"
+        return WARN, f"DataSource '{datasource_name}' exists but is not ready ({suffix})"
     
     def check_ssh_pod(self, pod_name: str, namespace: str) -> Tuple[bool, str]:
         """Verify SSH test pod exists and is running"""
@@ -258,16 +573,13 @@ class ClusterValidator:
             logger=self.logger
         )
         if returncode != 0:
-            self.warnings += 1
-            return True, f"SSH test pod '{pod_name}' not found in namespace '{namespace}' - WARNING (optional)"
+            return WARN, f"SSH test pod '{pod_name}' not found in namespace '{namespace}' (optional)"
         
         data = json.loads(stdout)
         phase = data.get('status', {}).get('phase')
         if phase == 'Running':
             return True, f"SSH test pod '{pod_name}' is running in namespace '{namespace}'"
-        
-        self.warnings += 1
-        return True, f"SSH test pod '{pod_name}' exists but is not running (phase: {phase}) - WARNING"
+        return WARN, f"SSH test pod '{pod_name}' exists but is not running (phase: {phase})"
     
     def check_permissions(self) -> Tuple[bool, str]:
         """Verify user has required permissions"""
@@ -292,11 +604,98 @@ class ClusterValidator:
             return False, f"Missing permissions: {', '.join(missing_perms)}"
         return True, "User has all required permissions"
     
+    def check_scratch_storage(self, paths: List[str], size_mb: int, slow_ratio: float,
+                              namespace: str, image: str) -> Tuple[bool, str]:
+        """Measure node-local scratch and container storage on every worker node and flag slow nodes.
+
+        Non-fatal: slow local storage does not block benchmarks, but it explains
+        node-to-node variance in import, clone and containerDisk boot times.
+        """
+        nodes = get_worker_nodes(self.logger)
+        if not nodes:
+            return WARN, "No ready worker nodes to probe"
+
+        outcomes = run_parallel(
+            measure_node_scratch, nodes, concurrency=len(nodes),
+            args=(paths, size_mb, namespace, image, self.logger),
+            logger=self.logger, description="scratch probe"
+        )
+        measured = {node: result for node, result, error in outcomes if error is None}
+        unreachable = [node for node, _, error in outcomes if error is not None]
+
+        self.logger.info(f"    {'Node':<40} {'Path':<22} {'Write MB/s':>11} {'Read MB/s':>10} {'Sync 4K ms':>11}")
+        for node in sorted(measured):
+            for path, r in measured[node].items():
+                if 'error' in r:
+                    self.logger.info(f"    {node:<40} {path:<22} {r['error']}")
+                else:
+                    self.logger.info(f"    {node:<40} {path:<22} {r['write_mb_s']:>11} {r['read_mb_s']:>10} "
+                                     f"{r['sync_write_ms']:>11}")
+
+        # A node is slow when any figure on a path is worse than slow_ratio x the median of all nodes
+        slow = set()
+        for path in paths:
+            rows = {node: r[path] for node, r in measured.items() if 'write_mb_s' in r.get(path, {})}
+            if len(rows) < 2:
+                continue
+            for key, higher_is_better in (('write_mb_s', True), ('read_mb_s', True), ('sync_write_ms', False)):
+                values = [row[key] for row in rows.values() if row[key]]
+                if not values:
+                    continue
+                median = statistics.median(values)
+                for node, row in rows.items():
+                    value = row[key]
+                    if value and (value < median * slow_ratio if higher_is_better else value > median / slow_ratio):
+                        slow.add(f"{node} ({path} {key}={value}, median {median:g})")
+
+        problems = []
+        if slow:
+            problems.append(f"slow local storage on {', '.join(sorted(slow))}")
+        if unreachable:
+            problems.append(f"could not probe {', '.join(unreachable)}")
+        if problems:
+            return WARN, '; '.join(problems)
+        return True, f"Local scratch storage consistent across {len(measured)} nodes"
+
+    @property
+    def status(self) -> str:
+        """Overall result: 'fail' if any check failed, 'warn' if any warned, else 'pass'"""
+        if self.checks_failed:
+            return 'fail'
+        return 'warn' if self.warnings else 'pass'
+
+    def report(self) -> dict:
+        """Check results as a report dict (written by --report, printed by --output json/yaml)"""
+        return {
+            'generated_at': datetime.now(timezone.utc).strftime('%Y-%m-%dT%H:%M:%SZ'),
+            'status': self.status,
+            'summary': {
+                'passed': self.checks_passed,
+                'warnings': self.warnings,
+                'failed': self.checks_failed,
+            },
+            'checks': self.results,
+        }
+
+    def write_report(self, path: str):
+        """Write the check results as a JSON report"""
+        report_dir = os.path.dirname(path)
+        if report_dir:
+            os.makedirs(report_dir, exist_ok=True)
+        with open(path, 'w') as f:
+            json.dump(self.report(), f, indent=2)
+        self.logger.info(f"Validation report written to {path}")
+
     def print_summary(self):
         """Print validation summary"""
         self.logger.info("\n" + "=" * 80)
         self.logger.info("VALIDATION SUMMARY")
         self.logger.info("=" * 80)
+        self.logger.info(f"{'Check':<45} {'Status':<7} Message")
+        self.logger.info("-" * 80)
+        for r in self.results:
+            self.logger.info(f"{r['check'][:45]:<45} {r['status']:<7} {r['message']}")
+        self.logger.info("-" * 80)
         self.logger.info(f"Checks Passed:  {self.checks_passed}")
         self.logger.info(f"Checks Failed:  {self.checks_failed}")
         self.logger.info(f"Warnings:       {self.warnings}")
@@ -324,7 +723,7 @@ Examples:
   %(prog)s --all
 
   # Validate with custom DataSource
-  %(prog)s --storage-class YOUR-STORAGE-CLASS --datasource rhel9 --datasource-namespace openshift-virtualization-os-images
+  %(prog)s --storage-class YOUR-STORAGE-CLASS --datasource rhel9 --datasource-namespace kubevirt-os-images
         """
     )
     
@@ -342,8 +741,9 @@ Examples:
     parser.add_argument(
         '--datasource-namespace',
         type=str,
-        default='openshift-virtualization-os-images',
-        help='DataSource namespace (default: openshift-virtualization-os-images)'
+        default=None,
+        help='DataSource namespace (default: openshift-virtualization-os-images on OpenShift, '
+             'kubevirt-os-images on Kubernetes, or wherever the DataSource is found)'
     )
     parser.add_argument(
         '--ssh-pod',
@@ -373,6 +773,75 @@ Examples:
         action='store_true',
         help='Run only core connectivity, virtualization, permission, worker-node, and storage-class checks'
     )
+    parser.add_argument(
+        '--scratch-disk',
+        action='store_true',
+        help='Measure node-local scratch/container storage on every worker node (also run by --all)'
+    )
+    parser.add_argument(
+        '--scratch-paths',
+        type=str,
+        default=','.join(DEFAULT_SCRATCH_PATHS),
+        help=f"Comma-separated host directories to measure (default: {','.join(DEFAULT_SCRATCH_PATHS)})"
+    )
+    parser.add_argument(
+        '--scratch-size-mb',
+        type=int,
+        default=DEFAULT_SCRATCH_SIZE_MB,
+        help=f'MiB written and read per directory (default: {DEFAULT_SCRATCH_SIZE_MB})'
+    )
+    parser.add_argument(
+        '--scratch-slow-ratio',
+        type=float,
+        default=DEFAULT_SCRATCH_SLOW_RATIO,
+        help=f'Flag nodes below this fraction of the median (default: {DEFAULT_SCRATCH_SLOW_RATIO})'
+    )
+    parser.add_argument(
+        '--scratch-namespace',
+        type=str,
+        default='default',
+        help='Namespace for the privileged probe pods (default: default)'
+    )
+    parser.add_argument(
+        '--scratch-image',
+        type=str,
+        default=DEFAULT_NODE_EXEC_IMAGE,
+        help=f'Image of the probe pods; must provide nsenter (default: {DEFAULT_NODE_EXEC_IMAGE})'
+    )
+    parser.add_argument(
+        '--gpu-device',
+        type=str,
+        help='Check GPU passthrough/vGPU support for this device plugin resource, e.g. nvidia.com/GA102GL_A10'
+    )
+    parser.add_argument(
+        '--gpu-vms',
+        type=int,
+        default=1,
+        help='VMs with one --gpu-device GPU each that must fit (default: 1)'
+    )
+    parser.add_argument(
+        '--network',
+        nargs='+',
+        default=[],
+        help='Check these NetworkAttachmentDefinitions ([namespace/]name, namespace default: default) '
+             'exist and SR-IOV ones have VFs'
+    )
+    parser.add_argument(
+        '--network-vms',
+        type=int,
+        default=1,
+        help='VMs attached to each SR-IOV --network that must fit on its VFs (default: 1)'
+    )
+    parser.add_argument(
+        '--report',
+        type=str,
+        help='Write the check results as a JSON report to this file'
+    )
+    parser.add_argument(
+        '--strict',
+        action='store_true',
+        help=f'Exit with code {EXIT_WARNINGS} when checks warn but none failed (default: 0)'
+    )
     parser.add_argument(
         '--log-level',
         type=str,
@@ -408,15 +877,29 @@ def main():
     # Core checks (always run)
     if not validator.run_check("kubectl access", validator.check_kubectl_access):
         validator.print_summary()
-        sys.exit(1)
-
-    validator.run_check("OpenShift Virtualization installation", validator.check_kubevirt_installed)
+        if args.report:
+            validator.write_report(args.report)
+        emit('validation-report', validator.report())
+        sys.exit(EXIT_FAILED)
+
+    validator.run_check("Platform", validator.check_platform)
+    validator.run_check(f"{virtualization_name(logger)} installation", validator.check_kubevirt_installed)
+    validator.run_check("HyperConverged operator health", validator.check_hco_health)
+    validator.run_check("CDI readiness", validator.check_cdi_ready)
     validator.run_check("User permissions", validator.check_permissions)
     validator.run_check("Worker nodes", validator.check_worker_nodes, args.min_worker_nodes)
+    validator.run_check("Node virtualization capability", validator.check_node_virtualization)
     
-    # Storage class check
+    # Storage class check (Harvester has a storage class of its own)
+    if not args.storage_class and default_storage_class(logger):
+        args.storage_class = default_storage_class(logger)
+        logger.info(f"No storage class specified, validating the platform's {args.storage_class}")
     if args.storage_class:
-        validator.run_check(f"Storage class '{args.storage_class}'", validator.check_storage_class, args.storage_class)
+        if validator.run_check(f"Storage class '{args.storage_class}'", validator.check_storage_class,
+                               args.storage_class):
+            validator.run_check("Storage class capabilities", validator.check_storage_capabilities,
+                                args.storage_class)
+            validator.run_check("Storage backend", validator.check_storage_backend, args.storage_class)
     elif args.all:
         logger.warning("No storage class specified. Use --storage-class to validate.")
     
@@ -426,7 +909,7 @@ def main():
             f"DataSource '{args.datasource}'",
             validator.check_datasource,
             args.datasource,
-            args.datasource_namespace
+            resolve_datasource_namespace(args.datasource, args.datasource_namespace, logger)
         )
     
     if not args.quick and (args.all or args.ssh_pod):
@@ -440,11 +923,35 @@ def main():
     # Resource checks
     if not args.quick:
         validator.run_check("Node resources", validator.check_node_resources)
+
+    if args.gpu_device:
+        validator.run_check(f"GPU devices '{args.gpu_device}'", validator.check_gpu_devices,
+                            args.gpu_device, args.gpu_vms)
+
+    for network in args.network:
+        validator.run_check(f"Network '{network}'", validator.check_network, network, args.network_vms)
+
+    # Local scratch storage preflight (writes to every worker node, so opt-in)
+    if args.scratch_disk or (args.all and not args.quick):
+        validator.run_check(
+            "Local scratch storage",
+            validator.check_scratch_storage,
+            [p.strip() for p in args.scratch_paths.split(',') if p.strip()],
+            args.scratch_size_mb,
+            args.scratch_slow_ratio,
+            args.scratch_namespace,
+            args.scratch_image
+        )
     
     # Print summary
     success = validator.print_summary()
-    
-    sys.exit(0 if success else 1)
+    if args.report:
+        validator.write_report(args.report)
+    emit('validation-report', validator.report())
+
+    if not success:
+        sys.exit(EXIT_FAILED)
+    sys.exit(EXIT_WARNINGS if args.strict and validator.warnings else 0)
 
 
 if __name__ == '__main__':
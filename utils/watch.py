@@ -0,0 +1,206 @@
+#!/usr/bin/env python3
+"""
+Watch-based change detection for Kubernetes objects.
+
+Polling with `kubectl get` every few seconds measures a state change only to
+the polling interval, and each poll of many objects costs an API request. A
+ResourceWatch runs `kubectl get <resource> --watch --output-watch-events -o
+json` in the background instead and records every change of the watched
+objects with the time it arrived, so transitions (a VMI becoming Ready, a
+virt-launcher pod scheduled on another node, a node going NotReady) are timed
+to well under a second.
+
+    watch = ResourceWatch('vmi', selector='kubevirt.io/vm', logger=logger)
+    watch.start()
+    ...
+    found = watch.wait_until(lambda: first_match(watch.history('ns-1', 'vm'), start_ts, is_ready), timeout=600)
+
+kubectl lists the current objects before it streams changes, so the first
+observations of each object are its state when the watch started. A watch the
+API server ends is restarted (and relisted). Times are utils.timing
+MonotonicTimestamps, like the workloads' start times (timing.now()).
+"""
+
+import json
+import logging
+import subprocess
+import threading
+import time
+from typing import Callable, Dict, List, Optional, Tuple, TypeVar
+
+from utils import timing
+from utils.base import impersonate
+from utils.timing import MonotonicTimestamp
+
+T = TypeVar('T')
+
+# Seconds before a watch that ended is restarted
+RESTART_DELAY = 1.0
+# Seconds wait_until() waits between checks when no change arrives
+CHECK_INTERVAL = 1.0
+
+# (time observed, event type, object or None when deleted)
+Observation = Tuple[MonotonicTimestamp, str, Optional[Dict]]
+
+
+class ResourceWatch:
+    """
+    Background `kubectl get --watch` of one resource type, recording every change on arrival.
+
+    Args:
+        resource: Resource type, e.g. 'vmi', 'pods', 'node'
+        namespace: Namespace to watch (default: all namespaces; ignored for cluster-scoped resources)
+        name: Only watch the object of this name
+        selector: Label selector
+        field_selector: Field selector
+        logger: Logger instance
+    """
+
+    def __init__(self, resource: str, namespace: Optional[str] = None, name: Optional[str] = None,
+                 selector: Optional[str] = None, field_selector: Optional[str] = None,
+                 logger: Optional[logging.Logger] = None):
+        self.resource = resource
+        self.namespace = namespace
+        self.name = name
+        self.selector = selector
+        self.field_selector = field_selector
+        self.logger = logger
+        self.started_at: Optional[MonotonicTimestamp] = None
+        self._history: Dict[Tuple[str, str], List[Observation]] = {}
+        self._changed = threading.Condition()
+        self._process: Optional[subprocess.Popen] = None
+        self._thread: Optional[threading.Thread] = None
+        self._stopped = threading.Event()
+
+    def command(self) -> List[str]:
+        cmd = ['kubectl', 'get', self.resource]
+        if self.name:
+            cmd.append(self.name)
+        cmd += ['-n', self.namespace] if self.namespace else ([] if self.name else ['--all-namespaces'])
+        if self.selector:
+            cmd += ['-l', self.selector]
+        if self.field_selector:
+            cmd += ['--field-selector', self.field_selector]
+        return impersonate(cmd + ['--watch', '--output-watch-events', '-o', 'json'])
+
+    def start(self) -> bool:
+        """Start watching in the background. Returns False if kubectl could not be started."""
+        if not self._spawn():
+            return False
+        self.started_at = timing.now()
+        self._thread = threading.Thread(target=self._run, daemon=True)
+        self._thread.start()
+        if self.logger:
+            self.logger.debug(f"Watching {self.resource} ({' '.join(self.command()[3:])})")
+        return True
+
+    def stop(self):
+        """Stop the watch."""
+        self._stopped.set()
+        process = self._process
+        if process is not None and process.poll() is None:
+            process.terminate()
+            try:
+                process.wait(timeout=5)
+            except subprocess.TimeoutExpired:
+                process.kill()
+        with self._changed:
+            self._changed.notify_all()
+
+    def _spawn(self) -> bool:
+        try:
+            self._process = subprocess.Popen(self.command(), stdout=subprocess.PIPE,
+                                             stderr=subprocess.DEVNULL, text=True)
+        except OSError as e:
+            if self.logger:
+                self.logger.warning(f"Could not watch {self.resource}: {e}")
+            return False
+        return True
+
+    def _run(self):
+        while not self._stopped.is_set():
+            self._read(self._process)
+            if self._stopped.is_set():
+                break
+            if self.logger:
+                self.logger.debug(f"Watch of {self.resource} ended (exit code {self._process.poll()}), restarting")
+            time.sleep(RESTART_DELAY)
+            if not self._spawn():
+                break
+
+    def _read(self, process: subprocess.Popen):
+        """Parse the stream of watch events (JSON objects spanning several lines)."""
+        decoder = json.JSONDecoder()
+        buffer = ''
+        for line in process.stdout:
+            buffer += line
+            while True:
+                buffer = buffer.lstrip()
+                if not buffer:
+                    break
+                try:
+                    event, end = decoder.raw_decode(buffer)
+                except json.JSONDecodeError:
+                    break
+                buffer = buffer[end:]
+                if isinstance(event, dict):
+                    self._record(event)
+
+    def _record(self, event: Dict):
+        observed = timing.now()
+        obj = event.get('object') if 'object' in event else event
+        if not isinstance(obj, dict):
+            return
+        metadata = obj.get('metadata') or {}
+        event_type = event.get('type', 'ADDED')
+        key = (metadata.get('namespace') or '', metadata.get('name') or '')
+        with self._changed:
+            self._history.setdefault(key, []).append(
+                (observed, event_type, None if event_type == 'DELETED' else obj))
+            self._changed.notify_all()
+
+    def history(self, namespace: str = '', name: Optional[str] = None) -> List[Observation]:
+        """Observations of the object namespace/name (of every object in namespace without name), oldest first."""
+        with self._changed:
+            if name is not None:
+                return list(self._history.get((namespace, name), []))
+            observations = [o for (ns, _), entries in self._history.items() if ns == namespace for o in entries]
+        return sorted(observations, key=lambda o: o[0])
+
+    def wait_until(self, check: Callable[[], Optional[T]], timeout: float) -> Optional[T]:
+        """
+        Call check() whenever a change arrives until it returns something other than None.
+
+        Returns:
+            check()'s result, or None on timeout or when the watch was stopped
+        """
+        deadline = time.monotonic() + timeout
+        with self._changed:
+            while True:
+                result = check()
+                if result is not None:
+                    return result
+                remaining = deadline - time.monotonic()
+                if remaining <= 0 or self._stopped.is_set():
+                    return None
+                # The condition's lock is re-entrant for history() in check()
+                self._changed.wait(min(remaining, CHECK_INTERVAL))
+
+
+def first_match(observations: List[Observation], since: MonotonicTimestamp,
+                predicate: Callable[[Optional[Dict]], bool]) -> Optional[Tuple[MonotonicTimestamp, Optional[Dict]]]:
+    """
+    First state at or after since that satisfies predicate.
+
+    The state current at since (the last one observed before it) counts as
+    observed at since, so an object that already matched returns since.
+
+    Returns:
+        (time the state was observed, object or None if deleted), or None
+    """
+    timeline = [(since, obj) for observed, _, obj in observations if observed <= since][-1:]
+    timeline += [(observed, obj) for observed, _, obj in observations if observed > since]
+    for observed, obj in timeline:
+        if predicate(obj):
+            return observed, obj
+    return None
@@ -0,0 +1,209 @@
+#!/usr/bin/env python3
+"""
+Seeded random workload plans (random-workload).
+
+generate_plan() turns a seed and a few knobs into a fixed sequence of
+steps that emulates tenants: VMs of weighted random sizes are created,
+started, stopped, restarted, migrated, snapshotted, resized and deleted,
+with exponential (Poisson) inter-arrival times around ``--rate`` operations
+per minute. The generator tracks the simulated state of every VM, so it only
+draws operations that are valid for it (no start of a running VM, nothing on
+a deleted one), and keeps at most ``max_vms`` VMs, each in its own namespace
+slot (``{namespace-prefix}-{slot}``); a deleted VM frees its slot for a
+later create.
+
+The same seed and parameters always give the same plan. The plan, with its
+seed and parameters, is saved as workload_plan.json with the results; load_plan()
+reads it back so ``--replay`` runs exactly the same sequence, independent of
+changes to the generator.
+
+Plan format:
+
+    {"generator_version": 1, "seed": 1234, "parameters": {...},
+     "steps": [{"step": 1, "at_sec": 4.2, "operation": "create", "slot": 1, "size": "2x4Gi"},
+               {"step": 2, "at_sec": 9.7, "operation": "resize", "slot": 1, "increment": "1Gi"}, ...]}
+"""
+
+import json
+import os
+import random
+import re
+from typing import Dict, List, Optional, Tuple
+
+GENERATOR_VERSION = 1
+PLAN_FILE = 'workload_plan.json'
+
+OPERATIONS = ['create', 'start', 'stop', 'restart', 'migrate', 'snapshot', 'resize', 'delete']
+# Operations valid for a VM in each simulated state ('create' needs a free slot instead)
+VALID_OPERATIONS = {
+    'running': {'stop', 'restart', 'migrate', 'snapshot', 'resize', 'delete'},
+    'stopped': {'start', 'snapshot', 'resize', 'delete'},
+}
+DEFAULT_MIX = ['create=3', 'start=2', 'stop=2', 'restart=2', 'migrate=1', 'snapshot=1', 'resize=1', 'delete=2']
+# CORESxMEMORY=WEIGHT: mostly small VMs, as in a typical tenant population
+DEFAULT_SIZES = ['1x2Gi=4', '2x4Gi=3', '4x8Gi=2', '8x16Gi=1']
+DEFAULT_OPERATIONS = 100
+DEFAULT_RATE = 6.0                # operations per minute, on average
+DEFAULT_MAX_VMS = 10
+DEFAULT_MAX_RESIZES = 3
+DEFAULT_RESIZE_INCREMENT = '1Gi'
+
+SIZE_RE = re.compile(r'^(\d+)x(\d+(?:\.\d+)?(?:Mi|Gi|Ti))$')
+
+
+def parse_weights(entries: List[str], allowed: Optional[List[str]] = None) -> Dict[str, float]:
+    """'name[=weight]' entries as {name: weight}; raises ValueError on unknown names or bad weights."""
+    weights = {}
+    for entry in entries:
+        name, _, weight = entry.partition('=')
+        if allowed is not None and name not in allowed:
+            raise ValueError(f"unknown operation {name!r} (choose from {', '.join(allowed)})")
+        if allowed is None and not SIZE_RE.match(name):
+            raise ValueError(f"invalid size {name!r} (expected CORESxMEMORY, e.g. 2x4Gi)")
+        try:
+            weights[name] = float(weight) if weight else 1.0
+        except ValueError:
+            raise ValueError(f"weight of {name} is not a number: {weight!r}")
+        if weights[name] <= 0:
+            raise ValueError(f"weight of {name} must be > 0")
+    return weights
+
+
+def parse_size(size: str) -> Tuple[int, str]:
+    """'2x4Gi' as (2, '4Gi')."""
+    match = SIZE_RE.match(size)
+    if not match:
+        raise ValueError(f"invalid size {size!r} (expected CORESxMEMORY, e.g. 2x4Gi)")
+    return int(match.group(1)), match.group(2)
+
+
+def new_seed() -> int:
+    """A random seed to record when none was given."""
+    return random.SystemRandom().randrange(2 ** 31)
+
+
+def _draw(rng: random.Random, weights: Dict[str, float], names: List[str]) -> str:
+    return rng.choices(names, weights=[weights[n] for n in names])[0]
+
+
+def generate_plan(seed: int, operations: int = DEFAULT_OPERATIONS, rate: float = DEFAULT_RATE,
+                  max_vms: int = DEFAULT_MAX_VMS, mix: Optional[Dict[str, float]] = None,
+                  sizes: Optional[Dict[str, float]] = None, max_resizes: int = DEFAULT_MAX_RESIZES,
+                  resize_increment: str = DEFAULT_RESIZE_INCREMENT) -> Dict:
+    """
+    Generate the plan of a seed.
+
+    Args:
+        seed: Random seed; the same seed and parameters give the same plan
+        operations: Number of steps
+        rate: Average operations per minute (exponential inter-arrival times)
+        max_vms: VMs that may exist at once (namespace slots 1..max_vms)
+        mix: {operation: weight} (default: DEFAULT_MIX)
+        sizes: {CORESxMEMORY: weight} of created VMs (default: DEFAULT_SIZES)
+        max_resizes: Resizes per VM before resize is no longer drawn for it
+        resize_increment: Size each resize adds to the VM's first PVC
+
+    Returns:
+        {'generator_version', 'seed', 'parameters', 'steps'}; steps stops short
+        of `operations` only when no operation of the mix is valid any more
+    """
+    mix = mix or parse_weights(DEFAULT_MIX, OPERATIONS)
+    sizes = sizes or parse_weights(DEFAULT_SIZES)
+    rng = random.Random(seed)
+    vms: Dict[int, Dict] = {}     # slot -> {'state', 'resizes'}
+    steps = []
+    at_sec = 0.0
+    ordered_ops = [op for op in OPERATIONS if op in mix]
+    ordered_sizes = sorted(sizes)
+    for index in range(1, operations + 1):
+        at_sec += rng.expovariate(rate / 60)
+        eligible = {}
+        for op in ordered_ops:
+            if op == 'create':
+                if len(vms) < max_vms:
+                    eligible[op] = []
+                continue
+            slots = [slot for slot in sorted(vms)
+                     if op in VALID_OPERATIONS[vms[slot]['state']]
+                     and (op != 'resize' or vms[slot]['resizes'] < max_resizes)]
+            if slots:
+                eligible[op] = slots
+        if not eligible:
+            break
+        op = _draw(rng, mix, list(eligible))
+        step = {'step': index, 'at_sec': round(at_sec, 3), 'operation': op}
+        if op == 'create':
+            slot = min(s for s in range(1, max_vms + 1) if s not in vms)
+            step['size'] = _draw(rng, sizes, ordered_sizes)
+            vms[slot] = {'state': 'running', 'resizes': 0}
+        else:
+            slot = rng.choice(eligible[op])
+            if op == 'stop':
+                vms[slot]['state'] = 'stopped'
+            elif op == 'start':
+                vms[slot]['state'] = 'running'
+            elif op == 'resize':
+                vms[slot]['resizes'] += 1
+                step['increment'] = resize_increment
+            elif op == 'delete':
+                del vms[slot]
+        step['slot'] = slot
+        steps.append(step)
+    return {
+        'generator_version': GENERATOR_VERSION,
+        'seed': seed,
+        'parameters': {
+            'operations': operations,
+            'rate_per_min': rate,
+            'max_vms': max_vms,
+            'mix': mix,
+            'sizes': sizes,
+            'max_resizes_per_vm': max_resizes,
+            'resize_increment': resize_increment,
+        },
+        'steps': steps,
+    }
+
+
+def plan_overview(plan: Dict) -> Dict:
+    """Operation and size counts of a plan, and its span in seconds."""
+    steps = plan['steps']
+    return {
+        'steps': len(steps),
+        'span_sec': steps[-1]['at_sec'] if steps else 0,
+        'operations': {op: sum(1 for s in steps if s['operation'] == op)
+                       for op in OPERATIONS if any(s['operation'] == op for s in steps)},
+        'sizes': {size: sum(1 for s in steps if s.get('size') == size)
+                  for size in sorted({s['size'] for s in steps if s.get('size')})},
+        'slots': max((s['slot'] for s in steps), default=0),
+    }
+
+
+def write_plan(plan: Dict, path: str):
+    """Write a plan as JSON; path may be a results directory."""
+    if os.path.isdir(path):
+        path = os.path.join(path, PLAN_FILE)
+    with open(path, 'w') as f:
+        json.dump(plan, f, indent=4)
+
+
+def load_plan(path: str) -> Dict:
+    """
+    Read a plan from a workload_plan.json file or a results directory holding one.
+
+    Raises:
+        ValueError: If the file is missing or not a plan
+    """
+    if os.path.isdir(path):
+        path = os.path.join(path, PLAN_FILE)
+    try:
+        with open(path) as f:
+            plan = json.load(f)
+    except (OSError, json.JSONDecodeError) as e:
+        raise ValueError(f"cannot read plan {path}: {e}")
+    if not isinstance(plan, dict) or not isinstance(plan.get('steps'), list) or 'seed' not in plan:
+        raise ValueError(f"{path} is not a workload plan")
+    for step in plan['steps']:
+        if step.get('operation') not in OPERATIONS or not isinstance(step.get('slot'), int):
+            raise ValueError(f"{path}: invalid step {step}")
+    return plan
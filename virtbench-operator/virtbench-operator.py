@@ -0,0 +1,339 @@
+#!/usr/bin/env python3
+"""
+Benchmark operator for KubeVirt performance testing.
+
+Reconciles VirtBenchRun custom resources (virtbenchrun-crd.yaml): each run
+names a virtbench workload and its options, the operator executes it with
+the virtbench CLI and records the outcome in the run's status - phase,
+conditions, exit code and where the results are. Results are written to the
+results directory (a PVC when deployed with operator.yaml); with
+spec.results.configMap the run summaries are also copied into a ConfigMap
+<run name>-results next to the run, so GitOps tooling can read them.
+
+    apiVersion: virtbench.io/v1alpha1
+    kind: VirtBenchRun
+    metadata:
+      name: clone-50
+    spec:
+      workload: datasource-clone
+      args:
+        start: 1
+        end: 50
+        storage-class: px-csi-db
+        save-results: true
+        cleanup: true
+
+Runs are started in creation order, at most --max-concurrent at a time.
+Each run uses its object UID as the virtbench run UUID, so a run that was
+in progress when the operator restarted is started again and adopts the
+resources it had already created. Deleting a running VirtBenchRun stops it.
+
+Usage:
+  python3 virtbench-operator.py [--namespace NS] [--poll-interval SEC] [--max-concurrent N]
+"""
+
+import argparse
+import json
+import os
+import signal
+import subprocess
+import sys
+import threading
+import time
+from datetime import datetime, timezone
+from pathlib import Path
+from typing import Dict, List, Optional
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
+
+from utils.apply import apply_manifest
+from utils.common import setup_logging, run_kubectl_command
+from virtbench.utils.launch import virtbench_command, results_since
+
+RESOURCE = 'virtbenchruns.virtbench.io'
+API_VERSION = 'virtbench.io/v1alpha1'
+KIND = 'VirtBenchRun'
+
+DEFAULT_POLL_INTERVAL = 10
+DEFAULT_MAX_CONCURRENT = 1
+LOG_TAIL_LINES = 20
+
+# Phases of status.phase
+PENDING = 'Pending'
+RUNNING = 'Running'
+SUCCEEDED = 'Succeeded'
+FAILED = 'Failed'
+
+# ConfigMaps are limited to 1 MiB; leave room for metadata
+CONFIGMAP_MAX_BYTES = 900 * 1024
+
+
+def now() -> str:
+    return datetime.now(timezone.utc).strftime('%Y-%m-%dT%H:%M:%SZ')
+
+
+def build_command(run: Dict) -> List[str]:
+    """virtbench command line of a VirtBenchRun."""
+    spec = run.get('spec') or {}
+    return virtbench_command(spec.get('workload'), spec.get('args'), spec.get('options'), run['metadata']['uid'])
+
+
+def set_condition(conditions: List[Dict], cond_type: str, status: str, reason: str, message: str = '') -> List[Dict]:
+    """Set a condition, keeping lastTransitionTime when its status does not change."""
+    previous = next((c for c in conditions if c['type'] == cond_type), None)
+    condition = {'type': cond_type, 'status': status, 'reason': reason, 'message': message,
+                 'lastTransitionTime': now()}
+    if previous and previous['status'] == status:
+        condition['lastTransitionTime'] = previous.get('lastTransitionTime', condition['lastTransitionTime'])
+    return [c for c in conditions if c['type'] != cond_type] + [condition]
+
+
+class Operator:
+    """Polls VirtBenchRun objects and runs the pending ones."""
+
+    def __init__(self, args, logger):
+        self.args = args
+        self.logger = logger
+        self.repo_root = Path(__file__).resolve().parent.parent
+        self.results_dir = Path(args.results_dir)
+        self.active: Dict[str, Dict] = {}   # uid -> {'process': Popen, 'run': VirtBenchRun}
+        # Runs finished by this instance; the listed status may not show it yet
+        self.finished = set()
+        self.lock = threading.Lock()
+        self.stopping = False
+
+    # --- API helpers ---
+
+    def list_runs(self) -> Optional[List[Dict]]:
+        scope = ['-n', self.args.namespace] if self.args.namespace else ['-A']
+        returncode, stdout, stderr = run_kubectl_command(['get', RESOURCE, '-o', 'json'] + scope,
+                                                         check=False, logger=self.logger)
+        if returncode != 0:
+            self.logger.error(f"Cannot list VirtBenchRuns: {stderr.strip()}")
+            return None
+        runs = json.loads(stdout).get('items', [])
+        return sorted(runs, key=lambda r: (r['metadata'].get('creationTimestamp', ''), r['metadata']['name']))
+
+    def patch_status(self, run: Dict, status: Dict) -> bool:
+        meta = run['metadata']
+        returncode, _, stderr = run_kubectl_command(
+            ['patch', RESOURCE, meta['name'], '-n', meta['namespace'], '--subresource=status',
+             '--type=merge', '-p', json.dumps({'status': status})],
+            check=False, logger=self.logger
+        )
+        if returncode != 0:
+            self.logger.warning(f"[{meta['namespace']}/{meta['name']}] Cannot update status: {stderr.strip()}")
+            return False
+        run.setdefault('status', {}).update(status)
+        return True
+
+    def write_configmap(self, run: Dict, files: List[Path]) -> Optional[str]:
+        """Copy result files into the ConfigMap <run>-results, owned by the run. Returns its name."""
+        meta = run['metadata']
+        name = f"{meta['name']}-results"
+        data, size, skipped = {}, 0, []
+        for path in files:
+            content = path.read_text(errors='replace')
+            if size + len(content) > CONFIGMAP_MAX_BYTES:
+                skipped.append(path.name)
+                continue
+            # ConfigMap keys must be unique file names; prefix with the run directory
+            data[f"{path.parent.name}_{path.name}"] = content
+            size += len(content)
+        if skipped:
+            self.logger.warning(f"[{meta['namespace']}/{meta['name']}] Left out of ConfigMap {name} "
+                                f"(1 MiB limit): {', '.join(skipped)}")
+        configmap = {
+            'apiVersion': 'v1',
+            'kind': 'ConfigMap',
+            'metadata': {
+                'name': name,
+                'namespace': meta['namespace'],
+                'labels': {'virtbench.io/run-uuid': meta['uid']},
+                'ownerReferences': [{'apiVersion': API_VERSION, 'kind': KIND, 'name': meta['name'],
+                                     'uid': meta['uid'], 'controller': True}],
+            },
+            'data': data,
+        }
+        applied, error = apply_manifest(json.dumps(configmap), logger=self.logger)
+        if not applied:
+            self.logger.error(f"[{meta['namespace']}/{meta['name']}] Cannot write ConfigMap {name}: {error}")
+            return None
+        return name
+
+    # --- Run lifecycle ---
+
+    def log_path(self, run: Dict) -> Path:
+        meta = run['metadata']
+        return self.results_dir / 'operator' / meta['namespace'] / f"{meta['name']}.log"
+
+    def start(self, run: Dict, resumed: bool):
+        meta = run['metadata']
+        ref = f"{meta['namespace']}/{meta['name']}"
+        conditions = (run.get('status') or {}).get('conditions', [])
+        try:
+            cmd = build_command(run)
+        except ValueError as e:
+            self.logger.error(f"[{ref}] {e}")
+            conditions = set_condition(conditions, 'Failed', 'True', 'InvalidSpec', str(e))
+            self.patch_status(run, {'phase': FAILED, 'completionTime': now(), 'conditions': conditions,
+                                    'observedGeneration': meta.get('generation')})
+            return
+
+        log_path = self.log_path(run)
+        log_path.parent.mkdir(parents=True, exist_ok=True)
+        self.logger.info(f"[{ref}] {'Resuming' if resumed else 'Starting'}: {' '.join(cmd)}")
+        started = time.time()
+        log_file = open(log_path, 'a')
+        env = dict(os.environ, VIRTBENCH_REPO=str(self.repo_root))
+        process = subprocess.Popen(cmd, cwd=self.repo_root, env=env, stdout=log_file, stderr=subprocess.STDOUT)
+
+        conditions = set_condition(conditions, 'Running', 'True', 'Resumed' if resumed else 'Started',
+                                   'Operator restarted; resuming with the same run UUID' if resumed else '')
+        status = {'phase': RUNNING, 'command': cmd, 'log': str(log_path), 'conditions': conditions,
+                  'observedGeneration': meta.get('generation')}
+        if not resumed:
+            status['startTime'] = now()
+        self.patch_status(run, status)
+
+        with self.lock:
+            self.active[meta['uid']] = {'process': process, 'run': run}
+        threading.Thread(target=self.wait, args=(run, process, log_file, started), daemon=True).start()
+
+    def wait(self, run: Dict, process: subprocess.Popen, log_file, started: float):
+        meta = run['metadata']
+        ref = f"{meta['namespace']}/{meta['name']}"
+        returncode = process.wait()
+        log_file.close()
+        with self.lock:
+            if run.get('_deleted') or self.stopping:
+                self.active.pop(meta['uid'], None)
+                return
+
+        # Results of this run: directories with result files written since it started
+        files = results_since(self.repo_root / 'results', started, exclude='operator')
+        status = {
+            'phase': SUCCEEDED if returncode == 0 else FAILED,
+            'completionTime': now(),
+            'exitCode': returncode,
+            'results': sorted({str(p.parent) for p in files}),
+        }
+        spec_results = (run.get('spec') or {}).get('results') or {}
+        if spec_results.get('configMap') and files:
+            status['resultsConfigMap'] = self.write_configmap(run, files)
+
+        conditions = set_condition(run.get('status', {}).get('conditions', []), 'Running', 'False', 'Finished')
+        if returncode == 0:
+            conditions = set_condition(conditions, 'Complete', 'True', 'Succeeded')
+        else:
+            with open(self.log_path(run), errors='replace') as f:
+                tail = ''.join(f.readlines()[-LOG_TAIL_LINES:])
+            conditions = set_condition(conditions, 'Failed', 'True', 'WorkloadFailed',
+                                       f"exit code {returncode}; last log lines:\n{tail}")
+        status['conditions'] = conditions
+        self.patch_status(run, status)
+        with self.lock:
+            self.finished.add(meta['uid'])
+            self.active.pop(meta['uid'], None)
+        self.logger.info(f"[{ref}] {status['phase']} (exit code {returncode}) "
+                         f"after {time.time() - started:.0f}s")
+
+    def stop(self, uid: str, reason: str):
+        with self.lock:
+            entry = self.active.get(uid)
+            if not entry:
+                return
+            entry['run']['_deleted'] = True
+        meta = entry['run']['metadata']
+        self.logger.info(f"[{meta['namespace']}/{meta['name']}] Stopping: {reason}")
+        entry['process'].send_signal(signal.SIGINT)
+
+    # --- Reconcile loop ---
+
+    def reconcile(self):
+        runs = self.list_runs()
+        if runs is None:
+            return
+        uids = {r['metadata']['uid'] for r in runs}
+        with self.lock:
+            orphaned = [uid for uid in self.active if uid not in uids]
+        for uid in orphaned:
+            self.stop(uid, 'VirtBenchRun was deleted')
+
+        for run in runs:
+            meta = run['metadata']
+            phase = (run.get('status') or {}).get('phase')
+            with self.lock:
+                if meta['uid'] in self.active or meta['uid'] in self.finished or phase in (SUCCEEDED, FAILED):
+                    continue
+                busy = len(self.active) >= self.args.max_concurrent
+            if meta.get('deletionTimestamp'):
+                continue
+            if busy:
+                if phase != PENDING:
+                    conditions = set_condition((run.get('status') or {}).get('conditions', []),
+                                               'Running', 'False', 'Queued',
+                                               f"Waiting for a free slot (--max-concurrent {self.args.max_concurrent})")
+                    self.patch_status(run, {'phase': PENDING, 'conditions': conditions})
+                continue
+            self.start(run, resumed=phase == RUNNING)
+
+    def run_forever(self):
+        def shutdown(signum, frame):
+            self.stopping = True
+
+        signal.signal(signal.SIGTERM, shutdown)
+        signal.signal(signal.SIGINT, shutdown)
+        self.logger.info(f"Watching VirtBenchRuns in {self.args.namespace or 'all namespaces'} "
+                         f"(max {self.args.max_concurrent} concurrent, poll every {self.args.poll_interval}s)")
+        while not self.stopping:
+            try:
+                self.reconcile()
+            except Exception as e:
+                self.logger.error(f"Reconcile failed: {e}")
+            for _ in range(self.args.poll_interval):
+                if self.stopping:
+                    break
+                time.sleep(1)
+
+        # Runs left Running are resumed by the next operator instance
+        with self.lock:
+            processes = [entry['process'] for entry in self.active.values()]
+        self.logger.info(f"Shutting down; interrupting {len(processes)} active run(s)")
+        for process in processes:
+            process.send_signal(signal.SIGINT)
+        for process in processes:
+            process.wait()
+
+
+def parse_args():
+    parser = argparse.ArgumentParser(description='Run VirtBenchRun custom resources (benchmark operator)')
+    parser.add_argument('--namespace', default=None,
+                        help='Only reconcile VirtBenchRuns in this namespace (default: all namespaces)')
+    parser.add_argument('--poll-interval', type=int, default=DEFAULT_POLL_INTERVAL,
+                        help=f'Seconds between reconciles (default: {DEFAULT_POLL_INTERVAL})')
+    parser.add_argument('--max-concurrent', type=int, default=DEFAULT_MAX_CONCURRENT,
+                        help=f'Runs executed at the same time (default: {DEFAULT_MAX_CONCURRENT})')
+    parser.add_argument('--results-dir', default=None,
+                        help='Directory for run logs (default: <repo>/results)')
+    parser.add_argument('--log-file', default=None, help='Log file path')
+    parser.add_argument('--log-level', default='INFO',
+                        choices=['DEBUG', 'INFO', 'WARNING', 'ERROR'], help='Logging level (default: INFO)')
+    args = parser.parse_args()
+    if args.max_concurrent < 1:
+        parser.error("--max-concurrent must be >= 1")
+    if args.poll_interval < 1:
+        parser.error("--poll-interval must be >= 1")
+    args.results_dir = args.results_dir or os.path.join(os.path.dirname(os.path.abspath(__file__)), '..', 'results')
+    return args
+
+
+def main():
+    args = parse_args()
+    logger = setup_logging(args.log_file, args.log_level)
+    Operator(args, logger).run_forever()
+
+
+if __name__ == '__main__':
+    main()
@@ -9,21 +9,82 @@ import json
 import os
 import sys
 from pathlib import Path
+from uuid import uuid4
 
 from virtbench.common import find_repo_root
+from virtbench.utils.assertions import load_assertion_file
+from virtbench.utils.multicluster import resolve_clusters
+from virtbench.utils.scheduling import DEFAULT_TAINT, load_affinity_file, parse_node_selector, parse_taint
 from virtbench.commands import (
     datasource_clone,
+    descheduler,
+    doctor,
     migration,
     chaos,
     failure_recovery,
     fio,
     elbencho,
     disk_ops,
+    estimate,
+    images,
+    node_drain,
+    plugins,
+    prewarm,
+    random_workload,
+    results,
+    run,
+    serve,
+    service_exposure,
+    serve_results,
+    soak,
+    template,
+    tune,
     validate,
     version,
+    virtbench_operator,
+    vm_clone,
+    vm_lifecycle,
+    lifecycle,
     vm_ops,
+    volume_hotplug,
+    volume_resize,
 )
 
+# Transient API error classes of utils/retry.py, validated here for --retry-on
+RETRY_ERROR_CLASSES = {'throttled', 'timeout', 'conflict', 'unavailable', 'connection'}
+# LimitRange keys of utils/quota.py, validated here for --namespace-limit-range
+LIMIT_RANGE_KEYS = ('max', 'min', 'default', 'defaultRequest', 'maxLimitRequestRatio')
+
+
+def check_resource_list(value: str, param_hint: str, limit_range: bool = False):
+    """Validate a name=quantity,... option value (of --namespace-quota/--namespace-limit-range)."""
+    for entry in (e.strip() for e in value.split(',') if e.strip()):
+        name, sep, quantity = entry.partition('=')
+        if not sep or not name.strip() or not quantity.strip():
+            raise click.BadParameter(f"expected name=quantity, got '{entry}'", param_hint=param_hint)
+        kind, _, resource = name.strip().partition('.')
+        if limit_range and (kind not in LIMIT_RANGE_KEYS or not resource):
+            raise click.BadParameter(f"expected {{{','.join(LIMIT_RANGE_KEYS)}}}.<resource>, got '{name}'",
+                                     param_hint=param_hint)
+
+
+def check_cloud_init_file(path: str):
+    """Validate a --cloud-init file: #cloud-config user data (a YAML mapping) or a #! script."""
+    import yaml
+
+    with open(path) as f:
+        content = f.read().lstrip()
+    if content.startswith('#!'):
+        return
+    if not content.startswith('#cloud-config'):
+        raise click.BadParameter(f"{path}: expected #cloud-config user data or a #! script", param_hint='--cloud-init')
+    try:
+        config = yaml.safe_load(content)
+    except yaml.YAMLError as e:
+        raise click.BadParameter(f"{path}: invalid cloud-config: {e}", param_hint='--cloud-init')
+    if not isinstance(config, dict):
+        raise click.BadParameter(f"{path}: cloud-config must be a mapping", param_hint='--cloud-init')
+
 
 class Context:
     """Global context for sharing state between commands"""
@@ -32,20 +93,29 @@ class Context:
         self.log_level = 'info'
         self.log_file = None
         self.kubeconfig = None
+        self.clusters = []
+        self.parallel_clusters = False
         self.timeout = '4h'
         self.uuid = None
+        self.dry_run = False
+        self.tui = False
+        self.output = 'table'
         self.repo_root = None
+        self.repo_root_error = None
     
-    def initialize(self):
-        """Initialize context (find repo root)"""
+    def initialize(self, required: bool = True):
+        """Initialize context (find repo root); without required, a missing root is recorded instead"""
         try:
             self.repo_root = find_repo_root()
         except RuntimeError as e:
+            if not required:
+                self.repo_root_error = str(e)
+                return
             click.echo(f"Error: {e}", err=True)
             raise click.Abort()
 
 
-@click.group(context_settings={'help_option_names': ['-h', '--help']})
+@click.group(cls=plugins.PluginGroup, context_settings={'help_option_names': ['-h', '--help']})
 @click.version_option(version='2.0.0', prog_name='virtbench')
 @click.option('--log-level',
               default='info',
@@ -56,19 +126,127 @@ class Context:
               help='Log file path (auto-generated if not specified)')
 @click.option('--kubeconfig', 
               type=click.Path(exists=True),
-              help='Path to kubeconfig file')
+              multiple=True,
+              help='Path to kubeconfig file; repeat to run the workload on several clusters')
+@click.option('--contexts',
+              help='Comma-separated kubeconfig contexts to run the workload on, one run per context')
+@click.option('--parallel-clusters', is_flag=True,
+              help='With several clusters, run them in parallel instead of one after another')
 @click.option('--timeout', 
               default='4h',
               help='Benchmark timeout (default: 4h)')
 @click.option('--uuid', 
-              help='Benchmark UUID (auto-generated if not specified)')
+              help='Benchmark UUID (auto-generated if not specified); reuse it to resume a failed run')
+@click.option('--metrics-config',
+              type=click.Path(exists=True),
+              help='YAML file of PromQL queries to evaluate over each run and embed in results')
+@click.option('--notify-config',
+              type=click.Path(exists=True),
+              help='YAML file of webhooks/commands to notify at phase boundaries and run completion')
+@click.option('--correlation-file',
+              help='Guest path where cloud-init writes the run UUID and VM correlation ID (e.g. /etc/virtbench-run.json)')
+@click.option('--cloud-init', 'cloud_init', type=click.Path(exists=True, dir_okay=False), multiple=True,
+              help='#cloud-config file merged into the user data of every created VM, or #! script run on '
+                   'first boot (repeatable)')
+@click.option('--ssh-key', type=click.Path(exists=True, dir_okay=False), multiple=True,
+              help='Public SSH key file authorized for the default user of every created VM (repeatable)')
+@click.option('--guest-user', help='Name of the default user of every created VM (pass the same --vm-user)')
+@click.option('--guest-package', multiple=True,
+              help='Package cloud-init installs in every created VM, e.g. fio (repeatable)')
+@click.option('--guest-hostname',
+              help='Hostname of every created VM from {namespace} and {vm}, e.g. {namespace}-{vm}')
+@click.option('--node-selector',
+              help='Node labels every created VM must run on, e.g. node-role.kubernetes.io/worker=,disktype=ssd')
+@click.option('--affinity-file', type=click.Path(exists=True, dir_okay=False),
+              help='YAML pod affinity (nodeAffinity, podAffinity, podAntiAffinity) for every created VM')
+@click.option('--anti-affinity', type=click.Choice(['soft', 'hard'], case_sensitive=False),
+              help='Keep the VMs of the run on different nodes: preferred (soft) or required (hard)')
+@click.option('--taint-nodes', metavar='SELECTOR',
+              help='Label selector of nodes tainted for the run, e.g. node-role.kubernetes.io/virt=; '
+                   'the taints are removed afterwards')
+@click.option('--taint', multiple=True, metavar='KEY[=VALUE]:EFFECT',
+              help=f'Taint of the --taint-nodes nodes, tolerated by every created VM (repeatable, '
+                   f'default {DEFAULT_TAINT})')
+@click.option('--dry-run', is_flag=True,
+              help='Print the manifests and API actions the workload would apply, without changing the cluster')
+@click.option('--tui', is_flag=True,
+              help='Show a live dashboard of VM states, progress, throughput and errors (interactive terminals only)')
+@click.option('--output',
+              type=click.Choice(['table', 'json', 'yaml'], case_sensitive=False),
+              default='table',
+              help='Format of command summaries on stdout; json/yaml send logs and banners to stderr')
+@click.option('--kube-api-qps', type=click.FloatRange(min=0),
+              help='Maximum API requests per second of each workload process (default: unlimited)')
+@click.option('--kube-api-burst', type=click.IntRange(min=1),
+              help='API requests allowed back-to-back above --kube-api-qps (default: 10)')
+@click.option('--retries', type=click.IntRange(min=0),
+              help='Retries per API call on transient errors (default: 3, 0 disables)')
+@click.option('--retry-backoff', type=click.FloatRange(min=0),
+              help='Delay before the first retry in seconds, doubled per retry up to 30s (default: 1)')
+@click.option('--retry-on',
+              help='Comma-separated transient error classes to retry: '
+                   'throttled,timeout,conflict,unavailable,connection (default: all)')
+@click.option('--outlier-sigma', type=click.FloatRange(min=0, min_open=True),
+              help='Flag VMs slower than the mean by more than N standard deviations as outliers (default: 3)')
+@click.option('--latency-histograms', is_flag=True,
+              help='Also save VM creation latencies (create call, scheduling, boot, ping) as HDR histograms '
+                   '(.hlog interval log and .hgrm percentile files) with the results')
+@click.option('--admission-latency', is_flag=True,
+              help='Measure the admission part of every VM create call (server-side dry run) and the time '
+                   'spent in each admission webhook (API server metrics)')
+@click.option('--collect-diagnostics',
+              type=click.Choice(['always', 'on-failure', 'never'], case_sensitive=False),
+              help='When to write a diagnostics bundle (component logs, failing resources, events, node '
+                   'conditions) to <results>/diagnostics (default: on-failure)')
+@click.option('--node-sampling-interval', type=click.FloatRange(min=0),
+              help='Seconds between node CPU/memory/pressure samples saved with the results (default: 15, 0 disables)')
+@click.option('--no-events', is_flag=True,
+              help='Do not capture Kubernetes events of the run (saved to events.json with the results)')
+@click.option('--assert', 'assert_file', type=click.Path(exists=True, dir_okay=False),
+              help='YAML file of pass/fail thresholds (e.g. "p95_running_time < 180s") checked against the '
+                   'run summaries; a failed assertion exits with code 10')
+@click.option('--junit-report', type=click.Path(dir_okay=False),
+              help='Write a JUnit XML report of the run (one test case per VM and per assertion) to this path')
+@click.option('--namespace-quota',
+              help='ResourceQuota hard limits for every namespace the workload creates, '
+                   'e.g. requests.cpu=8,requests.memory=32Gi,count/virtualmachines.kubevirt.io=10')
+@click.option('--namespace-limit-range',
+              help='Per-container LimitRange for every namespace the workload creates, '
+                   'e.g. max.cpu=4,default.memory=2Gi (keys: max, min, default, defaultRequest, maxLimitRequestRatio)')
+@click.option('--tenants', type=click.IntRange(min=1),
+              help='Split the namespaces the workload creates into N tenants and run the API calls in each '
+                   'as its tenant (impersonation), to measure with RBAC, admission and quota per tenant')
+@click.option('--tenant-identity', type=click.Choice(['user', 'serviceaccount'], case_sensitive=False),
+              default='user', show_default=True,
+              help='Identity tenants are impersonated as with --tenants')
+@click.option('--results-db', is_flag=True,
+              help='After each workload, import the results folder into <results>/results.db (SQLite)')
+@click.option('--storage-provider',
+              type=click.Choice(['auto', 'portworx', 'odf', 'ceph', 'lvms', 'longhorn', 'csi'], case_sensitive=False),
+              help='Storage provider for backend version, health and telemetry (default: auto, '
+                   'detected from the provisioner of the storage class)')
+@click.option('--storage-namespace',
+              help='Namespace of the storage backend (default per provider, e.g. portworx or kube-system '
+                   'for Portworx, openshift-storage for ODF and LVMS, rook-ceph for Ceph)')
+@click.option('--platform',
+              type=click.Choice(['auto', 'openshift', 'harvester', 'kubernetes'], case_sensitive=False),
+              help='Cluster platform for platform-specific defaults (DataSource namespace, SCCs, '
+                   'storage class) '
+                   '(default: auto, detected from the API groups of the cluster)')
+@click.option('--skip-permission-check', is_flag=True,
+              help='Run workloads without first auditing the permissions they need (SelfSubjectAccessReview)')
 @click.pass_context
-def cli(ctx, log_level, log_file, kubeconfig, timeout, uuid):
+def cli(ctx, log_level, log_file, kubeconfig, contexts, parallel_clusters, timeout, uuid, metrics_config, notify_config, correlation_file,
+        cloud_init, ssh_key, guest_user, guest_package, guest_hostname,
+        node_selector, affinity_file, anti_affinity, taint_nodes, taint, dry_run, tui, output, kube_api_qps, kube_api_burst, retries, retry_backoff, retry_on, outlier_sigma,
+        latency_histograms, admission_latency, collect_diagnostics, node_sampling_interval, no_events, assert_file, junit_report, namespace_quota, namespace_limit_range, tenants,
+        tenant_identity, results_db, storage_provider, storage_namespace, platform, skip_permission_check):
     """
     virtbench - KubeVirt Benchmark Suite
     
     Performance testing toolkit for KubeVirt virtual machines running on
-    OpenShift Container Platform (OCP).
+    OpenShift Container Platform (OCP), Harvester or Kubernetes with upstream
+    KubeVirt.
     
     \b
     Available Commands:
@@ -79,8 +257,30 @@ def cli(ctx, log_level, log_file, kubeconfig, timeout, uuid):
       fio                  Run FIO benchmark across VMs
       elbencho             Manage elbencho workloads on VMs
       disk-ops             Run disk hotplug/coldplug benchmark
+      volume-hotplug       Run DataVolume hotplug attach/detach benchmark
+      volume-resize        Run PVC expansion and in-guest grow benchmark
+      service-exposure     Run Service/DNS readiness benchmark, after exposure and migration
+      vm-clone             Run VirtualMachineClone benchmark
+      vm-lifecycle         Run per-verb VM lifecycle latency benchmark
+      lifecycle            Run start/stop/restart/pause/unpause benchmark on existing VMs
+      node-drain           Run node drain (eviction) evacuation benchmark
+      soak                 Run long-haul soak test (operation mix over hours or days)
+      random-workload      Run seeded, replayable random VM operations and sizes
+      descheduler          Run descheduler rebalancing benchmark
       vm-ops               VM operations (drain, rebalance, snapshot, blkdiscard, power)
       validate-cluster     Validate cluster prerequisites
+      doctor               Check local prerequisites (kubeconfig, permissions, CRDs, repo, Python)
+      estimate             Estimate whether a planned VM count fits on the cluster
+      prewarm              Pre-pull VM images and wait for DataSources before a run
+      images               List, upload and import guest OS images (DataSources)
+      template             Lint VM templates (schema, DataSources, storage classes, workload settings)
+      serve-results        Browse benchmark results in a web app
+      results              List, show, query, index and prune past runs
+      run                  Run a workload once or on a recurring schedule
+      tune                 Apply, revert and run workloads under KubeVirt tuning profiles
+      serve                Serve a REST API to run benchmarks remotely
+      operator             Run VirtBenchRun custom resources (benchmark operator)
+      plugins              List plugin workloads (Python plugins, virtbench-<name> executables)
       version              Print version information
 
     \b
@@ -96,30 +296,197 @@ def cli(ctx, log_level, log_file, kubeconfig, timeout, uuid):
 
       # Manage elbencho workloads
       virtbench elbencho -p datasource-clone -s 1 -e 10 -n rhel-elbencho-1 -a status
+
+      # Review what a large run would create before running it
+      virtbench --dry-run datasource-clone --start 1 --end 200 --storage-class YOUR-STORAGE-CLASS
+
+      # Follow a long run on a live dashboard
+      virtbench --tui datasource-clone --start 1 --end 500 --storage-class YOUR-STORAGE-CLASS
+
+      # Compare two clusters (e.g. two storage backends) with the same workload
+      virtbench --kubeconfig px.kubeconfig --kubeconfig ceph.kubeconfig --parallel-clusters \\
+          vm-clone --start 1 --end 20 --save-results
+
+      # Gate a CI pipeline on the validation result
+      virtbench --output json validate-cluster | jq -e '.data.status != "fail"'
     
     \b
     Global Flags:
       --log-level          Log level: debug, info, warn, error (default: info)
       --log-file           Log file path (auto-generated if not specified)
-      --kubeconfig         Path to kubeconfig file
+      --kubeconfig         Path to kubeconfig file (repeat for several clusters)
+      --contexts           Comma-separated kubeconfig contexts, one run per context
+      --parallel-clusters  Run several clusters in parallel (default: sequentially)
       --timeout            Benchmark timeout (default: 4h)
       --uuid               Benchmark UUID (auto-generated if not specified)
+      --metrics-config     PromQL custom metrics definition file (YAML)
+      --notify-config      Phase notification file (YAML)
+      --correlation-file   Guest path for the run/VM correlation IDs (via cloud-init)
+      --cloud-init         #cloud-config file or #! script for every created VM (repeatable)
+      --ssh-key            Public SSH key authorized in every created VM (repeatable)
+      --guest-user         Default user of every created VM
+      --guest-package      Package installed in every created VM (repeatable)
+      --guest-hostname     Per-VM hostname template, e.g. {namespace}-{vm}
+      --node-selector      Node labels every created VM must run on (key=value,...)
+      --affinity-file      YAML pod affinity for every created VM
+      --anti-affinity      Keep the run's VMs on different nodes: soft, hard
+      --taint-nodes        Label selector of nodes tainted for the run
+      --taint              Taint for --taint-nodes, tolerated by every created VM
+      --dry-run            Print the plan (manifests and API actions) without executing it
+      --tui                Live dashboard of VM states, progress and errors (plain logs if not a TTY)
+      --output             Summary format on stdout: table, json, yaml (default: table)
+      --kube-api-qps       API requests per second per workload process (default: unlimited)
+      --kube-api-burst     API request burst above --kube-api-qps (default: 10)
+      --retries            Retries per API call on transient errors (default: 3)
+      --retry-backoff      First retry delay in seconds, doubled per retry (default: 1)
+      --retry-on           Transient error classes to retry (default: all)
+      --outlier-sigma      Outlier threshold in standard deviations above the mean (default: 3)
+      --latency-histograms Save VM creation latencies as HDR histograms (.hlog/.hgrm)
+      --admission-latency  Measure admission time per VM create and per admission webhook
+      --assert             Pass/fail thresholds file (YAML); exit code 10 when one fails
+      --junit-report       JUnit XML report path for CI (one test case per VM and per assertion)
+      --namespace-quota    ResourceQuota hard limits for created namespaces (name=quantity,...)
+      --namespace-limit-range  Per-container LimitRange for created namespaces (max.cpu=4,...)
+      --tenants            Run as N impersonated tenants with per-tenant latency comparison
+      --tenant-identity    Tenant identity: user, serviceaccount (default: user)
+      --results-db         Import results into <results>/results.db after each workload
+      --storage-provider   Storage backend: auto, portworx, odf, ceph, lvms, longhorn, csi (default: auto)
+      --storage-namespace  Namespace of the storage backend (default per provider)
+      --platform           Cluster platform: auto, openshift, harvester, kubernetes (default: auto)
+      --skip-permission-check  Run workloads without the RBAC permission audit
     """
     # Create context object
     ctx.obj = Context()
     ctx.obj.log_level = log_level.lower()
     ctx.obj.log_file = log_file
-    ctx.obj.kubeconfig = kubeconfig
+    try:
+        ctx.obj.clusters = resolve_clusters(kubeconfig, contexts)
+    except ValueError as e:
+        raise click.UsageError(str(e))
+    ctx.obj.parallel_clusters = parallel_clusters
+    # With several clusters or --contexts, each run gets its own KUBECONFIG (see virtbench/utils/multicluster.py)
+    ctx.obj.kubeconfig = kubeconfig[0] if kubeconfig and len(kubeconfig) == 1 and not contexts else None
     ctx.obj.timeout = timeout
-    ctx.obj.uuid = uuid
+    ctx.obj.uuid = uuid or str(uuid4())
+    ctx.obj.dry_run = dry_run
+    ctx.obj.tui = tui
+    ctx.obj.output = output.lower()
 
-    if kubeconfig:
-        os.environ['KUBECONFIG'] = kubeconfig
+    if ctx.obj.kubeconfig:
+        os.environ['KUBECONFIG'] = ctx.obj.kubeconfig
+    if metrics_config:
+        os.environ['VIRTBENCH_METRICS_CONFIG'] = os.path.abspath(metrics_config)
+    if notify_config:
+        os.environ['VIRTBENCH_NOTIFY_CONFIG'] = os.path.abspath(notify_config)
+    if correlation_file:
+        os.environ['VIRTBENCH_CORRELATION_FILE'] = correlation_file
+    if cloud_init:
+        for path in cloud_init:
+            check_cloud_init_file(path)
+        os.environ['VIRTBENCH_CLOUD_INIT'] = os.pathsep.join(os.path.abspath(p) for p in cloud_init)
+    if ssh_key:
+        os.environ['VIRTBENCH_SSH_KEYS'] = os.pathsep.join(os.path.abspath(p) for p in ssh_key)
+    if guest_user:
+        os.environ['VIRTBENCH_GUEST_USER'] = guest_user
+    if guest_package:
+        os.environ['VIRTBENCH_GUEST_PACKAGES'] = ','.join(guest_package)
+    if guest_hostname:
+        try:
+            guest_hostname.format(namespace='ns', vm='vm')
+        except (KeyError, IndexError, ValueError):
+            raise click.BadParameter("only {namespace} and {vm} can be used", param_hint='--guest-hostname')
+        os.environ['VIRTBENCH_GUEST_HOSTNAME'] = guest_hostname
+    if node_selector:
+        try:
+            parse_node_selector(node_selector)
+        except ValueError as e:
+            raise click.BadParameter(str(e), param_hint='--node-selector')
+        os.environ['VIRTBENCH_NODE_SELECTOR'] = node_selector
+    if affinity_file:
+        try:
+            load_affinity_file(affinity_file)
+        except ValueError as e:
+            raise click.BadParameter(str(e), param_hint='--affinity-file')
+        os.environ['VIRTBENCH_AFFINITY_FILE'] = os.path.abspath(affinity_file)
+    if anti_affinity:
+        os.environ['VIRTBENCH_ANTI_AFFINITY'] = anti_affinity.lower()
+    for value in taint:
+        try:
+            parse_taint(value)
+        except ValueError as e:
+            raise click.BadParameter(str(e), param_hint='--taint')
+    if taint_nodes:
+        os.environ['VIRTBENCH_TAINT_NODES'] = taint_nodes
+    if taint or taint_nodes:
+        os.environ['VIRTBENCH_TAINTS'] = ','.join(taint) or DEFAULT_TAINT
+    if dry_run:
+        os.environ['VIRTBENCH_DRY_RUN'] = '1'
+    if tui:
+        os.environ['VIRTBENCH_TUI'] = '1'
+    if ctx.obj.output != 'table':
+        os.environ['VIRTBENCH_OUTPUT'] = ctx.obj.output
+        # Banners and progress go to stderr so stdout carries only the summary document
+        sys.stdout = sys.stderr
+    if kube_api_qps is not None:
+        os.environ['VIRTBENCH_KUBE_API_QPS'] = str(kube_api_qps)
+    if kube_api_burst is not None:
+        os.environ['VIRTBENCH_KUBE_API_BURST'] = str(kube_api_burst)
+    if retries is not None:
+        os.environ['VIRTBENCH_RETRIES'] = str(retries)
+    if retry_backoff is not None:
+        os.environ['VIRTBENCH_RETRY_BACKOFF'] = str(retry_backoff)
+    if retry_on:
+        classes = [c.strip() for c in retry_on.split(',') if c.strip()]
+        unknown = sorted(set(classes) - RETRY_ERROR_CLASSES)
+        if unknown:
+            raise click.BadParameter(f"unknown error class(es): {', '.join(unknown)}", param_hint='--retry-on')
+        os.environ['VIRTBENCH_RETRY_ON'] = ','.join(classes)
+    if outlier_sigma is not None:
+        os.environ['VIRTBENCH_OUTLIER_SIGMA'] = str(outlier_sigma)
+    if latency_histograms:
+        os.environ['VIRTBENCH_LATENCY_HISTOGRAMS'] = '1'
+    if admission_latency:
+        os.environ['VIRTBENCH_ADMISSION_LATENCY'] = '1'
+    if collect_diagnostics:
+        os.environ['VIRTBENCH_DIAGNOSTICS'] = collect_diagnostics.lower()
+    if node_sampling_interval is not None:
+        os.environ['VIRTBENCH_NODE_SAMPLING_INTERVAL'] = str(node_sampling_interval)
+    if no_events:
+        os.environ['VIRTBENCH_EVENTS'] = '0'
+    if assert_file:
+        try:
+            load_assertion_file(assert_file)
+        except ValueError as e:
+            raise click.BadParameter(str(e), param_hint='--assert')
+        os.environ['VIRTBENCH_ASSERT_FILE'] = os.path.abspath(assert_file)
+    if junit_report:
+        os.environ['VIRTBENCH_JUNIT_REPORT'] = os.path.abspath(junit_report)
+    if namespace_quota:
+        check_resource_list(namespace_quota, '--namespace-quota')
+        os.environ['VIRTBENCH_NAMESPACE_QUOTA'] = namespace_quota
+    if namespace_limit_range:
+        check_resource_list(namespace_limit_range, '--namespace-limit-range', limit_range=True)
+        os.environ['VIRTBENCH_NAMESPACE_LIMIT_RANGE'] = namespace_limit_range
+    if tenants:
+        os.environ['VIRTBENCH_TENANTS'] = str(tenants)
+        os.environ['VIRTBENCH_TENANT_IDENTITY'] = tenant_identity.lower()
+    if results_db:
+        os.environ['VIRTBENCH_RESULTS_DB'] = '1'
+    if storage_provider:
+        os.environ['VIRTBENCH_STORAGE_PROVIDER'] = storage_provider.lower()
+    if storage_namespace:
+        os.environ['VIRTBENCH_STORAGE_NAMESPACE'] = storage_namespace
+    if platform:
+        os.environ['VIRTBENCH_PLATFORM'] = platform.lower()
+    if skip_permission_check:
+        os.environ['VIRTBENCH_PERMISSION_CHECK'] = '0'
 
+    # Created resources are labeled with the UUID; a rerun with the same UUID adopts them
+    os.environ['VIRTBENCH_UUID'] = ctx.obj.uuid
     os.environ['VIRTBENCH_COMMAND_ARGS'] = json.dumps(['virtbench'] + sys.argv[1:])
     
-    # Initialize context (find repo root)
-    ctx.obj.initialize()
+    # Initialize context (find repo root); doctor reports a missing root itself
+    ctx.obj.initialize(required=ctx.invoked_subcommand != 'doctor')
 
 
 # Register subcommands
@@ -131,7 +498,29 @@ cli.add_command(fio.fio)
 cli.add_command(elbencho.elbencho)
 cli.add_command(disk_ops.disk_ops)
 cli.add_command(vm_ops.vm_ops)
+cli.add_command(volume_hotplug.volume_hotplug)
+cli.add_command(volume_resize.volume_resize)
+cli.add_command(service_exposure.service_exposure)
+cli.add_command(vm_clone.vm_clone)
+cli.add_command(vm_lifecycle.vm_lifecycle)
+cli.add_command(lifecycle.lifecycle)
+cli.add_command(soak.soak)
+cli.add_command(random_workload.random_workload)
+cli.add_command(node_drain.node_drain)
+cli.add_command(descheduler.descheduler)
 cli.add_command(validate.validate_cluster)
+cli.add_command(doctor.doctor)
+cli.add_command(estimate.estimate)
+cli.add_command(prewarm.prewarm)
+cli.add_command(images.images)
+cli.add_command(template.template)
+cli.add_command(serve_results.serve_results)
+cli.add_command(results.results)
+cli.add_command(run.run)
+cli.add_command(tune.tune)
+cli.add_command(serve.serve)
+cli.add_command(virtbench_operator.operator)
+cli.add_command(plugins.plugins)
 cli.add_command(version.version)
 
 
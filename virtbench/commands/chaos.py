@@ -3,35 +3,54 @@
 Chaos benchmark command
 """
 import click
-import subprocess
 import sys
 from pathlib import Path
 from rich.console import Console
 
 from virtbench.common import print_banner, build_python_command, generate_log_filename
+from virtbench.utils.orchestration import run_workload
+from virtbench.commands.datasource_clone import PLACEMENT_STRATEGIES
 
 console = Console()
 
 
 @click.command('chaos-benchmark')
 @click.option('--storage-class', required=False, help='Storage class name (required unless --cleanup-only)')
+@click.option('--data-storage-class', help='Storage class for data volumes (default: same as --storage-class)')
 @click.option('--concurrency', '-c', required=True, type=int, help='Number of concurrent operations (REQUIRED)')
+@click.option('--qps', default=0.0, type=float,
+              help='Max VM operations started per second (0 disables rate limiting)')
+@click.option('--burst', default=10, type=int, help='Max VM operations started back-to-back when --qps is set')
 @click.option('--namespace', '-n', default='virt-chaos-benchmark', help='Namespace for test resources')
 @click.option('--vms', default=5, type=int, help='Number of VMs to create per iteration')
 @click.option('--max-iterations', default=0, type=int, help='Maximum number of iterations (0 for unlimited)')
+@click.option('--placement', type=click.Choice(PLACEMENT_STRATEGIES), default='none',
+              help='How VMs are placed on nodes across iterations (none lets the scheduler decide)')
+@click.option('--placement-nodes', help='Comma-separated nodes the placement strategy uses (required by nodes)')
+@click.option('--max-vms-per-node', type=click.IntRange(1), help='VMs per node before pack moves to the next node')
+@click.option('--zone', help='Comma-separated zones; only place VMs on nodes in these zones')
+@click.option('--topology-key', help='Node label of the zone, e.g. a rack label (default: topology.kubernetes.io/zone)')
+@click.option('--topology-spread', is_flag=True, help='Spread VMs evenly across zones (same as --placement zone)')
+@click.option('--warmup-iterations', default=0, type=click.IntRange(0),
+              help='Unmeasured iterations before the measured ones (up to N, or until steady state)')
+@click.option('--steady-state-cv', default=0.1, type=click.FloatRange(0, min_open=True),
+              help='Coefficient of variation across warm-up iterations that counts as steady state')
 @click.option('--data-volume-count', default=1, type=int, help='Number of data volumes per VM (default: 1)')
 @click.option('--min-vol-size', default='30Gi', help='Minimum volume size (e.g., 30Gi, 100Mi)')
 @click.option('--min-vol-inc-size', default='10Gi', help='Volume size increment for resize (e.g., 10Gi, 50Mi)')
 @click.option('--vm-yaml', default='examples/vm-templates/vm-template.yaml', help='Path to VM YAML template')
 @click.option('--vm-name', default='rhel-9-vm', help='Base VM name')
 @click.option('--datasource-name', default='rhel9', help='DataSource name')
-@click.option('--datasource-namespace', default='openshift-virtualization-os-images', help='DataSource namespace')
+@click.option('--datasource-namespace',
+              help='DataSource namespace (default: per platform, or wherever the DataSource is found)')
 @click.option('--vm-memory', default='2048M', help='VM memory')
 @click.option('--vm-cpu-cores', default=1, type=int, help='VM CPU cores')
 @click.option('--skip-resize', is_flag=True, help='Skip volume resize phase')
 @click.option('--skip-clone', is_flag=True, help='Skip volume clone phase')
 @click.option('--skip-snapshot', is_flag=True, help='Skip VM snapshot phase')
 @click.option('--skip-restart', is_flag=True, help='Skip VM restart phase')
+@click.option('--legacy', is_flag=True,
+              help='Run iterations with the previous inline loop instead of the phase engine (no per-phase results)')
 @click.option('--scheduling-timeout', default=120, type=int,
               help='Seconds to wait in Scheduling/Provisioning state before failing (default: 120)')
 @click.option('--vm-timeout', default=1800, type=int, help='Total timeout for VM to reach Running state (default: 1800)')
@@ -41,7 +60,7 @@ console = Console()
 @click.option('--save-results', is_flag=True, help='Save results to JSON/CSV files in results directory')
 @click.option('--results-dir', default='results', help='Directory to save results (default: results)')
 @click.option('--storage-driver', default=None,
-              help='Storage driver label for results path (for example: portworx-3.6, ceph)')
+              help='Storage driver label for results path (for example: portworx-3.6, ceph), or auto to detect it')
 @click.option('--log-file', type=click.Path(), help='Log file path (auto-generated if not specified)')
 @click.option('--log-level', default='INFO', type=click.Choice(['DEBUG', 'INFO', 'WARNING', 'ERROR']),
               help='Logging level')
@@ -102,7 +121,7 @@ def chaos_benchmark(ctx, **kwargs):
         console.print()
 
         try:
-            result = subprocess.run(cmd, cwd=repo_root)
+            result = run_workload(ctx, cmd, cwd=repo_root)
             sys.exit(result.returncode)
         except KeyboardInterrupt:
             console.print("\n[yellow]Interrupted by user[/yellow]")
@@ -127,9 +146,13 @@ def chaos_benchmark(ctx, **kwargs):
     python_args = {
         'storage-class': kwargs['storage_class'],
         'concurrency': kwargs['concurrency'],
+        'qps': kwargs['qps'],
+        'burst': kwargs['burst'],
         'namespace': kwargs['namespace'],
         'vms': kwargs['vms'],
         'max-iterations': kwargs['max_iterations'],
+        'warmup-iterations': kwargs['warmup_iterations'],
+        'steady-state-cv': kwargs['steady_state_cv'],
         'data-volume-count': kwargs['data_volume_count'],
         'min-vol-size': kwargs['min_vol_size'],
         'min-vol-inc-size': kwargs['min_vol_inc_size'],
@@ -145,6 +168,21 @@ def chaos_benchmark(ctx, **kwargs):
         'log-level': kwargs['log_level'],
     }
 
+    if kwargs.get('data_storage_class'):
+        python_args['data-storage-class'] = kwargs['data_storage_class']
+    if kwargs['placement'] != 'none':
+        python_args['placement'] = kwargs['placement']
+    if kwargs.get('placement_nodes'):
+        python_args['placement-nodes'] = [n.strip() for n in kwargs['placement_nodes'].split(',') if n.strip()]
+    if kwargs.get('max_vms_per_node'):
+        python_args['max-vms-per-node'] = kwargs['max_vms_per_node']
+    if kwargs.get('zone'):
+        python_args['zone'] = [z.strip() for z in kwargs['zone'].split(',') if z.strip()]
+    if kwargs.get('topology_key'):
+        python_args['topology-key'] = kwargs['topology_key']
+    if kwargs.get('topology_spread'):
+        python_args['topology-spread'] = True
+
     # Add skip flags
     if kwargs['skip_resize']:
         python_args['skip-resize'] = True
@@ -154,6 +192,8 @@ def chaos_benchmark(ctx, **kwargs):
         python_args['skip-snapshot'] = True
     if kwargs['skip_restart']:
         python_args['skip-restart'] = True
+    if kwargs['legacy']:
+        python_args['legacy'] = True
 
     # Add cleanup flag
     if kwargs['cleanup']:
@@ -181,7 +221,7 @@ def chaos_benchmark(ctx, **kwargs):
     console.print()
 
     try:
-        result = subprocess.run(cmd, cwd=repo_root)
+        result = run_workload(ctx, cmd, cwd=repo_root)
         sys.exit(result.returncode)
     except KeyboardInterrupt:
         console.print("\n[yellow]Interrupted by user[/yellow]")
@@ -3,33 +3,70 @@
 DataSource Clone benchmark command
 """
 import click
-import subprocess
 import sys
 from pathlib import Path
 from rich.console import Console
 
-from virtbench.utils.yaml_modifier import modify_storage_class
+from virtbench.utils.yaml_modifier import modify_storage_class, write_storage_class_template
 from virtbench.common import print_banner, build_python_command, generate_log_filename
+from virtbench.utils.orchestration import run_workload
+from virtbench.utils.tuning import run_tuning_comparison
+from virtbench.utils.instancetype import run_instancetype_sweep
+from virtbench.utils.storageclass import (
+    STORAGE_CLASS_MODES, parse_access_modes, parse_storage_classes, parse_volume_modes,
+    run_storage_class_comparison, storage_variants,
+)
 
 console = Console()
 
+DEFAULT_TEMPLATES = {
+    'linux': 'examples/vm-templates/rhel9-vm-datasource.yaml',
+    'windows': 'examples/vm-templates/windows-vm-datasource.yaml',
+}
+DEFAULT_PING_TIMEOUTS = {'linux': 300, 'windows': 1800}
+# Placement strategies of utils/placement.py
+PLACEMENT_STRATEGIES = ['none', 'spread', 'pack', 'interleave', 'nodes', 'zone']
+
 
 @click.command('datasource-clone')
 @click.option('--start', '-s', default=1, type=int, help='Start index for test namespaces')
 @click.option('--end', '-e', default=10, type=int, help='End index for test namespaces')
-@click.option('--vm-name', '-n', default='rhel-9-vm', help='VM resource name')
+@click.option('--vm-name', '-n', help='VM resource name (default: rhel-9-vm, or win2k22-vm for Windows)')
 @click.option('--vm-template',
-              default='examples/vm-templates/rhel9-vm-datasource.yaml',
-              help='Path to VM template YAML')
+              help='Path to VM template YAML (default: rhel9-vm-datasource.yaml, or '
+                   'windows-vm-datasource.yaml for Windows)')
+@click.option('--guest-os', type=click.Choice(['linux', 'windows']), default='linux',
+              help='Guest OS; Windows guests are checked via RDP/WinRM instead of ping')
 @click.option('--secret-yaml', type=click.Path(exists=True),
               help='Path to cloudinit secret YAML file (optional)')
-@click.option('--storage-class', help='Storage class name (overrides template value)')
+@click.option('--storage-class', multiple=True,
+              help='Storage class name (overrides template value); repeat or comma-separate to compare storage classes')
+@click.option('--storage-class-mode', type=click.Choice(STORAGE_CLASS_MODES), default='per-class', show_default=True,
+              help='With several storage classes: one run per class, or the VMs of one run striped across the classes')
+@click.option('--access-modes',
+              help='Comma-separated access modes of the VM volumes (RWO, RWX); several compare them, one run each')
+@click.option('--volume-modes',
+              help='Comma-separated volume modes of the VM volumes (Block, Filesystem); several compare them, one run each')
 @click.option('--namespace-prefix', default='datasource-clone', help='Namespace prefix')
-@click.option('--concurrency', '-c', default=50, type=int, help='Max parallel threads for monitoring')
+@click.option('--single-namespace',
+              help='Create all VMs in this existing namespace (for users who cannot create namespaces)')
+@click.option('--concurrency', '-c', default=50, type=int, help='Max VM creations and monitoring threads in flight')
+@click.option('--qps', default=0.0, type=float,
+              help='Max VM operations started per second (0 disables rate limiting)')
+@click.option('--burst', default=10, type=int, help='Max VM operations started back-to-back when --qps is set')
 @click.option('--poll-interval', default=1, type=int, help='Seconds between status checks')
-@click.option('--ping-timeout', default=300, type=int, help='Timeout for ping tests in seconds')
+@click.option('--ping-timeout', type=int,
+              help='Timeout for guest readiness tests in seconds (default: 300, or 1800 for Windows)')
+@click.option('--guest-agent', is_flag=True,
+              help='Also measure time to qemu-guest-agent connected and record guest OS info')
+@click.option('--guest-agent-timeout', default=300, type=int,
+              help='Seconds to wait for the guest agent after the guest is reachable')
 @click.option('--ssh-pod', default='ssh-test-pod', help='Pod name for ping tests')
 @click.option('--ssh-pod-ns', default='default', help='Namespace for SSH test pod')
+@click.option('--reachability', type=click.Choice(['checker', 'ssh-pod']), default='checker',
+              help='Check guest reachability from a managed checker pod (in --ssh-pod-ns, '
+                   'millisecond time to first response) or by pinging through --ssh-pod')
+@click.option('--checker-host-network', is_flag=True, help='Run the reachability checker pod on the host network')
 @click.option('--cleanup/--no-cleanup', default=False, help='Delete test resources after completion')
 @click.option('--cleanup-on-failure/--no-cleanup-on-failure', default=False,
               help='Clean up resources even if tests fail')
@@ -42,17 +79,71 @@ console = Console()
               help='After initial test, shutdown all VMs and test boot storm')
 @click.option('--skip-vm-creation', is_flag=True,
               help='Skip VM creation phase (use with --boot-storm to test existing VMs)')
+@click.option('--skip-capacity-check', is_flag=True,
+              help='Do not abort when the capacity preflight estimates the VMs cannot fit')
+@click.option('--gpu-device',
+              help='Give each VM GPUs of this device plugin resource (passthrough or vGPU), '
+                   'e.g. nvidia.com/GA102GL_A10')
+@click.option('--gpus-per-vm', default=1, type=click.IntRange(1), help='GPUs of --gpu-device per VM')
+@click.option('--skip-gpu-check', is_flag=True,
+              help='Do not abort when the GPU preflight finds unpermitted, unadvertised or too few devices')
+@click.option('--dedicated-cpus', is_flag=True,
+              help='Pin every vCPU to a dedicated host core (needs the static CPU manager policy)')
+@click.option('--hugepages', type=click.Choice(['2Mi', '1Gi']), help='Back guest memory with hugepages of this size')
+@click.option('--numa', is_flag=True,
+              help='Pass the host NUMA topology through to the guest (requires --dedicated-cpus and --hugepages)')
+@click.option('--compare-tuning', is_flag=True,
+              help='Run without and then with the tuning flags and compare density and timings')
+@click.option('--instancetype',
+              help='Create VMs from this instancetype (e.g. u1.medium) instead of the template CPU/memory; '
+                   'comma-separated instancetypes run a sweep comparing creation times')
+@click.option('--preference', help='VM preference (e.g. rhel.9) to use with --instancetype')
+@click.option('--network',
+              help='Comma-separated NetworkAttachmentDefinitions ([namespace/]name, bridge or SR-IOV) '
+                   'to attach each VM to')
+@click.option('--skip-network-check', is_flag=True,
+              help='Do not abort when a network is missing or its SR-IOV VFs are too few')
+@click.option('--prewarm', is_flag=True,
+              help='Pre-pull images onto the nodes and wait for DataSources before timing starts')
+@click.option('--warmup-iterations', default=0, type=click.IntRange(0),
+              help='Create, boot and delete the VMs up to N times before the measured run (stops early at steady state)')
+@click.option('--steady-state-cv', default=0.1, type=click.FloatRange(0, min_open=True),
+              help='Coefficient of variation across warm-up iterations that counts as steady state')
 @click.option('--num-disks', type=int, default=None,
               help='Number of disks per VM (auto-detected from template or existing VM if not specified)')
 @click.option('--namespace-batch-size', default=20, type=int,
-              help='Number of namespaces to create in parallel')
+              help='Namespaces created per server-side apply batch')
+@click.option('--placement', type=click.Choice(PLACEMENT_STRATEGIES), default='none',
+              help='How VMs are placed on nodes (none lets the scheduler decide)')
+@click.option('--placement-nodes', help='Comma-separated nodes the placement strategy uses (required by nodes)')
+@click.option('--max-vms-per-node', type=click.IntRange(1), help='VMs per node before pack moves to the next node')
+@click.option('--zone', help='Comma-separated zones; only place VMs on nodes in these zones')
+@click.option('--topology-key', help='Node label of the zone, e.g. a rack label (default: topology.kubernetes.io/zone)')
+@click.option('--topology-spread', is_flag=True, help='Spread VMs evenly across zones (same as --placement zone)')
 @click.option('--single-node', is_flag=True, help='Run all VMs on a single node')
 @click.option('--node-name', help='Specific node name for single-node testing')
+@click.option('--chaos-mode',
+              type=click.Choice(['kubelet-stop', 'reboot', 'network-partition',
+                                 'storage-pod-kill', 'storage-pod-pause']),
+              help='Inject this failure periodically while VMs are created and boot-stormed')
+@click.option('--chaos-interval', default=120, type=click.IntRange(1), show_default=True,
+              help='Seconds between injected failures')
+@click.option('--chaos-duration', default=60, type=click.IntRange(1), show_default=True,
+              help='Seconds each kubelet-stop, network-partition or storage-pod-pause failure lasts')
+@click.option('--chaos-nodes', help='Comma-separated nodes failures are injected on, in rotation (default: all workers)')
+@click.option('--chaos-storage-pods', multiple=True,
+              help='namespace:label-selector of storage pods storage-pod-kill deletes (repeatable, default: Portworx)')
+@click.option('--chaos-storage-process', help='Host process pattern paused by storage-pod-pause')
+@click.option('--chaos-injector-image', help='Image for the privileged injector pods (must provide nsenter)')
+@click.option('--chaos-allow-quorum-loss', is_flag=True,
+              help='On Portworx, inject failures even when they would lose KVDB quorum')
 @click.option('--save-results', is_flag=True,
               help='Save detailed results (JSON and CSV) to results folder')
+@click.option('--precision', default=2, type=click.IntRange(0, 9),
+              help='Decimal places for durations in saved results')
 @click.option('--results-folder', default='results',
               help='Base directory to store test results')
-@click.option('--storage-driver', help='Storage driver label for results path (for example: portworx-3.6, ceph)')
+@click.option('--storage-driver', help='Storage driver label for results path (for example: portworx-3.6, ceph), or auto to detect it')
 @click.option('--log-file', type=click.Path(), help='Log file path (auto-generated if not specified)')
 @click.pass_context
 def datasource_clone(ctx, **kwargs):
@@ -70,6 +161,13 @@ def datasource_clone(ctx, **kwargs):
       # Run with custom storage class
       virtbench datasource-clone --start 1 --end 50 --storage-class YOUR-STORAGE-CLASS
 
+      # Compare two storage classes side by side, one run each
+      virtbench datasource-clone --start 1 --end 50 --storage-class px-db --storage-class ocs-storagecluster-ceph-rbd
+
+      # RWX vs RWO and Block vs Filesystem volumes of one storage class (four runs)
+      virtbench datasource-clone --start 1 --end 20 --storage-class px-db --access-modes RWX,RWO \
+        --volume-modes Block,Filesystem
+
       # Run with cleanup after test
       virtbench datasource-clone --start 1 --end 20 --cleanup
 
@@ -78,6 +176,28 @@ def datasource_clone(ctx, **kwargs):
 
       # Single node test
       virtbench datasource-clone --start 1 --end 10 --single-node --node-name worker-1
+
+      # Windows boot storm
+      virtbench datasource-clone --start 1 --end 10 --guest-os windows --boot-storm
+
+      # GPU VM density: scheduling latency and boot time of 16 VMs with an A10 each
+      virtbench datasource-clone --start 1 --end 16 --gpu-device nvidia.com/GA102GL_A10 --save-results
+
+      # Overhead of CPU pinning and hugepages: untuned vs tuned run
+      virtbench datasource-clone --start 1 --end 20 --dedicated-cpus --hugepages 1Gi --compare-tuning
+
+      # Provisioning latency while a storage pod is killed every 2 minutes
+      virtbench datasource-clone --start 1 --end 50 --chaos-mode storage-pod-kill --save-results
+
+      # VMs sized by an instancetype, and a creation time sweep across instancetypes
+      virtbench datasource-clone --start 1 --end 20 --instancetype u1.medium --preference rhel.9
+      virtbench datasource-clone --start 1 --end 20 --instancetype u1.small,u1.medium,u1.large
+
+      # VMs with a bridge and an SR-IOV secondary network
+      virtbench datasource-clone --start 1 --end 8 --network default/br1,sriov-ns/sriov-net
+
+      # Restricted user: all VMs in one existing namespace
+      virtbench datasource-clone --start 1 --end 10 --single-namespace my-project
     """
     print_banner("DataSource Clone Benchmark")
     
@@ -85,7 +205,7 @@ def datasource_clone(ctx, **kwargs):
     repo_root = ctx.obj.repo_root
     
     # Resolve template path
-    template_path = Path(kwargs['vm_template'])
+    template_path = Path(kwargs['vm_template'] or DEFAULT_TEMPLATES[kwargs['guest_os']])
     if not template_path.is_absolute():
         template_path = repo_root / template_path
 
@@ -104,11 +224,27 @@ def datasource_clone(ctx, **kwargs):
             console.print(f"[red]Error: Secret YAML file not found: {secret_yaml_path}[/red]")
             sys.exit(1)
 
-    # Handle storage class modification
-    if kwargs['storage_class']:
-        console.print(f"[cyan]Using storage class: {kwargs['storage_class']}[/cyan]")
+    # Handle storage class modification; several storage variants get a template copy per run
+    storage_classes = parse_storage_classes(kwargs['storage_class'])
+    try:
+        variants = storage_variants(storage_classes, parse_access_modes(kwargs.get('access_modes')),
+                                    parse_volume_modes(kwargs.get('volume_modes')))
+    except ValueError as e:
+        console.print(f"[red]Error: {e}[/red]")
+        sys.exit(1)
+    if len(variants) == 1 and (variants[0]['access_mode'] or variants[0]['volume_mode']):
+        console.print(f"[cyan]Using storage variant: {variants[0]['name']}[/cyan]")
         try:
-            modify_storage_class(template_path, kwargs['storage_class'])
+            template_path = write_storage_class_template(template_path, variants[0]['storage_class'],
+                                                         access_mode=variants[0]['access_mode'],
+                                                         volume_mode=variants[0]['volume_mode'])
+        except Exception as e:
+            console.print(f"[red]Error modifying storage class: {e}[/red]")
+            sys.exit(1)
+    elif len(storage_classes) == 1 and len(variants) == 1:
+        console.print(f"[cyan]Using storage class: {storage_classes[0]}[/cyan]")
+        try:
+            modify_storage_class(template_path, storage_classes[0])
         except Exception as e:
             console.print(f"[red]Error modifying storage class: {e}[/red]")
             sys.exit(1)
@@ -126,14 +262,22 @@ def datasource_clone(ctx, **kwargs):
         'end': kwargs['end'],
         'vm-name': kwargs['vm_name'],
         'vm-template': str(template_path),
+        'guest-os': kwargs['guest_os'],
         'namespace-prefix': kwargs['namespace_prefix'],
         'concurrency': kwargs['concurrency'],
+        'qps': kwargs['qps'],
+        'burst': kwargs['burst'],
         'poll-interval': kwargs['poll_interval'],
-        'ping-timeout': kwargs['ping_timeout'],
+        'ping-timeout': kwargs['ping_timeout'] or DEFAULT_PING_TIMEOUTS[kwargs['guest_os']],
+        'guest-agent-timeout': kwargs['guest_agent_timeout'],
         'ssh-pod': kwargs['ssh_pod'],
         'ssh-pod-ns': kwargs['ssh_pod_ns'],
+        'reachability': kwargs['reachability'],
         'namespace-batch-size': kwargs['namespace_batch_size'],
         'results-folder': kwargs['results_folder'],
+        'precision': kwargs['precision'],
+        'warmup-iterations': kwargs['warmup_iterations'],
+        'steady-state-cv': kwargs['steady_state_cv'],
         'log-level': ctx.obj.log_level.upper(),
     }
 
@@ -152,14 +296,70 @@ def datasource_clone(ctx, **kwargs):
         python_args['boot-storm'] = True
     if kwargs['skip_vm_creation']:
         python_args['skip-vm-creation'] = True
+    if kwargs['skip_capacity_check']:
+        python_args['skip-capacity-check'] = True
+    if kwargs['skip_gpu_check']:
+        python_args['skip-gpu-check'] = True
+    if kwargs['skip_network_check']:
+        python_args['skip-network-check'] = True
+    if kwargs['dedicated_cpus']:
+        python_args['dedicated-cpus'] = True
+    if kwargs['numa']:
+        python_args['numa'] = True
+    if kwargs.get('hugepages'):
+        python_args['hugepages'] = kwargs['hugepages']
+    if kwargs['prewarm']:
+        python_args['prewarm'] = True
     if kwargs['single_node']:
         python_args['single-node'] = True
     if kwargs['save_results']:
         python_args['save-results'] = True
+    if kwargs['guest_agent']:
+        python_args['guest-agent'] = True
+    if kwargs['checker_host_network']:
+        python_args['checker-host-network'] = True
 
     # Add optional args
     if kwargs.get('node_name'):
         python_args['node-name'] = kwargs['node_name']
+    if kwargs['placement'] != 'none':
+        python_args['placement'] = kwargs['placement']
+    if kwargs.get('placement_nodes'):
+        python_args['placement-nodes'] = [n.strip() for n in kwargs['placement_nodes'].split(',') if n.strip()]
+    if kwargs.get('max_vms_per_node'):
+        python_args['max-vms-per-node'] = kwargs['max_vms_per_node']
+    if kwargs.get('zone'):
+        python_args['zone'] = [z.strip() for z in kwargs['zone'].split(',') if z.strip()]
+    if kwargs.get('topology_key'):
+        python_args['topology-key'] = kwargs['topology_key']
+    if kwargs.get('topology_spread'):
+        python_args['topology-spread'] = True
+    if kwargs.get('chaos_mode'):
+        python_args['chaos-mode'] = kwargs['chaos_mode']
+        python_args['chaos-interval'] = kwargs['chaos_interval']
+        python_args['chaos-duration'] = kwargs['chaos_duration']
+    if kwargs.get('chaos_nodes'):
+        python_args['chaos-nodes'] = [n.strip() for n in kwargs['chaos_nodes'].split(',') if n.strip()]
+    if kwargs.get('chaos_storage_pods'):
+        python_args['chaos-storage-pods'] = list(kwargs['chaos_storage_pods'])
+    if kwargs.get('chaos_storage_process'):
+        python_args['chaos-storage-process'] = kwargs['chaos_storage_process']
+    if kwargs.get('chaos_injector_image'):
+        python_args['chaos-injector-image'] = kwargs['chaos_injector_image']
+    if kwargs.get('chaos_allow_quorum_loss'):
+        python_args['chaos-allow-quorum-loss'] = True
+    if kwargs.get('gpu_device'):
+        python_args['gpu-device'] = kwargs['gpu_device']
+        python_args['gpus-per-vm'] = kwargs['gpus_per_vm']
+    instancetypes = [t.strip() for t in (kwargs.get('instancetype') or '').split(',') if t.strip()]
+    if len(instancetypes) == 1:
+        python_args['instancetype'] = instancetypes[0]
+    if kwargs.get('preference'):
+        python_args['preference'] = kwargs['preference']
+    if kwargs.get('network'):
+        python_args['network'] = [n.strip() for n in kwargs['network'].split(',') if n.strip()]
+    if kwargs.get('single_namespace'):
+        python_args['single-namespace'] = kwargs['single_namespace']
     if kwargs.get('storage_driver'):
         python_args['storage-driver'] = kwargs['storage_driver']
     if kwargs.get('num_disks'):
@@ -176,6 +376,39 @@ def datasource_clone(ctx, **kwargs):
     elif not kwargs['save_results']:
         python_args['log-file'] = generate_log_filename('datasource-clone')
     
+    if len(variants) > 1:
+        if len(instancetypes) > 1 or kwargs['compare_tuning']:
+            console.print("[red]Error: comparing storage classes cannot be combined with an instancetype sweep "
+                          "or --compare-tuning[/red]")
+            sys.exit(1)
+        try:
+            sys.exit(run_storage_class_comparison(ctx, build_python_command(script_path, python_args),
+                                                  variants, repo_root, kwargs['storage_class_mode'],
+                                                  template_path=template_path))
+        except KeyboardInterrupt:
+            console.print("\n[yellow]Interrupted by user[/yellow]")
+            sys.exit(130)
+
+    if len(instancetypes) > 1:
+        if kwargs['compare_tuning']:
+            console.print("[red]Error: --compare-tuning cannot be combined with an instancetype sweep[/red]")
+            sys.exit(1)
+        try:
+            sys.exit(run_instancetype_sweep(ctx, script_path, python_args, instancetypes, repo_root))
+        except KeyboardInterrupt:
+            console.print("\n[yellow]Interrupted by user[/yellow]")
+            sys.exit(130)
+
+    if kwargs['compare_tuning']:
+        if not (kwargs['dedicated_cpus'] or kwargs['hugepages'] or kwargs['numa']):
+            console.print("[red]Error: --compare-tuning requires --dedicated-cpus, --hugepages or --numa[/red]")
+            sys.exit(1)
+        try:
+            sys.exit(run_tuning_comparison(ctx, script_path, python_args, repo_root))
+        except KeyboardInterrupt:
+            console.print("\n[yellow]Interrupted by user[/yellow]")
+            sys.exit(130)
+
     # Build and run command
     cmd = build_python_command(script_path, python_args)
     
@@ -183,7 +416,7 @@ def datasource_clone(ctx, **kwargs):
     console.print()
     
     try:
-        result = subprocess.run(cmd, cwd=repo_root)
+        result = run_workload(ctx, cmd, cwd=repo_root)
         sys.exit(result.returncode)
     except KeyboardInterrupt:
         console.print("\n[yellow]Interrupted by user[/yellow]")
@@ -0,0 +1,127 @@
+#!/usr/bin/env python3
+"""
+Descheduler Benchmark command - rebalancing of benchmark VMs by the descheduler
+"""
+import click
+import sys
+from rich.console import Console
+
+from virtbench.common import print_banner, build_python_command, generate_log_filename
+from virtbench.utils.orchestration import run_workload
+
+console = Console()
+
+
+@click.command('descheduler')
+@click.option('--start', '-s', default=1, type=int, help='Start namespace index')
+@click.option('--end', '-e', default=10, type=int, help='End namespace index')
+@click.option('--namespace-prefix', default='kubevirt-perf-test', help='Namespace prefix')
+@click.option('--vm-name', default='rhel-9-vm', help='VM name in each namespace')
+@click.option('--eviction-strategy',
+              type=click.Choice(['LiveMigrate', 'LiveMigrateIfPossible', 'External', 'None']),
+              help='Set this evictionStrategy on the benchmark VMs (default: leave them unchanged)')
+@click.option('--descheduler-namespace', default='openshift-kube-descheduler-operator',
+              help='Namespace of the descheduler')
+@click.option('--descheduler-profile',
+              help='Comma-separated KubeDescheduler profiles for the run, e.g. KubeVirtRelieveAndMigrate')
+@click.option('--descheduling-interval', type=int, help='KubeDescheduler deschedulingIntervalSeconds for the run')
+@click.option('--skip-descheduler-check', is_flag=True, help='Run even when no descheduler is found')
+@click.option('--imbalance-node', help='Node to pack the VMs onto (default: the node hosting the most benchmark VMs)')
+@click.option('--skip-imbalance', is_flag=True, help='Measure rebalancing of the current placement as is')
+@click.option('--imbalance-timeout', default=900, type=int,
+              help='Seconds to wait for the VMs to reach the imbalance node')
+@click.option('--balance-tolerance', default=1, type=int,
+              help='Balanced when VMs per node differ by at most this many')
+@click.option('--timeout', default=1800, type=int, help='Seconds to wait for the VMs to be balanced')
+@click.option('--poll-interval', default=2.0, type=float, help='Seconds between VMI status checks')
+@click.option('--precision', type=int, help='Decimal places for durations in saved results (default: 2)')
+@click.option('--save-results', is_flag=True, help='Save results to JSON/CSV files')
+@click.option('--results-folder', default='results', help='Base directory for results')
+@click.option('--storage-driver', help='Storage driver label for results path (for example: portworx-3.6, ceph), or auto to detect it')
+@click.option('--log-file', type=click.Path(), help='Log file path (auto-generated if not specified)')
+@click.option('--log-level', default='INFO',
+              type=click.Choice(['DEBUG', 'INFO', 'WARNING', 'ERROR']),
+              help='Logging level')
+@click.pass_context
+def descheduler(ctx, **kwargs):
+    """
+    Run descheduler rebalancing benchmark
+
+    Sets the eviction strategy on running benchmark VMs, packs them onto one
+    node by cordoning the others, and measures how quickly and evenly the
+    descheduler spreads them out again once the nodes are uncordoned: time
+    to the first migration, time until balanced, migrations and restarts
+    triggered, and the VMs per node before and after.
+
+    \b
+    Examples:
+      # Use the KubeVirt descheduler profile for the run
+      virtbench descheduler --start 1 --end 50 --eviction-strategy LiveMigrate \\
+          --descheduler-profile KubeVirtRelieveAndMigrate --descheduling-interval 60 --save-results
+    \b
+      # Measure the configured descheduler on the current placement
+      virtbench descheduler --start 1 --end 50 --skip-imbalance
+    """
+    print_banner("Descheduler Benchmark")
+
+    repo_root = ctx.obj.repo_root
+    script_path = repo_root / 'descheduler' / 'measure-descheduler.py'
+
+    if not script_path.exists():
+        console.print(f"[red]Error:[/red] Script not found: {script_path}")
+        sys.exit(1)
+
+    console.print(f"[cyan]Imbalance node:[/cyan] "
+                  f"{'none' if kwargs['skip_imbalance'] else kwargs['imbalance_node'] or 'busiest node'}")
+
+    # Map CLI args to the benchmark script's arguments
+    python_args = {
+        'start': kwargs['start'],
+        'end': kwargs['end'],
+        'namespace-prefix': kwargs['namespace_prefix'],
+        'vm-name': kwargs['vm_name'],
+        'eviction-strategy': kwargs['eviction_strategy'],
+        'descheduler-namespace': kwargs['descheduler_namespace'],
+        'descheduler-profile': [p.strip() for p in (kwargs['descheduler_profile'] or '').split(',') if p.strip()],
+        'descheduling-interval': kwargs['descheduling_interval'],
+        'imbalance-node': kwargs['imbalance_node'],
+        'imbalance-timeout': kwargs['imbalance_timeout'],
+        'balance-tolerance': kwargs['balance_tolerance'],
+        'timeout': kwargs['timeout'],
+        'poll-interval': kwargs['poll_interval'],
+        'precision': kwargs['precision'],
+        'results-folder': kwargs['results_folder'],
+        'storage-driver': kwargs['storage_driver'],
+        'log-level': kwargs['log_level'],
+    }
+
+    # Log file: explicit > global context > auto-generated timestamped file
+    if kwargs.get('log_file'):
+        python_args['log-file'] = kwargs['log_file']
+    elif ctx.obj.log_file:
+        python_args['log-file'] = ctx.obj.log_file
+    else:
+        python_args['log-file'] = generate_log_filename('descheduler')
+
+    # Flags
+    if kwargs['skip_descheduler_check']:
+        python_args['skip-descheduler-check'] = True
+    if kwargs['skip_imbalance']:
+        python_args['skip-imbalance'] = True
+    if kwargs['save_results']:
+        python_args['save-results'] = True
+
+    cmd = build_python_command(script_path, python_args)
+
+    console.print(f"[dim]Running: {' '.join(cmd[:2])} ...[/dim]")
+    console.print()
+
+    try:
+        result = run_workload(ctx, cmd, cwd=repo_root)
+        sys.exit(result.returncode)
+    except KeyboardInterrupt:
+        console.print("\n[yellow]Interrupted by user[/yellow]")
+        sys.exit(130)
+    except Exception as e:
+        console.print(f"[red]Error:[/red] {e}")
+        sys.exit(1)
@@ -3,12 +3,12 @@
 Disk Operations Benchmark command - Hotplug/Coldplug disk performance testing
 """
 import click
-import subprocess
 import sys
 from pathlib import Path
 from rich.console import Console
 
 from virtbench.common import print_banner, build_python_command, generate_log_filename
+from virtbench.utils.orchestration import run_workload
 
 console = Console()
 
@@ -41,7 +41,8 @@ console = Console()
 @click.option('--skip-validation', is_flag=True, help='Skip in-VM validation')
 @click.option('--results-dir', default='results', help='Base directory for results')
 @click.option('--save-results', is_flag=True, help='Save results to JSON/CSV files')
-@click.option('--px-version', default='px-unknown', help='Storage driver/version label for results folder')
+@click.option('--storage-driver', '--px-version', 'storage_driver', default='px-unknown',
+              help='Storage driver/version label for results folder, or auto to detect it (--px-version is the old name)')
 @click.option('--disk-type', default=None, help='Disk type label for results folder (default: <disks>-disk)')
 @click.option('--cleanup', is_flag=True, help='Remove hotplugged disks (and created VMs) after test')
 @click.option('--log-file', type=click.Path(), help='Log file path (auto-generated if not specified)')
@@ -124,7 +125,7 @@ def disk_ops(ctx, **kwargs):
         'attach-timeout': kwargs['attach_timeout'],
         'vm-timeout': kwargs['vm_timeout'],
         'results-dir': kwargs['results_dir'],
-        'px-version': kwargs['px_version'],
+        'storage-driver': kwargs['storage_driver'],
         'disk-type': kwargs['disk_type'],
         'log-level': kwargs['log_level'],
     }
@@ -157,7 +158,7 @@ def disk_ops(ctx, **kwargs):
     console.print()
 
     try:
-        result = subprocess.run(cmd, cwd=repo_root)
+        result = run_workload(ctx, cmd, cwd=repo_root)
         sys.exit(result.returncode)
     except KeyboardInterrupt:
         console.print("\n[yellow]Interrupted by user[/yellow]")
@@ -0,0 +1,276 @@
+#!/usr/bin/env python3
+"""
+Doctor command: local prerequisites of virtbench
+
+Checks what a run needs before it touches the cluster: kubectl and a
+working kubeconfig, the permissions of the current user (asked with
+SelfSubjectAccessReviews), the KubeVirt and CDI CRDs, the repository root
+with the workload scripts, the Python interpreter and modules the scripts
+run with, and a writable results folder. Every problem comes with the step
+that fixes it.
+"""
+import json
+import shutil
+import subprocess
+import sys
+import tempfile
+from importlib.util import find_spec
+from pathlib import Path
+from typing import Dict, List, Optional
+
+import click
+import yaml
+from rich.console import Console
+from rich.table import Table
+
+from virtbench.utils import rbac
+
+console = Console()
+
+OK, WARN, FAIL = 'ok', 'warn', 'fail'
+
+# What every workload does with the API; a missing one fails
+REQUIRED_PERMISSIONS = rbac.NAMESPACES + rbac.CREATE_VMS + rbac.CLEANUP + [
+    ('list', '', 'persistentvolumeclaims', None),
+    ('list', '', 'nodes', None),
+]
+# What some workloads do (SSH checks, migration, snapshots, node drain); a missing one warns
+OPTIONAL_PERMISSIONS = rbac.EXEC + rbac.MIGRATIONS + rbac.SNAPSHOTS + rbac.DRAIN
+
+# (CRD, required, what it is for)
+CRDS = [
+    ('virtualmachines.kubevirt.io', True, 'KubeVirt VMs'),
+    ('virtualmachineinstances.kubevirt.io', True, 'KubeVirt VMs'),
+    ('datavolumes.cdi.kubevirt.io', True, 'CDI disk import and clone'),
+    ('datasources.cdi.kubevirt.io', True, 'DataSource clones: datasource-clone, fio, chaos'),
+    ('virtualmachineinstancemigrations.kubevirt.io', False, 'live migration: migration, node-drain'),
+    ('virtualmachineclones.clone.kubevirt.io', False, 'vm-clone'),
+    ('volumesnapshots.snapshot.storage.k8s.io', False, 'VM snapshots: vm-ops snapshot, chaos'),
+    ('hyperconvergeds.hco.kubevirt.io', False, 'tuning profiles: tune'),
+]
+
+# Modules the workload scripts import, by pip package
+SCRIPT_MODULES = {'yaml': 'pyyaml', 'pandas': 'pandas'}
+
+
+def _check(name: str, status: str, message: str, remediation: Optional[str] = None) -> Dict:
+    return {'check': name, 'status': status, 'message': message, 'remediation': remediation}
+
+
+def _run(cmd: List[str]) -> subprocess.CompletedProcess:
+    try:
+        return subprocess.run(cmd, capture_output=True, text=True, timeout=30)
+    except subprocess.TimeoutExpired:
+        return subprocess.CompletedProcess(cmd, 1, '', f"timed out after 30s: {' '.join(cmd)}")
+
+
+def check_kubectl() -> Dict:
+    """kubectl on PATH."""
+    path = shutil.which('kubectl')
+    if not path:
+        return _check('kubectl', FAIL, 'kubectl not found on PATH',
+                      'Install kubectl (https://kubernetes.io/docs/tasks/tools/) or oc, and put it on PATH')
+    return _check('kubectl', OK, path)
+
+
+def check_kubeconfig(kubectl: List[str]) -> Dict:
+    """The kubeconfig has a current context and its API server answers."""
+    context = _run(kubectl + ['config', 'current-context'])
+    if context.returncode != 0:
+        return _check('kubeconfig', FAIL, context.stderr.strip() or 'no current context',
+                      'Point KUBECONFIG (or --kubeconfig) at a kubeconfig of the cluster, or run '
+                      '`kubectl config use-context <context>`')
+    version = _run(kubectl + ['version', '-o', 'json'])
+    try:
+        server = json.loads(version.stdout).get('serverVersion') or {}
+    except ValueError:
+        server = {}
+    if version.returncode != 0 or not server:
+        error = version.stderr.strip().splitlines()
+        return _check('kubeconfig', FAIL,
+                      f"context {context.stdout.strip()}: API server unreachable"
+                      + (f" ({error[-1]})" if error else ''),
+                      'Check the API server address and credentials of the context; log in again '
+                      '(`oc login`) if the token expired')
+    return _check('kubeconfig', OK, f"context {context.stdout.strip()}, server {server.get('gitVersion', '?')}")
+
+
+def check_permissions(kubectl: List[str]) -> List[Dict]:
+    """Ask the API server, with one SelfSubjectAccessReview per permission, what the user may do."""
+    permissions = list(dict.fromkeys(REQUIRED_PERMISSIONS + OPTIONAL_PERMISSIONS))
+    try:
+        allowed = rbac.access_review(kubectl, permissions)
+    except RuntimeError as e:
+        return [_check('permissions', FAIL, f"SelfSubjectAccessReview failed: {e}",
+                       'The user must be able to create selfsubjectaccessreviews '
+                       '(granted to every authenticated user by default)')]
+
+    denied_required, denied_optional = [], []
+    for permission, ok in zip(permissions, allowed):
+        if not ok:
+            denied = denied_required if permission in REQUIRED_PERMISSIONS else denied_optional
+            denied.append(rbac.permission_name(permission))
+    checks = []
+    if denied_required:
+        checks.append(_check('permissions', FAIL, f"denied: {', '.join(denied_required)}",
+                             'Run as cluster-admin, or bind a role granting these verbs cluster-wide '
+                             '(`kubectl create clusterrolebinding virtbench --clusterrole=cluster-admin '
+                             '--user=<user>`)'))
+    if denied_optional:
+        checks.append(_check('permissions', WARN, f"denied: {', '.join(denied_optional)}",
+                             'Grant these verbs to run the workloads that need them '
+                             '(SSH checks, migration, snapshots, node drain)'))
+    if not checks:
+        checks.append(_check('permissions', OK, f"{len(permissions)} permissions allowed"))
+    return checks
+
+
+def check_crds(kubectl: List[str]) -> List[Dict]:
+    """The KubeVirt and CDI resources are served (from API discovery, readable by every user)."""
+    result = _run(kubectl + ['api-resources', '-o', 'name'])
+    if result.returncode != 0:
+        return [_check('crds', FAIL, f"API discovery failed: {result.stderr.strip()}",
+                       'Check the API server with `kubectl api-resources`')]
+    served = set(result.stdout.split())
+    missing_required = [crd for crd, required, _ in CRDS if required and crd not in served]
+    missing_optional = [f"{crd} ({purpose})" for crd, required, purpose in CRDS
+                        if not required and crd not in served]
+    checks = []
+    if missing_required:
+        checks.append(_check('crds', FAIL, f"missing: {', '.join(missing_required)}",
+                             'Install OpenShift Virtualization (or KubeVirt and CDI) and wait for the '
+                             'HyperConverged resource to be Available'))
+    if missing_optional:
+        checks.append(_check('crds', WARN, f"missing: {', '.join(missing_optional)}",
+                             'Install the components these workloads need, e.g. the CSI snapshot '
+                             'controller for VolumeSnapshots'))
+    if not checks:
+        checks.append(_check('crds', OK, f"{len(CRDS)} CRDs served"))
+    return checks
+
+
+def check_repo_root(repo_root: Optional[Path], error: Optional[str]) -> Dict:
+    """The repository root with the workload scripts was found."""
+    if repo_root is None:
+        return _check('repo root', FAIL, (error or 'not found').splitlines()[0],
+                      'Run virtbench from the kubevirt-benchmark checkout, or set '
+                      'VIRTBENCH_REPO=/path/to/kubevirt-benchmark')
+    missing = [d for d in ('utils', 'datasource-clone', 'migration') if not (repo_root / d).is_dir()]
+    if missing:
+        return _check('repo root', WARN, f"{repo_root}: missing {', '.join(missing)}",
+                      'Update the checkout (`git pull`) or point VIRTBENCH_REPO at a complete one')
+    return _check('repo root', OK, str(repo_root))
+
+
+def check_python() -> Dict:
+    """The interpreter the workload scripts run with, and the modules they import."""
+    version = f"{sys.version_info.major}.{sys.version_info.minor}.{sys.version_info.micro}"
+    if sys.version_info < (3, 8):
+        return _check('python', FAIL, f"{sys.executable} is Python {version}",
+                      'Install Python 3.8 or newer and reinstall virtbench with it')
+    missing = [package for module, package in SCRIPT_MODULES.items() if find_spec(module) is None]
+    if missing:
+        return _check('python', FAIL, f"{sys.executable} ({version}): missing {', '.join(missing)}",
+                      f"{sys.executable} -m pip install {' '.join(missing)}")
+    return _check('python', OK, f"{sys.executable} ({version})")
+
+
+def check_results_folder(folder: Path) -> Dict:
+    """The results folder (or the folder it would be created in) is writable."""
+    target = folder
+    while not target.exists() and target != target.parent:
+        target = target.parent
+    if not target.is_dir():
+        return _check('results folder', FAIL, f"{target} is not a directory",
+                      f"Remove {target} or pass another --results-folder")
+    try:
+        with tempfile.TemporaryFile(dir=target):
+            pass
+    except OSError as e:
+        return _check('results folder', FAIL, f"{target} is not writable ({e.strerror})",
+                      f"Make {target} writable (`chmod u+w {target}`) or pass another --results-folder")
+    return _check('results folder', OK, str(folder) if folder.exists() else f"{folder} (will be created)")
+
+
+def run_checks(ctx, results_folder: str) -> List[Dict]:
+    """Every doctor check, with the cluster checks once per cluster."""
+    checks = [check_kubectl()]
+    if checks[0]['status'] == OK:
+        for cluster in ctx.obj.clusters or [None]:
+            kubectl = rbac.cluster_kubectl(cluster)
+            cluster_checks = [check_kubeconfig(kubectl)]
+            if cluster_checks[0]['status'] == OK:
+                cluster_checks += check_permissions(kubectl) + check_crds(kubectl)
+            if cluster:
+                for check in cluster_checks:
+                    check['check'] = f"{cluster['name']}: {check['check']}"
+            checks += cluster_checks
+    checks.append(check_repo_root(ctx.obj.repo_root, ctx.obj.repo_root_error))
+    checks.append(check_python())
+    folder = Path(results_folder)
+    if not folder.is_absolute() and ctx.obj.repo_root:
+        # Workloads run from the repository root, where relative results folders resolve
+        folder = ctx.obj.repo_root / folder
+    checks.append(check_results_folder(folder))
+    return checks
+
+
+@click.command('doctor')
+@click.option('--results-folder', default='results', show_default=True,
+              help='Results folder the workloads will write to')
+@click.option('--strict', is_flag=True, help='Exit with code 2 when checks warn but none failed')
+@click.pass_context
+def doctor(ctx, results_folder, strict):
+    """
+    Check local prerequisites and how to fix them
+
+    Verifies kubectl and the kubeconfig, the user's permissions
+    (SelfSubjectAccessReview), the KubeVirt/CDI CRDs, the repository root,
+    the Python interpreter of the workload scripts and a writable results
+    folder, and prints a remediation step for every problem. With several
+    clusters (--kubeconfig/--contexts), the cluster checks run per cluster.
+
+    Exit code 1 means a check failed; with --strict, exit code 2 means
+    checks warned but none failed.
+
+    \b
+    Examples:
+      # Check the current kubeconfig and checkout
+      virtbench doctor
+
+      # Check every cluster of a comparison, as JSON
+      virtbench --output json --contexts px-cluster,ceph-cluster doctor
+    """
+    checks = run_checks(ctx, results_folder)
+    counts = {status: sum(1 for c in checks if c['status'] == status) for status in (OK, WARN, FAIL)}
+    status = FAIL if counts[FAIL] else WARN if counts[WARN] else OK
+
+    if ctx.obj.output != 'table':
+        document = {'kind': 'doctor-report', 'data': {'status': status, 'counts': counts, 'checks': checks}}
+        if ctx.obj.output == 'json':
+            sys.__stdout__.write(json.dumps(document, indent=2) + '\n')
+        else:
+            sys.__stdout__.write(yaml.safe_dump(document, sort_keys=False, explicit_start=True))
+        sys.__stdout__.flush()
+    else:
+        styles = {OK: 'green', WARN: 'yellow', FAIL: 'red'}
+        table = Table(title="virtbench doctor", show_header=True, header_style="bold cyan")
+        table.add_column("Check", style="cyan")
+        table.add_column("Status")
+        table.add_column("Details")
+        for check in checks:
+            style = styles[check['status']]
+            table.add_row(check['check'], f"[{style}]{check['status'].upper()}[/{style}]", check['message'])
+        console.print()
+        console.print(table)
+        fixes = [c for c in checks if c['remediation'] and c['status'] != OK]
+        if fixes:
+            console.print("\n[bold]Remediation:[/bold]")
+            for i, check in enumerate(fixes, 1):
+                console.print(f"  {i}. [cyan]{check['check']}[/cyan]: {check['remediation']}")
+        console.print(f"\n{counts[OK]} ok, {counts[WARN]} warning(s), {counts[FAIL]} failure(s)\n")
+
+    if status == FAIL:
+        sys.exit(1)
+    if status == WARN and strict:
+        sys.exit(2)
@@ -4,7 +4,6 @@ Elbencho benchmark command for virtbench CLI.
 
 Wraps the measure-elbencho-performance.py script for managing elbencho workloads on VMs.
 """
-import subprocess
 import sys
 from pathlib import Path
 
@@ -12,6 +11,7 @@ import click
 from rich.console import Console
 
 from virtbench.common import print_banner
+from virtbench.utils.orchestration import run_workload
 
 console = Console()
 
@@ -46,7 +46,7 @@ console = Console()
 # Results parameters
 @click.option('--save-results', is_flag=True, help='Save results to JSON/CSV')
 @click.option('--results-dir', default='./results', help='Base results directory')
-@click.option('--storage-driver', default='Not-Specified', help='Storage driver label for results folder')
+@click.option('--storage-driver', default='Not-Specified', help='Storage driver label for results folder, or auto to detect it')
 @click.option('--disks-per-vm', default='auto', help='Disks per VM for results folder (auto-detect)')
 @click.option('--run-name', default=None, help='Custom run name')
 @click.option('--output-dir', default=None,
@@ -203,7 +203,7 @@ def elbencho(ctx, **kwargs):
     console.print(f"[dim]Full command: {' '.join(cmd)}[/dim]\n")
 
     try:
-        result = subprocess.run(cmd, cwd=str(repo_root))
+        result = run_workload(ctx, cmd, cwd=str(repo_root))
         sys.exit(result.returncode)
     except KeyboardInterrupt:
         console.print("\n[yellow]Interrupted by user[/yellow]")
@@ -0,0 +1,98 @@
+#!/usr/bin/env python3
+"""
+Capacity estimate command
+"""
+import click
+import os
+import sys
+from pathlib import Path
+from rich.console import Console
+
+from virtbench.common import print_banner, build_python_command
+from virtbench.commands.datasource_clone import DEFAULT_TEMPLATES
+from virtbench.utils.orchestration import run_workload
+
+console = Console()
+
+
+@click.command('estimate')
+@click.option('--vms', required=True, type=int, help='Number of VMs the run creates')
+@click.option('--vm-template',
+              help='Path to VM template YAML (default: rhel9-vm-datasource.yaml, or '
+                   'windows-vm-datasource.yaml for Windows)')
+@click.option('--guest-os', type=click.Choice(['linux', 'windows']), default='linux',
+              help='Guest OS of the default VM template')
+@click.option('--storage-class', help='Storage class of the VM disks (default: from the template)')
+@click.option('--node', help='Node all VMs are scheduled on (single-node runs)')
+@click.option('--headroom', type=float,
+              help='Warn when the run leaves less than this percentage of free capacity (default: 10)')
+@click.option('--overhead-mi', type=int,
+              help='Approximate virt-launcher memory overhead per VM in MiB (default: 256)')
+@click.option('--report', type=click.Path(), help='Write the estimate as JSON to this file')
+@click.pass_context
+def estimate(ctx, **kwargs):
+    """
+    Estimate whether a planned run fits on the cluster
+
+    Compares the CPU, memory and storage the planned VMs request against the
+    free allocatable resources of the schedulable nodes and the free space of
+    the storage pool. Exits with code 1 when the VMs cannot fit.
+
+    \b
+    Examples:
+      # Can 200 RHEL VMs run on this cluster?
+      virtbench estimate --vms 200 --storage-class YOUR-STORAGE-CLASS
+
+      # Single-node run with a custom template
+      virtbench estimate --vms 50 --node worker-1 --vm-template my-vm.yaml
+    """
+    print_banner("Capacity Estimate")
+
+    # Get repo root from context
+    repo_root = ctx.obj.repo_root
+
+    template_path = Path(kwargs['vm_template'] or DEFAULT_TEMPLATES[kwargs['guest_os']])
+    if not template_path.is_absolute():
+        template_path = repo_root / template_path
+
+    if not template_path.exists():
+        console.print(f"[red]Error: Template file not found: {template_path}[/red]")
+        sys.exit(1)
+
+    script_path = repo_root / 'utils' / 'estimate_capacity.py'
+
+    if not script_path.exists():
+        console.print(f"[red]Error: Script not found: {script_path}[/red]")
+        sys.exit(1)
+
+    # Map CLI args to Python script args
+    python_args = {
+        'log-level': ctx.obj.log_level.upper(),
+        'vm-template': str(template_path),
+        'vms': kwargs['vms'],
+        'storage-class': kwargs['storage_class'],
+        'node': kwargs['node'],
+        'headroom': kwargs['headroom'],
+        'overhead-mi': kwargs['overhead_mi'],
+        'report': os.path.abspath(kwargs['report']) if kwargs['report'] else None,
+    }
+
+    # Add global flags from context
+    if ctx.obj.kubeconfig:
+        python_args['kubeconfig'] = ctx.obj.kubeconfig
+
+    # Build and run command
+    cmd = build_python_command(script_path, python_args)
+
+    console.print(f"[dim]Running: {' '.join(cmd[:2])} ...[/dim]")
+    console.print()
+
+    try:
+        result = run_workload(ctx, cmd, cwd=repo_root)
+        sys.exit(result.returncode)
+    except KeyboardInterrupt:
+        console.print("\n[yellow]Interrupted by user[/yellow]")
+        sys.exit(130)
+    except Exception as e:
+        console.print(f"[red]Error: {e}[/red]")
+        sys.exit(1)
@@ -3,23 +3,41 @@
 Failure Recovery benchmark command
 """
 import click
-import subprocess
 import sys
 from pathlib import Path
 from rich.console import Console
 
 from virtbench.utils.yaml_modifier import modify_storage_class
 from virtbench.common import print_banner, build_python_command, generate_log_filename
+from virtbench.utils.orchestration import run_workload
 
 console = Console()
 
 
 @click.command('failure-recovery')
 @click.option('--mode',
-              type=click.Choice(['monitor', 'manual', 'far-operator']),
+              type=click.Choice(['monitor', 'manual', 'far-operator', 'inject']),
               default='monitor',
               show_default=True,
-              help='Failure workflow: monitor external failure, wait for manual failure, or trigger FAR')
+              help='Failure workflow: monitor external failure, wait for manual failure, trigger FAR, '
+                   'or inject a failure')
+@click.option('--failure-mode',
+              type=click.Choice(['drain', 'kubelet-stop', 'reboot', 'network-partition',
+                                 'storage-pod-kill', 'storage-pod-pause']),
+              help='Failure to inject with --mode inject')
+@click.option('--failure-duration', default=300, type=int,
+              help='Seconds before kubelet-stop / network-partition are reverted')
+@click.option('--partition-ports', multiple=True, type=int,
+              help='API server port blocked for network-partition (repeatable, default: 6443)')
+@click.option('--storage-pods', multiple=True,
+              help='namespace:label-selector of storage pods to kill (repeatable, default: Portworx)')
+@click.option('--include-remote-storage-pods', is_flag=True,
+              help='Also kill matching storage pods on other nodes')
+@click.option('--storage-process', help='Host process pattern paused by storage-pod-pause')
+@click.option('--vm-user', help='Guest SSH user for the storage failure I/O probe')
+@click.option('--vm-password',
+              help='Guest SSH password for the storage failure I/O probe and --verify-data (required by both)')
+@click.option('--injector-image', help='Image for the privileged injector pod (must provide nsenter)')
 @click.option('--node', required=True, help='Node name to auto-detect VMs from')
 @click.option('--vm-name', '-n', default='rhel-9-vm', help='VM resource name')
 @click.option('--vm-template',
@@ -33,10 +51,24 @@ console = Console()
 @click.option('--remove-node-selector', is_flag=True,
               help='Remove nodeSelector from VMs before recovery monitoring')
 @click.option('--concurrency', '-c', default=10, type=int, help='Max parallel threads')
+@click.option('--qps', default=0.0, type=float,
+              help='Max VM operations started per second (0 disables rate limiting)')
+@click.option('--burst', default=10, type=int, help='Max VM operations started back-to-back when --qps is set')
 @click.option('--poll-interval', default=5, type=int, help='Seconds between status checks')
+@click.option('--recovery-detection', type=click.Choice(['watch', 'poll']), default='watch',
+              help='Watch the node, VMIs and virt-launcher pods (sub-second timing) or poll them')
 @click.option('--node-timeout', default=600, type=int, help='Timeout for node to become NotReady')
 @click.option('--recovery-timeout', default=600, type=int, help='Timeout for recovery in seconds')
 @click.option('--skip-ping', is_flag=True, help='Skip ping recovery checks')
+@click.option('--track-kvdb', is_flag=True,
+              help='Track Portworx KVDB quorum, refuse to break it, and record KVDB failover times')
+@click.option('--allow-quorum-loss', is_flag=True, help='With --track-kvdb, proceed even if quorum would be lost')
+@click.option('--verify-fencing', is_flag=True,
+              help='Verify failed-node volume attachments are fenced before VMs restart elsewhere')
+@click.option('--verify-data', is_flag=True,
+              help='Write checksummed files in each guest before the failure and verify them after recovery')
+@click.option('--verify-data-size-mb', type=int, default=None,
+              help='Data written per VM for --verify-data in MiB (default: 64)')
 @click.option('--ssh-pod', default='ssh-test-pod', help='SSH pod name for ping checks')
 @click.option('--ssh-pod-namespace', default='default', help='SSH pod namespace for ping checks')
 @click.option('--cleanup/--no-cleanup', default=False, help='Delete test resources after completion')
@@ -48,7 +80,7 @@ console = Console()
 @click.option('--yes', '-y', is_flag=True, help='Skip confirmation prompts')
 @click.option('--save-results', is_flag=True, help='Save detailed results to results folder')
 @click.option('--results-folder', default='../results', help='Base directory to store test results')
-@click.option('--storage-driver', help='Storage driver label for results path (for example: portworx-3.6, ceph)')
+@click.option('--storage-driver', help='Storage driver label for results path (for example: portworx-3.6, ceph), or auto to detect it')
 @click.option('--log-file', type=click.Path(), help='Log file path (auto-generated if not specified)')
 @click.pass_context
 def failure_recovery(ctx, **kwargs):
@@ -65,6 +97,8 @@ def failure_recovery(ctx, **kwargs):
       virtbench failure-recovery --mode manual --node worker-1
 
       virtbench failure-recovery --mode far-operator --node worker-1
+
+      virtbench failure-recovery --mode inject --failure-mode kubelet-stop --node worker-1
     """
     print_banner("Failure Recovery Benchmark")
     
@@ -116,7 +150,10 @@ def failure_recovery(ctx, **kwargs):
         'namespace-prefix': kwargs['namespace_prefix'],
         'far-config': str(far_config_path) if far_config_path else None,
         'concurrency': kwargs['concurrency'],
+        'qps': kwargs['qps'],
+        'burst': kwargs['burst'],
         'poll-interval': kwargs['poll_interval'],
+        'recovery-detection': kwargs['recovery_detection'],
         'node-timeout': kwargs['node_timeout'],
         'recovery-timeout': kwargs['recovery_timeout'],
         'ssh-pod': kwargs['ssh_pod'],
@@ -136,6 +173,16 @@ def failure_recovery(ctx, **kwargs):
         python_args['remove-node-selector'] = True
     if kwargs['skip_ping']:
         python_args['skip-ping'] = True
+    if kwargs['verify_fencing']:
+        python_args['verify-fencing'] = True
+    if kwargs['verify_data']:
+        python_args['verify-data'] = True
+    if kwargs.get('verify_data_size_mb') is not None:
+        python_args['verify-data-size-mb'] = kwargs['verify_data_size_mb']
+    if kwargs['track_kvdb']:
+        python_args['track-kvdb'] = True
+    if kwargs['allow_quorum_loss']:
+        python_args['allow-quorum-loss'] = True
     if kwargs['yes']:
         python_args['yes'] = True
     if kwargs['save_results']:
@@ -149,6 +196,23 @@ def failure_recovery(ctx, **kwargs):
         python_args['far-namespace'] = kwargs['far_namespace']
     if kwargs.get('failed_node'):
         python_args['failed-node'] = kwargs['failed_node']
+    if kwargs.get('failure_mode'):
+        python_args['failure-mode'] = kwargs['failure_mode']
+        python_args['failure-duration'] = kwargs['failure_duration']
+    if kwargs.get('partition_ports'):
+        python_args['partition-ports'] = list(kwargs['partition_ports'])
+    if kwargs.get('injector_image'):
+        python_args['injector-image'] = kwargs['injector_image']
+    if kwargs.get('storage_pods'):
+        python_args['storage-pods'] = list(kwargs['storage_pods'])
+    if kwargs['include_remote_storage_pods']:
+        python_args['include-remote-storage-pods'] = True
+    if kwargs.get('storage_process'):
+        python_args['storage-process'] = kwargs['storage_process']
+    if kwargs.get('vm_user'):
+        python_args['vm-user'] = kwargs['vm_user']
+    if kwargs.get('vm_password'):
+        python_args['vm-password'] = kwargs['vm_password']
     
     # Add log-file only when explicitly requested. With --save-results, the
     # script creates the run directory first and writes the log next to JSON/CSV.
@@ -166,7 +230,7 @@ def failure_recovery(ctx, **kwargs):
     console.print()
     
     try:
-        result = subprocess.run(cmd, cwd=repo_root)
+        result = run_workload(ctx, cmd, cwd=repo_root)
         sys.exit(result.returncode)
     except KeyboardInterrupt:
         console.print("\n[yellow]Interrupted by user[/yellow]")
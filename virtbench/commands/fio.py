@@ -3,12 +3,17 @@
 FIO Benchmark command - Storage I/O performance testing
 """
 import click
-import subprocess
 import sys
 from pathlib import Path
 from rich.console import Console
 
 from virtbench.common import print_banner, generate_log_filename
+from virtbench.utils.orchestration import run_workload
+from virtbench.utils.storageclass import (
+    STORAGE_CLASS_MODES, parse_access_modes, parse_storage_classes, parse_volume_modes,
+    run_storage_class_comparison, storage_variants,
+)
+from virtbench.utils.yaml_modifier import write_storage_class_template
 
 console = Console()
 
@@ -19,7 +24,15 @@ console = Console()
               help='Action to perform')
 @click.option('--start', '-s', required=True, type=int, help='Start index for test namespaces')
 @click.option('--end', '-e', required=True, type=int, help='End index for test namespaces')
-@click.option('--storage-class', help='Storage class name (required for deploy/run-all)')
+@click.option('--storage-class', multiple=True,
+              help='Storage class name (required for deploy/run-all); repeat or comma-separate to compare '
+                   'storage classes with run-all')
+@click.option('--storage-class-mode', type=click.Choice(STORAGE_CLASS_MODES), default='per-class', show_default=True,
+              help='With several storage classes: one run per class, or the VMs of one run striped across the classes')
+@click.option('--access-modes',
+              help='Comma-separated access modes of the VM volumes (RWO, RWX); several compare them with run-all')
+@click.option('--volume-modes',
+              help='Comma-separated volume modes of the VM volumes (Block, Filesystem); several compare them with run-all')
 @click.option('--vm-name', '-n', default='fio-vm', help='VM resource name')
 @click.option('--vm-template', default='examples/vm-templates/fio-vm-template.yaml',
               help='Path to VM template YAML')
@@ -34,7 +47,7 @@ console = Console()
 @click.option('--fio-numjobs', default=4, type=int, help='Number of parallel jobs')
 @click.option('--fio-size', default='10G', help='Test file size')
 @click.option('--results-dir', default='results', help='Base directory for results')
-@click.option('--storage-driver', default='Not-Specified', help='Storage driver label for results folder')
+@click.option('--storage-driver', default='Not-Specified', help='Storage driver label for results folder, or auto to detect it')
 @click.option('--disks-per-vm', default='auto', help='Disks per VM for results folder (auto-detect)')
 @click.option('--save-results', is_flag=True, help='Save results to JSON/CSV files')
 @click.option('--cleanup/--no-cleanup', default=False, help='Delete VMs after test (for run-all)')
@@ -84,13 +97,29 @@ def fio(ctx, **kwargs):
       # Custom FIO parameters
       virtbench fio -a run-all -s 1 -e 50 --storage-class px-csi \\
           --fio-runtime 600 --fio-rw randrw --fio-bs 8k --save-results
+
+      # IOPS, bandwidth and latency of two storage classes side by side
+      virtbench fio -a run-all -s 1 -e 10 --storage-class px-csi --storage-class ocs-storagecluster-ceph-rbd
+
+      # Block vs Filesystem volumes of one storage class
+      virtbench fio -a run-all -s 1 -e 10 --storage-class px-csi --volume-modes Block,Filesystem
     """
     print_banner("FIO Benchmark")
 
     # Validate storage-class for deploy/run-all
-    if kwargs['action'] in ['deploy', 'run-all'] and not kwargs['storage_class']:
+    storage_classes = parse_storage_classes(kwargs['storage_class'])
+    if kwargs['action'] in ['deploy', 'run-all'] and not storage_classes:
         console.print(f"[red]Error:[/red] --storage-class is required for action '{kwargs['action']}'")
         sys.exit(1)
+    try:
+        variants = storage_variants(storage_classes, parse_access_modes(kwargs.get('access_modes')),
+                                    parse_volume_modes(kwargs.get('volume_modes')))
+    except ValueError as e:
+        console.print(f"[red]Error:[/red] {e}")
+        sys.exit(1)
+    if len(variants) > 1 and kwargs['action'] != 'run-all':
+        console.print("[red]Error:[/red] comparing storage classes requires --action run-all")
+        sys.exit(1)
 
     repo_root = ctx.obj.repo_root
     script_path = repo_root / 'io-benchmark' / 'fio' / 'measure-fio-performance.py'
@@ -108,6 +137,12 @@ def fio(ctx, **kwargs):
     if kwargs['action'] in ['deploy', 'run-all'] and not vm_template_path.exists():
         console.print(f"[red]Error:[/red] VM template not found: {vm_template_path}")
         sys.exit(1)
+    template_path = vm_template_path
+    if len(variants) == 1 and (variants[0]['access_mode'] or variants[0]['volume_mode']) \
+            and kwargs['action'] in ['deploy', 'run-all']:
+        console.print(f"[cyan]Using storage variant: {variants[0]['name']}[/cyan]")
+        vm_template_path = write_storage_class_template(template_path, None, access_mode=variants[0]['access_mode'],
+                                                        volume_mode=variants[0]['volume_mode'])
 
     # Build command
     cmd = [sys.executable, str(script_path)]
@@ -118,8 +153,8 @@ def fio(ctx, **kwargs):
     # Add arguments
     cmd.extend(['--start', str(kwargs['start'])])
     cmd.extend(['--end', str(kwargs['end'])])
-    if kwargs['storage_class']:
-        cmd.extend(['--storage-class', kwargs['storage_class']])
+    if storage_classes:
+        cmd.extend(['--storage-class', storage_classes[0]])
     cmd.extend(['--vm-name', kwargs['vm_name']])
     cmd.extend(['--vm-template', str(vm_template_path)])
     cmd.extend(['--namespace-prefix', kwargs['namespace_prefix']])
@@ -153,11 +188,19 @@ def fio(ctx, **kwargs):
     if kwargs['log_file']:
         cmd.extend(['--log-file', kwargs['log_file']])
 
+    if len(variants) > 1:
+        try:
+            sys.exit(run_storage_class_comparison(ctx, cmd, variants, repo_root, kwargs['storage_class_mode'],
+                                                  template_path=template_path, cleanup_args=('--cleanup',)))
+        except KeyboardInterrupt:
+            console.print("\n[yellow]Interrupted by user[/yellow]")
+            sys.exit(130)
+
     console.print(f"[cyan]Running:[/cyan] {' '.join(cmd[:3])}...")
     console.print(f"[dim]Full command: {' '.join(cmd)}[/dim]\n")
 
     try:
-        result = subprocess.run(cmd, cwd=str(repo_root))
+        result = run_workload(ctx, cmd, cwd=str(repo_root))
         sys.exit(result.returncode)
     except KeyboardInterrupt:
         console.print("\n[yellow]Interrupted by user[/yellow]")
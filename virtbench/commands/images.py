@@ -0,0 +1,125 @@
+#!/usr/bin/env python3
+"""
+Images command - List, upload and import guest OS images (DataSources)
+"""
+import click
+import os
+import sys
+from typing import Dict
+
+from rich.console import Console
+
+from virtbench.common import print_banner, build_python_command
+from virtbench.utils.orchestration import run_workload
+
+console = Console()
+
+
+def _images(ctx, python_args: Dict) -> int:
+    """Run utils/images.py on the clusters of this invocation."""
+    repo_root = ctx.obj.repo_root
+    python_args = dict(python_args, **{'log-level': ctx.obj.log_level.upper()})
+    if ctx.obj.kubeconfig:
+        python_args['kubeconfig'] = ctx.obj.kubeconfig
+    cmd = build_python_command(repo_root / 'utils' / 'images.py', python_args)
+    try:
+        return run_workload(ctx, cmd, cwd=repo_root).returncode
+    except KeyboardInterrupt:
+        console.print("\n[yellow]Interrupted by user[/yellow]")
+        return 130
+
+
+def _volume_options(func):
+    """Options of the image PVC shared by upload and import."""
+    for option in reversed([
+        click.option('--name', required=True, help='Name of the image PVC and DataSource, e.g. fedora'),
+        click.option('--namespace',
+                     help='Namespace of the image (default: the golden image namespace of the platform)'),
+        click.option('--size', default='30Gi', help='Size of the image PVC'),
+        click.option('--storage-class', help='Storage class of the image PVC (default: cluster default)'),
+        click.option('--access-mode', type=click.Choice(['ReadWriteOnce', 'ReadWriteMany']),
+                     help='Access mode of the image PVC (default: from the StorageProfile)'),
+        click.option('--volume-mode', type=click.Choice(['Block', 'Filesystem']),
+                     help='Volume mode of the image PVC (default: from the StorageProfile)'),
+        click.option('--preference', help='Default VirtualMachinePreference of the DataSource, e.g. rhel.9'),
+        click.option('--timeout', default=1800, type=int,
+                     help='Seconds to wait for the upload or import and the DataSource'),
+    ]):
+        func = option(func)
+    return func
+
+
+def _volume_args(kwargs: Dict) -> Dict:
+    return {
+        'name': kwargs['name'],
+        'namespace': kwargs['namespace'],
+        'size': kwargs['size'],
+        'storage-class': kwargs['storage_class'],
+        'access-mode': kwargs['access_mode'],
+        'volume-mode': kwargs['volume_mode'],
+        'preference': kwargs['preference'],
+        'timeout': kwargs['timeout'],
+    }
+
+
+@click.group('images')
+def images():
+    """
+    List, upload and import guest OS images
+
+    Benchmarks clone their VMs from a golden image DataSource. These
+    commands list the DataSources of the cluster and create new ones from a
+    local disk image (through the CDI upload proxy) or from an HTTP(S) URL,
+    without hand-written YAML. The DataSource is named like the image and
+    goes to the platform's golden image namespace unless --namespace says
+    otherwise.
+
+    \b
+    Examples:
+      # Available DataSources
+      virtbench images list
+    \b
+      # Upload a local qcow2 as DataSource fedora
+      virtbench images upload --file Fedora-Cloud-Base-40.qcow2 --name fedora --size 20Gi
+    \b
+      # Import an image from a URL onto a storage class
+      virtbench images import --url https://example.com/rhel-9.4.qcow2 --name rhel9 \\
+          --storage-class px-csi-db --preference rhel.9
+    """
+
+
+@images.command('list')
+@click.option('--namespace', help='Only list this namespace (default: all namespaces)')
+@click.pass_context
+def list_images(ctx, namespace):
+    """List DataSources (and Harvester images) with their readiness and source"""
+    sys.exit(_images(ctx, {'list': True, 'namespace': namespace}))
+
+
+@images.command('upload')
+@click.option('--file', 'image_file', required=True, type=click.Path(exists=True, dir_okay=False),
+              help='Local disk image (qcow2, raw or iso, optionally compressed)')
+@_volume_options
+@click.option('--uploadproxy-url', help='URL of the CDI upload proxy (default: found by virtctl)')
+@click.option('--insecure', is_flag=True, help='Do not verify the upload proxy certificate')
+@click.pass_context
+def upload(ctx, image_file, uploadproxy_url, insecure, **kwargs):
+    """Upload a local disk image and create a DataSource for it"""
+    print_banner("Upload Guest OS Image")
+    python_args = dict(_volume_args(kwargs), upload=os.path.abspath(image_file),
+                       **{'uploadproxy-url': uploadproxy_url})
+    if insecure:
+        python_args['insecure'] = True
+    sys.exit(_images(ctx, python_args))
+
+
+@images.command('import')
+@click.option('--url', required=True, help='HTTP(S) URL of the disk image')
+@_volume_options
+@click.option('--cert-configmap', help='ConfigMap with the CA bundle of the URL\'s server')
+@click.pass_context
+def import_image(ctx, url, cert_configmap, **kwargs):
+    """Import a disk image from a URL and create a DataSource for it"""
+    print_banner("Import Guest OS Image")
+    python_args = dict(_volume_args(kwargs), **{'import-url': url, 'cert-configmap': cert_configmap})
+    sys.exit(_images(ctx, python_args))
@@ -0,0 +1,130 @@
+#!/usr/bin/env python3
+"""
+Lifecycle command - start/stop/restart/pause/unpause latency of existing VMs
+"""
+import click
+import sys
+from rich.console import Console
+
+from virtbench.common import print_banner, build_python_command, generate_log_filename
+from virtbench.utils.orchestration import run_workload
+
+console = Console()
+
+# Verbs of measure-vm-lifecycle.py --existing-vms
+VERBS = ['start', 'pause', 'unpause', 'stop', 'restart']
+
+
+@click.command('lifecycle')
+@click.option('--start', '-s', default=1, type=int, help='Start namespace index')
+@click.option('--end', '-e', default=10, type=int, help='End namespace index')
+@click.option('--namespace-prefix', default='datasource-clone', help='Namespace prefix of the existing VMs')
+@click.option('--vm-name', default='rhel-9-vm', help='Name of the existing VM in each namespace')
+@click.option('--verbs', help='Comma-separated verbs to run in order as phases '
+                              '(default: stop,start,restart,pause,unpause)')
+@click.option('--mix', help='Comma-separated verb=weight operation mix, e.g. restart=2,pause=1,unpause=1; '
+                            'each round every VM runs one verb valid in its state')
+@click.option('--iterations', default=1, type=int, help='Passes over the verbs, or rounds of --mix')
+@click.option('--seed', type=int, help='Random seed of --mix')
+@click.option('--concurrency', '-c', default=10, type=int, help='VMs operated on concurrently')
+@click.option('--qps', type=float, help='Max operations started per second (default: unlimited)')
+@click.option('--burst', type=int, help='Max operations started back-to-back when --qps is set')
+@click.option('--poll-interval', default=0.5, type=float, help='Seconds between status checks')
+@click.option('--timeout', default=600, type=int, help='Timeout per operation (seconds)')
+@click.option('--precision', type=int, help='Decimal places for durations in saved results (default: 3)')
+@click.option('--save-results', is_flag=True, help='Save results to JSON/CSV files')
+@click.option('--results-folder', default='results', help='Base directory for results')
+@click.option('--storage-driver', help='Storage driver label for results path (for example: portworx-3.6, ceph), or auto to detect it')
+@click.option('--log-file', type=click.Path(), help='Log file path (auto-generated if not specified)')
+@click.option('--log-level', default='INFO',
+              type=click.Choice(['DEBUG', 'INFO', 'WARNING', 'ERROR']),
+              help='Logging level')
+@click.pass_context
+def lifecycle(ctx, **kwargs):
+    """
+    Run start/stop/restart/pause/unpause benchmark on existing VMs
+
+    Times lifecycle operations of VMs that already exist, for example those
+    of a datasource-clone run, and reports a per-verb latency table. Verbs
+    run as phases across all VMs, or with --mix as a weighted operation mix
+    in which different verbs overlap. VMs not in the state the first verb
+    needs sit out, and every VM is returned to its initial Running or
+    Stopped state afterwards.
+
+    \b
+    Examples:
+      # Stop, start, restart, pause and unpause 100 VMs of a datasource-clone run
+      virtbench lifecycle --namespace-prefix datasource-clone --start 1 --end 100 \\
+          --concurrency 50 --save-results
+    \b
+      # Restart latency only, three passes
+      virtbench lifecycle --start 1 --end 50 --verbs restart --iterations 3
+    \b
+      # Mixed load: 10 rounds of mostly restarts, with pauses and stop/start cycles
+      virtbench lifecycle --start 1 --end 100 --mix restart=3,pause=1,unpause=1,stop=1,start=1 \\
+          --iterations 10 --save-results
+    """
+    print_banner("Lifecycle Benchmark")
+
+    repo_root = ctx.obj.repo_root
+    script_path = repo_root / 'vm-lifecycle' / 'measure-vm-lifecycle.py'
+
+    if not script_path.exists():
+        console.print(f"[red]Error:[/red] Script not found: {script_path}")
+        sys.exit(1)
+    if kwargs.get('verbs') and kwargs.get('mix'):
+        console.print("[red]Error:[/red] --verbs and --mix cannot be combined")
+        sys.exit(1)
+
+    python_args = {
+        'existing-vms': True,
+        'start': kwargs['start'],
+        'end': kwargs['end'],
+        'namespace-prefix': kwargs['namespace_prefix'],
+        'vm-name': kwargs['vm_name'],
+        'iterations': kwargs['iterations'],
+        'seed': kwargs['seed'],
+        'concurrency': kwargs['concurrency'],
+        'qps': kwargs['qps'],
+        'burst': kwargs['burst'],
+        'poll-interval': kwargs['poll_interval'],
+        'timeout': kwargs['timeout'],
+        'precision': kwargs['precision'],
+        'results-folder': kwargs['results_folder'],
+        'storage-driver': kwargs['storage_driver'],
+        'log-level': kwargs['log_level'],
+    }
+    if kwargs.get('verbs'):
+        python_args['verbs'] = [v.strip() for v in kwargs['verbs'].split(',') if v.strip()]
+        invalid = [v for v in python_args['verbs'] if v not in VERBS]
+        if invalid:
+            console.print(f"[red]Error:[/red] Unknown verbs {', '.join(invalid)} (choose from {', '.join(VERBS)})")
+            sys.exit(1)
+    if kwargs.get('mix'):
+        python_args['mix'] = [m.strip() for m in kwargs['mix'].split(',') if m.strip()]
+
+    # Log file: explicit > global context > auto-generated timestamped file
+    if kwargs.get('log_file'):
+        python_args['log-file'] = kwargs['log_file']
+    elif ctx.obj.log_file:
+        python_args['log-file'] = ctx.obj.log_file
+    else:
+        python_args['log-file'] = generate_log_filename('lifecycle')
+
+    if kwargs['save_results']:
+        python_args['save-results'] = True
+
+    cmd = build_python_command(script_path, python_args)
+
+    console.print(f"[dim]Running: {' '.join(cmd[:2])} ...[/dim]")
+    console.print()
+
+    try:
+        result = run_workload(ctx, cmd, cwd=repo_root)
+        sys.exit(result.returncode)
+    except KeyboardInterrupt:
+        console.print("\n[yellow]Interrupted by user[/yellow]")
+        sys.exit(130)
+    except Exception as e:
+        console.print(f"[red]Error:[/red] {e}")
+        sys.exit(1)
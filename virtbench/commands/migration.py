@@ -3,13 +3,19 @@
 VM Migration benchmark command
 """
 import click
-import subprocess
 import sys
 from pathlib import Path
 from rich.console import Console
 
-from virtbench.utils.yaml_modifier import modify_storage_class
+from virtbench.utils.yaml_modifier import modify_storage_class, write_storage_class_template
 from virtbench.common import print_banner, build_python_command, generate_log_filename
+from virtbench.utils.orchestration import run_workload
+from virtbench.utils.tuning import run_tuning_comparison
+from virtbench.utils.storageclass import (
+    STORAGE_CLASS_MODES, parse_access_modes, parse_storage_classes, parse_volume_modes,
+    run_storage_class_comparison, storage_variants,
+)
+from virtbench.commands.datasource_clone import PLACEMENT_STRATEGIES
 
 console = Console()
 
@@ -28,12 +34,25 @@ def _normalize_source_nodes(values):
 @click.command('migration')
 @click.option('--start', '-s', default=1, type=int, help='Start index for test namespaces')
 @click.option('--end', '-e', default=10, type=int, help='End index for test namespaces')
-@click.option('--vm-name', '-n', default='rhel-9-vm', help='VM resource name')
+@click.option('--vm-name', '-n', help='VM resource name (default: rhel-9-vm, or win2k22-vm for Windows)')
 @click.option('--vm-template',
-              default='examples/vm-templates/rhel9-vm-datasource.yaml',
-              help='Path to VM template YAML')
-@click.option('--storage-class', help='Storage class name (required with --create-vms)')
+              help='Path to VM template YAML (default: rhel9-vm-datasource.yaml, or '
+                   'windows-vm-datasource.yaml for Windows)')
+@click.option('--guest-os', type=click.Choice(['linux', 'windows']), default='linux',
+              help='Guest OS; Windows guests are validated via RDP/WinRM instead of ping')
+@click.option('--storage-class', multiple=True,
+              help='Storage class name (required with --create-vms); repeat or comma-separate to compare storage classes')
+@click.option('--storage-class-mode', type=click.Choice(STORAGE_CLASS_MODES), default='per-class', show_default=True,
+              help='With several storage classes: one run per class, or the VMs of one run striped across the classes')
+@click.option('--access-modes',
+              help='Comma-separated access modes of the created VM volumes (RWO, RWX); several compare them, one run each')
+@click.option('--volume-modes',
+              help='Comma-separated volume modes of the created VM volumes (Block, Filesystem); several compare them, '
+                   'one run each')
+@click.option('--data-storage-class', help='Storage class for data disks in templates with {{DATA_STORAGE_CLASS_NAME}} (default: --storage-class)')
 @click.option('--namespace-prefix', default='migration', help='Namespace prefix')
+@click.option('--single-namespace',
+              help='Use VMs named <vm-name>-<index> in this existing namespace instead of one namespace per VM')
 @click.option('--source-node', help='Source node for VM creation and migration')
 @click.option('--source-nodes', multiple=True,
               help='Multi-node evacuation: list of source nodes whose VMs will all be migrated '
@@ -43,15 +62,40 @@ def _normalize_source_nodes(values):
                    'interleaved order so load is spread across source nodes from the start.')
 @click.option('--target-node', help='Target node name to migrate VMs to')
 @click.option('--create-vms', is_flag=True, help='Create VMs on source node before migration (requires --storage-class)')
+@click.option('--dedicated-cpus', is_flag=True, help='Create VMs with every vCPU pinned to a dedicated host core')
+@click.option('--hugepages', type=click.Choice(['2Mi', '1Gi']), help='Create VMs with hugepages-backed guest memory')
+@click.option('--numa', is_flag=True,
+              help='Pass the host NUMA topology through to the guest (requires --dedicated-cpus and --hugepages)')
+@click.option('--skip-hugepages-check', is_flag=True,
+              help='Do not abort when the nodes lack free hugepages for the VMs')
+@click.option('--compare-tuning', is_flag=True,
+              help='Run without and then with the tuning flags and compare migration times (requires --create-vms)')
 @click.option('--parallel', is_flag=True, help='Migrate all VMs in parallel')
 @click.option('--evacuate', is_flag=True, help='Evacuate all VMs from source node')
 @click.option('--auto-select-busiest', is_flag=True,
               help='Auto-select the node with the most matching VMs for evacuation')
 @click.option('--round-robin', is_flag=True,
               help='Migrate VMs to randomly selected different worker nodes')
+@click.option('--policy-matrix', type=click.Path(exists=True, dir_okay=False),
+              help='YAML file of MigrationPolicy settings to migrate the VMs under, one pass per policy')
+@click.option('--bandwidth-sweep',
+              help='Comma-separated bandwidthPerMigration values (e.g. 32Mi,64Mi,128Mi), one pass per value')
+@click.option('--parallel-sweep',
+              help='Comma-separated parallelMigrationsPerCluster values for the sweep (requires --parallel)')
 @click.option('--interleaved-scheduling', is_flag=True,
-              help='Interleave parallel migration scheduling across detected nodes')
+              help='Interleave parallel migration scheduling across detected nodes (same as --placement interleave)')
+@click.option('--placement', type=click.Choice(PLACEMENT_STRATEGIES), default='none',
+              help='Target node selection of --parallel and --source-nodes migrations (none lets KubeVirt pick)')
+@click.option('--placement-nodes', help='Comma-separated target nodes the placement strategy uses')
+@click.option('--max-vms-per-node', type=click.IntRange(1),
+              help='Migrations per target node before pack moves to the next node')
+@click.option('--migration-zone', type=click.Choice(['any', 'same', 'cross']), default='any',
+              help='Migrate within the zone of the current node (same), to another zone (cross) or anywhere')
+@click.option('--topology-key', help='Node label of the zone, e.g. a rack label (default: topology.kubernetes.io/zone)')
 @click.option('--concurrency', '-c', default=50, type=int, help='Max parallel threads')
+@click.option('--qps', default=0.0, type=float,
+              help='Max VM operations started per second (0 disables rate limiting)')
+@click.option('--burst', default=10, type=int, help='Max VM operations started back-to-back when --qps is set')
 @click.option('--poll-interval', default=1, type=int, help='Seconds between status checks')
 @click.option('--migration-timeout', default=600, type=int, help='Timeout for migration in seconds')
 @click.option('--max-migration-retries', default=3, type=int,
@@ -61,13 +105,28 @@ def _normalize_source_nodes(values):
 @click.option('--ping-timeout', default=3600, type=int,
               help='Timeout for ping validation in seconds (default: 3600s = 1 hour)')
 @click.option('--skip-ping', is_flag=True, help='Skip ping validation after migration')
+@click.option('--verify-data', is_flag=True,
+              help='Write checksummed files in each guest before migration and verify them afterwards')
+@click.option('--verify-data-size-mb', type=int, default=None,
+              help='Data written per VM for --verify-data in MiB (default: 64)')
+@click.option('--guest-load-memory-mb', type=int,
+              help='Keep rewriting this many MiB of guest memory during migration (Linux guests)')
+@click.option('--guest-load-dirty-rate', type=float,
+              help='Memory dirtying rate for --guest-load-memory-mb in MiB/s (default: as fast as possible)')
+@click.option('--guest-load-cpu', type=int, help='Busy-loop CPU workers started in each guest during migration')
+@click.option('--guest-load-disk-mbs', type=int,
+              help='Approximate direct disk write rate in each guest during migration (MiB/s)')
+@click.option('--vm-user', help='Guest SSH user for --verify-data and --guest-load-* (default: cloud-user)')
+@click.option('--vm-password', help='Guest SSH password for --verify-data and --guest-load-* (default: changeme)')
 @click.option('--ssh-pod', default='ssh-test-pod', help='SSH pod name for ping tests (default: ssh-test-pod)')
 @click.option('--ssh-pod-ns', default='default', help='SSH pod namespace (default: default)')
 @click.option('--cleanup/--no-cleanup', default=False, help='Delete test resources after completion')
 @click.option('--yes', '-y', is_flag=True, help='Skip confirmation prompts')
 @click.option('--save-results', is_flag=True, help='Save detailed results to results folder')
+@click.option('--precision', default=2, type=click.IntRange(0, 9),
+              help='Decimal places for durations in saved results')
 @click.option('--results-folder', default='../results', help='Base directory to store test results')
-@click.option('--storage-driver', help='Storage driver label for results path (for example: portworx-3.6, ceph)')
+@click.option('--storage-driver', help='Storage driver label for results path (for example: portworx-3.6, ceph), or auto to detect it')
 @click.option('--log-file', type=click.Path(), help='Log file path (auto-generated if not specified)')
 @click.pass_context
 def migration(ctx, **kwargs):
@@ -98,6 +157,10 @@ def migration(ctx, **kwargs):
       # Evacuate all VMs from a node
       virtbench migration --start 1 --end 100 --source-node worker-1 --evacuate
 
+      # All VMs in one existing namespace (my-project/rhel-9-vm-1 ... rhel-9-vm-20)
+      virtbench migration --start 1 --end 20 --single-namespace my-project \
+        --create-vms --storage-class YOUR-STORAGE-CLASS --parallel
+
       # Multi-node evacuation: discover VMs on multiple nodes and migrate
       # them in parallel, interleaved across source nodes
       virtbench migration --source-nodes worker-1,worker-2,worker-3 \\
@@ -105,6 +168,29 @@ def migration(ctx, **kwargs):
 
       # Evacuate every worker node in the cluster
       virtbench migration --source-nodes all --concurrency 20 --save-results
+
+      # Compare MigrationPolicy settings on the same VMs
+      virtbench migration --start 1 --end 10 --parallel --save-results \
+        --policy-matrix examples/benchmarks/migration-policy-matrix.yaml
+
+      # Total time vs downtime under bandwidth caps and parallel migration limits
+      virtbench migration --start 1 --end 10 --parallel --save-results \
+        --bandwidth-sweep 32Mi,64Mi,128Mi --parallel-sweep 2,5
+
+      # Measure cross-zone migrations (source and target zones are recorded per VM)
+      virtbench migration --start 1 --end 50 --parallel --migration-zone cross --save-results
+
+      # Migration time on two storage classes, half the VMs on each in one run
+      virtbench migration --start 1 --end 20 --create-vms --parallel --save-results \
+        --storage-class px-db --storage-class ocs-storagecluster-ceph-rbd --storage-class-mode striped
+
+      # Migration time of Block vs Filesystem RWX volumes
+      virtbench migration --start 1 --end 10 --create-vms --storage-class YOUR-STORAGE-CLASS --parallel \
+        --access-modes RWX --volume-modes Block,Filesystem --save-results
+
+      # Migration time of pinned, hugepages-backed VMs vs untuned ones
+      virtbench migration --start 1 --end 10 --create-vms --storage-class YOUR-STORAGE-CLASS --parallel \
+        --dedicated-cpus --hugepages 2Mi --compare-tuning
     """
     print_banner("VM Migration Benchmark")
 
@@ -112,14 +198,34 @@ def migration(ctx, **kwargs):
     repo_root = ctx.obj.repo_root
 
     # Validate --create-vms requires --storage-class
-    if kwargs['create_vms'] and not kwargs['storage_class']:
+    storage_classes = parse_storage_classes(kwargs['storage_class'])
+    if kwargs['create_vms'] and not storage_classes:
         console.print("[red]Error: --storage-class is required when using --create-vms[/red]")
         console.print("[yellow]Hint: Specify the storage class to use for VM creation:[/yellow]")
         console.print("  virtbench migration --create-vms --storage-class YOUR-STORAGE-CLASS ...")
         sys.exit(1)
+    try:
+        variants = storage_variants(storage_classes, parse_access_modes(kwargs.get('access_modes')),
+                                    parse_volume_modes(kwargs.get('volume_modes')))
+    except ValueError as e:
+        console.print(f"[red]Error: {e}[/red]")
+        sys.exit(1)
+    if (kwargs.get('access_modes') or kwargs.get('volume_modes')) and not kwargs['create_vms']:
+        console.print("[red]Error: --access-modes and --volume-modes require --create-vms[/red]")
+        sys.exit(1)
+    if len(variants) > 1 and not kwargs['create_vms']:
+        console.print("[red]Error: comparing storage classes requires --create-vms[/red]")
+        sys.exit(1)
+    if any(v['access_mode'] and v['access_mode'] != 'ReadWriteMany' for v in variants):
+        # KubeVirt only live-migrates VMs whose volumes are all RWX
+        console.print("[yellow]Warning: VMs with non-RWX volumes are not live-migratable; "
+                      "their migrations are expected to fail[/yellow]")
 
     # Resolve template path
-    template_path = Path(kwargs['vm_template'])
+    default_template = ('examples/vm-templates/windows-vm-datasource.yaml'
+                        if kwargs['guest_os'] == 'windows'
+                        else 'examples/vm-templates/rhel9-vm-datasource.yaml')
+    template_path = Path(kwargs['vm_template'] or default_template)
     if not template_path.is_absolute():
         template_path = repo_root / template_path
 
@@ -127,12 +233,21 @@ def migration(ctx, **kwargs):
         console.print(f"[red]Error: Template file not found: {template_path}[/red]")
         sys.exit(1)
 
-    # Handle storage class modification
-    if kwargs['storage_class'] and kwargs['create_vms']:
-        console.print(f"[cyan]Using storage class: {kwargs['storage_class']}[/cyan]")
+    # Handle storage class modification; several storage variants get a template copy per run
+    if len(variants) == 1 and (variants[0]['access_mode'] or variants[0]['volume_mode']):
+        console.print(f"[cyan]Using storage variant: {variants[0]['name']}[/cyan]")
+        try:
+            template_path = write_storage_class_template(template_path, variants[0]['storage_class'],
+                                                         kwargs.get('data_storage_class'), variants[0]['access_mode'],
+                                                         variants[0]['volume_mode'])
+        except Exception as e:
+            console.print(f"[red]Error modifying storage class: {e}[/red]")
+            sys.exit(1)
+    elif len(variants) == 1 and kwargs['create_vms']:
+        console.print(f"[cyan]Using storage class: {storage_classes[0]}[/cyan]")
         console.print(f"[cyan]VMs will be created on source node: {kwargs.get('source_node', 'auto-selected')}[/cyan]")
         try:
-            modify_storage_class(template_path, kwargs['storage_class'])
+            modify_storage_class(template_path, storage_classes[0], kwargs.get('data_storage_class'))
         except Exception as e:
             console.print(f"[red]Error modifying storage class: {e}[/red]")
             sys.exit(1)
@@ -150,8 +265,12 @@ def migration(ctx, **kwargs):
         'end': kwargs['end'],
         'vm-name': kwargs['vm_name'],
         'vm-template': str(template_path),
+        'guest-os': kwargs['guest_os'],
         'namespace-prefix': kwargs['namespace_prefix'],
+        'single-namespace': kwargs['single_namespace'],
         'concurrency': kwargs['concurrency'],
+        'qps': kwargs['qps'],
+        'burst': kwargs['burst'],
         'poll-interval': kwargs['poll_interval'],
         'migration-timeout': kwargs['migration_timeout'],
         'max-migration-retries': kwargs['max_migration_retries'],
@@ -160,6 +279,7 @@ def migration(ctx, **kwargs):
         'ssh-pod': kwargs['ssh_pod'],
         'ssh-pod-ns': kwargs['ssh_pod_ns'],
         'results-folder': kwargs['results_folder'],
+        'precision': kwargs['precision'],
         'log-level': ctx.obj.log_level.upper(),
     }
 
@@ -176,6 +296,16 @@ def migration(ctx, **kwargs):
         python_args['round-robin'] = True
     if kwargs['interleaved_scheduling']:
         python_args['interleaved-scheduling'] = True
+    if kwargs['placement'] != 'none':
+        python_args['placement'] = kwargs['placement']
+    if kwargs.get('placement_nodes'):
+        python_args['placement-nodes'] = [n.strip() for n in kwargs['placement_nodes'].split(',') if n.strip()]
+    if kwargs.get('max_vms_per_node'):
+        python_args['max-vms-per-node'] = kwargs['max_vms_per_node']
+    if kwargs['migration_zone'] != 'any':
+        python_args['migration-zone'] = kwargs['migration_zone']
+    if kwargs.get('topology_key'):
+        python_args['topology-key'] = kwargs['topology_key']
     if kwargs['cleanup']:
         python_args['cleanup'] = True
     if kwargs['yes']:
@@ -184,6 +314,32 @@ def migration(ctx, **kwargs):
         python_args['save-results'] = True
     if kwargs['skip_ping']:
         python_args['skip-ping'] = True
+    if kwargs['verify_data']:
+        python_args['verify-data'] = True
+    if kwargs['dedicated_cpus']:
+        python_args['dedicated-cpus'] = True
+    if kwargs['numa']:
+        python_args['numa'] = True
+    if kwargs.get('hugepages'):
+        python_args['hugepages'] = kwargs['hugepages']
+    if kwargs['skip_hugepages_check']:
+        python_args['skip-hugepages-check'] = True
+
+    if kwargs['policy_matrix']:
+        python_args['policy-matrix'] = str(Path(kwargs['policy_matrix']).resolve())
+    if kwargs.get('bandwidth_sweep'):
+        python_args['bandwidth-sweep'] = [b.strip() for b in kwargs['bandwidth_sweep'].split(',') if b.strip()]
+    if kwargs.get('parallel_sweep'):
+        try:
+            python_args['parallel-sweep'] = [int(p) for p in kwargs['parallel_sweep'].split(',') if p.strip()]
+        except ValueError:
+            console.print("[red]Error: --parallel-sweep takes comma-separated integers[/red]")
+            sys.exit(1)
+
+    for key in ('verify_data_size_mb', 'guest_load_memory_mb', 'guest_load_dirty_rate', 'guest_load_cpu',
+                'guest_load_disk_mbs', 'vm_user', 'vm_password'):
+        if kwargs.get(key) is not None:
+            python_args[key.replace('_', '-')] = kwargs[key]
 
     # Add optional args
     if kwargs.get('source_node'):
@@ -207,6 +363,34 @@ def migration(ctx, **kwargs):
     elif not kwargs['save_results']:
         python_args['log-file'] = generate_log_filename('migration')
     
+    if len(variants) > 1:
+        if kwargs['compare_tuning'] or kwargs['policy_matrix'] or kwargs.get('bandwidth_sweep') \
+                or kwargs.get('parallel_sweep'):
+            console.print("[red]Error: comparing storage classes cannot be combined with --compare-tuning, "
+                          "--policy-matrix or a sweep[/red]")
+            sys.exit(1)
+        try:
+            sys.exit(run_storage_class_comparison(ctx, build_python_command(script_path, python_args),
+                                                  variants, repo_root, kwargs['storage_class_mode'],
+                                                  template_path=template_path,
+                                                  data_storage_class=kwargs.get('data_storage_class')))
+        except KeyboardInterrupt:
+            console.print("\n[yellow]Interrupted by user[/yellow]")
+            sys.exit(130)
+
+    if kwargs['compare_tuning']:
+        if not (kwargs['dedicated_cpus'] or kwargs['hugepages'] or kwargs['numa']):
+            console.print("[red]Error: --compare-tuning requires --dedicated-cpus, --hugepages or --numa[/red]")
+            sys.exit(1)
+        if not kwargs['create_vms']:
+            console.print("[red]Error: --compare-tuning requires --create-vms[/red]")
+            sys.exit(1)
+        try:
+            sys.exit(run_tuning_comparison(ctx, script_path, python_args, repo_root))
+        except KeyboardInterrupt:
+            console.print("\n[yellow]Interrupted by user[/yellow]")
+            sys.exit(130)
+
     # Build and run command
     cmd = build_python_command(script_path, python_args)
     
@@ -214,7 +398,7 @@ def migration(ctx, **kwargs):
     console.print()
     
     try:
-        result = subprocess.run(cmd, cwd=repo_root)
+        result = run_workload(ctx, cmd, cwd=repo_root)
         sys.exit(result.returncode)
     except KeyboardInterrupt:
         console.print("\n[yellow]Interrupted by user[/yellow]")
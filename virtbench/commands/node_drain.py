@@ -0,0 +1,118 @@
+#!/usr/bin/env python3
+"""
+Node Drain Benchmark command - evacuation of a node with kubectl drain
+"""
+import click
+import sys
+from rich.console import Console
+
+from virtbench.common import print_banner, build_python_command, generate_log_filename
+from virtbench.utils.orchestration import run_workload
+
+console = Console()
+
+
+@click.command('node-drain')
+@click.option('--start', '-s', default=1, type=int, help='Start namespace index')
+@click.option('--end', '-e', default=10, type=int, help='End namespace index')
+@click.option('--namespace-prefix', default='kubevirt-perf-test', help='Namespace prefix')
+@click.option('--vm-name', default='rhel-9-vm', help='VM name in each namespace')
+@click.option('--node', help='Node to drain (default: the node hosting the most benchmark VMs)')
+@click.option('--drain-timeout', default=1800, type=int, help='kubectl drain timeout in seconds')
+@click.option('--grace-period', default=30, type=int, help='Pod termination grace period for non-VM pods')
+@click.option('--poll-interval', default=1.0, type=float, help='Seconds between VMI status checks')
+@click.option('--keep-cordoned', is_flag=True, help='Leave the node cordoned afterwards (default: uncordon)')
+@click.option('--pdb-fraction', default=0.0, type=float,
+              help='Create a PodDisruptionBudget for this fraction (0-1) of the VMs on the node before the drain')
+@click.option('--pdb-min-available', default=1, type=int, help='minAvailable of the PDBs')
+@click.option('--compare-pdb', is_flag=True, help='Drain once without and once with the PDBs and compare')
+@click.option('--precision', type=int, help='Decimal places for durations in saved results (default: 2)')
+@click.option('--save-results', is_flag=True, help='Save results to JSON/CSV files')
+@click.option('--results-folder', default='results', help='Base directory for results')
+@click.option('--storage-driver', help='Storage driver label for results path (for example: portworx-3.6, ceph), or auto to detect it')
+@click.option('--log-file', type=click.Path(), help='Log file path (auto-generated if not specified)')
+@click.option('--log-level', default='INFO',
+              type=click.Choice(['DEBUG', 'INFO', 'WARNING', 'ERROR']),
+              help='Logging level')
+@click.pass_context
+def node_drain(ctx, **kwargs):
+    """
+    Run node drain benchmark
+
+    Drains a node hosting benchmark VMs with kubectl drain and measures the
+    total drain time, the time until every benchmark VM has left the node,
+    per-VM migration times, and where the VMs were placed afterwards. The
+    migrations are created by KubeVirt from the evictions, unlike
+    `migration --evacuate`. With --pdb-fraction, PodDisruptionBudgets on
+    part of the VMs show how refused evictions slow the drain.
+
+    \b
+    Examples:
+      # Drain the node hosting the most benchmark VMs
+      virtbench node-drain --start 1 --end 50 --save-results
+    \b
+      # Drain a given node and leave it cordoned
+      virtbench node-drain --start 1 --end 50 --node worker-2 --keep-cordoned
+    \b
+      # Drain time without and with PDBs on half of the VMs
+      virtbench node-drain --start 1 --end 50 --pdb-fraction 0.5 --compare-pdb --save-results
+    """
+    print_banner("Node Drain Benchmark")
+
+    repo_root = ctx.obj.repo_root
+    script_path = repo_root / 'node-drain' / 'measure-node-drain.py'
+
+    if not script_path.exists():
+        console.print(f"[red]Error:[/red] Script not found: {script_path}")
+        sys.exit(1)
+
+    console.print(f"[cyan]Node:[/cyan] {kwargs['node'] or 'busiest node'}")
+
+    # Map CLI args to the benchmark script's arguments
+    python_args = {
+        'start': kwargs['start'],
+        'end': kwargs['end'],
+        'namespace-prefix': kwargs['namespace_prefix'],
+        'vm-name': kwargs['vm_name'],
+        'node': kwargs['node'],
+        'drain-timeout': kwargs['drain_timeout'],
+        'grace-period': kwargs['grace_period'],
+        'pdb-fraction': kwargs['pdb_fraction'],
+        'pdb-min-available': kwargs['pdb_min_available'],
+        'poll-interval': kwargs['poll_interval'],
+        'precision': kwargs['precision'],
+        'results-folder': kwargs['results_folder'],
+        'storage-driver': kwargs['storage_driver'],
+        'log-level': kwargs['log_level'],
+    }
+
+    # Log file: explicit > global context > auto-generated timestamped file
+    if kwargs.get('log_file'):
+        python_args['log-file'] = kwargs['log_file']
+    elif ctx.obj.log_file:
+        python_args['log-file'] = ctx.obj.log_file
+    else:
+        python_args['log-file'] = generate_log_filename('node-drain')
+
+    # Flags
+    if kwargs['keep_cordoned']:
+        python_args['keep-cordoned'] = True
+    if kwargs['compare_pdb']:
+        python_args['compare-pdb'] = True
+    if kwargs['save_results']:
+        python_args['save-results'] = True
+
+    cmd = build_python_command(script_path, python_args)
+
+    console.print(f"[dim]Running: {' '.join(cmd[:2])} ...[/dim]")
+    console.print()
+
+    try:
+        result = run_workload(ctx, cmd, cwd=repo_root)
+        sys.exit(result.returncode)
+    except KeyboardInterrupt:
+        console.print("\n[yellow]Interrupted by user[/yellow]")
+        sys.exit(130)
+    except Exception as e:
+        console.print(f"[red]Error:[/red] {e}")
+        sys.exit(1)
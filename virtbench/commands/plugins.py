@@ -0,0 +1,126 @@
+#!/usr/bin/env python3
+"""
+Plugin workloads - third-party benchmarks as virtbench commands (see virtbench/workload.py)
+"""
+import click
+import os
+import sys
+from rich.console import Console
+from rich.table import Table
+
+from virtbench.common import print_banner, generate_log_filename
+from virtbench.utils.orchestration import run_workload
+from virtbench.workload import exec_plugin_warnings, exec_plugins, load_plugins, match_exec_plugin, plugin_errors
+
+console = Console()
+
+
+def plugin_commands() -> dict:
+    """Plugin command names with (kind, source): Python workloads first, then exec plugins."""
+    plugins = {name: ('python', f"{cls.__module__}.{cls.__qualname__}") for name, cls in load_plugins().items()}
+    for name, path in exec_plugins().items():
+        plugins.setdefault(name, ('exec', path))
+    return plugins
+
+
+def plugin_environment(ctx, name: str) -> dict:
+    """
+    Global options for a plugin that are not already in the environment.
+
+    The CLI exports most global options itself (VIRTBENCH_UUID,
+    VIRTBENCH_DRY_RUN, VIRTBENCH_OUTPUT, KUBECONFIG, ...); these complete them.
+    """
+    env = {
+        'VIRTBENCH_PLUGIN': name,
+        'VIRTBENCH_REPO': str(ctx.obj.repo_root),
+        'VIRTBENCH_LOG_LEVEL': ctx.obj.log_level,
+        'VIRTBENCH_TIMEOUT': ctx.obj.timeout,
+    }
+    if ctx.obj.log_file:
+        env['VIRTBENCH_LOG_FILE'] = ctx.obj.log_file
+    return env
+
+
+def plugin_command(name: str, kind: str, source: str) -> click.Command:
+    """A command running a plugin workload, passing its arguments through unparsed."""
+    help_text = load_plugins()[name].help if kind == 'python' else f"Exec plugin {source}"
+
+    @click.command(name, help=help_text, short_help=help_text, add_help_option=False,
+                   context_settings={'ignore_unknown_options': True, 'allow_extra_args': True})
+    @click.argument('args', nargs=-1, type=click.UNPROCESSED)
+    @click.pass_context
+    def command(ctx, args):
+        print_banner(f"Plugin workload: {name}")
+        os.environ.update(plugin_environment(ctx, name))
+        if kind == 'python':
+            # The global log level and file are defaults; the plugin's own options override them
+            defaults = ['--log-level', ctx.obj.log_level.upper(),
+                        '--log-file', ctx.obj.log_file or generate_log_filename(name)]
+            cmd = [sys.executable, '-m', 'virtbench.workload', name] + defaults + list(args)
+        else:
+            cmd = [source] + list(args)
+        try:
+            result = run_workload(ctx, cmd, cwd=ctx.obj.repo_root)
+            sys.exit(result.returncode)
+        except KeyboardInterrupt:
+            console.print("\n[yellow]Interrupted by user[/yellow]")
+            sys.exit(130)
+        except OSError as e:
+            console.print(f"[red]Error:[/red] {e}")
+            sys.exit(1)
+
+    return command
+
+
+class PluginGroup(click.Group):
+    """Command group that falls back to plugin workloads for names it does not know."""
+
+    def list_commands(self, ctx):
+        return sorted(set(super().list_commands(ctx)) | set(plugin_commands()))
+
+    def get_command(self, ctx, cmd_name):
+        command = super().get_command(ctx, cmd_name)
+        if command is not None:
+            return command
+        plugin = plugin_commands().get(cmd_name)
+        return plugin_command(cmd_name, *plugin) if plugin else None
+
+    def resolve_command(self, ctx, args):
+        # Unknown names go to the longest matching exec plugin: "net perf" -> virtbench-net-perf
+        if args and super().get_command(ctx, args[0]) is None and args[0] not in load_plugins():
+            match = match_exec_plugin(args)
+            if match:
+                name, path, rest = match
+                return name, plugin_command(name, 'exec', path), rest
+        return super().resolve_command(ctx, args)
+
+
+@click.command('plugins')
+@click.pass_context
+def plugins(ctx):
+    """
+    List plugin workloads
+
+    Python workloads registered through the virtbench.workloads entry point
+    group or the VIRTBENCH_PLUGINS modules, and virtbench-<name> executables
+    on PATH. Plugins named like a built-in command are shadowed by it; files
+    that are not executable or come later on PATH than a plugin of the same
+    name are reported.
+
+    \b
+    Examples:
+      VIRTBENCH_PLUGINS=mybench virtbench plugins
+    """
+    builtin = set(ctx.parent.command.commands) if ctx.parent else set()
+    table = Table(title="Plugin workloads", show_header=True, header_style="bold cyan")
+    table.add_column("Command", style="cyan")
+    table.add_column("Kind")
+    table.add_column("Source")
+    for name, (kind, source) in sorted(plugin_commands().items()):
+        shadowed = " [yellow](shadowed by built-in)[/yellow]" if name in builtin else ""
+        table.add_row(name + shadowed, kind, source)
+    console.print(table)
+    for module, error in plugin_errors().items():
+        console.print(f"[yellow]Warning: could not load plugin {module}: {error}[/yellow]")
+    for warning in exec_plugin_warnings():
+        console.print(f"[yellow]Warning: {warning}[/yellow]")
@@ -0,0 +1,99 @@
+#!/usr/bin/env python3
+"""
+Image pre-pull and DataSource pre-warm command
+"""
+import click
+import sys
+from pathlib import Path
+from rich.console import Console
+
+from virtbench.common import print_banner, build_python_command
+from virtbench.commands.datasource_clone import DEFAULT_TEMPLATES
+from virtbench.utils.orchestration import run_workload
+
+console = Console()
+
+
+@click.command('prewarm')
+@click.option('--vm-template',
+              help='Path to VM template YAML (default: rhel9-vm-datasource.yaml, or '
+                   'windows-vm-datasource.yaml for Windows)')
+@click.option('--guest-os', type=click.Choice(['linux', 'windows']), default='linux',
+              help='Guest OS of the default VM template')
+@click.option('--image', multiple=True, help='Additional image to pre-pull (repeatable)')
+@click.option('--no-launcher', is_flag=True, help='Do not pre-pull the virt-launcher image')
+@click.option('--node', help='Only pre-pull onto this node (default: all schedulable nodes)')
+@click.option('--namespace', default='virtbench-prewarm', help='Namespace of the pre-pull DaemonSet')
+@click.option('--timeout', default=900, type=int, help='Seconds to wait for image pulls and DataSources')
+@click.option('--poll-interval', default=5, type=int, help='Seconds between status checks')
+@click.pass_context
+def prewarm(ctx, **kwargs):
+    """
+    Pre-pull images and wait for DataSources before a run
+
+    Pulls the virt-launcher image and the VM template's container disk
+    images onto every schedulable node with a short-lived DaemonSet, and
+    waits until the DataSources the template clones from are Ready. The
+    first VMs of the run then measure provisioning, not registry bandwidth
+    or golden image imports. Exits with code 1 when something is still
+    pending at the timeout.
+
+    \b
+    Examples:
+      # Warm the cluster for the default RHEL DataSource template
+      virtbench prewarm
+
+      # Custom template, one node, plus an extra image
+      virtbench prewarm --vm-template my-vm.yaml --node worker-1 --image quay.io/containerdisks/fedora:latest
+    """
+    print_banner("Image Pre-pull and DataSource Pre-warm")
+
+    # Get repo root from context
+    repo_root = ctx.obj.repo_root
+
+    template_path = Path(kwargs['vm_template'] or DEFAULT_TEMPLATES[kwargs['guest_os']])
+    if not template_path.is_absolute():
+        template_path = repo_root / template_path
+
+    if not template_path.exists():
+        console.print(f"[red]Error: Template file not found: {template_path}[/red]")
+        sys.exit(1)
+
+    script_path = repo_root / 'utils' / 'prewarm.py'
+
+    if not script_path.exists():
+        console.print(f"[red]Error: Script not found: {script_path}[/red]")
+        sys.exit(1)
+
+    # Map CLI args to Python script args
+    python_args = {
+        'log-level': ctx.obj.log_level.upper(),
+        'vm-template': str(template_path),
+        'node': kwargs['node'],
+        'namespace': kwargs['namespace'],
+        'timeout': kwargs['timeout'],
+        'poll-interval': kwargs['poll_interval'],
+        'image': list(kwargs['image']),
+    }
+    if kwargs['no_launcher']:
+        python_args['no-launcher'] = True
+
+    # Add global flags from context
+    if ctx.obj.kubeconfig:
+        python_args['kubeconfig'] = ctx.obj.kubeconfig
+
+    # Build and run command
+    cmd = build_python_command(script_path, python_args)
+
+    console.print(f"[dim]Running: {' '.join(cmd[:2])} ...[/dim]")
+    console.print()
+
+    try:
+        result = run_workload(ctx, cmd, cwd=repo_root)
+        sys.exit(result.returncode)
+    except KeyboardInterrupt:
+        console.print("\n[yellow]Interrupted by user[/yellow]")
+        sys.exit(130)
+    except Exception as e:
+        console.print(f"[red]Error: {e}[/red]")
+        sys.exit(1)
@@ -0,0 +1,167 @@
+#!/usr/bin/env python3
+"""
+Random Workload command - seeded, replayable VM operations and sizes emulating tenants
+"""
+import click
+import sys
+from pathlib import Path
+from rich.console import Console
+
+from virtbench.common import print_banner, build_python_command, generate_log_filename
+from virtbench.utils.yaml_modifier import modify_storage_class
+from virtbench.utils.orchestration import run_workload
+
+console = Console()
+
+DEFAULT_TEMPLATE = 'examples/vm-templates/rhel9-vm-datasource.yaml'
+
+
+@click.command('random-workload')
+@click.option('--seed', type=int, help='Random seed of the plan (default: random, saved with the results)')
+@click.option('--replay', type=click.Path(exists=True),
+              help='Run the saved plan of an earlier run (its results directory or workload_plan.json)')
+@click.option('--write-plan', type=click.Path(), help='Only generate the plan and write it to this file')
+@click.option('--operations', default=100, type=int, help='Number of operations in the plan')
+@click.option('--rate', default=6.0, type=float, help='Average operations per minute')
+@click.option('--max-vms', default=10, type=int, help='VMs that may exist at once, one namespace each')
+@click.option('--mix', help='Comma-separated operations with optional weights, from create, start, stop, '
+                            'restart, migrate, snapshot, resize, delete')
+@click.option('--sizes', help='Comma-separated VM sizes CORESxMEMORY with optional weights '
+                              '(default: 1x2Gi=4,2x4Gi=3,4x8Gi=2,8x16Gi=1)')
+@click.option('--max-resizes-per-vm', default=3, type=int, help='Resizes per VM before resize is no longer drawn for it')
+@click.option('--resize-increment', default='1Gi', help="Size each resize adds to the VM's first PVC")
+@click.option('--time-scale', default=1.0, type=float, help='Multiplier of the planned start times; 0 runs the steps back-to-back')
+@click.option('--namespace-prefix', default='virtbench-random', help='Namespace prefix')
+@click.option('--vm-name', default='rhel-9-vm', help='VM name in the template')
+@click.option('--vm-template', type=click.Path(), help=f'VM template YAML (default: {DEFAULT_TEMPLATE})')
+@click.option('--storage-class', help='Storage class name for the VM template')
+@click.option('--concurrency', '-c', default=5, type=int, help='Steps running at once')
+@click.option('--qps', default=0.0, type=float,
+              help='Max steps started per second (0 disables rate limiting)')
+@click.option('--burst', default=10, type=int, help='Max steps started back-to-back when --qps is set')
+@click.option('--poll-interval', default=2, type=float, help='Seconds between status checks')
+@click.option('--timeout', default=900, type=int, help='Timeout per operation (seconds)')
+@click.option('--precision', type=int, help='Decimal places for durations in saved results (default: 3)')
+@click.option('--skip-namespace-creation', is_flag=True, help='Use existing namespaces')
+@click.option('--cleanup', is_flag=True, help='Delete the test namespaces afterwards')
+@click.option('--save-results', is_flag=True, help='Save results to JSON/CSV files')
+@click.option('--results-folder', default='results', help='Base directory for results')
+@click.option('--storage-driver', help='Storage driver label for results path (for example: portworx-3.6, ceph), or auto to detect it')
+@click.option('--log-file', type=click.Path(), help='Log file path (auto-generated if not specified)')
+@click.option('--log-level', default='INFO',
+              type=click.Choice(['DEBUG', 'INFO', 'WARNING', 'ERROR']),
+              help='Logging level')
+@click.pass_context
+def random_workload(ctx, **kwargs):
+    """
+    Run seeded randomized workload
+
+    Generates a randomized but reproducible sequence of VM operations and VM
+    sizes from a seed, emulating tenants: VMs of random sizes are created,
+    started, stopped, restarted, migrated, snapshotted, resized and deleted
+    at random intervals. The seed and the plan are saved with the results,
+    so a problematic sequence can be replayed exactly with --replay.
+
+    \b
+    Examples:
+      # 200 operations over up to 20 VMs
+      virtbench random-workload --seed 42 --operations 200 --max-vms 20 \\
+          --storage-class YOUR-STORAGE-CLASS --save-results --cleanup
+    \b
+      # Replay the sequence of an earlier run
+      virtbench random-workload --replay results/random-workload/20250101-120000_virtbench-random_s42 \\
+          --storage-class YOUR-STORAGE-CLASS --save-results --cleanup
+    """
+    print_banner("Randomized Workload")
+
+    repo_root = ctx.obj.repo_root
+
+    template_path = Path(kwargs['vm_template'] or DEFAULT_TEMPLATE)
+    if not template_path.is_absolute():
+        template_path = repo_root / template_path
+
+    if not kwargs['write_plan']:
+        if not template_path.exists():
+            console.print(f"[red]Error: Template file not found: {template_path}[/red]")
+            sys.exit(1)
+
+        if kwargs['storage_class']:
+            console.print(f"[cyan]Using storage class: {kwargs['storage_class']}[/cyan]")
+            try:
+                modify_storage_class(template_path, kwargs['storage_class'])
+            except Exception as e:
+                console.print(f"[red]Error modifying storage class: {e}[/red]")
+                sys.exit(1)
+
+    script_path = repo_root / 'random-workload' / 'measure-random-workload.py'
+
+    if not script_path.exists():
+        console.print(f"[red]Error:[/red] Script not found: {script_path}")
+        sys.exit(1)
+
+    if kwargs['replay']:
+        console.print(f"[cyan]Replaying:[/cyan] {kwargs['replay']}")
+    else:
+        console.print(f"[cyan]Seed:[/cyan] {kwargs['seed'] if kwargs['seed'] is not None else 'random'}  "
+                      f"[cyan]Operations:[/cyan] {kwargs['operations']}")
+
+    # Map CLI args to the benchmark script's arguments
+    python_args = {
+        'seed': kwargs['seed'],
+        'replay': str(Path(kwargs['replay']).resolve()) if kwargs['replay'] else None,
+        'write-plan': str(Path(kwargs['write_plan']).resolve()) if kwargs['write_plan'] else None,
+        'operations': kwargs['operations'],
+        'rate': kwargs['rate'],
+        'max-vms': kwargs['max_vms'],
+        'max-resizes-per-vm': kwargs['max_resizes_per_vm'],
+        'resize-increment': kwargs['resize_increment'],
+        'time-scale': kwargs['time_scale'],
+        'namespace-prefix': kwargs['namespace_prefix'],
+        'vm-name': kwargs['vm_name'],
+        'vm-template': str(template_path),
+        'concurrency': kwargs['concurrency'],
+        'qps': kwargs['qps'],
+        'burst': kwargs['burst'],
+        'poll-interval': kwargs['poll_interval'],
+        'timeout': kwargs['timeout'],
+        'precision': kwargs['precision'],
+        'results-folder': kwargs['results_folder'],
+        'storage-driver': kwargs['storage_driver'],
+        'log-level': kwargs['log_level'],
+    }
+
+    # Log file: explicit > global context > auto-generated timestamped file
+    if kwargs.get('log_file'):
+        python_args['log-file'] = kwargs['log_file']
+    elif ctx.obj.log_file:
+        python_args['log-file'] = ctx.obj.log_file
+    else:
+        python_args['log-file'] = generate_log_filename('random-workload')
+
+    if kwargs.get('mix'):
+        python_args['mix'] = [m.strip() for m in kwargs['mix'].split(',') if m.strip()]
+    if kwargs.get('sizes'):
+        python_args['sizes'] = [s.strip() for s in kwargs['sizes'].split(',') if s.strip()]
+
+    # Flags
+    if kwargs['skip_namespace_creation']:
+        python_args['skip-namespace-creation'] = True
+    if kwargs['cleanup']:
+        python_args['cleanup'] = True
+    if kwargs['save_results']:
+        python_args['save-results'] = True
+
+    cmd = build_python_command(script_path, python_args)
+
+    console.print(f"[dim]Running: {' '.join(cmd[:2])} ...[/dim]")
+    console.print()
+
+    try:
+        result = run_workload(ctx, cmd, cwd=repo_root)
+        sys.exit(result.returncode)
+    except KeyboardInterrupt:
+        console.print("\n[yellow]Interrupted by user[/yellow]")
+        sys.exit(130)
+    except Exception as e:
+        console.print(f"[red]Error:[/red] {e}")
+        sys.exit(1)
@@ -0,0 +1,416 @@
+#!/usr/bin/env python3
+"""
+Results command group - Find, inspect, query, index and prune past runs
+
+    virtbench results list      Past runs: UUID, workload, date, cluster, status, key metrics
+    virtbench results show      Summary of one run, by UUID or run directory
+    virtbench results sync      Import runs into <results>/results.db (SQLite)
+    virtbench results query     SQL query over results.db
+    virtbench results index     Write <results>/index.json, the catalog of runs
+    virtbench results prune     Delete old runs (--keep-last, --older-than, --workload)
+"""
+import json
+import sqlite3
+import sys
+from datetime import datetime
+from pathlib import Path
+from typing import Dict
+
+import click
+import yaml
+from rich.console import Console
+from rich.table import Table
+
+from virtbench.utils.results_catalog import (
+    INDEX_FILE, delete_run, find_run, parse_age, scan_runs, select_prunable, write_index,
+)
+from virtbench.utils.results_db import default_db_path, delete_runs, query as query_db, sync_database
+from virtbench.utils.efficiency import efficiency_metrics, parse_node_costs
+
+console = Console()
+
+
+def _format_size(size: int) -> str:
+    for unit in ('B', 'KiB', 'MiB', 'GiB'):
+        if size < 1024 or unit == 'GiB':
+            return f"{size:.0f} {unit}" if unit == 'B' else f"{size:.1f} {unit}"
+        size /= 1024
+
+
+def _emit(ctx, kind: str, data):
+    """Write a {"kind", "data"} document to stdout with --output json/yaml (as the workloads do)."""
+    document = {'kind': kind, 'data': data}
+    if ctx.obj.output == 'json':
+        sys.__stdout__.write(json.dumps(document, indent=2, default=str) + '\n')
+    else:
+        sys.__stdout__.write(yaml.safe_dump(json.loads(json.dumps(document, default=str)), sort_keys=False,
+                                            explicit_start=True))
+    sys.__stdout__.flush()
+
+
+def _format_metric(value) -> str:
+    return f"{value:.2f}" if isinstance(value, float) else str(value)
+
+
+def _results_dir(path: str) -> Path:
+    results_dir = Path(path).expanduser()
+    if not results_dir.is_dir():
+        console.print(f"[red]Error:[/red] Results directory not found: {results_dir}")
+        sys.exit(1)
+    return results_dir
+
+
+@click.group('results')
+def results():
+    """
+    Find, inspect, query, index and prune past runs
+
+    \b
+    Examples:
+      # The last 20 runs
+      virtbench results list
+    \b
+      # Failed vm-clone runs of the last week
+      virtbench results list --workload vm-clone --status failed --since 7d
+    \b
+      # Summary of one run (UUID, UUID prefix or run directory)
+      virtbench results show 3f2c8e0a
+    \b
+      # Average clone time per day, from results/results.db
+      virtbench results query "SELECT substr(r.timestamp, 1, 10) AS day, avg(m.avg)
+          FROM metrics m JOIN runs r ON r.id = m.run_id
+          WHERE m.metric = 'clone_sec' GROUP BY day"
+    \b
+      # Refresh results/index.json
+      virtbench results index
+    \b
+      # Keep the last 10 runs of each workload
+      virtbench results prune --keep-last 10
+    \b
+      # Show which vm-clone runs older than 30 days would be deleted
+      virtbench --dry-run results prune --workload vm-clone --older-than 30d
+    """
+
+
+@results.command('list')
+@click.option('--results-dir', default='results', help='Base directory containing test results')
+@click.option('--workload', 'workloads', multiple=True, help='Only runs of this workload (repeatable)')
+@click.option('--cluster', help='Only runs on this cluster')
+@click.option('--status', type=click.Choice(['passed', 'partial', 'failed', 'unknown']), help='Only runs with this status')
+@click.option('--since', help='Only runs newer than this age, e.g. 12h, 7d, 2w')
+@click.option('--limit', default=20, type=click.IntRange(min=0), help='Show at most N runs, newest first (0: all)')
+@click.pass_context
+def list_runs(ctx, results_dir, workloads, cluster, status, since, limit):
+    """
+    List past runs
+
+    Shows each run's UUID, workload, date, cluster, status (from the
+    failed/successful counts of its summaries) and the averages of its first
+    summary metrics. UUID and cluster are recorded by runs saved with this
+    version; older runs show "-".
+    """
+    try:
+        cutoff = datetime.now() - parse_age(since) if since else None
+    except ValueError as e:
+        raise click.BadParameter(str(e), param_hint='--since')
+
+    runs = scan_runs(_results_dir(results_dir))
+    if workloads:
+        runs = [run for run in runs if set(workloads) & set(run['workloads'])]
+    if cluster:
+        runs = [run for run in runs if run['cluster'] == cluster]
+    if status:
+        runs = [run for run in runs if run['status'] == status]
+    if cutoff:
+        runs = [run for run in runs if datetime.fromisoformat(run['timestamp']) >= cutoff]
+    shown = runs[:limit] if limit else runs
+
+    if ctx.obj.output != 'table':
+        _emit(ctx, 'results-list', shown)
+        return
+
+    table = Table(title=f"Runs in {results_dir}")
+    table.add_column('UUID', no_wrap=True)
+    table.add_column('Workload')
+    table.add_column('Date', no_wrap=True)
+    table.add_column('Cluster')
+    table.add_column('Status')
+    table.add_column('Key metrics (avg)')
+    table.add_column('Run')
+    styles = {'passed': 'green', 'partial': 'yellow', 'failed': 'red', 'unknown': 'dim'}
+    for run in shown:
+        metrics = ', '.join(f"{name}={_format_metric(value)}" for name, value in list(run['metrics'].items())[:2])
+        table.add_row((run['uuid'] or '-')[:8], ', '.join(run['workloads']), run['timestamp'].replace('T', ' '),
+                      run['cluster'] or '-', f"[{styles[run['status']]}]{run['status']}[/{styles[run['status']]}]",
+                      metrics or '-', run['id'])
+    console.print(table)
+    if len(shown) < len(runs):
+        console.print(f"[dim]{len(shown)} of {len(runs)} runs; use --limit 0 to show all[/dim]")
+
+
+@results.command('show')
+@click.argument('run_key')
+@click.option('--results-dir', default='results', help='Base directory containing test results')
+@click.option('--node-cost', help='Hourly cost of the worker nodes: one price for all, or '
+                                  'NODE_OR_INSTANCE_TYPE=COST,...,default=COST')
+@click.option('--currency', default='', help='Currency label of --node-cost, e.g. USD')
+@click.pass_context
+def show(ctx, run_key, results_dir, node_cost, currency):
+    """
+    Show the summary of one run
+
+    RUN_KEY is a run UUID, a unique UUID prefix, or the run directory
+    (relative to the results directory, or just its name).
+
+    Efficiency metrics (VMs per physical core and per GiB of worker memory,
+    storage overhead) are derived from the run's cluster inventory; with
+    --node-cost also the cost per VM-hour and of the run.
+    """
+    try:
+        node_costs = parse_node_costs(node_cost) if node_cost else None
+    except ValueError as e:
+        raise click.BadParameter(str(e), param_hint='--node-cost')
+    base_dir = _results_dir(results_dir)
+    matches = find_run(scan_runs(base_dir), run_key)
+    if not matches:
+        console.print(f"[red]Error:[/red] No run matches '{run_key}' in {base_dir}")
+        sys.exit(1)
+    if len(matches) > 1:
+        console.print(f"[red]Error:[/red] '{run_key}' matches {len(matches)} runs:")
+        for run in matches:
+            console.print(f"  {run['uuid'] or '-'}  {run['id']}")
+        sys.exit(1)
+
+    run = matches[0]
+    run_dir = base_dir / run['id']
+    summaries = {}
+    for path in sorted(run_dir.glob('summary_*.json')):
+        try:
+            summaries[path.name] = json.loads(path.read_text())
+        except (OSError, ValueError):
+            continue
+
+    efficiency = {name: metrics for name, metrics in
+                  ((name, efficiency_metrics(s, node_costs)) for name, s in summaries.items() if isinstance(s, dict))
+                  if metrics is not None}
+
+    if ctx.obj.output != 'table':
+        _emit(ctx, 'results-run', {**run, 'summaries': summaries, 'efficiency': efficiency})
+        return
+
+    info = next((s['run'] for s in summaries.values() if isinstance(s, dict) and isinstance(s.get('run'), dict)), {})
+    console.print(f"[bold]Run:[/bold]        {run['id']}")
+    console.print(f"[bold]UUID:[/bold]       {run['uuid'] or '-'}")
+    console.print(f"[bold]Workloads:[/bold]  {', '.join(run['workloads'])}")
+    console.print(f"[bold]Date:[/bold]       {run['timestamp'].replace('T', ' ')}")
+    console.print(f"[bold]Cluster:[/bold]    {run['cluster'] or '-'}")
+    console.print(f"[bold]Status:[/bold]     {run['status']}")
+    if info.get('command'):
+        console.print(f"[bold]Command:[/bold]    {info['command']}")
+    console.print(f"[bold]Directory:[/bold]  {run_dir}")
+
+    for name, summary in summaries.items():
+        if not isinstance(summary, dict):
+            continue
+        console.print()
+        counts = ', '.join(f"{key} {summary[key]}" for key in ('total_vms', 'successful', 'failed')
+                           if summary.get(key) is not None)
+        console.print(f"[cyan]{name}[/cyan]" + (f"  ({counts})" if counts else ''))
+        table = Table(show_edge=False)
+        for column in ('Metric', 'Avg', 'Min', 'Max', 'P95', 'P99', 'Stddev', 'Count'):
+            table.add_column(column, justify='left' if column == 'Metric' else 'right')
+        for metric in summary.get('metrics') or []:
+            if isinstance(metric, dict) and metric.get('metric'):
+                table.add_row(metric['metric'], *[_format_metric(metric[k]) if metric.get(k) is not None else '-'
+                                                  for k in ('avg', 'min', 'max', 'p95', 'p99', 'stddev', 'count')])
+        for metric in summary.get('custom_metrics') or []:
+            table.add_row(f"{metric.get('name')} (custom)",
+                          *[_format_metric(metric.get(k)) if metric.get(k) is not None else '-'
+                            for k in ('avg', 'min', 'max', 'p95', 'p99', 'stddev', 'samples')])
+        if table.row_count:
+            console.print(table)
+        for outlier in summary.get('outliers') or []:
+            events = ', '.join(f"{reason} x{count}" for reason, count in (outlier.get('events') or {}).items())
+            console.print(f"[yellow]Outlier:[/yellow] {outlier.get('metric')} {outlier.get('item')} "
+                          f"{_format_metric(outlier.get('value'))}s (z={outlier.get('z_score')})"
+                          + (f"  [dim]events: {events}[/dim]" if events else ''))
+        for anomaly in (summary.get('events') or {}).get('anomalies') or []:
+            console.print(f"[yellow]Events:[/yellow] {anomaly.get('reason')} x{anomaly.get('count')} "
+                          f"on {anomaly.get('affected_objects')} object(s)")
+        cluster = summary.get('cluster') or {}
+        if cluster:
+            platform = cluster.get('platform') or {}
+            virt = cluster.get('virtualization') or {}
+            nodes = cluster.get('nodes') or {}
+            console.print(f"[dim]Cluster: OpenShift {platform.get('openshift_version') or 'n/a'}, "
+                          f"KubeVirt {virt.get('kubevirt_version') or 'n/a'}, "
+                          f"{nodes.get('count', 'n/a')} nodes[/dim]")
+        if name in efficiency:
+            _print_efficiency(efficiency[name], currency)
+
+
+def _print_efficiency(metrics: Dict, currency: str):
+    cores = f"{metrics['physical_cores']:g}" + (' (estimated)' if metrics['physical_cores_estimated'] else '')
+    line = (f"[bold]Efficiency:[/bold] {metrics['vms']} VMs on {metrics['worker_nodes']} worker node(s), "
+            f"{cores} cores, {metrics['memory_gib']:g} GiB; "
+            f"{_format_metric(metrics['vms_per_core'])} VMs/core, {metrics['vms_per_gib']} VMs/GiB")
+    if metrics.get('provisioned_gib_per_vm') is not None:
+        line += f", {metrics['provisioned_gib_per_vm']:g} GiB provisioned/VM"
+    if metrics.get('storage_overhead_ratio') is not None:
+        line += f", storage overhead {metrics['storage_overhead_ratio']:g}x"
+    console.print(line)
+    unit = f" {currency}" if currency else ''
+    if metrics.get('unpriced_nodes'):
+        console.print(f"[yellow]Cost:[/yellow] no --node-cost for {', '.join(metrics['unpriced_nodes'])}")
+    elif metrics.get('cost_per_vm_hour') is not None:
+        line = (f"[bold]Cost:[/bold] {metrics['cluster_cost_per_hour']:g}{unit}/h, "
+                f"{metrics['cost_per_vm_hour']:g}{unit} per VM-hour")
+        if metrics.get('run_cost') is not None:
+            line += f"; run {metrics['run_cost']:g}{unit}, {metrics['run_cost_per_vm']:g}{unit} per VM"
+        console.print(line)
+
+
+@results.command('sync')
+@click.option('--results-dir', default='results', help='Base directory containing test results')
+@click.option('--db', 'db_path', type=click.Path(dir_okay=False), help='Database file (default: <results-dir>/results.db)')
+def sync(results_dir, db_path):
+    """
+    Import runs into the SQLite results database
+
+    New and changed runs are imported; runs deleted from the folder (e.g. by
+    hand) are deleted from the database. The global --results-db flag does this
+    after every workload.
+    """
+    base_dir = _results_dir(results_dir)
+    db_path = Path(db_path) if db_path else default_db_path(base_dir)
+    imported, total = sync_database(base_dir, db_path)
+    console.print(f"[green]Imported {imported} of {total} run(s):[/green] {db_path}")
+
+
+@results.command('query')
+@click.argument('sql')
+@click.option('--results-dir', default='results', help='Base directory containing test results')
+@click.option('--db', 'db_path', type=click.Path(dir_okay=False), help='Database file (default: <results-dir>/results.db)')
+@click.option('--no-sync', is_flag=True, help='Query the database as is, without importing new runs first')
+@click.pass_context
+def query(ctx, sql, results_dir, db_path, no_sync):
+    """
+    Run a SQL query over the results database
+
+    \b
+    Tables (see docs: Results Database):
+      runs        id, uuid, workloads, timestamp, cluster, status, labels
+      summaries   run_id, file, workload, total_vms, successful, failed, duration_sec, data
+      metrics     run_id, workload, metric, avg, min, max, median, p95, p99, stddev, count
+      vm_results  run_id, file, namespace, vm_name, status, data
+
+    data columns hold the JSON documents, for json_extract(). New runs are
+    imported first unless --no-sync is given. The database is opened
+    read-only, so queries cannot change it.
+    """
+    base_dir = Path(results_dir).expanduser()
+    db_path = Path(db_path) if db_path else default_db_path(base_dir)
+    if not no_sync:
+        sync_database(_results_dir(results_dir), db_path)
+    if not db_path.is_file():
+        console.print(f"[red]Error:[/red] Results database not found: {db_path} (run 'virtbench results sync')")
+        sys.exit(1)
+    try:
+        columns, rows = query_db(db_path, sql)
+    except sqlite3.Error as e:
+        console.print(f"[red]Error:[/red] {e}")
+        sys.exit(1)
+
+    if ctx.obj.output != 'table':
+        _emit(ctx, 'results-query', [dict(zip(columns, row)) for row in rows])
+        return
+
+    table = Table(show_edge=False)
+    for column in columns:
+        table.add_column(column)
+    for row in rows:
+        table.add_row(*['NULL' if value is None else _format_metric(value) for value in row])
+    console.print(table)
+    console.print(f"[dim]{len(rows)} row(s)[/dim]")
+
+
+@results.command('index')
+@click.option('--results-dir', default='results', help='Base directory containing test results')
+def index(results_dir):
+    """Write the catalog of runs to <results-dir>/index.json"""
+    base_dir = _results_dir(results_dir)
+    runs = scan_runs(base_dir)
+    path = write_index(base_dir, runs)
+    console.print(f"[green]Indexed {len(runs)} run(s):[/green] {path}")
+
+
+@results.command('prune')
+@click.option('--results-dir', default='results', help='Base directory containing test results')
+@click.option('--keep-last', type=click.IntRange(min=0), help='Keep the newest N runs of each workload')
+@click.option('--older-than', help='Only delete runs older than this age, e.g. 12h, 30d, 2w')
+@click.option('--workload', 'workloads', multiple=True,
+              help='Only prune runs of this workload (repeatable), e.g. vm-clone, migration')
+@click.option('--yes', '-y', is_flag=True, help='Delete without asking for confirmation')
+@click.pass_context
+def prune(ctx, results_dir, keep_last, older_than, workloads, yes):
+    """
+    Delete old runs from the results folder
+
+    A run is deleted if it is outside the newest --keep-last runs of its
+    workload and older than --older-than; at least one of the two is
+    required. With the global --dry-run, the runs are only listed. The
+    index (index.json) is refreshed afterwards, and the deleted runs are
+    removed from the results database (results.db) if there is one.
+    """
+    if keep_last is None and older_than is None:
+        raise click.UsageError("give --keep-last and/or --older-than")
+    try:
+        age = parse_age(older_than) if older_than else None
+    except ValueError as e:
+        raise click.BadParameter(str(e), param_hint='--older-than')
+
+    base_dir = _results_dir(results_dir)
+    runs = scan_runs(base_dir)
+    prunable = select_prunable(runs, keep_last, age, workloads)
+    if not prunable:
+        console.print(f"[green]Nothing to prune[/green] ({len(runs)} run(s) in {base_dir})")
+        write_index(base_dir, runs)
+        return
+
+    table = Table(title=f"Runs to delete from {base_dir}")
+    table.add_column('Run')
+    table.add_column('Workloads')
+    table.add_column('Timestamp')
+    table.add_column('Size', justify='right')
+    for run in prunable:
+        table.add_row(run['id'], ', '.join(run['workloads']), run['timestamp'], _format_size(run['size_bytes']))
+    console.print(table)
+    total = sum(run['size_bytes'] for run in prunable)
+    console.print(f"{len(prunable)} of {len(runs)} run(s), {_format_size(total)}")
+
+    if ctx.obj.dry_run:
+        console.print("[yellow]Dry run: nothing deleted[/yellow]")
+        return
+    if not yes and not click.confirm('Delete these runs?', default=False):
+        console.print("[yellow]Aborted[/yellow]")
+        return
+
+    deleted = set()
+    freed = 0
+    for run in prunable:
+        try:
+            # A run already gone (e.g. deleted with the run it was nested in) only leaves the index
+            if delete_run(base_dir, run):
+                freed += run['size_bytes']
+        except (OSError, ValueError) as e:
+            console.print(f"[yellow]Warning: could not delete run {run['id']}: {e}[/yellow]")
+            continue
+        deleted.add(run['id'])
+    write_index(base_dir, [run for run in runs if run['id'] not in deleted])
+    db_path = default_db_path(base_dir)
+    if deleted and db_path.exists():
+        delete_runs(db_path, deleted)
+    console.print(f"[green]Deleted {len(deleted)} run(s), freed {_format_size(freed)}; "
+                  f"{INDEX_FILE} updated[/green]")
+    if len(deleted) < len(prunable):
+        sys.exit(1)
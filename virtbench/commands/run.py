@@ -0,0 +1,157 @@
+#!/usr/bin/env python3
+"""
+Run command - Run a workload once or on a recurring schedule
+"""
+import click
+import json
+import os
+import sys
+from datetime import datetime
+from pathlib import Path
+from typing import Dict, List
+
+import yaml
+from rich.console import Console
+
+from virtbench.common import print_banner
+from virtbench.utils.assertions import ASSERTIONS_ENV, parse_assertion
+from virtbench.utils.launch import virtbench_command
+from virtbench.utils.schedule import CronSchedule, ScheduleHistory, run_once, run_schedule
+
+console = Console()
+
+PLAN_KEYS = {'name', 'schedule', 'workload', 'args', 'options', 'retention', 'assert'}
+
+
+def load_plan(path: str) -> Dict:
+    """Load and check a plan file."""
+    with open(path) as f:
+        plan = yaml.safe_load(f) or {}
+    if not isinstance(plan, dict):
+        raise click.BadParameter('plan must be a YAML mapping', param_hint='--plan')
+    unknown = sorted(set(plan) - PLAN_KEYS)
+    if unknown:
+        raise click.BadParameter(f"unknown plan key(s): {', '.join(unknown)}", param_hint='--plan')
+    if not plan.get('workload'):
+        raise click.BadParameter('plan has no workload', param_hint='--plan')
+    if plan.get('assert') is not None:
+        if not isinstance(plan['assert'], list):
+            raise click.BadParameter('assert must be a list of assertions', param_hint='--plan')
+        try:
+            for text in plan['assert']:
+                parse_assertion(text)
+        except ValueError as e:
+            raise click.BadParameter(str(e), param_hint='--plan')
+    return plan
+
+
+def global_args(ctx) -> List[str]:
+    """Global options of this invocation to repeat on every run (all but --uuid)."""
+    args = ['--log-level', ctx.obj.log_level, '--timeout', ctx.obj.timeout]
+    if ctx.obj.log_file:
+        args += ['--log-file', ctx.obj.log_file]
+    contexts = [c['context'] for c in ctx.obj.clusters if c['context']]
+    if contexts:
+        args += ['--contexts', ','.join(contexts)]
+        if ctx.obj.clusters[0]['kubeconfig']:
+            args += ['--kubeconfig', ctx.obj.clusters[0]['kubeconfig']]
+    else:
+        for cluster in ctx.obj.clusters:
+            args += ['--kubeconfig', cluster['kubeconfig']]
+    if ctx.obj.parallel_clusters:
+        args.append('--parallel-clusters')
+    if ctx.obj.output != 'table':
+        args += ['--output', ctx.obj.output]
+    # The remaining global options reach the runs through the VIRTBENCH_* environment
+    return args
+
+
+@click.command('run', context_settings={'ignore_unknown_options': True, 'allow_interspersed_args': False})
+@click.option('--schedule', help='Cron expression to repeat the workload on, e.g. "0 2 * * *" or @daily')
+@click.option('--plan', type=click.Path(exists=True, dir_okay=False),
+              help='Plan file (YAML) with the workload, its options and optionally a schedule')
+@click.option('--name', help='Schedule name for the run history (default: the plan name or workload)')
+@click.option('--keep-runs', type=click.IntRange(min=1), help='Keep the results of the newest N scheduled runs')
+@click.option('--keep-days', type=click.FloatRange(min=0), help='Keep the results of scheduled runs newer than N days')
+@click.option('--results-dir', default='results', help='Base results directory of the workload (default: results)')
+@click.argument('workload_args', nargs=-1, type=click.UNPROCESSED)
+@click.pass_context
+def run(ctx, schedule, plan, name, keep_runs, keep_days, results_dir, workload_args):
+    """
+    Run a workload once or on a recurring schedule
+
+    Takes the workload and its options after the run options, or from a
+    plan file. With a schedule, the workload runs at every cron time with a
+    new run UUID until stopped; runs never overlap. Each run is recorded in
+    <results>/schedules/<name>/history.json, and --keep-runs/--keep-days
+    delete the results of older runs.
+
+    \b
+    Plan file:
+      name: nightly-clone
+      schedule: "0 2 * * *"
+      workload: datasource-clone
+      options: {log-level: info}
+      args: {start: 1, end: 50, storage-class: YOUR-STORAGE-CLASS, save-results: true, cleanup: true}
+      retention: {keep-runs: 14, keep-days: 30}
+      assert: ["p95_running_time < 180s", "success_rate >= 99%"]
+
+    \b
+    Examples:
+      # Every night at 02:00, keeping two weeks of results
+      virtbench run --schedule "0 2 * * *" --keep-runs 14 \\
+          datasource-clone --start 1 --end 50 --storage-class YOUR-STORAGE-CLASS --save-results --cleanup
+    \b
+      # Scheduled from a plan file
+      virtbench run --plan nightly-clone.yaml
+    """
+    print_banner("Scheduled Run" if schedule else "Run")
+
+    if plan and workload_args:
+        raise click.UsageError("give the workload in the plan file or on the command line, not both")
+    if plan:
+        plan_data = load_plan(plan)
+        retention = plan_data.get('retention') or {}
+        schedule = schedule or plan_data.get('schedule')
+        name = name or plan_data.get('name') or plan_data['workload'].replace(' ', '-')
+        keep_runs = keep_runs if keep_runs is not None else retention.get('keep-runs')
+        keep_days = keep_days if keep_days is not None else retention.get('keep-days')
+        if plan_data.get('assert'):
+            # Checked by the run itself (virtbench/utils/assertions.py)
+            os.environ[ASSERTIONS_ENV] = json.dumps([str(text) for text in plan_data['assert']])
+
+        def build_command(run_uuid: str) -> List[str]:
+            try:
+                cmd = virtbench_command(plan_data['workload'], plan_data.get('args'),
+                                        plan_data.get('options'), run_uuid)
+            except ValueError as e:
+                raise click.BadParameter(str(e), param_hint='--plan')
+            return cmd[:3] + global_args(ctx) + cmd[3:]
+    elif workload_args:
+        name = name or workload_args[0]
+
+        def build_command(run_uuid: str) -> List[str]:
+            return ['virtbench', '--uuid', run_uuid] + global_args(ctx) + list(workload_args)
+    else:
+        raise click.UsageError("no workload given: add it after the run options or use --plan")
+
+    repo_root = ctx.obj.repo_root
+    results_path = (repo_root / results_dir) if not Path(results_dir).is_absolute() else Path(results_dir)
+    # Check the command (and plan) before waiting for the first run
+    build_command(ctx.obj.uuid)
+
+    if not schedule:
+        history = ScheduleHistory(results_path, name) if (keep_runs or keep_days is not None) else None
+        returncode = run_once(build_command(ctx.obj.uuid), repo_root, results_path, history, ctx.obj.uuid)
+        if history is not None:
+            history.apply_retention(keep_runs, keep_days)
+        sys.exit(returncode)
+
+    try:
+        cron = CronSchedule(str(schedule))
+        cron.next_after(datetime.now())
+    except ValueError as e:
+        raise click.BadParameter(str(e), param_hint='--schedule')
+
+    history = ScheduleHistory(results_path, name)
+    run_schedule(cron, build_command, repo_root, results_path, history, keep_runs, keep_days)
@@ -0,0 +1,74 @@
+#!/usr/bin/env python3
+"""
+Serve command - REST API for remote benchmark orchestration
+"""
+import click
+import os
+import subprocess
+import sys
+from rich.console import Console
+
+from virtbench.common import print_banner, build_python_command
+
+console = Console()
+
+
+@click.command('serve')
+@click.option('--host', default='127.0.0.1', help='Address to listen on (0.0.0.0 for all interfaces)')
+@click.option('--port', '-p', default=8090, type=int, help='Port to listen on')
+@click.option('--token', envvar='VIRTBENCH_SERVE_TOKEN',
+              help='Bearer token required on API requests (or VIRTBENCH_SERVE_TOKEN)')
+@click.option('--max-concurrent', default=1, type=click.IntRange(min=1), help='Runs executed at the same time')
+@click.option('--results-dir', help='Results directory of the runs (default: <repo>/results)')
+@click.pass_context
+def serve(ctx, **kwargs):
+    """
+    Serve a REST API to run benchmarks remotely
+
+    Lets a central service start, stop and list virtbench runs on this
+    host and stream their logs, progress and results over HTTP, instead of
+    logging in to the jump host. Runs are queued and executed with the
+    virtbench CLI, at most --max-concurrent at a time.
+
+    \b
+    Examples:
+      # Serve on all interfaces, requiring a token
+      export VIRTBENCH_SERVE_TOKEN=$(openssl rand -hex 16)
+      virtbench serve --host 0.0.0.0
+    \b
+      # Start a run and follow its log
+      curl -H "Authorization: Bearer $VIRTBENCH_SERVE_TOKEN" -X POST http://jumphost:8090/api/runs \\
+          -d '{"workload": "vm-clone", "args": {"start": 1, "end": 20, "save-results": true}}'
+      curl -H "Authorization: Bearer $VIRTBENCH_SERVE_TOKEN" "http://jumphost:8090/api/runs/<id>/log?follow=true"
+    """
+    print_banner("API Server")
+
+    repo_root = ctx.obj.repo_root
+    script_path = repo_root / 'api-server' / 'virtbench-server.py'
+
+    if not script_path.exists():
+        console.print(f"[red]Error:[/red] Script not found: {script_path}")
+        sys.exit(1)
+
+    python_args = {
+        'host': kwargs['host'],
+        'port': kwargs['port'],
+        'max-concurrent': kwargs['max_concurrent'],
+        'results-dir': kwargs['results_dir'],
+        'log-level': 'WARNING' if ctx.obj.log_level == 'warn' else ctx.obj.log_level.upper(),
+        'log-file': ctx.obj.log_file,
+    }
+
+    cmd = build_python_command(script_path, python_args)
+
+    try:
+        # The token is passed in the environment rather than on the command line
+        env = dict(os.environ, VIRTBENCH_SERVE_TOKEN=kwargs['token']) if kwargs['token'] else None
+        result = subprocess.run(cmd, cwd=repo_root, env=env)
+        sys.exit(result.returncode)
+    except KeyboardInterrupt:
+        console.print("\n[yellow]Stopped[/yellow]")
+        sys.exit(0)
+    except Exception as e:
+        console.print(f"[red]Error:[/red] {e}")
+        sys.exit(1)
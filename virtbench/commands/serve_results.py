@@ -0,0 +1,77 @@
+#!/usr/bin/env python3
+"""
+Serve Results command - Browse the results catalog in a web app
+"""
+import click
+import subprocess
+import sys
+from pathlib import Path
+from rich.console import Console
+
+from virtbench.common import print_banner, build_python_command
+
+console = Console()
+
+
+@click.command('serve-results')
+@click.option('--results-dir', default='results', help='Base directory containing test results')
+@click.option('--host', default='127.0.0.1', help='Address to listen on (0.0.0.0 for all interfaces)')
+@click.option('--port', '-p', default=8080, type=int, help='Port to listen on')
+@click.pass_context
+def serve_results(ctx, **kwargs):
+    """
+    Serve benchmark results as a web app
+
+    Lists every run under --results-dir, drills down into one run (summary
+    metrics and per-VM charts) and compares summary metrics across runs.
+    New runs are picked up without a restart.
+
+    \b
+    In-cluster:
+      The server only needs the Python standard library. Deploy it with
+      dashboard/serve-results.yaml and copy results onto its volume to give
+      the team a shared place to browse benchmark history.
+
+    \b
+    Examples:
+      # Browse local results at http://127.0.0.1:8080/
+      virtbench serve-results
+    \b
+      # Serve a shared results directory on all interfaces
+      virtbench serve-results --results-dir /data/results --host 0.0.0.0 --port 9000
+    """
+    print_banner("Results Viewer")
+
+    repo_root = ctx.obj.repo_root
+    script_path = repo_root / 'dashboard' / 'serve_results.py'
+
+    if not script_path.exists():
+        console.print(f"[red]Error:[/red] Script not found: {script_path}")
+        sys.exit(1)
+
+    # Resolve results directory relative to the current directory, not the repo root
+    results_dir = Path(kwargs['results_dir']).expanduser().resolve()
+    if not results_dir.is_dir():
+        console.print(f"[red]Error:[/red] Results directory not found: {results_dir}")
+        sys.exit(1)
+
+    python_args = {
+        'results-dir': str(results_dir),
+        'host': kwargs['host'],
+        'port': kwargs['port'],
+    }
+
+    cmd = build_python_command(script_path, python_args)
+
+    console.print(f"[cyan]Open:[/cyan] http://{kwargs['host']}:{kwargs['port']}/  (Ctrl+C to stop)")
+    console.print()
+
+    try:
+        result = subprocess.run(cmd, cwd=repo_root)
+        sys.exit(result.returncode)
+    except KeyboardInterrupt:
+        console.print("\n[yellow]Stopped[/yellow]")
+        sys.exit(0)
+    except Exception as e:
+        console.print(f"[red]Error:[/red] {e}")
+        sys.exit(1)
@@ -0,0 +1,121 @@
+#!/usr/bin/env python3
+"""
+Service Exposure Benchmark command - Service endpoints, DNS and connectivity of VMs
+"""
+import click
+import sys
+from rich.console import Console
+
+from virtbench.common import print_banner, build_python_command, generate_log_filename
+from virtbench.utils.orchestration import run_workload
+
+console = Console()
+
+
+@click.command('service-exposure')
+@click.option('--start', '-s', default=1, type=int, help='Start namespace index')
+@click.option('--end', '-e', default=10, type=int, help='End namespace index')
+@click.option('--namespace-prefix', default='kubevirt-perf-test', help='Namespace prefix')
+@click.option('--vm-name', default='rhel-9-vm', help='Name of the running VM in each namespace')
+@click.option('--service-type', default='both', type=click.Choice(['clusterip', 'headless', 'both']),
+              help='Services to create per VM')
+@click.option('--port', default=22, type=int, help='Guest TCP port exposed and connected to')
+@click.option('--migrate', is_flag=True, help='Live migrate every VM and measure the Services again')
+@click.option('--concurrency', '-c', default=10, type=int, help='VMs processed concurrently')
+@click.option('--qps', type=float, help='Max VMs started per second (default: unlimited)')
+@click.option('--burst', type=int, help='Max VMs started back-to-back when --qps is set')
+@click.option('--poll-interval', default=1, type=int, help='Seconds between EndpointSlice checks')
+@click.option('--timeout', default=300, type=int, help='Timeout for endpoints, DNS and connectivity (seconds)')
+@click.option('--migration-timeout', default=600, type=int, help='Timeout for each migration (seconds)')
+@click.option('--checker-ns', default='default', help='Namespace of the reachability checker pod')
+@click.option('--cluster-domain', default='cluster.local', help='Cluster DNS domain')
+@click.option('--keep-services', is_flag=True, help='Do not delete the Services at the end of the run')
+@click.option('--save-results', is_flag=True, help='Save results to JSON/CSV files')
+@click.option('--results-folder', default='results', help='Base directory for results')
+@click.option('--log-file', type=click.Path(), help='Log file path (auto-generated if not specified)')
+@click.option('--log-level', default='INFO',
+              type=click.Choice(['DEBUG', 'INFO', 'WARNING', 'ERROR']),
+              help='Logging level')
+@click.pass_context
+def service_exposure(ctx, **kwargs):
+    """
+    Run Service exposure benchmark
+
+    Exposes running VMs with ClusterIP and/or headless Services and
+    measures the time until the EndpointSlice is ready, the Service name
+    resolves in cluster DNS and a TCP connection through it succeeds. With
+    --migrate, each VM is then live migrated and the time for endpoints,
+    DNS and connectivity to follow the new virt-launcher pod is measured.
+    Checks run from the managed reachability checker pod.
+
+    \b
+    Examples:
+      # ClusterIP and headless Services for 20 VMs
+      virtbench service-exposure --start 1 --end 20 --save-results
+    \b
+      # Headless Services, measured again after live migration
+      virtbench service-exposure --start 1 --end 50 --service-type headless --migrate
+    """
+    print_banner("Service Exposure Benchmark")
+
+    repo_root = ctx.obj.repo_root
+    script_path = repo_root / 'service-exposure' / 'measure-service-exposure.py'
+
+    if not script_path.exists():
+        console.print(f"[red]Error:[/red] Script not found: {script_path}")
+        sys.exit(1)
+
+    console.print(f"[cyan]Services:[/cyan] {kwargs['service_type']} on port {kwargs['port']}  "
+                  f"[cyan]Migrate:[/cyan] {'yes' if kwargs['migrate'] else 'no'}  "
+                  f"[cyan]Concurrency:[/cyan] {kwargs['concurrency']}")
+
+    # Map CLI args to the benchmark script's arguments
+    python_args = {
+        'start': kwargs['start'],
+        'end': kwargs['end'],
+        'namespace-prefix': kwargs['namespace_prefix'],
+        'vm-name': kwargs['vm_name'],
+        'service-type': kwargs['service_type'],
+        'port': kwargs['port'],
+        'concurrency': kwargs['concurrency'],
+        'qps': kwargs['qps'],
+        'burst': kwargs['burst'],
+        'poll-interval': kwargs['poll_interval'],
+        'timeout': kwargs['timeout'],
+        'migration-timeout': kwargs['migration_timeout'],
+        'checker-ns': kwargs['checker_ns'],
+        'cluster-domain': kwargs['cluster_domain'],
+        'results-folder': kwargs['results_folder'],
+        'log-level': kwargs['log_level'],
+    }
+
+    # Log file: explicit > global context > auto-generated timestamped file
+    if kwargs.get('log_file'):
+        python_args['log-file'] = kwargs['log_file']
+    elif ctx.obj.log_file:
+        python_args['log-file'] = ctx.obj.log_file
+    else:
+        python_args['log-file'] = generate_log_filename('service-exposure')
+
+    # Flags
+    if kwargs['migrate']:
+        python_args['migrate'] = True
+    if kwargs['keep_services']:
+        python_args['keep-services'] = True
+    if kwargs['save_results']:
+        python_args['save-results'] = True
+
+    cmd = build_python_command(script_path, python_args)
+
+    console.print(f"[dim]Running: {' '.join(cmd[:2])} ...[/dim]")
+    console.print()
+
+    try:
+        result = run_workload(ctx, cmd, cwd=repo_root)
+        sys.exit(result.returncode)
+    except KeyboardInterrupt:
+        console.print("\n[yellow]Interrupted by user[/yellow]")
+        sys.exit(130)
+    except Exception as e:
+        console.print(f"[red]Error:[/red] {e}")
+        sys.exit(1)
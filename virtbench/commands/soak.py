@@ -0,0 +1,174 @@
+#!/usr/bin/env python3
+"""
+Soak Test command - long-haul operation mix against a stable VM population
+"""
+import click
+import sys
+from pathlib import Path
+from rich.console import Console
+
+from virtbench.common import print_banner, build_python_command, generate_log_filename
+from virtbench.utils.yaml_modifier import modify_storage_class
+from virtbench.utils.orchestration import run_workload
+
+console = Console()
+
+DEFAULT_TEMPLATE = 'examples/vm-templates/rhel9-vm-datasource.yaml'
+# Operations of measure-soak.py --mix
+OPERATIONS = ('restart', 'migrate', 'snapshot', 'resize')
+
+
+@click.command('soak')
+@click.option('--start', '-s', default=1, type=int, help='Start namespace index')
+@click.option('--end', '-e', default=10, type=int, help='End namespace index')
+@click.option('--namespace-prefix', default='virtbench-soak', help='Namespace prefix')
+@click.option('--vm-name', default='rhel-9-vm', help='VM name in the template')
+@click.option('--vm-template', type=click.Path(), help=f'VM template YAML (default: {DEFAULT_TEMPLATE})')
+@click.option('--storage-class', help='Storage class name for the VM template')
+@click.option('--existing-vms', is_flag=True, help='Soak existing VMs instead of creating them')
+@click.option('--duration', default='24h', help='How long to run, e.g. 72h, 90m, 3d')
+@click.option('--window', default='1h', help='Length of the windows latency, errors and leaks are tracked over')
+@click.option('--rate', default=1.0, type=float, help='Operations started per minute')
+@click.option('--mix', help='Comma-separated operations with optional weights, from '
+                            f'{", ".join(OPERATIONS)} (default: restart=2,migrate=2,snapshot=1,resize=1)')
+@click.option('--seed', type=int, help='Random seed of the operation mix')
+@click.option('--concurrency', '-c', default=2, type=int, help='Operations in flight at once')
+@click.option('--qps', default=0.0, type=float,
+              help='Max operations started per second (0 disables rate limiting)')
+@click.option('--burst', default=10, type=int, help='Max operations started back-to-back when --qps is set')
+@click.option('--resize-increment', default='1Gi', help="Size each resize adds to the VM's first PVC")
+@click.option('--max-resizes-per-vm', default=5, type=int, help='Resizes per VM before resize is no longer drawn for it')
+@click.option('--keep-snapshots', is_flag=True, help='Keep the snapshots instead of deleting each once it is ready')
+@click.option('--poll-interval', default=5, type=float, help='Seconds between status checks')
+@click.option('--timeout', default=900, type=int, help='Timeout per operation (seconds)')
+@click.option('--precision', type=int, help='Decimal places for durations in saved results (default: 3)')
+@click.option('--skip-namespace-creation', is_flag=True, help='Use existing namespaces')
+@click.option('--cleanup', is_flag=True, help='Delete the test namespaces afterwards')
+@click.option('--save-results', is_flag=True, help='Save results to JSON/CSV files')
+@click.option('--results-folder', default='results', help='Base directory for results')
+@click.option('--storage-driver', help='Storage driver label for results path (for example: portworx-3.6, ceph), or auto to detect it')
+@click.option('--log-file', type=click.Path(), help='Log file path (auto-generated if not specified)')
+@click.option('--log-level', default='INFO',
+              type=click.Choice(['DEBUG', 'INFO', 'WARNING', 'ERROR']),
+              help='Logging level')
+@click.pass_context
+def soak(ctx, **kwargs):
+    """
+    Run long-haul soak test
+
+    Keeps a stable population of running VMs for hours or days while a low,
+    steady rate of restarts, live migrations, snapshots and PVC resizes runs
+    against them. Per window it tracks latency drift, accumulated failures
+    and resource leaks (leftover pods, VMIs, migrations, snapshots, and
+    virt-handler/virt-controller memory).
+
+    \b
+    Examples:
+      # Three days, 20 VMs, two operations per minute
+      virtbench soak --start 1 --end 20 --duration 72h --rate 2 \\
+          --storage-class YOUR-STORAGE-CLASS --save-results --cleanup
+    \b
+      # Restarts and migrations only, against VMs of an earlier datasource-clone run
+      virtbench soak --existing-vms --namespace-prefix datasource-clone --start 1 --end 100 \\
+          --duration 24h --mix restart,migrate --save-results
+    """
+    print_banner("Soak Test")
+
+    repo_root = ctx.obj.repo_root
+
+    mix = None
+    if kwargs.get('mix'):
+        mix = [m.strip() for m in kwargs['mix'].split(',') if m.strip()]
+        unknown = [m for m in mix if m.partition('=')[0] not in OPERATIONS]
+        if unknown:
+            console.print(f"[red]Error: Unknown operation(s) in --mix: {', '.join(unknown)} "
+                          f"(choose from {', '.join(OPERATIONS)})[/red]")
+            sys.exit(1)
+
+    template_path = Path(kwargs['vm_template'] or DEFAULT_TEMPLATE)
+    if not template_path.is_absolute():
+        template_path = repo_root / template_path
+
+    if not kwargs['existing_vms']:
+        if not template_path.exists():
+            console.print(f"[red]Error: Template file not found: {template_path}[/red]")
+            sys.exit(1)
+
+        if kwargs['storage_class']:
+            console.print(f"[cyan]Using storage class: {kwargs['storage_class']}[/cyan]")
+            try:
+                modify_storage_class(template_path, kwargs['storage_class'])
+            except Exception as e:
+                console.print(f"[red]Error modifying storage class: {e}[/red]")
+                sys.exit(1)
+
+    script_path = repo_root / 'soak' / 'measure-soak.py'
+
+    if not script_path.exists():
+        console.print(f"[red]Error:[/red] Script not found: {script_path}")
+        sys.exit(1)
+
+    console.print(f"[cyan]Duration:[/cyan] {kwargs['duration']}  "
+                  f"[cyan]Rate:[/cyan] {kwargs['rate']:g}/min")
+
+    # Map CLI args to the benchmark script's arguments
+    python_args = {
+        'start': kwargs['start'],
+        'end': kwargs['end'],
+        'namespace-prefix': kwargs['namespace_prefix'],
+        'vm-name': kwargs['vm_name'],
+        'vm-template': str(template_path),
+        'duration': kwargs['duration'],
+        'window': kwargs['window'],
+        'rate': kwargs['rate'],
+        'seed': kwargs['seed'],
+        'concurrency': kwargs['concurrency'],
+        'qps': kwargs['qps'],
+        'burst': kwargs['burst'],
+        'resize-increment': kwargs['resize_increment'],
+        'max-resizes-per-vm': kwargs['max_resizes_per_vm'],
+        'poll-interval': kwargs['poll_interval'],
+        'timeout': kwargs['timeout'],
+        'precision': kwargs['precision'],
+        'results-folder': kwargs['results_folder'],
+        'storage-driver': kwargs['storage_driver'],
+        'log-level': kwargs['log_level'],
+    }
+
+    # Log file: explicit > global context > auto-generated timestamped file
+    if kwargs.get('log_file'):
+        python_args['log-file'] = kwargs['log_file']
+    elif ctx.obj.log_file:
+        python_args['log-file'] = ctx.obj.log_file
+    else:
+        python_args['log-file'] = generate_log_filename('soak')
+
+    if mix:
+        python_args['mix'] = mix
+
+    # Flags
+    if kwargs['existing_vms']:
+        python_args['existing-vms'] = True
+    if kwargs['keep_snapshots']:
+        python_args['keep-snapshots'] = True
+    if kwargs['skip_namespace_creation']:
+        python_args['skip-namespace-creation'] = True
+    if kwargs['cleanup']:
+        python_args['cleanup'] = True
+    if kwargs['save_results']:
+        python_args['save-results'] = True
+
+    cmd = build_python_command(script_path, python_args)
+
+    console.print(f"[dim]Running: {' '.join(cmd[:2])} ...[/dim]")
+    console.print()
+
+    try:
+        result = run_workload(ctx, cmd, cwd=repo_root)
+        sys.exit(result.returncode)
+    except KeyboardInterrupt:
+        console.print("\n[yellow]Interrupted by user[/yellow]")
+        sys.exit(130)
+    except Exception as e:
+        console.print(f"[red]Error:[/red] {e}")
+        sys.exit(1)
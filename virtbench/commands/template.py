@@ -0,0 +1,98 @@
+#!/usr/bin/env python3
+"""
+Template command - Lint VM templates against the cluster before a run
+"""
+import click
+import os
+import sys
+
+from rich.console import Console
+
+from virtbench.common import print_banner, build_python_command
+from virtbench.utils.orchestration import run_workload
+
+console = Console()
+
+# Workloads with template checks (see WORKLOAD_CHECKS in utils/template_lint.py)
+LINT_WORKLOADS = ['chaos-benchmark', 'datasource-clone', 'descheduler', 'disk-ops', 'fio', 'migration',
+                  'node-drain', 'random-workload', 'soak', 'vm-clone', 'vm-lifecycle', 'vm-snapshot',
+                  'volume-hotplug', 'volume-resize']
+
+
+@click.group('template')
+def template():
+    """
+    Check VM templates
+
+    \b
+    Examples:
+      virtbench template lint examples/vm-templates/vm-template.yaml --set STORAGE_CLASS_NAME=px-csi-db
+    """
+
+
+@template.command('lint')
+@click.argument('file', type=click.Path(exists=True, dir_okay=False))
+@click.option('--set', 'values', multiple=True, metavar='NAME=VALUE',
+              help='Value of the template placeholder {{NAME}} (repeatable)')
+@click.option('--namespace', default='default', help='Namespace of objects without metadata.namespace')
+@click.option('--workload', 'workloads', multiple=True, type=click.Choice(LINT_WORKLOADS),
+              help='Only warn about settings that break this workload (repeatable; default: all)')
+@click.option('--schema', 'schemas', multiple=True, type=click.Path(exists=True, dir_okay=False),
+              help="CRD manifests to validate against instead of the cluster's CRDs (repeatable)")
+@click.option('--offline', is_flag=True, help='Do not query the cluster (only --schema and template settings)')
+@click.option('--report', type=click.Path(), help='Write the findings as a JSON report to this file')
+@click.option('--strict', is_flag=True, help='Exit with code 2 when there are only warnings')
+@click.pass_context
+def lint(ctx, file, values, namespace, workloads, schemas, offline, report, strict):
+    """
+    Lint a VM template before using it in a run
+
+    Validates the VirtualMachines, DataVolumes and other KubeVirt objects of
+    FILE against the OpenAPI schema of their CRDs, checks that the
+    DataSources and storage classes they reference exist in the cluster,
+    and warns about settings that break specific workloads, such as
+    ReadWriteOnce disks for live migration. Fields holding a placeholder
+    that --set does not fill are not checked.
+
+    Exit code 1 means an error was found; with --strict, exit code 2 means
+    only warnings were found.
+
+    \b
+    Examples:
+      # Lint a template against the current cluster
+      virtbench template lint examples/vm-templates/vm-template.yaml \\
+        --set STORAGE_CLASS_NAME=px-csi-db --set DATASOURCE_NAME=rhel9 \\
+        --set DATASOURCE_NAMESPACE=openshift-virtualization-os-images
+    \b
+      # Only the settings that matter to a migration run
+      virtbench template lint my-vm.yaml --workload migration
+    \b
+      # Without cluster access, against saved CRDs
+      kubectl get crd virtualmachines.kubevirt.io datavolumes.cdi.kubevirt.io -o yaml > crds.yaml
+      virtbench template lint my-vm.yaml --offline --schema crds.yaml
+    """
+    print_banner("Template Lint")
+    repo_root = ctx.obj.repo_root
+    python_args = {
+        'set': list(values) or None,
+        'namespace': namespace,
+        'workload': list(workloads) or None,
+        'schema': [os.path.abspath(s) for s in schemas] or None,
+        'report': os.path.abspath(report) if report else None,
+        'log-level': ctx.obj.log_level.upper(),
+    }
+    if offline:
+        python_args['offline'] = True
+    if strict:
+        python_args['strict'] = True
+    if ctx.obj.kubeconfig:
+        python_args['kubeconfig'] = ctx.obj.kubeconfig
+
+    cmd = build_python_command(repo_root / 'utils' / 'template_lint.py', python_args)
+    cmd.insert(2, os.path.abspath(file))
+    try:
+        result = run_workload(ctx, cmd, cwd=repo_root)
+        sys.exit(result.returncode)
+    except KeyboardInterrupt:
+        console.print("\n[yellow]Interrupted by user[/yellow]")
+        sys.exit(130)
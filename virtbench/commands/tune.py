@@ -0,0 +1,141 @@
+#!/usr/bin/env python3
+"""
+Tune command - Apply, revert and run workloads under KubeVirt tuning profiles
+"""
+import click
+import subprocess
+import sys
+from typing import Dict, List, Optional
+
+from rich.console import Console
+
+from virtbench.common import print_banner, build_python_command
+from virtbench.commands.run import global_args
+from virtbench.utils.orchestration import run_workload
+
+console = Console()
+
+
+def _tune(ctx, python_args: Dict) -> int:
+    """Run utils/tune.py on the cluster of this invocation."""
+    if len(ctx.obj.clusters) > 1:
+        console.print("[red]Error: virtbench tune changes one cluster at a time[/red]")
+        return 1
+    repo_root = ctx.obj.repo_root
+    python_args = dict(python_args, **{'log-level': ctx.obj.log_level})
+    return run_workload(ctx, build_python_command(repo_root / 'utils' / 'tune.py', python_args),
+                        cwd=repo_root).returncode
+
+
+def _apply_args(profile: Optional[str], settings: tuple, state_file: Optional[str], timeout: int) -> Dict:
+    if not profile and not settings:
+        raise click.UsageError("give a tuning profile (--profile) or settings (--set NAME=VALUE)")
+    return {'apply': profile, 'set': list(settings), 'state-file': state_file, 'timeout': timeout}
+
+
+_profile_option = click.option('--profile', type=click.Path(exists=True, dir_okay=False),
+                               help='Tuning profile (YAML), e.g. examples/tuning/migration-throughput.yaml')
+_set_option = click.option('--set', 'settings', multiple=True, metavar='NAME=VALUE',
+                           help='Setting to apply (repeatable; overrides the profile)')
+_state_file_option = click.option('--state-file',
+                                  help='Where the previous values are recorded (default: results/tune/state.json)')
+_timeout_option = click.option('--settle-timeout', 'timeout', default=300, type=int,
+                               help='Seconds to wait for the change to roll out')
+
+
+@click.group('tune')
+def tune():
+    """
+    Apply and revert KubeVirt tuning profiles
+
+    Sets migration parallelism and bandwidth, the workload update strategy
+    and virt-api/virt-controller replicas through the HyperConverged CR
+    (OpenShift Virtualization) or the KubeVirt CR. The previous values are
+    recorded before anything is changed, so a profile can always be
+    reverted, and a second profile cannot be applied on top of the first.
+
+    \b
+    Examples:
+      # Current values
+      virtbench tune show
+    \b
+      # Apply a profile, and later restore the previous values
+      virtbench tune apply --profile examples/tuning/migration-throughput.yaml
+      virtbench tune revert
+    \b
+      # Run a workload under a profile; reverted afterwards even if the run fails
+      virtbench tune run --profile examples/tuning/migration-throughput.yaml \\
+          migration --start 1 --end 50 --evacuate --save-results
+    """
+
+
+@tune.command('show')
+@_state_file_option
+@click.pass_context
+def show(ctx, state_file):
+    """Show the current values of the tunable settings"""
+    sys.exit(_tune(ctx, {'show': True, 'state-file': state_file}))
+
+
+@tune.command('apply')
+@_profile_option
+@_set_option
+@_state_file_option
+@_timeout_option
+@click.pass_context
+def apply(ctx, profile, settings, state_file, timeout):
+    """Record the current values and apply a tuning profile"""
+    print_banner("Apply Tuning Profile")
+    sys.exit(_tune(ctx, _apply_args(profile, settings, state_file, timeout)))
+
+
+@tune.command('revert')
+@_state_file_option
+@_timeout_option
+@click.pass_context
+def revert(ctx, state_file, timeout):
+    """Restore the values from before the last apply"""
+    print_banner("Revert Tuning Profile")
+    sys.exit(_tune(ctx, {'revert': True, 'state-file': state_file, 'timeout': timeout}))
+
+
+@tune.command('run', context_settings={'ignore_unknown_options': True, 'allow_interspersed_args': False})
+@_profile_option
+@_set_option
+@_state_file_option
+@_timeout_option
+@click.argument('workload_args', nargs=-1, required=True, type=click.UNPROCESSED)
+@click.pass_context
+def run(ctx, profile, settings, state_file, timeout, workload_args):
+    """
+    Run a workload under a tuning profile
+
+    Applies the profile, runs the workload given after the tune options, and
+    reverts the profile when the workload ends, fails or is interrupted. The
+    exit code is the workload's, or the revert's if only the revert failed.
+    """
+    print_banner("Tuned Run")
+    apply_args = _apply_args(profile, settings, state_file, timeout)
+
+    returncode = _tune(ctx, apply_args)
+    if returncode != 0:
+        console.print("[red]Could not apply the tuning profile; the workload was not run[/red]")
+        if returncode == 2:
+            # Applied but not settled: the previous values are recorded, so undo the change
+            _tune(ctx, {'revert': True, 'state-file': state_file, 'timeout': timeout})
+        sys.exit(returncode)
+
+    cmd: List[str] = ['virtbench', '--uuid', ctx.obj.uuid] + global_args(ctx) + list(workload_args)
+    console.print(f"[dim]Running: {' '.join(cmd)}[/dim]")
+    workload_returncode = 130
+    try:
+        workload_returncode = subprocess.run(cmd, cwd=ctx.obj.repo_root).returncode
+    except KeyboardInterrupt:
+        console.print("\n[yellow]Interrupted by user; reverting the tuning profile[/yellow]")
+    finally:
+        # A dry run applied nothing, so there is nothing to revert
+        revert_returncode = 0 if ctx.obj.dry_run else _tune(
+            ctx, {'revert': True, 'state-file': state_file, 'timeout': timeout})
+    if revert_returncode != 0:
+        console.print("[red]Reverting the tuning profile failed; retry with `virtbench tune revert`[/red]")
+    sys.exit(workload_returncode or revert_returncode)
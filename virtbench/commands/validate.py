@@ -3,36 +3,57 @@
 Cluster validation command
 """
 import click
-import subprocess
+import os
 import sys
 from pathlib import Path
 from rich.console import Console
 
 from virtbench.common import print_banner, build_python_command
+from virtbench.utils.orchestration import run_workload
 
 console = Console()
 
 
 @click.command('validate-cluster')
-@click.option('--storage-class', help='Storage class name to validate')
+@click.option('--storage-class', help='Storage class name to validate (default on Harvester: harvester-longhorn)')
 @click.option('--datasource', default='rhel9', help='DataSource name to validate')
-@click.option('--datasource-namespace', default='openshift-virtualization-os-images',
-              help='DataSource namespace')
+@click.option('--datasource-namespace',
+              help='DataSource namespace (default: per platform, or wherever the DataSource is found)')
 @click.option('--ssh-pod', default='ssh-test-pod', help='SSH test pod name')
 @click.option('--ssh-pod-namespace', default='default', help='SSH test pod namespace')
 @click.option('--min-worker-nodes', default=1, type=int, help='Minimum required worker nodes')
 @click.option('--all', 'run_all', is_flag=True, help='Run all validation checks')
 @click.option('--quick', is_flag=True, help='Run quick validation (skip some checks)')
+@click.option('--scratch-disk', is_flag=True,
+              help='Measure node-local scratch/container storage on every worker node (also run by --all)')
+@click.option('--scratch-paths', help='Comma-separated host directories to measure')
+@click.option('--scratch-size-mb', type=int, help='MiB written and read per directory (default: 256)')
+@click.option('--scratch-slow-ratio', type=float,
+              help='Flag nodes below this fraction of the median across nodes (default: 0.5)')
+@click.option('--scratch-namespace', help='Namespace for the privileged probe pods (default: default)')
+@click.option('--gpu-device', help='Check GPU passthrough/vGPU support for this device plugin resource')
+@click.option('--gpu-vms', type=click.IntRange(1), help='VMs with one GPU each that must fit (default: 1)')
+@click.option('--network', help='Comma-separated NetworkAttachmentDefinitions ([namespace/]name) to check')
+@click.option('--network-vms', type=click.IntRange(1),
+              help='VMs attached to each SR-IOV network that must fit on its VFs (default: 1)')
+@click.option('--report', type=click.Path(), help='Write the check results as a JSON report to this file')
+@click.option('--strict', is_flag=True, help='Exit with code 2 when checks warn but none failed')
 @click.pass_context
 def validate_cluster(ctx, **kwargs):
     """
     Validate cluster prerequisites
     
     Checks that the cluster has all required components for running benchmarks:
-    - KubeVirt installation
-    - Storage class availability
-    - Worker nodes
+    - KubeVirt installation, HyperConverged operator health and CDI readiness
+    - Storage class availability and capabilities (expansion, snapshots, RWX)
+    - Worker nodes and their virtualization capability
     - Required permissions
+    - Node-local scratch storage performance (--scratch-disk)
+    - GPU passthrough/vGPU devices: permitted, advertised and enough (--gpu-device)
+    - Secondary networks: NADs exist and SR-IOV VFs are enough (--network)
+
+    Each check passes, warns or fails. Exit code 1 means a check failed;
+    with --strict, exit code 2 means checks warned but none failed.
 
     \b
     Examples:
@@ -42,6 +63,19 @@ def validate_cluster(ctx, **kwargs):
       # Quick validation
       virtbench validate-cluster --quick
 
+      # Write a JSON report and fail CI on warnings too
+      virtbench validate-cluster --storage-class YOUR-STORAGE-CLASS \\
+        --report validation.json --strict
+
+      # Flag nodes with slow local scratch/container storage
+      virtbench validate-cluster --quick --scratch-disk
+
+      # Check that 8 VMs with an A10 GPU each can be scheduled
+      virtbench validate-cluster --quick --gpu-device nvidia.com/GA102GL_A10 --gpu-vms 8
+
+      # Check a bridge network and that 8 VMs fit on an SR-IOV network's VFs
+      virtbench validate-cluster --quick --network default/br1,sriov-ns/sriov-net --network-vms 8
+
       # Validate a custom DataSource
       virtbench validate-cluster --storage-class YOUR-STORAGE-CLASS \\
         --datasource fedora --datasource-namespace openshift-virtualization-os-images
@@ -66,6 +100,15 @@ def validate_cluster(ctx, **kwargs):
         'ssh-pod': kwargs['ssh_pod'],
         'ssh-pod-namespace': kwargs['ssh_pod_namespace'],
         'min-worker-nodes': kwargs['min_worker_nodes'],
+        'scratch-paths': kwargs['scratch_paths'],
+        'scratch-size-mb': kwargs['scratch_size_mb'],
+        'scratch-slow-ratio': kwargs['scratch_slow_ratio'],
+        'scratch-namespace': kwargs['scratch_namespace'],
+        'gpu-device': kwargs['gpu_device'],
+        'gpu-vms': kwargs['gpu_vms'],
+        'network': [n.strip() for n in kwargs['network'].split(',') if n.strip()] if kwargs['network'] else None,
+        'network-vms': kwargs['network_vms'],
+        'report': os.path.abspath(kwargs['report']) if kwargs['report'] else None,
     }
     
     # Add optional args
@@ -77,6 +120,10 @@ def validate_cluster(ctx, **kwargs):
         python_args['quick'] = True
     if kwargs['run_all']:
         python_args['all'] = True
+    if kwargs['scratch_disk']:
+        python_args['scratch-disk'] = True
+    if kwargs['strict']:
+        python_args['strict'] = True
     
     # Add global flags from context
     if ctx.obj.kubeconfig:
@@ -89,7 +136,7 @@ def validate_cluster(ctx, **kwargs):
     console.print()
     
     try:
-        result = subprocess.run(cmd, cwd=repo_root)
+        result = run_workload(ctx, cmd, cwd=repo_root)
         sys.exit(result.returncode)
     except KeyboardInterrupt:
         console.print("\n[yellow]Interrupted by user[/yellow]")
@@ -0,0 +1,72 @@
+#!/usr/bin/env python3
+"""
+Operator command - Run VirtBenchRun custom resources from inside the cluster
+"""
+import click
+import subprocess
+import sys
+from rich.console import Console
+
+from virtbench.common import print_banner, build_python_command
+
+console = Console()
+
+
+@click.command('operator')
+@click.option('--namespace', help='Only reconcile VirtBenchRuns in this namespace (default: all namespaces)')
+@click.option('--poll-interval', default=10, type=click.IntRange(min=1), help='Seconds between reconciles')
+@click.option('--max-concurrent', default=1, type=click.IntRange(min=1), help='Runs executed at the same time')
+@click.option('--results-dir', help='Directory for run logs (default: <repo>/results)')
+@click.pass_context
+def operator(ctx, **kwargs):
+    """
+    Run the benchmark operator
+
+    Watches VirtBenchRun custom resources and runs the virtbench workload
+    each one describes, recording phase, conditions, exit code and results
+    in its status. Benchmarks can then be scheduled by committing
+    VirtBenchRun manifests to a GitOps repository.
+
+    \b
+    Deploy:
+      kubectl apply -f virtbench-operator/virtbenchrun-crd.yaml
+      kubectl apply -f virtbench-operator/operator.yaml
+      kubectl apply -f virtbench-operator/virtbenchrun-example.yaml
+
+    \b
+    Examples:
+      # Run the operator from a workstation against the current cluster
+      virtbench operator --namespace benchmarks
+    \b
+      # Allow two benchmarks at a time
+      virtbench operator --max-concurrent 2
+    """
+    print_banner("Benchmark Operator")
+
+    repo_root = ctx.obj.repo_root
+    script_path = repo_root / 'virtbench-operator' / 'virtbench-operator.py'
+
+    if not script_path.exists():
+        console.print(f"[red]Error:[/red] Script not found: {script_path}")
+        sys.exit(1)
+
+    python_args = {
+        'namespace': kwargs['namespace'],
+        'poll-interval': kwargs['poll_interval'],
+        'max-concurrent': kwargs['max_concurrent'],
+        'results-dir': kwargs['results_dir'],
+        'log-level': 'WARNING' if ctx.obj.log_level == 'warn' else ctx.obj.log_level.upper(),
+        'log-file': ctx.obj.log_file,
+    }
+
+    cmd = build_python_command(script_path, python_args)
+
+    try:
+        result = subprocess.run(cmd, cwd=repo_root)
+        sys.exit(result.returncode)
+    except KeyboardInterrupt:
+        console.print("\n[yellow]Stopped[/yellow]")
+        sys.exit(0)
+    except Exception as e:
+        console.print(f"[red]Error:[/red] {e}")
+        sys.exit(1)
@@ -0,0 +1,133 @@
+#!/usr/bin/env python3
+"""
+VM Clone Benchmark command - VirtualMachineClone completion and boot times
+"""
+import click
+import sys
+from pathlib import Path
+from rich.console import Console
+
+from virtbench.common import print_banner, build_python_command, generate_log_filename
+from virtbench.utils.orchestration import run_workload
+
+console = Console()
+
+
+@click.command('vm-clone')
+@click.option('--start', '-s', default=1, type=int, help='Start namespace index')
+@click.option('--end', '-e', default=10, type=int, help='End namespace index')
+@click.option('--namespace-prefix', default='kubevirt-perf-test', help='Namespace prefix')
+@click.option('--vm-name', default='rhel-9-vm', help='Source VM name in each namespace')
+@click.option('--clones-per-vm', default=1, type=int, help='Clones created from each source VM')
+@click.option('--clone-api-version', default='clone.kubevirt.io/v1beta1',
+              help='VirtualMachineClone apiVersion (clone.kubevirt.io/v1alpha1 on older KubeVirt)')
+@click.option('--concurrency', '-c', default=20, type=int, help='Clones processed concurrently')
+@click.option('--qps', type=float, help='Max clones started per second (default: unlimited)')
+@click.option('--burst', type=int, help='Max clones started back-to-back when --qps is set')
+@click.option('--poll-interval', default=1, type=int, help='Seconds between status checks')
+@click.option('--clone-timeout', default=1800, type=int, help='Timeout for a clone to succeed (seconds)')
+@click.option('--ping-timeout', default=600, type=int, help='Timeout for a clone to answer ping (seconds)')
+@click.option('--skip-boot', is_flag=True, help='Measure clone completion only; do not start the clones')
+@click.option('--ssh-pod', default='ssh-test-pod', help='SSH helper pod used for ping')
+@click.option('--ssh-pod-ns', default='default', help='SSH helper pod namespace')
+@click.option('--compare-with', type=click.Path(exists=True),
+              help='datasource-clone results folder to compare against')
+@click.option('--cleanup', is_flag=True, help='Delete the clones afterwards')
+@click.option('--save-results', is_flag=True, help='Save results to JSON/CSV files')
+@click.option('--results-folder', default='results', help='Base directory for results')
+@click.option('--storage-driver', help='Storage driver label for results path (for example: portworx-3.6, ceph), or auto to detect it')
+@click.option('--log-file', type=click.Path(), help='Log file path (auto-generated if not specified)')
+@click.option('--log-level', default='INFO',
+              type=click.Choice(['DEBUG', 'INFO', 'WARNING', 'ERROR']),
+              help='Logging level')
+@click.pass_context
+def vm_clone(ctx, **kwargs):
+    """
+    Run VM clone benchmark
+
+    Clones existing VMs with the VirtualMachineClone API and measures clone
+    completion time and the time until each clone answers ping. With
+    --compare-with, averages are compared against a datasource-clone run to
+    quantify the benefit of snapshot-based (smart) cloning.
+
+    \b
+    Requirements:
+      The storage class needs a VolumeSnapshotClass, and the KubeVirt
+      Snapshot feature gate must be enabled.
+
+    \b
+    Examples:
+      # Two clones of every VM, removed afterwards
+      virtbench vm-clone --start 1 --end 20 --clones-per-vm 2 --save-results --cleanup
+    \b
+      # Compare with an earlier DataSource clone run of the same image
+      virtbench vm-clone --start 1 --end 20 --save-results \\
+          --compare-with results/1-disk/20250101-120000_datasource-clone_1-20
+    """
+    print_banner("VM Clone Benchmark")
+
+    repo_root = ctx.obj.repo_root
+    script_path = repo_root / 'vm-clone' / 'measure-vm-clone.py'
+
+    if not script_path.exists():
+        console.print(f"[red]Error:[/red] Script not found: {script_path}")
+        sys.exit(1)
+
+    console.print(f"[cyan]Clones per VM:[/cyan] {kwargs['clones_per_vm']}  "
+                  f"[cyan]Concurrency:[/cyan] {kwargs['concurrency']}")
+
+    # Map CLI args to the benchmark script's arguments
+    python_args = {
+        'start': kwargs['start'],
+        'end': kwargs['end'],
+        'namespace-prefix': kwargs['namespace_prefix'],
+        'vm-name': kwargs['vm_name'],
+        'clones-per-vm': kwargs['clones_per_vm'],
+        'clone-api-version': kwargs['clone_api_version'],
+        'concurrency': kwargs['concurrency'],
+        'qps': kwargs['qps'],
+        'burst': kwargs['burst'],
+        'poll-interval': kwargs['poll_interval'],
+        'clone-timeout': kwargs['clone_timeout'],
+        'ping-timeout': kwargs['ping_timeout'],
+        'ssh-pod': kwargs['ssh_pod'],
+        'ssh-pod-ns': kwargs['ssh_pod_ns'],
+        'results-folder': kwargs['results_folder'],
+        'storage-driver': kwargs['storage_driver'],
+        'log-level': kwargs['log_level'],
+    }
+
+    # Resolve the comparison folder relative to the current directory, not the repo root
+    if kwargs.get('compare_with'):
+        python_args['compare-with'] = str(Path(kwargs['compare_with']).resolve())
+
+    # Log file: explicit > global context > auto-generated timestamped file
+    if kwargs.get('log_file'):
+        python_args['log-file'] = kwargs['log_file']
+    elif ctx.obj.log_file:
+        python_args['log-file'] = ctx.obj.log_file
+    else:
+        python_args['log-file'] = generate_log_filename('vm-clone')
+
+    # Flags
+    if kwargs['skip_boot']:
+        python_args['skip-boot'] = True
+    if kwargs['cleanup']:
+        python_args['cleanup'] = True
+    if kwargs['save_results']:
+        python_args['save-results'] = True
+
+    cmd = build_python_command(script_path, python_args)
+
+    console.print(f"[dim]Running: {' '.join(cmd[:2])} ...[/dim]")
+    console.print()
+
+    try:
+        result = run_workload(ctx, cmd, cwd=repo_root)
+        sys.exit(result.returncode)
+    except KeyboardInterrupt:
+        console.print("\n[yellow]Interrupted by user[/yellow]")
+        sys.exit(130)
+    except Exception as e:
+        console.print(f"[red]Error:[/red] {e}")
+        sys.exit(1)
@@ -0,0 +1,144 @@
+#!/usr/bin/env python3
+"""
+VM Lifecycle Benchmark command - latency of individual VM lifecycle verbs
+"""
+import click
+import sys
+from pathlib import Path
+from rich.console import Console
+
+from virtbench.common import print_banner, build_python_command, generate_log_filename
+from virtbench.utils.yaml_modifier import modify_storage_class
+from virtbench.utils.orchestration import run_workload
+
+console = Console()
+
+DEFAULT_TEMPLATE = 'examples/vm-templates/rhel9-vm-datasource.yaml'
+
+
+@click.command('vm-lifecycle')
+@click.option('--start', '-s', default=1, type=int, help='Start namespace index')
+@click.option('--end', '-e', default=10, type=int, help='End namespace index')
+@click.option('--namespace-prefix', default='virtbench-lifecycle', help='Namespace prefix')
+@click.option('--vm-name', default='rhel-9-vm', help='VM name in the template')
+@click.option('--vm-template', type=click.Path(), help=f'VM template YAML (default: {DEFAULT_TEMPLATE})')
+@click.option('--storage-class', help='Storage class name for the VM template')
+@click.option('--iterations', default=1, type=int, help='Full lifecycles per VM')
+@click.option('--verbs', help='Comma-separated verbs to run, in order '
+                              '(default: create,start,pause,unpause,stop,delete; restart is also available)')
+@click.option('--concurrency', '-c', default=10, type=int, help='VMs operated on concurrently within a verb')
+@click.option('--qps', type=float, help='Max operations started per second (default: unlimited)')
+@click.option('--burst', type=int, help='Max operations started back-to-back when --qps is set')
+@click.option('--poll-interval', default=0.5, type=float, help='Seconds between status checks')
+@click.option('--timeout', default=600, type=int, help='Timeout per operation (seconds)')
+@click.option('--precision', type=int, help='Decimal places for durations in saved results (default: 3)')
+@click.option('--skip-namespace-creation', is_flag=True, help='Use existing namespaces')
+@click.option('--cleanup', is_flag=True, help='Delete the test namespaces afterwards')
+@click.option('--save-results', is_flag=True, help='Save results to JSON/CSV files')
+@click.option('--results-folder', default='results', help='Base directory for results')
+@click.option('--storage-driver', help='Storage driver label for results path (for example: portworx-3.6, ceph), or auto to detect it')
+@click.option('--log-file', type=click.Path(), help='Log file path (auto-generated if not specified)')
+@click.option('--log-level', default='INFO',
+              type=click.Choice(['DEBUG', 'INFO', 'WARNING', 'ERROR']),
+              help='Logging level')
+@click.pass_context
+def vm_lifecycle(ctx, **kwargs):
+    """
+    Run VM lifecycle micro-benchmark
+
+    Times each lifecycle verb (create, start, pause, unpause, stop, delete,
+    and optionally restart) on its own, one verb at a time across all VMs, and reports a per-verb
+    latency table (avg, min, p50, p95, max) to use as a baseline for other
+    workloads.
+
+    \b
+    Examples:
+      # Serialized baseline: one VM at a time, three lifecycles each
+      virtbench vm-lifecycle --start 1 --end 5 --concurrency 1 --iterations 3 \\
+          --storage-class YOUR-STORAGE-CLASS --save-results --cleanup
+    \b
+      # Same verbs across 50 VMs at once
+      virtbench vm-lifecycle --start 1 --end 50 --concurrency 50 \\
+          --storage-class YOUR-STORAGE-CLASS --save-results --cleanup
+    """
+    print_banner("VM Lifecycle Benchmark")
+
+    repo_root = ctx.obj.repo_root
+
+    template_path = Path(kwargs['vm_template'] or DEFAULT_TEMPLATE)
+    if not template_path.is_absolute():
+        template_path = repo_root / template_path
+
+    if not template_path.exists():
+        console.print(f"[red]Error: Template file not found: {template_path}[/red]")
+        sys.exit(1)
+
+    if kwargs['storage_class']:
+        console.print(f"[cyan]Using storage class: {kwargs['storage_class']}[/cyan]")
+        try:
+            modify_storage_class(template_path, kwargs['storage_class'])
+        except Exception as e:
+            console.print(f"[red]Error modifying storage class: {e}[/red]")
+            sys.exit(1)
+
+    script_path = repo_root / 'vm-lifecycle' / 'measure-vm-lifecycle.py'
+
+    if not script_path.exists():
+        console.print(f"[red]Error:[/red] Script not found: {script_path}")
+        sys.exit(1)
+
+    console.print(f"[cyan]Iterations:[/cyan] {kwargs['iterations']}  "
+                  f"[cyan]Concurrency:[/cyan] {kwargs['concurrency']}")
+
+    # Map CLI args to the benchmark script's arguments
+    python_args = {
+        'start': kwargs['start'],
+        'end': kwargs['end'],
+        'namespace-prefix': kwargs['namespace_prefix'],
+        'vm-name': kwargs['vm_name'],
+        'vm-template': str(template_path),
+        'iterations': kwargs['iterations'],
+        'concurrency': kwargs['concurrency'],
+        'qps': kwargs['qps'],
+        'burst': kwargs['burst'],
+        'poll-interval': kwargs['poll_interval'],
+        'timeout': kwargs['timeout'],
+        'precision': kwargs['precision'],
+        'results-folder': kwargs['results_folder'],
+        'storage-driver': kwargs['storage_driver'],
+        'log-level': kwargs['log_level'],
+    }
+
+    # Log file: explicit > global context > auto-generated timestamped file
+    if kwargs.get('log_file'):
+        python_args['log-file'] = kwargs['log_file']
+    elif ctx.obj.log_file:
+        python_args['log-file'] = ctx.obj.log_file
+    else:
+        python_args['log-file'] = generate_log_filename('vm-lifecycle')
+
+    if kwargs.get('verbs'):
+        python_args['verbs'] = [v.strip() for v in kwargs['verbs'].split(',') if v.strip()]
+
+    # Flags
+    if kwargs['skip_namespace_creation']:
+        python_args['skip-namespace-creation'] = True
+    if kwargs['cleanup']:
+        python_args['cleanup'] = True
+    if kwargs['save_results']:
+        python_args['save-results'] = True
+
+    cmd = build_python_command(script_path, python_args)
+
+    console.print(f"[dim]Running: {' '.join(cmd[:2])} ...[/dim]")
+    console.print()
+
+    try:
+        result = run_workload(ctx, cmd, cwd=repo_root)
+        sys.exit(result.returncode)
+    except KeyboardInterrupt:
+        console.print("\n[yellow]Interrupted by user[/yellow]")
+        sys.exit(130)
+    except Exception as e:
+        console.print(f"[red]Error:[/red] {e}")
+        sys.exit(1)
@@ -12,9 +12,8 @@ Operations:
   rebalance-vms      Rebalance VMs evenly across nodes
   vm-snapshot        Create VirtualMachineSnapshots in batches
   run-blkdiscard     Run blkdiscard on data disks inside VMs
-  power-toggle-vms   Power VMs on or off (--action {on,off})
+  power-toggle-vms   Power VMs on, off or restart them (--action {on,off,restart})
 """
-import subprocess
 import sys
 from pathlib import Path
 
@@ -22,6 +21,7 @@ import click
 from rich.console import Console
 
 from virtbench.common import build_python_command, generate_log_filename, print_banner
+from virtbench.utils.orchestration import run_workload
 
 console = Console()
 
@@ -53,12 +53,16 @@ def _run_script(ctx, op_name: str, python_args: dict) -> None:
         else:
             python_args['log-file'] = generate_log_filename(op_name)
 
+    # The global --dry-run maps onto each script's own --dry-run
+    if ctx.obj.dry_run:
+        python_args['dry-run'] = True
+
     cmd = build_python_command(script_path, python_args)
     console.print(f"[dim]Running: {' '.join(cmd[:2])} ...[/dim]")
     console.print()
 
     try:
-        result = subprocess.run(cmd, cwd=repo_root)
+        result = run_workload(ctx, cmd, cwd=repo_root)
         sys.exit(result.returncode)
     except KeyboardInterrupt:
         console.print("\n[yellow]Interrupted by user[/yellow]")
@@ -79,7 +83,7 @@ def vm_ops():
       rebalance-vms      Rebalance VMs evenly across nodes
       vm-snapshot        Create VirtualMachineSnapshots in batches
       run-blkdiscard     Run blkdiscard on data disks inside VMs
-      power-toggle-vms   Power VMs on or off (--action {on,off})
+      power-toggle-vms   Power VMs on, off or restart them (--action {on,off,restart})
 
     \b
     Examples:
@@ -89,6 +93,7 @@ def vm_ops():
       virtbench vm-ops run-blkdiscard --namespace-prefix rhel-eb-filler --start 1 --end 10 --vm-name rhel-elbencho-1
       virtbench vm-ops power-toggle-vms --action off --node worker-1 --percentage 50
       virtbench vm-ops power-toggle-vms --action on --namespace-prefix migration --start 1 --end 50 --vm-name rhel-9-vm
+      virtbench vm-ops power-toggle-vms --action restart --stop-mode compare --namespace-prefix migration --start 1 --end 50 --vm-name rhel-9-vm
     """
 
 
@@ -225,8 +230,13 @@ def run_blkdiscard(ctx, **kwargs):
 
 
 @vm_ops.command('power-toggle-vms', context_settings={'help_option_names': ['-h', '--help']})
-@click.option('--action', required=True, type=click.Choice(['on', 'off']),
-              help='Whether to power VMs on or off')
+@click.option('--action', required=True, type=click.Choice(['on', 'off', 'restart']),
+              help='Whether to power VMs on, off, or restart them')
+@click.option('--stop-mode', type=click.Choice(['graceful', 'force', 'compare']), default=None,
+              help='Stop mode for --action restart: graceful guest shutdown, forced stop, '
+                   'or compare both (default: graceful)')
+@click.option('--results-file', type=click.Path(), default=None,
+              help='Write restart storm results as JSON (--action restart)')
 @click.option('--node', default=None,
               help='Node name. Required for --action off (unless --vm-list-file). '
                    'Optional filter for --action on.')
@@ -245,11 +255,11 @@ def run_blkdiscard(ctx, **kwargs):
 @click.option('--dry-run', is_flag=True, help='Show what would be done without doing it')
 @click.pass_context
 def power_toggle_vms(ctx, **kwargs):
-    """Power VMs on or off (--action {on,off})."""
+    """Power VMs on, off or restart them (--action {on,off,restart})."""
     print_banner(f"VM-Ops: Power {kwargs['action'].upper()} VMs")
     args = {'action': kwargs['action'], 'log-level': ctx.obj.log_level.upper()}
-    for k in ('node', 'percentage', 'namespace_prefix', 'start', 'end',
-             'vm_name', 'vm_list_file', 'concurrency', 'wait_timeout'):
+    for k in ('stop_mode', 'results_file', 'node', 'percentage', 'namespace_prefix', 'start',
+             'end', 'vm_name', 'vm_list_file', 'concurrency', 'wait_timeout'):
         if kwargs[k] is not None:
             args[k.replace('_', '-')] = kwargs[k]
     if kwargs['dry_run']:
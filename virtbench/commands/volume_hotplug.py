@@ -0,0 +1,141 @@
+#!/usr/bin/env python3
+"""
+Volume Hotplug Benchmark command - DataVolume attach/detach latency on running VMs
+"""
+import click
+import sys
+from rich.console import Console
+
+from virtbench.common import print_banner, build_python_command, generate_log_filename
+from virtbench.utils.orchestration import run_workload
+
+console = Console()
+
+
+@click.command('volume-hotplug')
+@click.option('--start', '-s', default=1, type=int, help='Start namespace index')
+@click.option('--end', '-e', default=10, type=int, help='End namespace index')
+@click.option('--storage-class', required=True, help='Storage class for the hotplugged DataVolumes')
+@click.option('--volumes-per-vm', default=1, type=int, help='DataVolumes hotplugged into each VM')
+@click.option('--volume-size', default='1Gi', help='Size of each DataVolume')
+@click.option('--namespace-prefix', default='kubevirt-perf-test', help='Namespace prefix')
+@click.option('--vm-name', default='rhel-9-vm', help='Name of the running VM in each namespace')
+@click.option('--persist', is_flag=True, help='Persist hotplugged volumes in the VM spec')
+@click.option('--skip-detach', is_flag=True, help='Leave volumes attached (no detach measurement)')
+@click.option('--keep-volumes', is_flag=True, help='Do not delete the DataVolumes after the test')
+@click.option('--concurrency', '-c', default=10, type=int, help='VMs processed concurrently')
+@click.option('--qps', type=float, help='Max VMs started per second (default: unlimited)')
+@click.option('--burst', type=int, help='Max VMs started back-to-back when --qps is set')
+@click.option('--poll-interval', default=1, type=int, help='Seconds between status checks')
+@click.option('--attach-timeout', default=300, type=int, help='Attach/detach timeout (seconds)')
+@click.option('--guest-timeout', default=120, type=int,
+              help='Timeout for the disk to appear/disappear in the guest (seconds)')
+@click.option('--skip-guest-check', is_flag=True, help='Skip in-guest device visibility checks (no SSH)')
+@click.option('--vm-user', default='cloud-user', help='VM SSH user for in-guest checks')
+@click.option('--vm-password', default='changeme', help='VM SSH password for in-guest checks')
+@click.option('--ssh-pod', default='ssh-test-pod', help='SSH helper pod')
+@click.option('--ssh-pod-ns', default='default', help='SSH helper pod namespace')
+@click.option('--save-results', is_flag=True, help='Save results to JSON/CSV files')
+@click.option('--results-folder', default='results', help='Base directory for results')
+@click.option('--storage-driver', help='Storage driver label for results path (for example: portworx-3.6, ceph), or auto to detect it')
+@click.option('--log-file', type=click.Path(), help='Log file path (auto-generated if not specified)')
+@click.option('--log-level', default='INFO',
+              type=click.Choice(['DEBUG', 'INFO', 'WARNING', 'ERROR']),
+              help='Logging level')
+@click.pass_context
+def volume_hotplug(ctx, **kwargs):
+    """
+    Run volume hotplug benchmark
+
+    Creates blank DataVolumes, hotplugs them into running VMs with
+    `virtctl addvolume`, then unplugs them with `virtctl removevolume`,
+    measuring attach latency, detach latency and the time until the disk
+    appears in (and disappears from) the guest.
+
+    \b
+    Requirements:
+      The VMs must already be running, e.g. created with datasource-clone.
+      In-guest checks run lsblk over SSH through --ssh-pod using password
+      auth; use --skip-guest-check to measure only the VMI status.
+
+    \b
+    Examples:
+      # Hotplug 3 volumes into each of 50 VMs
+      virtbench volume-hotplug --start 1 --end 50 --storage-class px-csi-db \\
+          --volumes-per-vm 3 --save-results
+    \b
+      # Attach only, 20 VMs at a time, no SSH
+      virtbench volume-hotplug --start 1 --end 100 --storage-class px-csi-db \\
+          --concurrency 20 --skip-detach --skip-guest-check
+    """
+    print_banner("Volume Hotplug Benchmark")
+
+    repo_root = ctx.obj.repo_root
+    script_path = repo_root / 'volume-hotplug' / 'measure-volume-hotplug.py'
+
+    if not script_path.exists():
+        console.print(f"[red]Error:[/red] Script not found: {script_path}")
+        sys.exit(1)
+
+    console.print(f"[cyan]Storage class:[/cyan] {kwargs['storage_class']}  "
+                  f"[cyan]Volumes/VM:[/cyan] {kwargs['volumes_per_vm']}  "
+                  f"[cyan]Concurrency:[/cyan] {kwargs['concurrency']}")
+
+    # Map CLI args to the benchmark script's arguments
+    python_args = {
+        'start': kwargs['start'],
+        'end': kwargs['end'],
+        'storage-class': kwargs['storage_class'],
+        'volumes-per-vm': kwargs['volumes_per_vm'],
+        'volume-size': kwargs['volume_size'],
+        'namespace-prefix': kwargs['namespace_prefix'],
+        'vm-name': kwargs['vm_name'],
+        'concurrency': kwargs['concurrency'],
+        'qps': kwargs['qps'],
+        'burst': kwargs['burst'],
+        'poll-interval': kwargs['poll_interval'],
+        'attach-timeout': kwargs['attach_timeout'],
+        'guest-timeout': kwargs['guest_timeout'],
+        'vm-user': kwargs['vm_user'],
+        'vm-password': kwargs['vm_password'],
+        'ssh-pod': kwargs['ssh_pod'],
+        'ssh-pod-ns': kwargs['ssh_pod_ns'],
+        'results-folder': kwargs['results_folder'],
+        'storage-driver': kwargs['storage_driver'],
+        'log-level': kwargs['log_level'],
+    }
+
+    # Log file: explicit > global context > auto-generated timestamped file
+    if kwargs.get('log_file'):
+        python_args['log-file'] = kwargs['log_file']
+    elif ctx.obj.log_file:
+        python_args['log-file'] = ctx.obj.log_file
+    else:
+        python_args['log-file'] = generate_log_filename('volume-hotplug')
+
+    # Flags
+    if kwargs['persist']:
+        python_args['persist'] = True
+    if kwargs['skip_detach']:
+        python_args['skip-detach'] = True
+    if kwargs['keep_volumes']:
+        python_args['keep-volumes'] = True
+    if kwargs['skip_guest_check']:
+        python_args['skip-guest-check'] = True
+    if kwargs['save_results']:
+        python_args['save-results'] = True
+
+    cmd = build_python_command(script_path, python_args)
+
+    console.print(f"[dim]Running: {' '.join(cmd[:2])} ...[/dim]")
+    console.print()
+
+    try:
+        result = run_workload(ctx, cmd, cwd=repo_root)
+        sys.exit(result.returncode)
+    except KeyboardInterrupt:
+        console.print("\n[yellow]Interrupted by user[/yellow]")
+        sys.exit(130)
+    except Exception as e:
+        console.print(f"[red]Error:[/red] {e}")
+        sys.exit(1)
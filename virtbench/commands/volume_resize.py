@@ -0,0 +1,134 @@
+#!/usr/bin/env python3
+"""
+Volume Resize Benchmark command - PVC expansion and in-guest grow times
+"""
+import click
+import sys
+from rich.console import Console
+
+from virtbench.common import print_banner, build_python_command, generate_log_filename
+from virtbench.utils.orchestration import run_workload
+
+console = Console()
+
+
+@click.command('volume-resize')
+@click.option('--start', '-s', default=1, type=int, help='Start namespace index')
+@click.option('--end', '-e', default=10, type=int, help='End namespace index')
+@click.option('--namespace-prefix', default='kubevirt-perf-test', help='Namespace prefix')
+@click.option('--vm-name', default='rhel-9-vm', help='Name of the running VM in each namespace')
+@click.option('--volume-filter', help='Only resize PVCs whose name contains this string')
+@click.option('--increment-size', default='1Gi', help='Size added per increment (Gi)')
+@click.option('--increments', default=1, type=int, help='Number of increments per PVC')
+@click.option('--concurrency', '-c', default=10, type=int, help='VMs processed concurrently')
+@click.option('--qps', type=float, help='Max VMs started per second (default: unlimited)')
+@click.option('--burst', type=int, help='Max VMs started back-to-back when --qps is set')
+@click.option('--poll-interval', default=1, type=int, help='Seconds between status checks')
+@click.option('--resize-timeout', default=600, type=int, help='PVC expansion timeout (seconds)')
+@click.option('--guest-timeout', default=300, type=int,
+              help='Timeout for the guest to see the new size (seconds)')
+@click.option('--skip-guest-check', is_flag=True, help='Measure PVC expansion only (no SSH)')
+@click.option('--skip-fs-grow', is_flag=True, help='Do not grow partitions/filesystems in the guest')
+@click.option('--vm-user', default='cloud-user', help='VM SSH user for in-guest checks')
+@click.option('--vm-password', default='changeme', help='VM SSH password for in-guest checks')
+@click.option('--ssh-pod', default='ssh-test-pod', help='SSH helper pod')
+@click.option('--ssh-pod-ns', default='default', help='SSH helper pod namespace')
+@click.option('--save-results', is_flag=True, help='Save results to JSON/CSV files')
+@click.option('--results-folder', default='results', help='Base directory for results')
+@click.option('--storage-driver', help='Storage driver label for results path (for example: portworx-3.6, ceph), or auto to detect it')
+@click.option('--log-file', type=click.Path(), help='Log file path (auto-generated if not specified)')
+@click.option('--log-level', default='INFO',
+              type=click.Choice(['DEBUG', 'INFO', 'WARNING', 'ERROR']),
+              help='Logging level')
+@click.pass_context
+def volume_resize(ctx, **kwargs):
+    """
+    Run volume resize benchmark
+
+    Expands the PVCs behind running VMs in --increments steps of
+    --increment-size, measuring CSI expansion time, the time until the guest
+    sees the larger disk, and the time to grow the partition and filesystem.
+    Results are broken down per storage class.
+
+    \b
+    Requirements:
+      The storage classes must allow volume expansion, and KubeVirt must
+      propagate the new size to running guests (ExpandDisks feature gate).
+      In-guest checks need passwordless sudo for --vm-user; use
+      --skip-guest-check to measure PVC expansion only.
+
+    \b
+    Examples:
+      # Grow every VM disk three times by 1Gi
+      virtbench volume-resize --start 1 --end 20 --increments 3 --save-results
+    \b
+      # Only data disks, 5Gi at a time, without touching the filesystem
+      virtbench volume-resize --start 1 --end 50 --volume-filter data \\
+          --increment-size 5Gi --skip-fs-grow
+    """
+    print_banner("Volume Resize Benchmark")
+
+    repo_root = ctx.obj.repo_root
+    script_path = repo_root / 'volume-resize' / 'measure-volume-resize.py'
+
+    if not script_path.exists():
+        console.print(f"[red]Error:[/red] Script not found: {script_path}")
+        sys.exit(1)
+
+    console.print(f"[cyan]Increments:[/cyan] {kwargs['increments']} x {kwargs['increment_size']}  "
+                  f"[cyan]Concurrency:[/cyan] {kwargs['concurrency']}")
+
+    # Map CLI args to the benchmark script's arguments
+    python_args = {
+        'start': kwargs['start'],
+        'end': kwargs['end'],
+        'namespace-prefix': kwargs['namespace_prefix'],
+        'vm-name': kwargs['vm_name'],
+        'volume-filter': kwargs['volume_filter'],
+        'increment-size': kwargs['increment_size'],
+        'increments': kwargs['increments'],
+        'concurrency': kwargs['concurrency'],
+        'qps': kwargs['qps'],
+        'burst': kwargs['burst'],
+        'poll-interval': kwargs['poll_interval'],
+        'resize-timeout': kwargs['resize_timeout'],
+        'guest-timeout': kwargs['guest_timeout'],
+        'vm-user': kwargs['vm_user'],
+        'vm-password': kwargs['vm_password'],
+        'ssh-pod': kwargs['ssh_pod'],
+        'ssh-pod-ns': kwargs['ssh_pod_ns'],
+        'results-folder': kwargs['results_folder'],
+        'storage-driver': kwargs['storage_driver'],
+        'log-level': kwargs['log_level'],
+    }
+
+    # Log file: explicit > global context > auto-generated timestamped file
+    if kwargs.get('log_file'):
+        python_args['log-file'] = kwargs['log_file']
+    elif ctx.obj.log_file:
+        python_args['log-file'] = ctx.obj.log_file
+    else:
+        python_args['log-file'] = generate_log_filename('volume-resize')
+
+    # Flags
+    if kwargs['skip_guest_check']:
+        python_args['skip-guest-check'] = True
+    if kwargs['skip_fs_grow']:
+        python_args['skip-fs-grow'] = True
+    if kwargs['save_results']:
+        python_args['save-results'] = True
+
+    cmd = build_python_command(script_path, python_args)
+
+    console.print(f"[dim]Running: {' '.join(cmd[:2])} ...[/dim]")
+    console.print()
+
+    try:
+        result = run_workload(ctx, cmd, cwd=repo_root)
+        sys.exit(result.returncode)
+    except KeyboardInterrupt:
+        console.print("\n[yellow]Interrupted by user[/yellow]")
+        sys.exit(130)
+    except Exception as e:
+        console.print(f"[red]Error:[/red] {e}")
+        sys.exit(1)
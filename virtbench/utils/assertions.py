@@ -0,0 +1,199 @@
+#!/usr/bin/env python3
+"""
+Pass/fail thresholds for virtbench runs
+
+Assertions are declared in a YAML file given with the global --assert
+option, or in the `assert` section of a plan file (virtbench run --plan):
+
+    assert:
+      - p95_running_time < 180s
+      - migration_success_rate >= 99%
+      - failed == 0
+
+Each one compares a summary value with a threshold. Values are the numeric
+fields of the run summaries (total_vms, successful, failed, ...), every
+statistic of their metrics as `{stat}_{metric}` or `{metric}_{stat}` (the
+`_sec` suffix of time metrics may be left out, e.g. p95_running_time), and
+`success_rate`/`failure_rate` in percent of total_vms. A name may start
+with the summary kind to pick one summary, e.g. `migration_success_rate` or
+`vm_creation_p95_running_time`. Thresholds take an optional unit: ms, s, m
+or h for times (compared in seconds) and % for rates.
+
+After the workload, every summary_*.json it wrote is checked. The verdict
+is added to the summary under "assertions" and written to assertions.json
+next to it, and a failed assertion turns a successful run's exit code into
+ASSERTION_FAILED_EXIT (virtbench/utils/exitcodes.py) so CI pipelines can
+gate on it.
+"""
+import json
+import operator
+import os
+import re
+from pathlib import Path
+from typing import Dict, List
+
+import yaml
+
+ASSERT_FILE_ENV = 'VIRTBENCH_ASSERT_FILE'
+# Assertions of a plan file, passed to the run as a JSON list
+ASSERTIONS_ENV = 'VIRTBENCH_ASSERTIONS'
+ASSERTIONS_FILE = 'assertions.json'
+
+OPERATORS = {
+    '<=': operator.le, '>=': operator.ge, '==': operator.eq, '!=': operator.ne,
+    '<': operator.lt, '>': operator.gt,
+}
+UNITS = {'ms': 0.001, 's': 1, 'm': 60, 'h': 3600, '%': 1, '': 1}
+STATS = ('avg', 'min', 'max', 'median', 'p90', 'p95', 'p99', 'stddev', 'count')
+# Summary kinds of the workloads (summary_<kind>_results.json / summary_<kind>_benchmark.json)
+KNOWN_KINDS = ('vm_creation', 'boot_storm', 'migration', 'failure_recovery', 'volume_hotplug', 'volume_resize',
+               'service_exposure', 'vm_clone', 'vm_lifecycle', 'node_drain', 'descheduler', 'chaos', 'capacity', 'fio', 'soak',
+               'random_workload')
+
+ASSERTION_RE = re.compile(
+    r'^\s*(?P<name>[A-Za-z_][A-Za-z0-9_.]*)\s*(?P<op><=|>=|==|!=|<|>)\s*'
+    r'(?P<value>-?[0-9]+(?:\.[0-9]+)?)\s*(?P<unit>ms|s|m|h|%)?\s*$'
+)
+
+
+def parse_assertion(text: str) -> Dict:
+    """
+    Parse "p95_running_time < 180s" into {'expression', 'name', 'op', 'threshold'}.
+
+    Raises:
+        ValueError: If the text is not "<name> <op> <number>[unit]"
+    """
+    match = ASSERTION_RE.match(str(text))
+    if not match:
+        raise ValueError(f"invalid assertion '{text}' (expected e.g. 'p95_running_time < 180s')")
+    return {
+        'expression': str(text).strip(),
+        'name': match.group('name'),
+        'op': match.group('op'),
+        'threshold': float(match.group('value')) * UNITS[match.group('unit') or ''],
+    }
+
+
+def _assertion_list(data, source: str) -> List[str]:
+    if isinstance(data, dict):
+        data = data.get('assert', data.get('assertions'))
+    if not isinstance(data, list):
+        raise ValueError(f"{source}: expected a list of assertions or an 'assert' list")
+    return [str(item) for item in data]
+
+
+def load_assertion_file(path: str) -> List[Dict]:
+    """
+    Parse the assertions of a YAML file.
+
+    Raises:
+        ValueError: For an unreadable file or an invalid assertion
+    """
+    try:
+        with open(path) as f:
+            data = yaml.safe_load(f)
+    except (OSError, yaml.YAMLError) as e:
+        raise ValueError(f"cannot read {path}: {e}")
+    return [parse_assertion(text) for text in _assertion_list(data, path)]
+
+
+def configured_assertions() -> List[Dict]:
+    """Assertions of the --assert file and of the plan file, if any."""
+    assertions = []
+    if os.environ.get(ASSERT_FILE_ENV):
+        assertions += load_assertion_file(os.environ[ASSERT_FILE_ENV])
+    if os.environ.get(ASSERTIONS_ENV):
+        assertions += [parse_assertion(text) for text in json.loads(os.environ[ASSERTIONS_ENV])]
+    return assertions
+
+
+def summary_kind(path: Path) -> str:
+    """Kind of a summary file, e.g. "migration" for summary_migration_results.json."""
+    return re.sub(r'_(results|benchmark)$', '', path.stem[len('summary_'):])
+
+
+def summary_values(summary: Dict) -> Dict[str, float]:
+    """The values assertions can refer to in one run summary."""
+    values = {key: value for key, value in summary.items()
+              if isinstance(value, (int, float)) and not isinstance(value, bool)}
+    for metric in summary.get('metrics') or []:
+        if not isinstance(metric, dict) or not metric.get('metric'):
+            continue
+        names = {metric['metric'], re.sub(r'_sec$', '', metric['metric'])}
+        for stat in STATS:
+            value = metric.get(stat)
+            if isinstance(value, (int, float)) and not isinstance(value, bool):
+                for name in names:
+                    values[f"{stat}_{name}"] = value
+                    values[f"{name}_{stat}"] = value
+    total = summary.get('total_vms')
+    if isinstance(total, int) and total and isinstance(summary.get('successful'), int):
+        values['success_rate'] = round(summary['successful'] / total * 100, 3)
+        values['failure_rate'] = round(100 - values['success_rate'], 3)
+    return values
+
+
+def evaluate(assertions: List[Dict], kind: str, summary: Dict) -> Dict:
+    """
+    Check assertions against one run summary.
+
+    An assertion whose name starts with another summary's kind does not apply;
+    one whose value the summary does not have is "missing", which fails.
+
+    Returns:
+        {'verdict': 'pass'|'fail', 'passed', 'failed', 'results': [{'expression', 'value', 'threshold', 'status'}]}
+    """
+    values = summary_values(summary)
+    results = []
+    for assertion in assertions:
+        name = assertion['name']
+        if name.startswith(f"{kind}_") and name not in values:
+            name = name[len(kind) + 1:]
+        elif name not in values and any(name.startswith(f"{other}_") for other in KNOWN_KINDS if other != kind):
+            continue
+        value = values.get(name)
+        if value is None:
+            status = 'missing'
+        else:
+            status = 'pass' if OPERATORS[assertion['op']](value, assertion['threshold']) else 'fail'
+        results.append({'expression': assertion['expression'], 'value': value,
+                        'threshold': assertion['threshold'], 'status': status})
+    return _verdict(results)
+
+
+def _verdict(results: List[Dict]) -> Dict:
+    failed = sum(1 for r in results if r['status'] != 'pass')
+    return {
+        'verdict': 'fail' if failed else 'pass',
+        'passed': len(results) - failed,
+        'failed': failed,
+        'results': results,
+    }
+
+
+def check_results(assertions: List[Dict], summaries: List[Path]) -> Dict[Path, Dict]:
+    """
+    Check the summaries a run wrote, recording the verdict in each.
+
+    A workload writing several summaries (e.g. boot storm) is checked as a
+    whole: an assertion is only missing if no summary has its value.
+
+    Returns:
+        {summary path: evaluate() result}; unreadable summaries are skipped
+    """
+    loaded = {}
+    for path in summaries:
+        try:
+            loaded[path] = json.loads(path.read_text())
+        except (OSError, ValueError):
+            continue
+    verdicts = {path: evaluate(assertions, summary_kind(path), summary) for path, summary in loaded.items()}
+    resolved = {r['expression'] for verdict in verdicts.values() for r in verdict['results'] if r['status'] != 'missing'}
+    for path, summary in loaded.items():
+        verdict = _verdict([r for r in verdicts[path]['results']
+                            if r['status'] != 'missing' or r['expression'] not in resolved])
+        verdicts[path] = verdict
+        summary['assertions'] = verdict
+        path.write_text(json.dumps(summary, indent=4))
+        (path.parent / ASSERTIONS_FILE).write_text(json.dumps(dict(verdict, summary=path.name), indent=4))
+    return verdicts
@@ -0,0 +1,146 @@
+#!/usr/bin/env python3
+"""
+Cost and efficiency metrics of a run
+
+Computed by `virtbench results show` from what a run summary already
+records: the VMs of the run, the worker nodes of the cluster inventory
+("cluster" block) and, for datasource-clone, the provisioned and consumed
+storage of the test PVCs ("storage_usage" block):
+
+- vms_per_core: VMs per physical core of the worker nodes. Node capacity
+  counts logical CPUs; nodes that Node Feature Discovery labels as
+  multithreaded (inventory "smt": true) count half of them as physical
+  cores. physical_cores_estimated is true when a node's SMT state is unknown
+  and its logical CPUs were counted as physical cores.
+- vms_per_gib: VMs per GiB of worker node memory
+- storage_overhead_ratio: provisioned / consumed bytes of the measured PVCs
+
+With per-node costs (--node-cost, an hourly price per node name or instance
+type, or a default for all nodes) it adds the hourly cost of the worker
+nodes, the cost per VM-hour, and the cost of the run and per VM over the
+run's duration.
+"""
+import re
+from datetime import datetime
+from typing import Dict, Optional
+
+DEFAULT_COST_KEY = 'default'
+
+
+def parse_node_costs(text: str) -> Dict[str, float]:
+    """
+    Parse "2.5" or "m5.metal=4.1,worker-3=2.0,default=1.5" into {key: hourly cost}.
+
+    A bare number is the cost of every node. Keys are node names or instance
+    types (node.kubernetes.io/instance-type); "default" covers the others.
+
+    Raises:
+        ValueError: If an entry is not key=number or a cost is negative
+    """
+    costs = {}
+    for entry in (e.strip() for e in text.split(',') if e.strip()):
+        key, sep, value = entry.rpartition('=')
+        key = key.strip() if sep else DEFAULT_COST_KEY
+        try:
+            cost = float(value)
+        except ValueError:
+            raise ValueError(f"invalid node cost '{entry}' (expected NODE_OR_INSTANCE_TYPE=COST or COST)")
+        if cost < 0 or not key:
+            raise ValueError(f"invalid node cost '{entry}'")
+        costs[key] = cost
+    return costs
+
+
+def _cpu_count(quantity) -> Optional[float]:
+    if quantity is None:
+        return None
+    text = str(quantity)
+    try:
+        return float(text[:-1]) / 1000 if text.endswith('m') else float(text)
+    except ValueError:
+        return None
+
+
+def run_vms(summary: Dict) -> Optional[int]:
+    """VMs a summary measured: its successful count, else its total."""
+    for key in ('successful', 'total_vms'):
+        if isinstance(summary.get(key), int) and summary[key] > 0:
+            return summary[key]
+    return None
+
+
+def run_duration_sec(summary: Dict) -> Optional[float]:
+    """Duration of a run from its summary (total_test_duration_sec, else the timing block)."""
+    if isinstance(summary.get('total_test_duration_sec'), (int, float)):
+        return summary['total_test_duration_sec']
+    timing = summary.get('timing') or {}
+    try:
+        started, finished = (datetime.fromisoformat(re.sub(r'(\.\d{6})\d*', r'\1', timing[key]).replace('Z', '+00:00'))
+                             for key in ('started_at', 'finished_at'))
+    except (KeyError, TypeError, ValueError):
+        return None
+    return (finished - started).total_seconds()
+
+
+def efficiency_metrics(summary: Dict, node_costs: Optional[Dict[str, float]] = None) -> Optional[Dict]:
+    """
+    Efficiency (and, with node_costs, cost) metrics of one summary.
+
+    Returns:
+        The metrics, or None when the summary has no VM count or no node inventory
+    """
+    vms = run_vms(summary)
+    nodes = ((summary.get('cluster') or {}).get('nodes') or {}).get('items') or []
+    if not vms or not nodes:
+        return None
+    workers = [n for n in nodes if 'worker' in (n.get('roles') or [])] or nodes
+
+    logical = physical = memory_gib = 0.0
+    estimated = False
+    for node in workers:
+        cpus = _cpu_count(node.get('cpu')) or 0
+        logical += cpus
+        if node.get('smt') is None:
+            estimated = True
+        physical += cpus / 2 if node.get('smt') else cpus
+        memory_gib += node.get('memory_gib') or 0
+
+    metrics = {
+        'vms': vms,
+        'worker_nodes': len(workers),
+        'logical_cpus': logical,
+        'physical_cores': physical,
+        'physical_cores_estimated': estimated,
+        'memory_gib': round(memory_gib, 1),
+        'vms_per_core': round(vms / physical, 3) if physical else None,
+        'vms_per_gib': round(vms / memory_gib, 4) if memory_gib else None,
+    }
+
+    storage = summary.get('storage_usage') or {}
+    if storage.get('provisioned_bytes'):
+        metrics['provisioned_gib_per_vm'] = round(storage['provisioned_bytes'] / 2 ** 30 / vms, 2)
+    metrics['storage_overhead_ratio'] = storage.get('overhead_ratio')
+
+    if node_costs:
+        hourly = 0.0
+        unpriced = []
+        for node in workers:
+            cost = node_costs.get(node.get('name'))
+            if cost is None:
+                cost = node_costs.get(node.get('instance_type'))
+            if cost is None:
+                cost = node_costs.get(DEFAULT_COST_KEY)
+            if cost is None:
+                unpriced.append(node.get('name'))
+            else:
+                hourly += cost
+        if unpriced:
+            metrics['unpriced_nodes'] = unpriced
+        else:
+            duration = run_duration_sec(summary)
+            metrics['cluster_cost_per_hour'] = round(hourly, 4)
+            metrics['cost_per_vm_hour'] = round(hourly / vms, 4)
+            if duration:
+                metrics['run_cost'] = round(hourly * duration / 3600, 4)
+                metrics['run_cost_per_vm'] = round(hourly * duration / 3600 / vms, 4)
+    return metrics
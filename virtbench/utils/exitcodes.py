@@ -0,0 +1,21 @@
+#!/usr/bin/env python3
+"""
+Exit codes of virtbench runs
+
+A workload run exits with the exit code of its script: 0 on success, 1 on
+an error and 130 when interrupted. failure-recovery reports its verdicts
+with 2 (not every VM recovered), 3 (cleanup failed), 4 (volume fencing
+violated) and 5 (data integrity violated), and validate-cluster --strict and
+template lint --strict use 2 for warnings.
+
+The codes below are the wrapper's own, for a run it stops before the
+workload starts or judges afterwards. They are kept clear of every code a
+workload script returns, so CI can tell them apart.
+"""
+
+# An assertion of --assert (or a plan's assert section) failed on a run that succeeded
+ASSERTION_FAILED_EXIT = 10
+# The permission audit (virtbench/utils/rbac.py) found a denied permission; nothing ran
+PERMISSION_DENIED_EXIT = 11
+# No node satisfies --node-selector/--affinity-file, or --taint-nodes selects none; nothing ran
+NO_MATCHING_NODES_EXIT = 12
@@ -0,0 +1,78 @@
+#!/usr/bin/env python3
+"""
+Instancetype sweeps for virtbench
+
+`virtbench datasource-clone --instancetype` with several instancetypes runs
+the workload once per instancetype and compares the runs with
+utils/instancetype.py:
+
+    <results>/instancetype-sweep/<timestamp>/<instancetype>/...   one run each
+    <results>/instancetype-sweep/<timestamp>/instancetype_sweep.json
+
+Every run but the last cleans up its VMs so the next run can create them
+again under the same names.
+"""
+import subprocess
+from datetime import datetime
+from pathlib import Path
+from typing import Any, Dict, List
+
+from rich.console import Console
+
+from virtbench.common import build_python_command
+from virtbench.utils.orchestration import run_workload
+
+console = Console()
+
+
+def run_instancetype_sweep(ctx, script_path: Path, python_args: Dict[str, Any], instancetypes: List[str],
+                           repo_root: Path) -> int:
+    """
+    Run a workload once per instancetype, then compare the runs.
+
+    Args:
+        ctx: Click context of the command
+        script_path: Workload script
+        python_args: Script arguments shared by the runs
+        instancetypes: Instancetypes in sweep order
+        repo_root: Repository root (working directory of the runs)
+
+    Returns:
+        Exit code: the comparison's, or 1 when every run failed
+    """
+    if len(ctx.obj.clusters) > 1:
+        console.print("[red]Error: an instancetype sweep runs on one cluster at a time[/red]")
+        return 1
+    base = Path(python_args['results-folder']) / 'instancetype-sweep' / datetime.now().strftime("%Y%m%d-%H%M%S")
+    if not base.is_absolute():
+        base = repo_root / base
+
+    failed = []
+    for i, instancetype in enumerate(instancetypes):
+        console.print(f"[cyan]Instancetype sweep: {instancetype} ({i + 1}/{len(instancetypes)})[/cyan]")
+        run_args = dict(python_args, **{
+            'instancetype': instancetype,
+            'results-folder': str(base / instancetype),
+            'save-results': True,
+        })
+        if i < len(instancetypes) - 1:
+            run_args.update(cleanup=True, yes=True)
+        run_args.pop('log-file', None)
+        result = run_workload(ctx, build_python_command(script_path, run_args), cwd=repo_root)
+        if result.returncode != 0:
+            # A failed run still leaves timings of the VMs that came up; keep sweeping
+            console.print(f"[yellow]The {instancetype} run exited with code {result.returncode}[/yellow]")
+            failed.append(instancetype)
+
+    if len(failed) == len(instancetypes):
+        console.print("[red]Every run of the sweep failed; skipping the comparison[/red]")
+        return 1
+    if ctx.obj.dry_run:
+        return 0
+    cmd = build_python_command(repo_root / 'utils' / 'instancetype.py', {
+        'sweep': str(base),
+        'instancetypes': instancetypes,
+        'output': str(base / 'instancetype_sweep.json'),
+        'log-level': python_args.get('log-level'),
+    })
+    return subprocess.run(cmd, cwd=repo_root).returncode
@@ -0,0 +1,151 @@
+#!/usr/bin/env python3
+"""
+JUnit XML reports of virtbench runs
+
+With the global --junit-report <path>, a JUnit-style XML report of every
+workload run is written after it, so Jenkins, GitLab and Prow can show the
+outcome of a benchmark like a test run:
+
+    <testsuites name="virtbench">
+      <testsuite name="datasource-clone">           # the workload's exit code
+      <testsuite name="vm_creation">                # one per summary_*.json the run wrote
+        <testcase classname="vm_creation" name="datasource-clone-1" time="42.1"/>
+        <testcase classname="vm_creation" name="datasource-clone-2"><failure message="..."/></testcase>
+      <testsuite name="vm_creation.assertions">     # with --assert, one test case per assertion
+
+Per-VM test cases come from the detailed results next to each summary (e.g.
+vm_creation_results.json); an entry fails when its `success` is false or its
+`status` is Failed. Without detailed results the summary becomes a single
+test case that fails when `failed` is non-zero.
+"""
+import json
+import xml.etree.ElementTree as ET
+from pathlib import Path
+from typing import Dict, List, Optional
+
+from virtbench.utils.assertions import summary_kind
+
+JUNIT_REPORT_ENV = 'VIRTBENCH_JUNIT_REPORT'
+
+# Keys naming the item of a detailed result entry, joined with "/"
+NAME_KEYS = ('namespace', 'vm', 'vm_name', 'clone', 'pvc', 'step', 'node')
+# Preferred durations of an entry; otherwise its first *_sec value
+TIME_KEYS = ('running_time_sec', 'observed_time_sec', 'total_sec', 'duration_sec')
+FAILED_STATUSES = ('failed', 'error', 'timeout')
+
+
+def detailed_entries(summary_path: Path) -> Optional[List[Dict]]:
+    """Per-item entries of the detailed results next to a summary, or None without them."""
+    path = summary_path.with_name(summary_path.name[len('summary_'):])
+    try:
+        data = json.loads(path.read_text())
+    except (OSError, ValueError):
+        return None
+    if isinstance(data, dict):
+        data = next((value for value in data.values()
+                     if isinstance(value, list) and value and all(isinstance(v, dict) for v in value)), None)
+    if not isinstance(data, list) or not all(isinstance(entry, dict) for entry in data):
+        return None
+    return data
+
+
+def entry_name(entry: Dict, index: int) -> str:
+    parts = [str(entry[key]) for key in NAME_KEYS if entry.get(key) not in (None, '')]
+    return '/'.join(parts) or f"item-{index}"
+
+
+def entry_time(entry: Dict) -> Optional[float]:
+    for key in TIME_KEYS + tuple(k for k in entry if k.endswith('_sec')):
+        value = entry.get(key)
+        if isinstance(value, (int, float)) and not isinstance(value, bool):
+            return float(value)
+    return None
+
+
+def entry_failure(entry: Dict) -> Optional[str]:
+    """Failure message of an entry, or None if it succeeded."""
+    status = str(entry.get('status') or '').lower()
+    if entry.get('success') is False or status in FAILED_STATUSES:
+        return str(entry.get('error') or entry.get('status') or 'failed')
+    return None
+
+
+def _suite(parent: ET.Element, name: str) -> ET.Element:
+    return ET.SubElement(parent, 'testsuite', name=name)
+
+
+def _case(suite: ET.Element, classname: str, name: str, time: Optional[float] = None,
+          failure: Optional[str] = None, output: Optional[str] = None):
+    case = ET.SubElement(suite, 'testcase', classname=classname, name=name)
+    if time is not None:
+        case.set('time', f"{time:.3f}")
+    if failure is not None:
+        ET.SubElement(case, 'failure', message=failure[:500]).text = failure
+    if output:
+        ET.SubElement(case, 'system-out').text = output
+
+
+def _finish(suite: ET.Element):
+    cases = suite.findall('testcase')
+    suite.set('tests', str(len(cases)))
+    suite.set('failures', str(sum(1 for c in cases if c.find('failure') is not None)))
+    suite.set('skipped', '0')
+    suite.set('errors', '0')
+    suite.set('time', f"{sum(float(c.get('time', 0)) for c in cases):.3f}")
+
+
+def build_report(workload: str, returncode: int, duration_sec: float, summaries: List[Path]) -> ET.Element:
+    """JUnit XML tree of a run from its exit code and the summaries it wrote."""
+    root = ET.Element('testsuites', name='virtbench')
+
+    run = _suite(root, workload)
+    _case(run, workload, 'exit code', duration_sec,
+          failure=f"{workload} exited with code {returncode}" if returncode else None)
+    _finish(run)
+
+    for path in summaries:
+        try:
+            summary = json.loads(path.read_text())
+        except (OSError, ValueError):
+            continue
+        kind = summary_kind(path)
+        suite = _suite(root, kind)
+        suite.set('file', str(path))
+        entries = detailed_entries(path)
+        if entries:
+            for index, entry in enumerate(entries, 1):
+                _case(suite, kind, entry_name(entry, index), entry_time(entry), entry_failure(entry))
+        else:
+            failed = summary.get('failed')
+            _case(suite, kind, 'summary', summary.get('total_test_duration_sec'),
+                  failure=f"{failed} item(s) failed" if isinstance(failed, int) and failed else None)
+        _finish(suite)
+
+        assertions = summary.get('assertions')
+        if assertions:
+            checks = _suite(root, f"{kind}.assertions")
+            for result in assertions['results']:
+                failure = None
+                if result['status'] == 'missing':
+                    failure = 'no such value in the run summaries'
+                elif result['status'] != 'pass':
+                    failure = f"value {result['value']} (threshold {result['threshold']})"
+                _case(checks, f"{kind}.assertions", result['expression'], failure=failure,
+                      output=None if result['value'] is None else f"value: {result['value']}")
+            _finish(checks)
+
+    suites = root.findall('testsuite')
+    for attr in ('tests', 'failures', 'errors', 'skipped'):
+        root.set(attr, str(sum(int(s.get(attr)) for s in suites)))
+    root.set('time', f"{duration_sec:.3f}")
+    return root
+
+
+def write_report(path: Path, workload: str, returncode: int, duration_sec: float, summaries: List[Path]) -> Path:
+    """Write the JUnit XML report of a run; returns its path."""
+    root = build_report(workload, returncode, duration_sec, summaries)
+    if hasattr(ET, 'indent'):   # Python 3.9+
+        ET.indent(root)
+    path.parent.mkdir(parents=True, exist_ok=True)
+    ET.ElementTree(root).write(path, encoding='utf-8', xml_declaration=True)
+    return path
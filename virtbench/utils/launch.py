@@ -0,0 +1,80 @@
+#!/usr/bin/env python3
+"""
+Command lines of virtbench runs requested remotely.
+
+The benchmark operator (VirtBenchRun resources), the API server
+(virtbench serve) and plan files (virtbench run --plan) describe a run as
+a workload plus option mappings:
+
+    {'workload': 'vm-clone',
+     'options': {'log-level': 'debug'},                 # global options
+     'args': {'start': 1, 'end': 20, 'save-results': True}}
+
+and turn it into a virtbench command line. The workload and option names
+are checked so a request can only select virtbench commands and options.
+"""
+
+import re
+from pathlib import Path
+from typing import Dict, List, Optional
+
+# Workload command path, e.g. "vm-clone" or "vm-ops drain"
+WORKLOAD_RE = re.compile(r'^[a-z0-9][a-z0-9-]*( [a-z0-9][a-z0-9-]*)?$')
+OPTION_RE = re.compile(r'^[a-z0-9][a-z0-9-]*$')
+
+# File timestamps come from a coarser clock than time.time(); a file written
+# right after a run started can look a few milliseconds older
+MTIME_SLACK_SEC = 1.0
+
+
+def option_args(options: Optional[Dict]) -> List[str]:
+    """
+    Command line options from a mapping.
+
+    {'start': 1, 'save-results': True, 'cleanup': False, 'node': ['w1', 'w2']}
+    becomes ['--start', '1', '--save-results', '--node', 'w1', '--node', 'w2'].
+
+    Raises:
+        ValueError: For an option name that is not a plain --option
+    """
+    if options is not None and not isinstance(options, dict):
+        raise ValueError("options must be a mapping of option name to value")
+    args = []
+    for key, value in (options or {}).items():
+        if not OPTION_RE.match(str(key)):
+            raise ValueError(f"invalid option name '{key}'")
+        if isinstance(value, bool):
+            if value:
+                args.append(f'--{key}')
+        elif isinstance(value, list):
+            for item in value:
+                args.extend([f'--{key}', str(item)])
+        elif value is not None:
+            args.extend([f'--{key}', str(value)])
+    return args
+
+
+def virtbench_command(workload: str, args: Optional[Dict] = None, options: Optional[Dict] = None,
+                      run_uuid: Optional[str] = None) -> List[str]:
+    """
+    virtbench command line of a run.
+
+    Raises:
+        ValueError: For an invalid workload or option name
+    """
+    workload = str(workload or '').strip()
+    if not WORKLOAD_RE.match(workload):
+        raise ValueError(f"invalid workload '{workload}'")
+    cmd = ['virtbench']
+    if run_uuid:
+        cmd += ['--uuid', run_uuid]
+    return cmd + option_args(options) + workload.split() + option_args(args)
+
+
+def results_since(results_dir: Path, since: float, exclude: Optional[str] = None) -> List[Path]:
+    """summary_*.json files written under results_dir since the given time (skipping an `exclude` directory)."""
+    if not results_dir.is_dir():
+        return []
+    return sorted(p for p in results_dir.rglob('summary_*.json')
+                  if p.stat().st_mtime >= since - MTIME_SLACK_SEC
+                  and exclude not in p.relative_to(results_dir).parts)
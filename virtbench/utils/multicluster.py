@@ -0,0 +1,308 @@
+#!/usr/bin/env python3
+"""
+Multi-cluster execution for virtbench workloads
+
+With several clusters given (a repeated --kubeconfig, or --contexts), a
+workload runs once per cluster, sequentially or in parallel, with the
+results of each cluster in its own folder:
+
+    <results>/clusters/<cluster>/...
+
+and a combined comparison of the run summaries in:
+
+    <results>/multi-cluster/<timestamp>_<workload>/comparison.{json,csv}
+
+The permission audit, node taints, assertions, JUnit report, results
+database and diagnostics bundles around a run are in
+virtbench/utils/orchestration.py, which calls run_clusters().
+
+When the run is interrupted or terminated, the workload scripts still
+running are sent SIGINT and given STOP_GRACE_SEC to clean up.
+"""
+import atexit
+import csv
+import json
+import os
+import re
+import signal
+import subprocess
+import sys
+import tempfile
+import threading
+import time
+from datetime import datetime
+from pathlib import Path
+from typing import Dict, List, Optional, Tuple
+
+from rich.console import Console
+from rich.table import Table
+
+console = Console()
+
+# Script arguments that name the base results directory
+RESULTS_ARGS = ('--results-folder', '--results-dir')
+
+# Seconds a workload script gets to clean up after SIGINT before it is killed
+STOP_GRACE_SEC = 300
+
+# Metric statistics set side by side in the cluster comparison (see utils/stats.py)
+COMPARED_STATS = ('avg', 'median', 'p95', 'p99', 'stddev')
+
+
+def _cluster_name(value: str) -> str:
+    """Folder-safe cluster name from a kubeconfig file name or context name."""
+    return re.sub(r'[^A-Za-z0-9._-]+', '-', value).strip('-') or 'cluster'
+
+
+def resolve_clusters(kubeconfigs: Tuple[str, ...], contexts: Optional[str]) -> List[Dict]:
+    """
+    Clusters to run against, as [{'name': ..., 'kubeconfig': ..., 'context': ...}].
+
+    Each kubeconfig is a cluster (named after the file); with --contexts, each
+    context of the kubeconfig (or of the default kubeconfig) is a cluster.
+
+    Raises:
+        ValueError: If several kubeconfigs and contexts are combined, or a name repeats
+    """
+    context_list = [c.strip() for c in (contexts or '').split(',') if c.strip()]
+    if context_list and len(kubeconfigs) > 1:
+        raise ValueError("--contexts selects contexts of a single kubeconfig; "
+                         "repeat --kubeconfig or use --contexts, not both")
+    if context_list:
+        kubeconfig = kubeconfigs[0] if kubeconfigs else None
+        clusters = [{'name': _cluster_name(c), 'kubeconfig': kubeconfig, 'context': c} for c in context_list]
+    else:
+        clusters = [{'name': _cluster_name(Path(k).stem), 'kubeconfig': k, 'context': None} for k in kubeconfigs]
+
+    names = [c['name'] for c in clusters]
+    duplicates = sorted({n for n in names if names.count(n) > 1})
+    if duplicates:
+        raise ValueError(f"clusters must have distinct names, got {', '.join(duplicates)} more than once")
+    return clusters
+
+
+def _context_kubeconfig(cluster: Dict) -> str:
+    """Write a kubeconfig holding only the cluster's context, so KUBECONFIG selects it."""
+    env = dict(os.environ)
+    if cluster['kubeconfig']:
+        env['KUBECONFIG'] = cluster['kubeconfig']
+    result = subprocess.run(['kubectl', 'config', 'view', '--minify', '--flatten',
+                             '--context', cluster['context']],
+                            capture_output=True, text=True, env=env)
+    if result.returncode != 0:
+        raise RuntimeError(f"cannot read context '{cluster['context']}': {result.stderr.strip()}")
+    fd, path = tempfile.mkstemp(prefix=f"virtbench-{cluster['name']}-", suffix='.kubeconfig')
+    with os.fdopen(fd, 'w') as f:
+        f.write(result.stdout)
+    atexit.register(lambda: os.path.exists(path) and os.remove(path))
+    return path
+
+
+def _cluster_command(cmd: List[str], name: str) -> Tuple[List[str], Optional[str]]:
+    """
+    Command for one cluster: results under <results>/clusters/<name>, one log file (and report) per cluster.
+
+    Returns:
+        (command, base results directory or None)
+    """
+    cmd = list(cmd)
+    results_base = None
+    for i, arg in enumerate(cmd[:-1]):
+        if arg in RESULTS_ARGS:
+            results_base = cmd[i + 1]
+            cmd[i + 1] = os.path.join(results_base, 'clusters', name)
+        elif arg in ('--log-file', '--report'):
+            stem, ext = os.path.splitext(cmd[i + 1])
+            cmd[i + 1] = f"{stem}-{name}{ext}"
+    return cmd, results_base
+
+
+def _stream(process: subprocess.Popen, name: str):
+    """Copy a parallel run's output to the console, each line prefixed with its cluster."""
+    for line in process.stdout:
+        sys.stdout.write(f"[{name}] {line}")
+        sys.stdout.flush()
+
+
+def _flatten_summary(summary: Dict) -> Dict:
+    """
+    Scalar values and metric statistics of a run summary, e.g.
+    {'failed': 0, 'clone_sec_avg': 12.3, 'clone_sec_p95': 18.1}.
+    """
+    values = {}
+    for key, value in summary.items():
+        if isinstance(value, (int, float)) and not isinstance(value, bool):
+            values[key] = value
+    for metric in summary.get('metrics') or []:
+        if not isinstance(metric, dict) or not metric.get('metric'):
+            continue
+        for stat in COMPARED_STATS:
+            if metric.get(stat) is not None:
+                values[f"{metric['metric']}_{stat}"] = metric[stat]
+    return values
+
+
+def _collect_summaries(folder: Path, since: float) -> Dict:
+    """Flattened summary_*.json files written under folder since the given time."""
+    values = {}
+    if not folder.is_dir():
+        return values
+    for path in sorted(folder.rglob('summary_*.json')):
+        if path.stat().st_mtime < since:
+            continue
+        try:
+            summary = json.loads(path.read_text())
+        except (OSError, ValueError):
+            continue
+        if isinstance(summary, dict):
+            prefix = path.stem[len('summary_'):]
+            values.update({f"{prefix}.{k}": v for k, v in _flatten_summary(summary).items()})
+    return values
+
+
+def _write_comparison(workload: str, results_base: Path, runs: List[Dict], since: float) -> Optional[Path]:
+    """Write and print the combined comparison of the clusters' run summaries."""
+    per_cluster = {run['name']: _collect_summaries(results_base / 'clusters' / run['name'], since)
+                   for run in runs}
+    metrics = sorted({key for values in per_cluster.values() for key in values})
+    if not metrics:
+        console.print("[yellow]No run summaries found to compare (use --save-results)[/yellow]")
+        return None
+
+    out_dir = results_base / 'multi-cluster' / f"{datetime.now().strftime('%Y%m%d-%H%M%S')}_{workload}"
+    out_dir.mkdir(parents=True, exist_ok=True)
+    comparison = {
+        'workload': workload,
+        'clusters': [{'name': run['name'], 'context': run['context'], 'kubeconfig': run['kubeconfig'],
+                      'exit_code': run['returncode'], 'duration_sec': run['duration_sec'],
+                      'results': str(results_base / 'clusters' / run['name'])} for run in runs],
+        'metrics': {metric: {name: values.get(metric) for name, values in per_cluster.items()}
+                    for metric in metrics},
+    }
+    (out_dir / 'comparison.json').write_text(json.dumps(comparison, indent=4))
+    with open(out_dir / 'comparison.csv', 'w', newline='') as f:
+        writer = csv.writer(f)
+        writer.writerow(['metric'] + list(per_cluster))
+        for metric in metrics:
+            writer.writerow([metric] + [per_cluster[name].get(metric) for name in per_cluster])
+
+    table = Table(title=f"{workload}: cluster comparison")
+    table.add_column('Metric')
+    for name in per_cluster:
+        table.add_column(name, justify='right')
+    for metric in metrics:
+        table.add_row(metric, *['-' if per_cluster[name].get(metric) is None else str(per_cluster[name][metric])
+                                for name in per_cluster])
+    console.print(table)
+    console.print(f"[green]Comparison saved under:[/green] {out_dir}")
+    return out_dir
+
+
+def _stop(runs: List[Dict], interrupted: bool):
+    """
+    Stop the workload scripts still running and wait for them to clean up.
+
+    A Ctrl-C already reached them through the terminal; otherwise (SIGTERM)
+    they are sent SIGINT. A script still running after STOP_GRACE_SEC is killed.
+    """
+    running = [run['process'] for run in runs if run.get('process') and run['process'].poll() is None]
+    if not running:
+        return
+    if not interrupted:
+        for process in running:
+            process.send_signal(signal.SIGINT)
+    console.print(f"[yellow]Waiting up to {STOP_GRACE_SEC}s for {len(running)} workload run(s) "
+                  f"to clean up[/yellow]")
+    deadline = time.monotonic() + STOP_GRACE_SEC
+    for process in running:
+        try:
+            process.wait(timeout=max(0.0, deadline - time.monotonic()))
+        except subprocess.TimeoutExpired:
+            process.kill()
+            process.wait()
+
+
+def run_clusters(ctx, cmd: List[str], cwd) -> Tuple[subprocess.CompletedProcess, List[Dict]]:
+    """
+    Run a workload script once per cluster (or once, with a single cluster).
+
+    Args:
+        ctx: Click context of the command (ctx.obj.clusters, ctx.obj.parallel_clusters)
+        cmd: Script command line
+        cwd: Working directory (the repository root)
+
+    Returns:
+        (CompletedProcess, runs): the return code is the first non-zero return
+        code of the cluster runs, else 0; each run has the cmd, env,
+        returncode and duration_sec of a cluster's run
+    """
+    clusters = ctx.obj.clusters
+    if len(clusters) <= 1:
+        env = None
+        if clusters and clusters[0]['context']:
+            env = dict(os.environ, KUBECONFIG=_context_kubeconfig(clusters[0]))
+        run = dict(cmd=cmd, env=env, started=time.monotonic())
+        run['process'] = subprocess.Popen(cmd, cwd=cwd, env=env)
+        try:
+            run['returncode'] = run['process'].wait()
+        except BaseException as e:
+            _stop([run], isinstance(e, KeyboardInterrupt))
+            raise
+        run['duration_sec'] = time.monotonic() - run['started']
+        return subprocess.CompletedProcess(cmd, run['returncode']), [run]
+
+    workload = ctx.info_name
+    parallel = ctx.obj.parallel_clusters
+    console.print(f"[cyan]Running {workload} on {len(clusters)} clusters "
+                  f"({'in parallel' if parallel else 'sequentially'}):[/cyan] "
+                  f"{', '.join(c['name'] for c in clusters)}")
+
+    started = time.time()
+    runs = []
+    results_base = None
+    for cluster in clusters:
+        cluster_cmd, results_base = _cluster_command(cmd, cluster['name'])
+        env = dict(os.environ, VIRTBENCH_CLUSTER=cluster['name'])
+        if cluster['context']:
+            env['KUBECONFIG'] = _context_kubeconfig(cluster)
+        elif cluster['kubeconfig']:
+            env['KUBECONFIG'] = cluster['kubeconfig']
+        runs.append(dict(cluster, cmd=cluster_cmd, env=env, returncode=None, duration_sec=None))
+
+    try:
+        if parallel:
+            for run in runs:
+                run['started'] = time.monotonic()
+                run['process'] = subprocess.Popen(run['cmd'], cwd=cwd, env=run['env'], stdout=subprocess.PIPE,
+                                                  stderr=subprocess.STDOUT, text=True, bufsize=1)
+                run['reader'] = threading.Thread(target=_stream, args=(run['process'], run['name']), daemon=True)
+                run['reader'].start()
+            for run in runs:
+                run['returncode'] = run['process'].wait()
+                run['reader'].join()
+                run['duration_sec'] = round(time.monotonic() - run['started'], 2)
+        else:
+            for index, run in enumerate(runs, 1):
+                console.rule(f"Cluster {run['name']} ({index}/{len(runs)})")
+                run['started'] = time.monotonic()
+                run['process'] = subprocess.Popen(run['cmd'], cwd=cwd, env=run['env'])
+                run['returncode'] = run['process'].wait()
+                run['duration_sec'] = round(time.monotonic() - run['started'], 2)
+    except BaseException as e:
+        _stop(runs, isinstance(e, KeyboardInterrupt))
+        raise
+
+    table = Table(title=f"{workload}: cluster runs")
+    table.add_column('Cluster')
+    table.add_column('Exit code', justify='right')
+    table.add_column('Duration (s)', justify='right')
+    for run in runs:
+        style = 'green' if run['returncode'] == 0 else 'red'
+        table.add_row(run['name'], f"[{style}]{run['returncode']}[/{style}]", str(run['duration_sec']))
+    console.print(table)
+
+    if results_base is not None:
+        _write_comparison(workload, Path(cwd) / results_base, runs, started)
+
+    returncode = next((run['returncode'] for run in runs if run['returncode'] != 0), 0)
+    return subprocess.CompletedProcess(cmd, returncode), runs
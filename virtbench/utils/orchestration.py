@@ -0,0 +1,231 @@
+#!/usr/bin/env python3
+"""
+Run orchestration for virtbench workloads
+
+run_workload() wraps a workload script's run (on one or several clusters,
+see virtbench/utils/multicluster.py) in the steps every run shares:
+
+Before it runs, the permissions it needs are audited with
+SelfSubjectAccessReviews on every cluster (virtbench/utils/rbac.py); a
+denied permission stops the run with PERMISSION_DENIED_EXIT. No node
+matching --node-selector/--affinity-file stops it with
+NO_MATCHING_NODES_EXIT, and the --taint-nodes nodes are tainted
+(virtbench/utils/scheduling.py).
+
+The taints are removed when the run ends, however it ends: a SIGTERM
+(e.g. a Job or pod being deleted) is passed on to the workload scripts as
+SIGINT so they clean up, the taints are removed, and virtbench exits with
+128 + SIGTERM.
+
+After a failed run (or every run, with --collect-diagnostics always),
+utils/diagnostics.py writes a diagnostics bundle of the cluster to
+<results>/diagnostics/<timestamp>_<workload>.
+
+With --assert (or a plan's assert section), the summaries a workload wrote
+are checked against the declared thresholds (virtbench/utils/assertions.py).
+With --junit-report, a JUnit XML report of the run is written afterwards
+(virtbench/utils/junit.py). With --results-db, the results folder is
+imported into <results>/results.db (virtbench/utils/results_db.py).
+"""
+import os
+import signal
+import subprocess
+import sys
+import threading
+import time
+from contextlib import contextmanager
+from datetime import datetime
+from pathlib import Path
+from typing import Dict, List, Optional
+
+from rich.console import Console
+from rich.table import Table
+
+from virtbench.utils.exitcodes import ASSERTION_FAILED_EXIT, NO_MATCHING_NODES_EXIT, PERMISSION_DENIED_EXIT
+from virtbench.utils.junit import JUNIT_REPORT_ENV, write_report
+from virtbench.utils.multicluster import RESULTS_ARGS, run_clusters
+from virtbench.utils.rbac import audit_permissions
+from virtbench.utils.scheduling import check_node_constraints, taint_nodes, untaint_nodes
+
+console = Console()
+
+# Script arguments that name the benchmark namespaces, and the diagnostics.py argument each maps to
+# (a prefix, or one namespace matched exactly); workloads without one get no diagnostics bundle
+NAMESPACE_ARGS = {'--namespace-prefix': '--namespace-prefix', '--single-namespace': '--namespace',
+                  '--namespace': '--namespace'}
+
+DIAGNOSTICS_ENV = 'VIRTBENCH_DIAGNOSTICS'
+
+
+def _results_base(cmd: List[str]) -> str:
+    """The base results directory a script command line names (default: results)."""
+    return next((cmd[i + 1] for i, arg in enumerate(cmd[:-1]) if arg in RESULTS_ARGS), 'results')
+
+
+@contextmanager
+def _exit_on_sigterm():
+    """
+    Turn a SIGTERM into SystemExit(128 + SIGTERM) while the block runs, so
+    that finally blocks (removing the taints, stopping the workload scripts)
+    run instead of the process dying where it stands.
+
+    Signal handlers can only be set from the main thread; elsewhere the block
+    runs unchanged.
+    """
+    if threading.current_thread() is not threading.main_thread():
+        yield
+        return
+
+    def terminate(signum, frame):
+        console.print("[yellow]Received SIGTERM; stopping the run and cleaning up[/yellow]")
+        raise SystemExit(128 + signum)
+
+    previous = signal.signal(signal.SIGTERM, terminate)
+    try:
+        yield
+    finally:
+        signal.signal(signal.SIGTERM, previous)
+
+
+def _sync_results_db(cmd: List[str], cwd):
+    """Import the workload's results folder into its results.db (--results-db)."""
+    from virtbench.utils.results_db import sync_database
+
+    base_dir = Path(cwd) / _results_base(cmd)
+    if not base_dir.is_dir():
+        return
+    try:
+        imported, total = sync_database(base_dir)
+    except Exception as e:
+        console.print(f"[yellow]Warning: could not update the results database: {e}[/yellow]")
+        return
+    console.print(f"[dim]Results database: {imported} run(s) imported, {total} in {base_dir / 'results.db'}[/dim]")
+
+
+def _check_assertions(cmd: List[str], cwd, since: float, returncode: int) -> int:
+    """Check the run's summaries against --assert; returns the run's exit code with the verdict applied."""
+    from virtbench.utils.assertions import check_results, configured_assertions
+    from virtbench.utils.launch import results_since
+
+    try:
+        assertions = configured_assertions()
+    except ValueError as e:
+        console.print(f"[red]Assertions: {e}[/red]")
+        return returncode or ASSERTION_FAILED_EXIT
+    if not assertions:
+        return returncode
+    base_dir = Path(cwd) / _results_base(cmd)
+    verdicts = check_results(assertions, results_since(base_dir, since, exclude='multi-cluster'))
+    if not verdicts:
+        console.print("[red]Assertions: no run summary to check (use --save-results)[/red]")
+        return returncode or ASSERTION_FAILED_EXIT
+
+    table = Table(title="Assertions")
+    table.add_column('Summary')
+    table.add_column('Assertion')
+    table.add_column('Value', justify='right')
+    table.add_column('Result')
+    for path, verdict in verdicts.items():
+        for result in verdict['results']:
+            style = 'green' if result['status'] == 'pass' else 'red'
+            table.add_row(str(path.relative_to(base_dir)), result['expression'],
+                          '-' if result['value'] is None else str(result['value']),
+                          f"[{style}]{result['status'].upper()}[/{style}]")
+    console.print(table)
+    failed = sum(verdict['failed'] for verdict in verdicts.values())
+    if failed:
+        console.print(f"[red]Verdict: FAIL ({failed} assertion(s) failed)[/red]")
+        return returncode or ASSERTION_FAILED_EXIT
+    console.print("[green]Verdict: PASS[/green]")
+    return returncode
+
+
+def _write_junit_report(ctx, cmd: List[str], cwd, since: float, returncode: int):
+    """Write the JUnit XML report of a run (--junit-report)."""
+    from virtbench.utils.launch import results_since
+
+    summaries = results_since(Path(cwd) / _results_base(cmd), since, exclude='multi-cluster')
+    try:
+        path = write_report(Path(os.environ[JUNIT_REPORT_ENV]), ctx.info_name, returncode,
+                            time.time() - since, summaries)
+    except OSError as e:
+        console.print(f"[yellow]Warning: could not write the JUnit report: {e}[/yellow]")
+        return
+    console.print(f"[dim]JUnit report: {path}[/dim]")
+
+
+def _collect_diagnostics(ctx, cmd: List[str], cwd, returncode: int, duration_sec: float,
+                         env: Optional[Dict] = None):
+    """Write a diagnostics bundle of a run, as --collect-diagnostics asks (utils/diagnostics.py)."""
+    mode = os.environ.get(DIAGNOSTICS_ENV, 'on-failure')
+    # An interrupted run is not a failure
+    if ctx.obj.dry_run or mode == 'never' or (mode == 'on-failure' and returncode in (0, 130)):
+        return
+    namespaces = [(NAMESPACE_ARGS[arg], cmd[i + 1]) for i, arg in enumerate(cmd[:-1]) if arg in NAMESPACE_ARGS]
+    if not namespaces:
+        return
+    out_dir = (Path(cwd) / _results_base(cmd) / 'diagnostics'
+               / f"{datetime.now().strftime('%Y%m%d-%H%M%S')}_{ctx.info_name}")
+    console.print(f"[yellow]Collecting diagnostics ({'run failed' if returncode else 'always'}) "
+                  f"to {out_dir}[/yellow]")
+    diagnostics_cmd = [sys.executable, str(Path(cwd) / 'utils' / 'diagnostics.py'), '--output', str(out_dir),
+                       *[value for pair in namespaces for value in pair], '--since', str(int(duration_sec) + 60),
+                       '--workload', ctx.info_name, '--reason', 'failure' if returncode else 'always',
+                       '--exit-code', str(returncode)]
+    try:
+        subprocess.run(diagnostics_cmd, cwd=cwd, env=env)
+    except OSError as e:
+        console.print(f"[yellow]Warning: could not collect diagnostics: {e}[/yellow]")
+
+
+def run_workload(ctx, cmd: List[str], cwd) -> subprocess.CompletedProcess:
+    """
+    Run a workload script, once per cluster when several clusters were given.
+
+    The permissions the workload needs are audited first; when one is denied,
+    nothing runs and the return code is PERMISSION_DENIED_EXIT. Likewise when
+    no node satisfies --node-selector/--affinity-file (NO_MATCHING_NODES_EXIT).
+    The --taint-nodes nodes are tainted for the run and untainted when it
+    ends, also when it is interrupted or terminated. A diagnostics bundle is
+    collected as --collect-diagnostics asks. With --assert, the run's
+    summaries are checked and a failed assertion makes the return code
+    ASSERTION_FAILED_EXIT (if the run itself succeeded). With --junit-report,
+    the JUnit XML report is written, and with --results-db the results folder
+    is imported into results.db last.
+
+    Args:
+        ctx: Click context of the command (ctx.obj.clusters, ctx.obj.parallel_clusters)
+        cmd: Script command line
+        cwd: Working directory (the repository root)
+
+    Returns:
+        CompletedProcess; with several clusters the return code is the first
+        non-zero return code of the cluster runs, else 0
+
+    Raises:
+        SystemExit: 128 + SIGTERM, when virtbench is terminated during the run
+    """
+    if not audit_permissions(ctx, cmd):
+        return subprocess.CompletedProcess(cmd, PERMISSION_DENIED_EXIT)
+    if not check_node_constraints(ctx):
+        return subprocess.CompletedProcess(cmd, NO_MATCHING_NODES_EXIT)
+    tainted = []
+    with _exit_on_sigterm():
+        try:
+            if not taint_nodes(ctx, tainted):
+                return subprocess.CompletedProcess(cmd, NO_MATCHING_NODES_EXIT)
+            started = time.time()
+            result, runs = run_clusters(ctx, cmd, cwd)
+        finally:
+            untaint_nodes(tainted)
+    for run in runs:
+        _collect_diagnostics(ctx, run['cmd'], cwd, run['returncode'], run['duration_sec'], run['env'])
+    if not ctx.obj.dry_run:
+        returncode = _check_assertions(cmd, cwd, started, result.returncode)
+        if returncode != result.returncode:
+            result = subprocess.CompletedProcess(result.args, returncode)
+        if os.environ.get(JUNIT_REPORT_ENV):
+            _write_junit_report(ctx, cmd, cwd, started, result.returncode)
+    if os.environ.get('VIRTBENCH_RESULTS_DB') and not ctx.obj.dry_run:
+        _sync_results_db(cmd, cwd)
+    return result
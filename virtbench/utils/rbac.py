@@ -0,0 +1,242 @@
+#!/usr/bin/env python3
+"""
+RBAC permission audit of planned workloads
+
+Before a workload runs, the permissions it needs (create VMs, create
+VirtualMachineInstanceMigrations, delete namespaces, exec into the SSH pod,
+...) are asked of the API server with SelfSubjectAccessReviews, once per
+cluster. A denied permission stops the run before anything is created,
+with every missing permission listed, instead of failing halfway through.
+
+The permissions come from the workload (WORKLOAD_PERMISSIONS) and from
+the script flags that add API calls (FLAG_PERMISSIONS, e.g. --cleanup).
+They are checked cluster-wide, as the workloads create their own
+namespaces; --skip-permission-check (VIRTBENCH_PERMISSION_CHECK=0) runs
+without the audit, e.g. for users granted access per namespace.
+"""
+import json
+import os
+import subprocess
+from typing import Dict, List, Optional, Tuple
+
+import yaml
+from rich.console import Console
+from rich.table import Table
+
+console = Console()
+
+PERMISSION_CHECK_ENV = 'VIRTBENCH_PERMISSION_CHECK'
+# Set by virtbench --tenants/--tenant-identity (see utils/tenants.py)
+TENANTS_ENV = 'VIRTBENCH_TENANTS'
+TENANT_IDENTITY_ENV = 'VIRTBENCH_TENANT_IDENTITY'
+# Set by virtbench --taint-nodes (see virtbench/utils/scheduling.py)
+TAINT_NODES_ENV = 'VIRTBENCH_TAINT_NODES'
+
+# A permission: (verb, API group, resource, subresource)
+Permission = Tuple[str, str, str, Optional[str]]
+
+NAMESPACES: List[Permission] = [('create', '', 'namespaces', None)]
+CREATE_VMS: List[Permission] = [
+    ('create', 'kubevirt.io', 'virtualmachines', None),
+    ('create', 'cdi.kubevirt.io', 'datavolumes', None),
+    ('list', 'kubevirt.io', 'virtualmachineinstances', None),
+]
+READ_VMS: List[Permission] = [
+    ('list', 'kubevirt.io', 'virtualmachines', None),
+    ('list', 'kubevirt.io', 'virtualmachineinstances', None),
+]
+CLEANUP: List[Permission] = [
+    ('delete', '', 'namespaces', None),
+    ('delete', 'kubevirt.io', 'virtualmachines', None),
+]
+MIGRATIONS: List[Permission] = [('create', 'kubevirt.io', 'virtualmachineinstancemigrations', None)]
+POWER: List[Permission] = [
+    ('update', 'subresources.kubevirt.io', 'virtualmachines', 'start'),
+    ('update', 'subresources.kubevirt.io', 'virtualmachines', 'stop'),
+    ('update', 'subresources.kubevirt.io', 'virtualmachines', 'restart'),
+]
+PAUSE: List[Permission] = [
+    ('update', 'subresources.kubevirt.io', 'virtualmachineinstances', 'pause'),
+    ('update', 'subresources.kubevirt.io', 'virtualmachineinstances', 'unpause'),
+]
+HOTPLUG: List[Permission] = [
+    ('update', 'subresources.kubevirt.io', 'virtualmachines', 'addvolume'),
+    ('update', 'subresources.kubevirt.io', 'virtualmachines', 'removevolume'),
+]
+DRAIN: List[Permission] = [
+    ('patch', '', 'nodes', None),
+    ('create', '', 'pods', 'eviction'),
+]
+EXEC: List[Permission] = [('create', '', 'pods', 'exec')]
+RESIZE: List[Permission] = [('patch', '', 'persistentvolumeclaims', None)]
+SNAPSHOTS: List[Permission] = [('create', 'snapshot.kubevirt.io', 'virtualmachinesnapshots', None)]
+CLONES: List[Permission] = [('create', 'clone.kubevirt.io', 'virtualmachineclones', None)]
+SERVICES: List[Permission] = [
+    ('create', '', 'services', None),
+    ('list', 'discovery.k8s.io', 'endpointslices', None),
+    ('create', '', 'pods', None),
+] + EXEC
+
+# Permissions of each workload command (ctx.info_name); commands not listed are not audited
+WORKLOAD_PERMISSIONS: Dict[str, List[Permission]] = {
+    'datasource-clone': NAMESPACES + CREATE_VMS,
+    'migration': READ_VMS + MIGRATIONS,
+    'chaos-benchmark': NAMESPACES + CREATE_VMS + CLEANUP + POWER + RESIZE,
+    'failure-recovery': READ_VMS + DRAIN + CLEANUP,
+    'fio': NAMESPACES + CREATE_VMS,
+    'elbencho': READ_VMS,
+    'disk-ops': NAMESPACES + CREATE_VMS + POWER + HOTPLUG,
+    'volume-hotplug': NAMESPACES + CREATE_VMS + HOTPLUG,
+    'volume-resize': NAMESPACES + CREATE_VMS + RESIZE,
+    'service-exposure': READ_VMS + SERVICES,
+    'vm-clone': NAMESPACES + CREATE_VMS + CLONES,
+    'vm-lifecycle': NAMESPACES + CREATE_VMS + POWER + PAUSE + MIGRATIONS,
+    'lifecycle': READ_VMS + POWER + PAUSE,
+    'node-drain': READ_VMS + DRAIN + MIGRATIONS,
+    'soak': NAMESPACES + CREATE_VMS + POWER + MIGRATIONS,
+    'random-workload': NAMESPACES + CREATE_VMS + CLEANUP + POWER + MIGRATIONS,
+    'descheduler': READ_VMS + DRAIN + MIGRATIONS,
+    'drain-nodes': READ_VMS + DRAIN,
+    'rebalance-vms': READ_VMS + MIGRATIONS,
+    'vm-snapshot': READ_VMS + SNAPSHOTS,
+    'run-blkdiscard': READ_VMS + EXEC,
+    'power-toggle-vms': READ_VMS + POWER,
+}
+
+# Permissions added by script flags of any workload
+FLAG_PERMISSIONS: Dict[str, List[Permission]] = {
+    '--cleanup': CLEANUP,
+    '--cleanup-on-failure': CLEANUP,
+    '--cleanup-only': CLEANUP,
+    '--cleanup-vms': CLEANUP,
+    '--create-vms': NAMESPACES + CREATE_VMS,
+    '--ssh-pod': EXEC,
+    '--migrate': MIGRATIONS,
+}
+
+
+def tenant_permissions() -> List[Permission]:
+    """Permissions of virtbench --tenants: binding the tenants' roles and impersonating them."""
+    if not os.environ.get(TENANTS_ENV):
+        return []
+    identity = 'serviceaccounts' if os.environ.get(TENANT_IDENTITY_ENV) == 'serviceaccount' else 'users'
+    return [
+        ('impersonate', '', identity, None),
+        ('impersonate', '', 'groups', None),
+        ('create', 'rbac.authorization.k8s.io', 'rolebindings', None),
+        ('create', 'rbac.authorization.k8s.io', 'clusterroles', None),
+        ('create', 'rbac.authorization.k8s.io', 'clusterrolebindings', None),
+    ]
+
+
+def taint_permissions() -> List[Permission]:
+    """Permissions of virtbench --taint-nodes: listing and tainting the nodes."""
+    if not os.environ.get(TAINT_NODES_ENV):
+        return []
+    return [('list', '', 'nodes', None), ('patch', '', 'nodes', None)]
+
+
+def permission_name(permission: Permission) -> str:
+    """kubectl auth can-i style name of a permission, e.g. create virtualmachines.kubevirt.io."""
+    verb, group, resource, subresource = permission
+    return f"{verb} {resource}{'/' + subresource if subresource else ''}" + (f".{group}" if group else '')
+
+
+def required_permissions(workload: str, cmd: List[str]) -> List[Permission]:
+    """Permissions a workload run needs, from the workload and its script flags, without repeats."""
+    permissions = list(WORKLOAD_PERMISSIONS.get(workload, []))
+    if not permissions:
+        return []
+    for flag, extra in FLAG_PERMISSIONS.items():
+        if flag in cmd:
+            permissions += extra
+    permissions += tenant_permissions() + taint_permissions()
+    return list(dict.fromkeys(permissions))
+
+
+def access_review(kubectl: List[str], permissions: List[Permission]) -> List[bool]:
+    """
+    Whether the current user holds each permission, cluster-wide, from one
+    SelfSubjectAccessReview per permission (created in a single kubectl call).
+
+    Args:
+        kubectl: kubectl command line, with --kubeconfig/--context as needed
+
+    Raises:
+        RuntimeError: If the reviews could not be created
+    """
+    reviews = []
+    for verb, group, resource, subresource in permissions:
+        attributes = {'verb': verb, 'group': group, 'resource': resource}
+        if subresource:
+            attributes['subresource'] = subresource
+        reviews.append({'apiVersion': 'authorization.k8s.io/v1', 'kind': 'SelfSubjectAccessReview',
+                        'spec': {'resourceAttributes': attributes}})
+    try:
+        result = subprocess.run(kubectl + ['create', '-f', '-', '-o', 'json'],
+                                input=yaml.safe_dump_all(reviews, sort_keys=False),
+                                capture_output=True, text=True, timeout=30)
+    except (OSError, subprocess.TimeoutExpired) as e:
+        raise RuntimeError(str(e))
+    try:
+        document = json.loads(result.stdout)
+    except ValueError:
+        document = {}
+    # A single review comes back as itself, several as a List
+    items = document.get('items', [document]) if document.get('kind') == 'List' or len(reviews) == 1 else []
+    if result.returncode != 0 or len(items) != len(reviews):
+        raise RuntimeError(result.stderr.strip() or 'no SelfSubjectAccessReview answer')
+    return [bool((item.get('status') or {}).get('allowed')) for item in items]
+
+
+def cluster_kubectl(cluster: Optional[Dict]) -> List[str]:
+    """kubectl command line selecting the cluster's kubeconfig and context."""
+    cmd = ['kubectl']
+    if cluster and cluster.get('kubeconfig'):
+        cmd += ['--kubeconfig', str(cluster['kubeconfig'])]
+    if cluster and cluster.get('context'):
+        cmd += ['--context', cluster['context']]
+    return cmd
+
+
+def audit_permissions(ctx, cmd: List[str]) -> bool:
+    """
+    Check the permissions of a planned workload run on every cluster.
+
+    Returns:
+        False when a permission is denied (the missing ones are printed), else
+        True; an audit that cannot ask the API server only warns
+    """
+    if ctx.obj.dry_run or os.environ.get(PERMISSION_CHECK_ENV) == '0':
+        return True
+    permissions = required_permissions(ctx.info_name, cmd)
+    if not permissions:
+        return True
+
+    missing = []
+    for cluster in ctx.obj.clusters or [None]:
+        try:
+            allowed = access_review(cluster_kubectl(cluster), permissions)
+        except RuntimeError as e:
+            where = f" on {cluster['name']}" if cluster else ''
+            console.print(f"[yellow]Warning: could not audit permissions{where}: {e}[/yellow]")
+            continue
+        missing += [(cluster['name'] if cluster else None, permission)
+                    for permission, ok in zip(permissions, allowed) if not ok]
+    if not missing:
+        return True
+
+    table = Table(title=f"{ctx.info_name}: missing permissions")
+    if ctx.obj.clusters:
+        table.add_column('Cluster')
+    table.add_column('Verb')
+    table.add_column('Resource')
+    table.add_column('API group')
+    for cluster, (verb, group, resource, subresource) in missing:
+        row = [verb, resource + (f"/{subresource}" if subresource else ''), group or '(core)']
+        table.add_row(*([cluster] if ctx.obj.clusters else []) + row)
+    console.print(table)
+    console.print(f"[red]Error: {ctx.info_name} needs {len(missing)} permission(s) the current user does not "
+                  f"have; nothing was run. Grant them (e.g. a ClusterRole bound to the user or "
+                  f"ServiceAccount), or pass --skip-permission-check to run anyway.[/red]")
+    return False
@@ -0,0 +1,245 @@
+#!/usr/bin/env python3
+"""
+Catalog of the runs in a results folder, and pruning of old runs
+
+Every directory under the results folder that holds a summary_*.json file
+is a run, as in the results viewer (dashboard/serve_results.py). The
+catalog is written to <results>/index.json:
+
+    {"generated": "...", "runs": [{"id": "px/1-disk/20250101-120000_vm_clone_20vms",
+                                  "uuid": "...", "workloads": ["vm-clone"], "timestamp": "...",
+                                  "cluster": "...", "status": "passed", "metrics": {...},
+                                  "size_bytes": 123456, "files": 4}, ...]}
+
+so tools can list runs without walking thousands of directories. UUID and
+cluster come from the "run" block that summaries carry (utils/common.py
+run_metadata); older results have none.
+"""
+import json
+import re
+import shutil
+from datetime import datetime, timedelta
+from pathlib import Path
+from typing import Dict, Iterable, List, Optional
+
+INDEX_FILE = 'index.json'
+
+# summary file stem -> workload (as in dashboard/serve_results.py)
+WORKLOAD_MAP = {
+    'summary_vm_creation_results': 'datasource-clone',
+    'summary_boot_storm_results': 'boot-storm',
+    'summary_migration_results': 'migration',
+    'summary_failure_recovery_results': 'failure-recovery',
+    'summary_volume_hotplug_results': 'volume-hotplug',
+    'summary_volume_resize_results': 'volume-resize',
+    'summary_service_exposure_results': 'service-exposure',
+    'summary_vm_clone_results': 'vm-clone',
+    'summary_vm_lifecycle_results': 'vm-lifecycle',
+    'summary_node_drain_results': 'node-drain',
+    'summary_descheduler_results': 'descheduler',
+    'summary_soak_results': 'soak',
+    'summary_random_workload_results': 'random-workload',
+}
+
+TIMESTAMP_RE = re.compile(r'^(\d{8}-\d{6})_')
+DURATION_RE = re.compile(r'^(\d+(?:\.\d+)?)([mhdw])$')
+DURATION_UNITS = {'m': 'minutes', 'h': 'hours', 'd': 'days', 'w': 'weeks'}
+
+
+def parse_age(text: str) -> timedelta:
+    """
+    Parse an age such as 90m, 12h, 30d or 2w.
+
+    Raises:
+        ValueError: If the text is not a number followed by m, h, d or w
+    """
+    match = DURATION_RE.match(text.strip().lower())
+    if not match:
+        raise ValueError(f"invalid age '{text}' (expected e.g. 12h, 30d or 2w)")
+    return timedelta(**{DURATION_UNITS[match.group(2)]: float(match.group(1))})
+
+
+def _run_timestamp(run_dir: Path, summaries: List[Path]) -> datetime:
+    """Start time from the run directory name (YYYYMMDD-HHMMSS_...), else the newest summary's mtime."""
+    match = TIMESTAMP_RE.match(run_dir.name)
+    if match:
+        try:
+            return datetime.strptime(match.group(1), '%Y%m%d-%H%M%S')
+        except ValueError:
+            pass
+    return datetime.fromtimestamp(max(p.stat().st_mtime for p in summaries))
+
+
+def _load_summary(path: Path) -> Dict:
+    try:
+        data = json.loads(path.read_text())
+    except (OSError, ValueError):
+        return {}
+    return data if isinstance(data, dict) else {}
+
+
+def run_status(summaries: List[Dict]) -> str:
+    """passed, partial (some items failed), failed (none succeeded) or unknown, from the summary counts."""
+    counted = [s for s in summaries if isinstance(s.get('failed'), int)]
+    if not counted:
+        return 'unknown'
+    failed = sum(s['failed'] for s in counted)
+    successful = sum(s.get('successful') or 0 for s in counted)
+    if not failed:
+        return 'passed'
+    return 'partial' if successful else 'failed'
+
+
+def key_metrics(summaries: List[Dict]) -> Dict:
+    """Averages of the summary metrics, e.g. {'running_time_sec': 9.2, 'ping_time_sec': 12.4}."""
+    metrics = {}
+    for summary in summaries:
+        for metric in summary.get('metrics') or []:
+            if isinstance(metric, dict) and metric.get('metric') and metric.get('avg') is not None:
+                metrics.setdefault(metric['metric'], metric['avg'])
+    return metrics
+
+
+def _cluster_name(run_info: Dict) -> Optional[str]:
+    """Cluster of a run: its multi-cluster name, else the API server host."""
+    if run_info.get('cluster'):
+        return run_info['cluster']
+    server = run_info.get('api_server') or ''
+    return re.sub(r'^https?://', '', server).split(':')[0] or None
+
+
+def _run_dirs(root: Path) -> Dict[Path, List[Path]]:
+    """Directories at or below root that hold summary_*.json files, with those files."""
+    by_dir: Dict[Path, List[Path]] = {}
+    for summary in root.rglob('summary_*.json'):
+        by_dir.setdefault(summary.parent, []).append(summary)
+    return by_dir
+
+
+def _own_files(run_dir: Path, run_dirs: Iterable[Path]) -> List[Path]:
+    """Files of a run directory, leaving out those of runs nested below it."""
+    run_dirs = set(run_dirs) | {run_dir}
+    return [p for p in run_dir.rglob('*')
+            if p.is_file() and next(parent for parent in p.parents if parent in run_dirs) == run_dir]
+
+
+def scan_runs(base_dir: Path) -> List[Dict]:
+    """
+    Every run below base_dir, newest first.
+
+    Summaries directly in base_dir are not a run. A run nested in another
+    run's directory is a run of its own, and its files are not counted in
+    the outer run. A run whose files vanish while it is scanned is left out.
+    """
+    by_dir = _run_dirs(base_dir)
+    by_dir.pop(base_dir, None)
+
+    runs = []
+    for run_dir, summary_paths in by_dir.items():
+        try:
+            files = _own_files(run_dir, by_dir)
+            summaries = [_load_summary(p) for p in sorted(summary_paths)]
+            run_info = next((s['run'] for s in summaries if isinstance(s.get('run'), dict)), {})
+            runs.append({
+                'id': run_dir.relative_to(base_dir).as_posix(),
+                'uuid': run_info.get('uuid'),
+                'workloads': sorted({WORKLOAD_MAP.get(p.stem, p.stem[len('summary_'):]) for p in summary_paths}),
+                # Leading path segments are labels such as the storage driver and disk layout
+                'labels': list(run_dir.relative_to(base_dir).parts[:-1]),
+                'timestamp': _run_timestamp(run_dir, summary_paths).isoformat(timespec='seconds'),
+                'cluster': _cluster_name(run_info),
+                'status': run_status(summaries),
+                'metrics': key_metrics(summaries),
+                'size_bytes': sum(p.stat().st_size for p in files),
+                'files': len(files),
+            })
+        except OSError:
+            continue
+    return sorted(runs, key=lambda r: (r['timestamp'], r['id']), reverse=True)
+
+
+def find_run(runs: List[Dict], key: str) -> List[Dict]:
+    """Runs matching a run UUID (or a unique prefix of it) or a run id (path below the results folder)."""
+    exact = [run for run in runs if key in (run['uuid'], run['id'])]
+    if exact:
+        return exact
+    return [run for run in runs if (run['uuid'] or '').startswith(key) or run['id'].endswith('/' + key)]
+
+
+def write_index(base_dir: Path, runs: Optional[List[Dict]] = None) -> Path:
+    """Write <base_dir>/index.json; scans the folder unless runs are given."""
+    runs = scan_runs(base_dir) if runs is None else runs
+    path = base_dir / INDEX_FILE
+    path.write_text(json.dumps({'generated': datetime.now().isoformat(timespec='seconds'), 'runs': runs},
+                               indent=2))
+    return path
+
+
+def select_prunable(runs: List[Dict], keep_last: Optional[int] = None, older_than: Optional[timedelta] = None,
+                    workloads: Iterable[str] = ()) -> List[Dict]:
+    """
+    Runs to delete.
+
+    Only runs of the given workloads are considered (all runs without a
+    filter). A run is deleted if it is outside the newest keep_last runs of
+    each of its workloads and older than older_than; a criterion that is
+    not given does not protect any run.
+    """
+    workloads = set(workloads)
+    cutoff = datetime.now() - older_than if older_than is not None else None
+    seen: Dict[str, int] = {}
+    prunable = []
+    for run in runs:  # newest first
+        if workloads and not workloads & set(run['workloads']):
+            continue
+        recent = False
+        for workload in run['workloads']:
+            seen[workload] = seen.get(workload, 0) + 1
+            if keep_last is not None and seen[workload] <= keep_last:
+                recent = True
+        if recent:
+            continue
+        if cutoff is not None and datetime.fromisoformat(run['timestamp']) >= cutoff:
+            continue
+        prunable.append(run)
+    return prunable
+
+
+def delete_run(base_dir: Path, run: Dict) -> bool:
+    """
+    Delete a run directory, and parent directories left empty (up to base_dir).
+
+    Runs nested in the run directory are kept: only the run's own files and
+    the directories they leave empty are deleted then.
+
+    Returns:
+        False if the run directory no longer exists, else True
+
+    Raises:
+        ValueError: If the run directory is not below base_dir
+        OSError: If a file cannot be deleted
+    """
+    base = base_dir.resolve()
+    run_dir = (base_dir / run['id']).resolve()
+    # Never delete outside the results folder
+    if base not in run_dir.parents:
+        raise ValueError(f"run directory outside {base_dir}: {run['id']}")
+    if not run_dir.is_dir():
+        return False
+    nested = set(_run_dirs(run_dir)) - {run_dir}
+    if not nested:
+        shutil.rmtree(run_dir)
+    else:
+        for path in _own_files(run_dir, nested):
+            path.unlink()
+        for path in sorted((p for p in run_dir.rglob('*') if p.is_dir()), key=lambda p: len(p.parts), reverse=True):
+            if not any(path.iterdir()):
+                path.rmdir()
+        if any(run_dir.iterdir()):
+            return True
+        run_dir.rmdir()
+    parent = run_dir.parent
+    while parent != base and not any(parent.iterdir()):
+        parent.rmdir()
+        parent = parent.parent
+    return True
@@ -0,0 +1,200 @@
+#!/usr/bin/env python3
+"""
+SQLite database of run results for virtbench
+
+Imports the runs of a results folder (see results_catalog.py) into an
+embedded SQLite database, <results>/results.db by default, so trends across
+hundreds of runs can be queried with SQL and no other infrastructure:
+
+    runs        one row per run: id (run directory), uuid, workloads, timestamp, cluster, status
+    summaries   one row per summary_*.json: counts, duration and the whole summary as JSON
+    metrics     one row per summary metric: avg, min, max, median, p95, p99, stddev, count
+    vm_results  one row per per-VM record (JSON list files such as vm_clone_results.json)
+
+The import is incremental: a run is re-imported only when its files change,
+and the rows of runs no longer in the results folder (e.g. pruned) are deleted.
+Whole documents are stored as JSON text for json_extract() queries.
+"""
+import json
+import sqlite3
+from datetime import datetime
+from pathlib import Path
+from typing import Dict, Iterable, List, Optional, Tuple
+
+from virtbench.utils.results_catalog import WORKLOAD_MAP, scan_runs
+
+DB_FILE = 'results.db'
+
+SCHEMA = """
+CREATE TABLE IF NOT EXISTS runs (
+    id TEXT PRIMARY KEY,
+    uuid TEXT,
+    workloads TEXT,
+    timestamp TEXT,
+    cluster TEXT,
+    status TEXT,
+    labels TEXT,
+    signature TEXT,
+    imported_at TEXT
+);
+CREATE INDEX IF NOT EXISTS runs_uuid ON runs (uuid);
+CREATE INDEX IF NOT EXISTS runs_timestamp ON runs (timestamp);
+CREATE TABLE IF NOT EXISTS summaries (
+    run_id TEXT REFERENCES runs (id) ON DELETE CASCADE,
+    file TEXT,
+    workload TEXT,
+    total_vms INTEGER,
+    successful INTEGER,
+    failed INTEGER,
+    duration_sec REAL,
+    data TEXT,
+    PRIMARY KEY (run_id, file)
+);
+CREATE TABLE IF NOT EXISTS metrics (
+    run_id TEXT REFERENCES runs (id) ON DELETE CASCADE,
+    workload TEXT,
+    metric TEXT,
+    avg REAL,
+    min REAL,
+    max REAL,
+    median REAL,
+    p95 REAL,
+    p99 REAL,
+    stddev REAL,
+    count INTEGER
+);
+CREATE INDEX IF NOT EXISTS metrics_metric ON metrics (workload, metric);
+CREATE TABLE IF NOT EXISTS vm_results (
+    run_id TEXT REFERENCES runs (id) ON DELETE CASCADE,
+    file TEXT,
+    namespace TEXT,
+    vm_name TEXT,
+    status TEXT,
+    data TEXT
+);
+CREATE INDEX IF NOT EXISTS vm_results_run ON vm_results (run_id);
+"""
+
+
+def default_db_path(base_dir: Path) -> Path:
+    return base_dir / DB_FILE
+
+
+def connect(db_path: Path) -> sqlite3.Connection:
+    """Open (and create) the database."""
+    conn = sqlite3.connect(str(db_path))
+    conn.execute('PRAGMA foreign_keys = ON')
+    conn.executescript(SCHEMA)
+    return conn
+
+
+def _signature(run_dir: Path) -> str:
+    """Changes when a file of the run is added, removed or rewritten."""
+    stats = sorted((p.name, p.stat().st_size, int(p.stat().st_mtime)) for p in run_dir.glob('*.json'))
+    return json.dumps(stats)
+
+
+def _load(path: Path):
+    try:
+        return json.loads(path.read_text())
+    except (OSError, ValueError):
+        return None
+
+
+def _number(value) -> Optional[float]:
+    return value if isinstance(value, (int, float)) and not isinstance(value, bool) else None
+
+
+def _import_run(conn: sqlite3.Connection, run_dir: Path, run: Dict, signature: str):
+    conn.execute('DELETE FROM runs WHERE id = ?', (run['id'],))
+    conn.execute('INSERT INTO runs VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)',
+                 (run['id'], run['uuid'], ','.join(run['workloads']), run['timestamp'], run['cluster'],
+                  run['status'], '/'.join(run['labels']), signature, datetime.now().isoformat(timespec='seconds')))
+    for path in sorted(run_dir.glob('*.json')):
+        data = _load(path)
+        if path.stem.startswith('summary_') and isinstance(data, dict):
+            workload = WORKLOAD_MAP.get(path.stem, path.stem[len('summary_'):])
+            conn.execute('INSERT INTO summaries VALUES (?, ?, ?, ?, ?, ?, ?, ?)',
+                         (run['id'], path.name, workload, _number(data.get('total_vms')),
+                          _number(data.get('successful')), _number(data.get('failed')),
+                          _number(data.get('total_test_duration_sec') or data.get('total_duration_sec')),
+                          json.dumps(data)))
+            for metric in data.get('metrics') or []:
+                if isinstance(metric, dict) and metric.get('metric'):
+                    conn.execute('INSERT INTO metrics VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)',
+                                 (run['id'], workload, metric['metric'],
+                                  *[_number(metric.get(k)) for k in ('avg', 'min', 'max', 'median', 'p95', 'p99',
+                                                                     'stddev', 'count')]))
+        elif isinstance(data, list):
+            for item in data:
+                if isinstance(item, dict):
+                    vm_name = item.get('vm_name') or item.get('clone') or item.get('vm') or item.get('name')
+                    conn.execute('INSERT INTO vm_results VALUES (?, ?, ?, ?, ?, ?)',
+                                 (run['id'], path.name, item.get('namespace'), vm_name, item.get('status'),
+                                  json.dumps(item)))
+
+
+def sync_database(base_dir: Path, db_path: Optional[Path] = None) -> Tuple[int, int]:
+    """
+    Import new and changed runs of base_dir into the database, and delete
+    the runs that are no longer in base_dir.
+
+    Returns:
+        (runs imported, runs in the results folder)
+    """
+    db_path = db_path or default_db_path(base_dir)
+    runs = scan_runs(base_dir)
+    conn = connect(db_path)
+    try:
+        known = dict(conn.execute('SELECT id, signature FROM runs'))
+        imported = 0
+        for run in runs:
+            run_dir = base_dir / run['id']
+            signature = _signature(run_dir)
+            if known.get(run['id']) == signature:
+                continue
+            with conn:
+                _import_run(conn, run_dir, run, signature)
+            imported += 1
+        current = {run['id'] for run in runs}
+        with conn:
+            for run_id in set(known) - current:
+                conn.execute('DELETE FROM runs WHERE id = ?', (run_id,))
+    finally:
+        conn.close()
+    return imported, len(runs)
+
+
+def delete_runs(db_path: Path, run_ids: Iterable[str]) -> int:
+    """
+    Delete runs (and their summaries, metrics and per-VM rows) from the database.
+
+    Returns:
+        Number of runs deleted
+    """
+    run_ids = list(run_ids)
+    conn = connect(db_path)
+    try:
+        with conn:
+            return sum(conn.execute('DELETE FROM runs WHERE id = ?', (run_id,)).rowcount for run_id in run_ids)
+    finally:
+        conn.close()
+
+
+def query(db_path: Path, sql: str, params: Tuple = ()) -> Tuple[List[str], List[tuple]]:
+    """
+    Run a read-only query.
+
+    Returns:
+        (column names, rows)
+
+    Raises:
+        sqlite3.Error: For an invalid query, or one that tries to write
+    """
+    conn = sqlite3.connect(f"file:{db_path}?mode=ro", uri=True)
+    try:
+        cursor = conn.execute(sql, params)
+        columns = [d[0] for d in cursor.description or []]
+        return columns, cursor.fetchall()
+    finally:
+        conn.close()
@@ -0,0 +1,237 @@
+#!/usr/bin/env python3
+"""
+Scheduled and recurring benchmark runs for virtbench
+
+`virtbench run --schedule CRON` (or a plan file with a schedule) repeats a
+workload on a cron cadence, each run with its own UUID, and keeps the
+results of the last runs only:
+
+    <results>/schedules/<name>/history.json
+
+records every run of a schedule (UUID, start, exit code and the result
+directories it wrote); retention deletes the result directories of the
+runs that fall outside --keep-runs / --keep-days.
+
+Cron expressions have the five standard fields (minute hour day-of-month
+month day-of-week) with *, lists, ranges and steps, or one of @hourly,
+@daily, @weekly and @monthly. They are evaluated in local time.
+"""
+import json
+import shutil
+import signal
+import subprocess
+import time
+from datetime import datetime, timedelta
+from pathlib import Path
+from typing import Dict, List, Optional, Set
+from uuid import uuid4
+
+from rich.console import Console
+
+from virtbench.utils.launch import results_since
+
+console = Console()
+
+MACROS = {
+    '@hourly': '0 * * * *',
+    '@daily': '0 0 * * *',
+    '@midnight': '0 0 * * *',
+    '@weekly': '0 0 * * 0',
+    '@monthly': '0 0 1 * *',
+}
+
+# (name, minimum, maximum) of the five cron fields
+FIELDS = (('minute', 0, 59), ('hour', 0, 23), ('day of month', 1, 31), ('month', 1, 12), ('day of week', 0, 6))
+
+MONTH_NAMES = ['jan', 'feb', 'mar', 'apr', 'may', 'jun', 'jul', 'aug', 'sep', 'oct', 'nov', 'dec']
+DAY_NAMES = ['sun', 'mon', 'tue', 'wed', 'thu', 'fri', 'sat']
+
+# Longest search for the next matching minute (a leap day every four years)
+MAX_LOOKAHEAD_DAYS = 4 * 366
+
+
+def _parse_value(value: str, field: int) -> int:
+    names = MONTH_NAMES if field == 3 else DAY_NAMES if field == 4 else None
+    if names and value.lower() in names:
+        return names.index(value.lower()) + (1 if field == 3 else 0)
+    return int(value)
+
+
+def _parse_field(text: str, field: int) -> Set[int]:
+    name, low, high = FIELDS[field]
+    values = set()
+    for part in text.split(','):
+        step = 1
+        if '/' in part:
+            part, step_text = part.split('/', 1)
+            step = int(step_text)
+            if step < 1:
+                raise ValueError(f"invalid step in {name} field: {text}")
+        if part == '*':
+            start, end = low, high
+        elif '-' in part:
+            start, end = (_parse_value(v, field) for v in part.split('-', 1))
+        else:
+            start = _parse_value(part, field)
+            end = high if step > 1 else start
+        top = high + 1 if field == 4 else high
+        if not (low <= start <= top and low <= end <= top) or start > end:
+            raise ValueError(f"{name} field out of range ({low}-{high}): {text}")
+        # Sunday may be written as 0 or 7
+        values.update(v % 7 if field == 4 else v for v in range(start, end + 1, step))
+    return values
+
+
+class CronSchedule:
+    """A parsed cron expression."""
+
+    def __init__(self, expression: str):
+        """
+        Raises:
+            ValueError: If the expression is not a valid cron expression
+        """
+        self.expression = expression.strip()
+        fields = MACROS.get(self.expression.lower(), self.expression).split()
+        if len(fields) != 5:
+            raise ValueError(f"expected 5 fields (minute hour day-of-month month day-of-week) "
+                             f"or a macro such as @daily, got '{expression}'")
+        try:
+            self.minutes, self.hours, self.days, self.months, self.weekdays = (
+                _parse_field(text, i) for i, text in enumerate(fields))
+        except ValueError as e:
+            raise ValueError(str(e) if 'field' in str(e) else f"invalid cron expression '{expression}': {e}")
+        # As in cron, a restricted day of month and day of week match either
+        self.any_day = fields[2] == '*'
+        self.any_weekday = fields[4] == '*'
+
+    def _day_matches(self, moment: datetime) -> bool:
+        day = moment.day in self.days
+        weekday = (moment.isoweekday() % 7) in self.weekdays
+        if self.any_day or self.any_weekday:
+            return day and weekday
+        return day or weekday
+
+    def next_after(self, moment: datetime) -> datetime:
+        """First matching minute strictly after the given time."""
+        candidate = moment.replace(second=0, microsecond=0) + timedelta(minutes=1)
+        limit = candidate + timedelta(days=MAX_LOOKAHEAD_DAYS)
+        while candidate < limit:
+            if candidate.month not in self.months or not self._day_matches(candidate):
+                candidate = (candidate + timedelta(days=1)).replace(hour=0, minute=0)
+            elif candidate.hour not in self.hours:
+                candidate = (candidate + timedelta(hours=1)).replace(minute=0)
+            elif candidate.minute not in self.minutes:
+                candidate += timedelta(minutes=1)
+            else:
+                return candidate
+        raise ValueError(f"cron expression '{self.expression}' never matches")
+
+
+class ScheduleHistory:
+    """Runs of one schedule and their result directories."""
+
+    def __init__(self, results_dir: Path, name: str):
+        self.results_dir = results_dir
+        self.path = results_dir / 'schedules' / name / 'history.json'
+        self.runs: List[Dict] = []
+        if self.path.exists():
+            try:
+                self.runs = json.loads(self.path.read_text()).get('runs', [])
+            except (OSError, ValueError):
+                console.print(f"[yellow]Warning:[/yellow] cannot read {self.path}, starting a new history")
+
+    def save(self):
+        self.path.parent.mkdir(parents=True, exist_ok=True)
+        self.path.write_text(json.dumps({'runs': self.runs}, indent=2))
+
+    def add(self, run: Dict):
+        self.runs.append(run)
+        self.save()
+
+    def apply_retention(self, keep_runs: Optional[int], keep_days: Optional[float]):
+        """Delete the result directories of runs beyond the newest keep_runs or older than keep_days."""
+        if keep_runs is None and keep_days is None:
+            return
+        cutoff = datetime.now() - timedelta(days=keep_days) if keep_days is not None else None
+        kept = []
+        for index, run in enumerate(reversed(self.runs)):
+            too_many = keep_runs is not None and index >= keep_runs
+            too_old = cutoff is not None and datetime.fromisoformat(run['started']) < cutoff
+            if not (too_many or too_old):
+                kept.append(run)
+                continue
+            for folder in run.get('results', []):
+                path = (self.results_dir / folder).resolve()
+                # Only ever delete directories inside the results directory
+                if self.results_dir.resolve() in path.parents and path.is_dir():
+                    shutil.rmtree(path)
+            console.print(f"[dim]Retention: removed results of run {run['uuid']} ({run['started']})[/dim]")
+        self.runs = list(reversed(kept))
+        self.save()
+
+
+def _result_folders(results_dir: Path, since: float) -> List[str]:
+    """Result directories (relative to results_dir) with summary files written since the given time."""
+    return sorted({p.parent.relative_to(results_dir).as_posix()
+                   for p in results_since(results_dir, since, exclude='schedules')})
+
+
+def run_once(cmd: List[str], cwd: Path, results_dir: Path, history: Optional[ScheduleHistory],
+             run_uuid: str) -> int:
+    """Run one scheduled workload run and record it in the history."""
+    started = datetime.now()
+    console.print(f"[cyan]Run {run_uuid} started {started.strftime('%Y-%m-%d %H:%M:%S')}:[/cyan] {' '.join(cmd)}")
+    returncode = subprocess.run(cmd, cwd=cwd).returncode
+    finished = datetime.now()
+    style = 'green' if returncode == 0 else 'red'
+    console.print(f"[{style}]Run {run_uuid} finished with exit code {returncode} "
+                  f"after {(finished - started).total_seconds():.0f}s[/{style}]")
+    if history is not None:
+        history.add({
+            'uuid': run_uuid,
+            'started': started.isoformat(timespec='seconds'),
+            'finished': finished.isoformat(timespec='seconds'),
+            'exit_code': returncode,
+            'results': _result_folders(results_dir, started.timestamp()),
+        })
+    return returncode
+
+
+def run_schedule(schedule: CronSchedule, build_command, cwd: Path, results_dir: Path,
+                 history: ScheduleHistory, keep_runs: Optional[int], keep_days: Optional[float]):
+    """
+    Run the workload at every schedule time until interrupted.
+
+    Runs never overlap: fire times that pass while a run is in progress are
+    skipped, and the next run starts at the next fire time after it ends.
+
+    Args:
+        schedule: Cron schedule
+        build_command: Callable(run_uuid) returning the virtbench command line of a run
+        cwd: Working directory (the repository root)
+        results_dir: Base results directory
+        history: History of the schedule
+        keep_runs: Keep the results of this many newest runs (None: all)
+        keep_days: Keep the results of runs newer than this many days (None: all)
+    """
+    stopping = []
+    signal.signal(signal.SIGTERM, lambda signum, frame: stopping.append(signum))
+    console.print(f"[cyan]Schedule '{schedule.expression}':[/cyan] results history in {history.path}")
+    try:
+        while not stopping:
+            next_run = schedule.next_after(datetime.now())
+            console.print(f"[cyan]Next run:[/cyan] {next_run.strftime('%Y-%m-%d %H:%M')}")
+            while not stopping and datetime.now() < next_run:
+                time.sleep(min(30.0, max(0.0, (next_run - datetime.now()).total_seconds())))
+            if stopping:
+                break
+            run_uuid = str(uuid4())
+            run_once(build_command(run_uuid), cwd, results_dir, history, run_uuid)
+            history.apply_retention(keep_runs, keep_days)
+            missed = schedule.next_after(next_run)
+            if missed < datetime.now():
+                console.print(f"[yellow]Run outlasted the schedule; skipped the run due at "
+                              f"{missed.strftime('%Y-%m-%d %H:%M')}[/yellow]")
+    except KeyboardInterrupt:
+        pass
+    console.print("[yellow]Schedule stopped[/yellow]")
@@ -0,0 +1,295 @@
+#!/usr/bin/env python3
+"""
+Scheduling constraint check of planned workload runs
+
+The global --node-selector, --affinity-file and --anti-affinity options
+constrain the nodes every created VM may run on (utils/scheduling.py adds
+them to the VM manifests). A constraint that no node satisfies leaves every
+VM Pending until the workload times out, so before a workload starts the
+--node-selector labels and the required node affinity of the
+--affinity-file are matched against the nodes of every cluster, term by
+term as the scheduler does. When no node matches on a cluster, nothing runs
+and the return code is NO_MATCHING_NODES_EXIT (virtbench/utils/exitcodes.py).
+
+With --taint-nodes, the nodes of a label selector are tainted (--taint,
+virtbench.io/dedicated=virtbench:NoSchedule by default) on every cluster
+before the workload starts, so only VMs with a matching toleration (added
+to every created VM, see utils/scheduling.py) are scheduled there; a
+NoExecute taint also evicts what does not tolerate it. The taints are
+removed when the workload finishes, fails or is interrupted, except those a
+node already had. A selector that matches no node stops the run with
+NO_MATCHING_NODES_EXIT as well.
+"""
+import json
+import os
+import subprocess
+from typing import Dict, List, Optional, Tuple
+
+import yaml
+from rich.console import Console
+
+from virtbench.utils.rbac import cluster_kubectl
+
+console = Console()
+
+NODE_SELECTOR_ENV = 'VIRTBENCH_NODE_SELECTOR'
+AFFINITY_FILE_ENV = 'VIRTBENCH_AFFINITY_FILE'
+ANTI_AFFINITY_ENV = 'VIRTBENCH_ANTI_AFFINITY'
+TAINT_NODES_ENV = 'VIRTBENCH_TAINT_NODES'
+TAINTS_ENV = 'VIRTBENCH_TAINTS'
+
+# Taint of --taint-nodes without --taint
+DEFAULT_TAINT = 'virtbench.io/dedicated=virtbench:NoSchedule'
+TAINT_EFFECTS = ('NoSchedule', 'PreferNoSchedule', 'NoExecute')
+
+AFFINITY_KINDS = ('nodeAffinity', 'podAffinity', 'podAntiAffinity')
+
+
+def parse_node_selector(value: str) -> Dict[str, str]:
+    """
+    Labels of a --node-selector value, e.g. "node-role.kubernetes.io/worker=,disktype=ssd".
+
+    Raises:
+        ValueError: If an entry is not key=value
+    """
+    selector = {}
+    for entry in (e.strip() for e in value.split(',') if e.strip()):
+        key, sep, label_value = entry.partition('=')
+        if not sep or not key.strip():
+            raise ValueError(f"expected key=value, got '{entry}'")
+        selector[key.strip()] = label_value.strip()
+    return selector
+
+
+def load_affinity_file(path: str) -> Dict:
+    """
+    Affinity of an --affinity-file: a pod affinity object, bare or under an "affinity" key.
+
+    Raises:
+        ValueError: If the file is not a mapping of nodeAffinity, podAffinity and podAntiAffinity
+    """
+    with open(path) as f:
+        try:
+            affinity = yaml.safe_load(f)
+        except yaml.YAMLError as e:
+            raise ValueError(f"{path}: invalid YAML: {e}")
+    if isinstance(affinity, dict) and set(affinity) == {'affinity'}:
+        affinity = affinity['affinity']
+    if not isinstance(affinity, dict) or not affinity:
+        raise ValueError(f"{path}: expected an affinity mapping")
+    unknown = [key for key in affinity if key not in AFFINITY_KINDS]
+    if unknown:
+        raise ValueError(f"{path}: unknown affinity field(s) {', '.join(unknown)} "
+                         f"(expected {', '.join(AFFINITY_KINDS)})")
+    return affinity
+
+
+def parse_taint(value: str) -> Dict[str, str]:
+    """
+    Taint of a --taint value, KEY[=VALUE]:EFFECT, e.g. "dedicated=virt:NoSchedule".
+
+    Raises:
+        ValueError: If the value has no key or an unknown effect
+    """
+    key_value, sep, effect = value.strip().rpartition(':')
+    key, _, taint_value = key_value.partition('=')
+    if not sep or not key.strip():
+        raise ValueError(f"expected KEY[=VALUE]:EFFECT, got '{value}'")
+    if effect not in TAINT_EFFECTS:
+        raise ValueError(f"unknown effect '{effect}' (expected {', '.join(TAINT_EFFECTS)})")
+    taint = {'key': key.strip(), 'effect': effect}
+    if taint_value.strip():
+        taint['value'] = taint_value.strip()
+    return taint
+
+
+def parse_taints(value: str) -> List[Dict[str, str]]:
+    """Taints of VIRTBENCH_TAINTS (comma-separated --taint values)."""
+    return [parse_taint(entry) for entry in value.split(',') if entry.strip()]
+
+
+def taint_arg(taint: Dict[str, str], remove: bool = False) -> str:
+    """kubectl taint argument adding (KEY=VALUE:EFFECT) or removing (KEY:EFFECT-) a taint."""
+    if remove:
+        return f"{taint['key']}:{taint['effect']}-"
+    return f"{taint['key']}{'=' + taint['value'] if taint.get('value') else ''}:{taint['effect']}"
+
+
+def requirement_matches(requirement: Dict, values: Dict[str, str]) -> bool:
+    """Whether a node selector requirement (In, NotIn, Exists, DoesNotExist, Gt, Lt) holds for labels or fields."""
+    key, operator, expected = requirement.get('key'), requirement.get('operator'), requirement.get('values') or []
+    value = values.get(key)
+    if operator == 'In':
+        return value in expected
+    if operator == 'NotIn':
+        return value not in expected
+    if operator == 'Exists':
+        return key in values
+    if operator == 'DoesNotExist':
+        return key not in values
+    if operator in ('Gt', 'Lt') and value is not None and expected:
+        try:
+            return int(value) > int(expected[0]) if operator == 'Gt' else int(value) < int(expected[0])
+        except ValueError:
+            return False
+    return False
+
+
+def node_matches(node: Dict, selector: Dict[str, str], affinity: Optional[Dict]) -> bool:
+    """Whether a node satisfies a node selector and the required node affinity (one of its terms)."""
+    labels = node.get('metadata', {}).get('labels') or {}
+    if any(labels.get(key) != value for key, value in selector.items()):
+        return False
+    required = ((affinity or {}).get('nodeAffinity') or {}).get('requiredDuringSchedulingIgnoredDuringExecution')
+    terms = (required or {}).get('nodeSelectorTerms') or []
+    if not terms:
+        return True
+    fields = {'metadata.name': node.get('metadata', {}).get('name')}
+    return any(all(requirement_matches(r, labels) for r in term.get('matchExpressions') or [])
+               and all(requirement_matches(r, fields) for r in term.get('matchFields') or [])
+               for term in terms)
+
+
+def _schedulable(node: Dict) -> bool:
+    ready = any(c.get('type') == 'Ready' and c.get('status') == 'True'
+                for c in node.get('status', {}).get('conditions') or [])
+    return ready and not node.get('spec', {}).get('unschedulable')
+
+
+def check_node_constraints(ctx) -> bool:
+    """
+    Check that some node of every cluster satisfies the --node-selector and --affinity-file.
+
+    Returns:
+        False when no node matches on a cluster (the constraints are printed),
+        else True; a check that cannot list the nodes only warns
+    """
+    selector = parse_node_selector(os.environ.get(NODE_SELECTOR_ENV, ''))
+    affinity = load_affinity_file(os.environ[AFFINITY_FILE_ENV]) if os.environ.get(AFFINITY_FILE_ENV) else None
+    constrained = bool(selector or (affinity or {}).get('nodeAffinity'))
+    if not constrained and os.environ.get(ANTI_AFFINITY_ENV) != 'hard':
+        return True
+
+    failed = []
+    for cluster in ctx.obj.clusters or [None]:
+        where = f" on {cluster['name']}" if cluster else ''
+        try:
+            result = subprocess.run(cluster_kubectl(cluster) + ['get', 'nodes', '-o', 'json'],
+                                    capture_output=True, text=True, timeout=60)
+            nodes = json.loads(result.stdout).get('items', []) if result.returncode == 0 else None
+        except (OSError, subprocess.SubprocessError, json.JSONDecodeError):
+            nodes = None
+        if nodes is None:
+            console.print(f"[yellow]Warning: could not list nodes to check the scheduling constraints{where}[/yellow]")
+            continue
+        matching = [node for node in nodes if node_matches(node, selector, affinity)]
+        schedulable = [node for node in matching if _schedulable(node)]
+        if not schedulable and constrained:
+            failed.append(cluster['name'] if cluster else None)
+            not_ready = f" ({len(matching)} match but are not Ready or are cordoned)" if matching else ''
+            console.print(f"[red]No schedulable node{where} satisfies the scheduling constraints{not_ready}[/red]")
+            continue
+        names = [node['metadata']['name'] for node in schedulable]
+        console.print(f"[dim]{len(schedulable)} schedulable node(s){where} satisfy the scheduling constraints: "
+                      f"{', '.join(names[:10])}{' ...' if len(names) > 10 else ''}[/dim]")
+        if os.environ.get(ANTI_AFFINITY_ENV) == 'hard':
+            console.print(f"[dim]--anti-affinity hard: at most {len(schedulable)} VM(s) can run{where}, "
+                          f"one per node[/dim]")
+    if not failed:
+        return True
+
+    constraints = []
+    if selector:
+        constraints.append(f"--node-selector {os.environ[NODE_SELECTOR_ENV]}")
+    if (affinity or {}).get('nodeAffinity'):
+        constraints.append(f"node affinity of --affinity-file {os.environ[AFFINITY_FILE_ENV]}")
+    console.print(f"[red]Error: {ctx.info_name} would leave every VM Pending: no node matches "
+                  f"{' and '.join(constraints)}; nothing was run. Check the node labels "
+                  f"(kubectl get nodes --show-labels).[/red]")
+    return False
+
+
+def _list_nodes(cluster: Optional[Dict], selector: str) -> Optional[List[Dict]]:
+    try:
+        result = subprocess.run(cluster_kubectl(cluster) + ['get', 'nodes', '-l', selector, '-o', 'json'],
+                                capture_output=True, text=True, timeout=60)
+        return json.loads(result.stdout).get('items', []) if result.returncode == 0 else None
+    except (OSError, subprocess.SubprocessError, json.JSONDecodeError):
+        return None
+
+
+def taint_nodes(ctx, added: List[Tuple[Optional[Dict], str, Dict]]) -> bool:
+    """
+    Taint the --taint-nodes nodes of every cluster with the --taint taints.
+
+    A taint whose key and effect a node already has is left as it is, and is
+    not removed afterwards.
+
+    Args:
+        ctx: Click context of the command
+        added: List the (cluster, node, taint) taints are appended to as each
+            node is tainted, for untaint_nodes(); the caller removes them in a
+            finally block, so a run stopped halfway through tainting is
+            cleaned up as well
+
+    Returns:
+        False when a cluster has no node to taint or a node could not be
+        tainted (nothing should run), else True
+    """
+    selector = os.environ.get(TAINT_NODES_ENV)
+    if not selector:
+        return True
+    taints = parse_taints(os.environ.get(TAINTS_ENV) or DEFAULT_TAINT)
+    wanted = ', '.join(taint_arg(taint) for taint in taints)
+    for cluster in ctx.obj.clusters or [None]:
+        where = f" on {cluster['name']}" if cluster else ''
+        nodes = _list_nodes(cluster, selector)
+        if not nodes:
+            reason = 'could not list the nodes of' if nodes is None else 'no node matches'
+            console.print(f"[red]Error: {reason} --taint-nodes {selector}{where}; nothing was run.[/red]")
+            return False
+        names = [node['metadata']['name'] for node in nodes]
+        if ctx.obj.dry_run:
+            console.print(f"[dim]Would taint {len(names)} node(s){where} with {wanted}: {', '.join(names)}[/dim]")
+            continue
+        for node, name in zip(nodes, names):
+            present = {(t.get('key'), t.get('effect')) for t in node.get('spec', {}).get('taints') or []}
+            new = [taint for taint in taints if (taint['key'], taint['effect']) not in present]
+            if len(new) < len(taints):
+                console.print(f"[dim]Node {name}{where} already has a taint of the same key and effect; "
+                              f"it is kept[/dim]")
+            if not new:
+                continue
+            result = subprocess.run(cluster_kubectl(cluster) + ['taint', 'nodes', name]
+                                    + [taint_arg(taint) for taint in new],
+                                    capture_output=True, text=True, timeout=60)
+            if result.returncode != 0:
+                console.print(f"[red]Error: could not taint node {name}{where}: {result.stderr.strip()}; "
+                              f"nothing was run.[/red]")
+                return False
+            added.extend((cluster, name, taint) for taint in new)
+        console.print(f"[cyan]Tainted {len(names)} node(s){where} with {wanted}:[/cyan] {', '.join(names)}")
+    return True
+
+
+def untaint_nodes(added: List[Tuple[Optional[Dict], str, Dict]]):
+    """Remove the taints taint_nodes() added; a node that cannot be untainted only warns, with the command to run."""
+    by_node: Dict[Tuple[Optional[str], str], Tuple[Optional[Dict], List[Dict]]] = {}
+    for cluster, name, taint in added:
+        by_node.setdefault((cluster['name'] if cluster else None, name), (cluster, []))[1].append(taint)
+    removed = 0
+    for (cluster_name, name), (cluster, taints) in by_node.items():
+        where = f" on {cluster_name}" if cluster_name else ''
+        args = ['taint', 'nodes', name] + [taint_arg(taint, remove=True) for taint in taints]
+        try:
+            result = subprocess.run(cluster_kubectl(cluster) + args, capture_output=True, text=True, timeout=60)
+            error = result.stderr.strip() if result.returncode != 0 else None
+        except (OSError, subprocess.SubprocessError) as e:
+            error = str(e)
+        if error:
+            console.print(f"[yellow]Warning: could not remove the taints of node {name}{where}: {error}. "
+                          f"Remove them with: kubectl {' '.join(args)}[/yellow]")
+        else:
+            removed += 1
+    if removed:
+        console.print(f"[cyan]Removed the benchmark taints of {removed} node(s)[/cyan]")
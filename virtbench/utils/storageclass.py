@@ -0,0 +1,258 @@
+#!/usr/bin/env python3
+"""
+Storage class comparison runs for virtbench
+
+`virtbench datasource-clone`, `virtbench migration --create-vms` and
+`virtbench fio` with several --storage-class values run the identical
+workload on every storage class and compare the runs with
+utils/storageclass.py. --access-modes and --volume-modes add a matrix of
+access modes (RWO, RWX) and volume modes (Block, Filesystem): every
+(storage class, access mode, volume mode) variant is a run of its own:
+
+    <results>/storage-class-comparison/<timestamp>/<variant>/...   one run each, e.g. px-db-rwx-block
+    <results>/storage-class-comparison/<timestamp>/variants.json
+    <results>/storage-class-comparison/<timestamp>/storage_class_comparison.{json,csv}
+
+With --storage-class-mode per-class (the default) the runs follow each
+other, and every run but the last cleans up its VMs so the next run can
+create them again under the same names. With striped, the namespace range
+is split into one slice per variant and the slices run at the same time,
+so the variants share the load of a single run.
+"""
+import json
+import subprocess
+import threading
+from datetime import datetime
+from pathlib import Path
+from typing import Dict, Iterable, List, Optional, Sequence, Tuple
+
+from rich.console import Console
+from rich.table import Table
+
+from virtbench.utils.multicluster import RESULTS_ARGS, _stream
+from virtbench.utils.orchestration import run_workload
+from virtbench.utils.yaml_modifier import ACCESS_MODES, VOLUME_MODES, write_storage_class_template
+
+console = Console()
+
+STORAGE_CLASS_MODES = ['per-class', 'striped']
+
+
+def parse_storage_classes(values: Iterable[str]) -> List[str]:
+    """Storage classes of repeated and comma-separated --storage-class values, in order, without repeats."""
+    classes = []
+    for value in values or ():
+        for name in value.split(','):
+            name = name.strip()
+            if name and name not in classes:
+                classes.append(name)
+    return classes
+
+
+def parse_access_modes(value: Optional[str]) -> List[str]:
+    """
+    Access modes of a comma-separated --access-modes value (RWO, RWX, ... or the full names).
+
+    Raises:
+        ValueError: For an unknown access mode
+    """
+    modes = []
+    for name in (value or '').split(','):
+        name = name.strip()
+        if not name:
+            continue
+        mode = ACCESS_MODES.get(name.upper(), name)
+        if mode not in ACCESS_MODES.values():
+            raise ValueError(f"unknown access mode '{name}' (use {', '.join(ACCESS_MODES)})")
+        if mode not in modes:
+            modes.append(mode)
+    return modes
+
+
+def parse_volume_modes(value: Optional[str]) -> List[str]:
+    """
+    Volume modes of a comma-separated --volume-modes value (Block, Filesystem).
+
+    Raises:
+        ValueError: For an unknown volume mode
+    """
+    modes = []
+    for name in (value or '').split(','):
+        name = name.strip()
+        if not name:
+            continue
+        mode = next((m for m in VOLUME_MODES if m.lower() == name.lower()), None)
+        if mode is None:
+            raise ValueError(f"unknown volume mode '{name}' (use {', '.join(VOLUME_MODES)})")
+        if mode not in modes:
+            modes.append(mode)
+    return modes
+
+
+def storage_variants(storage_classes: List[str], access_modes: Optional[List[str]] = None,
+                     volume_modes: Optional[List[str]] = None) -> List[Dict]:
+    """
+    Every (storage class, access mode, volume mode) combination, in the order given.
+
+    An empty list keeps the template's value of that dimension (None). The
+    variant name, used as its run folder, is the storage class followed by the
+    short access mode and volume mode, e.g. px-db-rwx-block.
+
+    Returns:
+        [{'name', 'storage_class', 'access_mode', 'volume_mode'}]
+    """
+    short = {mode: name for name, mode in ACCESS_MODES.items()}
+    variants = []
+    for storage_class in storage_classes or [None]:
+        for access_mode in access_modes or [None]:
+            for volume_mode in volume_modes or [None]:
+                parts = [storage_class or 'template']
+                if access_mode:
+                    parts.append(short[access_mode].lower())
+                if volume_mode:
+                    parts.append(volume_mode.lower())
+                variants.append({'name': '-'.join(parts), 'storage_class': storage_class,
+                                 'access_mode': access_mode, 'volume_mode': volume_mode})
+    return variants
+
+
+def stripe_ranges(start: int, end: int, count: int) -> List[Tuple[int, int]]:
+    """
+    Split the namespace range start..end into count contiguous slices of (almost) equal size.
+
+    Raises:
+        ValueError: If the range has fewer indexes than slices
+    """
+    total = end - start + 1
+    if total < count:
+        raise ValueError(f"--start {start} --end {end} has {max(total, 0)} VMs, "
+                         f"fewer than the {count} storage classes to stripe across")
+    ranges = []
+    for i in range(count):
+        size = total // count + (1 if i < total % count else 0)
+        ranges.append((start, start + size - 1))
+        start += size
+    return ranges
+
+
+def _set_arg(cmd: List[str], name: str, value: Optional[str] = None) -> List[str]:
+    """Command with --name set to value (a flag when value is None), replacing an earlier value."""
+    cmd = list(cmd)
+    if name in cmd:
+        i = cmd.index(name)
+        if value is not None:
+            cmd[i + 1] = str(value)
+    elif value is None:
+        cmd.append(name)
+    else:
+        cmd.extend([name, str(value)])
+    return cmd
+
+
+def _drop_arg(cmd: List[str], name: str) -> List[str]:
+    """Command without --name and its value."""
+    if name not in cmd:
+        return list(cmd)
+    i = cmd.index(name)
+    return cmd[:i] + cmd[i + 2:]
+
+
+def run_storage_class_comparison(ctx, cmd: List[str], variants: List[Dict], repo_root: Path,
+                                 mode: str = 'per-class', template_path: Optional[Path] = None,
+                                 data_storage_class: Optional[str] = None,
+                                 cleanup_args: Sequence[str] = ('--cleanup', '--yes')) -> int:
+    """
+    Run a workload on every storage variant, then compare the runs.
+
+    Args:
+        ctx: Click context of the command
+        cmd: Script command line of the workload
+        variants: storage_variants() in comparison order
+        repo_root: Repository root (working directory of the runs)
+        mode: per-class (one run per variant, in turn) or striped (the
+            namespace range split across the variants, run at the same time)
+        template_path: VM template the storage class and modes are written into;
+            a --storage-class of the script is set as well
+        data_storage_class: Storage class of the template's data disks (default: each run's)
+        cleanup_args: Script flags that make a per-class run delete its VMs
+
+    Returns:
+        Exit code: the comparison's, or 1 when every run failed
+    """
+    if len(ctx.obj.clusters) > 1:
+        console.print("[red]Error: a storage class comparison runs on one cluster at a time[/red]")
+        return 1
+    results_arg = next((arg for arg in RESULTS_ARGS if arg in cmd), RESULTS_ARGS[0])
+    results_folder = cmd[cmd.index(results_arg) + 1] if results_arg in cmd else 'results'
+    base = Path(results_folder) / 'storage-class-comparison' / datetime.now().strftime("%Y%m%d-%H%M%S")
+    if not base.is_absolute():
+        base = repo_root / base
+
+    if mode == 'striped':
+        try:
+            ranges = stripe_ranges(int(cmd[cmd.index('--start') + 1]), int(cmd[cmd.index('--end') + 1]),
+                                   len(variants))
+        except ValueError as e:
+            console.print(f"[red]Error: {e}[/red]")
+            return 1
+
+    runs = []
+    for i, variant in enumerate(variants):
+        run_cmd = _set_arg(_drop_arg(cmd, '--log-file'), results_arg, base / variant['name'])
+        run_cmd = _set_arg(run_cmd, '--save-results')
+        if template_path:
+            path = write_storage_class_template(template_path, variant['storage_class'], data_storage_class,
+                                                variant['access_mode'], variant['volume_mode'])
+            run_cmd = _set_arg(run_cmd, '--vm-template', path)
+        if variant['storage_class'] and (not template_path or '--storage-class' in cmd):
+            run_cmd = _set_arg(run_cmd, '--storage-class', variant['storage_class'])
+        if mode == 'striped':
+            run_cmd = _set_arg(_set_arg(run_cmd, '--start', ranges[i][0]), '--end', ranges[i][1])
+        elif i < len(variants) - 1:
+            for flag in cleanup_args:
+                run_cmd = _set_arg(run_cmd, flag)
+        runs.append({'name': variant['name'], 'cmd': run_cmd, 'returncode': None})
+
+    if mode == 'striped':
+        console.print("[cyan]Storage class comparison: striping "
+                      + ', '.join(f"{run['name']} ({start}-{end})"
+                                  for run, (start, end) in zip(runs, ranges)) + "[/cyan]")
+        for run in runs:
+            run['process'] = subprocess.Popen(run['cmd'], cwd=repo_root, stdout=subprocess.PIPE,
+                                              stderr=subprocess.STDOUT, text=True, bufsize=1)
+            run['reader'] = threading.Thread(target=_stream, args=(run['process'], run['name']),
+                                             daemon=True)
+            run['reader'].start()
+        for run in runs:
+            run['returncode'] = run['process'].wait()
+            run['reader'].join()
+    else:
+        for i, run in enumerate(runs):
+            console.print(f"[cyan]Storage class comparison: {run['name']} "
+                          f"({i + 1}/{len(runs)})[/cyan]")
+            run['returncode'] = run_workload(ctx, run['cmd'], cwd=repo_root).returncode
+
+    table = Table(title="Storage class runs")
+    table.add_column('Storage variant')
+    table.add_column('Exit code', justify='right')
+    for run in runs:
+        style = 'green' if run['returncode'] == 0 else 'red'
+        table.add_row(run['name'], f"[{style}]{run['returncode']}[/{style}]")
+    console.print(table)
+
+    if all(run['returncode'] != 0 for run in runs):
+        console.print("[red]Every storage class run failed; skipping the comparison[/red]")
+        return 1
+    if ctx.obj.dry_run:
+        return 0
+    # Tells the comparison the storage class and modes behind every run folder
+    base.mkdir(parents=True, exist_ok=True)
+    (base / 'variants.json').write_text(json.dumps(variants, indent=2))
+    # A failed run still leaves timings of the VMs that came up; compare what the runs saved
+    log_level = cmd[cmd.index('--log-level') + 1] if '--log-level' in cmd else None
+    compare = [cmd[0], str(repo_root / 'utils' / 'storageclass.py'), '--comparison', str(base),
+               '--mode', mode,
+               '--output', str(base / 'storage_class_comparison.json')]
+    if log_level:
+        compare += ['--log-level', log_level]
+    return subprocess.run(compare, cwd=repo_root).returncode
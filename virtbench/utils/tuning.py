@@ -0,0 +1,74 @@
+#!/usr/bin/env python3
+"""
+Tuned vs untuned comparison runs for virtbench
+
+`virtbench datasource-clone` and `virtbench migration` with --compare-tuning
+run the workload twice, first without and then with the CPU pinning,
+hugepages and NUMA flags, and compare the two runs with utils/tuning.py:
+
+    <results>/tuning-comparison/<timestamp>/baseline/...   untuned run
+    <results>/tuning-comparison/<timestamp>/tuned/...      tuned run
+    <results>/tuning-comparison/<timestamp>/tuning_comparison.json
+
+The baseline run always cleans up its VMs so the tuned run can create them
+again under the same names.
+"""
+import subprocess
+from datetime import datetime
+from pathlib import Path
+from typing import Any, Dict
+
+from rich.console import Console
+
+from virtbench.common import build_python_command
+from virtbench.utils.orchestration import run_workload
+
+console = Console()
+
+# Script flags that make a run tuned
+TUNING_FLAGS = ('dedicated-cpus', 'hugepages', 'numa')
+
+
+def run_tuning_comparison(ctx, script_path: Path, python_args: Dict[str, Any], repo_root: Path) -> int:
+    """
+    Run a workload without and with its tuning flags, then compare the runs.
+
+    Args:
+        ctx: Click context of the command
+        script_path: Workload script
+        python_args: Script arguments of the tuned run
+        repo_root: Repository root (working directory of the runs)
+
+    Returns:
+        Exit code: the first failing run's, else the comparison's
+    """
+    if len(ctx.obj.clusters) > 1:
+        console.print("[red]Error: --compare-tuning runs on one cluster at a time[/red]")
+        return 1
+    base = Path(python_args['results-folder']) / 'tuning-comparison' / datetime.now().strftime("%Y%m%d-%H%M%S")
+    if not base.is_absolute():
+        base = repo_root / base
+
+    runs = {
+        'baseline': dict({k: v for k, v in python_args.items() if k not in TUNING_FLAGS},
+                         cleanup=True, yes=True),
+        'tuned': python_args,
+    }
+    for name, run_args in runs.items():
+        console.print(f"[cyan]Tuning comparison: {name} run[/cyan]")
+        run_args = dict(run_args, **{'results-folder': str(base / name), 'save-results': True})
+        run_args.pop('log-file', None)
+        result = run_workload(ctx, build_python_command(script_path, run_args), cwd=repo_root)
+        if result.returncode != 0:
+            console.print(f"[red]The {name} run failed; skipping the comparison[/red]")
+            return result.returncode
+
+    if ctx.obj.dry_run:
+        return 0
+    cmd = build_python_command(repo_root / 'utils' / 'tuning.py', {
+        'baseline': str(base / 'baseline'),
+        'tuned': str(base / 'tuned'),
+        'output': str(base / 'tuning_comparison.json'),
+        'log-level': python_args.get('log-level'),
+    })
+    return subprocess.run(cmd, cwd=repo_root).returncode
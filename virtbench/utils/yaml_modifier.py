@@ -4,16 +4,29 @@ YAML modification utilities for storage class injection
 """
 import yaml
 import atexit
+import os
+import tempfile
 from pathlib import Path
-from typing import Union
+from typing import Optional, Union
+
+# Access modes by their kubectl short names
+ACCESS_MODES = {
+    'RWO': 'ReadWriteOnce',
+    'RWX': 'ReadWriteMany',
+    'ROX': 'ReadOnlyMany',
+    'RWOP': 'ReadWriteOncePod',
+}
+VOLUME_MODES = ['Block', 'Filesystem']
 
 
 class YAMLModifier:
     """Context manager for temporary YAML modifications"""
     
-    def __init__(self, template_path: Union[str, Path], storage_class: str):
+    def __init__(self, template_path: Union[str, Path], storage_class: str,
+                 data_storage_class: Optional[str] = None):
         self.template_path = Path(template_path)
         self.storage_class = storage_class
+        self.data_storage_class = data_storage_class
         self.original_content = None
     
     def __enter__(self):
@@ -44,6 +57,10 @@ class YAMLModifier:
         Returns:
             Modified YAML content
         """
+        # Data disks default to the OS disk storage class
+        content = content.replace('{{DATA_STORAGE_CLASS_NAME}}',
+                                  self.data_storage_class or self.storage_class)
+
         # Simple string replacement for {{STORAGE_CLASS_NAME}} placeholder
         if '{{STORAGE_CLASS_NAME}}' in content:
             return content.replace('{{STORAGE_CLASS_NAME}}', self.storage_class)
@@ -68,7 +85,8 @@ class YAMLModifier:
         return yaml.dump(data, default_flow_style=False, sort_keys=False)
 
 
-def modify_storage_class(template_path: Union[str, Path], storage_class: str) -> None:
+def modify_storage_class(template_path: Union[str, Path], storage_class: str,
+                         data_storage_class: Optional[str] = None) -> None:
     """
     Modify storage class in VM template YAML file in-place.
     Automatically restores original content on program exit.
@@ -76,18 +94,24 @@ def modify_storage_class(template_path: Union[str, Path], storage_class: str) ->
     Args:
         template_path: Path to VM template YAML file
         storage_class: Storage class name to inject
+        data_storage_class: Storage class for {{DATA_STORAGE_CLASS_NAME}} data disks
+            (defaults to storage_class)
     """
     template_path = Path(template_path)
     
     # Read original content
     original_content = template_path.read_text()
     
+    # Data disks default to the OS disk storage class
+    content = original_content.replace('{{DATA_STORAGE_CLASS_NAME}}',
+                                       data_storage_class or storage_class)
+
     # Simple string replacement for {{STORAGE_CLASS_NAME}} placeholder
-    if '{{STORAGE_CLASS_NAME}}' in original_content:
-        modified_content = original_content.replace('{{STORAGE_CLASS_NAME}}', storage_class)
+    if '{{STORAGE_CLASS_NAME}}' in content:
+        modified_content = content.replace('{{STORAGE_CLASS_NAME}}', storage_class)
     else:
         # Parse YAML and modify storageClassName field
-        data = yaml.safe_load(original_content)
+        data = yaml.safe_load(content)
         
         # Navigate to dataVolumeTemplates and update storageClassName
         modified = False
@@ -111,3 +135,78 @@ def modify_storage_class(template_path: Union[str, Path], storage_class: str) ->
     # Register cleanup to restore original content on exit
     atexit.register(lambda: template_path.write_text(original_content))
 
+
+def set_volume_modes(content: str, access_mode: Optional[str] = None,
+                     volume_mode: Optional[str] = None) -> str:
+    """
+    Set the access mode and volume mode of every volume that names a storage class.
+
+    The template is edited as text, since templates with {{...}} placeholders
+    are not valid YAML: existing accessModes/volumeMode settings are dropped and
+    the new ones are written right after each storageClassName.
+
+    Args:
+        content: VM template YAML content
+        access_mode: Access mode, e.g. ReadWriteMany (None keeps the template's)
+        volume_mode: Block or Filesystem (None keeps the template's)
+
+    Returns:
+        Modified YAML content
+    """
+    lines = []
+    list_indent = None
+    for line in content.splitlines():
+        stripped = line.lstrip()
+        indent = len(line) - len(stripped)
+        if list_indent is not None:
+            # Items of a dropped accessModes list
+            if stripped.startswith('- ') and indent >= list_indent:
+                continue
+            list_indent = None
+        if access_mode and stripped.startswith('accessModes:'):
+            if stripped.rstrip() == 'accessModes:':
+                list_indent = indent
+            continue
+        if volume_mode and stripped.startswith('volumeMode:'):
+            continue
+        lines.append(line)
+        if stripped.startswith('storageClassName:'):
+            pad = ' ' * indent
+            if access_mode:
+                lines += [f"{pad}accessModes:", f"{pad}  - {access_mode}"]
+            if volume_mode:
+                lines.append(f"{pad}volumeMode: {volume_mode}")
+    return '\n'.join(lines) + '\n'
+
+
+def write_storage_class_template(template_path: Union[str, Path], storage_class: Optional[str],
+                                 data_storage_class: Optional[str] = None, access_mode: Optional[str] = None,
+                                 volume_mode: Optional[str] = None) -> Path:
+    """
+    Write a copy of a VM template using a storage class, leaving the template itself alone.
+    The copy is removed on program exit.
+
+    Args:
+        template_path: Path to VM template YAML file
+        storage_class: Storage class name to inject (None keeps the template's)
+        data_storage_class: Storage class for {{DATA_STORAGE_CLASS_NAME}} data disks
+            (defaults to storage_class)
+        access_mode: Access mode of the volumes, e.g. ReadWriteMany (None keeps the template's)
+        volume_mode: Volume mode of the volumes, Block or Filesystem (None keeps the template's)
+
+    Returns:
+        Path of the copy
+    """
+    template_path = Path(template_path)
+    content = template_path.read_text()
+    if storage_class:
+        content = YAMLModifier(template_path, storage_class, data_storage_class)._modify_content(content)
+    if access_mode or volume_mode:
+        content = set_volume_modes(content, access_mode, volume_mode)
+
+    fd, path = tempfile.mkstemp(prefix=f"virtbench-{storage_class or 'template'}-", suffix='.yaml')
+    with os.fdopen(fd, 'w') as f:
+        f.write(content)
+    atexit.register(lambda: os.path.exists(path) and os.remove(path))
+    return Path(path)
+
@@ -0,0 +1,299 @@
+#!/usr/bin/env python3
+"""
+Workload interface for third-party benchmarks.
+
+Downstream users add workloads to virtbench without modifying the command
+files, in one of two ways.
+
+Python workloads subclass Workload and register it with register_workload():
+
+    from virtbench.workload import Workload, register_workload
+
+    @register_workload
+    class EtcdLatency(Workload):
+        name = 'etcd-latency'
+        help = 'Measure etcd request latency under VM churn'
+
+        def add_arguments(self, parser):
+            parser.add_argument('--vms', type=int, default=10)
+
+        def execute(self, args, logger):
+            ...
+
+        def collect(self, args, logger):
+            return {'metrics': [{'metric': 'request_latency_sec', 'avg': 0.02, 'count': 500}]}
+
+The module is loaded from the ``virtbench.workloads`` entry point group of an
+installed package (``etcd-latency = "mypkg.bench"``), or from the modules
+listed in VIRTBENCH_PLUGINS (comma-separated, importable from the working
+directory or PYTHONPATH). ``virtbench etcd-latency --vms 20`` then runs
+``python3 -m virtbench.workload etcd-latency --vms 20`` in the repository
+root, which calls setup(), execute() and collect(), and cleanup() with
+--cleanup. With --save-results the dict collect() returns is saved as
+``<results-folder>/<name>/<timestamp>/summary_<name>_results.json``, so
+`virtbench results`, --assert and --junit-report pick it up like the summary
+of a built-in workload.
+
+Exec plugins are executables named ``virtbench-<name>`` on PATH, like kubectl
+plugins: ``virtbench <name> ARGS`` runs ``virtbench-<name> ARGS``, and the
+longest match wins, so ``virtbench net perf`` runs ``virtbench-net-perf`` if it
+exists and ``virtbench-net perf`` otherwise. The global options reach them as
+environment variables (VIRTBENCH_UUID, VIRTBENCH_DRY_RUN, VIRTBENCH_LOG_LEVEL,
+KUBECONFIG, ...; see plugin_environment() in virtbench/commands/plugins.py).
+
+Both kinds run through run_workload(), so --contexts, --assert, --junit-report,
+--results-db and diagnostics apply to them too. Built-in commands take
+precedence over plugins of the same name, and plugins are not part of the
+RBAC permission audit.
+"""
+
+import argparse
+import json
+import logging
+import os
+import socket
+import sys
+from datetime import datetime
+from pathlib import Path
+from typing import Dict, List, Optional, Tuple, Type
+
+PLUGINS_ENV = 'VIRTBENCH_PLUGINS'
+ENTRY_POINT_GROUP = 'virtbench.workloads'
+EXEC_PLUGIN_PREFIX = 'virtbench-'
+
+_registry: Dict[str, Type['Workload']] = {}
+_load_errors: Dict[str, str] = {}
+_loaded = False
+
+
+class Workload:
+    """
+    A benchmark workload.
+
+    Subclasses set name (the virtbench command) and help, add their options
+    in add_arguments() and implement the phases they need. Every phase gets
+    the parsed arguments and a logger; an exception fails the run.
+    """
+
+    name: str = ''
+    help: str = ''
+
+    def add_arguments(self, parser: argparse.ArgumentParser):
+        """Add the workload's options to its argument parser."""
+
+    def setup(self, args: argparse.Namespace, logger: logging.Logger):
+        """Prepare the run: check prerequisites, create namespaces and VMs."""
+
+    def execute(self, args: argparse.Namespace, logger: logging.Logger):
+        """Run the measured part of the benchmark."""
+        raise NotImplementedError
+
+    def collect(self, args: argparse.Namespace, logger: logging.Logger) -> Optional[Dict]:
+        """Return the run summary (with a flat "metrics" list of metric statistics), or None."""
+        return None
+
+    def cleanup(self, args: argparse.Namespace, logger: logging.Logger):
+        """Delete what setup() and execute() created (with --cleanup)."""
+
+
+def register_workload(cls: Type[Workload]) -> Type[Workload]:
+    """Class decorator registering a Workload subclass under its name."""
+    if not (isinstance(cls, type) and issubclass(cls, Workload)) or not cls.name:
+        raise TypeError(f"{cls!r} is not a Workload subclass with a name")
+    _registry[cls.name] = cls
+    return cls
+
+
+def _entry_points() -> list:
+    try:
+        from importlib.metadata import entry_points
+    except ImportError:
+        return []
+    found = entry_points()
+    if hasattr(found, 'select'):
+        return list(found.select(group=ENTRY_POINT_GROUP))
+    return list(found.get(ENTRY_POINT_GROUP, []))
+
+
+def load_plugins() -> Dict[str, Type[Workload]]:
+    """
+    Registered Python workloads by name, loading the plugin modules once.
+
+    Modules that fail to import are skipped; see plugin_errors().
+    """
+    global _loaded
+    if _loaded:
+        return dict(_registry)
+    _loaded = True
+    for entry_point in _entry_points():
+        try:
+            loaded = entry_point.load()
+            if isinstance(loaded, type) and issubclass(loaded, Workload) and loaded.name not in _registry:
+                register_workload(loaded)
+        except Exception as e:
+            _load_errors[entry_point.value] = str(e)
+    for module in (m.strip() for m in os.environ.get(PLUGINS_ENV, '').split(',') if m.strip()):
+        try:
+            __import__(module)
+        except Exception as e:
+            _load_errors[module] = str(e)
+    return dict(_registry)
+
+
+def plugin_errors() -> Dict[str, str]:
+    """Plugin modules that could not be loaded, with the error."""
+    load_plugins()
+    return dict(_load_errors)
+
+
+def _scan_exec_plugins() -> List[Tuple[str, str, bool]]:
+    """(name, path, executable) of every virtbench-<name> file on PATH, in PATH order."""
+    found = []
+    for directory in os.environ.get('PATH', '').split(os.pathsep):
+        try:
+            entries = sorted(os.listdir(directory or '.'))
+        except OSError:
+            continue
+        for entry in entries:
+            path = os.path.join(directory or '.', entry)
+            if entry.startswith(EXEC_PLUGIN_PREFIX) and len(entry) > len(EXEC_PLUGIN_PREFIX) and os.path.isfile(path):
+                found.append((entry[len(EXEC_PLUGIN_PREFIX):], path, os.access(path, os.X_OK)))
+    return found
+
+
+def exec_plugins() -> Dict[str, str]:
+    """Executables named virtbench-<name> on PATH by name; the first one on PATH wins."""
+    plugins = {}
+    for name, path, executable in _scan_exec_plugins():
+        if executable:
+            plugins.setdefault(name, path)
+    return plugins
+
+
+def exec_plugin_warnings() -> List[str]:
+    """Problems with the virtbench-<name> files on PATH, as `kubectl plugin list` reports them."""
+    warnings = []
+    plugins = exec_plugins()
+    for name, path, executable in _scan_exec_plugins():
+        if not executable:
+            warnings.append(f"{path} is named like a plugin, but it is not executable")
+        elif plugins[name] != path:
+            warnings.append(f"{path} is overshadowed by {plugins[name]}, earlier on PATH")
+    return warnings
+
+
+def match_exec_plugin(args: List[str]) -> Optional[Tuple[str, str, List[str]]]:
+    """
+    Longest exec plugin match of a command line, like kubectl plugins.
+
+    ['net', 'perf', '--vms', '5'] runs virtbench-net-perf --vms 5 if it exists,
+    else virtbench-net perf --vms 5.
+
+    Returns:
+        (name, path, remaining args), or None
+    """
+    plugins = exec_plugins()
+    words = []
+    for arg in args:
+        if arg.startswith('-'):
+            break
+        words.append(arg)
+    for length in range(len(words), 0, -1):
+        name = '-'.join(words[:length])
+        if name in plugins:
+            return name, plugins[name], list(args[length:])
+    return None
+
+
+def _setup_logging(name: str, log_file: Optional[str], log_level: str) -> logging.Logger:
+    logger = logging.getLogger(f"virtbench.workload.{name}")
+    logger.setLevel(log_level)
+    formatter = logging.Formatter('%(asctime)s - %(levelname)s - %(message)s')
+    handlers = [logging.StreamHandler(sys.stdout)]
+    if log_file:
+        handlers.append(logging.FileHandler(log_file))
+    for handler in handlers:
+        handler.setFormatter(formatter)
+        logger.addHandler(handler)
+    return logger
+
+
+def save_summary(name: str, summary: Dict, results_folder: str, logger: logging.Logger) -> Path:
+    """Save a plugin workload's summary as <results_folder>/<name>/<timestamp>/summary_<name>_results.json."""
+    timestamp = datetime.now().strftime('%Y%m%d-%H%M%S')
+    out_dir = Path(results_folder) / name / timestamp
+    out_dir.mkdir(parents=True, exist_ok=True)
+    summary = dict(summary)
+    summary.setdefault('run', {
+        'uuid': os.environ.get('VIRTBENCH_UUID') or None,
+        'workload': name,
+        'saved_at': datetime.now().isoformat(timespec='seconds'),
+        'cluster': os.environ.get('VIRTBENCH_CLUSTER'),
+        'host': socket.gethostname(),
+    })
+    path = out_dir / f"summary_{name.replace('-', '_')}_results.json"
+    with open(path, 'w') as f:
+        json.dump(summary, f, indent=4)
+    logger.info(f"Saved summary to {path}")
+    return path
+
+
+def run(name: str, argv: List[str]) -> int:
+    """
+    Run a registered Python workload: setup, execute, collect, then cleanup with --cleanup.
+
+    Returns:
+        Exit code: 0, or 1 when the workload is unknown or a phase raised
+    """
+    workload_cls = load_plugins().get(name)
+    if workload_cls is None:
+        print(f"Error: unknown plugin workload '{name}'", file=sys.stderr)
+        for module, error in _load_errors.items():
+            print(f"  could not load {module}: {error}", file=sys.stderr)
+        return 1
+    workload = workload_cls()
+
+    parser = argparse.ArgumentParser(prog=f"virtbench {name}", description=workload.help)
+    parser.add_argument('--save-results', action='store_true', help='Save the run summary')
+    parser.add_argument('--results-folder', default='results', help='Base directory for results')
+    parser.add_argument('--cleanup', action='store_true', help='Delete created resources after the run')
+    parser.add_argument('--log-file', help='Log file path')
+    parser.add_argument('--log-level', default='INFO', type=str.upper,
+                        choices=['DEBUG', 'INFO', 'WARN', 'WARNING', 'ERROR'], help='Logging level')
+    workload.add_arguments(parser)
+    args = parser.parse_args(argv)
+    logger = _setup_logging(name, args.log_file, args.log_level)
+
+    status = 0
+    phase = 'setup'
+    try:
+        workload.setup(args, logger)
+        phase = 'execute'
+        workload.execute(args, logger)
+        phase = 'collect'
+        summary = workload.collect(args, logger)
+        if summary is not None and args.save_results:
+            save_summary(name, summary, args.results_folder, logger)
+    except KeyboardInterrupt:
+        logger.warning("Interrupted by user")
+        status = 130
+    except Exception as e:
+        logger.error(f"{name} failed in {phase}: {e}")
+        status = 1
+    finally:
+        if args.cleanup:
+            try:
+                workload.cleanup(args, logger)
+            except Exception as e:
+                logger.error(f"{name} cleanup failed: {e}")
+                status = status or 1
+    return status
+
+
+if __name__ == '__main__':
+    if len(sys.argv) < 2:
+        print("Usage: python3 -m virtbench.workload <name> [options]", file=sys.stderr)
+        sys.exit(1)
+    # Plugins register with the imported module, not with this __main__ copy
+    from virtbench.workload import run as run_workload
+    sys.exit(run_workload(sys.argv[1], sys.argv[2:]))
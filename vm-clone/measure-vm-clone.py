@@ -0,0 +1,536 @@
+#!/usr/bin/env python3
+"""
+KubeVirt VM Clone Benchmark
+
+Clones existing VMs with the VirtualMachineClone API (clone.kubevirt.io)
+and measures, per clone:
+
+  - clone time:   VirtualMachineClone creation until it reports Succeeded
+                  (source snapshot, target VM creation and volume restore)
+  - running time: clone completion until the target VM is Running
+  - boot time:    clone completion until the target guest answers ping
+  - total time:   clone time + boot time, i.e. request to usable VM
+
+Snapshot-based clones let CSI drivers with smart clone support copy volumes
+without moving data. Pass --compare-with a datasource-clone results folder
+to compare clone and creation-to-ping times against DataSource cloning of
+the same image.
+
+The source VMs must already exist (for example created with
+datasource-clone). Running sources are snapshotted online.
+
+Usage:
+    python3 measure-vm-clone.py --start 1 --end 20 --vm-name rhel-9-vm \\
+        --clones-per-vm 2 --save-results --cleanup
+
+Author: KubeVirt Benchmark Suite Contributors
+License: Apache 2.0
+"""
+
+import argparse
+import csv
+import json
+import os
+import sys
+import time
+from datetime import datetime
+from typing import Dict, List, Optional, Tuple
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
+
+from utils import timing
+from utils.concurrency import run_parallel, DEFAULT_QPS, DEFAULT_BURST
+from utils.common import (
+    setup_logging, run_kubectl_command, create_or_adopt, get_vm_status, get_vmi_ip,
+    check_guest_ready, start_vm, delete_vm, round_duration, set_run_workload,
+    run_metadata,
+)
+from utils.custommetrics import collect_custom_metrics
+from utils.events import collect_run_events, watch_events
+from utils.inventory import cluster_inventory, resolve_storage_driver
+from utils.guestexec import ensure_helper_pod
+from utils.dryrun import DryRunPlan, is_dry_run
+from utils.output import emit
+from utils.stats import log_outliers, metric_outliers, metric_stats, stat_columns
+from utils.notify import notify_run, watch_run, phase_status, percentile_metrics
+from utils.storageprovider import collect_storage_backend
+from utils.utilization import collect_node_samples, sample_nodes
+
+# Default configuration
+DEFAULT_VM_NAME = 'rhel-9-vm'
+DEFAULT_NAMESPACE_PREFIX = 'kubevirt-perf-test'
+DEFAULT_CLONES_PER_VM = 1
+DEFAULT_CONCURRENCY = 20
+DEFAULT_POLL_INTERVAL = 1
+DEFAULT_CLONE_TIMEOUT = 1800
+DEFAULT_PING_TIMEOUT = 600
+DEFAULT_SSH_POD = 'ssh-test-pod'
+DEFAULT_SSH_POD_NS = 'default'
+DEFAULT_CLONE_API_VERSION = 'clone.kubevirt.io/v1beta1'
+DATASOURCE_SUMMARY = 'summary_vm_creation_results.json'
+
+
+def parse_args():
+    parser = argparse.ArgumentParser(
+        description='Measure VirtualMachineClone completion and clone boot times',
+        formatter_class=argparse.RawDescriptionHelpFormatter,
+    )
+    parser.add_argument('--start', type=int, default=1, help='Starting namespace index (default: 1)')
+    parser.add_argument('--end', type=int, default=10, help='Ending namespace index (default: 10)')
+    parser.add_argument('--namespace-prefix', default=DEFAULT_NAMESPACE_PREFIX,
+                        help=f'Namespace prefix (default: {DEFAULT_NAMESPACE_PREFIX})')
+    parser.add_argument('--vm-name', default=DEFAULT_VM_NAME,
+                        help=f'Source VM name in each namespace (default: {DEFAULT_VM_NAME})')
+    parser.add_argument('--clones-per-vm', type=int, default=DEFAULT_CLONES_PER_VM,
+                        help=f'Clones created from each source VM (default: {DEFAULT_CLONES_PER_VM})')
+    parser.add_argument('--clone-api-version', default=DEFAULT_CLONE_API_VERSION,
+                        help=f'VirtualMachineClone apiVersion (default: {DEFAULT_CLONE_API_VERSION})')
+    parser.add_argument('--concurrency', '-c', type=int, default=DEFAULT_CONCURRENCY,
+                        help=f'Clones processed concurrently (default: {DEFAULT_CONCURRENCY})')
+    parser.add_argument('--qps', type=float, default=DEFAULT_QPS,
+                        help='Max clones started per second (default: 0 = unlimited)')
+    parser.add_argument('--burst', type=int, default=DEFAULT_BURST,
+                        help=f'Max clones started back-to-back when --qps is set (default: {DEFAULT_BURST})')
+    parser.add_argument('--poll-interval', type=int, default=DEFAULT_POLL_INTERVAL,
+                        help=f'Seconds between status checks (default: {DEFAULT_POLL_INTERVAL})')
+    parser.add_argument('--clone-timeout', type=int, default=DEFAULT_CLONE_TIMEOUT,
+                        help=f'Timeout for a clone to succeed in seconds (default: {DEFAULT_CLONE_TIMEOUT})')
+    parser.add_argument('--ping-timeout', type=int, default=DEFAULT_PING_TIMEOUT,
+                        help=f'Timeout for a clone to answer ping in seconds (default: {DEFAULT_PING_TIMEOUT})')
+    parser.add_argument('--skip-boot', action='store_true',
+                        help='Measure clone completion only; do not start the clones')
+    parser.add_argument('--ssh-pod', default=DEFAULT_SSH_POD,
+                        help=f'SSH helper pod used for ping (default: {DEFAULT_SSH_POD})')
+    parser.add_argument('--ssh-pod-ns', default=DEFAULT_SSH_POD_NS,
+                        help=f'SSH helper pod namespace (default: {DEFAULT_SSH_POD_NS})')
+    parser.add_argument('--compare-with', default=None,
+                        help='datasource-clone results folder (or its summary JSON) to compare against')
+    parser.add_argument('--cleanup', action='store_true',
+                        help='Delete the clones and VirtualMachineClone objects afterwards')
+    parser.add_argument('--precision', type=int, default=timing.DEFAULT_PRECISION,
+                        help=f'Decimal places for durations in saved results (default: {timing.DEFAULT_PRECISION})')
+    parser.add_argument('--save-results', action='store_true', help='Save results as JSON and CSV')
+    parser.add_argument('--results-folder', default='results',
+                        help='Base directory for results (default: results)')
+    parser.add_argument('--storage-driver', default=None,
+                        help='Storage driver label to include in the results path, or auto to detect it')
+    parser.add_argument('--log-file', default=None, help='Log file path')
+    parser.add_argument('--log-level', default='INFO',
+                        choices=['DEBUG', 'INFO', 'WARNING', 'ERROR'], help='Logging level (default: INFO)')
+    args = parser.parse_args()
+
+    if args.clones_per_vm < 1:
+        parser.error("--clones-per-vm must be >= 1")
+    if args.compare_with and not os.path.exists(args.compare_with):
+        parser.error(f"--compare-with not found: {args.compare_with}")
+    return args
+
+
+def build_results_dir(args, timestamp: Optional[str] = None) -> str:
+    """Build the canonical vm-clone results directory."""
+    timestamp = timestamp or datetime.now().strftime("%Y%m%d-%H%M%S")
+    suffix = f"{args.namespace_prefix}_{args.start}-{args.end}"
+    if args.storage_driver:
+        return os.path.join(args.results_folder, args.storage_driver, 'vm-clone', f"{timestamp}_{suffix}")
+    return os.path.join(args.results_folder, 'vm-clone', f"{timestamp}_{suffix}")
+
+
+def clone_name(vm_name: str, index: int) -> str:
+    """Name of the index-th clone of a VM (used for both the target VM and the clone object)."""
+    return f"{vm_name}-clone-{index}"
+
+
+def vm_clone_manifest(name: str, namespace: str, source_vm: str, api_version: str) -> str:
+    """VirtualMachineClone manifest that clones source_vm into a new VM called name."""
+    return f"""apiVersion: {api_version}
+kind: VirtualMachineClone
+metadata:
+  name: {name}
+  namespace: {namespace}
+  labels:
+    app: kubevirt-perf-test
+spec:
+  source:
+    apiGroup: kubevirt.io
+    kind: VirtualMachine
+    name: {source_vm}
+  target:
+    apiGroup: kubevirt.io
+    kind: VirtualMachine
+    name: {name}
+"""
+
+
+def create_vm_clone(name: str, namespace: str, source_vm: str, api_version: str, logger) -> bool:
+    """Create a VirtualMachineClone that clones source_vm into a new VM called name."""
+    manifest = vm_clone_manifest(name, namespace, source_vm, api_version)
+    created, _, error = create_or_adopt(manifest, logger=logger)
+    if not created:
+        logger.error(f"[{namespace}] Failed to create VirtualMachineClone {name}: {error}")
+        return False
+    return True
+
+
+def wait_for_clone(name: str, namespace: str, timeout: int, poll_interval: int) -> Tuple[Optional[str], str]:
+    """
+    Wait for a VirtualMachineClone to finish.
+
+    Returns:
+        Tuple of (final phase or None on timeout, last phase seen)
+    """
+    last_phase = ''
+    deadline = time.monotonic() + timeout
+    while time.monotonic() < deadline:
+        rc, out, _ = run_kubectl_command(
+            ['get', 'vmclone', name, '-n', namespace, '-o', 'jsonpath={.status.phase}'], check=False
+        )
+        if rc == 0:
+            last_phase = out.strip()
+            if last_phase in ('Succeeded', 'Failed'):
+                return last_phase, last_phase
+        time.sleep(poll_interval)
+    return None, last_phase
+
+
+def wait_for_clone_boot(vm_name: str, namespace: str, started: float, args, logger) -> Tuple[Optional[float], Optional[float]]:
+    """
+    Wait for a cloned VM to reach Running and answer ping.
+
+    Returns:
+        Tuple of (running seconds, ping seconds) measured from started
+        (time.monotonic()); either is None on timeout
+    """
+    running_sec = None
+    deadline = started + args.ping_timeout
+    while time.monotonic() < deadline:
+        if get_vm_status(vm_name, namespace, logger) == 'Running':
+            running_sec = time.monotonic() - started
+            break
+        time.sleep(args.poll_interval)
+    if running_sec is None:
+        return None, None
+
+    while time.monotonic() < deadline:
+        ip = get_vmi_ip(vm_name, namespace, logger)
+        if ip and check_guest_ready(ip, args.ssh_pod, args.ssh_pod_ns, logger=logger):
+            return running_sec, time.monotonic() - started
+        time.sleep(args.poll_interval)
+    return running_sec, None
+
+
+def clone_vm(item: Tuple[str, int], args, logger) -> Dict:
+    """Clone the source VM of one namespace once and time the clone and its boot."""
+    namespace, index = item
+    name = clone_name(args.vm_name, index)
+    row = {
+        'namespace': namespace, 'source_vm': args.vm_name, 'clone': name,
+        'clone_sec': None, 'running_sec': None, 'boot_sec': None, 'total_sec': None,
+        'success': False, 'error': None,
+    }
+
+    started = time.monotonic()
+    if not create_vm_clone(name, namespace, args.vm_name, args.clone_api_version, logger):
+        row['error'] = 'clone not created'
+        return row
+
+    phase, last_phase = wait_for_clone(name, namespace, args.clone_timeout, args.poll_interval)
+    if phase != 'Succeeded':
+        row['error'] = 'clone failed' if phase == 'Failed' else f"clone timed out ({last_phase or 'no phase'})"
+        logger.error(f"[{namespace}] {name}: {row['error']}")
+        return row
+    row['clone_sec'] = time.monotonic() - started
+    logger.info(f"[{namespace}] {name} cloned in {row['clone_sec']:.2f}s")
+
+    if args.skip_boot:
+        row['success'] = True
+        return row
+
+    # Clones keep the source run strategy; setting Always is a no-op for running sources
+    booted = time.monotonic()
+    start_vm(name, namespace, logger)
+    row['running_sec'], row['boot_sec'] = wait_for_clone_boot(name, namespace, booted, args, logger)
+    if row['boot_sec'] is None:
+        row['error'] = 'clone did not boot' if row['running_sec'] is None else 'clone not reachable'
+        logger.error(f"[{namespace}] {name}: {row['error']}")
+        return row
+
+    row['total_sec'] = row['clone_sec'] + row['boot_sec']
+    row['success'] = True
+    logger.info(f"[{namespace}] {name} reachable {row['boot_sec']:.2f}s after cloning")
+    return row
+
+
+def cleanup_clone(item: Tuple[str, int], args, logger) -> bool:
+    """Delete one clone VM and its VirtualMachineClone object."""
+    namespace, index = item
+    name = clone_name(args.vm_name, index)
+    run_kubectl_command(['delete', 'vmclone', name, '-n', namespace, '--ignore-not-found'],
+                        check=False, logger=logger)
+    return delete_vm(name, namespace, logger)
+
+
+METRICS = ['clone_sec', 'running_sec', 'boot_sec', 'total_sec']
+
+
+def clone_outliers(results: List[Dict]) -> List[Dict]:
+    """Clones more than --outlier-sigma standard deviations slower than the mean, per metric."""
+    return metric_outliers([r for r in results if r['success']], METRICS,
+                           lambda r: f"{r['namespace']}/{r['clone']}")
+
+
+def load_datasource_summary(path: str) -> Optional[Dict]:
+    """Load a datasource-clone summary from a results folder or summary JSON path."""
+    if os.path.isdir(path):
+        path = os.path.join(path, DATASOURCE_SUMMARY)
+    try:
+        with open(path) as f:
+            return json.load(f)
+    except (OSError, ValueError):
+        return None
+
+
+def compare_with_datasource(metrics: List[Dict], datasource: Dict, source: str) -> Dict:
+    """
+    Compare VM clone averages with a datasource-clone run.
+
+    DataSource clone_duration_sec is set against clone_sec (volume copy), and
+    ping_time_sec (creation to ping) against total_sec (clone request to
+    ping). Speedups above 1 mean VirtualMachineClone was faster.
+    """
+    ours = {m['metric']: m['avg'] for m in metrics}
+    theirs = {m['metric']: m['avg'] for m in datasource.get('metrics', [])}
+
+    def speedup(baseline, value):
+        return round(baseline / value, 2) if baseline and value else None
+
+    return {
+        'datasource_results': source,
+        'datasource_clone_sec': theirs.get('clone_duration_sec'),
+        'vm_clone_sec': ours.get('clone_sec'),
+        'clone_speedup': speedup(theirs.get('clone_duration_sec'), ours.get('clone_sec')),
+        'datasource_ready_sec': theirs.get('ping_time_sec'),
+        'vm_clone_ready_sec': ours.get('total_sec'),
+        'ready_speedup': speedup(theirs.get('ping_time_sec'), ours.get('total_sec')),
+    }
+
+
+def print_summary(results: List[Dict], metrics: List[Dict], comparison: Optional[Dict],
+                  total_time: float, logger) -> None:
+    def fmt(value):
+        return f"{value:.2f}" if value is not None else '-'
+
+    logger.info("")
+    logger.info("=" * 110)
+    logger.info(f"{'Namespace':<28}{'Clone':<26}{'Clone(s)':<11}{'Running(s)':<12}"
+                f"{'Boot(s)':<10}{'Total(s)':<10}{'Status':<16}")
+    logger.info("-" * 110)
+    for r in sorted(results, key=lambda x: (x['namespace'], x['clone'])):
+        status = 'Success' if r['success'] else (r['error'] or 'Failed')
+        logger.info(f"{r['namespace']:<28}{r['clone']:<26}{fmt(r['clone_sec']):<11}"
+                    f"{fmt(r['running_sec']):<12}{fmt(r['boot_sec']):<10}{fmt(r['total_sec']):<10}"
+                    f"{status:<16}")
+    logger.info("=" * 110)
+
+    successful = sum(1 for r in results if r['success'])
+    logger.info(f"  Clones:                 {len(results)}")
+    logger.info(f"  Successful:             {successful}")
+    logger.info(f"  Failed:                 {len(results) - successful}")
+    labels = {
+        'clone_sec': 'Clone time',
+        'running_sec': 'Clone to Running',
+        'boot_sec': 'Clone to ping',
+        'total_sec': 'Request to ping',
+    }
+    for m in metrics:
+        if m['count']:
+            logger.info(f"  {labels[m['metric']] + ':':<24}avg {m['avg']}s, "
+                        f"min {m['min']}s, max {m['max']}s, p95 {m['p95']}s, p99 {m['p99']}s "
+                        f"({m['count']} clones)")
+    if comparison:
+        logger.info(f"  vs DataSource clone:    clone {fmt(comparison['datasource_clone_sec'])}s -> "
+                    f"{fmt(comparison['vm_clone_sec'])}s (x{comparison['clone_speedup'] or '-'}), "
+                    f"ready {fmt(comparison['datasource_ready_sec'])}s -> "
+                    f"{fmt(comparison['vm_clone_ready_sec'])}s (x{comparison['ready_speedup'] or '-'})")
+    logger.info(f"  Total test duration:    {total_time:.2f}s")
+    logger.info("=" * 110)
+    log_outliers(clone_outliers(results), logger)
+
+
+def clone_summary(args, results: List[Dict], metrics: List[Dict], comparison: Optional[Dict],
+                  total_time: float, timing_block: Optional[Dict] = None) -> Dict:
+    """Summary of the run: clone counts, metric statistics and the DataSource comparison."""
+    summary = {
+        'total_clones': len(results),
+        'successful': sum(1 for r in results if r['success']),
+        'failed': sum(1 for r in results if not r['success']),
+        'vms': len({r['namespace'] for r in results}),
+        'clones_per_vm': args.clones_per_vm,
+        'concurrency': args.concurrency,
+        'total_test_duration_sec': round_duration(total_time),
+        'metrics': metrics,
+        'outliers': clone_outliers(results),
+    }
+    if timing_block:
+        summary['timing'] = timing_block
+    if comparison:
+        summary['datasource_comparison'] = comparison
+    return summary
+
+
+def save_clone_results(out_dir: str, args, results: List[Dict], metrics: List[Dict],
+                       comparison: Optional[Dict], total_time: float, timing_block: Dict,
+                       logger) -> None:
+    """Write per-clone results and the summary as JSON and CSV."""
+    os.makedirs(out_dir, exist_ok=True)
+    rows = []
+    for r in sorted(results, key=lambda x: (x['namespace'], x['clone'])):
+        row = dict(r)
+        for m in METRICS:
+            row[m] = round_duration(row[m])
+        rows.append(row)
+
+    with open(os.path.join(out_dir, 'vm_clone_results.json'), 'w') as f:
+        json.dump(rows, f, indent=4)
+    with open(os.path.join(out_dir, 'vm_clone_results.csv'), 'w', newline='') as f:
+        writer = csv.DictWriter(f, fieldnames=rows[0].keys())
+        writer.writeheader()
+        writer.writerows(rows)
+
+    summary = clone_summary(args, results, metrics, comparison, total_time, timing_block)
+    custom_metrics = collect_custom_metrics(timing_block, logger)
+    if custom_metrics is not None:
+        summary['custom_metrics'] = custom_metrics
+    run_events = collect_run_events(out_dir, logger, summary.get('outliers'))
+    if run_events is not None:
+        summary['events'] = run_events
+    node_utilization = collect_node_samples(out_dir, logger)
+    if node_utilization is not None:
+        summary['node_utilization'] = node_utilization
+    storage_backend = collect_storage_backend(out_dir, args.namespace_prefix, logger)
+    if storage_backend is not None:
+        summary['storage_backend'] = storage_backend
+    summary['cluster'] = cluster_inventory(logger)
+    summary['run'] = run_metadata()
+    with open(os.path.join(out_dir, 'summary_vm_clone_results.json'), 'w') as f:
+        json.dump(summary, f, indent=4)
+    with open(os.path.join(out_dir, 'summary_vm_clone_results.csv'), 'w', newline='') as f:
+        writer = csv.DictWriter(f, fieldnames=stat_columns('metric', 'avg', 'max', 'min', 'count'))
+        writer.writeheader()
+        writer.writerows(metrics)
+    logger.info(f"Results saved under: {out_dir}")
+
+
+def plan_run(items: List[Tuple[str, int]], args, logger):
+    """Print the clones main() would create, start and delete (virtbench --dry-run)."""
+    plan = DryRunPlan('vm-clone', logger)
+    for namespace, index in items:
+        name = clone_name(args.vm_name, index)
+        plan.apply(vm_clone_manifest(name, namespace, args.vm_name, args.clone_api_version), namespace,
+                   detail=f"from vm/{namespace}/{args.vm_name}")
+        if not args.skip_boot:
+            plan.action('start', f"vm/{namespace}/{name}")
+    if args.cleanup:
+        for namespace, index in items:
+            name = clone_name(args.vm_name, index)
+            plan.action('delete', f"vmclone/{namespace}/{name}")
+            plan.action('delete', f"vm/{namespace}/{name}")
+    plan.report()
+
+
+def main():
+    args = parse_args()
+    set_run_workload('vm-clone')
+    args.storage_driver = resolve_storage_driver(args.storage_driver)
+    dry_run = is_dry_run()
+    if dry_run:
+        # A dry run has no results to save
+        args.save_results = False
+
+    out_dir = None
+    if args.save_results:
+        out_dir = build_results_dir(args)
+        os.makedirs(out_dir, exist_ok=True)
+        if not args.log_file:
+            args.log_file = os.path.join(out_dir, 'vm-clone.log')
+
+    logger = setup_logging(args.log_file, args.log_level)
+    watch_run('vm-clone', logger)
+    watch_events(args.namespace_prefix, logger)
+    sample_nodes(logger)
+    timing.set_precision(args.precision)
+
+    datasource = None
+    if args.compare_with:
+        datasource = load_datasource_summary(args.compare_with)
+        if datasource is None:
+            logger.error(f"Could not read a datasource-clone summary from {args.compare_with}")
+            sys.exit(1)
+
+    logger.info("=" * 80)
+    logger.info("KubeVirt VM Clone Benchmark")
+    logger.info("=" * 80)
+    logger.info(f"Namespaces: {args.namespace_prefix}-{args.start} to {args.namespace_prefix}-{args.end}")
+    logger.info(f"Source VM: {args.vm_name}")
+    logger.info(f"Clones per VM: {args.clones_per_vm}")
+    logger.info(f"Concurrency: {args.concurrency}")
+    logger.info(f"Boot check: {'disabled' if args.skip_boot else 'enabled'}")
+    logger.info("=" * 80)
+
+    # ensure_helper_pod creates the SSH pod when it is missing
+    if not args.skip_boot and not dry_run:
+        ready, _ = ensure_helper_pod(args.ssh_pod, args.ssh_pod_ns, logger=logger)
+        if not ready:
+            logger.error("SSH pod unavailable; re-run with --skip-boot to measure clone time only")
+            sys.exit(1)
+
+    items = [(f"{args.namespace_prefix}-{i}", n)
+             for i in range(args.start, args.end + 1)
+             for n in range(1, args.clones_per_vm + 1)]
+    if dry_run:
+        plan_run(items, args, logger)
+        sys.exit(0)
+
+    clock_skew = timing.estimate_clock_skew(logger=logger) if args.save_results else None
+    if args.save_results:
+        cluster_inventory(logger)
+    test_start = timing.now()
+
+    results: List[Dict] = []
+    try:
+        outcomes = run_parallel(clone_vm, items, concurrency=args.concurrency,
+                                qps=args.qps, burst=args.burst, args=(args, logger),
+                                logger=logger, description="VM clone")
+        for _, row, error in outcomes:
+            if error is None:
+                results.append(row)
+        total_time = (timing.now() - test_start).total_seconds()
+    finally:
+        if args.cleanup:
+            logger.info(f"Deleting {len(items)} clones...")
+            run_parallel(cleanup_clone, items, concurrency=args.concurrency,
+                         qps=args.qps, burst=args.burst, args=(args, logger),
+                         logger=logger, description="clone cleanup")
+
+    if not results:
+        logger.error("No clones were processed")
+        sys.exit(1)
+
+    metrics = [metric_stats(m, [r[m] for r in results if r[m] is not None]) for m in METRICS]
+    comparison = compare_with_datasource(metrics, datasource, args.compare_with) if datasource else None
+    print_summary(results, metrics, comparison, total_time, logger)
+    if args.save_results:
+        save_clone_results(out_dir, args, results, metrics, comparison, total_time,
+                           timing.timing_metadata(test_start, clock_skew=clock_skew), logger)
+    emit('vm-clone-summary', clone_summary(args, results, metrics, comparison, total_time))
+
+    failed = sum(1 for r in results if not r['success'])
+    run_metrics = {'clones': len(results), 'failed': failed}
+    run_metrics.update(percentile_metrics('total_sec', [r['total_sec'] for r in results if r['success']]))
+    notify_run('vm-clone', run_metrics, phase_status(failed, len(results)), out_dir, logger)
+
+    sys.exit(0 if all(r['success'] for r in results) else 2)
+
+
+if __name__ == '__main__':
+    main()
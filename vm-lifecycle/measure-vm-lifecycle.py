@@ -0,0 +1,638 @@
+#!/usr/bin/env python3
+"""
+KubeVirt VM Lifecycle Micro-Benchmark
+
+Measures each VM lifecycle verb on its own and produces a baseline
+operation-latency table:
+
+  - create:   create the VM definition (runStrategy Halted) until it is Stopped
+  - start:    runStrategy Always until the VM is Running
+  - pause:    `virtctl pause` until the VM is Paused
+  - unpause:  `virtctl unpause` until the VM is Running again
+  - stop:     runStrategy Halted until the VM is Stopped
+  - restart:  `virtctl restart` until a new VMI is Running
+  - delete:   delete the VM until the object is gone
+
+Verbs run as separate phases: every VM completes one verb before the next
+verb starts, so operations never overlap with a different verb. Each
+operation records the API call time and the time until the new state is
+observed. Run with --concurrency 1 for fully serialized measurements, or
+higher to see how each verb scales. --verbs picks the verbs and their order
+(default: all but restart).
+
+With --existing-vms, the verbs run against VMs that already exist (e.g. from
+datasource-clone) instead of VMs the benchmark creates: create and delete are
+not available, VMs not in the state the first verb needs are skipped, and
+every VM is returned to its initial state (Running or Stopped) afterwards.
+--mix replaces the phases with an operation mix: in each of --iterations
+rounds, every VM runs one verb drawn by weight from those valid in its
+current state, so different verbs overlap.
+
+Usage:
+    python3 measure-vm-lifecycle.py --start 1 --end 10 \\
+        --vm-template ../examples/vm-templates/rhel9-vm-datasource.yaml \\
+        --iterations 3 --save-results --cleanup
+
+    python3 measure-vm-lifecycle.py --existing-vms --namespace-prefix datasource-clone \\
+        --start 1 --end 100 --mix restart=2 pause=1 unpause=1 stop=1 start=1 --iterations 10
+
+Author: KubeVirt Benchmark Suite Contributors
+License: Apache 2.0
+"""
+
+import argparse
+import csv
+import json
+import os
+import random
+import subprocess
+import sys
+import time
+from datetime import datetime
+from typing import Dict, List, Optional
+
+import yaml
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
+
+from utils import timing
+from utils.concurrency import run_parallel, DEFAULT_QPS, DEFAULT_BURST
+from utils.common import (
+    _kubectl_with_input, setup_logging, run_kubectl_command, create_namespaces_parallel, get_vm_status,
+    start_vm, stop_vm, cleanup_test_namespaces, print_cleanup_summary, round_duration,
+    stamp_manifest, set_run_workload, run_selector,
+    run_metadata,
+)
+from utils.custommetrics import collect_custom_metrics
+from utils.events import collect_run_events, watch_events
+from utils.inventory import cluster_inventory, resolve_storage_driver
+from utils.dryrun import DryRunPlan, is_dry_run
+from utils.notify import notify_run, watch_run, phase_status
+from utils.stats import log_outliers, metric_outliers, metric_stats, percentile
+from utils.storageprovider import collect_storage_backend
+from utils.utilization import collect_node_samples, sample_nodes
+
+# Default configuration
+DEFAULT_VM_NAME = 'rhel-9-vm'
+DEFAULT_VM_YAML = '../examples/vm-templates/rhel9-vm-datasource.yaml'
+DEFAULT_NAMESPACE_PREFIX = 'virtbench-lifecycle'
+DEFAULT_ITERATIONS = 1
+DEFAULT_CONCURRENCY = 10
+DEFAULT_POLL_INTERVAL = 0.5
+DEFAULT_TIMEOUT = 600
+# Millisecond resolution by default; verbs such as pause complete in well under a second
+DEFAULT_PRECISION = 3
+VERBS = ['create', 'start', 'pause', 'unpause', 'stop', 'restart', 'delete']
+DEFAULT_VERBS = ['create', 'start', 'pause', 'unpause', 'stop', 'delete']
+# Existing VMs are neither created nor deleted; they start out Running
+EXISTING_VERBS = ['start', 'pause', 'unpause', 'stop', 'restart']
+DEFAULT_EXISTING_VERBS = ['stop', 'start', 'restart', 'pause', 'unpause']
+# printableStatus that completes each verb (None: the VM object is gone)
+TARGET_STATUS = {
+    'create': 'Stopped',
+    'start': 'Running',
+    'pause': 'Paused',
+    'unpause': 'Running',
+    'stop': 'Stopped',
+    'restart': 'Running',
+    'delete': None,
+}
+# printableStatus a VM needs for each verb of existing VMs and --mix
+REQUIRED_STATUS = {
+    'start': 'Stopped',
+    'pause': 'Running',
+    'unpause': 'Paused',
+    'stop': 'Running',
+    'restart': 'Running',
+}
+
+
+def parse_args():
+    parser = argparse.ArgumentParser(
+        description='Measure the latency of individual KubeVirt VM lifecycle operations',
+        formatter_class=argparse.RawDescriptionHelpFormatter,
+    )
+    parser.add_argument('--start', type=int, default=1, help='Starting namespace index (default: 1)')
+    parser.add_argument('--end', type=int, default=10, help='Ending namespace index (default: 10)')
+    parser.add_argument('--namespace-prefix', default=DEFAULT_NAMESPACE_PREFIX,
+                        help=f'Namespace prefix (default: {DEFAULT_NAMESPACE_PREFIX})')
+    parser.add_argument('--vm-name', default=DEFAULT_VM_NAME,
+                        help=f'VM name in the template (default: {DEFAULT_VM_NAME})')
+    parser.add_argument('--vm-template', default=DEFAULT_VM_YAML,
+                        help=f'VM template YAML (default: {DEFAULT_VM_YAML})')
+    parser.add_argument('--iterations', type=int, default=DEFAULT_ITERATIONS,
+                        help=f'Full lifecycles per VM, or rounds with --mix (default: {DEFAULT_ITERATIONS})')
+    parser.add_argument('--verbs', nargs='+', choices=VERBS, default=None,
+                        help=f'Verbs to run, in order (default: {" ".join(DEFAULT_VERBS)}, or '
+                             f'{" ".join(DEFAULT_EXISTING_VERBS)} with --existing-vms)')
+    parser.add_argument('--existing-vms', action='store_true',
+                        help='Operate on existing VMs instead of creating them; they are returned to '
+                             'their initial state afterwards')
+    parser.add_argument('--mix', nargs='+', default=None, metavar='VERB[=WEIGHT]',
+                        help='With --existing-vms: in each round every VM runs one verb drawn by weight '
+                             'from those valid in its state, e.g. restart=2 pause=1 unpause=1')
+    parser.add_argument('--seed', type=int, default=None, help='Random seed of --mix (default: random)')
+    parser.add_argument('--concurrency', '-c', type=int, default=DEFAULT_CONCURRENCY,
+                        help=f'VMs operated on concurrently within a verb (default: {DEFAULT_CONCURRENCY})')
+    parser.add_argument('--qps', type=float, default=DEFAULT_QPS,
+                        help='Max operations started per second (default: 0 = unlimited)')
+    parser.add_argument('--burst', type=int, default=DEFAULT_BURST,
+                        help=f'Max operations started back-to-back when --qps is set (default: {DEFAULT_BURST})')
+    parser.add_argument('--poll-interval', type=float, default=DEFAULT_POLL_INTERVAL,
+                        help=f'Seconds between status checks (default: {DEFAULT_POLL_INTERVAL})')
+    parser.add_argument('--timeout', type=int, default=DEFAULT_TIMEOUT,
+                        help=f'Timeout per operation in seconds (default: {DEFAULT_TIMEOUT})')
+    parser.add_argument('--skip-namespace-creation', action='store_true',
+                        help='Use existing namespaces')
+    parser.add_argument('--cleanup', action='store_true',
+                        help='Delete the test namespaces afterwards')
+    parser.add_argument('--precision', type=int, default=DEFAULT_PRECISION,
+                        help=f'Decimal places for durations in saved results (default: {DEFAULT_PRECISION})')
+    parser.add_argument('--save-results', action='store_true', help='Save results as JSON and CSV')
+    parser.add_argument('--results-folder', default='results',
+                        help='Base directory for results (default: results)')
+    parser.add_argument('--storage-driver', default=None,
+                        help='Storage driver label to include in the results path, or auto to detect it')
+    parser.add_argument('--log-file', default=None, help='Log file path')
+    parser.add_argument('--log-level', default='INFO',
+                        choices=['DEBUG', 'INFO', 'WARNING', 'ERROR'], help='Logging level (default: INFO)')
+    args = parser.parse_args()
+
+    if args.iterations < 1:
+        parser.error("--iterations must be >= 1")
+    if args.poll_interval <= 0:
+        parser.error("--poll-interval must be > 0")
+    if args.existing_vms:
+        if args.cleanup:
+            parser.error("--cleanup would delete the existing VMs; it cannot be combined with --existing-vms")
+        args.verbs = args.verbs or DEFAULT_EXISTING_VERBS
+        invalid = [v for v in args.verbs if v not in EXISTING_VERBS]
+        if invalid:
+            parser.error(f"--existing-vms supports {', '.join(EXISTING_VERBS)}, not {', '.join(invalid)}")
+    else:
+        if args.mix:
+            parser.error("--mix requires --existing-vms")
+        args.verbs = args.verbs or DEFAULT_VERBS
+        if not os.path.exists(args.vm_template):
+            parser.error(f"VM template file not found: {args.vm_template}")
+    args.mix_weights = None
+    if args.mix:
+        try:
+            args.mix_weights = parse_mix(args.mix)
+        except ValueError as e:
+            parser.error(f"--mix: {e}")
+        args.verbs = [v for v in EXISTING_VERBS if v in args.mix_weights]
+    return args
+
+
+def parse_mix(mix: List[str]) -> Dict[str, float]:
+    """'verb[=weight]' entries as {verb: weight}; raises ValueError on unknown verbs or bad weights."""
+    weights = {}
+    for entry in mix:
+        verb, _, weight = entry.partition('=')
+        if verb not in EXISTING_VERBS:
+            raise ValueError(f"unknown verb {verb!r} (choose from {', '.join(EXISTING_VERBS)})")
+        try:
+            weights[verb] = float(weight) if weight else 1.0
+        except ValueError:
+            raise ValueError(f"weight of {verb} is not a number: {weight!r}")
+        if weights[verb] <= 0:
+            raise ValueError(f"weight of {verb} must be > 0")
+    return weights
+
+
+def pick_verb(status: Optional[str], weights: Dict[str, float], rng: random.Random) -> Optional[str]:
+    """
+    A verb of the mix valid in the VM's status, drawn by weight.
+
+    When no verb of the mix is valid (e.g. a mix of only pause leaves the VM
+    Paused), the verb that makes the VM Running again is returned; None when
+    the status allows no verb at all.
+    """
+    valid = [v for v in weights if REQUIRED_STATUS[v] == status]
+    if valid:
+        return rng.choices(valid, weights=[weights[v] for v in valid])[0]
+    return {'Stopped': 'start', 'Paused': 'unpause'}.get(status)
+
+
+def build_results_dir(args, timestamp: Optional[str] = None) -> str:
+    """Build the canonical vm-lifecycle results directory."""
+    timestamp = timestamp or datetime.now().strftime("%Y%m%d-%H%M%S")
+    suffix = f"{args.namespace_prefix}_{args.start}-{args.end}"
+    if args.storage_driver:
+        return os.path.join(args.results_folder, args.storage_driver, 'vm-lifecycle', f"{timestamp}_{suffix}")
+    return os.path.join(args.results_folder, 'vm-lifecycle', f"{timestamp}_{suffix}")
+
+
+def render_halted_vm(vm_yaml: str) -> str:
+    """Return the template with the VM set to runStrategy Halted, so create and start are timed apart."""
+    with open(vm_yaml) as f:
+        docs = [doc for doc in yaml.safe_load_all(f) if doc]
+    for doc in docs:
+        if doc.get('kind') == 'VirtualMachine':
+            spec = doc.setdefault('spec', {})
+            spec.pop('running', None)
+            spec['runStrategy'] = 'Halted'
+    return yaml.safe_dump_all(docs, sort_keys=False)
+
+
+def virtctl(action: str, vm_name: str, namespace: str) -> None:
+    """Run `virtctl pause|unpause|restart vm`; raises RuntimeError on failure."""
+    try:
+        result = subprocess.run(['virtctl', action, 'vm', vm_name, '-n', namespace],
+                                capture_output=True, text=True, timeout=60)
+    except (subprocess.TimeoutExpired, FileNotFoundError) as e:
+        raise RuntimeError(f"virtctl {action} failed: {e}")
+    if result.returncode != 0:
+        raise RuntimeError(f"virtctl {action} failed: {result.stderr.strip()}")
+
+
+def vm_gone(vm_name: str, namespace: str) -> bool:
+    rc, _, err = run_kubectl_command(['get', 'vm', vm_name, '-n', namespace], check=False)
+    return rc != 0 and 'NotFound' in err
+
+
+def vmi_uid(vm_name: str, namespace: str) -> Optional[str]:
+    """UID of the VM's current VMI, None when it has none; a restart replaces the VMI."""
+    rc, stdout, _ = run_kubectl_command(['get', 'vmi', vm_name, '-n', namespace,
+                                         '-o', 'jsonpath={.metadata.uid}'], check=False)
+    if rc != 0:
+        return None
+    return stdout.strip() or None
+
+
+def issue_verb(verb: str, vm_name: str, namespace: str, manifest: str, logger) -> None:
+    """Send the API request for one verb; raises RuntimeError if it is rejected."""
+    if verb == 'create':
+        returncode, _, stderr = _kubectl_with_input(['create', '-f', '-', '-n', namespace],
+                                                    stamp_manifest(manifest, namespace, logger), logger)
+        if returncode != 0:
+            raise RuntimeError(f"create failed: {stderr.strip()}")
+    elif verb == 'start':
+        if not start_vm(vm_name, namespace, logger):
+            raise RuntimeError("start failed")
+    elif verb in ('pause', 'unpause', 'restart'):
+        virtctl(verb, vm_name, namespace)
+    elif verb == 'stop':
+        if not stop_vm(vm_name, namespace, logger):
+            raise RuntimeError("stop failed")
+    elif verb == 'delete':
+        rc, _, err = run_kubectl_command(['delete', 'vm', vm_name, '-n', namespace, '--wait=false'],
+                                         check=False, logger=logger)
+        if rc != 0:
+            raise RuntimeError(f"delete failed: {err.strip()}")
+
+
+def run_verb(namespace: str, verb: str, iteration: int, args, manifest: str, logger) -> Dict:
+    """Issue one verb for the VM of a namespace and time it until the target state is observed."""
+    row = {'namespace': namespace, 'iteration': iteration, 'verb': verb,
+           'api_sec': None, 'total_sec': None, 'success': False, 'error': None}
+    old_vmi = vmi_uid(args.vm_name, namespace) if verb == 'restart' else None
+    started = timing.now()
+    try:
+        issue_verb(verb, args.vm_name, namespace, manifest, logger)
+    except RuntimeError as e:
+        row['error'] = str(e)
+        logger.error(f"[{namespace}] {verb}: {e}")
+        return row
+    row['api_sec'] = (timing.now() - started).total_seconds()
+
+    target = TARGET_STATUS[verb]
+    deadline = time.monotonic() + args.timeout
+    while time.monotonic() < deadline:
+        if target is None:
+            reached = vm_gone(args.vm_name, namespace)
+        else:
+            reached = get_vm_status(args.vm_name, namespace) == target
+            if reached and verb == 'restart':
+                # The old VMI may still report Running until it is replaced
+                reached = vmi_uid(args.vm_name, namespace) not in (None, old_vmi)
+        if reached:
+            row['total_sec'] = (timing.now() - started).total_seconds()
+            row['success'] = True
+            logger.debug(f"[{namespace}] {verb} took {row['total_sec']:.3f}s")
+            return row
+        time.sleep(args.poll_interval)
+
+    row['error'] = f"timed out waiting for {target or 'deletion'}"
+    logger.error(f"[{namespace}] {verb}: {row['error']}")
+    return row
+
+
+def run_mixed(namespace: str, iteration: int, args, statuses: Dict[str, Optional[str]],
+              rng: random.Random, logger) -> Dict:
+    """Run one verb of the --mix, valid in the VM's current status, and time it."""
+    verb = pick_verb(statuses[namespace], args.mix_weights, rng)
+    if verb is None:
+        return {'namespace': namespace, 'iteration': iteration, 'verb': None, 'api_sec': None,
+                'total_sec': None, 'success': False, 'error': f"VM is {statuses[namespace]}"}
+    row = run_verb(namespace, verb, iteration, args, '', logger)
+    statuses[namespace] = TARGET_STATUS[verb] if row['success'] else get_vm_status(args.vm_name, namespace)
+    return row
+
+
+def existing_statuses(namespaces: List[str], vm_name: str, logger) -> Dict[str, Optional[str]]:
+    """printableStatus of the existing VM of every namespace (None when it does not exist)."""
+    outcomes = run_parallel(lambda ns: get_vm_status(vm_name, ns), namespaces, concurrency=20,
+                            logger=logger, description="VM status")
+    return {ns: status for ns, status, _ in outcomes}
+
+
+def restore_vms(initial: Dict[str, Optional[str]], args, logger):
+    """Return the existing VMs to their initial Running or Stopped state, untimed."""
+    def restore(namespace: str):
+        status = get_vm_status(args.vm_name, namespace)
+        verbs = {
+            ('Running', 'Paused'): ['unpause'],
+            ('Running', 'Stopped'): ['start'],
+            ('Stopped', 'Running'): ['stop'],
+            ('Stopped', 'Paused'): ['stop'],
+        }.get((initial[namespace], status), [])
+        for verb in verbs:
+            issue_verb(verb, args.vm_name, namespace, '', logger)
+        return verbs
+
+    logger.info("Returning the VMs to their initial state...")
+    outcomes = run_parallel(restore, [ns for ns, status in initial.items() if status in ('Running', 'Stopped')],
+                            concurrency=args.concurrency, qps=args.qps, burst=args.burst,
+                            logger=logger, description="VM restore")
+    for ns, verbs, error in outcomes:
+        if error is not None:
+            logger.warning(f"[{ns}] Could not return the VM to {initial[ns]}: {error}")
+        elif verbs:
+            logger.debug(f"[{ns}] Restored with {', '.join(verbs)}")
+
+
+def verb_stats(results: List[Dict], verbs: List[str]) -> List[Dict]:
+    """Latency statistics per verb (operation total time) plus mean API call time."""
+    stats = []
+    for verb in verbs:
+        rows = [r for r in results if r['verb'] == verb]
+        values = [r['total_sec'] for r in rows if r['success']]
+        api = [r['api_sec'] for r in rows if r['api_sec'] is not None]
+        stats.append({
+            **metric_stats(f"{verb}_sec", values),
+            'p50': round_duration(percentile(values, 50)),
+            'api_avg': round_duration(sum(api) / len(api)) if api else None,
+            'failed': len(rows) - len(values),
+        })
+    return stats
+
+
+def lifecycle_outliers(results: List[Dict]) -> List[Dict]:
+    """Operations more than --outlier-sigma standard deviations slower than the mean of their verb."""
+    outliers = []
+    for verb in VERBS:
+        rows = [r for r in results if r['verb'] == verb and r['success']]
+        found = metric_outliers(rows, ['total_sec'], lambda r: f"{r['namespace']} #{r['iteration']}")
+        outliers += [{**o, 'metric': f"{verb}_sec"} for o in found]
+    return outliers
+
+
+def print_summary(stats: List[Dict], total_time: float, logger) -> None:
+    def fmt(value):
+        return f"{value:.3f}" if value is not None else '-'
+
+    logger.info("")
+    logger.info("=" * 100)
+    logger.info("VM LIFECYCLE OPERATION LATENCY (seconds)")
+    logger.info("=" * 100)
+    logger.info(f"{'Verb':<10}{'Avg':>10}{'Min':>10}{'P50':>10}{'P95':>10}{'P99':>10}{'Max':>10}"
+                f"{'API avg':>10}{'Count':>8}{'Failed':>8}")
+    logger.info("-" * 100)
+    for s in stats:
+        logger.info(f"{s['metric'][:-4]:<10}{fmt(s['avg']):>10}{fmt(s['min']):>10}{fmt(s['p50']):>10}"
+                    f"{fmt(s['p95']):>10}{fmt(s['p99']):>10}{fmt(s['max']):>10}{fmt(s['api_avg']):>10}"
+                    f"{s['count']:>8}{s['failed']:>8}")
+    logger.info("=" * 100)
+    logger.info(f"  Total test duration:    {total_time:.2f}s")
+
+
+def save_lifecycle_results(out_dir: str, args, results: List[Dict], stats: List[Dict],
+                           total_time: float, timing_block: Dict, logger) -> None:
+    """Write per-operation results and the latency table as JSON and CSV."""
+    os.makedirs(out_dir, exist_ok=True)
+    rows = []
+    for r in results:
+        row = dict(r)
+        row['api_sec'] = round_duration(row['api_sec'])
+        row['total_sec'] = round_duration(row['total_sec'])
+        rows.append(row)
+
+    with open(os.path.join(out_dir, 'vm_lifecycle_results.json'), 'w') as f:
+        json.dump(rows, f, indent=4)
+    with open(os.path.join(out_dir, 'vm_lifecycle_results.csv'), 'w', newline='') as f:
+        writer = csv.DictWriter(f, fieldnames=rows[0].keys())
+        writer.writeheader()
+        writer.writerows(rows)
+
+    summary = {
+        'total_operations': len(results),
+        'successful': sum(1 for r in results if r['success']),
+        'failed': sum(1 for r in results if not r['success']),
+        'vms': len({r['namespace'] for r in results}),
+        'iterations': args.iterations,
+        'existing_vms': args.existing_vms,
+        'verbs': args.verbs,
+        'mix': args.mix_weights,
+        'concurrency': args.concurrency,
+        'poll_interval_sec': args.poll_interval,
+        'total_test_duration_sec': round_duration(total_time),
+        'metrics': stats,
+        'outliers': lifecycle_outliers(results),
+        'timing': timing_block,
+    }
+    custom_metrics = collect_custom_metrics(timing_block, logger)
+    if custom_metrics is not None:
+        summary['custom_metrics'] = custom_metrics
+    run_events = collect_run_events(out_dir, logger, summary.get('outliers'))
+    if run_events is not None:
+        summary['events'] = run_events
+    node_utilization = collect_node_samples(out_dir, logger)
+    if node_utilization is not None:
+        summary['node_utilization'] = node_utilization
+    storage_backend = collect_storage_backend(out_dir, args.namespace_prefix, logger)
+    if storage_backend is not None:
+        summary['storage_backend'] = storage_backend
+    summary['cluster'] = cluster_inventory(logger)
+    summary['run'] = run_metadata()
+    with open(os.path.join(out_dir, 'summary_vm_lifecycle_results.json'), 'w') as f:
+        json.dump(summary, f, indent=4)
+    with open(os.path.join(out_dir, 'summary_vm_lifecycle_results.csv'), 'w', newline='') as f:
+        writer = csv.DictWriter(f, fieldnames=stats[0].keys())
+        writer.writeheader()
+        writer.writerows(stats)
+    logger.info(f"Results saved under: {out_dir}")
+
+
+def plan_run(args, namespaces: List[str], manifest: str, logger):
+    """Print the verbs main() would issue, in order (virtbench --dry-run)."""
+    plan = DryRunPlan('vm-lifecycle', logger)
+    if not args.skip_namespace_creation and not args.existing_vms:
+        plan.create_namespaces(namespaces)
+    for iteration in range(1, args.iterations + 1):
+        if args.mix_weights:
+            for ns in namespaces:
+                plan.action('mix', f"vm/{ns}/{args.vm_name}",
+                            f"round {iteration}: one of {', '.join(args.mix_weights)}")
+            continue
+        for verb in args.verbs:
+            for ns in namespaces:
+                if verb == 'create':
+                    plan.apply(manifest, ns, detail=f"iteration {iteration}")
+                else:
+                    plan.action(verb, f"vm/{ns}/{args.vm_name}", f"iteration {iteration}")
+    if args.cleanup:
+        plan.delete_namespaces(namespaces)
+    plan.report()
+
+
+def main():
+    args = parse_args()
+    set_run_workload('vm-lifecycle')
+    args.storage_driver = resolve_storage_driver(args.storage_driver)
+    dry_run = is_dry_run()
+    if dry_run:
+        # A dry run has no results to save
+        args.save_results = False
+
+    out_dir = None
+    if args.save_results:
+        out_dir = build_results_dir(args)
+        os.makedirs(out_dir, exist_ok=True)
+        if not args.log_file:
+            args.log_file = os.path.join(out_dir, 'vm-lifecycle.log')
+
+    logger = setup_logging(args.log_file, args.log_level)
+    watch_run('vm-lifecycle', logger)
+    watch_events(args.namespace_prefix, logger)
+    sample_nodes(logger)
+    timing.set_precision(args.precision)
+    manifest = '' if args.existing_vms else render_halted_vm(args.vm_template)
+
+    logger.info("=" * 80)
+    logger.info("KubeVirt VM Lifecycle Micro-Benchmark")
+    logger.info("=" * 80)
+    logger.info(f"Namespaces: {args.namespace_prefix}-{args.start} to {args.namespace_prefix}-{args.end}")
+    if args.existing_vms:
+        logger.info(f"VM: existing {args.vm_name}")
+    else:
+        logger.info(f"VM: {args.vm_name} from {args.vm_template}")
+    if args.mix_weights:
+        logger.info(f"Mix: {', '.join(f'{v}={w:g}' for v, w in args.mix_weights.items())} "
+                    f"x {args.iterations} rounds")
+    else:
+        logger.info(f"Verbs: {', '.join(args.verbs)} x {args.iterations} iterations")
+    logger.info(f"Concurrency: {args.concurrency}  Poll interval: {args.poll_interval}s")
+    logger.info("=" * 80)
+
+    namespaces = [f"{args.namespace_prefix}-{i}" for i in range(args.start, args.end + 1)]
+    if dry_run:
+        plan_run(args, namespaces, manifest, logger)
+        sys.exit(0)
+
+    initial = {}
+    if args.existing_vms:
+        initial = existing_statuses(namespaces, args.vm_name, logger)
+        missing = [ns for ns, status in initial.items() if status is None]
+        if missing:
+            logger.warning(f"{len(missing)} namespaces have no VM {args.vm_name}: {', '.join(sorted(missing)[:5])}"
+                           f"{' ...' if len(missing) > 5 else ''}")
+        namespaces = [ns for ns in namespaces if initial.get(ns) is not None]
+        if not namespaces:
+            logger.error("No existing VMs found")
+            sys.exit(1)
+        logger.info(f"Existing VMs: {len(namespaces)}")
+    elif not args.skip_namespace_creation:
+        created = create_namespaces_parallel(namespaces, args.concurrency, logger)
+        if len(created) != len(namespaces):
+            logger.error(f"Failed to create {len(namespaces) - len(created)} namespaces")
+            sys.exit(1)
+
+    clock_skew = timing.estimate_clock_skew(logger=logger) if args.save_results else None
+    if args.save_results:
+        cluster_inventory(logger)
+    test_start = timing.now()
+
+    results: List[Dict] = []
+    rng = random.Random(args.seed)
+    try:
+        for iteration in range(1, args.iterations + 1):
+            if args.mix_weights:
+                # Each round, every VM runs one verb of the mix; different verbs overlap
+                statuses = existing_statuses(namespaces, args.vm_name, logger)
+                logger.info(f"Round {iteration}/{args.iterations}: mix ({len(namespaces)} VMs)")
+                outcomes = run_parallel(run_mixed, namespaces, concurrency=args.concurrency,
+                                        qps=args.qps, burst=args.burst,
+                                        args=(iteration, args, statuses, rng, logger),
+                                        logger=logger, description="VM operation")
+                for ns, row, error in outcomes:
+                    if error is not None:
+                        row = {'namespace': ns, 'iteration': iteration, 'verb': None, 'api_sec': None,
+                               'total_sec': None, 'success': False, 'error': str(error)}
+                    results.append(row)
+                continue
+
+            # A VM that failed a verb sits out the rest of this lifecycle
+            active = list(namespaces)
+            if args.existing_vms:
+                required = REQUIRED_STATUS[args.verbs[0]]
+                statuses = existing_statuses(namespaces, args.vm_name, logger)
+                active = [ns for ns in namespaces if statuses[ns] == required]
+                if len(active) < len(namespaces):
+                    logger.warning(f"{len(namespaces) - len(active)} VMs are not {required}; "
+                                   f"they sit out iteration {iteration}")
+            for verb in args.verbs:
+                if not active:
+                    break
+                logger.info(f"Iteration {iteration}/{args.iterations}: {verb} ({len(active)} VMs)")
+                outcomes = run_parallel(run_verb, active, concurrency=args.concurrency,
+                                        qps=args.qps, burst=args.burst,
+                                        args=(verb, iteration, args, manifest, logger),
+                                        logger=logger, description=f"VM {verb}")
+                active = []
+                for ns, row, error in outcomes:
+                    if error is not None:
+                        row = {'namespace': ns, 'iteration': iteration, 'verb': verb, 'api_sec': None,
+                               'total_sec': None, 'success': False, 'error': str(error)}
+                    results.append(row)
+                    if row['success']:
+                        active.append(ns)
+    finally:
+        if args.existing_vms:
+            restore_vms(initial, args, logger)
+        if args.cleanup:
+            stats = cleanup_test_namespaces(
+                namespace_prefix=args.namespace_prefix, start=args.start, end=args.end,
+                vm_name=args.vm_name, delete_namespaces=True, batch_size=args.concurrency,
+                logger=logger, qps=args.qps, burst=args.burst, selector=run_selector()
+            )
+            print_cleanup_summary(stats, logger)
+
+    total_time = (timing.now() - test_start).total_seconds()
+    if not results:
+        logger.error("No operations were run")
+        sys.exit(1)
+
+    # A mix also runs the verbs that bring VMs back to Running when none of its own are valid
+    verbs = args.verbs + [v for v in VERBS if v not in args.verbs and any(r['verb'] == v for r in results)]
+    stats = verb_stats(results, verbs)
+    print_summary(stats, total_time, logger)
+    log_outliers(lifecycle_outliers(results), logger)
+    if args.save_results:
+        save_lifecycle_results(out_dir, args, results, stats, total_time,
+                               timing.timing_metadata(test_start, clock_skew=clock_skew), logger)
+
+    failed = sum(1 for r in results if not r['success'])
+    run_metrics = {'operations': len(results), 'failed': failed}
+    for s in stats:
+        if s['count']:
+            run_metrics.update({f"p50_{s['metric']}": s['p50'], f"p95_{s['metric']}": s['p95']})
+    notify_run('vm-lifecycle', run_metrics, phase_status(failed, len(results)), out_dir, logger)
+
+    sys.exit(0 if all(r['success'] for r in results) else 2)
+
+
+if __name__ == '__main__':
+    main()
@@ -1,8 +1,8 @@
 #!/usr/bin/env python3
 """
-Power VMs on or off.
+Power VMs on, off, or restart them.
 
-Two modes are supported via --action:
+Three modes are supported via --action:
 
   --action off   Find running VMs on a node, select a percentage of them,
                  and power them off in parallel. The selected VMs are saved
@@ -14,6 +14,16 @@ Two modes are supported via --action:
                  so node-only discovery is not possible — a namespace range
                  (or list file) is required.
 
+  --action restart
+                 Restart storm: stop the selected VMs (a namespace range, a
+                 list file, or a percentage of running VMs on --node), wait
+                 for them to stop, then start them again. --stop-mode picks a
+                 graceful guest shutdown (ACPI / guest agent, bounded by the
+                 VM's terminationGracePeriodSeconds) or a forced stop.
+                 --stop-mode compare runs both back-to-back on the same VMs
+                 and reports how many VMs needed the full grace period and
+                 how much that added to the storm duration.
+
 Usage:
     # Power off 50% of running VMs on a node
     python3 power-toggle-vms.py --action off --node worker-1 --percentage 50
@@ -28,6 +38,10 @@ Usage:
     # Power on VMs from a saved list file
     python3 power-toggle-vms.py --action on --vm-list-file powered_off_vms_worker-1_*.txt
 
+    # Compare graceful vs forced restart storms on a namespace range
+    python3 power-toggle-vms.py --action restart --stop-mode compare \
+        --namespace-prefix migration --start 1 --end 50 --vm-name rhel-9-vm
+
     # Dry run
     python3 power-toggle-vms.py --action off --node worker-1 --percentage 50 --dry-run
 """
@@ -43,6 +57,13 @@ from concurrent.futures import ThreadPoolExecutor, as_completed
 from datetime import datetime
 from typing import List, Tuple, Dict, Optional
 
+# KubeVirt's terminationGracePeriodSeconds when the VM spec does not set one
+DEFAULT_GRACE_PERIOD = 180
+# Stops that finish within this many seconds of the grace period are counted
+# as having waited for it (polling granularity is 2s)
+GRACE_PERIOD_MARGIN = 3
+STOP_MODES = ["graceful", "force", "compare"]
+
 
 def setup_logging(level: str = "INFO") -> logging.Logger:
     """Configure logging."""
@@ -149,22 +170,29 @@ def discover_vms_in_range(namespace_prefix: str, start: int, end: int,
 
 def _virtctl_action(action: str, namespace: str, vm_name: str,
                     logger: logging.Logger,
-                    dry_run: bool = False) -> Tuple[str, str, bool, float]:
-    """Send `virtctl {start|stop} <vm>`. Returns (ns, name, success, duration)."""
+                    dry_run: bool = False,
+                    force: bool = False) -> Tuple[str, str, bool, float]:
+    """
+    Send `virtctl {start|stop} <vm>`. Returns (ns, name, success, duration).
+
+    With force=True a stop is sent as `--force --grace-period 0`, so the VMI
+    is killed instead of waiting for the guest to shut down.
+    """
     log_prefix = f"[{namespace}/{vm_name}]"
     started = time.time()
     verb = "stop" if action == "off" else "start"
+    extra = ["--force", "--grace-period", "0"] if force and action == "off" else []
 
     if dry_run:
-        logger.info(f"{log_prefix} DRY-RUN: Would {verb} VM")
+        logger.info(f"{log_prefix} DRY-RUN: Would {verb} VM" + (" (forced)" if extra else ""))
         return namespace, vm_name, True, 0.0
 
     # Prefer `kubectl virt <verb>` (krew plugin) and fall back to `virtctl`.
-    rc, _, stderr = run_kubectl(["virt", verb, vm_name, "-n", namespace], timeout=30)
+    rc, _, stderr = run_kubectl(["virt", verb, vm_name, "-n", namespace] + extra, timeout=30)
     if rc != 0:
         try:
             result = subprocess.run(
-                ["virtctl", verb, vm_name, "-n", namespace],
+                ["virtctl", verb, vm_name, "-n", namespace] + extra,
                 capture_output=True, text=True, timeout=30
             )
             rc, stderr = result.returncode, result.stderr
@@ -212,6 +240,17 @@ def wait_for_vm_phase(namespace: str, vm_name: str, target: str,
     return False
 
 
+def get_vm_grace_period(namespace: str, vm_name: str) -> int:
+    """Return the VM's terminationGracePeriodSeconds (KubeVirt default if unset)."""
+    rc, stdout, _ = run_kubectl([
+        "get", "vm", vm_name, "-n", namespace,
+        "-o", "jsonpath={.spec.template.spec.terminationGracePeriodSeconds}",
+    ], timeout=15)
+    if rc == 0 and stdout.strip().isdigit():
+        return int(stdout.strip())
+    return DEFAULT_GRACE_PERIOD
+
+
 def load_vm_list_file(path: str, logger: logging.Logger) -> List[Dict]:
     """Read 'namespace/name' lines from *path* into VM dicts."""
     vms: List[Dict] = []
@@ -307,6 +346,174 @@ def _run_action_in_parallel(action: str, vms: List[Dict], concurrency: int,
     logger.info("=" * 60)
 
 
+def _stop_and_time(vm: Dict, force: bool, wait_timeout: int,
+                   logger: logging.Logger) -> Dict:
+    """Stop one VM and measure the time until its VMI is gone."""
+    ns, name = vm["namespace"], vm["name"]
+    grace = get_vm_grace_period(ns, name)
+    started = time.monotonic()
+    _, _, sent, _ = _virtctl_action("off", ns, name, logger, force=force)
+    stopped = sent and wait_for_vm_phase(ns, name, "stopped", logger, wait_timeout)
+    duration = time.monotonic() - started
+    return {
+        "namespace": ns,
+        "name": name,
+        "stopped": stopped,
+        "stop_seconds": round(duration, 2),
+        "grace_period": grace,
+        # The guest did not shut down on its own; virt-launcher waited out the grace period
+        "hit_grace_period": stopped and not force and duration >= grace - GRACE_PERIOD_MARGIN,
+    }
+
+
+def _start_and_time(vm: Dict, wait_timeout: int, logger: logging.Logger) -> Dict:
+    """Start one VM and measure the time until its VMI is Running."""
+    ns, name = vm["namespace"], vm["name"]
+    started = time.monotonic()
+    _, _, sent, _ = _virtctl_action("on", ns, name, logger)
+    running = sent and wait_for_vm_phase(ns, name, "running", logger, wait_timeout)
+    return {"running": running, "start_seconds": round(time.monotonic() - started, 2)}
+
+
+def _run_restart_storm(vms: List[Dict], mode: str, concurrency: int,
+                       wait_timeout: int, logger: logging.Logger) -> Dict:
+    """
+    Stop every VM (graceful or forced), then start them all again.
+
+    Returns a summary with per-phase and total durations, the number of VMs
+    whose graceful stop took the full terminationGracePeriodSeconds, and
+    per-VM details.
+    """
+    force = mode == "force"
+    logger.info("=" * 60)
+    logger.info(f"RESTART STORM ({mode.upper()} STOP): {len(vms)} VM(s)")
+    logger.info("=" * 60)
+
+    storm_start = time.monotonic()
+    per_vm: Dict[Tuple[str, str], Dict] = {}
+    with ThreadPoolExecutor(max_workers=concurrency) as executor:
+        futures = [executor.submit(_stop_and_time, vm, force, wait_timeout, logger) for vm in vms]
+        for future in as_completed(futures):
+            r = future.result()
+            per_vm[(r["namespace"], r["name"])] = r
+            note = " (waited full grace period)" if r["hit_grace_period"] else ""
+            logger.info(f"[{r['namespace']}/{r['name']}] stopped in {r['stop_seconds']:.2f}s{note}"
+                        if r["stopped"] else f"[{r['namespace']}/{r['name']}] failed to stop")
+    stop_phase = time.monotonic() - storm_start
+
+    to_start = [vm for vm in vms if per_vm[(vm["namespace"], vm["name"])]["stopped"]]
+    start_phase_start = time.monotonic()
+    with ThreadPoolExecutor(max_workers=concurrency) as executor:
+        futures = {executor.submit(_start_and_time, vm, wait_timeout, logger): vm for vm in to_start}
+        for future in as_completed(futures):
+            vm = futures[future]
+            per_vm[(vm["namespace"], vm["name"])].update(future.result())
+    start_phase = time.monotonic() - start_phase_start
+    total = time.monotonic() - storm_start
+
+    results = list(per_vm.values())
+    stop_times = [r["stop_seconds"] for r in results if r["stopped"]]
+    summary = {
+        "stop_mode": mode,
+        "vms": len(vms),
+        "stopped": len(stop_times),
+        "restarted": sum(1 for r in results if r.get("running")),
+        "hit_grace_period": sum(1 for r in results if r["hit_grace_period"]),
+        "avg_stop_seconds": round(sum(stop_times) / len(stop_times), 2) if stop_times else None,
+        "max_stop_seconds": round(max(stop_times), 2) if stop_times else None,
+        "stop_phase_seconds": round(stop_phase, 2),
+        "start_phase_seconds": round(start_phase, 2),
+        "total_seconds": round(total, 2),
+        "results": sorted(results, key=lambda r: (r["namespace"], r["name"])),
+    }
+
+    logger.info("")
+    logger.info("=" * 60)
+    logger.info(f"RESTART STORM SUMMARY ({mode.upper()} STOP)")
+    logger.info("=" * 60)
+    logger.info(f"VMs targeted:              {summary['vms']}")
+    logger.info(f"VMs stopped:               {summary['stopped']}")
+    logger.info(f"VMs restarted:             {summary['restarted']}")
+    if not force:
+        logger.info(f"Needed full grace period:  {summary['hit_grace_period']}")
+    logger.info("-" * 60)
+    logger.info(f"Avg / max stop time:       {summary['avg_stop_seconds']}s / {summary['max_stop_seconds']}s")
+    logger.info(f"Stop phase:                {summary['stop_phase_seconds']:.2f}s")
+    logger.info(f"Start phase:               {summary['start_phase_seconds']:.2f}s")
+    logger.info(f"Total restart storm:       {summary['total_seconds']:.2f}s")
+    logger.info("=" * 60)
+    return summary
+
+
+def _select_vms_for_restart(args: argparse.Namespace, logger: logging.Logger) -> List[Dict]:
+    """Pick restart targets from a list file, a namespace range, or running VMs on --node."""
+    if args.vm_list_file:
+        return load_vm_list_file(args.vm_list_file, logger)
+
+    if args.namespace_prefix is not None:
+        missing = [k for k in ("start", "end", "vm_name") if getattr(args, k) is None]
+        if missing:
+            logger.error("--namespace-prefix requires --start, --end and --vm-name. Missing: "
+                         f"{', '.join('--' + m.replace('_', '-') for m in missing)}")
+            sys.exit(1)
+        return discover_vms_in_range(args.namespace_prefix, args.start, args.end,
+                                     args.vm_name, args.node, logger)
+
+    if args.node:
+        all_vms = get_running_vms_on_node(args.node, logger)
+        if not all_vms:
+            return []
+        count = max(1, int(len(all_vms) * args.percentage / 100))
+        return random.sample(all_vms, count)
+
+    logger.error("--action restart requires --vm-list-file, --namespace-prefix/--start/--end/"
+                 "--vm-name, or --node")
+    sys.exit(1)
+
+
+def _do_restart(args: argparse.Namespace, logger: logging.Logger) -> None:
+    """Run a graceful and/or forced restart storm and compare them."""
+    vms = _select_vms_for_restart(args, logger)
+    if not vms:
+        logger.error("No VMs matched the selection criteria")
+        sys.exit(1)
+    logger.info(f"Selected {len(vms)} VM(s) for restart (stop mode: {args.stop_mode})")
+
+    if args.dry_run:
+        logger.info("DRY-RUN MODE - No changes will be made")
+        for vm in vms:
+            logger.info(f"  Would restart: {vm['namespace']}/{vm['name']}")
+        return
+
+    modes = ["graceful", "force"] if args.stop_mode == "compare" else [args.stop_mode]
+    summaries = [_run_restart_storm(vms, mode, args.concurrency, args.wait_timeout, logger)
+                 for mode in modes]
+
+    if len(summaries) == 2:
+        graceful, forced = summaries
+        logger.info("")
+        logger.info("=" * 60)
+        logger.info("GRACEFUL vs FORCED RESTART")
+        logger.info("=" * 60)
+        logger.info(f"{'':<28}{'graceful':>14}{'force':>14}")
+        for key, label in (("avg_stop_seconds", "Avg stop (s)"),
+                           ("max_stop_seconds", "Max stop (s)"),
+                           ("stop_phase_seconds", "Stop phase (s)"),
+                           ("start_phase_seconds", "Start phase (s)"),
+                           ("total_seconds", "Total storm (s)")):
+            logger.info(f"{label:<28}{str(graceful[key]):>14}{str(forced[key]):>14}")
+        logger.info(f"{'Needed full grace period':<28}{graceful['hit_grace_period']:>14}{'-':>14}")
+        logger.info("-" * 60)
+        logger.info(f"Graceful shutdown added {graceful['total_seconds'] - forced['total_seconds']:.2f}s "
+                    f"to the restart storm")
+        logger.info("=" * 60)
+
+    if args.results_file:
+        with open(args.results_file, "w") as f:
+            json.dump({"restart_storms": summaries}, f, indent=2)
+        logger.info(f"Saved restart results to: {args.results_file}")
+
+
 def _do_power_off(args: argparse.Namespace, logger: logging.Logger) -> None:
     """Discover, sample, save, then power off VMs."""
     if args.vm_list_file:
@@ -384,11 +591,17 @@ def _do_power_on(args: argparse.Namespace, logger: logging.Logger) -> None:
 
 def main():
     parser = argparse.ArgumentParser(
-        description="Power VMs on or off (--action {on,off})",
+        description="Power VMs on, off or restart them (--action {on,off,restart})",
         formatter_class=argparse.RawDescriptionHelpFormatter,
     )
-    parser.add_argument("--action", required=True, choices=["on", "off"],
-                        help="Whether to power VMs on or off")
+    parser.add_argument("--action", required=True, choices=["on", "off", "restart"],
+                        help="Whether to power VMs on, off, or restart them")
+    parser.add_argument("--stop-mode", default="graceful", choices=STOP_MODES,
+                        help="How VMs are stopped for --action restart: graceful guest "
+                             "shutdown, forced stop, or compare (both, back-to-back) "
+                             "(default: graceful)")
+    parser.add_argument("--results-file", default=None,
+                        help="Write restart storm results as JSON to this file (--action restart)")
 
     # Selection: --action off uses --node + --percentage. --action on uses
     # --namespace-prefix/--start/--end (default), with optional --node filter,
@@ -426,6 +639,8 @@ def main():
 
     if args.action == "off":
         _do_power_off(args, logger)
+    elif args.action == "restart":
+        _do_restart(args, logger)
     else:
         _do_power_on(args, logger)
 
@@ -156,7 +156,7 @@ def run_blkdiscard_on_vm(namespace: str, vm_name: str,
     }
 
     # Get VM IP
-    ip = get_vmi_ip(namespace, vm_name, logger)
+    ip = get_vmi_ip(vm_name, namespace, logger)
     if not ip:
         result["error"] = "Could not get VM IP"
         logger.warning(f"{log_prefix} Could not get VM IP")
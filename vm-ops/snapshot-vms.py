@@ -24,6 +24,7 @@ Usage:
 
 import argparse
 import logging
+import os
 import subprocess
 import sys
 import time
@@ -82,6 +83,18 @@ def run_kubectl(args: List[str], timeout: int = 60) -> Tuple[int, str, str]:
         return -1, "", str(e)
 
 
+def snapshot_labels() -> dict:
+    """virtbench resource labels for the snapshots (see utils.common.run_labels)."""
+    os.environ.setdefault("VIRTBENCH_RUN_TIMESTAMP", datetime.now().strftime("%Y%m%d-%H%M%S"))
+    labels = {
+        "virtbench.io/workload": os.environ.get("VIRTBENCH_WORKLOAD") or "vm-ops",
+        "virtbench.io/run-timestamp": os.environ["VIRTBENCH_RUN_TIMESTAMP"],
+    }
+    if os.environ.get("VIRTBENCH_UUID"):
+        labels["virtbench.io/run-uuid"] = os.environ["VIRTBENCH_UUID"]
+    return labels
+
+
 def create_snapshot_yaml(namespace: str, vm_name: str, snapshot_name: str) -> dict:
     """Create VirtualMachineSnapshot YAML definition."""
     return {
@@ -89,7 +102,8 @@ def create_snapshot_yaml(namespace: str, vm_name: str, snapshot_name: str) -> di
         "kind": "VirtualMachineSnapshot",
         "metadata": {
             "name": snapshot_name,
-            "namespace": namespace
+            "namespace": namespace,
+            "labels": snapshot_labels()
         },
         "spec": {
             "source": {
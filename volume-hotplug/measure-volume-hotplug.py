@@ -0,0 +1,572 @@
+#!/usr/bin/env python3
+"""
+KubeVirt Volume Hotplug Benchmark
+
+Attaches blank DataVolumes to running VMs with `virtctl addvolume`, then
+detaches them with `virtctl removevolume`, and measures per volume:
+
+  - attach latency:       addvolume until the VMI reports the volume Ready
+  - guest visible time:   addvolume until the disk shows up in the guest
+                          (matched by its serial number in lsblk)
+  - detach latency:       removevolume until the volume leaves the VMI status
+  - guest removed time:   removevolume until the disk is gone from the guest
+
+Volumes of one VM are attached concurrently; --concurrency bounds how many
+VMs are processed at once. The VMs must already be running (for example
+created with datasource-clone).
+
+Usage:
+    python3 measure-volume-hotplug.py --start 1 --end 50 --vm-name rhel-9-vm \\
+        --storage-class px-csi-db --volumes-per-vm 3 --save-results
+
+Author: KubeVirt Benchmark Suite Contributors
+License: Apache 2.0
+"""
+
+import argparse
+import csv
+import json
+import os
+import subprocess
+import sys
+import time
+import uuid
+from datetime import datetime
+from typing import Dict, List, Optional, Set
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
+
+from utils import timing
+from utils.apply import apply_manifest
+from utils.concurrency import run_parallel, DEFAULT_QPS, DEFAULT_BURST
+from utils.common import (
+    setup_logging, run_kubectl_command, get_vm_status, delete_datavolume,
+    round_duration, stamp_manifest, set_run_workload,
+    run_metadata,
+)
+from utils.custommetrics import collect_custom_metrics
+from utils.events import collect_run_events, watch_events
+from utils.inventory import cluster_inventory, resolve_storage_driver
+from utils.guestexec import GuestExecutor, ensure_helper_pod
+from utils.dryrun import DryRunPlan, is_dry_run
+from utils.notify import notify_run, watch_run, phase_status, percentile_metrics
+from utils.stats import log_outliers, metric_outliers, metric_stats, stat_columns
+from utils.storageprovider import collect_storage_backend
+from utils.utilization import collect_node_samples, sample_nodes
+
+# Default configuration
+DEFAULT_VM_NAME = 'rhel-9-vm'
+DEFAULT_NAMESPACE_PREFIX = 'kubevirt-perf-test'
+DEFAULT_VOLUMES_PER_VM = 1
+DEFAULT_VOLUME_SIZE = '1Gi'
+DEFAULT_CONCURRENCY = 10
+DEFAULT_POLL_INTERVAL = 1
+DEFAULT_ATTACH_TIMEOUT = 300
+DEFAULT_GUEST_TIMEOUT = 120
+DEFAULT_VM_USER = 'cloud-user'
+DEFAULT_VM_PASSWORD = 'changeme'
+DEFAULT_SSH_POD = 'ssh-test-pod'
+DEFAULT_SSH_POD_NS = 'default'
+# DataVolumes that can be hotplugged without waiting for population to finish
+HOTPLUGGABLE_DV_PHASES = ('Succeeded', 'WaitForFirstConsumer', 'PendingPopulation')
+
+
+def parse_args():
+    parser = argparse.ArgumentParser(
+        description='Measure DataVolume hotplug/unplug latency on running KubeVirt VMs',
+        formatter_class=argparse.RawDescriptionHelpFormatter,
+    )
+    parser.add_argument('--start', type=int, default=1, help='Starting namespace index (default: 1)')
+    parser.add_argument('--end', type=int, default=10, help='Ending namespace index (default: 10)')
+    parser.add_argument('--namespace-prefix', default=DEFAULT_NAMESPACE_PREFIX,
+                        help=f'Namespace prefix (default: {DEFAULT_NAMESPACE_PREFIX})')
+    parser.add_argument('--vm-name', default=DEFAULT_VM_NAME,
+                        help=f'Name of the running VM in each namespace (default: {DEFAULT_VM_NAME})')
+    parser.add_argument('--storage-class', required=True, help='Storage class for the hotplugged DataVolumes')
+    parser.add_argument('--volumes-per-vm', type=int, default=DEFAULT_VOLUMES_PER_VM,
+                        help=f'DataVolumes hotplugged into each VM (default: {DEFAULT_VOLUMES_PER_VM})')
+    parser.add_argument('--volume-size', default=DEFAULT_VOLUME_SIZE,
+                        help=f'Size of each DataVolume (default: {DEFAULT_VOLUME_SIZE})')
+    parser.add_argument('--persist', action='store_true',
+                        help='Persist hotplugged volumes in the VM spec (virtctl --persist)')
+    parser.add_argument('--skip-detach', action='store_true',
+                        help='Leave volumes attached (no detach measurement)')
+    parser.add_argument('--keep-volumes', action='store_true',
+                        help='Do not delete the DataVolumes after the test')
+    parser.add_argument('--concurrency', '-c', type=int, default=DEFAULT_CONCURRENCY,
+                        help=f'VMs processed concurrently (default: {DEFAULT_CONCURRENCY})')
+    parser.add_argument('--qps', type=float, default=DEFAULT_QPS,
+                        help='Max VMs started per second (default: 0 = unlimited)')
+    parser.add_argument('--burst', type=int, default=DEFAULT_BURST,
+                        help=f'Max VMs started back-to-back when --qps is set (default: {DEFAULT_BURST})')
+    parser.add_argument('--poll-interval', type=int, default=DEFAULT_POLL_INTERVAL,
+                        help=f'Seconds between status checks (default: {DEFAULT_POLL_INTERVAL})')
+    parser.add_argument('--attach-timeout', type=int, default=DEFAULT_ATTACH_TIMEOUT,
+                        help=f'Timeout for attach/detach in seconds (default: {DEFAULT_ATTACH_TIMEOUT})')
+    parser.add_argument('--guest-timeout', type=int, default=DEFAULT_GUEST_TIMEOUT,
+                        help=f'Timeout for the disk to appear/disappear in the guest '
+                             f'(default: {DEFAULT_GUEST_TIMEOUT})')
+    parser.add_argument('--skip-guest-check', action='store_true',
+                        help='Do not measure in-guest device visibility (no SSH)')
+    parser.add_argument('--vm-user', default=DEFAULT_VM_USER,
+                        help=f'Guest SSH user (default: {DEFAULT_VM_USER})')
+    parser.add_argument('--vm-password', default=DEFAULT_VM_PASSWORD,
+                        help=f'Guest SSH password (default: {DEFAULT_VM_PASSWORD})')
+    parser.add_argument('--ssh-pod', default=DEFAULT_SSH_POD,
+                        help=f'SSH helper pod (default: {DEFAULT_SSH_POD})')
+    parser.add_argument('--ssh-pod-ns', default=DEFAULT_SSH_POD_NS,
+                        help=f'SSH helper pod namespace (default: {DEFAULT_SSH_POD_NS})')
+    parser.add_argument('--precision', type=int, default=timing.DEFAULT_PRECISION,
+                        help=f'Decimal places for durations in saved results (default: {timing.DEFAULT_PRECISION})')
+    parser.add_argument('--save-results', action='store_true', help='Save results as JSON and CSV')
+    parser.add_argument('--results-folder', default='results',
+                        help='Base directory for results (default: results)')
+    parser.add_argument('--storage-driver', default=None,
+                        help='Storage driver label to include in the results path, or auto to detect it')
+    parser.add_argument('--log-file', default=None, help='Log file path')
+    parser.add_argument('--log-level', default='INFO',
+                        choices=['DEBUG', 'INFO', 'WARNING', 'ERROR'], help='Logging level (default: INFO)')
+    return parser.parse_args()
+
+
+def build_results_dir(args, timestamp: Optional[str] = None) -> str:
+    """Build the canonical volume-hotplug results directory."""
+    timestamp = timestamp or datetime.now().strftime("%Y%m%d-%H%M%S")
+    suffix = f"{args.namespace_prefix}_{args.start}-{args.end}"
+    if args.storage_driver:
+        return os.path.join(args.results_folder, args.storage_driver, 'volume-hotplug', f"{timestamp}_{suffix}")
+    return os.path.join(args.results_folder, 'volume-hotplug', f"{timestamp}_{suffix}")
+
+
+def blank_datavolume_manifest(name: str, namespace: str, size: str, storage_class: str) -> str:
+    """Manifest of a blank DataVolume to hotplug."""
+    return f"""apiVersion: cdi.kubevirt.io/v1beta1
+kind: DataVolume
+metadata:
+  name: {name}
+  namespace: {namespace}
+  labels:
+    app: kubevirt-perf-test
+spec:
+  source:
+    blank: {{}}
+  storage:
+    storageClassName: {storage_class}
+    resources:
+      requests:
+        storage: {size}
+"""
+
+
+def create_blank_datavolume(name: str, namespace: str, size: str, storage_class: str,
+                            logger) -> bool:
+    """Create a blank DataVolume to hotplug."""
+    manifest = blank_datavolume_manifest(name, namespace, size, storage_class)
+    applied, error = apply_manifest(stamp_manifest(manifest), logger=logger)
+    if not applied:
+        logger.error(f"[{namespace}] Failed to create DataVolume {name}: {error}")
+        return False
+    return True
+
+
+def wait_for_datavolume(name: str, namespace: str, timeout: int, poll_interval: int) -> Optional[str]:
+    """Wait until a DataVolume can be hotplugged. Returns its phase, or None on timeout."""
+    deadline = time.monotonic() + timeout
+    while time.monotonic() < deadline:
+        rc, out, _ = run_kubectl_command(
+            ['get', 'dv', name, '-n', namespace, '-o', 'jsonpath={.status.phase}'], check=False
+        )
+        if rc == 0 and out.strip() in HOTPLUGGABLE_DV_PHASES:
+            return out.strip()
+        time.sleep(poll_interval)
+    return None
+
+
+def virtctl_volume(action: str, vm_name: str, namespace: str, volume: str,
+                   persist: bool, logger, serial: Optional[str] = None) -> bool:
+    """Run `virtctl addvolume|removevolume`."""
+    cmd = ['virtctl', action, vm_name, f'--volume-name={volume}', '-n', namespace]
+    if serial:
+        cmd.append(f'--serial={serial}')
+    if persist:
+        cmd.append('--persist')
+    try:
+        result = subprocess.run(cmd, capture_output=True, text=True, timeout=60)
+    except (subprocess.TimeoutExpired, FileNotFoundError) as e:
+        logger.error(f"[{namespace}] virtctl {action} {volume} failed: {e}")
+        return False
+    if result.returncode != 0:
+        logger.error(f"[{namespace}] virtctl {action} {volume} failed: {result.stderr.strip()}")
+        return False
+    return True
+
+
+def get_hotplug_phases(vm_name: str, namespace: str) -> Optional[Dict[str, str]]:
+    """Return {volume name: phase} from the VMI's volumeStatus, or None if unreadable."""
+    rc, out, _ = run_kubectl_command(
+        ['get', 'vmi', vm_name, '-n', namespace, '-o', 'json'], check=False
+    )
+    if rc != 0:
+        return None
+    try:
+        statuses = json.loads(out).get('status', {}).get('volumeStatus', [])
+    except json.JSONDecodeError:
+        return None
+    return {s.get('name'): s.get('phase', '') for s in statuses}
+
+
+def get_guest_serials(executor: GuestExecutor, vm_name: str, namespace: str) -> Optional[Set[str]]:
+    """Return the disk serial numbers visible in the guest, or None if unreachable."""
+    rc, out, _ = executor.run_on_vmi(vm_name, namespace, 'lsblk -d -n -o SERIAL', timeout=30)
+    if rc != 0:
+        return None
+    return {line.strip() for line in out.splitlines() if line.strip()}
+
+
+def _wait_all(pending: Dict[str, str], done, started, timeout: int, poll_interval: int,
+              probe) -> Dict[str, float]:
+    """
+    Poll probe() until done(state, volume, key) holds for every pending volume.
+
+    Args:
+        pending: {volume: key} still to be observed (key is the value done() checks)
+        done: Callable(state, volume, key) -> bool
+        started: MonotonicTimestamp the latencies are measured from
+        probe: Callable returning the current state, or None if it could not be read
+
+    Returns:
+        {volume: seconds since started} for every volume observed in time
+    """
+    observed = {}
+    pending = dict(pending)
+    deadline = time.monotonic() + timeout
+    while pending and time.monotonic() < deadline:
+        state = probe()
+        if state is not None:
+            now = timing.now()
+            for volume, key in list(pending.items()):
+                if done(state, volume, key):
+                    observed[volume] = (now - started).total_seconds()
+                    del pending[volume]
+        if pending:
+            time.sleep(poll_interval)
+    return observed
+
+
+def hotplug_vm(namespace: str, args, executor: Optional[GuestExecutor], logger) -> List[Dict]:
+    """
+    Create, attach and detach --volumes-per-vm DataVolumes on one VM.
+
+    Returns:
+        One result dict per volume
+    """
+    vm_name = args.vm_name
+    suffix = uuid.uuid4().hex[:6]
+    volumes = {f"hp-{suffix}-{i}": f"hp{suffix}{i}" for i in range(1, args.volumes_per_vm + 1)}
+    results = {
+        name: {
+            'namespace': namespace, 'volume': name, 'storage_class': args.storage_class,
+            'attach_sec': None, 'guest_visible_sec': None,
+            'detach_sec': None, 'guest_removed_sec': None,
+            'success': False, 'error': None,
+        }
+        for name in volumes
+    }
+
+    if get_vm_status(vm_name, namespace, logger) != 'Running':
+        for r in results.values():
+            r['error'] = 'VM not running'
+        logger.error(f"[{namespace}] VM {vm_name} is not running, skipping")
+        return list(results.values())
+
+    # Provision the DataVolumes up front so attach latency excludes provisioning
+    ready = {}
+    for name, serial in volumes.items():
+        if not create_blank_datavolume(name, namespace, args.volume_size, args.storage_class, logger):
+            results[name]['error'] = 'DataVolume creation failed'
+            continue
+        if wait_for_datavolume(name, namespace, args.attach_timeout, args.poll_interval) is None:
+            results[name]['error'] = 'DataVolume not ready'
+            continue
+        ready[name] = serial
+
+    # Attach
+    attach_start = timing.now()
+    attached = {}
+    for name, serial in ready.items():
+        if virtctl_volume('addvolume', vm_name, namespace, name, args.persist, logger, serial=serial):
+            attached[name] = serial
+        else:
+            results[name]['error'] = 'addvolume failed'
+
+    probe_vmi = lambda: get_hotplug_phases(vm_name, namespace)
+    attach_times = _wait_all(attached, lambda state, vol, _: state.get(vol) == 'Ready',
+                             attach_start, args.attach_timeout, args.poll_interval, probe_vmi)
+    guest_times = {}
+    if executor:
+        guest_times = _wait_all(attached, lambda serials, _, serial: serial in serials,
+                                attach_start, args.guest_timeout, args.poll_interval,
+                                lambda: get_guest_serials(executor, vm_name, namespace))
+
+    for name in attached:
+        results[name]['attach_sec'] = attach_times.get(name)
+        results[name]['guest_visible_sec'] = guest_times.get(name)
+        if name not in attach_times:
+            results[name]['error'] = 'attach timed out'
+        elif executor and name not in guest_times:
+            results[name]['error'] = 'disk not visible in guest'
+    logger.info(f"[{namespace}] Attached {len(attach_times)}/{len(volumes)} volume(s)")
+
+    # Detach
+    detached_ok = set(attach_times)
+    if not args.skip_detach and attach_times:
+        detach_start = timing.now()
+        detaching = {}
+        for name in attach_times:
+            if virtctl_volume('removevolume', vm_name, namespace, name, args.persist, logger):
+                detaching[name] = volumes[name]
+            else:
+                results[name]['error'] = 'removevolume failed'
+
+        detach_times = _wait_all(detaching, lambda state, vol, _: vol not in state,
+                                 detach_start, args.attach_timeout, args.poll_interval, probe_vmi)
+        removed_times = {}
+        if executor:
+            removed_times = _wait_all(detaching, lambda serials, _, serial: serial not in serials,
+                                      detach_start, args.guest_timeout, args.poll_interval,
+                                      lambda: get_guest_serials(executor, vm_name, namespace))
+
+        detached_ok = set()
+        for name in detaching:
+            results[name]['detach_sec'] = detach_times.get(name)
+            results[name]['guest_removed_sec'] = removed_times.get(name)
+            if name not in detach_times:
+                results[name]['error'] = results[name]['error'] or 'detach timed out'
+            elif executor and name not in removed_times:
+                results[name]['error'] = results[name]['error'] or 'disk still visible in guest'
+            else:
+                detached_ok.add(name)
+        logger.info(f"[{namespace}] Detached {len(detach_times)}/{len(detaching)} volume(s)")
+
+    for name, r in results.items():
+        r['success'] = r['error'] is None and name in detached_ok
+
+    if not args.keep_volumes and not args.skip_detach:
+        for name in volumes:
+            delete_datavolume(name, namespace, logger)
+
+    return list(results.values())
+
+
+METRICS = ['attach_sec', 'guest_visible_sec', 'detach_sec', 'guest_removed_sec']
+
+
+def hotplug_outliers(results: List[Dict]) -> List[Dict]:
+    """Volumes more than --outlier-sigma standard deviations slower than the mean, per metric."""
+    return metric_outliers([r for r in results if r['success']], METRICS,
+                           lambda r: f"{r['namespace']}/{r['volume']}")
+
+
+def print_summary(results: List[Dict], total_time: float, logger) -> List[Dict]:
+    """Log the per-volume table and summary statistics. Returns the summary metrics."""
+    def fmt(value):
+        return f"{value:.2f}" if value is not None else '-'
+
+    logger.info("")
+    logger.info("=" * 110)
+    logger.info(f"{'Namespace':<28}{'Volume':<18}{'Attach(s)':<12}{'InGuest(s)':<12}"
+                f"{'Detach(s)':<12}{'Removed(s)':<12}{'Status':<16}")
+    logger.info("-" * 110)
+    for r in sorted(results, key=lambda x: (x['namespace'], x['volume'])):
+        status = 'Success' if r['success'] else (r['error'] or 'Failed')
+        logger.info(f"{r['namespace']:<28}{r['volume']:<18}{fmt(r['attach_sec']):<12}"
+                    f"{fmt(r['guest_visible_sec']):<12}{fmt(r['detach_sec']):<12}"
+                    f"{fmt(r['guest_removed_sec']):<12}{status:<16}")
+    logger.info("=" * 110)
+
+    metrics = [metric_stats(m, [r[m] for r in results if r[m] is not None]) for m in METRICS]
+    successful = sum(1 for r in results if r['success'])
+    logger.info(f"  Volumes:                {len(results)}")
+    logger.info(f"  Successful:             {successful}")
+    logger.info(f"  Failed:                 {len(results) - successful}")
+    labels = {
+        'attach_sec': 'Attach latency',
+        'guest_visible_sec': 'Guest visible',
+        'detach_sec': 'Detach latency',
+        'guest_removed_sec': 'Guest removed',
+    }
+    for m in metrics:
+        if m['count']:
+            logger.info(f"  {labels[m['metric']] + ':':<24}avg {m['avg']}s, "
+                        f"min {m['min']}s, max {m['max']}s, p95 {m['p95']}s, p99 {m['p99']}s "
+                        f"({m['count']} volumes)")
+    logger.info(f"  Total test duration:    {total_time:.2f}s")
+    logger.info("=" * 110)
+    log_outliers(hotplug_outliers(results), logger)
+    return metrics
+
+
+def save_hotplug_results(out_dir: str, args, results: List[Dict], metrics: List[Dict],
+                         total_time: float, timing_block: Dict, logger) -> None:
+    """Write per-volume results and the summary as JSON and CSV."""
+    os.makedirs(out_dir, exist_ok=True)
+    rows = []
+    for r in sorted(results, key=lambda x: (x['namespace'], x['volume'])):
+        row = dict(r)
+        for m in METRICS:
+            row[m] = round_duration(row[m])
+        rows.append(row)
+
+    with open(os.path.join(out_dir, 'volume_hotplug_results.json'), 'w') as f:
+        json.dump(rows, f, indent=4)
+    with open(os.path.join(out_dir, 'volume_hotplug_results.csv'), 'w', newline='') as f:
+        writer = csv.DictWriter(f, fieldnames=rows[0].keys())
+        writer.writeheader()
+        writer.writerows(rows)
+
+    summary = {
+        'total_volumes': len(results),
+        'successful': sum(1 for r in results if r['success']),
+        'failed': sum(1 for r in results if not r['success']),
+        'vms': len({r['namespace'] for r in results}),
+        'volumes_per_vm': args.volumes_per_vm,
+        'volume_size': args.volume_size,
+        'storage_class': args.storage_class,
+        'concurrency': args.concurrency,
+        'total_test_duration_sec': round_duration(total_time),
+        'metrics': metrics,
+        'outliers': hotplug_outliers(results),
+        'timing': timing_block,
+    }
+    custom_metrics = collect_custom_metrics(timing_block, logger)
+    if custom_metrics is not None:
+        summary['custom_metrics'] = custom_metrics
+    run_events = collect_run_events(out_dir, logger, summary.get('outliers'))
+    if run_events is not None:
+        summary['events'] = run_events
+    node_utilization = collect_node_samples(out_dir, logger)
+    if node_utilization is not None:
+        summary['node_utilization'] = node_utilization
+    storage_backend = collect_storage_backend(out_dir, args.namespace_prefix, logger)
+    if storage_backend is not None:
+        summary['storage_backend'] = storage_backend
+    summary['cluster'] = cluster_inventory(logger)
+    summary['run'] = run_metadata()
+    with open(os.path.join(out_dir, 'summary_volume_hotplug_results.json'), 'w') as f:
+        json.dump(summary, f, indent=4)
+    with open(os.path.join(out_dir, 'summary_volume_hotplug_results.csv'), 'w', newline='') as f:
+        writer = csv.DictWriter(f, fieldnames=stat_columns('metric', 'avg', 'max', 'min', 'count'))
+        writer.writeheader()
+        writer.writerows(metrics)
+    logger.info(f"Results saved under: {out_dir}")
+
+
+def plan_run(namespaces: List[str], args, logger):
+    """Print the DataVolumes main() would create, attach and detach (virtbench --dry-run)."""
+    plan = DryRunPlan('volume-hotplug', logger)
+    logger.info("[DRY RUN] Volume names get a random suffix, so a real run uses different names")
+    for namespace in namespaces:
+        suffix = uuid.uuid4().hex[:6]
+        volumes = [f"hp-{suffix}-{i}" for i in range(1, args.volumes_per_vm + 1)]
+        target = f"vm/{namespace}/{args.vm_name}"
+        for name in volumes:
+            plan.apply(blank_datavolume_manifest(name, namespace, args.volume_size, args.storage_class),
+                       namespace, verb='apply')
+        for name in volumes:
+            plan.action('addvolume', target, f"{name}{' --persist' if args.persist else ''}")
+        if not args.skip_detach:
+            for name in volumes:
+                plan.action('removevolume', target, name)
+            if not args.keep_volumes:
+                for name in volumes:
+                    plan.action('delete', f"dv/{namespace}/{name}")
+    plan.report()
+
+
+def main():
+    args = parse_args()
+    set_run_workload('volume-hotplug')
+    args.storage_driver = resolve_storage_driver(args.storage_driver, args.storage_class)
+    dry_run = is_dry_run()
+    if dry_run:
+        # A dry run has no results to save
+        args.save_results = False
+
+    out_dir = None
+    if args.save_results:
+        out_dir = build_results_dir(args)
+        os.makedirs(out_dir, exist_ok=True)
+        if not args.log_file:
+            args.log_file = os.path.join(out_dir, 'volume-hotplug.log')
+
+    logger = setup_logging(args.log_file, args.log_level)
+    watch_run('volume-hotplug', logger)
+    watch_events(args.namespace_prefix, logger)
+    sample_nodes(logger)
+    timing.set_precision(args.precision)
+
+    logger.info("=" * 80)
+    logger.info("KubeVirt Volume Hotplug Benchmark")
+    logger.info("=" * 80)
+    logger.info(f"Namespaces: {args.namespace_prefix}-{args.start} to {args.namespace_prefix}-{args.end}")
+    logger.info(f"VM name: {args.vm_name}")
+    logger.info(f"Volumes per VM: {args.volumes_per_vm} x {args.volume_size} ({args.storage_class})")
+    logger.info(f"Concurrency: {args.concurrency}")
+    logger.info(f"In-guest check: {'disabled' if args.skip_guest_check else 'enabled'}")
+    logger.info("=" * 80)
+
+    executor = None
+    # ensure_helper_pod creates the SSH pod when it is missing
+    if not args.skip_guest_check and not dry_run:
+        ready, _ = ensure_helper_pod(args.ssh_pod, args.ssh_pod_ns, logger=logger)
+        if not ready:
+            logger.error("SSH pod unavailable; re-run with --skip-guest-check to measure attach/detach only")
+            sys.exit(1)
+        executor = GuestExecutor(args.vm_user, args.vm_password, ssh_pod=args.ssh_pod,
+                                 ssh_pod_ns=args.ssh_pod_ns, max_sessions=args.concurrency,
+                                 logger=logger)
+
+    namespaces = [f"{args.namespace_prefix}-{i}" for i in range(args.start, args.end + 1)]
+    if dry_run:
+        plan_run(namespaces, args, logger)
+        sys.exit(0)
+
+    clock_skew = timing.estimate_clock_skew(logger=logger) if args.save_results else None
+    if args.save_results:
+        cluster_inventory(logger)
+    test_start = timing.now()
+
+    results: List[Dict] = []
+    try:
+        outcomes = run_parallel(hotplug_vm, namespaces, concurrency=args.concurrency,
+                                qps=args.qps, burst=args.burst, args=(args, executor, logger),
+                                logger=logger, description="volume hotplug")
+        for ns, vm_results, error in outcomes:
+            if error is None:
+                results.extend(vm_results)
+    finally:
+        if executor:
+            executor.close()
+
+    total_time = (timing.now() - test_start).total_seconds()
+    if not results:
+        logger.error("No volumes were processed")
+        sys.exit(1)
+
+    metrics = print_summary(results, total_time, logger)
+    if args.save_results:
+        save_hotplug_results(out_dir, args, results, metrics, total_time,
+                             timing.timing_metadata(test_start, clock_skew=clock_skew), logger)
+
+    failed = sum(1 for r in results if not r['success'])
+    run_metrics = {'volumes': len(results), 'failed': failed}
+    for m in ('attach_sec', 'detach_sec'):
+        run_metrics.update(percentile_metrics(m, [r[m] for r in results]))
+    notify_run('volume-hotplug', run_metrics, phase_status(failed, len(results)), out_dir, logger)
+
+    sys.exit(0 if all(r['success'] for r in results) else 2)
+
+
+if __name__ == '__main__':
+    main()
@@ -0,0 +1,506 @@
+#!/usr/bin/env python3
+"""
+KubeVirt Volume Resize Benchmark
+
+Expands the PVCs behind running VMs in configurable increments and measures,
+per PVC and increment:
+
+  - expansion time:   PVC patch until the PVC reports the new capacity
+                      (controller + node expansion by the CSI driver)
+  - guest disk time:  PVC patch until the guest sees the larger block device
+  - filesystem grow:  time to grow the partition and filesystem mounted from
+                      that device (growpart + xfs_growfs/resize2fs)
+
+Results are reported overall and per storage class. PVCs of one VM are
+resized one at a time so the grown guest device can be identified; VMs are
+processed concurrently. The VMs must already be running.
+
+Usage:
+    python3 measure-volume-resize.py --start 1 --end 20 --vm-name rhel-9-vm \\
+        --increment-size 1Gi --increments 3 --save-results
+
+Author: KubeVirt Benchmark Suite Contributors
+License: Apache 2.0
+"""
+
+import argparse
+import csv
+import json
+import os
+import re
+import sys
+import time
+from datetime import datetime
+from typing import Dict, List, Optional
+
+# Add parent directory to path for imports
+sys.path.insert(0, os.path.abspath(os.path.join(os.path.dirname(__file__), '..')))
+
+from utils import timing
+from utils.concurrency import run_parallel, DEFAULT_QPS, DEFAULT_BURST
+from utils.common import (
+    setup_logging, get_vm_status, get_vm_volume_names, get_pvc_storage_class,
+    get_pvc_size, expand_pvc, parse_size_to_gi, round_duration,
+    run_metadata,
+)
+from utils.custommetrics import collect_custom_metrics
+from utils.events import collect_run_events, watch_events
+from utils.inventory import cluster_inventory, resolve_storage_driver
+from utils.guestexec import GuestExecutor, ensure_helper_pod
+from utils.dryrun import DryRunPlan, is_dry_run
+from utils.notify import notify_run, watch_run, phase_status, percentile_metrics
+from utils.stats import log_outliers, metric_outliers, metric_stats, stat_columns
+from utils.storageprovider import collect_storage_backend
+from utils.utilization import collect_node_samples, sample_nodes
+
+# Default configuration
+DEFAULT_VM_NAME = 'rhel-9-vm'
+DEFAULT_NAMESPACE_PREFIX = 'kubevirt-perf-test'
+DEFAULT_INCREMENT_SIZE = '1Gi'
+DEFAULT_INCREMENTS = 1
+DEFAULT_CONCURRENCY = 10
+DEFAULT_POLL_INTERVAL = 1
+DEFAULT_RESIZE_TIMEOUT = 600
+DEFAULT_GUEST_TIMEOUT = 300
+DEFAULT_VM_USER = 'cloud-user'
+DEFAULT_VM_PASSWORD = 'changeme'
+DEFAULT_SSH_POD = 'ssh-test-pod'
+DEFAULT_SSH_POD_NS = 'default'
+GIB = 1024 ** 3
+# A guest device counts as grown once it gained this share of the increment;
+# filesystem-mode PVCs keep some capacity back for the disk image overhead
+GROWTH_THRESHOLD = 0.9
+GROWABLE_FILESYSTEMS = ('xfs', 'ext4', 'ext3')
+
+
+def parse_args():
+    parser = argparse.ArgumentParser(
+        description='Measure PVC expansion and in-guest grow times on running KubeVirt VMs',
+        formatter_class=argparse.RawDescriptionHelpFormatter,
+    )
+    parser.add_argument('--start', type=int, default=1, help='Starting namespace index (default: 1)')
+    parser.add_argument('--end', type=int, default=10, help='Ending namespace index (default: 10)')
+    parser.add_argument('--namespace-prefix', default=DEFAULT_NAMESPACE_PREFIX,
+                        help=f'Namespace prefix (default: {DEFAULT_NAMESPACE_PREFIX})')
+    parser.add_argument('--vm-name', default=DEFAULT_VM_NAME,
+                        help=f'Name of the running VM in each namespace (default: {DEFAULT_VM_NAME})')
+    parser.add_argument('--volume-filter', default=None,
+                        help='Only resize PVCs whose name contains this string (default: all VM volumes)')
+    parser.add_argument('--increment-size', default=DEFAULT_INCREMENT_SIZE,
+                        help=f'Size added per increment, in Gi (default: {DEFAULT_INCREMENT_SIZE})')
+    parser.add_argument('--increments', type=int, default=DEFAULT_INCREMENTS,
+                        help=f'Number of increments per PVC (default: {DEFAULT_INCREMENTS})')
+    parser.add_argument('--concurrency', '-c', type=int, default=DEFAULT_CONCURRENCY,
+                        help=f'VMs processed concurrently (default: {DEFAULT_CONCURRENCY})')
+    parser.add_argument('--qps', type=float, default=DEFAULT_QPS,
+                        help='Max VMs started per second (default: 0 = unlimited)')
+    parser.add_argument('--burst', type=int, default=DEFAULT_BURST,
+                        help=f'Max VMs started back-to-back when --qps is set (default: {DEFAULT_BURST})')
+    parser.add_argument('--poll-interval', type=int, default=DEFAULT_POLL_INTERVAL,
+                        help=f'Seconds between status checks (default: {DEFAULT_POLL_INTERVAL})')
+    parser.add_argument('--resize-timeout', type=int, default=DEFAULT_RESIZE_TIMEOUT,
+                        help=f'Timeout for PVC expansion in seconds (default: {DEFAULT_RESIZE_TIMEOUT})')
+    parser.add_argument('--guest-timeout', type=int, default=DEFAULT_GUEST_TIMEOUT,
+                        help=f'Timeout for the guest to see the new size (default: {DEFAULT_GUEST_TIMEOUT})')
+    parser.add_argument('--skip-guest-check', action='store_true',
+                        help='Measure PVC expansion only (no SSH)')
+    parser.add_argument('--skip-fs-grow', action='store_true',
+                        help='Do not grow partitions/filesystems in the guest')
+    parser.add_argument('--vm-user', default=DEFAULT_VM_USER,
+                        help=f'Guest SSH user (default: {DEFAULT_VM_USER})')
+    parser.add_argument('--vm-password', default=DEFAULT_VM_PASSWORD,
+                        help=f'Guest SSH password (default: {DEFAULT_VM_PASSWORD})')
+    parser.add_argument('--ssh-pod', default=DEFAULT_SSH_POD,
+                        help=f'SSH helper pod (default: {DEFAULT_SSH_POD})')
+    parser.add_argument('--ssh-pod-ns', default=DEFAULT_SSH_POD_NS,
+                        help=f'SSH helper pod namespace (default: {DEFAULT_SSH_POD_NS})')
+    parser.add_argument('--precision', type=int, default=timing.DEFAULT_PRECISION,
+                        help=f'Decimal places for durations in saved results (default: {timing.DEFAULT_PRECISION})')
+    parser.add_argument('--save-results', action='store_true', help='Save results as JSON and CSV')
+    parser.add_argument('--results-folder', default='results',
+                        help='Base directory for results (default: results)')
+    parser.add_argument('--storage-driver', default=None,
+                        help='Storage driver label to include in the results path, or auto to detect it')
+    parser.add_argument('--log-file', default=None, help='Log file path')
+    parser.add_argument('--log-level', default='INFO',
+                        choices=['DEBUG', 'INFO', 'WARNING', 'ERROR'], help='Logging level (default: INFO)')
+    return parser.parse_args()
+
+
+def build_results_dir(args, timestamp: Optional[str] = None) -> str:
+    """Build the canonical volume-resize results directory."""
+    timestamp = timestamp or datetime.now().strftime("%Y%m%d-%H%M%S")
+    suffix = f"{args.namespace_prefix}_{args.start}-{args.end}"
+    if args.storage_driver:
+        return os.path.join(args.results_folder, args.storage_driver, 'volume-resize', f"{timestamp}_{suffix}")
+    return os.path.join(args.results_folder, 'volume-resize', f"{timestamp}_{suffix}")
+
+
+def get_guest_disk_sizes(executor: GuestExecutor, vm_name: str, namespace: str) -> Optional[Dict[str, int]]:
+    """Return {device name: size in bytes} of the guest's disks, or None if unreachable."""
+    rc, out, _ = executor.run_on_vmi(vm_name, namespace, 'lsblk -b -d -n -o NAME,SIZE', timeout=30)
+    if rc != 0:
+        return None
+    sizes = {}
+    for line in out.splitlines():
+        parts = line.split()
+        if len(parts) == 2 and parts[1].isdigit():
+            sizes[parts[0]] = int(parts[1])
+    return sizes
+
+
+def wait_for_guest_growth(executor: GuestExecutor, vm_name: str, namespace: str,
+                          before: Dict[str, int], increment_bytes: int,
+                          timeout: int, poll_interval: int) -> Optional[str]:
+    """Wait until one guest disk grew by the increment. Returns its device name."""
+    deadline = time.monotonic() + timeout
+    while time.monotonic() < deadline:
+        sizes = get_guest_disk_sizes(executor, vm_name, namespace)
+        if sizes:
+            grown = {dev: size - before[dev] for dev, size in sizes.items() if dev in before}
+            if grown:
+                device, growth = max(grown.items(), key=lambda item: item[1])
+                if growth >= increment_bytes * GROWTH_THRESHOLD:
+                    return device
+        time.sleep(poll_interval)
+    return None
+
+
+def grow_filesystem(executor: GuestExecutor, vm_name: str, namespace: str,
+                    device: str, logger) -> Dict:
+    """
+    Grow the partition and filesystem mounted from a guest device.
+
+    Returns:
+        Dict with filesystem (type, or None if nothing is mounted from the
+        device), seconds and error
+    """
+    result = {'filesystem': None, 'seconds': None, 'error': None}
+    rc, out, _ = executor.run_on_vmi(
+        vm_name, namespace, f'lsblk -ln -o NAME,TYPE,FSTYPE,MOUNTPOINT /dev/{device}', timeout=30
+    )
+    if rc != 0:
+        result['error'] = 'could not list guest partitions'
+        return result
+
+    target = None
+    for line in out.splitlines():
+        parts = line.split()
+        if len(parts) == 4 and parts[2] in GROWABLE_FILESYSTEMS:
+            target = parts
+            break
+    if target is None:
+        return result
+
+    name, kind, fstype, mountpoint = target
+    result['filesystem'] = fstype
+    steps = []
+    if kind == 'part':
+        match = re.search(r'(\d+)$', name)
+        if match:
+            # growpart exits 1 when there is nothing to grow (NOCHANGE)
+            steps.append(f"sudo -n growpart /dev/{device} {match.group(1)} || [ $? -eq 1 ]")
+    if fstype == 'xfs':
+        steps.append(f"sudo -n xfs_growfs {mountpoint} >/dev/null")
+    else:
+        steps.append(f"sudo -n resize2fs /dev/{name} >/dev/null 2>&1")
+
+    started = time.monotonic()
+    rc, _, err = executor.run_on_vmi(vm_name, namespace, ' && '.join(steps), timeout=120)
+    if rc != 0:
+        result['error'] = f"filesystem grow failed: {(err or '').strip()}"
+        if logger:
+            logger.warning(f"[{namespace}] Growing {fstype} on /dev/{name} failed: {(err or '').strip()}")
+        return result
+    result['seconds'] = time.monotonic() - started
+    return result
+
+
+def resize_vm(namespace: str, args, executor: Optional[GuestExecutor], logger) -> List[Dict]:
+    """
+    Expand every selected PVC of one VM --increments times.
+
+    Returns:
+        One result dict per PVC and increment
+    """
+    vm_name = args.vm_name
+    if get_vm_status(vm_name, namespace, logger) != 'Running':
+        logger.error(f"[{namespace}] VM {vm_name} is not running, skipping")
+        return [{'namespace': namespace, 'pvc': None, 'storage_class': None, 'step': None,
+                 'old_size': None, 'new_size': None, 'expansion_sec': None, 'guest_disk_sec': None,
+                 'fs_grow_sec': None, 'guest_device': None, 'filesystem': None,
+                 'success': False, 'error': 'VM not running'}]
+
+    pvcs = [p for p in get_vm_volume_names(vm_name, namespace, logger)
+            if not args.volume_filter or args.volume_filter in p]
+    if not pvcs:
+        logger.warning(f"[{namespace}] No PVCs selected for {vm_name}")
+        return []
+
+    increment_bytes = parse_size_to_gi(args.increment_size) * GIB
+    results = []
+    for pvc in pvcs:
+        storage_class = get_pvc_storage_class(pvc, namespace, logger)
+        for step in range(1, args.increments + 1):
+            row = {
+                'namespace': namespace, 'pvc': pvc, 'storage_class': storage_class, 'step': step,
+                'old_size': get_pvc_size(pvc, namespace, logger), 'new_size': None,
+                'expansion_sec': None, 'guest_disk_sec': None, 'fs_grow_sec': None,
+                'guest_device': None, 'filesystem': None, 'success': False, 'error': None,
+            }
+            results.append(row)
+
+            before = get_guest_disk_sizes(executor, vm_name, namespace) if executor else None
+            if executor and before is None:
+                row['error'] = 'guest unreachable'
+                break
+
+            row['new_size'], row['expansion_sec'], row['error'] = expand_pvc(
+                pvc, namespace, args.increment_size, timeout=args.resize_timeout,
+                poll_interval=args.poll_interval, logger=logger
+            )
+            if row['error']:
+                break
+
+            if executor:
+                # Guest time is measured from the PVC patch, like the expansion time
+                waited = time.monotonic()
+                device = wait_for_guest_growth(executor, vm_name, namespace, before, increment_bytes,
+                                               args.guest_timeout, args.poll_interval)
+                if device is None:
+                    row['error'] = 'guest disk did not grow'
+                    break
+                row['guest_device'] = device
+                row['guest_disk_sec'] = row['expansion_sec'] + (time.monotonic() - waited)
+
+                if not args.skip_fs_grow:
+                    grown = grow_filesystem(executor, vm_name, namespace, device, logger)
+                    row['filesystem'] = grown['filesystem']
+                    row['fs_grow_sec'] = grown['seconds']
+                    row['error'] = grown['error']
+
+            row['success'] = row['error'] is None
+            logger.info(f"[{namespace}] {pvc} step {step}: {row['old_size']} -> {row['new_size']} "
+                        f"in {row['expansion_sec']:.2f}s")
+    return results
+
+
+METRICS = ['expansion_sec', 'guest_disk_sec', 'fs_grow_sec']
+
+
+def summarize(results: List[Dict]) -> Dict:
+    """Per-metric statistics overall and per storage class, and the outlier resizes."""
+    by_class = {}
+    for r in results:
+        if r['pvc']:
+            by_class.setdefault(r['storage_class'] or 'unknown', []).append(r)
+    return {
+        'metrics': [metric_stats(m, [r[m] for r in results if r[m] is not None]) for m in METRICS],
+        'per_storage_class': {
+            sc: [metric_stats(m, [r[m] for r in rows if r[m] is not None]) for m in METRICS]
+            for sc, rows in sorted(by_class.items())
+        },
+        'outliers': metric_outliers([r for r in results if r['success']], METRICS,
+                                    lambda r: f"{r['namespace']}/{r['pvc']} step {r['step']}"),
+    }
+
+
+def print_summary(results: List[Dict], stats: Dict, total_time: float, logger) -> None:
+    def fmt(value):
+        return f"{value:.2f}" if value is not None else '-'
+
+    logger.info("")
+    logger.info("=" * 110)
+    logger.info(f"{'Namespace':<26}{'PVC':<28}{'Step':<6}{'New size':<10}{'Expand(s)':<11}"
+                f"{'Guest(s)':<10}{'FS grow(s)':<12}{'Status':<16}")
+    logger.info("-" * 110)
+    for r in sorted(results, key=lambda x: (x['namespace'], x['pvc'] or '', x['step'] or 0)):
+        status = 'Success' if r['success'] else (r['error'] or 'Failed')
+        logger.info(f"{r['namespace']:<26}{(r['pvc'] or '-'):<28}{str(r['step'] or '-'):<6}"
+                    f"{(r['new_size'] or '-'):<10}{fmt(r['expansion_sec']):<11}"
+                    f"{fmt(r['guest_disk_sec']):<10}{fmt(r['fs_grow_sec']):<12}{status:<16}")
+    logger.info("=" * 110)
+
+    successful = sum(1 for r in results if r['success'])
+    logger.info(f"  Resizes:                {len(results)}")
+    logger.info(f"  Successful:             {successful}")
+    logger.info(f"  Failed:                 {len(results) - successful}")
+    for sc, metrics in stats['per_storage_class'].items():
+        logger.info(f"  Storage class {sc}:")
+        for m in metrics:
+            if m['count']:
+                logger.info(f"    {m['metric'] + ':':<22}avg {m['avg']}s, min {m['min']}s, "
+                            f"max {m['max']}s, p95 {m['p95']}s ({m['count']})")
+    logger.info(f"  Total test duration:    {total_time:.2f}s")
+    logger.info("=" * 110)
+    log_outliers(stats['outliers'], logger)
+
+
+def save_resize_results(out_dir: str, args, results: List[Dict], stats: Dict,
+                        total_time: float, timing_block: Dict, logger) -> None:
+    """Write per-resize results and the summary as JSON and CSV."""
+    os.makedirs(out_dir, exist_ok=True)
+    rows = []
+    for r in sorted(results, key=lambda x: (x['namespace'], x['pvc'] or '', x['step'] or 0)):
+        row = dict(r)
+        for m in METRICS:
+            row[m] = round_duration(row[m])
+        rows.append(row)
+
+    with open(os.path.join(out_dir, 'volume_resize_results.json'), 'w') as f:
+        json.dump(rows, f, indent=4)
+    with open(os.path.join(out_dir, 'volume_resize_results.csv'), 'w', newline='') as f:
+        writer = csv.DictWriter(f, fieldnames=rows[0].keys())
+        writer.writeheader()
+        writer.writerows(rows)
+
+    summary = {
+        'total_resizes': len(results),
+        'successful': sum(1 for r in results if r['success']),
+        'failed': sum(1 for r in results if not r['success']),
+        'vms': len({r['namespace'] for r in results}),
+        'increment_size': args.increment_size,
+        'increments': args.increments,
+        'concurrency': args.concurrency,
+        'total_test_duration_sec': round_duration(total_time),
+        'metrics': stats['metrics'],
+        'per_storage_class': stats['per_storage_class'],
+        'outliers': stats['outliers'],
+        'timing': timing_block,
+    }
+    custom_metrics = collect_custom_metrics(timing_block, logger)
+    if custom_metrics is not None:
+        summary['custom_metrics'] = custom_metrics
+    run_events = collect_run_events(out_dir, logger, summary.get('outliers'))
+    if run_events is not None:
+        summary['events'] = run_events
+    node_utilization = collect_node_samples(out_dir, logger)
+    if node_utilization is not None:
+        summary['node_utilization'] = node_utilization
+    storage_backend = collect_storage_backend(out_dir, args.namespace_prefix, logger)
+    if storage_backend is not None:
+        summary['storage_backend'] = storage_backend
+    summary['cluster'] = cluster_inventory(logger)
+    summary['run'] = run_metadata()
+    with open(os.path.join(out_dir, 'summary_volume_resize_results.json'), 'w') as f:
+        json.dump(summary, f, indent=4)
+    with open(os.path.join(out_dir, 'summary_volume_resize_results.csv'), 'w', newline='') as f:
+        writer = csv.DictWriter(f, fieldnames=stat_columns('storage_class', 'metric', 'avg', 'max', 'min', 'count'))
+        writer.writeheader()
+        for m in stats['metrics']:
+            writer.writerow({'storage_class': 'all', **m})
+        for sc, metrics in stats['per_storage_class'].items():
+            for m in metrics:
+                writer.writerow({'storage_class': sc, **m})
+    logger.info(f"Results saved under: {out_dir}")
+
+
+def plan_run(namespaces: List[str], args, logger):
+    """Print the PVC expansions main() would make (virtbench --dry-run)."""
+    plan = DryRunPlan('volume-resize', logger)
+    for namespace in namespaces:
+        pvcs = [p for p in get_vm_volume_names(args.vm_name, namespace, logger)
+                if not args.volume_filter or args.volume_filter in p]
+        if not pvcs:
+            logger.warning(f"[{namespace}] No PVCs selected for {args.vm_name}")
+        for pvc in pvcs:
+            for step in range(1, args.increments + 1):
+                plan.action('expand', f"pvc/{namespace}/{pvc}", f"+{args.increment_size} (step {step})")
+                if not args.skip_guest_check and not args.skip_fs_grow:
+                    plan.action('growfs', f"vm/{namespace}/{args.vm_name}", f"filesystem on {pvc}")
+    plan.report()
+
+
+def main():
+    args = parse_args()
+    args.storage_driver = resolve_storage_driver(args.storage_driver)
+    dry_run = is_dry_run()
+    if dry_run:
+        # A dry run has no results to save
+        args.save_results = False
+
+    out_dir = None
+    if args.save_results:
+        out_dir = build_results_dir(args)
+        os.makedirs(out_dir, exist_ok=True)
+        if not args.log_file:
+            args.log_file = os.path.join(out_dir, 'volume-resize.log')
+
+    logger = setup_logging(args.log_file, args.log_level)
+    watch_run('volume-resize', logger)
+    watch_events(args.namespace_prefix, logger)
+    sample_nodes(logger)
+    timing.set_precision(args.precision)
+
+    try:
+        parse_size_to_gi(args.increment_size)
+    except ValueError as e:
+        logger.error(f"Invalid --increment-size: {e}")
+        sys.exit(1)
+
+    logger.info("=" * 80)
+    logger.info("KubeVirt Volume Resize Benchmark")
+    logger.info("=" * 80)
+    logger.info(f"Namespaces: {args.namespace_prefix}-{args.start} to {args.namespace_prefix}-{args.end}")
+    logger.info(f"VM name: {args.vm_name}")
+    logger.info(f"Increments: {args.increments} x {args.increment_size}"
+                f"{f' (PVCs matching {args.volume_filter})' if args.volume_filter else ''}")
+    logger.info(f"Concurrency: {args.concurrency}")
+    logger.info(f"In-guest check: {'disabled' if args.skip_guest_check else 'enabled'}")
+    logger.info("=" * 80)
+
+    executor = None
+    # ensure_helper_pod creates the SSH pod when it is missing
+    if not args.skip_guest_check and not dry_run:
+        ready, _ = ensure_helper_pod(args.ssh_pod, args.ssh_pod_ns, logger=logger)
+        if not ready:
+            logger.error("SSH pod unavailable; re-run with --skip-guest-check to measure expansion only")
+            sys.exit(1)
+        executor = GuestExecutor(args.vm_user, args.vm_password, ssh_pod=args.ssh_pod,
+                                 ssh_pod_ns=args.ssh_pod_ns, max_sessions=args.concurrency,
+                                 logger=logger)
+
+    namespaces = [f"{args.namespace_prefix}-{i}" for i in range(args.start, args.end + 1)]
+    if dry_run:
+        plan_run(namespaces, args, logger)
+        sys.exit(0)
+
+    clock_skew = timing.estimate_clock_skew(logger=logger) if args.save_results else None
+    if args.save_results:
+        cluster_inventory(logger)
+    test_start = timing.now()
+
+    results: List[Dict] = []
+    try:
+        outcomes = run_parallel(resize_vm, namespaces, concurrency=args.concurrency,
+                                qps=args.qps, burst=args.burst, args=(args, executor, logger),
+                                logger=logger, description="volume resize")
+        for ns, vm_results, error in outcomes:
+            if error is None:
+                results.extend(vm_results)
+    finally:
+        if executor:
+            executor.close()
+
+    total_time = (timing.now() - test_start).total_seconds()
+    if not results:
+        logger.error("No volumes were resized")
+        sys.exit(1)
+
+    stats = summarize(results)
+    print_summary(results, stats, total_time, logger)
+    if args.save_results:
+        save_resize_results(out_dir, args, results, stats, total_time,
+                            timing.timing_metadata(test_start, clock_skew=clock_skew), logger)
+
+    failed = sum(1 for r in results if not r['success'])
+    run_metrics = {'volumes': len(results), 'failed': failed}
+    for m in ('expansion_sec', 'fs_grow_sec'):
+        run_metrics.update(percentile_metrics(m, [r[m] for r in results]))
+    notify_run('volume-resize', run_metrics, phase_status(failed, len(results)), out_dir, logger)
+
+    sys.exit(0 if all(r['success'] for r in results) else 2)
+
+
+if __name__ == '__main__':
+    main()